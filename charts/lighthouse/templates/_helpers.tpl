@@ -26,7 +26,121 @@ We truncate at 63 chars because some Kubernetes name fields are limited to this
 {{- printf "%s-%s" .Chart.Name $name | trunc 63 | trimSuffix "-" -}}
 {{- end -}}
 
+{{- define "scmProxy.name" -}}
+{{- $name := default "scm-proxy" .Values.scmProxy.nameOverride -}}
+{{- printf "%s-%s" .Chart.Name $name | trunc 63 | trimSuffix "-" -}}
+{{- end -}}
+
 {{- define "gcJobs.name" -}}
 {{- $name := default "gc-jobs" .Values.gcJobs.nameOverride -}}
 {{- printf "%s-%s" .Chart.Name $name | trunc 63 | trimSuffix "-" -}}
 {{- end -}}
+
+{{/*
+Environment variables loading the HMAC and git tokens from Vault, which are refreshed without restarting.
+*/}}
+{{- define "lighthouse.vaultEnv" -}}
+{{- if .Values.vault.addr }}
+- name: "VAULT_ADDR"
+  value: "{{ .Values.vault.addr }}"
+{{- if .Values.vault.role }}
+- name: "VAULT_ROLE"
+  value: "{{ .Values.vault.role }}"
+{{- end }}
+{{- if .Values.vault.authPath }}
+- name: "VAULT_AUTH_PATH"
+  value: "{{ .Values.vault.authPath }}"
+{{- end }}
+{{- if .Values.vault.hmacTokenPath }}
+- name: "HMAC_TOKEN_VAULT_PATH"
+  value: "{{ .Values.vault.hmacTokenPath }}"
+{{- end }}
+{{- if .Values.vault.gitTokenPath }}
+- name: "GIT_TOKEN_VAULT_PATH"
+  value: "{{ .Values.vault.gitTokenPath }}"
+{{- end }}
+- name: "LIGHTHOUSE_SECRETS_REFRESH_INTERVAL"
+  value: "{{ .Values.vault.refreshInterval }}"
+{{- end }}
+{{- end -}}
+
+{{/*
+Environment variables enabling the audit stream of chat commands and bot actions
+*/}}
+{{- define "lighthouse.auditEnv" -}}
+{{- if .Values.audit.log }}
+- name: "LIGHTHOUSE_AUDIT_LOG"
+  value: "stdout"
+{{- end }}
+{{- if .Values.audit.webhookURL }}
+- name: "LIGHTHOUSE_AUDIT_WEBHOOK_URL"
+  value: "{{ .Values.audit.webhookURL }}"
+{{- end }}
+{{- end -}}
+
+{{/*
+Environment variable sending the API requests of the default SCM provider through the caching SCM proxy
+*/}}
+{{- define "lighthouse.scmProxyEnv" -}}
+{{- if .Values.scmProxy.enabled }}
+- name: "GIT_PROXY_URL"
+  value: "http://{{ template "scmProxy.name" . }}"
+{{- end }}
+{{- end -}}
+
+{{/*
+Environment variables configuring the command prefix and the display name of the bot
+*/}}
+{{- define "lighthouse.botEnv" -}}
+{{- if .Values.bot.commandPrefix }}
+- name: "LIGHTHOUSE_COMMAND_PREFIX"
+  value: {{ .Values.bot.commandPrefix | quote }}
+{{- end }}
+{{- if .Values.bot.displayName }}
+- name: "LIGHTHOUSE_BOT_DISPLAY_NAME"
+  value: {{ .Values.bot.displayName | quote }}
+{{- end }}
+{{- end -}}
+
+{{/*
+Environment variable configuring the most changed files fetched for a pull request
+*/}}
+{{- define "lighthouse.changesEnv" -}}
+{{- if .Values.maxChangedFiles }}
+- name: "LIGHTHOUSE_MAX_CHANGED_FILES"
+  value: {{ .Values.maxChangedFiles | quote }}
+{{- end }}
+{{- end -}}
+
+{{/*
+Environment variables configuring the SCM providers served in addition to the default one
+*/}}
+{{- define "lighthouse.providersEnv" -}}
+{{- if .Values.providers }}
+- name: "LIGHTHOUSE_PROVIDERS"
+  value: "{{ range $i, $p := .Values.providers }}{{ if $i }},{{ end }}{{ $p.name }}{{ end }}"
+{{- range .Values.providers }}
+{{- $prefix := upper (replace "-" "_" .name) }}
+- name: "{{ $prefix }}_GIT_KIND"
+  value: "{{ .kind }}"
+- name: "{{ $prefix }}_GIT_SERVER"
+  value: "{{ .server }}"
+- name: "{{ $prefix }}_GIT_USER"
+  value: "{{ .user }}"
+{{- if .orgs }}
+- name: "{{ $prefix }}_GIT_ORGS"
+  value: "{{ join "," .orgs }}"
+{{- end }}
+- name: "{{ $prefix }}_GIT_TOKEN"
+  valueFrom:
+    secretKeyRef:
+      name: "{{ .tokenSecret }}"
+      key: oauth
+- name: "{{ $prefix }}_HMAC_TOKEN"
+  valueFrom:
+    secretKeyRef:
+      name: "{{ .hmacSecret }}"
+      key: hmac
+{{- end }}
+{{- end }}
+{{- end -}}
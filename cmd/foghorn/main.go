@@ -2,42 +2,143 @@ package main
 
 import (
 	"flag"
+	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	jxclient "github.com/jenkins-x/jx/v2/pkg/client/clientset/versioned"
 	jxinformers "github.com/jenkins-x/jx/v2/pkg/client/informers/externalversions"
 	"github.com/jenkins-x/jx/v2/pkg/jxfactory"
+	"github.com/jenkins-x/lighthouse/pkg/admin"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
 	clientset "github.com/jenkins-x/lighthouse/pkg/client/clientset/versioned"
 	lhinformers "github.com/jenkins-x/lighthouse/pkg/client/informers/externalversions"
 	"github.com/jenkins-x/lighthouse/pkg/foghorn"
+	"github.com/jenkins-x/lighthouse/pkg/gitprovider"
+	"github.com/jenkins-x/lighthouse/pkg/health"
+	"github.com/jenkins-x/lighthouse/pkg/hooks"
 	"github.com/jenkins-x/lighthouse/pkg/interrupts"
+	"github.com/jenkins-x/lighthouse/pkg/jenkins"
 	"github.com/jenkins-x/lighthouse/pkg/logrusutil"
+	"github.com/jenkins-x/lighthouse/pkg/notifier"
+	"github.com/jenkins-x/lighthouse/pkg/podagent"
+	"github.com/jenkins-x/lighthouse/pkg/provenance"
+	"github.com/jenkins-x/lighthouse/pkg/settings"
+	"github.com/jenkins-x/lighthouse/pkg/store"
+	"github.com/jenkins-x/lighthouse/pkg/usage"
+	"github.com/jenkins-x/lighthouse/pkg/watchdog"
 	"github.com/sirupsen/logrus"
+	tektonclient "github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
 
 type options struct {
-	namespace string
+	settingsFile string
+	settings     *settings.Settings
+
+	namespace     string
+	allNamespaces bool
+	port          int
+	adminPort     int
 
 	dryRun bool
+
+	watchdogInterval   time.Duration
+	pendingTimeout     time.Duration
+	unscheduledTimeout time.Duration
+	missingRunTimeout  time.Duration
+
+	jenkinsSyncInterval time.Duration
+
+	podSyncInterval      time.Duration
+	cloneImage           string
+	logsImage            string
+	logArchiveClaim      string
+	gitCredentialsSecret string
+	logsURL              string
+	podMaxRetries        int
+
+	emailDigestHour int
+
+	usageStore store.Options
+
+	provenanceDir       string
+	provenanceKey       string
+	provenanceFulcioURL string
+	provenanceTokenFile string
+	provenanceBuilderID string
+
+	hookURL          string
+	hookSyncInterval time.Duration
+	hookPrune        bool
+	hookDryRun       bool
 }
 
 func (o *options) Validate() error {
+	if o.hookSyncInterval > 0 && o.hookURL == "" {
+		return fmt.Errorf("--hook-url is required to reconcile webhooks")
+	}
+	if o.usageStore.Kind == store.Memory {
+		return fmt.Errorf("--usage-store must be configmap or redis so that the usage reports survive restarts")
+	}
+	if o.provenanceDir != "" && (o.provenanceKey == "") == (o.provenanceFulcioURL == "") {
+		return fmt.Errorf("exactly one of --provenance-key or --provenance-fulcio-url is required to sign the provenance")
+	}
+	if o.emailDigestHour > 23 {
+		return fmt.Errorf("--email-digest-hour must be an hour between 0 and 23, or -1")
+	}
 	return nil
 }
 
 func gatherOptions(fs *flag.FlagSet, args ...string) options {
 	var o options
-	fs.BoolVar(&o.dryRun, "dry-run", true, "Whether to mutate any real-world state.")
+	fs.BoolVar(&o.dryRun, "dry-run", false, "Only log the jobs the watchdog would abort or error, without aborting them, updating their status or reporting them.")
 	fs.StringVar(&o.namespace, "namespace", "", "The namespace to listen in")
+	fs.BoolVar(&o.allNamespaces, "all-namespaces", false, "Handle the LighthouseJobs of every namespace, such as the namespaces of the tenants, while still reading the configuration from --namespace.")
+	fs.IntVar(&o.adminPort, "admin-port", 0, "The TCP port serving the admin endpoints: pprof profiles, expvar variables at /debug/vars and "+logrusutil.LevelPath+" to read or PUT the log level. It should not be exposed publicly. Disabled by default.")
+	fs.IntVar(&o.port, "port", 8080, "The TCP port serving the "+health.LivenessPath+" and "+health.ReadinessPath+" endpoints, 0 to disable them.")
+	fs.DurationVar(&o.watchdogInterval, "watchdog-interval", time.Minute, "How often to check for stuck and timed out LighthouseJobs, 0 to disable the check.")
+	fs.DurationVar(&o.pendingTimeout, "pending-timeout", time.Hour, "How long a LighthouseJob may stay triggered or pending before it is errored.")
+	fs.DurationVar(&o.unscheduledTimeout, "unscheduled-timeout", 30*time.Minute, "How long a pipeline pod may stay unscheduled before its LighthouseJob is errored.")
+	fs.DurationVar(&o.jenkinsSyncInterval, "jenkins-sync-interval", 30*time.Second, "How often to poll the Jenkins server in $JENKINS_URL for the status of builds.")
+	fs.DurationVar(&o.podSyncInterval, "pod-sync-interval", 10*time.Second, "How often to create and sync the pods of LighthouseJobs using the kubernetes agent, 0 to disable the agent.")
+	fs.IntVar(&o.podMaxRetries, "pod-max-retries", 3, "How many times the pod of a LighthouseJob using the kubernetes agent is recreated after being evicted or lost with its node, before the job is errored.")
+	fs.StringVar(&o.cloneImage, "clone-image", podagent.DefaultCloneImage, "The image used to clone repositories for jobs using the kubernetes agent.")
+	fs.StringVar(&o.logsImage, "logs-image", podagent.DefaultLogsImage, "The image of the log capture sidecar for jobs using the kubernetes agent.")
+	fs.StringVar(&o.logArchiveClaim, "log-archive-claim", "", "The PersistentVolumeClaim the build logs of jobs using the kubernetes agent are archived to.")
+	fs.StringVar(&o.logsURL, "logs-url", "", "The public URL of the webhooks service, e.g. https://hook.example.com, serving the build logs the commit statuses of jobs using the kubernetes agent link to.")
+	fs.StringVar(&o.gitCredentialsSecret, "git-credentials-secret", "", "The secret holding the .git-credentials used to clone repositories for jobs using the kubernetes agent.")
+	fs.StringVar(&o.hookURL, "hook-url", "", "The public URL of the hook endpoint the webhooks of the configured repositories should point at.")
+	fs.DurationVar(&o.hookSyncInterval, "hook-sync-interval", 0, "How often to register and reconcile the webhooks of the configured repositories, 0 to disable it.")
+	fs.BoolVar(&o.hookPrune, "hook-prune", false, "Remove the webhooks of repositories which are no longer configured in the orgs lighthouse is used in.")
+	fs.BoolVar(&o.hookDryRun, "hook-dry-run", false, "Only report webhook drift without changing any webhook.")
+	fs.IntVar(&o.emailDigestHour, "email-digest-hour", 8, "The UTC hour the daily digests of failed postsubmits and flaky presubmits are emailed to the maintainers configured in the notifications of config.yaml, -1 to disable them.")
+	fs.StringVar(&o.usageStore.Kind, "usage-store", "", "Where the monthly usage reports served at /usage on the admin port are kept: configmap or redis, or none if empty. The usage metrics are exported regardless.")
+	fs.StringVar(&o.usageStore.ConfigMap, "usage-configmap", "lighthouse-usage", "The ConfigMap the usage reports are kept in with --usage-store=configmap.")
+	fs.StringVar(&o.usageStore.RedisAddress, "usage-redis-address", "", "The host:port of the Redis server the usage reports are kept in with --usage-store=redis. Its password is read from $"+store.RedisPasswordEnv+".")
+	fs.StringVar(&o.usageStore.RedisPrefix, "usage-redis-prefix", "lighthouse:", "The prefix of the Redis keys of the usage reports.")
+	fs.StringVar(&o.provenanceDir, "provenance-dir", "", "The directory, usually the mounted log archive, the signed SLSA provenance of the completed jobs is stored to, next to their build logs. Provenance is not generated if empty.")
+	fs.StringVar(&o.provenanceKey, "provenance-key", "", "The file of the PEM encoded ECDSA or ed25519 private key signing the provenance.")
+	fs.StringVar(&o.provenanceFulcioURL, "provenance-fulcio-url", "", "The URL of the Fulcio instance, e.g. "+provenance.DefaultFulcioURL+", certifying the ephemeral keys signing the provenance keylessly, instead of --provenance-key.")
+	fs.StringVar(&o.provenanceTokenFile, "provenance-identity-token", "/var/run/sigstore/cosign/oidc-token", "The file of the OIDC token, e.g. a projected service account token with the sigstore audience, Fulcio certifies the ephemeral keys for.")
+	fs.StringVar(&o.provenanceBuilderID, "provenance-builder-id", "https://github.com/jenkins-x/lighthouse", "The identity of the builder recorded in the provenance.")
+	fs.DurationVar(&o.missingRunTimeout, "missing-pipelinerun-timeout", 5*time.Minute, "How long after starting a LighthouseJob may be without a PipelineRun before it is errored.")
+	fs.StringVar(&o.settingsFile, settings.FileFlag, "", "The LighthouseSettings file, usually mounted from the lighthouse-settings ConfigMap, whose foghorn section overrides the other flags. Its log_level is reloaded when it changes.")
 
 	err := fs.Parse(args)
 	if err != nil {
 		logrus.WithError(err).Fatal("Invalid options")
 	}
+	o.settings, err = settings.Configure(fs, o.settingsFile, "foghorn")
+	if err != nil {
+		logrus.WithError(err).Fatal("Invalid settings")
+	}
 
 	return o
 }
@@ -58,6 +159,73 @@ func stopper() chan struct{} {
 	return stop
 }
 
+// reconcileHooks periodically reconciles the webhooks of the configured repositories hosted by the provider.
+// The webhooks of an additional provider are delivered to its own sub-path of the hook URL.
+func reconcileHooks(controller *foghorn.Controller, provider *gitprovider.Provider, o options) {
+	scmClients := func(owner string) (hooks.RepositoryClient, error) {
+		return controller.RepositoryClientForOwner(provider, owner)
+	}
+	hookOptions := hooks.ProviderOptions(provider, o.hookURL)
+	hookOptions.Prune = o.hookPrune
+	hookOptions.DryRun = o.hookDryRun
+	secret := hooks.ProviderSecret(provider)
+	logger := logrus.WithField("provider", provider.String())
+	hosts := func(org string) bool {
+		answer, err := provider.Hosts(org)
+		if err != nil {
+			logger.WithError(err).Error("Error finding the provider of an org")
+		}
+		return answer
+	}
+	reconciler := hooks.NewReconciler(scmClients, secret, hookOptions, nil)
+	interrupts.TickLiteral(func() {
+		orgs, repos := controller.ConfiguredRepositories()
+		if len(orgs) == 0 && len(repos) == 0 {
+			// the configuration has not been loaded yet
+			return
+		}
+		var hostedOrgs, hostedRepos []string
+		for _, org := range orgs {
+			if hosts(org) {
+				hostedOrgs = append(hostedOrgs, org)
+			}
+		}
+		for _, repo := range repos {
+			if i := strings.LastIndex(repo, "/"); i > 0 && hosts(repo[:i]) {
+				hostedRepos = append(hostedRepos, repo)
+			}
+		}
+		if len(hostedOrgs) == 0 && len(hostedRepos) == 0 {
+			return
+		}
+		if _, err := reconciler.Reconcile(hostedOrgs, hostedRepos); err != nil {
+			logger.WithError(err).Error("Error reconciling webhooks")
+		}
+	}, o.hookSyncInterval)
+}
+
+// healthChecker checks the Kubernetes API, the configuration, the informer caches and the API of each SCM provider
+func healthChecker(controller *foghorn.Controller, kubeClient kubernetes.Interface) *health.Checker {
+	checker := &health.Checker{}
+	checker.AddLiveness("kubernetes", health.Kubernetes(kubeClient))
+	checker.AddReadiness("config", health.Loaded("configuration", controller.ConfigLoaded))
+	checker.AddReadiness("informers", health.Synced(controller.HasSynced))
+	providers, err := gitprovider.All()
+	if err != nil {
+		logrus.WithError(err).Warn("not checking the APIs of the SCM providers")
+		return checker
+	}
+	for _, p := range providers {
+		scmClient, err := p.NewClient("")
+		if err != nil {
+			logrus.WithError(err).Warnf("not checking the API of provider %s", p)
+			continue
+		}
+		checker.AddReadiness("scm-"+p.String(), health.Cached(health.Reachable(scmClient.BaseURL.String()), time.Minute))
+	}
+	return checker
+}
+
 func main() {
 	logrusutil.ComponentInit("lighthouse-foghorn")
 
@@ -69,6 +237,7 @@ func main() {
 	if err := o.Validate(); err != nil {
 		logrus.WithError(err).Fatal("Invalid options")
 	}
+	settings.Watch(o.settingsFile, "foghorn", o.settings, nil)
 
 	cfg, err := jxfactory.NewFactory().CreateKubeConfig()
 	if err != nil {
@@ -87,8 +256,12 @@ func main() {
 	if err != nil {
 		logrus.WithError(err).Fatal("Could not create Kubernetes API client")
 	}
-	jxInformerFactory := jxinformers.NewSharedInformerFactoryWithOptions(jxClient, time.Minute*30, jxinformers.WithNamespace(o.namespace))
-	lhInformerFactory := lhinformers.NewSharedInformerFactoryWithOptions(lhClient, time.Minute*30, lhinformers.WithNamespace(o.namespace))
+	jobsNamespace := o.namespace
+	if o.allNamespaces {
+		jobsNamespace = metav1.NamespaceAll
+	}
+	jxInformerFactory := jxinformers.NewSharedInformerFactoryWithOptions(jxClient, time.Minute*30, jxinformers.WithNamespace(jobsNamespace))
+	lhInformerFactory := lhinformers.NewSharedInformerFactoryWithOptions(lhClient, time.Minute*30, lhinformers.WithNamespace(jobsNamespace))
 
 	controller, err := foghorn.NewController(kubeClient,
 		jxClient,
@@ -98,6 +271,122 @@ func main() {
 		o.namespace,
 		nil)
 
+	if err != nil {
+		logrus.WithError(err).Fatal("Could not create controller")
+	}
+
+	var ledger *usage.Ledger
+	if o.usageStore.Kind != "" {
+		s, err := o.usageStore.New(kubeClient, o.namespace)
+		if err != nil {
+			logrus.WithError(err).Fatal("Could not create the usage store")
+		}
+		ledger = usage.NewLedger(s)
+		admin.Handle("/usage", ledger.Handler())
+	}
+	controller.AccountUsage(usage.NewAccountant(kubeClient, ledger, nil))
+
+	if o.provenanceDir != "" {
+		var signer provenance.Signer
+		if o.provenanceKey != "" {
+			signer, err = provenance.LoadKeySigner(o.provenanceKey)
+			if err != nil {
+				logrus.WithError(err).Fatal("Could not load the provenance signing key")
+			}
+		} else {
+			signer = provenance.NewFulcioSigner(o.provenanceFulcioURL, o.provenanceTokenFile)
+		}
+		controller.AttestProvenance(provenance.NewAttestor(o.provenanceDir, o.provenanceBuilderID, signer, nil))
+	}
+
+	if o.watchdogInterval > 0 {
+		tektonClient, err := tektonclient.NewForConfig(cfg)
+		if err != nil {
+			logrus.WithError(err).Fatal("Could not create Tekton API client")
+		}
+		scmClients := func(job *v1alpha1.LighthouseJob) (watchdog.StatusClient, error) {
+			return controller.SCMClientForJob(job)
+		}
+		timeouts := watchdog.Timeouts{
+			Pending:            o.pendingTimeout,
+			Unscheduled:        o.unscheduledTimeout,
+			MissingPipelineRun: o.missingRunTimeout,
+		}
+		w := watchdog.NewWatchdog(lhClient, tektonClient, kubeClient, scmClients, jobsNamespace, timeouts, nil)
+		w.DryRun(o.dryRun)
+		if o.dryRun {
+			logrus.Warn("running the watchdog in dry run mode: no job is aborted or errored")
+		}
+		interrupts.TickLiteral(func() {
+			if _, err := w.Check(); err != nil {
+				logrus.WithError(err).Error("Error checking for stuck LighthouseJobs")
+			}
+		}, o.watchdogInterval)
+	}
+
+	if jenkinsClient := jenkins.NewClientFromEnv(); jenkinsClient != nil && o.jenkinsSyncInterval > 0 {
+		scmClients := func(job *v1alpha1.LighthouseJob) (jenkins.StatusClient, error) {
+			return controller.SCMClientForJob(job)
+		}
+		syncer := jenkins.NewSyncer(jenkinsClient, lhClient, scmClients, jobsNamespace, nil)
+		interrupts.TickLiteral(func() {
+			if err := syncer.Sync(); err != nil {
+				logrus.WithError(err).Error("Error syncing Jenkins builds")
+			}
+		}, o.jenkinsSyncInterval)
+	}
+
+	if o.podSyncInterval > 0 {
+		scmClients := func(job *v1alpha1.LighthouseJob) (podagent.StatusClient, error) {
+			return controller.SCMClientForJob(job)
+		}
+		decoration := podagent.Decoration{
+			CloneImage:           o.cloneImage,
+			LogsImage:            o.logsImage,
+			LogArchiveClaim:      o.logArchiveClaim,
+			GitCredentialsSecret: o.gitCredentialsSecret,
+			LogsURL:              o.logsURL,
+		}
+		syncer := podagent.NewSyncer(kubeClient, lhClient, scmClients, jobsNamespace, decoration, o.podMaxRetries, nil)
+		interrupts.TickLiteral(func() {
+			if err := syncer.Sync(); err != nil {
+				logrus.WithError(err).Error("Error syncing LighthouseJob pods")
+			}
+		}, o.podSyncInterval)
+	}
+
+	if o.emailDigestHour >= 0 {
+		// the digest due when starting was sent before restarting
+		last := time.Now()
+		interrupts.TickLiteral(func() {
+			now := time.Now()
+			if !notifier.DigestDue(last, now, o.emailDigestHour) {
+				return
+			}
+			last = now
+			if err := controller.SendDigests(now.Add(-24*time.Hour), now); err != nil {
+				logrus.WithError(err).Error("Error sending the email digests")
+			}
+		}, time.Minute)
+	}
+
+	if o.hookSyncInterval > 0 {
+		providers, err := gitprovider.All()
+		if err != nil {
+			logrus.WithError(err).Fatal("Error reading the git providers")
+		}
+		for _, provider := range providers {
+			reconcileHooks(controller, provider, o)
+		}
+	}
+
+	admin.Serve(o.adminPort)
+	if o.port > 0 {
+		mux := http.NewServeMux()
+		healthChecker(controller, kubeClient).Register(mux)
+		interrupts.ListenAndServe(&http.Server{Addr: ":" + strconv.Itoa(o.port), Handler: mux}, 5*time.Second)
+	}
+
 	jxInformerFactory.Start(stopCh)
 	lhInformerFactory.Start(stopCh)
 
@@ -4,27 +4,74 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
+	jxclient "github.com/jenkins-x/jx/v2/pkg/client/clientset/versioned"
 	"github.com/jenkins-x/jx/v2/pkg/jxfactory"
 	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
 	clientset "github.com/jenkins-x/lighthouse/pkg/client/clientset/versioned"
-	lhclient "github.com/jenkins-x/lighthouse/pkg/client/clientset/versioned/typed/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/gc"
 	"github.com/jenkins-x/lighthouse/pkg/logrusutil"
+	"github.com/jenkins-x/lighthouse/pkg/metrics"
+	"github.com/jenkins-x/lighthouse/pkg/settings"
 	"github.com/sirupsen/logrus"
+	tektonclient "github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+type stateMaxAges []string
+
+func (s *stateMaxAges) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stateMaxAges) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
 type options struct {
-	namespace string
-	maxAge    time.Duration
+	settingsFile string
+	settings     *settings.Settings
+
+	namespace          string
+	allNamespaces      bool
+	maxAge             time.Duration
+	succeededMaxAge    time.Duration
+	failedMaxAge       time.Duration
+	stateMaxAges       stateMaxAges
+	maxPerRepo         int
+	dryRun             bool
+	pushGatewayAddress string
+	archiveDir         string
 }
 
 func (o *options) Validate() error {
 	if o.namespace == "" {
 		return fmt.Errorf("no --namespace given")
 	}
-	return nil
+	if o.maxPerRepo < 0 {
+		return fmt.Errorf("--max-per-repo must not be negative")
+	}
+	_, err := gc.ParseStateMaxAges(o.stateMaxAges)
+	return err
+}
+
+func (o *options) policy() gc.Policy {
+	// Validate has already checked the values parse
+	stateMaxAge, _ := gc.ParseStateMaxAges(o.stateMaxAges)
+	if _, ok := stateMaxAge[v1alpha1.SuccessState]; !ok && o.succeededMaxAge > 0 {
+		stateMaxAge[v1alpha1.SuccessState] = o.succeededMaxAge
+	}
+	if _, ok := stateMaxAge[v1alpha1.FailureState]; !ok && o.failedMaxAge > 0 {
+		stateMaxAge[v1alpha1.FailureState] = o.failedMaxAge
+	}
+	return gc.Policy{
+		MaxAge:      o.maxAge,
+		StateMaxAge: stateMaxAge,
+		MaxPerRepo:  o.maxPerRepo,
+	}
 }
 
 func gatherOptions(fs *flag.FlagSet, args ...string) options {
@@ -32,12 +79,25 @@ func gatherOptions(fs *flag.FlagSet, args ...string) options {
 
 	var o options
 	fs.DurationVar(&o.maxAge, "max-age", 7*24*time.Hour, "Maximum age to keep LighthouseJobs.")
+	fs.DurationVar(&o.succeededMaxAge, "succeeded-max-age", 0, "Maximum age to keep successful LighthouseJobs, defaults to --max-age.")
+	fs.DurationVar(&o.failedMaxAge, "failed-max-age", 0, "Maximum age to keep failed LighthouseJobs, defaults to --max-age.")
+	fs.Var(&o.stateMaxAges, "state-max-age", "Maximum age to keep LighthouseJobs in a given state, as state=duration. Can be repeated.")
+	fs.IntVar(&o.maxPerRepo, "max-per-repo", 0, "Maximum number of completed LighthouseJobs to keep per repository, 0 for no limit.")
+	fs.BoolVar(&o.dryRun, "dry-run", false, "Log the objects that would be deleted without deleting them.")
+	fs.StringVar(&o.pushGatewayAddress, "push-gateway", "", "The Prometheus push gateway to push reclaimed object metrics to.")
+	fs.StringVar(&o.archiveDir, "archive-dir", "", "The directory, usually a mounted storage bucket, to archive LighthouseJobs to before deleting them.")
 	fs.StringVar(&o.namespace, "namespace", "", "The namespace to listen in")
+	fs.BoolVar(&o.allNamespaces, "all-namespaces", false, "Collect the LighthouseJobs of every namespace, such as the namespaces of the tenants.")
+	fs.StringVar(&o.settingsFile, settings.FileFlag, "", "The LighthouseSettings file, usually mounted from the lighthouse-settings ConfigMap, whose gc section overrides the other flags.")
 
 	err := fs.Parse(args)
 	if err != nil {
 		logrus.WithError(err).Fatal("Invalid options")
 	}
+	o.settings, err = settings.Configure(fs, o.settingsFile, "gc")
+	if err != nil {
+		logrus.WithError(err).Fatal("Invalid settings")
+	}
 
 	return o
 }
@@ -56,36 +116,32 @@ func main() {
 	if err != nil {
 		logrus.WithError(err).Fatal("Could not create Lighthouse API client")
 	}
+	jxClient, err := jxclient.NewForConfig(cfg)
+	if err != nil {
+		logrus.WithError(err).Fatal("Could not create Jenkins X API client")
+	}
+	tektonClient, err := tektonclient.NewForConfig(cfg)
+	if err != nil {
+		logrus.WithError(err).Fatal("Could not create Tekton API client")
+	}
 
-	lhInterface := lhClient.LighthouseV1alpha1().LighthouseJobs(o.namespace)
-
-	jobList, err := lhInterface.List(metav1.ListOptions{})
+	namespace := o.namespace
+	if o.allNamespaces {
+		namespace = metav1.NamespaceAll
+	}
+	collector := gc.NewCollector(lhClient, jxClient, tektonClient, namespace, o.policy(), o.dryRun, logrus.NewEntry(logrus.StandardLogger()))
+	if o.archiveDir != "" {
+		collector.WithArchiver(&gc.DirArchiver{Dir: o.archiveDir})
+	}
+	deleted, err := collector.Run()
 	if err != nil {
-		logrus.WithError(err).Fatalf("Could not list LighthouseJobs in namespace %s", o.namespace)
-	}
-
-	now := time.Now()
-
-	for _, job := range jobList.Items {
-		j := job
-		completionTime := j.Status.CompletionTime
-		if completionTime != nil && completionTime.Add(o.maxAge).Before(now) {
-			// The job completed at least maxAge ago, so delete it.
-			err = deleteLighthouseJob(lhInterface, &j)
-			if err != nil {
-				logrus.WithError(err).Fatalf("Failed to delete LighthouseJob %s", j.Name)
-			}
-		} else if completionTime == nil && j.Status.StartTime.Add(o.maxAge).Before(now) {
-			// The job never completed, but was created at least maxAge ago, so delete it.
-			err = deleteLighthouseJob(lhInterface, &j)
-			if err != nil {
-				logrus.WithError(err).Fatalf("Failed to delete LighthouseJob %s", j.Name)
-			}
-		}
+		logrus.WithError(err).Fatal("Failed to garbage collect LighthouseJobs")
 	}
-}
+	logrus.Infof("Garbage collected %d LighthouseJobs", len(deleted))
 
-func deleteLighthouseJob(lhInterface lhclient.LighthouseJobInterface, lhJob *v1alpha1.LighthouseJob) error {
-	logrus.Infof("Deleting LighthouseJob %s", lhJob.Name)
-	return lhInterface.Delete(lhJob.Name, metav1.NewDeleteOptions(0))
+	if o.pushGatewayAddress != "" {
+		if err := metrics.PushOnce("lighthouse-gc-jobs", o.pushGatewayAddress); err != nil {
+			logrus.WithError(err).Error("Failed to push metrics")
+		}
+	}
 }
@@ -24,30 +24,47 @@ import (
 	"time"
 
 	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/admin"
+	"github.com/jenkins-x/lighthouse/pkg/clients"
+	"github.com/jenkins-x/lighthouse/pkg/configinclude"
+	"github.com/jenkins-x/lighthouse/pkg/configshards"
+	"github.com/jenkins-x/lighthouse/pkg/gitprovider"
+	"github.com/jenkins-x/lighthouse/pkg/health"
 	"github.com/jenkins-x/lighthouse/pkg/interrupts"
-	"github.com/jenkins-x/lighthouse/pkg/jobutil"
 	"github.com/jenkins-x/lighthouse/pkg/keeper"
 	"github.com/jenkins-x/lighthouse/pkg/keeper/githubapp"
 	"github.com/jenkins-x/lighthouse/pkg/logrusutil"
 	"github.com/jenkins-x/lighthouse/pkg/metrics"
+	"github.com/jenkins-x/lighthouse/pkg/notifier"
+	"github.com/jenkins-x/lighthouse/pkg/secrets"
+	"github.com/jenkins-x/lighthouse/pkg/settings"
+	"github.com/jenkins-x/lighthouse/pkg/tenants"
 	"github.com/jenkins-x/lighthouse/pkg/util"
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 type options struct {
-	port int
+	settingsFile string
+	settings     *settings.Settings
+
+	port      int
+	adminPort int
 
 	configPath    string
 	jobConfigPath string
 	botName       string
 	gitServerURL  string
 	gitKind       string
+	provider      string
 
 	syncThrottle   int
 	statusThrottle int
 
-	dryRun  bool
-	runOnce bool
+	dryRun                 bool
+	runOnce                bool
+	watchLighthouseConfigs bool
 
 	maxRecordsPerPool int
 	// historyURI where Keeper should store its action history.
@@ -66,30 +83,41 @@ type options struct {
 }
 
 func (o *options) Validate() error {
+	if o.watchLighthouseConfigs && o.jobConfigPath != "" {
+		return errors.New("--job-config-path cannot be used with --watch-lighthouse-configs")
+	}
 	return nil
 }
 
 func gatherOptions(fs *flag.FlagSet, args ...string) options {
 	var o options
 	fs.IntVar(&o.port, "port", 8888, "Port to listen on.")
+	fs.IntVar(&o.adminPort, "admin-port", 0, "The TCP port serving the admin endpoints: pprof profiles, expvar variables at /debug/vars and "+logrusutil.LevelPath+" to read or PUT the log level. It should not be exposed publicly. Disabled by default.")
 	fs.StringVar(&o.configPath, "config-path", "", "Path to config.yaml.")
 	fs.StringVar(&o.jobConfigPath, "job-config-path", "", "Path to prow job configs.")
 	fs.StringVar(&o.botName, "bot-name", "", "The bot name")
 	fs.StringVar(&o.gitServerURL, "git-url", "", "The git provider URL")
 	fs.StringVar(&o.gitKind, "git-kind", "", "The git provider kind (e.g. github, gitlab, bitbucketserver")
-	fs.BoolVar(&o.dryRun, "dry-run", true, "Whether to mutate any real-world state.")
+	fs.StringVar(&o.provider, "provider", "", "The name of the provider in $"+gitprovider.ProvidersEnv+" whose pull requests are merged, which defaults to the provider configured by $GIT_KIND and $GIT_SERVER. Run a keeper for each provider.")
+	fs.BoolVar(&o.dryRun, "dry-run", false, "Only log and export what would be merged or tested, without merging, launching jobs or writing statuses and comments.")
 	fs.BoolVar(&o.runOnce, "run-once", false, "If true, run only once then quit.")
+	fs.BoolVar(&o.watchLighthouseConfigs, "watch-lighthouse-configs", false, "Merges the jobs of the LighthouseConfig resources of every namespace into the config.yaml, which then cannot be split with --job-config-path.")
 	fs.IntVar(&o.syncThrottle, "sync-hourly-tokens", 800, "The maximum number of tokens per hour to be used by the sync controller.")
 	fs.IntVar(&o.statusThrottle, "status-hourly-tokens", 400, "The maximum number of tokens per hour to be used by the status controller.")
 
 	fs.IntVar(&o.maxRecordsPerPool, "max-records-per-pool", 1000, "The maximum number of history records stored for an individual Keeper pool.")
 	fs.StringVar(&o.historyURI, "history-uri", "", "The /local/path or gs://path/to/object to store keeper action history. GCS writes will use the default object ACL for the bucket")
 	fs.StringVar(&o.statusURI, "status-path", "", "The /local/path or gs://path/to/object to store status controller state. GCS writes will use the default object ACL for the bucket.")
+	fs.StringVar(&o.settingsFile, settings.FileFlag, "", "The LighthouseSettings file, usually mounted from the lighthouse-settings ConfigMap, whose keeper section overrides the other flags. Its log_level is reloaded when it changes.")
 
 	err := fs.Parse(args)
 	if err != nil {
 		logrus.WithError(err).Fatal("Invalid options")
 	}
+	o.settings, err = settings.Configure(fs, o.settingsFile, "keeper")
+	if err != nil {
+		logrus.WithError(err).Fatal("Invalid settings")
+	}
 	o.configPath = config.Path(o.configPath)
 	return o
 }
@@ -99,22 +127,43 @@ func main() {
 
 	defer interrupts.WaitForGracefulShutdown()
 
-	jobutil.ServePProf()
-
 	o := gatherOptions(flag.NewFlagSet(os.Args[0], flag.ExitOnError), os.Args[1:]...)
 	if err := o.Validate(); err != nil {
 		logrus.WithError(err).Fatal("Invalid options")
 	}
+	settings.Watch(o.settingsFile, "keeper", o.settings, nil)
 
 	configAgent := &config.Agent{}
-	if err := configAgent.Start(o.configPath, o.jobConfigPath); err != nil {
+	if o.watchLighthouseConfigs {
+		if err := watchLighthouseConfigs(configAgent, o.configPath); err != nil {
+			logrus.WithError(err).Fatal("Error watching the LighthouseConfigs.")
+		}
+	} else if o.jobConfigPath != "" {
+		if err := configAgent.Start(o.configPath, o.jobConfigPath); err != nil {
+			logrus.WithError(err).Fatal("Error starting config agent.")
+		}
+	} else if err := configinclude.StartAgent(configAgent, o.configPath, time.Minute); err != nil {
 		logrus.WithError(err).Fatal("Error starting config agent.")
 	}
+	keeper.WatchExtension(o.configPath)
+	tenantConfig := tenants.FileConfig(o.configPath)
+	keeper.ScopeTenants(tenantConfig)
+	mergeNotifier := notifier.New(notifier.FileConfig(o.configPath), configAgent.Config, nil)
+	mergeNotifier.ScopeTenants(tenantConfig)
+	keeper.NotifyMerges(mergeNotifier)
+	if o.dryRun {
+		logrus.Warn("running in dry run mode: no pull request is merged and no job is launched")
+	}
+	admin.PublishConfigHash("config_hash", func() interface{} { return configAgent.Config() })
+	admin.Serve(o.adminPort)
 
-	var err error
+	provider, err := gitprovider.Named(o.provider)
+	if err != nil {
+		logrus.WithError(err).Fatal("Invalid --provider")
+	}
 	botName := o.botName
 	if botName == "" {
-		botName = os.Getenv("GIT_USER")
+		botName = os.Getenv(provider.EnvName("GIT_USER"))
 	}
 	if util.GetGitHubAppSecretDir() != "" {
 		botName, err = util.GetGitHubAppAPIUser()
@@ -123,32 +172,33 @@ func main() {
 		}
 	}
 	if botName == "" {
-		logrus.Fatal("no $GIT_USER defined")
+		logrus.Fatalf("no $%s defined", provider.EnvName("GIT_USER"))
 	}
 	serverURL := o.gitServerURL
 	if serverURL == "" {
-		serverURL = os.Getenv("GIT_SERVER")
+		serverURL = provider.ServerURL()
 	}
 	if serverURL == "" {
 		serverURL = "https://github.com"
 	}
 	gitKind := o.gitKind
 	if gitKind == "" {
-		gitKind = os.Getenv("GIT_KIND")
+		gitKind = provider.Kind()
 	}
-	if gitKind == "" {
-		gitKind = "github"
+	gitToken, err := secrets.FromEnv(provider.EnvName("GIT_TOKEN")).Get()
+	if err != nil {
+		logrus.WithError(err).Fatal("Error loading git token.")
 	}
-	gitToken := os.Getenv("GIT_TOKEN")
 
 	cfg := configAgent.Config
-	c, err := githubapp.NewKeeperController(configAgent, botName, gitKind, gitToken, serverURL, o.maxRecordsPerPool, o.historyURI, o.statusURI)
+	c, err := githubapp.NewKeeperController(configAgent, botName, gitKind, gitToken, serverURL, o.maxRecordsPerPool, o.historyURI, o.statusURI, o.dryRun)
 	if err != nil {
 		logrus.WithError(err).Fatal("Error creating Keeper controller.")
 	}
 	defer c.Shutdown()
 	http.Handle("/", c)
 	http.Handle("/history", c.GetHistory())
+	healthChecker(configAgent, gitKind, serverURL).Register(http.DefaultServeMux)
 	server := &http.Server{Addr: ":" + strconv.Itoa(o.port)}
 
 	start := time.Now()
@@ -182,8 +232,48 @@ func main() {
 	}
 }
 
+// healthChecker checks the Kubernetes API, the configuration and the API of the SCM provider
+func healthChecker(configAgent *config.Agent, gitKind, serverURL string) *health.Checker {
+	checker := &health.Checker{}
+	_, _, kubeClient, _, _, err := clients.GetClientsAndNamespace(nil)
+	if err != nil {
+		logrus.WithError(err).Warn("not checking the Kubernetes API")
+	} else {
+		checker.AddLiveness("kubernetes", health.Kubernetes(kubeClient))
+	}
+	checker.AddReadiness("config", health.Loaded("configuration", func() bool {
+		return configAgent.Config() != nil
+	}))
+	scmClient, err := gitprovider.NewSCMClient(gitKind, serverURL, "", "")
+	if err != nil {
+		logrus.WithError(err).Warn("not checking the SCM provider API")
+	} else {
+		checker.AddReadiness("scm", health.Cached(health.Reachable(scmClient.BaseURL.String()), time.Minute))
+	}
+	return checker
+}
+
 func sync(c keeper.Controller) {
 	if err := c.Sync(); err != nil {
 		logrus.WithError(err).Error("Error syncing.")
 	}
 }
+
+// watchLighthouseConfigs loads the config.yaml merged with the LighthouseConfigs of every namespace
+func watchLighthouseConfigs(configAgent *config.Agent, configPath string) error {
+	_, _, _, lhClient, _, err := clients.GetClientsAndNamespace(nil)
+	if err != nil {
+		return err
+	}
+	w, err := configshards.NewWatcher(lhClient, metav1.NamespaceAll, configAgent.Set, interrupts.Context().Done())
+	if err != nil {
+		return err
+	}
+	if err := w.WatchFile(configPath, time.Minute); err != nil {
+		return err
+	}
+	if configAgent.Config() == nil {
+		return errors.Errorf("invalid config %s", configPath)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/admin"
+	"github.com/jenkins-x/lighthouse/pkg/health"
+	"github.com/jenkins-x/lighthouse/pkg/interrupts"
+	"github.com/jenkins-x/lighthouse/pkg/logrusutil"
+	"github.com/jenkins-x/lighthouse/pkg/metrics"
+	"github.com/jenkins-x/lighthouse/pkg/scmproxy"
+	"github.com/sirupsen/logrus"
+)
+
+type options struct {
+	port           int
+	adminPort      int
+	upstream       string
+	maxCacheSizeMB int64
+}
+
+func (o *options) Validate() error {
+	if o.upstream == "" {
+		return fmt.Errorf("no --upstream given")
+	}
+	if o.maxCacheSizeMB <= 0 {
+		return fmt.Errorf("--max-cache-size-mb must be positive")
+	}
+	return nil
+}
+
+func gatherOptions(fs *flag.FlagSet, args ...string) options {
+	var o options
+	fs.IntVar(&o.port, "port", 8888, "The TCP port the proxied API, and the "+health.LivenessPath+" and "+health.ReadinessPath+" endpoints, are served on.")
+	fs.IntVar(&o.adminPort, "admin-port", 0, "The TCP port serving the admin endpoints: pprof profiles, expvar variables at /debug/vars and "+logrusutil.LevelPath+" to read or PUT the log level. It should not be exposed publicly. Disabled by default.")
+	fs.StringVar(&o.upstream, "upstream", "https://api.github.com", "The URL of the API of the SCM provider the requests are forwarded to, e.g. https://api.github.com or https://gitlab.example.com.")
+	fs.Int64Var(&o.maxCacheSizeMB, "max-cache-size-mb", 256, "The size in megabytes of the responses cached in memory, beyond which the least recently used ones are evicted.")
+
+	err := fs.Parse(args)
+	if err != nil {
+		logrus.WithError(err).Fatal("Invalid options")
+	}
+	return o
+}
+
+func main() {
+	logrusutil.ComponentInit("lighthouse-scm-proxy")
+
+	defer interrupts.WaitForGracefulShutdown()
+
+	o := gatherOptions(flag.NewFlagSet(os.Args[0], flag.ExitOnError), os.Args[1:]...)
+	if err := o.Validate(); err != nil {
+		logrus.WithError(err).Fatal("Invalid options")
+	}
+
+	proxy, err := scmproxy.NewProxy(o.upstream, o.maxCacheSizeMB<<20, nil)
+	if err != nil {
+		logrus.WithError(err).Fatal("Invalid options")
+	}
+
+	go metrics.ExposeMetrics("lighthouse-scm-proxy", config.PushGateway{})
+	admin.Serve(o.adminPort)
+
+	checker := &health.Checker{}
+	checker.AddReadiness("upstream", health.Cached(health.Reachable(o.upstream), time.Minute))
+	mux := http.NewServeMux()
+	checker.Register(mux)
+	mux.Handle("/", proxy)
+	logrus.Infof("Proxying %s on port %d", o.upstream, o.port)
+	interrupts.ListenAndServe(&http.Server{Addr: ":" + strconv.Itoa(o.port), Handler: mux}, 5*time.Second)
+}
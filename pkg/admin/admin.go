@@ -0,0 +1,94 @@
+// Package admin serves the debug endpoints of the lighthouse components on a port which is not exposed publicly:
+// the net/http/pprof profiles, the expvar variables and the log level.
+package admin
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	"reflect"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jenkins-x/lighthouse/pkg/interrupts"
+	"github.com/jenkins-x/lighthouse/pkg/logrusutil"
+	"github.com/sirupsen/logrus"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	publishGoroutines sync.Once
+
+	handlersLock sync.Mutex
+	handlers     = map[string]http.Handler{}
+)
+
+// Handle registers an additional admin endpoint of the component, such as the onboarding of repositories, which
+// is served by the muxes created afterwards
+func Handle(pattern string, handler http.Handler) {
+	handlersLock.Lock()
+	defer handlersLock.Unlock()
+	handlers[pattern] = handler
+}
+
+// NewMux returns the admin endpoints: the profiles below /debug/pprof/, the expvar variables at /debug/vars, the
+// log level at logrusutil.LevelPath and the endpoints registered with Handle
+func NewMux() *http.ServeMux {
+	publishGoroutines.Do(func() {
+		expvar.Publish("goroutines", expvar.Func(func() interface{} {
+			return runtime.NumGoroutine()
+		}))
+	})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/debug/vars", expvar.Handler())
+	mux.Handle(logrusutil.LevelPath, logrusutil.LevelHandler{})
+	handlersLock.Lock()
+	defer handlersLock.Unlock()
+	for pattern, handler := range handlers {
+		mux.Handle(pattern, handler)
+	}
+	return mux
+}
+
+// Serve serves the admin endpoints on the port asynchronously, unless the port is 0
+func Serve(port int) {
+	if port <= 0 {
+		return
+	}
+	logrus.Infof("Serving the admin endpoints on port %d", port)
+	server := &http.Server{Addr: ":" + strconv.Itoa(port), Handler: NewMux()}
+	interrupts.ListenAndServe(server, 5*time.Second)
+}
+
+// PublishConfigHash publishes the hash of the configuration returned by config as an expvar variable, so that
+// the configuration replicas run with can be compared. Publishing the same name twice keeps the first one.
+func PublishConfigHash(name string, config func() interface{}) {
+	if expvar.Get(name) != nil {
+		return
+	}
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return ConfigHash(config())
+	}))
+}
+
+// ConfigHash returns the SHA-256 of the YAML of the configuration, or an empty string if there is none
+func ConfigHash(config interface{}) string {
+	if v := reflect.ValueOf(config); !v.IsValid() || (v.Kind() == reflect.Ptr && v.IsNil()) {
+		return ""
+	}
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
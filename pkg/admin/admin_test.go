@@ -0,0 +1,56 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testConfig struct {
+	Name string `json:"name"`
+}
+
+func TestMuxServesVars(t *testing.T) {
+	PublishConfigHash("test_config_hash", func() interface{} { return &testConfig{Name: "a"} })
+
+	rr := httptest.NewRecorder()
+	NewMux().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/debug/vars", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	vars := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &vars))
+	assert.NotZero(t, vars["goroutines"])
+	assert.Equal(t, ConfigHash(&testConfig{Name: "a"}), vars["test_config_hash"])
+}
+
+func TestMuxServesLogLevel(t *testing.T) {
+	defer logrus.SetLevel(logrus.GetLevel())
+
+	rr := httptest.NewRecorder()
+	NewMux().ServeHTTP(rr, httptest.NewRequest(http.MethodPut, "/admin/loglevel?level=trace", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, logrus.TraceLevel, logrus.GetLevel())
+}
+
+func TestMuxServesPProf(t *testing.T) {
+	rr := httptest.NewRecorder()
+	NewMux().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/debug/pprof/", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "goroutine")
+}
+
+func TestConfigHash(t *testing.T) {
+	var config *testConfig
+	assert.Equal(t, "", ConfigHash(nil))
+	assert.Equal(t, "", ConfigHash(config))
+
+	a := ConfigHash(&testConfig{Name: "a"})
+	assert.Len(t, a, 64)
+	assert.Equal(t, a, ConfigHash(&testConfig{Name: "a"}))
+	assert.NotEqual(t, a, ConfigHash(&testConfig{Name: "b"}))
+}
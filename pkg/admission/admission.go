@@ -0,0 +1,217 @@
+// Package admission implements a validating admission webhook for LighthouseJobs, so that malformed jobs created
+// with kubectl or by a buggy controller are rejected with a clear message rather than failing later on.
+package admission
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1beta1"
+	lighthouseclient "github.com/jenkins-x/lighthouse/pkg/client/clientset/versioned/typed/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// Path is the path the LighthouseJobs are validated at
+const Path = "/validate/lighthousejobs"
+
+// maxReviewSize is the largest admission review accepted, which is far more than any LighthouseJob needs
+const maxReviewSize = 3 * 1024 * 1024
+
+// Validator validates the LighthouseJobs created or updated in a namespace
+type Validator struct {
+	// JobClient is used to find the jobs whose context collides with a new job
+	JobClient lighthouseclient.LighthouseJobInterface
+	// Namespace is the namespace LighthouseJobs are created in
+	Namespace string
+	Logger    *logrus.Entry
+}
+
+// Validate returns an error explaining what is wrong with the job, if anything
+func (v *Validator) Validate(job *v1alpha1.LighthouseJob, create bool) error {
+	if v.Namespace != "" && job.Namespace != "" && job.Namespace != v.Namespace {
+		return errors.Errorf("LighthouseJobs must be created in namespace %s, not %s", v.Namespace, job.Namespace)
+	}
+	spec := &job.Spec
+	switch spec.Type {
+	case config.PresubmitJob, config.PostsubmitJob, config.PeriodicJob, config.BatchJob:
+	case "":
+		return errors.New("spec.type is required")
+	default:
+		return errors.Errorf("unknown job type %q in spec.type, expected one of %s, %s, %s or %s", spec.Type, config.PresubmitJob, config.PostsubmitJob, config.PeriodicJob, config.BatchJob)
+	}
+	if spec.Job == "" {
+		return errors.New("spec.job is required")
+	}
+	switch spec.Agent {
+	case "", v1alpha1.TektonAgent, v1alpha1.JenkinsAgent:
+	case v1alpha1.KubernetesAgent:
+		if spec.PodSpec == nil {
+			return errors.Errorf("spec.pod_spec is required by the %s agent", v1alpha1.KubernetesAgent)
+		}
+	default:
+		return errors.Errorf("unknown agent %q in spec.agent, expected one of %s, %s or %s", spec.Agent, v1alpha1.TektonAgent, v1alpha1.JenkinsAgent, v1alpha1.KubernetesAgent)
+	}
+	if spec.ConcurrencyGroup != "" && spec.Agent != v1alpha1.KubernetesAgent {
+		return errors.Errorf("spec.concurrency_group is only supported by the %s agent", v1alpha1.KubernetesAgent)
+	}
+	if spec.JobEnvVersion < 0 || spec.JobEnvVersion > v1alpha1.LatestJobEnvVersion {
+		return errors.Errorf("unsupported spec.job_env_version %d, expected a version from 1 to %d", spec.JobEnvVersion, v1alpha1.LatestJobEnvVersion)
+	}
+	if err := validateRefs(spec); err != nil {
+		return err
+	}
+	if create {
+		return v.validateContext(job)
+	}
+	return nil
+}
+
+func validateRefs(spec *v1alpha1.LighthouseJobSpec) error {
+	refs := spec.Refs
+	if refs == nil {
+		if spec.Type == config.PeriodicJob {
+			return nil
+		}
+		return errors.Errorf("spec.refs is required by %s jobs", spec.Type)
+	}
+	var missing []string
+	if refs.Org == "" {
+		missing = append(missing, "org")
+	}
+	if refs.Repo == "" {
+		missing = append(missing, "repo")
+	}
+	if refs.BaseRef == "" {
+		missing = append(missing, "base_ref")
+	}
+	if len(missing) > 0 {
+		return errors.Errorf("spec.refs is missing %s", strings.Join(missing, ", "))
+	}
+	if len(refs.Pulls) == 0 && (spec.Type == config.PresubmitJob || spec.Type == config.BatchJob) {
+		return errors.Errorf("spec.refs.pulls is required by %s jobs", spec.Type)
+	}
+	for i, pull := range refs.Pulls {
+		if pull.Number <= 0 {
+			return errors.Errorf("spec.refs.pulls[%d] has no number", i)
+		}
+	}
+	return nil
+}
+
+// validateContext checks that no other job of the repository is still reporting the context of the job on the
+// same commit, as they would overwrite each other's status
+func (v *Validator) validateContext(job *v1alpha1.LighthouseJob) error {
+	spec := &job.Spec
+	if v.JobClient == nil || spec.Context == "" || spec.Refs == nil {
+		return nil
+	}
+	sha := spec.GetSHA()
+	if sha == "" {
+		return nil
+	}
+	selector := labels.Set{
+		util.OrgLabel:  strings.ToLower(spec.Refs.Org),
+		util.RepoLabel: spec.Refs.Repo,
+	}
+	list, err := v.JobClient.List(metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		// the admission should not fail because the other jobs cannot be listed
+		v.logger().WithError(err).Warn("failed to list the LighthouseJobs of the repository")
+		return nil
+	}
+	for _, other := range list.Items {
+		if other.Name == job.Name || other.Spec.Job == spec.Job || other.Spec.Context != spec.Context || other.Status.CompletionTime != nil {
+			continue
+		}
+		if other.Spec.Refs == nil || other.Spec.GetSHA() != sha {
+			continue
+		}
+		return errors.Errorf("context %s of commit %s is already reported by job %s of LighthouseJob %s", spec.Context, sha, other.Spec.Job, other.Name)
+	}
+	return nil
+}
+
+func (v *Validator) logger() *logrus.Entry {
+	if v.Logger == nil {
+		return logrus.WithField("handler", "admission")
+	}
+	return v.Logger
+}
+
+// ServeHTTP reviews the LighthouseJob of the AdmissionReview
+func (v *Validator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "only POST is supported", http.StatusMethodNotAllowed)
+		return
+	}
+	data, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxReviewSize))
+	if err != nil {
+		http.Error(w, "failed to read the request", http.StatusBadRequest)
+		return
+	}
+	review := admissionv1beta1.AdmissionReview{}
+	if err := json.Unmarshal(data, &review); err != nil || review.Request == nil {
+		http.Error(w, "expected an AdmissionReview with a request", http.StatusBadRequest)
+		return
+	}
+	review.Response = v.review(review.Request)
+	review.Request = nil
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(review); err != nil {
+		v.logger().WithError(err).Warn("failed to write the admission response")
+	}
+}
+
+func (v *Validator) review(request *admissionv1beta1.AdmissionRequest) *admissionv1beta1.AdmissionResponse {
+	response := &admissionv1beta1.AdmissionResponse{UID: request.UID, Allowed: true}
+	if request.Operation != admissionv1beta1.Create && request.Operation != admissionv1beta1.Update {
+		return response
+	}
+	job, err := decodeJob(request)
+	if err != nil {
+		err = errors.Wrap(err, "parsing the LighthouseJob")
+	} else {
+		if job.Namespace == "" {
+			job.Namespace = request.Namespace
+		}
+		err = v.Validate(job, request.Operation == admissionv1beta1.Create)
+	}
+	if err != nil {
+		v.logger().WithError(err).WithField("name", request.Name).Info("Rejecting the LighthouseJob.")
+		response.Allowed = false
+		response.Result = &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Reason:  metav1.StatusReasonInvalid,
+			Code:    http.StatusUnprocessableEntity,
+			Message: fmt.Sprintf("invalid LighthouseJob: %v", err),
+		}
+	}
+	return response
+}
+
+// decodeJob decodes the job of the request, converting the jobs created as v1beta1 to the v1alpha1 jobs they are
+// stored as
+func decodeJob(request *admissionv1beta1.AdmissionRequest) (*v1alpha1.LighthouseJob, error) {
+	if request.Kind.Version == v1beta1.SchemeGroupVersion.Version {
+		job := &v1beta1.LighthouseJob{}
+		if err := json.Unmarshal(request.Object.Raw, job); err != nil {
+			return nil, err
+		}
+		return job.ConvertToV1alpha1(), nil
+	}
+	job := &v1alpha1.LighthouseJob{}
+	if err := json.Unmarshal(request.Object.Raw, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
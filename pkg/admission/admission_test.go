@@ -0,0 +1,220 @@
+package admission
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1beta1"
+	lhfake "github.com/jenkins-x/lighthouse/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func makeJob(name, job, context string) *v1alpha1.LighthouseJob {
+	return &v1alpha1.LighthouseJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "jx",
+			Labels: map[string]string{
+				util.OrgLabel:  "org",
+				util.RepoLabel: "repo",
+			},
+		},
+		Spec: v1alpha1.LighthouseJobSpec{
+			Type:    config.PresubmitJob,
+			Job:     job,
+			Context: context,
+			Refs: &v1alpha1.Refs{
+				Org:     "org",
+				Repo:    "repo",
+				BaseRef: "master",
+				Pulls:   []v1alpha1.Pull{{Number: 1, SHA: "abc"}},
+			},
+		},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	running := makeJob("running", "lint", "pr-lint")
+	completed := makeJob("completed", "test", "pr-build")
+	now := metav1.Now()
+	completed.Status.CompletionTime = &now
+	v := &Validator{
+		JobClient: lhfake.NewSimpleClientset(running, completed).LighthouseV1alpha1().LighthouseJobs("jx"),
+		Namespace: "jx",
+	}
+
+	testCases := []struct {
+		name     string
+		modify   func(job *v1alpha1.LighthouseJob)
+		expected string
+	}{
+		{
+			name: "valid job",
+		},
+		{
+			name:     "other namespace",
+			modify:   func(job *v1alpha1.LighthouseJob) { job.Namespace = "default" },
+			expected: "LighthouseJobs must be created in namespace jx, not default",
+		},
+		{
+			name:     "unknown type",
+			modify:   func(job *v1alpha1.LighthouseJob) { job.Spec.Type = "nightly" },
+			expected: `unknown job type "nightly"`,
+		},
+		{
+			name:     "unknown agent",
+			modify:   func(job *v1alpha1.LighthouseJob) { job.Spec.Agent = "prow" },
+			expected: `unknown agent "prow"`,
+		},
+		{
+			name:     "kubernetes agent without a pod spec",
+			modify:   func(job *v1alpha1.LighthouseJob) { job.Spec.Agent = v1alpha1.KubernetesAgent },
+			expected: "spec.pod_spec is required",
+		},
+		{
+			name:     "concurrency group of another agent",
+			modify:   func(job *v1alpha1.LighthouseJob) { job.Spec.ConcurrencyGroup = "deploy-staging" },
+			expected: "spec.concurrency_group is only supported by the kubernetes agent",
+		},
+		{
+			name: "concurrency group of the kubernetes agent",
+			modify: func(job *v1alpha1.LighthouseJob) {
+				job.Spec.Agent = v1alpha1.KubernetesAgent
+				job.Spec.PodSpec = &corev1.PodSpec{}
+				job.Spec.ConcurrencyGroup = "deploy-staging"
+			},
+		},
+		{
+			name:     "unsupported job env version",
+			modify:   func(job *v1alpha1.LighthouseJob) { job.Spec.JobEnvVersion = v1alpha1.LatestJobEnvVersion + 1 },
+			expected: "unsupported spec.job_env_version",
+		},
+		{
+			name:     "missing refs",
+			modify:   func(job *v1alpha1.LighthouseJob) { job.Spec.Refs = nil },
+			expected: "spec.refs is required by presubmit jobs",
+		},
+		{
+			name: "periodic without refs",
+			modify: func(job *v1alpha1.LighthouseJob) {
+				job.Spec.Type = config.PeriodicJob
+				job.Spec.Refs = nil
+			},
+		},
+		{
+			name:     "incomplete refs",
+			modify:   func(job *v1alpha1.LighthouseJob) { job.Spec.Refs.Repo, job.Spec.Refs.BaseRef = "", "" },
+			expected: "spec.refs is missing repo, base_ref",
+		},
+		{
+			name:     "presubmit without pulls",
+			modify:   func(job *v1alpha1.LighthouseJob) { job.Spec.Refs.Pulls = nil },
+			expected: "spec.refs.pulls is required by presubmit jobs",
+		},
+		{
+			name:     "context of a running job",
+			modify:   func(job *v1alpha1.LighthouseJob) { job.Spec.Context = "pr-lint" },
+			expected: "context pr-lint of commit abc is already reported by job lint of LighthouseJob running",
+		},
+		{
+			name: "context of a running job on another commit",
+			modify: func(job *v1alpha1.LighthouseJob) {
+				job.Spec.Context = "pr-lint"
+				job.Spec.Refs.Pulls[0].SHA = "def"
+			},
+		},
+		{
+			name: "rerun of a running job",
+			modify: func(job *v1alpha1.LighthouseJob) {
+				job.Spec.Job = "lint"
+				job.Spec.Context = "pr-lint"
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			job := makeJob("new", "build", "pr-build")
+			if tc.modify != nil {
+				tc.modify(job)
+			}
+			err := v.Validate(job, true)
+			if tc.expected == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.expected)
+		})
+	}
+}
+
+func TestServeHTTP(t *testing.T) {
+	v := &Validator{Namespace: "jx"}
+	review := func(job *v1alpha1.LighthouseJob) *admissionv1beta1.AdmissionResponse {
+		raw, err := json.Marshal(job)
+		require.NoError(t, err)
+		body, err := json.Marshal(admissionv1beta1.AdmissionReview{
+			Request: &admissionv1beta1.AdmissionRequest{
+				UID:       "1234",
+				Namespace: "jx",
+				Operation: admissionv1beta1.Create,
+				Object:    runtime.RawExtension{Raw: raw},
+			},
+		})
+		require.NoError(t, err)
+		w := httptest.NewRecorder()
+		v.ServeHTTP(w, httptest.NewRequest(http.MethodPost, Path, bytes.NewReader(body)))
+		require.Equal(t, http.StatusOK, w.Code)
+		answer := admissionv1beta1.AdmissionReview{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &answer))
+		require.NotNil(t, answer.Response)
+		assert.Equal(t, "1234", string(answer.Response.UID))
+		return answer.Response
+	}
+
+	job := makeJob("new", "build", "pr-build")
+	job.Namespace = ""
+	assert.True(t, review(job).Allowed)
+
+	job.Spec.Agent = "prow"
+	response := review(job)
+	assert.False(t, response.Allowed)
+	require.NotNil(t, response.Result)
+	assert.Contains(t, response.Result.Message, `unknown agent "prow"`)
+
+	// the jobs created as v1beta1 are validated too
+	raw, err := json.Marshal(v1beta1.ConvertFromV1alpha1(job))
+	require.NoError(t, err)
+	body, err := json.Marshal(admissionv1beta1.AdmissionReview{
+		Request: &admissionv1beta1.AdmissionRequest{
+			UID:       "1234",
+			Kind:      metav1.GroupVersionKind{Group: "lighthouse.jenkins.io", Version: "v1beta1", Kind: "LighthouseJob"},
+			Namespace: "jx",
+			Operation: admissionv1beta1.Create,
+			Object:    runtime.RawExtension{Raw: raw},
+		},
+	})
+	require.NoError(t, err)
+	w := httptest.NewRecorder()
+	v.ServeHTTP(w, httptest.NewRequest(http.MethodPost, Path, bytes.NewReader(body)))
+	answer := admissionv1beta1.AdmissionReview{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &answer))
+	require.NotNil(t, answer.Response)
+	assert.False(t, answer.Response.Allowed)
+	assert.Contains(t, answer.Response.Result.Message, `unknown agent "prow"`)
+
+	w = httptest.NewRecorder()
+	v.ServeHTTP(w, httptest.NewRequest(http.MethodPost, Path, bytes.NewReader([]byte("{}"))))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
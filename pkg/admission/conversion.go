@@ -0,0 +1,136 @@
+package admission
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1beta1"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// ConversionPath is the path the LighthouseJobs are converted between versions at
+const ConversionPath = "/convert/lighthousejobs"
+
+// conversionReview is the apiextensions.k8s.io/v1beta1 ConversionReview sent by the API server to the conversion
+// webhook of a CRD, which the vendored apiextensions API does not define yet
+type conversionReview struct {
+	metav1.TypeMeta `json:",inline"`
+	Request         *conversionRequest  `json:"request,omitempty"`
+	Response        *conversionResponse `json:"response,omitempty"`
+}
+
+type conversionRequest struct {
+	UID               types.UID              `json:"uid"`
+	DesiredAPIVersion string                 `json:"desiredAPIVersion"`
+	Objects           []runtime.RawExtension `json:"objects"`
+}
+
+type conversionResponse struct {
+	UID              types.UID              `json:"uid"`
+	ConvertedObjects []runtime.RawExtension `json:"convertedObjects"`
+	Result           metav1.Status          `json:"result"`
+}
+
+// Converter converts LighthouseJobs between the versions served by the CRD
+type Converter struct {
+	Logger *logrus.Entry
+}
+
+// Convert converts the JSON of a LighthouseJob to the desired API version
+func Convert(data []byte, desiredAPIVersion string) ([]byte, error) {
+	meta := metav1.TypeMeta{}
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, errors.Wrap(err, "parsing the type of the object")
+	}
+	if meta.Kind != "LighthouseJob" {
+		return nil, errors.Errorf("cannot convert a %s", meta.Kind)
+	}
+	if meta.APIVersion == desiredAPIVersion {
+		return data, nil
+	}
+	var job *v1beta1.LighthouseJob
+	switch meta.APIVersion {
+	case v1alpha1.SchemeGroupVersion.String():
+		from := &v1alpha1.LighthouseJob{}
+		if err := json.Unmarshal(data, from); err != nil {
+			return nil, errors.Wrapf(err, "parsing the %s LighthouseJob", meta.APIVersion)
+		}
+		job = v1beta1.ConvertFromV1alpha1(from)
+	case v1beta1.SchemeGroupVersion.String():
+		job = &v1beta1.LighthouseJob{}
+		if err := json.Unmarshal(data, job); err != nil {
+			return nil, errors.Wrapf(err, "parsing the %s LighthouseJob", meta.APIVersion)
+		}
+	default:
+		return nil, errors.Errorf("unknown API version %s", meta.APIVersion)
+	}
+	switch desiredAPIVersion {
+	case v1alpha1.SchemeGroupVersion.String():
+		return json.Marshal(job.ConvertToV1alpha1())
+	case v1beta1.SchemeGroupVersion.String():
+		return json.Marshal(job)
+	default:
+		return nil, errors.Errorf("unknown API version %s", desiredAPIVersion)
+	}
+}
+
+func (c *Converter) logger() *logrus.Entry {
+	if c.Logger == nil {
+		return logrus.WithField("handler", "conversion")
+	}
+	return c.Logger
+}
+
+// ServeHTTP converts the LighthouseJobs of the ConversionReview
+func (c *Converter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "only POST is supported", http.StatusMethodNotAllowed)
+		return
+	}
+	data, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxReviewSize))
+	if err != nil {
+		http.Error(w, "failed to read the request", http.StatusBadRequest)
+		return
+	}
+	review := conversionReview{}
+	if err := json.Unmarshal(data, &review); err != nil || review.Request == nil {
+		http.Error(w, "expected a ConversionReview with a request", http.StatusBadRequest)
+		return
+	}
+	review.Response = c.convert(review.Request)
+	review.Request = nil
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(review); err != nil {
+		c.logger().WithError(err).Warn("failed to write the conversion response")
+	}
+}
+
+func (c *Converter) convert(request *conversionRequest) *conversionResponse {
+	response := &conversionResponse{
+		UID:    request.UID,
+		Result: metav1.Status{Status: metav1.StatusSuccess},
+	}
+	for i, object := range request.Objects {
+		converted, err := Convert(object.Raw, request.DesiredAPIVersion)
+		if err != nil {
+			c.logger().WithError(err).Warnf("failed to convert a LighthouseJob to %s", request.DesiredAPIVersion)
+			// the API server fails the whole request when any object cannot be converted
+			return &conversionResponse{
+				UID: request.UID,
+				Result: metav1.Status{
+					Status:  metav1.StatusFailure,
+					Message: fmt.Sprintf("converting object %d to %s: %v", i, request.DesiredAPIVersion, err),
+				},
+			}
+		}
+		response.ConvertedObjects = append(response.ConvertedObjects, runtime.RawExtension{Raw: converted})
+	}
+	return response
+}
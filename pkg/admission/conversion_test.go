@@ -0,0 +1,81 @@
+package admission
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestServeConversion(t *testing.T) {
+	v1alpha1Job := `{"apiVersion":"lighthouse.jenkins.io/v1alpha1","kind":"LighthouseJob","metadata":{"name":"job"},"spec":{"type":"presubmit","job":"unit","rerun_command":"/test unit","refs":{"org":"org","repo":"repo","base_ref":"master"}},"status":{"state":"pending"}}`
+	testCases := []struct {
+		name     string
+		objects  []string
+		desired  string
+		expected []string
+		failure  string
+	}{
+		{
+			name:     "to v1beta1",
+			objects:  []string{v1alpha1Job},
+			desired:  "lighthouse.jenkins.io/v1beta1",
+			expected: []string{`"rerunCommand":"/test unit"`, `"baseRef":"master"`, `"state":"pending"`},
+		},
+		{
+			name:     "back to v1alpha1",
+			objects:  []string{`{"apiVersion":"lighthouse.jenkins.io/v1beta1","kind":"LighthouseJob","metadata":{"name":"job"},"spec":{"type":"presubmit","job":"unit","rerunCommand":"/test unit","timeout":"1h0m0s"}}`},
+			desired:  "lighthouse.jenkins.io/v1alpha1",
+			expected: []string{`"rerun_command":"/test unit"`, `"timeout":"1h0m0s"`},
+		},
+		{
+			name:     "same version",
+			objects:  []string{v1alpha1Job},
+			desired:  "lighthouse.jenkins.io/v1alpha1",
+			expected: []string{`"rerun_command":"/test unit"`},
+		},
+		{
+			name:    "unknown version",
+			objects: []string{v1alpha1Job},
+			desired: "lighthouse.jenkins.io/v2",
+			failure: "converting object 0 to lighthouse.jenkins.io/v2: unknown API version lighthouse.jenkins.io/v2",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			request := &conversionRequest{UID: "123", DesiredAPIVersion: tc.desired}
+			for _, object := range tc.objects {
+				request.Objects = append(request.Objects, runtime.RawExtension{Raw: []byte(object)})
+			}
+			body, err := json.Marshal(conversionReview{Request: request})
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			(&Converter{}).ServeHTTP(rr, httptest.NewRequest(http.MethodPost, ConversionPath, bytes.NewReader(body)))
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			review := conversionReview{}
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &review))
+			require.NotNil(t, review.Response)
+			assert.Equal(t, "123", string(review.Response.UID))
+			if tc.failure != "" {
+				assert.Equal(t, metav1.StatusFailure, review.Response.Result.Status)
+				assert.Equal(t, tc.failure, review.Response.Result.Message)
+				return
+			}
+			assert.Equal(t, metav1.StatusSuccess, review.Response.Result.Status)
+			require.Len(t, review.Response.ConvertedObjects, 1)
+			converted := string(review.Response.ConvertedObjects[0].Raw)
+			assert.Contains(t, converted, `"apiVersion":"`+tc.desired+`"`)
+			for _, expected := range tc.expected {
+				assert.Contains(t, converted, expected)
+			}
+		})
+	}
+}
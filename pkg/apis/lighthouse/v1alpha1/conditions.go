@@ -0,0 +1,143 @@
+package v1alpha1
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// JobConditionType is the type of a condition of a LighthouseJob
+type JobConditionType string
+
+const (
+	// JobScheduled is true once the pipeline, pod or build of the job has been created
+	JobScheduled JobConditionType = "Scheduled"
+	// JobStarted is true once the job has started running
+	JobStarted JobConditionType = "Started"
+	// JobCompleted is true once the job has reached a final state, which is the reason of the condition
+	JobCompleted JobConditionType = "Completed"
+	// JobReported is true once the state of the job has been reported to the SCM provider, and false if reporting
+	// it failed, so that the report is retried
+	JobReported JobConditionType = "Reported"
+)
+
+const (
+	// ReportedReason is the reason of the Reported condition of the jobs whose state was reported
+	ReportedReason = "Reported"
+	// ReportFailedReason is the reason of the Reported condition of the jobs whose state failed to be reported
+	ReportFailedReason = "ReportFailed"
+)
+
+// JobCondition is a condition of a LighthouseJob
+type JobCondition struct {
+	// Type is the type of the condition
+	Type JobConditionType `json:"type"`
+	// Status is True, False or Unknown
+	Status corev1.ConditionStatus `json:"status"`
+	// ObservedGeneration is the generation of the job the condition was set for
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// LastTransitionTime is the last time the status of the condition changed
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+	// Reason is a CamelCase reason for the status of the condition
+	Reason string `json:"reason,omitempty"`
+	// Message is a human readable description of the status of the condition
+	Message string `json:"message,omitempty"`
+}
+
+// GetCondition returns the condition of the given type, or nil if the job does not have it yet
+func (s *LighthouseJobStatus) GetCondition(conditionType JobConditionType) *JobCondition {
+	for i := range s.Conditions {
+		if s.Conditions[i].Type == conditionType {
+			return &s.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// ConditionStatus returns the status of the condition of the given type, which is unknown if the job does not
+// have it yet
+func (s *LighthouseJobStatus) ConditionStatus(conditionType JobConditionType) corev1.ConditionStatus {
+	if c := s.GetCondition(conditionType); c != nil {
+		return c.Status
+	}
+	return corev1.ConditionUnknown
+}
+
+// ReportFailed returns true if the last report of the state of the job to the SCM provider failed
+func (s *LighthouseJobStatus) ReportFailed() bool {
+	c := s.GetCondition(JobReported)
+	return c != nil && c.Status == corev1.ConditionFalse && c.Reason == ReportFailedReason
+}
+
+// SetCondition sets the condition of the given type, whose transition time only changes with its status, and
+// records the generation of the job the status was observed at
+func (j *LighthouseJob) SetCondition(conditionType JobConditionType, status corev1.ConditionStatus, reason, message string) {
+	j.Status.ObservedGeneration = j.Generation
+	c := j.Status.GetCondition(conditionType)
+	if c == nil {
+		j.Status.Conditions = append(j.Status.Conditions, JobCondition{Type: conditionType})
+		c = &j.Status.Conditions[len(j.Status.Conditions)-1]
+	}
+	if c.Status != status {
+		c.LastTransitionTime = metav1.Now()
+	}
+	c.Status = status
+	c.ObservedGeneration = j.Generation
+	c.Reason = reason
+	c.Message = message
+}
+
+// SetStateConditions sets the Scheduled, Started and Completed conditions from the state of the job
+func (j *LighthouseJob) SetStateConditions() {
+	state := j.Status.State
+	if state == "" {
+		return
+	}
+	reason := stateReason(state)
+	if state == TriggeredState {
+		j.SetCondition(JobScheduled, corev1.ConditionFalse, reason, j.Status.Description)
+	} else {
+		j.SetCondition(JobScheduled, corev1.ConditionTrue, "Scheduled", "")
+	}
+	switch state {
+	case RunningState, SuccessState, FailureState:
+		j.SetCondition(JobStarted, corev1.ConditionTrue, "Started", "")
+	case TriggeredState, PendingState:
+		j.SetCondition(JobStarted, corev1.ConditionFalse, reason, j.Status.Description)
+	default:
+		// a job aborted or errored may not have started, it keeps the condition it had
+		if j.Status.GetCondition(JobStarted) == nil {
+			j.SetCondition(JobStarted, corev1.ConditionFalse, reason, j.Status.Description)
+		}
+	}
+	if j.Status.CompletionTime != nil || finalState(state) {
+		j.SetCondition(JobCompleted, corev1.ConditionTrue, reason, j.Status.Description)
+	} else {
+		j.SetCondition(JobCompleted, corev1.ConditionFalse, reason, j.Status.Description)
+	}
+}
+
+// SetReported sets the Reported condition after reporting the given state of the job to the SCM provider, which
+// failed if err is not nil
+func (j *LighthouseJob) SetReported(state string, err error) {
+	if err != nil {
+		j.SetCondition(JobReported, corev1.ConditionFalse, ReportFailedReason, err.Error())
+		return
+	}
+	j.SetCondition(JobReported, corev1.ConditionTrue, ReportedReason, "Reported "+state)
+}
+
+func finalState(state PipelineState) bool {
+	switch state {
+	case SuccessState, FailureState, AbortedState, ErrorState:
+		return true
+	}
+	return false
+}
+
+// stateReason returns the CamelCase condition reason of a state, such as Success for success
+func stateReason(state PipelineState) string {
+	s := string(state)
+	return strings.ToUpper(s[:1]) + s[1:]
+}
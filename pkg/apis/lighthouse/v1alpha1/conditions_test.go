@@ -0,0 +1,61 @@
+package v1alpha1_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSetStateConditions(t *testing.T) {
+	job := &v1alpha1.LighthouseJob{ObjectMeta: metav1.ObjectMeta{Generation: 2}}
+	job.SetStateConditions()
+	assert.Empty(t, job.Status.Conditions)
+
+	job.Status.State = v1alpha1.TriggeredState
+	job.SetStateConditions()
+	assert.Equal(t, corev1.ConditionFalse, job.Status.ConditionStatus(v1alpha1.JobScheduled))
+
+	job.Status.State = v1alpha1.PendingState
+	job.SetStateConditions()
+	assert.Equal(t, corev1.ConditionTrue, job.Status.ConditionStatus(v1alpha1.JobScheduled))
+	assert.Equal(t, corev1.ConditionFalse, job.Status.ConditionStatus(v1alpha1.JobStarted))
+	assert.Equal(t, corev1.ConditionFalse, job.Status.ConditionStatus(v1alpha1.JobCompleted))
+	assert.Equal(t, corev1.ConditionUnknown, job.Status.ConditionStatus(v1alpha1.JobReported))
+
+	job.Status.State = v1alpha1.RunningState
+	job.SetStateConditions()
+	started := job.Status.GetCondition(v1alpha1.JobStarted)
+	require.NotNil(t, started)
+	assert.Equal(t, corev1.ConditionTrue, started.Status)
+	startedAt := started.LastTransitionTime
+
+	job.Status.State = v1alpha1.AbortedState
+	job.Status.Description = "Pipeline timed out"
+	job.SetStateConditions()
+	assert.Equal(t, startedAt, job.Status.GetCondition(v1alpha1.JobStarted).LastTransitionTime)
+	completed := job.Status.GetCondition(v1alpha1.JobCompleted)
+	require.NotNil(t, completed)
+	assert.Equal(t, corev1.ConditionTrue, completed.Status)
+	assert.Equal(t, "Aborted", completed.Reason)
+	assert.Equal(t, "Pipeline timed out", completed.Message)
+	assert.Equal(t, int64(2), completed.ObservedGeneration)
+	assert.Equal(t, int64(2), job.Status.ObservedGeneration)
+	assert.Len(t, job.Status.Conditions, 3)
+}
+
+func TestSetReported(t *testing.T) {
+	job := &v1alpha1.LighthouseJob{}
+	job.SetReported("success", errors.New("rate limited"))
+	assert.True(t, job.Status.ReportFailed())
+	assert.Equal(t, "rate limited", job.Status.GetCondition(v1alpha1.JobReported).Message)
+
+	job.SetReported("success", nil)
+	assert.False(t, job.Status.ReportFailed())
+	assert.Equal(t, corev1.ConditionTrue, job.Status.ConditionStatus(v1alpha1.JobReported))
+	assert.Equal(t, v1alpha1.ReportedReason, job.Status.GetCondition(v1alpha1.JobReported).Reason)
+}
@@ -0,0 +1,37 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// LighthouseConfig holds the jobs of the repositories of some orgs, which are merged into the config.yaml of the
+// lighthouse ConfigMap, so that each team can maintain the jobs of its orgs in its own namespace
+type LighthouseConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec LighthouseConfigSpec `json:"spec,omitempty"`
+}
+
+// LighthouseConfigSpec the spec of a LighthouseConfig
+type LighthouseConfigSpec struct {
+	// Orgs are the orgs, or org/repo repositories, the config may define presubmits and postsubmits for. An org
+	// can only be claimed by one LighthouseConfig.
+	Orgs []string `json:"orgs,omitempty"`
+	// Config holds the presubmits, postsubmits and periodics in the format of config.yaml
+	Config string `json:"config,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// LighthouseConfigList represents a list of LighthouseConfigs
+type LighthouseConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []LighthouseConfig `json:"items"`
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
 	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -35,10 +36,27 @@ const (
 
 	// AbortedState aborted
 	AbortedState PipelineState = "aborted"
+
+	// ErrorState pipeline could not be run, e.g. its pods never started
+	ErrorState PipelineState = "error"
+)
+
+// Agents which can run jobs.
+const (
+	// TektonAgent runs the job as a Jenkins X pipeline on Tekton
+	TektonAgent = config.TektonAgent
+
+	// JenkinsAgent runs the job on a Jenkins server
+	JenkinsAgent = "jenkins"
+
+	// KubernetesAgent runs the job's PodSpec as a plain pod
+	KubernetesAgent = "kubernetes"
 )
 
 // Environment variables to be added to the pipeline we kick off
 const (
+	// BuildIDEnv is the unique identifier of the build, added to decorated pods
+	BuildIDEnv = "BUILD_ID"
 	// JobSpecEnv is a legacy Prow variable with "type:(type)"
 	JobSpecEnv = "JOB_SPEC"
 	// JobNameEnv is the name of the job
@@ -59,6 +77,43 @@ const (
 	PullNumberEnv = "PULL_NUMBER"
 	// PullPullShaEnv is the pull request's sha
 	PullPullShaEnv = "PULL_PULL_SHA"
+	// PullChangedFilesEnv is the comma separated list of the files changed by the pull request, unset if they are
+	// unknown or too many to be passed
+	PullChangedFilesEnv = "PULL_CHANGED_FILES"
+	// PullChangesHashEnv is a hash of the paths and contents of the files changed by the pull request which are
+	// relevant to the job
+	PullChangesHashEnv = "PULL_CHANGES_HASH"
+	// JobEnvVersionEnv is the version of the contract of the environment variables the pipeline runs with, since
+	// version 2
+	JobEnvVersionEnv = "JOB_ENV_VERSION"
+	// RepoURLEnv is the URL of the repository we're building, since version 2
+	RepoURLEnv = "REPO_URL"
+	// PullAuthorEnv is the login of the author of the pull request, since version 2
+	PullAuthorEnv = "PULL_AUTHOR"
+	// PullLinkEnv is the URL of the pull request, since version 2
+	PullLinkEnv = "PULL_LINK"
+	// PromoteAppEnv is the application a promotion job promotes, only set for the jobs of /promote
+	PromoteAppEnv = "PROMOTE_APP"
+	// PromoteEnvironmentEnv is the environment a promotion job promotes to, only set for the jobs of /promote
+	PromoteEnvironmentEnv = "PROMOTE_ENVIRONMENT"
+	// IssueNumberEnv is the number of the issue whose command triggered the job, only set for the jobs triggered
+	// by issue comments
+	IssueNumberEnv = "ISSUE_NUMBER"
+	// IssueCommandEnv is the command which triggered the job, such as "/release patch", only set for the jobs
+	// triggered by issue comments
+	IssueCommandEnv = "ISSUE_COMMAND"
+
+	// DefaultJobEnvVersion is the version of the contract of the environment variables the jobs not pinning a
+	// version run with, which is the set of variables inherited from Prow
+	DefaultJobEnvVersion = 1
+	// LatestJobEnvVersion is the latest version of the contract of the environment variables. Version 2 adds
+	// JOB_ENV_VERSION, REPO_URL, PULL_AUTHOR and PULL_LINK to version 1. A version never changes once released, so
+	// that the pipelines relying on it keep working across upgrades.
+	LatestJobEnvVersion = 2
+
+	// MaxChangedFilesLength is the maximum length of the list of changed files passed to the pipelines, beyond which
+	// the list is left out and the pipelines should build everything
+	MaxChangedFilesLength = 32 * 1024
 )
 
 // +genclient
@@ -91,6 +146,17 @@ type LighthouseJobStatus struct {
 	LastReportState string `json:"lastReportState,omitempty"`
 	// LastCommitSHA is the commit that will be/has been reported to on the SCM provider
 	LastCommitSHA string `json:"lastCommitSHA,omitempty"`
+	// Retries is the number of times the pod of the job was recreated after being lost
+	Retries int `json:"retries,omitempty"`
+	// FailureClass is the name of the failure classification rule matching the logs of the failed job, if any
+	FailureClass string `json:"failureClass,omitempty"`
+	// Artifacts are the values the successful job published in its logs, such as binary sizes or image digests,
+	// keyed by the names of the artifacts
+	Artifacts map[string]string `json:"artifacts,omitempty"`
+	// Conditions are the Scheduled, Started, Completed and Reported conditions of the job
+	Conditions []JobCondition `json:"conditions,omitempty"`
+	// ObservedGeneration is the generation of the job the status was last updated for
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -124,6 +190,45 @@ type LighthouseJobSpec struct {
 	// MaxConcurrency restricts the total number of instances
 	// of this job that can run in parallel at once
 	MaxConcurrency int `json:"max_concurrency,omitempty"`
+	// Agent is the agent which runs the job, defaulting to tekton
+	Agent string `json:"agent,omitempty"`
+	// PipelineRef is the name of the Tekton Pipeline to run, instead of generating the pipeline
+	// from the repository's jenkins-x.yml
+	PipelineRef string `json:"pipeline_ref,omitempty"`
+	// PipelineParams are the parameters passed to the PipelineRef, whose values are templates
+	// evaluated against the event which triggered the job
+	PipelineParams map[string]string `json:"pipeline_params,omitempty"`
+	// PodSpec is the pod run for jobs using the kubernetes agent
+	PodSpec *corev1.PodSpec `json:"pod_spec,omitempty"`
+	// Timeout is how long the job may run before it is aborted
+	Timeout *Duration `json:"timeout,omitempty"`
+	// GracePeriod is how long the pods of a timed out job are given to terminate
+	// before they are killed
+	GracePeriod *Duration `json:"grace_period,omitempty"`
+	// ErrorOnEviction errors the job when its pod is evicted or lost with its node, rather than
+	// recreating the pod
+	ErrorOnEviction bool `json:"error_on_eviction,omitempty"`
+	// Environment is the environment a postsubmit deploys to, or a promotion job promotes the App to, such as
+	// staging or production
+	Environment string `json:"environment,omitempty"`
+	// App is the application a promotion job launched by /promote promotes to the Environment
+	App string `json:"app,omitempty"`
+	// IssueNumber is the number of the issue whose comment triggered the job, which reports its result as a
+	// comment on the issue rather than as a commit status
+	IssueNumber int `json:"issue_number,omitempty"`
+	// IssueCommand is the command of the issue comment which triggered the job
+	IssueCommand string `json:"issue_command,omitempty"`
+	// ServiceAccountName is the service account the job runs with, overriding the one of its pod spec
+	// and the defaults, such as a restricted service account for the jobs of untrusted pull requests
+	ServiceAccountName string `json:"service_account_name,omitempty"`
+	// EnvFromSecrets are the secrets of the namespace the environment variables of the job's pod are set from
+	EnvFromSecrets []string `json:"env_from_secrets,omitempty"`
+	// JobEnvVersion is the version of the contract of the environment variables the pipeline runs with,
+	// defaulting to the DefaultJobEnvVersion
+	JobEnvVersion int `json:"job_env_version,omitempty"`
+	// ConcurrencyGroup is the group of jobs of which only one may run at a time, whatever their repository
+	// or name, such as the jobs deploying to a shared environment
+	ConcurrencyGroup string `json:"concurrency_group,omitempty"`
 }
 
 // GetBranch returns the branch name corresponding to the refs on this spec.
@@ -141,12 +246,39 @@ func (s *LighthouseJobSpec) GetBranch() string {
 	return branch
 }
 
-// GetEnvVars gets a map of the environment variables we'll set in the pipeline for this spec.
+// GetSHA returns the commit SHA the job is building, which is the head of the pull request for presubmits.
+func (s *LighthouseJobSpec) GetSHA() string {
+	if s.Refs == nil {
+		return ""
+	}
+	if s.Type != config.PostsubmitJob && s.Type != config.BatchJob && len(s.Refs.Pulls) > 0 {
+		return s.Refs.Pulls[0].SHA
+	}
+	return s.Refs.BaseSHA
+}
+
+// GetJobEnvVersion returns the version of the contract of the environment variables the pipeline runs with
+func (s *LighthouseJobSpec) GetJobEnvVersion() int {
+	switch {
+	case s.JobEnvVersion <= 0:
+		return DefaultJobEnvVersion
+	case s.JobEnvVersion > LatestJobEnvVersion:
+		return LatestJobEnvVersion
+	}
+	return s.JobEnvVersion
+}
+
+// GetEnvVars gets a map of the environment variables we'll set in the pipeline for this spec, as defined by the
+// version of their contract.
 func (s *LighthouseJobSpec) GetEnvVars() map[string]string {
+	version := s.GetJobEnvVersion()
 	env := map[string]string{
 		JobNameEnv: s.Job,
 		JobTypeEnv: string(s.Type),
 	}
+	if version >= 2 {
+		env[JobEnvVersionEnv] = strconv.Itoa(version)
+	}
 
 	registry := os.Getenv("DOCKER_REGISTRY")
 	if registry != "" {
@@ -154,6 +286,14 @@ func (s *LighthouseJobSpec) GetEnvVars() map[string]string {
 	}
 
 	env[JobSpecEnv] = fmt.Sprintf("type:%s", s.Type)
+	if s.App != "" {
+		env[PromoteAppEnv] = s.App
+		env[PromoteEnvironmentEnv] = s.Environment
+	}
+	if s.IssueNumber != 0 {
+		env[IssueNumberEnv] = strconv.Itoa(s.IssueNumber)
+		env[IssueCommandEnv] = s.IssueCommand
+	}
 
 	if s.Type == config.PeriodicJob {
 		return env
@@ -164,6 +304,12 @@ func (s *LighthouseJobSpec) GetEnvVars() map[string]string {
 	env[PullBaseRefEnv] = s.Refs.BaseRef
 	env[PullBaseShaEnv] = s.Refs.BaseSHA
 	env[PullRefsEnv] = s.Refs.String()
+	if version >= 2 {
+		env[RepoURLEnv] = s.Refs.CloneURI
+		if env[RepoURLEnv] == "" {
+			env[RepoURLEnv] = s.Refs.RepoLink
+		}
+	}
 
 	if s.Type == config.PostsubmitJob || s.Type == config.BatchJob {
 		return env
@@ -171,6 +317,16 @@ func (s *LighthouseJobSpec) GetEnvVars() map[string]string {
 
 	env[PullNumberEnv] = strconv.Itoa(s.Refs.Pulls[0].Number)
 	env[PullPullShaEnv] = s.Refs.Pulls[0].SHA
+	if files := s.Refs.Pulls[0].ChangedFilesList(); files != "" {
+		env[PullChangedFilesEnv] = files
+	}
+	if hash := s.Refs.Pulls[0].ChangesHash; hash != "" {
+		env[PullChangesHashEnv] = hash
+	}
+	if version >= 2 {
+		env[PullAuthorEnv] = s.Refs.Pulls[0].Author
+		env[PullLinkEnv] = s.Refs.Pulls[0].Link
+	}
 
 	return env
 }
@@ -271,6 +427,21 @@ type Pull struct {
 	CommitLink string `json:"commit_link,omitempty"`
 	// AuthorLink links to the author of the pull request.
 	AuthorLink string `json:"author_link,omitempty"`
+	// ChangedFiles are the files changed by the pull request, only populated when a job needs them.
+	ChangedFiles []string `json:"changed_files,omitempty"`
+	// ChangesHash is a hash of the paths and contents of the changed files relevant to the job, which stays the same
+	// as long as they do so that pipelines can reuse the builds of unchanged modules.
+	ChangesHash string `json:"changes_hash,omitempty"`
+}
+
+// ChangedFilesList returns the comma separated list of the changed files, or an empty string if it is longer than
+// MaxChangedFilesLength.
+func (p *Pull) ChangedFilesList() string {
+	files := strings.Join(p.ChangedFiles, ",")
+	if len(files) > MaxChangedFilesLength {
+		return ""
+	}
+	return files
 }
 
 // Refs describes how the repo was constructed.
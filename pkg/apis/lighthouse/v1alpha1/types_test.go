@@ -3,6 +3,7 @@ package v1alpha1_test
 import (
 	"fmt"
 	"os"
+	"strconv"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -85,6 +86,153 @@ func TestPipelineOptionsSpec_GetEnvVars(t *testing.T) {
 				v1alpha1.PullPullShaEnv: "5678",
 			},
 		},
+		{
+			name: "presubmit with changed files",
+			spec: &v1alpha1.LighthouseJobSpec{
+				Type:      config.PresubmitJob,
+				Namespace: "jx",
+				Job:       "some-pr-job",
+				Refs: &v1alpha1.Refs{
+					Org:     "some-org",
+					Repo:    "some-repo",
+					BaseRef: "master",
+					BaseSHA: "1234abcd",
+					Pulls: []v1alpha1.Pull{
+						{
+							Number:       1,
+							SHA:          "5678",
+							ChangedFiles: []string{"api/main.go", "web/index.html"},
+							ChangesHash:  "9abc",
+						},
+					},
+				},
+			},
+			env: map[string]string{
+				v1alpha1.JobNameEnv:          "some-pr-job",
+				v1alpha1.JobTypeEnv:          string(config.PresubmitJob),
+				v1alpha1.JobSpecEnv:          fmt.Sprintf("type:%s", config.PresubmitJob),
+				v1alpha1.RepoNameEnv:         "some-repo",
+				v1alpha1.RepoOwnerEnv:        "some-org",
+				v1alpha1.PullBaseRefEnv:      "master",
+				v1alpha1.PullBaseShaEnv:      "1234abcd",
+				v1alpha1.PullRefsEnv:         "master:1234abcd,1:5678",
+				v1alpha1.PullNumberEnv:       "1",
+				v1alpha1.PullPullShaEnv:      "5678",
+				v1alpha1.PullChangedFilesEnv: "api/main.go,web/index.html",
+				v1alpha1.PullChangesHashEnv:  "9abc",
+			},
+		},
+		{
+			name: "presubmit with version 2",
+			spec: &v1alpha1.LighthouseJobSpec{
+				Type:          config.PresubmitJob,
+				Namespace:     "jx",
+				Job:           "some-pr-job",
+				JobEnvVersion: 2,
+				Refs: &v1alpha1.Refs{
+					Org:      "some-org",
+					Repo:     "some-repo",
+					RepoLink: "https://github.com/some-org/some-repo",
+					BaseRef:  "master",
+					BaseSHA:  "1234abcd",
+					Pulls: []v1alpha1.Pull{
+						{
+							Number: 1,
+							SHA:    "5678",
+							Author: "some-user",
+							Link:   "https://github.com/some-org/some-repo/pull/1",
+						},
+					},
+				},
+			},
+			env: map[string]string{
+				v1alpha1.JobNameEnv:       "some-pr-job",
+				v1alpha1.JobTypeEnv:       string(config.PresubmitJob),
+				v1alpha1.JobSpecEnv:       fmt.Sprintf("type:%s", config.PresubmitJob),
+				v1alpha1.JobEnvVersionEnv: "2",
+				v1alpha1.RepoNameEnv:      "some-repo",
+				v1alpha1.RepoOwnerEnv:     "some-org",
+				v1alpha1.RepoURLEnv:       "https://github.com/some-org/some-repo",
+				v1alpha1.PullBaseRefEnv:   "master",
+				v1alpha1.PullBaseShaEnv:   "1234abcd",
+				v1alpha1.PullRefsEnv:      "master:1234abcd,1:5678",
+				v1alpha1.PullNumberEnv:    "1",
+				v1alpha1.PullPullShaEnv:   "5678",
+				v1alpha1.PullAuthorEnv:    "some-user",
+				v1alpha1.PullLinkEnv:      "https://github.com/some-org/some-repo/pull/1",
+			},
+		},
+		{
+			name: "promotion",
+			spec: &v1alpha1.LighthouseJobSpec{
+				Type:        config.PostsubmitJob,
+				Namespace:   "jx",
+				Job:         "promote",
+				Environment: "staging",
+				App:         "some-app",
+				Refs: &v1alpha1.Refs{
+					Org:     "some-org",
+					Repo:    "some-repo",
+					BaseRef: "master",
+					BaseSHA: "1234abcd",
+				},
+			},
+			env: map[string]string{
+				v1alpha1.JobNameEnv:            "promote",
+				v1alpha1.JobTypeEnv:            string(config.PostsubmitJob),
+				v1alpha1.JobSpecEnv:            fmt.Sprintf("type:%s", config.PostsubmitJob),
+				v1alpha1.PromoteAppEnv:         "some-app",
+				v1alpha1.PromoteEnvironmentEnv: "staging",
+				v1alpha1.RepoNameEnv:           "some-repo",
+				v1alpha1.RepoOwnerEnv:          "some-org",
+				v1alpha1.PullBaseRefEnv:        "master",
+				v1alpha1.PullBaseShaEnv:        "1234abcd",
+				v1alpha1.PullRefsEnv:           "master:1234abcd",
+			},
+		},
+		{
+			name: "issue command",
+			spec: &v1alpha1.LighthouseJobSpec{
+				Type:         config.PostsubmitJob,
+				Namespace:    "jx",
+				Job:          "release",
+				IssueNumber:  12,
+				IssueCommand: "/release patch",
+				Refs: &v1alpha1.Refs{
+					Org:     "some-org",
+					Repo:    "some-repo",
+					BaseRef: "master",
+					BaseSHA: "1234abcd",
+				},
+			},
+			env: map[string]string{
+				v1alpha1.JobNameEnv:      "release",
+				v1alpha1.JobTypeEnv:      string(config.PostsubmitJob),
+				v1alpha1.JobSpecEnv:      fmt.Sprintf("type:%s", config.PostsubmitJob),
+				v1alpha1.IssueNumberEnv:  "12",
+				v1alpha1.IssueCommandEnv: "/release patch",
+				v1alpha1.RepoNameEnv:     "some-repo",
+				v1alpha1.RepoOwnerEnv:    "some-org",
+				v1alpha1.PullBaseRefEnv:  "master",
+				v1alpha1.PullBaseShaEnv:  "1234abcd",
+				v1alpha1.PullRefsEnv:     "master:1234abcd",
+			},
+		},
+		{
+			name: "periodic with an unknown version",
+			spec: &v1alpha1.LighthouseJobSpec{
+				Type:          config.PeriodicJob,
+				Namespace:     "jx",
+				Job:           "some-job",
+				JobEnvVersion: 99,
+			},
+			env: map[string]string{
+				v1alpha1.JobNameEnv:       "some-job",
+				v1alpha1.JobTypeEnv:       string(config.PeriodicJob),
+				v1alpha1.JobSpecEnv:       fmt.Sprintf("type:%s", config.PeriodicJob),
+				v1alpha1.JobEnvVersionEnv: strconv.Itoa(v1alpha1.LatestJobEnvVersion),
+			},
+		},
 		{
 			name: "batch",
 			spec: &v1alpha1.LighthouseJobSpec{
@@ -143,3 +291,45 @@ func TestPipelineOptionsSpec_GetEnvVars(t *testing.T) {
 		})
 	}
 }
+
+func TestLighthouseJobSpec_GetSHA(t *testing.T) {
+	refs := &v1alpha1.Refs{
+		Org:     "some-org",
+		Repo:    "some-repo",
+		BaseRef: "master",
+		BaseSHA: "1234abcd",
+		Pulls:   []v1alpha1.Pull{{Number: 1, SHA: "5678efgh"}},
+	}
+	tests := []struct {
+		name string
+		spec *v1alpha1.LighthouseJobSpec
+		sha  string
+	}{
+		{
+			name: "periodic",
+			spec: &v1alpha1.LighthouseJobSpec{Type: config.PeriodicJob},
+		},
+		{
+			name: "presubmit",
+			spec: &v1alpha1.LighthouseJobSpec{Type: config.PresubmitJob, Refs: refs},
+			sha:  "5678efgh",
+		},
+		{
+			name: "postsubmit",
+			spec: &v1alpha1.LighthouseJobSpec{Type: config.PostsubmitJob, Refs: refs},
+			sha:  "1234abcd",
+		},
+		{
+			name: "batch",
+			spec: &v1alpha1.LighthouseJobSpec{Type: config.BatchJob, Refs: refs},
+			sha:  "1234abcd",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if sha := tt.spec.GetSHA(); sha != tt.sha {
+				t.Errorf("expected SHA %q but got %q", tt.sha, sha)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package v1beta1
+
+import (
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ConvertFromV1alpha1 converts a v1alpha1 LighthouseJob to v1beta1
+func ConvertFromV1alpha1(in *v1alpha1.LighthouseJob) *LighthouseJob {
+	in = in.DeepCopy()
+	out := &LighthouseJob{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: SchemeGroupVersion.String(),
+			Kind:       "LighthouseJob",
+		},
+		ObjectMeta: in.ObjectMeta,
+		Spec: LighthouseJobSpec{
+			Type:               in.Spec.Type,
+			Namespace:          in.Spec.Namespace,
+			Job:                in.Spec.Job,
+			Context:            in.Spec.Context,
+			RerunCommand:       in.Spec.RerunCommand,
+			MaxConcurrency:     in.Spec.MaxConcurrency,
+			Agent:              in.Spec.Agent,
+			PipelineRef:        in.Spec.PipelineRef,
+			PipelineParams:     in.Spec.PipelineParams,
+			PodSpec:            in.Spec.PodSpec,
+			Timeout:            durationFromV1alpha1(in.Spec.Timeout),
+			GracePeriod:        durationFromV1alpha1(in.Spec.GracePeriod),
+			ErrorOnEviction:    in.Spec.ErrorOnEviction,
+			Environment:        in.Spec.Environment,
+			App:                in.Spec.App,
+			IssueNumber:        in.Spec.IssueNumber,
+			IssueCommand:       in.Spec.IssueCommand,
+			ServiceAccountName: in.Spec.ServiceAccountName,
+			EnvFromSecrets:     in.Spec.EnvFromSecrets,
+			JobEnvVersion:      in.Spec.JobEnvVersion,
+			ConcurrencyGroup:   in.Spec.ConcurrencyGroup,
+		},
+		Status: LighthouseJobStatus(in.Status),
+	}
+	if in.Spec.Refs != nil {
+		refs := Refs{
+			Org:            in.Spec.Refs.Org,
+			Repo:           in.Spec.Refs.Repo,
+			RepoLink:       in.Spec.Refs.RepoLink,
+			BaseRef:        in.Spec.Refs.BaseRef,
+			BaseSHA:        in.Spec.Refs.BaseSHA,
+			BaseLink:       in.Spec.Refs.BaseLink,
+			PathAlias:      in.Spec.Refs.PathAlias,
+			CloneURI:       in.Spec.Refs.CloneURI,
+			SkipSubmodules: in.Spec.Refs.SkipSubmodules,
+			CloneDepth:     in.Spec.Refs.CloneDepth,
+		}
+		for _, pull := range in.Spec.Refs.Pulls {
+			refs.Pulls = append(refs.Pulls, Pull(pull))
+		}
+		out.Spec.Refs = &refs
+	}
+	return out
+}
+
+// ConvertToV1alpha1 converts the LighthouseJob to v1alpha1
+func (in *LighthouseJob) ConvertToV1alpha1() *v1alpha1.LighthouseJob {
+	in = in.DeepCopy()
+	out := &v1alpha1.LighthouseJob{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: v1alpha1.SchemeGroupVersion.String(),
+			Kind:       "LighthouseJob",
+		},
+		ObjectMeta: in.ObjectMeta,
+		Spec: v1alpha1.LighthouseJobSpec{
+			Type:               in.Spec.Type,
+			Namespace:          in.Spec.Namespace,
+			Job:                in.Spec.Job,
+			Context:            in.Spec.Context,
+			RerunCommand:       in.Spec.RerunCommand,
+			MaxConcurrency:     in.Spec.MaxConcurrency,
+			Agent:              in.Spec.Agent,
+			PipelineRef:        in.Spec.PipelineRef,
+			PipelineParams:     in.Spec.PipelineParams,
+			PodSpec:            in.Spec.PodSpec,
+			Timeout:            durationToV1alpha1(in.Spec.Timeout),
+			GracePeriod:        durationToV1alpha1(in.Spec.GracePeriod),
+			ErrorOnEviction:    in.Spec.ErrorOnEviction,
+			Environment:        in.Spec.Environment,
+			App:                in.Spec.App,
+			IssueNumber:        in.Spec.IssueNumber,
+			IssueCommand:       in.Spec.IssueCommand,
+			ServiceAccountName: in.Spec.ServiceAccountName,
+			EnvFromSecrets:     in.Spec.EnvFromSecrets,
+			JobEnvVersion:      in.Spec.JobEnvVersion,
+			ConcurrencyGroup:   in.Spec.ConcurrencyGroup,
+		},
+		Status: v1alpha1.LighthouseJobStatus(in.Status),
+	}
+	if in.Spec.Refs != nil {
+		refs := v1alpha1.Refs{
+			Org:            in.Spec.Refs.Org,
+			Repo:           in.Spec.Refs.Repo,
+			RepoLink:       in.Spec.Refs.RepoLink,
+			BaseRef:        in.Spec.Refs.BaseRef,
+			BaseSHA:        in.Spec.Refs.BaseSHA,
+			BaseLink:       in.Spec.Refs.BaseLink,
+			PathAlias:      in.Spec.Refs.PathAlias,
+			CloneURI:       in.Spec.Refs.CloneURI,
+			SkipSubmodules: in.Spec.Refs.SkipSubmodules,
+			CloneDepth:     in.Spec.Refs.CloneDepth,
+		}
+		for _, pull := range in.Spec.Refs.Pulls {
+			refs.Pulls = append(refs.Pulls, v1alpha1.Pull(pull))
+		}
+		out.Spec.Refs = &refs
+	}
+	return out
+}
+
+func durationFromV1alpha1(d *v1alpha1.Duration) *metav1.Duration {
+	if d == nil {
+		return nil
+	}
+	return &metav1.Duration{Duration: d.Duration}
+}
+
+func durationToV1alpha1(d *metav1.Duration) *v1alpha1.Duration {
+	if d == nil {
+		return nil
+	}
+	return &v1alpha1.Duration{Duration: d.Duration}
+}
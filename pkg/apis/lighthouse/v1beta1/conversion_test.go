@@ -0,0 +1,61 @@
+package v1beta1
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestConversionRoundTrip(t *testing.T) {
+	completed := metav1.NewTime(time.Date(2020, 6, 15, 12, 0, 0, 0, time.UTC))
+	job := &v1alpha1.LighthouseJob{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "lighthouse.jenkins.io/v1alpha1", Kind: "LighthouseJob"},
+		ObjectMeta: metav1.ObjectMeta{Name: "job", Namespace: "jx", Labels: map[string]string{"a": "b"}},
+		Spec: v1alpha1.LighthouseJobSpec{
+			Type:             config.PresubmitJob,
+			Namespace:        "jx",
+			Job:              "unit",
+			Context:          "unit",
+			RerunCommand:     "/test unit",
+			Agent:            "kubernetes",
+			PipelineParams:   map[string]string{"x": "y"},
+			PodSpec:          &corev1.PodSpec{Containers: []corev1.Container{{Image: "golang"}}},
+			Timeout:          &v1alpha1.Duration{Duration: time.Hour},
+			EnvFromSecrets:   []string{"token"},
+			ConcurrencyGroup: "deploy-staging",
+			Refs: &v1alpha1.Refs{
+				Org:     "org",
+				Repo:    "repo",
+				BaseRef: "master",
+				BaseSHA: "abc",
+				Pulls:   []v1alpha1.Pull{{Number: 1, Author: "someone", SHA: "def", ChangedFiles: []string{"main.go"}}},
+			},
+		},
+		Status: v1alpha1.LighthouseJobStatus{
+			State:          v1alpha1.SuccessState,
+			CompletionTime: &completed,
+			LastCommitSHA:  "def",
+		},
+	}
+
+	converted := ConvertFromV1alpha1(job)
+	assert.Equal(t, "lighthouse.jenkins.io/v1beta1", converted.APIVersion)
+	assert.Equal(t, time.Hour, converted.Spec.Timeout.Duration)
+	assert.Nil(t, converted.Spec.GracePeriod)
+	assert.Equal(t, "def", converted.Spec.Refs.Pulls[0].SHA)
+
+	data, err := json.Marshal(converted)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"rerunCommand":"/test unit"`)
+	assert.Contains(t, string(data), `"baseRef":"master"`)
+	assert.Contains(t, string(data), `"changedFiles":["main.go"]`)
+
+	assert.Equal(t, job, converted.ConvertToV1alpha1())
+}
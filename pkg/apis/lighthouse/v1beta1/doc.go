@@ -0,0 +1,6 @@
+// Package v1beta1 contains the v1beta1 version of LighthouseJob, whose fields follow the Kubernetes API conventions.
+// LighthouseJobs are stored as v1alpha1 and converted by the conversion webhook.
+// +k8s:openapi-gen=true
+// +k8s:deepcopy-gen=package,register
+// +groupName=lighthouse.jenkins.io
+package v1beta1
@@ -0,0 +1,166 @@
+package v1beta1
+
+import (
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// LighthouseJob contains the arguments to create a Jenkins X Pipeline and to report on it
+type LighthouseJob struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   LighthouseJobSpec   `json:"spec,omitempty"`
+	Status LighthouseJobStatus `json:"status,omitempty"`
+}
+
+// LighthouseJobSpec the spec of a pipeline request
+type LighthouseJobSpec struct {
+	// Type is the type of job and informs how the job is triggered
+	Type config.PipelineKind `json:"type,omitempty"`
+	// Namespace defines where to create pods/resources
+	Namespace string `json:"namespace,omitempty"`
+	// Job is the name of the job
+	Job string `json:"job,omitempty"`
+	// Refs is the code under test
+	Refs *Refs `json:"refs,omitempty"`
+	// Context is the name of the status context used to report back to the SCM provider
+	Context string `json:"context,omitempty"`
+	// RerunCommand is the command a user would write to trigger this job on their pull request
+	RerunCommand string `json:"rerunCommand,omitempty"`
+	// MaxConcurrency restricts the total number of instances of this job that can run in parallel at once
+	MaxConcurrency int `json:"maxConcurrency,omitempty"`
+	// Agent is the agent which runs the job, defaulting to tekton
+	Agent string `json:"agent,omitempty"`
+	// PipelineRef is the name of the Tekton Pipeline to run, instead of generating the pipeline from the
+	// repository's jenkins-x.yml
+	PipelineRef string `json:"pipelineRef,omitempty"`
+	// PipelineParams are the parameters passed to the PipelineRef, whose values are templates evaluated against
+	// the event which triggered the job
+	PipelineParams map[string]string `json:"pipelineParams,omitempty"`
+	// PodSpec is the pod run for jobs using the kubernetes agent
+	PodSpec *corev1.PodSpec `json:"podSpec,omitempty"`
+	// Timeout is how long the job may run before it is aborted
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+	// GracePeriod is how long the pods of a timed out job are given to terminate before they are killed
+	GracePeriod *metav1.Duration `json:"gracePeriod,omitempty"`
+	// ErrorOnEviction errors the job when its pod is evicted or lost with its node, rather than recreating the pod
+	ErrorOnEviction bool `json:"errorOnEviction,omitempty"`
+	// Environment is the environment a postsubmit deploys to, or a promotion job promotes the App to, such as
+	// staging or production
+	Environment string `json:"environment,omitempty"`
+	// App is the application a promotion job launched by /promote promotes to the Environment
+	App string `json:"app,omitempty"`
+	// IssueNumber is the number of the issue whose comment triggered the job, which reports its result as a
+	// comment on the issue rather than as a commit status
+	IssueNumber int `json:"issueNumber,omitempty"`
+	// IssueCommand is the command of the issue comment which triggered the job
+	IssueCommand string `json:"issueCommand,omitempty"`
+	// ServiceAccountName is the service account the job runs with, overriding the one of its pod spec and the
+	// defaults
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+	// EnvFromSecrets are the secrets of the namespace the environment variables of the job's pod are set from
+	EnvFromSecrets []string `json:"envFromSecrets,omitempty"`
+	// JobEnvVersion is the version of the contract of the environment variables the pipeline runs with,
+	// defaulting to version 1
+	JobEnvVersion int `json:"jobEnvVersion,omitempty"`
+	// ConcurrencyGroup is the group of jobs of which only one may run at a time, whatever their repository or name,
+	// such as the jobs deploying to a shared environment
+	ConcurrencyGroup string `json:"concurrencyGroup,omitempty"`
+}
+
+// LighthouseJobStatus represents the status of a pipeline
+type LighthouseJobStatus struct {
+	// State is the full state of the job
+	State v1alpha1.PipelineState `json:"state,omitempty"`
+	// ActivityName is the name of the PipelineActivity associated with this job, if any
+	ActivityName string `json:"activityName,omitempty"`
+	// Description is used for the description of the commit status we report
+	Description string `json:"description,omitempty"`
+	// ReportURL is the link that will be used in the commit status
+	ReportURL string `json:"reportURL,omitempty"`
+	// StartTime is when the job was created
+	StartTime metav1.Time `json:"startTime,omitempty"`
+	// CompletionTime is when the job finished reconciling and entered a terminal state
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	// LastReportState is the state from the last time we reported commit status for this job
+	LastReportState string `json:"lastReportState,omitempty"`
+	// LastCommitSHA is the commit that will be/has been reported to on the SCM provider
+	LastCommitSHA string `json:"lastCommitSHA,omitempty"`
+	// Retries is the number of times the pod of the job was recreated after being lost
+	Retries int `json:"retries,omitempty"`
+	// FailureClass is the name of the failure classification rule matching the logs of the failed job, if any
+	FailureClass string `json:"failureClass,omitempty"`
+	// Artifacts are the values the successful job published in its logs, such as binary sizes or image digests,
+	// keyed by the names of the artifacts
+	Artifacts map[string]string `json:"artifacts,omitempty"`
+	// Conditions are the Scheduled, Started, Completed and Reported conditions of the job
+	Conditions []JobCondition `json:"conditions,omitempty"`
+	// ObservedGeneration is the generation of the job the status was last updated for
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// JobCondition is a condition of a LighthouseJob, which is the same in both versions
+type JobCondition = v1alpha1.JobCondition
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// LighthouseJobList represents a list of pipeline options
+type LighthouseJobList struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []LighthouseJob `json:"items"`
+}
+
+// Refs describes how the repo was constructed
+type Refs struct {
+	// Org is something like kubernetes or k8s.io
+	Org string `json:"org"`
+	// Repo is something like test-infra
+	Repo string `json:"repo"`
+	// RepoLink links to the source for Repo
+	RepoLink string `json:"repoLink,omitempty"`
+
+	BaseRef string `json:"baseRef,omitempty"`
+	BaseSHA string `json:"baseSHA,omitempty"`
+	// BaseLink is a link to the commit identified by BaseSHA
+	BaseLink string `json:"baseLink,omitempty"`
+
+	Pulls []Pull `json:"pulls,omitempty"`
+
+	// PathAlias is the location under <root-dir>/src where this repository is cloned
+	PathAlias string `json:"pathAlias,omitempty"`
+	// CloneURI is the URI that is used to clone the repository
+	CloneURI string `json:"cloneURI,omitempty"`
+	// SkipSubmodules determines if submodules should be cloned when the job is run
+	SkipSubmodules bool `json:"skipSubmodules,omitempty"`
+	// CloneDepth is the depth of the clone that will be used, zero for a full clone
+	CloneDepth int `json:"cloneDepth,omitempty"`
+}
+
+// Pull describes a pull request at a particular point in time
+type Pull struct {
+	Number int    `json:"number"`
+	Author string `json:"author"`
+	SHA    string `json:"sha"`
+	Title  string `json:"title,omitempty"`
+
+	// Ref is git ref can be checked out for a change, such as pull/123/head
+	Ref string `json:"ref,omitempty"`
+	// Link links to the pull request itself
+	Link string `json:"link,omitempty"`
+	// CommitLink links to the commit identified by the SHA
+	CommitLink string `json:"commitLink,omitempty"`
+	// AuthorLink links to the author of the pull request
+	AuthorLink string `json:"authorLink,omitempty"`
+	// ChangedFiles are the files changed by the pull request, only populated when a job needs them
+	ChangedFiles []string `json:"changedFiles,omitempty"`
+	// ChangesHash is a hash of the paths and contents of the changed files relevant to the job
+	ChangesHash string `json:"changesHash,omitempty"`
+}
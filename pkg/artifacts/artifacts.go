@@ -0,0 +1,289 @@
+// Package artifacts compares the artifacts published by the jobs of pull requests, such as binary sizes, bundle
+// sizes or image digests, with those published by the last postsubmits of their base branch. A job publishes an
+// artifact by printing a line in its logs:
+//
+//	lighthouse-artifact: size/hook=48213504
+//	lighthouse-artifact: image/hook=sha256:4f1b...
+//
+// Numeric values are compared as such, the increases beyond the thresholds configured in the artifact_diff section
+// of config.yaml being flagged as regressions, while the other values are only reported as changed.
+package artifacts
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// Prefix starts the lines of the logs publishing an artifact, followed by its name=value
+	Prefix = "lighthouse-artifact:"
+
+	// DefaultTailLines is the number of lines at the end of the logs of a job which are scanned by default
+	DefaultTailLines = 1000
+)
+
+// namePattern matches the valid names of artifacts
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._/:-]*$`)
+
+// Config holds the thresholds of the artifacts, read from the artifact_diff section of config.yaml. The artifacts
+// are only compared once the section is present:
+//
+//	artifact_diff:
+//	  tail_lines: 2000
+//	  thresholds:
+//	  - pattern: '^size/'
+//	    max_increase_percent: 5
+//	  - pattern: '^bundle/'
+//	    max_increase: 102400
+type Config struct {
+	// TailLines is the number of lines at the end of the logs which are scanned, DefaultTailLines if zero
+	TailLines int `json:"tail_lines,omitempty"`
+	// Thresholds are the increases of the numeric artifacts which are flagged, the first threshold whose pattern
+	// matches the name of an artifact applying to it
+	Thresholds []Threshold `json:"thresholds,omitempty"`
+}
+
+// Threshold is the largest increase allowed for the numeric artifacts whose names match a regular expression. An
+// increase is flagged as a regression if it exceeds either limit.
+type Threshold struct {
+	// Pattern is the regular expression matched against the names of the artifacts
+	Pattern string `json:"pattern"`
+	// MaxIncrease is the largest absolute increase, unlimited if zero
+	MaxIncrease float64 `json:"max_increase,omitempty"`
+	// MaxIncreasePercent is the largest increase relative to the value of the base branch, unlimited if zero
+	MaxIncreasePercent float64 `json:"max_increase_percent,omitempty"`
+
+	re *regexp.Regexp
+}
+
+// LoadConfig reads the Config from the artifact_diff section of the text of config.yaml, returning nil if the
+// artifacts are not compared
+func LoadConfig(data []byte) (*Config, error) {
+	answer := struct {
+		ArtifactDiff *Config `json:"artifact_diff,omitempty"`
+	}{}
+	if err := yaml.Unmarshal(data, &answer); err != nil {
+		return nil, errors.Wrap(err, "parsing the artifact diff")
+	}
+	c := answer.ArtifactDiff
+	if c == nil {
+		return nil, nil
+	}
+	if err := c.compile(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Config) compile() error {
+	if c.TailLines < 0 {
+		return errors.Errorf("artifact diff tail_lines %d is negative", c.TailLines)
+	}
+	for i := range c.Thresholds {
+		t := &c.Thresholds[i]
+		if t.MaxIncrease < 0 || t.MaxIncreasePercent < 0 {
+			return errors.Errorf("artifact diff threshold %d has a negative maximum increase", i)
+		}
+		re, err := regexp.Compile(t.Pattern)
+		if err != nil {
+			return errors.Wrapf(err, "compiling the pattern of artifact diff threshold %d", i)
+		}
+		t.re = re
+	}
+	return nil
+}
+
+// compiled compiles the thresholds of a Config which was not loaded with LoadConfig
+func (c *Config) compiled() error {
+	for _, t := range c.Thresholds {
+		if t.re == nil {
+			c.Thresholds = append([]Threshold(nil), c.Thresholds...)
+			return c.compile()
+		}
+	}
+	return nil
+}
+
+// Lines returns the number of lines at the end of the logs which are scanned
+func (c *Config) Lines() int {
+	if c.TailLines > 0 {
+		return c.TailLines
+	}
+	return DefaultTailLines
+}
+
+// threshold returns the threshold of the artifact, or nil if its increases are not limited
+func (c *Config) threshold(name string) *Threshold {
+	for i := range c.Thresholds {
+		if c.Thresholds[i].re.MatchString(name) {
+			return &c.Thresholds[i]
+		}
+	}
+	return nil
+}
+
+// Parse returns the artifacts published in the logs, the last value of an artifact published several times
+// winning. The lines with an invalid name are ignored.
+func Parse(logs io.Reader) (map[string]string, error) {
+	answer := map[string]string{}
+	scanner := bufio.NewScanner(logs)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		i := strings.Index(line, Prefix)
+		if i < 0 {
+			continue
+		}
+		parts := strings.SplitN(strings.TrimSpace(line[i+len(Prefix):]), "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		name, value := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		if namePattern.MatchString(name) && value != "" {
+			answer[name] = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "reading the logs")
+	}
+	return answer, nil
+}
+
+// Delta is the change of an artifact of a pull request from its value on the base branch
+type Delta struct {
+	Name string
+	// Base is the value of the base branch, empty for a new artifact
+	Base string
+	// Head is the value of the pull request
+	Head string
+	// Change describes the change, e.g. "+1024 (+2.5%)", "changed" or "new"
+	Change string
+	// Regression is true if the increase exceeds the threshold of the artifact
+	Regression bool
+}
+
+// Compare returns the changes of the artifacts of the pull request from those of the base branch, sorted by name.
+// The artifacts of the base branch which the pull request did not publish are left out, since the jobs publishing
+// them may not have run.
+func (c *Config) Compare(base, head map[string]string) ([]Delta, error) {
+	if err := c.compiled(); err != nil {
+		return nil, err
+	}
+	var names []string
+	for name := range head {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	answer := make([]Delta, 0, len(names))
+	for _, name := range names {
+		d := Delta{Name: name, Base: base[name], Head: head[name]}
+		switch {
+		case d.Base == "":
+			d.Change = "new"
+		case d.Base == d.Head:
+			d.Change = "unchanged"
+		default:
+			d.Change = "changed"
+			before, err1 := strconv.ParseFloat(d.Base, 64)
+			after, err2 := strconv.ParseFloat(d.Head, 64)
+			if err1 == nil && err2 == nil {
+				d.Change, d.Regression = c.numericChange(name, before, after)
+			}
+		}
+		answer = append(answer, d)
+	}
+	return answer, nil
+}
+
+// numericChange describes the change of a numeric artifact, returning true if it exceeds the threshold
+func (c *Config) numericChange(name string, before, after float64) (string, bool) {
+	increase := after - before
+	change := formatNumber(increase)
+	if increase >= 0 {
+		change = "+" + change
+	}
+	percent := math.Inf(1)
+	if before != 0 {
+		percent = increase / math.Abs(before) * 100
+		change += fmt.Sprintf(" (%+.1f%%)", percent)
+	}
+	t := c.threshold(name)
+	if t == nil || increase <= 0 {
+		return change, false
+	}
+	regression := (t.MaxIncrease > 0 && increase > t.MaxIncrease) || (t.MaxIncreasePercent > 0 && percent > t.MaxIncreasePercent)
+	return change, regression
+}
+
+func formatNumber(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// Comment formats the table of the changes of the artifacts of the pull request at the commit, compared with the
+// last postsubmits of the base branch
+func Comment(deltas []Delta, sha, baseRef string) string {
+	lines := []string{
+		fmt.Sprintf("The artifacts of %s compared with the last postsubmits of `%s`:", sha, baseRef),
+		"",
+		fmt.Sprintf("| Artifact | `%s` | This pull request | Change |", baseRef),
+		"| --- | --- | --- | --- |",
+	}
+	regressions := 0
+	for _, d := range deltas {
+		change := d.Change
+		if d.Regression {
+			regressions++
+			change += " :warning:"
+		}
+		lines = append(lines, fmt.Sprintf("| %s | %s | %s | %s |", d.Name, cell(d.Base), cell(d.Head), change))
+	}
+	switch regressions {
+	case 0:
+	case 1:
+		lines = append(lines, "", ":warning: 1 artifact grew beyond its threshold.")
+	default:
+		lines = append(lines, "", fmt.Sprintf(":warning: %d artifacts grew beyond their thresholds.", regressions))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// cell formats a value in the table, shortening the long digests
+func cell(value string) string {
+	if value == "" {
+		return ""
+	}
+	if len(value) > 24 {
+		value = value[:21] + "..."
+	}
+	return "`" + strings.Replace(value, "|", "\\|", -1) + "`"
+}
+
+// Agent holds the current Config
+type Agent struct {
+	lock   sync.RWMutex
+	config *Config
+}
+
+// Set replaces the current Config
+func (a *Agent) Set(config *Config) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.config = config
+}
+
+// Config returns the current Config, which is nil if the artifacts are not compared
+func (a *Agent) Config() *Config {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+	return a.config
+}
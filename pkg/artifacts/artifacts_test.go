@@ -0,0 +1,99 @@
+package artifacts
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	logs := `Step 3/3 : build
+lighthouse-artifact: size/hook=1000
+[build] lighthouse-artifact: image/hook = sha256:abc
+lighthouse-artifact: size/hook=1200
+lighthouse-artifact: no value
+lighthouse-artifact: -invalid=1
+lighthouse-artifact: empty=
+`
+	published, err := Parse(strings.NewReader(logs))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"size/hook":  "1200",
+		"image/hook": "sha256:abc",
+	}, published)
+}
+
+func TestLoadConfig(t *testing.T) {
+	cfg, err := LoadConfig([]byte("tide: {}\n"))
+	require.NoError(t, err)
+	assert.Nil(t, cfg, "the artifacts are not compared without the section")
+
+	cfg, err = LoadConfig([]byte("artifact_diff: {}\n"))
+	require.NoError(t, err)
+	require.NotNil(t, cfg)
+	assert.Equal(t, DefaultTailLines, cfg.Lines())
+
+	_, err = LoadConfig([]byte("artifact_diff:\n  thresholds:\n  - pattern: '('\n"))
+	assert.Error(t, err)
+
+	_, err = LoadConfig([]byte("artifact_diff:\n  thresholds:\n  - pattern: size\n    max_increase: -1\n"))
+	assert.Error(t, err)
+}
+
+func TestCompare(t *testing.T) {
+	cfg, err := LoadConfig([]byte(`artifact_diff:
+  thresholds:
+  - pattern: '^size/'
+    max_increase_percent: 5
+  - pattern: '^bundle/'
+    max_increase: 100
+`))
+	require.NoError(t, err)
+
+	base := map[string]string{
+		"size/hook":    "1000",
+		"size/keeper":  "1000",
+		"size/foghorn": "1000",
+		"bundle/ui":    "1000",
+		"image/hook":   "sha256:abc",
+		"image/keeper": "sha256:abc",
+		"removed":      "1",
+	}
+	head := map[string]string{
+		"size/hook":    "1040",
+		"size/keeper":  "1100",
+		"size/foghorn": "900",
+		"bundle/ui":    "1200",
+		"image/hook":   "sha256:abc",
+		"image/keeper": "sha256:def",
+		"other":        "5",
+	}
+	deltas, err := cfg.Compare(base, head)
+	require.NoError(t, err)
+	assert.Equal(t, []Delta{
+		{Name: "bundle/ui", Base: "1000", Head: "1200", Change: "+200 (+20.0%)", Regression: true},
+		{Name: "image/hook", Base: "sha256:abc", Head: "sha256:abc", Change: "unchanged"},
+		{Name: "image/keeper", Base: "sha256:abc", Head: "sha256:def", Change: "changed"},
+		{Name: "other", Head: "5", Change: "new"},
+		{Name: "size/foghorn", Base: "1000", Head: "900", Change: "-100 (-10.0%)"},
+		{Name: "size/hook", Base: "1000", Head: "1040", Change: "+40 (+4.0%)"},
+		{Name: "size/keeper", Base: "1000", Head: "1100", Change: "+100 (+10.0%)", Regression: true},
+	}, deltas)
+}
+
+func TestComment(t *testing.T) {
+	deltas := []Delta{
+		{Name: "image/hook", Base: "sha256:4f1b2c3d4e5f60718293a4b5c6d7e8f9", Head: "sha256:abc", Change: "changed"},
+		{Name: "size/hook", Base: "1000", Head: "1100", Change: "+100 (+10.0%)", Regression: true},
+	}
+	assert.Equal(t, "The artifacts of abc123 compared with the last postsubmits of `master`:\n"+
+		"\n"+
+		"| Artifact | `master` | This pull request | Change |\n"+
+		"| --- | --- | --- | --- |\n"+
+		"| image/hook | `sha256:4f1b2c3d4e5f60...` | `sha256:abc` | changed |\n"+
+		"| size/hook | `1000` | `1100` | +100 (+10.0%) :warning: |\n"+
+		"\n"+
+		":warning: 1 artifact grew beyond its threshold.", Comment(deltas, "abc123", "master"))
+}
@@ -0,0 +1,230 @@
+// Package audit records the chat commands users run and the changes the bot makes to repositories, pull
+// requests and jobs as a structured stream for compliance and post-incident review.
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// CommandEvent records a chat command run by a user
+	CommandEvent = "command"
+	// ActionEvent records a change made by the bot
+	ActionEvent = "action"
+
+	// OutcomeAccepted is the outcome of commands passed on to the plugins
+	OutcomeAccepted = "accepted"
+	// OutcomeDenied is the outcome of commands denied by the chat command policy
+	OutcomeDenied = "denied"
+	// OutcomeSuccess is the outcome of actions which succeeded
+	OutcomeSuccess = "success"
+	// OutcomeFailure is the outcome of actions which failed
+	OutcomeFailure = "failure"
+
+	// LogEnv enables the audit stream on stdout when set to stdout
+	LogEnv = "LIGHTHOUSE_AUDIT_LOG"
+	// WebhookURLEnv is the URL the audit events are posted to, one JSON event per request
+	WebhookURLEnv = "LIGHTHOUSE_AUDIT_WEBHOOK_URL"
+
+	webhookQueueSize = 1000
+	webhookTimeout   = 10 * time.Second
+)
+
+// Event is an entry of the audit stream
+type Event struct {
+	Time time.Time `json:"time"`
+	// Type is either CommandEvent or ActionEvent
+	Type string `json:"type"`
+	// Component is the lighthouse component which recorded the event
+	Component string `json:"component,omitempty"`
+	// Actor is the user who ran the command or the bot user who made the change
+	Actor  string `json:"actor"`
+	Org    string `json:"org,omitempty"`
+	Repo   string `json:"repo,omitempty"`
+	Number int    `json:"number,omitempty"`
+	// Ref is the commit or git ref changed, e.g. the SHA a status is set on
+	Ref     string `json:"ref,omitempty"`
+	Command string `json:"command,omitempty"`
+	Args    string `json:"args,omitempty"`
+	// Action is the kind of change, e.g. add_label, create_status, merge or create_job
+	Action string `json:"action,omitempty"`
+	// Target is what the action applies to, e.g. the label, the status context or the job name
+	Target  string `json:"target,omitempty"`
+	Outcome string `json:"outcome"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Sink writes audit events somewhere
+type Sink interface {
+	Write(event *Event) error
+}
+
+// WriterSink writes the events as JSON lines
+type WriterSink struct {
+	lock sync.Mutex
+	w    io.Writer
+}
+
+// NewWriterSink creates a sink writing JSON lines to the writer
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{w: w}
+}
+
+// Write writes the event as a line of JSON
+func (s *WriterSink) Write(event *Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	_, err = s.w.Write(append(data, '\n'))
+	return err
+}
+
+// WebhookSink posts each event as JSON to a URL, such as a log collector's HTTP input. Events are queued and
+// sent in the background so that auditing does not slow down event handling; they are dropped if the queue
+// is full.
+type WebhookSink struct {
+	url        string
+	httpClient *http.Client
+	queue      chan *Event
+}
+
+// NewWebhookSink creates a sink posting the events to the URL
+func NewWebhookSink(url string) *WebhookSink {
+	s := &WebhookSink{
+		url:        url,
+		httpClient: &http.Client{Timeout: webhookTimeout},
+		queue:      make(chan *Event, webhookQueueSize),
+	}
+	go s.run()
+	return s
+}
+
+// Write queues the event
+func (s *WebhookSink) Write(event *Event) error {
+	select {
+	case s.queue <- event:
+		return nil
+	default:
+		return errors.Errorf("audit webhook queue is full, dropping the event")
+	}
+}
+
+func (s *WebhookSink) run() {
+	for event := range s.queue {
+		if err := s.post(event); err != nil {
+			logrus.WithError(err).Warnf("failed to post audit event to %s", s.url)
+		}
+	}
+}
+
+func (s *WebhookSink) post(event *Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	resp, err := s.httpClient.Post(s.url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.Errorf("unexpected status %q", resp.Status)
+	}
+	return nil
+}
+
+// Logger records events to its sinks
+type Logger struct {
+	component string
+	sinks     []Sink
+	now       func() time.Time
+}
+
+// NewLogger creates a logger recording the events of the component to the sinks
+func NewLogger(component string, sinks ...Sink) *Logger {
+	return &Logger{
+		component: component,
+		sinks:     sinks,
+		now:       time.Now,
+	}
+}
+
+// Enabled returns true if the logger has any sink
+func (l *Logger) Enabled() bool {
+	return l != nil && len(l.sinks) > 0
+}
+
+// Record writes the event to every sink
+func (l *Logger) Record(event Event) {
+	if !l.Enabled() {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = l.now().UTC()
+	}
+	if event.Component == "" {
+		event.Component = l.component
+	}
+	for _, sink := range l.sinks {
+		if err := sink.Write(&event); err != nil {
+			logrus.WithError(err).Warn("failed to record audit event")
+		}
+	}
+}
+
+var (
+	defaultLock   sync.Mutex
+	defaultLogger *Logger
+)
+
+// Default returns the logger configured by $LIGHTHOUSE_AUDIT_LOG and $LIGHTHOUSE_AUDIT_WEBHOOK_URL, which has
+// no sinks if neither is set
+func Default() *Logger {
+	defaultLock.Lock()
+	defer defaultLock.Unlock()
+
+	if defaultLogger == nil {
+		var sinks []Sink
+		if os.Getenv(LogEnv) == "stdout" {
+			sinks = append(sinks, NewWriterSink(os.Stdout))
+		}
+		if url := os.Getenv(WebhookURLEnv); url != "" {
+			sinks = append(sinks, NewWebhookSink(url))
+		}
+		defaultLogger = NewLogger(filepath.Base(os.Args[0]), sinks...)
+	}
+	return defaultLogger
+}
+
+// SetDefault replaces the default logger, e.g. in tests
+func SetDefault(l *Logger) {
+	defaultLock.Lock()
+	defer defaultLock.Unlock()
+	defaultLogger = l
+}
+
+// Record writes the event with the default logger
+func Record(event Event) {
+	Default().Record(event)
+}
+
+// Outcome returns the outcome of an action which failed with err, if not nil
+func Outcome(err error) (outcome string, message string) {
+	if err != nil {
+		return OutcomeFailure, err.Error()
+	}
+	return OutcomeSuccess, ""
+}
@@ -0,0 +1,74 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriterSink(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := NewLogger("webhooks", NewWriterSink(buf))
+	now := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	logger.now = func() time.Time { return now }
+
+	logger.Record(Event{Type: CommandEvent, Actor: "alice", Org: "org", Repo: "repo", Number: 3, Command: "test", Args: "all", Outcome: OutcomeAccepted})
+	logger.Record(Event{Type: ActionEvent, Actor: "bot", Org: "org", Repo: "repo", Number: 3, Action: "add_label", Target: "lgtm", Outcome: OutcomeSuccess})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.Equal(t, `{"time":"2020-06-01T12:00:00Z","type":"command","component":"webhooks","actor":"alice","org":"org","repo":"repo","number":3,"command":"test","args":"all","outcome":"accepted"}`, lines[0])
+
+	event := Event{}
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &event))
+	assert.Equal(t, "add_label", event.Action)
+	assert.Equal(t, "lgtm", event.Target)
+	assert.Equal(t, now, event.Time)
+}
+
+func TestWebhookSink(t *testing.T) {
+	events := make(chan Event, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		event := Event{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+		events <- event
+	}))
+	defer server.Close()
+
+	logger := NewLogger("keeper", NewWebhookSink(server.URL))
+	logger.Record(Event{Type: ActionEvent, Actor: "bot", Org: "org", Repo: "repo", Number: 7, Action: "merge", Outcome: OutcomeSuccess})
+
+	select {
+	case event := <-events:
+		assert.Equal(t, "merge", event.Action)
+		assert.Equal(t, "keeper", event.Component)
+		assert.Equal(t, 7, event.Number)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the audit event")
+	}
+}
+
+func TestDisabledLogger(t *testing.T) {
+	var logger *Logger
+	assert.False(t, logger.Enabled())
+	logger.Record(Event{Type: ActionEvent})
+	assert.False(t, NewLogger("webhooks").Enabled())
+}
+
+func TestOutcome(t *testing.T) {
+	outcome, message := Outcome(nil)
+	assert.Equal(t, OutcomeSuccess, outcome)
+	assert.Equal(t, "", message)
+
+	outcome, message = Outcome(errors.New("boom"))
+	assert.Equal(t, OutcomeFailure, outcome)
+	assert.Equal(t, "boom", message)
+}
@@ -0,0 +1,129 @@
+// Package buildnum allocates sequential build numbers per job, so that builds can be referred to with short,
+// human friendly numbers in commit status links, log paths and the dashboard.
+package buildnum
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// ConfigMapName is the ConfigMap holding the last build number allocated to each job
+	ConfigMapName = "lighthouse-build-numbers"
+
+	maxConflictRetries = 20
+	maxKeyPrefixLength = 200
+)
+
+var invalidKeyChars = regexp.MustCompile(`[^-._a-zA-Z0-9]`)
+
+// Allocator hands out monotonically increasing build numbers for each job of a repository
+type Allocator interface {
+	// Next returns the next build number of the job, starting at 1
+	Next(org, repo, job string) (int, error)
+}
+
+// Key returns the key the counter of a job is stored under, which is a valid ConfigMap key. A hash of the
+// job's coordinates keeps keys unique when sanitizing or truncating them.
+func Key(org, repo, job string) string {
+	id := strings.Join([]string{strings.ToLower(org), repo, job}, "/")
+	prefix := invalidKeyChars.ReplaceAllString(strings.Replace(id, "/", ".", -1), "_")
+	if len(prefix) > maxKeyPrefixLength {
+		prefix = prefix[:maxKeyPrefixLength]
+	}
+	sum := sha256.Sum256([]byte(id))
+	return fmt.Sprintf("%s.%x", prefix, sum[:4])
+}
+
+// MemoryAllocator allocates build numbers in memory, which is only suitable for a single process such as tests
+type MemoryAllocator struct {
+	lock     sync.Mutex
+	counters map[string]int
+}
+
+// NewMemoryAllocator creates an in memory allocator
+func NewMemoryAllocator() *MemoryAllocator {
+	return &MemoryAllocator{counters: map[string]int{}}
+}
+
+// Next returns the next build number of the job
+func (a *MemoryAllocator) Next(org, repo, job string) (int, error) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	key := Key(org, repo, job)
+	a.counters[key]++
+	return a.counters[key], nil
+}
+
+// ConfigMapAllocator stores the last build number of each job in a ConfigMap. Updates use the ConfigMap's
+// resource version so that concurrent launchers never hand out the same number twice.
+type ConfigMapAllocator struct {
+	kubeClient kubernetes.Interface
+	namespace  string
+}
+
+// NewConfigMapAllocator creates an allocator storing the build numbers in the namespace
+func NewConfigMapAllocator(kubeClient kubernetes.Interface, namespace string) *ConfigMapAllocator {
+	return &ConfigMapAllocator{
+		kubeClient: kubeClient,
+		namespace:  namespace,
+	}
+}
+
+// Next returns the next build number of the job
+func (a *ConfigMapAllocator) Next(org, repo, job string) (int, error) {
+	key := Key(org, repo, job)
+	configMaps := a.kubeClient.CoreV1().ConfigMaps(a.namespace)
+	for i := 0; i < maxConflictRetries; i++ {
+		cm, err := configMaps.Get(ConfigMapName, metav1.GetOptions{})
+		if kubeerrors.IsNotFound(err) {
+			cm = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: ConfigMapName},
+				Data:       map[string]string{key: "1"},
+			}
+			_, err = configMaps.Create(cm)
+			if kubeerrors.IsAlreadyExists(err) {
+				continue
+			}
+			if err != nil {
+				return 0, errors.Wrapf(err, "creating ConfigMap %s", ConfigMapName)
+			}
+			return 1, nil
+		}
+		if err != nil {
+			return 0, errors.Wrapf(err, "getting ConfigMap %s", ConfigMapName)
+		}
+
+		last := 0
+		if value, ok := cm.Data[key]; ok {
+			last, err = strconv.Atoi(value)
+			if err != nil {
+				return 0, errors.Wrapf(err, "parsing the last build number of %s/%s/%s in ConfigMap %s", org, repo, job, ConfigMapName)
+			}
+		}
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+		next := last + 1
+		cm.Data[key] = strconv.Itoa(next)
+		_, err = configMaps.Update(cm)
+		if kubeerrors.IsConflict(err) {
+			continue
+		}
+		if err != nil {
+			return 0, errors.Wrapf(err, "updating ConfigMap %s", ConfigMapName)
+		}
+		return next, nil
+	}
+	return 0, errors.Errorf("too many conflicts updating ConfigMap %s", ConfigMapName)
+}
@@ -0,0 +1,62 @@
+package buildnum
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestKey(t *testing.T) {
+	key := Key("Org", "repo", "pr/build")
+	assert.Regexp(t, `^org\.repo\.pr\.build\.[0-9a-f]{8}$`, key)
+	assert.Equal(t, key, Key("org", "repo", "pr/build"))
+	assert.NotEqual(t, Key("org", "repo", "a.b"), Key("org", "repo", "a/b"))
+}
+
+func TestConfigMapAllocator(t *testing.T) {
+	kubeClient := kubefake.NewSimpleClientset()
+	a := NewConfigMapAllocator(kubeClient, "jx")
+
+	for i := 1; i <= 3; i++ {
+		build, err := a.Next("org", "repo", "unit")
+		require.NoError(t, err)
+		assert.Equal(t, i, build)
+	}
+	build, err := a.Next("org", "repo", "lint")
+	require.NoError(t, err)
+	assert.Equal(t, 1, build)
+
+	cm, err := kubeClient.CoreV1().ConfigMaps("jx").Get(ConfigMapName, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "3", cm.Data[Key("org", "repo", "unit")])
+	assert.Equal(t, "1", cm.Data[Key("org", "repo", "lint")])
+}
+
+func TestMemoryAllocatorConcurrency(t *testing.T) {
+	a := NewMemoryAllocator()
+	var wg sync.WaitGroup
+	builds := make(chan int, 50)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			build, err := a.Next("org", "repo", "unit")
+			assert.NoError(t, err)
+			builds <- build
+		}()
+	}
+	wg.Wait()
+	close(builds)
+
+	seen := map[int]bool{}
+	for build := range builds {
+		assert.False(t, seen[build], fmt.Sprintf("build %d allocated twice", build))
+		seen[build] = true
+	}
+	assert.Len(t, seen, 50)
+}
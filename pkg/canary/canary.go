@@ -0,0 +1,122 @@
+// Package canary rolls out a new version of config.yaml to some repositories before the others. The canary version
+// is read from the config.yaml key of the config-canary ConfigMap, whose canary section lists the orgs, or org/repo
+// repositories, it applies to:
+//
+//	canary:
+//	  repos:
+//	  - jenkins-x/lighthouse
+//	  - sandbox
+//
+// The events of the other repositories keep using the stable config.yaml of the config ConfigMap. Compare reports
+// which jobs would be triggered and which merges would be allowed differently by the canary, so that it can be
+// reviewed before it is promoted by copying it to the config ConfigMap.
+package canary
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// ConfigMapName is the name of the ConfigMap holding the canary config.yaml
+	ConfigMapName = "config-canary"
+	// Path is the path of the admin endpoint comparing the canary config.yaml with the stable one
+	Path = "/config/canary"
+)
+
+// Settings tells which repositories the canary config.yaml applies to
+type Settings struct {
+	// Repos are the orgs, or org/repo repositories, whose events use the canary config.yaml. The canary is
+	// disabled if empty.
+	Repos []string `json:"repos,omitempty"`
+}
+
+// Includes returns true if the repository of the org, or the whole org, uses the canary
+func (s *Settings) Includes(org, repo string) bool {
+	fullName := strings.ToLower(org + "/" + repo)
+	for _, r := range s.Repos {
+		r = strings.ToLower(r)
+		if r == fullName || r == strings.ToLower(org) {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadSettings reads the Settings from the canary section of the text of the canary config.yaml
+func LoadSettings(data []byte) (*Settings, error) {
+	answer := &struct {
+		Canary Settings `json:"canary,omitempty"`
+	}{}
+	if err := yaml.Unmarshal(data, answer); err != nil {
+		return nil, errors.Wrap(err, "parsing the canary settings")
+	}
+	for _, r := range answer.Canary.Repos {
+		if r == "" || strings.HasPrefix(r, "/") || strings.HasSuffix(r, "/") || strings.Count(r, "/") > 1 {
+			return nil, errors.Errorf("invalid canary repository %q, expected an org or org/repo", r)
+		}
+	}
+	return &answer.Canary, nil
+}
+
+// Agent holds the canary config.yaml and the repositories it applies to
+type Agent struct {
+	lock     sync.RWMutex
+	settings Settings
+	config   *config.Agent
+}
+
+// Load replaces the canary with the text of the canary config.yaml
+func (a *Agent) Load(data []byte) error {
+	settings, err := LoadSettings(data)
+	if err != nil {
+		return err
+	}
+	cfg, err := config.LoadYAMLConfig(data)
+	if err != nil {
+		return errors.Wrap(err, "loading the canary config.yaml")
+	}
+	a.Set(settings, cfg)
+	return nil
+}
+
+// Set replaces the canary config.yaml and the repositories it applies to
+func (a *Agent) Set(settings *Settings, cfg *config.Config) {
+	agent := &config.Agent{}
+	agent.Set(cfg)
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.settings = *settings
+	a.config = agent
+}
+
+// Snapshot returns the canary settings and config.yaml, which is nil if no canary was loaded
+func (a *Agent) Snapshot() (Settings, *config.Config) {
+	if a == nil {
+		return Settings{}, nil
+	}
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+	if a.config == nil {
+		return a.settings, nil
+	}
+	return a.settings, a.config.Config()
+}
+
+// ConfigAgentFor returns the agent of the canary config.yaml if the repository of the org uses the canary, the
+// stable agent otherwise
+func (a *Agent) ConfigAgentFor(stable *config.Agent, org, repo string) *config.Agent {
+	if a == nil {
+		return stable
+	}
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+	if a.config == nil || !a.settings.Includes(org, repo) {
+		return stable
+	}
+	return a.config
+}
@@ -0,0 +1,133 @@
+package canary
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const stableConfig = `
+presubmits:
+  platform/infra:
+  - name: lint
+    context: lint
+    agent: tekton
+    always_run: true
+  - name: unit
+    context: unit
+    agent: tekton
+    always_run: true
+  sandbox/app:
+  - name: unit
+    context: unit
+    agent: tekton
+    always_run: true
+postsubmits:
+  platform/infra:
+  - name: release
+    agent: tekton
+tide:
+  queries:
+  - repos:
+    - platform/infra
+    - sandbox/app
+    labels:
+    - approved
+`
+
+const canaryConfig = `
+canary:
+  repos:
+  - sandbox
+presubmits:
+  platform/infra:
+  - name: lint
+    context: lint
+    agent: tekton
+    run_if_changed: '\.go$'
+  - name: e2e
+    context: e2e
+    agent: tekton
+    always_run: true
+  sandbox/app:
+  - name: unit
+    context: unit
+    agent: tekton
+    always_run: true
+postsubmits:
+  platform/infra:
+  - name: release
+    agent: tekton
+tide:
+  merge_method:
+    platform/infra: squash
+  queries:
+  - repos:
+    - sandbox/app
+    labels:
+    - approved
+`
+
+func TestSettings(t *testing.T) {
+	settings, err := LoadSettings([]byte(canaryConfig))
+	require.NoError(t, err)
+	assert.True(t, settings.Includes("sandbox", "app"))
+	assert.True(t, settings.Includes("Sandbox", "other"), "orgs should match case insensitively")
+	assert.False(t, settings.Includes("platform", "infra"))
+
+	settings, err = LoadSettings([]byte("canary:\n  repos:\n  - platform/infra\n"))
+	require.NoError(t, err)
+	assert.True(t, settings.Includes("platform", "infra"))
+	assert.False(t, settings.Includes("platform", "other"))
+
+	_, err = LoadSettings([]byte("canary:\n  repos:\n  - platform/infra/extra\n"))
+	assert.Error(t, err)
+}
+
+func TestConfigAgentFor(t *testing.T) {
+	stableCfg, err := config.LoadYAMLConfig([]byte(stableConfig))
+	require.NoError(t, err)
+	stable := &config.Agent{}
+	stable.Set(stableCfg)
+
+	var none *Agent
+	assert.Equal(t, stable, none.ConfigAgentFor(stable, "sandbox", "app"), "no canary should use the stable config")
+	agent := &Agent{}
+	assert.Equal(t, stable, agent.ConfigAgentFor(stable, "sandbox", "app"), "an unloaded canary should use the stable config")
+
+	require.NoError(t, agent.Load([]byte(canaryConfig)))
+	assert.Equal(t, stable, agent.ConfigAgentFor(stable, "platform", "infra"))
+	canaryAgent := agent.ConfigAgentFor(stable, "sandbox", "app")
+	require.NotEqual(t, stable, canaryAgent)
+	assert.Equal(t, "squash", string(canaryAgent.Config().Keeper.MergeMethod("platform", "infra")))
+
+	assert.Error(t, agent.Load([]byte("presubmits: {")))
+	settings, cfg := agent.Snapshot()
+	assert.Equal(t, []string{"sandbox"}, settings.Repos, "an invalid canary should keep the previous one")
+	assert.NotNil(t, cfg)
+}
+
+func TestCompare(t *testing.T) {
+	stable, err := config.LoadYAMLConfig([]byte(stableConfig))
+	require.NoError(t, err)
+	agent := &Agent{}
+	require.NoError(t, agent.Load([]byte(canaryConfig)))
+	settings, canary := agent.Snapshot()
+
+	report := Compare(stable, canary, settings, "master")
+	assert.Equal(t, []string{"sandbox"}, report.Canary)
+	require.Len(t, report.Repos, 1, "sandbox/app behaves the same")
+	diff := report.Repos[0]
+	assert.Equal(t, "platform/infra", diff.Repo)
+	assert.False(t, diff.Canary)
+	assert.Equal(t, []string{"presubmit e2e"}, diff.AddedJobs)
+	assert.Equal(t, []string{"presubmit unit"}, diff.RemovedJobs)
+	assert.Equal(t, []string{"presubmit lint: always_run, run_if_changed"}, diff.ChangedJobs)
+	assert.Equal(t, []string{
+		"merged by keeper: true -> false",
+		"merge method: merge -> squash",
+		"required contexts of master: [lint unit] -> [e2e]",
+	}, diff.Merges)
+}
@@ -0,0 +1,218 @@
+package canary
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// Report lists the repositories the canary config.yaml behaves differently for
+type Report struct {
+	// Canary are the orgs and repositories the canary applies to
+	Canary []string `json:"canary"`
+	// Repos are the differences by repository, sorted by name
+	Repos []RepoDiff `json:"repos"`
+}
+
+// RepoDiff describes how the canary behaves differently for a repository
+type RepoDiff struct {
+	// Repo is the org/repo name of the repository
+	Repo string `json:"repo"`
+	// Canary is true if the repository already uses the canary
+	Canary bool `json:"canary"`
+	// AddedJobs are the jobs only the canary triggers, e.g. presubmit lint
+	AddedJobs []string `json:"added_jobs,omitempty"`
+	// RemovedJobs are the jobs only the stable config.yaml triggers
+	RemovedJobs []string `json:"removed_jobs,omitempty"`
+	// ChangedJobs describe the jobs triggered differently, with the fields which changed, e.g.
+	// presubmit lint: always_run, trigger
+	ChangedJobs []string `json:"changed_jobs,omitempty"`
+	// Merges describe how the merges of the pull requests are allowed differently, e.g.
+	// merge method: merge -> squash
+	Merges []string `json:"merges,omitempty"`
+}
+
+// Compare reports the repositories of either config.yaml the canary triggers different jobs or allows different
+// merges for. The required contexts are compared on the branches of the context options of the repository and the
+// given default branch.
+func Compare(stable, canary *config.Config, settings Settings, defaultBranch string) *Report {
+	report := &Report{Canary: append([]string{}, settings.Repos...), Repos: []RepoDiff{}}
+	for _, fullName := range repositories(stable, canary, settings) {
+		parts := strings.SplitN(fullName, "/", 2)
+		org, repo := parts[0], parts[1]
+		diff := RepoDiff{Repo: fullName, Canary: settings.Includes(org, repo)}
+		diff.AddedJobs, diff.RemovedJobs, diff.ChangedJobs = compareJobs(jobsOf(stable, fullName), jobsOf(canary, fullName))
+		diff.Merges = compareMerges(stable, canary, org, repo, defaultBranch)
+		if len(diff.AddedJobs)+len(diff.RemovedJobs)+len(diff.ChangedJobs)+len(diff.Merges) > 0 {
+			report.Repos = append(report.Repos, diff)
+		}
+	}
+	return report
+}
+
+// repositories returns the repositories which have jobs or are merged by keeper in either config.yaml, along with
+// the repositories of the canary
+func repositories(stable, canary *config.Config, settings Settings) []string {
+	repos := sets.NewString()
+	for _, cfg := range []*config.Config{stable, canary} {
+		for fullName := range cfg.Presubmits {
+			repos.Insert(fullName)
+		}
+		for fullName := range cfg.Postsubmits {
+			repos.Insert(fullName)
+		}
+		for _, q := range cfg.Keeper.Queries {
+			repos.Insert(q.Repos...)
+		}
+	}
+	for _, r := range settings.Repos {
+		if strings.Contains(r, "/") {
+			repos.Insert(r)
+		}
+	}
+	return repos.List()
+}
+
+// job is a job of a repository as far as triggering it is concerned
+type job struct {
+	fields map[string]interface{}
+	spec   string
+}
+
+// jobsOf returns the presubmits and postsubmits of the repository by kind and name, e.g. presubmit lint
+func jobsOf(cfg *config.Config, fullName string) map[string]job {
+	answer := map[string]job{}
+	for _, ps := range cfg.Presubmits[fullName] {
+		answer["presubmit "+ps.Name] = job{
+			fields: map[string]interface{}{
+				"always_run":     ps.AlwaysRun,
+				"optional":       ps.Optional,
+				"trigger":        ps.Trigger,
+				"rerun_command":  ps.RerunCommand,
+				"run_if_changed": ps.RunIfChanged,
+				"branches":       ps.Branches,
+				"skip_branches":  ps.SkipBranches,
+				"context":        ps.Context,
+				"skip_report":    ps.SkipReport,
+			},
+			spec: marshal(ps),
+		}
+	}
+	for _, ps := range cfg.Postsubmits[fullName] {
+		answer["postsubmit "+ps.Name] = job{
+			fields: map[string]interface{}{
+				"run_if_changed": ps.RunIfChanged,
+				"branches":       ps.Branches,
+				"skip_branches":  ps.SkipBranches,
+				"context":        ps.Context,
+				"skip_report":    ps.SkipReport,
+			},
+			spec: marshal(ps),
+		}
+	}
+	return answer
+}
+
+func marshal(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%#v", v)
+	}
+	return string(data)
+}
+
+// compareJobs returns the jobs only the canary has, the jobs only the stable config has and the jobs which differ,
+// with the triggering fields which changed or spec if only the rest of the job changed
+func compareJobs(stable, canary map[string]job) (added, removed, changed []string) {
+	for name, c := range canary {
+		s, ok := stable[name]
+		if !ok {
+			added = append(added, name)
+			continue
+		}
+		var fields []string
+		for field, value := range c.fields {
+			if !reflect.DeepEqual(value, s.fields[field]) {
+				fields = append(fields, field)
+			}
+		}
+		if len(fields) == 0 && c.spec != s.spec {
+			fields = append(fields, "spec")
+		}
+		if len(fields) > 0 {
+			sort.Strings(fields)
+			changed = append(changed, name+": "+strings.Join(fields, ", "))
+		}
+	}
+	for name := range stable {
+		if _, ok := canary[name]; !ok {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	sort.Strings(changed)
+	return added, removed, changed
+}
+
+// compareMerges describes how keeper would merge the pull requests of the repository differently: whether they are
+// merged at all and with which query, merge method and required contexts
+func compareMerges(stable, canary *config.Config, org, repo, defaultBranch string) []string {
+	var answer []string
+	changed := func(what string, s, c interface{}) {
+		if !reflect.DeepEqual(s, c) {
+			answer = append(answer, fmt.Sprintf("%s: %v -> %v", what, s, c))
+		}
+	}
+	stableQueries, canaryQueries := queries(stable, org, repo), queries(canary, org, repo)
+	changed("merged by keeper", len(stableQueries) > 0, len(canaryQueries) > 0)
+	if len(stableQueries) > 0 && len(canaryQueries) > 0 {
+		changed("keeper queries", stableQueries, canaryQueries)
+	}
+	changed("merge method", stable.Keeper.MergeMethod(org, repo), canary.Keeper.MergeMethod(org, repo))
+	changed("batch size limit", stable.Keeper.BatchSizeLimit(org, repo), canary.Keeper.BatchSizeLimit(org, repo))
+
+	branches := sets.NewString()
+	if defaultBranch != "" {
+		branches.Insert(defaultBranch)
+	}
+	for _, cfg := range []*config.Config{stable, canary} {
+		for branch := range cfg.Keeper.ContextOptions.Orgs[org].Repos[repo].Branches {
+			branches.Insert(branch)
+		}
+	}
+	for _, branch := range branches.List() {
+		s, c := requiredContexts(stable, org, repo, branch), requiredContexts(canary, org, repo, branch)
+		changed("required contexts of "+branch, s, c)
+	}
+	return answer
+}
+
+// queries returns the keeper queries of the repository, restricted to the repository so that the changes of the
+// other repositories of a query are not reported
+func queries(cfg *config.Config, org, repo string) []string {
+	answer := []string{}
+	for _, q := range cfg.Keeper.Queries {
+		if q.ForRepo(org, repo) {
+			q.Orgs, q.ExcludedRepos = nil, nil
+			q.Repos = []string{org + "/" + repo}
+			answer = append(answer, q.Query())
+		}
+	}
+	sort.Strings(answer)
+	return answer
+}
+
+// requiredContexts returns the contexts keeper requires to merge the pull requests of the branch
+func requiredContexts(cfg *config.Config, org, repo, branch string) []string {
+	policy, err := cfg.GetKeeperContextPolicy(org, repo, branch)
+	if err != nil || policy == nil {
+		return []string{}
+	}
+	return append([]string{}, policy.RequiredContexts...)
+}
@@ -0,0 +1,177 @@
+// Package censor masks the values of secrets, and their base64 encodings, in build logs so that jobs printing the
+// secrets they are given do not leak them to whoever reads their logs or the excerpts posted on pull requests.
+package censor
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// Mask replaces the censored values
+	Mask = "***"
+
+	// MinLength is the length of the shortest value censored, as masking every occurrence of shorter strings would
+	// garble the logs without protecting much
+	MinLength = 4
+
+	// maxBuffered is how much of a line a Writer buffers before censoring it anyway
+	maxBuffered = 64 * 1024
+)
+
+// Censorer masks a set of secret values
+type Censorer struct {
+	replacer *strings.Replacer
+}
+
+// New returns a Censorer masking the values, each of their lines and the base64 encodings of the values
+func New(values ...string) *Censorer {
+	censored := sets.NewString()
+	add := func(value string) {
+		value = strings.TrimSpace(value)
+		if len(value) >= MinLength {
+			censored.Insert(value)
+		}
+	}
+	for _, value := range values {
+		add(value)
+		// logs are censored line by line so the lines of multi-line secrets, such as keys, are masked on their own
+		for _, line := range strings.Split(value, "\n") {
+			add(line)
+		}
+		for _, encoding := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+			add(encoding.EncodeToString([]byte(value)))
+		}
+	}
+	if censored.Len() == 0 {
+		return &Censorer{}
+	}
+	// the replacer gives precedence to the earlier values, so the longest are masked first
+	list := censored.List()
+	sort.SliceStable(list, func(i, j int) bool {
+		return len(list[i]) > len(list[j])
+	})
+	var oldnew []string
+	for _, value := range list {
+		oldnew = append(oldnew, value, Mask)
+	}
+	return &Censorer{replacer: strings.NewReplacer(oldnew...)}
+}
+
+// Censor returns the text with the secret values masked
+func (c *Censorer) Censor(text string) string {
+	if c == nil || c.replacer == nil {
+		return text
+	}
+	return c.replacer.Replace(text)
+}
+
+// NewWriter returns a Writer censoring what is written to w
+func (c *Censorer) NewWriter(w io.Writer) *Writer {
+	return &Writer{censorer: c, w: w}
+}
+
+// Writer censors the lines written to it before writing them to the underlying writer. Incomplete lines are
+// buffered until they are completed or Flush is called, so that no secret is split across writes.
+type Writer struct {
+	censorer *Censorer
+	w        io.Writer
+	buf      []byte
+}
+
+// Write writes the censored complete lines of p, buffering the rest
+func (w *Writer) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	n := bytes.LastIndexByte(w.buf, '\n') + 1
+	if n == 0 && len(w.buf) < maxBuffered {
+		return len(p), nil
+	}
+	if n == 0 {
+		n = len(w.buf)
+	}
+	if err := w.write(w.buf[:n]); err != nil {
+		return 0, err
+	}
+	w.buf = append(w.buf[:0], w.buf[n:]...)
+	return len(p), nil
+}
+
+// Flush writes the buffered incomplete line
+func (w *Writer) Flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	err := w.write(w.buf)
+	w.buf = w.buf[:0]
+	return err
+}
+
+func (w *Writer) write(data []byte) error {
+	_, err := io.WriteString(w.w, w.censorer.Censor(string(data)))
+	return err
+}
+
+// SecretNames returns the names of the secrets the pod mounts as volumes or reads environment variables from
+func SecretNames(spec *corev1.PodSpec) []string {
+	names := sets.NewString()
+	if spec == nil {
+		return nil
+	}
+	for _, volume := range spec.Volumes {
+		if volume.Secret != nil {
+			names.Insert(volume.Secret.SecretName)
+		}
+		if volume.Projected != nil {
+			for _, source := range volume.Projected.Sources {
+				if source.Secret != nil {
+					names.Insert(source.Secret.Name)
+				}
+			}
+		}
+	}
+	for _, container := range append(append([]corev1.Container{}, spec.InitContainers...), spec.Containers...) {
+		for _, env := range container.Env {
+			if env.ValueFrom != nil && env.ValueFrom.SecretKeyRef != nil {
+				names.Insert(env.ValueFrom.SecretKeyRef.Name)
+			}
+		}
+		for _, envFrom := range container.EnvFrom {
+			if envFrom.SecretRef != nil {
+				names.Insert(envFrom.SecretRef.Name)
+			}
+		}
+	}
+	names.Delete("")
+	return names.List()
+}
+
+// SecretValues returns the values of the secrets of the namespace, ignoring the secrets which do not exist
+func SecretValues(kubeClient kubernetes.Interface, namespace string, names []string) ([]string, error) {
+	var values []string
+	for _, name := range names {
+		secret, err := kubeClient.CoreV1().Secrets(namespace).Get(name, metav1.GetOptions{})
+		if kubeerrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "getting secret %s in namespace %s", name, namespace)
+		}
+		for _, data := range secret.Data {
+			values = append(values, string(data))
+		}
+		for _, data := range secret.StringData {
+			values = append(values, data)
+		}
+	}
+	return values, nil
+}
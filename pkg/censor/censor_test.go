@@ -0,0 +1,93 @@
+package censor
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestCensor(t *testing.T) {
+	c := New("s3cr3t", "abc", "-----BEGIN KEY-----\nMIIEvQIBADAN\n-----END KEY-----\n")
+
+	testCases := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{
+			name:     "value",
+			text:     "token=s3cr3t\n",
+			expected: "token=***\n",
+		},
+		{
+			name:     "base64 encoded value",
+			text:     "Authorization: Basic " + base64.StdEncoding.EncodeToString([]byte("s3cr3t")),
+			expected: "Authorization: Basic ***",
+		},
+		{
+			name:     "line of a multi-line value",
+			text:     "key: MIIEvQIBADAN",
+			expected: "key: ***",
+		},
+		{
+			name:     "short values are not censored",
+			text:     "abc",
+			expected: "abc",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, c.Censor(tc.text))
+		})
+	}
+
+	var nothing *Censorer
+	assert.Equal(t, "s3cr3t", nothing.Censor("s3cr3t"))
+	assert.Equal(t, "s3cr3t", New().Censor("s3cr3t"))
+}
+
+func TestWriterCensorsValuesSplitAcrossWrites(t *testing.T) {
+	out := &bytes.Buffer{}
+	w := New("s3cr3t").NewWriter(out)
+
+	for _, part := range []string{"token=s3c", "r3t\nnext ", "s3", "cr3t"} {
+		n, err := w.Write([]byte(part))
+		require.NoError(t, err)
+		assert.Equal(t, len(part), n)
+	}
+	assert.Equal(t, "token=***\n", out.String())
+	require.NoError(t, w.Flush())
+	assert.Equal(t, "token=***\nnext ***", out.String())
+}
+
+func TestSecretNamesAndValues(t *testing.T) {
+	spec := &corev1.PodSpec{
+		Volumes: []corev1.Volume{
+			{Name: "creds", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "git-creds"}}},
+			{Name: "cache", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+		},
+		Containers: []corev1.Container{{
+			Env: []corev1.EnvVar{
+				{Name: "PLAIN", Value: "value"},
+				{Name: "TOKEN", ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "token"}, Key: "token"}}},
+			},
+			EnvFrom: []corev1.EnvFromSource{{SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "env"}}}},
+		}},
+	}
+	names := SecretNames(spec)
+	assert.Equal(t, []string{"env", "git-creds", "token"}, names)
+
+	kubeClient := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "token", Namespace: "jx"},
+		Data:       map[string][]byte{"token": []byte("s3cr3t")},
+	})
+	values, err := SecretValues(kubeClient, "jx", names)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"s3cr3t"}, values)
+}
@@ -2,4 +2,6 @@
 
 package v1alpha1
 
+type LighthouseConfigExpansion interface{}
+
 type LighthouseJobExpansion interface{}
@@ -11,6 +11,7 @@ import (
 
 type LighthouseV1alpha1Interface interface {
 	RESTClient() rest.Interface
+	LighthouseConfigsGetter
 	LighthouseJobsGetter
 }
 
@@ -19,6 +20,10 @@ type LighthouseV1alpha1Client struct {
 	restClient rest.Interface
 }
 
+func (c *LighthouseV1alpha1Client) LighthouseConfigs(namespace string) LighthouseConfigInterface {
+	return newLighthouseConfigs(c, namespace)
+}
+
 func (c *LighthouseV1alpha1Client) LighthouseJobs(namespace string) LighthouseJobInterface {
 	return newLighthouseJobs(c, namespace)
 }
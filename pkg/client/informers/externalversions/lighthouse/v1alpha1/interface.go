@@ -8,6 +8,8 @@ import (
 
 // Interface provides access to all the informers in this group version.
 type Interface interface {
+	// LighthouseConfigs returns a LighthouseConfigInformer.
+	LighthouseConfigs() LighthouseConfigInformer
 	// LighthouseJobs returns a LighthouseJobInformer.
 	LighthouseJobs() LighthouseJobInformer
 }
@@ -23,6 +25,11 @@ func New(f internalinterfaces.SharedInformerFactory, namespace string, tweakList
 	return &version{factory: f, namespace: namespace, tweakListOptions: tweakListOptions}
 }
 
+// LighthouseConfigs returns a LighthouseConfigInformer.
+func (v *version) LighthouseConfigs() LighthouseConfigInformer {
+	return &lighthouseConfigInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // LighthouseJobs returns a LighthouseJobInformer.
 func (v *version) LighthouseJobs() LighthouseJobInformer {
 	return &lighthouseJobInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
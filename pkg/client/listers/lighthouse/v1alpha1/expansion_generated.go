@@ -2,6 +2,14 @@
 
 package v1alpha1
 
+// LighthouseConfigListerExpansion allows custom methods to be added to
+// LighthouseConfigLister.
+type LighthouseConfigListerExpansion interface{}
+
+// LighthouseConfigNamespaceListerExpansion allows custom methods to be added to
+// LighthouseConfigNamespaceLister.
+type LighthouseConfigNamespaceListerExpansion interface{}
+
 // LighthouseJobListerExpansion allows custom methods to be added to
 // LighthouseJobLister.
 type LighthouseJobListerExpansion interface{}
@@ -18,6 +18,8 @@ limitations under the License.
 package commentpruner
 
 import (
+	"fmt"
+	"strings"
 	"sync"
 
 	"github.com/jenkins-x/go-scm/scm"
@@ -29,6 +31,21 @@ type scmProviderClient interface {
 	ListIssueComments(org, repo string, number int) ([]*scm.Comment, error)
 	ListPullRequestComments(org, repo string, number int) ([]*scm.Comment, error)
 	DeleteComment(org, repo string, number, id int, pr bool) error
+	CreateComment(org, repo string, number int, pr bool, comment string) error
+	EditComment(org, repo string, number, id int, comment string, pr bool) error
+}
+
+// Tag returns the hidden marker identifying the comments of a plugin, e.g. <!-- lighthouse:lgtm -->, which is
+// appended to the comments passed to UpsertComment and matched by Tagged.
+func Tag(name string) string {
+	return fmt.Sprintf("<!-- lighthouse:%s -->", name)
+}
+
+// Tagged returns a func for PruneComments which prunes the comments carrying the tag
+func Tagged(tag string) func(*scm.Comment) bool {
+	return func(comment *scm.Comment) bool {
+		return strings.Contains(comment.Body, tag)
+	}
 }
 
 // EventClient is a struct that provides bot comment deletion for an event related to an issue.
@@ -65,6 +82,64 @@ func NewEventClient(spc scmProviderClient, log *logrus.Entry, org, repo string,
 // PruneComments fetches issue comments if they have not yet been fetched for this webhook event
 // and then deletes any bot comments indicated by the func 'shouldPrune'.
 func (c *EventClient) PruneComments(pr bool, shouldPrune func(*scm.Comment) bool) {
+	c.fetchComments(pr)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.comments = c.prune(pr, c.comments, shouldPrune)
+}
+
+// UpsertComment maintains a single bot comment carrying the tag: the most recent one is edited in place with
+// the body, unless it is unchanged, and the older ones are deleted. The comment is created if there is none.
+func (c *EventClient) UpsertComment(pr bool, tag, body string) error {
+	c.fetchComments(pr)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if !strings.Contains(body, tag) {
+		body = body + "\n\n" + tag
+	}
+	var latest *scm.Comment
+	for _, comment := range c.comments {
+		if strings.Contains(comment.Body, tag) {
+			latest = comment
+		}
+	}
+	if latest == nil {
+		return c.spc.CreateComment(c.org, c.repo, c.number, pr, body)
+	}
+	c.comments = c.prune(pr, c.comments, func(comment *scm.Comment) bool {
+		return comment != latest && strings.Contains(comment.Body, tag)
+	})
+	if latest.Body == body {
+		return nil
+	}
+	if err := c.spc.EditComment(c.org, c.repo, c.number, latest.ID, body, pr); err != nil {
+		return err
+	}
+	latest.Body = body
+	return nil
+}
+
+// TaggedComment returns the most recent bot comment carrying the tag, or nil if there is none
+func (c *EventClient) TaggedComment(pr bool, tag string) *scm.Comment {
+	c.fetchComments(pr)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	var latest *scm.Comment
+	for _, comment := range c.comments {
+		if strings.Contains(comment.Body, tag) {
+			latest = comment
+		}
+	}
+	return latest
+}
+
+// fetchComments lists the bot's comments the first time it is called
+func (c *EventClient) fetchComments(pr bool) {
 	c.once.Do(func() {
 		botName, err := c.spc.BotName()
 		if err != nil {
@@ -87,12 +162,12 @@ func (c *EventClient) PruneComments(pr bool, shouldPrune func(*scm.Comment) bool
 			}
 		}
 	})
+}
 
-	c.lock.Lock()
-	defer c.lock.Unlock()
-
+// prune deletes the comments indicated by shouldPrune, returning the remaining ones
+func (c *EventClient) prune(pr bool, comments []*scm.Comment, shouldPrune func(*scm.Comment) bool) []*scm.Comment {
 	var remaining []*scm.Comment
-	for _, comment := range c.comments {
+	for _, comment := range comments {
 		removed := false
 		if shouldPrune(comment) {
 			if err := c.spc.DeleteComment(c.org, c.repo, c.number, comment.ID, pr); err != nil {
@@ -105,5 +180,5 @@ func (c *EventClient) PruneComments(pr bool, shouldPrune func(*scm.Comment) bool
 			remaining = append(remaining, comment)
 		}
 	}
-	c.comments = remaining
+	return remaining
 }
@@ -28,6 +28,8 @@ import (
 type fakeSCMProviderClient struct {
 	comments        []*scm.Comment
 	deletedComments []int
+	createdComments []string
+	editedComments  map[int]string
 	listCallCount   int
 }
 
@@ -50,6 +52,19 @@ func (f *fakeSCMProviderClient) DeleteComment(_, _ string, number, ID int, _ boo
 	return nil
 }
 
+func (f *fakeSCMProviderClient) CreateComment(_, _ string, _ int, _ bool, comment string) error {
+	f.createdComments = append(f.createdComments, comment)
+	return nil
+}
+
+func (f *fakeSCMProviderClient) EditComment(_, _ string, _, ID int, comment string, _ bool) error {
+	if f.editedComments == nil {
+		f.editedComments = map[int]string{}
+	}
+	f.editedComments[ID] = comment
+	return nil
+}
+
 func newFakeSCMProviderClient(commentsToLogins map[int]string) *fakeSCMProviderClient {
 	comments := make([]*scm.Comment, 0, len(commentsToLogins))
 	for num, login := range commentsToLogins {
@@ -150,3 +165,58 @@ func TestPruneComments(t *testing.T) {
 		}
 	}
 }
+
+func TestUpsertComment(t *testing.T) {
+	botLogin := "k8s-ci-robot"
+	tag := Tag("report")
+
+	tcs := []struct {
+		name            string
+		comments        []*scm.Comment
+		body            string
+		expectedCreated []string
+		expectedEdited  map[int]string
+		expectedDeleted []int
+	}{
+		{
+			name:            "no tagged comment",
+			comments:        []*scm.Comment{{ID: 1, Body: "hello", Author: scm.User{Login: botLogin}}},
+			body:            "report",
+			expectedCreated: []string{"report\n\n" + tag},
+			expectedDeleted: []int{},
+		},
+		{
+			name: "latest tagged comment is edited, older ones deleted",
+			comments: []*scm.Comment{
+				{ID: 1, Body: "old\n\n" + tag, Author: scm.User{Login: botLogin}},
+				{ID: 2, Body: "older\n\n" + tag, Author: scm.User{Login: botLogin}},
+				{ID: 3, Body: "human\n\n" + tag, Author: scm.User{Login: "cjwagner"}},
+			},
+			body:            "report",
+			expectedEdited:  map[int]string{2: "report\n\n" + tag},
+			expectedDeleted: []int{1},
+		},
+		{
+			name:            "unchanged comment is not edited",
+			comments:        []*scm.Comment{{ID: 1, Body: "report\n\n" + tag, Author: scm.User{Login: botLogin}}},
+			body:            "report",
+			expectedDeleted: []int{},
+		},
+	}
+	for _, tc := range tcs {
+		fsc := &fakeSCMProviderClient{comments: tc.comments, deletedComments: []int{}}
+		client := NewEventClient(fsc, logrus.WithField("client", "commentpruner"), "org", "repo", 1)
+		if err := client.UpsertComment(true, tag, tc.body); err != nil {
+			t.Fatalf("[%s]: unexpected error: %v", tc.name, err)
+		}
+		if !reflect.DeepEqual(tc.expectedCreated, fsc.createdComments) {
+			t.Errorf("[%s]: expected created comments %#v, got %#v", tc.name, tc.expectedCreated, fsc.createdComments)
+		}
+		if !reflect.DeepEqual(tc.expectedEdited, fsc.editedComments) {
+			t.Errorf("[%s]: expected edited comments %#v, got %#v", tc.name, tc.expectedEdited, fsc.editedComments)
+		}
+		if !reflect.DeepEqual(tc.expectedDeleted, fsc.deletedComments) {
+			t.Errorf("[%s]: expected deleted comments %#v, got %#v", tc.name, tc.expectedDeleted, fsc.deletedComments)
+		}
+	}
+}
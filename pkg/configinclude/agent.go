@@ -0,0 +1,56 @@
+package configinclude
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"time"
+
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/interrupts"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// ReadFile reads the config file, resolving its includes relative to its directory
+func ReadFile(path string) ([]byte, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading %s", path)
+	}
+	data, err = Resolve(data, filepath.Dir(path))
+	if err != nil {
+		return nil, errors.Wrapf(err, "resolving the includes of %s", path)
+	}
+	return data, nil
+}
+
+// StartAgent loads the config file with its includes into the agent, then loads it again every interval so that
+// the changes of the file and of the files it includes are picked up. It returns the error of the first load.
+func StartAgent(agent *config.Agent, path string, interval time.Duration) error {
+	var last string
+	load := func() error {
+		data, err := ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if string(data) == last {
+			return nil
+		}
+		cfg, err := config.LoadYAMLConfig(data)
+		if err != nil {
+			return errors.Wrapf(err, "loading %s", path)
+		}
+		last = string(data)
+		agent.Set(cfg)
+		return nil
+	}
+	if err := load(); err != nil {
+		return err
+	}
+	interrupts.TickLiteral(func() {
+		if err := load(); err != nil {
+			logrus.WithError(err).WithField("prowConfig", path).Error("Error loading config.")
+		}
+	}, interval)
+	return nil
+}
@@ -0,0 +1,234 @@
+// Package configinclude resolves the include directives of the config.yaml, so that large installations can split
+// their config by team into other files, directories or HTTPS URLs pinned by their checksums.
+package configinclude
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// Key is the key of the include directives in the config.yaml
+	Key = "include"
+
+	// maxURLSize is the maximum size of an included URL
+	maxURLSize = 10 * 1024 * 1024
+)
+
+// Include is an include directive, which includes either a path or an HTTPS URL
+type Include struct {
+	// Path is the file, or the directory whose *.yaml and *.yml files are included in the order of their names,
+	// relative to the directory of the including file. It is relative to the URL of an included URL.
+	Path string `json:"path,omitempty"`
+	// URL is the HTTPS URL of the included file
+	URL string `json:"url,omitempty"`
+	// SHA256 is the hex encoded checksum of the included URL, which is required so that a change of the URL does
+	// not change the config unnoticed
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+// Resolver resolves the include directives of config files
+type Resolver struct {
+	// Client fetches the included URLs, defaulting to a client with a timeout
+	Client *http.Client
+}
+
+// Resolve returns the config with the included files merged into it, resolving the relative paths against dir
+func Resolve(data []byte, dir string) ([]byte, error) {
+	return (&Resolver{}).Resolve(data, dir)
+}
+
+// Resolve returns the config with the included files merged into it, resolving the relative paths against dir.
+//
+// The included files are merged in the order of the directives, then the config itself: their maps are merged, their
+// lists appended to each other, and the values of a later file override those of an earlier one. The config is
+// returned unchanged if it has no include directive.
+func (r *Resolver) Resolve(data []byte, dir string) ([]byte, error) {
+	doc := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, errors.Wrap(err, "parsing the config")
+	}
+	if _, ok := doc[Key]; !ok {
+		return data, nil
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "resolving %s", dir)
+	}
+	merged, err := r.resolve(doc, source{dir: absDir}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(merged)
+}
+
+// source is where a config was read from, either a local file or directory or a URL
+type source struct {
+	// dir is the directory of a local file
+	dir string
+	// url is the URL of a remote file
+	url *url.URL
+}
+
+func (r *Resolver) resolve(doc map[string]interface{}, src source, stack []string) (map[string]interface{}, error) {
+	includes, err := parseIncludes(doc[Key])
+	if err != nil {
+		return nil, err
+	}
+	delete(doc, Key)
+
+	merged := map[string]interface{}{}
+	for i, include := range includes {
+		names, err := r.expand(include, src)
+		if err != nil {
+			return nil, errors.Wrapf(err, "include #%d", i)
+		}
+		for _, name := range names {
+			for _, s := range stack {
+				if s == name {
+					return nil, errors.Errorf("include cycle: %s -> %s", strings.Join(stack, " -> "), name)
+				}
+			}
+			included, includedSrc, err := r.read(name, include.SHA256)
+			if err != nil {
+				return nil, err
+			}
+			includedDoc := map[string]interface{}{}
+			if err := yaml.Unmarshal(included, &includedDoc); err != nil {
+				return nil, errors.Wrapf(err, "parsing %s", name)
+			}
+			resolved, err := r.resolve(includedDoc, includedSrc, append(append([]string{}, stack...), name))
+			if err != nil {
+				return nil, err
+			}
+			merge(merged, resolved)
+		}
+	}
+	merge(merged, doc)
+	return merged, nil
+}
+
+func parseIncludes(value interface{}) ([]Include, error) {
+	data, err := yaml.Marshal(value)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid %s", Key)
+	}
+	var includes []Include
+	if err := yaml.UnmarshalStrict(data, &includes); err != nil {
+		return nil, errors.Wrapf(err, "invalid %s", Key)
+	}
+	for i, include := range includes {
+		if (include.Path == "") == (include.URL == "") {
+			return nil, errors.Errorf("include #%d needs either a path or a url", i)
+		}
+	}
+	return includes, nil
+}
+
+// expand returns the absolute paths or the URLs the include directive includes
+func (r *Resolver) expand(include Include, src source) ([]string, error) {
+	if include.URL != "" || src.url != nil {
+		u, err := url.Parse(include.URL)
+		if include.URL == "" {
+			u, err = src.url.Parse(include.Path)
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid url")
+		}
+		if u.Scheme != "https" {
+			return nil, errors.Errorf("%s is not an https URL", u)
+		}
+		if include.SHA256 == "" {
+			return nil, errors.Errorf("%s has no sha256", u)
+		}
+		return []string{u.String()}, nil
+	}
+
+	path := include.Path
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(src.dir, path)
+	}
+	files, err := ioutil.ReadDir(path)
+	if err != nil {
+		// not a directory
+		return []string{filepath.Clean(path)}, nil
+	}
+	var answer []string
+	for _, f := range files {
+		// skipping the hidden entries such as the ..data link of the mounted ConfigMaps
+		name := f.Name()
+		if strings.HasPrefix(name, ".") || (filepath.Ext(name) != ".yaml" && filepath.Ext(name) != ".yml") {
+			continue
+		}
+		answer = append(answer, filepath.Join(path, name))
+	}
+	sort.Strings(answer)
+	return answer, nil
+}
+
+// read reads the file or URL, verifying the checksum of a URL
+func (r *Resolver) read(name, checksum string) ([]byte, source, error) {
+	u, err := url.Parse(name)
+	if err != nil || u.Scheme != "https" {
+		data, err := ioutil.ReadFile(name)
+		if err != nil {
+			return nil, source{}, errors.Wrapf(err, "reading %s", name)
+		}
+		return data, source{dir: filepath.Dir(name)}, nil
+	}
+
+	client := r.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	resp, err := client.Get(name)
+	if err != nil {
+		return nil, source{}, errors.Wrapf(err, "fetching %s", name)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, source{}, errors.Errorf("fetching %s: %s", name, resp.Status)
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxURLSize+1))
+	if err != nil {
+		return nil, source{}, errors.Wrapf(err, "fetching %s", name)
+	}
+	if len(data) > maxURLSize {
+		return nil, source{}, errors.Errorf("%s is larger than %d bytes", name, maxURLSize)
+	}
+	sum := sha256.Sum256(data)
+	if actual := hex.EncodeToString(sum[:]); !strings.EqualFold(actual, checksum) {
+		return nil, source{}, errors.Errorf("the sha256 of %s is %s rather than %s", name, actual, checksum)
+	}
+	return data, source{url: u}, nil
+}
+
+// merge merges from into the config: maps are merged, lists are appended and other values overridden
+func merge(into, from map[string]interface{}) {
+	for k, v := range from {
+		switch existing := into[k].(type) {
+		case map[string]interface{}:
+			if m, ok := v.(map[string]interface{}); ok {
+				merge(existing, m)
+				continue
+			}
+		case []interface{}:
+			if l, ok := v.([]interface{}); ok {
+				into[k] = append(existing, l...)
+				continue
+			}
+		}
+		into[k] = v
+	}
+}
@@ -0,0 +1,161 @@
+package configinclude
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+	}
+}
+
+func TestResolve(t *testing.T) {
+	dir, err := ioutil.TempDir("", "configinclude")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	writeFiles(t, dir, map[string]string{
+		"teams/a.yaml": `
+presubmits:
+  org/a:
+  - name: lint
+    context: lint
+    agent: tekton
+`,
+		"teams/b.yml": `
+include:
+- path: ../shared/periodics.yaml
+presubmits:
+  org/b:
+  - name: unit
+    context: unit
+    agent: tekton
+`,
+		"teams/README.md":    "not included",
+		"teams/.hidden.yaml": "not: included",
+		"shared/periodics.yaml": `
+periodics:
+- name: nightly
+  cron: "0 0 * * *"
+  agent: tekton
+`,
+	})
+
+	data, err := Resolve([]byte(`
+include:
+- path: teams
+presubmits:
+  org/a:
+  - name: build
+    context: build
+    agent: tekton
+`), dir)
+	require.NoError(t, err)
+
+	cfg, err := config.LoadYAMLConfig(data)
+	require.NoError(t, err)
+	var names []string
+	for _, job := range cfg.Presubmits["org/a"] {
+		names = append(names, job.Name)
+	}
+	assert.Equal(t, []string{"lint", "build"}, names)
+	require.Len(t, cfg.Presubmits["org/b"], 1)
+	require.Len(t, cfg.Periodics, 1)
+	assert.Equal(t, "nightly", cfg.Periodics[0].Name)
+
+	again, err := Resolve([]byte("include:\n- path: teams\n"), dir)
+	require.NoError(t, err)
+	again2, err := Resolve([]byte("include:\n- path: teams\n"), dir)
+	require.NoError(t, err)
+	assert.Equal(t, string(again), string(again2), "the includes should be merged deterministically")
+
+	text := "presubmits: {}\n"
+	unchanged, err := Resolve([]byte(text), dir)
+	require.NoError(t, err)
+	assert.Equal(t, text, string(unchanged))
+}
+
+func TestResolveOverrides(t *testing.T) {
+	dir, err := ioutil.TempDir("", "configinclude")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	writeFiles(t, dir, map[string]string{
+		"defaults.yaml": "keeper:\n  sync_period: 1m\n  target_url: https://keeper.example.com\n",
+	})
+
+	data, err := Resolve([]byte("include:\n- path: defaults.yaml\nkeeper:\n  sync_period: 2m\n"), dir)
+	require.NoError(t, err)
+	merged := map[string]interface{}{}
+	require.NoError(t, yaml.Unmarshal(data, &merged))
+	assert.Equal(t, map[string]interface{}{
+		"keeper": map[string]interface{}{"sync_period": "2m", "target_url": "https://keeper.example.com"},
+	}, merged)
+}
+
+func TestResolveCycle(t *testing.T) {
+	dir, err := ioutil.TempDir("", "configinclude")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	writeFiles(t, dir, map[string]string{
+		"a.yaml": "include:\n- path: b.yaml\n",
+		"b.yaml": "include:\n- path: a.yaml\n",
+	})
+
+	_, err = Resolve([]byte("include:\n- path: a.yaml\n"), dir)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "include cycle")
+}
+
+func TestResolveURL(t *testing.T) {
+	remote := "presubmits:\n  org/remote:\n  - name: unit\n    context: unit\n    agent: tekton\n"
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/config.yaml":
+			_, _ = w.Write([]byte("include:\n- path: remote.yaml\n  sha256: " + checksum(remote) + "\n"))
+		case "/remote.yaml":
+			_, _ = w.Write([]byte(remote))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	r := &Resolver{Client: server.Client()}
+
+	root := "include:\n- url: " + server.URL + "/config.yaml\n  sha256: %s\n"
+	configSum := checksum("include:\n- path: remote.yaml\n  sha256: " + checksum(remote) + "\n")
+	data, err := r.Resolve([]byte(fmt.Sprintf(root, configSum)), "")
+	require.NoError(t, err)
+	cfg, err := config.LoadYAMLConfig(data)
+	require.NoError(t, err)
+	assert.Len(t, cfg.Presubmits["org/remote"], 1)
+
+	_, err = r.Resolve([]byte(fmt.Sprintf(root, checksum("other"))), "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "sha256")
+
+	_, err = r.Resolve([]byte("include:\n- url: "+server.URL+"/config.yaml\n"), "")
+	require.Error(t, err, "a URL should need a checksum")
+
+	_, err = r.Resolve([]byte("include:\n- url: http://example.com/config.yaml\n  sha256: abc\n"), "")
+	require.Error(t, err, "a URL should need https")
+}
+
+func checksum(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,187 @@
+// Package configshards merges the jobs of the LighthouseConfig custom resources into the config.yaml of the
+// lighthouse ConfigMap. Each LighthouseConfig claims some orgs and may only define the jobs of their
+// repositories, so that the teams can maintain their jobs in their own namespaces with their own RBAC.
+package configshards
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/tenants"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+// shardConfig is the part of config.yaml a LighthouseConfig may hold
+type shardConfig struct {
+	Presubmits  map[string][]interface{} `json:"presubmits,omitempty"`
+	Postsubmits map[string][]interface{} `json:"postsubmits,omitempty"`
+	Periodics   []interface{}            `json:"periodics,omitempty"`
+}
+
+// Key returns the namespace/name key of a LighthouseConfig
+func Key(shard *v1alpha1.LighthouseConfig) string {
+	return shard.Namespace + "/" + shard.Name
+}
+
+// Merge merges the jobs of the LighthouseConfigs into the config.yaml, returning the effective config and why the
+// LighthouseConfigs which were left out of it were rejected, by key. A LighthouseConfig is rejected when it claims
+// an org already claimed by an older LighthouseConfig, defines jobs outside of its orgs or for repositories of the
+// ConfigMap, or makes the config invalid, without preventing the other LighthouseConfigs from being merged. When
+// the config.yaml defines tenants, the orgs of a tenant can only be claimed by a LighthouseConfig of the namespace
+// of the tenant, which cannot claim any other org.
+func Merge(base string, shards []v1alpha1.LighthouseConfig) (*config.Config, map[string]error, error) {
+	cfg, err := config.LoadYAMLConfig([]byte(base))
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "loading the config.yaml")
+	}
+	merged := map[string]interface{}{}
+	if err := yaml.Unmarshal([]byte(base), &merged); err != nil {
+		return nil, nil, errors.Wrap(err, "parsing the config.yaml")
+	}
+	tenantConfig, err := tenants.LoadConfig([]byte(base))
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "loading the tenants of the config.yaml")
+	}
+
+	// the oldest LighthouseConfig claiming an org gets it
+	shards = append([]v1alpha1.LighthouseConfig{}, shards...)
+	sort.SliceStable(shards, func(i, j int) bool {
+		ti, tj := shards[i].CreationTimestamp, shards[j].CreationTimestamp
+		if !ti.Equal(&tj) {
+			return ti.Before(&tj)
+		}
+		return Key(&shards[i]) < Key(&shards[j])
+	})
+
+	rejected := map[string]error{}
+	claimed := map[string]string{}
+	for i := range shards {
+		shard := &shards[i]
+		key := Key(shard)
+		if err := checkTenant(tenantConfig, shard); err != nil {
+			rejected[key] = err
+			continue
+		}
+		if err := claim(claimed, shard); err != nil {
+			rejected[key] = err
+			continue
+		}
+		candidate, err := mergeShard(merged, shard)
+		if err == nil {
+			var data []byte
+			data, err = yaml.Marshal(candidate)
+			if err == nil {
+				var c *config.Config
+				c, err = config.LoadYAMLConfig(data)
+				if err == nil {
+					cfg, merged = c, candidate
+					continue
+				}
+			}
+		}
+		rejected[key] = err
+		// the orgs of a rejected LighthouseConfig can be claimed by another one
+		for _, org := range shard.Spec.Orgs {
+			if claimed[strings.ToLower(org)] == key {
+				delete(claimed, strings.ToLower(org))
+			}
+		}
+	}
+	return cfg, rejected, nil
+}
+
+// claim records the orgs of the LighthouseConfig unless another one already claimed them or their repositories
+func claim(claimed map[string]string, shard *v1alpha1.LighthouseConfig) error {
+	key := Key(shard)
+	if len(shard.Spec.Orgs) == 0 {
+		return errors.New("spec.orgs is empty")
+	}
+	for _, org := range shard.Spec.Orgs {
+		org = strings.ToLower(org)
+		for other, owner := range claimed {
+			if other == org || strings.HasPrefix(other, org+"/") || strings.HasPrefix(org, other+"/") {
+				return errors.Errorf("%s is already claimed by LighthouseConfig %s", org, owner)
+			}
+		}
+	}
+	for _, org := range shard.Spec.Orgs {
+		claimed[strings.ToLower(org)] = key
+	}
+	return nil
+}
+
+// checkTenant returns an error if the LighthouseConfig of the namespace of a tenant claims orgs outside of the
+// tenant, or if a LighthouseConfig of another namespace claims orgs of a tenant
+func checkTenant(cfg *tenants.Config, shard *v1alpha1.LighthouseConfig) error {
+	tenant := cfg.ForNamespace(shard.Namespace)
+	for _, org := range shard.Spec.Orgs {
+		if tenant != nil && !tenant.Owns(org) {
+			return errors.Errorf("%s is outside of the orgs of tenant %s", org, tenant.Name)
+		}
+		for i := range cfg.Tenants {
+			t := &cfg.Tenants[i]
+			if t != tenant && t.Overlaps(org) {
+				return errors.Errorf("%s belongs to tenant %s whose LighthouseConfigs must be in namespace %s", org, t.Name, t.Namespace)
+			}
+		}
+	}
+	return nil
+}
+
+// owns returns true if the LighthouseConfig claimed the org or the repository
+func owns(shard *v1alpha1.LighthouseConfig, repo string) bool {
+	repo = strings.ToLower(repo)
+	for _, org := range shard.Spec.Orgs {
+		org = strings.ToLower(org)
+		if repo == org || strings.HasPrefix(repo, org+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeShard returns a copy of the merged config.yaml with the jobs of the LighthouseConfig
+func mergeShard(merged map[string]interface{}, shard *v1alpha1.LighthouseConfig) (map[string]interface{}, error) {
+	sc := shardConfig{}
+	if err := yaml.UnmarshalStrict([]byte(shard.Spec.Config), &sc); err != nil {
+		return nil, errors.Wrap(err, "parsing spec.config, which may only hold presubmits, postsubmits and periodics")
+	}
+	candidate := map[string]interface{}{}
+	for k, v := range merged {
+		candidate[k] = v
+	}
+	for _, kind := range []struct {
+		field string
+		jobs  map[string][]interface{}
+	}{
+		{field: "presubmits", jobs: sc.Presubmits},
+		{field: "postsubmits", jobs: sc.Postsubmits},
+	} {
+		if len(kind.jobs) == 0 {
+			continue
+		}
+		existing, _ := candidate[kind.field].(map[string]interface{})
+		repos := map[string]interface{}{}
+		for repo, jobs := range existing {
+			repos[repo] = jobs
+		}
+		for repo, jobs := range kind.jobs {
+			if !owns(shard, repo) {
+				return nil, errors.Errorf("%s of %s are outside of the orgs of the LighthouseConfig", kind.field, repo)
+			}
+			if _, ok := repos[repo]; ok {
+				return nil, errors.Errorf("%s of %s are already configured", kind.field, repo)
+			}
+			repos[repo] = jobs
+		}
+		candidate[kind.field] = repos
+	}
+	if len(sc.Periodics) > 0 {
+		existing, _ := candidate["periodics"].([]interface{})
+		candidate["periodics"] = append(append([]interface{}{}, existing...), sc.Periodics...)
+	}
+	return candidate, nil
+}
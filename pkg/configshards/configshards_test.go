@@ -0,0 +1,213 @@
+package configshards
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	lhfake "github.com/jenkins-x/lighthouse/pkg/client/clientset/versioned/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const base = `
+presubmits:
+  platform/infra:
+  - name: lint
+    context: lint
+    agent: tekton
+`
+
+func makeShard(namespace, name string, age time.Duration, cfg string, orgs ...string) v1alpha1.LighthouseConfig {
+	return v1alpha1.LighthouseConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:         namespace,
+			Name:              name,
+			CreationTimestamp: metav1.NewTime(time.Date(2020, 6, 15, 12, 0, 0, 0, time.UTC).Add(-age)),
+		},
+		Spec: v1alpha1.LighthouseConfigSpec{Orgs: orgs, Config: cfg},
+	}
+}
+
+func jobNames(cfg *config.Config) []string {
+	var names []string
+	for _, jobs := range cfg.Presubmits {
+		for _, job := range jobs {
+			names = append(names, job.Name)
+		}
+	}
+	for _, jobs := range cfg.Postsubmits {
+		for _, job := range jobs {
+			names = append(names, job.Name)
+		}
+	}
+	for _, job := range cfg.Periodics {
+		names = append(names, job.Name)
+	}
+	return names
+}
+
+func TestMerge(t *testing.T) {
+	teamA := makeShard("team-a", "config", 2*time.Hour, `
+presubmits:
+  team-a/app:
+  - name: unit
+    context: unit
+    agent: tekton
+postsubmits:
+  team-a/app:
+  - name: release
+    agent: tekton
+periodics:
+- name: nightly
+  cron: "0 2 * * *"
+  agent: tekton
+`, "team-a")
+	testCases := []struct {
+		name     string
+		shards   []v1alpha1.LighthouseConfig
+		expected []string
+		rejected map[string]string
+	}{
+		{
+			name:     "no LighthouseConfig",
+			expected: []string{"lint"},
+		},
+		{
+			name:     "jobs merged",
+			shards:   []v1alpha1.LighthouseConfig{teamA},
+			expected: []string{"lint", "unit", "release", "nightly"},
+		},
+		{
+			name: "org already claimed",
+			shards: []v1alpha1.LighthouseConfig{
+				makeShard("team-b", "config", time.Hour, "", "team-a/app"),
+				teamA,
+			},
+			expected: []string{"lint", "unit", "release", "nightly"},
+			rejected: map[string]string{"team-b/config": "team-a/app is already claimed by LighthouseConfig team-a/config"},
+		},
+		{
+			name: "jobs outside of the orgs",
+			shards: []v1alpha1.LighthouseConfig{makeShard("team-b", "config", time.Hour, `
+presubmits:
+  team-a/app:
+  - name: steal
+    context: steal
+`, "team-b")},
+			expected: []string{"lint"},
+			rejected: map[string]string{"team-b/config": "presubmits of team-a/app are outside of the orgs of the LighthouseConfig"},
+		},
+		{
+			name: "repository of the ConfigMap",
+			shards: []v1alpha1.LighthouseConfig{makeShard("platform", "config", time.Hour, `
+presubmits:
+  platform/infra:
+  - name: unit
+    context: unit
+`, "platform")},
+			expected: []string{"lint"},
+			rejected: map[string]string{"platform/config": "presubmits of platform/infra are already configured"},
+		},
+		{
+			name: "global settings",
+			shards: []v1alpha1.LighthouseConfig{makeShard("team-b", "config", time.Hour, `
+tide:
+  queries: []
+`, "team-b")},
+			expected: []string{"lint"},
+			rejected: map[string]string{"team-b/config": `parsing spec.config, which may only hold presubmits, postsubmits and periodics: error unmarshaling JSON: while decoding JSON: json: unknown field "tide"`},
+		},
+		{
+			name: "invalid jobs",
+			shards: []v1alpha1.LighthouseConfig{teamA, makeShard("team-b", "config", time.Hour, `
+periodics:
+- name: nightly
+  cron: "0 3 * * *"
+  agent: tekton
+`, "team-b")},
+			expected: []string{"lint", "unit", "release", "nightly"},
+			rejected: map[string]string{"team-b/config": "duplicated periodic job : nightly"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg, rejected, err := Merge(base, tc.shards)
+			require.NoError(t, err)
+			assert.ElementsMatch(t, tc.expected, jobNames(cfg))
+			messages := map[string]string{}
+			for key, err := range rejected {
+				messages[key] = err.Error()
+			}
+			if tc.rejected == nil {
+				tc.rejected = map[string]string{}
+			}
+			assert.Equal(t, tc.rejected, messages)
+		})
+	}
+}
+
+func TestMergeTenants(t *testing.T) {
+	tenantBase := base + `
+tenants:
+- name: team-a
+  namespace: team-a
+  orgs:
+  - team-a
+  - shared/frontend
+`
+	shards := []v1alpha1.LighthouseConfig{
+		makeShard("team-a", "config", 3*time.Hour, `
+presubmits:
+  shared/frontend:
+  - name: unit
+    context: unit
+    agent: tekton
+`, "team-a", "shared/frontend"),
+		makeShard("team-a", "escape", 2*time.Hour, "", "other"),
+		makeShard("jx", "steal", time.Hour, "", "shared"),
+		makeShard("jx", "config", time.Hour, "", "other"),
+	}
+	cfg, rejected, err := Merge(tenantBase, shards)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"lint", "unit"}, jobNames(cfg))
+	messages := map[string]string{}
+	for key, err := range rejected {
+		messages[key] = err.Error()
+	}
+	assert.Equal(t, map[string]string{
+		"team-a/escape": "other is outside of the orgs of tenant team-a",
+		"jx/steal":      "shared belongs to tenant team-a whose LighthouseConfigs must be in namespace team-a",
+	}, messages)
+}
+
+func TestWatcher(t *testing.T) {
+	shard := makeShard("team-a", "config", time.Hour, `
+presubmits:
+  team-a/app:
+  - name: unit
+    context: unit
+    agent: tekton
+`, "team-a")
+	lhClient := lhfake.NewSimpleClientset(&shard)
+
+	configs := make(chan *config.Config, 10)
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	w, err := NewWatcher(lhClient, "", func(cfg *config.Config) { configs <- cfg }, stopCh)
+	require.NoError(t, err)
+	assert.Empty(t, configs, "the config is only merged once the config.yaml is known")
+
+	w.SetBase(base)
+	assert.ElementsMatch(t, []string{"lint", "unit"}, jobNames(<-configs))
+
+	require.NoError(t, lhClient.LighthouseV1alpha1().LighthouseConfigs("team-a").Delete("config", &metav1.DeleteOptions{}))
+	select {
+	case cfg := <-configs:
+		assert.ElementsMatch(t, []string{"lint"}, jobNames(cfg))
+	case <-time.After(5 * time.Second):
+		t.Fatal("the config was not updated when the LighthouseConfig was deleted")
+	}
+}
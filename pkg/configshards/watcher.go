@@ -0,0 +1,127 @@
+package configshards
+
+import (
+	"sync"
+	"time"
+
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	clientset "github.com/jenkins-x/lighthouse/pkg/client/clientset/versioned"
+	informers "github.com/jenkins-x/lighthouse/pkg/client/informers/externalversions"
+	"github.com/jenkins-x/lighthouse/pkg/configinclude"
+	"github.com/jenkins-x/lighthouse/pkg/interrupts"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/tools/cache"
+)
+
+// resyncPeriod is how often the LighthouseConfigs are listed again
+const resyncPeriod = 10 * time.Minute
+
+// Watcher watches the LighthouseConfigs, calling back with the effective config whenever they or the config.yaml
+// change
+type Watcher struct {
+	onChange func(*config.Config)
+	logger   *logrus.Entry
+
+	lock   sync.Mutex
+	base   string
+	shards map[string]v1alpha1.LighthouseConfig
+}
+
+// NewWatcher creates a watcher of the LighthouseConfigs of the namespace, or of every namespace if it is empty,
+// which waits for them to be listed
+func NewWatcher(lhClient clientset.Interface, namespace string, onChange func(*config.Config), stopCh <-chan struct{}) (*Watcher, error) {
+	w := &Watcher{
+		onChange: onChange,
+		logger:   logrus.WithField("component", "LighthouseConfigWatcher"),
+		shards:   map[string]v1alpha1.LighthouseConfig{},
+	}
+	factory := informers.NewSharedInformerFactoryWithOptions(lhClient, resyncPeriod, informers.WithNamespace(namespace))
+	informer := factory.Lighthouse().V1alpha1().LighthouseConfigs().Informer()
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			w.set(obj)
+		},
+		UpdateFunc: func(_, obj interface{}) {
+			w.set(obj)
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if shard, ok := obj.(*v1alpha1.LighthouseConfig); ok {
+				w.update(func() { delete(w.shards, Key(shard)) })
+			}
+		},
+	})
+	factory.Start(stopCh)
+	if ok := cache.WaitForCacheSync(stopCh, informer.HasSynced); !ok {
+		return nil, errors.New("failed to wait for the LighthouseConfigs to be listed")
+	}
+	return w, nil
+}
+
+// WatchFile sets the config.yaml from the file with its includes, reading it again every interval, for the
+// components which mount the ConfigMap rather than watching it
+func (w *Watcher) WatchFile(path string, interval time.Duration) error {
+	var last string
+	load := func() error {
+		data, err := configinclude.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if text := string(data); text != last {
+			last = text
+			w.SetBase(text)
+		}
+		return nil
+	}
+	if err := load(); err != nil {
+		return err
+	}
+	interrupts.TickLiteral(func() {
+		if err := load(); err != nil {
+			w.logger.WithError(err).Warn("failed to reload the config.yaml")
+		}
+	}, interval)
+	return nil
+}
+
+// SetBase sets the config.yaml of the ConfigMap the LighthouseConfigs are merged into
+func (w *Watcher) SetBase(text string) {
+	w.update(func() { w.base = text })
+}
+
+func (w *Watcher) set(obj interface{}) {
+	shard, ok := obj.(*v1alpha1.LighthouseConfig)
+	if !ok {
+		w.logger.Errorf("unexpected object %#v", obj)
+		return
+	}
+	w.update(func() { w.shards[Key(shard)] = *shard.DeepCopy() })
+}
+
+// update applies the change then calls back with the effective config, once the config.yaml is known
+func (w *Watcher) update(change func()) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	change()
+	if w.base == "" {
+		return
+	}
+	var shards []v1alpha1.LighthouseConfig
+	for _, shard := range w.shards {
+		shards = append(shards, shard)
+	}
+	cfg, rejected, err := Merge(w.base, shards)
+	if err != nil {
+		w.logger.WithError(err).Error("Error processing the prow Config YAML")
+		return
+	}
+	for key, err := range rejected {
+		w.logger.WithError(err).WithField("lighthouseConfig", key).Error("Ignoring the LighthouseConfig")
+	}
+	w.logger.Infof("updating the prow core configuration with %d LighthouseConfigs", len(shards)-len(rejected))
+	w.onChange(cfg)
+}
@@ -0,0 +1,126 @@
+/*
+Package externalplugin is a harness for writing lighthouse external plugins, which are HTTP services the hook
+forwards the webhooks of the repositories they are enabled for to, as configured in the external_plugins
+section of plugins.yaml.
+
+A plugin registers handlers for the events it is interested in and serves them:
+
+	func main() {
+		server, err := externalplugin.NewServer(externalplugin.Plugin{
+			Name:         "greeter",
+			HelpProvider: helpProvider,
+			SCMClient:    externalplugin.SCMClientFromEnv,
+			PullRequestHandler: func(agent externalplugin.Agent, event *scm.PullRequestHook) error {
+				if event.Action != scm.ActionOpen {
+					return nil
+				}
+				repo := event.Repo
+				return agent.SCMClient.CreateComment(repo.Namespace, repo.Name, event.PullRequest.Number, true, "Hello!")
+			},
+		})
+		if err != nil {
+			logrus.WithError(err).Fatal("creating the plugin server")
+		}
+		server.Run(externalplugin.DefaultPort)
+	}
+
+The server validates the signature of the payloads with the HMAC tokens in $HMAC_TOKEN, decodes them with the
+go-scm parser of $GIT_KIND and calls the handler of the event. The plugin help is served at /help.
+*/
+package externalplugin
+
+import (
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse/pkg/pluginhelp"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	"github.com/sirupsen/logrus"
+)
+
+// Agent holds the context a handler is called with
+type Agent struct {
+	// Logger has the fields of the event
+	Logger *logrus.Entry
+	// SCMClient is created by the SCMClient factory of the plugin, or is nil if the plugin has none
+	SCMClient scmprovider.SCMClient
+	// Delivery is the ID the provider gave the webhook delivery, if any
+	Delivery string
+}
+
+// PullRequestHandler handles pull request events
+type PullRequestHandler func(Agent, *scm.PullRequestHook) error
+
+// PullRequestCommentHandler handles comments on pull requests
+type PullRequestCommentHandler func(Agent, *scm.PullRequestCommentHook) error
+
+// IssueCommentHandler handles comments on issues and, for some providers, on pull requests
+type IssueCommentHandler func(Agent, *scm.IssueCommentHook) error
+
+// PushHandler handles push events
+type PushHandler func(Agent, *scm.PushHook) error
+
+// ReviewHandler handles pull request review events
+type ReviewHandler func(Agent, *scm.ReviewHook) error
+
+// WebhookHandler handles the events without a typed handler
+type WebhookHandler func(Agent, scm.Webhook) error
+
+// HelpProvider returns the help of the plugin given the org and org/repo names it is enabled for
+type HelpProvider func(enabledRepos []string) (*pluginhelp.PluginHelp, error)
+
+// Plugin describes an external plugin and the handlers of the events it processes
+type Plugin struct {
+	// Name of the plugin, as referenced in plugins.yaml
+	Name string
+	// HelpProvider describes the plugin, a minimal help is served if it is nil
+	HelpProvider HelpProvider
+
+	PullRequestHandler        PullRequestHandler
+	PullRequestCommentHandler PullRequestCommentHandler
+	IssueCommentHandler       IssueCommentHandler
+	PushHandler               PushHandler
+	ReviewHandler             ReviewHandler
+	// WebhookHandler is called for every other kind of event
+	WebhookHandler WebhookHandler
+
+	// SCMClient creates the client handlers use to call the SCM provider, see SCMClientFromEnv
+	SCMClient func() (scmprovider.SCMClient, error)
+	// Tokens returns the HMAC tokens payloads may be signed with, defaulting to those in $HMAC_TOKEN. No
+	// signature is required if there are no tokens.
+	Tokens func() ([]string, error)
+	// GitKind is the kind of SCM provider sending the webhooks, defaulting to $GIT_KIND or github
+	GitKind string
+	// GitServer is the URL of the SCM provider, defaulting to $GIT_SERVER
+	GitServer string
+	// MaxPayloadSize is the largest payload accepted, in bytes
+	MaxPayloadSize int64
+}
+
+// handle calls the handler of the event, returning false if the plugin has none
+func (p *Plugin) handle(agent Agent, webhook scm.Webhook) (bool, error) {
+	switch event := webhook.(type) {
+	case *scm.PullRequestHook:
+		if p.PullRequestHandler != nil {
+			return true, p.PullRequestHandler(agent, event)
+		}
+	case *scm.PullRequestCommentHook:
+		if p.PullRequestCommentHandler != nil {
+			return true, p.PullRequestCommentHandler(agent, event)
+		}
+	case *scm.IssueCommentHook:
+		if p.IssueCommentHandler != nil {
+			return true, p.IssueCommentHandler(agent, event)
+		}
+	case *scm.PushHook:
+		if p.PushHandler != nil {
+			return true, p.PushHandler(agent, event)
+		}
+	case *scm.ReviewHook:
+		if p.ReviewHandler != nil {
+			return true, p.ReviewHandler(agent, event)
+		}
+	}
+	if p.WebhookHandler != nil {
+		return true, p.WebhookHandler(agent, webhook)
+	}
+	return false, nil
+}
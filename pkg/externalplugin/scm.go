@@ -0,0 +1,42 @@
+package externalplugin
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jenkins-x/lighthouse/pkg/gitprovider"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	"github.com/jenkins-x/lighthouse/pkg/secrets"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"github.com/pkg/errors"
+)
+
+// SCMClientFromEnv creates an SCM client from the same environment variables as the hook: $GIT_KIND,
+// $GIT_SERVER, $GIT_USER and the $GIT_TOKEN secret, which may be loaded from a file or Vault.
+func SCMClientFromEnv() (scmprovider.SCMClient, error) {
+	kind := os.Getenv("GIT_KIND")
+	if kind == "" {
+		kind = "github"
+	}
+	token, err := secrets.FromEnv("GIT_TOKEN").Get()
+	if err != nil {
+		return nil, err
+	}
+	if token == "" {
+		return nil, fmt.Errorf("no token available for git kind %s at environment variable $GIT_TOKEN", kind)
+	}
+	return NewSCMClient(kind, os.Getenv("GIT_SERVER"), os.Getenv("GIT_USER"), token)
+}
+
+// NewSCMClient creates an SCM client for the provider authenticating with the token of the bot user
+func NewSCMClient(kind, serverURL, botName, token string) (scmprovider.SCMClient, error) {
+	if botName == "" {
+		botName = "jenkins-x-bot"
+	}
+	client, err := gitprovider.NewSCMClient(kind, serverURL, botName, "")
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating %s client", kind)
+	}
+	util.AddAuthToSCMClient(client, token, false)
+	return scmprovider.ToClient(client, botName), nil
+}
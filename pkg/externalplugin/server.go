@@ -0,0 +1,198 @@
+package externalplugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse/pkg/gitprovider"
+	"github.com/jenkins-x/lighthouse/pkg/interrupts"
+	"github.com/jenkins-x/lighthouse/pkg/payload"
+	"github.com/jenkins-x/lighthouse/pkg/pluginhelp"
+	"github.com/jenkins-x/lighthouse/pkg/secrets"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultPort is the port external plugins listen on by default
+	DefaultPort = 8888
+	// HelpPath is the path the plugin help is served at. A POST request may send the JSON list of org and
+	// org/repo names the plugin is enabled for.
+	HelpPath = "/help"
+
+	shutdownGracePeriod = 5 * time.Second
+)
+
+// Server is an http.Handler decoding the webhooks forwarded by the hook and calling the handlers of the plugin
+type Server struct {
+	plugin    Plugin
+	scmClient *scm.Client
+	logger    *logrus.Entry
+
+	// Tracks running handlers for graceful shutdown
+	wg sync.WaitGroup
+}
+
+// NewServer creates the server of the plugin
+func NewServer(plugin Plugin) (*Server, error) {
+	if plugin.Name == "" {
+		return nil, errors.New("the plugin has no name")
+	}
+	if plugin.GitKind == "" {
+		plugin.GitKind = os.Getenv("GIT_KIND")
+	}
+	if plugin.GitKind == "" {
+		plugin.GitKind = "github"
+	}
+	if plugin.GitServer == "" {
+		plugin.GitServer = os.Getenv("GIT_SERVER")
+	}
+	if plugin.Tokens == nil {
+		plugin.Tokens = func() ([]string, error) {
+			return secrets.Tokens("HMAC_TOKEN")
+		}
+	}
+	// the client is only used to parse webhooks so it needs no credentials
+	scmClient, err := gitprovider.NewSCMClient(plugin.GitKind, plugin.GitServer, "", "")
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating the %s webhook parser", plugin.GitKind)
+	}
+	return &Server{
+		plugin:    plugin,
+		scmClient: scmClient,
+		logger:    logrus.WithField("plugin", plugin.Name),
+	}, nil
+}
+
+// Run serves the plugin on the port until the process is interrupted, letting running handlers finish
+func (s *Server) Run(port int) {
+	mux := http.NewServeMux()
+	mux.Handle("/", s)
+	server := &http.Server{Addr: ":" + strconv.Itoa(port), Handler: mux}
+	// let the handlers started before the interrupt finish
+	interrupts.Run(func(ctx context.Context) {
+		<-ctx.Done()
+		s.wg.Wait()
+	})
+	s.logger.Infof("listening on port %d", port)
+	interrupts.ListenAndServe(server, shutdownGracePeriod)
+	interrupts.WaitForGracefulShutdown()
+}
+
+// ServeHTTP serves the plugin help and handles webhooks
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == HelpPath {
+		s.serveHelp(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "405 Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	tokens, err := s.plugin.Tokens()
+	if err != nil {
+		s.logger.WithError(err).Error("failed to load the HMAC tokens")
+		http.Error(w, "500 Internal Server Error: cannot load the HMAC tokens", http.StatusInternalServerError)
+		return
+	}
+	webhook, _, err := payload.Parse(s.scmClient, r, tokens, s.plugin.MaxPayloadSize)
+	switch {
+	case err == payload.ErrTooLarge:
+		http.Error(w, "413 Request Entity Too Large", http.StatusRequestEntityTooLarge)
+		return
+	case err == scm.ErrSignatureInvalid:
+		s.logger.Warn("webhook has an invalid signature")
+		http.Error(w, "401 Unauthorized: invalid webhook signature", http.StatusUnauthorized)
+		return
+	case err != nil:
+		http.Error(w, fmt.Sprintf("400 Bad Request: %s", err.Error()), http.StatusBadRequest)
+		return
+	case webhook == nil:
+		// events go-scm does not support are ignored
+		fmt.Fprint(w, "Event ignored.")
+		return
+	}
+
+	l := s.logger.WithField("event", webhook.Kind())
+	if repo := webhook.Repository(); repo.Name != "" {
+		l = l.WithField("repo", scm.Join(repo.Namespace, repo.Name))
+	}
+	delivery := payload.DeliveryID(r.Header)
+	if delivery != "" {
+		l = l.WithField("delivery", delivery)
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.handle(Agent{Logger: l, Delivery: delivery}, webhook)
+	}()
+	fmt.Fprint(w, "Event received. Have a nice day.")
+}
+
+func (s *Server) handle(agent Agent, webhook scm.Webhook) {
+	if s.plugin.SCMClient != nil {
+		client, err := s.plugin.SCMClient()
+		if err != nil {
+			agent.Logger.WithError(err).Error("failed to create the SCM client")
+			return
+		}
+		agent.SCMClient = client
+	}
+	handled, err := s.plugin.handle(agent, webhook)
+	if err != nil {
+		agent.Logger.WithError(err).Error("failed to handle the event")
+		return
+	}
+	if handled {
+		agent.Logger.Debug("handled the event")
+	}
+}
+
+func (s *Server) serveHelp(w http.ResponseWriter, r *http.Request) {
+	var enabledRepos []string
+	if r.Method == http.MethodPost && r.Body != nil {
+		if err := json.NewDecoder(r.Body).Decode(&enabledRepos); err != nil {
+			http.Error(w, fmt.Sprintf("400 Bad Request: invalid list of repositories: %s", err.Error()), http.StatusBadRequest)
+			return
+		}
+	}
+	help := &pluginhelp.PluginHelp{Description: fmt.Sprintf("The %s external plugin.", s.plugin.Name)}
+	if s.plugin.HelpProvider != nil {
+		var err error
+		help, err = s.plugin.HelpProvider(enabledRepos)
+		if err != nil {
+			s.logger.WithError(err).Error("failed to get the plugin help")
+			http.Error(w, "500 Internal Server Error: cannot get the plugin help", http.StatusInternalServerError)
+			return
+		}
+	}
+	help.Events = s.events()
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(help); err != nil {
+		s.logger.WithError(err).Debug("failed to write the plugin help")
+	}
+}
+
+// events returns the kinds of events the plugin has handlers for
+func (s *Server) events() []string {
+	p := s.plugin
+	var events []string
+	add := func(handler bool, kind scm.WebhookKind) {
+		if handler {
+			events = append(events, string(kind))
+		}
+	}
+	add(p.PullRequestHandler != nil, scm.WebhookKindPullRequest)
+	add(p.PullRequestCommentHandler != nil, scm.WebhookKindPullRequestComment)
+	add(p.IssueCommentHandler != nil, scm.WebhookKindIssueComment)
+	add(p.PushHandler != nil, scm.WebhookKindPush)
+	add(p.ReviewHandler != nil, scm.WebhookKindReview)
+	return events
+}
@@ -0,0 +1,104 @@
+package externalplugin
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse/pkg/pluginhelp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func signedRequest(t *testing.T, event, file, token string) *http.Request {
+	body, err := ioutil.ReadFile(filepath.Join("test_data", file))
+	require.NoError(t, err)
+	mac := hmac.New(sha256.New, []byte(token))
+	_, _ = mac.Write(body)
+
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	req.Header.Set("X-GitHub-Event", event)
+	req.Header.Set("X-GitHub-Delivery", "f2467dea-70d6-11e8-8955-3c83993e0aef")
+	req.Header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	return req
+}
+
+func TestServerDispatchesTypedEvents(t *testing.T) {
+	var received *scm.PullRequestHook
+	var agent Agent
+	server, err := NewServer(Plugin{
+		Name:    "greeter",
+		GitKind: "github",
+		Tokens:  func() ([]string, error) { return []string{"new", "old"}, nil },
+		PullRequestHandler: func(a Agent, event *scm.PullRequestHook) error {
+			agent = a
+			received = event
+			return nil
+		},
+	})
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, signedRequest(t, "pull_request", "pr_opened.json", "old"))
+	server.wg.Wait()
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	require.NotNil(t, received)
+	assert.Equal(t, scm.ActionOpen, received.Action)
+	assert.Equal(t, "f2467dea-70d6-11e8-8955-3c83993e0aef", agent.Delivery)
+	assert.Nil(t, agent.SCMClient)
+}
+
+func TestServerRejectsInvalidSignatures(t *testing.T) {
+	called := false
+	server, err := NewServer(Plugin{
+		Name:    "greeter",
+		GitKind: "github",
+		Tokens:  func() ([]string, error) { return []string{"token"}, nil },
+		PullRequestHandler: func(Agent, *scm.PullRequestHook) error {
+			called = true
+			return nil
+		},
+	})
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, signedRequest(t, "pull_request", "pr_opened.json", "other"))
+	server.wg.Wait()
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.False(t, called)
+}
+
+func TestServerHelp(t *testing.T) {
+	server, err := NewServer(Plugin{
+		Name:    "greeter",
+		GitKind: "github",
+		HelpProvider: func(enabledRepos []string) (*pluginhelp.PluginHelp, error) {
+			return &pluginhelp.PluginHelp{
+				Description: "Greets new pull requests.",
+				Config:      map[string]string{"": "Enabled for " + enabledRepos[0]},
+			}, nil
+		},
+		PullRequestHandler: func(Agent, *scm.PullRequestHook) error { return nil },
+	})
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest(http.MethodPost, HelpPath, bytes.NewReader([]byte(`["myorg/myrepo"]`))))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	help := pluginhelp.PluginHelp{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &help))
+	assert.Equal(t, "Greets new pull requests.", help.Description)
+	assert.Equal(t, "Enabled for myorg/myrepo", help.Config[""])
+	assert.Equal(t, []string{"pull_request"}, help.Events)
+}
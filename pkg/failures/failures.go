@@ -0,0 +1,233 @@
+package failures
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// DefaultTailLines is the number of lines at the end of the logs of a failed job which are scanned by default
+	DefaultTailLines = 200
+
+	// maxDescriptionLength is the length commit status descriptions are truncated to, as some providers reject
+	// longer descriptions
+	maxDescriptionLength = 63
+)
+
+// Config holds the rules classifying the failed jobs from their logs, read from the failure_classification section
+// of config.yaml. The failures are classified once the section is present, with the DefaultRules if it has no rules:
+//
+//	failure_classification:
+//	  tail_lines: 500
+//	  rules:
+//	  - name: infra-flake
+//	    pattern: '(?i)connection reset by peer|i/o timeout'
+//	    description: Infra flake
+//	    hint: say /retest
+type Config struct {
+	// TailLines is the number of lines at the end of the logs which are scanned, DefaultTailLines if zero
+	TailLines int `json:"tail_lines,omitempty"`
+	// Rules are the classes of failures, the first rule matching a line of the logs classifying the failure
+	Rules []Rule `json:"rules,omitempty"`
+}
+
+// Rule is a class of failures whose logs match a regular expression
+type Rule struct {
+	// Name is the name of the class, which is recorded in the status of the classified jobs
+	Name string `json:"name"`
+	// Pattern is the regular expression matched against each line of the logs
+	Pattern string `json:"pattern"`
+	// Description replaces the generic description of the commit status of the job
+	Description string `json:"description"`
+	// Hint tells the author of the pull request what to do about the failure, e.g. say /retest
+	Hint string `json:"hint,omitempty"`
+
+	re *regexp.Regexp
+}
+
+// DefaultRules are the rules used when the failure_classification section has none. The more specific classes
+// come first, as an infrastructure problem usually fails the tests too.
+var DefaultRules = []Rule{
+	{
+		Name:        "quota-exceeded",
+		Pattern:     `(?i)(exceeded quota|quota exceeded|insufficient quota|rate limit exceeded|resourceexhausted)`,
+		Description: "Quota exceeded",
+		Hint:        "say /retest later",
+	},
+	{
+		Name:        "infra-flake",
+		Pattern:     `(?i)(connection reset by peer|connection refused|i/o timeout|tls handshake timeout|no such host|errimagepull|imagepullbackoff|503 service unavailable|the node was low on resource)`,
+		Description: "Infra flake",
+		Hint:        "say /retest",
+	},
+	{
+		Name:        "compile-error",
+		Pattern:     `(?i)(compilation (error|failed)|cannot find symbol|syntax error|error TS\d+:|\.go:\d+:\d+: )`,
+		Description: "Compile error",
+		Hint:        "fix the build",
+	},
+	{
+		Name:        "test-failure",
+		Pattern:     `(^--- FAIL: |^FAIL\s|(?i)tests? failed|\d+ failing|Failures: [1-9])`,
+		Description: "Test failure",
+		Hint:        "see the failed tests in the logs",
+	},
+}
+
+// Classification is the class of a failed job
+type Classification struct {
+	// Rule is the name of the rule which matched
+	Rule string
+	// Description and Hint are those of the rule
+	Description string
+	Hint        string
+	// Line is the line of the logs which matched
+	Line string
+}
+
+// StatusDescription returns the description of the commit status of the classified job, e.g.
+// "Infra flake — say /retest"
+func (c *Classification) StatusDescription() string {
+	answer := c.Description
+	if c.Hint != "" {
+		answer += " — " + c.Hint
+	}
+	if utf8.RuneCountInString(answer) > maxDescriptionLength {
+		answer = string([]rune(answer)[:maxDescriptionLength-3]) + "..."
+	}
+	return answer
+}
+
+// LoadConfig reads the Config from the failure_classification section of the text of config.yaml, returning nil
+// if the failures are not classified
+func LoadConfig(data []byte) (*Config, error) {
+	answer := struct {
+		FailureClassification *Config `json:"failure_classification,omitempty"`
+	}{}
+	if err := yaml.Unmarshal(data, &answer); err != nil {
+		return nil, errors.Wrap(err, "parsing the failure classification")
+	}
+	c := answer.FailureClassification
+	if c == nil {
+		return nil, nil
+	}
+	if len(c.Rules) == 0 {
+		c.Rules = append([]Rule(nil), DefaultRules...)
+	}
+	if err := c.compile(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Config) compile() error {
+	if c.TailLines < 0 {
+		return errors.Errorf("failure classification tail_lines %d is negative", c.TailLines)
+	}
+	names := map[string]bool{}
+	for i := range c.Rules {
+		r := &c.Rules[i]
+		if r.Name == "" {
+			return errors.Errorf("failure classification rule %d has no name", i)
+		}
+		if names[r.Name] {
+			return errors.Errorf("failure classification rule %s is defined twice", r.Name)
+		}
+		names[r.Name] = true
+		if r.Description == "" {
+			return errors.Errorf("failure classification rule %s has no description", r.Name)
+		}
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return errors.Wrapf(err, "compiling the pattern of failure classification rule %s", r.Name)
+		}
+		r.re = re
+	}
+	return nil
+}
+
+// Lines returns the number of lines at the end of the logs which are scanned
+func (c *Config) Lines() int {
+	if c.TailLines > 0 {
+		return c.TailLines
+	}
+	return DefaultTailLines
+}
+
+// Classify scans the last lines of the logs of a failed job against the rules, returning the class of the first rule
+// matching any of them, or nil if none does
+func (c *Config) Classify(logs io.Reader) (*Classification, error) {
+	if err := c.compiled(); err != nil {
+		return nil, err
+	}
+	lines, err := tail(logs, c.Lines())
+	if err != nil {
+		return nil, errors.Wrap(err, "reading the logs")
+	}
+	for _, r := range c.Rules {
+		// the last lines are closest to the failure
+		for i := len(lines) - 1; i >= 0; i-- {
+			if r.re.MatchString(lines[i]) {
+				return &Classification{Rule: r.Name, Description: r.Description, Hint: r.Hint, Line: lines[i]}, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// compiled compiles the rules of a Config which was not loaded with LoadConfig
+func (c *Config) compiled() error {
+	for _, r := range c.Rules {
+		if r.re == nil {
+			c.Rules = append([]Rule(nil), c.Rules...)
+			return c.compile()
+		}
+	}
+	return nil
+}
+
+// tail returns the last n lines of the logs
+func tail(logs io.Reader, n int) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(logs)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+		if len(lines) > 2*n {
+			lines = append([]string(nil), lines[len(lines)-n:]...)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines, nil
+}
+
+// Agent holds the current Config
+type Agent struct {
+	lock   sync.RWMutex
+	config *Config
+}
+
+// Set replaces the current Config
+func (a *Agent) Set(config *Config) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.config = config
+}
+
+// Config returns the current Config, which is nil if the failures are not classified
+func (a *Agent) Config() *Config {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+	return a.config
+}
@@ -0,0 +1,122 @@
+package failures
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig(t *testing.T) {
+	cfg, err := LoadConfig([]byte("keeper:\n  sync_period: 1m\n"))
+	require.NoError(t, err)
+	assert.Nil(t, cfg, "the failures are not classified without the section")
+
+	cfg, err = LoadConfig([]byte("failure_classification: {}\n"))
+	require.NoError(t, err)
+	require.NotNil(t, cfg)
+	assert.Len(t, cfg.Rules, len(DefaultRules))
+	assert.Equal(t, DefaultTailLines, cfg.Lines())
+
+	cfg, err = LoadConfig([]byte(`
+failure_classification:
+  tail_lines: 10
+  rules:
+  - name: oom
+    pattern: OOMKilled
+    description: Out of memory
+    hint: raise the memory limit
+`))
+	require.NoError(t, err)
+	require.Len(t, cfg.Rules, 1)
+	assert.Equal(t, 10, cfg.Lines())
+
+	for name, text := range map[string]string{
+		"no name":        "failure_classification:\n  rules:\n  - pattern: x\n    description: X\n",
+		"no description": "failure_classification:\n  rules:\n  - name: x\n    pattern: x\n",
+		"bad pattern":    "failure_classification:\n  rules:\n  - name: x\n    pattern: '('\n    description: X\n",
+		"duplicate":      "failure_classification:\n  rules:\n  - name: x\n    pattern: x\n    description: X\n  - name: x\n    pattern: y\n    description: Y\n",
+	} {
+		_, err := LoadConfig([]byte(text))
+		assert.Error(t, err, name)
+	}
+}
+
+func TestClassify(t *testing.T) {
+	testCases := []struct {
+		name        string
+		logs        string
+		expected    string
+		description string
+	}{
+		{
+			name:        "infra flake",
+			logs:        "Step 1/3\ndial tcp 10.0.0.1:443: i/o timeout\nFAIL\tgithub.com/org/repo/pkg\t1.2s\n",
+			expected:    "infra-flake",
+			description: "Infra flake — say /retest",
+		},
+		{
+			name:        "compile error",
+			logs:        "# github.com/org/repo/pkg\npkg/foo.go:12:3: undefined: bar\n",
+			expected:    "compile-error",
+			description: "Compile error — fix the build",
+		},
+		{
+			name:        "test failure",
+			logs:        "=== RUN   TestFoo\n--- FAIL: TestFoo (0.00s)\nFAIL\n",
+			expected:    "test-failure",
+			description: "Test failure — see the failed tests in the logs",
+		},
+		{
+			name:        "quota exceeded",
+			logs:        "Error: pods \"build\" is forbidden: exceeded quota: compute-resources\n",
+			expected:    "quota-exceeded",
+			description: "Quota exceeded — say /retest later",
+		},
+		{
+			name: "unknown failure",
+			logs: "something went wrong\n",
+		},
+	}
+	cfg := &Config{Rules: DefaultRules}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			class, err := cfg.Classify(strings.NewReader(tc.logs))
+			require.NoError(t, err)
+			if tc.expected == "" {
+				assert.Nil(t, class)
+				return
+			}
+			require.NotNil(t, class)
+			assert.Equal(t, tc.expected, class.Rule)
+			assert.Equal(t, tc.description, class.StatusDescription())
+		})
+	}
+}
+
+func TestClassifyTail(t *testing.T) {
+	var logs strings.Builder
+	logs.WriteString("connection reset by peer\n")
+	for i := 0; i < 20; i++ {
+		fmt.Fprintf(&logs, "line %d\n", i)
+	}
+	cfg := &Config{TailLines: 10, Rules: DefaultRules}
+	class, err := cfg.Classify(strings.NewReader(logs.String()))
+	require.NoError(t, err)
+	assert.Nil(t, class, "only the last lines are scanned")
+
+	cfg.TailLines = 30
+	class, err = cfg.Classify(strings.NewReader(logs.String()))
+	require.NoError(t, err)
+	require.NotNil(t, class)
+	assert.Equal(t, "infra-flake", class.Rule)
+}
+
+func TestStatusDescriptionTruncated(t *testing.T) {
+	class := &Classification{Description: "Quota exceeded", Hint: strings.Repeat("wait for the quota to be raised ", 3)}
+	description := class.StatusDescription()
+	assert.Equal(t, maxDescriptionLength, len([]rune(description)))
+	assert.True(t, strings.HasSuffix(description, "..."))
+}
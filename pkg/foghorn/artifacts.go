@@ -0,0 +1,125 @@
+package foghorn
+
+import (
+	"bytes"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/artifacts"
+	"github.com/jenkins-x/lighthouse/pkg/commentpruner"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// artifactsCommentTag identifies the comment of the pull requests comparing their artifacts
+var artifactsCommentTag = commentpruner.Tag("artifacts")
+
+// artifactsCommenter is the subset of the SCM client used to maintain the artifacts comment of pull requests
+type artifactsCommenter interface {
+	BotName() (string, error)
+	CreateComment(org, repo string, number int, pr bool, comment string) error
+	EditComment(org, repo string, number, id int, comment string, pr bool) error
+	DeleteComment(org, repo string, number, id int, pr bool) error
+	ListIssueComments(org, repo string, number int) ([]*scm.Comment, error)
+	ListPullRequestComments(org, repo string, number int) ([]*scm.Comment, error)
+}
+
+// publishedArtifacts returns the artifacts published in the last lines of the logs of the pods of the successful
+// job's PipelineRuns, or nil if the artifacts are not compared or it published none
+func (c *Controller) publishedArtifacts(ns string, job *v1alpha1.LighthouseJob) map[string]string {
+	if c.artifacts == nil || c.kubeClient == nil {
+		return nil
+	}
+	cfg := c.artifacts.Config()
+	if cfg == nil {
+		return nil
+	}
+	logs, err := c.pipelineLogs(ns, job, cfg.Lines())
+	if err != nil {
+		c.logger.WithField("job", job.Name).WithError(err).Warn("failed to get the logs of the job publishing artifacts")
+		return nil
+	}
+	published, err := artifacts.Parse(bytes.NewReader(logs))
+	if err != nil {
+		c.logger.WithField("job", job.Name).WithError(err).Warn("failed to parse the artifacts of the job")
+		return nil
+	}
+	if len(published) == 0 {
+		return nil
+	}
+	return published
+}
+
+// reportArtifacts updates the comment of the pull request comparing the artifacts of the successful presubmits of
+// its head with those of the last postsubmits of its base branch, once the job published some
+func (c *Controller) reportArtifacts(scmClient artifactsCommenter, ns string, job *v1alpha1.LighthouseJob, sha string, fields map[string]interface{}) {
+	if c.artifacts == nil || len(job.Status.Artifacts) == 0 || job.Spec.Type != config.PresubmitJob {
+		return
+	}
+	cfg := c.artifacts.Config()
+	refs := job.Spec.Refs
+	if cfg == nil || refs == nil || len(refs.Pulls) != 1 {
+		return
+	}
+	jobs, err := c.jobsForCommit(ns, job, sha)
+	if err != nil {
+		c.logger.WithFields(fields).WithError(err).Warn("failed to list the jobs of the commit for the artifacts comment")
+		return
+	}
+	base, err := c.baseArtifacts(ns, job)
+	if err != nil {
+		c.logger.WithFields(fields).WithError(err).Warn("failed to list the postsubmits of the base branch for the artifacts comment")
+		return
+	}
+	deltas, err := cfg.Compare(base, latestArtifacts(jobs))
+	if err != nil {
+		c.logger.WithFields(fields).WithError(err).Warn("failed to compare the artifacts")
+		return
+	}
+	comments := commentpruner.NewEventClient(scmClient, c.logger.WithFields(fields), refs.Org, refs.Repo, refs.Pulls[0].Number)
+	if err := comments.UpsertComment(true, artifactsCommentTag, artifacts.Comment(deltas, sha, refs.BaseRef)); err != nil {
+		c.logger.WithFields(fields).WithError(err).Warn("failed to update the artifacts comment on the PR")
+	}
+}
+
+// baseArtifacts returns the artifacts last published by the successful postsubmits of the base branch of the job
+func (c *Controller) baseArtifacts(ns string, job *v1alpha1.LighthouseJob) (map[string]string, error) {
+	selector := labels.Set{config.LighthouseJobTypeLabel: string(config.PostsubmitJob)}
+	for _, key := range []string{util.OrgLabel, util.RepoLabel} {
+		if value, ok := job.Labels[key]; ok {
+			selector[key] = value
+		}
+	}
+	list, err := c.lhLister.LighthouseJobs(ns).List(labels.SelectorFromSet(selector))
+	if err != nil {
+		return nil, err
+	}
+	var postsubmits []*v1alpha1.LighthouseJob
+	for _, j := range list {
+		// the branch label may have been shortened to be a valid label value
+		if j.Spec.Refs != nil && j.Spec.Refs.BaseRef == job.Spec.Refs.BaseRef {
+			postsubmits = append(postsubmits, j)
+		}
+	}
+	return latestArtifacts(postsubmits), nil
+}
+
+// latestArtifacts merges the artifacts of the successful jobs, the value of the job which completed last winning
+func latestArtifacts(jobs []*v1alpha1.LighthouseJob) map[string]string {
+	answer := map[string]string{}
+	completed := map[string]*v1alpha1.LighthouseJob{}
+	for _, j := range jobs {
+		if j.Status.State != v1alpha1.SuccessState || j.Status.CompletionTime == nil {
+			continue
+		}
+		for name, value := range j.Status.Artifacts {
+			if previous, ok := completed[name]; ok && j.Status.CompletionTime.Before(previous.Status.CompletionTime) {
+				continue
+			}
+			completed[name] = j
+			answer[name] = value
+		}
+	}
+	return answer
+}
@@ -0,0 +1,172 @@
+package foghorn
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/artifacts"
+	lhlisters "github.com/jenkins-x/lighthouse/pkg/client/listers/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+// fakeCommenter keeps the comments of the bot on the pull requests
+type fakeCommenter struct {
+	comments map[int][]*scm.Comment
+	nextID   int
+}
+
+func (f *fakeCommenter) BotName() (string, error) {
+	return "bot", nil
+}
+
+func (f *fakeCommenter) CreateComment(org, repo string, number int, pr bool, comment string) error {
+	f.nextID++
+	f.comments[number] = append(f.comments[number], &scm.Comment{ID: f.nextID, Body: comment, Author: scm.User{Login: "bot"}})
+	return nil
+}
+
+func (f *fakeCommenter) EditComment(org, repo string, number, id int, comment string, pr bool) error {
+	for _, c := range f.comments[number] {
+		if c.ID == id {
+			c.Body = comment
+		}
+	}
+	return nil
+}
+
+func (f *fakeCommenter) DeleteComment(org, repo string, number, id int, pr bool) error {
+	var kept []*scm.Comment
+	for _, c := range f.comments[number] {
+		if c.ID != id {
+			kept = append(kept, c)
+		}
+	}
+	f.comments[number] = kept
+	return nil
+}
+
+func (f *fakeCommenter) ListIssueComments(org, repo string, number int) ([]*scm.Comment, error) {
+	return f.comments[number], nil
+}
+
+func (f *fakeCommenter) ListPullRequestComments(org, repo string, number int) ([]*scm.Comment, error) {
+	return f.comments[number], nil
+}
+
+func artifactsJob(name string, kind config.PipelineKind, baseRef string, completed time.Time, published map[string]string) *v1alpha1.LighthouseJob {
+	refs := &v1alpha1.Refs{Org: "org", Repo: "repo", BaseRef: baseRef}
+	branch := baseRef
+	if kind == config.PresubmitJob {
+		refs.Pulls = []v1alpha1.Pull{{Number: 7, SHA: "head"}}
+		branch = "PR-7"
+	}
+	completionTime := metav1.NewTime(completed)
+	return &v1alpha1.LighthouseJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "jx",
+			Labels: map[string]string{
+				config.LighthouseJobTypeLabel: string(kind),
+				util.OrgLabel:                 "org",
+				util.RepoLabel:                "repo",
+				util.BranchLabel:              branch,
+			},
+		},
+		Spec: v1alpha1.LighthouseJobSpec{Type: kind, Refs: refs},
+		Status: v1alpha1.LighthouseJobStatus{
+			State:          v1alpha1.SuccessState,
+			StartTime:      completionTime,
+			CompletionTime: &completionTime,
+			Artifacts:      published,
+		},
+	}
+}
+
+func TestPublishedArtifacts(t *testing.T) {
+	job := &v1alpha1.LighthouseJob{
+		ObjectMeta: metav1.ObjectMeta{Name: "job", Labels: map[string]string{util.BuildNumLabel: "3"}},
+		Spec: v1alpha1.LighthouseJobSpec{
+			Context: "build",
+			Refs:    &v1alpha1.Refs{Org: "org", Repo: "repo", BaseRef: "master"},
+		},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pipeline-pod",
+			Namespace: "jx",
+			Labels: map[string]string{
+				util.ActivityOwnerLabel:      "org",
+				util.ActivityRepositoryLabel: "repo",
+				util.ActivityBranchLabel:     "master",
+				util.ActivityBuildLabel:      "3",
+				util.ActivityContextLabel:    "build",
+			},
+		},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "step-build"}}},
+	}
+	agent := &artifacts.Agent{}
+	c := &Controller{
+		kubeClient: kubefake.NewSimpleClientset(pod),
+		artifacts:  agent,
+		logger:     logrus.WithField("controller", controllerName),
+		containerLog: func(ns, pod, container string, tailLines int64) ([]byte, error) {
+			assert.Equal(t, int64(artifacts.DefaultTailLines), tailLines)
+			return []byte("go build ./...\nlighthouse-artifact: size/hook=48213504\n"), nil
+		},
+	}
+	assert.Nil(t, c.publishedArtifacts("jx", job), "the artifacts are not compared without configuration")
+
+	cfg, err := artifacts.LoadConfig([]byte("artifact_diff: {}\n"))
+	require.NoError(t, err)
+	agent.Set(cfg)
+	assert.Equal(t, map[string]string{"size/hook": "48213504"}, c.publishedArtifacts("jx", job))
+}
+
+func TestReportArtifacts(t *testing.T) {
+	now := time.Now()
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, j := range []*v1alpha1.LighthouseJob{
+		artifactsJob("old-postsubmit", config.PostsubmitJob, "master", now.Add(-2*time.Hour), map[string]string{"size/hook": "500", "size/keeper": "2000"}),
+		artifactsJob("postsubmit", config.PostsubmitJob, "master", now.Add(-time.Hour), map[string]string{"size/hook": "1000"}),
+		artifactsJob("release-postsubmit", config.PostsubmitJob, "release", now, map[string]string{"size/hook": "10"}),
+		artifactsJob("other-presubmit", config.PresubmitJob, "master", now, map[string]string{"image/hook": "sha256:abc"}),
+	} {
+		require.NoError(t, indexer.Add(j))
+	}
+	agent := &artifacts.Agent{}
+	cfg, err := artifacts.LoadConfig([]byte("artifact_diff:\n  thresholds:\n  - pattern: '^size/'\n    max_increase_percent: 5\n"))
+	require.NoError(t, err)
+	agent.Set(cfg)
+	c := &Controller{
+		lhLister:  lhlisters.NewLighthouseJobLister(indexer),
+		artifacts: agent,
+		logger:    logrus.WithField("controller", controllerName),
+	}
+	spc := &fakeCommenter{comments: map[int][]*scm.Comment{}}
+	job := artifactsJob("presubmit", config.PresubmitJob, "master", now, map[string]string{"size/hook": "1100", "size/keeper": "2000"})
+
+	c.reportArtifacts(spc, "jx", job, "head", nil)
+
+	require.Len(t, spc.comments[7], 1)
+	body := spc.comments[7][0].Body
+	assert.Contains(t, body, "| image/hook |  | `sha256:abc` | new |")
+	assert.Contains(t, body, "| size/hook | `1000` | `1100` | +100 (+10.0%) :warning: |")
+	assert.Contains(t, body, "| size/keeper | `2000` | `2000` | unchanged |")
+	assert.True(t, strings.HasSuffix(body, artifactsCommentTag), "the comment is tagged")
+
+	job.Status.Artifacts = map[string]string{"size/hook": "1000", "size/keeper": "2000"}
+	c.reportArtifacts(spc, "jx", job, "head", nil)
+	require.Len(t, spc.comments[7], 1, "the comment is updated in place")
+	assert.NotContains(t, spc.comments[7][0].Body, ":warning:")
+}
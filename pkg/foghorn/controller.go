@@ -11,19 +11,30 @@ import (
 	"time"
 
 	"github.com/jenkins-x/go-scm/scm"
-	"github.com/jenkins-x/go-scm/scm/factory"
 	jxv1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
 	jxclient "github.com/jenkins-x/jx/v2/pkg/client/clientset/versioned"
 	jxinformers "github.com/jenkins-x/jx/v2/pkg/client/informers/externalversions/jenkins.io/v1"
 	jxlisters "github.com/jenkins-x/jx/v2/pkg/client/listers/jenkins.io/v1"
 	"github.com/jenkins-x/lighthouse-config/pkg/config"
 	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/artifacts"
 	clientset "github.com/jenkins-x/lighthouse/pkg/client/clientset/versioned"
 	lhinformers "github.com/jenkins-x/lighthouse/pkg/client/informers/externalversions/lighthouse/v1alpha1"
 	lhlisters "github.com/jenkins-x/lighthouse/pkg/client/listers/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/configinclude"
+	"github.com/jenkins-x/lighthouse/pkg/failures"
+	"github.com/jenkins-x/lighthouse/pkg/gitprovider"
+	"github.com/jenkins-x/lighthouse/pkg/hooks"
+	"github.com/jenkins-x/lighthouse/pkg/notifier"
 	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/jenkins-x/lighthouse/pkg/plugins/preview"
+	"github.com/jenkins-x/lighthouse/pkg/plugins/promote"
+	"github.com/jenkins-x/lighthouse/pkg/provenance"
 	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
 	"github.com/jenkins-x/lighthouse/pkg/scmprovider/reporter"
+	"github.com/jenkins-x/lighthouse/pkg/slo"
+	"github.com/jenkins-x/lighthouse/pkg/tenants"
+	"github.com/jenkins-x/lighthouse/pkg/usage"
 	"github.com/jenkins-x/lighthouse/pkg/util"
 	"github.com/jenkins-x/lighthouse/pkg/watcher"
 	"github.com/pkg/errors"
@@ -43,6 +54,10 @@ import (
 const (
 	controllerName           = "foghorn"
 	defaultTargetURLTemplate = "{{ .BaseURL }}/teams/{{ .Team }}/projects/{{ .Owner }}/{{ .Repository }}/{{ .Branch }}/{{ .Build }}"
+
+	// maxReportRetries is how many times the report of a job is retried, with an exponential back-off, before giving
+	// up until its activity changes again
+	maxReportRetries = 10
 )
 
 // Controller listens for changes to PipelineActivitys and updates the corresponding LighthouseJobs and provider commit statuses.
@@ -68,6 +83,17 @@ type Controller struct {
 	jobConfig    *config.Agent
 	pluginConfig *plugins.ConfigAgent
 
+	notifier  *notifier.Notifier
+	failures  *failures.Agent
+	artifacts *artifacts.Agent
+	usage     *usage.Accountant
+	attestor  *provenance.Attestor
+
+	summaries summaryCache
+
+	// containerLog returns the last lines of the log of a container, read from the kubernetes API if nil
+	containerLog func(ns, pod, container string, tailLines int64) ([]byte, error)
+
 	logger *logrus.Entry
 	ns     string
 }
@@ -81,16 +107,49 @@ func NewController(kubeClient kubernetes.Interface, jxClient jxclient.Interface,
 
 	configAgent := &config.Agent{}
 	pluginAgent := &plugins.ConfigAgent{}
+	notificationsAgent := &notifier.Agent{}
+	failuresAgent := &failures.Agent{}
+	artifactsAgent := &artifacts.Agent{}
+	tenantsAgent := &tenants.Agent{}
 
 	onConfigYamlChange := func(text string) {
 		if text != "" {
-			cfg, err := config.LoadYAMLConfig([]byte(text))
+			data, err := configinclude.Resolve([]byte(text), "")
+			if err != nil {
+				logrus.WithError(err).Error("Error resolving the includes of the prow Config YAML")
+				return
+			}
+			cfg, err := config.LoadYAMLConfig(data)
 			if err != nil {
 				logrus.WithError(err).Error("Error processing the prow Config YAML")
 			} else {
 				logrus.Info("updating the prow core configuration")
 				configAgent.Set(cfg)
 			}
+			notifications, err := notifier.LoadConfig(data)
+			if err != nil {
+				logrus.WithError(err).Error("Error processing the notifications of the prow Config YAML")
+			} else {
+				notificationsAgent.Set(notifications)
+			}
+			classification, err := failures.LoadConfig(data)
+			if err != nil {
+				logrus.WithError(err).Error("Error processing the failure classification of the prow Config YAML")
+			} else {
+				failuresAgent.Set(classification)
+			}
+			artifactDiff, err := artifacts.LoadConfig(data)
+			if err != nil {
+				logrus.WithError(err).Error("Error processing the artifact diff of the prow Config YAML")
+			} else {
+				artifactsAgent.Set(artifactDiff)
+			}
+			tenantConfig, err := tenants.LoadConfig(data)
+			if err != nil {
+				logrus.WithError(err).Error("Error processing the tenants of the prow Config YAML")
+			} else {
+				tenantsAgent.Set(tenantConfig)
+			}
 		}
 	}
 
@@ -137,7 +196,11 @@ func NewController(kubeClient kubernetes.Interface, jxClient jxclient.Interface,
 		pluginConfig:     pluginAgent,
 		configMapWatcher: configMapWatcher,
 		kubeClient:       kubeClient,
+		notifier:         notifier.New(notificationsAgent.Config, configAgent.Config, logger),
+		failures:         failuresAgent,
+		artifacts:        artifactsAgent,
 	}
+	controller.notifier.ScopeTenants(tenantsAgent.Config)
 
 	activityInformer.Informer()
 	logger.Info("Setting up event handlers")
@@ -163,6 +226,27 @@ func NewController(kubeClient kubernetes.Interface, jxClient jxclient.Interface,
 		},
 	})
 
+	lhInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldJob, ok := oldObj.(*v1alpha1.LighthouseJob)
+			if !ok {
+				return
+			}
+			newJob, ok := newObj.(*v1alpha1.LighthouseJob)
+			if !ok {
+				return
+			}
+			// report asynchronously so that a slow chat service or provider does not hold up the informer
+			go func() {
+				controller.notifier.JobChanged(oldJob, newJob)
+				controller.reportDeployment(oldJob, newJob)
+				controller.reportPeriodic(oldJob, newJob)
+				controller.usage.JobChanged(oldJob, newJob)
+				controller.attestor.JobChanged(oldJob, newJob)
+			}()
+		},
+	})
+
 	return controller, nil
 }
 
@@ -327,6 +411,12 @@ func (c *Controller) syncHandler(key string) error {
 			return err
 		}
 	}
+	if jobCopy.Status.ReportFailed() {
+		if c.queue.NumRequeues(key) < maxReportRetries {
+			return errors.Errorf("failed to report the status of job %s", jobCopy.Name)
+		}
+		c.logger.Warnf("giving up reporting the status of job %s", jobCopy.Name)
+	}
 	return nil
 }
 
@@ -341,6 +431,7 @@ func (c *Controller) updateJobStatusForActivity(activity *jxv1.PipelineActivity,
 	if activity.Spec.CompletedTimestamp != nil && activity.Spec.CompletedTimestamp != job.Status.CompletionTime {
 		job.Status.CompletionTime = activity.Spec.CompletedTimestamp
 	}
+	job.SetStateConditions()
 }
 
 // RateLimiter creates a ratelimiting queue for the foghorn controller.
@@ -384,7 +475,12 @@ func (c *Controller) reportStatus(ns string, activity *jxv1.PipelineActivity, jo
 	repo := activity.Spec.GitRepository
 	gitURL := activity.Spec.GitURL
 	activityStatus := activity.Spec.Status
-	statusInfo := toScmStatusDescriptionRunningStages(activity, c.gitKind())
+	provider, err := providerOf(job)
+	if err != nil {
+		c.logger.WithField("name", activity.Name).WithError(err).Warn("failed to find the provider of the job")
+		return
+	}
+	statusInfo := toScmStatusDescriptionRunningStages(activity, provider.Kind())
 
 	fields := map[string]interface{}{
 		"name":        activity.Name,
@@ -426,6 +522,16 @@ func (c *Controller) reportStatus(ns string, activity *jxv1.PipelineActivity, jo
 		return
 	}
 
+	if statusInfo.scmStatus == scm.StateFailure || statusInfo.scmStatus == scm.StateError {
+		if class := c.classifyFailure(ns, job); class != nil {
+			statusInfo.description = class.StatusDescription()
+			job.Status.FailureClass = class.Rule
+		}
+	}
+	if statusInfo.scmStatus == scm.StateSuccess {
+		job.Status.Artifacts = c.publishedArtifacts(ns, job)
+	}
+
 	c.logger.WithFields(fields).Warnf("last report: %s, current: %s, last desc: %s, current: %s", job.Status.LastReportState, statusInfo.scmStatus.String(),
 		job.Status.Description, statusInfo.description)
 
@@ -469,30 +575,70 @@ func (c *Controller) reportStatus(ns string, activity *jxv1.PipelineActivity, jo
 			gitRepoStatus.Target = targetURL
 		}
 	}
-	scmClient, _, _, err := c.createSCMClient(owner)
+	scmClient, _, _, err := c.createSCMClient(provider, owner)
 	if err != nil {
 		c.logger.WithFields(fields).WithError(err).Warnf("failed to create SCM client")
+		job.SetReported(statusInfo.scmStatus.String(), err)
+		return
+	}
+
+	if job.Spec.IssueNumber != 0 {
+		// the jobs run from issues report their result on the issue rather than on the commit
+		if err := reportIssue(scmClient, job, statusInfo.scmStatus, gitRepoStatus.Target); err != nil {
+			c.logger.WithFields(fields).WithError(err).Warn("failed to report the job on its issue")
+			job.SetReported(statusInfo.scmStatus.String(), err)
+			return
+		}
+		job.SetReported(statusInfo.scmStatus.String(), nil)
+		if gitRepoStatus.Target != "" {
+			job.Status.ReportURL = gitRepoStatus.Target
+		}
+		job.Status.Description = statusInfo.description
+		job.Status.LastReportState = statusInfo.scmStatus.String()
 		return
 	}
 
 	_, err = scmClient.CreateStatus(owner, repo, sha, gitRepoStatus)
 	if err != nil {
 		c.logger.WithFields(fields).WithError(err).Warnf("failed to report git status with target URL '%s'", gitRepoStatus.Target)
-		// TODO: Need something here to prevent infinite attempts to create status from just bombing us. (apb)
+		job.SetReported(statusInfo.scmStatus.String(), err)
 		return
 	}
+	slo.JobReported(c.logger.WithFields(fields), job, statusInfo.scmStatus.String(), time.Now())
+	job.SetReported(statusInfo.scmStatus.String(), nil)
 
-	err = reporter.Report(scmClient, c.jobConfig.Config().Plank.ReportTemplate, job, []config.PipelineKind{config.PresubmitJob})
-	if err != nil {
-		// For now, we're just going to ignore failures here.
-		c.logger.WithFields(fields).WithError(err).Warnf("failed to update comments on the PR")
-	}
 	c.logger.WithFields(fields).Info("reported git status")
 	if gitRepoStatus.Target != "" {
 		job.Status.ReportURL = gitRepoStatus.Target
 	}
 	job.Status.Description = statusInfo.description
 	job.Status.LastReportState = statusInfo.scmStatus.String()
+
+	c.reportSummary(scmClient, ns, job, owner, repo, sha, fields)
+	c.reportArtifacts(scmClient, ns, job, sha, fields)
+
+	presubmits := []config.PipelineKind{config.PresubmitJob}
+	if c.singleReport(owner, repo) {
+		err = reporter.ReportSummary(scmClient, job, presubmits)
+	} else {
+		err = reporter.Report(scmClient, c.jobConfig.Config().Plank.ReportTemplate, job, presubmits)
+	}
+	if err != nil {
+		// For now, we're just going to ignore failures here.
+		c.logger.WithFields(fields).WithError(err).Warnf("failed to update comments on the PR")
+	}
+	if err := preview.Report(scmClient, job, statusInfo.scmStatus, c.logger.WithFields(fields)); err != nil {
+		c.logger.WithFields(fields).WithError(err).Warnf("failed to update the preview comment on the PR")
+	}
+	if err := promote.Report(scmClient, job, activity, statusInfo.scmStatus, c.logger.WithFields(fields)); err != nil {
+		c.logger.WithFields(fields).WithError(err).Warnf("failed to update the promotion comment")
+	}
+}
+
+// singleReport returns true if the repository maintains a single report comment per pull request
+func (c *Controller) singleReport(owner, repo string) bool {
+	cfg := c.pluginConfig.Config()
+	return cfg != nil && cfg.CommentsFor(owner, repo).SingleReport
 }
 
 // getReportURLBase gets the base report URL from the environment
@@ -598,9 +744,88 @@ func durationString(start *metav1.Time, end *metav1.Time) string {
 	return end.Sub(start.Time).Round(time.Second).String()
 }
 
-func (c *Controller) createSCMClient(owner string) (scmprovider.SCMClient, string, string, error) {
-	kind := c.gitKind()
-	serverURL := os.Getenv("GIT_SERVER")
+// SCMClientForJob returns the SCM client used to report the statuses of the job to its provider
+func (c *Controller) SCMClientForJob(job *v1alpha1.LighthouseJob) (scmprovider.SCMClient, error) {
+	provider, err := providerOf(job)
+	if err != nil {
+		return nil, err
+	}
+	client, _, _, err := c.createSCMClient(provider, job.Spec.Refs.Org)
+	return client, err
+}
+
+// RepositoryClientForOwner returns the SCM repository service used to manage the webhooks of the given owner
+// on the provider hosting it
+func (c *Controller) RepositoryClientForOwner(provider *gitprovider.Provider, owner string) (scm.RepositoryService, error) {
+	client, _, _, err := c.createGoSCMClient(provider, owner)
+	if err != nil {
+		return nil, err
+	}
+	return client.Repositories, nil
+}
+
+// ConfigLoaded returns true once both the configuration and the plugins configuration were loaded
+func (c *Controller) ConfigLoaded() bool {
+	return c.jobConfig.Config() != nil && c.pluginConfig.Config() != nil
+}
+
+// HasSynced returns true once the informer caches of the controller are synced
+func (c *Controller) HasSynced() bool {
+	return c.activitySynced() && c.lhSynced()
+}
+
+// AccountUsage makes the controller record the usage of the jobs as they complete
+func (c *Controller) AccountUsage(a *usage.Accountant) {
+	c.usage = a
+}
+
+// AttestProvenance makes the controller attest the provenance of the jobs as they complete
+func (c *Controller) AttestProvenance(a *provenance.Attestor) {
+	c.attestor = a
+}
+
+// SendDigests emails the digests of the LighthouseJobs completed during the period to the maintainers of
+// their repositories
+func (c *Controller) SendDigests(since, until time.Time) error {
+	// the informer only caches the jobs of the namespaces foghorn handles
+	jobs, err := c.lhLister.List(labels.Everything())
+	if err != nil {
+		return errors.Wrap(err, "listing LighthouseJobs")
+	}
+	c.notifier.SendDigests(jobs, since, until)
+	return nil
+}
+
+// ConfiguredRepositories returns the orgs and repositories referenced by the current configuration
+func (c *Controller) ConfiguredRepositories() (orgs, repos []string) {
+	return hooks.ConfiguredRepositories(c.jobConfig.Config(), c.pluginConfig.Config())
+}
+
+// providerOf returns the provider of the job, which is labelled with the provider's name when it was triggered
+// by the webhooks of an additional provider. Other jobs, such as periodics, use the provider hosting their org.
+func providerOf(job *v1alpha1.LighthouseJob) (*gitprovider.Provider, error) {
+	if job == nil {
+		return gitprovider.Default(), nil
+	}
+	if name := job.Labels[util.ProviderLabel]; name != "" {
+		return gitprovider.Named(name)
+	}
+	if job.Spec.Refs != nil {
+		return gitprovider.ForOrg(job.Spec.Refs.Org)
+	}
+	return gitprovider.Default(), nil
+}
+
+func (c *Controller) createSCMClient(provider *gitprovider.Provider, owner string) (scmprovider.SCMClient, string, string, error) {
+	client, serverURL, token, err := c.createGoSCMClient(provider, owner)
+	if err != nil {
+		return nil, serverURL, token, err
+	}
+	return scmprovider.ToClient(client, provider.BotName()), serverURL, token, nil
+}
+
+func (c *Controller) createGoSCMClient(provider *gitprovider.Provider, owner string) (*scm.Client, string, string, error) {
+	serverURL := provider.ServerURL()
 	ghaSecretDir := util.GetGitHubAppSecretDir()
 
 	var token string
@@ -613,41 +838,14 @@ func (c *Controller) createSCMClient(owner string) (scmprovider.SCMClient, strin
 			return nil, "", "", errors.Wrapf(err, "failed to read owner token for owner %s", owner)
 		}
 	} else {
-		token, err = c.createSCMToken(kind)
+		token, err = provider.Token()
 		if err != nil {
 			return nil, serverURL, token, err
 		}
 	}
 
-	client, err := factory.NewClient(kind, serverURL, token)
-	scmClient := scmprovider.ToClient(client, c.GetBotName())
-	return scmClient, serverURL, token, err
-}
-
-func (c *Controller) gitKind() string {
-	kind := os.Getenv("GIT_KIND")
-	if kind == "" {
-		kind = "github"
-	}
-	return kind
-}
-
-// GetBotName returns the bot name
-func (c *Controller) GetBotName() string {
-	botName := os.Getenv("GIT_USER")
-	if botName == "" {
-		botName = "jenkins-x-bot"
-	}
-	return botName
-}
-
-func (c *Controller) createSCMToken(gitKind string) (string, error) {
-	envName := "GIT_TOKEN"
-	value := os.Getenv(envName)
-	if value == "" {
-		return value, fmt.Errorf("No token available for git kind %s at environment variable $%s", gitKind, envName)
-	}
-	return value, nil
+	client, err := provider.NewClient(token)
+	return client, serverURL, token, err
 }
 
 // stopper returns a channel that remains open until an interrupt is received.
@@ -0,0 +1,70 @@
+package foghorn
+
+import (
+	"context"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"github.com/pkg/errors"
+)
+
+// reportDeployment reports the state of a job deploying to an environment as a status of its deployment, when
+// the state changes
+func (c *Controller) reportDeployment(old, job *v1alpha1.LighthouseJob) {
+	if job.Annotations[util.DeploymentAnnotation] == "" || deploymentState(old.Status.State) == deploymentState(job.Status.State) {
+		return
+	}
+	log := c.logger.WithField("job", job.Name).WithField("environment", job.Spec.Environment)
+	provider, err := providerOf(job)
+	if err != nil {
+		log.WithError(err).Warn("failed to find the provider of the job")
+		return
+	}
+	client, _, _, err := c.createGoSCMClient(provider, job.Spec.Refs.Org)
+	if err != nil {
+		log.WithError(err).Warn("failed to create SCM client")
+		return
+	}
+	if client.Deployments == nil {
+		return
+	}
+	if err := reportDeployment(client.Deployments, job); err != nil {
+		log.WithError(err).Warn("failed to report the deployment status")
+	}
+}
+
+// reportDeployment creates the status of the deployment of the job matching its state
+func reportDeployment(deployments scm.DeploymentService, job *v1alpha1.LighthouseJob) error {
+	id := job.Annotations[util.DeploymentAnnotation]
+	state := deploymentState(job.Status.State)
+	if id == "" || state == "" || job.Spec.Refs == nil {
+		return nil
+	}
+	fullName := scm.Join(job.Spec.Refs.Org, job.Spec.Refs.Repo)
+	_, _, err := deployments.CreateStatus(context.Background(), fullName, id, &scm.DeploymentStatusInput{
+		State:       state,
+		TargetLink:  job.Status.ReportURL,
+		LogLink:     job.Status.ReportURL,
+		Description: job.Status.Description,
+		Environment: job.Spec.Environment,
+		// a successful deployment replaces the previous deployments of the environment
+		AutoInactive: state == "success",
+	})
+	return errors.Wrapf(err, "creating the status of deployment %s of %s", id, fullName)
+}
+
+// deploymentState returns the state of a deployment whose job is in the given state
+func deploymentState(state v1alpha1.PipelineState) string {
+	switch state {
+	case v1alpha1.TriggeredState, v1alpha1.PendingState, v1alpha1.RunningState:
+		return "pending"
+	case v1alpha1.SuccessState:
+		return "success"
+	case v1alpha1.FailureState:
+		return "failure"
+	case v1alpha1.ErrorState, v1alpha1.AbortedState:
+		return "error"
+	}
+	return ""
+}
@@ -0,0 +1,81 @@
+package foghorn
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type deploymentStatus struct {
+	repo, id string
+	input    *scm.DeploymentStatusInput
+}
+
+type fakeDeployments struct {
+	scm.DeploymentService
+	statuses []deploymentStatus
+}
+
+func (d *fakeDeployments) CreateStatus(ctx context.Context, repoFullName string, deploymentID string, input *scm.DeploymentStatusInput) (*scm.DeploymentStatus, *scm.Response, error) {
+	d.statuses = append(d.statuses, deploymentStatus{repo: repoFullName, id: deploymentID, input: input})
+	return &scm.DeploymentStatus{}, nil, nil
+}
+
+func TestReportDeployment(t *testing.T) {
+	job := &v1alpha1.LighthouseJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{util.DeploymentAnnotation: "42"},
+		},
+		Spec: v1alpha1.LighthouseJobSpec{
+			Type:        config.PostsubmitJob,
+			Environment: "staging",
+			Refs:        &v1alpha1.Refs{Org: "org", Repo: "repo"},
+		},
+		Status: v1alpha1.LighthouseJobStatus{
+			State:       v1alpha1.SuccessState,
+			Description: "Pipeline successful",
+			ReportURL:   "https://dashboard/org/repo/master/1",
+		},
+	}
+	deployments := &fakeDeployments{}
+	require.NoError(t, reportDeployment(deployments, job))
+	assert.Equal(t, []deploymentStatus{{
+		repo: "org/repo",
+		id:   "42",
+		input: &scm.DeploymentStatusInput{
+			State:        "success",
+			TargetLink:   "https://dashboard/org/repo/master/1",
+			LogLink:      "https://dashboard/org/repo/master/1",
+			Description:  "Pipeline successful",
+			Environment:  "staging",
+			AutoInactive: true,
+		},
+	}}, deployments.statuses)
+
+	job.Status.State = v1alpha1.AbortedState
+	require.NoError(t, reportDeployment(deployments, job))
+	require.Len(t, deployments.statuses, 2)
+	assert.Equal(t, "error", deployments.statuses[1].input.State)
+	assert.False(t, deployments.statuses[1].input.AutoInactive)
+
+	// jobs without a deployment are not reported
+	delete(job.Annotations, util.DeploymentAnnotation)
+	require.NoError(t, reportDeployment(deployments, job))
+	assert.Len(t, deployments.statuses, 2)
+}
+
+func TestDeploymentState(t *testing.T) {
+	assert.Equal(t, "pending", deploymentState(v1alpha1.TriggeredState))
+	assert.Equal(t, "pending", deploymentState(v1alpha1.RunningState))
+	assert.Equal(t, "success", deploymentState(v1alpha1.SuccessState))
+	assert.Equal(t, "failure", deploymentState(v1alpha1.FailureState))
+	assert.Equal(t, "error", deploymentState(v1alpha1.ErrorState))
+	assert.Equal(t, "", deploymentState(""))
+}
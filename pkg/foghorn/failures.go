@@ -0,0 +1,80 @@
+package foghorn
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"sort"
+
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/failures"
+	"github.com/jenkins-x/lighthouse/pkg/gc"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// classifyFailure classifies the failed job from the last lines of the logs of the pods of its PipelineRuns,
+// returning nil if the failures are not classified or no rule matches
+func (c *Controller) classifyFailure(ns string, job *v1alpha1.LighthouseJob) *failures.Classification {
+	if c.failures == nil || c.kubeClient == nil {
+		return nil
+	}
+	cfg := c.failures.Config()
+	if cfg == nil {
+		return nil
+	}
+	logs, err := c.pipelineLogs(ns, job, cfg.Lines())
+	if err != nil {
+		c.logger.WithField("job", job.Name).WithError(err).Warn("failed to get the logs of the failed job")
+		return nil
+	}
+	class, err := cfg.Classify(bytes.NewReader(logs))
+	if err != nil {
+		c.logger.WithField("job", job.Name).WithError(err).Warn("failed to classify the failed job")
+		return nil
+	}
+	return class
+}
+
+// pipelineLogs returns the last lines of the logs of every container of the pods of the job's PipelineRuns, in the
+// order the pods were created
+func (c *Controller) pipelineLogs(ns string, job *v1alpha1.LighthouseJob, lines int) ([]byte, error) {
+	selector := gc.PipelineRunSelector(job)
+	if selector == "" {
+		return nil, nil
+	}
+	list, err := c.kubeClient.CoreV1().Pods(ns).List(metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, errors.Wrap(err, "listing the pods of the PipelineRuns")
+	}
+	sort.Slice(list.Items, func(i, j int) bool {
+		return list.Items[i].CreationTimestamp.Before(&list.Items[j].CreationTimestamp)
+	})
+	containerLog := c.containerLog
+	if containerLog == nil {
+		containerLog = c.kubeContainerLog
+	}
+	tailLines := int64(lines)
+	var buf bytes.Buffer
+	for _, pod := range list.Items {
+		for _, container := range pod.Spec.Containers {
+			data, err := containerLog(ns, pod.Name, container.Name, tailLines)
+			if err != nil {
+				return nil, errors.Wrapf(err, "getting the log of container %s of pod %s", container.Name, pod.Name)
+			}
+			fmt.Fprintf(&buf, "%s\n", bytes.TrimRight(data, "\n"))
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// kubeContainerLog returns the last lines of the log of a container
+func (c *Controller) kubeContainerLog(ns, pod, container string, tailLines int64) ([]byte, error) {
+	stream, err := c.kubeClient.CoreV1().Pods(ns).GetLogs(pod, &corev1.PodLogOptions{Container: container, TailLines: &tailLines}).Stream()
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+	return ioutil.ReadAll(stream)
+}
@@ -0,0 +1,60 @@
+package foghorn
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/failures"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestClassifyFailure(t *testing.T) {
+	job := &v1alpha1.LighthouseJob{
+		ObjectMeta: metav1.ObjectMeta{Name: "job", Labels: map[string]string{util.BuildNumLabel: "3"}},
+		Spec: v1alpha1.LighthouseJobSpec{
+			Context: "unit",
+			Refs:    &v1alpha1.Refs{Org: "org", Repo: "repo", BaseRef: "master"},
+		},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pipeline-pod",
+			Namespace: "jx",
+			Labels: map[string]string{
+				util.ActivityOwnerLabel:      "org",
+				util.ActivityRepositoryLabel: "repo",
+				util.ActivityBranchLabel:     "master",
+				util.ActivityBuildLabel:      "3",
+				util.ActivityContextLabel:    "unit",
+			},
+		},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "step-build"}}},
+	}
+	agent := &failures.Agent{}
+	c := &Controller{
+		kubeClient: kubefake.NewSimpleClientset(pod),
+		failures:   agent,
+		logger:     logrus.WithField("controller", controllerName),
+		containerLog: func(ns, pod, container string, tailLines int64) ([]byte, error) {
+			assert.Equal(t, "step-build", container)
+			return []byte("Step 1/3\ndial tcp 10.0.0.1:443: i/o timeout\n"), nil
+		},
+	}
+	assert.Nil(t, c.classifyFailure("jx", job), "the failures are not classified without configuration")
+
+	cfg, err := failures.LoadConfig([]byte("failure_classification: {}\n"))
+	require.NoError(t, err)
+	agent.Set(cfg)
+	class := c.classifyFailure("jx", job)
+	require.NotNil(t, class)
+	assert.Equal(t, "infra-flake", class.Rule)
+	assert.Equal(t, "Infra flake — say /retest", class.StatusDescription())
+
+	assert.Nil(t, c.classifyFailure("other", job), "no pod runs the job in the namespace")
+}
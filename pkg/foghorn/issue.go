@@ -0,0 +1,54 @@
+package foghorn
+
+import (
+	"fmt"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"github.com/pkg/errors"
+)
+
+// issueCommenter is the subset of the SCM client used to report the jobs triggered by issue comments
+type issueCommenter interface {
+	CreateComment(owner, repo string, number int, pr bool, comment string) error
+}
+
+// reportIssue comments the result of a job triggered by a command on an issue once it completes, rather than
+// reporting its state as a commit status
+func reportIssue(spc issueCommenter, job *v1alpha1.LighthouseJob, state scm.State, targetURL string) error {
+	if job.Spec.Refs == nil {
+		return nil
+	}
+	message := issueReport(job, state, targetURL)
+	if message == "" {
+		return nil
+	}
+	refs := job.Spec.Refs
+	err := spc.CreateComment(refs.Org, refs.Repo, job.Spec.IssueNumber, false, message)
+	return errors.Wrapf(err, "commenting on issue %d of %s/%s", job.Spec.IssueNumber, refs.Org, refs.Repo)
+}
+
+// issueReport returns the comment reporting the result of the job, or an empty string if it has not completed
+func issueReport(job *v1alpha1.LighthouseJob, state scm.State, targetURL string) string {
+	var outcome string
+	switch state {
+	case scm.StateSuccess:
+		outcome = "succeeded"
+	case scm.StateFailure, scm.StateError:
+		outcome = "failed"
+	case scm.StateCanceled:
+		outcome = "was aborted"
+	default:
+		return ""
+	}
+	message := fmt.Sprintf("The job `%s` run by `%s` %s on `%s` at %s", job.Spec.Job, job.Spec.IssueCommand, outcome, job.Spec.Refs.BaseRef, job.Spec.Refs.BaseSHA)
+	if targetURL != "" {
+		message += fmt.Sprintf(", see the [details](%s)", targetURL)
+	}
+	message += "."
+	if state != scm.StateSuccess {
+		message += fmt.Sprintf(" Comment `%s` to run it again.", util.FormatCommand(job.Spec.IssueCommand))
+	}
+	return message
+}
@@ -0,0 +1,44 @@
+package foghorn
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeIssueCommenter struct {
+	comments []string
+}
+
+func (f *fakeIssueCommenter) CreateComment(owner, repo string, number int, pr bool, comment string) error {
+	f.comments = append(f.comments, fmt.Sprintf("%s/%s#%d:%t:%s", owner, repo, number, pr, comment))
+	return nil
+}
+
+func TestReportIssue(t *testing.T) {
+	job := &v1alpha1.LighthouseJob{
+		Spec: v1alpha1.LighthouseJobSpec{
+			Type:         config.PostsubmitJob,
+			Job:          "release",
+			IssueNumber:  12,
+			IssueCommand: "/release patch",
+			Refs:         &v1alpha1.Refs{Org: "org", Repo: "repo", BaseRef: "main", BaseSHA: "abc"},
+		},
+	}
+	spc := &fakeIssueCommenter{}
+
+	require.NoError(t, reportIssue(spc, job, scm.StateRunning, "https://dashboard/logs"))
+	assert.Empty(t, spc.comments, "running jobs are not reported")
+
+	require.NoError(t, reportIssue(spc, job, scm.StateSuccess, "https://dashboard/logs"))
+	require.NoError(t, reportIssue(spc, job, scm.StateFailure, ""))
+	assert.Equal(t, []string{
+		"org/repo#12:false:The job `release` run by `/release patch` succeeded on `main` at abc, see the [details](https://dashboard/logs).",
+		"org/repo#12:false:The job `release` run by `/release patch` failed on `main` at abc. Comment `/release patch` to run it again.",
+	}, spc.comments)
+}
@@ -0,0 +1,198 @@
+package foghorn
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// periodicIssueMarker tags the issue of a failing periodic, so that it is updated rather than filed again
+	periodicIssueMarker = "<!-- lighthouse:periodic:%s -->"
+
+	// maxListedCommits is the maximum number of commits since the last successful run listed in the issues
+	maxListedCommits = 30
+)
+
+// reportPeriodic files an issue when a periodic asking for it fails, comments on it while it keeps failing and closes
+// it once the periodic succeeds again
+func (c *Controller) reportPeriodic(old, job *v1alpha1.LighthouseJob) {
+	if job.Spec.Type != config.PeriodicJob || job.Annotations[util.FileIssueOnFailureAnnotation] != "true" ||
+		old.Status.State == job.Status.State || periodicOutcome(job.Status.State) == "" {
+		return
+	}
+	log := c.logger.WithField("job", job.Name).WithField("periodic", job.Spec.Job)
+	org, repo := periodicIssueRepo(job)
+	if org == "" || repo == "" {
+		log.Warnf("no repository to file the issues of the periodic in, the %s annotation should be set", util.IssueRepoAnnotation)
+		return
+	}
+	provider, err := providerOf(job)
+	if err != nil {
+		log.WithError(err).Warn("failed to find the provider of the job")
+		return
+	}
+	client, _, _, err := c.createGoSCMClient(provider, org)
+	if err != nil {
+		log.WithError(err).Warn("failed to create SCM client")
+		return
+	}
+	var lastGreen *v1alpha1.LighthouseJob
+	if jobs, err := c.lhLister.LighthouseJobs(c.ns).List(labels.Everything()); err != nil {
+		log.WithError(err).Warn("failed to list the previous runs of the periodic")
+	} else {
+		lastGreen = lastSuccessfulRun(jobs, job)
+	}
+	if err := reportPeriodic(client.Issues, client.Git, scm.Join(org, repo), job, lastGreen); err != nil {
+		log.WithError(err).Warn("failed to report the periodic in an issue")
+	}
+}
+
+// reportPeriodic opens or comments on the issue of the failed periodic, or closes it if the periodic succeeded
+func reportPeriodic(issues scm.IssueService, git scm.GitService, fullName string, job, lastGreen *v1alpha1.LighthouseJob) error {
+	ctx := context.Background()
+	issue, err := findPeriodicIssue(issues, fullName, job.Spec.Job)
+	if err != nil {
+		return err
+	}
+	if periodicOutcome(job.Status.State) == "success" {
+		if issue == nil {
+			return nil
+		}
+		comment := fmt.Sprintf("The periodic job `%s` succeeded again%s, closing this issue.", job.Spec.Job, logsLink(job))
+		if _, _, err := issues.CreateComment(ctx, fullName, issue.Number, &scm.CommentInput{Body: comment}); err != nil {
+			return errors.Wrapf(err, "commenting on issue %d of %s", issue.Number, fullName)
+		}
+		_, err := issues.Close(ctx, fullName, issue.Number)
+		return errors.Wrapf(err, "closing issue %d of %s", issue.Number, fullName)
+	}
+
+	report := failureReport(ctx, git, job, lastGreen)
+	if issue != nil {
+		_, _, err := issues.CreateComment(ctx, fullName, issue.Number, &scm.CommentInput{Body: report})
+		return errors.Wrapf(err, "commenting on issue %d of %s", issue.Number, fullName)
+	}
+	_, _, err = issues.Create(ctx, fullName, &scm.IssueInput{
+		Title: periodicIssueTitle(job.Spec.Job),
+		Body:  report + "\n\nThis issue is updated while the job keeps failing and closed once it succeeds again.\n" + fmt.Sprintf(periodicIssueMarker, job.Spec.Job),
+	})
+	return errors.Wrapf(err, "creating an issue in %s", fullName)
+}
+
+// findPeriodicIssue returns the open issue filed for the periodic, if any
+func findPeriodicIssue(issues scm.IssueService, fullName, periodic string) (*scm.Issue, error) {
+	marker := fmt.Sprintf(periodicIssueMarker, periodic)
+	opts := scm.IssueListOptions{Open: true, Size: 100}
+	for opts.Page = 1; ; opts.Page++ {
+		list, _, err := issues.List(context.Background(), fullName, opts)
+		if err != nil {
+			return nil, errors.Wrapf(err, "listing the issues of %s", fullName)
+		}
+		for _, issue := range list {
+			if !issue.PullRequest && !issue.Closed && strings.Contains(issue.Body, marker) {
+				return issue, nil
+			}
+		}
+		if len(list) < opts.Size {
+			return nil, nil
+		}
+	}
+}
+
+// failureReport describes the failure of the periodic along with the commits since its last successful run
+func failureReport(ctx context.Context, git scm.GitService, job, lastGreen *v1alpha1.LighthouseJob) string {
+	report := fmt.Sprintf("The periodic job `%s` ended with the state `%s`%s", job.Spec.Job, job.Status.State, logsLink(job))
+	if job.Status.Description != "" {
+		report += ": " + job.Status.Description
+	}
+	report += "."
+	if lastGreen == nil {
+		return report + "\n\nThe job has not succeeded recently."
+	}
+	report += "\n\nThe job last succeeded"
+	if lastGreen.Status.CompletionTime != nil {
+		report += " at " + lastGreen.Status.CompletionTime.UTC().Format("2006-01-02 15:04 MST")
+	}
+	refs, greenRefs := job.Spec.Refs, lastGreen.Spec.Refs
+	if refs == nil || greenRefs == nil || refs.BaseSHA == "" || greenRefs.BaseSHA == "" {
+		return report + "."
+	}
+	if refs.BaseSHA == greenRefs.BaseSHA {
+		return report + fmt.Sprintf(" on the same commit %s, so the failure is not caused by a change of %s/%s.", refs.BaseSHA, refs.Org, refs.Repo)
+	}
+	report += fmt.Sprintf(" on %s. The commits of %s/%s since then are:\n", greenRefs.BaseSHA, refs.Org, refs.Repo)
+	commits, _, err := git.ListCommits(ctx, scm.Join(refs.Org, refs.Repo), scm.CommitListOptions{Ref: refs.BaseSHA, Size: maxListedCommits})
+	if err != nil {
+		return report + fmt.Sprintf("\nThe commits could not be listed: %v", err)
+	}
+	for _, commit := range commits {
+		if commit.Sha == greenRefs.BaseSHA {
+			return report
+		}
+		title := strings.SplitN(commit.Message, "\n", 2)[0]
+		report += fmt.Sprintf("\n* %s %s (%s)", commit.Sha, title, commit.Author.Name)
+	}
+	if len(commits) < maxListedCommits {
+		return report + "\n\nThe last successful commit is not in the history of the branch anymore."
+	}
+	return report + "\n\nThe earlier commits are left out."
+}
+
+// lastSuccessfulRun returns the latest successful run of the periodic of the job
+func lastSuccessfulRun(jobs []*v1alpha1.LighthouseJob, job *v1alpha1.LighthouseJob) *v1alpha1.LighthouseJob {
+	var answer *v1alpha1.LighthouseJob
+	for _, j := range jobs {
+		if j.Spec.Type != config.PeriodicJob || j.Spec.Job != job.Spec.Job || j.Status.State != v1alpha1.SuccessState || j.Status.CompletionTime == nil {
+			continue
+		}
+		if answer == nil || answer.Status.CompletionTime.Before(j.Status.CompletionTime) {
+			answer = j
+		}
+	}
+	return answer
+}
+
+// periodicIssueRepo returns the org and repo the issues of the periodic are filed in
+func periodicIssueRepo(job *v1alpha1.LighthouseJob) (string, string) {
+	if fullName := job.Annotations[util.IssueRepoAnnotation]; fullName != "" {
+		parts := strings.SplitN(fullName, "/", 2)
+		if len(parts) == 2 {
+			return parts[0], parts[1]
+		}
+		return "", ""
+	}
+	if job.Spec.Refs != nil {
+		return job.Spec.Refs.Org, job.Spec.Refs.Repo
+	}
+	return "", ""
+}
+
+// periodicOutcome returns whether the state of a finished periodic is a success or a failure, or an empty string if
+// the periodic is not finished or was aborted
+func periodicOutcome(state v1alpha1.PipelineState) string {
+	switch state {
+	case v1alpha1.SuccessState:
+		return "success"
+	case v1alpha1.FailureState, v1alpha1.ErrorState:
+		return "failure"
+	}
+	return ""
+}
+
+func periodicIssueTitle(periodic string) string {
+	return fmt.Sprintf("Periodic job %s is failing", periodic)
+}
+
+func logsLink(job *v1alpha1.LighthouseJob) string {
+	if job.Status.ReportURL == "" {
+		return ""
+	}
+	return fmt.Sprintf(" ([logs](%s))", job.Status.ReportURL)
+}
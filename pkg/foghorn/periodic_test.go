@@ -0,0 +1,122 @@
+package foghorn
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type fakeIssues struct {
+	scm.IssueService
+	issues   []*scm.Issue
+	comments map[int][]string
+}
+
+func (f *fakeIssues) List(ctx context.Context, repo string, opts scm.IssueListOptions) ([]*scm.Issue, *scm.Response, error) {
+	var answer []*scm.Issue
+	for _, issue := range f.issues {
+		if !issue.Closed {
+			answer = append(answer, issue)
+		}
+	}
+	return answer, nil, nil
+}
+
+func (f *fakeIssues) Create(ctx context.Context, repo string, input *scm.IssueInput) (*scm.Issue, *scm.Response, error) {
+	issue := &scm.Issue{Number: len(f.issues) + 1, Title: input.Title, Body: input.Body}
+	f.issues = append(f.issues, issue)
+	return issue, nil, nil
+}
+
+func (f *fakeIssues) CreateComment(ctx context.Context, repo string, number int, input *scm.CommentInput) (*scm.Comment, *scm.Response, error) {
+	f.comments[number] = append(f.comments[number], input.Body)
+	return &scm.Comment{}, nil, nil
+}
+
+func (f *fakeIssues) Close(ctx context.Context, repo string, number int) (*scm.Response, error) {
+	f.issues[number-1].Closed = true
+	return nil, nil
+}
+
+type fakeGit struct {
+	scm.GitService
+	commits []*scm.Commit
+}
+
+func (f *fakeGit) ListCommits(ctx context.Context, repo string, opts scm.CommitListOptions) ([]*scm.Commit, *scm.Response, error) {
+	return f.commits, nil, nil
+}
+
+func TestReportPeriodic(t *testing.T) {
+	periodic := func(state v1alpha1.PipelineState, sha string) *v1alpha1.LighthouseJob {
+		return &v1alpha1.LighthouseJob{
+			Spec: v1alpha1.LighthouseJobSpec{
+				Type: config.PeriodicJob,
+				Job:  "nightly",
+				Refs: &v1alpha1.Refs{Org: "org", Repo: "repo", BaseRef: "master", BaseSHA: sha},
+			},
+			Status: v1alpha1.LighthouseJobStatus{
+				State:          state,
+				ReportURL:      "https://dashboard/nightly/" + sha,
+				CompletionTime: &metav1.Time{Time: time.Date(2020, 6, 1, 2, 0, 0, 0, time.UTC)},
+			},
+		}
+	}
+	green := periodic(v1alpha1.SuccessState, "aaa")
+	issues := &fakeIssues{issues: []*scm.Issue{{Number: 1, Title: "unrelated"}}, comments: map[int][]string{}}
+	git := &fakeGit{commits: []*scm.Commit{
+		{Sha: "ccc", Message: "Break the build\n\nDetails", Author: scm.Signature{Name: "Alice"}},
+		{Sha: "bbb", Message: "Refactor", Author: scm.Signature{Name: "Bob"}},
+		{Sha: "aaa", Message: "Green"},
+	}}
+
+	// nothing is done for a successful periodic without an issue
+	require.NoError(t, reportPeriodic(issues, git, "org/infra", green, green))
+	assert.Len(t, issues.issues, 1)
+
+	require.NoError(t, reportPeriodic(issues, git, "org/infra", periodic(v1alpha1.FailureState, "ccc"), green))
+	require.Len(t, issues.issues, 2)
+	issue := issues.issues[1]
+	assert.Equal(t, "Periodic job nightly is failing", issue.Title)
+	assert.True(t, strings.Contains(issue.Body, "([logs](https://dashboard/nightly/ccc))"), issue.Body)
+	assert.True(t, strings.Contains(issue.Body, "* ccc Break the build (Alice)\n* bbb Refactor (Bob)\n\nThis issue"), issue.Body)
+	assert.False(t, strings.Contains(issue.Body, "Green"), issue.Body)
+
+	// the issue is updated while the periodic keeps failing
+	require.NoError(t, reportPeriodic(issues, git, "org/infra", periodic(v1alpha1.ErrorState, "ccc"), green))
+	assert.Len(t, issues.issues, 2)
+	require.Len(t, issues.comments[2], 1)
+	assert.True(t, strings.Contains(issues.comments[2][0], "ended with the state `error`"), issues.comments[2][0])
+
+	require.NoError(t, reportPeriodic(issues, git, "org/infra", periodic(v1alpha1.SuccessState, "ddd"), green))
+	assert.True(t, issues.issues[1].Closed)
+	assert.False(t, issues.issues[0].Closed)
+	require.Len(t, issues.comments[2], 2)
+	assert.True(t, strings.Contains(issues.comments[2][1], "succeeded again"), issues.comments[2][1])
+}
+
+func TestLastSuccessfulRun(t *testing.T) {
+	run := func(job string, state v1alpha1.PipelineState, hour int) *v1alpha1.LighthouseJob {
+		return &v1alpha1.LighthouseJob{
+			Spec:   v1alpha1.LighthouseJobSpec{Type: config.PeriodicJob, Job: job},
+			Status: v1alpha1.LighthouseJobStatus{State: state, CompletionTime: &metav1.Time{Time: time.Date(2020, 6, 1, hour, 0, 0, 0, time.UTC)}},
+		}
+	}
+	latest := run("nightly", v1alpha1.SuccessState, 2)
+	jobs := []*v1alpha1.LighthouseJob{
+		run("nightly", v1alpha1.SuccessState, 1),
+		latest,
+		run("nightly", v1alpha1.FailureState, 3),
+		run("other", v1alpha1.SuccessState, 4),
+	}
+	assert.Equal(t, latest, lastSuccessfulRun(jobs, run("nightly", v1alpha1.FailureState, 5)))
+	assert.Nil(t, lastSuccessfulRun(jobs, run("weekly", v1alpha1.FailureState, 5)))
+}
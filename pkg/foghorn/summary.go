@@ -0,0 +1,158 @@
+package foghorn
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// SummaryContextEnv is the commit status context of the aggregate result of all the jobs of a commit, e.g.
+	// lighthouse/summary. No aggregate status is published if it is not set.
+	SummaryContextEnv = "LIGHTHOUSE_SUMMARY_CONTEXT"
+
+	summaryTargetURLTemplate = "{{ .BaseURL }}/teams/{{ .Team }}/projects/{{ .Owner }}/{{ .Repository }}/{{ .Branch }}"
+
+	// maxSummaryCacheSize bounds the number of commits whose last summary is remembered
+	maxSummaryCacheSize = 5000
+)
+
+// summaryCache remembers the last summary published for each commit so that it is only updated on changes
+type summaryCache struct {
+	lock      sync.Mutex
+	summaries map[string]string
+}
+
+// changed records the summary of the commit, returning false if it was already published
+func (s *summaryCache) changed(key, summary string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.summaries[key] == summary {
+		return false
+	}
+	if s.summaries == nil || len(s.summaries) >= maxSummaryCacheSize {
+		s.summaries = map[string]string{}
+	}
+	s.summaries[key] = summary
+	return true
+}
+
+// forget removes the summary of the commit so that it is published again
+func (s *summaryCache) forget(key string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	delete(s.summaries, key)
+}
+
+// getSummaryContext gets the context of the aggregate status from the environment
+func (c *Controller) getSummaryContext() string {
+	return os.Getenv(SummaryContextEnv)
+}
+
+// reportSummary publishes the aggregate status of the jobs of the same kind as the job which ran against the
+// commit: pending while any of them runs, failed if any failed and successful once all succeeded.
+func (c *Controller) reportSummary(scmClient scmprovider.SCMClient, ns string, job *v1alpha1.LighthouseJob, owner, repo, sha string, fields map[string]interface{}) {
+	summaryContext := c.getSummaryContext()
+	if summaryContext == "" || job.Spec.Type == config.PeriodicJob || job.Spec.Type == config.BatchJob {
+		return
+	}
+	jobs, err := c.jobsForCommit(ns, job, sha)
+	if err != nil {
+		c.logger.WithFields(fields).WithError(err).Warn("failed to list the jobs of the commit for the summary status")
+		return
+	}
+	state, description := summarize(jobs)
+
+	key := strings.Join([]string{owner, repo, sha, string(job.Spec.Type)}, "/")
+	if !c.summaries.changed(key, state.String()+description) {
+		return
+	}
+	status := &scm.StatusInput{
+		State: state,
+		Label: summaryContext,
+		Desc:  description,
+	}
+	if urlBase := c.getReportURLBase(); urlBase != "" {
+		team := ns
+		if urlTeam := c.getReportURLTeam(); urlTeam != "" {
+			team = urlTeam
+		}
+		targetURL := c.createReportTargetURL(summaryTargetURLTemplate, ReportParams{
+			Owner:      owner,
+			Repository: repo,
+			Branch:     job.Spec.GetBranch(),
+			Context:    summaryContext,
+			BaseURL:    strings.TrimRight(urlBase, "/"),
+			Team:       team,
+		})
+		if strings.HasPrefix(targetURL, "http://") || strings.HasPrefix(targetURL, "https://") {
+			status.Target = targetURL
+		}
+	}
+	if _, err := scmClient.CreateStatus(owner, repo, sha, status); err != nil {
+		c.summaries.forget(key)
+		c.logger.WithFields(fields).WithError(err).Warnf("failed to report the summary status %s", summaryContext)
+	}
+}
+
+// jobsForCommit returns the jobs of the same kind and branch as the job which ran against the commit, using
+// the given job rather than the cached copy as it may not have been stored yet
+func (c *Controller) jobsForCommit(ns string, job *v1alpha1.LighthouseJob, sha string) ([]*v1alpha1.LighthouseJob, error) {
+	selector := labels.Set{}
+	for _, key := range []string{config.LighthouseJobTypeLabel, util.OrgLabel, util.RepoLabel, util.BranchLabel} {
+		if value, ok := job.Labels[key]; ok {
+			selector[key] = value
+		}
+	}
+	list, err := c.lhLister.LighthouseJobs(ns).List(labels.SelectorFromSet(selector))
+	if err != nil {
+		return nil, err
+	}
+	answer := []*v1alpha1.LighthouseJob{job}
+	for _, j := range list {
+		if j.Name == job.Name || j.Spec.Type != job.Spec.Type || j.Spec.GetSHA() != sha {
+			continue
+		}
+		answer = append(answer, j)
+	}
+	return answer, nil
+}
+
+// summarize aggregates the latest result of each context of the jobs into a single state
+func summarize(jobs []*v1alpha1.LighthouseJob) (scm.State, string) {
+	latest := map[string]*v1alpha1.LighthouseJob{}
+	for _, j := range jobs {
+		if previous, ok := latest[j.Spec.Context]; ok && j.Status.StartTime.Before(&previous.Status.StartTime) {
+			continue
+		}
+		latest[j.Spec.Context] = j
+	}
+	var passed, failed, running int
+	for _, j := range latest {
+		switch j.Status.State {
+		case v1alpha1.SuccessState:
+			passed++
+		case v1alpha1.FailureState, v1alpha1.ErrorState, v1alpha1.AbortedState:
+			failed++
+		default:
+			running++
+		}
+	}
+	total := len(latest)
+	switch {
+	case running > 0:
+		return scm.StatePending, fmt.Sprintf("%d/%d jobs passed, %d failed, %d running", passed, total, failed, running)
+	case failed > 0:
+		return scm.StateFailure, fmt.Sprintf("%d/%d jobs passed, %d failed", passed, total, failed)
+	default:
+		return scm.StateSuccess, fmt.Sprintf("All %d jobs passed", total)
+	}
+}
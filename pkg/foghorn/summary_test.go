@@ -0,0 +1,79 @@
+package foghorn
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func summaryJob(context string, state v1alpha1.PipelineState, started time.Time) *v1alpha1.LighthouseJob {
+	return &v1alpha1.LighthouseJob{
+		Spec: v1alpha1.LighthouseJobSpec{Context: context},
+		Status: v1alpha1.LighthouseJobStatus{
+			State:     state,
+			StartTime: metav1.NewTime(started),
+		},
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	now := time.Now()
+	earlier := now.Add(-time.Hour)
+
+	testCases := []struct {
+		name                string
+		jobs                []*v1alpha1.LighthouseJob
+		expectedState       scm.State
+		expectedDescription string
+	}{
+		{
+			name: "pending while a job runs",
+			jobs: []*v1alpha1.LighthouseJob{
+				summaryJob("unit", v1alpha1.SuccessState, now),
+				summaryJob("lint", v1alpha1.FailureState, now),
+				summaryJob("e2e", v1alpha1.RunningState, now),
+			},
+			expectedState:       scm.StatePending,
+			expectedDescription: "1/3 jobs passed, 1 failed, 1 running",
+		},
+		{
+			name: "failed once all jobs completed",
+			jobs: []*v1alpha1.LighthouseJob{
+				summaryJob("unit", v1alpha1.SuccessState, now),
+				summaryJob("lint", v1alpha1.ErrorState, now),
+			},
+			expectedState:       scm.StateFailure,
+			expectedDescription: "1/2 jobs passed, 1 failed",
+		},
+		{
+			name: "latest run of a context wins",
+			jobs: []*v1alpha1.LighthouseJob{
+				summaryJob("unit", v1alpha1.SuccessState, now),
+				summaryJob("lint", v1alpha1.FailureState, earlier),
+				summaryJob("lint", v1alpha1.SuccessState, now),
+			},
+			expectedState:       scm.StateSuccess,
+			expectedDescription: "All 2 jobs passed",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			state, description := summarize(tc.jobs)
+			assert.Equal(t, tc.expectedState, state)
+			assert.Equal(t, tc.expectedDescription, description)
+		})
+	}
+}
+
+func TestSummaryCache(t *testing.T) {
+	cache := summaryCache{}
+	assert.True(t, cache.changed("org/repo/abc", "pending"))
+	assert.False(t, cache.changed("org/repo/abc", "pending"))
+	assert.True(t, cache.changed("org/repo/abc", "success"))
+	cache.forget("org/repo/abc")
+	assert.True(t, cache.changed("org/repo/abc", "success"))
+}
@@ -0,0 +1,112 @@
+package gc
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"github.com/pkg/errors"
+)
+
+const (
+	// ArchiveFileName is the name of the file each job is archived to inside its build directory
+	ArchiveFileName = "lighthousejob.json"
+	// BuildLogFileName is the name of the file the build log of a job is archived to inside its build directory
+	BuildLogFileName = "build-log.txt"
+)
+
+// Archiver stores completed jobs before they are deleted
+type Archiver interface {
+	// Archive stores the record under the given key, overwriting any existing record
+	Archive(key string, record *ArchiveRecord) error
+}
+
+// ArchiveRecord is the archived form of a LighthouseJob
+type ArchiveRecord struct {
+	// Job is the full LighthouseJob, including its spec and status
+	Job v1alpha1.LighthouseJob `json:"job"`
+	// LogsURL points at where the logs for the job can be found once the job is gone
+	LogsURL string `json:"logsURL,omitempty"`
+	// ArchivedAt is when the record was written
+	ArchivedAt time.Time `json:"archivedAt"`
+}
+
+// ArchiveKey returns the key used to archive a job, laid out as org/repo/job/build-number so
+// that history can be browsed and queried by repository and job.
+func ArchiveKey(job *v1alpha1.LighthouseJob) string {
+	return path.Join(BuildDir(job), ArchiveFileName)
+}
+
+// BuildDir returns the directory below the archive which holds the files of a job's build
+func BuildDir(job *v1alpha1.LighthouseJob) string {
+	return BuildPath(BuildCoordinates(job))
+}
+
+// BuildCoordinates returns the org, repo, job name and build number identifying a job's build
+func BuildCoordinates(job *v1alpha1.LighthouseJob) (org, repo, jobName, build string) {
+	org, repo = "unknown", "unknown"
+	if job.Spec.Refs != nil {
+		org = job.Spec.Refs.Org
+		repo = job.Spec.Refs.Repo
+	}
+	jobName = job.Spec.Job
+	if jobName == "" {
+		jobName = job.Spec.Context
+	}
+	build = job.Labels[util.BuildNumLabel]
+	if build == "" {
+		// without a build number fall back to the object name which is unique in the namespace
+		build = job.Name
+	}
+	return org, repo, jobName, build
+}
+
+// BuildPath returns the directory below the archive which holds the files of a build of a repository's job
+func BuildPath(org, repo, jobName, build string) string {
+	return path.Join(sanitizeKeyPart(org), sanitizeKeyPart(repo), sanitizeKeyPart(jobName), sanitizeKeyPart(build))
+}
+
+func sanitizeKeyPart(s string) string {
+	s = strings.Replace(s, "/", "-", -1)
+	if s == "" || s == "." || s == ".." {
+		return "unknown"
+	}
+	return s
+}
+
+// NewArchiveRecord creates the record to archive for a job
+func NewArchiveRecord(job *v1alpha1.LighthouseJob, archivedAt time.Time) *ArchiveRecord {
+	return &ArchiveRecord{
+		Job:        *job.DeepCopy(),
+		LogsURL:    job.Status.ReportURL,
+		ArchivedAt: archivedAt,
+	}
+}
+
+// DirArchiver archives jobs as JSON files below a directory, which is typically a volume
+// backed by a storage bucket.
+type DirArchiver struct {
+	Dir string
+}
+
+// Archive writes the record as JSON to the key below the archive directory
+func (a *DirArchiver) Archive(key string, record *ArchiveRecord) error {
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return errors.Wrapf(err, "marshalling archive record for %s", record.Job.Name)
+	}
+	fileName := filepath.Join(a.Dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
+		return errors.Wrapf(err, "creating archive directory for %s", fileName)
+	}
+	if err := ioutil.WriteFile(fileName, data, 0644); err != nil {
+		return errors.Wrapf(err, "writing archive file %s", fileName)
+	}
+	return nil
+}
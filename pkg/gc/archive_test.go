@@ -0,0 +1,79 @@
+package gc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	lhfake "github.com/jenkins-x/lighthouse/pkg/client/clientset/versioned/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type failingArchiver struct{}
+
+func (f *failingArchiver) Archive(key string, record *ArchiveRecord) error {
+	return fmt.Errorf("bucket unavailable")
+}
+
+func TestArchiveKey(t *testing.T) {
+	job := makeJob("old", "repo", v1alpha1.SuccessState, time.Hour, time.Hour)
+	job.Spec.Job = "pr-build"
+	assert.Equal(t, "org/repo/pr-build/1/lighthousejob.json", ArchiveKey(job))
+
+	job.Spec.Job = ""
+	job.Labels = nil
+	assert.Equal(t, "org/repo/ci/old/lighthousejob.json", ArchiveKey(job))
+
+	job.Spec.Refs = nil
+	job.Spec.Context = "../.."
+	assert.Equal(t, "unknown/unknown/..-../old/lighthousejob.json", ArchiveKey(job))
+}
+
+func TestCollectorArchivesBeforeDeleting(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lighthouse-gc-archive")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	job := makeJob("old", "repo", v1alpha1.FailureState, 100*time.Hour, 99*time.Hour)
+	job.Spec.Job = "pr-build"
+	job.Status.ReportURL = "https://dashboard/logs/old"
+	lhClient := lhfake.NewSimpleClientset(job)
+
+	c := NewCollector(lhClient, nil, nil, ns, Policy{MaxAge: time.Hour}, false, nil).WithArchiver(&DirArchiver{Dir: dir})
+	c.now = func() time.Time { return now }
+
+	deleted, err := c.Run()
+	require.NoError(t, err)
+	assert.Len(t, deleted, 1)
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "org", "repo", "pr-build", "1", ArchiveFileName))
+	require.NoError(t, err)
+	record := &ArchiveRecord{}
+	require.NoError(t, json.Unmarshal(data, record))
+	assert.Equal(t, "old", record.Job.Name)
+	assert.Equal(t, v1alpha1.FailureState, record.Job.Status.State)
+	assert.Equal(t, "https://dashboard/logs/old", record.LogsURL)
+	assert.True(t, now.Equal(record.ArchivedAt))
+}
+
+func TestCollectorKeepsJobWhenArchiveFails(t *testing.T) {
+	job := makeJob("old", "repo", v1alpha1.SuccessState, 100*time.Hour, 99*time.Hour)
+	lhClient := lhfake.NewSimpleClientset(job)
+
+	c := NewCollector(lhClient, nil, nil, ns, Policy{MaxAge: time.Hour}, false, nil).WithArchiver(&failingArchiver{})
+	c.now = func() time.Time { return now }
+
+	_, err := c.Run()
+	assert.Error(t, err)
+
+	jobs, err := lhClient.LighthouseV1alpha1().LighthouseJobs(ns).List(metav1.ListOptions{})
+	require.NoError(t, err)
+	assert.Len(t, jobs.Items, 1)
+}
@@ -0,0 +1,245 @@
+// Package gc contains the garbage collector for LighthouseJobs and the pipeline
+// resources created for them.
+package gc
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	jxclient "github.com/jenkins-x/jx/v2/pkg/client/clientset/versioned"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	clientset "github.com/jenkins-x/lighthouse/pkg/client/clientset/versioned"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+	tektonclient "github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
+	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// KindLighthouseJob is the metric label used for reclaimed LighthouseJobs
+	KindLighthouseJob = "LighthouseJob"
+	// KindPipelineActivity is the metric label used for reclaimed PipelineActivities
+	KindPipelineActivity = "PipelineActivity"
+	// KindPipelineRun is the metric label used for reclaimed PipelineRuns
+	KindPipelineRun = "PipelineRun"
+)
+
+var reclaimedObjects = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "lighthouse_gc_reclaimed_objects",
+	Help: "A counter of the objects deleted (or that would have been deleted in dry-run mode) by the garbage collector.",
+}, []string{"kind", "dry_run"})
+
+func init() {
+	prometheus.MustRegister(reclaimedObjects)
+}
+
+// Policy describes how long LighthouseJobs are retained before being collected.
+type Policy struct {
+	// MaxAge is the retention for jobs whose state has no entry in StateMaxAge, and
+	// for jobs which never completed, measured from their start time.
+	MaxAge time.Duration
+	// StateMaxAge overrides MaxAge for completed jobs in the given state, measured from
+	// their completion time.
+	StateMaxAge map[v1alpha1.PipelineState]time.Duration
+	// MaxPerRepo is the maximum number of completed jobs kept for each org/repo, newest
+	// first. Zero means there is no limit.
+	MaxPerRepo int
+}
+
+// maxAgeFor returns the retention for a job in the given state
+func (p *Policy) maxAgeFor(state v1alpha1.PipelineState) time.Duration {
+	if d, ok := p.StateMaxAge[state]; ok {
+		return d
+	}
+	return p.MaxAge
+}
+
+// ParseStateMaxAges parses a list of state=duration pairs, e.g. "success=24h", into a map.
+func ParseStateMaxAges(values []string) (map[v1alpha1.PipelineState]time.Duration, error) {
+	answer := map[v1alpha1.PipelineState]time.Duration{}
+	for _, value := range values {
+		parts := strings.SplitN(value, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid state max age %q, expected state=duration", value)
+		}
+		d, err := time.ParseDuration(parts[1])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid duration for state %s", parts[0])
+		}
+		answer[v1alpha1.PipelineState(parts[0])] = d
+	}
+	return answer, nil
+}
+
+// Collector deletes LighthouseJobs which are older than the retention policy allows
+// along with the PipelineActivity and PipelineRuns created for them.
+type Collector struct {
+	lhClient     clientset.Interface
+	jxClient     jxclient.Interface
+	tektonClient tektonclient.Interface
+	namespace    string
+	policy       Policy
+	dryRun       bool
+	archiver     Archiver
+	logger       *logrus.Entry
+
+	now func() time.Time
+}
+
+// NewCollector creates a new garbage collector for the given namespace
+func NewCollector(lhClient clientset.Interface, jxClient jxclient.Interface, tektonClient tektonclient.Interface, namespace string, policy Policy, dryRun bool, logger *logrus.Entry) *Collector {
+	if logger == nil {
+		logger = logrus.NewEntry(logrus.StandardLogger())
+	}
+	return &Collector{
+		lhClient:     lhClient,
+		jxClient:     jxClient,
+		tektonClient: tektonClient,
+		namespace:    namespace,
+		policy:       policy,
+		dryRun:       dryRun,
+		logger:       logger.WithField("controller", "gc"),
+		now:          time.Now,
+	}
+}
+
+// WithArchiver configures the collector to archive each job before it is deleted. A job which
+// fails to archive is not deleted.
+func (c *Collector) WithArchiver(archiver Archiver) *Collector {
+	c.archiver = archiver
+	return c
+}
+
+// Run performs a single garbage collection pass, returning the jobs which were deleted.
+func (c *Collector) Run() ([]v1alpha1.LighthouseJob, error) {
+	jobList, err := c.lhClient.LighthouseV1alpha1().LighthouseJobs(c.namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing LighthouseJobs in namespace %s", c.namespace)
+	}
+
+	var deleted []v1alpha1.LighthouseJob
+	for _, job := range c.selectJobs(jobList.Items) {
+		if err := c.deleteJob(job); err != nil {
+			return deleted, err
+		}
+		deleted = append(deleted, job)
+	}
+	return deleted, nil
+}
+
+// selectJobs returns the jobs which should be collected according to the policy
+func (c *Collector) selectJobs(jobs []v1alpha1.LighthouseJob) []v1alpha1.LighthouseJob {
+	now := c.now()
+	var expired []v1alpha1.LighthouseJob
+	byRepo := map[string][]v1alpha1.LighthouseJob{}
+	for _, job := range jobs {
+		completionTime := job.Status.CompletionTime
+		if completionTime != nil {
+			if completionTime.Add(c.policy.maxAgeFor(job.Status.State)).Before(now) {
+				// The job completed longer ago than its state is retained for, so delete it.
+				expired = append(expired, job)
+				continue
+			}
+			if job.Spec.Refs != nil {
+				key := job.Spec.Refs.Org + "/" + job.Spec.Refs.Repo
+				byRepo[key] = append(byRepo[key], job)
+			}
+		} else if job.Status.StartTime.Add(c.policy.MaxAge).Before(now) {
+			// The job never completed, but was created at least MaxAge ago, so delete it.
+			expired = append(expired, job)
+		}
+	}
+
+	if c.policy.MaxPerRepo > 0 {
+		for _, repoJobs := range byRepo {
+			if len(repoJobs) <= c.policy.MaxPerRepo {
+				continue
+			}
+			sort.Slice(repoJobs, func(i, j int) bool {
+				return repoJobs[i].Status.CompletionTime.After(repoJobs[j].Status.CompletionTime.Time)
+			})
+			expired = append(expired, repoJobs[c.policy.MaxPerRepo:]...)
+		}
+	}
+	return expired
+}
+
+func (c *Collector) deleteJob(job v1alpha1.LighthouseJob) error {
+	dryRun := fmt.Sprintf("%t", c.dryRun)
+	l := c.logger.WithFields(logrus.Fields{
+		"job":   job.Name,
+		"state": job.Status.State,
+	})
+
+	if c.archiver != nil {
+		key := ArchiveKey(&job)
+		l.Infof("Archiving LighthouseJob %s to %s", job.Name, key)
+		if !c.dryRun {
+			if err := c.archiver.Archive(key, NewArchiveRecord(&job, c.now())); err != nil {
+				return errors.Wrapf(err, "archiving LighthouseJob %s", job.Name)
+			}
+		}
+	}
+
+	if job.Status.ActivityName != "" && c.jxClient != nil {
+		l.Infof("Deleting PipelineActivity %s", job.Status.ActivityName)
+		if !c.dryRun {
+			err := c.jxClient.JenkinsV1().PipelineActivities(job.Namespace).Delete(job.Status.ActivityName, metav1.NewDeleteOptions(0))
+			if err != nil && !kubeerrors.IsNotFound(err) {
+				return errors.Wrapf(err, "deleting PipelineActivity %s", job.Status.ActivityName)
+			}
+		}
+		reclaimedObjects.WithLabelValues(KindPipelineActivity, dryRun).Inc()
+	}
+
+	if selector := PipelineRunSelector(&job); selector != "" && c.tektonClient != nil {
+		runs, err := c.tektonClient.TektonV1alpha1().PipelineRuns(job.Namespace).List(metav1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			return errors.Wrapf(err, "listing PipelineRuns for LighthouseJob %s", job.Name)
+		}
+		for _, run := range runs.Items {
+			l.Infof("Deleting PipelineRun %s", run.Name)
+			if !c.dryRun {
+				err := c.tektonClient.TektonV1alpha1().PipelineRuns(job.Namespace).Delete(run.Name, metav1.NewDeleteOptions(0))
+				if err != nil && !kubeerrors.IsNotFound(err) {
+					return errors.Wrapf(err, "deleting PipelineRun %s", run.Name)
+				}
+			}
+			reclaimedObjects.WithLabelValues(KindPipelineRun, dryRun).Inc()
+		}
+	}
+
+	l.Infof("Deleting LighthouseJob %s", job.Name)
+	if !c.dryRun {
+		err := c.lhClient.LighthouseV1alpha1().LighthouseJobs(job.Namespace).Delete(job.Name, metav1.NewDeleteOptions(0))
+		if err != nil && !kubeerrors.IsNotFound(err) {
+			return errors.Wrapf(err, "deleting LighthouseJob %s", job.Name)
+		}
+	}
+	reclaimedObjects.WithLabelValues(KindLighthouseJob, dryRun).Inc()
+	return nil
+}
+
+// PipelineRunSelector returns the label selector matching the PipelineRuns created for the job, and their pods,
+// or an empty string if the job does not carry enough information to find them safely.
+func PipelineRunSelector(job *v1alpha1.LighthouseJob) string {
+	buildNum := job.Labels[util.BuildNumLabel]
+	if buildNum == "" || job.Spec.Refs == nil {
+		return ""
+	}
+	selectors := []string{
+		fmt.Sprintf("%s=%s", util.ActivityOwnerLabel, job.Spec.Refs.Org),
+		fmt.Sprintf("%s=%s", util.ActivityRepositoryLabel, job.Spec.Refs.Repo),
+		fmt.Sprintf("%s=%s", util.ActivityBranchLabel, job.Spec.GetBranch()),
+		fmt.Sprintf("%s=%s", util.ActivityBuildLabel, buildNum),
+	}
+	if job.Spec.Context != "" {
+		selectors = append(selectors, fmt.Sprintf("%s=%s", util.ActivityContextLabel, job.Spec.Context))
+	}
+	return strings.Join(selectors, ",")
+}
@@ -0,0 +1,213 @@
+package gc
+
+import (
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	jxv1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	jxfake "github.com/jenkins-x/jx/v2/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	lhfake "github.com/jenkins-x/lighthouse/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	pipelinev1alpha1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
+	tektonfake "github.com/tektoncd/pipeline/pkg/client/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const ns = "jx"
+
+var now = time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func makeJob(name, repo string, state v1alpha1.PipelineState, started, completed time.Duration) *v1alpha1.LighthouseJob {
+	job := &v1alpha1.LighthouseJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ns,
+			Labels:    map[string]string{util.BuildNumLabel: "1"},
+		},
+		Spec: v1alpha1.LighthouseJobSpec{
+			Type:    "postsubmit",
+			Context: "ci",
+			Refs: &v1alpha1.Refs{
+				Org:     "org",
+				Repo:    repo,
+				BaseRef: "master",
+			},
+		},
+		Status: v1alpha1.LighthouseJobStatus{
+			State:        state,
+			ActivityName: name + "-activity",
+			StartTime:    metav1.NewTime(now.Add(-started)),
+		},
+	}
+	if completed > 0 {
+		t := metav1.NewTime(now.Add(-completed))
+		job.Status.CompletionTime = &t
+	}
+	return job
+}
+
+func TestCollector(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   Policy
+		jobs     []*v1alpha1.LighthouseJob
+		expected []string
+	}{
+		{
+			name:   "default max age",
+			policy: Policy{MaxAge: 48 * time.Hour},
+			jobs: []*v1alpha1.LighthouseJob{
+				makeJob("old", "repo", v1alpha1.SuccessState, 100*time.Hour, 99*time.Hour),
+				makeJob("new", "repo", v1alpha1.SuccessState, 2*time.Hour, time.Hour),
+				makeJob("stuck", "repo", v1alpha1.PendingState, 50*time.Hour, 0),
+				makeJob("running", "repo", v1alpha1.RunningState, 10*time.Hour, 0),
+			},
+			expected: []string{"old", "stuck"},
+		},
+		{
+			name: "per state max age",
+			policy: Policy{
+				MaxAge: 7 * 24 * time.Hour,
+				StateMaxAge: map[v1alpha1.PipelineState]time.Duration{
+					v1alpha1.SuccessState: 24 * time.Hour,
+					v1alpha1.FailureState: 72 * time.Hour,
+				},
+			},
+			jobs: []*v1alpha1.LighthouseJob{
+				makeJob("success-old", "repo", v1alpha1.SuccessState, 30*time.Hour, 25*time.Hour),
+				makeJob("success-new", "repo", v1alpha1.SuccessState, 20*time.Hour, 20*time.Hour),
+				makeJob("failure-kept", "repo", v1alpha1.FailureState, 30*time.Hour, 25*time.Hour),
+				makeJob("failure-old", "repo", v1alpha1.FailureState, 80*time.Hour, 73*time.Hour),
+				makeJob("aborted-kept", "repo", v1alpha1.AbortedState, 80*time.Hour, 73*time.Hour),
+			},
+			expected: []string{"failure-old", "success-old"},
+		},
+		{
+			name:   "max per repo",
+			policy: Policy{MaxAge: 48 * time.Hour, MaxPerRepo: 2},
+			jobs: []*v1alpha1.LighthouseJob{
+				makeJob("a1", "a", v1alpha1.SuccessState, 5*time.Hour, 4*time.Hour),
+				makeJob("a2", "a", v1alpha1.FailureState, 4*time.Hour, 3*time.Hour),
+				makeJob("a3", "a", v1alpha1.SuccessState, 3*time.Hour, 2*time.Hour),
+				makeJob("a4", "a", v1alpha1.SuccessState, 2*time.Hour, time.Hour),
+				makeJob("a-running", "a", v1alpha1.RunningState, time.Hour, 0),
+				makeJob("b1", "b", v1alpha1.SuccessState, 5*time.Hour, 4*time.Hour),
+			},
+			expected: []string{"a1", "a2"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var lhObjects, jxObjects, tektonObjects []runtime.Object
+			for _, job := range tc.jobs {
+				lhObjects = append(lhObjects, job)
+				jxObjects = append(jxObjects, &jxv1.PipelineActivity{
+					ObjectMeta: metav1.ObjectMeta{Name: job.Status.ActivityName, Namespace: ns},
+				})
+				tektonObjects = append(tektonObjects, &pipelinev1alpha1.PipelineRun{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      job.Name + "-run",
+						Namespace: ns,
+						Labels: map[string]string{
+							util.ActivityOwnerLabel:      job.Spec.Refs.Org,
+							util.ActivityRepositoryLabel: job.Spec.Refs.Repo,
+							util.ActivityBranchLabel:     job.Spec.GetBranch(),
+							util.ActivityBuildLabel:      "1",
+							util.ActivityContextLabel:    job.Spec.Context,
+						},
+					},
+				})
+			}
+			// Give every job in the same repo a distinct build so that the runs can be told apart
+			for i, obj := range tektonObjects {
+				run := obj.(*pipelinev1alpha1.PipelineRun)
+				build := strconv.Itoa(i + 1)
+				run.Labels[util.ActivityBuildLabel] = build
+				tc.jobs[i].Labels[util.BuildNumLabel] = build
+			}
+
+			lhClient := lhfake.NewSimpleClientset(lhObjects...)
+			jxClient := jxfake.NewSimpleClientset(jxObjects...)
+			tektonClient := tektonfake.NewSimpleClientset(tektonObjects...)
+
+			c := NewCollector(lhClient, jxClient, tektonClient, ns, tc.policy, false, nil)
+			c.now = func() time.Time { return now }
+
+			deleted, err := c.Run()
+			require.NoError(t, err)
+
+			var deletedNames []string
+			for _, job := range deleted {
+				deletedNames = append(deletedNames, job.Name)
+			}
+			sort.Strings(deletedNames)
+			assert.Equal(t, tc.expected, deletedNames)
+
+			jobs, err := lhClient.LighthouseV1alpha1().LighthouseJobs(ns).List(metav1.ListOptions{})
+			require.NoError(t, err)
+			assert.Len(t, jobs.Items, len(tc.jobs)-len(tc.expected))
+
+			activities, err := jxClient.JenkinsV1().PipelineActivities(ns).List(metav1.ListOptions{})
+			require.NoError(t, err)
+			assert.Len(t, activities.Items, len(tc.jobs)-len(tc.expected))
+			for _, a := range activities.Items {
+				for _, name := range tc.expected {
+					assert.NotEqual(t, name+"-activity", a.Name)
+				}
+			}
+
+			runs, err := tektonClient.TektonV1alpha1().PipelineRuns(ns).List(metav1.ListOptions{})
+			require.NoError(t, err)
+			assert.Len(t, runs.Items, len(tc.jobs)-len(tc.expected))
+			for _, r := range runs.Items {
+				for _, name := range tc.expected {
+					assert.NotEqual(t, name+"-run", r.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestCollectorDryRun(t *testing.T) {
+	job := makeJob("old", "repo", v1alpha1.SuccessState, 100*time.Hour, 99*time.Hour)
+	lhClient := lhfake.NewSimpleClientset(job)
+	jxClient := jxfake.NewSimpleClientset(&jxv1.PipelineActivity{
+		ObjectMeta: metav1.ObjectMeta{Name: job.Status.ActivityName, Namespace: ns},
+	})
+
+	c := NewCollector(lhClient, jxClient, tektonfake.NewSimpleClientset(), ns, Policy{MaxAge: time.Hour}, true, nil)
+	c.now = func() time.Time { return now }
+
+	deleted, err := c.Run()
+	require.NoError(t, err)
+	assert.Len(t, deleted, 1)
+
+	jobs, err := lhClient.LighthouseV1alpha1().LighthouseJobs(ns).List(metav1.ListOptions{})
+	require.NoError(t, err)
+	assert.Len(t, jobs.Items, 1)
+
+	activities, err := jxClient.JenkinsV1().PipelineActivities(ns).List(metav1.ListOptions{})
+	require.NoError(t, err)
+	assert.Len(t, activities.Items, 1)
+}
+
+func TestParseStateMaxAges(t *testing.T) {
+	ages, err := ParseStateMaxAges([]string{"success=24h", "failure=72h"})
+	require.NoError(t, err)
+	assert.Equal(t, map[v1alpha1.PipelineState]time.Duration{
+		v1alpha1.SuccessState: 24 * time.Hour,
+		v1alpha1.FailureState: 72 * time.Hour,
+	}, ages)
+
+	_, err = ParseStateMaxAges([]string{"success"})
+	assert.Error(t, err)
+	_, err = ParseStateMaxAges([]string{"success=forever"})
+	assert.Error(t, err)
+}
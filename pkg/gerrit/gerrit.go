@@ -0,0 +1,358 @@
+// Package gerrit implements a go-scm client of the Gerrit REST API, so that lighthouse serves Gerrit projects like
+// the repositories of the other providers. The changes of a project are its pull requests, their current patch set
+// being the head, their messages the comments and their hashtags the labels. Statuses are reported as review
+// messages which also vote on the Verified label, and the projects are named org/repo.
+//
+// go-scm has no Gerrit driver, so the clients are created with New rather than its factory.
+package gerrit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/pkg/errors"
+)
+
+const (
+	// Kind is the git kind of Gerrit servers, e.g. in $GIT_KIND
+	Kind = "gerrit"
+
+	// Driver identifies the clients created by New, go-scm not having a driver for Gerrit
+	Driver scm.Driver = 1000
+)
+
+// xssiPrefix is the prefix Gerrit adds to its JSON responses to prevent them from being run as scripts
+const xssiPrefix = ")]}'"
+
+// timeLayout is the layout of the timestamps of the Gerrit REST API, which are in UTC
+const timeLayout = "2006-01-02 15:04:05.000000000"
+
+// New returns a client of the Gerrit server at the URL, which authenticates as the user with the HTTP password
+// given to SetCredentials
+func New(serverURL, username string) (*scm.Client, error) {
+	base, err := url.Parse(serverURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing Gerrit URL %s", serverURL)
+	}
+	if !strings.HasSuffix(base.Path, "/") {
+		base.Path = base.Path + "/"
+	}
+	client := &wrapper{Client: &scm.Client{}, username: username}
+	client.BaseURL = base
+	client.Driver = Driver
+	client.Contents = &contentService{client}
+	client.Git = &gitService{client}
+	client.Issues = &issueService{}
+	client.Organizations = &organizationService{client}
+	client.PullRequests = &pullService{client}
+	client.Repositories = &repositoryService{client}
+	client.Reviews = &reviewService{}
+	client.Users = &userService{client}
+	client.Webhooks = &webhookService{client}
+	return client.Client, nil
+}
+
+// Username returns the user a client created by New authenticates as, and false for the clients of other drivers
+func Username(client *scm.Client) (string, bool) {
+	users, ok := client.Users.(*userService)
+	if !ok {
+		return "", false
+	}
+	return users.client.username, true
+}
+
+// SetCredentials authenticates the requests of the client with the HTTP password of its user, which is generated
+// in the settings of the account on the Gerrit server
+func SetCredentials(client *scm.Client, password string) {
+	username, _ := Username(client)
+	client.Client = &http.Client{Transport: &basicAuth{username: username, password: password}}
+}
+
+type basicAuth struct {
+	username, password string
+}
+
+// RoundTrip adds the credentials to the request
+func (t *basicAuth) RoundTrip(r *http.Request) (*http.Response, error) {
+	r2 := r.Clone(r.Context())
+	r2.SetBasicAuth(t.username, t.password)
+	return http.DefaultTransport.RoundTrip(r2)
+}
+
+// wrapper sends the requests of the services to the REST API
+type wrapper struct {
+	*scm.Client
+	username string
+}
+
+// do sends the request to the path of the REST API, below /a/ for authenticated requests, and decodes its JSON
+// response into out unless it is nil
+func (c *wrapper) do(ctx context.Context, method, path string, in, out interface{}) (*scm.Response, error) {
+	if c.username != "" {
+		path = "a/" + path
+	}
+	req := &scm.Request{Method: method, Path: path}
+	if in != nil {
+		buf := new(bytes.Buffer)
+		if err := json.NewEncoder(buf).Encode(in); err != nil {
+			return nil, err
+		}
+		req.Header = map[string][]string{"Content-Type": {"application/json"}}
+		req.Body = buf
+	}
+	res, err := c.Client.Do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return res, err
+	}
+	if res.Status == http.StatusNotFound {
+		return res, scm.ErrNotFound
+	}
+	if res.Status >= 300 {
+		return res, errors.Errorf("%s %s: %s: %s", method, path, http.StatusText(res.Status), strings.TrimSpace(string(body)))
+	}
+	if out == nil {
+		return res, nil
+	}
+	if w, ok := out.(io.Writer); ok {
+		_, err = w.Write(body)
+		return res, err
+	}
+	body = bytes.TrimPrefix(body, []byte(xssiPrefix))
+	return res, json.Unmarshal(body, out)
+}
+
+// projectURL returns the web page of the project
+func (c *wrapper) projectURL(project string) string {
+	return c.BaseURL.String() + "admin/repos/" + project
+}
+
+// changeURL returns the web page of the change
+func (c *wrapper) changeURL(project string, number int) string {
+	return fmt.Sprintf("%sc/%s/+/%d", c.BaseURL.String(), project, number)
+}
+
+// repository returns the repository of the project, which is named org/repo
+func (c *wrapper) repository(project, branch string) scm.Repository {
+	namespace, name := "", project
+	if i := strings.LastIndex(project, "/"); i >= 0 {
+		namespace, name = project[:i], project[i+1:]
+	}
+	clone := c.BaseURL.String() + project
+	if c.username != "" {
+		// the credentials of the bot are only asked for under /a/
+		clone = c.BaseURL.String() + "a/" + project
+	}
+	return scm.Repository{
+		ID:        project,
+		Namespace: namespace,
+		Name:      name,
+		FullName:  project,
+		Branch:    branch,
+		Link:      c.projectURL(project),
+		Clone:     clone,
+	}
+}
+
+// changeID identifies the change of the project in the paths of the REST API
+func changeID(project string, number int) string {
+	return fmt.Sprintf("%s~%d", url.PathEscape(project), number)
+}
+
+// projectID identifies the project in the paths of the REST API
+func projectID(project string) string {
+	return url.PathEscape(project)
+}
+
+// timestamp is a time of the REST API
+type timestamp struct {
+	time.Time
+}
+
+// UnmarshalJSON parses the time, e.g. "2013-02-21 11:16:36.775000000"
+func (t *timestamp) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	if value == "" {
+		return nil
+	}
+	parsed, err := time.Parse(timeLayout, value)
+	if err != nil {
+		return err
+	}
+	t.Time = parsed
+	return nil
+}
+
+type accountInfo struct {
+	AccountID int    `json:"_account_id"`
+	Name      string `json:"name"`
+	Email     string `json:"email"`
+	Username  string `json:"username"`
+}
+
+// user returns the user of the account, whose login is its username
+func (a accountInfo) user() scm.User {
+	login := a.Username
+	if login == "" {
+		login = a.Email
+	}
+	return scm.User{ID: a.AccountID, Login: login, Name: a.Name, Email: a.Email}
+}
+
+type commitInfo struct {
+	Commit  string `json:"commit"`
+	Parents []struct {
+		Commit string `json:"commit"`
+	} `json:"parents"`
+	Author struct {
+		Name  string    `json:"name"`
+		Email string    `json:"email"`
+		Date  timestamp `json:"date"`
+	} `json:"author"`
+	Committer struct {
+		Name  string    `json:"name"`
+		Email string    `json:"email"`
+		Date  timestamp `json:"date"`
+	} `json:"committer"`
+	Subject string `json:"subject"`
+	Message string `json:"message"`
+}
+
+type revisionInfo struct {
+	Number int        `json:"_number"`
+	Ref    string     `json:"ref"`
+	Commit commitInfo `json:"commit"`
+}
+
+type changeMessageInfo struct {
+	ID             string      `json:"id"`
+	Author         accountInfo `json:"author"`
+	Date           timestamp   `json:"date"`
+	Message        string      `json:"message"`
+	Tag            string      `json:"tag"`
+	RevisionNumber int         `json:"_revision_number"`
+}
+
+type changeInfo struct {
+	ID              string                  `json:"id"`
+	Project         string                  `json:"project"`
+	Branch          string                  `json:"branch"`
+	Subject         string                  `json:"subject"`
+	Status          string                  `json:"status"`
+	Created         timestamp               `json:"created"`
+	Updated         timestamp               `json:"updated"`
+	Number          int                     `json:"_number"`
+	Owner           accountInfo             `json:"owner"`
+	Hashtags        []string                `json:"hashtags"`
+	WorkInProgress  bool                    `json:"work_in_progress"`
+	Mergeable       *bool                   `json:"mergeable"`
+	CurrentRevision string                  `json:"current_revision"`
+	Revisions       map[string]revisionInfo `json:"revisions"`
+	Messages        []changeMessageInfo     `json:"messages"`
+	MoreChanges     bool                    `json:"_more_changes"`
+}
+
+// pullRequest converts the change into the pull request of its current patch set
+func (c *wrapper) pullRequest(change *changeInfo) *scm.PullRequest {
+	repo := c.repository(change.Project, "")
+	revision := change.Revisions[change.CurrentRevision]
+	var baseSHA string
+	if len(revision.Commit.Parents) > 0 {
+		baseSHA = revision.Commit.Parents[0].Commit
+	}
+	pr := &scm.PullRequest{
+		Number:  change.Number,
+		Title:   change.Subject,
+		Body:    revision.Commit.Message,
+		Sha:     change.CurrentRevision,
+		Ref:     revision.Ref,
+		Source:  revision.Ref,
+		Target:  change.Branch,
+		Fork:    change.Project,
+		Link:    c.changeURL(change.Project, change.Number),
+		Draft:   change.WorkInProgress,
+		Closed:  change.Status != "NEW",
+		Merged:  change.Status == "MERGED",
+		State:   "open",
+		Base:    scm.PullRequestBranch{Ref: change.Branch, Sha: baseSHA, Repo: repo},
+		Head:    scm.PullRequestBranch{Ref: revision.Ref, Sha: change.CurrentRevision, Repo: repo},
+		Author:  change.Owner.user(),
+		Created: change.Created.Time,
+		Updated: change.Updated.Time,
+	}
+	if pr.Closed {
+		pr.State = "closed"
+	}
+	if pr.Merged {
+		pr.MergeSha = change.CurrentRevision
+	}
+	if change.Mergeable != nil {
+		pr.Mergeable = *change.Mergeable
+		if !pr.Mergeable {
+			pr.MergeableState = scm.MergeableStateConflicting
+		}
+	}
+	for _, hashtag := range change.Hashtags {
+		pr.Labels = append(pr.Labels, &scm.Label{Name: hashtag})
+	}
+	return pr
+}
+
+// comments converts the messages of the change into comments, numbered in order since they have no numeric ID
+func comments(messages []changeMessageInfo) []*scm.Comment {
+	answer := make([]*scm.Comment, 0, len(messages))
+	for i, message := range messages {
+		answer = append(answer, &scm.Comment{
+			ID:      i + 1,
+			Body:    message.Message,
+			Author:  message.Author.user(),
+			Created: message.Date.Time,
+			Updated: message.Date.Time,
+		})
+	}
+	return answer
+}
+
+// page returns the response of a listing of the REST API, which has another page if the last element listed says
+// there are more
+func page(res *scm.Response, opts scm.ListOptions, more bool) *scm.Response {
+	if res == nil {
+		return nil
+	}
+	res.Page.First = 1
+	res.Page.Last = opts.Page
+	if more {
+		res.Page.Next = opts.Page + 1
+		res.Page.Last = opts.Page + 1
+	}
+	return res
+}
+
+// pageQuery returns the query parameters of the page of a listing
+func pageQuery(opts scm.ListOptions) string {
+	size := opts.Size
+	if size <= 0 {
+		size = 100
+	}
+	start := 0
+	if opts.Page > 1 {
+		start = (opts.Page - 1) * size
+	}
+	return fmt.Sprintf("n=%d&S=%d", size, start)
+}
@@ -0,0 +1,170 @@
+package gerrit
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSHA = "8ef1c5ef3f9f0ee4a5f5bd4a27b0ae1a8a7e2c01"
+
+// newTestClient returns a client of a fake server answering the GET requests of the paths, including their query
+// strings, with the JSON of the responses and recording the bodies of the POST requests
+func newTestClient(t *testing.T, responses map[string]string, posted map[string]string) *scm.Client {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, ok := r.BasicAuth()
+		assert.True(t, ok, "no credentials sent to %s", r.URL)
+		assert.Equal(t, "bot", user)
+		assert.Equal(t, "secret", password)
+		if r.Method == http.MethodPost {
+			body, err := ioutil.ReadAll(r.Body)
+			require.NoError(t, err)
+			posted[r.URL.EscapedPath()] = string(body)
+			_, _ = w.Write([]byte(xssiPrefix + "{}"))
+			return
+		}
+		response, ok := responses[r.URL.RequestURI()]
+		if !ok {
+			t.Logf("unexpected request %s", r.URL.RequestURI())
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(xssiPrefix + response))
+	}))
+	t.Cleanup(server.Close)
+	client, err := New(server.URL, "bot")
+	require.NoError(t, err)
+	SetCredentials(client, "secret")
+	return client
+}
+
+func TestListPullRequests(t *testing.T) {
+	client := newTestClient(t, map[string]string{
+		"/a/changes/?q=project%3Aorg%2Frepo+status%3Aopen&o=CURRENT_REVISION&o=CURRENT_COMMIT&o=DETAILED_ACCOUNTS&n=100&S=0": `[{
+			"project": "org/repo",
+			"branch": "main",
+			"_number": 42,
+			"subject": "Fix the build",
+			"status": "NEW",
+			"owner": {"_account_id": 1, "name": "Jane", "email": "jane@example.com", "username": "jane"},
+			"current_revision": "` + testSHA + `",
+			"revisions": {"` + testSHA + `": {"_number": 3, "ref": "refs/changes/42/42/3", "commit": {"parents": [{"commit": "base"}], "message": "Fix the build\n\nDetails"}}},
+			"hashtags": ["approved"]
+		}]`,
+	}, nil)
+
+	prs, res, err := client.PullRequests.List(context.Background(), "org/repo", scm.PullRequestListOptions{Open: true, Page: 1, Size: 100})
+	require.NoError(t, err)
+	require.Len(t, prs, 1)
+	assert.Equal(t, 1, res.Page.Last)
+
+	pr := prs[0]
+	assert.Equal(t, 42, pr.Number)
+	assert.Equal(t, "Fix the build", pr.Title)
+	assert.Equal(t, testSHA, pr.Sha)
+	assert.Equal(t, "refs/changes/42/42/3", pr.Ref)
+	assert.Equal(t, "main", pr.Base.Ref)
+	assert.Equal(t, "base", pr.Base.Sha)
+	assert.Equal(t, "org", pr.Base.Repo.Namespace)
+	assert.Equal(t, "repo", pr.Base.Repo.Name)
+	assert.Equal(t, "jane", pr.Author.Login)
+	assert.Equal(t, "open", pr.State)
+	require.Len(t, pr.Labels, 1)
+	assert.Equal(t, "approved", pr.Labels[0].Name)
+}
+
+func TestCreateStatus(t *testing.T) {
+	responses := map[string]string{
+		"/a/changes/?q=project%3Aorg%2Frepo+commit%3A" + testSHA + "&o=ALL_REVISIONS&o=MESSAGES": `[{
+			"project": "org/repo",
+			"branch": "main",
+			"_number": 42,
+			"status": "NEW",
+			"revisions": {"` + testSHA + `": {"_number": 2}},
+			"messages": [
+				{"id": "a", "tag": "autogenerated:lighthouse", "_revision_number": 1, "message": "Patch Set 1: Verified-1\n\nLighthouse: unit failure"},
+				{"id": "b", "tag": "autogenerated:lighthouse", "_revision_number": 2, "message": "Patch Set 2:\n\nLighthouse: unit success"},
+				{"id": "c", "tag": "autogenerated:lighthouse", "_revision_number": 2, "message": "Patch Set 2:\n\nLighthouse: lint pending"}
+			]
+		}]`,
+	}
+	tests := []struct {
+		name   string
+		state  scm.State
+		vote   int
+		notify string
+	}{
+		{name: "all succeeded", state: scm.StateSuccess, vote: 1, notify: "OWNER"},
+		{name: "one failed", state: scm.StateFailure, vote: -1, notify: "OWNER"},
+		{name: "still running", state: scm.StateRunning, vote: 0, notify: "NONE"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			posted := map[string]string{}
+			client := newTestClient(t, responses, posted)
+
+			_, _, err := client.Repositories.CreateStatus(context.Background(), "org/repo", testSHA, &scm.StatusInput{
+				State:  tc.state,
+				Label:  "lint",
+				Desc:   "Lint the code",
+				Target: "https://dashboard.example.com/lint",
+			})
+			require.NoError(t, err)
+
+			body, ok := posted["/a/changes/org%2Frepo~42/revisions/"+testSHA+"/review"]
+			require.True(t, ok, "no review posted, got %v", posted)
+			review := &reviewInput{}
+			require.NoError(t, json.Unmarshal([]byte(body), review))
+			assert.Equal(t, statusTag, review.Tag)
+			assert.Equal(t, tc.notify, review.Notify)
+			assert.Equal(t, map[string]int{VerifiedLabel: tc.vote}, review.Labels)
+			assert.Equal(t, "Lighthouse: lint "+tc.state.String()+"\nLint the code\nhttps://dashboard.example.com/lint", review.Message)
+		})
+	}
+}
+
+func TestFindCombinedStatus(t *testing.T) {
+	client := newTestClient(t, map[string]string{
+		"/a/changes/?q=project%3Aorg%2Frepo+commit%3A" + testSHA + "&o=ALL_REVISIONS&o=MESSAGES": `[{
+			"project": "org/repo",
+			"_number": 42,
+			"revisions": {"` + testSHA + `": {"_number": 1}},
+			"messages": [
+				{"id": "a", "tag": "autogenerated:lighthouse", "_revision_number": 1, "message": "Patch Set 1: Verified-1\n\nLighthouse: unit failure\nTests failed"},
+				{"id": "b", "_revision_number": 1, "message": "Patch Set 1:\n\n/retest"},
+				{"id": "c", "tag": "autogenerated:lighthouse", "_revision_number": 1, "message": "Patch Set 1: Verified+1\n\nLighthouse: unit success"}
+			]
+		}]`,
+	}, nil)
+
+	combined, _, err := client.Repositories.FindCombinedStatus(context.Background(), "org/repo", testSHA)
+	require.NoError(t, err)
+	assert.Equal(t, scm.StateSuccess, combined.State)
+	require.Len(t, combined.Statuses, 1)
+	assert.Equal(t, "unit", combined.Statuses[0].Label)
+}
+
+func TestParseStatusMessage(t *testing.T) {
+	status := &scm.Status{State: scm.StateFailure, Label: "integration tests", Desc: "2 tests failed", Target: "https://example.com/job/1"}
+	message := "Patch Set 3: Verified-1\n\n" + statusMessage(status)
+	assert.Equal(t, status, parseStatusMessage(message))
+
+	assert.Nil(t, parseStatusMessage("Patch Set 3: Code-Review+2\n\nLooks good"))
+}
+
+func TestVote(t *testing.T) {
+	status := func(state scm.State) *scm.Status {
+		return &scm.Status{State: state}
+	}
+	assert.Equal(t, 0, vote(nil))
+	assert.Equal(t, 1, vote([]*scm.Status{status(scm.StateSuccess), status(scm.StateSuccess)}))
+	assert.Equal(t, 0, vote([]*scm.Status{status(scm.StateSuccess), status(scm.StatePending)}))
+	assert.Equal(t, -1, vote([]*scm.Status{status(scm.StatePending), status(scm.StateError)}))
+}
@@ -0,0 +1,226 @@
+package gerrit
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jenkins-x/go-scm/scm"
+)
+
+// shaPattern matches the full commit SHAs, which are looked up as commits rather than branches
+var shaPattern = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+type gitService struct {
+	client *wrapper
+}
+
+type branchInfo struct {
+	Ref      string `json:"ref"`
+	Revision string `json:"revision"`
+}
+
+type fileInfo struct {
+	Status        string `json:"status"`
+	LinesInserted int    `json:"lines_inserted"`
+	LinesDeleted  int    `json:"lines_deleted"`
+}
+
+func (s *gitService) FindBranch(ctx context.Context, repo, name string) (*scm.Reference, *scm.Response, error) {
+	branch := &branchInfo{}
+	res, err := s.client.do(ctx, "GET", fmt.Sprintf("projects/%s/branches/%s", projectID(repo), url.PathEscape(name)), nil, branch)
+	if err != nil {
+		return nil, res, err
+	}
+	return branchReference(branch), res, nil
+}
+
+func (s *gitService) ListBranches(ctx context.Context, repo string, opts scm.ListOptions) ([]*scm.Reference, *scm.Response, error) {
+	var branches []*branchInfo
+	res, err := s.client.do(ctx, "GET", fmt.Sprintf("projects/%s/branches/?%s", projectID(repo), pageQuery(opts)), nil, &branches)
+	if err != nil {
+		return nil, res, err
+	}
+	var answer []*scm.Reference
+	for _, branch := range branches {
+		// HEAD and refs/meta/config are listed along with the branches
+		if strings.HasPrefix(branch.Ref, "refs/heads/") {
+			answer = append(answer, branchReference(branch))
+		}
+	}
+	size := opts.Size
+	if size <= 0 {
+		size = 100
+	}
+	return answer, page(res, opts, len(branches) >= size), nil
+}
+
+func branchReference(branch *branchInfo) *scm.Reference {
+	return &scm.Reference{
+		Name: strings.TrimPrefix(branch.Ref, "refs/heads/"),
+		Path: branch.Ref,
+		Sha:  branch.Revision,
+	}
+}
+
+// FindRef returns the commit of the branch, tag or commit
+func (s *gitService) FindRef(ctx context.Context, repo, ref string) (string, *scm.Response, error) {
+	if shaPattern.MatchString(ref) {
+		return ref, nil, nil
+	}
+	if strings.HasPrefix(ref, "refs/tags/") || strings.HasPrefix(ref, "tags/") {
+		tag, res, err := s.FindTag(ctx, repo, strings.TrimPrefix(strings.TrimPrefix(ref, "refs/"), "tags/"))
+		if err != nil {
+			return "", res, err
+		}
+		return tag.Sha, res, nil
+	}
+	branch, res, err := s.FindBranch(ctx, repo, strings.TrimPrefix(strings.TrimPrefix(ref, "refs/"), "heads/"))
+	if err != nil {
+		return "", res, err
+	}
+	return branch.Sha, res, nil
+}
+
+func (s *gitService) FindCommit(ctx context.Context, repo, ref string) (*scm.Commit, *scm.Response, error) {
+	sha, res, err := s.FindRef(ctx, repo, ref)
+	if err != nil {
+		return nil, res, err
+	}
+	commit := &commitInfo{}
+	res, err = s.client.do(ctx, "GET", fmt.Sprintf("projects/%s/commits/%s", projectID(repo), sha), nil, commit)
+	if err != nil {
+		return nil, res, err
+	}
+	return &scm.Commit{
+		Sha:       sha,
+		Message:   commit.Message,
+		Author:    scm.Signature{Name: commit.Author.Name, Email: commit.Author.Email, Date: commit.Author.Date.Time},
+		Committer: scm.Signature{Name: commit.Committer.Name, Email: commit.Committer.Email, Date: commit.Committer.Date.Time},
+	}, res, nil
+}
+
+func (s *gitService) FindTag(ctx context.Context, repo, name string) (*scm.Reference, *scm.Response, error) {
+	tag := &branchInfo{}
+	res, err := s.client.do(ctx, "GET", fmt.Sprintf("projects/%s/tags/%s", projectID(repo), url.PathEscape(name)), nil, tag)
+	if err != nil {
+		return nil, res, err
+	}
+	return &scm.Reference{Name: name, Path: tag.Ref, Sha: tag.Revision}, res, nil
+}
+
+func (s *gitService) ListTags(ctx context.Context, repo string, opts scm.ListOptions) ([]*scm.Reference, *scm.Response, error) {
+	var tags []*branchInfo
+	res, err := s.client.do(ctx, "GET", fmt.Sprintf("projects/%s/tags/?%s", projectID(repo), pageQuery(opts)), nil, &tags)
+	if err != nil {
+		return nil, res, err
+	}
+	answer := make([]*scm.Reference, 0, len(tags))
+	for _, tag := range tags {
+		answer = append(answer, &scm.Reference{Name: strings.TrimPrefix(tag.Ref, "refs/tags/"), Path: tag.Ref, Sha: tag.Revision})
+	}
+	size := opts.Size
+	if size <= 0 {
+		size = 100
+	}
+	return answer, page(res, opts, len(tags) >= size), nil
+}
+
+// ListChanges returns the files changed by the commit against its first parent
+func (s *gitService) ListChanges(ctx context.Context, repo, ref string, opts scm.ListOptions) ([]*scm.Change, *scm.Response, error) {
+	sha, res, err := s.FindRef(ctx, repo, ref)
+	if err != nil {
+		return nil, res, err
+	}
+	files := map[string]fileInfo{}
+	res, err = s.client.do(ctx, "GET", fmt.Sprintf("projects/%s/commits/%s/files/", projectID(repo), sha), nil, &files)
+	if err != nil {
+		return nil, res, err
+	}
+	return changes(files), page(res, opts, false), nil
+}
+
+// changes converts the files of a commit, leaving out the magic files of its message and merge list
+func changes(files map[string]fileInfo) []*scm.Change {
+	var paths []string
+	for path := range files {
+		if !strings.HasPrefix(path, "/") {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	answer := make([]*scm.Change, 0, len(paths))
+	for _, path := range paths {
+		file := files[path]
+		answer = append(answer, &scm.Change{
+			Path:      path,
+			Added:     file.Status == "A",
+			Deleted:   file.Status == "D",
+			Renamed:   file.Status == "R",
+			Additions: file.LinesInserted,
+			Deletions: file.LinesDeleted,
+			Changes:   file.LinesInserted + file.LinesDeleted,
+		})
+	}
+	return answer
+}
+
+func (s *gitService) ListCommits(context.Context, string, scm.CommitListOptions) ([]*scm.Commit, *scm.Response, error) {
+	return nil, nil, scm.ErrNotSupported
+}
+
+func (s *gitService) DeleteRef(context.Context, string, string) (*scm.Response, error) {
+	return nil, scm.ErrNotSupported
+}
+
+func (s *gitService) CreateRef(context.Context, string, string, string) (*scm.Reference, *scm.Response, error) {
+	return nil, nil, scm.ErrNotSupported
+}
+
+type contentService struct {
+	client *wrapper
+}
+
+// Find returns the content of the file at the branch or commit
+func (s *contentService) Find(ctx context.Context, repo, path, ref string) (*scm.Content, *scm.Response, error) {
+	var base string
+	if shaPattern.MatchString(ref) {
+		base = fmt.Sprintf("projects/%s/commits/%s", projectID(repo), ref)
+	} else {
+		if ref == "" {
+			ref = "HEAD"
+		}
+		base = fmt.Sprintf("projects/%s/branches/%s", projectID(repo), url.PathEscape(strings.TrimPrefix(ref, "refs/heads/")))
+	}
+	encoded := new(strings.Builder)
+	res, err := s.client.do(ctx, "GET", fmt.Sprintf("%s/files/%s/content", base, url.PathEscape(path)), nil, encoded)
+	if err != nil {
+		return nil, res, err
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded.String()))
+	if err != nil {
+		return nil, res, err
+	}
+	return &scm.Content{Path: path, Data: data}, res, nil
+}
+
+// List is not supported, the REST API does not list the files of a directory
+func (s *contentService) List(context.Context, string, string, string) ([]*scm.FileEntry, *scm.Response, error) {
+	return nil, nil, scm.ErrNotSupported
+}
+
+func (s *contentService) Create(context.Context, string, string, *scm.ContentParams) (*scm.Response, error) {
+	return nil, scm.ErrNotSupported
+}
+
+func (s *contentService) Update(context.Context, string, string, *scm.ContentParams) (*scm.Response, error) {
+	return nil, scm.ErrNotSupported
+}
+
+func (s *contentService) Delete(context.Context, string, string, string) (*scm.Response, error) {
+	return nil, scm.ErrNotSupported
+}
@@ -0,0 +1,209 @@
+package gerrit
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/jenkins-x/go-scm/scm"
+)
+
+// changeOptions are the details listed with the changes
+const changeOptions = "o=CURRENT_REVISION&o=CURRENT_COMMIT&o=DETAILED_ACCOUNTS"
+
+type pullService struct {
+	client *wrapper
+}
+
+type reviewInput struct {
+	Message string         `json:"message,omitempty"`
+	Tag     string         `json:"tag,omitempty"`
+	Labels  map[string]int `json:"labels,omitempty"`
+	Notify  string         `json:"notify,omitempty"`
+}
+
+type hashtagsInput struct {
+	Add    []string `json:"add,omitempty"`
+	Remove []string `json:"remove,omitempty"`
+}
+
+func (s *pullService) Find(ctx context.Context, repo string, number int) (*scm.PullRequest, *scm.Response, error) {
+	change, res, err := s.find(ctx, repo, number, "")
+	if err != nil {
+		return nil, res, err
+	}
+	return s.client.pullRequest(change), res, nil
+}
+
+// find returns the change with its current revision and the additional options of the query string, if any
+func (s *pullService) find(ctx context.Context, repo string, number int, options string) (*changeInfo, *scm.Response, error) {
+	path := fmt.Sprintf("changes/%s?%s", changeID(repo, number), changeOptions)
+	if options != "" {
+		path += "&" + options
+	}
+	change := &changeInfo{}
+	res, err := s.client.do(ctx, "GET", path, nil, change)
+	return change, res, err
+}
+
+// List returns the open changes of the project, the other ones being returned along with the merged ones when
+// closed changes are asked for
+func (s *pullService) List(ctx context.Context, repo string, opts scm.PullRequestListOptions) ([]*scm.PullRequest, *scm.Response, error) {
+	query := "project:" + repo
+	switch {
+	case opts.Open && !opts.Closed:
+		query += " status:open"
+	case opts.Closed && !opts.Open:
+		query += " status:closed"
+	}
+	listOpts := scm.ListOptions{Page: opts.Page, Size: opts.Size}
+	path := fmt.Sprintf("changes/?q=%s&%s&%s", url.QueryEscape(query), changeOptions, pageQuery(listOpts))
+	var changes []*changeInfo
+	res, err := s.client.do(ctx, "GET", path, nil, &changes)
+	if err != nil {
+		return nil, res, err
+	}
+	answer := make([]*scm.PullRequest, 0, len(changes))
+	for _, change := range changes {
+		answer = append(answer, s.client.pullRequest(change))
+	}
+	return answer, page(res, listOpts, len(changes) > 0 && changes[len(changes)-1].MoreChanges), nil
+}
+
+// ListChanges returns the files changed by the current patch set of the change
+func (s *pullService) ListChanges(ctx context.Context, repo string, number int, opts scm.ListOptions) ([]*scm.Change, *scm.Response, error) {
+	files := map[string]fileInfo{}
+	res, err := s.client.do(ctx, "GET", fmt.Sprintf("changes/%s/revisions/current/files/", changeID(repo, number)), nil, &files)
+	if err != nil {
+		return nil, res, err
+	}
+	return changes(files), page(res, opts, false), nil
+}
+
+// ListComments returns the messages of the change
+func (s *pullService) ListComments(ctx context.Context, repo string, number int, opts scm.ListOptions) ([]*scm.Comment, *scm.Response, error) {
+	var messages []changeMessageInfo
+	res, err := s.client.do(ctx, "GET", fmt.Sprintf("changes/%s/messages", changeID(repo, number)), nil, &messages)
+	if err != nil {
+		return nil, res, err
+	}
+	return comments(messages), page(res, opts, false), nil
+}
+
+// FindComment returns the message of the change numbered as by ListComments
+func (s *pullService) FindComment(ctx context.Context, repo string, number, id int) (*scm.Comment, *scm.Response, error) {
+	all, res, err := s.ListComments(ctx, repo, number, scm.ListOptions{})
+	if err != nil {
+		return nil, res, err
+	}
+	if id < 1 || id > len(all) {
+		return nil, res, scm.ErrNotFound
+	}
+	return all[id-1], res, nil
+}
+
+// CreateComment adds a message to the current patch set of the change
+func (s *pullService) CreateComment(ctx context.Context, repo string, number int, input *scm.CommentInput) (*scm.Comment, *scm.Response, error) {
+	res, err := s.client.do(ctx, "POST", fmt.Sprintf("changes/%s/revisions/current/review", changeID(repo, number)), &reviewInput{Message: input.Body}, nil)
+	if err != nil {
+		return nil, res, err
+	}
+	return &scm.Comment{Body: input.Body, Author: scm.User{Login: s.client.username}}, res, nil
+}
+
+// ListLabels returns the hashtags of the change
+func (s *pullService) ListLabels(ctx context.Context, repo string, number int, opts scm.ListOptions) ([]*scm.Label, *scm.Response, error) {
+	var hashtags []string
+	res, err := s.client.do(ctx, "GET", fmt.Sprintf("changes/%s/hashtags", changeID(repo, number)), nil, &hashtags)
+	if err != nil {
+		return nil, res, err
+	}
+	answer := make([]*scm.Label, 0, len(hashtags))
+	for _, hashtag := range hashtags {
+		answer = append(answer, &scm.Label{Name: hashtag})
+	}
+	return answer, page(res, opts, false), nil
+}
+
+// AddLabel adds the hashtag to the change
+func (s *pullService) AddLabel(ctx context.Context, repo string, number int, label string) (*scm.Response, error) {
+	return s.client.do(ctx, "POST", fmt.Sprintf("changes/%s/hashtags", changeID(repo, number)), &hashtagsInput{Add: []string{label}}, nil)
+}
+
+// DeleteLabel removes the hashtag from the change
+func (s *pullService) DeleteLabel(ctx context.Context, repo string, number int, label string) (*scm.Response, error) {
+	return s.client.do(ctx, "POST", fmt.Sprintf("changes/%s/hashtags", changeID(repo, number)), &hashtagsInput{Remove: []string{label}}, nil)
+}
+
+// Merge submits the change, which is merged according to the submit type of the project
+func (s *pullService) Merge(ctx context.Context, repo string, number int, opts *scm.PullRequestMergeOptions) (*scm.Response, error) {
+	return s.client.do(ctx, "POST", fmt.Sprintf("changes/%s/submit", changeID(repo, number)), map[string]string{}, nil)
+}
+
+// Close abandons the change
+func (s *pullService) Close(ctx context.Context, repo string, number int) (*scm.Response, error) {
+	return s.client.do(ctx, "POST", fmt.Sprintf("changes/%s/abandon", changeID(repo, number)), map[string]string{}, nil)
+}
+
+// Reopen restores the abandoned change
+func (s *pullService) Reopen(ctx context.Context, repo string, number int) (*scm.Response, error) {
+	return s.client.do(ctx, "POST", fmt.Sprintf("changes/%s/restore", changeID(repo, number)), map[string]string{}, nil)
+}
+
+// AssignIssue adds the users as reviewers of the change, Gerrit having no assignees
+func (s *pullService) AssignIssue(ctx context.Context, repo string, number int, logins []string) (*scm.Response, error) {
+	return s.RequestReview(ctx, repo, number, logins)
+}
+
+// UnassignIssue removes the users from the reviewers of the change
+func (s *pullService) UnassignIssue(ctx context.Context, repo string, number int, logins []string) (*scm.Response, error) {
+	return s.UnrequestReview(ctx, repo, number, logins)
+}
+
+// RequestReview adds the users as reviewers of the change
+func (s *pullService) RequestReview(ctx context.Context, repo string, number int, logins []string) (*scm.Response, error) {
+	var res *scm.Response
+	for _, login := range logins {
+		var err error
+		res, err = s.client.do(ctx, "POST", fmt.Sprintf("changes/%s/reviewers", changeID(repo, number)), map[string]string{"reviewer": login}, nil)
+		if err != nil {
+			return res, err
+		}
+	}
+	return res, nil
+}
+
+// UnrequestReview removes the users from the reviewers of the change
+func (s *pullService) UnrequestReview(ctx context.Context, repo string, number int, logins []string) (*scm.Response, error) {
+	var res *scm.Response
+	for _, login := range logins {
+		var err error
+		res, err = s.client.do(ctx, "POST", fmt.Sprintf("changes/%s/reviewers/%s/delete", changeID(repo, number), url.PathEscape(login)), map[string]string{}, nil)
+		if err != nil {
+			return res, err
+		}
+	}
+	return res, nil
+}
+
+func (s *pullService) Update(context.Context, string, int, *scm.PullRequestInput) (*scm.PullRequest, *scm.Response, error) {
+	return nil, nil, scm.ErrNotSupported
+}
+
+func (s *pullService) ListEvents(context.Context, string, int, scm.ListOptions) ([]*scm.ListedIssueEvent, *scm.Response, error) {
+	return nil, nil, scm.ErrNotSupported
+}
+
+// DeleteComment is not supported, messages of changes can only be deleted by administrators
+func (s *pullService) DeleteComment(context.Context, string, int, int) (*scm.Response, error) {
+	return nil, scm.ErrNotSupported
+}
+
+// EditComment is not supported, messages of changes cannot be edited
+func (s *pullService) EditComment(context.Context, string, int, int, *scm.CommentInput) (*scm.Comment, *scm.Response, error) {
+	return nil, nil, scm.ErrNotSupported
+}
+
+func (s *pullService) Create(context.Context, string, *scm.PullRequestInput) (*scm.PullRequest, *scm.Response, error) {
+	return nil, nil, scm.ErrNotSupported
+}
@@ -0,0 +1,308 @@
+package gerrit
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/jenkins-x/go-scm/scm"
+)
+
+const (
+	// VerifiedLabel is the label of the changes the statuses vote on
+	VerifiedLabel = "Verified"
+
+	// statusTag tags the review messages of the statuses, the autogenerated prefix letting the web UI hide them
+	statusTag = "autogenerated:lighthouse"
+
+	// statusPrefix starts the first line of the review message of a status, e.g. "Lighthouse: unit success"
+	statusPrefix = "Lighthouse: "
+)
+
+type repositoryService struct {
+	client *wrapper
+}
+
+type projectInfo struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+func (s *repositoryService) Find(ctx context.Context, repo string) (*scm.Repository, *scm.Response, error) {
+	project := &projectInfo{}
+	res, err := s.client.do(ctx, "GET", "projects/"+projectID(repo), nil, project)
+	if err != nil {
+		return nil, res, err
+	}
+	var head string
+	res, err = s.client.do(ctx, "GET", "projects/"+projectID(repo)+"/HEAD", nil, &head)
+	if err != nil {
+		return nil, res, err
+	}
+	answer := s.client.repository(repo, strings.TrimPrefix(head, "refs/heads/"))
+	return &answer, res, nil
+}
+
+// ListOrganisation returns the projects named org/...
+func (s *repositoryService) ListOrganisation(ctx context.Context, org string, opts scm.ListOptions) ([]*scm.Repository, *scm.Response, error) {
+	projects := map[string]projectInfo{}
+	path := fmt.Sprintf("projects/?p=%s&%s", url.QueryEscape(org+"/"), pageQuery(opts))
+	res, err := s.client.do(ctx, "GET", path, nil, &projects)
+	if err != nil {
+		return nil, res, err
+	}
+	var names []string
+	for name := range projects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	answer := make([]*scm.Repository, 0, len(names))
+	for _, name := range names {
+		repo := s.client.repository(name, "")
+		answer = append(answer, &repo)
+	}
+	size := opts.Size
+	if size <= 0 {
+		size = 100
+	}
+	return answer, page(res, opts, len(projects) >= size), nil
+}
+
+// List returns the projects of the server
+func (s *repositoryService) List(ctx context.Context, opts scm.ListOptions) ([]*scm.Repository, *scm.Response, error) {
+	return s.ListOrganisation(ctx, "", opts)
+}
+
+type accessCheckInfo struct {
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+}
+
+// IsCollaborator returns true if the user can submit the changes of the default branch of the project, which
+// requires the bot to have the View Access capability
+func (s *repositoryService) IsCollaborator(ctx context.Context, repo, user string) (bool, *scm.Response, error) {
+	project, res, err := s.Find(ctx, repo)
+	if err != nil {
+		return false, res, err
+	}
+	check := &accessCheckInfo{}
+	path := fmt.Sprintf("projects/%s/check.access?account=%s&perm=submit&ref=%s", projectID(repo), url.QueryEscape(user), url.QueryEscape("refs/heads/"+project.Branch))
+	res, err = s.client.do(ctx, "GET", path, nil, check)
+	if err != nil {
+		return false, res, err
+	}
+	return check.Status == 200, res, nil
+}
+
+// FindUserPermission returns write for the users who can submit the changes of the project and read otherwise
+func (s *repositoryService) FindUserPermission(ctx context.Context, repo, user string) (string, *scm.Response, error) {
+	collaborator, res, err := s.IsCollaborator(ctx, repo, user)
+	if err != nil {
+		return "", res, err
+	}
+	if collaborator {
+		return "write", res, nil
+	}
+	return "read", res, nil
+}
+
+// CreateStatus reports the status on the patch set of the commit with a review message, voting on the Verified
+// label according to all the statuses of the patch set: -1 once one fails, +1 once all succeeded and 0 while some
+// are pending. The owner is only notified of the final statuses.
+func (s *repositoryService) CreateStatus(ctx context.Context, repo, ref string, input *scm.StatusInput) (*scm.Status, *scm.Response, error) {
+	change, revision, res, err := s.findRevision(ctx, repo, ref)
+	if err != nil {
+		return nil, res, err
+	}
+	status := &scm.Status{State: input.State, Label: input.Label, Desc: input.Desc, Target: input.Target}
+	statuses := append(revisionStatuses(change, revision), status)
+	review := &reviewInput{
+		Message: statusMessage(status),
+		Tag:     statusTag,
+		Labels:  map[string]int{VerifiedLabel: vote(latest(statuses))},
+		Notify:  "OWNER",
+	}
+	if status.State == scm.StatePending || status.State == scm.StateRunning {
+		review.Notify = "NONE"
+	}
+	res, err = s.client.do(ctx, "POST", fmt.Sprintf("changes/%s/revisions/%s/review", changeID(change.Project, change.Number), ref), review, nil)
+	if err != nil {
+		return nil, res, err
+	}
+	return status, res, nil
+}
+
+// ListStatus returns the latest status of each context reported on the patch set of the commit
+func (s *repositoryService) ListStatus(ctx context.Context, repo, ref string, opts scm.ListOptions) ([]*scm.Status, *scm.Response, error) {
+	change, revision, res, err := s.findRevision(ctx, repo, ref)
+	if err != nil {
+		return nil, res, err
+	}
+	return latest(revisionStatuses(change, revision)), page(res, opts, false), nil
+}
+
+// FindCombinedStatus returns the statuses of the patch set of the commit, failed if one failed, pending if one is
+// pending and successful otherwise
+func (s *repositoryService) FindCombinedStatus(ctx context.Context, repo, ref string) (*scm.CombinedStatus, *scm.Response, error) {
+	statuses, res, err := s.ListStatus(ctx, repo, ref, scm.ListOptions{})
+	if err != nil {
+		return nil, res, err
+	}
+	combined := &scm.CombinedStatus{State: scm.StateUnknown, Sha: ref, Statuses: statuses}
+	switch vote(statuses) {
+	case -1:
+		combined.State = scm.StateFailure
+	case 1:
+		combined.State = scm.StateSuccess
+	default:
+		if len(statuses) > 0 {
+			combined.State = scm.StatePending
+		}
+	}
+	return combined, res, nil
+}
+
+// findRevision returns the change of the commit, with its messages, and the number of the patch set of the commit
+func (s *repositoryService) findRevision(ctx context.Context, repo, sha string) (*changeInfo, int, *scm.Response, error) {
+	query := url.QueryEscape(fmt.Sprintf("project:%s commit:%s", repo, sha))
+	var changes []*changeInfo
+	res, err := s.client.do(ctx, "GET", fmt.Sprintf("changes/?q=%s&o=ALL_REVISIONS&o=MESSAGES", query), nil, &changes)
+	if err != nil {
+		return nil, 0, res, err
+	}
+	for _, change := range changes {
+		if revision, ok := change.Revisions[sha]; ok {
+			return change, revision.Number, res, nil
+		}
+	}
+	return nil, 0, res, scm.ErrNotFound
+}
+
+// statusMessage formats the review message of the status
+func statusMessage(status *scm.Status) string {
+	lines := []string{statusPrefix + status.Label + " " + status.State.String()}
+	if status.Desc != "" {
+		lines = append(lines, status.Desc)
+	}
+	if status.Target != "" {
+		lines = append(lines, status.Target)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// revisionStatuses parses the statuses reported on the patch set from the messages of the change, oldest first
+func revisionStatuses(change *changeInfo, revision int) []*scm.Status {
+	var answer []*scm.Status
+	for _, message := range change.Messages {
+		if message.Tag != statusTag || message.RevisionNumber != revision {
+			continue
+		}
+		if status := parseStatusMessage(message.Message); status != nil {
+			answer = append(answer, status)
+		}
+	}
+	return answer
+}
+
+// parseStatusMessage parses the status of the review message, after the "Patch Set N: Verified+1" line Gerrit
+// starts it with, returning nil if it is not the message of a status
+func parseStatusMessage(message string) *scm.Status {
+	lines := strings.Split(message, "\n")
+	for i, line := range lines {
+		if !strings.HasPrefix(line, statusPrefix) {
+			continue
+		}
+		text := strings.TrimPrefix(line, statusPrefix)
+		j := strings.LastIndex(text, " ")
+		if j <= 0 {
+			return nil
+		}
+		status := &scm.Status{Label: text[:j], State: scm.ToState(text[j+1:])}
+		for _, rest := range lines[i+1:] {
+			switch {
+			case rest == "":
+			case strings.HasPrefix(rest, "http://") || strings.HasPrefix(rest, "https://"):
+				status.Target = rest
+			case status.Desc == "":
+				status.Desc = rest
+			}
+		}
+		return status
+	}
+	return nil
+}
+
+// latest returns the last status of each context, in the order they were first reported
+func latest(statuses []*scm.Status) []*scm.Status {
+	index := map[string]int{}
+	var answer []*scm.Status
+	for _, status := range statuses {
+		if i, ok := index[status.Label]; ok {
+			answer[i] = status
+			continue
+		}
+		index[status.Label] = len(answer)
+		answer = append(answer, status)
+	}
+	return answer
+}
+
+// vote returns the vote on the Verified label of the latest statuses of the contexts
+func vote(statuses []*scm.Status) int {
+	if len(statuses) == 0 {
+		return 0
+	}
+	answer := 1
+	for _, status := range statuses {
+		switch status.State {
+		case scm.StateFailure, scm.StateError, scm.StateCanceled:
+			return -1
+		case scm.StateSuccess:
+		default:
+			answer = 0
+		}
+	}
+	return answer
+}
+
+func (s *repositoryService) FindHook(context.Context, string, string) (*scm.Hook, *scm.Response, error) {
+	return nil, nil, scm.ErrNotSupported
+}
+
+func (s *repositoryService) FindPerms(context.Context, string) (*scm.Perm, *scm.Response, error) {
+	return nil, nil, scm.ErrNotSupported
+}
+
+func (s *repositoryService) ListUser(context.Context, string, scm.ListOptions) ([]*scm.Repository, *scm.Response, error) {
+	return nil, nil, scm.ErrNotSupported
+}
+
+func (s *repositoryService) ListLabels(context.Context, string, scm.ListOptions) ([]*scm.Label, *scm.Response, error) {
+	return nil, nil, scm.ErrNotSupported
+}
+
+func (s *repositoryService) ListHooks(context.Context, string, scm.ListOptions) ([]*scm.Hook, *scm.Response, error) {
+	return nil, nil, scm.ErrNotSupported
+}
+
+func (s *repositoryService) Create(context.Context, *scm.RepositoryInput) (*scm.Repository, *scm.Response, error) {
+	return nil, nil, scm.ErrNotSupported
+}
+
+func (s *repositoryService) CreateHook(context.Context, string, *scm.HookInput) (*scm.Hook, *scm.Response, error) {
+	return nil, nil, scm.ErrNotSupported
+}
+
+func (s *repositoryService) DeleteHook(context.Context, string, string) (*scm.Response, error) {
+	return nil, scm.ErrNotSupported
+}
+
+func (s *repositoryService) AddCollaborator(context.Context, string, string, string) (bool, bool, *scm.Response, error) {
+	return false, false, nil, scm.ErrNotSupported
+}
+
+func (s *repositoryService) ListCollaborators(context.Context, string, scm.ListOptions) ([]scm.User, *scm.Response, error) {
+	return nil, nil, scm.ErrNotSupported
+}
@@ -0,0 +1,71 @@
+package gerrit
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/jenkins-x/go-scm/scm"
+)
+
+type userService struct {
+	client *wrapper
+}
+
+// Find returns the user the client authenticates as
+func (s *userService) Find(ctx context.Context) (*scm.User, *scm.Response, error) {
+	return s.FindLogin(ctx, "self")
+}
+
+// FindLogin returns the user with the username
+func (s *userService) FindLogin(ctx context.Context, login string) (*scm.User, *scm.Response, error) {
+	account := &accountInfo{}
+	res, err := s.client.do(ctx, "GET", "accounts/"+url.PathEscape(login), nil, account)
+	if err != nil {
+		return nil, res, err
+	}
+	user := account.user()
+	return &user, res, nil
+}
+
+func (s *userService) FindEmail(ctx context.Context) (string, *scm.Response, error) {
+	user, res, err := s.Find(ctx)
+	if err != nil {
+		return "", res, err
+	}
+	return user.Email, res, nil
+}
+
+// organizationService answers for the orgs the projects are named after, which Gerrit has no members of
+type organizationService struct {
+	client *wrapper
+}
+
+func (s *organizationService) Find(ctx context.Context, name string) (*scm.Organization, *scm.Response, error) {
+	return &scm.Organization{Name: name}, nil, nil
+}
+
+// IsMember returns false, the trusted users being those who can submit the changes of the project
+func (s *organizationService) IsMember(context.Context, string, string) (bool, *scm.Response, error) {
+	return false, nil, nil
+}
+
+// IsAdmin returns false, the admins of the projects are given the Owner permission instead
+func (s *organizationService) IsAdmin(context.Context, string, string) (bool, *scm.Response, error) {
+	return false, nil, nil
+}
+
+func (s *organizationService) List(context.Context, scm.ListOptions) ([]*scm.Organization, *scm.Response, error) {
+	return nil, nil, scm.ErrNotSupported
+}
+
+func (s *organizationService) ListTeams(context.Context, string, scm.ListOptions) ([]*scm.Team, *scm.Response, error) {
+	return nil, nil, scm.ErrNotSupported
+}
+
+func (s *organizationService) ListTeamMembers(context.Context, int, string, scm.ListOptions) ([]*scm.TeamMember, *scm.Response, error) {
+	return nil, nil, scm.ErrNotSupported
+}
+
+func (s *organizationService) ListOrgMembers(context.Context, string, scm.ListOptions) ([]*scm.TeamMember, *scm.Response, error) {
+	return nil, nil, scm.ErrNotSupported
+}
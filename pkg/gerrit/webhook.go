@@ -0,0 +1,224 @@
+package gerrit
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/pkg/errors"
+)
+
+// TokenParameter is the query parameter of the webhook URL holding the token the events are authenticated with,
+// since the webhooks plugin of Gerrit does not sign them
+const TokenParameter = "token"
+
+// zeroSHA is the old revision of a created branch and the new revision of a deleted one
+const zeroSHA = "0000000000000000000000000000000000000000"
+
+type webhookService struct {
+	client *wrapper
+}
+
+// event is an event sent by the webhooks plugin of Gerrit, the same as the events streamed over SSH
+type event struct {
+	Type      string       `json:"type"`
+	Change    *eventChange `json:"change"`
+	PatchSet  *eventPatch  `json:"patchSet"`
+	Uploader  accountAttr  `json:"uploader"`
+	Author    accountAttr  `json:"author"`
+	Submitter accountAttr  `json:"submitter"`
+	Abandoner accountAttr  `json:"abandoner"`
+	Restorer  accountAttr  `json:"restorer"`
+	Comment   string       `json:"comment"`
+	RefUpdate *struct {
+		OldRev  string `json:"oldRev"`
+		NewRev  string `json:"newRev"`
+		RefName string `json:"refName"`
+		Project string `json:"project"`
+	} `json:"refUpdate"`
+	EventCreatedOn int64 `json:"eventCreatedOn"`
+}
+
+type eventChange struct {
+	Project       string      `json:"project"`
+	Branch        string      `json:"branch"`
+	Number        int         `json:"number"`
+	Subject       string      `json:"subject"`
+	Owner         accountAttr `json:"owner"`
+	URL           string      `json:"url"`
+	CommitMessage string      `json:"commitMessage"`
+	CreatedOn     int64       `json:"createdOn"`
+	Status        string      `json:"status"`
+	WIP           bool        `json:"wip"`
+}
+
+type eventPatch struct {
+	Number    int         `json:"number"`
+	Revision  string      `json:"revision"`
+	Parents   []string    `json:"parents"`
+	Ref       string      `json:"ref"`
+	Uploader  accountAttr `json:"uploader"`
+	CreatedOn int64       `json:"createdOn"`
+}
+
+type accountAttr struct {
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Username string `json:"username"`
+}
+
+func (a accountAttr) user() scm.User {
+	return accountInfo{Name: a.Name, Email: a.Email, Username: a.Username}.user()
+}
+
+// Parse decodes the event of the request, which is only accepted if the token of its URL is the one returned by fn.
+// The patch sets, comments, merges, abandons and restores of the changes are converted into the webhooks of their
+// pull requests and the updates of the branches into pushes.
+func (s *webhookService) Parse(req *http.Request, fn scm.SecretFunc) (scm.Webhook, error) {
+	data, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	src := &event{}
+	if err := json.Unmarshal(data, src); err != nil {
+		return nil, errors.Wrap(err, "decoding Gerrit event")
+	}
+	hook, err := s.convert(src)
+	if err != nil {
+		return nil, err
+	}
+	token, err := fn(hook)
+	if err != nil || token == "" {
+		return hook, err
+	}
+	if subtle.ConstantTimeCompare([]byte(req.URL.Query().Get(TokenParameter)), []byte(token)) != 1 {
+		return hook, scm.ErrSignatureInvalid
+	}
+	return hook, nil
+}
+
+func (s *webhookService) convert(src *event) (scm.Webhook, error) {
+	switch src.Type {
+	case "patchset-created", "change-merged", "change-abandoned", "change-restored", "comment-added":
+		if src.Change == nil || src.PatchSet == nil {
+			return nil, errors.Errorf("no change in Gerrit %s event", src.Type)
+		}
+	}
+	switch src.Type {
+	case "patchset-created":
+		action := scm.ActionSync
+		if src.PatchSet.Number == 1 {
+			action = scm.ActionOpen
+		}
+		return s.pullRequestHook(action, src, src.Uploader), nil
+	case "change-merged":
+		return s.pullRequestHook(scm.ActionClose, src, src.Submitter), nil
+	case "change-abandoned":
+		return s.pullRequestHook(scm.ActionClose, src, src.Abandoner), nil
+	case "change-restored":
+		return s.pullRequestHook(scm.ActionReopen, src, src.Restorer), nil
+	case "comment-added":
+		pr := s.pullRequest(src)
+		return &scm.PullRequestCommentHook{
+			Action:      scm.ActionCreate,
+			Repo:        pr.Base.Repo,
+			PullRequest: pr,
+			Comment: scm.Comment{
+				Body:    src.Comment,
+				Author:  src.Author.user(),
+				Created: eventTime(src.EventCreatedOn),
+				Updated: eventTime(src.EventCreatedOn),
+			},
+			Sender: src.Author.user(),
+		}, nil
+	case "ref-updated":
+		if src.RefUpdate == nil {
+			return nil, errors.New("no ref update in Gerrit ref-updated event")
+		}
+		ref := src.RefUpdate.RefName
+		if !strings.HasPrefix(ref, "refs/") {
+			ref = "refs/heads/" + ref
+		}
+		if !strings.HasPrefix(ref, "refs/heads/") && !strings.HasPrefix(ref, "refs/tags/") {
+			// the refs of changes and of the meta data of the server
+			return nil, scm.UnknownWebhook{Event: fmt.Sprintf("%s of %s", src.Type, ref)}
+		}
+		update := src.RefUpdate
+		return &scm.PushHook{
+			Ref:     ref,
+			Repo:    s.client.repository(update.Project, ""),
+			Before:  update.OldRev,
+			After:   update.NewRev,
+			Created: update.OldRev == zeroSHA,
+			Deleted: update.NewRev == zeroSHA,
+			Commit:  scm.Commit{Sha: update.NewRev},
+			Sender:  src.Submitter.user(),
+		}, nil
+	default:
+		return nil, scm.UnknownWebhook{Event: src.Type}
+	}
+}
+
+func (s *webhookService) pullRequestHook(action scm.Action, src *event, sender accountAttr) *scm.PullRequestHook {
+	pr := s.pullRequest(src)
+	return &scm.PullRequestHook{
+		Action:      action,
+		Repo:        pr.Base.Repo,
+		PullRequest: pr,
+		Sender:      sender.user(),
+	}
+}
+
+// pullRequest returns the pull request of the patch set of the event
+func (s *webhookService) pullRequest(src *event) scm.PullRequest {
+	change, patch := src.Change, src.PatchSet
+	repo := s.client.repository(change.Project, "")
+	var baseSHA string
+	if len(patch.Parents) > 0 {
+		baseSHA = patch.Parents[0]
+	}
+	link := change.URL
+	if link == "" {
+		link = s.client.changeURL(change.Project, change.Number)
+	}
+	pr := scm.PullRequest{
+		Number:  change.Number,
+		Title:   change.Subject,
+		Body:    change.CommitMessage,
+		Sha:     patch.Revision,
+		Ref:     patch.Ref,
+		Source:  patch.Ref,
+		Target:  change.Branch,
+		Fork:    change.Project,
+		Link:    link,
+		Draft:   change.WIP,
+		State:   "open",
+		Base:    scm.PullRequestBranch{Ref: change.Branch, Sha: baseSHA, Repo: repo},
+		Head:    scm.PullRequestBranch{Ref: patch.Ref, Sha: patch.Revision, Repo: repo},
+		Author:  change.Owner.user(),
+		Created: eventTime(change.CreatedOn),
+		Updated: eventTime(src.EventCreatedOn),
+	}
+	switch {
+	case src.Type == "change-merged" || change.Status == "MERGED":
+		pr.Merged, pr.Closed, pr.State = true, true, "closed"
+	case src.Type == "change-abandoned" || change.Status == "ABANDONED":
+		pr.Closed, pr.State = true, "closed"
+	}
+	if src.Type == "change-restored" {
+		pr.Closed, pr.State = false, "open"
+	}
+	return pr
+}
+
+func eventTime(seconds int64) time.Time {
+	if seconds == 0 {
+		return time.Time{}
+	}
+	return time.Unix(seconds, 0).UTC()
+}
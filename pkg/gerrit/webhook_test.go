@@ -0,0 +1,141 @@
+package gerrit
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const patchsetCreated = `{
+	"type": "patchset-created",
+	"change": {
+		"project": "org/repo",
+		"branch": "main",
+		"number": 42,
+		"subject": "Fix the build",
+		"owner": {"name": "Jane", "email": "jane@example.com", "username": "jane"},
+		"url": "https://review.example.com/c/org/repo/+/42",
+		"status": "NEW"
+	},
+	"patchSet": {
+		"number": 2,
+		"revision": "` + testSHA + `",
+		"parents": ["base"],
+		"ref": "refs/changes/42/42/2",
+		"uploader": {"name": "Jane", "username": "jane"}
+	},
+	"uploader": {"name": "Jane", "username": "jane"},
+	"eventCreatedOn": 1600000000
+}`
+
+func parseEvent(t *testing.T, body, query, token string) (scm.Webhook, error) {
+	client, err := New("https://review.example.com", "bot")
+	require.NoError(t, err)
+	req := httptest.NewRequest("POST", "/hook"+query, strings.NewReader(body))
+	return client.Webhooks.Parse(req, func(scm.Webhook) (string, error) {
+		return token, nil
+	})
+}
+
+func TestParseToken(t *testing.T) {
+	_, err := parseEvent(t, patchsetCreated, "?token=secret", "secret")
+	assert.NoError(t, err)
+
+	_, err = parseEvent(t, patchsetCreated, "?token=other", "secret")
+	assert.Equal(t, scm.ErrSignatureInvalid, err)
+
+	_, err = parseEvent(t, patchsetCreated, "", "secret")
+	assert.Equal(t, scm.ErrSignatureInvalid, err)
+
+	_, err = parseEvent(t, patchsetCreated, "", "")
+	assert.NoError(t, err)
+}
+
+func TestParsePatchsetCreated(t *testing.T) {
+	hook, err := parseEvent(t, patchsetCreated, "", "")
+	require.NoError(t, err)
+	prHook, ok := hook.(*scm.PullRequestHook)
+	require.True(t, ok, "got %T", hook)
+
+	assert.Equal(t, scm.ActionSync, prHook.Action)
+	assert.Equal(t, "org/repo", prHook.Repo.FullName)
+	assert.Equal(t, "https://review.example.com/a/org/repo", prHook.Repo.Clone)
+	pr := prHook.PullRequest
+	assert.Equal(t, 42, pr.Number)
+	assert.Equal(t, testSHA, pr.Head.Sha)
+	assert.Equal(t, "refs/changes/42/42/2", pr.Ref)
+	assert.Equal(t, "main", pr.Base.Ref)
+	assert.Equal(t, "base", pr.Base.Sha)
+	assert.Equal(t, "jane", pr.Author.Login)
+	assert.Equal(t, "jane", prHook.Sender.Login)
+
+	hook, err = parseEvent(t, strings.Replace(patchsetCreated, `"number": 2`, `"number": 1`, 1), "", "")
+	require.NoError(t, err)
+	assert.Equal(t, scm.ActionOpen, hook.(*scm.PullRequestHook).Action)
+}
+
+func TestParseChangeEvents(t *testing.T) {
+	tests := []struct {
+		event  string
+		action scm.Action
+		merged bool
+		closed bool
+	}{
+		{event: "change-merged", action: scm.ActionClose, merged: true, closed: true},
+		{event: "change-abandoned", action: scm.ActionClose, closed: true},
+		{event: "change-restored", action: scm.ActionReopen},
+	}
+	for _, tc := range tests {
+		t.Run(tc.event, func(t *testing.T) {
+			hook, err := parseEvent(t, strings.Replace(patchsetCreated, "patchset-created", tc.event, 1), "", "")
+			require.NoError(t, err)
+			prHook, ok := hook.(*scm.PullRequestHook)
+			require.True(t, ok, "got %T", hook)
+			assert.Equal(t, tc.action, prHook.Action)
+			assert.Equal(t, tc.merged, prHook.PullRequest.Merged)
+			assert.Equal(t, tc.closed, prHook.PullRequest.Closed)
+		})
+	}
+}
+
+func TestParseCommentAdded(t *testing.T) {
+	body := strings.Replace(patchsetCreated, `"uploader": {"name": "Jane", "username": "jane"},
+	"eventCreatedOn"`, `"author": {"name": "Joe", "username": "joe"},
+	"comment": "Patch Set 2:\n\n/retest",
+	"eventCreatedOn"`, 1)
+	body = strings.Replace(body, "patchset-created", "comment-added", 1)
+
+	hook, err := parseEvent(t, body, "", "")
+	require.NoError(t, err)
+	commentHook, ok := hook.(*scm.PullRequestCommentHook)
+	require.True(t, ok, "got %T", hook)
+	assert.Equal(t, scm.ActionCreate, commentHook.Action)
+	assert.Equal(t, "Patch Set 2:\n\n/retest", commentHook.Comment.Body)
+	assert.Equal(t, "joe", commentHook.Comment.Author.Login)
+	assert.Equal(t, 42, commentHook.PullRequest.Number)
+}
+
+func TestParseRefUpdated(t *testing.T) {
+	refUpdated := `{
+		"type": "ref-updated",
+		"submitter": {"username": "jane"},
+		"refUpdate": {"oldRev": "base", "newRev": "` + testSHA + `", "refName": "%s", "project": "org/repo"}
+	}`
+
+	hook, err := parseEvent(t, strings.Replace(refUpdated, "%s", "refs/heads/main", 1), "", "")
+	require.NoError(t, err)
+	push, ok := hook.(*scm.PushHook)
+	require.True(t, ok, "got %T", hook)
+	assert.Equal(t, "refs/heads/main", push.Ref)
+	assert.Equal(t, "base", push.Before)
+	assert.Equal(t, testSHA, push.After)
+	assert.Equal(t, "org/repo", push.Repo.FullName)
+	assert.Equal(t, "jane", push.Sender.Login)
+
+	_, err = parseEvent(t, strings.Replace(refUpdated, "%s", "refs/changes/42/42/2", 1), "", "")
+	assert.IsType(t, scm.UnknownWebhook{}, err)
+}
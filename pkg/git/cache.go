@@ -0,0 +1,151 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// lockSuffix is the suffix of the files locking the bare repos of the cache
+const lockSuffix = ".lock"
+
+// evictionInterval is the least time between two evictions, as they walk the whole cache
+var evictionInterval = time.Minute
+
+// CacheOptions configure the cache of bare repos the clones are made from
+type CacheOptions struct {
+	// Dir is the directory of the cache, e.g. on a persistent volume shared by the replicas, which Clean keeps. A
+	// temporary directory removed by Clean is used if it is empty.
+	Dir string
+	// MaxSize is the size of the cache in bytes above which the least recently used repos are evicted, unlimited
+	// if 0.
+	MaxSize int64
+}
+
+// NewClientWithCache returns a client cloning the repos from the cache of the options. It will fail if git is not
+// in the PATH.
+func NewClientWithCache(serverURL string, gitKind string, options CacheOptions) (Client, error) {
+	if options.Dir == "" {
+		c, err := NewClient(serverURL, gitKind)
+		if err != nil {
+			return nil, err
+		}
+		c.(*client).maxSize = options.MaxSize
+		return c, nil
+	}
+	if err := os.MkdirAll(options.Dir, os.ModePerm); err != nil {
+		return nil, err
+	}
+	g, err := exec.LookPath("git")
+	if err != nil {
+		return nil, err
+	}
+	return &client{
+		logger:     logrus.WithField("client", "git"),
+		dir:        options.Dir,
+		persistent: true,
+		maxSize:    options.MaxSize,
+		git:        g,
+		base:       serverURL,
+		gitKind:    gitKind,
+		repoLocks:  make(map[string]*sync.Mutex),
+	}, nil
+}
+
+// cachedRepo is a bare repo of the cache
+type cachedRepo struct {
+	path     string
+	size     int64
+	lastUsed time.Time
+}
+
+// evict removes the least recently used repos of the cache, other than the one just used, until the cache fits in
+// its maximum size. The repos locked by a clone of this or another replica are skipped.
+func (c *client) evict(used string) {
+	if c.maxSize <= 0 {
+		return
+	}
+	c.evictLock.Lock()
+	defer c.evictLock.Unlock()
+	if time.Since(c.lastEviction) < evictionInterval {
+		return
+	}
+	c.lastEviction = time.Now()
+
+	repos, total, err := cachedRepos(c.dir)
+	if err != nil {
+		c.logger.WithError(err).Warn("Failed to list the repos of the git cache.")
+		return
+	}
+	sort.Slice(repos, func(i, j int) bool {
+		return repos[i].lastUsed.Before(repos[j].lastUsed)
+	})
+	for _, r := range repos {
+		if total <= c.maxSize {
+			return
+		}
+		if r.path == used {
+			continue
+		}
+		unlock, err := lockFile(r.path+lockSuffix, false)
+		if err != nil {
+			continue
+		}
+		if err := os.RemoveAll(r.path); err != nil {
+			c.logger.WithError(err).Warnf("Failed to evict %s from the git cache.", r.path)
+		} else {
+			c.logger.Infof("Evicted %s of %d bytes from the git cache.", r.path, r.size)
+			total -= r.size
+		}
+		unlock()
+	}
+}
+
+// cachedRepos returns the bare repos of the cache and their total size
+func cachedRepos(dir string) ([]cachedRepo, int64, error) {
+	var repos []cachedRepo
+	var total int64
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				// removed by another replica
+				return nil
+			}
+			return err
+		}
+		if !info.IsDir() || path == dir || !strings.HasSuffix(path, ".git") {
+			return nil
+		}
+		size, err := dirSize(path)
+		if err != nil {
+			return err
+		}
+		repos = append(repos, cachedRepo{path: path, size: size, lastUsed: info.ModTime()})
+		total += size
+		return filepath.SkipDir
+	})
+	return repos, total, err
+}
+
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.Walk(dir, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
@@ -0,0 +1,53 @@
+package git_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/lighthouse/pkg/git"
+	"github.com/jenkins-x/lighthouse/pkg/git/localgit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache(t *testing.T) {
+	lg, _, err := localgit.New()
+	require.NoError(t, err)
+	defer lg.Clean()
+	require.NoError(t, lg.MakeFakeRepo("org", "a"))
+	require.NoError(t, lg.CheckoutNewBranch("org", "a", "feature"))
+	require.NoError(t, lg.AddCommit("org", "a", map[string][]byte{"OWNERS": []byte("approvers:\n- alice\n")}))
+	require.NoError(t, lg.CheckoutNewBranch("org", "a", "other"))
+	require.NoError(t, lg.MakeFakeRepo("org", "b"))
+
+	git.SetEvictionInterval(0)
+
+	dir, err := ioutil.TempDir("", "gitcache")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	c, err := git.NewClientWithCache("https://github.com", "github", git.CacheOptions{Dir: dir, MaxSize: 1})
+	require.NoError(t, err)
+	c.SetCredentials("", func() []byte { return nil })
+	c.SetRemote(lg.Dir)
+
+	r, err := c.CloneRef("org/a", "feature")
+	require.NoError(t, err)
+	require.NoError(t, r.Checkout("feature"))
+	assert.FileExists(t, filepath.Join(r.Dir, "OWNERS"))
+	_, err = r.RevParse("origin/other")
+	assert.Error(t, err, "only the ref is fetched")
+	require.NoError(t, r.Clean())
+	assert.DirExists(t, filepath.Join(dir, "org", "a.git"))
+
+	r, err = c.Clone("org/b")
+	require.NoError(t, err)
+	require.NoError(t, r.Clean())
+	assert.DirExists(t, filepath.Join(dir, "org", "b.git"))
+	_, err = os.Stat(filepath.Join(dir, "org", "a.git"))
+	assert.True(t, os.IsNotExist(err), "the least recently used repo is evicted")
+
+	require.NoError(t, c.Clean())
+	assert.DirExists(t, filepath.Join(dir, "org", "b.git"), "the persistent cache is kept")
+}
@@ -0,0 +1,8 @@
+package git
+
+import "time"
+
+// SetEvictionInterval sets the least time between two evictions of the cache
+func SetEvictionInterval(interval time.Duration) {
+	evictionInterval = interval
+}
@@ -17,6 +17,7 @@ import (
 
 const (
 	kindBitbucketServer = "bitbucketserver"
+	kindGerrit          = "gerrit"
 )
 
 // Client represents a git client
@@ -25,6 +26,7 @@ type Client interface {
 	SetRemote(remote string)
 	SetCredentials(user string, tokenGenerator func() []byte)
 	Clone(repo string) (*Repo, error)
+	CloneRef(repo, ref string) (*Repo, error)
 }
 
 // client can clone repos. It keeps a local cache, so successive clones of the
@@ -42,6 +44,11 @@ type client struct {
 
 	// dir is the location of the git cache.
 	dir string
+	// persistent is true if the cache is kept by Clean, e.g. as it is shared with other replicas.
+	persistent bool
+	// maxSize is the size of the cache in bytes above which its least recently used repos are evicted,
+	// unlimited if 0.
+	maxSize int64
 	// git is the path to the git binary.
 	git string
 	// base is the base path for git clone calls. For users it will be set to
@@ -56,10 +63,17 @@ type client struct {
 	// Lock with Client.lockRepo, unlock with Client.unlockRepo.
 	rlm       sync.Mutex
 	repoLocks map[string]*sync.Mutex
+
+	// evictLock protects lastEviction.
+	evictLock    sync.Mutex
+	lastEviction time.Time
 }
 
-// Clean removes the local repo cache. The Client is unusable after calling.
+// Clean removes the local repo cache, unless it is persistent. The Client is unusable after calling.
 func (c *client) Clean() error {
+	if c.persistent {
+		return nil
+	}
 	return os.RemoveAll(c.dir)
 }
 
@@ -134,6 +148,21 @@ func (c *client) unlockRepo(repo string) {
 // take a while. Once that is done, it will do a git fetch instead of a clone,
 // which will usually take at most a few seconds.
 func (c *client) Clone(repo string) (*Repo, error) {
+	return c.clone(repo, "")
+}
+
+// CloneRef clones a repository like Clone, but only fetches the given branch
+// or ref into the cache, which is much quicker for repos with many branches
+// or pull requests when only their base branch is needed, e.g. to read their
+// OWNERS files.
+func (c *client) CloneRef(repo, ref string) (*Repo, error) {
+	if ref == "" {
+		return nil, errors.New("no ref to clone")
+	}
+	return c.clone(repo, ref)
+}
+
+func (c *client) clone(repo, ref string) (*Repo, error) {
 	c.lockRepo(repo)
 	defer c.unlockRepo(repo)
 
@@ -144,44 +173,20 @@ func (c *client) Clone(repo string) (*Repo, error) {
 		base = fmt.Sprintf("https://%s:%s@%s", user, pass, host)
 	}
 	cache := filepath.Join(c.dir, repo) + ".git"
-	if _, err := os.Stat(cache); os.IsNotExist(err) {
-		// Cache miss, clone it now.
-		c.logger.Infof("Cloning %s for the first time.", repo)
-		if err := os.Mkdir(filepath.Dir(cache), os.ModePerm); err != nil && !os.IsExist(err) {
-			return nil, err
-		}
-		prefix := ""
-		repoText := repo
-		if c.gitKind == kindBitbucketServer {
-			prefix = "scm/"
-			idx := strings.Index(repo, "/")
-
-			// to clone on bitbucket we need to lower case the projectKey owner
-			if idx > 0 {
-				repoText = fmt.Sprintf("%s/%s", strings.ToLower(repo[0:idx]), repo[idx+1:])
-			}
-		}
-		remote := fmt.Sprintf("%s/%s%s", base, prefix, repoText)
-		if b, err := retryCmd(c.logger, "", c.git, "clone", "--mirror", remote, cache); err != nil {
-			return nil, fmt.Errorf("git cache clone error: %v. output: %s", err, string(b))
-		}
-	} else if err != nil {
+	if err := os.MkdirAll(filepath.Dir(cache), os.ModePerm); err != nil {
 		return nil, err
-	} else {
-		// Cache hit. Do a git fetch to keep updated.
-		c.logger.Infof("Fetching %s.", repo)
-		if b, err := retryCmd(c.logger, cache, c.git, "fetch"); err != nil {
-			return nil, fmt.Errorf("git fetch error: %v. output: %s", err, string(b))
-		}
 	}
-	t, err := ioutil.TempDir("", "git")
+	// the replicas sharing the cache lock its repos too
+	unlock, err := lockFile(cache+lockSuffix, true)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("locking the git cache of %s: %v", repo, err)
 	}
-	b, err := exec.Command(c.git, "clone", cache, t).CombinedOutput() // #nosec
+	t, err := c.updateAndClone(cache, repo, ref, c.remote(base, repo), c.remote(c.base, repo))
+	unlock()
 	if err != nil {
-		return nil, fmt.Errorf("git repo clone error: %v. output: %s", err, string(b))
+		return nil, err
 	}
+	c.evict(cache)
 	return &Repo{
 		Dir:    t,
 		logger: c.logger,
@@ -193,6 +198,90 @@ func (c *client) Clone(repo string) (*Repo, error) {
 	}, nil
 }
 
+// remote returns the URL of the repo at the base
+func (c *client) remote(base, repo string) string {
+	prefix := ""
+	repoText := repo
+	if c.gitKind == kindBitbucketServer {
+		prefix = "scm/"
+		idx := strings.Index(repo, "/")
+
+		// to clone on bitbucket we need to lower case the projectKey owner
+		if idx > 0 {
+			repoText = fmt.Sprintf("%s/%s", strings.ToLower(repo[0:idx]), repo[idx+1:])
+		}
+	}
+	if c.gitKind == kindGerrit {
+		// the authenticated repositories of Gerrit are served under /a/
+		prefix = "a/"
+	}
+	return fmt.Sprintf("%s/%s%s", base, prefix, repoText)
+}
+
+// updateAndClone fetches the ref, or every ref if empty, from the remote into the bare repo of the cache and
+// clones it into a temporary directory. The credentials of the remote are not kept in the cache, which may be on a
+// volume shared with other replicas.
+func (c *client) updateAndClone(cache, repo, ref, remote, plainRemote string) (string, error) {
+	refspec := "+refs/*:refs/*"
+	if ref != "" {
+		if !strings.HasPrefix(ref, "refs/") {
+			ref = "refs/heads/" + ref
+		}
+		refspec = fmt.Sprintf("+%s:%s", ref, ref)
+	}
+	_, err := os.Stat(cache)
+	switch {
+	case os.IsNotExist(err) && ref == "":
+		// Cache miss, clone it now.
+		c.logger.Infof("Cloning %s for the first time.", repo)
+		if b, err := retryCmd(c.logger, "", c.git, "clone", "--mirror", remote, cache); err != nil {
+			return "", fmt.Errorf("git cache clone error: %v. output: %s", err, string(b))
+		}
+		if b, err := retryCmd(c.logger, cache, c.git, "remote", "set-url", "origin", plainRemote); err != nil {
+			return "", fmt.Errorf("git cache remote error: %v. output: %s", err, string(b))
+		}
+	case os.IsNotExist(err):
+		// Cache miss, only fetch the ref.
+		c.logger.Infof("Fetching %s of %s for the first time.", ref, repo)
+		if b, err := retryCmd(c.logger, "", c.git, "init", "--bare", cache); err != nil {
+			return "", fmt.Errorf("git cache init error: %v. output: %s", err, string(b))
+		}
+		if b, err := retryCmd(c.logger, cache, c.git, "symbolic-ref", "HEAD", ref); err != nil {
+			return "", fmt.Errorf("git cache HEAD error: %v. output: %s", err, string(b))
+		}
+		if b, err := retryCmd(c.logger, cache, c.git, "fetch", remote, refspec); err != nil {
+			return "", fmt.Errorf("git fetch error: %v. output: %s", err, string(b))
+		}
+	case err != nil:
+		return "", err
+	default:
+		// Cache hit. Do a git fetch to keep updated.
+		c.logger.Infof("Fetching %s.", repo)
+		args := []string{"fetch", remote, refspec}
+		if ref == "" {
+			args = []string{"fetch", "--prune", remote, refspec}
+		}
+		if b, err := retryCmd(c.logger, cache, c.git, args...); err != nil {
+			return "", fmt.Errorf("git fetch error: %v. output: %s", err, string(b))
+		}
+	}
+	// the modification time of the cache tells when it was last used
+	now := time.Now()
+	if err := os.Chtimes(cache, now, now); err != nil {
+		c.logger.WithError(err).Warnf("Failed to record the use of the cache of %s.", repo)
+	}
+	t, err := ioutil.TempDir("", "git")
+	if err != nil {
+		return "", err
+	}
+	b, err := exec.Command(c.git, "clone", cache, t).CombinedOutput() // #nosec
+	if err != nil {
+		_ = os.RemoveAll(t)
+		return "", fmt.Errorf("git repo clone error: %v. output: %s", err, string(b))
+	}
+	return t, nil
+}
+
 func gitHost(s string) string {
 	u, err := url.Parse(s)
 	if err == nil {
@@ -252,6 +341,36 @@ func (r *Repo) RevParse(commitlike string) (string, error) {
 	return string(b), nil
 }
 
+// IsAncestor returns true if the ancestor is the commitlike or one of its ancestors, that is if the merge base of
+// both is the ancestor.
+func (r *Repo) IsAncestor(ancestor, commitlike string) (bool, error) {
+	r.logger.Infof("Checking whether %s is an ancestor of %s.", ancestor, commitlike)
+	b, err := r.gitCommand("merge-base", "--is-ancestor", ancestor, commitlike).CombinedOutput()
+	if err == nil {
+		return true, nil
+	}
+	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+		return false, nil
+	}
+	return false, fmt.Errorf("error checking whether %s is an ancestor of %s: %v. output: %s", ancestor, commitlike, err, string(b))
+}
+
+// ChangedFiles returns the paths of the files which differ between the two commits.
+func (r *Repo) ChangedFiles(from, to string) ([]string, error) {
+	r.logger.Infof("Listing the files changed between %s and %s.", from, to)
+	b, err := r.gitCommand("diff", "--name-only", from, to).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("error listing the files changed between %s and %s: %v. output: %s", from, to, err, string(b))
+	}
+	var files []string
+	for _, line := range strings.Split(string(b), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			files = append(files, line)
+		}
+	}
+	return files, nil
+}
+
 // CheckoutNewBranch creates a new branch and checks it out.
 func (r *Repo) CheckoutNewBranch(branch string) error {
 	r.logger.Infof("Launch and checkout %s.", branch)
@@ -0,0 +1,17 @@
+package git
+
+import (
+	"os"
+)
+
+// lockFile creates the file without locking it, as the caches shared by several processes are not supported on
+// Windows. It returns the function releasing the lock.
+func lockFile(path string, wait bool) (func(), error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return func() {
+		_ = f.Close()
+	}, nil
+}
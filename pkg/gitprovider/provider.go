@@ -0,0 +1,244 @@
+// Package gitprovider describes the SCM providers a lighthouse installation serves. The default provider is
+// configured with the $GIT_KIND, $GIT_SERVER, $GIT_USER, $GIT_TOKEN and $HMAC_TOKEN environment variables.
+// Each additional provider listed in $LIGHTHOUSE_PROVIDERS is configured with the same variables prefixed by
+// its upper cased name, e.g. $GITLAB_GIT_KIND and $GITLAB_GIT_TOKEN for a provider named gitlab.
+//
+// The orgs hosted by a provider, such as a GitHub Enterprise server next to github.com, may be listed in its
+// $GIT_ORGS variable so that the jobs and webhooks of those orgs use the provider's endpoint and credentials.
+// The default provider hosts all the orgs which are not listed by another provider.
+package gitprovider
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/go-scm/scm/factory"
+	"github.com/jenkins-x/lighthouse/pkg/gerrit"
+	"github.com/jenkins-x/lighthouse/pkg/secrets"
+	"github.com/pkg/errors"
+)
+
+const (
+	// ProvidersEnv is the environment variable listing the names of the providers served in addition to the
+	// default one, separated by commas
+	ProvidersEnv = "LIGHTHOUSE_PROVIDERS"
+
+	defaultKind    = "github"
+	defaultBotName = "jenkins-x-bot"
+)
+
+var validName = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+
+// Provider is an SCM provider, such as a GitHub Enterprise server or a GitLab instance
+type Provider struct {
+	// Name is empty for the default provider
+	Name      string
+	envPrefix string
+}
+
+// Default returns the default provider
+func Default() *Provider {
+	return &Provider{}
+}
+
+// Named returns the provider with the given name, which must be listed in $LIGHTHOUSE_PROVIDERS. The empty
+// name is the default provider.
+func Named(name string) (*Provider, error) {
+	if name == "" {
+		return Default(), nil
+	}
+	providers, err := All()
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range providers {
+		if p.Name == name {
+			return p, nil
+		}
+	}
+	return nil, errors.Errorf("unknown provider %s, expected one of $%s", name, ProvidersEnv)
+}
+
+// All returns the default provider followed by the providers listed in $LIGHTHOUSE_PROVIDERS
+func All() ([]*Provider, error) {
+	answer := []*Provider{Default()}
+	seen := map[string]bool{}
+	for _, name := range strings.Split(os.Getenv(ProvidersEnv), ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		if !validName.MatchString(name) {
+			return nil, errors.Errorf("invalid provider name %q in $%s, expected lower case letters, digits and dashes", name, ProvidersEnv)
+		}
+		seen[name] = true
+		answer = append(answer, &Provider{
+			Name:      name,
+			envPrefix: strings.ToUpper(strings.Replace(name, "-", "_", -1)) + "_",
+		})
+	}
+	return answer, nil
+}
+
+// ForOrg returns the provider hosting the org, which is the first provider listing it in its $GIT_ORGS or else
+// the default provider
+func ForOrg(org string) (*Provider, error) {
+	providers, err := All()
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range providers {
+		if p.lists(org) {
+			return p, nil
+		}
+	}
+	return Default(), nil
+}
+
+// Hosts returns true if the repositories of the org are hosted by the provider, which is the case for any org
+// not listed by another provider when the provider does not list its orgs
+func (p *Provider) Hosts(org string) (bool, error) {
+	if p.lists(org) {
+		return true, nil
+	}
+	if len(p.Orgs()) > 0 {
+		return false, nil
+	}
+	hosting, err := ForOrg(org)
+	if err != nil {
+		return false, err
+	}
+	return !hosting.lists(org), nil
+}
+
+// Orgs returns the orgs listed in the $GIT_ORGS of the provider, separated by commas
+func (p *Provider) Orgs() []string {
+	var answer []string
+	for _, org := range strings.Split(os.Getenv(p.EnvName("GIT_ORGS")), ",") {
+		if org = strings.Trim(strings.TrimSpace(org), "/"); org != "" {
+			answer = append(answer, org)
+		}
+	}
+	return answer
+}
+
+// lists returns true if the org, or the group it belongs to on GitLab, is one of the orgs of the provider
+func (p *Provider) lists(org string) bool {
+	org = strings.ToLower(org)
+	for _, listed := range p.Orgs() {
+		listed = strings.ToLower(listed)
+		if org == listed || strings.HasPrefix(org, listed+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the name of the provider for logging
+func (p *Provider) String() string {
+	if p.Name == "" {
+		return "default"
+	}
+	return p.Name
+}
+
+// EnvName returns the name of the environment variable of the provider for the given setting, e.g. GIT_TOKEN
+func (p *Provider) EnvName(name string) string {
+	return p.envPrefix + name
+}
+
+// Kind returns the kind of the provider, e.g. github or gitlab
+func (p *Provider) Kind() string {
+	kind := os.Getenv(p.EnvName("GIT_KIND"))
+	if kind == "" {
+		kind = defaultKind
+	}
+	return kind
+}
+
+// ServerURL returns the URL of the provider, which is empty for the public server of its kind
+func (p *Provider) ServerURL() string {
+	return os.Getenv(p.EnvName("GIT_SERVER"))
+}
+
+// BotName returns the login of the bot user of the provider
+func (p *Provider) BotName() string {
+	botName := os.Getenv(p.EnvName("GIT_USER"))
+	if botName == "" {
+		botName = defaultBotName
+	}
+	return botName
+}
+
+// Token returns the token of the bot user, which may be loaded from a file or Vault, see secrets.FromEnv
+func (p *Provider) Token() (string, error) {
+	envName := p.EnvName("GIT_TOKEN")
+	value, err := secrets.FromEnv(envName).Get()
+	if err != nil {
+		return "", errors.Wrapf(err, "loading token for git kind %s", p.Kind())
+	}
+	if value == "" {
+		return value, fmt.Errorf("No token available for git kind %s at environment variable $%s", p.Kind(), envName)
+	}
+	return value, nil
+}
+
+// HMACTokens returns the tokens webhooks of the provider are signed with, see secrets.Tokens
+func (p *Provider) HMACTokens() ([]string, error) {
+	return secrets.Tokens(p.EnvName("HMAC_TOKEN"))
+}
+
+// ProxyURL returns the URL of the caching proxy, such as the lighthouse SCM proxy, the API requests of the
+// provider are sent to, which is empty if they are sent to the provider directly
+func (p *Provider) ProxyURL() string {
+	return os.Getenv(p.EnvName("GIT_PROXY_URL"))
+}
+
+// NewClient creates a client of the provider authenticated with the token, which may be empty
+func (p *Provider) NewClient(token string) (*scm.Client, error) {
+	client, err := NewSCMClient(p.Kind(), p.ServerURL(), p.BotName(), token)
+	if err != nil {
+		return nil, err
+	}
+	return client, UseProxy(client, p.ProxyURL())
+}
+
+// NewSCMClient creates a client of the given kind of server authenticated with the token, which may be empty. The
+// clients of Gerrit, which go-scm has no driver for, authenticate as the bot with the token as its HTTP password.
+func NewSCMClient(kind, serverURL, botName, token string) (*scm.Client, error) {
+	if kind == gerrit.Kind {
+		client, err := gerrit.New(serverURL, botName)
+		if err != nil {
+			return nil, err
+		}
+		if token != "" {
+			gerrit.SetCredentials(client, token)
+		}
+		return client, nil
+	}
+	return factory.NewClient(kind, serverURL, token)
+}
+
+// UseProxy sends the API requests of the client to the proxy at the URL, unless it is empty. Only the scheme and
+// host of the API URL are replaced, so that the proxy forwards the requests to the same path of the provider.
+func UseProxy(client *scm.Client, proxyURL string) error {
+	if proxyURL == "" {
+		return nil
+	}
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return errors.Wrapf(err, "parsing proxy URL %s", proxyURL)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return errors.Errorf("invalid proxy URL %s, expected an absolute URL", proxyURL)
+	}
+	base := *client.BaseURL
+	base.Scheme = u.Scheme
+	base.Host = u.Host
+	client.BaseURL = &base
+	return nil
+}
@@ -0,0 +1,144 @@
+package gitprovider
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setEnv(t *testing.T, values map[string]string) {
+	for name, value := range values {
+		old, ok := os.LookupEnv(name)
+		require.NoError(t, os.Setenv(name, value))
+		name := name
+		t.Cleanup(func() {
+			if ok {
+				_ = os.Setenv(name, old)
+			} else {
+				_ = os.Unsetenv(name)
+			}
+		})
+	}
+}
+
+func TestProviders(t *testing.T) {
+	setEnv(t, map[string]string{
+		ProvidersEnv:               "gitlab, bitbucket-cloud,gitlab",
+		"GIT_SERVER":               "https://github.example.com",
+		"GIT_TOKEN":                "github-token",
+		"GITLAB_GIT_KIND":          "gitlab",
+		"GITLAB_GIT_SERVER":        "https://gitlab.example.com",
+		"GITLAB_GIT_USER":          "gitlab-bot",
+		"GITLAB_GIT_TOKEN":         "gitlab-token",
+		"GITLAB_HMAC_TOKEN":        "new,old",
+		"BITBUCKET_CLOUD_GIT_KIND": "bitbucketcloud",
+	})
+
+	providers, err := All()
+	require.NoError(t, err)
+	require.Len(t, providers, 3)
+	assert.Equal(t, "", providers[0].Name)
+	assert.Equal(t, "gitlab", providers[1].Name)
+	assert.Equal(t, "bitbucket-cloud", providers[2].Name)
+
+	github := providers[0]
+	assert.Equal(t, "default", github.String())
+	assert.Equal(t, "github", github.Kind())
+	assert.Equal(t, "https://github.example.com", github.ServerURL())
+	token, err := github.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "github-token", token)
+
+	gitlab, err := Named("gitlab")
+	require.NoError(t, err)
+	assert.Equal(t, "gitlab", gitlab.Kind())
+	assert.Equal(t, "https://gitlab.example.com", gitlab.ServerURL())
+	assert.Equal(t, "gitlab-bot", gitlab.BotName())
+	token, err = gitlab.Token()
+	require.NoError(t, err)
+	assert.Equal(t, "gitlab-token", token)
+	tokens, err := gitlab.HMACTokens()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"new", "old"}, tokens)
+	client, err := gitlab.NewClient("")
+	require.NoError(t, err)
+	assert.Equal(t, "gitlab", client.Driver.String())
+
+	bitbucket := providers[2]
+	assert.Equal(t, "BITBUCKET_CLOUD_GIT_KIND", bitbucket.EnvName("GIT_KIND"))
+	assert.Equal(t, "bitbucketcloud", bitbucket.Kind())
+	assert.Equal(t, "jenkins-x-bot", bitbucket.BotName())
+	_, err = bitbucket.Token()
+	assert.Error(t, err)
+
+	_, err = Named("gitea")
+	assert.Error(t, err)
+
+	setEnv(t, map[string]string{ProvidersEnv: "GitLab"})
+	_, err = All()
+	assert.Error(t, err)
+}
+
+func TestProviderOrgs(t *testing.T) {
+	setEnv(t, map[string]string{
+		ProvidersEnv:          "ghe,gitlab",
+		"GHE_GIT_ORGS":        "Acme, acme-internal",
+		"GITLAB_GIT_ORGS":     "platform/",
+		"GIT_ORGS":            "",
+		"GITLAB_GIT_KIND":     "gitlab",
+		"GHE_GIT_SERVER":      "https://github.acme.com",
+		"GITLAB_GIT_SERVER":   "https://gitlab.acme.com",
+		"NOT_LISTED_GIT_ORGS": "other",
+	})
+
+	testCases := []struct {
+		org      string
+		expected string
+	}{
+		{org: "acme", expected: "ghe"},
+		{org: "ACME-internal", expected: "ghe"},
+		{org: "platform", expected: "gitlab"},
+		{org: "platform/tools", expected: "gitlab"},
+		{org: "platform-tools", expected: ""},
+		{org: "jenkins-x", expected: ""},
+		{org: "other", expected: ""},
+	}
+	providers, err := All()
+	require.NoError(t, err)
+	for _, tc := range testCases {
+		p, err := ForOrg(tc.org)
+		require.NoError(t, err)
+		assert.Equal(t, tc.expected, p.Name, "provider of org %s", tc.org)
+
+		for _, provider := range providers {
+			hosted, err := provider.Hosts(tc.org)
+			require.NoError(t, err)
+			assert.Equal(t, provider.Name == tc.expected, hosted, "whether %s hosts org %s", provider, tc.org)
+		}
+	}
+	assert.Equal(t, []string{"Acme", "acme-internal"}, providers[1].Orgs())
+	assert.Empty(t, providers[0].Orgs())
+}
+
+func TestProviderProxy(t *testing.T) {
+	setEnv(t, map[string]string{
+		ProvidersEnv:        "ghe",
+		"GIT_SERVER":        "",
+		"GIT_PROXY_URL":     "http://lighthouse-scm-proxy:8888",
+		"GHE_GIT_SERVER":    "https://github.acme.com",
+		"GHE_GIT_PROXY_URL": "http://lighthouse-scm-proxy-ghe",
+	})
+	providers, err := All()
+	require.NoError(t, err)
+
+	client, err := providers[0].NewClient("token")
+	require.NoError(t, err)
+	assert.Equal(t, "http://lighthouse-scm-proxy:8888/", client.BaseURL.String())
+	client, err = providers[1].NewClient("token")
+	require.NoError(t, err)
+	assert.Equal(t, "http://lighthouse-scm-proxy-ghe/api/v3/", client.BaseURL.String(), "the path of the API should be kept")
+
+	assert.Error(t, UseProxy(client, "lighthouse-scm-proxy"))
+}
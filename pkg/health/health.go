@@ -0,0 +1,214 @@
+// Package health serves the liveness and readiness endpoints of the lighthouse components, which run checks of the
+// dependencies of the component and report the result of each check as JSON.
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// LivenessPath is the path of the liveness endpoint
+	LivenessPath = "/healthz"
+	// ReadinessPath is the path of the readiness endpoint
+	ReadinessPath = "/readyz"
+
+	// DefaultTimeout is how long a check may take before it is considered failed
+	DefaultTimeout = 5 * time.Second
+)
+
+// Check returns an error if the dependency it checks is not available
+type Check func() error
+
+// Result is the result of a check
+type Result struct {
+	Name  string `json:"name"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// Report is the response of the endpoints
+type Report struct {
+	OK     bool     `json:"ok"`
+	Checks []Result `json:"checks"`
+}
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Checker runs the checks of a component. The liveness endpoint runs the liveness checks while the readiness
+// endpoint runs both the liveness and the readiness checks.
+type Checker struct {
+	// Timeout is how long a check may take, defaulting to DefaultTimeout
+	Timeout time.Duration
+
+	lock      sync.RWMutex
+	liveness  []namedCheck
+	readiness []namedCheck
+}
+
+// AddLiveness adds a check which fails both endpoints
+func (c *Checker) AddLiveness(name string, check Check) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.liveness = append(c.liveness, namedCheck{name: name, check: check})
+}
+
+// AddReadiness adds a check which only fails the readiness endpoint
+func (c *Checker) AddReadiness(name string, check Check) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.readiness = append(c.readiness, namedCheck{name: name, check: check})
+}
+
+// Register serves the endpoints on the mux
+func (c *Checker) Register(mux *http.ServeMux) {
+	mux.HandleFunc(LivenessPath, func(w http.ResponseWriter, r *http.Request) {
+		c.serve(w, c.Liveness())
+	})
+	mux.HandleFunc(ReadinessPath, func(w http.ResponseWriter, r *http.Request) {
+		c.serve(w, c.Readiness())
+	})
+}
+
+// Liveness runs the liveness checks
+func (c *Checker) Liveness() Report {
+	c.lock.RLock()
+	checks := append([]namedCheck(nil), c.liveness...)
+	c.lock.RUnlock()
+	return c.run(checks)
+}
+
+// Readiness runs the liveness and readiness checks
+func (c *Checker) Readiness() Report {
+	c.lock.RLock()
+	checks := append(append([]namedCheck(nil), c.liveness...), c.readiness...)
+	c.lock.RUnlock()
+	return c.run(checks)
+}
+
+// run runs the checks concurrently, failing the ones which time out
+func (c *Checker) run(checks []namedCheck) Report {
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	report := Report{OK: true, Checks: make([]Result, len(checks))}
+	var wg sync.WaitGroup
+	for i, nc := range checks {
+		wg.Add(1)
+		go func(i int, nc namedCheck) {
+			defer wg.Done()
+			result := Result{Name: nc.name, OK: true}
+			if err := runWithTimeout(nc.check, timeout); err != nil {
+				result.OK = false
+				result.Error = err.Error()
+			}
+			report.Checks[i] = result
+		}(i, nc)
+	}
+	wg.Wait()
+	for _, result := range report.Checks {
+		if !result.OK {
+			report.OK = false
+		}
+	}
+	return report
+}
+
+func runWithTimeout(check Check, timeout time.Duration) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- check()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(timeout):
+		return errors.Errorf("timed out after %s", timeout)
+	}
+}
+
+func (c *Checker) serve(w http.ResponseWriter, report Report) {
+	w.Header().Set("Content-Type", "application/json")
+	if !report.OK {
+		logrus.WithField("checks", report.Checks).Warn("health check failed")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		logrus.WithError(err).Debug("failed to write the health report")
+	}
+}
+
+// Cached caches the result of the check for the given duration, for checks which call rate limited APIs
+func Cached(check Check, ttl time.Duration) Check {
+	var lock sync.Mutex
+	var checked time.Time
+	var last error
+	return func() error {
+		lock.Lock()
+		defer lock.Unlock()
+		if !checked.IsZero() && time.Since(checked) < ttl {
+			return last
+		}
+		last = check()
+		checked = time.Now()
+		return last
+	}
+}
+
+// Kubernetes checks the Kubernetes API server can be reached
+func Kubernetes(client kubernetes.Interface) Check {
+	return func() error {
+		_, err := client.Discovery().ServerVersion()
+		return errors.Wrap(err, "getting the version of the Kubernetes API server")
+	}
+}
+
+// Reachable checks a server answers at the URL, which is the case as long as it does not fail with a server
+// error. The requests are not authenticated so client errors such as 401 are expected.
+func Reachable(url string) Check {
+	client := &http.Client{Timeout: DefaultTimeout}
+	return func() error {
+		resp, err := client.Get(url)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= http.StatusInternalServerError {
+			return errors.Errorf("%s answered %s", url, resp.Status)
+		}
+		return nil
+	}
+}
+
+// Loaded checks a configuration was loaded
+func Loaded(what string, loaded func() bool) Check {
+	return func() error {
+		if !loaded() {
+			return errors.Errorf("the %s has not been loaded yet", what)
+		}
+		return nil
+	}
+}
+
+// Synced checks the caches of informers are synced
+func Synced(synced ...cache.InformerSynced) Check {
+	return func() error {
+		for _, s := range synced {
+			if !s() {
+				return errors.New("the informer caches are not synced yet")
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,74 @@
+package health
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestChecker(t *testing.T) {
+	loaded := false
+	checker := &Checker{Timeout: 100 * time.Millisecond}
+	checker.AddLiveness("kubernetes", Kubernetes(kubefake.NewSimpleClientset()))
+	checker.AddReadiness("config", Loaded("configuration", func() bool { return loaded }))
+	checker.AddReadiness("slow", func() error {
+		time.Sleep(time.Second)
+		return nil
+	})
+	mux := http.NewServeMux()
+	checker.Register(mux)
+
+	get := func(path string) (int, Report) {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		var report Report
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+		return w.Code, report
+	}
+
+	code, report := get(LivenessPath)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, Report{OK: true, Checks: []Result{{Name: "kubernetes", OK: true}}}, report)
+
+	code, report = get(ReadinessPath)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, Report{Checks: []Result{
+		{Name: "kubernetes", OK: true},
+		{Name: "config", Error: "the configuration has not been loaded yet"},
+		{Name: "slow", Error: "timed out after 100ms"},
+	}}, report)
+
+	loaded = true
+	report = checker.Readiness()
+	assert.True(t, report.Checks[1].OK)
+}
+
+func TestCached(t *testing.T) {
+	calls := 0
+	check := Cached(func() error {
+		calls++
+		return errors.New("unreachable")
+	}, time.Hour)
+	assert.EqualError(t, check(), "unreachable")
+	assert.EqualError(t, check(), "unreachable")
+	assert.Equal(t, 1, calls)
+}
+
+func TestReachable(t *testing.T) {
+	status := http.StatusUnauthorized
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	assert.NoError(t, Reachable(server.URL)(), "client errors mean the server is reachable")
+	status = http.StatusBadGateway
+	assert.Error(t, Reachable(server.URL)())
+}
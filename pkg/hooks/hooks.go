@@ -0,0 +1,427 @@
+// Package hooks registers the webhooks of the repositories lighthouse is configured for, keeping them
+// pointing at the hook endpoint with the right events and secret.
+package hooks
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/gitprovider"
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// MissingDrift is reported for configured repositories without a webhook
+	MissingDrift = "missing"
+	// OutdatedDrift is reported for webhooks which are inactive or lack some of the events
+	OutdatedDrift = "outdated"
+	// DuplicateDrift is reported for extra webhooks pointing at the hook endpoint
+	DuplicateDrift = "duplicate"
+	// OrphanedDrift is reported for webhooks of repositories which are no longer configured
+	OrphanedDrift = "orphaned"
+
+	pageSize = 100
+)
+
+// DefaultGitHubEvents are the GitHub events the webhook subscribes to
+var DefaultGitHubEvents = []string{
+	"create",
+	"delete",
+	"issue_comment",
+	"issues",
+	"pull_request",
+	"pull_request_review",
+	"pull_request_review_comment",
+	"push",
+	"status",
+}
+
+// AllEvents subscribes to every event go-scm can abstract across providers
+var AllEvents = scm.HookEvents{
+	Branch:             true,
+	Issue:              true,
+	IssueComment:       true,
+	PullRequest:        true,
+	PullRequestComment: true,
+	Push:               true,
+	ReviewComment:      true,
+	Tag:                true,
+}
+
+var webhookDrift = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "lighthouse_webhook_drift",
+	Help: "The number of webhooks which differed from the desired state in the last reconciliation, by kind of drift.",
+}, []string{"kind"})
+
+func init() {
+	prometheus.MustRegister(webhookDrift)
+}
+
+// RepositoryClient is the subset of the SCM repository service used to manage webhooks
+type RepositoryClient interface {
+	ListOrganisation(ctx context.Context, org string, opts scm.ListOptions) ([]*scm.Repository, *scm.Response, error)
+	ListHooks(ctx context.Context, repo string, opts scm.ListOptions) ([]*scm.Hook, *scm.Response, error)
+	CreateHook(ctx context.Context, repo string, input *scm.HookInput) (*scm.Hook, *scm.Response, error)
+	DeleteHook(ctx context.Context, repo string, id string) (*scm.Response, error)
+}
+
+// Options describe the desired webhooks
+type Options struct {
+	// Target is the URL of the hook endpoint
+	Target string
+	// Events are the provider independent events to subscribe to
+	Events scm.HookEvents
+	// NativeEvents are provider specific events to subscribe to. As webhooks report the events they
+	// subscribe to in the provider's terms, only these are checked for drift.
+	NativeEvents []string
+	// Prune removes the webhooks of repositories which are no longer configured in the orgs lighthouse is used in
+	Prune bool
+	// DryRun only reports drift without changing any webhook
+	DryRun bool
+}
+
+// ProviderOptions returns the options of the webhooks of the provider, which subscribe to its native events on
+// GitHub. The webhooks of a provider other than the default one are delivered to its own sub-path of the hook URL.
+func ProviderOptions(provider *gitprovider.Provider, hookURL string) Options {
+	options := Options{Target: hookURL}
+	if provider.Name != "" {
+		options.Target = strings.TrimSuffix(hookURL, "/") + "/" + provider.Name
+	}
+	if provider.Kind() == "github" {
+		options.NativeEvents = DefaultGitHubEvents
+	} else {
+		options.Events = AllEvents
+	}
+	return options
+}
+
+// ProviderSecret returns the secret of the webhooks of the provider, which is its newest HMAC token
+func ProviderSecret(provider *gitprovider.Provider) func() (string, error) {
+	return func() (string, error) {
+		tokens, err := provider.HMACTokens()
+		if err != nil || len(tokens) == 0 {
+			return "", err
+		}
+		return tokens[0], nil
+	}
+}
+
+// Drift is a difference between the webhooks of a repository and the desired state
+type Drift struct {
+	Repo   string
+	Kind   string
+	HookID string
+}
+
+// Reconciler creates, recreates and removes webhooks until they match the configuration
+type Reconciler struct {
+	scmClients func(owner string) (RepositoryClient, error)
+	secret     func() (string, error)
+	options    Options
+	logger     *logrus.Entry
+}
+
+// NewReconciler creates a reconciler signing the webhooks it creates with the secret
+func NewReconciler(scmClients func(owner string) (RepositoryClient, error), secret func() (string, error), options Options, logger *logrus.Entry) *Reconciler {
+	if logger == nil {
+		logger = logrus.NewEntry(logrus.StandardLogger())
+	}
+	return &Reconciler{
+		scmClients: scmClients,
+		secret:     secret,
+		options:    options,
+		logger:     logger.WithField("controller", "webhooks"),
+	}
+}
+
+// ConfiguredRepositories returns the orgs and org/repo names referenced by the job, keeper and plugin
+// configuration, sorted by name
+func ConfiguredRepositories(cfg *config.Config, pluginCfg *plugins.Configuration) (orgs, repos []string) {
+	orgSet := map[string]bool{}
+	repoSet := map[string]bool{}
+	add := func(name string) {
+		if name == "" || name == "*" {
+			return
+		}
+		if strings.Contains(name, "/") {
+			repoSet[name] = true
+		} else {
+			orgSet[name] = true
+		}
+	}
+	if cfg != nil {
+		for name := range cfg.Presubmits {
+			add(name)
+		}
+		for name := range cfg.Postsubmits {
+			add(name)
+		}
+		for _, query := range cfg.Keeper.Queries {
+			for _, org := range query.Orgs {
+				add(org)
+			}
+			for _, repo := range query.Repos {
+				add(repo)
+			}
+		}
+	}
+	if pluginCfg != nil {
+		for name := range pluginCfg.Plugins {
+			add(name)
+		}
+		for name := range pluginCfg.ExternalPlugins {
+			add(name)
+		}
+	}
+	return sortedKeys(orgSet), sortedKeys(repoSet)
+}
+
+// Reconcile makes the webhooks of the repositories, and of all the repositories of the orgs, match the
+// desired state, returning the drift it found
+func (r *Reconciler) Reconcile(orgs, repos []string) ([]Drift, error) {
+	desired := map[string]bool{}
+	for _, repo := range repos {
+		desired[repo] = true
+	}
+	listed := map[string][]string{}
+	for _, org := range orgs {
+		names, err := r.listOrg(org)
+		if err != nil {
+			return nil, err
+		}
+		listed[org] = names
+		for _, name := range names {
+			desired[name] = true
+		}
+	}
+
+	var drifts []Drift
+	var errs []string
+	for _, repo := range sortedKeys(desired) {
+		found, err := r.reconcileRepo(repo)
+		drifts = append(drifts, found...)
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if r.options.Prune {
+		for _, repo := range repos {
+			org := strings.SplitN(repo, "/", 2)[0]
+			if _, ok := listed[org]; ok {
+				continue
+			}
+			names, err := r.listOrg(org)
+			if err != nil {
+				// the owner may be a user rather than an org
+				r.logger.WithError(err).Debugf("cannot list the repositories of %s to prune webhooks", org)
+			}
+			listed[org] = names
+		}
+		for _, org := range sortedKeys(toSet(listed)) {
+			for _, repo := range listed[org] {
+				if desired[repo] {
+					continue
+				}
+				found, err := r.pruneRepo(repo)
+				drifts = append(drifts, found...)
+				if err != nil {
+					errs = append(errs, err.Error())
+				}
+			}
+		}
+	}
+
+	counts := map[string]float64{MissingDrift: 0, OutdatedDrift: 0, DuplicateDrift: 0, OrphanedDrift: 0}
+	for _, drift := range drifts {
+		counts[drift.Kind]++
+	}
+	for kind, count := range counts {
+		webhookDrift.WithLabelValues(kind).Set(count)
+	}
+	if len(errs) > 0 {
+		return drifts, errors.Errorf("failed to reconcile webhooks: %s", strings.Join(errs, "; "))
+	}
+	return drifts, nil
+}
+
+func (r *Reconciler) reconcileRepo(repo string) ([]Drift, error) {
+	client, err := r.clientFor(repo)
+	if err != nil {
+		return nil, err
+	}
+	hooks, err := listHooks(client, repo)
+	if err != nil {
+		return nil, err
+	}
+	var drifts []Drift
+	var current *scm.Hook
+	for _, hook := range hooks {
+		if !sameTarget(hook.Target, r.options.Target) {
+			continue
+		}
+		if current == nil {
+			current = hook
+			continue
+		}
+		drifts = append(drifts, Drift{Repo: repo, Kind: DuplicateDrift, HookID: hook.ID})
+	}
+	switch {
+	case current == nil:
+		drifts = append(drifts, Drift{Repo: repo, Kind: MissingDrift})
+	case r.outdated(current):
+		drifts = append(drifts, Drift{Repo: repo, Kind: OutdatedDrift, HookID: current.ID})
+	}
+
+	for _, drift := range drifts {
+		l := r.logger.WithFields(logrus.Fields{"repo": repo, "drift": drift.Kind, "hook": drift.HookID})
+		l.Info("webhook drift detected")
+		if r.options.DryRun {
+			continue
+		}
+		if drift.HookID != "" {
+			if _, err := client.DeleteHook(context.Background(), repo, drift.HookID); err != nil {
+				return drifts, errors.Wrapf(err, "deleting webhook %s of %s", drift.HookID, repo)
+			}
+		}
+		if drift.Kind != DuplicateDrift {
+			if err := r.createHook(client, repo); err != nil {
+				return drifts, err
+			}
+			l.Info("registered webhook")
+		}
+	}
+	return drifts, nil
+}
+
+func (r *Reconciler) pruneRepo(repo string) ([]Drift, error) {
+	client, err := r.clientFor(repo)
+	if err != nil {
+		return nil, err
+	}
+	hooks, err := listHooks(client, repo)
+	if err != nil {
+		return nil, err
+	}
+	var drifts []Drift
+	for _, hook := range hooks {
+		if !sameTarget(hook.Target, r.options.Target) {
+			continue
+		}
+		drifts = append(drifts, Drift{Repo: repo, Kind: OrphanedDrift, HookID: hook.ID})
+		r.logger.WithFields(logrus.Fields{"repo": repo, "drift": OrphanedDrift, "hook": hook.ID}).Info("webhook drift detected")
+		if r.options.DryRun {
+			continue
+		}
+		if _, err := client.DeleteHook(context.Background(), repo, hook.ID); err != nil {
+			return drifts, errors.Wrapf(err, "deleting webhook %s of %s", hook.ID, repo)
+		}
+	}
+	return drifts, nil
+}
+
+func (r *Reconciler) createHook(client RepositoryClient, repo string) error {
+	secret, err := r.secret()
+	if err != nil {
+		return errors.Wrap(err, "loading the webhook secret")
+	}
+	input := &scm.HookInput{
+		Name:         "lighthouse",
+		Target:       r.options.Target,
+		Secret:       secret,
+		Events:       r.options.Events,
+		NativeEvents: r.options.NativeEvents,
+	}
+	if _, _, err := client.CreateHook(context.Background(), repo, input); err != nil {
+		return errors.Wrapf(err, "creating webhook for %s", repo)
+	}
+	return nil
+}
+
+// outdated returns true if the hook is inactive or does not subscribe to the native events
+func (r *Reconciler) outdated(hook *scm.Hook) bool {
+	if !hook.Active {
+		return true
+	}
+	events := map[string]bool{}
+	for _, event := range hook.Events {
+		events[event] = true
+	}
+	for _, event := range r.options.NativeEvents {
+		if !events[event] {
+			return true
+		}
+	}
+	return false
+}
+
+// clientFor returns the client for the owner of the repository
+func (r *Reconciler) clientFor(repo string) (RepositoryClient, error) {
+	owner := strings.SplitN(repo, "/", 2)[0]
+	client, err := r.scmClients(owner)
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating SCM client for %s", owner)
+	}
+	return client, nil
+}
+
+func (r *Reconciler) listOrg(org string) ([]string, error) {
+	client, err := r.clientFor(org)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for page := 1; ; page++ {
+		repos, _, err := client.ListOrganisation(context.Background(), org, scm.ListOptions{Page: page, Size: pageSize})
+		if err != nil {
+			return nil, errors.Wrapf(err, "listing the repositories of %s", org)
+		}
+		for _, repo := range repos {
+			names = append(names, scm.Join(repo.Namespace, repo.Name))
+		}
+		if len(repos) < pageSize {
+			return names, nil
+		}
+	}
+}
+
+func listHooks(client RepositoryClient, repo string) ([]*scm.Hook, error) {
+	var answer []*scm.Hook
+	for page := 1; ; page++ {
+		hooks, _, err := client.ListHooks(context.Background(), repo, scm.ListOptions{Page: page, Size: pageSize})
+		if err != nil {
+			return nil, errors.Wrapf(err, "listing the webhooks of %s", repo)
+		}
+		answer = append(answer, hooks...)
+		if len(hooks) < pageSize {
+			return answer, nil
+		}
+	}
+}
+
+func sameTarget(a, b string) bool {
+	return strings.TrimSuffix(a, "/") == strings.TrimSuffix(b, "/")
+}
+
+func toSet(m map[string][]string) map[string]bool {
+	answer := map[string]bool{}
+	for k := range m {
+		answer[k] = true
+	}
+	return answer
+}
+
+func sortedKeys(m map[string]bool) []string {
+	var answer []string
+	for k := range m {
+		answer = append(answer, k)
+	}
+	sort.Strings(answer)
+	return answer
+}
@@ -0,0 +1,131 @@
+package hooks
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const target = "https://hook.example.com/hook"
+
+type fakeRepositories struct {
+	orgs    map[string][]string
+	hooks   map[string][]*scm.Hook
+	created map[string]*scm.HookInput
+	deleted []string
+	nextID  int
+}
+
+func (f *fakeRepositories) ListOrganisation(_ context.Context, org string, _ scm.ListOptions) ([]*scm.Repository, *scm.Response, error) {
+	names, ok := f.orgs[org]
+	if !ok {
+		return nil, nil, fmt.Errorf("no org %s", org)
+	}
+	var answer []*scm.Repository
+	for _, name := range names {
+		answer = append(answer, &scm.Repository{Namespace: org, Name: name})
+	}
+	return answer, nil, nil
+}
+
+func (f *fakeRepositories) ListHooks(_ context.Context, repo string, _ scm.ListOptions) ([]*scm.Hook, *scm.Response, error) {
+	return f.hooks[repo], nil, nil
+}
+
+func (f *fakeRepositories) CreateHook(_ context.Context, repo string, input *scm.HookInput) (*scm.Hook, *scm.Response, error) {
+	f.nextID++
+	f.created[repo] = input
+	hook := &scm.Hook{ID: fmt.Sprintf("new-%d", f.nextID), Target: input.Target, Events: input.NativeEvents, Active: true}
+	f.hooks[repo] = append(f.hooks[repo], hook)
+	return hook, nil, nil
+}
+
+func (f *fakeRepositories) DeleteHook(_ context.Context, repo string, id string) (*scm.Response, error) {
+	f.deleted = append(f.deleted, repo+":"+id)
+	return nil, nil
+}
+
+func newFake() *fakeRepositories {
+	return &fakeRepositories{
+		orgs: map[string][]string{
+			"org":   {"a", "b"},
+			"other": {"configured", "removed"},
+		},
+		hooks: map[string][]*scm.Hook{
+			"org/a": {
+				{ID: "1", Target: target + "/", Events: DefaultGitHubEvents, Active: true},
+				{ID: "2", Target: "https://ci.example.com/hook", Active: true},
+			},
+			"org/b": {
+				{ID: "3", Target: target, Events: []string{"push"}, Active: true},
+				{ID: "4", Target: target, Events: DefaultGitHubEvents, Active: true},
+			},
+			"other/removed": {
+				{ID: "5", Target: target, Active: true},
+			},
+		},
+		created: map[string]*scm.HookInput{},
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	fake := newFake()
+	secret := func() (string, error) { return "hmac", nil }
+	options := Options{Target: target, NativeEvents: DefaultGitHubEvents, Prune: true}
+	r := NewReconciler(func(string) (RepositoryClient, error) { return fake, nil }, secret, options, nil)
+
+	drifts, err := r.Reconcile([]string{"org"}, []string{"other/configured"})
+	require.NoError(t, err)
+	assert.Equal(t, []Drift{
+		{Repo: "org/b", Kind: DuplicateDrift, HookID: "4"},
+		{Repo: "org/b", Kind: OutdatedDrift, HookID: "3"},
+		{Repo: "other/configured", Kind: MissingDrift},
+		{Repo: "other/removed", Kind: OrphanedDrift, HookID: "5"},
+	}, drifts)
+	assert.Equal(t, []string{"org/b:4", "org/b:3", "other/removed:5"}, fake.deleted)
+
+	require.Len(t, fake.created, 2)
+	for _, repo := range []string{"org/b", "other/configured"} {
+		input := fake.created[repo]
+		require.NotNil(t, input, repo)
+		assert.Equal(t, target, input.Target)
+		assert.Equal(t, "hmac", input.Secret)
+		assert.Equal(t, DefaultGitHubEvents, input.NativeEvents)
+	}
+}
+
+func TestReconcileDryRun(t *testing.T) {
+	fake := newFake()
+	options := Options{Target: target, NativeEvents: DefaultGitHubEvents, Prune: true, DryRun: true}
+	r := NewReconciler(func(string) (RepositoryClient, error) { return fake, nil }, nil, options, nil)
+
+	drifts, err := r.Reconcile([]string{"org"}, []string{"other/configured"})
+	require.NoError(t, err)
+	assert.Len(t, drifts, 4)
+	assert.Empty(t, fake.deleted)
+	assert.Empty(t, fake.created)
+}
+
+func TestConfiguredRepositories(t *testing.T) {
+	cfg := &config.Config{
+		JobConfig: config.JobConfig{
+			Presubmits:  map[string][]config.Presubmit{"org/a": nil},
+			Postsubmits: map[string][]config.Postsubmit{"org/b": nil},
+		},
+	}
+	cfg.Keeper.Queries = config.KeeperQueries{{Orgs: []string{"keeper-org"}, Repos: []string{"org/c"}}}
+	pluginCfg := &plugins.Configuration{
+		Plugins:         map[string][]string{"plugin-org": {"lgtm"}, "org/a": {"lgtm"}},
+		ExternalPlugins: map[string][]plugins.ExternalPlugin{"org/d": nil},
+	}
+
+	orgs, repos := ConfiguredRepositories(cfg, pluginCfg)
+	assert.Equal(t, []string{"keeper-org", "plugin-org"}, orgs)
+	assert.Equal(t, []string{"org/a", "org/b", "org/c", "org/d"}, repos)
+}
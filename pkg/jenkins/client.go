@@ -0,0 +1,171 @@
+// Package jenkins contains a client for triggering and following builds on a Jenkins server
+// through its REST API, and the controller which syncs the builds back to LighthouseJobs.
+package jenkins
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// URLEnv is the environment variable containing the URL of the Jenkins server
+	URLEnv = "JENKINS_URL"
+	// UserEnv is the environment variable containing the user to authenticate to Jenkins as
+	UserEnv = "JENKINS_USER"
+	// TokenEnv is the environment variable containing the API token of the Jenkins user
+	TokenEnv = "JENKINS_TOKEN" // #nosec
+)
+
+// Build results reported by Jenkins
+const (
+	ResultSuccess  = "SUCCESS"
+	ResultUnstable = "UNSTABLE"
+	ResultFailure  = "FAILURE"
+	ResultNotBuilt = "NOT_BUILT"
+	ResultAborted  = "ABORTED"
+)
+
+// QueueItem is a build waiting in the Jenkins queue
+type QueueItem struct {
+	Cancelled  bool        `json:"cancelled"`
+	Why        string      `json:"why"`
+	Executable *Executable `json:"executable"`
+}
+
+// Executable identifies the build started for a queue item
+type Executable struct {
+	Number int    `json:"number"`
+	URL    string `json:"url"`
+}
+
+// Build is the status of a Jenkins build
+type Build struct {
+	Number   int    `json:"number"`
+	URL      string `json:"url"`
+	Building bool   `json:"building"`
+	Result   string `json:"result"`
+}
+
+// Client triggers and inspects Jenkins builds
+type Client interface {
+	// Build triggers the job with the given parameters, returning the URL of the queue item
+	Build(jobPath string, params map[string]string) (string, error)
+	// GetQueueItem returns the queue item at the given URL
+	GetQueueItem(queueURL string) (*QueueItem, error)
+	// GetBuild returns the build at the given URL
+	GetBuild(buildURL string) (*Build, error)
+}
+
+type client struct {
+	baseURL    string
+	user       string
+	token      string
+	httpClient *http.Client
+}
+
+// NewClient creates a client for the Jenkins server at baseURL, authenticating with the user's API token
+func NewClient(baseURL, user, token string) Client {
+	return &client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		user:       user,
+		token:      token,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// NewClientFromEnv creates a client from the JENKINS_URL, JENKINS_USER and JENKINS_TOKEN environment
+// variables, returning nil if no Jenkins server is configured.
+func NewClientFromEnv() Client {
+	baseURL := os.Getenv(URLEnv)
+	if baseURL == "" {
+		return nil
+	}
+	return NewClient(baseURL, os.Getenv(UserEnv), os.Getenv(TokenEnv))
+}
+
+// jobURL returns the URL of the job with the given path, where folders are separated by '/'
+func (c *client) jobURL(jobPath string) string {
+	var parts []string
+	for _, name := range strings.Split(strings.Trim(jobPath, "/"), "/") {
+		parts = append(parts, "job", url.PathEscape(name))
+	}
+	return c.baseURL + "/" + strings.Join(parts, "/")
+}
+
+func (c *client) Build(jobPath string, params map[string]string) (string, error) {
+	values := url.Values{}
+	for k, v := range params {
+		values.Set(k, v)
+	}
+	req, err := http.NewRequest(http.MethodPost, c.jobURL(jobPath)+"/buildWithParameters", strings.NewReader(values.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := c.do(req)
+	if err != nil {
+		return "", errors.Wrapf(err, "triggering Jenkins job %s", jobPath)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("triggering Jenkins job %s returned status %d", jobPath, resp.StatusCode)
+	}
+	location := resp.Header.Get("Location")
+	if location == "" {
+		return "", fmt.Errorf("triggering Jenkins job %s did not return a queue item", jobPath)
+	}
+	return location, nil
+}
+
+func (c *client) GetQueueItem(queueURL string) (*QueueItem, error) {
+	item := &QueueItem{}
+	if err := c.getJSON(queueURL, item); err != nil {
+		return nil, errors.Wrapf(err, "getting Jenkins queue item %s", queueURL)
+	}
+	return item, nil
+}
+
+func (c *client) GetBuild(buildURL string) (*Build, error) {
+	build := &Build{}
+	if err := c.getJSON(buildURL, build); err != nil {
+		return nil, errors.Wrapf(err, "getting Jenkins build %s", buildURL)
+	}
+	return build, nil
+}
+
+func (c *client) getJSON(resourceURL string, into interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, strings.TrimRight(resourceURL, "/")+"/api/json", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %d: %s", resp.StatusCode, string(body))
+	}
+	return json.Unmarshal(body, into)
+}
+
+func (c *client) do(req *http.Request) (*http.Response, error) {
+	if c.user != "" {
+		req.SetBasicAuth(c.user, c.token)
+	}
+	return c.httpClient.Do(req)
+}
@@ -0,0 +1,59 @@
+package jenkins
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, token, ok := r.BasicAuth()
+		if !ok || user != "bot" || token != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/job/folder/job/my-job/buildWithParameters":
+			assert.Equal(t, http.MethodPost, r.Method)
+			require.NoError(t, r.ParseForm())
+			assert.Equal(t, "some-job", r.PostForm.Get("JOB_NAME"))
+			w.Header().Set("Location", server.URL+"/queue/item/12/")
+			w.WriteHeader(http.StatusCreated)
+		case "/queue/item/12/api/json":
+			fmt.Fprintf(w, `{"cancelled": false, "executable": {"number": 3, "url": "%s/job/folder/job/my-job/3/"}}`, server.URL)
+		case "/job/folder/job/my-job/3/api/json":
+			fmt.Fprintf(w, `{"number": 3, "url": "%s/job/folder/job/my-job/3/", "building": false, "result": "SUCCESS"}`, server.URL)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	c := NewClient(server.URL+"/", "bot", "secret")
+
+	queueURL, err := c.Build("folder/my-job", map[string]string{"JOB_NAME": "some-job"})
+	require.NoError(t, err)
+	assert.Equal(t, server.URL+"/queue/item/12/", queueURL)
+
+	item, err := c.GetQueueItem(queueURL)
+	require.NoError(t, err)
+	require.NotNil(t, item.Executable)
+	assert.Equal(t, 3, item.Executable.Number)
+
+	build, err := c.GetBuild(item.Executable.URL)
+	require.NoError(t, err)
+	assert.False(t, build.Building)
+	assert.Equal(t, ResultSuccess, build.Result)
+
+	_, err = c.Build("missing", nil)
+	assert.Error(t, err)
+
+	_, err = NewClient(server.URL, "bot", "wrong").GetBuild(item.Executable.URL)
+	assert.Error(t, err)
+}
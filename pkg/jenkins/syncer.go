@@ -0,0 +1,232 @@
+package jenkins
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	clientset "github.com/jenkins-x/lighthouse/pkg/client/clientset/versioned"
+	"github.com/jenkins-x/lighthouse/pkg/slo"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// StatusClient is the subset of the SCM client used to report build statuses
+type StatusClient interface {
+	CreateStatus(org, repo, ref string, s *scm.StatusInput) (*scm.Status, error)
+}
+
+// Syncer polls Jenkins for the builds of LighthouseJobs run by the Jenkins agent, updates the jobs'
+// status and reports it to the SCM provider with a link to the Jenkins build.
+type Syncer struct {
+	jenkins    Client
+	lhClient   clientset.Interface
+	scmClients func(job *v1alpha1.LighthouseJob) (StatusClient, error)
+	namespace  string
+	logger     *logrus.Entry
+
+	now func() time.Time
+}
+
+// NewSyncer creates a new syncer for the Jenkins LighthouseJobs in the given namespace
+func NewSyncer(jenkins Client, lhClient clientset.Interface, scmClients func(job *v1alpha1.LighthouseJob) (StatusClient, error), namespace string, logger *logrus.Entry) *Syncer {
+	if logger == nil {
+		logger = logrus.NewEntry(logrus.StandardLogger())
+	}
+	return &Syncer{
+		jenkins:    jenkins,
+		lhClient:   lhClient,
+		scmClients: scmClients,
+		namespace:  namespace,
+		logger:     logger.WithField("controller", "jenkins-syncer"),
+		now:        time.Now,
+	}
+}
+
+// Sync updates every Jenkins LighthouseJob which has not completed yet
+func (s *Syncer) Sync() error {
+	jobList, err := s.lhClient.LighthouseV1alpha1().LighthouseJobs(s.namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "listing LighthouseJobs in namespace %s", s.namespace)
+	}
+	for i := range jobList.Items {
+		job := &jobList.Items[i]
+		if job.Spec.Agent != v1alpha1.JenkinsAgent {
+			continue
+		}
+		if job.Status.CompletionTime != nil {
+			if job.Status.ReportFailed() {
+				if err := s.retryReport(job); err != nil {
+					s.logger.WithError(err).Warnf("failed to report LighthouseJob %s", job.Name)
+				}
+			}
+			continue
+		}
+		if err := s.syncJob(job); err != nil {
+			s.logger.WithError(err).Warnf("failed to sync LighthouseJob %s", job.Name)
+		}
+	}
+	return nil
+}
+
+func (s *Syncer) syncJob(job *v1alpha1.LighthouseJob) error {
+	jobCopy := job.DeepCopy()
+
+	buildURL := jobCopy.Status.ReportURL
+	if buildURL == "" {
+		queueURL := jobCopy.Annotations[util.JenkinsQueueURLAnnotation]
+		if queueURL == "" {
+			return fmt.Errorf("no Jenkins queue item recorded")
+		}
+		item, err := s.jenkins.GetQueueItem(queueURL)
+		if err != nil {
+			return err
+		}
+		switch {
+		case item.Cancelled:
+			s.updateState(jobCopy, v1alpha1.AbortedState, "Jenkins build was cancelled")
+		case item.Executable != nil:
+			buildURL = item.Executable.URL
+			jobCopy.Status.ReportURL = buildURL
+			if jobCopy.Labels == nil {
+				jobCopy.Labels = map[string]string{}
+			}
+			jobCopy.Labels[util.BuildNumLabel] = strconv.Itoa(item.Executable.Number)
+		default:
+			s.updateState(jobCopy, v1alpha1.PendingState, "Jenkins build queued")
+		}
+	}
+
+	if buildURL != "" && jobCopy.Status.CompletionTime == nil {
+		build, err := s.jenkins.GetBuild(buildURL)
+		if err != nil {
+			return err
+		}
+		state, description := ToPipelineState(build)
+		s.updateState(jobCopy, state, description)
+	}
+
+	if jobCopy.Labels[util.BuildNumLabel] != job.Labels[util.BuildNumLabel] {
+		updated, err := s.lhClient.LighthouseV1alpha1().LighthouseJobs(jobCopy.Namespace).Update(jobCopy)
+		if err != nil {
+			return errors.Wrapf(err, "updating labels of LighthouseJob %s", job.Name)
+		}
+		updated.Status = jobCopy.Status
+		jobCopy = updated
+	}
+	if !reflect.DeepEqual(jobCopy.Status, job.Status) {
+		if _, err := s.lhClient.LighthouseV1alpha1().LighthouseJobs(jobCopy.Namespace).UpdateStatus(jobCopy); err != nil {
+			return errors.Wrapf(err, "updating status of LighthouseJob %s", job.Name)
+		}
+	}
+	return nil
+}
+
+// ToPipelineState converts the state of a Jenkins build into a LighthouseJob state and description
+func ToPipelineState(build *Build) (v1alpha1.PipelineState, string) {
+	if build.Building {
+		return v1alpha1.RunningState, "Jenkins build running"
+	}
+	switch build.Result {
+	case ResultSuccess:
+		return v1alpha1.SuccessState, "Jenkins build successful"
+	case ResultFailure, ResultUnstable:
+		return v1alpha1.FailureState, fmt.Sprintf("Jenkins build %s", resultDescription(build.Result))
+	case ResultAborted, ResultNotBuilt:
+		return v1alpha1.AbortedState, "Jenkins build aborted"
+	case "":
+		// the build has started but Jenkins has not begun running it yet
+		return v1alpha1.PendingState, "Jenkins build pending"
+	default:
+		return v1alpha1.FailureState, fmt.Sprintf("Jenkins build finished with result %s", build.Result)
+	}
+}
+
+func resultDescription(result string) string {
+	if result == ResultUnstable {
+		return "unstable"
+	}
+	return "failed"
+}
+
+// updateState updates the job's state, completing it and reporting to the SCM provider if the state changed
+func (s *Syncer) updateState(job *v1alpha1.LighthouseJob, state v1alpha1.PipelineState, description string) {
+	if job.Status.State == state && job.Status.Description == description && !job.Status.ReportFailed() {
+		return
+	}
+	job.Status.State = state
+	job.Status.Description = description
+	switch state {
+	case v1alpha1.SuccessState, v1alpha1.FailureState, v1alpha1.AbortedState:
+		if job.Status.CompletionTime == nil {
+			now := metav1.NewTime(s.now())
+			job.Status.CompletionTime = &now
+		}
+	}
+	job.SetStateConditions()
+	if scmState := toSCMState(state); s.report(job, scmState, description) {
+		job.Status.LastReportState = scmState.String()
+	}
+}
+
+func toSCMState(state v1alpha1.PipelineState) scm.State {
+	switch state {
+	case v1alpha1.SuccessState:
+		return scm.StateSuccess
+	case v1alpha1.FailureState:
+		return scm.StateFailure
+	case v1alpha1.AbortedState:
+		return scm.StateCanceled
+	case v1alpha1.RunningState:
+		return scm.StateRunning
+	default:
+		return scm.StatePending
+	}
+}
+
+// report sets the status of the job's commit, returning whether the status was created
+func (s *Syncer) report(job *v1alpha1.LighthouseJob, state scm.State, description string) bool {
+	sha := job.Spec.GetSHA()
+	if s.scmClients == nil || job.Spec.Refs == nil || sha == "" || job.Spec.Context == "" {
+		return false
+	}
+	l := s.logger.WithField("job", job.Name)
+	scmClient, err := s.scmClients(job)
+	if err != nil {
+		l.WithError(err).Warn("failed to create SCM client")
+		job.SetReported(state.String(), err)
+		return false
+	}
+	status := &scm.StatusInput{
+		State:  state,
+		Label:  job.Spec.Context,
+		Desc:   description,
+		Target: job.Status.ReportURL,
+	}
+	if _, err := scmClient.CreateStatus(job.Spec.Refs.Org, job.Spec.Refs.Repo, sha, status); err != nil {
+		l.WithError(err).Warn("failed to report Jenkins build status")
+		job.SetReported(state.String(), err)
+		return false
+	}
+	slo.JobReported(l, job, state.String(), s.now())
+	job.SetReported(state.String(), nil)
+	return true
+}
+
+// retryReport reports the final state of the completed job again, as its last report failed
+func (s *Syncer) retryReport(job *v1alpha1.LighthouseJob) error {
+	jobCopy := job.DeepCopy()
+	s.updateState(jobCopy, job.Status.State, job.Status.Description)
+	if reflect.DeepEqual(jobCopy.Status, job.Status) {
+		return nil
+	}
+	if _, err := s.lhClient.LighthouseV1alpha1().LighthouseJobs(jobCopy.Namespace).UpdateStatus(jobCopy); err != nil {
+		return errors.Wrapf(err, "updating status of LighthouseJob %s", job.Name)
+	}
+	return nil
+}
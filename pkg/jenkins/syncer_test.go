@@ -0,0 +1,158 @@
+package jenkins
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	lhfake "github.com/jenkins-x/lighthouse/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type fakeJenkins struct {
+	queue  map[string]*QueueItem
+	builds map[string]*Build
+}
+
+func (f *fakeJenkins) Build(jobPath string, params map[string]string) (string, error) {
+	return "", fmt.Errorf("not implemented")
+}
+
+func (f *fakeJenkins) GetQueueItem(queueURL string) (*QueueItem, error) {
+	if item, ok := f.queue[queueURL]; ok {
+		return item, nil
+	}
+	return nil, fmt.Errorf("no queue item %s", queueURL)
+}
+
+func (f *fakeJenkins) GetBuild(buildURL string) (*Build, error) {
+	if build, ok := f.builds[buildURL]; ok {
+		return build, nil
+	}
+	return nil, fmt.Errorf("no build %s", buildURL)
+}
+
+type fakeStatusClient struct {
+	statuses []*scm.StatusInput
+}
+
+func (f *fakeStatusClient) CreateStatus(org, repo, ref string, s *scm.StatusInput) (*scm.Status, error) {
+	f.statuses = append(f.statuses, s)
+	return &scm.Status{}, nil
+}
+
+func makeJob(name, queueURL, buildURL string) *v1alpha1.LighthouseJob {
+	return &v1alpha1.LighthouseJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   "jx",
+			Labels:      map[string]string{},
+			Annotations: map[string]string{util.JenkinsQueueURLAnnotation: queueURL},
+		},
+		Spec: v1alpha1.LighthouseJobSpec{
+			Type:    "presubmit",
+			Agent:   v1alpha1.JenkinsAgent,
+			Context: name,
+			Refs: &v1alpha1.Refs{
+				Org:   "org",
+				Repo:  "repo",
+				Pulls: []v1alpha1.Pull{{Number: 1, SHA: "head"}},
+			},
+		},
+		Status: v1alpha1.LighthouseJobStatus{
+			State:     v1alpha1.PendingState,
+			ReportURL: buildURL,
+		},
+	}
+}
+
+func TestSync(t *testing.T) {
+	jenkins := &fakeJenkins{
+		queue: map[string]*QueueItem{
+			"queue/1": {},
+			"queue/2": {Executable: &Executable{Number: 7, URL: "build/7"}},
+			"queue/3": {Cancelled: true},
+		},
+		builds: map[string]*Build{
+			"build/7": {Number: 7, Building: true},
+			"build/8": {Number: 8, Result: ResultFailure},
+		},
+	}
+	tekton := makeJob("tekton", "", "")
+	tekton.Spec.Agent = v1alpha1.TektonAgent
+	lhClient := lhfake.NewSimpleClientset(
+		makeJob("queued", "queue/1", ""),
+		makeJob("started", "queue/2", ""),
+		makeJob("cancelled", "queue/3", ""),
+		makeJob("failed", "queue/4", "build/8"),
+		tekton,
+	)
+	statusClient := &fakeStatusClient{}
+	scmClients := func(job *v1alpha1.LighthouseJob) (StatusClient, error) {
+		return statusClient, nil
+	}
+
+	s := NewSyncer(jenkins, lhClient, scmClients, "jx", nil)
+	now := time.Now()
+	s.now = func() time.Time { return now }
+	require.NoError(t, s.Sync())
+
+	expected := map[string]struct {
+		state       v1alpha1.PipelineState
+		description string
+		reportURL   string
+		buildNum    string
+		completed   bool
+	}{
+		"queued":    {state: v1alpha1.PendingState, description: "Jenkins build queued"},
+		"started":   {state: v1alpha1.RunningState, description: "Jenkins build running", reportURL: "build/7", buildNum: "7"},
+		"cancelled": {state: v1alpha1.AbortedState, description: "Jenkins build was cancelled", completed: true},
+		"failed":    {state: v1alpha1.FailureState, description: "Jenkins build failed", reportURL: "build/8", completed: true},
+		"tekton":    {state: v1alpha1.PendingState},
+	}
+	for name, e := range expected {
+		job, err := lhClient.LighthouseV1alpha1().LighthouseJobs("jx").Get(name, metav1.GetOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, e.state, job.Status.State, name)
+		assert.Equal(t, e.description, job.Status.Description, name)
+		assert.Equal(t, e.reportURL, job.Status.ReportURL, name)
+		assert.Equal(t, e.buildNum, job.Labels[util.BuildNumLabel], name)
+		assert.Equal(t, e.completed, job.Status.CompletionTime != nil, name)
+	}
+
+	require.Len(t, statusClient.statuses, 4)
+	for _, status := range statusClient.statuses {
+		if status.Label == "started" {
+			assert.Equal(t, scm.StateRunning, status.State)
+			assert.Equal(t, "build/7", status.Target)
+		}
+		if status.Label == "failed" {
+			assert.Equal(t, scm.StateFailure, status.State)
+			assert.Equal(t, "build/8", status.Target)
+		}
+	}
+}
+
+func TestToPipelineState(t *testing.T) {
+	tests := []struct {
+		build *Build
+		state v1alpha1.PipelineState
+	}{
+		{build: &Build{Building: true}, state: v1alpha1.RunningState},
+		{build: &Build{Result: ResultSuccess}, state: v1alpha1.SuccessState},
+		{build: &Build{Result: ResultFailure}, state: v1alpha1.FailureState},
+		{build: &Build{Result: ResultUnstable}, state: v1alpha1.FailureState},
+		{build: &Build{Result: ResultAborted}, state: v1alpha1.AbortedState},
+		{build: &Build{Result: ResultNotBuilt}, state: v1alpha1.AbortedState},
+		{build: &Build{}, state: v1alpha1.PendingState},
+	}
+	for _, tc := range tests {
+		state, _ := ToPipelineState(tc.build)
+		assert.Equal(t, tc.state, state, "result %q", tc.build.Result)
+	}
+}
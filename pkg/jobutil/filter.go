@@ -18,21 +18,30 @@ package jobutil
 
 import (
 	"regexp"
+	"strings"
 
+	"github.com/jenkins-x/lighthouse/pkg/util"
 	"github.com/sirupsen/logrus"
 
 	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/requiredjobs"
 	"k8s.io/apimachinery/pkg/util/sets"
 )
 
 // TestAllRe provides the regex for `/test all`
-var TestAllRe = regexp.MustCompile(`(?m)^/(?:lh-)?test all,?($|\s.*)`)
+var TestAllRe = regexp.MustCompile(`(?m)^/` + util.CommandPrefixPattern + `test all,?($|\s.*)`)
 
 // RetestRe provides the regex for `/retest`
-var RetestRe = regexp.MustCompile(`(?m)^/(?:lh-)?retest\s*$`)
+var RetestRe = regexp.MustCompile(`(?m)^/` + util.CommandPrefixPattern + `retest\s*$`)
 
 // OkToTestRe provies the regex for `/ok-to-test`
-var OkToTestRe = regexp.MustCompile(`(?m)^/(?:lh-)?ok-to-test\s*$`)
+var OkToTestRe = regexp.MustCompile(`(?m)^/` + util.CommandPrefixPattern + `ok-to-test\s*$`)
+
+// AbortRe provides the regex for `/abort`, optionally followed by the names of the jobs to abort
+var AbortRe = regexp.MustCompile(`(?m)^/` + util.CommandPrefixPattern + `abort(?:[ \t]+([^\r\n]*?))?[ \t]*\r?$`)
+
+// TestTrustedRe provides the regex for `/test-trusted`
+var TestTrustedRe = regexp.MustCompile(`(?m)^/` + util.CommandPrefixPattern + `test-trusted\s*$`)
 
 // Filter digests a presubmit config to determine if:
 //  - we the presubmit matched the filter
@@ -44,7 +53,8 @@ type Filter func(p config.Presubmit) (shouldRun bool, forcedToRun bool, defaultB
 // CommandFilter builds a filter for `/test foo`
 func CommandFilter(body string) Filter {
 	return func(p config.Presubmit) (bool, bool, bool) {
-		return p.TriggerMatches(body), p.TriggerMatches(body), true
+		matches := p.TriggerMatches(util.TrimCommandPrefix(body))
+		return matches, matches, true
 	}
 }
 
@@ -70,6 +80,39 @@ func AggregateFilter(filters []Filter) Filter {
 	}
 }
 
+// RefMatches returns true if the branches and skip_branches of a postsubmit, favoring skip_branches, match the ref.
+// A ref such as refs/heads/main or refs/tags/v1.0.0 is matched both as it is and by its short name, main or v1.0.0,
+// so that the entries starting with refs/heads/ or refs/tags/ only match branches or tags while the other entries
+// match both.
+func RefMatches(br config.Brancher, ref string) bool {
+	if br.RunsAgainstAllBranch() {
+		return true
+	}
+	name := strings.TrimPrefix(strings.TrimPrefix(ref, "refs/heads/"), "refs/tags/")
+	matches := func(re *regexp.Regexp) bool {
+		return re != nil && (re.MatchString(name) || (name != ref && re.MatchString(ref)))
+	}
+	if len(br.SkipBranches) != 0 && matches(br.GetRESkip()) {
+		return false
+	}
+	return len(br.Branches) == 0 || matches(br.GetRE())
+}
+
+// PostsubmitShouldRun returns true if the postsubmit runs against a push of the ref changing the files, like
+// config.Postsubmit.ShouldRun but telling branches and tags apart with RefMatches
+func PostsubmitShouldRun(ps config.Postsubmit, ref string, changes config.ChangedFilesProvider) (bool, error) {
+	if !RefMatches(ps.Brancher, ref) {
+		return false, nil
+	}
+	if determined, shouldRun, err := ps.RegexpChangeMatcher.ShouldRun(changes); err != nil {
+		return false, err
+	} else if determined {
+		return shouldRun, nil
+	}
+	// Postsubmits default to always run
+	return true, nil
+}
+
 // FilterPresubmits determines which presubmits should run and which should be skipped
 // by evaluating the user-provided filter.
 func FilterPresubmits(filter Filter, changes config.ChangedFilesProvider, branch string, presubmits []config.Presubmit, logger *logrus.Entry) ([]config.Presubmit, []config.Presubmit, error) {
@@ -82,7 +125,7 @@ func FilterPresubmits(filter Filter, changes config.ChangedFilesProvider, branch
 		if !matches {
 			continue
 		}
-		shouldRun, err := presubmit.ShouldRun(branch, changes, forced, defaults)
+		shouldRun, err := requiredjobs.ShouldRun(presubmit, branch, changes, forced, defaults)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -859,3 +859,61 @@ func TestPresubmitFilter(t *testing.T) {
 		})
 	}
 }
+
+func TestRefMatches(t *testing.T) {
+	testCases := []struct {
+		name     string
+		brancher config.Brancher
+		ref      string
+		expected bool
+	}{
+		{
+			name:     "all refs",
+			ref:      "refs/tags/v1.0.0",
+			expected: true,
+		},
+		{
+			name:     "branch name",
+			brancher: config.Brancher{Branches: []string{"^main$"}},
+			ref:      "refs/heads/main",
+			expected: true,
+		},
+		{
+			name:     "short ref",
+			brancher: config.Brancher{Branches: []string{"^main$"}},
+			ref:      "main",
+			expected: true,
+		},
+		{
+			name:     "tag matched by its name",
+			brancher: config.Brancher{Branches: []string{`^v\d`}},
+			ref:      "refs/tags/v1.0.0",
+			expected: true,
+		},
+		{
+			name:     "tags only",
+			brancher: config.Brancher{Branches: []string{"^refs/tags/"}},
+			ref:      "refs/heads/v1.0.0",
+		},
+		{
+			name:     "tags skipped",
+			brancher: config.Brancher{SkipBranches: []string{"^refs/tags/"}},
+			ref:      "refs/tags/v1.0.0",
+		},
+		{
+			name:     "skip favored",
+			brancher: config.Brancher{Branches: []string{"^release-"}, SkipBranches: []string{"^release-0"}},
+			ref:      "refs/heads/release-0.9",
+		},
+		{
+			name:     "other branch",
+			brancher: config.Brancher{Branches: []string{"^release-"}},
+			ref:      "refs/heads/main",
+		},
+	}
+	for _, tc := range testCases {
+		if actual := RefMatches(tc.brancher, tc.ref); actual != tc.expected {
+			t.Errorf("%s: expected %t, got %t", tc.name, tc.expected, actual)
+		}
+	}
+}
@@ -19,10 +19,15 @@ package jobutil
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/jenkins-x/go-scm/scm"
 	"github.com/jenkins-x/lighthouse-config/pkg/config"
@@ -33,6 +38,7 @@ import (
 	"github.com/sirupsen/logrus"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/yaml"
 )
 
 // NewLighthouseJob initializes a LighthouseJob out of a LighthouseJobSpec.
@@ -59,6 +65,18 @@ func createRefs(pr *scm.PullRequest, baseSHA string) v1alpha1.Refs {
 	repo := pr.Base.Repo.Name
 	number := pr.Number
 	repoLink := pr.Base.Repo.Link
+	pull := v1alpha1.Pull{
+		Number:     number,
+		Author:     pr.Author.Login,
+		SHA:        pr.Head.Sha,
+		Link:       pr.Link,
+		AuthorLink: pr.Author.Link,
+		CommitLink: fmt.Sprintf("%s/pull/%d/commits/%s", repoLink, number, pr.Head.Sha),
+	}
+	// the patch sets of Gerrit changes can only be fetched from their own refs
+	if strings.HasPrefix(pr.Ref, "refs/changes/") {
+		pull.Ref = pr.Ref
+	}
 	return v1alpha1.Refs{
 		Org:      org,
 		Repo:     repo,
@@ -67,16 +85,7 @@ func createRefs(pr *scm.PullRequest, baseSHA string) v1alpha1.Refs {
 
 		BaseRef: pr.Base.Ref,
 		BaseSHA: baseSHA,
-		Pulls: []v1alpha1.Pull{
-			{
-				Number:     number,
-				Author:     pr.Author.Login,
-				SHA:        pr.Head.Sha,
-				Link:       pr.Link,
-				AuthorLink: pr.Author.Link,
-				CommitLink: fmt.Sprintf("%s/pull/%d/commits/%s", repoLink, number, pr.Head.Sha),
-			},
-		},
+		Pulls:   []v1alpha1.Pull{pull},
 	}
 }
 
@@ -102,7 +111,7 @@ func PresubmitSpec(p config.Presubmit, refs v1alpha1.Refs) v1alpha1.LighthouseJo
 	pjs := specFromJobBase(p.JobBase)
 	pjs.Type = config.PresubmitJob
 	pjs.Context = p.Context
-	pjs.RerunCommand = p.RerunCommand
+	pjs.RerunCommand = util.FormatCommand(p.RerunCommand)
 	pjs.Refs = completePrimaryRefs(refs, p.JobBase)
 
 	return pjs
@@ -114,6 +123,7 @@ func PostsubmitSpec(p config.Postsubmit, refs v1alpha1.Refs) v1alpha1.Lighthouse
 	pjs.Type = config.PostsubmitJob
 	pjs.Context = p.Context
 	pjs.Refs = completePrimaryRefs(refs, p.JobBase)
+	pjs.Environment = p.Annotations[util.EnvironmentAnnotation]
 
 	return pjs
 }
@@ -141,11 +151,164 @@ func specFromJobBase(jb config.JobBase) v1alpha1.LighthouseJobSpec {
 	if jb.Namespace != nil {
 		namespace = *jb.Namespace
 	}
-	return v1alpha1.LighthouseJobSpec{
-		Job:            jb.Name,
-		Namespace:      namespace,
-		MaxConcurrency: jb.MaxConcurrency,
+	agent := jb.Agent
+	if override := jb.Labels[util.AgentLabel]; override != "" {
+		agent = override
+	}
+	spec := v1alpha1.LighthouseJobSpec{
+		Job:             jb.Name,
+		Namespace:       namespace,
+		MaxConcurrency:  jb.MaxConcurrency,
+		Agent:           agent,
+		PipelineRef:     jb.Annotations[util.PipelineRefAnnotation],
+		PodSpec:         jb.Spec.DeepCopy(),
+		ErrorOnEviction: jb.ErrorOnEviction,
+	}
+	if params := jb.Annotations[util.PipelineParamsAnnotation]; params != "" && spec.PipelineRef != "" {
+		if err := yaml.Unmarshal([]byte(params), &spec.PipelineParams); err != nil {
+			logrus.WithError(err).WithField("job", jb.Name).Warnf("ignoring invalid %s annotation", util.PipelineParamsAnnotation)
+		}
+	}
+	for _, secret := range strings.Split(jb.Annotations[util.EnvFromSecretsAnnotation], ",") {
+		if secret = strings.TrimSpace(secret); secret != "" {
+			spec.EnvFromSecrets = append(spec.EnvFromSecrets, secret)
+		}
+	}
+	spec.Timeout = durationAnnotation(jb, util.TimeoutAnnotation)
+	if spec.Timeout != nil {
+		spec.GracePeriod = durationAnnotation(jb, util.GracePeriodAnnotation)
+	}
+	spec.JobEnvVersion = jobEnvVersionAnnotation(jb)
+	spec.ConcurrencyGroup = strings.TrimSpace(jb.Annotations[util.ConcurrencyGroupAnnotation])
+	return spec
+}
+
+// jobEnvVersionAnnotation parses the version of the environment variables pinned by the job's annotation, returning
+// 0 for the default version if it is missing or unsupported
+func jobEnvVersionAnnotation(jb config.JobBase) int {
+	value := jb.Annotations[util.JobEnvVersionAnnotation]
+	if value == "" {
+		return 0
+	}
+	version, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || version < 1 || version > v1alpha1.LatestJobEnvVersion {
+		logrus.WithError(err).WithField("job", jb.Name).Warnf("ignoring unsupported %s annotation %q, the latest version is %d", util.JobEnvVersionAnnotation, value, v1alpha1.LatestJobEnvVersion)
+		return 0
+	}
+	return version
+}
+
+// durationAnnotation parses the duration in the job's annotation, returning nil if it is missing or invalid
+func durationAnnotation(jb config.JobBase, annotation string) *v1alpha1.Duration {
+	value := jb.Annotations[annotation]
+	if value == "" {
+		return nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		logrus.WithError(err).WithField("job", jb.Name).Warnf("ignoring invalid %s annotation %q", annotation, value)
+		return nil
+	}
+	return &v1alpha1.Duration{Duration: d}
+}
+
+// TriggerContext returns the context and the state which trigger the job according to its TriggerOnContextAnnotation,
+// or empty strings if the job is triggered by pushes
+func TriggerContext(jb config.JobBase) (context string, state string) {
+	value := strings.TrimSpace(jb.Annotations[util.TriggerOnContextAnnotation])
+	if value == "" {
+		return "", ""
+	}
+	context, state = value, "success"
+	if i := strings.LastIndex(value, "="); i >= 0 {
+		context, state = strings.TrimSpace(value[:i]), strings.ToLower(strings.TrimSpace(value[i+1:]))
+	}
+	return context, state
+}
+
+// IssueTrigger returns the regular expression of the commands on issues which trigger the job according to its
+// IssueTriggerAnnotation, or nil if the job is not triggered by issue comments or the expression is invalid
+func IssueTrigger(jb config.JobBase) *regexp.Regexp {
+	value := strings.TrimSpace(jb.Annotations[util.IssueTriggerAnnotation])
+	if value == "" {
+		return nil
+	}
+	re, err := regexp.Compile(value)
+	if err != nil {
+		logrus.WithError(err).WithField("job", jb.Name).Warnf("ignoring invalid %s annotation %q", util.IssueTriggerAnnotation, value)
+		return nil
+	}
+	return re
+}
+
+// RerunAuthConfig returns the users and org/team teams allowed to run the job on demand according to its
+// RerunAuthConfigAnnotation, or nil if anyone trusted can
+func RerunAuthConfig(jb config.JobBase) []string {
+	var answer []string
+	for _, principal := range strings.Split(jb.Annotations[util.RerunAuthConfigAnnotation], ",") {
+		if principal = strings.TrimSpace(principal); principal != "" {
+			answer = append(answer, principal)
+		}
+	}
+	return answer
+}
+
+// NeedsChangedFiles returns true if the job's pipeline parameters refer to the files changed by the pull request
+func NeedsChangedFiles(spec *v1alpha1.LighthouseJobSpec) bool {
+	if spec.PipelineRef == "" {
+		return false
+	}
+	for _, param := range spec.PipelineParams {
+		if strings.Contains(param, ".ChangedFiles") {
+			return true
+		}
+	}
+	return false
+}
+
+// AddBuildCacheHints records the files changed by the pull request on the presubmit along with a hash of the ones its
+// run_if_changed matches, and annotates it with them and its base SHA so that its pipeline can build incrementally
+func AddBuildCacheHints(pj *v1alpha1.LighthouseJob, changes []*scm.Change, runIfChanged string) {
+	refs := pj.Spec.Refs
+	if refs == nil || len(refs.Pulls) == 0 {
+		return
+	}
+	var relevant *regexp.Regexp
+	if runIfChanged != "" {
+		// the regexp was validated when the configuration was loaded
+		relevant, _ = regexp.Compile(runIfChanged)
+	}
+	var files, hashed []string
+	for _, change := range changes {
+		files = append(files, change.Path)
+		if relevant != nil && !relevant.MatchString(change.Path) {
+			continue
+		}
+		content := change.Sha
+		if change.Deleted {
+			content = "deleted"
+		} else if content == "" {
+			// without the blob SHA of the file, the hash only matches for the same commit
+			content = refs.Pulls[0].SHA
+		}
+		hashed = append(hashed, change.Path+"\x00"+content)
+	}
+	sort.Strings(hashed)
+	sum := sha256.Sum256([]byte(strings.Join(hashed, "\n")))
+	hash := hex.EncodeToString(sum[:])
+	for i := range refs.Pulls {
+		refs.Pulls[i].ChangedFiles = files
+		refs.Pulls[i].ChangesHash = hash
+	}
+
+	if pj.Annotations == nil {
+		pj.Annotations = map[string]string{}
+	}
+	if list := refs.Pulls[0].ChangedFilesList(); list != "" {
+		pj.Annotations[util.ChangedFilesAnnotation] = list
 	}
+	pj.Annotations[util.BaseSHAAnnotation] = refs.BaseSHA
+	pj.Annotations[util.ChangesHashAnnotation] = hash
 }
 
 func completePrimaryRefs(refs v1alpha1.Refs, jb config.JobBase) *v1alpha1.Refs {
@@ -17,14 +17,17 @@ limitations under the License.
 package jobutil
 
 import (
+	"fmt"
 	"reflect"
 	"testing"
 	"text/template"
+	"time"
 
 	"github.com/jenkins-x/go-scm/scm"
 	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
 	"github.com/jenkins-x/lighthouse/pkg/util"
 	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
 	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/util/diff"
 
@@ -92,6 +95,21 @@ func TestPostsubmitSpec(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "environment annotation",
+			p: config.Postsubmit{
+				JobBase: config.JobBase{
+					Annotations: map[string]string{
+						util.EnvironmentAnnotation: "production",
+					},
+				},
+			},
+			expected: v1alpha1.LighthouseJobSpec{
+				Type:        config.PostsubmitJob,
+				Refs:        &v1alpha1.Refs{},
+				Environment: "production",
+			},
+		},
 	}
 
 	for _, tc := range tests {
@@ -545,6 +563,21 @@ func TestCreateRefs(t *testing.T) {
 	}
 }
 
+func TestCreateRefsOfGerritChange(t *testing.T) {
+	pr := &scm.PullRequest{
+		Number: 42,
+		Ref:    "refs/changes/42/42/3",
+		Head:   scm.PullRequestBranch{Sha: "123456"},
+		Base:   scm.PullRequestBranch{Ref: "master", Repo: scm.Repository{Namespace: "org", Name: "repo"}},
+	}
+	refs := createRefs(pr, "abcdef")
+	assert.Equal(t, "refs/changes/42/42/3", refs.Pulls[0].Ref)
+	assert.Equal(t, "master:abcdef,42:123456:refs/changes/42/42/3", refs.String())
+
+	pr.Ref = "refs/pull/42/head"
+	assert.Empty(t, createRefs(pr, "abcdef").Pulls[0].Ref)
+}
+
 func TestSpecFromJobBase(t *testing.T) {
 	testCases := []struct {
 		name    string
@@ -576,6 +609,148 @@ func TestSpecFromJobBase(t *testing.T) {
 				return nil
 			},
 		},
+		{
+			name:    "Verify agent gets copied",
+			jobBase: config.JobBase{Agent: config.TektonAgent},
+			verify: func(pj v1alpha1.LighthouseJobSpec) error {
+				if pj.Agent != v1alpha1.TektonAgent {
+					return fmt.Errorf("Expected agent to be %q, was %q", v1alpha1.TektonAgent, pj.Agent)
+				}
+				return nil
+			},
+		},
+		{
+			name: "Verify agent label overrides the agent",
+			jobBase: config.JobBase{
+				Agent:  config.TektonAgent,
+				Labels: map[string]string{util.AgentLabel: v1alpha1.JenkinsAgent},
+			},
+			verify: func(pj v1alpha1.LighthouseJobSpec) error {
+				if pj.Agent != v1alpha1.JenkinsAgent {
+					return fmt.Errorf("Expected agent to be %q, was %q", v1alpha1.JenkinsAgent, pj.Agent)
+				}
+				return nil
+			},
+		},
+		{
+			name: "Verify pipeline ref and params get copied from annotations",
+			jobBase: config.JobBase{
+				Annotations: map[string]string{
+					util.PipelineRefAnnotation:    "build-and-test",
+					util.PipelineParamsAnnotation: "sha: '{{ .PullSHA }}'\nfiles: '{{ join \",\" .ChangedFiles }}'\n",
+				},
+			},
+			verify: func(pj v1alpha1.LighthouseJobSpec) error {
+				if pj.PipelineRef != "build-and-test" {
+					return fmt.Errorf("Expected pipeline ref to be %q, was %q", "build-and-test", pj.PipelineRef)
+				}
+				if pj.PipelineParams["sha"] != "{{ .PullSHA }}" {
+					return fmt.Errorf("Expected sha param to be %q, was %q", "{{ .PullSHA }}", pj.PipelineParams["sha"])
+				}
+				if !NeedsChangedFiles(&pj) {
+					return fmt.Errorf("Expected job to need changed files")
+				}
+				return nil
+			},
+		},
+		{
+			name: "Verify pipeline params are ignored without a pipeline ref",
+			jobBase: config.JobBase{
+				Annotations: map[string]string{util.PipelineParamsAnnotation: "files: '{{ .ChangedFiles }}'"},
+			},
+			verify: func(pj v1alpha1.LighthouseJobSpec) error {
+				if len(pj.PipelineParams) != 0 {
+					return fmt.Errorf("Expected no pipeline params, got %v", pj.PipelineParams)
+				}
+				if NeedsChangedFiles(&pj) {
+					return fmt.Errorf("Expected job not to need changed files")
+				}
+				return nil
+			},
+		},
+		{
+			name: "Verify timeout and grace period get copied from annotations",
+			jobBase: config.JobBase{
+				Annotations: map[string]string{
+					util.TimeoutAnnotation:     "1h30m",
+					util.GracePeriodAnnotation: "30s",
+				},
+			},
+			verify: func(pj v1alpha1.LighthouseJobSpec) error {
+				if pj.Timeout == nil || pj.Timeout.Duration != 90*time.Minute {
+					return fmt.Errorf("Expected timeout to be 1h30m, was %v", pj.Timeout)
+				}
+				if pj.GracePeriod == nil || pj.GracePeriod.Duration != 30*time.Second {
+					return fmt.Errorf("Expected grace period to be 30s, was %v", pj.GracePeriod)
+				}
+				return nil
+			},
+		},
+		{
+			name: "Verify invalid timeouts are ignored",
+			jobBase: config.JobBase{
+				Annotations: map[string]string{
+					util.TimeoutAnnotation:     "forever",
+					util.GracePeriodAnnotation: "30s",
+				},
+			},
+			verify: func(pj v1alpha1.LighthouseJobSpec) error {
+				if pj.Timeout != nil || pj.GracePeriod != nil {
+					return fmt.Errorf("Expected no timeout nor grace period, got %v and %v", pj.Timeout, pj.GracePeriod)
+				}
+				return nil
+			},
+		},
+		{
+			name: "Verify secrets get copied from annotations",
+			jobBase: config.JobBase{
+				Annotations: map[string]string{
+					util.EnvFromSecretsAnnotation: "npm-token, sonar-token,",
+				},
+			},
+			verify: func(pj v1alpha1.LighthouseJobSpec) error {
+				if !reflect.DeepEqual(pj.EnvFromSecrets, []string{"npm-token", "sonar-token"}) {
+					return fmt.Errorf("Expected secrets npm-token and sonar-token, got %v", pj.EnvFromSecrets)
+				}
+				return nil
+			},
+		},
+		{
+			name: "Verify the job env version gets copied from annotations",
+			jobBase: config.JobBase{
+				Annotations: map[string]string{util.JobEnvVersionAnnotation: "2"},
+			},
+			verify: func(pj v1alpha1.LighthouseJobSpec) error {
+				if pj.JobEnvVersion != 2 {
+					return fmt.Errorf("Expected job env version 2, was %d", pj.JobEnvVersion)
+				}
+				return nil
+			},
+		},
+		{
+			name: "Verify unsupported job env versions are ignored",
+			jobBase: config.JobBase{
+				Annotations: map[string]string{util.JobEnvVersionAnnotation: "99"},
+			},
+			verify: func(pj v1alpha1.LighthouseJobSpec) error {
+				if pj.JobEnvVersion != 0 {
+					return fmt.Errorf("Expected the default job env version, was %d", pj.JobEnvVersion)
+				}
+				return nil
+			},
+		},
+		{
+			name: "Verify the concurrency group gets copied from annotations",
+			jobBase: config.JobBase{
+				Annotations: map[string]string{util.ConcurrencyGroupAnnotation: " deploy-staging "},
+			},
+			verify: func(pj v1alpha1.LighthouseJobSpec) error {
+				if pj.ConcurrencyGroup != "deploy-staging" {
+					return fmt.Errorf("Expected concurrency group deploy-staging, was %q", pj.ConcurrencyGroup)
+				}
+				return nil
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -587,3 +762,92 @@ func TestSpecFromJobBase(t *testing.T) {
 		})
 	}
 }
+
+func TestTriggerContext(t *testing.T) {
+	testCases := []struct {
+		annotation string
+		context    string
+		state      string
+	}{
+		{},
+		{annotation: "security-scan", context: "security-scan", state: "success"},
+		{annotation: "security-scan=failure", context: "security-scan", state: "failure"},
+		{annotation: " ci/scan = Error ", context: "ci/scan", state: "error"},
+	}
+	for _, tc := range testCases {
+		jb := config.JobBase{Annotations: map[string]string{util.TriggerOnContextAnnotation: tc.annotation}}
+		context, state := TriggerContext(jb)
+		if context != tc.context || state != tc.state {
+			t.Errorf("annotation %q: expected %q=%q, got %q=%q", tc.annotation, tc.context, tc.state, context, state)
+		}
+	}
+}
+
+func TestIssueTrigger(t *testing.T) {
+	testCases := []struct {
+		annotation string
+		body       string
+		matches    bool
+	}{
+		{body: "/release patch"},
+		{annotation: `^/release (patch|minor)$`, body: "/release patch", matches: true},
+		{annotation: `^/release (patch|minor)$`, body: "/release major"},
+		{annotation: `(?m)^/benchmark (?P<branch>\S+)$`, body: "please\n/benchmark main", matches: true},
+		{annotation: `/release (`, body: "/release ("},
+	}
+	for _, tc := range testCases {
+		jb := config.JobBase{Annotations: map[string]string{util.IssueTriggerAnnotation: tc.annotation}}
+		re := IssueTrigger(jb)
+		if matches := re != nil && re.MatchString(tc.body); matches != tc.matches {
+			t.Errorf("annotation %q: expected %q to match %t, got %t", tc.annotation, tc.body, tc.matches, matches)
+		}
+	}
+}
+
+func TestAddBuildCacheHints(t *testing.T) {
+	pr := &scm.PullRequest{
+		Number: 1,
+		Head:   scm.PullRequestBranch{Sha: "cafe"},
+		Base: scm.PullRequestBranch{
+			Ref:  "master",
+			Repo: scm.Repository{Namespace: "org", Name: "repo"},
+		},
+	}
+	job := config.Presubmit{JobBase: config.JobBase{Name: "api"}, Reporter: config.Reporter{Context: "api"}}
+	hints := func(changes []*scm.Change, runIfChanged string) v1alpha1.LighthouseJob {
+		pj := NewPresubmit(pr, "beef", job, "guid")
+		AddBuildCacheHints(&pj, changes, runIfChanged)
+		return pj
+	}
+	api := &scm.Change{Path: "api/main.go", Sha: "111"}
+	web := &scm.Change{Path: "web/index.html", Sha: "222"}
+
+	pj := hints([]*scm.Change{api, web}, "")
+	pull := pj.Spec.Refs.Pulls[0]
+	if !reflect.DeepEqual(pull.ChangedFiles, []string{"api/main.go", "web/index.html"}) {
+		t.Errorf("unexpected changed files %v", pull.ChangedFiles)
+	}
+	if pull.ChangesHash == "" || pj.Annotations[util.ChangesHashAnnotation] != pull.ChangesHash {
+		t.Errorf("expected the changes hash %q to be annotated, got %q", pull.ChangesHash, pj.Annotations[util.ChangesHashAnnotation])
+	}
+	if got := pj.Annotations[util.ChangedFilesAnnotation]; got != "api/main.go,web/index.html" {
+		t.Errorf("unexpected changed files annotation %q", got)
+	}
+	if got := pj.Annotations[util.BaseSHAAnnotation]; got != "beef" {
+		t.Errorf("unexpected base SHA annotation %q", got)
+	}
+	if other := hints([]*scm.Change{web, api}, ""); other.Spec.Refs.Pulls[0].ChangesHash != pull.ChangesHash {
+		t.Error("the changes hash should not depend on the order of the changes")
+	}
+
+	// only the changes matching run_if_changed are hashed
+	apiOnly := hints([]*scm.Change{api, web}, "^api/").Spec.Refs.Pulls[0].ChangesHash
+	changedWeb := hints([]*scm.Change{api, {Path: "web/index.html", Sha: "333"}}, "^api/").Spec.Refs.Pulls[0].ChangesHash
+	changedAPI := hints([]*scm.Change{{Path: "api/main.go", Sha: "444"}, web}, "^api/").Spec.Refs.Pulls[0].ChangesHash
+	if apiOnly != changedWeb {
+		t.Error("the changes hash should not depend on the files run_if_changed does not match")
+	}
+	if apiOnly == changedAPI {
+		t.Error("the changes hash should depend on the contents of the files run_if_changed matches")
+	}
+}
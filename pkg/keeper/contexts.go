@@ -0,0 +1,94 @@
+package keeper
+
+import (
+	"github.com/jenkins-x/lighthouse/pkg/labels"
+	"github.com/jenkins-x/lighthouse/pkg/requiredjobs"
+	githubql "github.com/shurcooL/githubv4"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// prContextChecker refines the context policy of a branch for a pull request. The contexts of the required
+// presubmits which only run for some changed files can only be required if present by the branch, while for
+// a pull request they are known to be either required, when its changes make them run, or optional.
+type prContextChecker struct {
+	branch   contextChecker
+	required sets.String
+	optional sets.String
+	// skipped are the contexts which are not required when missing, as the presubmits of a pull request labeled
+	// skip-ci only run when requested
+	skipped sets.String
+}
+
+// IsOptional tells whether a context can be ignored for the pull request.
+func (c *prContextChecker) IsOptional(context string) bool {
+	if c.required.Has(context) {
+		return false
+	}
+	if c.optional.Has(context) {
+		return true
+	}
+	return c.branch.IsOptional(context)
+}
+
+// MissingRequiredContexts tells which of the contexts required by the branch or the changes of the pull
+// request are missing.
+func (c *prContextChecker) MissingRequiredContexts(contexts []string) []string {
+	missing := sets.NewString(c.branch.MissingRequiredContexts(contexts)...)
+	missing.Insert(c.required.Difference(sets.NewString(contexts...)).UnsortedList()...)
+	return missing.Difference(c.skipped).List()
+}
+
+// contextCheckerFor returns the context policy of the subpool refined with the conditional presubmits the
+// changes of the pull request make run
+func (sp *subpool) contextCheckerFor(pr *PullRequest) contextChecker {
+	skipped := sets.NewString()
+	if pr.hasLabel(labels.SkipCI) {
+		skipped = sp.skippedContexts
+	}
+	if sp.conditionalContexts.Len() == 0 && len(sp.gates) == 0 && skipped.Len() == 0 {
+		return sp.cc
+	}
+	required := sets.NewString()
+	for _, job := range sp.presubmits[int(pr.Number)] {
+		if sp.conditionalContexts.Has(job.Context) {
+			required.Insert(job.Context)
+		}
+	}
+	// the merge gates run right before merging, so their contexts do not keep the pull request out of the pool
+	optional := sp.conditionalContexts.Difference(required)
+	for _, gate := range sp.gates {
+		optional.Insert(gate.Context)
+	}
+	return &prContextChecker{
+		branch:   sp.cc,
+		required: required,
+		optional: optional,
+		skipped:  skipped,
+	}
+}
+
+// hasLabel returns true if the pull request has the label
+func (pr *PullRequest) hasLabel(name string) bool {
+	for _, l := range pr.Labels.Nodes {
+		if string(l.Name) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// reportedSkipped returns the contexts the trigger plugin reported as skipped on the head commit of the pull request
+func (pr *PullRequest) reportedSkipped() sets.String {
+	answer := sets.NewString()
+	for _, node := range pr.Commits.Nodes {
+		if node.Commit.OID != pr.HeadRefOID {
+			continue
+		}
+		for _, ctx := range node.Commit.Status.Contexts {
+			if ctx.State == githubql.StatusStateSuccess && string(ctx.Description) == requiredjobs.SkippedDescription {
+				answer.Insert(string(ctx.Context))
+			}
+		}
+	}
+	return answer
+}
@@ -0,0 +1,229 @@
+package keeper
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/labels"
+	"github.com/jenkins-x/lighthouse/pkg/requiredjobs"
+	githubql "github.com/shurcooL/githubv4"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConditionalContexts(t *testing.T) {
+	cfg := &config.Config{}
+	require.NoError(t, cfg.SetPresubmits(map[string][]config.Presubmit{
+		"org/repo": {
+			{
+				JobBase:   config.JobBase{Name: "unit"},
+				Reporter:  config.Reporter{Context: "unit"},
+				AlwaysRun: true,
+			},
+			{
+				JobBase:             config.JobBase{Name: "go"},
+				Reporter:            config.Reporter{Context: "go"},
+				RegexpChangeMatcher: config.RegexpChangeMatcher{RunIfChanged: `\.go$`},
+			},
+			{
+				JobBase:   config.JobBase{Name: "lint"},
+				Reporter:  config.Reporter{Context: "lint"},
+				AlwaysRun: true,
+				Optional:  true,
+			},
+		},
+	}))
+	ca := &config.Agent{}
+	ca.Set(cfg)
+
+	pr := func(number int, contexts map[string]githubql.StatusState) PullRequest {
+		var pr PullRequest
+		pr.Number = githubql.Int(number)
+		pr.HeadRefOID = githubql.String("sha")
+		pr.Repository.Owner.Login = "org"
+		pr.Repository.Name = "repo"
+		commit := Commit{OID: "sha"}
+		for context, state := range contexts {
+			commit.Status.Contexts = append(commit.Status.Contexts, Context{Context: githubql.String(context), State: state})
+		}
+		pr.Commits.Nodes = []struct{ Commit Commit }{{Commit: commit}}
+		return pr
+	}
+	success, failure := githubql.StatusStateSuccess, githubql.StatusStateFailure
+	prs := []PullRequest{
+		// changes go files and passes
+		pr(1, map[string]githubql.StatusState{"unit": success, "go": success}),
+		// changes go files but the go job failed
+		pr(2, map[string]githubql.StatusState{"unit": success, "go": failure}),
+		// changes go files but the go job did not report
+		pr(3, map[string]githubql.StatusState{"unit": success}),
+		// changes docs only, so the go job someone ran manually does not matter
+		pr(4, map[string]githubql.StatusState{"unit": success, "go": failure}),
+		// the optional job failed
+		pr(5, map[string]githubql.StatusState{"unit": success, "lint": failure}),
+	}
+	changes := map[changeCacheKey][]string{}
+	for _, pr := range prs {
+		files := []string{"main.go"}
+		if pr.Number >= 4 {
+			files = []string{"README.md"}
+		}
+		changes[changeCacheKey{org: "org", repo: "repo", number: int(pr.Number), sha: "sha"}] = files
+	}
+	c := &DefaultController{
+		config: ca.Config,
+		spc:    &fgc{},
+		changedFiles: &changedFilesAgent{
+			spc:             &fgc{},
+			changeCache:     changes,
+			nextChangeCache: map[changeCacheKey][]string{},
+		},
+	}
+	sp := &subpool{
+		log:    logrus.WithField("component", "keeper"),
+		org:    "org",
+		repo:   "repo",
+		branch: "master",
+		prs:    prs,
+	}
+	require.NoError(t, c.initSubpoolData(sp))
+	assert.Equal(t, []string{"go"}, sp.conditionalContexts.List())
+
+	filtered := filterSubpool(c.spc, sp)
+	require.NotNil(t, filtered)
+	assert.Equal(t, []int{1, 4, 5}, prNumbers(filtered.prs))
+	assert.Equal(t, []string{"go"}, sp.contextCheckerFor(&prs[2]).MissingRequiredContexts([]string{"unit"}))
+	assert.Empty(t, sp.contextCheckerFor(&prs[3]).MissingRequiredContexts([]string{"unit"}))
+}
+
+func TestSkipCIContexts(t *testing.T) {
+	cfg := &config.Config{}
+	require.NoError(t, cfg.SetPresubmits(map[string][]config.Presubmit{
+		"org/repo": {
+			{
+				JobBase:   config.JobBase{Name: "unit"},
+				Reporter:  config.Reporter{Context: "unit"},
+				AlwaysRun: true,
+			},
+			{
+				JobBase:             config.JobBase{Name: "go"},
+				Reporter:            config.Reporter{Context: "go"},
+				RegexpChangeMatcher: config.RegexpChangeMatcher{RunIfChanged: `\.go$`},
+			},
+		},
+	}))
+	ca := &config.Agent{}
+	ca.Set(cfg)
+
+	pr := func(number int, skipCI bool, contexts map[string]githubql.StatusState) PullRequest {
+		var pr PullRequest
+		pr.Number = githubql.Int(number)
+		pr.HeadRefOID = githubql.String("sha")
+		pr.Repository.Owner.Login = "org"
+		pr.Repository.Name = "repo"
+		if skipCI {
+			pr.Labels.Nodes = append(pr.Labels.Nodes, struct{ Name githubql.String }{Name: labels.SkipCI})
+		}
+		commit := Commit{OID: "sha"}
+		for context, state := range contexts {
+			commit.Status.Contexts = append(commit.Status.Contexts, Context{Context: githubql.String(context), State: state})
+		}
+		pr.Commits.Nodes = []struct{ Commit Commit }{{Commit: commit}}
+		return pr
+	}
+	prs := []PullRequest{
+		// skips CI without any context
+		pr(1, true, nil),
+		// skips CI but the unit job someone ran manually failed
+		pr(2, true, map[string]githubql.StatusState{"unit": githubql.StatusStateFailure}),
+		// runs CI but the jobs did not report yet
+		pr(3, false, nil),
+	}
+	changes := map[changeCacheKey][]string{}
+	for _, pr := range prs {
+		changes[changeCacheKey{org: "org", repo: "repo", number: int(pr.Number), sha: "sha"}] = []string{"main.go"}
+	}
+	c := &DefaultController{
+		config: ca.Config,
+		spc:    &fgc{},
+		changedFiles: &changedFilesAgent{
+			spc:             &fgc{},
+			changeCache:     changes,
+			nextChangeCache: map[changeCacheKey][]string{},
+		},
+	}
+	sp := &subpool{
+		log:    logrus.WithField("component", "keeper"),
+		org:    "org",
+		repo:   "repo",
+		branch: "master",
+		prs:    prs,
+	}
+	require.NoError(t, c.initSubpoolData(sp))
+	assert.Empty(t, sp.presubmits[1], "the presubmits of a PR skipping CI are not required")
+	assert.Len(t, sp.presubmits[3], 2)
+
+	filtered := filterSubpool(c.spc, sp)
+	require.NotNil(t, filtered)
+	assert.Equal(t, []int{1}, prNumbers(filtered.prs))
+}
+
+func TestDependencyUpdateContexts(t *testing.T) {
+	cfg := &config.Config{}
+	require.NoError(t, cfg.SetPresubmits(map[string][]config.Presubmit{
+		"org/repo": {
+			{
+				JobBase:   config.JobBase{Name: "unit"},
+				Reporter:  config.Reporter{Context: "unit"},
+				AlwaysRun: true,
+			},
+			{
+				JobBase:   config.JobBase{Name: "e2e"},
+				Reporter:  config.Reporter{Context: "e2e"},
+				AlwaysRun: true,
+			},
+		},
+	}))
+	ca := &config.Agent{}
+	ca.Set(cfg)
+
+	pr := func(number int, dependencyUpdate bool) PullRequest {
+		var pr PullRequest
+		pr.Number = githubql.Int(number)
+		pr.HeadRefOID = githubql.String("sha")
+		pr.Repository.Owner.Login = "org"
+		pr.Repository.Name = "repo"
+		if dependencyUpdate {
+			pr.Labels.Nodes = append(pr.Labels.Nodes, struct{ Name githubql.String }{Name: labels.DependencyUpdate})
+		}
+		commit := Commit{OID: "sha"}
+		commit.Status.Contexts = []Context{
+			{Context: "unit", State: githubql.StatusStatePending},
+			{Context: "e2e", Description: requiredjobs.SkippedDescription, State: githubql.StatusStateSuccess},
+		}
+		pr.Commits.Nodes = []struct{ Commit Commit }{{Commit: commit}}
+		return pr
+	}
+	prs := []PullRequest{pr(1, true), pr(2, false)}
+	c := &DefaultController{
+		config: ca.Config,
+		spc:    &fgc{},
+		changedFiles: &changedFilesAgent{
+			spc:             &fgc{},
+			changeCache:     map[changeCacheKey][]string{},
+			nextChangeCache: map[changeCacheKey][]string{},
+		},
+	}
+	sp := &subpool{
+		log:    logrus.WithField("component", "keeper"),
+		org:    "org",
+		repo:   "repo",
+		branch: "master",
+		prs:    prs,
+	}
+	require.NoError(t, c.initSubpoolData(sp))
+	require.Len(t, sp.presubmits[1], 1, "the presubmits skipped by the dependency update profile are not required")
+	assert.Equal(t, "unit", sp.presubmits[1][0].Name)
+	assert.Len(t, sp.presubmits[2], 2)
+}
@@ -0,0 +1,29 @@
+package keeper
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// dryRunActions counts the actions keeper would have taken in dry run mode
+var dryRunActions = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "dryrunactions",
+	Help: "A counter of the actions keeper would have taken on the pools in dry run mode.",
+}, []string{
+	"org",
+	"repo",
+	"branch",
+	"action",
+})
+
+func init() {
+	prometheus.MustRegister(dryRunActions)
+}
+
+// recordDryRun logs and counts the action keeper would have taken on the subpool
+func (c *DefaultController) recordDryRun(sp subpool, act Action, targets []PullRequest) {
+	if !c.dryRun || !recordableActions[act] {
+		return
+	}
+	dryRunActions.WithLabelValues(sp.org, sp.repo, sp.branch, string(act)).Inc()
+	sp.log.WithField("action", string(act)).WithField("targets", prNumbers(targets)).Info("Dry run: the action was not taken.")
+}
@@ -0,0 +1,64 @@
+package keeper
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	launcherfake "github.com/jenkins-x/lighthouse/pkg/launcher/fake"
+	githubql "github.com/shurcooL/githubv4"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTakeActionDryRun(t *testing.T) {
+	pr := func(number int) PullRequest {
+		var pr PullRequest
+		pr.Number = githubql.Int(number)
+		pr.HeadRefOID = "head"
+		pr.Commits.Nodes = []struct {
+			Commit Commit
+		}{{Commit: Commit{OID: "head", Status: struct{ Contexts []Context }{Contexts: []Context{
+			{Context: "unit", State: githubql.StatusStateSuccess},
+		}}}}}
+		return pr
+	}
+	passing, missing := pr(1), pr(2)
+
+	ca := &config.Agent{}
+	ca.Set(&config.Config{})
+	fgc := &fgc{}
+	launcher := launcherfake.NewLauncher()
+	c := &DefaultController{
+		logger:         logrus.WithField("controller", "keeper"),
+		config:         ca.Config,
+		spc:            fgc,
+		launcherClient: launcher,
+		dryRun:         true,
+	}
+	unit := config.Presubmit{JobBase: config.JobBase{Name: "unit"}, Reporter: config.Reporter{Context: "unit"}}
+	sp := subpool{
+		log:        c.logger,
+		cc:         &config.KeeperContextPolicy{},
+		org:        "org",
+		repo:       "repo",
+		branch:     "master",
+		sha:        "base",
+		prs:        []PullRequest{passing},
+		presubmits: map[int][]config.Presubmit{1: {unit}, 2: {unit}},
+	}
+
+	act, targets, err := c.takeAction(sp, nil, []PullRequest{passing}, nil, nil, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, Action(Merge), act)
+	assert.Equal(t, []int{1}, prNumbers(targets))
+	assert.Equal(t, 0, fgc.merged, "nothing should be merged in dry run mode")
+
+	sp.prs = []PullRequest{missing}
+	act, targets, err = c.takeAction(sp, nil, nil, nil, []PullRequest{missing}, nil, map[int][]config.Presubmit{2: {unit}})
+	require.NoError(t, err)
+	assert.Equal(t, Action(Trigger), act)
+	assert.Equal(t, []int{2}, prNumbers(targets))
+	assert.Empty(t, launcher.Pipelines, "no job should be launched in dry run mode")
+	assert.Empty(t, fgc.combinedStatus, "no status should be set in dry run mode")
+}
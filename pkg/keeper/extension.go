@@ -0,0 +1,229 @@
+package keeper
+
+import (
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"sigs.k8s.io/yaml"
+)
+
+// Extension holds the keeper settings which are not part of the shared configuration, read from the keeper
+// section of config.yaml next to the settings they complement:
+//
+//	keeper:
+//	  squash_label: keeper/squash
+//	  rebase_label: keeper/rebase
+//	  allowed_merge_methods:
+//	    org/repo:
+//	    - merge
+//	    - squash
+//	  max_merges_per_hour:
+//	    org: 20
+//	    org/repo: 5
+//	  max_batch_size:
+//	    org/repo: 3
+//	  retest_not_required:
+//	    org/repo: true
+//	  selective_retest:
+//	    org: true
+//	  queue_comments:
+//	    org/repo: true
+//	  gitlab:
+//	    org:
+//	      require_approvals: true
+//	      require_pipeline: true
+//	      merge_when_pipeline_succeeds: true
+//	  queries:
+//	  - repos:
+//	    - org/repo
+//	    labels:
+//	    - approved
+//	    author: release-bot
+//	    requiredApprovingReviews: 2
+//	    codeOwnersApprovalRequired: true
+type Extension struct {
+	// Queries extend the keeper queries with the same index
+	Queries []QueryExtension `json:"queries,omitempty"`
+	// AllowedMergeMethods are the merge methods the merge labels of a pull request can select, keyed by "org"
+	// or "org/repo". Any method can be selected when no methods are listed.
+	AllowedMergeMethods map[string][]config.PullRequestMergeType `json:"allowed_merge_methods,omitempty"`
+	// MaxMergesPerHour is the maximum number of pull requests of each repository merged in an hour, keyed by "org"
+	// or "org/repo", so that the systems reacting to the merges are not overwhelmed when many pull requests become
+	// mergeable at once
+	MaxMergesPerHour map[string]int `json:"max_merges_per_hour,omitempty"`
+	// MaxBatchSize is the maximum number of pull requests tested and merged together in a batch, keyed by "org" or
+	// "org/repo"
+	MaxBatchSize map[string]int `json:"max_batch_size,omitempty"`
+	// GitLab are the settings of the merge requests of GitLab repositories, keyed by "org" or "org/repo"
+	GitLab map[string]GitLabSettings `json:"gitlab,omitempty"`
+	// RetestNotRequired merges the pull requests whose head contains the base branch and has all its required contexts
+	// successful without retesting them against the base, keyed by "org" or "org/repo", as merging them results in
+	// their head
+	RetestNotRequired map[string]bool `json:"retest_not_required,omitempty"`
+	// SelectiveRetest keeps the successful results of the presubmits of the pool PRs once the base branch advanced
+	// when their run_if_changed matches none of the files changed by the new base commits, keyed by "org" or
+	// "org/repo". Only the required presubmits affected by the new base commits are run again.
+	SelectiveRetest map[string]bool `json:"selective_retest,omitempty"`
+	// QueueComments maintains a comment on each pool PR explaining why it is not merged yet, such as the required
+	// contexts it waits for, its position in the queue or the issues blocking the merges, keyed by "org" or
+	// "org/repo". The comment is updated in place and deleted once the PR leaves the pool.
+	QueueComments map[string]bool `json:"queue_comments,omitempty"`
+}
+
+// GitLabSettings configures how keeper takes the GitLab approval rules and pipelines of merge requests into account
+type GitLabSettings struct {
+	// RequireApprovals keeps the merge requests whose approval rules are not satisfied out of the pool
+	RequireApprovals bool `json:"require_approvals,omitempty"`
+	// RequirePipeline keeps the merge requests whose latest pipeline did not succeed out of the pool. The external
+	// pipelines of the commit statuses are ignored, as keeper checks the statuses themselves.
+	RequirePipeline bool `json:"require_pipeline,omitempty"`
+	// MergeWhenPipelineSucceeds lets the merge requests whose pipeline is still running into the pool, and merges
+	// them with the merge when pipeline succeeds option of GitLab
+	MergeWhenPipelineSucceeds bool `json:"merge_when_pipeline_succeeds,omitempty"`
+}
+
+// Query returns the extension of the query at the given index
+func (e *Extension) Query(index int) QueryExtension {
+	if index >= len(e.Queries) {
+		return QueryExtension{}
+	}
+	return e.Queries[index]
+}
+
+// MergeMethodAllowed returns true if the merge labels of the pull requests of the repository can select the method
+func (e *Extension) MergeMethodAllowed(org, repo string, method config.PullRequestMergeType) bool {
+	methods, ok := e.AllowedMergeMethods[org+"/"+repo]
+	if !ok {
+		methods, ok = e.AllowedMergeMethods[org]
+	}
+	if !ok {
+		return true
+	}
+	for _, m := range methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// MergesPerHourLimit returns the maximum number of pull requests of the repository merged in an hour, or 0 if the
+// merges are not limited
+func (e *Extension) MergesPerHourLimit(org, repo string) int {
+	return repoLimit(e.MaxMergesPerHour, org, repo)
+}
+
+// BatchSizeLimit caps the batch size limit of the shared configuration with the maximum batch size of the repository
+// and with its maximum number of merges per hour, as a larger batch could never be merged. A limit of 0 means no limit.
+func (e *Extension) BatchSizeLimit(org, repo string, limit int) int {
+	for _, l := range []int{repoLimit(e.MaxBatchSize, org, repo), e.MergesPerHourLimit(org, repo)} {
+		if l > 0 && (limit <= 0 || l < limit) {
+			limit = l
+		}
+	}
+	return limit
+}
+
+// GitLabSettingsFor returns the GitLab settings of the repository, falling back to the settings of its org
+func (e *Extension) GitLabSettingsFor(org, repo string) GitLabSettings {
+	if settings, ok := e.GitLab[org+"/"+repo]; ok {
+		return settings
+	}
+	return e.GitLab[org]
+}
+
+// RetestNotRequiredFor returns true if the pull requests of the repository containing the base branch are merged
+// without being retested, falling back to the setting of its org
+func (e *Extension) RetestNotRequiredFor(org, repo string) bool {
+	if enabled, ok := e.RetestNotRequired[org+"/"+repo]; ok {
+		return enabled
+	}
+	return e.RetestNotRequired[org]
+}
+
+// SelectiveRetestFor returns true if only the presubmits of the pull requests of the repository affected by the new
+// commits of their base are run again, falling back to the setting of its org
+func (e *Extension) SelectiveRetestFor(org, repo string) bool {
+	if enabled, ok := e.SelectiveRetest[org+"/"+repo]; ok {
+		return enabled
+	}
+	return e.SelectiveRetest[org]
+}
+
+// QueueCommentsFor returns true if the pool PRs of the repository get a comment explaining why they are not merged yet,
+// falling back to the setting of its org
+func (e *Extension) QueueCommentsFor(org, repo string) bool {
+	if enabled, ok := e.QueueComments[org+"/"+repo]; ok {
+		return enabled
+	}
+	return e.QueueComments[org]
+}
+
+// repoLimit returns the limit of the repository, falling back to the limit of its org
+func repoLimit(limits map[string]int, org, repo string) int {
+	if limit, ok := limits[org+"/"+repo]; ok {
+		return limit
+	}
+	return limits[org]
+}
+
+// LoadExtension reads the Extension from the config.yaml file
+func LoadExtension(fileName string) (*Extension, error) {
+	data, err := ioutil.ReadFile(fileName) // #nosec
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading config file %s", fileName)
+	}
+	answer := struct {
+		Keeper Extension `json:"keeper,omitempty"`
+	}{}
+	if err := yaml.Unmarshal(data, &answer); err != nil {
+		return nil, errors.Wrapf(err, "parsing config file %s", fileName)
+	}
+	return &answer.Keeper, nil
+}
+
+// extensionLoader reloads the Extension whenever the config file changes
+type extensionLoader struct {
+	fileName string
+
+	lock      sync.Mutex
+	modTime   time.Time
+	extension *Extension
+}
+
+var keeperExtension *extensionLoader
+
+// WatchExtension makes keeper use the Extension of the config file
+func WatchExtension(configPath string) {
+	keeperExtension = &extensionLoader{fileName: configPath}
+}
+
+// get returns the current Extension, keeping the previous one if the file cannot be loaded
+func (l *extensionLoader) get() *Extension {
+	if l == nil {
+		return &Extension{}
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if info, err := os.Stat(l.fileName); err != nil {
+		logrus.WithError(err).Warnf("failed to find config file %s", l.fileName)
+	} else if !info.ModTime().Equal(l.modTime) {
+		loaded, err := LoadExtension(l.fileName)
+		if err != nil {
+			logrus.WithError(err).Warn("failed to load the keeper extension")
+		} else {
+			l.extension = loaded
+			l.modTime = info.ModTime()
+		}
+	}
+	if l.extension == nil {
+		return &Extension{}
+	}
+	return l.extension
+}
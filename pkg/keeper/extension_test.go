@@ -0,0 +1,172 @@
+package keeper
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	githubql "github.com/shurcooL/githubv4"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const extensionConfig = `keeper:
+  squash_label: keeper/squash
+  rebase_label: keeper/rebase
+  allowed_merge_methods:
+    org: [merge, squash]
+    org/anything: []
+  max_merges_per_hour:
+    org: 2
+    org/batched: 10
+  max_batch_size:
+    org/batched: 4
+  selective_retest:
+    org: true
+    org/anything: false
+  queue_comments:
+    org/repo: true
+  queries:
+  - repos:
+    - org/repo
+    labels:
+    - approved
+  - repos:
+    - org/other
+    milestone: v1.0
+    author: release-bot
+    assignee: releaser
+    requiredApprovingReviews: 2
+    codeOwnersApprovalRequired: true
+`
+
+func writeExtensionConfig(t *testing.T) string {
+	fileName := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, ioutil.WriteFile(fileName, []byte(extensionConfig), 0600))
+	return fileName
+}
+
+func TestLoadExtension(t *testing.T) {
+	l := &extensionLoader{fileName: writeExtensionConfig(t)}
+	extension := l.get()
+
+	assert.Equal(t, QueryExtension{}, extension.Query(0))
+	assert.Equal(t, QueryExtension{
+		ReviewRequirements: ReviewRequirements{RequiredApprovingReviews: 2, CodeOwnersApprovalRequired: true},
+		Author:             "release-bot",
+		Assignee:           "releaser",
+	}, extension.Query(1))
+	assert.Equal(t, QueryExtension{}, extension.Query(2))
+
+	assert.True(t, extension.MergeMethodAllowed("org", "repo", config.MergeSquash))
+	assert.False(t, extension.MergeMethodAllowed("org", "repo", config.MergeRebase))
+	assert.False(t, extension.MergeMethodAllowed("org", "anything", config.MergeMerge), "the repository overrides its org")
+	assert.True(t, extension.MergeMethodAllowed("other", "repo", config.MergeRebase), "any method is allowed by default")
+
+	assert.Equal(t, 2, extension.MergesPerHourLimit("org", "repo"))
+	assert.Equal(t, 0, extension.MergesPerHourLimit("other", "repo"))
+	assert.Equal(t, 2, extension.BatchSizeLimit("org", "repo", 0), "a batch should not exceed the merges per hour")
+	assert.Equal(t, 4, extension.BatchSizeLimit("org", "batched", 5))
+	assert.Equal(t, 3, extension.BatchSizeLimit("org", "batched", 3))
+	assert.Equal(t, 0, extension.BatchSizeLimit("other", "repo", 0))
+
+	assert.True(t, extension.SelectiveRetestFor("org", "repo"))
+	assert.False(t, extension.SelectiveRetestFor("org", "anything"), "the repository overrides its org")
+	assert.False(t, extension.SelectiveRetestFor("other", "repo"))
+
+	assert.True(t, extension.QueueCommentsFor("org", "repo"))
+	assert.False(t, extension.QueueCommentsFor("org", "anything"))
+
+	var unset *extensionLoader
+	assert.Equal(t, QueryExtension{}, unset.get().Query(0))
+	assert.True(t, unset.get().MergeMethodAllowed("org", "repo", config.MergeRebase))
+}
+
+func TestMergePRsAllowedMergeMethods(t *testing.T) {
+	sleep = func(time.Duration) {}
+	defer func() { sleep = time.Sleep }()
+	WatchExtension(writeExtensionConfig(t))
+	defer func() { keeperExtension = nil }()
+
+	ca := &config.Agent{}
+	ca.Set(&config.Config{ProwConfig: config.ProwConfig{Keeper: config.Keeper{
+		SquashLabel: "keeper/squash",
+		RebaseLabel: "keeper/rebase",
+	}}})
+	pr := func(number int, label string) PullRequest {
+		var pr PullRequest
+		pr.Number = githubql.Int(number)
+		if label != "" {
+			pr.Labels.Nodes = append(pr.Labels.Nodes, struct{ Name githubql.String }{Name: githubql.String(label)})
+		}
+		return pr
+	}
+	fgc := &fgc{}
+	c := &DefaultController{
+		logger: logrus.WithField("controller", "keeper"),
+		config: ca.Config,
+		spc:    fgc,
+	}
+	sp := subpool{log: c.logger, org: "org", repo: "repo", branch: "master"}
+
+	err := c.mergePRs(sp, []PullRequest{pr(1, ""), pr(2, "keeper/squash"), pr(3, "keeper/rebase")})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "[3]")
+	assert.Equal(t, 2, fgc.merged)
+}
+
+func TestTakeActionMergeRateLimit(t *testing.T) {
+	sleep = func(time.Duration) {}
+	defer func() { sleep = time.Sleep }()
+	WatchExtension(writeExtensionConfig(t))
+	defer func() { keeperExtension = nil }()
+
+	ca := &config.Agent{}
+	ca.Set(&config.Config{})
+	pr := func(number int) PullRequest {
+		var pr PullRequest
+		pr.Number = githubql.Int(number)
+		pr.Commits.Nodes = []struct {
+			Commit Commit
+		}{{Commit: Commit{OID: githubql.String(fmt.Sprintf("sha-%d", number))}}}
+		return pr
+	}
+	fgc := &fgc{ignoreExpected: true}
+	c := &DefaultController{
+		logger: logrus.WithField("controller", "keeper"),
+		config: ca.Config,
+		spc:    fgc,
+	}
+	sp := subpool{log: c.logger, cc: &config.KeeperContextPolicy{}, org: "org", repo: "repo", branch: "master"}
+
+	act, _, err := c.takeAction(sp, nil, nil, nil, nil, []PullRequest{pr(1), pr(2), pr(3)}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, Action(MergeRateLimited), act, "the batch is larger than the merges per hour")
+	assert.Equal(t, 0, fgc.merged)
+
+	act, _, err = c.takeAction(sp, nil, []PullRequest{pr(1)}, nil, nil, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, Action(Merge), act)
+	act, _, err = c.takeAction(sp, nil, nil, nil, nil, []PullRequest{pr(2), pr(3)}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, Action(MergeRateLimited), act, "a single merge is left in the hour")
+	act, _, err = c.takeAction(sp, nil, []PullRequest{pr(2)}, nil, nil, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, Action(Merge), act)
+	act, _, err = c.takeAction(sp, nil, []PullRequest{pr(3)}, nil, nil, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, Action(MergeRateLimited), act)
+	assert.Equal(t, 2, fgc.merged)
+
+	// the merges of other repositories are not limited by the repository
+	other := sp
+	other.org = "other"
+	act, _, err = c.takeAction(other, nil, []PullRequest{pr(3)}, nil, nil, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, Action(Merge), act)
+	assert.Equal(t, 3, fgc.merged)
+}
@@ -0,0 +1,62 @@
+package keeper
+
+import (
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+)
+
+// mergeGateStates returns the best state of each merge gate run against the pull request on top of the base SHA of
+// the subpool, i.e. against the exact result of merging it
+func mergeGateStates(sp subpool, pr PullRequest) map[string]simpleState {
+	states := map[string]simpleState{}
+	for _, pj := range sp.pjs {
+		if pj.Spec.Type != config.PresubmitJob || len(pj.Spec.Refs.Pulls) != 1 {
+			continue
+		}
+		if pull := pj.Spec.Refs.Pulls[0]; pull.Number != int(pr.Number) || pull.SHA != string(pr.HeadRefOID) {
+			continue
+		}
+		oldState, newState := states[pj.Spec.Context], toSimpleState(pj.Status.State)
+		if oldState == failureState || oldState == "" || (oldState == pendingState && newState == successState) {
+			states[pj.Spec.Context] = newState
+		}
+	}
+	return states
+}
+
+// withoutFailedMergeGates filters out the pull requests a merge gate failed for, which are not merged until their
+// head or the base branch changes
+func withoutFailedMergeGates(sp subpool, prs []PullRequest) []PullRequest {
+	if len(sp.gates) == 0 {
+		return prs
+	}
+	var answer []PullRequest
+	for _, pr := range prs {
+		states := mergeGateStates(sp, pr)
+		failed := false
+		for _, gate := range sp.gates {
+			if states[gate.Context] == failureState {
+				sp.log.WithFields(pr.logFields()).Debugf("merge gate %s failed", gate.Context)
+				failed = true
+			}
+		}
+		if !failed {
+			answer = append(answer, pr)
+		}
+	}
+	return answer
+}
+
+// pendingMergeGates returns the merge gates which have not run yet against the pull request and whether some are
+// still running
+func pendingMergeGates(sp subpool, pr PullRequest) (missing []config.Presubmit, running bool) {
+	states := mergeGateStates(sp, pr)
+	for _, gate := range sp.gates {
+		switch states[gate.Context] {
+		case "":
+			missing = append(missing, gate)
+		case pendingState:
+			running = true
+		}
+	}
+	return missing, running
+}
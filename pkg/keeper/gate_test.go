@@ -0,0 +1,79 @@
+package keeper
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	launcherfake "github.com/jenkins-x/lighthouse/pkg/launcher/fake"
+	githubql "github.com/shurcooL/githubv4"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTakeActionMergeGate(t *testing.T) {
+	var pr PullRequest
+	pr.Number = 1
+	pr.HeadRefOID = "head"
+	pr.Commits.Nodes = []struct {
+		Commit Commit
+	}{{Commit: Commit{OID: "head", Status: struct{ Contexts []Context }{Contexts: []Context{
+		{Context: "unit", State: githubql.StatusStateSuccess},
+		{Context: "gate", State: githubql.StatusStatePending},
+	}}}}}
+
+	ca := &config.Agent{}
+	ca.Set(&config.Config{})
+	fgc := &fgc{}
+	launcher := launcherfake.NewLauncher()
+	c := &DefaultController{
+		logger:         logrus.WithField("controller", "keeper"),
+		config:         ca.Config,
+		spc:            fgc,
+		launcherClient: launcher,
+	}
+	gate := config.Presubmit{JobBase: config.JobBase{Name: "gate"}, Reporter: config.Reporter{Context: "gate"}}
+	sp := subpool{
+		log:    c.logger,
+		cc:     &config.KeeperContextPolicy{},
+		org:    "org",
+		repo:   "repo",
+		branch: "master",
+		sha:    "base",
+		prs:    []PullRequest{pr},
+		gates:  []config.Presubmit{gate},
+	}
+
+	act, _, err := c.takeAction(sp, nil, []PullRequest{pr}, nil, nil, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, Action(TriggerMergeGate), act, "the pending context of the gate should be ignored")
+	require.Len(t, launcher.Pipelines, 1)
+	run := launcher.Pipelines[0]
+	assert.Equal(t, "gate", run.Spec.Job)
+	assert.Equal(t, "base", run.Spec.Refs.BaseSHA)
+	assert.Equal(t, "head", run.Spec.Refs.Pulls[0].SHA)
+
+	run.Status.State = v1alpha1.PendingState
+	sp.pjs = []v1alpha1.LighthouseJob{*run}
+	act, _, err = c.takeAction(sp, nil, []PullRequest{pr}, nil, nil, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, Wait, act)
+	assert.Len(t, launcher.Pipelines, 1)
+
+	sp.pjs[0].Status.State = v1alpha1.FailureState
+	act, _, err = c.takeAction(sp, nil, []PullRequest{pr}, nil, nil, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, Wait, act, "the gate should not be run again once it failed")
+	assert.Len(t, launcher.Pipelines, 1)
+	assert.Equal(t, 0, fgc.merged)
+
+	// a later successful run wins
+	retest := sp.pjs[0]
+	retest.Status.State = v1alpha1.SuccessState
+	sp.pjs = append(sp.pjs, retest)
+	act, _, err = c.takeAction(sp, nil, []PullRequest{pr}, nil, nil, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, Action(Merge), act)
+	assert.Equal(t, 1, fgc.merged)
+}
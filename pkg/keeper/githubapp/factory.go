@@ -1,11 +1,11 @@
 package githubapp
 
 import (
-	"github.com/jenkins-x/go-scm/scm/factory"
 	"github.com/jenkins-x/jx/v2/pkg/jxfactory"
 	"github.com/jenkins-x/lighthouse-config/pkg/config"
 	"github.com/jenkins-x/lighthouse/pkg/clients"
 	"github.com/jenkins-x/lighthouse/pkg/git"
+	"github.com/jenkins-x/lighthouse/pkg/gitprovider"
 	"github.com/jenkins-x/lighthouse/pkg/keeper"
 	"github.com/jenkins-x/lighthouse/pkg/launcher"
 	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
@@ -15,7 +15,7 @@ import (
 
 // NewKeeperController creates a new controller; either regular or a GitHub App flavour
 // depending on the $GITHUB_APP_SECRET_DIR environment variable
-func NewKeeperController(configAgent *config.Agent, botName string, gitKind string, gitToken string, serverURL string, maxRecordsPerPool int, historyURI string, statusURI string) (keeper.Controller, error) {
+func NewKeeperController(configAgent *config.Agent, botName string, gitKind string, gitToken string, serverURL string, maxRecordsPerPool int, historyURI string, statusURI string, dryRun bool) (keeper.Controller, error) {
 	clientFactory := jxfactory.NewFactory()
 	mpClient, err := launcher.NewMetaPipelineClient(clientFactory)
 	if err != nil {
@@ -23,16 +23,19 @@ func NewKeeperController(configAgent *config.Agent, botName string, gitKind stri
 	}
 	githubAppSecretDir := util.GetGitHubAppSecretDir()
 	if githubAppSecretDir != "" {
-		return NewGitHubAppKeeperController(githubAppSecretDir, configAgent, mpClient, botName, gitKind, maxRecordsPerPool, historyURI, statusURI)
+		return NewGitHubAppKeeperController(githubAppSecretDir, configAgent, mpClient, botName, gitKind, maxRecordsPerPool, historyURI, statusURI, dryRun)
 	}
 
-	scmClient, err := factory.NewClient(gitKind, serverURL, "")
+	scmClient, err := gitprovider.NewSCMClient(gitKind, serverURL, botName, "")
 	if err != nil {
 		return nil, errors.Wrap(err, "cannot create SCM client")
 	}
+	if err := gitprovider.UseProxy(scmClient, gitprovider.Default().ProxyURL()); err != nil {
+		return nil, err
+	}
 	util.AddAuthToSCMClient(scmClient, gitToken, false)
 	gitproviderClient := scmprovider.ToClient(scmClient, botName)
-	gitClient, err := git.NewClient(serverURL, botName)
+	gitClient, err := git.NewClient(serverURL, gitKind)
 	if err != nil {
 		return nil, errors.Wrap(err, "creating git client")
 	}
@@ -40,14 +43,14 @@ func NewKeeperController(configAgent *config.Agent, botName string, gitKind stri
 		return []byte(gitToken)
 	})
 
-	tektonClient, jxClient, _, lhClient, ns, err := clients.GetClientsAndNamespace(nil)
+	tektonClient, jxClient, kubeClient, lhClient, ns, err := clients.GetClientsAndNamespace(nil)
 	if err != nil {
 		return nil, errors.Wrap(err, "Error creating kubernetes resource clients.")
 	}
-	launcherClient, err := launcher.NewLauncher(jxClient, lhClient, ns)
+	launcherClient, err := launcher.NewLauncher(jxClient, tektonClient, lhClient, kubeClient, ns)
 	if err != nil {
 		return nil, errors.Wrap(err, "Error getting PipelineLauncher client.")
 	}
-	c, err := keeper.NewController(gitproviderClient, gitproviderClient, launcherClient, mpClient, tektonClient, lhClient, ns, configAgent.Config, gitClient, maxRecordsPerPool, historyURI, statusURI, nil)
+	c, err := keeper.NewController(gitproviderClient, gitproviderClient, launcherClient, mpClient, tektonClient, lhClient, ns, configAgent.Config, gitClient, maxRecordsPerPool, historyURI, statusURI, dryRun, nil)
 	return c, err
 }
@@ -13,6 +13,7 @@ import (
 	"github.com/jenkins-x/lighthouse-config/pkg/config"
 	"github.com/jenkins-x/lighthouse/pkg/clients"
 	"github.com/jenkins-x/lighthouse/pkg/git"
+	"github.com/jenkins-x/lighthouse/pkg/gitprovider"
 	"github.com/jenkins-x/lighthouse/pkg/keeper"
 	"github.com/jenkins-x/lighthouse/pkg/keeper/history"
 	"github.com/jenkins-x/lighthouse/pkg/launcher"
@@ -34,13 +35,14 @@ type gitHubAppKeeperController struct {
 	maxRecordsPerPool  int
 	historyURI         string
 	statusURI          string
+	dryRun             bool
 	logger             *logrus.Entry
 	m                  sync.Mutex
 }
 
 // NewGitHubAppKeeperController creates a GitHub App style controller which needs to process each github owner
 // using a separate git provider client due to the way GitHub App tokens work
-func NewGitHubAppKeeperController(githubAppSecretDir string, configAgent *config.Agent, mpClient metapipeline.Client, botName string, gitKind string, maxRecordsPerPool int, historyURI string, statusURI string) (keeper.Controller, error) {
+func NewGitHubAppKeeperController(githubAppSecretDir string, configAgent *config.Agent, mpClient metapipeline.Client, botName string, gitKind string, maxRecordsPerPool int, historyURI string, statusURI string, dryRun bool) (keeper.Controller, error) {
 
 	gitServer := util.GithubServer
 	return &gitHubAppKeeperController{
@@ -53,6 +55,7 @@ func NewGitHubAppKeeperController(githubAppSecretDir string, configAgent *config
 		maxRecordsPerPool: maxRecordsPerPool,
 		historyURI:        historyURI,
 		statusURI:         statusURI,
+		dryRun:            dryRun,
 		logger:            logrus.NewEntry(logrus.StandardLogger()),
 	}, nil
 
@@ -161,6 +164,9 @@ func (g *gitHubAppKeeperController) createOwnerController(owner string, configGe
 	if err != nil {
 		return nil, errors.Wrap(err, "cannot create SCM client")
 	}
+	if err := gitprovider.UseProxy(scmClient, gitprovider.Default().ProxyURL()); err != nil {
+		return nil, err
+	}
 	util.AddAuthToSCMClient(scmClient, token, true)
 	gitproviderClient := scmprovider.ToClient(scmClient, g.botName)
 	gitClient, err := git.NewClient(g.gitServer, g.gitKind)
@@ -170,15 +176,15 @@ func (g *gitHubAppKeeperController) createOwnerController(owner string, configGe
 	gitClient.SetCredentials(util.GitHubAppGitRemoteUsername, func() []byte {
 		return []byte(token)
 	})
-	tektonClient, jxClient, _, lhClient, ns, err := clients.GetClientsAndNamespace(nil)
+	tektonClient, jxClient, kubeClient, lhClient, ns, err := clients.GetClientsAndNamespace(nil)
 	if err != nil {
 		return nil, errors.Wrap(err, "Error creating kubernetes resource clients.")
 	}
-	launcherClient, err := launcher.NewLauncher(jxClient, lhClient, ns)
+	launcherClient, err := launcher.NewLauncher(jxClient, tektonClient, lhClient, kubeClient, ns)
 	if err != nil {
 		return nil, errors.Wrap(err, "Error getting PipelineLauncher client.")
 	}
-	c, err := keeper.NewController(gitproviderClient, gitproviderClient, launcherClient, g.mpClient, tektonClient, lhClient, ns, configGetter, gitClient, g.maxRecordsPerPool, g.historyURI, g.statusURI, nil)
+	c, err := keeper.NewController(gitproviderClient, gitproviderClient, launcherClient, g.mpClient, tektonClient, lhClient, ns, configGetter, gitClient, g.maxRecordsPerPool, g.historyURI, g.statusURI, g.dryRun, nil)
 	return c, err
 }
 
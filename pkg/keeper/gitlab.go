@@ -0,0 +1,53 @@
+package keeper
+
+import (
+	"fmt"
+
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	"github.com/pkg/errors"
+)
+
+// gitlabMergeBlocker returns why the approval rules or the pipeline of a GitLab merge request prevent keeper from
+// merging it, as configured by the GitLab settings of the repository, or an empty string if none does
+func gitlabMergeBlocker(spc scmProviderClient, settings GitLabSettings, org, repo string, pr *PullRequest) (string, error) {
+	number := int(pr.Number)
+	if settings.RequireApprovals {
+		approvals, err := spc.GetMergeRequestApprovals(org, repo, number)
+		if err != nil {
+			return "", errors.Wrap(err, "getting the approvals")
+		}
+		if !approvals.Approved {
+			return fmt.Sprintf("%d of the %d approvals required by the approval rules are missing", approvals.ApprovalsLeft, approvals.ApprovalsRequired), nil
+		}
+	}
+	if settings.RequirePipeline {
+		pipelines, err := spc.ListMergeRequestPipelines(org, repo, number)
+		if err != nil {
+			return "", errors.Wrap(err, "listing the pipelines")
+		}
+		pipeline := headPipeline(pipelines, string(pr.HeadRefOID))
+		switch {
+		case pipeline == nil:
+			return "no pipeline ran on the head commit", nil
+		case pipeline.Status == scmprovider.PipelineSuccess:
+		case pipeline.Status == scmprovider.PipelineFailed || pipeline.Status == scmprovider.PipelineCanceled || pipeline.Status == scmprovider.PipelineSkipped:
+			return fmt.Sprintf("the pipeline %d is %s", pipeline.ID, pipeline.Status), nil
+		case pipeline.Status == scmprovider.PipelineManual:
+			return fmt.Sprintf("the pipeline %d is waiting for a manual action", pipeline.ID), nil
+		case !settings.MergeWhenPipelineSucceeds:
+			return fmt.Sprintf("the pipeline %d is %s", pipeline.ID, pipeline.Status), nil
+		}
+	}
+	return "", nil
+}
+
+// headPipeline returns the most recent pipeline of the head commit, ignoring the external pipelines which GitLab
+// creates for the commit statuses
+func headPipeline(pipelines []*scmprovider.Pipeline, sha string) *scmprovider.Pipeline {
+	for _, pipeline := range pipelines {
+		if pipeline.SHA == sha && pipeline.Source != "external" {
+			return pipeline
+		}
+	}
+	return nil
+}
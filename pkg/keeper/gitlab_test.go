@@ -0,0 +1,105 @@
+package keeper
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeGitLab serves the approvals and pipelines of merge requests
+type fakeGitLab struct {
+	fgc
+	approvals *scmprovider.MergeRequestApprovals
+	pipelines []*scmprovider.Pipeline
+}
+
+func (f *fakeGitLab) ProviderType() string {
+	return "gitlab"
+}
+
+func (f *fakeGitLab) GetMergeRequestApprovals(org, repo string, number int) (*scmprovider.MergeRequestApprovals, error) {
+	return f.approvals, nil
+}
+
+func (f *fakeGitLab) ListMergeRequestPipelines(org, repo string, number int) ([]*scmprovider.Pipeline, error) {
+	return f.pipelines, nil
+}
+
+func TestGitLabMergeBlocker(t *testing.T) {
+	approved := &scmprovider.MergeRequestApprovals{Approved: true, ApprovalsRequired: 2}
+	pipeline := func(id int, sha, status, source string) *scmprovider.Pipeline {
+		return &scmprovider.Pipeline{ID: id, SHA: sha, Status: status, Source: source}
+	}
+	testCases := []struct {
+		name      string
+		settings  GitLabSettings
+		approvals *scmprovider.MergeRequestApprovals
+		pipelines []*scmprovider.Pipeline
+		expected  string
+	}{
+		{
+			name:     "nothing required",
+			expected: "",
+		},
+		{
+			name:      "approved",
+			settings:  GitLabSettings{RequireApprovals: true},
+			approvals: approved,
+			expected:  "",
+		},
+		{
+			name:      "missing approvals",
+			settings:  GitLabSettings{RequireApprovals: true},
+			approvals: &scmprovider.MergeRequestApprovals{ApprovalsRequired: 2, ApprovalsLeft: 1},
+			expected:  "1 of the 2 approvals required by the approval rules are missing",
+		},
+		{
+			name:      "successful pipeline",
+			settings:  GitLabSettings{RequireApprovals: true, RequirePipeline: true},
+			approvals: approved,
+			pipelines: []*scmprovider.Pipeline{pipeline(3, "head", "running", "external"), pipeline(2, "head", "success", "merge_request_event"), pipeline(1, "old", "failed", "push")},
+			expected:  "",
+		},
+		{
+			name:      "no pipeline of the head commit",
+			settings:  GitLabSettings{RequirePipeline: true},
+			pipelines: []*scmprovider.Pipeline{pipeline(1, "old", "success", "push")},
+			expected:  "no pipeline ran on the head commit",
+		},
+		{
+			name:      "failed pipeline",
+			settings:  GitLabSettings{RequirePipeline: true, MergeWhenPipelineSucceeds: true},
+			pipelines: []*scmprovider.Pipeline{pipeline(2, "head", "failed", "push")},
+			expected:  "the pipeline 2 is failed",
+		},
+		{
+			name:      "running pipeline",
+			settings:  GitLabSettings{RequirePipeline: true},
+			pipelines: []*scmprovider.Pipeline{pipeline(2, "head", "running", "push")},
+			expected:  "the pipeline 2 is running",
+		},
+		{
+			name:      "running pipeline merged when it succeeds",
+			settings:  GitLabSettings{RequirePipeline: true, MergeWhenPipelineSucceeds: true},
+			pipelines: []*scmprovider.Pipeline{pipeline(2, "head", "running", "push")},
+			expected:  "",
+		},
+		{
+			name:      "manual pipeline",
+			settings:  GitLabSettings{RequirePipeline: true, MergeWhenPipelineSucceeds: true},
+			pipelines: []*scmprovider.Pipeline{pipeline(2, "head", "manual", "push")},
+			expected:  "the pipeline 2 is waiting for a manual action",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			spc := &fakeGitLab{approvals: tc.approvals, pipelines: tc.pipelines}
+			pr := &PullRequest{Number: 1, HeadRefOID: "head"}
+			blocker, err := gitlabMergeBlocker(spc, tc.settings, "org", "repo", pr)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, blocker)
+		})
+	}
+}
@@ -40,6 +40,9 @@ import (
 	"github.com/jenkins-x/lighthouse/pkg/jobutil"
 	"github.com/jenkins-x/lighthouse/pkg/keeper/blockers"
 	"github.com/jenkins-x/lighthouse/pkg/keeper/history"
+	"github.com/jenkins-x/lighthouse/pkg/labels"
+	"github.com/jenkins-x/lighthouse/pkg/notifier"
+	"github.com/jenkins-x/lighthouse/pkg/requiredjobs"
 	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
 	"github.com/jenkins-x/lighthouse/pkg/util"
 	"github.com/pkg/errors"
@@ -70,6 +73,8 @@ type scmProviderClient interface {
 	ProviderType() string
 	GetRepositoryByFullName(string) (*scm.Repository, error)
 	ListAllPullRequestsForFullNameRepo(string, scm.PullRequestListOptions) ([]*scm.PullRequest, error)
+	GetMergeRequestApprovals(org, repo string, number int) (*scmprovider.MergeRequestApprovals, error)
+	ListMergeRequestPipelines(org, repo string, number int) ([]*scmprovider.Pipeline, error)
 }
 
 type contextChecker interface {
@@ -84,6 +89,7 @@ type DefaultController struct {
 	logger         *logrus.Entry
 	config         config.Getter
 	spc            scmProviderClient
+	reviews        reviewClient
 	launcherClient launcher
 	gc             git.Client
 	mpClient       metapipeline.Client
@@ -91,6 +97,9 @@ type DefaultController struct {
 	lhClient       clientset.Interface
 	ns             string
 
+	// dryRun is true if keeper only logs and exports what it would do
+	dryRun bool
+
 	sc *statusController
 
 	m     sync.Mutex
@@ -100,6 +109,23 @@ type DefaultController struct {
 	// Cache entries expire if they are not used during a sync loop.
 	changedFiles *changedFilesAgent
 
+	// mergeBases caches whether the heads of PRs contain the base of their branch.
+	mergeBases mergeBaseAgent
+
+	// baseChanges caches the files changed on the branches between their commits.
+	baseChanges baseChangesAgent
+
+	// merges remembers the recent merges of each repository to limit the merges per hour.
+	merges mergeHistory
+
+	// poolEntries remembers when the PRs were first found in the pool.
+	poolEntries poolEntries
+
+	// comments writes the comments explaining why the pool PRs are not merged yet, if enabled.
+	comments queueCommentClient
+	// queueComments remembers the comments written on the pool PRs.
+	queueComments queueComments
+
 	History *history.History
 }
 
@@ -109,21 +135,24 @@ type Action string
 
 // Constants for various actions the controller might take
 const (
-	Wait         Action = "WAIT"
-	Trigger             = "TRIGGER"
-	TriggerBatch        = "TRIGGER_BATCH"
-	Merge               = "MERGE"
-	MergeBatch          = "MERGE_BATCH"
-	PoolBlocked         = "BLOCKED"
+	Wait             Action = "WAIT"
+	Trigger                 = "TRIGGER"
+	TriggerBatch            = "TRIGGER_BATCH"
+	Merge                   = "MERGE"
+	MergeBatch              = "MERGE_BATCH"
+	PoolBlocked             = "BLOCKED"
+	MergeRateLimited        = "RATE_LIMITED"
+	TriggerMergeGate        = "TRIGGER_MERGE_GATE"
 )
 
 // recordableActions is the subset of actions that we keep historical record of.
 // Ignore idle actions to avoid flooding the records with useless data.
 var recordableActions = map[Action]bool{
-	Trigger:      true,
-	TriggerBatch: true,
-	Merge:        true,
-	MergeBatch:   true,
+	Trigger:          true,
+	TriggerBatch:     true,
+	TriggerMergeGate: true,
+	Merge:            true,
+	MergeBatch:       true,
 }
 
 // Pool represents information about a keeper pool. There is one for every
@@ -148,6 +177,15 @@ type Pool struct {
 	Target   []PullRequest
 	Blockers []blockers.Blocker
 	Error    string
+
+	// ContextPolicy is the policy the status contexts of the PRs are checked against, listing the contexts
+	// which are required, required if present or optional and whether unknown contexts are skipped.
+	ContextPolicy *config.KeeperContextPolicy `json:",omitempty"`
+	// ConditionalContexts are the contexts of the required presubmits which are only required for the PRs
+	// whose changes make them run.
+	ConditionalContexts []string `json:",omitempty"`
+	// DryRun is true if the Action was only logged, keeper running in dry run mode.
+	DryRun bool `json:",omitempty"`
 }
 
 // Prometheus Metrics
@@ -160,6 +198,7 @@ var (
 
 		// Singleton
 		syncDuration         prometheus.Gauge
+		syncDurations        prometheus.Histogram
 		statusUpdateDuration prometheus.Gauge
 	}{
 		pooledPRs: prometheus.NewGaugeVec(prometheus.GaugeOpts{
@@ -194,6 +233,12 @@ var (
 			Help: "The duration of the last loop of the sync controller.",
 		}),
 
+		syncDurations: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "lighthouse_slo_keeper_sync_duration_seconds",
+			Help:    "The duration of the loops of the sync controller.",
+			Buckets: prometheus.ExponentialBuckets(1, 2, 10),
+		}),
+
 		statusUpdateDuration: prometheus.NewGauge(prometheus.GaugeOpts{
 			Name: "statusupdatedur",
 			Help: "The duration of the last loop of the status update controller.",
@@ -206,11 +251,14 @@ func init() {
 	prometheus.MustRegister(keeperMetrics.updateTime)
 	prometheus.MustRegister(keeperMetrics.merges)
 	prometheus.MustRegister(keeperMetrics.syncDuration)
+	prometheus.MustRegister(keeperMetrics.syncDurations)
 	prometheus.MustRegister(keeperMetrics.statusUpdateDuration)
 }
 
-// NewController makes a DefaultController out of the given clients.
-func NewController(spcSync, spcStatus *scmprovider.Client, launcherClient launcher, mpClient metapipeline.Client, tektonClient tektonclient.Interface, lighthouseClient clientset.Interface, ns string, cfg config.Getter, gc git.Client, maxRecordsPerPool int, historyURI, statusURI string, logger *logrus.Entry) (*DefaultController, error) {
+// NewController makes a DefaultController out of the given clients. In dry run mode, the controller evaluates the
+// pools and picks the batches as usual but only logs and exports what it would merge or test, without merging,
+// launching jobs, rerunning PipelineRuns or writing statuses and comments.
+func NewController(spcSync, spcStatus *scmprovider.Client, launcherClient launcher, mpClient metapipeline.Client, tektonClient tektonclient.Interface, lighthouseClient clientset.Interface, ns string, cfg config.Getter, gc git.Client, maxRecordsPerPool int, historyURI, statusURI string, dryRun bool, logger *logrus.Entry) (*DefaultController, error) {
 	if logger == nil {
 		logger = logrus.NewEntry(logrus.StandardLogger())
 	}
@@ -225,16 +273,20 @@ func NewController(spcSync, spcStatus *scmprovider.Client, launcherClient launch
 		newPoolPending: make(chan bool, 1),
 		shutDown:       make(chan bool),
 		path:           statusURI,
+		dryRun:         dryRun,
 	}
 	go sc.run()
 	return &DefaultController{
 		logger:         logger.WithField("controller", "sync"),
 		spc:            spcSync,
+		reviews:        spcSync,
+		comments:       spcSync,
 		launcherClient: launcherClient,
 		mpClient:       mpClient,
 		tektonClient:   tektonClient,
 		lhClient:       lighthouseClient,
 		ns:             ns,
+		dryRun:         dryRun,
 		config:         cfg,
 		gc:             gc,
 		sc:             sc,
@@ -303,14 +355,18 @@ func (c *DefaultController) Sync() error {
 		duration := time.Since(start)
 		c.logger.WithField("duration", duration.String()).Info("Synced")
 		keeperMetrics.syncDuration.Set(duration.Seconds())
+		keeperMetrics.syncDurations.Observe(duration.Seconds())
 	}()
 	defer c.changedFiles.prune()
+	defer c.mergeBases.prune()
+	defer c.baseChanges.prune()
 
 	c.logger.Debug("Building keeper pool.")
 	prs := make(map[string]PullRequest)
 	if c.spc.SupportsGraphQL() {
-		for _, query := range c.config().Keeper.Queries {
-			q := query.Query()
+		for i, query := range c.config().Keeper.Queries {
+			extension := keeperExtension.get().Query(i)
+			q := extension.SearchQuery(query.Query())
 			results, err := graphQLSearch(c.spc.Query, c.logger, q, time.Time{}, time.Now())
 			if err != nil && len(results) == 0 {
 				return fmt.Errorf("query %q, err: %v", q, err)
@@ -319,21 +375,25 @@ func (c *DefaultController) Sync() error {
 				c.logger.WithError(err).WithField("query", q).Warning("found partial results")
 			}
 
-			for _, pr := range results {
+			for _, pr := range filterReviewed(c.reviews, c.logger, extension.ReviewRequirements, extension.filter(results)) {
 				p := pr
 				prs[prKey(&p)] = pr
 			}
 		}
 	} else {
-		results, err := restAPISearch(c.spc, c.logger, c.config().Keeper.Queries, time.Time{}, time.Now())
-		if err != nil {
-			c.logger.WithError(err).Warnf("failed to perform REST query for PRs")
-			return errors.Wrapf(err, "failed to perform REST query for PRs")
-		}
+		for i, query := range c.config().Keeper.Queries {
+			// each query is searched on its own so that its extension only applies to its results
+			extension := keeperExtension.get().Query(i)
+			results, err := restAPISearch(c.spc, c.logger, config.KeeperQueries{query}, time.Time{}, time.Now())
+			if err != nil {
+				c.logger.WithError(err).Warnf("failed to perform REST query for PRs")
+				return errors.Wrapf(err, "failed to perform REST query for PRs")
+			}
 
-		for _, pr := range results {
-			p := pr
-			prs[prKey(&p)] = pr
+			for _, pr := range filterReviewed(c.reviews, c.logger, extension.ReviewRequirements, extension.filter(results)) {
+				p := pr
+				prs[prKey(&p)] = pr
+			}
 		}
 	}
 	c.logger.WithField(
@@ -345,7 +405,7 @@ func (c *DefaultController) Sync() error {
 	var err error
 	if len(prs) > 0 {
 		start := time.Now()
-		lhjList, err := c.lhClient.LighthouseV1alpha1().LighthouseJobs(c.ns).List(metav1.ListOptions{})
+		lhjList, err := c.lhClient.LighthouseV1alpha1().LighthouseJobs(c.jobsNamespace()).List(metav1.ListOptions{})
 		if err != nil {
 			c.logger.WithField("duration", time.Since(start).String()).Debug("Failed to list LighthouseJobs from the cluster.")
 			return err
@@ -376,6 +436,7 @@ func (c *DefaultController) Sync() error {
 		return err
 	}
 	filteredPools := c.filterSubpools(c.config().Keeper.MaxGoroutines, rawPools)
+	c.poolEntries.update(filteredPools, now())
 
 	// Notify statusController about the new pool.
 	c.sc.Lock()
@@ -407,11 +468,16 @@ func (c *DefaultController) Sync() error {
 		pools = append(pools, pool)
 	}
 	sortPools(pools)
+	if c.comments != nil && !c.dryRun {
+		c.queueComments.prune(c.comments, c.logger, poolPRMap(filteredPools))
+	}
 	c.m.Lock()
 	c.pools = pools
 	// While we're locked, rerun failed-but-rerunnable PipelineRuns.
 	c.logger.WithField("duration", time.Since(start).String()).Debug("Rerunning PipelineRuns failed due to race condition.")
-	err = rerunPipelineRunsWithRaceConditionFailure(c.tektonClient, c.ns, c.logger)
+	if !c.dryRun {
+		err = rerunPipelineRunsWithRaceConditionFailure(c.tektonClient, c.ns, c.logger)
+	}
 	if err != nil {
 		c.logger.WithError(err).Error("Error rerunning PipelineRuns failed by Tekton race condition")
 	}
@@ -506,10 +572,15 @@ func (c *DefaultController) initSubpoolData(sp *subpool) error {
 	if err != nil {
 		return fmt.Errorf("error determining required presubmit PipelineActivitys: %v", err)
 	}
-	sp.cc, err = c.config().GetKeeperContextPolicy(sp.org, sp.repo, sp.branch)
+	sp.contextPolicy, err = c.config().GetKeeperContextPolicy(sp.org, sp.repo, sp.branch)
 	if err != nil {
 		return fmt.Errorf("error setting up context checker: %v", err)
 	}
+	sp.cc = sp.contextPolicy
+	presubmits := requiredjobs.Presubmits(c.config(), sp.org, sp.repo)
+	sp.conditionalContexts = requiredjobs.ConditionalContexts(presubmits, sp.branch)
+	sp.skippedContexts = requiredjobs.SkippedContexts(presubmits, sp.branch)
+	sp.gates = requiredjobs.MergeGates(presubmits, sp.branch)
 	return nil
 }
 
@@ -541,6 +612,7 @@ func filterSubpool(spc scmProviderClient, sp *subpool) *subpool {
 //   status is preventing merge. Required PipelineActivity statuses are allowed to be
 //   'pending' because this prevents kicking PRs from the pool when Keeper is
 //   retesting them.)
+// - Are GitLab merge requests whose approval rules or pipeline prevent merging them.
 func filterPR(spc scmProviderClient, sp *subpool, pr *PullRequest) bool {
 	log := sp.log.WithFields(pr.logFields())
 	// Skip PRs that are known to be unmergeable.
@@ -548,6 +620,10 @@ func filterPR(spc scmProviderClient, sp *subpool, pr *PullRequest) bool {
 		log.Debug("filtering out PR as it is unmergeable")
 		return true
 	}
+	if t, ok := mergeAfter(pr); ok {
+		log.WithField("mergeAfter", t).Debug("filtering out PR as its merge-after label has not passed")
+		return true
+	}
 	// Filter out PRs with unsuccessful contexts unless the only unsuccessful
 	// contexts are pending required PipelineActivitys.
 	contexts, err := headContexts(log, spc, pr)
@@ -563,7 +639,7 @@ func filterPR(spc scmProviderClient, sp *subpool, pr *PullRequest) bool {
 		}
 		return false
 	}
-	for _, ctx := range unsuccessfulContexts(contexts, sp.cc, log) {
+	for _, ctx := range unsuccessfulContexts(contexts, sp.contextCheckerFor(pr), log) {
 		if ctx.State != githubql.StatusStatePending {
 			log.WithField("context", ctx.Context).Debug("filtering out PR as unsuccessful context is not pending")
 			return true
@@ -573,6 +649,17 @@ func filterPR(spc scmProviderClient, sp *subpool, pr *PullRequest) bool {
 			return true
 		}
 	}
+	if settings := keeperExtension.get().GitLabSettingsFor(sp.org, sp.repo); (settings.RequireApprovals || settings.RequirePipeline) && spc.ProviderType() == "gitlab" {
+		blocker, err := gitlabMergeBlocker(spc, settings, sp.org, sp.repo, pr)
+		if err != nil {
+			log.WithError(err).Error("Checking the GitLab approval rules and pipelines.")
+			return true
+		}
+		if blocker != "" {
+			log.WithField("reason", blocker).Debug("filtering out PR as GitLab prevents merging it")
+			return true
+		}
+	}
 
 	return false
 }
@@ -650,7 +737,7 @@ func unsuccessfulContexts(contexts []Context, cc contextChecker, log *logrus.Ent
 	return failed
 }
 
-func pickSmallestPassingNumber(log *logrus.Entry, spc scmProviderClient, prs []PullRequest, cc contextChecker) (bool, PullRequest) {
+func pickSmallestPassingNumber(log *logrus.Entry, spc scmProviderClient, prs []PullRequest, ccFor func(*PullRequest) contextChecker) (bool, PullRequest) {
 	smallestNumber := -1
 	var smallestPR PullRequest
 	for _, pr := range prs {
@@ -660,7 +747,8 @@ func pickSmallestPassingNumber(log *logrus.Entry, spc scmProviderClient, prs []P
 		if len(pr.Commits.Nodes) < 1 {
 			continue
 		}
-		if !isPassingTests(log, spc, pr, cc) {
+		p := pr
+		if !isPassingTests(log, spc, pr, ccFor(&p)) {
 			continue
 		}
 		smallestNumber = int(pr.Number)
@@ -769,29 +857,7 @@ func accumulate(presubmits map[int][]config.Presubmit, prs []PullRequest, pjs []
 
 	missingTests = map[int][]config.Presubmit{}
 	for _, pr := range prs {
-		// Accumulate the best result for each job (Passing > Pending > Failing/Unknown)
-		// We can ignore the baseSHA here because the subPool only contains PipelineActivitys with the correct baseSHA
-		psStates := make(map[string]simpleState)
-		for _, pj := range pjs {
-			if pj.Spec.Type != config.PresubmitJob {
-				continue
-			}
-			if len(pj.Spec.Refs.Pulls) == 0 || pj.Spec.Refs.Pulls[0].Number != int(pr.Number) {
-				continue
-			}
-			if pj.Spec.Refs.Pulls[0].SHA != string(pr.HeadRefOID) {
-				continue
-			}
-
-			name := pj.Spec.Context
-			oldState := psStates[name]
-			newState := toSimpleState(pj.Status.State)
-			if oldState == failureState || oldState == "" {
-				psStates[name] = newState
-			} else if oldState == pendingState && newState == successState {
-				psStates[name] = successState
-			}
-		}
+		psStates := presubmitStates(pjs, pr)
 		// The overall result for the PR is the worst of the best of all its
 		// required Presubmits
 		overallState := successState
@@ -824,6 +890,34 @@ func accumulate(presubmits map[int][]config.Presubmit, prs []PullRequest, pjs []
 	return
 }
 
+// presubmitStates accumulates the best result of each presubmit context of the head of the PR (Passing > Pending >
+// Failing/Unknown). We can ignore the baseSHA here because the subPool only contains PipelineActivitys with the
+// correct baseSHA.
+func presubmitStates(pjs []v1alpha1.LighthouseJob, pr PullRequest) map[string]simpleState {
+	psStates := make(map[string]simpleState)
+	for _, pj := range pjs {
+		if pj.Spec.Type != config.PresubmitJob {
+			continue
+		}
+		if len(pj.Spec.Refs.Pulls) == 0 || pj.Spec.Refs.Pulls[0].Number != int(pr.Number) {
+			continue
+		}
+		if pj.Spec.Refs.Pulls[0].SHA != string(pr.HeadRefOID) {
+			continue
+		}
+
+		name := pj.Spec.Context
+		oldState := psStates[name]
+		newState := toSimpleState(pj.Status.State)
+		if oldState == failureState || oldState == "" {
+			psStates[name] = newState
+		} else if oldState == pendingState && newState == successState {
+			psStates[name] = successState
+		}
+	}
+	return psStates
+}
+
 func prNumbers(prs []PullRequest) []int {
 	var nums []int
 	for _, pr := range prs {
@@ -832,18 +926,20 @@ func prNumbers(prs []PullRequest) []int {
 	return nums
 }
 
-func (c *DefaultController) pickBatch(sp subpool, cc contextChecker) ([]PullRequest, error) {
+func (c *DefaultController) pickBatch(sp subpool) ([]PullRequest, error) {
 	batchLimit := c.config().Keeper.BatchSizeLimit(sp.org, sp.repo)
 	if batchLimit < 0 {
 		sp.log.Debug("Batch merges disabled by configuration in this repo.")
 		return nil, nil
 	}
+	batchLimit = keeperExtension.get().BatchSizeLimit(sp.org, sp.repo, batchLimit)
 	// we must choose the oldest PRs for the batch
 	sort.Slice(sp.prs, func(i, j int) bool { return sp.prs[i].Number < sp.prs[j].Number })
 
 	var candidates []PullRequest
 	for _, pr := range sp.prs {
-		if isPassingTests(sp.log, c.spc, pr, cc) {
+		p := pr
+		if isPassingTests(sp.log, c.spc, pr, sp.contextCheckerFor(&p)) {
 			candidates = append(candidates, pr)
 		}
 	}
@@ -958,8 +1054,12 @@ func (c *DefaultController) mergePRs(sp subpool, prs []PullRequest) error {
 		rebaseLabel := c.config().Keeper.RebaseLabel
 		mergeLabel := c.config().Keeper.MergeLabel
 		if squashLabel != "" || rebaseLabel != "" || mergeLabel != "" {
+			defaultMethod := mergeMethod
 			var err error
 			mergeMethod, err = checkMergeLabels(pr, squashLabel, rebaseLabel, mergeLabel, mergeMethod)
+			if err == nil && mergeMethod != defaultMethod && !keeperExtension.get().MergeMethodAllowed(sp.org, sp.repo, mergeMethod) {
+				err = fmt.Errorf("the %s merge method requested by the labels is not allowed in %s/%s", mergeMethod, sp.org, sp.repo)
+			}
 			if err != nil {
 				log.WithError(err).Error("Merge failed.")
 				errs = append(errs, err)
@@ -968,8 +1068,16 @@ func (c *DefaultController) mergePRs(sp subpool, prs []PullRequest) error {
 			}
 		}
 
+		if c.dryRun {
+			log.WithField("merge-method", mergeMethod).Info("Dry run: would merge.")
+			continue
+		}
+
 		keepTrying, err := tryMerge(func() error {
 			ghMergeDetails := c.prepareMergeDetails(commitTemplates, pr, mergeMethod)
+			if c.spc.ProviderType() == "gitlab" {
+				ghMergeDetails.MergeWhenPipelineSucceeds = keeperExtension.get().GitLabSettingsFor(sp.org, sp.repo).MergeWhenPipelineSucceeds
+			}
 			return c.spc.Merge(sp.org, sp.repo, int(pr.Number), ghMergeDetails)
 		})
 		if err != nil {
@@ -979,6 +1087,9 @@ func (c *DefaultController) mergePRs(sp subpool, prs []PullRequest) error {
 		} else {
 			log.Info("Merged.")
 			merged = append(merged, int(pr.Number))
+			c.merges.record(sp.org, sp.repo, now())
+			c.recordMergeMetrics(sp, pr, now())
+			notifyMerge(sp, pr)
 		}
 		if !keepTrying {
 			break
@@ -1005,6 +1116,28 @@ func (c *DefaultController) mergePRs(sp subpool, prs []PullRequest) error {
 	return fmt.Errorf("failed merging %v%s: %v", failed, batch, errorutil.NewAggregate(errs...))
 }
 
+// mergeNotifier notifies the pull requests merged by keeper
+var mergeNotifier *notifier.Notifier
+
+// NotifyMerges makes keeper notify the pull requests it merges
+func NotifyMerges(n *notifier.Notifier) {
+	mergeNotifier = n
+}
+
+func notifyMerge(sp subpool, pr PullRequest) {
+	mergeNotifier.Notify(&notifier.Event{
+		Kind:   notifier.Merge,
+		Org:    sp.org,
+		Repo:   sp.repo,
+		Branch: sp.branch,
+		SHA:    string(pr.HeadRefOID),
+		Number: int(pr.Number),
+		Title:  string(pr.Title),
+		Author: string(pr.Author.Login),
+		Link:   string(pr.URL),
+	})
+}
+
 // tryMerge attempts 1 merge and returns a bool indicating if we should try
 // to merge the remaining PRs and possibly an error.
 func tryMerge(mergeFunc func() error) (bool, error) {
@@ -1101,6 +1234,16 @@ func (c *DefaultController) trigger(sp subpool, presubmits map[int][]config.Pres
 				spec = jobutil.BatchSpec(ps, refs)
 			}
 			pj := jobutil.NewLighthouseJob(spec, ps.Labels, ps.Annotations)
+			if c.dryRun {
+				c.logger.WithFields(logrus.Fields{
+					"org":  refs.Org,
+					"repo": refs.Repo,
+					"job":  spec.Job,
+					"type": string(spec.Type),
+					"prs":  prNumbers(prs),
+				}).Info("Dry run: would trigger.")
+				continue
+			}
 			start := time.Now()
 			cloneURL := string(pr.Repository.URL)
 			if cloneURL == "" {
@@ -1116,6 +1259,7 @@ func (c *DefaultController) trigger(sp subpool, presubmits map[int][]config.Pres
 				Branch:    string(pr.BaseRef.Name),
 				Clone:     cloneURL,
 			}
+			keeperTenants().Assign(&pj)
 			if _, err := c.launcherClient.Launch(&pj, c.mpClient, repo); err != nil {
 				c.logger.WithField("duration", time.Since(start).String()).Debug("Failed to create pipeline on the cluster.")
 				return fmt.Errorf("failed to create a pipeline for job: %q, PRs: %v: %v", spec.Job, prNumbers(prs), err)
@@ -1141,14 +1285,30 @@ func (c *DefaultController) trigger(sp subpool, presubmits map[int][]config.Pres
 }
 
 func (c *DefaultController) takeAction(sp subpool, batchPending, successes, pendings, missings, batchMerges []PullRequest, missingSerialTests map[int][]config.Presubmit) (Action, []PullRequest, error) {
+	// Wait for the merges per hour to allow merging, the whole batch at once.
+	allowance := c.mergeAllowance(sp)
 	// Merge the batch!
 	if len(batchMerges) > 0 {
+		if allowance >= 0 && len(batchMerges) > allowance {
+			sp.log.WithField("allowance", allowance).Info("Too many merges in the last hour to merge the batch.")
+			return MergeRateLimited, batchMerges, nil
+		}
 		return MergeBatch, batchMerges, c.mergePRs(sp, batchMerges)
 	}
 	// Do not merge PRs while waiting for a batch to complete. We don't want to
 	// invalidate the old batch result.
 	if len(successes) > 0 && len(batchPending) == 0 {
-		if ok, pr := pickSmallestPassingNumber(sp.log, c.spc, successes, sp.cc); ok {
+		if ok, pr := pickSmallestPassingNumber(sp.log, c.spc, withoutFailedMergeGates(sp, successes), sp.contextCheckerFor); ok {
+			if allowance == 0 {
+				sp.log.Info("Too many merges in the last hour to merge the pull request.")
+				return MergeRateLimited, []PullRequest{pr}, nil
+			}
+			// Run the merge gates against the exact merge result right before merging.
+			if missing, running := pendingMergeGates(sp, pr); len(missing) > 0 {
+				return TriggerMergeGate, []PullRequest{pr}, c.trigger(sp, map[int][]config.Presubmit{int(pr.Number): missing}, []PullRequest{pr})
+			} else if running {
+				return Wait, []PullRequest{pr}, nil
+			}
 			return Merge, []PullRequest{pr}, c.mergePRs(sp, []PullRequest{pr})
 		}
 	}
@@ -1158,7 +1318,7 @@ func (c *DefaultController) takeAction(sp subpool, batchPending, successes, pend
 	}
 	// If we have no batch, trigger one.
 	if len(sp.prs) > 1 && len(batchPending) == 0 {
-		batch, err := c.pickBatch(sp, sp.cc)
+		batch, err := c.pickBatch(sp)
 		if err != nil {
 			return Wait, nil, err
 		}
@@ -1168,7 +1328,7 @@ func (c *DefaultController) takeAction(sp subpool, batchPending, successes, pend
 	}
 	// If we have no serial jobs pending or successful, trigger one.
 	if len(missings) > 0 && len(pendings) == 0 && len(successes) == 0 {
-		if ok, pr := pickSmallestPassingNumber(sp.log, c.spc, missings, sp.cc); ok {
+		if ok, pr := pickSmallestPassingNumber(sp.log, c.spc, missings, sp.contextCheckerFor); ok {
 			return Trigger, []PullRequest{pr}, c.trigger(sp, missingSerialTests, []PullRequest{pr})
 		}
 	}
@@ -1210,11 +1370,11 @@ func (c *changedFilesAgent) prChanges(pr *PullRequest) config.ChangedFilesProvid
 			c.Lock()
 			c.nextChangeCache[cacheKey] = changedFiles
 			c.Unlock()
-			return changedFiles, nil
+			return cachedChanges(changedFiles)
 		}
 		if changedFiles, ok = c.nextChangeCache[cacheKey]; ok {
 			c.RUnlock()
-			return changedFiles, nil
+			return cachedChanges(changedFiles)
 		}
 		c.RUnlock()
 
@@ -1224,8 +1384,8 @@ func (c *changedFilesAgent) prChanges(pr *PullRequest) config.ChangedFilesProvid
 			string(pr.Repository.Name),
 			int(pr.Number),
 		)
-		if err != nil {
-			return nil, fmt.Errorf("error getting PR changes for #%d: %v", int(pr.Number), err)
+		if err != nil && !scmprovider.IsTooManyChanges(err) {
+			return nil, errors.Wrapf(err, "error getting PR changes for #%d", int(pr.Number))
 		}
 		changedFiles = make([]string, 0, len(changes))
 		for _, change := range changes {
@@ -1235,10 +1395,18 @@ func (c *changedFilesAgent) prChanges(pr *PullRequest) config.ChangedFilesProvid
 		c.Lock()
 		c.nextChangeCache[cacheKey] = changedFiles
 		c.Unlock()
-		return changedFiles, nil
+		return changedFiles, err
 	}
 }
 
+// cachedChanges returns the cached files changed by a PR, along with a TooManyChangesError if they were truncated
+func cachedChanges(changedFiles []string) ([]string, error) {
+	if len(changedFiles) >= scmprovider.MaxChangedFiles {
+		return changedFiles, &scmprovider.TooManyChangesError{Limit: scmprovider.MaxChangedFiles}
+	}
+	return changedFiles, nil
+}
+
 // prune removes any cached file changes that were not used since the last prune.
 func (c *changedFilesAgent) prune() {
 	c.Lock()
@@ -1257,16 +1425,24 @@ func (c *DefaultController) presubmitsByPull(sp *subpool) (map[int][]config.Pres
 		}
 	}
 
-	for _, ps := range c.config().Presubmits[sp.org+"/"+sp.repo] {
-		if !ps.ContextRequired() {
+	for _, ps := range requiredjobs.Presubmits(c.config(), sp.org, sp.repo) {
+		if !requiredjobs.Required(ps) {
 			continue
 		}
 
 		for _, pr := range sp.prs {
 			p := pr
-			if shouldRun, err := ps.ShouldRun(sp.branch, c.changedFiles.prChanges(&p), false, false); err != nil {
+			if p.hasLabel(labels.SkipCI) {
+				// the presubmits of the PR only run when requested
+				continue
+			}
+			if p.hasLabel(labels.DependencyUpdate) && p.reportedSkipped().Has(ps.Context) {
+				// the presubmit is left out by the dependency update profile of the author of the PR
+				continue
+			}
+			if applies, err := requiredjobs.Applies(ps, sp.branch, c.changedFiles.prChanges(&p)); err != nil {
 				return nil, err
-			} else if shouldRun {
+			} else if applies {
 				record(int(pr.Number), ps)
 			}
 		}
@@ -1276,7 +1452,25 @@ func (c *DefaultController) presubmitsByPull(sp *subpool) (map[int][]config.Pres
 
 func (c *DefaultController) syncSubpool(sp subpool, blocks []blockers.Blocker) (Pool, error) {
 	sp.log.Infof("Syncing subpool: %d PRs, %d PJs.", len(sp.prs), len(sp.pjs))
-	successes, pendings, missings, missingSerialTests := accumulate(sp.presubmits, sp.prs, sp.pjs, sp.log)
+	pjs := sp.pjs
+	carried, carryErr := c.carriedOverResults(&sp)
+	if carryErr != nil {
+		sp.log.WithError(carryErr).Warn("Failed to carry the results of the presubmits over the new base commits.")
+	} else if len(carried) > 0 {
+		sp.log.WithField("jobs", len(carried)).Info("Presubmits not affected by the new base commits are not run again.")
+		pjs = append(append([]v1alpha1.LighthouseJob{}, sp.pjs...), carried...)
+	}
+	successes, pendings, missings, missingSerialTests := accumulate(sp.presubmits, sp.prs, pjs, sp.log)
+	if upToDate, err := c.retestNotRequired(&sp, missings); err != nil {
+		sp.log.WithError(err).Warn("Failed to find the PRs which do not need to be retested.")
+	} else if len(upToDate) > 0 {
+		sp.log.WithField("prs", prNumbers(upToDate)).Info("PRs containing the base do not need to be retested.")
+		successes = append(successes, upToDate...)
+		missings = withoutPRs(missings, upToDate)
+		for _, pr := range upToDate {
+			delete(missingSerialTests, int(pr.Number))
+		}
+	}
 	batchMerge, batchPending := accumulateBatch(sp.presubmits, sp.prs, sp.pjs, sp.log)
 	sp.log.WithFields(logrus.Fields{
 		"prs-passing":   prNumbers(successes),
@@ -1296,7 +1490,16 @@ func (c *DefaultController) syncSubpool(sp subpool, blocks []blockers.Blocker) (
 		act, targets, err = c.takeAction(sp, batchPending, successes, pendings, missings, batchMerge, missingSerialTests)
 		if err != nil {
 			errorString = err.Error()
+		} else if len(carried) > 0 && act != Merge && act != MergeBatch {
+			refreshed, refreshErr := c.refreshAffected(sp, carried, missings, targets, missingSerialTests)
+			if refreshErr != nil {
+				sp.log.WithError(refreshErr).Warn("Failed to run the presubmits affected by the new base commits.")
+			}
+			if len(refreshed) > 0 {
+				sp.log.WithField("prs", prNumbers(refreshed)).Info("Ran the presubmits affected by the new base commits.")
+			}
 		}
+		c.recordDryRun(sp, act, targets)
 		if recordableActions[act] {
 			c.History.Record(
 				poolKey(sp.org, sp.repo, sp.branch),
@@ -1314,23 +1517,32 @@ func (c *DefaultController) syncSubpool(sp subpool, blocks []blockers.Blocker) (
 	}).Info("Subpool synced.")
 	keeperMetrics.pooledPRs.WithLabelValues(sp.org, sp.repo, sp.branch).Set(float64(len(sp.prs)))
 	keeperMetrics.updateTime.WithLabelValues(sp.org, sp.repo, sp.branch).Set(float64(time.Now().Unix()))
-	return Pool{
-			Org:    sp.org,
-			Repo:   sp.repo,
-			Branch: sp.branch,
+	var conditionalContexts []string
+	if sp.conditionalContexts.Len() > 0 {
+		conditionalContexts = sp.conditionalContexts.List()
+	}
+	pool := Pool{
+		Org:    sp.org,
+		Repo:   sp.repo,
+		Branch: sp.branch,
 
-			SuccessPRs: successes,
-			PendingPRs: pendings,
-			MissingPRs: missings,
+		SuccessPRs: successes,
+		PendingPRs: pendings,
+		MissingPRs: missings,
 
-			BatchPending: batchPending,
+		BatchPending: batchPending,
 
-			Action:   act,
-			Target:   targets,
-			Blockers: blocks,
-			Error:    errorString,
-		},
-		err
+		Action:   act,
+		Target:   targets,
+		Blockers: blocks,
+		Error:    errorString,
+
+		ContextPolicy:       sp.contextPolicy,
+		ConditionalContexts: conditionalContexts,
+		DryRun:              c.dryRun,
+	}
+	c.updateQueueComments(sp, pool, pjs)
+	return pool, err
 }
 
 func prMeta(prs ...PullRequest) []v1alpha1.Pull {
@@ -1379,12 +1591,22 @@ type subpool struct {
 	// pjs contains all PipelineActivitys of type Presubmit or Batch
 	// that have the same baseSHA as the subpool
 	pjs []v1alpha1.LighthouseJob
+	// previousPJs contains the presubmits which ran against an earlier baseSHA of the branch
+	previousPJs []v1alpha1.LighthouseJob
 	prs []PullRequest
 
 	cc contextChecker
+	// contextPolicy is the context policy of the branch, which cc checks the contexts against
+	contextPolicy *config.KeeperContextPolicy
+	// conditionalContexts are the contexts of the required presubmits which only run for some changed files
+	conditionalContexts sets.String
+	// skippedContexts are the contexts of the required presubmits which the PRs labeled skip-ci do not require
+	skippedContexts sets.String
 	// presubmit contains all required presubmits for each PR
 	// in this subpool
 	presubmits map[int][]config.Presubmit
+	// gates are the merge gates run against the merge result of a pull request right before merging it
+	gates []config.Presubmit
 }
 
 func poolKey(org, repo, branch string) string {
@@ -1426,7 +1648,13 @@ func (c *DefaultController) dividePool(pool map[string]PullRequest, pjs []v1alph
 			continue
 		}
 		fn := poolKey(pj.Spec.Refs.Org, pj.Spec.Refs.Repo, pj.Spec.Refs.BaseRef)
-		if sps[fn] == nil || pj.Spec.Refs.BaseSHA != sps[fn].sha {
+		if sps[fn] == nil {
+			continue
+		}
+		if pj.Spec.Refs.BaseSHA != sps[fn].sha {
+			if pj.Spec.Type == config.PresubmitJob {
+				sps[fn].previousPJs = append(sps[fn].previousPJs, pj)
+			}
 			continue
 		}
 		sps[fn].pjs = append(sps[fn].pjs, pj)
@@ -1466,8 +1694,13 @@ type PullRequest struct {
 	Milestone *struct {
 		Title githubql.String
 	}
+	Assignees struct {
+		Nodes []SCMUser
+	} `graphql:"assignees(first: 10)"`
 	Body      githubql.String
 	Title     githubql.String
+	URL       githubql.String
+	CreatedAt githubql.DateTime
 	UpdatedAt githubql.DateTime
 }
 
@@ -1677,7 +1910,9 @@ func restAPISearch(spc scmProviderClient, log *logrus.Entry, queries config.Keep
 					}
 				}
 
-				if !missingRequiredLabels && !hasExcludedLabel && !hasExcludedBranch && hasIncludedBranch {
+				hasMilestone := q.Milestone == "" || pr.Milestone.Title == q.Milestone
+
+				if !missingRequiredLabels && !hasExcludedLabel && !hasExcludedBranch && hasIncludedBranch && hasMilestone {
 					matches = true
 					break
 				}
@@ -1739,7 +1974,7 @@ func scmPRToGraphQLPR(scmPR *scm.PullRequest, scmRepo *scm.Repository) *PullRequ
 		labels.Nodes = append(labels.Nodes, struct{ Name githubql.String }{Name: githubql.String(l.Name)})
 	}
 
-	return &PullRequest{
+	answer := &PullRequest{
 		Number:      githubql.Int(scmPR.Number),
 		Author:      author,
 		BaseRef:     baseRef,
@@ -1750,8 +1985,19 @@ func scmPRToGraphQLPR(scmPR *scm.PullRequest, scmRepo *scm.Repository) *PullRequ
 		Labels:      labels,
 		Body:        githubql.String(scmPR.Body),
 		Title:       githubql.String(scmPR.Title),
+		URL:         githubql.String(scmPR.Link),
+		CreatedAt:   githubql.DateTime{Time: scmPR.Created},
 		UpdatedAt:   githubql.DateTime{Time: scmPR.Updated},
 	}
+	if scmPR.Milestone.Title != "" {
+		answer.Milestone = &struct {
+			Title githubql.String
+		}{Title: githubql.String(scmPR.Milestone.Title)}
+	}
+	for _, assignee := range scmPR.Assignees {
+		answer.Assignees.Nodes = append(answer.Assignees.Nodes, SCMUser{Login: githubql.String(assignee.Login)})
+	}
+	return answer
 }
 
 func scmRepoToGraphQLRepo(scmRepo *scm.Repository) Repository {
@@ -530,6 +530,14 @@ func (f *fgc) ListAllPullRequestsForFullNameRepo(string, scm.PullRequestListOpti
 	return nil, scm.ErrNotSupported
 }
 
+func (f *fgc) GetMergeRequestApprovals(org, repo string, number int) (*scmprovider.MergeRequestApprovals, error) {
+	return nil, scm.ErrNotSupported
+}
+
+func (f *fgc) ListMergeRequestPipelines(org, repo string, number int) ([]*scmprovider.Pipeline, error) {
+	return nil, scm.ErrNotSupported
+}
+
 func (f *fgc) GetRef(o, r, ref string) (string, error) {
 	return f.refs[o+"/"+r+" "+ref], nil
 }
@@ -837,6 +845,7 @@ func TestPickBatch(t *testing.T) {
 		repo:   "r",
 		branch: "master",
 		sha:    "master",
+		cc:     &config.KeeperContextPolicy{},
 	}
 	for _, testpr := range testprs {
 		if err := lg.CheckoutNewBranch("o", "r", fmt.Sprintf("pr-%d", testpr.number)); err != nil {
@@ -874,7 +883,7 @@ func TestPickBatch(t *testing.T) {
 		gc:     gc,
 		config: ca.Config,
 	}
-	prs, err := c.pickBatch(sp, &config.KeeperContextPolicy{})
+	prs, err := c.pickBatch(sp)
 	if err != nil {
 		t.Fatalf("Error from pickBatch: %v", err)
 	}
@@ -1689,7 +1698,13 @@ func TestSync(t *testing.T) {
 			}
 			if match == nil {
 				t.Errorf("Failed to find expected pool %s/%s %s.", expected.Org, expected.Repo, expected.Branch)
-			} else if !reflect.DeepEqual(*match, expected) {
+				continue
+			}
+			expected.ContextPolicy, err = ca.Config().GetKeeperContextPolicy(expected.Org, expected.Repo, expected.Branch)
+			if err != nil {
+				t.Fatalf("Failed to get the context policy: %v", err)
+			}
+			if !reflect.DeepEqual(*match, expected) {
 				t.Errorf("Expected pool %#v does not match actual pool %#v.", expected, *match)
 			}
 		}
@@ -0,0 +1,21 @@
+package keeper
+
+import (
+	"time"
+
+	"github.com/jenkins-x/lighthouse/pkg/labels"
+)
+
+// mergeAfter returns the time the merge-after labels of the pull request keep it out of the pool until, or false if
+// it has no merge-after label or the time has passed, the pull request then entering the pool on the next sync
+func mergeAfter(pr *PullRequest) (time.Time, bool) {
+	var names []string
+	for _, l := range pr.Labels.Nodes {
+		names = append(names, string(l.Name))
+	}
+	t, ok := labels.MergeAfterTime(names)
+	if !ok || !t.After(now()) {
+		return time.Time{}, false
+	}
+	return t, true
+}
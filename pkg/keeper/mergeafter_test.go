@@ -0,0 +1,39 @@
+package keeper
+
+import (
+	"testing"
+	"time"
+
+	githubql "github.com/shurcooL/githubv4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeAfter(t *testing.T) {
+	defer func() { now = time.Now }()
+	now = func() time.Time { return time.Date(2024, 7, 1, 8, 0, 0, 0, time.UTC) }
+
+	pr := func(labels ...string) *PullRequest {
+		pr := &PullRequest{}
+		for _, l := range labels {
+			pr.Labels.Nodes = append(pr.Labels.Nodes, struct{ Name githubql.String }{Name: githubql.String(l)})
+		}
+		return pr
+	}
+
+	_, ok := mergeAfter(pr("lgtm"))
+	assert.False(t, ok, "a PR without merge-after label is not scheduled")
+
+	after, ok := mergeAfter(pr("lgtm", "merge-after: 2024-07-01T09:00Z"))
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2024, 7, 1, 9, 0, 0, 0, time.UTC), after)
+
+	after, ok = mergeAfter(pr("merge-after: 2024-07-01T09:00Z", "merge-after: 2024-07-02"))
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2024, 7, 2, 0, 0, 0, 0, time.UTC), after, "the latest merge-after label wins")
+
+	_, ok = mergeAfter(pr("merge-after: 2024-07-01T07:59Z"))
+	assert.False(t, ok, "a PR whose merge-after label has passed enters the pool")
+
+	_, ok = mergeAfter(pr("merge-after: tomorrow"))
+	assert.False(t, ok, "an invalid merge-after label is ignored")
+}
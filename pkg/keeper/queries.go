@@ -0,0 +1,57 @@
+package keeper
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+)
+
+// QueryExtension holds the fields of a keeper query which keeper evaluates itself rather than the search of
+// the git provider
+type QueryExtension struct {
+	ReviewRequirements
+	// Author is the login of the user who must have opened the pull requests
+	Author string `json:"author,omitempty"`
+	// Assignee is the login of a user the pull requests must be assigned to
+	Assignee string `json:"assignee,omitempty"`
+}
+
+// SearchQuery returns the search query with the filters of the extension which the search of GitHub supports
+func (e QueryExtension) SearchQuery(query string) string {
+	toks := []string{query}
+	if e.Author != "" {
+		toks = append(toks, fmt.Sprintf("author:\"%s\"", e.Author))
+	}
+	if e.Assignee != "" {
+		toks = append(toks, fmt.Sprintf("assignee:\"%s\"", e.Assignee))
+	}
+	return strings.Join(toks, " ")
+}
+
+// filter returns the pull requests matching the author and assignee of the extension
+func (e QueryExtension) filter(prs []PullRequest) []PullRequest {
+	if e.Author == "" && e.Assignee == "" {
+		return prs
+	}
+	var answer []PullRequest
+	for _, pr := range prs {
+		if e.Author != "" && scmprovider.NormLogin(string(pr.Author.Login)) != scmprovider.NormLogin(e.Author) {
+			continue
+		}
+		if e.Assignee != "" && !pr.isAssignedTo(e.Assignee) {
+			continue
+		}
+		answer = append(answer, pr)
+	}
+	return answer
+}
+
+func (pr *PullRequest) isAssignedTo(login string) bool {
+	for _, assignee := range pr.Assignees.Nodes {
+		if scmprovider.NormLogin(string(assignee.Login)) == scmprovider.NormLogin(login) {
+			return true
+		}
+	}
+	return false
+}
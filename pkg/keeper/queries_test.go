@@ -0,0 +1,43 @@
+package keeper
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	githubql "github.com/shurcooL/githubv4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryExtensionSearchQuery(t *testing.T) {
+	extension := QueryExtension{Author: "release-bot", Assignee: "releaser"}
+	assert.Equal(t, `is:pr author:"release-bot" assignee:"releaser"`, extension.SearchQuery("is:pr"))
+	assert.Equal(t, "is:pr", QueryExtension{}.SearchQuery("is:pr"))
+}
+
+func TestQueryExtensionFilter(t *testing.T) {
+	scmPR := func(number int, author string, assignees ...string) PullRequest {
+		pr := &scm.PullRequest{Number: number, Author: scm.User{Login: author}, Milestone: scm.Milestone{Title: "v1.0"}}
+		for _, assignee := range assignees {
+			pr.Assignees = append(pr.Assignees, scm.User{Login: assignee})
+		}
+		return *scmPRToGraphQLPR(pr, &scm.Repository{Namespace: "org", Name: "repo"})
+	}
+	prs := []PullRequest{
+		scmPR(1, "release-bot", "releaser"),
+		scmPR(2, "Release-Bot"),
+		scmPR(3, "someone", "releaser", "other"),
+	}
+	assert.Equal(t, githubql.String("v1.0"), prs[0].Milestone.Title)
+
+	numbers := func(prs []PullRequest) []int {
+		var answer []int
+		for _, pr := range prs {
+			answer = append(answer, int(pr.Number))
+		}
+		return answer
+	}
+	assert.Equal(t, []int{1, 2, 3}, numbers(QueryExtension{}.filter(prs)))
+	assert.Equal(t, []int{1, 2}, numbers(QueryExtension{Author: "release-bot"}.filter(prs)))
+	assert.Equal(t, []int{1, 3}, numbers(QueryExtension{Assignee: "releaser"}.filter(prs)))
+	assert.Equal(t, []int{1}, numbers(QueryExtension{Author: "release-bot", Assignee: "releaser"}.filter(prs)))
+}
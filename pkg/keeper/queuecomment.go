@@ -0,0 +1,316 @@
+package keeper
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/keeper/blockers"
+	"github.com/sirupsen/logrus"
+)
+
+// queueCommentTag marks the comment explaining why a pool PR is not merged yet, so that it is updated in place
+const queueCommentTag = "<!-- keeper-queue -->"
+
+type queueCommentClient interface {
+	BotName() (string, error)
+	ListPullRequestComments(owner, repo string, number int) ([]*scm.Comment, error)
+	CreateComment(owner, repo string, number int, pr bool, comment string) error
+	EditComment(owner, repo string, number int, id int, comment string, pr bool) error
+	DeleteComment(owner, repo string, number, id int, pr bool) error
+}
+
+// queueComment is the comment keeper last wrote on a pool PR
+type queueComment struct {
+	org, repo string
+	number    int
+	// id is the ID of the comment, 0 until it is looked up again after being created
+	id   int
+	body string
+}
+
+// queueComments remembers the comments keeper wrote on the pool PRs, keyed by prKey, so that they are only
+// listed when they need to change
+type queueComments struct {
+	lock     sync.Mutex
+	comments map[string]queueComment
+}
+
+// queueReasons explains why the PR of the subpool is not merged yet once the subpool was synced into the pool, given
+// the jobs of the subpool including those carried over from earlier bases. It returns the position of the PR in the
+// queue of the subpool, and no reason if the PR is being merged.
+func queueReasons(sp subpool, pool Pool, pjs []v1alpha1.LighthouseJob, pr PullRequest) (int, []string) {
+	queue := append(append(sortedByNumber(pool.SuccessPRs), sortedByNumber(pool.PendingPRs)...), sortedByNumber(pool.MissingPRs)...)
+	position := 0
+	var ahead []string
+	for i := range queue {
+		if queue[i].Number == pr.Number {
+			position = i + 1
+			break
+		}
+		ahead = append(ahead, fmt.Sprintf("#%d", queue[i].Number))
+	}
+	targeted := hasPR(pool.Target, pr)
+
+	if len(pool.Blockers) > 0 {
+		return position, []string{blockedReason(pool.Branch, pool.Blockers)}
+	}
+	switch {
+	case targeted && (pool.Action == Merge || pool.Action == MergeBatch) && pool.Error == "":
+		return position, nil
+	case targeted && pool.Action == MergeRateLimited:
+		return position, []string{"The repository reached its limit of merges per hour, it is merged once the limit allows it."}
+	case targeted && (pool.Action == TriggerMergeGate || pool.Action == Wait) && len(sp.gates) > 0:
+		return position, []string{fmt.Sprintf("The merge gates %s run against its merge result right before merging it.", contextList(gateContexts(sp)))}
+	}
+
+	var reasons []string
+	var pending, missing []string
+	if !hasPR(pool.SuccessPRs, pr) {
+		states := presubmitStates(pjs, pr)
+		for _, ps := range sp.presubmits[int(pr.Number)] {
+			switch states[ps.Context] {
+			case successState:
+			case pendingState:
+				pending = append(pending, ps.Context)
+			default:
+				missing = append(missing, ps.Context)
+			}
+		}
+	}
+	base := sp.sha
+	if len(base) > 8 {
+		base = base[:8]
+	}
+	if len(pending) > 0 {
+		reasons = append(reasons, fmt.Sprintf("The required contexts %s are running against the base %s.", contextList(pending), base))
+	}
+	if len(missing) > 0 {
+		reasons = append(reasons, fmt.Sprintf("The required contexts %s have not passed against the base %s yet, they are run again when it is the turn of this pull request.", contextList(missing), base))
+	}
+	if failed := failedGateContexts(sp, pr); len(failed) > 0 {
+		reasons = append(reasons, fmt.Sprintf("The merge gates %s failed against its merge result.", contextList(failed)))
+	}
+	if hasPR(pool.BatchPending, pr) {
+		reasons = append(reasons, fmt.Sprintf("It is tested in a batch with %s.", strings.Join(otherNumbers(pool.BatchPending, pr), ", ")))
+	} else if len(pool.BatchPending) > 0 {
+		reasons = append(reasons, fmt.Sprintf("The pull requests are not merged one by one while the batch of %s is tested.", strings.Join(otherNumbers(pool.BatchPending, pr), ", ")))
+	}
+	if len(ahead) > 0 {
+		reasons = append(reasons, fmt.Sprintf("The pull requests %s are ahead of it in the queue.", strings.Join(ahead, ", ")))
+	}
+	if pool.Error != "" && targeted {
+		reasons = append(reasons, fmt.Sprintf("The last `%s` action of keeper on it failed: %s", pool.Action, pool.Error))
+	}
+	if len(reasons) == 0 {
+		reasons = append(reasons, "It waits for the next sync of keeper.")
+	}
+	return position, reasons
+}
+
+// queueCommentBody formats the comment explaining why the PR is not merged yet
+func queueCommentBody(pool Pool, position int, reasons []string) string {
+	size := len(pool.SuccessPRs) + len(pool.PendingPRs) + len(pool.MissingPRs)
+	lines := []string{
+		queueCommentTag,
+		fmt.Sprintf("This pull request is in the merge pool of the `%s` branch of %s/%s, at position %d of %d.", pool.Branch, pool.Org, pool.Repo, position, size),
+		"",
+		"It is not merged yet because:",
+		"",
+	}
+	for _, reason := range reasons {
+		lines = append(lines, "* "+reason)
+	}
+	lines = append(lines, "", "This comment is updated by keeper as the pool changes and deleted once the pull request leaves the pool.")
+	return strings.Join(lines, "\n")
+}
+
+// updateQueueComments writes the comments explaining why the PRs of the subpool are not merged yet
+func (c *DefaultController) updateQueueComments(sp subpool, pool Pool, pjs []v1alpha1.LighthouseJob) {
+	if c.comments == nil || c.dryRun || !keeperExtension.get().QueueCommentsFor(sp.org, sp.repo) {
+		return
+	}
+	for _, pr := range sp.prs {
+		position, reasons := queueReasons(sp, pool, pjs, pr)
+		if len(reasons) == 0 {
+			continue
+		}
+		log := sp.log.WithFields(pr.logFields())
+		if err := c.queueComments.update(c.comments, log, sp.org, sp.repo, pr, queueCommentBody(pool, position, reasons)); err != nil {
+			log.WithError(err).Warn("Failed to update the comment explaining why the PR is not merged yet.")
+		}
+	}
+}
+
+// update creates or edits the queue comment of the PR, unless keeper already wrote the same body
+func (q *queueComments) update(spc queueCommentClient, log *logrus.Entry, org, repo string, pr PullRequest, body string) error {
+	key := prKey(&pr)
+	q.lock.Lock()
+	cached, ok := q.comments[key]
+	q.lock.Unlock()
+	if ok && cached.body == body {
+		return nil
+	}
+	comment := queueComment{org: org, repo: repo, number: int(pr.Number), id: cached.id, body: body}
+	if comment.id == 0 {
+		existing, err := findQueueComment(spc, org, repo, int(pr.Number))
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			comment.id = existing.ID
+			if existing.Body == body {
+				q.set(key, comment)
+				return nil
+			}
+		}
+	}
+	if comment.id != 0 {
+		log.Debug("Updating the queue comment.")
+		if err := spc.EditComment(org, repo, comment.number, comment.id, body, true); err != nil {
+			return err
+		}
+	} else {
+		log.Info("Commenting why the PR is not merged yet.")
+		if err := spc.CreateComment(org, repo, comment.number, true, body); err != nil {
+			return err
+		}
+	}
+	q.set(key, comment)
+	return nil
+}
+
+func (q *queueComments) set(key string, comment queueComment) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if q.comments == nil {
+		q.comments = map[string]queueComment{}
+	}
+	q.comments[key] = comment
+}
+
+// prune deletes the queue comments of the PRs which left the pool
+func (q *queueComments) prune(spc queueCommentClient, log *logrus.Entry, pool map[string]PullRequest) {
+	q.lock.Lock()
+	var left []string
+	for key := range q.comments {
+		if _, ok := pool[key]; !ok {
+			left = append(left, key)
+		}
+	}
+	q.lock.Unlock()
+	for _, key := range left {
+		q.lock.Lock()
+		comment := q.comments[key]
+		q.lock.Unlock()
+		l := log.WithFields(logrus.Fields{"org": comment.org, "repo": comment.repo, "pr": comment.number})
+		if err := deleteQueueComment(spc, comment); err != nil {
+			l.WithError(err).Warn("Failed to delete the queue comment of the PR which left the pool.")
+			continue
+		}
+		l.Info("Deleted the queue comment of the PR which left the pool.")
+		q.lock.Lock()
+		delete(q.comments, key)
+		q.lock.Unlock()
+	}
+}
+
+func deleteQueueComment(spc queueCommentClient, comment queueComment) error {
+	if comment.id == 0 {
+		existing, err := findQueueComment(spc, comment.org, comment.repo, comment.number)
+		if err != nil || existing == nil {
+			return err
+		}
+		comment.id = existing.ID
+	}
+	return spc.DeleteComment(comment.org, comment.repo, comment.number, comment.id, true)
+}
+
+// findQueueComment returns the latest queue comment of the bot on the PR, or nil if there is none
+func findQueueComment(spc queueCommentClient, org, repo string, number int) (*scm.Comment, error) {
+	botName, err := spc.BotName()
+	if err != nil {
+		return nil, err
+	}
+	comments, err := spc.ListPullRequestComments(org, repo, number)
+	if err != nil {
+		return nil, err
+	}
+	var answer *scm.Comment
+	for _, comment := range comments {
+		if comment.Author.Login == botName && strings.Contains(comment.Body, queueCommentTag) {
+			answer = comment
+		}
+	}
+	return answer, nil
+}
+
+func blockedReason(branch string, blocks []blockers.Blocker) string {
+	var issues []string
+	for _, b := range blocks {
+		if b.URL != "" {
+			issues = append(issues, fmt.Sprintf("[#%d %s](%s)", b.Number, b.Title, b.URL))
+		} else {
+			issues = append(issues, fmt.Sprintf("#%d %s", b.Number, b.Title))
+		}
+	}
+	return fmt.Sprintf("Merging into `%s` is blocked by %s.", branch, strings.Join(issues, ", "))
+}
+
+func gateContexts(sp subpool) []string {
+	var contexts []string
+	for _, gate := range sp.gates {
+		contexts = append(contexts, gate.Context)
+	}
+	return contexts
+}
+
+func failedGateContexts(sp subpool, pr PullRequest) []string {
+	if len(sp.gates) == 0 {
+		return nil
+	}
+	states := mergeGateStates(sp, pr)
+	var failed []string
+	for _, gate := range sp.gates {
+		if states[gate.Context] == failureState {
+			failed = append(failed, gate.Context)
+		}
+	}
+	return failed
+}
+
+func contextList(contexts []string) string {
+	quoted := make([]string, 0, len(contexts))
+	for _, context := range contexts {
+		quoted = append(quoted, "`"+context+"`")
+	}
+	return strings.Join(quoted, ", ")
+}
+
+func sortedByNumber(prs []PullRequest) []PullRequest {
+	answer := append([]PullRequest{}, prs...)
+	sort.Slice(answer, func(i, j int) bool { return answer[i].Number < answer[j].Number })
+	return answer
+}
+
+func hasPR(prs []PullRequest, pr PullRequest) bool {
+	for _, p := range prs {
+		if p.Number == pr.Number {
+			return true
+		}
+	}
+	return false
+}
+
+func otherNumbers(prs []PullRequest, pr PullRequest) []string {
+	var answer []string
+	for _, p := range sortedByNumber(prs) {
+		if p.Number != pr.Number {
+			answer = append(answer, fmt.Sprintf("#%d", p.Number))
+		}
+	}
+	return answer
+}
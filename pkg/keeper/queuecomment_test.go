@@ -0,0 +1,137 @@
+package keeper
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/keeper/blockers"
+	fake2 "github.com/jenkins-x/lighthouse/pkg/scmprovider/fake"
+	githubql "github.com/shurcooL/githubv4"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueueReasons(t *testing.T) {
+	pr := func(number int) PullRequest {
+		var pr PullRequest
+		pr.Number = githubql.Int(number)
+		pr.HeadRefOID = githubql.String("head")
+		return pr
+	}
+	job := func(number int, context string, state v1alpha1.PipelineState) v1alpha1.LighthouseJob {
+		var pj v1alpha1.LighthouseJob
+		pj.Spec.Type = config.PresubmitJob
+		pj.Spec.Context = context
+		pj.Spec.Refs = &v1alpha1.Refs{Pulls: []v1alpha1.Pull{{Number: number, SHA: "head"}}}
+		pj.Status.State = state
+		return pj
+	}
+	presubmit := func(context string) config.Presubmit {
+		return config.Presubmit{Reporter: config.Reporter{Context: context}}
+	}
+	sp := subpool{
+		org:    "org",
+		repo:   "repo",
+		branch: "master",
+		sha:    "0123456789abcdef",
+		presubmits: map[int][]config.Presubmit{
+			1: {presubmit("unit"), presubmit("e2e")},
+			2: {presubmit("unit"), presubmit("e2e")},
+			3: {presubmit("unit"), presubmit("e2e")},
+		},
+	}
+	pjs := []v1alpha1.LighthouseJob{
+		job(1, "unit", v1alpha1.SuccessState),
+		job(1, "e2e", v1alpha1.SuccessState),
+		job(2, "unit", v1alpha1.SuccessState),
+		job(2, "e2e", v1alpha1.PendingState),
+		job(3, "unit", v1alpha1.FailureState),
+	}
+	pool := Pool{
+		Org: "org", Repo: "repo", Branch: "master",
+		SuccessPRs: []PullRequest{pr(1)},
+		PendingPRs: []PullRequest{pr(2)},
+		MissingPRs: []PullRequest{pr(3)},
+		Action:     Merge,
+		Target:     []PullRequest{pr(1)},
+	}
+
+	position, reasons := queueReasons(sp, pool, pjs, pr(1))
+	assert.Equal(t, 1, position)
+	assert.Empty(t, reasons, "the merged PR should not be explained")
+
+	position, reasons = queueReasons(sp, pool, pjs, pr(2))
+	assert.Equal(t, 2, position)
+	assert.Equal(t, []string{
+		"The required contexts `e2e` are running against the base 01234567.",
+		"The pull requests #1 are ahead of it in the queue.",
+	}, reasons)
+
+	position, reasons = queueReasons(sp, pool, pjs, pr(3))
+	assert.Equal(t, 3, position)
+	assert.Equal(t, []string{
+		"The required contexts `unit`, `e2e` have not passed against the base 01234567 yet, they are run again when it is the turn of this pull request.",
+		"The pull requests #1, #2 are ahead of it in the queue.",
+	}, reasons)
+
+	rateLimited := pool
+	rateLimited.Action = MergeRateLimited
+	_, reasons = queueReasons(sp, rateLimited, pjs, pr(1))
+	assert.Equal(t, []string{"The repository reached its limit of merges per hour, it is merged once the limit allows it."}, reasons)
+
+	batch := pool
+	batch.Action, batch.Target = Wait, nil
+	batch.BatchPending = []PullRequest{pr(2), pr(3)}
+	_, reasons = queueReasons(sp, batch, pjs, pr(1))
+	assert.Equal(t, []string{"The pull requests are not merged one by one while the batch of #2, #3 is tested."}, reasons)
+	_, reasons = queueReasons(sp, batch, pjs, pr(3))
+	assert.Contains(t, reasons, "It is tested in a batch with #2.")
+
+	blocked := pool
+	blocked.Action, blocked.Target = PoolBlocked, nil
+	blocked.Blockers = []blockers.Blocker{{Number: 42, Title: "Code freeze", URL: "https://github.com/org/repo/issues/42"}}
+	_, reasons = queueReasons(sp, blocked, pjs, pr(1))
+	assert.Equal(t, []string{"Merging into `master` is blocked by [#42 Code freeze](https://github.com/org/repo/issues/42)."}, reasons)
+}
+
+func TestQueueComments(t *testing.T) {
+	var pr PullRequest
+	pr.Number = 1
+	pr.Repository.Owner.Login = "org"
+	pr.Repository.Name = "repo"
+	spc := &fake2.SCMClient{
+		IssueCommentID: 1,
+		PullRequestComments: map[int][]*scm.Comment{
+			1: {{ID: 100, Body: "LGTM", Author: scm.User{Login: "alice"}}},
+		},
+	}
+	log := logrus.WithField("controller", "keeper")
+	q := queueComments{}
+
+	require.NoError(t, q.update(spc, log, "org", "repo", pr, queueCommentTag+"\nfirst"))
+	require.Len(t, spc.PullRequestCommentsAdded, 1)
+	require.NoError(t, q.update(spc, log, "org", "repo", pr, queueCommentTag+"\nfirst"))
+	assert.Len(t, spc.PullRequestCommentsAdded, 1, "an unchanged comment should not be written again")
+
+	require.NoError(t, q.update(spc, log, "org", "repo", pr, queueCommentTag+"\nsecond"))
+	assert.Len(t, spc.PullRequestCommentsAdded, 1, "the comment should be updated in place")
+	require.Len(t, spc.PullRequestComments[1], 2)
+	assert.Equal(t, queueCommentTag+"\nsecond", spc.PullRequestComments[1][1].Body)
+
+	// a restarted keeper finds its comment again
+	restarted := queueComments{}
+	require.NoError(t, restarted.update(spc, log, "org", "repo", pr, queueCommentTag+"\nthird"))
+	assert.Len(t, spc.PullRequestCommentsAdded, 1)
+	assert.True(t, strings.HasSuffix(spc.PullRequestComments[1][1].Body, "third"))
+
+	restarted.prune(spc, log, map[string]PullRequest{prKey(&pr): pr})
+	assert.Empty(t, spc.PullRequestCommentsDeleted, "the comment of a PR still in the pool should be kept")
+	restarted.prune(spc, log, map[string]PullRequest{})
+	assert.Equal(t, []string{"org/repo#1"}, spc.PullRequestCommentsDeleted)
+	assert.Len(t, spc.PullRequestComments[1], 1)
+	assert.Empty(t, restarted.comments)
+}
@@ -0,0 +1,65 @@
+package keeper
+
+import (
+	"sync"
+	"time"
+)
+
+// For mocking out the current time during unit tests.
+var now = time.Now
+
+// mergeHistory remembers when keeper merged the pull requests of each repository during the last hour, to limit the
+// number of merges per hour. It is kept in memory, so a restarted keeper starts with a full allowance.
+type mergeHistory struct {
+	lock   sync.Mutex
+	merges map[string][]time.Time
+}
+
+// record remembers a merge in the repository
+func (h *mergeHistory) record(org, repo string, t time.Time) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if h.merges == nil {
+		h.merges = map[string][]time.Time{}
+	}
+	key := org + "/" + repo
+	h.merges[key] = append(h.prune(key, t), t)
+}
+
+// remaining returns how many more pull requests of the repository can be merged during the hour before the given
+// time, or -1 if the merges are not limited
+func (h *mergeHistory) remaining(org, repo string, limit int, t time.Time) int {
+	if limit <= 0 {
+		return -1
+	}
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if remaining := limit - len(h.prune(org+"/"+repo, t)); remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+// prune forgets the merges of the repository older than an hour, the lock being held
+func (h *mergeHistory) prune(key string, t time.Time) []time.Time {
+	merges := h.merges[key]
+	i := 0
+	for i < len(merges) && !merges[i].After(t.Add(-time.Hour)) {
+		i++
+	}
+	merges = merges[i:]
+	if len(merges) == 0 {
+		delete(h.merges, key)
+		return nil
+	}
+	h.merges[key] = merges
+	return merges
+}
+
+// mergeAllowance returns how many more pull requests of the subpool's repository can be merged now, or -1 if the
+// merges are not limited
+func (c *DefaultController) mergeAllowance(sp subpool) int {
+	return c.merges.remaining(sp.org, sp.repo, keeperExtension.get().MergesPerHourLimit(sp.org, sp.repo), now())
+}
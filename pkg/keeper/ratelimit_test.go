@@ -0,0 +1,24 @@
+package keeper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeHistory(t *testing.T) {
+	start := time.Date(2020, 6, 1, 10, 0, 0, 0, time.UTC)
+	h := &mergeHistory{}
+	assert.Equal(t, -1, h.remaining("org", "repo", 0, start))
+	assert.Equal(t, 2, h.remaining("org", "repo", 2, start))
+
+	h.record("org", "repo", start)
+	h.record("org", "repo", start.Add(30*time.Minute))
+	h.record("org", "other", start.Add(30*time.Minute))
+	assert.Equal(t, 0, h.remaining("org", "repo", 2, start.Add(45*time.Minute)))
+	assert.Equal(t, 1, h.remaining("org", "other", 2, start.Add(45*time.Minute)))
+	assert.Equal(t, 1, h.remaining("org", "repo", 2, start.Add(time.Hour)), "the merges older than an hour are forgotten")
+	assert.Equal(t, 2, h.remaining("org", "repo", 2, start.Add(2*time.Hour)))
+	assert.Empty(t, h.merges["org/repo"])
+}
@@ -0,0 +1,111 @@
+package keeper
+
+import (
+	"sync"
+
+	"github.com/jenkins-x/lighthouse/pkg/git"
+)
+
+// mergeBaseAgent tracks whether the heads of the PRs contain the base of their branch, that is whether their merge
+// base with the branch is the branch itself. Cache entries expire if they are not used during a sync loop.
+type mergeBaseAgent struct {
+	cache map[mergeBaseKey]bool
+	// nextCache caches the results used this sync for use next sync.
+	nextCache map[mergeBaseKey]bool
+	sync.Mutex
+}
+
+type mergeBaseKey struct {
+	org, repo string
+	baseSHA   string
+	headSHA   string
+}
+
+func (a *mergeBaseAgent) get(key mergeBaseKey) (bool, bool) {
+	a.Lock()
+	defer a.Unlock()
+	if a.nextCache == nil {
+		a.nextCache = make(map[mergeBaseKey]bool)
+	}
+	upToDate, ok := a.cache[key]
+	if !ok {
+		upToDate, ok = a.nextCache[key]
+	}
+	if ok {
+		a.nextCache[key] = upToDate
+	}
+	return upToDate, ok
+}
+
+func (a *mergeBaseAgent) put(key mergeBaseKey, upToDate bool) {
+	a.Lock()
+	defer a.Unlock()
+	if a.nextCache == nil {
+		a.nextCache = make(map[mergeBaseKey]bool)
+	}
+	a.nextCache[key] = upToDate
+}
+
+// prune removes any cached merge bases that were not used since the last prune.
+func (a *mergeBaseAgent) prune() {
+	a.Lock()
+	defer a.Unlock()
+	a.cache = a.nextCache
+	a.nextCache = make(map[mergeBaseKey]bool)
+}
+
+// retestNotRequired returns the PRs missing presubmits against the base of the subpool which can be merged without
+// retesting them: their required contexts are all successful and their head contains the base, so that merging them
+// results in the head which was tested.
+func (c *DefaultController) retestNotRequired(sp *subpool, missings []PullRequest) ([]PullRequest, error) {
+	if len(missings) == 0 || !keeperExtension.get().RetestNotRequiredFor(sp.org, sp.repo) {
+		return nil, nil
+	}
+	var r *git.Repo
+	defer func() {
+		if r != nil {
+			r.Clean()
+		}
+	}()
+	var answer []PullRequest
+	for _, pr := range missings {
+		p := pr
+		if !isPassingTests(sp.log, c.spc, pr, sp.contextCheckerFor(&p)) {
+			continue
+		}
+		key := mergeBaseKey{org: sp.org, repo: sp.repo, baseSHA: sp.sha, headSHA: string(pr.HeadRefOID)}
+		upToDate, ok := c.mergeBases.get(key)
+		if !ok {
+			if r == nil {
+				var err error
+				if r, err = c.gc.Clone(sp.org + "/" + sp.repo); err != nil {
+					return nil, err
+				}
+			}
+			var err error
+			if upToDate, err = r.IsAncestor(sp.sha, key.headSHA); err != nil {
+				return nil, err
+			}
+			c.mergeBases.put(key, upToDate)
+		}
+		if upToDate {
+			answer = append(answer, pr)
+		}
+	}
+	return answer, nil
+}
+
+// withoutPRs returns the PRs which are not excluded
+func withoutPRs(prs, excluded []PullRequest) []PullRequest {
+	numbers := map[int]bool{}
+	for _, pr := range excluded {
+		numbers[int(pr.Number)] = true
+	}
+	var answer []PullRequest
+	for _, pr := range prs {
+		if !numbers[int(pr.Number)] {
+			answer = append(answer, pr)
+		}
+	}
+	return answer
+}
@@ -0,0 +1,78 @@
+package keeper
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/git/localgit"
+	githubql "github.com/shurcooL/githubv4"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetestNotRequired(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, ioutil.WriteFile(fileName, []byte("keeper:\n  retest_not_required:\n    org/repo: true\n"), 0600))
+	WatchExtension(fileName)
+	defer func() { keeperExtension = nil }()
+
+	lg, gc, err := localgit.New()
+	require.NoError(t, err)
+	defer gc.Clean()
+	defer lg.Clean()
+	require.NoError(t, lg.MakeFakeRepo("org", "repo"))
+	require.NoError(t, lg.CheckoutNewBranch("org", "repo", "base"))
+	oldBase, err := lg.RevParse("org", "repo", "HEAD")
+	require.NoError(t, err)
+	require.NoError(t, lg.CheckoutNewBranch("org", "repo", "pr"))
+	require.NoError(t, lg.AddCommit("org", "repo", map[string][]byte{"pr": []byte("pr")}))
+	head, err := lg.RevParse("org", "repo", "HEAD")
+	require.NoError(t, err)
+	require.NoError(t, lg.Checkout("org", "repo", "base"))
+	require.NoError(t, lg.AddCommit("org", "repo", map[string][]byte{"base": []byte("base")}))
+	newBase, err := lg.RevParse("org", "repo", "HEAD")
+	require.NoError(t, err)
+
+	pr := func(number int, state githubql.StatusState) PullRequest {
+		var pr PullRequest
+		pr.Number = githubql.Int(number)
+		pr.HeadRefOID = githubql.String(head)
+		pr.Commits.Nodes = []struct{ Commit Commit }{{Commit: Commit{
+			OID: githubql.String(head),
+			Status: struct{ Contexts []Context }{Contexts: []Context{
+				{Context: "unit", State: state},
+			}},
+		}}}
+		return pr
+	}
+	c := &DefaultController{
+		logger: logrus.WithField("controller", "keeper"),
+		spc:    &fgc{},
+		gc:     gc,
+	}
+	missings := []PullRequest{pr(1, githubql.StatusStateSuccess), pr(2, githubql.StatusStatePending)}
+	sp := &subpool{
+		log:  c.logger,
+		org:  "org",
+		repo: "repo",
+		sha:  oldBase,
+		cc:   &config.KeeperContextPolicy{RequiredContexts: []string{"unit"}},
+		prs:  missings,
+	}
+
+	upToDate, err := c.retestNotRequired(sp, missings)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1}, prNumbers(upToDate), "only the passing PR containing the base is not retested")
+	cached, ok := c.mergeBases.get(mergeBaseKey{org: "org", repo: "repo", baseSHA: oldBase, headSHA: head})
+	assert.True(t, ok && cached, "the merge base of the PR is tracked")
+
+	sp.sha = newBase
+	upToDate, err = c.retestNotRequired(sp, missings)
+	require.NoError(t, err)
+	assert.Empty(t, upToDate, "the PR is retested once the base advanced past its merge base")
+
+	assert.Equal(t, []int{2}, prNumbers(withoutPRs(missings, missings[:1])))
+}
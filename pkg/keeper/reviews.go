@@ -0,0 +1,277 @@
+package keeper
+
+import (
+	"bufio"
+	"bytes"
+	"path/filepath"
+	"strings"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// codeOwnersFiles are the locations GitHub looks for the CODEOWNERS file, in order
+var codeOwnersFiles = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// ReviewRequirements are the reviews a pull request needs to be merged by a keeper query
+type ReviewRequirements struct {
+	// RequiredApprovingReviews is the number of users other than the author whose latest review approves
+	RequiredApprovingReviews int `json:"requiredApprovingReviews,omitempty"`
+	// CodeOwnersApprovalRequired requires every changed file with code owners to be approved by one of them
+	CodeOwnersApprovalRequired bool `json:"codeOwnersApprovalRequired,omitempty"`
+}
+
+// IsZero returns true if no review is required
+func (r ReviewRequirements) IsZero() bool {
+	return r.RequiredApprovingReviews <= 0 && !r.CodeOwnersApprovalRequired
+}
+
+type reviewClient interface {
+	ListReviews(owner, repo string, number int) ([]*scm.Review, error)
+	GetPullRequestChanges(org, repo string, number int) ([]*scm.Change, error)
+	GetFile(org, repo, file, commit string) ([]byte, error)
+	ListTeams(org string) ([]*scm.Team, error)
+	ListTeamMembers(id int, role string) ([]*scm.TeamMember, error)
+	ProviderType() string
+}
+
+// filterReviewed returns the pull requests which have the required reviews, logging why the others are left out
+func filterReviewed(rc reviewClient, log *logrus.Entry, requirements ReviewRequirements, prs []PullRequest) []PullRequest {
+	if requirements.IsZero() {
+		return prs
+	}
+	if !scmprovider.CapabilitiesOf(rc.ProviderType()).Reviews {
+		log.Warnf("the %s provider does not list the reviews of pull requests, so none meets the review requirements", rc.ProviderType())
+		return nil
+	}
+	var answer []PullRequest
+	for _, pr := range prs {
+		l := log.WithFields(pr.logFields())
+		missing, err := missingReviews(rc, requirements, &pr)
+		if err != nil {
+			l.WithError(err).Warn("failed to evaluate the reviews of the pull request")
+			continue
+		}
+		if missing != "" {
+			l.Debugf("filtering out PR as it is missing %s", missing)
+			continue
+		}
+		answer = append(answer, pr)
+	}
+	return answer
+}
+
+// missingReviews describes the reviews the pull request still needs, returning an empty string once it meets
+// the requirements
+func missingReviews(rc reviewClient, requirements ReviewRequirements, pr *PullRequest) (string, error) {
+	org, repo, number := string(pr.Repository.Owner.Login), string(pr.Repository.Name), int(pr.Number)
+	reviews, err := rc.ListReviews(org, repo, number)
+	if err != nil {
+		return "", errors.Wrap(err, "listing reviews")
+	}
+	approvers := approvingReviewers(reviews, string(pr.Author.Login))
+	if approvers.Len() < requirements.RequiredApprovingReviews {
+		return "approving reviews", nil
+	}
+	if !requirements.CodeOwnersApprovalRequired {
+		return "", nil
+	}
+	rules := loadCodeOwners(rc, org, repo, string(pr.BaseRef.Name))
+	if len(rules) == 0 {
+		return "", nil
+	}
+	changes, err := rc.GetPullRequestChanges(org, repo, number)
+	if err != nil {
+		return "", errors.Wrap(err, "listing changed files")
+	}
+	teams := &teamMembers{rc: rc, teams: map[string][]*scm.Team{}, members: map[string]sets.String{}}
+	for _, change := range changes {
+		owners := codeOwnersOf(rules, change.Path)
+		if len(owners) == 0 {
+			continue
+		}
+		approved, err := teams.anyOwner(owners, approvers)
+		if err != nil {
+			return "", err
+		}
+		if !approved {
+			return "an approval from the code owners of " + change.Path, nil
+		}
+	}
+	return "", nil
+}
+
+// approvingReviewers returns the normalized logins of the reviewers whose latest review approves. Comments do
+// not change an earlier approval, while requesting changes or a dismissal does.
+func approvingReviewers(reviews []*scm.Review, author string) sets.String {
+	latest := map[string]string{}
+	for _, review := range reviews {
+		login := scmprovider.NormLogin(review.Author.Login)
+		if review.State == scm.ReviewStateCommented || review.State == scm.ReviewStatePending || login == scmprovider.NormLogin(author) {
+			continue
+		}
+		latest[login] = review.State
+	}
+	answer := sets.NewString()
+	for login, state := range latest {
+		if state == scm.ReviewStateApproved {
+			answer.Insert(login)
+		}
+	}
+	return answer
+}
+
+// codeOwnersRule is a line of a CODEOWNERS file
+type codeOwnersRule struct {
+	pattern []string
+	owners  []string
+}
+
+// loadCodeOwners parses the first CODEOWNERS file found on the branch
+func loadCodeOwners(rc reviewClient, org, repo, branch string) []codeOwnersRule {
+	for _, name := range codeOwnersFiles {
+		data, err := rc.GetFile(org, repo, name, branch)
+		if err != nil || len(data) == 0 {
+			continue
+		}
+		return parseCodeOwners(data)
+	}
+	return nil
+}
+
+func parseCodeOwners(data []byte) []codeOwnersRule {
+	var answer []codeOwnersRule
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		answer = append(answer, codeOwnersRule{pattern: compileCodeOwnersPattern(fields[0]), owners: fields[1:]})
+	}
+	return answer
+}
+
+// compileCodeOwnersPattern splits a gitignore style pattern into path elements, where ** matches any number
+// of elements. Patterns without a slash other than a trailing one match at any depth, and patterns matching a
+// directory match all the files below it unless they end with a wildcard.
+func compileCodeOwnersPattern(pattern string) []string {
+	dir := strings.HasSuffix(pattern, "/")
+	pattern = strings.TrimSuffix(pattern, "/")
+	anchored := strings.Contains(pattern, "/")
+	elements := strings.Split(strings.TrimPrefix(pattern, "/"), "/")
+	if !anchored {
+		elements = append([]string{"**"}, elements...)
+	}
+	if last := elements[len(elements)-1]; dir || !strings.Contains(last, "*") {
+		elements = append(elements, "**")
+	}
+	return elements
+}
+
+func matchCodeOwnersPattern(pattern, path []string) bool {
+	if len(pattern) == 0 {
+		return len(path) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(path); i++ {
+			if matchCodeOwnersPattern(pattern[1:], path[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(path) == 0 {
+		return false
+	}
+	matched, err := filepath.Match(pattern[0], path[0])
+	return err == nil && matched && matchCodeOwnersPattern(pattern[1:], path[1:])
+}
+
+// codeOwnersOf returns the owners of the last rule matching the path
+func codeOwnersOf(rules []codeOwnersRule, path string) []string {
+	elements := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	for i := len(rules) - 1; i >= 0; i-- {
+		if matchCodeOwnersPattern(rules[i].pattern, elements) {
+			return rules[i].owners
+		}
+	}
+	return nil
+}
+
+// teamMembers caches the members of the teams owning code while the reviews of a pull request are evaluated
+type teamMembers struct {
+	rc      reviewClient
+	teams   map[string][]*scm.Team
+	members map[string]sets.String
+}
+
+// anyOwner returns true if one of the approvers is an owner, given as @user or @org/team. Owners given as email
+// addresses cannot be matched to a login so they never approve.
+func (t *teamMembers) anyOwner(owners []string, approvers sets.String) (bool, error) {
+	for _, owner := range owners {
+		if !strings.HasPrefix(owner, "@") {
+			continue
+		}
+		owner = strings.TrimPrefix(owner, "@")
+		parts := strings.SplitN(owner, "/", 2)
+		if len(parts) == 1 {
+			if approvers.Has(scmprovider.NormLogin(owner)) {
+				return true, nil
+			}
+			continue
+		}
+		members, err := t.of(parts[0], parts[1])
+		if err != nil {
+			return false, err
+		}
+		if members.HasAny(approvers.UnsortedList()...) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (t *teamMembers) of(org, slug string) (sets.String, error) {
+	key := strings.ToLower(org + "/" + slug)
+	if members, ok := t.members[key]; ok {
+		return members, nil
+	}
+	if !scmprovider.CapabilitiesOf(t.rc.ProviderType()).TeamMembership {
+		// the teams owning code cannot approve on a provider which does not list their members
+		t.members[key] = sets.NewString()
+		return t.members[key], nil
+	}
+	teams, ok := t.teams[org]
+	if !ok {
+		var err error
+		teams, err = t.rc.ListTeams(org)
+		if err != nil {
+			return nil, errors.Wrapf(err, "listing the teams of %s", org)
+		}
+		t.teams[org] = teams
+	}
+	members := sets.NewString()
+	for _, team := range teams {
+		if !strings.EqualFold(team.Slug, slug) && !strings.EqualFold(team.Name, slug) {
+			continue
+		}
+		teamMembers, err := t.rc.ListTeamMembers(team.ID, scmprovider.RoleAll)
+		if err != nil {
+			return nil, errors.Wrapf(err, "listing the members of team %s", slug)
+		}
+		for _, member := range teamMembers {
+			members.Insert(scmprovider.NormLogin(member.Login))
+		}
+		break
+	}
+	t.members[key] = members
+	return members, nil
+}
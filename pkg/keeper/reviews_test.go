@@ -0,0 +1,117 @@
+package keeper
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	fake2 "github.com/jenkins-x/lighthouse/pkg/scmprovider/fake"
+	githubql "github.com/shurcooL/githubv4"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCodeOwnersOf(t *testing.T) {
+	rules := parseCodeOwners([]byte(`# default owners
+*       @org/leads
+*.go    @gopher # go code
+/docs/  @writer
+build/logs/ @ops
+apps/   @apps
+/scripts/* @scripter
+/empty
+`))
+	testCases := []struct {
+		path     string
+		expected []string
+	}{
+		{path: "README.md", expected: []string{"@org/leads"}},
+		{path: "cmd/main.go", expected: []string{"@gopher"}},
+		{path: "docs/guide/index.md", expected: []string{"@writer"}},
+		{path: "pkg/docs/index.md", expected: []string{"@org/leads"}},
+		{path: "build/logs/today.log", expected: []string{"@ops"}},
+		{path: "deep/apps/main.js", expected: []string{"@apps"}},
+		{path: "scripts/run.sh", expected: []string{"@scripter"}},
+		{path: "scripts/ci/run.sh", expected: []string{"@org/leads"}},
+		{path: "empty/file.txt"},
+	}
+	for _, tc := range testCases {
+		assert.ElementsMatch(t, tc.expected, codeOwnersOf(rules, tc.path), tc.path)
+	}
+}
+
+func TestFilterReviewed(t *testing.T) {
+	review := func(login, state string) *scm.Review {
+		return &scm.Review{Author: scm.User{Login: login}, State: state}
+	}
+	testCases := []struct {
+		name         string
+		requirements ReviewRequirements
+		reviews      []*scm.Review
+		changes      []string
+		expected     bool
+	}{
+		{
+			name:     "no requirements",
+			expected: true,
+		},
+		{
+			name:         "enough approvals",
+			requirements: ReviewRequirements{RequiredApprovingReviews: 2},
+			reviews:      []*scm.Review{review("a", scm.ReviewStateApproved), review("b", scm.ReviewStateApproved), review("a", scm.ReviewStateCommented)},
+			expected:     true,
+		},
+		{
+			name:         "the approval of the author does not count",
+			requirements: ReviewRequirements{RequiredApprovingReviews: 2},
+			reviews:      []*scm.Review{review("a", scm.ReviewStateApproved), review("Author", scm.ReviewStateApproved)},
+		},
+		{
+			name:         "changes requested after an approval",
+			requirements: ReviewRequirements{RequiredApprovingReviews: 1},
+			reviews:      []*scm.Review{review("a", scm.ReviewStateApproved), review("a", scm.ReviewStateChangesRequested)},
+		},
+		{
+			name:         "approved by the code owners",
+			requirements: ReviewRequirements{CodeOwnersApprovalRequired: true},
+			reviews:      []*scm.Review{review("gopher", scm.ReviewStateApproved), review("sig-lead", scm.ReviewStateApproved)},
+			changes:      []string{"main.go", "README.md", "unowned/file.txt"},
+			expected:     true,
+		},
+		{
+			name:         "a changed file is not approved by its owners",
+			requirements: ReviewRequirements{CodeOwnersApprovalRequired: true},
+			reviews:      []*scm.Review{review("sig-lead", scm.ReviewStateApproved)},
+			changes:      []string{"main.go", "README.md"},
+		},
+		{
+			name:         "team owner approved by a member",
+			requirements: ReviewRequirements{RequiredApprovingReviews: 1, CodeOwnersApprovalRequired: true},
+			reviews:      []*scm.Review{review("sig-lead", scm.ReviewStateApproved)},
+			changes:      []string{"README.md"},
+			expected:     true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var changes []*scm.Change
+			for _, path := range tc.changes {
+				changes = append(changes, &scm.Change{Path: path})
+			}
+			rc := &fake2.SCMClient{
+				Reviews:            map[int][]*scm.Review{1: tc.reviews},
+				PullRequestChanges: map[int][]*scm.Change{1: changes},
+				RemoteFiles: map[string]map[string]string{
+					".github/CODEOWNERS": {"master": "* @org/leads\n*.go @gopher\n/unowned/\n"},
+				},
+			}
+			pr := PullRequest{Number: githubql.Int(1)}
+			pr.Author.Login = "author"
+			pr.BaseRef.Name = "master"
+			pr.Repository.Owner.Login = "org"
+			pr.Repository.Name = "repo"
+
+			filtered := filterReviewed(rc, logrus.WithField("test", t.Name()), tc.requirements, []PullRequest{pr})
+			assert.Equal(t, tc.expected, len(filtered) == 1)
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package keeper
+
+import (
+	"sync"
+
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/git"
+)
+
+// baseChangesAgent caches the files changed on a branch between two of its commits. Cache entries expire if they are
+// not used during a sync loop.
+type baseChangesAgent struct {
+	cache map[baseChangesKey][]string
+	// nextCache caches the results used this sync for use next sync.
+	nextCache map[baseChangesKey][]string
+	sync.Mutex
+}
+
+type baseChangesKey struct {
+	org, repo string
+	from, to  string
+}
+
+func (a *baseChangesAgent) get(key baseChangesKey) ([]string, bool) {
+	a.Lock()
+	defer a.Unlock()
+	if a.nextCache == nil {
+		a.nextCache = make(map[baseChangesKey][]string)
+	}
+	changes, ok := a.cache[key]
+	if !ok {
+		changes, ok = a.nextCache[key]
+	}
+	if ok {
+		a.nextCache[key] = changes
+	}
+	return changes, ok
+}
+
+func (a *baseChangesAgent) put(key baseChangesKey, changes []string) {
+	a.Lock()
+	defer a.Unlock()
+	if a.nextCache == nil {
+		a.nextCache = make(map[baseChangesKey][]string)
+	}
+	a.nextCache[key] = changes
+}
+
+// prune removes any cached changes that were not used since the last prune.
+func (a *baseChangesAgent) prune() {
+	a.Lock()
+	defer a.Unlock()
+	a.cache = a.nextCache
+	a.nextCache = make(map[baseChangesKey][]string)
+}
+
+// carriedOverResults returns the successful presubmits of the PRs of the subpool which ran against an earlier base of
+// the branch and whose run_if_changed matches none of the files changed on the branch since, so that they count as
+// run against the current base and only the presubmits affected by the new base commits are run again. The
+// presubmits without run_if_changed always run again.
+func (c *DefaultController) carriedOverResults(sp *subpool) ([]v1alpha1.LighthouseJob, error) {
+	if len(sp.previousPJs) == 0 || !keeperExtension.get().SelectiveRetestFor(sp.org, sp.repo) {
+		return nil, nil
+	}
+	var r *git.Repo
+	defer func() {
+		if r != nil {
+			r.Clean()
+		}
+	}()
+	changesSince := func(base string) ([]string, error) {
+		key := baseChangesKey{org: sp.org, repo: sp.repo, from: base, to: sp.sha}
+		if changes, ok := c.baseChanges.get(key); ok {
+			return changes, nil
+		}
+		if r == nil {
+			var err error
+			if r, err = c.gc.Clone(sp.org + "/" + sp.repo); err != nil {
+				return nil, err
+			}
+		}
+		changes, err := r.ChangedFiles(base, sp.sha)
+		if err != nil {
+			return nil, err
+		}
+		c.baseChanges.put(key, changes)
+		return changes, nil
+	}
+
+	var answer []v1alpha1.LighthouseJob
+	for _, pr := range sp.prs {
+		for _, ps := range sp.presubmits[int(pr.Number)] {
+			if !ps.RegexpChangeMatcher.CouldRun() || ranFor(sp.pjs, pr, ps) {
+				continue
+			}
+			previous := lastSuccess(sp.previousPJs, pr, ps)
+			if previous == nil {
+				continue
+			}
+			changes, err := changesSince(previous.Spec.Refs.BaseSHA)
+			if err != nil {
+				return nil, err
+			}
+			if !ps.RunsAgainstChanges(changes) {
+				answer = append(answer, *previous)
+			}
+		}
+	}
+	return answer, nil
+}
+
+// ranFor returns true if one of the jobs is the presubmit run for the head of the PR
+func ranFor(pjs []v1alpha1.LighthouseJob, pr PullRequest, ps config.Presubmit) bool {
+	for i := range pjs {
+		if isRunFor(&pjs[i], pr, ps) {
+			return true
+		}
+	}
+	return false
+}
+
+// lastSuccess returns the latest successful run of the presubmit for the head of the PR, or nil if there is none
+func lastSuccess(pjs []v1alpha1.LighthouseJob, pr PullRequest, ps config.Presubmit) *v1alpha1.LighthouseJob {
+	var answer *v1alpha1.LighthouseJob
+	for i := range pjs {
+		pj := &pjs[i]
+		if !isRunFor(pj, pr, ps) || toSimpleState(pj.Status.State) != successState {
+			continue
+		}
+		if answer == nil || answer.Status.StartTime.Before(&pj.Status.StartTime) {
+			answer = pj
+		}
+	}
+	return answer
+}
+
+func isRunFor(pj *v1alpha1.LighthouseJob, pr PullRequest, ps config.Presubmit) bool {
+	return pj.Spec.Type == config.PresubmitJob && pj.Spec.Context == ps.Context && len(pj.Spec.Refs.Pulls) > 0 &&
+		pj.Spec.Refs.Pulls[0].Number == int(pr.Number) && pj.Spec.Refs.Pulls[0].SHA == string(pr.HeadRefOID)
+}
+
+// refreshAffected runs the presubmits affected by the new base commits of the PRs whose other results were carried
+// over, and which keeper did not just run for, so that the results of the whole pool stay fresh. The presubmits which
+// already ran against the current base are not run again, whether they passed or not.
+func (c *DefaultController) refreshAffected(sp subpool, carried []v1alpha1.LighthouseJob, missings, targets []PullRequest, missingSerialTests map[int][]config.Presubmit) ([]PullRequest, error) {
+	numbers := map[int]bool{}
+	for _, pj := range carried {
+		numbers[pj.Spec.Refs.Pulls[0].Number] = true
+	}
+	for _, pr := range targets {
+		delete(numbers, int(pr.Number))
+	}
+	var refreshed []PullRequest
+	for _, pr := range missings {
+		if !numbers[int(pr.Number)] {
+			continue
+		}
+		var affected []config.Presubmit
+		for _, ps := range missingSerialTests[int(pr.Number)] {
+			if !ranFor(sp.pjs, pr, ps) {
+				affected = append(affected, ps)
+			}
+		}
+		if len(affected) == 0 {
+			continue
+		}
+		if err := c.trigger(sp, map[int][]config.Presubmit{int(pr.Number): affected}, []PullRequest{pr}); err != nil {
+			return refreshed, err
+		}
+		refreshed = append(refreshed, pr)
+	}
+	return refreshed, nil
+}
@@ -0,0 +1,130 @@
+package keeper
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/git/localgit"
+	launcherfake "github.com/jenkins-x/lighthouse/pkg/launcher/fake"
+	"github.com/jenkins-x/lighthouse/pkg/requiredjobs"
+	githubql "github.com/shurcooL/githubv4"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectiveRetest(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, ioutil.WriteFile(fileName, []byte("keeper:\n  selective_retest:\n    org/repo: true\n"), 0600))
+	WatchExtension(fileName)
+	defer func() { keeperExtension = nil }()
+
+	lg, gc, err := localgit.New()
+	require.NoError(t, err)
+	defer gc.Clean()
+	defer lg.Clean()
+	require.NoError(t, lg.MakeFakeRepo("org", "repo"))
+	oldBase, err := lg.RevParse("org", "repo", "HEAD")
+	require.NoError(t, err)
+	require.NoError(t, lg.AddCommit("org", "repo", map[string][]byte{"docs/guide.md": []byte("guide")}))
+	newBase, err := lg.RevParse("org", "repo", "HEAD")
+	require.NoError(t, err)
+
+	cfg := &config.Config{}
+	require.NoError(t, cfg.SetPresubmits(map[string][]config.Presubmit{
+		"org/repo": {
+			{JobBase: config.JobBase{Name: "unit"}, Reporter: config.Reporter{Context: "unit"}, RegexpChangeMatcher: config.RegexpChangeMatcher{RunIfChanged: `\.go$`}},
+			{JobBase: config.JobBase{Name: "docs"}, Reporter: config.Reporter{Context: "docs"}, RegexpChangeMatcher: config.RegexpChangeMatcher{RunIfChanged: `^docs/`}},
+			{JobBase: config.JobBase{Name: "e2e"}, Reporter: config.Reporter{Context: "e2e"}},
+		},
+	}))
+	presubmits := requiredjobs.Presubmits(cfg, "org", "repo")
+	require.Len(t, presubmits, 3)
+
+	pr := func(number int) PullRequest {
+		var pr PullRequest
+		pr.Number = githubql.Int(number)
+		pr.HeadRefOID = "head"
+		pr.Commits.Nodes = []struct{ Commit Commit }{{Commit: Commit{OID: "head"}}}
+		return pr
+	}
+	ran := func(number int, ps config.Presubmit, base string, state v1alpha1.PipelineState) v1alpha1.LighthouseJob {
+		job := v1alpha1.LighthouseJob{Spec: v1alpha1.LighthouseJobSpec{
+			Type:    config.PresubmitJob,
+			Job:     ps.Name,
+			Context: ps.Context,
+			Refs:    &v1alpha1.Refs{Org: "org", Repo: "repo", BaseRef: "master", BaseSHA: base, Pulls: []v1alpha1.Pull{{Number: number, SHA: "head"}}},
+		}}
+		job.Status.State = state
+		return job
+	}
+
+	launcher := launcherfake.NewLauncher()
+	c := &DefaultController{
+		logger:         logrus.WithField("controller", "keeper"),
+		spc:            &fgc{},
+		gc:             gc,
+		launcherClient: launcher,
+	}
+	prs := []PullRequest{pr(1), pr(2)}
+	sp := subpool{
+		log:        c.logger,
+		org:        "org",
+		repo:       "repo",
+		branch:     "master",
+		sha:        newBase,
+		prs:        prs,
+		presubmits: map[int][]config.Presubmit{1: presubmits, 2: presubmits},
+		previousPJs: []v1alpha1.LighthouseJob{
+			ran(1, presubmits[0], oldBase, v1alpha1.SuccessState),
+			ran(1, presubmits[1], oldBase, v1alpha1.SuccessState),
+			ran(1, presubmits[2], oldBase, v1alpha1.SuccessState),
+			ran(2, presubmits[0], oldBase, v1alpha1.FailureState),
+		},
+	}
+
+	carried, err := c.carriedOverResults(&sp)
+	require.NoError(t, err)
+	require.Len(t, carried, 1, "only the successful presubmit not affected by the new base commits is carried over")
+	assert.Equal(t, "unit", carried[0].Spec.Context)
+	assert.Equal(t, 1, carried[0].Spec.Refs.Pulls[0].Number)
+	_, ok := c.baseChanges.get(baseChangesKey{org: "org", repo: "repo", from: oldBase, to: newBase})
+	assert.True(t, ok, "the changes of the base are cached")
+
+	_, _, missings, missingTests := accumulate(sp.presubmits, sp.prs, carried, sp.log)
+	assert.Equal(t, []int{1, 2}, prNumbers(missings))
+	assert.Equal(t, []string{"docs", "e2e"}, contexts(missingTests[1]))
+
+	// PR 2 was just triggered by keeper, so only the affected presubmits of PR 1 are run
+	refreshed, err := c.refreshAffected(sp, carried, missings, prs[1:], missingTests)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1}, prNumbers(refreshed))
+	var jobs []string
+	for _, run := range launcher.Pipelines {
+		assert.Equal(t, newBase, run.Spec.Refs.BaseSHA)
+		jobs = append(jobs, run.Spec.Job)
+	}
+	assert.ElementsMatch(t, []string{"docs", "e2e"}, jobs)
+
+	// the presubmits which already ran against the new base are not run again
+	sp.pjs = []v1alpha1.LighthouseJob{ran(1, presubmits[1], newBase, v1alpha1.FailureState), ran(1, presubmits[2], newBase, v1alpha1.PendingState)}
+	refreshed, err = c.refreshAffected(sp, carried, missings, nil, missingTests)
+	require.NoError(t, err)
+	assert.Empty(t, refreshed)
+
+	keeperExtension = nil
+	carried, err = c.carriedOverResults(&sp)
+	require.NoError(t, err)
+	assert.Empty(t, carried, "the results are not carried over unless enabled")
+}
+
+func contexts(presubmits []config.Presubmit) []string {
+	var answer []string
+	for _, ps := range presubmits {
+		answer = append(answer, ps.Context)
+	}
+	return answer
+}
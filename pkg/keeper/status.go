@@ -68,6 +68,8 @@ type statusController struct {
 	logger *logrus.Entry
 	config config.Getter
 	spc    scmProviderClient
+	// dryRun is true if the status context is only logged rather than set
+	dryRun bool
 
 	// newPoolPending is a size 1 chan that signals that the main Keeper loop has
 	// updated the 'poolPRs' field with a freshly updated pool.
@@ -203,15 +205,19 @@ func requirementDiff(pr *PullRequest, q *config.KeeperQuery, cc contextChecker)
 	}
 
 	// fixing label issues takes precedence over status contexts
-	var contexts []string
+	var contexts, unreported []string
 	for _, commit := range pr.Commits.Nodes {
 		if commit.Commit.OID == pr.HeadRefOID {
 			for _, ctx := range unsuccessfulContexts(commit.Commit.Status.Contexts, cc, logrus.New().WithFields(pr.logFields())) {
-				contexts = append(contexts, string(ctx.Context))
+				if ctx.State == githubql.StatusStateExpected {
+					unreported = append(unreported, string(ctx.Context))
+				} else {
+					contexts = append(contexts, string(ctx.Context))
+				}
 			}
 		}
 	}
-	diff += len(contexts)
+	diff += len(contexts) + len(unreported)
 	if desc == "" && len(contexts) > 0 {
 		sort.Strings(contexts)
 		trunced := truncate(contexts)
@@ -221,6 +227,16 @@ func requirementDiff(pr *PullRequest, q *config.KeeperQuery, cc contextChecker)
 			desc = fmt.Sprintf(" Jobs %s have not succeeded.", strings.Join(trunced, ", "))
 		}
 	}
+	// the contexts required by the context policy, e.g. from the branch protection, which were never reported
+	if desc == "" && len(unreported) > 0 {
+		sort.Strings(unreported)
+		trunced := truncate(unreported)
+		if len(trunced) == 1 {
+			desc = fmt.Sprintf(" Required context %s has not been reported.", trunced[0])
+		} else {
+			desc = fmt.Sprintf(" Required contexts %s have not been reported.", strings.Join(trunced, ", "))
+		}
+	}
 
 	// TODO(cjwagner): List reviews (states:[APPROVED], first: 1) as part of open
 	// PR query.
@@ -258,6 +274,10 @@ func expectedStatus(queryMap *config.QueryMap, pr *PullRequest, pool map[string]
 				minDiff = diff
 			}
 		}
+		// a PR matching the query is kept out of the pool until the time of its merge-after label
+		if t, ok := mergeAfter(pr); ok && minDiff == "" {
+			minDiff = fmt.Sprintf(" Scheduled to merge after %s.", t.Format(time.RFC3339))
+		}
 		// GitLab doesn't like updating status description without a state change.
 		if providerType == "gitlab" {
 			minDiff = ""
@@ -321,10 +341,13 @@ func (sc *statusController) setStatuses(all []PullRequest, pool map[string]PullR
 				actualDesc = string(ctx.Description)
 			}
 		}
-		if wantState != strings.ToLower(string(actualState)) || wantDesc != actualDesc {
+		changed := wantState != strings.ToLower(string(actualState)) || wantDesc != actualDesc
+		if changed && sc.dryRun {
+			log.WithField("state", wantState).WithField("description", wantDesc).Debug("Dry run: would set the status context.")
+		} else if changed {
 			reportURL := ""
 			// BitBucket Server requires a valid URL in all status reports
-			if sc.spc.ProviderType() == "stash" {
+			if scmprovider.CapabilitiesOf(sc.spc.ProviderType()).StatusTargetURLRequired {
 				reportURL = "https://github.com/jenkins-x/lighthouse"
 			}
 			if _, err := sc.spc.CreateGraphQLStatus(
@@ -43,6 +43,7 @@ func TestExpectedStatus(t *testing.T) {
 		labels          []string
 		milestone       string
 		contexts        []Context
+		required        []string
 		inPool          bool
 		blocks          []int
 
@@ -166,6 +167,28 @@ func TestExpectedStatus(t *testing.T) {
 			state: scmprovider.StatusPending,
 			desc:  fmt.Sprintf(statusNotInPool, " Jobs job-name, other-job-name have not succeeded."),
 		},
+		{
+			name:      "unreported required context",
+			labels:    neededLabels,
+			milestone: "v1.0",
+			contexts:  []Context{{Context: githubql.String("job-name"), State: githubql.StatusStateSuccess}},
+			required:  []string{"job-name", "security-scan"},
+			inPool:    false,
+
+			state: scmprovider.StatusPending,
+			desc:  fmt.Sprintf(statusNotInPool, " Required context security-scan has not been reported."),
+		},
+		{
+			name:      "failed context before unreported required context",
+			labels:    neededLabels,
+			milestone: "v1.0",
+			contexts:  []Context{{Context: githubql.String("job-name"), State: githubql.StatusStateFailure}},
+			required:  []string{"job-name", "security-scan"},
+			inPool:    false,
+
+			state: scmprovider.StatusPending,
+			desc:  fmt.Sprintf(statusNotInPool, " Job job-name has not succeeded."),
+		},
 		{
 			name:      "wrong milestone",
 			labels:    neededLabels,
@@ -186,6 +209,26 @@ func TestExpectedStatus(t *testing.T) {
 			state: scmprovider.StatusPending,
 			desc:  fmt.Sprintf(statusNotInPool, ""),
 		},
+		{
+			name:      "scheduled merge",
+			labels:    append(append([]string{}, neededLabels...), "merge-after: 2999-07-01T09:00Z"),
+			milestone: "v1.0",
+			contexts:  []Context{{Context: githubql.String("job-name"), State: githubql.StatusStateSuccess}},
+			inPool:    false,
+
+			state: scmprovider.StatusPending,
+			desc:  fmt.Sprintf(statusNotInPool, " Scheduled to merge after 2999-07-01T09:00:00Z."),
+		},
+		{
+			name:      "scheduled merge passed",
+			labels:    append(append([]string{}, neededLabels...), "merge-after: 2000-07-01T09:00Z"),
+			milestone: "v1.0",
+			contexts:  []Context{{Context: githubql.String("job-name"), State: githubql.StatusStateSuccess}},
+			inPool:    false,
+
+			state: scmprovider.StatusPending,
+			desc:  fmt.Sprintf(statusNotInPool, ""),
+		},
 		{
 			name:      "check that min diff query is used",
 			labels:    []string{"3", "4", "5", "6", "7"},
@@ -274,7 +317,7 @@ func TestExpectedStatus(t *testing.T) {
 		}
 		blocks.Repo[blockers.OrgRepo{Org: "", Repo: ""}] = items
 
-		state, desc := expectedStatus(queriesByRepo, &pr, pool, &config.KeeperContextPolicy{}, blocks, "fake")
+		state, desc := expectedStatus(queriesByRepo, &pr, pool, &config.KeeperContextPolicy{RequiredContexts: tc.required}, blocks, "fake")
 		if state != tc.state {
 			t.Errorf("Expected status state %q, but got %q.", string(tc.state), string(state))
 		}
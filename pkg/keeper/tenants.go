@@ -0,0 +1,25 @@
+package keeper
+
+import (
+	"github.com/jenkins-x/lighthouse/pkg/tenants"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// keeperTenants returns the tenants whose jobs keeper launches in their own namespaces
+var keeperTenants = func() *tenants.Config {
+	return &tenants.Config{}
+}
+
+// ScopeTenants makes keeper launch the jobs of the repositories of the tenants in their namespaces
+func ScopeTenants(config func() *tenants.Config) {
+	keeperTenants = config
+}
+
+// jobsNamespace returns the namespace keeper lists the jobs in, which is every namespace when the jobs of the
+// tenants run in their own namespaces
+func (c *DefaultController) jobsNamespace() string {
+	if len(keeperTenants().Tenants) > 0 {
+		return metav1.NamespaceAll
+	}
+	return c.ns
+}
@@ -0,0 +1,21 @@
+package keeper
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/lighthouse/pkg/tenants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestJobsNamespace(t *testing.T) {
+	c := &DefaultController{ns: "jx"}
+	assert.Equal(t, "jx", c.jobsNamespace())
+
+	cfg, err := tenants.LoadConfig([]byte("tenants:\n- name: a\n  namespace: a\n  orgs: [a]\n"))
+	require.NoError(t, err)
+	ScopeTenants(func() *tenants.Config { return cfg })
+	defer ScopeTenants(func() *tenants.Config { return &tenants.Config{} })
+	assert.Equal(t, metav1.NamespaceAll, c.jobsNamespace(), "the jobs of the tenants are listed in every namespace")
+}
@@ -0,0 +1,109 @@
+package keeper
+
+import (
+	"sync"
+	"time"
+
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+var throughputMetrics = struct {
+	mergedPRs   *prometheus.CounterVec
+	timeInPool  *prometheus.HistogramVec
+	openToMerge *prometheus.HistogramVec
+	retests     *prometheus.HistogramVec
+}{
+	mergedPRs: prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "lighthouse_keeper_merged_prs",
+		Help: "A counter of the pull requests merged by keeper.",
+	}, []string{"org", "repo"}),
+	timeInPool: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "lighthouse_keeper_time_in_pool_seconds",
+		Help:    "The time the merged pull requests spent in the pool, from the first sync they were found in it.",
+		Buckets: prometheus.ExponentialBuckets(60, 2, 12),
+	}, []string{"org", "repo"}),
+	openToMerge: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "lighthouse_keeper_open_to_merge_seconds",
+		Help:    "The time from the opening of the merged pull requests to their merge.",
+		Buckets: prometheus.ExponentialBuckets(600, 2, 14),
+	}, []string{"org", "repo"}),
+	retests: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "lighthouse_keeper_merged_pr_retests",
+		Help:    "The number of presubmits rerun against the head and base the merged pull requests were merged with.",
+		Buckets: []float64{0, 1, 2, 3, 5, 8, 13, 21},
+	}, []string{"org", "repo"}),
+}
+
+func init() {
+	prometheus.MustRegister(throughputMetrics.mergedPRs)
+	prometheus.MustRegister(throughputMetrics.timeInPool)
+	prometheus.MustRegister(throughputMetrics.openToMerge)
+	prometheus.MustRegister(throughputMetrics.retests)
+}
+
+// poolEntries remembers when each pull request was first found in the pool, forgetting those which left it. It is
+// kept in memory, so the time in pool of the pull requests pooled before a restart of keeper starts over.
+type poolEntries struct {
+	lock    sync.Mutex
+	entries map[string]time.Time
+}
+
+// update records the pull requests found in the pool at the given time and forgets the others
+func (p *poolEntries) update(pools map[string]*subpool, t time.Time) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	entries := map[string]time.Time{}
+	for _, sp := range pools {
+		for i := range sp.prs {
+			key := prKey(&sp.prs[i])
+			if entered, ok := p.entries[key]; ok {
+				entries[key] = entered
+			} else {
+				entries[key] = t
+			}
+		}
+	}
+	p.entries = entries
+}
+
+// entered returns when the pull request was first found in the pool
+func (p *poolEntries) entered(pr *PullRequest) (time.Time, bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	t, ok := p.entries[prKey(pr)]
+	return t, ok
+}
+
+// recordMergeMetrics records the merge of the pull request of the subpool at the given time in the throughput metrics
+func (c *DefaultController) recordMergeMetrics(sp subpool, pr PullRequest, t time.Time) {
+	throughputMetrics.mergedPRs.WithLabelValues(sp.org, sp.repo).Inc()
+	if entered, ok := c.poolEntries.entered(&pr); ok {
+		throughputMetrics.timeInPool.WithLabelValues(sp.org, sp.repo).Observe(t.Sub(entered).Seconds())
+	}
+	if !pr.CreatedAt.IsZero() {
+		throughputMetrics.openToMerge.WithLabelValues(sp.org, sp.repo).Observe(t.Sub(pr.CreatedAt.Time).Seconds())
+	}
+	throughputMetrics.retests.WithLabelValues(sp.org, sp.repo).Observe(float64(retests(sp, pr)))
+}
+
+// retests returns the number of presubmits of the pull request rerun against its head and the base of the subpool,
+// that is its presubmit jobs beyond the first of each context
+func retests(sp subpool, pr PullRequest) int {
+	contexts := sets.NewString()
+	runs := 0
+	for _, job := range sp.pjs {
+		if job.Spec.Type != config.PresubmitJob || job.Spec.Refs == nil || len(job.Spec.Refs.Pulls) != 1 {
+			continue
+		}
+		pull := job.Spec.Refs.Pulls[0]
+		if pull.Number != int(pr.Number) || pull.SHA != string(pr.HeadRefOID) {
+			continue
+		}
+		contexts.Insert(job.Spec.Context)
+		runs++
+	}
+	return runs - contexts.Len()
+}
@@ -0,0 +1,72 @@
+package keeper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	githubql "github.com/shurcooL/githubv4"
+	"github.com/stretchr/testify/assert"
+)
+
+func throughputPR(number int, sha string) PullRequest {
+	pr := PullRequest{Number: githubql.Int(number), HeadRefOID: githubql.String(sha)}
+	pr.Repository.NameWithOwner = "org/repo"
+	return pr
+}
+
+func TestPoolEntries(t *testing.T) {
+	start := time.Date(2020, 6, 1, 10, 0, 0, 0, time.UTC)
+	first, second := throughputPR(1, "a"), throughputPR(2, "b")
+	p := &poolEntries{}
+
+	p.update(map[string]*subpool{"org/repo:master": {prs: []PullRequest{first}}}, start)
+	p.update(map[string]*subpool{"org/repo:master": {prs: []PullRequest{first, second}}}, start.Add(time.Minute))
+	entered, ok := p.entered(&first)
+	assert.True(t, ok)
+	assert.Equal(t, start, entered, "a PR stays in the pool since it was first found")
+	entered, ok = p.entered(&second)
+	assert.True(t, ok)
+	assert.Equal(t, start.Add(time.Minute), entered)
+
+	p.update(map[string]*subpool{"org/repo:master": {prs: []PullRequest{second}}}, start.Add(2*time.Minute))
+	_, ok = p.entered(&first)
+	assert.False(t, ok, "a PR which left the pool is forgotten")
+}
+
+func TestRetests(t *testing.T) {
+	job := func(kind config.PipelineKind, context string, number int, sha string) v1alpha1.LighthouseJob {
+		return v1alpha1.LighthouseJob{Spec: v1alpha1.LighthouseJobSpec{
+			Type:    kind,
+			Context: context,
+			Refs:    &v1alpha1.Refs{Pulls: []v1alpha1.Pull{{Number: number, SHA: sha}}},
+		}}
+	}
+	sp := subpool{pjs: []v1alpha1.LighthouseJob{
+		job(config.PresubmitJob, "unit", 1, "head"),
+		job(config.PresubmitJob, "unit", 1, "head"),
+		job(config.PresubmitJob, "unit", 1, "head"),
+		job(config.PresubmitJob, "e2e", 1, "head"),
+		job(config.PresubmitJob, "unit", 1, "old"),
+		job(config.PresubmitJob, "unit", 2, "other"),
+		job(config.BatchJob, "unit", 1, "head"),
+	}}
+	assert.Equal(t, 2, retests(sp, throughputPR(1, "head")))
+	assert.Equal(t, 0, retests(sp, throughputPR(2, "other")))
+	assert.Equal(t, 0, retests(sp, throughputPR(3, "none")))
+}
+
+func TestRecordMergeMetrics(t *testing.T) {
+	merged := time.Date(2020, 6, 1, 10, 0, 0, 0, time.UTC)
+	pr := throughputPR(1, "head")
+	pr.CreatedAt = githubql.DateTime{Time: merged.Add(-time.Hour)}
+	sp := subpool{org: "metrics", repo: "repo", prs: []PullRequest{pr}}
+	c := &DefaultController{}
+	c.poolEntries.update(map[string]*subpool{"metrics/repo:master": &sp}, merged.Add(-time.Minute))
+
+	before := testutil.ToFloat64(throughputMetrics.mergedPRs.WithLabelValues("metrics", "repo"))
+	c.recordMergeMetrics(sp, pr, merged)
+	assert.Equal(t, before+1, testutil.ToFloat64(throughputMetrics.mergedPRs.WithLabelValues("metrics", "repo")))
+}
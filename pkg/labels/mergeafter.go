@@ -0,0 +1,67 @@
+package labels
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// MergeAfterPrefix starts the labels keeping a pull request out of the keeper pool until a time, such as
+// "merge-after: 2024-07-01T09:00Z"
+const MergeAfterPrefix = "merge-after: "
+
+// mergeAfterLayout is the layout of the time of the merge-after labels, which is always in UTC
+const mergeAfterLayout = "2006-01-02T15:04Z07:00"
+
+// mergeAfterLayouts are the layouts the time of a merge-after label can be given with, the times without a zone
+// being in UTC
+var mergeAfterLayouts = []string{
+	time.RFC3339,
+	mergeAfterLayout,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+// ParseMergeAfterTime parses the time a pull request can be merged after, e.g. 2024-07-01T09:00Z or 2024-07-01
+func ParseMergeAfterTime(value string) (time.Time, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	for _, layout := range mergeAfterLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, errors.Errorf("invalid time %q, expected a time like 2024-07-01T09:00Z", value)
+}
+
+// MergeAfter returns the label keeping a pull request out of the keeper pool until the time, to the minute
+func MergeAfter(t time.Time) string {
+	return MergeAfterPrefix + t.UTC().Truncate(time.Minute).Format(mergeAfterLayout)
+}
+
+// IsMergeAfter returns true if the label keeps a pull request out of the keeper pool until a time
+func IsMergeAfter(label string) bool {
+	return strings.HasPrefix(label, MergeAfterPrefix)
+}
+
+// MergeAfterTime returns the latest of the times the merge-after labels keep a pull request out of the keeper pool
+// until, or false if none of the labels is a valid merge-after label
+func MergeAfterTime(labels []string) (time.Time, bool) {
+	var answer time.Time
+	found := false
+	for _, label := range labels {
+		if !IsMergeAfter(label) {
+			continue
+		}
+		t, err := ParseMergeAfterTime(strings.TrimPrefix(label, MergeAfterPrefix))
+		if err != nil {
+			continue
+		}
+		if !found || t.After(answer) {
+			answer = t
+		}
+		found = true
+	}
+	return answer, found
+}
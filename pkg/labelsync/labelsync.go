@@ -0,0 +1,220 @@
+// Package labelsync reconciles the labels of repositories with a declarative label set, so that the labels which
+// the plugins depend on always exist with consistent colors and descriptions.
+package labelsync
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"sigs.k8s.io/yaml"
+)
+
+var colorRegex = regexp.MustCompile(`^#?[0-9a-fA-F]{6}$`)
+
+// Label is a label which should exist in the repositories
+type Label struct {
+	// Name is the name of the label
+	Name string `json:"name"`
+	// Color is the hexadecimal RGB color of the label, e.g. 0ffa16
+	Color string `json:"color"`
+	// Description is the description of the label
+	Description string `json:"description,omitempty"`
+	// Aliases are the previous names of the label, whose labels are renamed or migrated to this label
+	Aliases []string `json:"aliases,omitempty"`
+}
+
+// Configuration is the label set of the repositories
+type Configuration struct {
+	// Default are the labels of all the repositories
+	Default []Label `json:"default,omitempty"`
+	// Repos are the additional labels of the orgs or org/repo repositories, which override the labels of the same
+	// name of the org and default labels
+	Repos map[string][]Label `json:"repos,omitempty"`
+}
+
+// Load loads and validates the label configuration of the file
+func Load(path string) (*Configuration, error) {
+	data, err := ioutil.ReadFile(path) // #nosec
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading label configuration %s", path)
+	}
+	config := &Configuration{}
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return nil, errors.Wrapf(err, "parsing label configuration %s", path)
+	}
+	if err := config.Validate(); err != nil {
+		return nil, errors.Wrapf(err, "invalid label configuration %s", path)
+	}
+	return config, nil
+}
+
+// Validate checks that the labels are well formed and that no name is used twice in the label set of a repository
+func (c *Configuration) Validate() error {
+	if err := validateLabels(c.Default); err != nil {
+		return errors.Wrap(err, "default labels")
+	}
+	for name := range c.Repos {
+		org, repo := name, ""
+		if i := strings.Index(name, "/"); i >= 0 {
+			org, repo = name[:i], name[i+1:]
+		}
+		if err := validateLabels(c.LabelsFor(org, repo)); err != nil {
+			return errors.Wrapf(err, "labels of %s", name)
+		}
+	}
+	return nil
+}
+
+func validateLabels(labels []Label) error {
+	names := map[string]string{}
+	for _, label := range labels {
+		if label.Name == "" {
+			return fmt.Errorf("a label has no name")
+		}
+		if !colorRegex.MatchString(label.Color) {
+			return fmt.Errorf("label %s has the invalid color %q, expected 6 hexadecimal digits", label.Name, label.Color)
+		}
+		for i, name := range append([]string{label.Name}, label.Aliases...) {
+			key := strings.ToLower(name)
+			if i > 0 && strings.EqualFold(name, label.Name) {
+				// the label is renamed to a different case
+				continue
+			}
+			if other, ok := names[key]; ok {
+				return fmt.Errorf("labels %s and %s both use the name %s", other, label.Name, name)
+			}
+			names[key] = label.Name
+		}
+	}
+	return nil
+}
+
+// LabelsFor returns the labels of the repository, sorted by name
+func (c *Configuration) LabelsFor(org, repo string) []Label {
+	labels := map[string]Label{}
+	for _, group := range [][]Label{c.Default, c.Repos[org], c.Repos[org+"/"+repo]} {
+		for _, label := range group {
+			labels[strings.ToLower(label.Name)] = label
+		}
+	}
+	var answer []Label
+	for _, label := range labels {
+		answer = append(answer, label)
+	}
+	sort.Slice(answer, func(i, j int) bool {
+		return answer[i].Name < answer[j].Name
+	})
+	return answer
+}
+
+// Client is the subset of the SCM API used to synchronize labels
+type Client interface {
+	GetRepoLabels(org, repo string) ([]*scm.Label, error)
+	CreateRepoLabel(org, repo string, label scm.Label) error
+	UpdateRepoLabel(org, repo, name string, label scm.Label) error
+	DeleteRepoLabel(org, repo, name string) error
+	Search(opts scm.SearchOptions) ([]*scm.SearchIssue, *scmprovider.RateLimits, error)
+	AddLabel(org, repo string, number int, label string, pr bool) error
+	RemoveLabel(org, repo string, number int, label string, pr bool) error
+}
+
+// Sync makes the labels of the repository match the labels. A missing label is created, or renamed from one of
+// its aliases so that it stays on the same issues and pull requests. The issues and pull requests of the other
+// aliases are labelled with the label before the aliases are deleted. The labels of the repository which are not
+// in the label set are left alone.
+func Sync(client Client, org, repo string, labels []Label, logger *logrus.Entry) error {
+	current, err := client.GetRepoLabels(org, repo)
+	if err != nil {
+		return errors.Wrap(err, "listing the labels")
+	}
+	existing := map[string]*scm.Label{}
+	for _, label := range current {
+		existing[strings.ToLower(label.Name)] = label
+	}
+
+	var errs []string
+	for _, label := range labels {
+		l := logger.WithField("label", label.Name)
+		if err := syncLabel(client, org, repo, label, existing, l); err != nil {
+			l.WithError(err).Error("failed to synchronize the label")
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to synchronize %d labels: %s", len(errs), strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func syncLabel(client Client, org, repo string, label Label, existing map[string]*scm.Label, logger *logrus.Entry) error {
+	desired := scm.Label{
+		Name:        label.Name,
+		Color:       strings.ToLower(strings.TrimPrefix(label.Color, "#")),
+		Description: label.Description,
+	}
+	current := existing[strings.ToLower(label.Name)]
+	var aliases []*scm.Label
+	for _, alias := range label.Aliases {
+		if l, ok := existing[strings.ToLower(alias)]; ok && l != current {
+			aliases = append(aliases, l)
+		}
+	}
+
+	switch {
+	case current == nil && len(aliases) > 0:
+		logger.Infof("renaming the label %s", aliases[0].Name)
+		if err := client.UpdateRepoLabel(org, repo, aliases[0].Name, desired); err != nil {
+			return errors.Wrapf(err, "renaming the label %s", aliases[0].Name)
+		}
+		aliases = aliases[1:]
+	case current == nil:
+		logger.Info("creating the label")
+		if err := client.CreateRepoLabel(org, repo, desired); err != nil {
+			return errors.Wrap(err, "creating the label")
+		}
+	case current.Name != desired.Name || !strings.EqualFold(strings.TrimPrefix(current.Color, "#"), desired.Color) || current.Description != desired.Description:
+		logger.Info("updating the label")
+		if err := client.UpdateRepoLabel(org, repo, current.Name, desired); err != nil {
+			return errors.Wrap(err, "updating the label")
+		}
+	}
+
+	for _, alias := range aliases {
+		if err := migrateAlias(client, org, repo, alias.Name, label.Name, logger); err != nil {
+			return errors.Wrapf(err, "migrating the label %s", alias.Name)
+		}
+	}
+	return nil
+}
+
+// migrateAlias labels the issues and pull requests of the alias with the label, then deletes the alias once none
+// is left. Search results are limited, so the remaining ones are migrated by the next synchronizations.
+func migrateAlias(client Client, org, repo, alias, name string, logger *logrus.Entry) error {
+	results, _, err := client.Search(scm.SearchOptions{
+		Query: fmt.Sprintf("repo:%s/%s label:%q", org, repo, alias),
+	})
+	if err != nil {
+		return errors.Wrap(err, "searching the issues and pull requests")
+	}
+	for _, issue := range results {
+		logger.Infof("migrating %s/%s#%d from the label %s", org, repo, issue.Number, alias)
+		if err := client.AddLabel(org, repo, issue.Number, name, issue.PullRequest); err != nil {
+			return errors.Wrapf(err, "labelling %s/%s#%d", org, repo, issue.Number)
+		}
+		if err := client.RemoveLabel(org, repo, issue.Number, alias, issue.PullRequest); err != nil {
+			return errors.Wrapf(err, "unlabelling %s/%s#%d", org, repo, issue.Number)
+		}
+	}
+	if len(results) > 0 {
+		return nil
+	}
+	logger.Infof("deleting the label %s", alias)
+	return client.DeleteRepoLabel(org, repo, alias)
+}
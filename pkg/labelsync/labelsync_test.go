@@ -0,0 +1,174 @@
+package labelsync
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClient keeps the labels of a repository and of its issues
+type fakeClient struct {
+	labels  map[string]scm.Label
+	issues  map[int][]string
+	actions []string
+}
+
+func (f *fakeClient) GetRepoLabels(org, repo string) ([]*scm.Label, error) {
+	var answer []*scm.Label
+	for _, label := range f.labels {
+		label := label
+		answer = append(answer, &label)
+	}
+	return answer, nil
+}
+
+func (f *fakeClient) CreateRepoLabel(org, repo string, label scm.Label) error {
+	f.actions = append(f.actions, "create "+label.Name)
+	f.labels[label.Name] = label
+	return nil
+}
+
+func (f *fakeClient) UpdateRepoLabel(org, repo, name string, label scm.Label) error {
+	f.actions = append(f.actions, fmt.Sprintf("update %s to %s", name, label.Name))
+	delete(f.labels, name)
+	f.labels[label.Name] = label
+	for number, labels := range f.issues {
+		for i, l := range labels {
+			if l == name {
+				f.issues[number][i] = label.Name
+			}
+		}
+	}
+	return nil
+}
+
+func (f *fakeClient) DeleteRepoLabel(org, repo, name string) error {
+	f.actions = append(f.actions, "delete "+name)
+	delete(f.labels, name)
+	return nil
+}
+
+func (f *fakeClient) Search(opts scm.SearchOptions) ([]*scm.SearchIssue, *scmprovider.RateLimits, error) {
+	var answer []*scm.SearchIssue
+	for number, labels := range f.issues {
+		for _, l := range labels {
+			if strings.HasSuffix(opts.Query, fmt.Sprintf("label:%q", l)) {
+				answer = append(answer, &scm.SearchIssue{Issue: scm.Issue{Number: number, PullRequest: true}})
+			}
+		}
+	}
+	return answer, nil, nil
+}
+
+func (f *fakeClient) AddLabel(org, repo string, number int, label string, pr bool) error {
+	f.issues[number] = append(f.issues[number], label)
+	return nil
+}
+
+func (f *fakeClient) RemoveLabel(org, repo string, number int, label string, pr bool) error {
+	var labels []string
+	for _, l := range f.issues[number] {
+		if l != label {
+			labels = append(labels, l)
+		}
+	}
+	f.issues[number] = labels
+	return nil
+}
+
+func TestSync(t *testing.T) {
+	client := &fakeClient{
+		labels: map[string]scm.Label{
+			"LGTM":          {Name: "LGTM", Color: "15dd18"},
+			"approved":      {Name: "approved", Color: "#000000", Description: "old"},
+			"needs-ok":      {Name: "needs-ok", Color: "ededed"},
+			"ok-to-test":    {Name: "ok-to-test", Color: "ededed"},
+			"do-not-merge":  {Name: "do-not-merge", Color: "e11d21"},
+			"kind/question": {Name: "kind/question", Color: "ededed"},
+		},
+		issues: map[int][]string{
+			1: {"LGTM"},
+			2: {"needs-ok", "approved"},
+		},
+	}
+	labels := []Label{
+		{Name: "lgtm", Color: "15dd18", Description: "Indicates that a PR is ready to be merged.", Aliases: []string{"LGTM"}},
+		{Name: "approved", Color: "0ffa16", Description: "Indicates a PR has been approved."},
+		{Name: "needs-ok-to-test", Color: "e11d21", Aliases: []string{"needs-ok", "ok-to-test"}},
+		{Name: "do-not-merge/hold", Color: "#E11D21"},
+	}
+
+	require.NoError(t, Sync(client, "org", "repo", labels, logrus.WithField("test", t.Name())))
+	assert.ElementsMatch(t, []string{
+		"update LGTM to lgtm",
+		"update approved to approved",
+		"update needs-ok to needs-ok-to-test",
+		"delete ok-to-test",
+		"create do-not-merge/hold",
+	}, client.actions)
+	assert.Equal(t, scm.Label{Name: "approved", Color: "0ffa16", Description: "Indicates a PR has been approved."}, client.labels["approved"])
+	assert.Equal(t, "e11d21", client.labels["do-not-merge/hold"].Color)
+	assert.Contains(t, client.labels, "kind/question", "the labels which are not configured should be kept")
+	assert.Equal(t, []string{"lgtm"}, client.issues[1])
+	assert.ElementsMatch(t, []string{"needs-ok-to-test", "approved"}, client.issues[2])
+
+	// aliases which are still in use are migrated before being deleted
+	client.labels["ok-to-test"] = scm.Label{Name: "ok-to-test", Color: "ededed"}
+	client.issues[3] = []string{"ok-to-test"}
+	client.actions = nil
+	require.NoError(t, Sync(client, "org", "repo", labels, logrus.WithField("test", t.Name())))
+	assert.Empty(t, client.actions, "the alias should only be deleted once it is not used")
+	assert.Equal(t, []string{"needs-ok-to-test"}, client.issues[3])
+	require.NoError(t, Sync(client, "org", "repo", labels, logrus.WithField("test", t.Name())))
+	assert.Equal(t, []string{"delete ok-to-test"}, client.actions)
+
+	client.actions = nil
+	require.NoError(t, Sync(client, "org", "repo", labels, logrus.WithField("test", t.Name())))
+	assert.Empty(t, client.actions, "the labels should be in sync")
+}
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "labelsync")
+	require.NoError(t, err)
+	path := filepath.Join(dir, "labels.yaml")
+	require.NoError(t, ioutil.WriteFile(path, []byte(`
+default:
+- name: lgtm
+  color: 15dd18
+  aliases: [LGTM]
+- name: approved
+  color: 0ffa16
+repos:
+  org:
+  - name: approved
+    color: "#000000"
+  org/repo:
+  - name: area/docs
+    color: d2b48c
+`), 0600))
+	config, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, []Label{
+		{Name: "approved", Color: "#000000"},
+		{Name: "area/docs", Color: "d2b48c"},
+		{Name: "lgtm", Color: "15dd18", Aliases: []string{"LGTM"}},
+	}, config.LabelsFor("org", "repo"))
+	assert.Len(t, config.LabelsFor("other", "repo"), 2)
+
+	for name, labels := range map[string][]Label{
+		"missing name":  {{Color: "15dd18"}},
+		"invalid color": {{Name: "lgtm", Color: "green"}},
+		"duplicate":     {{Name: "lgtm", Color: "15dd18"}, {Name: "approved", Color: "0ffa16", Aliases: []string{"LGTM"}}},
+	} {
+		config := &Configuration{Repos: map[string][]Label{"org/repo": labels}}
+		assert.Error(t, config.Validate(), name)
+	}
+}
@@ -0,0 +1,209 @@
+package launcher
+
+import (
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/yaml"
+)
+
+// JobDefaultsEnv is the environment variable pointing at the file containing the JobDefaults
+const JobDefaultsEnv = "LIGHTHOUSE_JOB_DEFAULTS"
+
+// PodDefaults are the settings of the pods of a job. The namespace is only used by jobs using the
+// kubernetes agent, as pipelines have to run in the namespace of the launcher, or of the tenant of the job, to be
+// tracked. Note that the
+// job configuration fills in the namespace of every job from pod_namespace, so a default namespace only
+// applies to jobs created without one.
+type PodDefaults struct {
+	Namespace          string                      `json:"namespace,omitempty"`
+	ServiceAccountName string                      `json:"service_account_name,omitempty"`
+	NodeSelector       map[string]string           `json:"node_selector,omitempty"`
+	Tolerations        []corev1.Toleration         `json:"tolerations,omitempty"`
+	Resources          corev1.ResourceRequirements `json:"resources,omitempty"`
+	// AllowedSecrets are the secrets jobs can set their environment variables from, keyed by namespace. The
+	// allowed secrets of the "*", org and repository defaults add up, as they cannot be given by the job.
+	AllowedSecrets map[string][]string `json:"allowed_secrets,omitempty"`
+}
+
+// JobDefaults are the PodDefaults keyed by "*", "org" or "org/repo", where the more specific keys
+// override the less specific ones and the job's own settings override them all.
+type JobDefaults map[string]PodDefaults
+
+// LoadJobDefaults reads the JobDefaults from a YAML file
+func LoadJobDefaults(fileName string) (JobDefaults, error) {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading job defaults file %s", fileName)
+	}
+	defaults := JobDefaults{}
+	if err := yaml.Unmarshal(data, &defaults); err != nil {
+		return nil, errors.Wrapf(err, "parsing job defaults file %s", fileName)
+	}
+	return defaults, nil
+}
+
+// For returns the merged defaults for the given repository
+func (d JobDefaults) For(org, repo string) PodDefaults {
+	answer := d["*"]
+	if org != "" {
+		answer = answer.Merge(d[org])
+		if repo != "" {
+			answer = answer.Merge(d[org+"/"+repo])
+		}
+	}
+	return answer
+}
+
+// Merge returns the defaults overridden by the fields set in override. Node selectors and resources are
+// merged by key while tolerations are replaced.
+func (p PodDefaults) Merge(override PodDefaults) PodDefaults {
+	answer := PodDefaults{
+		Namespace:          p.Namespace,
+		ServiceAccountName: p.ServiceAccountName,
+		NodeSelector:       mergeStrings(p.NodeSelector, override.NodeSelector),
+		Tolerations:        p.Tolerations,
+		Resources: corev1.ResourceRequirements{
+			Limits:   mergeResources(p.Resources.Limits, override.Resources.Limits),
+			Requests: mergeResources(p.Resources.Requests, override.Resources.Requests),
+		},
+		AllowedSecrets: mergeAllowedSecrets(p.AllowedSecrets, override.AllowedSecrets),
+	}
+	if override.Namespace != "" {
+		answer.Namespace = override.Namespace
+	}
+	if override.ServiceAccountName != "" {
+		answer.ServiceAccountName = override.ServiceAccountName
+	}
+	if len(override.Tolerations) > 0 {
+		answer.Tolerations = override.Tolerations
+	}
+	return answer
+}
+
+func mergeStrings(values, overrides map[string]string) map[string]string {
+	if len(values) == 0 && len(overrides) == 0 {
+		return nil
+	}
+	answer := map[string]string{}
+	for k, v := range values {
+		answer[k] = v
+	}
+	for k, v := range overrides {
+		answer[k] = v
+	}
+	return answer
+}
+
+func mergeAllowedSecrets(values, overrides map[string][]string) map[string][]string {
+	if len(values) == 0 && len(overrides) == 0 {
+		return nil
+	}
+	answer := map[string][]string{}
+	for _, m := range []map[string][]string{values, overrides} {
+		for ns, names := range m {
+			merged := sets.NewString(answer[ns]...)
+			merged.Insert(names...)
+			answer[ns] = merged.List()
+		}
+	}
+	return answer
+}
+
+func mergeResources(values, overrides corev1.ResourceList) corev1.ResourceList {
+	if len(values) == 0 && len(overrides) == 0 {
+		return nil
+	}
+	answer := corev1.ResourceList{}
+	for k, v := range values {
+		answer[k] = v.DeepCopy()
+	}
+	for k, v := range overrides {
+		answer[k] = v.DeepCopy()
+	}
+	return answer
+}
+
+// ApplyPodDefaults merges the defaults into the namespace and pod spec of the job, returning the
+// resulting settings so that pipelines can use them too
+func ApplyPodDefaults(spec *v1alpha1.LighthouseJobSpec, defaults JobDefaults) PodDefaults {
+	jobSettings := PodDefaults{Namespace: spec.Namespace}
+	if spec.PodSpec != nil {
+		jobSettings.ServiceAccountName = spec.PodSpec.ServiceAccountName
+		jobSettings.NodeSelector = spec.PodSpec.NodeSelector
+		jobSettings.Tolerations = spec.PodSpec.Tolerations
+	}
+	var org, repo string
+	if spec.Refs != nil {
+		org, repo = spec.Refs.Org, spec.Refs.Repo
+	}
+	settings := defaults.For(org, repo).Merge(jobSettings)
+	if spec.ServiceAccountName != "" {
+		settings.ServiceAccountName = spec.ServiceAccountName
+	}
+
+	spec.Namespace = settings.Namespace
+	if spec.PodSpec != nil {
+		spec.PodSpec.ServiceAccountName = settings.ServiceAccountName
+		spec.PodSpec.NodeSelector = settings.NodeSelector
+		spec.PodSpec.Tolerations = settings.Tolerations
+		for i := range spec.PodSpec.Containers {
+			c := &spec.PodSpec.Containers[i]
+			c.Resources.Limits = mergeResources(settings.Resources.Limits, c.Resources.Limits)
+			c.Resources.Requests = mergeResources(settings.Resources.Requests, c.Resources.Requests)
+		}
+	}
+	return settings
+}
+
+// jobDefaultsLoader reloads the JobDefaults whenever their file changes, such as when the ConfigMap
+// it is mounted from is updated
+type jobDefaultsLoader struct {
+	fileName string
+
+	lock     sync.Mutex
+	modTime  time.Time
+	defaults JobDefaults
+}
+
+// newJobDefaultsLoaderFromEnv returns a loader for the file in $LIGHTHOUSE_JOB_DEFAULTS or nil if it is not set
+func newJobDefaultsLoaderFromEnv() *jobDefaultsLoader {
+	fileName := os.Getenv(JobDefaultsEnv)
+	if fileName == "" {
+		return nil
+	}
+	return &jobDefaultsLoader{fileName: fileName}
+}
+
+// get returns the current JobDefaults, keeping the previous ones if the file cannot be loaded
+func (l *jobDefaultsLoader) get() JobDefaults {
+	if l == nil {
+		return nil
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	info, err := os.Stat(l.fileName)
+	if err != nil {
+		logrus.WithError(err).Warnf("failed to find job defaults file %s", l.fileName)
+		return l.defaults
+	}
+	if info.ModTime().Equal(l.modTime) {
+		return l.defaults
+	}
+	defaults, err := LoadJobDefaults(l.fileName)
+	if err != nil {
+		logrus.WithError(err).Warn("failed to load job defaults")
+		return l.defaults
+	}
+	l.defaults = defaults
+	l.modTime = info.ModTime()
+	return l.defaults
+}
@@ -0,0 +1,142 @@
+package launcher
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const jobDefaultsYAML = `
+"*":
+  namespace: jobs
+  service_account_name: builder
+  node_selector:
+    pool: ci
+  resources:
+    requests:
+      cpu: 500m
+      memory: 1Gi
+org:
+  node_selector:
+    disk: ssd
+  tolerations:
+  - key: dedicated
+    value: org
+    effect: NoSchedule
+org/repo:
+  service_account_name: repo-builder
+  resources:
+    limits:
+      memory: 4Gi
+`
+
+func TestJobDefaultsFor(t *testing.T) {
+	dir, err := ioutil.TempDir("", "job-defaults")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	fileName := filepath.Join(dir, "job-defaults.yaml")
+	require.NoError(t, ioutil.WriteFile(fileName, []byte(jobDefaultsYAML), 0600))
+
+	defaults, err := LoadJobDefaults(fileName)
+	require.NoError(t, err)
+
+	other := defaults.For("other", "repo")
+	assert.Equal(t, "builder", other.ServiceAccountName)
+	assert.Equal(t, map[string]string{"pool": "ci"}, other.NodeSelector)
+	assert.Empty(t, other.Tolerations)
+
+	repo := defaults.For("org", "repo")
+	assert.Equal(t, "jobs", repo.Namespace)
+	assert.Equal(t, "repo-builder", repo.ServiceAccountName)
+	assert.Equal(t, map[string]string{"pool": "ci", "disk": "ssd"}, repo.NodeSelector)
+	require.Len(t, repo.Tolerations, 1)
+	assert.Equal(t, "org", repo.Tolerations[0].Value)
+	assert.Equal(t, resource.MustParse("500m"), repo.Resources.Requests[corev1.ResourceCPU])
+	assert.Equal(t, resource.MustParse("4Gi"), repo.Resources.Limits[corev1.ResourceMemory])
+}
+
+func TestApplyPodDefaults(t *testing.T) {
+	defaults := JobDefaults{
+		"*": {
+			Namespace:          "jobs",
+			ServiceAccountName: "builder",
+			NodeSelector:       map[string]string{"pool": "ci"},
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("500m"),
+					corev1.ResourceMemory: resource.MustParse("1Gi"),
+				},
+			},
+		},
+	}
+	spec := &v1alpha1.LighthouseJobSpec{
+		Refs: &v1alpha1.Refs{Org: "org", Repo: "repo"},
+		PodSpec: &corev1.PodSpec{
+			NodeSelector: map[string]string{"disk": "ssd"},
+			Containers: []corev1.Container{{
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")},
+				},
+			}},
+		},
+	}
+
+	settings := ApplyPodDefaults(spec, defaults)
+	assert.Equal(t, "jobs", spec.Namespace)
+	assert.Equal(t, "builder", settings.ServiceAccountName)
+	assert.Equal(t, "builder", spec.PodSpec.ServiceAccountName)
+	assert.Equal(t, map[string]string{"pool": "ci", "disk": "ssd"}, spec.PodSpec.NodeSelector)
+	requests := spec.PodSpec.Containers[0].Resources.Requests
+	assert.Equal(t, resource.MustParse("2"), requests[corev1.ResourceCPU])
+	assert.Equal(t, resource.MustParse("1Gi"), requests[corev1.ResourceMemory])
+
+	// the job's own namespace is kept
+	spec = &v1alpha1.LighthouseJobSpec{Namespace: "mine"}
+	settings = ApplyPodDefaults(spec, defaults)
+	assert.Equal(t, "mine", spec.Namespace)
+	assert.Equal(t, "builder", settings.ServiceAccountName)
+
+	// the service account of the job overrides its pod spec and the defaults
+	spec = &v1alpha1.LighthouseJobSpec{ServiceAccountName: "restricted", PodSpec: &corev1.PodSpec{ServiceAccountName: "mine"}}
+	settings = ApplyPodDefaults(spec, defaults)
+	assert.Equal(t, "restricted", settings.ServiceAccountName)
+	assert.Equal(t, "restricted", spec.PodSpec.ServiceAccountName)
+
+	// without defaults nothing changes
+	spec = &v1alpha1.LighthouseJobSpec{PodSpec: &corev1.PodSpec{Containers: []corev1.Container{{}}}}
+	ApplyPodDefaults(spec, nil)
+	assert.Empty(t, spec.Namespace)
+	assert.Nil(t, spec.PodSpec.NodeSelector)
+	assert.Nil(t, spec.PodSpec.Containers[0].Resources.Requests)
+}
+
+func TestJobDefaultsLoaderReloads(t *testing.T) {
+	dir, err := ioutil.TempDir("", "job-defaults")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	fileName := filepath.Join(dir, "job-defaults.yaml")
+
+	loader := &jobDefaultsLoader{fileName: fileName}
+	assert.Nil(t, loader.get())
+
+	require.NoError(t, ioutil.WriteFile(fileName, []byte(`"*": {service_account_name: first}`), 0600))
+	assert.Equal(t, "first", loader.get().For("", "").ServiceAccountName)
+
+	require.NoError(t, ioutil.WriteFile(fileName, []byte(`"*": {service_account_name: second}`), 0600))
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(fileName, later, later))
+	assert.Equal(t, "second", loader.get().For("", "").ServiceAccountName)
+
+	require.NoError(t, ioutil.WriteFile(fileName, []byte(`not: [valid`), 0600))
+	later = later.Add(time.Minute)
+	require.NoError(t, os.Chtimes(fileName, later, later))
+	assert.Equal(t, "second", loader.get().For("", "").ServiceAccountName)
+}
@@ -0,0 +1,63 @@
+package launcher
+
+import (
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	clientset "github.com/jenkins-x/lighthouse/pkg/client/clientset/versioned"
+	"github.com/jenkins-x/lighthouse/pkg/jenkins"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// jenkinsLauncher triggers jobs with the jenkins agent on a Jenkins server
+type jenkinsLauncher struct {
+	jenkins   jenkins.Client
+	lhClient  clientset.Interface
+	namespace string
+}
+
+func (b *jenkinsLauncher) launch(request *v1alpha1.LighthouseJob, repository scm.Repository) (*v1alpha1.LighthouseJob, error) {
+	spec := &request.Spec
+	jobPath := request.Annotations[util.JenkinsJobAnnotation]
+	if jobPath == "" {
+		jobPath = spec.Job
+	}
+
+	l := logrus.WithFields(logrus.Fields{
+		"Owner":      repository.Namespace,
+		"Name":       repository.Name,
+		"Job":        spec.Job,
+		"JenkinsJob": jobPath,
+	})
+	l.Info("about to trigger Jenkins job")
+
+	queueURL, err := b.jenkins.Build(jobPath, spec.GetEnvVars())
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to trigger Jenkins job %s", jobPath)
+	}
+
+	if request.Annotations == nil {
+		request.Annotations = map[string]string{}
+	}
+	request.Annotations[util.JenkinsQueueURLAnnotation] = queueURL
+
+	ns := jobNamespace(request, b.namespace)
+	appliedJob, err := b.lhClient.LighthouseV1alpha1().LighthouseJobs(ns).Create(request)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to apply LighthouseJob")
+	}
+
+	appliedJob.Status = v1alpha1.LighthouseJobStatus{
+		State:       v1alpha1.PendingState,
+		Description: "Jenkins build queued",
+		StartTime:   metav1.Now(),
+	}
+	appliedJob.SetStateConditions()
+	fullyCreatedJob, err := b.lhClient.LighthouseV1alpha1().LighthouseJobs(ns).UpdateStatus(appliedJob)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to set status on LighthouseJob %s", appliedJob.Name)
+	}
+	return fullyCreatedJob, nil
+}
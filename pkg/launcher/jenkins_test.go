@@ -0,0 +1,95 @@
+package launcher
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	lhfake "github.com/jenkins-x/lighthouse/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/lighthouse/pkg/jenkins"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type fakeJenkinsClient struct {
+	jobPath string
+	params  map[string]string
+}
+
+func (f *fakeJenkinsClient) Build(jobPath string, params map[string]string) (string, error) {
+	f.jobPath = jobPath
+	f.params = params
+	return "https://jenkins/queue/item/1/", nil
+}
+
+func (f *fakeJenkinsClient) GetQueueItem(queueURL string) (*jenkins.QueueItem, error) {
+	return &jenkins.QueueItem{}, nil
+}
+
+func (f *fakeJenkinsClient) GetBuild(buildURL string) (*jenkins.Build, error) {
+	return &jenkins.Build{}, nil
+}
+
+func TestLaunchJenkinsJob(t *testing.T) {
+	lhClient := lhfake.NewSimpleClientset()
+	jenkinsClient := &fakeJenkinsClient{}
+	l := &launcher{
+		lhClient:  lhClient,
+		namespace: "jx",
+		jenkins: &jenkinsLauncher{
+			jenkins:   jenkinsClient,
+			lhClient:  lhClient,
+			namespace: "jx",
+		},
+	}
+
+	request := &v1alpha1.LighthouseJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "job",
+			Labels:      map[string]string{},
+			Annotations: map[string]string{util.JenkinsJobAnnotation: "folder/build"},
+		},
+		Spec: v1alpha1.LighthouseJobSpec{
+			Type:  "postsubmit",
+			Job:   "build",
+			Agent: v1alpha1.JenkinsAgent,
+			Refs: &v1alpha1.Refs{
+				Org:     "org",
+				Repo:    "repo",
+				BaseRef: "master",
+				BaseSHA: "1234",
+			},
+		},
+	}
+	job, err := l.Launch(request, nil, scm.Repository{Namespace: "org", Name: "repo"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "folder/build", jenkinsClient.jobPath)
+	assert.Equal(t, "1234", jenkinsClient.params[v1alpha1.PullBaseShaEnv])
+	assert.Equal(t, v1alpha1.PendingState, job.Status.State)
+	assert.Equal(t, "https://jenkins/queue/item/1/", job.Annotations[util.JenkinsQueueURLAnnotation])
+
+	stored, err := lhClient.LighthouseV1alpha1().LighthouseJobs("jx").Get("job", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, v1alpha1.PendingState, stored.Status.State)
+}
+
+func TestLaunchJenkinsJobWithoutServer(t *testing.T) {
+	l := &launcher{lhClient: lhfake.NewSimpleClientset(), namespace: "jx"}
+	request := &v1alpha1.LighthouseJob{
+		Spec: v1alpha1.LighthouseJobSpec{Job: "build", Agent: v1alpha1.JenkinsAgent},
+	}
+	_, err := l.Launch(request, nil, scm.Repository{})
+	assert.Error(t, err)
+}
+
+func TestLaunchJenkinsJobInConcurrencyGroup(t *testing.T) {
+	l := &launcher{lhClient: lhfake.NewSimpleClientset(), namespace: "jx", jenkins: &jenkinsLauncher{}}
+	request := &v1alpha1.LighthouseJob{
+		Spec: v1alpha1.LighthouseJobSpec{Job: "deploy", Agent: v1alpha1.JenkinsAgent, ConcurrencyGroup: "deploy-staging"},
+	}
+	_, err := l.Launch(request, nil, scm.Repository{})
+	assert.EqualError(t, err, "job deploy is in concurrency group deploy-staging, which is only supported by jobs using the kubernetes agent")
+}
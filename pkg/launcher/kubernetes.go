@@ -0,0 +1,73 @@
+package launcher
+
+import (
+	"strconv"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// launchPod creates a job using the kubernetes agent, whose pod is then created by the pod syncer in foghorn
+func (b *launcher) launchPod(request *v1alpha1.LighthouseJob, repository scm.Repository) (*v1alpha1.LighthouseJob, error) {
+	spec := &request.Spec
+	if spec.PodSpec == nil {
+		return nil, errors.Errorf("job %s uses the %s agent but has no pod spec", spec.Job, spec.Agent)
+	}
+	if spec.Refs != nil && spec.Refs.CloneURI == "" {
+		spec.Refs.CloneURI = repository.Clone
+	}
+
+	l := logrus.WithFields(logrus.Fields{
+		"Owner": repository.Namespace,
+		"Name":  repository.Name,
+		"Job":   spec.Job,
+	})
+	if err := b.allocateBuildNumber(request); err != nil {
+		// the job can still run, its build is then identified by the name of the LighthouseJob
+		l.WithError(err).Warn("failed to allocate a build number")
+	}
+	l.Info("about to create LighthouseJob for pod")
+
+	ns := jobNamespace(request, b.namespace)
+	appliedJob, err := b.lhClient.LighthouseV1alpha1().LighthouseJobs(ns).Create(request)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to apply LighthouseJob")
+	}
+
+	appliedJob.Status = v1alpha1.LighthouseJobStatus{
+		State:       v1alpha1.TriggeredState,
+		Description: "Waiting for pod",
+		StartTime:   metav1.Now(),
+	}
+	appliedJob.SetStateConditions()
+	fullyCreatedJob, err := b.lhClient.LighthouseV1alpha1().LighthouseJobs(ns).UpdateStatus(appliedJob)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to set status on LighthouseJob %s", appliedJob.Name)
+	}
+	return fullyCreatedJob, nil
+}
+
+// allocateBuildNumber labels the job with the next build number of the job unless it already has one
+func (b *launcher) allocateBuildNumber(request *v1alpha1.LighthouseJob) error {
+	if b.buildNumbers == nil || request.Labels[util.BuildNumLabel] != "" {
+		return nil
+	}
+	var org, repo string
+	if request.Spec.Refs != nil {
+		org = request.Spec.Refs.Org
+		repo = request.Spec.Refs.Repo
+	}
+	build, err := b.buildNumbers.Next(org, repo, request.Spec.Job)
+	if err != nil {
+		return err
+	}
+	if request.Labels == nil {
+		request.Labels = map[string]string{}
+	}
+	request.Labels[util.BuildNumLabel] = strconv.Itoa(build)
+	return nil
+}
@@ -0,0 +1,46 @@
+package launcher
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/buildnum"
+	lhfake "github.com/jenkins-x/lighthouse/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestLaunchPod(t *testing.T) {
+	lhClient := lhfake.NewSimpleClientset()
+	l := &launcher{lhClient: lhClient, namespace: "jx", buildNumbers: buildnum.NewMemoryAllocator()}
+
+	request := &v1alpha1.LighthouseJob{
+		ObjectMeta: metav1.ObjectMeta{Name: "job", Labels: map[string]string{}},
+		Spec: v1alpha1.LighthouseJobSpec{
+			Type:    "postsubmit",
+			Job:     "build",
+			Agent:   v1alpha1.KubernetesAgent,
+			Refs:    &v1alpha1.Refs{Org: "org", Repo: "repo", BaseRef: "master"},
+			PodSpec: &corev1.PodSpec{Containers: []corev1.Container{{Image: "busybox", Command: []string{"true"}}}},
+		},
+	}
+	job, err := l.Launch(request, nil, scm.Repository{Namespace: "org", Name: "repo", Clone: "https://github.com/org/repo.git"})
+	require.NoError(t, err)
+	assert.Equal(t, v1alpha1.TriggeredState, job.Status.State)
+	assert.Equal(t, "https://github.com/org/repo.git", job.Spec.Refs.CloneURI)
+	assert.Equal(t, "1", job.Labels[util.BuildNumLabel])
+
+	request.Name = "job-2"
+	delete(request.Labels, util.BuildNumLabel)
+	job, err = l.Launch(request, nil, scm.Repository{Namespace: "org", Name: "repo"})
+	require.NoError(t, err)
+	assert.Equal(t, "2", job.Labels[util.BuildNumLabel])
+
+	request.Spec.PodSpec = nil
+	_, err = l.Launch(request, nil, scm.Repository{})
+	assert.Error(t, err)
+}
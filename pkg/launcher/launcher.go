@@ -8,34 +8,104 @@ import (
 	jxclient "github.com/jenkins-x/jx/v2/pkg/client/clientset/versioned"
 	"github.com/jenkins-x/jx/v2/pkg/tekton/metapipeline"
 	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/audit"
+	"github.com/jenkins-x/lighthouse/pkg/buildnum"
 	clientset "github.com/jenkins-x/lighthouse/pkg/client/clientset/versioned"
+	"github.com/jenkins-x/lighthouse/pkg/jenkins"
 	"github.com/jenkins-x/lighthouse/pkg/util"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	tektonclient "github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
 )
 
 // launcher default launcher
 type launcher struct {
-	jxClient  jxclient.Interface
-	lhClient  clientset.Interface
-	namespace string
+	jxClient    jxclient.Interface
+	lhClient    clientset.Interface
+	namespace   string
+	jenkins     *jenkinsLauncher
+	pipelineRef *pipelineRefLauncher
+	jobDefaults *jobDefaultsLoader
+	// buildNumbers allocates the build numbers of jobs which have no other source of them
+	buildNumbers buildnum.Allocator
 }
 
 // NewLauncher creates a new builder
-func NewLauncher(jxClient jxclient.Interface, lhClient clientset.Interface, namespace string) (PipelineLauncher, error) {
+func NewLauncher(jxClient jxclient.Interface, tektonClient tektonclient.Interface, lhClient clientset.Interface, kubeClient kubernetes.Interface, namespace string) (PipelineLauncher, error) {
 	b := &launcher{
-		jxClient:  jxClient,
-		lhClient:  lhClient,
-		namespace: namespace,
+		jxClient:     jxClient,
+		lhClient:     lhClient,
+		namespace:    namespace,
+		buildNumbers: buildnum.NewConfigMapAllocator(kubeClient, namespace),
+		pipelineRef: &pipelineRefLauncher{
+			jxClient:       jxClient,
+			tektonClient:   tektonClient,
+			lhClient:       lhClient,
+			namespace:      namespace,
+			serviceAccount: serviceAccount(),
+		},
+		jobDefaults: newJobDefaultsLoaderFromEnv(),
+	}
+	if jenkinsClient := jenkins.NewClientFromEnv(); jenkinsClient != nil {
+		b.jenkins = &jenkinsLauncher{
+			jenkins:   jenkinsClient,
+			lhClient:  lhClient,
+			namespace: namespace,
+		}
 	}
 	return b, nil
 }
 
 // Launch creates a pipeline
-// TODO: This should be moved somewhere else, probably, and needs some kind of unit testing (apb)
 func (b *launcher) Launch(request *v1alpha1.LighthouseJob, metapipelineClient metapipeline.Client, repository scm.Repository) (*v1alpha1.LighthouseJob, error) {
+	job, err := b.launch(request, metapipelineClient, repository)
+	recordLaunch(request, err)
+	return job, err
+}
+
+// recordLaunch records the creation of the job to the audit stream
+func recordLaunch(request *v1alpha1.LighthouseJob, err error) {
+	spec := &request.Spec
+	event := audit.Event{
+		Type:   audit.ActionEvent,
+		Action: "create_job",
+		Target: spec.Job,
+	}
+	if spec.Refs != nil {
+		event.Org = spec.Refs.Org
+		event.Repo = spec.Refs.Repo
+		event.Ref = spec.Refs.BaseSHA
+		if len(spec.Refs.Pulls) > 0 {
+			event.Number = spec.Refs.Pulls[0].Number
+			event.Ref = spec.Refs.Pulls[0].SHA
+		}
+	}
+	event.Outcome, event.Error = audit.Outcome(err)
+	audit.Record(event)
+}
+
+// TODO: This should be moved somewhere else, probably, and needs some kind of unit testing (apb)
+func (b *launcher) launch(request *v1alpha1.LighthouseJob, metapipelineClient metapipeline.Client, repository scm.Repository) (*v1alpha1.LighthouseJob, error) {
 	spec := &request.Spec
+	settings := ApplyPodDefaults(spec, b.jobDefaults.get())
+	if err := injectSecrets(spec, settings); err != nil {
+		return nil, err
+	}
+	if spec.ConcurrencyGroup != "" && spec.Agent != v1alpha1.KubernetesAgent {
+		return nil, errors.Errorf("job %s is in concurrency group %s, which is only supported by jobs using the %s agent", spec.Job, spec.ConcurrencyGroup, v1alpha1.KubernetesAgent)
+	}
+
+	if spec.Agent == v1alpha1.JenkinsAgent {
+		if b.jenkins == nil {
+			return nil, errors.Errorf("job %s uses the %s agent but no Jenkins server is configured", spec.Job, spec.Agent)
+		}
+		return b.jenkins.launch(request, repository)
+	}
+	if spec.Agent == v1alpha1.KubernetesAgent {
+		return b.launchPod(request, repository)
+	}
 
 	name := repository.Name
 	owner := repository.Namespace
@@ -58,6 +128,10 @@ func (b *launcher) Launch(request *v1alpha1.LighthouseJob, metapipelineClient me
 		pullRefs = branch + ":"
 	}
 
+	if spec.PipelineRef != "" {
+		return b.pipelineRef.launch(request, repository, branch, settings)
+	}
+
 	job := spec.Job
 	var kind metapipeline.PipelineKind
 	if len(spec.Refs.Pulls) > 0 {
@@ -76,9 +150,9 @@ func (b *launcher) Launch(request *v1alpha1.LighthouseJob, metapipelineClient me
 	}))
 	l.Info("about to start Jenkinx X meta pipeline")
 
-	sa := os.Getenv("JX_SERVICE_ACCOUNT")
+	sa := settings.ServiceAccountName
 	if sa == "" {
-		sa = "tekton-bot"
+		sa = serviceAccount()
 	}
 
 	pipelineCreateParam := metapipeline.PipelineCreateParam{
@@ -102,6 +176,11 @@ func (b *launcher) Launch(request *v1alpha1.LighthouseJob, metapipelineClient me
 	// Add the build number from the activity key to the labels on the job
 	request.Labels[util.BuildNumLabel] = activityKey.Build
 
+	if request.Namespace != "" && request.Namespace != b.namespace {
+		// the meta pipeline client creates its resources in the namespace of the launcher
+		l.Warnf("job of tenant namespace %s runs in namespace %s as it uses the meta pipeline", request.Namespace, b.namespace)
+		request.Namespace = ""
+	}
 	appliedJob, err := b.lhClient.LighthouseV1alpha1().LighthouseJobs(b.namespace).Create(request)
 	if err != nil {
 		return nil, errors.Wrap(err, "unable to apply LighthouseJob")
@@ -113,6 +192,7 @@ func (b *launcher) Launch(request *v1alpha1.LighthouseJob, metapipelineClient me
 		ActivityName: util.ToValidName(activityKey.Name),
 		StartTime:    metav1.Now(),
 	}
+	appliedJob.SetStateConditions()
 	fullyCreatedJob, err := b.lhClient.LighthouseV1alpha1().LighthouseJobs(b.namespace).UpdateStatus(appliedJob)
 	if err != nil {
 		return nil, errors.Wrapf(err, "unable to set status on LighthouseJob %s", appliedJob.Name)
@@ -125,6 +205,24 @@ func (b *launcher) Launch(request *v1alpha1.LighthouseJob, metapipelineClient me
 	return fullyCreatedJob, nil
 }
 
+// jobNamespace returns the namespace of the job, which is the namespace of its tenant if it has one, or the
+// namespace of the launcher
+func jobNamespace(request *v1alpha1.LighthouseJob, namespace string) string {
+	if request.Namespace != "" {
+		return request.Namespace
+	}
+	return namespace
+}
+
+// serviceAccount returns the service account pipelines run as
+func serviceAccount() string {
+	sa := os.Getenv("JX_SERVICE_ACCOUNT")
+	if sa == "" {
+		sa = "tekton-bot"
+	}
+	return sa
+}
+
 func (b *launcher) getPullRefs(sourceURL string, spec *v1alpha1.LighthouseJobSpec) metapipeline.PullRef {
 	var pullRef metapipeline.PullRef
 	if len(spec.Refs.Pulls) > 0 {
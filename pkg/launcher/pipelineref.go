@@ -0,0 +1,211 @@
+package launcher
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+	jxclient "github.com/jenkins-x/jx/v2/pkg/client/clientset/versioned"
+	"github.com/jenkins-x/jx/v2/pkg/gits"
+	"github.com/jenkins-x/jx/v2/pkg/tekton"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	clientset "github.com/jenkins-x/lighthouse/pkg/client/clientset/versioned"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	pipelinev1alpha1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
+	tektonclient "github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// buildNumberTimeout is how long to keep retrying when allocating the next build number
+const buildNumberTimeout = 20 * time.Second
+
+// ParamContext is the data available to the templates of a job's pipeline parameters
+type ParamContext struct {
+	Org          string
+	Repo         string
+	Job          string
+	Context      string
+	Type         string
+	Branch       string
+	BaseRef      string
+	BaseSHA      string
+	PullNumber   string
+	PullSHA      string
+	Author       string
+	ChangedFiles []string
+	ChangesHash  string
+	App          string
+	Environment  string
+	IssueNumber  string
+	IssueCommand string
+}
+
+// NewParamContext creates the template data for the given job spec
+func NewParamContext(spec *v1alpha1.LighthouseJobSpec) *ParamContext {
+	ctx := &ParamContext{
+		Job:         spec.Job,
+		Context:     spec.Context,
+		Type:        string(spec.Type),
+		Branch:      spec.GetBranch(),
+		App:         spec.App,
+		Environment: spec.Environment,
+	}
+	if spec.IssueNumber != 0 {
+		ctx.IssueNumber = strconv.Itoa(spec.IssueNumber)
+		ctx.IssueCommand = spec.IssueCommand
+	}
+	if spec.Refs != nil {
+		ctx.Org = spec.Refs.Org
+		ctx.Repo = spec.Refs.Repo
+		ctx.BaseRef = spec.Refs.BaseRef
+		ctx.BaseSHA = spec.Refs.BaseSHA
+		if len(spec.Refs.Pulls) > 0 {
+			pull := spec.Refs.Pulls[0]
+			ctx.PullNumber = strconv.Itoa(pull.Number)
+			ctx.PullSHA = pull.SHA
+			ctx.Author = pull.Author
+			ctx.ChangedFiles = pull.ChangedFiles
+			ctx.ChangesHash = pull.ChangesHash
+		}
+	}
+	return ctx
+}
+
+var paramFuncs = template.FuncMap{
+	"join": func(sep string, values []string) string {
+		return strings.Join(values, sep)
+	},
+}
+
+// RenderPipelineParams evaluates the parameter templates of the job spec, returning the Tekton params sorted by name
+func RenderPipelineParams(spec *v1alpha1.LighthouseJobSpec) ([]pipelinev1alpha1.Param, error) {
+	ctx := NewParamContext(spec)
+	var names []string
+	for name := range spec.PipelineParams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var params []pipelinev1alpha1.Param
+	for _, name := range names {
+		tmpl, err := template.New(name).Funcs(paramFuncs).Option("missingkey=error").Parse(spec.PipelineParams[name])
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing template for pipeline parameter %s", name)
+		}
+		buf := &bytes.Buffer{}
+		if err := tmpl.Execute(buf, ctx); err != nil {
+			return nil, errors.Wrapf(err, "evaluating template for pipeline parameter %s", name)
+		}
+		params = append(params, pipelinev1alpha1.Param{
+			Name: name,
+			Value: pipelinev1alpha1.ArrayOrString{
+				Type:      pipelinev1alpha1.ParamTypeString,
+				StringVal: buf.String(),
+			},
+		})
+	}
+	return params, nil
+}
+
+// pipelineRefLauncher runs jobs which reference an existing Tekton Pipeline
+type pipelineRefLauncher struct {
+	jxClient       jxclient.Interface
+	tektonClient   tektonclient.Interface
+	lhClient       clientset.Interface
+	namespace      string
+	serviceAccount string
+}
+
+func (b *pipelineRefLauncher) launch(request *v1alpha1.LighthouseJob, repository scm.Repository, branch string, settings PodDefaults) (*v1alpha1.LighthouseJob, error) {
+	spec := &request.Spec
+	l := logrus.WithFields(logrus.Fields{
+		"Owner":       repository.Namespace,
+		"Name":        repository.Name,
+		"Branch":      branch,
+		"Job":         spec.Job,
+		"PipelineRef": spec.PipelineRef,
+	})
+	l.Info("about to start Tekton Pipeline")
+
+	params, err := RenderPipelineParams(spec)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to render parameters for job %s", spec.Job)
+	}
+
+	gitInfo, err := gits.ParseGitURL(repository.Clone)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to parse git URL %s", repository.Clone)
+	}
+
+	ns := jobNamespace(request, b.namespace)
+	buildNumber, err := tekton.GenerateNextBuildNumber(b.tektonClient, b.jxClient, ns, gitInfo, branch, buildNumberTimeout, spec.Context, false)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to allocate build number for %s/%s/%s", gitInfo.Organisation, gitInfo.Name, branch)
+	}
+
+	activityKey := tekton.GeneratePipelineActivity(buildNumber, branch, gitInfo, spec.Context, nil)
+	if _, _, err := activityKey.GetOrCreate(b.jxClient, ns); err != nil {
+		return nil, errors.Wrapf(err, "unable to create PipelineActivity %s", activityKey.Name)
+	}
+
+	request.Labels[util.BuildNumLabel] = buildNumber
+
+	appliedJob, err := b.lhClient.LighthouseV1alpha1().LighthouseJobs(ns).Create(request)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to apply LighthouseJob")
+	}
+
+	appliedJob.Status = v1alpha1.LighthouseJobStatus{
+		State:        v1alpha1.PendingState,
+		ActivityName: util.ToValidName(activityKey.Name),
+		StartTime:    metav1.Now(),
+	}
+	appliedJob.SetStateConditions()
+	fullyCreatedJob, err := b.lhClient.LighthouseV1alpha1().LighthouseJobs(ns).UpdateStatus(appliedJob)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to set status on LighthouseJob %s", appliedJob.Name)
+	}
+
+	labels := map[string]string{
+		util.ActivityOwnerLabel:      gitInfo.Organisation,
+		util.ActivityRepositoryLabel: gitInfo.Name,
+		util.ActivityBranchLabel:     branch,
+		util.ActivityBuildLabel:      buildNumber,
+	}
+	nameParts := []string{gitInfo.Organisation, gitInfo.Name, branch}
+	if spec.Context != "" {
+		labels[util.ActivityContextLabel] = spec.Context
+		nameParts = append(nameParts, spec.Context)
+	}
+	nameParts = append(nameParts, buildNumber)
+
+	sa := settings.ServiceAccountName
+	if sa == "" {
+		sa = b.serviceAccount
+	}
+	run := &pipelinev1alpha1.PipelineRun{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   util.ToValidName(strings.Join(nameParts, "-")),
+			Labels: labels,
+		},
+		Spec: pipelinev1alpha1.PipelineRunSpec{
+			PipelineRef:        pipelinev1alpha1.PipelineRef{Name: spec.PipelineRef},
+			Params:             params,
+			ServiceAccountName: sa,
+			PodTemplate: pipelinev1alpha1.PodTemplate{
+				NodeSelector: settings.NodeSelector,
+				Tolerations:  settings.Tolerations,
+			},
+		},
+	}
+	if _, err := b.tektonClient.TektonV1alpha1().PipelineRuns(ns).Create(run); err != nil {
+		return nil, errors.Wrapf(err, "unable to create PipelineRun %s", run.Name)
+	}
+	return fullyCreatedJob, nil
+}
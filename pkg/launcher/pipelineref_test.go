@@ -0,0 +1,107 @@
+package launcher
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	jxfake "github.com/jenkins-x/jx/v2/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	lhfake "github.com/jenkins-x/lighthouse/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tektonfake "github.com/tektoncd/pipeline/pkg/client/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+func pipelineRefJob() *v1alpha1.LighthouseJob {
+	return &v1alpha1.LighthouseJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "job",
+			Labels: map[string]string{},
+		},
+		Spec: v1alpha1.LighthouseJobSpec{
+			Type:        "presubmit",
+			Job:         "unit",
+			Context:     "unit",
+			PipelineRef: "build-and-test",
+			PipelineParams: map[string]string{
+				"sha":    "{{ .PullSHA }}",
+				"pr":     "{{ .Org }}/{{ .Repo }}#{{ .PullNumber }} by {{ .Author }}",
+				"files":  `{{ join "," .ChangedFiles }}`,
+				"branch": "{{ .Branch }}",
+			},
+			Refs: &v1alpha1.Refs{
+				Org:     "org",
+				Repo:    "repo",
+				BaseRef: "master",
+				BaseSHA: "base",
+				Pulls: []v1alpha1.Pull{{
+					Number:       12,
+					SHA:          "head",
+					Author:       "alice",
+					ChangedFiles: []string{"a.go", "docs/b.md"},
+				}},
+			},
+		},
+	}
+}
+
+func TestRenderPipelineParams(t *testing.T) {
+	params, err := RenderPipelineParams(&pipelineRefJob().Spec)
+	require.NoError(t, err)
+
+	values := map[string]string{}
+	var names []string
+	for _, p := range params {
+		names = append(names, p.Name)
+		values[p.Name] = p.Value.StringVal
+	}
+	assert.Equal(t, []string{"branch", "files", "pr", "sha"}, names)
+	assert.Equal(t, "PR-12", values["branch"])
+	assert.Equal(t, "a.go,docs/b.md", values["files"])
+	assert.Equal(t, "org/repo#12 by alice", values["pr"])
+	assert.Equal(t, "head", values["sha"])
+
+	spec := &pipelineRefJob().Spec
+	spec.PipelineParams = map[string]string{"bad": "{{ .Missing }}"}
+	_, err = RenderPipelineParams(spec)
+	assert.Error(t, err)
+}
+
+func TestLaunchPipelineRef(t *testing.T) {
+	jxClient := jxfake.NewSimpleClientset()
+	tektonClient := tektonfake.NewSimpleClientset()
+	lhClient := lhfake.NewSimpleClientset()
+
+	l, err := NewLauncher(jxClient, tektonClient, lhClient, kubefake.NewSimpleClientset(), "jx")
+	require.NoError(t, err)
+
+	repository := scm.Repository{Namespace: "org", Name: "repo", Clone: "https://github.com/org/repo.git"}
+	job, err := l.Launch(pipelineRefJob(), nil, repository)
+	require.NoError(t, err)
+
+	assert.Equal(t, v1alpha1.PendingState, job.Status.State)
+	assert.Equal(t, "org-repo-pr-12-1", job.Status.ActivityName)
+	assert.Equal(t, "1", job.Labels[util.BuildNumLabel])
+
+	_, err = jxClient.JenkinsV1().PipelineActivities("jx").Get(job.Status.ActivityName, metav1.GetOptions{})
+	require.NoError(t, err)
+
+	runs, err := tektonClient.TektonV1alpha1().PipelineRuns("jx").List(metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, runs.Items, 1)
+	run := runs.Items[0]
+	assert.Equal(t, "org-repo-pr-12-unit-1", run.Name)
+	assert.Equal(t, "build-and-test", run.Spec.PipelineRef.Name)
+	assert.Equal(t, "tekton-bot", run.Spec.ServiceAccountName)
+	assert.Len(t, run.Spec.Params, 4)
+	assert.Equal(t, map[string]string{
+		util.ActivityOwnerLabel:      "org",
+		util.ActivityRepositoryLabel: "repo",
+		util.ActivityBranchLabel:     "PR-12",
+		util.ActivityBuildLabel:      "1",
+		util.ActivityContextLabel:    "unit",
+	}, run.Labels)
+}
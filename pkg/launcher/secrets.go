@@ -0,0 +1,48 @@
+package launcher
+
+import (
+	"strings"
+
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/censor"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// injectSecrets sets the environment variables of the containers of the job's pod from its EnvFromSecrets, after
+// checking that the job defaults of its repository allow them, and every other secret its pod spec reads, in its
+// namespace, so that the configuration of a repository cannot read any secret of the cluster
+func injectSecrets(spec *v1alpha1.LighthouseJobSpec, settings PodDefaults) error {
+	allowed := sets.NewString(settings.AllowedSecrets[spec.Namespace]...)
+	if denied := sets.NewString(censor.SecretNames(spec.PodSpec)...).Difference(allowed); denied.Len() > 0 {
+		return errors.Errorf("job %s cannot read the secrets %s which are not allowed in namespace %s", spec.Job, strings.Join(denied.List(), ", "), spec.Namespace)
+	}
+	if len(spec.EnvFromSecrets) == 0 {
+		return nil
+	}
+	if spec.Agent != v1alpha1.KubernetesAgent || spec.PodSpec == nil {
+		return errors.Errorf("job %s sets its environment from secrets, which is only supported by jobs using the %s agent", spec.Job, v1alpha1.KubernetesAgent)
+	}
+	if denied := sets.NewString(spec.EnvFromSecrets...).Difference(allowed); denied.Len() > 0 {
+		return errors.Errorf("job %s cannot read the secrets %s which are not allowed in namespace %s", spec.Job, strings.Join(denied.List(), ", "), spec.Namespace)
+	}
+	for i := range spec.PodSpec.Containers {
+		c := &spec.PodSpec.Containers[i]
+		existing := sets.NewString()
+		for _, envFrom := range c.EnvFrom {
+			if envFrom.SecretRef != nil {
+				existing.Insert(envFrom.SecretRef.Name)
+			}
+		}
+		for _, name := range spec.EnvFromSecrets {
+			if existing.Has(name) {
+				continue
+			}
+			c.EnvFrom = append(c.EnvFrom, corev1.EnvFromSource{
+				SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: name}},
+			})
+		}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package launcher
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestInjectSecrets(t *testing.T) {
+	defaults := JobDefaults{
+		"*":   {AllowedSecrets: map[string][]string{"jobs": {"npm-token"}}},
+		"org": {AllowedSecrets: map[string][]string{"jobs": {"sonar-token"}, "other": {"deploy-key"}}},
+	}
+	newSpec := func(org string, secrets ...string) *v1alpha1.LighthouseJobSpec {
+		return &v1alpha1.LighthouseJobSpec{
+			Job:            "build",
+			Namespace:      "jobs",
+			Agent:          v1alpha1.KubernetesAgent,
+			Refs:           &v1alpha1.Refs{Org: org, Repo: "repo"},
+			PodSpec:        &corev1.PodSpec{Containers: []corev1.Container{{Name: "build"}}},
+			EnvFromSecrets: secrets,
+		}
+	}
+
+	spec := newSpec("org", "npm-token", "sonar-token")
+	require.NoError(t, injectSecrets(spec, ApplyPodDefaults(spec, defaults)))
+	var names []string
+	for _, envFrom := range spec.PodSpec.Containers[0].EnvFrom {
+		names = append(names, envFrom.SecretRef.Name)
+	}
+	assert.Equal(t, []string{"npm-token", "sonar-token"}, names)
+
+	// the secrets allowed for an org are not allowed for the other orgs
+	spec = newSpec("another-org", "sonar-token")
+	err := injectSecrets(spec, ApplyPodDefaults(spec, defaults))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "sonar-token")
+	assert.Empty(t, spec.PodSpec.Containers[0].EnvFrom)
+
+	// the secrets are allowed by namespace
+	spec = newSpec("org", "deploy-key")
+	assert.Error(t, injectSecrets(spec, ApplyPodDefaults(spec, defaults)))
+
+	spec = newSpec("org", "npm-token")
+	spec.Agent = v1alpha1.TektonAgent
+	assert.Error(t, injectSecrets(spec, ApplyPodDefaults(spec, defaults)))
+
+	// the secrets the pod spec reads are allowed like those of the environment
+	refs := map[string]corev1.PodSpec{
+		"env": {Containers: []corev1.Container{{Name: "build", Env: []corev1.EnvVar{{
+			Name:      "TOKEN",
+			ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "cluster-admin"}, Key: "token"}},
+		}}}}},
+		"envFrom": {Containers: []corev1.Container{{Name: "build", EnvFrom: []corev1.EnvFromSource{{
+			SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "cluster-admin"}},
+		}}}}},
+		"init container": {
+			InitContainers: []corev1.Container{{Name: "init", Env: []corev1.EnvVar{{
+				Name:      "TOKEN",
+				ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "cluster-admin"}, Key: "token"}},
+			}}}},
+			Containers: []corev1.Container{{Name: "build"}},
+		},
+		"volume": {
+			Containers: []corev1.Container{{Name: "build"}},
+			Volumes:    []corev1.Volume{{Name: "token", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "cluster-admin"}}}},
+		},
+		"projected volume": {
+			Containers: []corev1.Container{{Name: "build"}},
+			Volumes: []corev1.Volume{{Name: "token", VolumeSource: corev1.VolumeSource{Projected: &corev1.ProjectedVolumeSource{Sources: []corev1.VolumeProjection{{
+				Secret: &corev1.SecretProjection{LocalObjectReference: corev1.LocalObjectReference{Name: "cluster-admin"}},
+			}}}}}},
+		},
+	}
+	for name, podSpec := range refs {
+		t.Run(name, func(t *testing.T) {
+			spec := newSpec("org")
+			spec.PodSpec = podSpec.DeepCopy()
+			err := injectSecrets(spec, ApplyPodDefaults(spec, defaults))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "cluster-admin")
+
+			allowed := JobDefaults{"org": {AllowedSecrets: map[string][]string{"jobs": {"cluster-admin"}}}}
+			spec = newSpec("org")
+			spec.PodSpec = podSpec.DeepCopy()
+			assert.NoError(t, injectSecrets(spec, ApplyPodDefaults(spec, allowed)))
+		})
+	}
+}
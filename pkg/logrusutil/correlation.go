@@ -0,0 +1,80 @@
+package logrusutil
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// CorrelationIDField is the log field holding the correlation ID of the webhook being handled
+	CorrelationIDField = "correlation_id"
+
+	// CorrelationIDHeader is the header carrying the correlation ID in the requests made for a webhook
+	CorrelationIDHeader = "X-Correlation-ID"
+)
+
+// deliveryHeaders are the headers SCM providers and proxies identify a delivery with, in order of preference
+var deliveryHeaders = []string{CorrelationIDHeader, "X-GitHub-Delivery", "X-Gitlab-Event-UUID", "X-Request-Id", "X-Request-UUID"}
+
+// CorrelationID returns the ID of the delivery of the request, generating one if the request has none
+func CorrelationID(r *http.Request) string {
+	for _, header := range deliveryHeaders {
+		if id := r.Header.Get(header); id != "" {
+			return id
+		}
+	}
+	return NewCorrelationID()
+}
+
+// NewCorrelationID generates a correlation ID for an event which was not delivered by a request, e.g. when polling
+func NewCorrelationID() string {
+	id, err := uuid.NewV4()
+	if err != nil {
+		return time.Now().UTC().Format("20060102150405.000000000")
+	}
+	return id.String()
+}
+
+// CorrelatedTransport logs the requests made with the correlation ID of the logger and sends the ID along
+type CorrelatedTransport struct {
+	Base   http.RoundTripper
+	Logger *logrus.Entry
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *CorrelatedTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if id, ok := t.Logger.Data[CorrelationIDField].(string); ok && r.Header.Get(CorrelationIDHeader) == "" {
+		// the request must not be modified by a RoundTripper
+		r = r.Clone(r.Context())
+		r.Header.Set(CorrelationIDHeader, id)
+	}
+	start := time.Now()
+	resp, err := base.RoundTrip(r)
+	l := t.Logger.WithFields(logrus.Fields{
+		"method":   r.Method,
+		"url":      redactQuery(r.URL),
+		"duration": time.Since(start).String(),
+	})
+	if err != nil {
+		l.WithError(err).Debug("request failed")
+		return resp, err
+	}
+	l.WithField("status", resp.StatusCode).Debug("request done")
+	return resp, nil
+}
+
+// redactQuery removes the query of the URL, which may hold tokens
+func redactQuery(u *url.URL) string {
+	redacted := *u
+	redacted.RawQuery = ""
+	redacted.User = nil
+	return redacted.String()
+}
@@ -0,0 +1,72 @@
+package logrusutil
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCorrelationID(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/hook", nil)
+	generated := CorrelationID(r)
+	assert.NotEmpty(t, generated)
+	assert.NotEqual(t, generated, CorrelationID(r), "a new ID should be generated for each request without one")
+
+	r.Header.Set("X-GitHub-Delivery", "github-delivery")
+	assert.Equal(t, "github-delivery", CorrelationID(r))
+	r.Header.Set(CorrelationIDHeader, "forwarded")
+	assert.Equal(t, "forwarded", CorrelationID(r))
+}
+
+func TestCorrelatedTransport(t *testing.T) {
+	var received string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get(CorrelationIDHeader)
+	}))
+	defer server.Close()
+
+	logger, hook := test.NewNullLogger()
+	logger.SetLevel(logrus.DebugLevel)
+	client := &http.Client{Transport: &CorrelatedTransport{Logger: logger.WithField(CorrelationIDField, "delivery")}}
+	resp, err := client.Get(server.URL + "/repos?access_token=secret")
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, "delivery", received)
+	entry := hook.LastEntry()
+	require.NotNil(t, entry)
+	assert.Equal(t, "delivery", entry.Data[CorrelationIDField])
+	assert.Equal(t, http.StatusOK, entry.Data["status"])
+	assert.Equal(t, server.URL+"/repos", entry.Data["url"])
+}
+
+func TestLevelHandler(t *testing.T) {
+	defer logrus.SetLevel(logrus.GetLevel())
+	logrus.SetLevel(logrus.InfoLevel)
+
+	serve := func(method, target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		LevelHandler{}.ServeHTTP(w, httptest.NewRequest(method, target, nil))
+		return w
+	}
+	w := serve(http.MethodGet, LevelPath)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "info", strings.TrimSpace(w.Body.String()))
+
+	w = serve(http.MethodPut, LevelPath+"?level=debug")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, logrus.DebugLevel, logrus.GetLevel())
+
+	w = serve(http.MethodPut, LevelPath+"?level=loud")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, logrus.DebugLevel, logrus.GetLevel())
+
+	w = serve(http.MethodDelete, LevelPath)
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
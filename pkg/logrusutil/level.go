@@ -0,0 +1,36 @@
+package logrusutil
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+)
+
+// LevelPath is the path of the admin endpoint reading and changing the log level
+const LevelPath = "/admin/loglevel"
+
+// LevelHandler serves the log level of the standard logger on GET and changes it to the level form value on
+// PUT or POST, so that debug logs can be enabled without restarting
+type LevelHandler struct{}
+
+// ServeHTTP implements http.Handler
+func (LevelHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut, http.MethodPost:
+		level, err := logrus.ParseLevel(r.FormValue("level"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if level != logrus.GetLevel() {
+			logrus.Infof("changing the log level from %s to %s", logrus.GetLevel(), level)
+			logrus.SetLevel(level)
+		}
+	default:
+		http.Error(w, fmt.Sprintf("method %s is not allowed", r.Method), http.StatusMethodNotAllowed)
+		return
+	}
+	fmt.Fprintln(w, logrus.GetLevel().String())
+}
@@ -46,16 +46,21 @@ func Init(formatter *DefaultFieldsFormatter) {
 	logrus.SetReportCaller(formatter.PrintLineNumber)
 }
 
-// CreateDefaultFormatter creates a default JSON formatter
+// CreateDefaultFormatter creates the formatter selected by $LOGRUS_FORMAT, defaulting to JSON
 func CreateDefaultFormatter() logrus.Formatter {
-	if os.Getenv("LOGRUS_FORMAT") == "text" {
+	return CreateFormatter(os.Getenv("LOGRUS_FORMAT"))
+}
+
+// CreateFormatter creates a formatter for the format, which is text, stackdriver or otherwise JSON
+func CreateFormatter(format string) logrus.Formatter {
+	if format == "text" {
 		return &logrus.TextFormatter{
 			ForceColors:      true,
 			DisableTimestamp: true,
 		}
 	}
 
-	if os.Getenv("LOGRUS_FORMAT") == "stackdriver" {
+	if format == "stackdriver" {
 		return &stackdriver.Formatter{}
 	}
 
@@ -0,0 +1,353 @@
+// Package logs serves the build logs of LighthouseJobs, streaming them from the job's pods while they exist and
+// from the log archive once the job has completed, so that the details link of a commit status keeps working.
+package logs
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/censor"
+	lighthouseclient "github.com/jenkins-x/lighthouse/pkg/client/clientset/versioned/typed/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/gc"
+	"github.com/jenkins-x/lighthouse/pkg/podagent"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// Path is the path the logs are served below, as /logs/{org}/{repo}/{job}/{build}, see podagent.LogsURL
+	Path = "/logs/"
+
+	// DefaultPollInterval is how often an archived log is checked for new output when following it
+	DefaultPollInterval = 2 * time.Second
+)
+
+// ErrNotFound is returned when there is no log for a build
+var ErrNotFound = errors.New("no log found")
+
+// Handler serves the build logs of the LighthouseJobs of a namespace
+type Handler struct {
+	KubeClient kubernetes.Interface
+	JobClient  lighthouseclient.LighthouseJobInterface
+	// Namespace is where the pods of jobs run unless their spec says otherwise
+	Namespace string
+	// ArchiveDir is where the build logs are archived, using the layout of gc.BuildDir. No archived log is
+	// served if it is empty.
+	ArchiveDir string
+	// CensorSecrets are the secrets of the namespace masked in every log, in addition to the secrets mounted into
+	// the pods of the job, such as the secrets of pipelines whose pods were garbage collected
+	CensorSecrets []string
+	PollInterval  time.Duration
+	Logger        *logrus.Entry
+}
+
+// ServeHTTP streams the log of the build, following it until the build completes if ?follow=true is set
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "only GET is supported", http.StatusMethodNotAllowed)
+		return
+	}
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, Path), "/"), "/")
+	if len(parts) != 4 {
+		http.Error(w, "expected a path of the form /logs/{org}/{repo}/{job}/{build}", http.StatusBadRequest)
+		return
+	}
+	org, repo, jobName, build := parts[0], parts[1], parts[2], parts[3]
+	follow, _ := strconv.ParseBool(r.URL.Query().Get("follow"))
+	l := h.logger().WithFields(logrus.Fields{"org": org, "repo": repo, "job": jobName, "build": build})
+
+	job, err := h.findJob(org, repo, jobName, build)
+	if err != nil {
+		l.WithError(err).Warn("failed to find the LighthouseJob of the build")
+	}
+
+	censorer, err := h.censorer(job)
+	if err != nil {
+		l.WithError(err).Error("failed to load the secrets to censor from the log of the build")
+		http.Error(w, "failed to censor the log of the build", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	out := newFlushWriter(w)
+	censored := censorer.NewWriter(out)
+	err = h.stream(r, censored, job, gc.BuildPath(org, repo, jobName, build), follow)
+	if flushErr := censored.Flush(); err == nil {
+		err = flushErr
+	}
+	if err == ErrNotFound {
+		message := "no log found for the build"
+		if job != nil {
+			message = fmt.Sprintf("no log available yet, the build is %s", job.Status.State)
+		}
+		http.Error(w, message, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		l.WithError(err).Warn("failed to stream the log of the build")
+		if !out.written {
+			http.Error(w, "failed to read the log of the build", http.StatusInternalServerError)
+		}
+	}
+}
+
+// stream writes the log from the pods of a running build, falling back to the archive, and the other way
+// around for completed builds whose pods may have been garbage collected
+func (h *Handler) stream(r *http.Request, out io.Writer, job *v1alpha1.LighthouseJob, buildPath string, follow bool) error {
+	if job == nil {
+		return h.streamArchive(r, out, nil, buildPath, follow)
+	}
+	if job.Status.CompletionTime != nil {
+		err := h.streamArchive(r, out, job, buildPath, false)
+		if err != ErrNotFound {
+			return err
+		}
+		return h.streamPods(r, out, job, false)
+	}
+	err := h.streamPods(r, out, job, follow)
+	if err == nil {
+		return nil
+	}
+	if err != ErrNotFound {
+		h.logger().WithError(err).WithField("job", job.Name).Debug("failed to stream the logs of the pods of the build")
+	}
+	return h.streamArchive(r, out, job, buildPath, follow)
+}
+
+func (h *Handler) logger() *logrus.Entry {
+	if h.Logger == nil {
+		return logrus.WithField("handler", "logs")
+	}
+	return h.Logger
+}
+
+// censorer returns the Censorer masking the secrets mounted into the pods of the job, which are read from the pods
+// of its PipelineRuns while they exist, and the CensorSecrets
+func (h *Handler) censorer(job *v1alpha1.LighthouseJob) (*censor.Censorer, error) {
+	if h.KubeClient == nil {
+		return censor.New(), nil
+	}
+	values, err := censor.SecretValues(h.KubeClient, h.Namespace, h.CensorSecrets)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil {
+		return censor.New(values...), nil
+	}
+	ns := job.Spec.Namespace
+	if ns == "" {
+		ns = h.Namespace
+	}
+	names := censor.SecretNames(job.Spec.PodSpec)
+	pods := h.KubeClient.CoreV1().Pods(ns)
+	if job.Spec.Agent == v1alpha1.KubernetesAgent {
+		// the decorated pod mounts more secrets than the spec of the job, such as the git credentials
+		pod, err := pods.Get(job.Name, metav1.GetOptions{})
+		if err != nil && !kubeerrors.IsNotFound(err) {
+			return nil, errors.Wrapf(err, "getting the pod of job %s", job.Name)
+		}
+		if err == nil {
+			names = append(names, censor.SecretNames(&pod.Spec)...)
+		}
+	} else if selector := gc.PipelineRunSelector(job); selector != "" {
+		list, err := pods.List(metav1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			return nil, errors.Wrap(err, "listing the pods of the PipelineRuns")
+		}
+		for i := range list.Items {
+			names = append(names, censor.SecretNames(&list.Items[i].Spec)...)
+		}
+	}
+	jobValues, err := censor.SecretValues(h.KubeClient, ns, names)
+	if err != nil {
+		return nil, err
+	}
+	return censor.New(append(values, jobValues...)...), nil
+}
+
+// findJob returns the LighthouseJob of the build, which may be named by its build number or by the job's name
+// if it has none, or nil if it no longer exists
+func (h *Handler) findJob(org, repo, jobName, build string) (*v1alpha1.LighthouseJob, error) {
+	if h.JobClient == nil {
+		return nil, nil
+	}
+	selector := labels.Set{
+		util.OrgLabel:      strings.ToLower(org),
+		util.RepoLabel:     repo,
+		util.BuildNumLabel: build,
+	}
+	list, err := h.JobClient.List(metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, errors.Wrap(err, "listing LighthouseJobs")
+	}
+	for i := range list.Items {
+		job := &list.Items[i]
+		if _, _, name, _ := gc.BuildCoordinates(job); name == jobName {
+			return job, nil
+		}
+	}
+	job, err := h.JobClient.Get(build, metav1.GetOptions{})
+	if kubeerrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting LighthouseJob %s", build)
+	}
+	if _, _, name, _ := gc.BuildCoordinates(job); name != jobName || job.Spec.Refs == nil || !strings.EqualFold(job.Spec.Refs.Org, org) || job.Spec.Refs.Repo != repo {
+		return nil, nil
+	}
+	return job, nil
+}
+
+// streamPods writes the logs of the pods running the job: the log capture sidecar of jobs using the kubernetes
+// agent, or every container of the pods of the job's PipelineRuns
+func (h *Handler) streamPods(r *http.Request, out io.Writer, job *v1alpha1.LighthouseJob, follow bool) error {
+	if h.KubeClient == nil {
+		return ErrNotFound
+	}
+	ns := job.Spec.Namespace
+	if ns == "" {
+		ns = h.Namespace
+	}
+	pods := h.KubeClient.CoreV1().Pods(ns)
+	if job.Spec.Agent == v1alpha1.KubernetesAgent {
+		if _, err := pods.Get(job.Name, metav1.GetOptions{}); err != nil {
+			if kubeerrors.IsNotFound(err) {
+				return ErrNotFound
+			}
+			return err
+		}
+		return h.streamContainer(out, ns, job.Name, podagent.LogsContainerName, follow)
+	}
+
+	selector := gc.PipelineRunSelector(job)
+	if selector == "" {
+		return ErrNotFound
+	}
+	list, err := pods.List(metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return errors.Wrap(err, "listing the pods of the PipelineRuns")
+	}
+	if len(list.Items) == 0 {
+		return ErrNotFound
+	}
+	sort.Slice(list.Items, func(i, j int) bool {
+		return list.Items[i].CreationTimestamp.Before(&list.Items[j].CreationTimestamp)
+	})
+	for _, pod := range list.Items {
+		for _, c := range pod.Spec.Containers {
+			if r.Context().Err() != nil {
+				return nil
+			}
+			if _, err := fmt.Fprintf(out, "==> %s/%s <==\n", pod.Name, c.Name); err != nil {
+				return nil
+			}
+			if err := h.streamContainer(out, ns, pod.Name, c.Name, follow); err != nil {
+				fmt.Fprintf(out, "failed to get the log: %v\n", err)
+			}
+		}
+	}
+	return nil
+}
+
+func (h *Handler) streamContainer(out io.Writer, ns, pod, container string, follow bool) error {
+	stream, err := h.KubeClient.CoreV1().Pods(ns).GetLogs(pod, &corev1.PodLogOptions{Container: container, Follow: follow}).Stream()
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+	_, err = io.Copy(out, stream)
+	return err
+}
+
+// streamArchive writes the archived log of the build, polling it for new output until the build completes
+// when following a running build
+func (h *Handler) streamArchive(r *http.Request, out io.Writer, job *v1alpha1.LighthouseJob, buildPath string, follow bool) error {
+	if h.ArchiveDir == "" {
+		return ErrNotFound
+	}
+	dir := filepath.Join(h.ArchiveDir, filepath.FromSlash(buildPath))
+	if job != nil {
+		dir = filepath.Join(h.ArchiveDir, filepath.FromSlash(gc.BuildDir(job)))
+	}
+	f, err := os.Open(filepath.Join(dir, gc.BuildLogFileName))
+	if os.IsNotExist(err) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	interval := h.PollInterval
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	for {
+		if _, err := io.Copy(out, f); err != nil {
+			return err
+		}
+		if !follow || job == nil || job.Status.CompletionTime != nil || h.finished(dir, job) {
+			_, err := io.Copy(out, f)
+			return err
+		}
+		select {
+		case <-r.Context().Done():
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+// finished returns true once the sidecar archived the exit code of the build or the job completed
+func (h *Handler) finished(dir string, job *v1alpha1.LighthouseJob) bool {
+	if _, err := os.Stat(filepath.Join(dir, podagent.ExitCodeFile)); err == nil {
+		return true
+	}
+	if h.JobClient == nil {
+		return false
+	}
+	current, err := h.JobClient.Get(job.Name, metav1.GetOptions{})
+	return err != nil || current.Status.CompletionTime != nil
+}
+
+// flushWriter flushes each write so that followed logs reach the client as they are written
+type flushWriter struct {
+	w       io.Writer
+	flusher http.Flusher
+	written bool
+}
+
+func newFlushWriter(w http.ResponseWriter) *flushWriter {
+	fw := &flushWriter{w: w}
+	if flusher, ok := w.(http.Flusher); ok {
+		fw.flusher = flusher
+	}
+	return fw
+}
+
+func (fw *flushWriter) Write(p []byte) (int, error) {
+	if len(p) > 0 {
+		fw.written = true
+	}
+	n, err := fw.w.Write(p)
+	if fw.flusher != nil {
+		fw.flusher.Flush()
+	}
+	return n, err
+}
@@ -0,0 +1,191 @@
+package logs
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	lhfake "github.com/jenkins-x/lighthouse/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/lighthouse/pkg/podagent"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+func makeJob(name, build string, state v1alpha1.PipelineState, completed bool) *v1alpha1.LighthouseJob {
+	job := &v1alpha1.LighthouseJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "jx",
+			Labels: map[string]string{
+				util.OrgLabel:      "org",
+				util.RepoLabel:     "repo",
+				util.BuildNumLabel: build,
+			},
+		},
+		Spec: v1alpha1.LighthouseJobSpec{
+			Job:   "unit",
+			Agent: v1alpha1.KubernetesAgent,
+			Refs:  &v1alpha1.Refs{Org: "org", Repo: "repo"},
+		},
+		Status: v1alpha1.LighthouseJobStatus{State: state},
+	}
+	if completed {
+		now := metav1.Now()
+		job.Status.CompletionTime = &now
+	}
+	return job
+}
+
+func writeArchive(t *testing.T, dir, build, log string, finished bool) string {
+	buildDir := filepath.Join(dir, "org", "repo", "unit", build)
+	require.NoError(t, os.MkdirAll(buildDir, 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(buildDir, "build-log.txt"), []byte(log), 0644))
+	if finished {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(buildDir, podagent.ExitCodeFile), []byte("0"), 0644))
+	}
+	return buildDir
+}
+
+func TestServeArchivedLogs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logs")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	writeArchive(t, dir, "1", "done\n", true)
+	writeArchive(t, dir, "2", "gc'd\n", true)
+
+	lhClient := lhfake.NewSimpleClientset(makeJob("job-1", "1", v1alpha1.SuccessState, true), makeJob("job-3", "3", v1alpha1.PendingState, false))
+	h := &Handler{
+		JobClient:  lhClient.LighthouseV1alpha1().LighthouseJobs("jx"),
+		Namespace:  "jx",
+		ArchiveDir: dir,
+	}
+
+	testCases := []struct {
+		name           string
+		method         string
+		path           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "completed job",
+			path:           "/logs/org/repo/unit/1",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "done\n",
+		},
+		{
+			name:           "garbage collected job",
+			path:           "/logs/org/repo/unit/2?follow=true",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "gc'd\n",
+		},
+		{
+			name:           "pending job",
+			path:           "/logs/org/repo/unit/3",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "no log available yet, the build is pending\n",
+		},
+		{
+			name:           "unknown build",
+			path:           "/logs/org/repo/unit/4",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "no log found for the build\n",
+		},
+		{
+			name:           "invalid path",
+			path:           "/logs/org/repo",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid method",
+			method:         http.MethodPost,
+			path:           "/logs/org/repo/unit/1",
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			method := tc.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(method, tc.path, nil))
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedBody != "" {
+				assert.Equal(t, tc.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestFollowArchivedLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logs")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	buildDir := writeArchive(t, dir, "5", "step 1\n", false)
+	lhClient := lhfake.NewSimpleClientset(makeJob("job-5", "5", v1alpha1.RunningState, false))
+	h := &Handler{
+		JobClient:    lhClient.LighthouseV1alpha1().LighthouseJobs("jx"),
+		Namespace:    "jx",
+		ArchiveDir:   dir,
+		PollInterval: 10 * time.Millisecond,
+	}
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		f, err := os.OpenFile(filepath.Join(buildDir, "build-log.txt"), os.O_APPEND|os.O_WRONLY, 0644)
+		if assert.NoError(t, err) {
+			_, err = f.WriteString("step 2\n")
+			assert.NoError(t, err)
+			assert.NoError(t, f.Close())
+		}
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(buildDir, podagent.ExitCodeFile), []byte("0"), 0644))
+	}()
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/logs/org/repo/unit/5?follow=true", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "step 1\nstep 2\n", w.Body.String())
+}
+
+func TestServeCensoredLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logs")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	writeArchive(t, dir, "6", "pushing with s3cr3t-token\nsigned with the release key\n", true)
+	job := makeJob("job-6", "6", v1alpha1.SuccessState, true)
+	job.Spec.PodSpec = &corev1.PodSpec{
+		Containers: []corev1.Container{{
+			EnvFrom: []corev1.EnvFromSource{{SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "push-token"}}}},
+		}},
+	}
+	kubeClient := kubefake.NewSimpleClientset(
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "push-token", Namespace: "jx"}, Data: map[string][]byte{"TOKEN": []byte("s3cr3t-token")}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "signing", Namespace: "jx"}, Data: map[string][]byte{"key": []byte("release key")}},
+	)
+	h := &Handler{
+		KubeClient:    kubeClient,
+		JobClient:     lhfake.NewSimpleClientset(job).LighthouseV1alpha1().LighthouseJobs("jx"),
+		Namespace:     "jx",
+		ArchiveDir:    dir,
+		CensorSecrets: []string{"signing", "missing"},
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/logs/org/repo/unit/6", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "pushing with ***\nsigned with the ***\n", w.Body.String())
+}
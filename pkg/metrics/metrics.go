@@ -62,3 +62,9 @@ func pushMetrics(component, endpoint string, interval time.Duration) {
 		}
 	}, interval)
 }
+
+// PushOnce pushes the metrics to the provided endpoint a single time, which is
+// useful for short lived jobs which exit before a periodic push would happen.
+func PushOnce(component, endpoint string) error {
+	return push.FromGatherer(component, push.HostnameGroupingKey(), endpoint, prometheus.DefaultGatherer)
+}
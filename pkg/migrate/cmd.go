@@ -0,0 +1,76 @@
+package migrate
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/jenkins-x/lighthouse/pkg/cmd/helper"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// Options are the options of the migrate command
+type Options struct {
+	FromProw  bool
+	OutputDir string
+}
+
+// NewCmdMigrate creates the command converting the configuration of Prow
+func NewCmdMigrate() *cobra.Command {
+	options := Options{}
+
+	cmd := &cobra.Command{
+		Use:   "migrate --from-prow config.yaml plugins.yaml",
+		Short: "Converts the config.yaml and plugins.yaml of Prow into the configuration of lighthouse",
+		Long:  "Converts the jobs, the tide queries, the branch protection and the plugins of the config.yaml and plugins.yaml of Prow into the configuration of lighthouse, reporting the unsupported fields which are left out.",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := options.Run(cmd, args[0], args[1])
+			helper.CheckErr(err)
+		},
+	}
+
+	cmd.Flags().BoolVar(&options.FromProw, "from-prow", false, "Converts the configuration of Prow, which is the only supported source.")
+	cmd.Flags().StringVar(&options.OutputDir, "output-dir", "lighthouse-config", "The directory the lighthouse "+util.ProwConfigFilename+" and "+util.ProwPluginsFilename+" are written to.")
+
+	return cmd
+}
+
+// Run converts the config and plugins files
+func (o *Options) Run(cmd *cobra.Command, configPath, pluginsPath string) error {
+	if !o.FromProw {
+		return errors.New("the source of the configuration is required: --from-prow")
+	}
+	if err := os.MkdirAll(o.OutputDir, 0755); err != nil {
+		return errors.Wrapf(err, "creating %s", o.OutputDir)
+	}
+	for _, f := range []struct {
+		path    string
+		name    string
+		migrate func([]byte) ([]byte, []string, error)
+	}{
+		{path: configPath, name: util.ProwConfigFilename, migrate: Config},
+		{path: pluginsPath, name: util.ProwPluginsFilename, migrate: Plugins},
+	} {
+		data, err := ioutil.ReadFile(f.path)
+		if err != nil {
+			return errors.Wrapf(err, "reading %s", f.path)
+		}
+		migrated, unsupported, err := f.migrate(data)
+		if err != nil {
+			return errors.Wrapf(err, "migrating %s", f.path)
+		}
+		for _, message := range unsupported {
+			fmt.Fprintf(cmd.ErrOrStderr(), "%s: %s\n", f.path, message)
+		}
+		output := filepath.Join(o.OutputDir, f.name)
+		if err := ioutil.WriteFile(output, migrated, 0644); err != nil {
+			return errors.Wrapf(err, "writing %s", output)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "migrated %s to %s, leaving out %d unsupported fields\n", f.path, output, len(unsupported))
+	}
+	return nil
+}
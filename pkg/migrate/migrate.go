@@ -0,0 +1,280 @@
+// Package migrate converts the config.yaml and plugins.yaml of Prow into their lighthouse equivalents, reporting the
+// fields lighthouse does not support.
+package migrate
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+// ignoredJobFields are the fields of the Prow jobs which lighthouse parses but ignores
+var ignoredJobFields = map[string]string{
+	"cluster":     "the jobs run in the cluster of lighthouse",
+	"clone_depth": "the repositories are fully cloned",
+}
+
+// Config converts the config.yaml of Prow, returning the config.yaml of lighthouse and the unsupported fields
+// which were left out of it
+func Config(data []byte) ([]byte, []string, error) {
+	doc := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, nil, errors.Wrap(err, "parsing the Prow config")
+	}
+	r := &report{}
+	for _, kind := range []string{"presubmits", "postsubmits"} {
+		repos, _ := doc[kind].(map[string]interface{})
+		for repo, jobs := range repos {
+			jobs, _ := jobs.([]interface{})
+			for _, job := range jobs {
+				if job, ok := job.(map[string]interface{}); ok {
+					migrateJob(job, jobPath(kind+"."+repo, job), r)
+				}
+			}
+		}
+	}
+	periodics, _ := doc["periodics"].([]interface{})
+	for _, job := range periodics {
+		if job, ok := job.(map[string]interface{}); ok {
+			migrateJob(job, jobPath("periodics", job), r)
+		}
+	}
+
+	prune(doc, reflect.TypeOf(config.Config{}), "", r)
+	answer, err := yaml.Marshal(doc)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := config.LoadYAMLConfig(answer); err != nil {
+		return nil, nil, errors.Wrap(err, "the migrated config is invalid")
+	}
+	return answer, r.list(), nil
+}
+
+// Plugins converts the plugins.yaml of Prow, returning the plugins.yaml of lighthouse and the unsupported fields and
+// plugins which were left out of it
+func Plugins(data []byte) ([]byte, []string, error) {
+	doc := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, nil, errors.Wrap(err, "parsing the Prow plugins")
+	}
+	r := &report{}
+	if enabled, ok := doc["plugins"].(map[string]interface{}); ok {
+		doc["plugins"] = migratePlugins(enabled, r)
+	}
+
+	prune(doc, reflect.TypeOf(plugins.Configuration{}), "", r)
+	answer, err := yaml.Marshal(doc)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := (&plugins.ConfigAgent{}).LoadYAMLConfig(answer); err != nil {
+		return nil, nil, errors.Wrap(err, "the migrated plugins are invalid")
+	}
+	return answer, r.list(), nil
+}
+
+// migrateJob runs the pod of a Prow job with the kubernetes or jenkins agent, which lighthouse selects with a label,
+// and moves its decoration timeouts to annotations
+func migrateJob(job map[string]interface{}, path string, r *report) {
+	agent, _ := job["agent"].(string)
+	switch agent {
+	case "", v1alpha1.KubernetesAgent:
+		if _, ok := job["spec"]; ok {
+			setString(job, "labels", util.AgentLabel, v1alpha1.KubernetesAgent)
+		}
+	case v1alpha1.JenkinsAgent:
+		setString(job, "labels", util.AgentLabel, v1alpha1.JenkinsAgent)
+	case v1alpha1.TektonAgent:
+	default:
+		r.add("%s.agent: the %s agent is not supported, the job runs as a Jenkins X pipeline", path, agent)
+	}
+	job["agent"] = v1alpha1.TektonAgent
+
+	if decoration, ok := job["decoration_config"].(map[string]interface{}); ok {
+		for field, annotation := range map[string]string{"timeout": util.TimeoutAnnotation, "grace_period": util.GracePeriodAnnotation} {
+			if value, ok := decoration[field].(string); ok {
+				setString(job, "annotations", annotation, value)
+				delete(decoration, field)
+			}
+		}
+		if len(decoration) == 0 {
+			delete(job, "decoration_config")
+		}
+	}
+	for field, reason := range ignoredJobFields {
+		if _, ok := job[field]; ok {
+			r.add("%s.%s: %s", path, field, reason)
+			delete(job, field)
+		}
+	}
+}
+
+// migratePlugins converts the plugins enabled for orgs with excluded repos into the plugins disabled for these repos
+func migratePlugins(enabled map[string]interface{}, r *report) map[string]interface{} {
+	known := plugins.HelpProviders()
+	answer := map[string]interface{}{}
+	add := func(repo string, names []interface{}, prefix string) {
+		list, _ := answer[repo].([]interface{})
+		for _, name := range names {
+			name, ok := name.(string)
+			if !ok {
+				continue
+			}
+			if _, ok := known[name]; !ok {
+				if prefix == "" {
+					r.add("plugins.%s: the %s plugin is not available", repo, name)
+				}
+				continue
+			}
+			list = append(list, prefix+name)
+		}
+		if len(list) > 0 {
+			answer[repo] = list
+		}
+	}
+	repos := make([]string, 0, len(enabled))
+	for repo := range enabled {
+		repos = append(repos, repo)
+	}
+	sort.Strings(repos)
+	for _, repo := range repos {
+		switch value := enabled[repo].(type) {
+		case []interface{}:
+			add(repo, value, "")
+		case map[string]interface{}:
+			names, _ := value["plugins"].([]interface{})
+			add(repo, names, "")
+			excluded, _ := value["excluded_repos"].([]interface{})
+			for _, name := range excluded {
+				if name, ok := name.(string); ok {
+					add(repo+"/"+name, names, "-")
+				}
+			}
+		}
+	}
+	return answer
+}
+
+func setString(job map[string]interface{}, field, key, value string) {
+	values, ok := job[field].(map[string]interface{})
+	if !ok {
+		values = map[string]interface{}{}
+		job[field] = values
+	}
+	if _, ok := values[key]; !ok {
+		values[key] = value
+	}
+}
+
+func jobPath(parent string, job map[string]interface{}) string {
+	if name, ok := job["name"].(string); ok {
+		return fmt.Sprintf("%s[%s]", parent, name)
+	}
+	return parent
+}
+
+// prune removes the fields of the value which the type has no field for, reporting them
+func prune(value interface{}, t reflect.Type, path string, r *report) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	// the types decoding themselves, such as quantities, are not structs in YAML
+	if reflect.PtrTo(t).Implements(reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()) {
+		return
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return
+		}
+		fields := jsonFields(t)
+		for k, v := range m {
+			ft, ok := fields[k]
+			if !ok {
+				r.add("%s: the field is not supported", join(path, k))
+				delete(m, k)
+				continue
+			}
+			prune(v, ft, join(path, k), r)
+		}
+	case reflect.Map:
+		if m, ok := value.(map[string]interface{}); ok {
+			for k, v := range m {
+				prune(v, t.Elem(), join(path, k), r)
+			}
+		}
+	case reflect.Slice:
+		if l, ok := value.([]interface{}); ok {
+			for i, v := range l {
+				p := fmt.Sprintf("%s[%d]", path, i)
+				if m, ok := v.(map[string]interface{}); ok {
+					p = jobPath(path, m)
+				}
+				prune(v, t.Elem(), p, r)
+			}
+		}
+	}
+}
+
+// jsonFields returns the types of the fields of the struct by their JSON name, including the fields of its embedded
+// structs
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	answer := map[string]reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		name := strings.Split(tag, ",")[0]
+		if name == "-" || f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+		if name == "" && (f.Anonymous || strings.Contains(tag, "inline")) {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				for k, v := range jsonFields(ft) {
+					answer[k] = v
+				}
+				continue
+			}
+		}
+		if name == "" {
+			name = f.Name
+		}
+		answer[name] = f.Type
+	}
+	return answer
+}
+
+func join(path, field string) string {
+	if path == "" {
+		return field
+	}
+	return path + "." + field
+}
+
+// report collects the unsupported fields
+type report struct {
+	messages []string
+}
+
+func (r *report) add(format string, args ...interface{}) {
+	r.messages = append(r.messages, fmt.Sprintf(format, args...))
+}
+
+func (r *report) list() []string {
+	sort.Strings(r.messages)
+	return r.messages
+}
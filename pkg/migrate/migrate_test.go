@@ -0,0 +1,127 @@
+package migrate
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	_ "github.com/jenkins-x/lighthouse/pkg/plugins/approve"
+	_ "github.com/jenkins-x/lighthouse/pkg/plugins/lgtm"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const prowConfig = `
+prowjob_namespace: prow
+plank:
+  default_decoration_configs:
+    '*':
+      gcs_configuration:
+        bucket: prow-logs
+presubmits:
+  org/repo:
+  - name: unit
+    always_run: true
+    decorate: true
+    cluster: build
+    decoration_config:
+      timeout: 2h
+      grace_period: 10m
+    reporter_config:
+      slack:
+        channel: ci
+    spec:
+      containers:
+      - image: golang
+        command: ["make", "test"]
+        resources:
+          requests:
+            cpu: 500m
+postsubmits:
+  org/repo:
+  - name: release
+    agent: jenkins
+    branches:
+    - master
+periodics:
+- name: nightly
+  cron: "0 0 * * *"
+  agent: tekton-pipeline
+tide:
+  queries:
+  - repos:
+    - org/repo
+    labels:
+    - lgtm
+    - approved
+  merge_method:
+    org/repo: squash
+branch-protection:
+  orgs:
+    org:
+      protect: true
+`
+
+func TestConfig(t *testing.T) {
+	data, unsupported, err := Config([]byte(prowConfig))
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"periodics[nightly].agent: the tekton-pipeline agent is not supported, the job runs as a Jenkins X pipeline",
+		"plank.default_decoration_configs: the field is not supported",
+		"presubmits.org/repo[unit].cluster: the jobs run in the cluster of lighthouse",
+		"presubmits.org/repo[unit].reporter_config: the field is not supported",
+	}, unsupported)
+
+	cfg, err := config.LoadYAMLConfig(data)
+	require.NoError(t, err)
+	assert.Equal(t, "prow", cfg.LighthouseJobNamespace)
+	require.Len(t, cfg.Presubmits["org/repo"], 1)
+	unit := cfg.Presubmits["org/repo"][0]
+	assert.Equal(t, config.TektonAgent, unit.Agent)
+	assert.Equal(t, "kubernetes", unit.Labels[util.AgentLabel])
+	assert.Equal(t, "2h", unit.Annotations[util.TimeoutAnnotation])
+	assert.Equal(t, "10m", unit.Annotations[util.GracePeriodAnnotation])
+	require.NotNil(t, unit.Spec)
+	assert.Equal(t, "500m", unit.Spec.Containers[0].Resources.Requests.Cpu().String())
+	require.Len(t, cfg.Postsubmits["org/repo"], 1)
+	assert.Equal(t, "jenkins", cfg.Postsubmits["org/repo"][0].Labels[util.AgentLabel])
+	require.Len(t, cfg.Keeper.Queries, 1)
+	assert.Equal(t, []string{"lgtm", "approved"}, cfg.Keeper.Queries[0].Labels)
+	assert.Equal(t, config.MergeSquash, cfg.Keeper.MergeMethod("org", "repo"))
+	assert.NotNil(t, cfg.BranchProtection.Orgs["org"].Protect)
+}
+
+func TestPlugins(t *testing.T) {
+	data, unsupported, err := Plugins([]byte(`
+plugins:
+  org:
+    plugins:
+    - approve
+    - lgtm
+    - dco
+    excluded_repos:
+    - legacy
+  other/repo:
+  - lgtm
+lgtm:
+- repos:
+  - org
+  review_acts_as_lgtm: true
+bugzilla:
+  default: {}
+`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"bugzilla: the field is not supported",
+		"plugins.org: the dco plugin is not available",
+	}, unsupported)
+
+	cfg, err := (&plugins.ConfigAgent{}).LoadYAMLConfig(data)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"approve", "lgtm"}, cfg.PluginsFor("org", "repo"))
+	assert.Empty(t, cfg.PluginsFor("org", "legacy"))
+	assert.Equal(t, []string{"lgtm"}, cfg.PluginsFor("other", "repo"))
+	require.Len(t, cfg.Lgtm, 1)
+	assert.True(t, cfg.Lgtm[0].ReviewActsAsLgtm)
+}
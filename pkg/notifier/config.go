@@ -0,0 +1,321 @@
+package notifier
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"sigs.k8s.io/yaml"
+)
+
+// EventKind is a kind of event the notification rules subscribe to
+type EventKind string
+
+const (
+	// JobFailure is any job which failed, errored or was aborted
+	JobFailure EventKind = "failure"
+	// RequiredJobFailure is a job which failed and must pass to merge, i.e. a presubmit whose context is
+	// required, a batch, a postsubmit or a periodic
+	RequiredJobFailure EventKind = "required_failure"
+	// JobSuccess is a job which succeeded
+	JobSuccess EventKind = "success"
+	// Merge is a pull request merged by keeper
+	Merge EventKind = "merge"
+)
+
+// WebhookKind is the kind of service an outgoing webhook posts to
+type WebhookKind string
+
+const (
+	// JSONWebhook posts the event and its message as JSON
+	JSONWebhook WebhookKind = "json"
+	// TeamsWebhook posts message cards to a Microsoft Teams incoming webhook
+	TeamsWebhook WebhookKind = "teams"
+	// DiscordWebhook posts messages to a Discord webhook
+	DiscordWebhook WebhookKind = "discord"
+)
+
+// Config holds the notification sinks and the rules sending events to them, read from the notifications
+// section of config.yaml:
+//
+//	notifications:
+//	  slack:
+//	    token_path: /etc/slack/token
+//	  webhooks:
+//	  - name: team-a
+//	    kind: teams
+//	    url_path: /etc/notifications/team-a
+//	  rules:
+//	  - repos:
+//	    - org/repo
+//	    channel: "#ci"
+//	    events:
+//	    - required_failure
+//	    - merge
+//	    template: '{{ .Job }} failed on {{ link .Link "the PR" }}, see {{ link .LogURL "the logs" }}'
+//	  - repos:
+//	    - org/other
+//	    sink: team-a
+type Config struct {
+	// Slack configures the Slack sink
+	Slack Slack `json:"slack,omitempty"`
+	// Webhooks are the outgoing webhook sinks, which rules select by name
+	Webhooks []Webhook `json:"webhooks,omitempty"`
+	// Email configures the email sink, which rules select with the email sink name
+	Email Email `json:"email,omitempty"`
+	// Rules select the events sent to each channel
+	Rules []Rule `json:"rules,omitempty"`
+}
+
+// Webhook configures an outgoing webhook sink
+type Webhook struct {
+	// Name is the name rules refer to the webhook by
+	Name string `json:"name"`
+	// Kind is the kind of service the webhook posts to, defaulting to json
+	Kind WebhookKind `json:"kind,omitempty"`
+	// URLPath is the file holding the URL of the webhook, as the URLs of incoming webhooks embed their credentials
+	URLPath string `json:"url_path"`
+}
+
+// EmailSink is the name rules select the email sink with, whose channels are comma separated addresses
+const EmailSink = "email"
+
+// Email configures how emails are sent, the authors emailed when their jobs fail and the maintainers
+// emailed the daily digest of their repositories:
+//
+//	email:
+//	  smtp:
+//	    host: smtp.example.com
+//	    username_path: /etc/smtp/username
+//	    password_path: /etc/smtp/password
+//	  from: lighthouse@example.com
+//	  authors:
+//	    someone: someone@example.com
+//	  digest:
+//	    org/repo:
+//	    - maintainers@example.com
+type Email struct {
+	// SMTP is the server the emails are sent with
+	SMTP SMTP `json:"smtp,omitempty"`
+	// From is the sender of the emails
+	From string `json:"from,omitempty"`
+	// Authors are the addresses of the pull request authors who opted in to be emailed when the jobs
+	// of their pull requests fail, keyed by login
+	Authors map[string]string `json:"authors,omitempty"`
+	// Digest are the addresses of the maintainers emailed the daily digest of the failed postsubmits
+	// and flaky presubmits of their repositories, keyed by "org" or "org/repo"
+	Digest map[string][]string `json:"digest,omitempty"`
+}
+
+// SMTP configures the SMTP server emails are sent with
+type SMTP struct {
+	// Host is the host of the SMTP server
+	Host string `json:"host,omitempty"`
+	// Port is the port of the SMTP server, defaulting to 587
+	Port int `json:"port,omitempty"`
+	// UsernamePath and PasswordPath are the files holding the credentials of the SMTP server, if it
+	// requires authentication
+	UsernamePath string `json:"username_path,omitempty"`
+	PasswordPath string `json:"password_path,omitempty"`
+}
+
+// DigestRecipients returns the maintainers of the repository emailed its daily digest
+func (e *Email) DigestRecipients(org, repo string) []string {
+	var answer []string
+	seen := map[string]bool{}
+	for _, key := range []string{org + "/" + repo, org} {
+		for _, address := range e.Digest[key] {
+			if !seen[address] {
+				seen[address] = true
+				answer = append(answer, address)
+			}
+		}
+	}
+	return answer
+}
+
+// Webhook returns the outgoing webhook with the given name
+func (c *Config) Webhook(name string) *Webhook {
+	for i := range c.Webhooks {
+		if c.Webhooks[i].Name == name {
+			return &c.Webhooks[i]
+		}
+	}
+	return nil
+}
+
+// Slack configures how messages are posted to Slack
+type Slack struct {
+	// TokenPath is the file holding the token of the Slack bot posting the messages
+	TokenPath string `json:"token_path,omitempty"`
+	// URL is the URL of the Slack API, defaulting to https://slack.com/api
+	URL string `json:"url,omitempty"`
+}
+
+// Rule sends the events of some kinds in some repositories to a channel
+type Rule struct {
+	// Repos are the "org" or "org/repo" the rule applies to, all of them if empty
+	Repos []string `json:"repos,omitempty"`
+	// Sink is the name of the webhook the messages are sent to, EmailSink to email them, Slack if empty
+	Sink string `json:"sink,omitempty"`
+	// Channel is the channel the messages are sent to, which is required by Slack and holds the comma
+	// separated addresses of emails. Webhooks post to the channel of their URL and only pass it along in
+	// the JSON of json webhooks.
+	Channel string `json:"channel,omitempty"`
+	// Events are the kinds of events sent, job failures if empty
+	Events []EventKind `json:"events,omitempty"`
+	// Template is the Go template of the messages, evaluated against the Event. A default message
+	// linking to the pull request and the logs is sent if empty.
+	Template string `json:"template,omitempty"`
+}
+
+// Matches returns true if the rule sends the event
+func (r *Rule) Matches(e *Event) bool {
+	if !r.appliesTo(e.Org, e.Repo) {
+		return false
+	}
+	events := r.Events
+	if len(events) == 0 {
+		events = []EventKind{JobFailure}
+	}
+	for _, k := range events {
+		switch {
+		case k == e.Kind:
+			return true
+		case k == JobFailure && e.Kind == RequiredJobFailure:
+			// required failures are failures too
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) appliesTo(org, repo string) bool {
+	if len(r.Repos) == 0 {
+		return true
+	}
+	for _, name := range r.Repos {
+		if name == org || name == org+"/"+repo {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadConfig reads the Config from the notifications section of the text of config.yaml
+func LoadConfig(data []byte) (*Config, error) {
+	answer := struct {
+		Notifications Config `json:"notifications,omitempty"`
+	}{}
+	if err := yaml.Unmarshal(data, &answer); err != nil {
+		return nil, errors.Wrap(err, "parsing the notifications")
+	}
+	if err := answer.Notifications.validate(); err != nil {
+		return nil, err
+	}
+	return &answer.Notifications, nil
+}
+
+func (c *Config) validate() error {
+	names := map[string]bool{}
+	for i, w := range c.Webhooks {
+		if w.Name == "" {
+			return errors.Errorf("notification webhook %d has no name", i)
+		}
+		if names[w.Name] {
+			return errors.Errorf("notification webhook %s is defined twice", w.Name)
+		}
+		if w.Name == EmailSink {
+			return errors.Errorf("notification webhook %s has the reserved name of the email sink", w.Name)
+		}
+		names[w.Name] = true
+		switch w.Kind {
+		case "", JSONWebhook, TeamsWebhook, DiscordWebhook:
+		default:
+			return errors.Errorf("notification webhook %s has an unknown kind %s", w.Name, w.Kind)
+		}
+		if w.URLPath == "" {
+			return errors.Errorf("notification webhook %s has no url_path", w.Name)
+		}
+	}
+	emailed := len(c.Email.Authors) > 0 || len(c.Email.Digest) > 0
+	for i, r := range c.Rules {
+		if r.Sink == EmailSink {
+			emailed = true
+			if strings.TrimSpace(r.Channel) == "" {
+				return errors.Errorf("notification rule %d has no email addresses in its channel", i)
+			}
+		} else if r.Sink != "" {
+			if !names[r.Sink] {
+				return errors.Errorf("notification rule %d refers to the unknown webhook %s", i, r.Sink)
+			}
+		} else if strings.TrimSpace(r.Channel) == "" {
+			return errors.Errorf("notification rule %d has no channel", i)
+		}
+	}
+	if emailed && (c.Email.SMTP.Host == "" || c.Email.From == "") {
+		return errors.New("the email notifications need an smtp host and a from address")
+	}
+	return nil
+}
+
+// Agent holds the current Config
+type Agent struct {
+	lock   sync.RWMutex
+	config *Config
+}
+
+// Set replaces the current Config
+func (a *Agent) Set(config *Config) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.config = config
+}
+
+// Config returns the current Config, which is empty until one is set
+func (a *Agent) Config() *Config {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+	if a.config == nil {
+		return &Config{}
+	}
+	return a.config
+}
+
+// FileConfig returns the Config of the config file, reloaded whenever the file changes. The previous
+// Config is kept if the file cannot be loaded.
+func FileConfig(fileName string) func() *Config {
+	var lock sync.Mutex
+	var modTime time.Time
+	config := &Config{}
+	return func() *Config {
+		lock.Lock()
+		defer lock.Unlock()
+
+		info, err := os.Stat(fileName)
+		if err != nil {
+			logrus.WithError(err).Warnf("failed to find config file %s", fileName)
+			return config
+		}
+		if info.ModTime().Equal(modTime) {
+			return config
+		}
+		data, err := ioutil.ReadFile(fileName) // #nosec
+		if err == nil {
+			var loaded *Config
+			loaded, err = LoadConfig(data)
+			if err == nil {
+				config = loaded
+				modTime = info.ModTime()
+			}
+		}
+		if err != nil {
+			logrus.WithError(err).Warnf("failed to load the notifications of config file %s", fileName)
+		}
+		return config
+	}
+}
@@ -0,0 +1,117 @@
+package notifier
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const configYAML = `
+keeper:
+  sync_period: 1m
+notifications:
+  slack:
+    token_path: /etc/slack/token
+  rules:
+  - repos:
+    - org/repo
+    channel: "#ci"
+    events:
+    - required_failure
+    - merge
+  - repos:
+    - other
+    channel: "#other"
+`
+
+func TestLoadConfig(t *testing.T) {
+	cfg, err := LoadConfig([]byte(configYAML))
+	require.NoError(t, err)
+	assert.Equal(t, "/etc/slack/token", cfg.Slack.TokenPath)
+	require.Len(t, cfg.Rules, 2)
+	assert.Equal(t, []EventKind{RequiredJobFailure, Merge}, cfg.Rules[0].Events)
+	assert.Equal(t, "#other", cfg.Rules[1].Channel)
+
+	cfg, err = LoadConfig([]byte("keeper: {}"))
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Rules)
+
+	invalid := map[string]string{
+		"no channel":          "rules:\n- repos: [org]\n",
+		"unknown webhook":     "rules:\n- sink: missing\n",
+		"unnamed webhook":     "webhooks:\n- url_path: /etc/url\n",
+		"duplicate names":     "webhooks:\n- name: a\n  url_path: /etc/a\n- name: a\n  url_path: /etc/b\n",
+		"unknown kind":        "webhooks:\n- name: a\n  kind: irc\n  url_path: /etc/a\n",
+		"webhook with no url": "webhooks:\n- name: a\n  kind: teams\n",
+		"reserved name":       "webhooks:\n- name: email\n  url_path: /etc/a\n",
+		"no addresses":        "email:\n  smtp:\n    host: smtp\n  from: a@example.com\nrules:\n- sink: email\n",
+		"no smtp host":        "email:\n  from: a@example.com\n  authors:\n    someone: someone@example.com\n",
+		"no from":             "email:\n  smtp:\n    host: smtp\nrules:\n- sink: email\n  channel: a@example.com\n",
+	}
+	for name, text := range invalid {
+		_, err = LoadConfig([]byte("notifications:\n" + indent(text)))
+		assert.Error(t, err, name)
+	}
+
+	cfg, err = LoadConfig([]byte("notifications:\n" + indent("webhooks:\n- name: a\n  kind: discord\n  url_path: /etc/a\nrules:\n- sink: a\n")))
+	require.NoError(t, err)
+	assert.Equal(t, &Webhook{Name: "a", Kind: DiscordWebhook, URLPath: "/etc/a"}, cfg.Webhook("a"))
+	assert.Nil(t, cfg.Webhook("b"))
+}
+
+func indent(text string) string {
+	return "  " + strings.Replace(strings.TrimSuffix(text, "\n"), "\n", "\n  ", -1) + "\n"
+}
+
+func TestRuleMatches(t *testing.T) {
+	cfg, err := LoadConfig([]byte(configYAML))
+	require.NoError(t, err)
+	repoRule, orgRule := &cfg.Rules[0], &cfg.Rules[1]
+
+	tests := []struct {
+		name  string
+		rule  *Rule
+		event Event
+		want  bool
+	}{
+		{"required failure", repoRule, Event{Kind: RequiredJobFailure, Org: "org", Repo: "repo"}, true},
+		{"optional failure", repoRule, Event{Kind: JobFailure, Org: "org", Repo: "repo"}, false},
+		{"merge", repoRule, Event{Kind: Merge, Org: "org", Repo: "repo"}, true},
+		{"other repo", repoRule, Event{Kind: Merge, Org: "org", Repo: "other"}, false},
+		{"failures by default", orgRule, Event{Kind: JobFailure, Org: "other", Repo: "repo"}, true},
+		{"required failures are failures", orgRule, Event{Kind: RequiredJobFailure, Org: "other", Repo: "repo"}, true},
+		{"no success by default", orgRule, Event{Kind: JobSuccess, Org: "other", Repo: "repo"}, false},
+		{"all repos", &Rule{Channel: "#all", Events: []EventKind{JobSuccess}}, Event{Kind: JobSuccess, Org: "any", Repo: "repo"}, true},
+	}
+	for _, tc := range tests {
+		assert.Equal(t, tc.want, tc.rule.Matches(&tc.event), tc.name)
+	}
+}
+
+func TestFileConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "notifier")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	fileName := filepath.Join(dir, "config.yaml")
+	require.NoError(t, ioutil.WriteFile(fileName, []byte(configYAML), 0600))
+
+	config := FileConfig(fileName)
+	assert.Len(t, config().Rules, 2)
+
+	// an invalid file keeps the previous configuration
+	require.NoError(t, ioutil.WriteFile(fileName, []byte("notifications: ["), 0600))
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(fileName, later, later))
+	assert.Len(t, config().Rules, 2)
+
+	require.NoError(t, ioutil.WriteFile(fileName, []byte("notifications:\n  rules:\n  - channel: '#ci'\n"), 0600))
+	later = later.Add(time.Minute)
+	require.NoError(t, os.Chtimes(fileName, later, later))
+	assert.Len(t, config().Rules, 1)
+}
@@ -0,0 +1,199 @@
+package notifier
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+)
+
+// Digest summarizes the failed postsubmits and the flaky presubmits of a repository over a period
+type Digest struct {
+	Org   string
+	Repo  string
+	Since time.Time
+	Until time.Time
+
+	FailedPostsubmits []*Event
+	FlakyPresubmits   []*Flake
+}
+
+// Flake is a presubmit which both failed and passed on the same commit of a pull request
+type Flake struct {
+	Job       string
+	Number    int
+	Link      string
+	SHA       string
+	Failures  int
+	Successes int
+}
+
+// Digests returns the digests of the repositories whose jobs failed or flaked during the period, sorted
+// by repository
+func Digests(jobs []*v1alpha1.LighthouseJob, since, until time.Time) []*Digest {
+	type run struct {
+		org, repo, job, sha string
+	}
+	digests := map[string]*Digest{}
+	flakes := map[run]*Flake{}
+	digest := func(org, repo string) *Digest {
+		key := org + "/" + repo
+		d, ok := digests[key]
+		if !ok {
+			d = &Digest{Org: org, Repo: repo, Since: since, Until: until}
+			digests[key] = d
+		}
+		return d
+	}
+	for _, job := range jobs {
+		completion := job.Status.CompletionTime
+		if job.Spec.Refs == nil || completion == nil || completion.Time.Before(since) || !completion.Time.Before(until) {
+			continue
+		}
+		failed := job.Status.State == v1alpha1.FailureState || job.Status.State == v1alpha1.ErrorState
+		switch job.Spec.Type {
+		case config.PostsubmitJob:
+			if failed {
+				d := digest(job.Spec.Refs.Org, job.Spec.Refs.Repo)
+				d.FailedPostsubmits = append(d.FailedPostsubmits, jobEvent(JobFailure, job))
+			}
+		case config.PresubmitJob:
+			if !failed && job.Status.State != v1alpha1.SuccessState {
+				continue
+			}
+			e := jobEvent(JobFailure, job)
+			key := run{org: e.Org, repo: e.Repo, job: e.Job, sha: e.SHA}
+			f, ok := flakes[key]
+			if !ok {
+				f = &Flake{Job: e.Job, Number: e.Number, Link: e.Link, SHA: e.SHA}
+				flakes[key] = f
+			}
+			if failed {
+				f.Failures++
+			} else {
+				f.Successes++
+			}
+		}
+	}
+	for key, f := range flakes {
+		if f.Failures == 0 || f.Successes == 0 {
+			continue
+		}
+		d := digest(key.org, key.repo)
+		d.FlakyPresubmits = append(d.FlakyPresubmits, f)
+	}
+
+	var answer []*Digest
+	for _, d := range digests {
+		sort.Slice(d.FailedPostsubmits, func(i, j int) bool {
+			return d.FailedPostsubmits[i].Job < d.FailedPostsubmits[j].Job
+		})
+		sort.Slice(d.FlakyPresubmits, func(i, j int) bool {
+			a, b := d.FlakyPresubmits[i], d.FlakyPresubmits[j]
+			if a.Job != b.Job {
+				return a.Job < b.Job
+			}
+			return a.Number < b.Number
+		})
+		answer = append(answer, d)
+	}
+	sort.Slice(answer, func(i, j int) bool {
+		return answer[i].Org+"/"+answer[i].Repo < answer[j].Org+"/"+answer[j].Repo
+	})
+	return answer
+}
+
+// Subject is the subject of the email of the digest
+func (d *Digest) Subject() string {
+	return fmt.Sprintf("digest of %s/%s for %s", d.Org, d.Repo, d.Until.Format("2006-01-02"))
+}
+
+// Text is the plain text body of the email of the digest
+func (d *Digest) Text() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Jobs of %s/%s from %s to %s\n", d.Org, d.Repo, d.Since.Format(time.RFC3339), d.Until.Format(time.RFC3339))
+	if len(d.FailedPostsubmits) > 0 {
+		b.WriteString("\nFailed postsubmits:\n")
+		for _, e := range d.FailedPostsubmits {
+			fmt.Fprintf(&b, "- %s on %s (%s)", e.Job, e.Branch, shortSHA(e.SHA))
+			if e.Description != "" {
+				fmt.Fprintf(&b, ": %s", e.Description)
+			}
+			if e.LogURL != "" {
+				fmt.Fprintf(&b, " %s", e.LogURL)
+			}
+			b.WriteString("\n")
+		}
+	}
+	if len(d.FlakyPresubmits) > 0 {
+		b.WriteString("\nFlaky presubmits, which failed and passed on the same commit:\n")
+		for _, f := range d.FlakyPresubmits {
+			fmt.Fprintf(&b, "- %s on #%d (%s): failed %s, passed %s", f.Job, f.Number, shortSHA(f.SHA), times(f.Failures), times(f.Successes))
+			if f.Link != "" {
+				fmt.Fprintf(&b, " %s", f.Link)
+			}
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// SendDigests emails the digests of the jobs completed during the period to the maintainers of their
+// repositories
+func (n *Notifier) SendDigests(jobs []*v1alpha1.LighthouseJob, since, until time.Time) {
+	if n == nil {
+		return
+	}
+	cfg := n.config()
+	if cfg == nil || len(cfg.Email.Digest) == 0 {
+		return
+	}
+	var mailer Mailer
+	for _, d := range Digests(jobs, since, until) {
+		to := cfg.Email.DigestRecipients(d.Org, d.Repo)
+		if len(to) == 0 {
+			continue
+		}
+		log := n.logger.WithField("org", d.Org).WithField("repo", d.Repo)
+		if mailer == nil {
+			var err error
+			mailer, err = n.newMailer(cfg)
+			if err != nil {
+				log.WithError(err).Warn("failed to create the email sink")
+				return
+			}
+		}
+		if err := mailer.Mail(to, d.Subject(), d.Text()); err != nil {
+			log.WithError(err).Warn("failed to send the digest")
+			continue
+		}
+		log.Info("sent the digest")
+	}
+}
+
+// DigestDue returns true if the daily digest sent at the given UTC hour is due at now, as it was last sent at last
+func DigestDue(last, now time.Time, hour int) bool {
+	now = now.UTC()
+	scheduled := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, time.UTC)
+	if now.Before(scheduled) {
+		scheduled = scheduled.AddDate(0, 0, -1)
+	}
+	return last.Before(scheduled)
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
+
+func times(n int) string {
+	if n == 1 {
+		return "once"
+	}
+	return fmt.Sprintf("%d times", n)
+}
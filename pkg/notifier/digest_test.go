@@ -0,0 +1,117 @@
+package notifier
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type fakeMailer struct {
+	fakeSink
+	mails []sentMail
+}
+
+func (m *fakeMailer) Mail(to []string, subject, body string) error {
+	m.mails = append(m.mails, sentMail{to: to, subject: subject, msg: body})
+	return nil
+}
+
+var (
+	digestSince = time.Date(2020, 5, 1, 8, 0, 0, 0, time.UTC)
+	digestUntil = digestSince.Add(24 * time.Hour)
+)
+
+func completedJob(kind config.PipelineKind, repo, name, sha string, number int, state v1alpha1.PipelineState, completion time.Time) *v1alpha1.LighthouseJob {
+	job := presubmit(name, state)
+	job.Spec.Type = kind
+	job.Spec.Refs.Repo = repo
+	job.Spec.Refs.BaseSHA = sha
+	job.Spec.Refs.Pulls[0].SHA = sha
+	job.Spec.Refs.Pulls[0].Number = number
+	job.Status.CompletionTime = &metav1.Time{Time: completion}
+	if kind == config.PostsubmitJob {
+		job.Spec.Refs.Pulls = nil
+	}
+	return job
+}
+
+func digestJobs() []*v1alpha1.LighthouseJob {
+	during := digestSince.Add(time.Hour)
+	return []*v1alpha1.LighthouseJob{
+		completedJob(config.PostsubmitJob, "repo", "release", "1234567890", 0, v1alpha1.FailureState, during),
+		completedJob(config.PostsubmitJob, "repo", "release", "abcdef0123", 0, v1alpha1.SuccessState, during),
+		completedJob(config.PostsubmitJob, "repo", "release", "0000000000", 0, v1alpha1.FailureState, digestSince.Add(-time.Hour)),
+		completedJob(config.PresubmitJob, "repo", "unit", "aaaaaaaaaa", 12, v1alpha1.FailureState, during),
+		completedJob(config.PresubmitJob, "repo", "unit", "aaaaaaaaaa", 12, v1alpha1.ErrorState, during),
+		completedJob(config.PresubmitJob, "repo", "unit", "aaaaaaaaaa", 12, v1alpha1.SuccessState, during),
+		completedJob(config.PresubmitJob, "repo", "unit", "bbbbbbbbbb", 13, v1alpha1.FailureState, during),
+		completedJob(config.PresubmitJob, "repo", "lint", "bbbbbbbbbb", 13, v1alpha1.SuccessState, during),
+		completedJob(config.PresubmitJob, "other", "unit", "cccccccccc", 1, v1alpha1.FailureState, during),
+		completedJob(config.PresubmitJob, "other", "unit", "cccccccccc", 1, v1alpha1.SuccessState, digestUntil.Add(time.Hour)),
+	}
+}
+
+func TestDigests(t *testing.T) {
+	digests := Digests(digestJobs(), digestSince, digestUntil)
+	require.Len(t, digests, 1)
+	d := digests[0]
+	assert.Equal(t, "org", d.Org)
+	assert.Equal(t, "repo", d.Repo)
+	require.Len(t, d.FailedPostsubmits, 1)
+	assert.Equal(t, "release", d.FailedPostsubmits[0].Job)
+	assert.Equal(t, []*Flake{
+		{Job: "unit", Number: 12, Link: "https://github.com/org/repo/pull/12", SHA: "aaaaaaaaaa", Failures: 2, Successes: 1},
+	}, d.FlakyPresubmits)
+
+	assert.Equal(t, "digest of org/repo for 2020-05-02", d.Subject())
+	assert.Equal(t, `Jobs of org/repo from 2020-05-01T08:00:00Z to 2020-05-02T08:00:00Z
+
+Failed postsubmits:
+- release on master (1234567): Pipeline failed https://dashboard/org/repo/PR-12/1
+
+Flaky presubmits, which failed and passed on the same commit:
+- unit on #12 (aaaaaaa): failed 2 times, passed once https://github.com/org/repo/pull/12
+`, d.Text())
+}
+
+func TestSendDigests(t *testing.T) {
+	mailer := &fakeMailer{}
+	n := New(func() *Config {
+		return &Config{Email: Email{Digest: map[string][]string{
+			"org":       {"maintainers@example.com"},
+			"org/repo":  {"repo@example.com", "maintainers@example.com"},
+			"org/other": {"other@example.com"},
+		}}}
+	}, nil, nil)
+	n.newMailer = func(*Config) (Mailer, error) { return mailer, nil }
+
+	n.SendDigests(digestJobs(), digestSince, digestUntil)
+	require.Len(t, mailer.mails, 1)
+	assert.Equal(t, []string{"repo@example.com", "maintainers@example.com"}, mailer.mails[0].to)
+	assert.Equal(t, "digest of org/repo for 2020-05-02", mailer.mails[0].subject)
+}
+
+func TestDigestDue(t *testing.T) {
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2020, 5, day, hour, minute, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		last, now time.Time
+		want      bool
+	}{
+		{at(1, 7, 0), at(1, 7, 59), false},
+		{at(1, 7, 0), at(1, 8, 0), true},
+		{at(1, 8, 0), at(1, 8, 1), false},
+		{at(1, 8, 0), at(2, 7, 59), false},
+		{at(1, 8, 0), at(2, 8, 1), true},
+		{at(1, 9, 0), at(3, 6, 0), true},
+	}
+	for _, tc := range tests {
+		assert.Equal(t, tc.want, DigestDue(tc.last, tc.now, 8), "last %s, now %s", tc.last, tc.now)
+	}
+}
@@ -0,0 +1,92 @@
+package notifier
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const defaultSMTPPort = 587
+
+// Mailer is the email sink, which also sends emails which are not about a single event
+type Mailer interface {
+	Sink
+	Mail(to []string, subject, body string) error
+}
+
+// email sends plain text emails with an SMTP server
+type email struct {
+	addr     string
+	from     string
+	auth     smtp.Auth
+	sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewEmail returns a Mailer sending emails with the SMTP server of the configuration
+func NewEmail(cfg Email) (Mailer, error) {
+	if cfg.SMTP.Host == "" || cfg.From == "" {
+		return nil, errors.New("email needs an smtp host and a from address")
+	}
+	port := cfg.SMTP.Port
+	if port == 0 {
+		port = defaultSMTPPort
+	}
+	answer := &email{
+		addr:     net.JoinHostPort(cfg.SMTP.Host, strconv.Itoa(port)),
+		from:     cfg.From,
+		sendMail: smtp.SendMail,
+	}
+	if cfg.SMTP.UsernamePath != "" {
+		username, err := ioutil.ReadFile(cfg.SMTP.UsernamePath) // #nosec
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading the smtp username %s", cfg.SMTP.UsernamePath)
+		}
+		password, err := ioutil.ReadFile(cfg.SMTP.PasswordPath) // #nosec
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading the smtp password %s", cfg.SMTP.PasswordPath)
+		}
+		answer.auth = smtp.PlainAuth("", strings.TrimSpace(string(username)), strings.TrimSpace(string(password)), cfg.SMTP.Host)
+	}
+	return answer, nil
+}
+
+// Format leaves the links of emails as plain text
+func (m *email) Format() Format {
+	return PlainFormat
+}
+
+// Send emails the notification to the comma separated addresses of its channel
+func (m *email) Send(n *Notification) error {
+	var to []string
+	for _, address := range strings.Split(n.Channel, ",") {
+		if address = strings.TrimSpace(address); address != "" {
+			to = append(to, address)
+		}
+	}
+	return m.Mail(to, summary(n.Event), n.Text)
+}
+
+// Mail sends a plain text email
+func (m *email) Mail(to []string, subject, body string) error {
+	if len(to) == 0 {
+		return errors.New("no email address to send to")
+	}
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", m.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&msg, "Subject: [lighthouse] %s\r\n", subject)
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(strings.Replace(body, "\n", "\r\n", -1))
+	msg.WriteString("\r\n")
+	if err := m.sendMail(m.addr, m.auth, m.from, to, []byte(msg.String())); err != nil {
+		return errors.Wrapf(err, "sending email to %s", strings.Join(to, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package notifier
+
+import (
+	"io/ioutil"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sentMail struct {
+	addr    string
+	auth    smtp.Auth
+	from    string
+	to      []string
+	subject string
+	msg     string
+}
+
+func TestEmailSend(t *testing.T) {
+	dir, err := ioutil.TempDir("", "email")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "username"), []byte("bot\n"), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "password"), []byte("secret\n"), 0600))
+
+	mailer, err := NewEmail(Email{
+		SMTP: SMTP{
+			Host:         "smtp.example.com",
+			UsernamePath: filepath.Join(dir, "username"),
+			PasswordPath: filepath.Join(dir, "password"),
+		},
+		From: "lighthouse@example.com",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, PlainFormat, mailer.Format())
+
+	var sent []sentMail
+	mailer.(*email).sendMail = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		sent = append(sent, sentMail{addr: addr, auth: a, from: from, to: to, msg: string(msg)})
+		return nil
+	}
+
+	event := &Event{Kind: JobFailure, Job: "unit", State: v1alpha1.FailureState}
+	require.NoError(t, mailer.Send(&Notification{Channel: "a@example.com, b@example.com", Text: "unit failed\nsee the logs", Event: event}))
+	require.Len(t, sent, 1)
+	assert.Equal(t, "smtp.example.com:587", sent[0].addr)
+	assert.NotNil(t, sent[0].auth)
+	assert.Equal(t, "lighthouse@example.com", sent[0].from)
+	assert.Equal(t, []string{"a@example.com", "b@example.com"}, sent[0].to)
+	assert.Equal(t, "From: lighthouse@example.com\r\n"+
+		"To: a@example.com, b@example.com\r\n"+
+		"Subject: [lighthouse] unit failure\r\n"+
+		"MIME-Version: 1.0\r\n"+
+		"Content-Type: text/plain; charset=UTF-8\r\n"+
+		"\r\n"+
+		"unit failed\r\nsee the logs\r\n", sent[0].msg)
+
+	assert.Error(t, mailer.Send(&Notification{Channel: " , ", Text: "nobody", Event: event}))
+
+	_, err = NewEmail(Email{SMTP: SMTP{Host: "smtp.example.com"}})
+	assert.Error(t, err)
+	_, err = NewEmail(Email{SMTP: SMTP{Host: "smtp.example.com", UsernamePath: filepath.Join(dir, "missing")}, From: "lighthouse@example.com"})
+	assert.Error(t, err)
+}
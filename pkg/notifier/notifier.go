@@ -0,0 +1,341 @@
+// Package notifier sends chat notifications of job and merge events, according to the rules of the
+// notifications section of config.yaml.
+package notifier
+
+import (
+	"strings"
+	"text/template"
+
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/requiredjobs"
+	"github.com/jenkins-x/lighthouse/pkg/tenants"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultFailureTemplate = `{{ emoji "x" }}{{ .Job }} {{ .State }} on {{ template "target" . }}{{ if .Description }}: {{ .Description }}{{ end }}{{ if .LogURL }} ({{ link .LogURL "logs" }}){{ end }}`
+	defaultSuccessTemplate = `{{ emoji "white_check_mark" }}{{ .Job }} succeeded on {{ template "target" . }}{{ if .LogURL }} ({{ link .LogURL "logs" }}){{ end }}`
+	defaultMergeTemplate   = `{{ emoji "twisted_rightwards_arrows" }}{{ template "target" . }}{{ if .Title }} "{{ .Title }}"{{ end }} by {{ .Author }} was merged into {{ .Branch }}`
+	targetTemplate         = `{{ define "target" }}{{ if .Number }}{{ link .Link (printf "%s/%s#%d" .Org .Repo .Number) }}{{ else }}{{ .Org }}/{{ .Repo }}@{{ .Branch }}{{ end }}{{ end }}`
+)
+
+// Format is the markup of the messages of a sink
+type Format string
+
+const (
+	// SlackFormat formats links as <url|text>
+	SlackFormat Format = "slack"
+	// MarkdownFormat formats links as [text](url)
+	MarkdownFormat Format = "markdown"
+	// PlainFormat formats links as text (url) and leaves out emojis
+	PlainFormat Format = "plain"
+)
+
+// Event is a job or merge event, which the templates of the messages are evaluated against
+type Event struct {
+	Kind EventKind `json:"kind"`
+
+	Org    string `json:"org"`
+	Repo   string `json:"repo"`
+	Branch string `json:"branch,omitempty"`
+	SHA    string `json:"sha,omitempty"`
+
+	// Number, Title, Author and Link describe the pull request, if any
+	Number int    `json:"number,omitempty"`
+	Title  string `json:"title,omitempty"`
+	Author string `json:"author,omitempty"`
+	Link   string `json:"link,omitempty"`
+
+	// Job, Type, State, Description and LogURL describe the job of job events
+	Job         string                 `json:"job,omitempty"`
+	Type        config.PipelineKind    `json:"type,omitempty"`
+	State       v1alpha1.PipelineState `json:"state,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	LogURL      string                 `json:"log_url,omitempty"`
+}
+
+// Notification is a message about an event sent to a channel
+type Notification struct {
+	Channel string
+	Text    string
+	Event   *Event
+}
+
+// Sink sends notifications to a chat service
+type Sink interface {
+	// Format is the markup of the text of the notifications
+	Format() Format
+	Send(n *Notification) error
+}
+
+// Notifier sends the events matching the notification rules to their channels
+type Notifier struct {
+	config    func() *Config
+	jobConfig config.Getter
+	newSink   func(cfg *Config, name string) (Sink, error)
+	newMailer func(cfg *Config) (Mailer, error)
+	tenants   func() *tenants.Config
+	logger    *logrus.Entry
+}
+
+// New returns a Notifier sending events according to the current Config. The job configuration tells
+// which contexts are required.
+func New(notifications func() *Config, jobConfig config.Getter, logger *logrus.Entry) *Notifier {
+	if logger == nil {
+		logger = logrus.NewEntry(logrus.StandardLogger())
+	}
+	return &Notifier{
+		config:    notifications,
+		jobConfig: jobConfig,
+		newSink:   newSink,
+		newMailer: newMailer,
+		logger:    logger.WithField("component", "notifier"),
+	}
+}
+
+// ScopeTenants makes the notifier only send the events of the repositories of a tenant to the sinks reserved to
+// the tenant, and never to the sinks reserved to another tenant
+func (n *Notifier) ScopeTenants(config func() *tenants.Config) {
+	n.tenants = config
+}
+
+// reserved returns true if the sink is reserved to another tenant than the one of the repository of the event
+func (n *Notifier) reserved(sink string, e *Event) bool {
+	if n.tenants == nil {
+		return false
+	}
+	cfg := n.tenants()
+	owner := cfg.ForSink(sink)
+	if owner == nil {
+		return false
+	}
+	t := cfg.ForRepo(e.Org, e.Repo)
+	return t == nil || t.Name != owner.Name
+}
+
+// JobChanged notifies the end of a job when its state changes from a running to a final state
+func (n *Notifier) JobChanged(old, job *v1alpha1.LighthouseJob) {
+	if n == nil || old == nil || job == nil || old.Status.State == job.Status.State || final(old.Status.State) {
+		return
+	}
+	var kind EventKind
+	switch job.Status.State {
+	case v1alpha1.SuccessState:
+		kind = JobSuccess
+	case v1alpha1.FailureState, v1alpha1.ErrorState, v1alpha1.AbortedState:
+		kind = JobFailure
+		if n.required(job) {
+			kind = RequiredJobFailure
+		}
+	default:
+		return
+	}
+	n.Notify(jobEvent(kind, job))
+}
+
+// Notify sends the event to the channels of the rules matching it
+func (n *Notifier) Notify(e *Event) {
+	if n == nil {
+		return
+	}
+	cfg := n.config()
+	if cfg == nil {
+		return
+	}
+	sinks := map[string]Sink{}
+	sink := func(name string) (Sink, error) {
+		if s, ok := sinks[name]; ok {
+			return s, nil
+		}
+		s, err := n.newSink(cfg, name)
+		if err == nil {
+			sinks[name] = s
+		}
+		return s, err
+	}
+	for i := range cfg.Rules {
+		rule := &cfg.Rules[i]
+		if !rule.Matches(e) || n.reserved(rule.Sink, e) {
+			continue
+		}
+		log := n.logger.WithFields(logrus.Fields{
+			"event":   e.Kind,
+			"org":     e.Org,
+			"repo":    e.Repo,
+			"sink":    rule.Sink,
+			"channel": rule.Channel,
+		})
+		s, err := sink(rule.Sink)
+		if err != nil {
+			log.WithError(err).Warn("failed to create the notification sink")
+			continue
+		}
+		n.send(s, rule.Channel, rule.Template, e, log)
+	}
+	if address := cfg.Email.Authors[e.Author]; address != "" && (e.Kind == JobFailure || e.Kind == RequiredJobFailure) {
+		log := n.logger.WithFields(logrus.Fields{
+			"event":  e.Kind,
+			"org":    e.Org,
+			"repo":   e.Repo,
+			"author": e.Author,
+		})
+		s, err := sink(EmailSink)
+		if err != nil {
+			log.WithError(err).Warn("failed to create the email sink")
+			return
+		}
+		n.send(s, address, "", e, log)
+	}
+}
+
+func (n *Notifier) send(s Sink, channel, text string, e *Event, log *logrus.Entry) {
+	text, err := Message(s.Format(), text, e)
+	if err != nil {
+		log.WithError(err).Warn("failed to evaluate the notification template")
+		return
+	}
+	if err := s.Send(&Notification{Channel: channel, Text: text, Event: e}); err != nil {
+		log.WithError(err).Warn("failed to send the notification")
+		return
+	}
+	log.Debug("sent notification")
+}
+
+// Message evaluates the template of a rule against the event, using the default template of the kind
+// of event if it is empty. The link and emoji functions of the template format links and emojis in the
+// markup of the sink.
+func Message(format Format, text string, e *Event) (string, error) {
+	if text == "" {
+		switch e.Kind {
+		case JobSuccess:
+			text = defaultSuccessTemplate
+		case Merge:
+			text = defaultMergeTemplate
+		default:
+			text = defaultFailureTemplate
+		}
+	}
+	funcs := template.FuncMap{
+		"link": func(url, text string) string {
+			return link(format, url, text)
+		},
+		"emoji": func(name string) string {
+			if format == PlainFormat {
+				return ""
+			}
+			return ":" + name + ": "
+		},
+	}
+	tmpl, err := template.New("notification").Funcs(funcs).Parse(targetTemplate)
+	if err == nil {
+		tmpl, err = tmpl.Parse(text)
+	}
+	if err != nil {
+		return "", errors.Wrapf(err, "parsing template %q", text)
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, e); err != nil {
+		return "", errors.Wrapf(err, "executing template %q", text)
+	}
+	return b.String(), nil
+}
+
+// required returns true if the job must pass for its pull request or branch to be merged
+func (n *Notifier) required(job *v1alpha1.LighthouseJob) bool {
+	if job.Spec.Type != config.PresubmitJob {
+		return true
+	}
+	if n.jobConfig == nil || job.Spec.Refs == nil {
+		return false
+	}
+	cfg := n.jobConfig()
+	if cfg == nil {
+		return false
+	}
+	return requiredjobs.IsRequired(cfg, job.Spec.Refs.Org, job.Spec.Refs.Repo, job.Spec.Job)
+}
+
+func jobEvent(kind EventKind, job *v1alpha1.LighthouseJob) *Event {
+	e := &Event{
+		Kind:        kind,
+		Job:         job.Spec.Job,
+		Type:        job.Spec.Type,
+		State:       job.Status.State,
+		Description: job.Status.Description,
+		LogURL:      job.Status.ReportURL,
+		SHA:         job.Spec.GetSHA(),
+	}
+	if e.Job == "" {
+		e.Job = job.Spec.Context
+	}
+	if refs := job.Spec.Refs; refs != nil {
+		e.Org = refs.Org
+		e.Repo = refs.Repo
+		e.Branch = refs.BaseRef
+		if len(refs.Pulls) > 0 && job.Spec.Type != config.BatchJob {
+			pr := refs.Pulls[0]
+			e.Number = pr.Number
+			e.Title = pr.Title
+			e.Author = pr.Author
+			e.Link = pr.Link
+		}
+	}
+	return e
+}
+
+// link formats a link to the url in the markup of the format, leaving the text alone if there is no url
+func link(format Format, url, text string) string {
+	switch {
+	case url == "":
+		return text
+	case format == SlackFormat:
+		return "<" + url + "|" + text + ">"
+	case format == PlainFormat:
+		return text + " (" + url + ")"
+	default:
+		return "[" + text + "](" + url + ")"
+	}
+}
+
+// summary is a one line summary of the event, such as the subject of emails
+func summary(e *Event) string {
+	switch e.Kind {
+	case Merge:
+		return e.Org + "/" + e.Repo + " pull request merged"
+	case JobSuccess:
+		return e.Job + " succeeded"
+	default:
+		return e.Job + " " + string(e.State)
+	}
+}
+
+func final(state v1alpha1.PipelineState) bool {
+	switch state {
+	case v1alpha1.SuccessState, v1alpha1.FailureState, v1alpha1.ErrorState, v1alpha1.AbortedState:
+		return true
+	}
+	return false
+}
+
+func newSink(cfg *Config, name string) (Sink, error) {
+	if name == EmailSink {
+		return NewEmail(cfg.Email)
+	}
+	if name != "" {
+		webhook := cfg.Webhook(name)
+		if webhook == nil {
+			return nil, errors.Errorf("no webhook is named %s", name)
+		}
+		return NewWebhook(*webhook)
+	}
+	if cfg.Slack.TokenPath == "" {
+		return nil, errors.New("slack is not configured")
+	}
+	return NewSlack(cfg.Slack)
+}
+
+func newMailer(cfg *Config) (Mailer, error) {
+	return NewEmail(cfg.Email)
+}
@@ -0,0 +1,224 @@
+package notifier
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/tenants"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type message struct {
+	channel, text string
+}
+
+type fakeSink struct {
+	format Format
+	sent   []message
+}
+
+func (s *fakeSink) Format() Format {
+	return s.format
+}
+
+func (s *fakeSink) Send(n *Notification) error {
+	s.sent = append(s.sent, message{channel: n.Channel, text: n.Text})
+	return nil
+}
+
+func testNotifier(rules []Rule) (*Notifier, *fakeSink) {
+	sink := &fakeSink{format: SlackFormat}
+	jobConfig := &config.Config{}
+	jobConfig.Presubmits = map[string][]config.Presubmit{
+		"org/repo": {
+			{JobBase: config.JobBase{Name: "unit"}},
+			{JobBase: config.JobBase{Name: "lint"}, Optional: true},
+		},
+	}
+	n := New(func() *Config { return &Config{Rules: rules} }, func() *config.Config { return jobConfig }, nil)
+	n.newSink = func(*Config, string) (Sink, error) { return sink, nil }
+	return n, sink
+}
+
+func presubmit(name string, state v1alpha1.PipelineState) *v1alpha1.LighthouseJob {
+	return &v1alpha1.LighthouseJob{
+		Spec: v1alpha1.LighthouseJobSpec{
+			Type:    config.PresubmitJob,
+			Job:     name,
+			Context: name,
+			Refs: &v1alpha1.Refs{
+				Org:     "org",
+				Repo:    "repo",
+				BaseRef: "master",
+				Pulls: []v1alpha1.Pull{{
+					Number: 12,
+					Author: "someone",
+					SHA:    "abc",
+					Title:  "Fix it",
+					Link:   "https://github.com/org/repo/pull/12",
+				}},
+			},
+		},
+		Status: v1alpha1.LighthouseJobStatus{
+			State:       state,
+			Description: "Pipeline failed",
+			ReportURL:   "https://dashboard/org/repo/PR-12/1",
+		},
+	}
+}
+
+func TestJobChanged(t *testing.T) {
+	n, sink := testNotifier([]Rule{
+		{Channel: "#required", Events: []EventKind{RequiredJobFailure}},
+		{Channel: "#all", Events: []EventKind{JobFailure, JobSuccess}},
+	})
+
+	n.JobChanged(presubmit("unit", v1alpha1.RunningState), presubmit("unit", v1alpha1.FailureState))
+	require.Len(t, sink.sent, 2)
+	assert.Equal(t, "#required", sink.sent[0].channel)
+	assert.Equal(t, "#all", sink.sent[1].channel)
+	assert.Equal(t, ":x: unit failure on <https://github.com/org/repo/pull/12|org/repo#12>: Pipeline failed (<https://dashboard/org/repo/PR-12/1|logs>)", sink.sent[0].text)
+
+	sink.sent = nil
+	n.JobChanged(presubmit("lint", v1alpha1.RunningState), presubmit("lint", v1alpha1.ErrorState))
+	require.Len(t, sink.sent, 1)
+	assert.Equal(t, "#all", sink.sent[0].channel)
+
+	sink.sent = nil
+	n.JobChanged(presubmit("unit", v1alpha1.PendingState), presubmit("unit", v1alpha1.SuccessState))
+	require.Len(t, sink.sent, 1)
+	assert.Equal(t, ":white_check_mark: unit succeeded on <https://github.com/org/repo/pull/12|org/repo#12> (<https://dashboard/org/repo/PR-12/1|logs>)", sink.sent[0].text)
+
+	// only transitions to a final state are notified
+	sink.sent = nil
+	n.JobChanged(presubmit("unit", v1alpha1.PendingState), presubmit("unit", v1alpha1.RunningState))
+	n.JobChanged(presubmit("unit", v1alpha1.FailureState), presubmit("unit", v1alpha1.FailureState))
+	n.JobChanged(presubmit("unit", v1alpha1.FailureState), presubmit("unit", v1alpha1.SuccessState))
+	assert.Empty(t, sink.sent)
+}
+
+func TestJobChangedEmailsAuthors(t *testing.T) {
+	sink := &fakeSink{format: PlainFormat}
+	n := New(func() *Config {
+		return &Config{Email: Email{Authors: map[string]string{"someone": "someone@example.com"}}}
+	}, nil, nil)
+	n.newSink = func(cfg *Config, name string) (Sink, error) {
+		assert.Equal(t, EmailSink, name)
+		return sink, nil
+	}
+
+	n.JobChanged(presubmit("unit", v1alpha1.RunningState), presubmit("unit", v1alpha1.FailureState))
+	assert.Equal(t, []message{
+		{channel: "someone@example.com", text: "unit failure on org/repo#12 (https://github.com/org/repo/pull/12): Pipeline failed (logs (https://dashboard/org/repo/PR-12/1))"},
+	}, sink.sent)
+
+	// authors are only emailed about failures, if they opted in
+	sink.sent = nil
+	n.JobChanged(presubmit("unit", v1alpha1.RunningState), presubmit("unit", v1alpha1.SuccessState))
+	other := presubmit("unit", v1alpha1.FailureState)
+	other.Spec.Refs.Pulls[0].Author = "other"
+	n.JobChanged(presubmit("unit", v1alpha1.RunningState), other)
+	assert.Empty(t, sink.sent)
+}
+
+func TestJobChangedPostsubmitFailuresAreRequired(t *testing.T) {
+	n, sink := testNotifier([]Rule{{Channel: "#required", Events: []EventKind{RequiredJobFailure}}})
+
+	old, job := presubmit("release", v1alpha1.RunningState), presubmit("release", v1alpha1.FailureState)
+	old.Spec.Type, job.Spec.Type = config.PostsubmitJob, config.PostsubmitJob
+	n.JobChanged(old, job)
+	require.Len(t, sink.sent, 1)
+	assert.Contains(t, sink.sent[0].text, "release failure on <https://github.com/org/repo/pull/12|org/repo#12>")
+}
+
+func TestNotifyMerge(t *testing.T) {
+	n, sink := testNotifier([]Rule{
+		{Repos: []string{"org/repo"}, Channel: "#merges", Events: []EventKind{Merge}},
+		{Repos: []string{"org"}, Channel: "#custom", Events: []EventKind{Merge}, Template: "{{ .Number }} merged by keeper"},
+		{Repos: []string{"other"}, Channel: "#other", Events: []EventKind{Merge}},
+	})
+
+	n.Notify(&Event{
+		Kind:   Merge,
+		Org:    "org",
+		Repo:   "repo",
+		Branch: "master",
+		Number: 12,
+		Title:  "Fix it",
+		Author: "someone",
+		Link:   "https://github.com/org/repo/pull/12",
+	})
+	assert.Equal(t, []message{
+		{channel: "#merges", text: `:twisted_rightwards_arrows: <https://github.com/org/repo/pull/12|org/repo#12> "Fix it" by someone was merged into master`},
+		{channel: "#custom", text: "12 merged by keeper"},
+	}, sink.sent)
+}
+
+func TestNotifySinks(t *testing.T) {
+	slack, team := &fakeSink{format: SlackFormat}, &fakeSink{format: MarkdownFormat}
+	n := New(func() *Config {
+		return &Config{Rules: []Rule{
+			{Channel: "#merges", Events: []EventKind{Merge}},
+			{Sink: "team", Events: []EventKind{Merge}},
+			{Sink: "broken", Events: []EventKind{Merge}},
+		}}
+	}, nil, nil)
+	n.newSink = func(cfg *Config, name string) (Sink, error) {
+		switch name {
+		case "":
+			return slack, nil
+		case "team":
+			return team, nil
+		}
+		return nil, fmt.Errorf("no webhook is named %s", name)
+	}
+
+	n.Notify(&Event{Kind: Merge, Org: "org", Repo: "repo", Branch: "master", Number: 12, Author: "someone", Link: "https://github.com/org/repo/pull/12"})
+	assert.Equal(t, []message{
+		{channel: "#merges", text: ":twisted_rightwards_arrows: <https://github.com/org/repo/pull/12|org/repo#12> by someone was merged into master"},
+	}, slack.sent)
+	assert.Equal(t, []message{
+		{text: ":twisted_rightwards_arrows: [org/repo#12](https://github.com/org/repo/pull/12) by someone was merged into master"},
+	}, team.sent)
+}
+
+func TestMessage(t *testing.T) {
+	text, err := Message(SlackFormat, "", &Event{Kind: JobFailure, Org: "org", Repo: "repo", Branch: "master", Job: "nightly", State: v1alpha1.ErrorState})
+	require.NoError(t, err)
+	assert.Equal(t, ":x: nightly error on org/repo@master", text)
+
+	text, err = Message(MarkdownFormat, `{{ link .LogURL "logs" }} {{ link "" "no link" }}`, &Event{LogURL: "https://dashboard/1"})
+	require.NoError(t, err)
+	assert.Equal(t, "[logs](https://dashboard/1) no link", text)
+
+	_, err = Message(SlackFormat, "{{ .Missing }}", &Event{})
+	assert.Error(t, err)
+}
+
+func TestNotifyTenantSinks(t *testing.T) {
+	slack, team := &fakeSink{format: SlackFormat}, &fakeSink{format: MarkdownFormat}
+	n := New(func() *Config {
+		return &Config{Rules: []Rule{
+			{Channel: "#merges", Events: []EventKind{Merge}},
+			{Sink: "team", Events: []EventKind{Merge}},
+		}}
+	}, nil, nil)
+	n.newSink = func(cfg *Config, name string) (Sink, error) {
+		if name == "team" {
+			return team, nil
+		}
+		return slack, nil
+	}
+	cfg, err := tenants.LoadConfig([]byte("tenants:\n- name: team\n  namespace: team\n  orgs: [team]\n  notification_sinks: [team]\n"))
+	require.NoError(t, err)
+	n.ScopeTenants(func() *tenants.Config { return cfg })
+
+	n.Notify(&Event{Kind: Merge, Org: "org", Repo: "repo", Branch: "master"})
+	n.Notify(&Event{Kind: Merge, Org: "team", Repo: "repo", Branch: "master"})
+	assert.Len(t, slack.sent, 2)
+	require.Len(t, team.sent, 1, "the sinks of a tenant only get the events of its repositories")
+	assert.Contains(t, team.sent[0].text, "team/repo@master")
+}
@@ -0,0 +1,80 @@
+package notifier
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const defaultSlackURL = "https://slack.com/api"
+
+// slack posts messages with the chat.postMessage method of the Slack API
+type slack struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+// NewSlack returns a Sink posting messages to Slack with the token of the configuration
+func NewSlack(cfg Slack) (Sink, error) {
+	data, err := ioutil.ReadFile(cfg.TokenPath) // #nosec
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading the Slack token %s", cfg.TokenPath)
+	}
+	url := cfg.URL
+	if url == "" {
+		url = defaultSlackURL
+	}
+	return &slack{
+		url:    strings.TrimSuffix(url, "/"),
+		token:  strings.TrimSpace(string(data)),
+		client: &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// Format formats the links of the messages as <url|text>
+func (s *slack) Format() Format {
+	return SlackFormat
+}
+
+// Send posts the text of the notification to its channel
+func (s *slack) Send(n *Notification) error {
+	body, err := json.Marshal(map[string]string{
+		"channel": n.Channel,
+		"text":    n.Text,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, s.url+"/chat.postMessage", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Authorization", "Bearer "+s.token)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "posting the Slack message")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("posting the Slack message returned status %d", resp.StatusCode)
+	}
+	// the Slack API reports errors in the body of successful responses
+	answer := struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&answer); err != nil {
+		return errors.Wrap(err, "decoding the Slack response")
+	}
+	if !answer.OK {
+		return errors.Errorf("posting the Slack message to %s failed: %s", n.Channel, answer.Error)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package notifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlackSend(t *testing.T) {
+	var posted map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/chat.postMessage", r.URL.Path)
+		assert.Equal(t, "Bearer xoxb-token", r.Header.Get("Authorization"))
+		posted = map[string]string{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&posted))
+		if posted["channel"] == "#missing" {
+			fmt.Fprint(w, `{"ok": false, "error": "channel_not_found"}`)
+			return
+		}
+		fmt.Fprint(w, `{"ok": true}`)
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "slack")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	tokenPath := filepath.Join(dir, "token")
+	require.NoError(t, ioutil.WriteFile(tokenPath, []byte("xoxb-token\n"), 0600))
+
+	sink, err := NewSlack(Slack{TokenPath: tokenPath, URL: server.URL + "/"})
+	require.NoError(t, err)
+
+	require.NoError(t, sink.Send(&Notification{Channel: "#ci", Text: "hello"}))
+	assert.Equal(t, map[string]string{"channel": "#ci", "text": "hello"}, posted)
+
+	err = sink.Send(&Notification{Channel: "#missing", Text: "hello"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "channel_not_found")
+
+	_, err = NewSlack(Slack{TokenPath: filepath.Join(dir, "missing")})
+	assert.Error(t, err)
+}
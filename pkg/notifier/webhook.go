@@ -0,0 +1,107 @@
+package notifier
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// discordMaxLength is the maximum length of the content of a Discord message
+const discordMaxLength = 2000
+
+// webhook posts notifications to an outgoing webhook
+type webhook struct {
+	name   string
+	kind   WebhookKind
+	url    string
+	client *http.Client
+}
+
+// NewWebhook returns a Sink posting notifications to the URL of the webhook, in the payload of its kind
+func NewWebhook(cfg Webhook) (Sink, error) {
+	data, err := ioutil.ReadFile(cfg.URLPath) // #nosec
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading the URL of webhook %s", cfg.Name)
+	}
+	kind := cfg.Kind
+	if kind == "" {
+		kind = JSONWebhook
+	}
+	return &webhook{
+		name:   cfg.Name,
+		kind:   kind,
+		url:    strings.TrimSpace(string(data)),
+		client: &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// Format formats the links of the messages in markdown, which Teams and Discord render
+func (w *webhook) Format() Format {
+	return MarkdownFormat
+}
+
+// Send posts the notification
+func (w *webhook) Send(n *Notification) error {
+	body, err := json.Marshal(w.payload(n))
+	if err != nil {
+		return err
+	}
+	resp, err := w.client.Post(w.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		// the error includes the URL, which holds the credentials of the webhook
+		return errors.Errorf("posting to webhook %s failed", w.name)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.Errorf("posting to webhook %s returned status %d", w.name, resp.StatusCode)
+	}
+	return nil
+}
+
+func (w *webhook) payload(n *Notification) interface{} {
+	switch w.kind {
+	case TeamsWebhook:
+		return map[string]string{
+			"@type":      "MessageCard",
+			"@context":   "https://schema.org/extensions",
+			"summary":    summary(n.Event),
+			"themeColor": themeColor(n.Event),
+			"text":       n.Text,
+		}
+	case DiscordWebhook:
+		content := n.Text
+		if len(content) > discordMaxLength {
+			content = content[:discordMaxLength-3] + "..."
+		}
+		return map[string]string{
+			"content": content,
+		}
+	default:
+		return struct {
+			Channel string `json:"channel,omitempty"`
+			Text    string `json:"text"`
+			Event   *Event `json:"event"`
+		}{
+			Channel: n.Channel,
+			Text:    n.Text,
+			Event:   n.Event,
+		}
+	}
+}
+
+// themeColor is the color of the border of Teams cards
+func themeColor(e *Event) string {
+	switch e.Kind {
+	case Merge:
+		return "6F42C1"
+	case JobSuccess:
+		return "2CBE4E"
+	default:
+		return "CB2431"
+	}
+}
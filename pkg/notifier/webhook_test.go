@@ -0,0 +1,95 @@
+package notifier
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookSend(t *testing.T) {
+	var posted map[string]interface{}
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/hooks/secret", r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		posted = map[string]interface{}{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&posted))
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "webhook")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	urlPath := filepath.Join(dir, "url")
+	require.NoError(t, ioutil.WriteFile(urlPath, []byte(server.URL+"/hooks/secret\n"), 0600))
+
+	event := &Event{Kind: JobFailure, Org: "org", Repo: "repo", Number: 12, Job: "unit", State: v1alpha1.FailureState}
+	notification := &Notification{Channel: "#ci", Text: "unit failure", Event: event}
+
+	tests := []struct {
+		kind WebhookKind
+		want map[string]interface{}
+	}{
+		{
+			kind: "",
+			want: map[string]interface{}{
+				"channel": "#ci",
+				"text":    "unit failure",
+				"event": map[string]interface{}{
+					"kind":   "failure",
+					"org":    "org",
+					"repo":   "repo",
+					"number": float64(12),
+					"job":    "unit",
+					"state":  "failure",
+				},
+			},
+		},
+		{
+			kind: TeamsWebhook,
+			want: map[string]interface{}{
+				"@type":      "MessageCard",
+				"@context":   "https://schema.org/extensions",
+				"summary":    "unit failure",
+				"themeColor": "CB2431",
+				"text":       "unit failure",
+			},
+		},
+		{
+			kind: DiscordWebhook,
+			want: map[string]interface{}{
+				"content": "unit failure",
+			},
+		},
+	}
+	for _, tc := range tests {
+		sink, err := NewWebhook(Webhook{Name: "test", Kind: tc.kind, URLPath: urlPath})
+		require.NoError(t, err)
+		assert.Equal(t, MarkdownFormat, sink.Format())
+		require.NoError(t, sink.Send(notification), string(tc.kind))
+		assert.Equal(t, tc.want, posted, string(tc.kind))
+	}
+
+	sink, err := NewWebhook(Webhook{Name: "test", Kind: DiscordWebhook, URLPath: urlPath})
+	require.NoError(t, err)
+	require.NoError(t, sink.Send(&Notification{Text: strings.Repeat("x", 3000), Event: event}))
+	assert.Len(t, posted["content"], discordMaxLength)
+
+	status = http.StatusNotFound
+	err = sink.Send(notification)
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "secret")
+
+	_, err = NewWebhook(Webhook{Name: "test", URLPath: filepath.Join(dir, "missing")})
+	assert.Error(t, err)
+}
@@ -0,0 +1,144 @@
+package onboard
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/cmd/helper"
+	"github.com/jenkins-x/lighthouse/pkg/configinclude"
+	"github.com/jenkins-x/lighthouse/pkg/gitprovider"
+	"github.com/jenkins-x/lighthouse/pkg/hooks"
+	"github.com/jenkins-x/lighthouse/pkg/labelsync"
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// CmdOptions are the options of the onboard command
+type CmdOptions struct {
+	ConfigFile    string
+	PluginFile    string
+	LabelConfig   string
+	HookURL       string
+	Provider      string
+	StatusContext string
+	HelpURL       string
+	DryRun        bool
+}
+
+// NewCmdOnboard creates the command onboarding a repository
+func NewCmdOnboard() *cobra.Command {
+	options := CmdOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "onboard org/repo",
+		Short: "Onboards a repository: registers its webhook, creates its labels and reports its plugins",
+		Long:  "Checks that the config.yaml or plugins.yaml reference the repository, registers its webhook, synchronizes its labels with the label set and reports the plugins enabled for it as a status of its default branch. It uses the SCM credentials of the provider from the same environment variables as the webhooks.",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := options.Run(cmd, args[0])
+			helper.CheckErr(err)
+		},
+	}
+
+	cmd.Flags().StringVar(&options.ConfigFile, "config-file", "", "Path to the config.yaml file.")
+	cmd.Flags().StringVar(&options.PluginFile, "plugin-file", "", "Path to the plugins.yaml file.")
+	cmd.Flags().StringVar(&options.LabelConfig, "label-config", "", "Path to the labels.yaml file declaring the labels the repository should have. No label is created if not given.")
+	cmd.Flags().StringVar(&options.HookURL, "hook-url", "", "The public URL of the hook endpoint the webhook should point at.")
+	cmd.Flags().StringVar(&options.Provider, "provider", "", "The name of the provider hosting the repository, as listed in $"+gitprovider.ProvidersEnv+". Defaults to the provider hosting its org.")
+	cmd.Flags().StringVar(&options.StatusContext, "status-context", DefaultStatusContext, "The context of the status listing the plugins enabled for the repository.")
+	cmd.Flags().StringVar(&options.HelpURL, "help-url", "", "The URL of the plugin help page of the webhooks, e.g. https://lighthouse.example.com/plugin-help, the status links to.")
+	cmd.Flags().BoolVar(&options.DryRun, "dry-run", false, "Only check the configuration and report what would change.")
+
+	return cmd
+}
+
+// Run onboards the repository
+func (o *CmdOptions) Run(cmd *cobra.Command, fullName string) error {
+	if o.ConfigFile == "" || o.PluginFile == "" || o.HookURL == "" {
+		return errors.New("--config-file, --plugin-file and --hook-url are required")
+	}
+	cfg, err := loadConfig(o.ConfigFile)
+	if err != nil {
+		return err
+	}
+	data, err := ioutil.ReadFile(o.PluginFile)
+	if err != nil {
+		return errors.Wrapf(err, "reading %s", o.PluginFile)
+	}
+	pluginCfg, err := (&plugins.ConfigAgent{}).LoadYAMLConfig(data)
+	if err != nil {
+		return errors.Wrapf(err, "loading %s", o.PluginFile)
+	}
+	options := Options{
+		StatusContext: o.StatusContext,
+		HelpURL:       o.HelpURL,
+		DryRun:        o.DryRun,
+	}
+	if o.LabelConfig != "" {
+		options.Labels, err = labelsync.Load(o.LabelConfig)
+		if err != nil {
+			return err
+		}
+	}
+
+	provider, err := o.provider(fullName)
+	if err != nil {
+		return err
+	}
+	options.Hook = hooks.ProviderOptions(provider, o.HookURL)
+	options.Secret = hooks.ProviderSecret(provider)
+	token, err := provider.Token()
+	if err != nil {
+		return err
+	}
+	scmClient, err := provider.NewClient(token)
+	if err != nil {
+		return errors.Wrapf(err, "creating the client of provider %s", provider)
+	}
+	clients := Clients{
+		Hooks: scmClient.Repositories,
+		SCM:   scmprovider.ToClient(scmClient, provider.BotName()),
+	}
+
+	report, err := Onboard(cfg, pluginCfg, clients, options, fullName, nil)
+	for _, step := range report.Steps {
+		if step.Error != "" {
+			fmt.Fprintf(cmd.ErrOrStderr(), "%s: %s\n", step.Name, step.Error)
+		} else {
+			fmt.Fprintf(cmd.OutOrStdout(), "%s: %s\n", step.Name, step.Message)
+		}
+	}
+	return err
+}
+
+func (o *CmdOptions) provider(fullName string) (*gitprovider.Provider, error) {
+	if o.Provider != "" {
+		return gitprovider.Named(o.Provider)
+	}
+	org := fullName
+	if i := strings.LastIndex(fullName, "/"); i > 0 {
+		org = fullName[:i]
+	}
+	return gitprovider.ForOrg(org)
+}
+
+func loadConfig(path string) (*config.Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading %s", path)
+	}
+	data, err = configinclude.Resolve(data, filepath.Dir(path))
+	if err != nil {
+		return nil, errors.Wrapf(err, "resolving the includes of %s", path)
+	}
+	cfg, err := config.LoadYAMLConfig(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "loading %s", path)
+	}
+	return cfg, nil
+}
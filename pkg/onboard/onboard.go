@@ -0,0 +1,250 @@
+// Package onboard onboards a repository in one shot: it checks that the configuration references the repository,
+// registers its webhook, creates the labels of the label set and reports the plugins enabled for it as a status of
+// its default branch.
+package onboard
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/hooks"
+	"github.com/jenkins-x/lighthouse/pkg/labelsync"
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// Path is the URL path of the onboarding endpoint on the admin port, as POST /onboard?repo={org}/{repo}
+	Path = "/onboard"
+
+	// DefaultStatusContext is the context of the status summarizing the plugins enabled for the repository
+	DefaultStatusContext = "lighthouse/onboarding"
+
+	// maxDescriptionLength is the longest status description GitHub accepts
+	maxDescriptionLength = 140
+)
+
+// The steps of the onboarding
+const (
+	ConfigStep  = "config"
+	WebhookStep = "webhook"
+	LabelsStep  = "labels"
+	StatusStep  = "status"
+)
+
+// Client is the subset of the SCM API used to create the labels and report the summary of the repository
+type Client interface {
+	labelsync.Client
+	GetRepositoryByFullName(fullName string) (*scm.Repository, error)
+	GetRef(owner, repo, ref string) (string, error)
+	CreateStatus(owner, repo, ref string, s *scm.StatusInput) (*scm.Status, error)
+}
+
+// Clients are the clients of the provider hosting the repository
+type Clients struct {
+	// Hooks registers the webhook
+	Hooks hooks.RepositoryClient
+	// SCM creates the labels and reports the summary
+	SCM Client
+}
+
+// Options describe how repositories are onboarded
+type Options struct {
+	// Hook describes the webhook to register
+	Hook hooks.Options
+	// Secret returns the secret the webhook is signed with
+	Secret func() (string, error)
+	// Labels is the label set of the repositories, no label is created if nil
+	Labels *labelsync.Configuration
+	// StatusContext is the context of the summary status, defaulting to DefaultStatusContext
+	StatusContext string
+	// HelpURL is the URL of the plugin help page the summary status links to, without the repo parameter
+	HelpURL string
+	// DryRun only checks the configuration and reports the webhook drift without changing the repository
+	DryRun bool
+}
+
+// Step is the outcome of an onboarding step
+type Step struct {
+	Name    string `json:"name"`
+	Message string `json:"message"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Report lists the outcome of the onboarding steps, up to the first which failed
+type Report struct {
+	Repo  string `json:"repo"`
+	Steps []Step `json:"steps"`
+}
+
+func (r *Report) add(name, message string, err error) {
+	step := Step{Name: name, Message: message}
+	if err != nil {
+		step.Error = err.Error()
+	}
+	r.Steps = append(r.Steps, step)
+}
+
+// Onboard onboards the org/repo repository, stopping at the first step which fails
+func Onboard(cfg *config.Config, pluginCfg *plugins.Configuration, clients Clients, options Options, fullName string, logger *logrus.Entry) (*Report, error) {
+	if logger == nil {
+		logger = logrus.NewEntry(logrus.StandardLogger())
+	}
+	report := &Report{Repo: fullName}
+	i := strings.LastIndex(fullName, "/")
+	if i <= 0 || i == len(fullName)-1 {
+		return report, errors.Errorf("invalid repository %q, expected org/repo", fullName)
+	}
+	org, repo := fullName[:i], fullName[i+1:]
+	l := logger.WithField("repo", fullName)
+
+	summary, err := CheckConfig(cfg, pluginCfg, org, repo)
+	report.add(ConfigStep, summary, err)
+	if err != nil {
+		return report, err
+	}
+
+	message, err := registerWebhook(clients.Hooks, options, fullName, l)
+	report.add(WebhookStep, message, err)
+	if err != nil {
+		return report, err
+	}
+
+	message, err = createLabels(clients.SCM, options, org, repo, l)
+	report.add(LabelsStep, message, err)
+	if err != nil {
+		return report, err
+	}
+
+	message, err = reportPlugins(clients.SCM, pluginCfg, options, org, repo)
+	report.add(StatusStep, message, err)
+	if err != nil {
+		return report, err
+	}
+	l.Info("onboarded the repository")
+	return report, nil
+}
+
+// CheckConfig returns a summary of the jobs and plugins of the repository, or an error if the configuration does
+// not reference the repository or its org, in which case the webhooks of the repository would not be handled
+func CheckConfig(cfg *config.Config, pluginCfg *plugins.Configuration, org, repo string) (string, error) {
+	if cfg == nil || pluginCfg == nil {
+		return "", errors.New("the configuration is not loaded")
+	}
+	fullName := org + "/" + repo
+	orgs, repos := hooks.ConfiguredRepositories(cfg, pluginCfg)
+	if !contains(orgs, org) && !contains(repos, fullName) {
+		return "", errors.Errorf("neither %s nor %s is referenced by the jobs, keeper queries or plugins of the configuration", fullName, org)
+	}
+	pluginNames := enabledPlugins(pluginCfg, org, repo)
+	return fmt.Sprintf("%d presubmits, %d postsubmits and %d plugins are configured", len(cfg.Presubmits[fullName]), len(cfg.Postsubmits[fullName]), len(pluginNames)), nil
+}
+
+func registerWebhook(client hooks.RepositoryClient, options Options, fullName string, logger *logrus.Entry) (string, error) {
+	hookOptions := options.Hook
+	hookOptions.Prune = false
+	hookOptions.DryRun = options.DryRun
+	clients := func(string) (hooks.RepositoryClient, error) {
+		return client, nil
+	}
+	drifts, err := hooks.NewReconciler(clients, options.Secret, hookOptions, logger).Reconcile(nil, []string{fullName})
+	if err != nil {
+		return "", err
+	}
+	if len(drifts) == 0 {
+		return "the webhook is already registered", nil
+	}
+	var kinds []string
+	for _, drift := range drifts {
+		kinds = append(kinds, drift.Kind)
+	}
+	if options.DryRun {
+		return fmt.Sprintf("the webhook would be registered, found %s webhooks", strings.Join(kinds, ", ")), nil
+	}
+	return fmt.Sprintf("registered the webhook, fixing %s webhooks", strings.Join(kinds, ", ")), nil
+}
+
+func createLabels(client Client, options Options, org, repo string, logger *logrus.Entry) (string, error) {
+	if options.Labels == nil {
+		return "no label set is configured", nil
+	}
+	labels := options.Labels.LabelsFor(org, repo)
+	if len(labels) == 0 {
+		return "the label set has no label for the repository", nil
+	}
+	if options.DryRun {
+		return fmt.Sprintf("%d labels would be synchronized", len(labels)), nil
+	}
+	if err := labelsync.Sync(client, org, repo, labels, logger); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("synchronized %d labels", len(labels)), nil
+}
+
+// reportPlugins reports the plugins enabled for the repository as a successful status of the head of its default
+// branch
+func reportPlugins(client Client, pluginCfg *plugins.Configuration, options Options, org, repo string) (string, error) {
+	fullName := org + "/" + repo
+	description := Summary(enabledPlugins(pluginCfg, org, repo))
+	if options.DryRun {
+		return description, nil
+	}
+	repository, err := client.GetRepositoryByFullName(fullName)
+	if err != nil {
+		return "", errors.Wrap(err, "getting the repository")
+	}
+	sha, err := client.GetRef(org, repo, "heads/"+repository.Branch)
+	if err != nil {
+		return "", errors.Wrapf(err, "getting the head of %s", repository.Branch)
+	}
+	status := &scm.StatusInput{
+		State: scm.StateSuccess,
+		Label: options.StatusContext,
+		Desc:  description,
+	}
+	if status.Label == "" {
+		status.Label = DefaultStatusContext
+	}
+	if options.HelpURL != "" {
+		status.Target = options.HelpURL + "?repo=" + fullName
+	}
+	if _, err := client.CreateStatus(org, repo, sha, status); err != nil {
+		return "", errors.Wrap(err, "creating the status")
+	}
+	return description, nil
+}
+
+// Summary returns the description of the status listing the plugins, truncated to the length GitHub accepts
+func Summary(pluginNames []string) string {
+	if len(pluginNames) == 0 {
+		return "Onboarded without any plugin"
+	}
+	answer := fmt.Sprintf("Onboarded with %d plugins: %s", len(pluginNames), strings.Join(pluginNames, ", "))
+	if len(answer) > maxDescriptionLength {
+		answer = answer[:maxDescriptionLength-3] + "..."
+	}
+	return answer
+}
+
+// enabledPlugins returns the sorted names of the plugins and external plugins enabled for the repository
+func enabledPlugins(pluginCfg *plugins.Configuration, org, repo string) []string {
+	answer := append([]string{}, pluginCfg.PluginsFor(org, repo)...)
+	for _, plugin := range pluginCfg.ExternalPluginsFor(org, repo) {
+		answer = append(answer, plugin.Name)
+	}
+	sort.Strings(answer)
+	return answer
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
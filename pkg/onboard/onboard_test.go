@@ -0,0 +1,192 @@
+package onboard
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/hooks"
+	"github.com/jenkins-x/lighthouse/pkg/labelsync"
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const target = "https://hook.example.com/hook"
+
+type fakeRepositories struct {
+	hooks   []*scm.Hook
+	created []*scm.HookInput
+}
+
+func (f *fakeRepositories) ListOrganisation(context.Context, string, scm.ListOptions) ([]*scm.Repository, *scm.Response, error) {
+	return nil, nil, fmt.Errorf("not listed")
+}
+
+func (f *fakeRepositories) ListHooks(context.Context, string, scm.ListOptions) ([]*scm.Hook, *scm.Response, error) {
+	return f.hooks, nil, nil
+}
+
+func (f *fakeRepositories) CreateHook(_ context.Context, _ string, input *scm.HookInput) (*scm.Hook, *scm.Response, error) {
+	f.created = append(f.created, input)
+	hook := &scm.Hook{ID: "1", Target: input.Target, Active: true}
+	f.hooks = append(f.hooks, hook)
+	return hook, nil, nil
+}
+
+func (f *fakeRepositories) DeleteHook(context.Context, string, string) (*scm.Response, error) {
+	return nil, nil
+}
+
+type fakeClient struct {
+	labels   []*scm.Label
+	statuses map[string][]*scm.StatusInput
+}
+
+func (f *fakeClient) GetRepoLabels(string, string) ([]*scm.Label, error) {
+	return f.labels, nil
+}
+
+func (f *fakeClient) CreateRepoLabel(_, _ string, label scm.Label) error {
+	f.labels = append(f.labels, &label)
+	return nil
+}
+
+func (f *fakeClient) UpdateRepoLabel(string, string, string, scm.Label) error {
+	return nil
+}
+
+func (f *fakeClient) DeleteRepoLabel(string, string, string) error {
+	return nil
+}
+
+func (f *fakeClient) Search(scm.SearchOptions) ([]*scm.SearchIssue, *scmprovider.RateLimits, error) {
+	return nil, nil, nil
+}
+
+func (f *fakeClient) AddLabel(string, string, int, string, bool) error {
+	return nil
+}
+
+func (f *fakeClient) RemoveLabel(string, string, int, string, bool) error {
+	return nil
+}
+
+func (f *fakeClient) GetRepositoryByFullName(string) (*scm.Repository, error) {
+	return &scm.Repository{Branch: "main"}, nil
+}
+
+func (f *fakeClient) GetRef(_, _, ref string) (string, error) {
+	return "sha-of-" + ref, nil
+}
+
+func (f *fakeClient) CreateStatus(_, _, ref string, s *scm.StatusInput) (*scm.Status, error) {
+	f.statuses[ref] = append(f.statuses[ref], s)
+	return &scm.Status{}, nil
+}
+
+func newConfigs() (*config.Config, *plugins.Configuration) {
+	cfg := &config.Config{}
+	cfg.Presubmits = map[string][]config.Presubmit{"org/repo": {{}, {}}}
+	pluginCfg := &plugins.Configuration{
+		Plugins: map[string][]string{
+			"org":      {"lgtm", "approve"},
+			"org/repo": {"trigger"},
+		},
+	}
+	return cfg, pluginCfg
+}
+
+func newOptions() Options {
+	return Options{
+		Hook: hooks.Options{Target: target, Events: hooks.AllEvents},
+		Secret: func() (string, error) {
+			return "secret", nil
+		},
+		Labels:  &labelsync.Configuration{Default: []labelsync.Label{{Name: "lgtm", Color: "0ffa16"}}},
+		HelpURL: "https://hook.example.com/plugin-help",
+	}
+}
+
+func TestOnboard(t *testing.T) {
+	cfg, pluginCfg := newConfigs()
+	repositories := &fakeRepositories{}
+	client := &fakeClient{statuses: map[string][]*scm.StatusInput{}}
+
+	report, err := Onboard(cfg, pluginCfg, Clients{Hooks: repositories, SCM: client}, newOptions(), "org/repo", nil)
+	require.NoError(t, err)
+
+	var names []string
+	for _, step := range report.Steps {
+		names = append(names, step.Name)
+		assert.Empty(t, step.Error)
+	}
+	assert.Equal(t, []string{ConfigStep, WebhookStep, LabelsStep, StatusStep}, names)
+	assert.Equal(t, "2 presubmits, 0 postsubmits and 3 plugins are configured", report.Steps[0].Message)
+
+	require.Len(t, repositories.created, 1)
+	assert.Equal(t, target, repositories.created[0].Target)
+	assert.Equal(t, "secret", repositories.created[0].Secret)
+
+	require.Len(t, client.labels, 1)
+	assert.Equal(t, "lgtm", client.labels[0].Name)
+
+	statuses := client.statuses["sha-of-heads/main"]
+	require.Len(t, statuses, 1)
+	assert.Equal(t, DefaultStatusContext, statuses[0].Label)
+	assert.Equal(t, scm.StateSuccess, statuses[0].State)
+	assert.Equal(t, "Onboarded with 3 plugins: approve, lgtm, trigger", statuses[0].Desc)
+	assert.Equal(t, "https://hook.example.com/plugin-help?repo=org/repo", statuses[0].Target)
+
+	// onboarding again leaves the webhook alone
+	report, err = Onboard(cfg, pluginCfg, Clients{Hooks: repositories, SCM: client}, newOptions(), "org/repo", nil)
+	require.NoError(t, err)
+	assert.Len(t, repositories.created, 1)
+	assert.Equal(t, "the webhook is already registered", report.Steps[1].Message)
+}
+
+func TestOnboardDryRun(t *testing.T) {
+	cfg, pluginCfg := newConfigs()
+	repositories := &fakeRepositories{}
+	client := &fakeClient{statuses: map[string][]*scm.StatusInput{}}
+	options := newOptions()
+	options.DryRun = true
+
+	report, err := Onboard(cfg, pluginCfg, Clients{Hooks: repositories, SCM: client}, options, "org/repo", nil)
+	require.NoError(t, err)
+	assert.Len(t, report.Steps, 4)
+	assert.Empty(t, repositories.created)
+	assert.Empty(t, client.labels)
+	assert.Empty(t, client.statuses)
+}
+
+func TestOnboardUnconfiguredRepository(t *testing.T) {
+	cfg, pluginCfg := newConfigs()
+	repositories := &fakeRepositories{}
+	client := &fakeClient{statuses: map[string][]*scm.StatusInput{}}
+
+	report, err := Onboard(cfg, pluginCfg, Clients{Hooks: repositories, SCM: client}, newOptions(), "other/repo", nil)
+	require.Error(t, err)
+	require.Len(t, report.Steps, 1)
+	assert.Equal(t, ConfigStep, report.Steps[0].Name)
+	assert.NotEmpty(t, report.Steps[0].Error)
+	assert.Empty(t, repositories.created)
+
+	_, err = Onboard(cfg, pluginCfg, Clients{Hooks: repositories, SCM: client}, newOptions(), "repo", nil)
+	assert.Error(t, err)
+}
+
+func TestSummary(t *testing.T) {
+	assert.Equal(t, "Onboarded without any plugin", Summary(nil))
+	var names []string
+	for i := 0; i < 30; i++ {
+		names = append(names, fmt.Sprintf("plugin-%d", i))
+	}
+	summary := Summary(names)
+	assert.Len(t, summary, maxDescriptionLength)
+	assert.True(t, strings.HasSuffix(summary, "..."))
+}
@@ -0,0 +1,109 @@
+package payload
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/pkg/errors"
+)
+
+// WebhookKindCheckRerequest is the kind of CheckRerequestHook
+const WebhookKindCheckRerequest scm.WebhookKind = "check_rerequest"
+
+// CheckRerequestHook is a request to run the checks of a commit again, sent by GitHub when "Re-run" is clicked
+// for a check run or a check suite. go-scm does not parse the commit, check run and pull requests of these, so they
+// are decoded here from the rerequested check_run and check_suite events.
+type CheckRerequestHook struct {
+	Repo   scm.Repository
+	SHA    string
+	Branch string
+	// Name is the name of the check run to run again, which is empty for a check suite
+	Name string
+	// ExternalID is the external ID of the check run, which is the name of the LighthouseJob it was reported for
+	ExternalID string
+	// PullRequests are the numbers of the open pull requests whose head is the commit
+	PullRequests []int
+	Sender       scm.User
+	Installation *scm.InstallationRef
+}
+
+// Repository returns the repository of the commit
+func (h *CheckRerequestHook) Repository() scm.Repository { return h.Repo }
+
+// GetInstallationRef returns the GitHub App installation the event was sent to, if any
+func (h *CheckRerequestHook) GetInstallationRef() *scm.InstallationRef { return h.Installation }
+
+// Kind returns the kind of the webhook
+func (h *CheckRerequestHook) Kind() scm.WebhookKind { return WebhookKindCheckRerequest }
+
+type githubCheckSuite struct {
+	HeadBranch   string `json:"head_branch"`
+	HeadSHA      string `json:"head_sha"`
+	PullRequests []struct {
+		Number int `json:"number"`
+	} `json:"pull_requests"`
+}
+
+type githubCheckRerequest struct {
+	Action   string `json:"action"`
+	CheckRun *struct {
+		Name         string `json:"name"`
+		HeadSHA      string `json:"head_sha"`
+		ExternalID   string `json:"external_id"`
+		PullRequests []struct {
+			Number int `json:"number"`
+		} `json:"pull_requests"`
+		CheckSuite githubCheckSuite `json:"check_suite"`
+	} `json:"check_run"`
+	CheckSuite   *githubCheckSuite   `json:"check_suite"`
+	Repository   githubRepository    `json:"repository"`
+	Sender       githubUser          `json:"sender"`
+	Installation *githubInstallation `json:"installation"`
+}
+
+// ParseCheckRerequest decodes the payload if it is a rerequested check run or check suite, returning nil otherwise
+func ParseCheckRerequest(header http.Header, body []byte) (*CheckRerequestHook, error) {
+	event := header.Get("X-GitHub-Event")
+	if event != "check_run" && event != "check_suite" {
+		return nil, nil
+	}
+	src := &githubCheckRerequest{}
+	if err := json.Unmarshal(body, src); err != nil {
+		return nil, errors.Wrapf(err, "decoding %s", event)
+	}
+	if src.Action != "rerequested" {
+		return nil, nil
+	}
+	hook := &CheckRerequestHook{
+		Repo:         src.Repository.toSCM(),
+		Sender:       scm.User{ID: src.Sender.ID, Login: src.Sender.Login, Name: src.Sender.Name},
+		Installation: src.Installation.toSCM(),
+	}
+	suite := src.CheckSuite
+	if event == "check_run" {
+		if src.CheckRun == nil {
+			return nil, errors.New("decoding check_run: no check run")
+		}
+		run := src.CheckRun
+		hook.Name = run.Name
+		hook.ExternalID = run.ExternalID
+		hook.SHA = run.HeadSHA
+		for _, pr := range run.PullRequests {
+			hook.PullRequests = append(hook.PullRequests, pr.Number)
+		}
+		suite = &run.CheckSuite
+	} else if suite == nil {
+		return nil, errors.New("decoding check_suite: no check suite")
+	}
+	hook.Branch = suite.HeadBranch
+	if hook.SHA == "" {
+		hook.SHA = suite.HeadSHA
+	}
+	if len(hook.PullRequests) == 0 {
+		for _, pr := range suite.PullRequests {
+			hook.PullRequests = append(hook.PullRequests, pr.Number)
+		}
+	}
+	return hook, nil
+}
@@ -0,0 +1,66 @@
+package payload
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const githubCheckRunRerequestedBody = `{
+  "action": "rerequested",
+  "check_run": {
+    "name": "unit",
+    "head_sha": "abc",
+    "external_id": "org-repo-pr-1-unit-1",
+    "pull_requests": [{"number": 1}],
+    "check_suite": {"head_branch": "feature", "head_sha": "abc"}
+  },
+  "repository": {"id": 7, "name": "repo", "full_name": "org/repo", "owner": {"login": "org"}, "default_branch": "main"},
+  "sender": {"id": 3, "login": "alice"},
+  "installation": {"id": 42}
+}`
+
+const githubCheckSuiteRerequestedBody = `{
+  "action": "rerequested",
+  "check_suite": {"head_branch": "feature", "head_sha": "abc", "pull_requests": [{"number": 1}, {"number": 2}]},
+  "repository": {"id": 7, "name": "repo", "full_name": "org/repo", "owner": {"login": "org"}, "default_branch": "main"},
+  "sender": {"id": 3, "login": "alice"}
+}`
+
+func TestParseCheckRerequest(t *testing.T) {
+	header := http.Header{}
+	header.Set("X-GitHub-Event", "check_run")
+	hook, err := ParseCheckRerequest(header, []byte(githubCheckRunRerequestedBody))
+	require.NoError(t, err)
+	require.NotNil(t, hook)
+	assert.Equal(t, WebhookKindCheckRerequest, hook.Kind())
+	assert.Equal(t, "org/repo", hook.Repository().FullName)
+	assert.Equal(t, "abc", hook.SHA)
+	assert.Equal(t, "feature", hook.Branch)
+	assert.Equal(t, "unit", hook.Name)
+	assert.Equal(t, "org-repo-pr-1-unit-1", hook.ExternalID)
+	assert.Equal(t, []int{1}, hook.PullRequests)
+	assert.Equal(t, "alice", hook.Sender.Login)
+	assert.Equal(t, int64(42), hook.GetInstallationRef().ID)
+
+	header.Set("X-GitHub-Event", "check_suite")
+	hook, err = ParseCheckRerequest(header, []byte(githubCheckSuiteRerequestedBody))
+	require.NoError(t, err)
+	require.NotNil(t, hook)
+	assert.Equal(t, "abc", hook.SHA)
+	assert.Empty(t, hook.Name)
+	assert.Equal(t, []int{1, 2}, hook.PullRequests)
+	assert.Nil(t, hook.GetInstallationRef())
+
+	header.Set("X-GitHub-Event", "check_run")
+	hook, err = ParseCheckRerequest(header, []byte(githubCheckRunBody))
+	assert.NoError(t, err)
+	assert.Nil(t, hook, "only rerequested check runs are decoded")
+
+	header.Set("X-GitHub-Event", "status")
+	hook, err = ParseCheckRerequest(header, []byte(githubStatusBody))
+	assert.NoError(t, err)
+	assert.Nil(t, hook)
+}
@@ -0,0 +1,195 @@
+package payload
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/pkg/errors"
+)
+
+// WebhookKindCommitComment is the kind of CommitCommentHook
+const WebhookKindCommitComment scm.WebhookKind = "commit_comment"
+
+// CommitCommentHook is a comment on a commit. go-scm does not parse these, so they are decoded here from the
+// commit_comment events of GitHub and the notes on commits of GitLab.
+type CommitCommentHook struct {
+	Action       scm.Action
+	Repo         scm.Repository
+	SHA          string
+	Comment      scm.Comment
+	Installation *scm.InstallationRef
+}
+
+// Repository returns the repository of the commit
+func (h *CommitCommentHook) Repository() scm.Repository { return h.Repo }
+
+// GetInstallationRef returns the GitHub App installation the event was sent to, if any
+func (h *CommitCommentHook) GetInstallationRef() *scm.InstallationRef { return h.Installation }
+
+// Kind returns the kind of the webhook
+func (h *CommitCommentHook) Kind() scm.WebhookKind { return WebhookKindCommitComment }
+
+type githubUser struct {
+	ID    int    `json:"id"`
+	Login string `json:"login"`
+	Name  string `json:"name"`
+}
+
+type githubCommitComment struct {
+	Comment struct {
+		ID       int        `json:"id"`
+		Body     string     `json:"body"`
+		HTMLURL  string     `json:"html_url"`
+		CommitID string     `json:"commit_id"`
+		User     githubUser `json:"user"`
+	} `json:"comment"`
+	Repository   githubRepository    `json:"repository"`
+	Installation *githubInstallation `json:"installation"`
+}
+
+type githubRepository struct {
+	ID       int64  `json:"id"`
+	Name     string `json:"name"`
+	FullName string `json:"full_name"`
+	Owner    struct {
+		Login string `json:"login"`
+	} `json:"owner"`
+	Private       bool   `json:"private"`
+	HTMLURL       string `json:"html_url"`
+	CloneURL      string `json:"clone_url"`
+	SSHURL        string `json:"ssh_url"`
+	DefaultBranch string `json:"default_branch"`
+}
+
+func (r *githubRepository) toSCM() scm.Repository {
+	return scm.Repository{
+		ID:        scmID(r.ID),
+		Namespace: r.Owner.Login,
+		Name:      r.Name,
+		FullName:  r.FullName,
+		Branch:    r.DefaultBranch,
+		Private:   r.Private,
+		Clone:     r.CloneURL,
+		CloneSSH:  r.SSHURL,
+		Link:      r.HTMLURL,
+	}
+}
+
+type githubInstallation struct {
+	ID     int64  `json:"id"`
+	NodeID string `json:"node_id"`
+}
+
+func (i *githubInstallation) toSCM() *scm.InstallationRef {
+	if i == nil {
+		return nil
+	}
+	return &scm.InstallationRef{ID: i.ID, NodeID: i.NodeID}
+}
+
+type gitlabCommitNote struct {
+	User struct {
+		ID       int    `json:"id"`
+		Name     string `json:"name"`
+		Username string `json:"username"`
+	} `json:"user"`
+	Project struct {
+		ID                int64  `json:"id"`
+		PathWithNamespace string `json:"path_with_namespace"`
+		WebURL            string `json:"web_url"`
+		GitHTTPURL        string `json:"git_http_url"`
+		GitSSHURL         string `json:"git_ssh_url"`
+		DefaultBranch     string `json:"default_branch"`
+	} `json:"project"`
+	ObjectAttributes struct {
+		ID           int    `json:"id"`
+		Note         string `json:"note"`
+		NoteableType string `json:"noteable_type"`
+		CommitID     string `json:"commit_id"`
+		URL          string `json:"url"`
+	} `json:"object_attributes"`
+}
+
+// ParseCommitComment decodes the payload if it is a comment on a commit, returning nil otherwise
+func ParseCommitComment(header http.Header, body []byte) (*CommitCommentHook, error) {
+	switch {
+	case header.Get("X-GitHub-Event") == "commit_comment":
+		src := &githubCommitComment{}
+		if err := json.Unmarshal(body, src); err != nil {
+			return nil, errors.Wrap(err, "decoding commit comment")
+		}
+		return &CommitCommentHook{
+			Action: scm.ActionCreate,
+			Repo:   src.Repository.toSCM(),
+			SHA:    src.Comment.CommitID,
+			Comment: scm.Comment{
+				ID:     src.Comment.ID,
+				Body:   src.Comment.Body,
+				Link:   src.Comment.HTMLURL,
+				Author: scm.User{ID: src.Comment.User.ID, Login: src.Comment.User.Login, Name: src.Comment.User.Name},
+			},
+			Installation: src.Installation.toSCM(),
+		}, nil
+	case header.Get("X-Gitlab-Event") == "Note Hook":
+		src := &gitlabCommitNote{}
+		if err := json.Unmarshal(body, src); err != nil {
+			return nil, errors.Wrap(err, "decoding note")
+		}
+		if src.ObjectAttributes.NoteableType != "Commit" {
+			return nil, nil
+		}
+		project := src.Project
+		namespace, name := "", project.PathWithNamespace
+		if i := strings.LastIndex(name, "/"); i >= 0 {
+			namespace, name = name[:i], name[i+1:]
+		}
+		return &CommitCommentHook{
+			Action: scm.ActionCreate,
+			Repo: scm.Repository{
+				ID:        scmID(project.ID),
+				Namespace: namespace,
+				Name:      name,
+				FullName:  project.PathWithNamespace,
+				Branch:    project.DefaultBranch,
+				Clone:     project.GitHTTPURL,
+				CloneSSH:  project.GitSSHURL,
+				Link:      project.WebURL,
+			},
+			SHA: src.ObjectAttributes.CommitID,
+			Comment: scm.Comment{
+				ID:     src.ObjectAttributes.ID,
+				Body:   src.ObjectAttributes.Note,
+				Link:   src.ObjectAttributes.URL,
+				Author: scm.User{ID: src.User.ID, Login: src.User.Username, Name: src.User.Name},
+			},
+		}, nil
+	}
+	return nil, nil
+}
+
+func scmID(id int64) string {
+	if id == 0 {
+		return ""
+	}
+	return strconv.FormatInt(id, 10)
+}
+
+// fillCommentAction sets the action of review comments on pull requests, which the GitHub parser of go-scm
+// leaves unset so that plugins would ignore the commands of the comments
+func fillCommentAction(webhook scm.Webhook, body []byte) {
+	hook, ok := webhook.(*scm.PullRequestCommentHook)
+	if !ok || hook.Action != scm.Action(0) {
+		return
+	}
+	src := struct {
+		Action scm.Action `json:"action"`
+	}{}
+	if err := json.Unmarshal(body, &src); err == nil && src.Action != scm.Action(0) {
+		hook.Action = src.Action
+		return
+	}
+	hook.Action = scm.ActionCreate
+}
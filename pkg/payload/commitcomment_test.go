@@ -0,0 +1,108 @@
+package payload
+
+import (
+	"bytes"
+	"crypto/sha1" // #nosec
+	"net/http"
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/go-scm/scm/factory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const githubCommitCommentBody = `{
+  "action": "created",
+  "comment": {"id": 11, "body": "/lgtm", "html_url": "https://github.com/org/repo/commit/abc#r11", "commit_id": "abc", "user": {"id": 3, "login": "alice"}},
+  "repository": {"id": 7, "name": "repo", "full_name": "org/repo", "owner": {"login": "org"}, "clone_url": "https://github.com/org/repo.git", "default_branch": "main"},
+  "installation": {"id": 42}
+}`
+
+const gitlabCommitNoteBody = `{
+  "object_kind": "note",
+  "user": {"id": 3, "name": "Alice", "username": "alice"},
+  "project": {"id": 7, "path_with_namespace": "group/sub/repo", "git_http_url": "https://gitlab.com/group/sub/repo.git", "default_branch": "main"},
+  "object_attributes": {"id": 11, "note": "/retest", "noteable_type": "Commit", "commit_id": "abc", "url": "https://gitlab.com/group/sub/repo/-/commit/abc#note_11"}
+}`
+
+func TestParseCommitComment(t *testing.T) {
+	header := http.Header{}
+	header.Set("X-GitHub-Event", "commit_comment")
+	hook, err := ParseCommitComment(header, []byte(githubCommitCommentBody))
+	require.NoError(t, err)
+	require.NotNil(t, hook)
+	assert.Equal(t, WebhookKindCommitComment, hook.Kind())
+	assert.Equal(t, scm.ActionCreate, hook.Action)
+	assert.Equal(t, "abc", hook.SHA)
+	assert.Equal(t, "/lgtm", hook.Comment.Body)
+	assert.Equal(t, "alice", hook.Comment.Author.Login)
+	assert.Equal(t, scm.Repository{ID: "7", Namespace: "org", Name: "repo", FullName: "org/repo", Branch: "main", Clone: "https://github.com/org/repo.git"}, hook.Repository())
+	assert.Equal(t, int64(42), hook.GetInstallationRef().ID)
+
+	header = http.Header{}
+	header.Set("X-Gitlab-Event", "Note Hook")
+	hook, err = ParseCommitComment(header, []byte(gitlabCommitNoteBody))
+	require.NoError(t, err)
+	require.NotNil(t, hook)
+	assert.Equal(t, "group/sub", hook.Repo.Namespace)
+	assert.Equal(t, "repo", hook.Repo.Name)
+	assert.Equal(t, "abc", hook.SHA)
+	assert.Equal(t, "/retest", hook.Comment.Body)
+
+	hook, err = ParseCommitComment(header, []byte(`{"object_attributes": {"noteable_type": "MergeRequest"}}`))
+	assert.NoError(t, err)
+	assert.Nil(t, hook)
+
+	header = http.Header{}
+	header.Set("X-GitHub-Event", "ping")
+	hook, err = ParseCommitComment(header, []byte(pingBody))
+	assert.NoError(t, err)
+	assert.Nil(t, hook)
+}
+
+func TestParseSignedCommitComment(t *testing.T) {
+	scmClient, err := factory.NewClient("github", "", "")
+	require.NoError(t, err)
+
+	newRequest := func(signature string) *http.Request {
+		req, err := http.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(githubCommitCommentBody)))
+		require.NoError(t, err)
+		req.Header.Set("X-GitHub-Event", "commit_comment")
+		req.Header.Set("X-GitHub-Delivery", "72d3162e-cc78-11e3-81ab-4c9367dc0958")
+		if signature != "" {
+			req.Header.Set("X-Hub-Signature", signature)
+		}
+		return req
+	}
+
+	webhook, _, err := Parse(scmClient, newRequest("sha1="+sign(sha1.New, "old", githubCommitCommentBody)), []string{"new", "old"}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, WebhookKindCommitComment, webhook.Kind())
+
+	_, _, err = Parse(scmClient, newRequest("sha1="+sign(sha1.New, "other", githubCommitCommentBody)), []string{"new", "old"}, 0)
+	assert.Equal(t, scm.ErrSignatureInvalid, err)
+
+	_, _, err = Parse(scmClient, newRequest(""), []string{"new", "old"}, 0)
+	assert.Error(t, err)
+}
+
+func TestParseReviewCommentAction(t *testing.T) {
+	scmClient, err := factory.NewClient("github", "", "")
+	require.NoError(t, err)
+
+	for action, expected := range map[string]scm.Action{"created": scm.ActionCreate, "edited": scm.ActionEdited, "deleted": scm.ActionDelete} {
+		body := `{"action": "` + action + `", "comment": {"id": 1, "body": "/lgtm", "user": {"login": "alice"}}, "pull_request": {"number": 5}, "repository": {"name": "repo", "owner": {"login": "org"}}}`
+		req, err := http.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(body)))
+		require.NoError(t, err)
+		req.Header.Set("X-GitHub-Event", "pull_request_review_comment")
+		req.Header.Set("X-GitHub-Delivery", "72d3162e-cc78-11e3-81ab-4c9367dc0958")
+
+		webhook, _, err := Parse(scmClient, req, nil, 0)
+		require.NoError(t, err)
+		hook, ok := webhook.(*scm.PullRequestCommentHook)
+		require.True(t, ok)
+		assert.Equal(t, expected, hook.Action, action)
+		assert.Equal(t, "/lgtm", hook.Comment.Body)
+	}
+}
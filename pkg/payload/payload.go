@@ -0,0 +1,154 @@
+// Package payload reads and authenticates the webhook payloads sent by the SCM providers, both to the hook and
+// by the hook to external plugins.
+package payload
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/jenkins-x/go-scm/pkg/hmac"
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/pkg/errors"
+)
+
+// DefaultMaxSize is the largest webhook payload accepted by default, matching the limit of the go-scm parsers
+const DefaultMaxSize = 10000000
+
+// ErrTooLarge is returned when a webhook payload exceeds the maximum size
+var ErrTooLarge = errors.New("webhook payload too large")
+
+// deliveryHeaders are the headers the SCM providers send the unique ID of a delivery in
+var deliveryHeaders = []string{
+	"X-GitHub-Delivery",
+	"X-Gitea-Delivery",
+	"X-Gogs-Delivery",
+	"X-Gitlab-Event-UUID",
+	"X-Request-UUID",
+	"X-Request-Id",
+}
+
+// DeliveryID returns the unique ID of the webhook delivery, or an empty string if the provider sent none
+func DeliveryID(header http.Header) string {
+	for _, name := range deliveryHeaders {
+		if id := header.Get(name); id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// Read reads the body of the webhook request, failing if it is larger than maxSize
+func Read(r *http.Request, maxSize int64) ([]byte, error) {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxSize
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxSize+1))
+	if err != nil {
+		return nil, errors.Wrap(err, "reading webhook body")
+	}
+	if int64(len(body)) > maxSize {
+		return nil, ErrTooLarge
+	}
+	return body, nil
+}
+
+// Verify checks the signature headers sent by GitHub, GitHub Enterprise, Bitbucket Server, Gitea and
+// GitLab against each of the tokens. It returns false for checked if the request carries none of them, in
+// which case the go-scm parser of the provider has to validate it.
+func Verify(header http.Header, body []byte, tokens []string) (checked bool, valid bool) {
+	var match func(token string) bool
+	switch {
+	case header.Get("X-Hub-Signature-256") != "":
+		signature := header.Get("X-Hub-Signature-256")
+		match = func(token string) bool { return hmac.ValidatePrefix(body, []byte(token), signature) }
+	case header.Get("X-Hub-Signature") != "":
+		// GitHub sends sha1= signatures here while Bitbucket Server sends sha256= ones
+		signature := header.Get("X-Hub-Signature")
+		match = func(token string) bool { return hmac.ValidatePrefix(body, []byte(token), signature) }
+	case header.Get("X-Gitea-Signature") != "":
+		signature := header.Get("X-Gitea-Signature")
+		match = func(token string) bool { return hmac.Validate(sha256.New, body, []byte(token), signature) }
+	case header.Get("X-Gitlab-Token") != "":
+		value := []byte(header.Get("X-Gitlab-Token"))
+		match = func(token string) bool { return subtle.ConstantTimeCompare(value, []byte(token)) == 1 }
+	default:
+		return false, false
+	}
+	for _, token := range tokens {
+		if match(token) {
+			return true, true
+		}
+	}
+	return true, false
+}
+
+// Parse reads the webhook request and decodes it into the go-scm event of the provider, accepting it if it is
+// signed by any of the tokens so that tokens can be rotated without dropping deliveries. No signature is
+// required if there are no tokens. The raw payload is returned along with the event.
+func Parse(scmClient *scm.Client, r *http.Request, tokens []string, maxSize int64) (scm.Webhook, []byte, error) {
+	body, err := Read(r, maxSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	parse := func(token string) (scm.Webhook, error) {
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		webhook, err := scmClient.Webhooks.Parse(r, func(scm.Webhook) (string, error) {
+			return token, nil
+		})
+		if err == nil {
+			fillCommentAction(webhook, body)
+		}
+		return webhook, err
+	}
+	// commit comments, check rerequests and statuses are not parsed by go-scm, so they are only accepted once their
+	// signature is verified here
+	parseVerified := func() (scm.Webhook, []byte, error) {
+		hook, err := ParseCommitComment(r.Header, body)
+		if err != nil {
+			return nil, body, err
+		}
+		if hook != nil {
+			return hook, body, nil
+		}
+		rerequest, err := ParseCheckRerequest(r.Header, body)
+		if err != nil {
+			return nil, body, err
+		}
+		if rerequest != nil {
+			return rerequest, body, nil
+		}
+		status, err := ParseStatus(r.Header, body)
+		if err != nil {
+			return nil, body, err
+		}
+		if status != nil {
+			return status, body, nil
+		}
+		webhook, err := parse("")
+		return webhook, body, err
+	}
+	if len(tokens) == 0 {
+		return parseVerified()
+	}
+	if checked, valid := Verify(r.Header, body, tokens); checked {
+		if !valid {
+			return nil, body, scm.ErrSignatureInvalid
+		}
+		// the signature has been verified already
+		return parseVerified()
+	}
+
+	// providers signing webhooks in other ways are validated by their go-scm parser
+	var webhook scm.Webhook
+	for _, token := range tokens {
+		webhook, err = parse(token)
+		if err != scm.ErrSignatureInvalid {
+			return webhook, body, err
+		}
+	}
+	return webhook, body, err
+}
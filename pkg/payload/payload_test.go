@@ -0,0 +1,83 @@
+package payload
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1" // #nosec
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/go-scm/scm/factory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const pingBody = `{"zen": "Keep it logically awesome.", "hook_id": 1}`
+
+func sign(h func() hash.Hash, token, body string) string {
+	mac := hmac.New(h, []byte(token))
+	_, _ = mac.Write([]byte(body))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(pingBody)
+	tokens := []string{"new", "old"}
+
+	testCases := []struct {
+		name    string
+		header  string
+		value   string
+		checked bool
+		valid   bool
+	}{
+		{name: "github sha256", header: "X-Hub-Signature-256", value: "sha256=" + sign(sha256.New, "old", pingBody), checked: true, valid: true},
+		{name: "bitbucket server", header: "X-Hub-Signature", value: "sha256=" + sign(sha256.New, "new", pingBody), checked: true, valid: true},
+		{name: "github sha1 wrong token", header: "X-Hub-Signature", value: "sha1=" + sign(sha1.New, "other", pingBody), checked: true},
+		{name: "gitea", header: "X-Gitea-Signature", value: sign(sha256.New, "new", pingBody), checked: true, valid: true},
+		{name: "gitlab", header: "X-Gitlab-Token", value: "old", checked: true, valid: true},
+		{name: "gitlab wrong token", header: "X-Gitlab-Token", value: "other", checked: true},
+		{name: "unsigned", header: "X-Other", value: "x"},
+	}
+	for _, tc := range testCases {
+		header := http.Header{}
+		header.Set(tc.header, tc.value)
+		checked, valid := Verify(header, body, tokens)
+		assert.Equal(t, tc.checked, checked, tc.name)
+		assert.Equal(t, tc.valid, valid, tc.name)
+	}
+}
+
+func TestParse(t *testing.T) {
+	scmClient, err := factory.NewClient("github", "", "")
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(pingBody)))
+	require.NoError(t, err)
+	req.Header.Set("X-GitHub-Event", "ping")
+	req.Header.Set("X-GitHub-Delivery", "72d3162e-cc78-11e3-81ab-4c9367dc0958")
+	req.Header.Set("X-Hub-Signature", "sha1="+sign(sha1.New, "old", pingBody))
+
+	webhook, body, err := Parse(scmClient, req, []string{"new", "old"}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, scm.WebhookKindPing, webhook.Kind())
+	assert.Equal(t, pingBody, string(body))
+
+	req.Body = ioutil.NopCloser(bytes.NewReader([]byte(pingBody)))
+	_, _, err = Parse(scmClient, req, []string{"new", "old"}, 10)
+	assert.Equal(t, ErrTooLarge, err)
+}
+
+func TestDeliveryID(t *testing.T) {
+	header := http.Header{}
+	assert.Equal(t, "", DeliveryID(header))
+	header.Set("X-Gitlab-Event-UUID", "gitlab-uuid")
+	assert.Equal(t, "gitlab-uuid", DeliveryID(header))
+	header.Set("X-GitHub-Delivery", "github-guid")
+	assert.Equal(t, "github-guid", DeliveryID(header))
+}
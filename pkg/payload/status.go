@@ -0,0 +1,128 @@
+package payload
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/pkg/errors"
+)
+
+// WebhookKindStatus is the kind of StatusHook
+const WebhookKindStatus scm.WebhookKind = "status"
+
+// StatusHook is a change of the state of a context of a commit, reported either as a commit status or as a
+// check run. go-scm does not parse the commit, context and state of these, so they are decoded here from the
+// status and check_run events of GitHub.
+type StatusHook struct {
+	Repo    scm.Repository
+	SHA     string
+	Context string
+	// State is pending, success, failure or error. Completed check runs whose conclusion is neutral or
+	// skipped keep their conclusion as their state.
+	State       string
+	Description string
+	Link        string
+	// Branches are the branches whose head is the commit
+	Branches     []string
+	Installation *scm.InstallationRef
+}
+
+// Repository returns the repository of the commit
+func (h *StatusHook) Repository() scm.Repository { return h.Repo }
+
+// GetInstallationRef returns the GitHub App installation the event was sent to, if any
+func (h *StatusHook) GetInstallationRef() *scm.InstallationRef { return h.Installation }
+
+// Kind returns the kind of the webhook
+func (h *StatusHook) Kind() scm.WebhookKind { return WebhookKindStatus }
+
+type githubStatus struct {
+	SHA         string `json:"sha"`
+	Context     string `json:"context"`
+	State       string `json:"state"`
+	Description string `json:"description"`
+	TargetURL   string `json:"target_url"`
+	Branches    []struct {
+		Name string `json:"name"`
+	} `json:"branches"`
+	Repository   githubRepository    `json:"repository"`
+	Installation *githubInstallation `json:"installation"`
+}
+
+type githubCheckRun struct {
+	CheckRun struct {
+		Name       string `json:"name"`
+		HeadSHA    string `json:"head_sha"`
+		Status     string `json:"status"`
+		Conclusion string `json:"conclusion"`
+		HTMLURL    string `json:"html_url"`
+		Output     struct {
+			Title string `json:"title"`
+		} `json:"output"`
+		CheckSuite struct {
+			HeadBranch string `json:"head_branch"`
+		} `json:"check_suite"`
+	} `json:"check_run"`
+	Repository   githubRepository    `json:"repository"`
+	Installation *githubInstallation `json:"installation"`
+}
+
+// ParseStatus decodes the payload if it is a commit status or a check run, returning nil otherwise
+func ParseStatus(header http.Header, body []byte) (*StatusHook, error) {
+	switch header.Get("X-GitHub-Event") {
+	case "status":
+		src := &githubStatus{}
+		if err := json.Unmarshal(body, src); err != nil {
+			return nil, errors.Wrap(err, "decoding status")
+		}
+		hook := &StatusHook{
+			Repo:         src.Repository.toSCM(),
+			SHA:          src.SHA,
+			Context:      src.Context,
+			State:        src.State,
+			Description:  src.Description,
+			Link:         src.TargetURL,
+			Installation: src.Installation.toSCM(),
+		}
+		for _, branch := range src.Branches {
+			hook.Branches = append(hook.Branches, branch.Name)
+		}
+		return hook, nil
+	case "check_run":
+		src := &githubCheckRun{}
+		if err := json.Unmarshal(body, src); err != nil {
+			return nil, errors.Wrap(err, "decoding check run")
+		}
+		run := src.CheckRun
+		hook := &StatusHook{
+			Repo:         src.Repository.toSCM(),
+			SHA:          run.HeadSHA,
+			Context:      run.Name,
+			State:        checkRunState(run.Status, run.Conclusion),
+			Description:  run.Output.Title,
+			Link:         run.HTMLURL,
+			Installation: src.Installation.toSCM(),
+		}
+		if run.CheckSuite.HeadBranch != "" {
+			hook.Branches = []string{run.CheckSuite.HeadBranch}
+		}
+		return hook, nil
+	}
+	return nil, nil
+}
+
+// checkRunState maps the status and conclusion of a check run to the state of a commit status
+func checkRunState(status, conclusion string) string {
+	if status != "completed" {
+		return "pending"
+	}
+	switch conclusion {
+	case "success", "neutral", "skipped":
+		return conclusion
+	case "cancelled", "timed_out", "stale":
+		return "error"
+	default:
+		return "failure"
+	}
+}
@@ -0,0 +1,95 @@
+package payload
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm/factory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const githubStatusBody = `{
+  "sha": "abc",
+  "context": "security-scan",
+  "state": "success",
+  "description": "no vulnerabilities",
+  "target_url": "https://scanner.example.com/abc",
+  "branches": [{"name": "main", "commit": {"sha": "abc"}}, {"name": "release", "commit": {"sha": "abc"}}],
+  "repository": {"id": 7, "name": "repo", "full_name": "org/repo", "owner": {"login": "org"}, "default_branch": "main"},
+  "installation": {"id": 42}
+}`
+
+const githubCheckRunBody = `{
+  "action": "completed",
+  "check_run": {
+    "name": "security-scan",
+    "head_sha": "abc",
+    "status": "completed",
+    "conclusion": "timed_out",
+    "html_url": "https://github.com/org/repo/runs/1",
+    "output": {"title": "scan timed out"},
+    "check_suite": {"head_branch": "main"}
+  },
+  "repository": {"id": 7, "name": "repo", "full_name": "org/repo", "owner": {"login": "org"}, "default_branch": "main"}
+}`
+
+func TestParseStatus(t *testing.T) {
+	header := http.Header{}
+	header.Set("X-GitHub-Event", "status")
+	hook, err := ParseStatus(header, []byte(githubStatusBody))
+	require.NoError(t, err)
+	require.NotNil(t, hook)
+	assert.Equal(t, WebhookKindStatus, hook.Kind())
+	assert.Equal(t, "org/repo", hook.Repository().FullName)
+	assert.Equal(t, "abc", hook.SHA)
+	assert.Equal(t, "security-scan", hook.Context)
+	assert.Equal(t, "success", hook.State)
+	assert.Equal(t, "https://scanner.example.com/abc", hook.Link)
+	assert.Equal(t, []string{"main", "release"}, hook.Branches)
+	assert.Equal(t, int64(42), hook.GetInstallationRef().ID)
+
+	header.Set("X-GitHub-Event", "check_run")
+	hook, err = ParseStatus(header, []byte(githubCheckRunBody))
+	require.NoError(t, err)
+	require.NotNil(t, hook)
+	assert.Equal(t, "abc", hook.SHA)
+	assert.Equal(t, "security-scan", hook.Context)
+	assert.Equal(t, "error", hook.State)
+	assert.Equal(t, "scan timed out", hook.Description)
+	assert.Equal(t, []string{"main"}, hook.Branches)
+	assert.Nil(t, hook.GetInstallationRef())
+
+	header.Set("X-GitHub-Event", "ping")
+	hook, err = ParseStatus(header, []byte(pingBody))
+	assert.NoError(t, err)
+	assert.Nil(t, hook)
+}
+
+func TestCheckRunState(t *testing.T) {
+	for _, tc := range []struct {
+		status, conclusion, expected string
+	}{
+		{status: "queued", expected: "pending"},
+		{status: "in_progress", expected: "pending"},
+		{status: "completed", conclusion: "success", expected: "success"},
+		{status: "completed", conclusion: "neutral", expected: "neutral"},
+		{status: "completed", conclusion: "failure", expected: "failure"},
+		{status: "completed", conclusion: "action_required", expected: "failure"},
+		{status: "completed", conclusion: "cancelled", expected: "error"},
+	} {
+		assert.Equal(t, tc.expected, checkRunState(tc.status, tc.conclusion), "%s %s", tc.status, tc.conclusion)
+	}
+}
+
+func TestParseStatusWebhook(t *testing.T) {
+	scmClient, err := factory.NewClient("github", "", "")
+	require.NoError(t, err)
+	req, err := http.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(githubStatusBody)))
+	require.NoError(t, err)
+	req.Header.Set("X-GitHub-Event", "status")
+	webhook, _, err := Parse(scmClient, req, nil, 0)
+	require.NoError(t, err)
+	assert.Equal(t, WebhookKindStatus, webhook.Kind())
+}
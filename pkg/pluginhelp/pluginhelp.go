@@ -18,6 +18,8 @@ limitations under the License.
 // These structs are used by sub-packages 'hook' and 'externalplugins'.
 package pluginhelp
 
+import "github.com/jenkins-x/lighthouse/pkg/util"
+
 // Command is a serializable representation of the command information for a single command.
 type Command struct {
 	// Usage is a usage string for the command.
@@ -67,7 +69,10 @@ type Help struct {
 	ExternalPluginHelp map[string]PluginHelp
 }
 
-// AddCommand registers new help text for a bot command.
+// AddCommand registers new help text for a bot command. Its usage and examples are rewritten with the command prefix
+// when it is required.
 func (pluginHelp *PluginHelp) AddCommand(command Command) {
+	command.Usage = util.FormatCommand(command.Usage)
+	command.Examples = util.FormatCommands(command.Examples)
 	pluginHelp.Commands = append(pluginHelp.Commands, command)
 }
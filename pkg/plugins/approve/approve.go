@@ -47,6 +47,9 @@ const (
 	cancelArgument  = "cancel"
 	lgtmCommand     = "LGTM"
 	noIssueArgument = "no-issue"
+
+	// rootOwnersFile stands for the files of a PR changing too many files to be listed
+	rootOwnersFile = "OWNERS"
 )
 
 var (
@@ -92,6 +95,9 @@ type state struct {
 	author    string
 	assignees []scm.User
 	htmlURL   string
+
+	// autoApproved is true for the pull requests of the dependency update bots approved by their profile
+	autoApproved bool
 }
 
 func init() {
@@ -173,7 +179,9 @@ func handleGenericComment(log *logrus.Entry, spc scmProviderClient, oc ownersCli
 	}
 
 	opts := optionsForRepo(config, ce.Repo.Namespace, ce.Repo.Name)
-	if !isApprovalCommand(botName, opts.LgtmActsAsApprove, &comment{Body: ce.Body, Author: ce.Author.Login}) {
+	// the overrides of the base branch, which is not known yet, may make /lgtm act as approval
+	lgtmActsAsApprove := opts.LgtmActsAsApprove || len(config.BranchOverridesFor(ce.Repo.Namespace, ce.Repo.Name)) > 0
+	if !isApprovalCommand(botName, lgtmActsAsApprove, &comment{Body: ce.Body, Author: ce.Author.Login}) {
 		return nil
 	}
 
@@ -181,6 +189,10 @@ func handleGenericComment(log *logrus.Entry, spc scmProviderClient, oc ownersCli
 	if err != nil {
 		return err
 	}
+	opts = config.ApproveForBranch(opts, ce.Repo.Namespace, ce.Repo.Name, pr.Base.Ref)
+	if !isApprovalCommand(botName, opts.LgtmActsAsApprove, &comment{Body: ce.Body, Author: ce.Author.Login}) {
+		return nil
+	}
 
 	repo, err := oc.LoadRepoOwners(ce.Repo.Namespace, ce.Repo.Name, pr.Base.Ref)
 	if err != nil {
@@ -194,14 +206,15 @@ func handleGenericComment(log *logrus.Entry, spc scmProviderClient, oc ownersCli
 		serverURL,
 		opts,
 		&state{
-			org:       ce.Repo.Namespace,
-			repo:      ce.Repo.Name,
-			branch:    pr.Base.Ref,
-			number:    ce.Number,
-			body:      ce.IssueBody,
-			author:    ce.IssueAuthor.Login,
-			assignees: ce.Assignees,
-			htmlURL:   ce.IssueLink,
+			org:          ce.Repo.Namespace,
+			repo:         ce.Repo.Name,
+			branch:       pr.Base.Ref,
+			number:       ce.Number,
+			body:         ce.IssueBody,
+			author:       ce.IssueAuthor.Login,
+			assignees:    ce.Assignees,
+			htmlURL:      ce.IssueLink,
+			autoApproved: autoApproved(config, ce.Repo.Namespace, ce.Repo.Name, ce.IssueAuthor.Login),
 		},
 	)
 }
@@ -235,7 +248,7 @@ func handleReview(log *logrus.Entry, spc scmProviderClient, oc ownersClient, ser
 		return err
 	}
 
-	opts := optionsForRepo(config, re.Repo.Namespace, re.Repo.Name)
+	opts := optionsForBranch(config, re.Repo.Namespace, re.Repo.Name, re.PullRequest.Base.Ref)
 
 	// Check for an approval command is in the body. If one exists, let the
 	// genericCommentEventHandler handle this event. Approval commands override
@@ -260,16 +273,17 @@ func handleReview(log *logrus.Entry, spc scmProviderClient, oc ownersClient, ser
 		spc,
 		repo,
 		serverURL,
-		optionsForRepo(config, re.Repo.Namespace, re.Repo.Name),
+		opts,
 		&state{
-			org:       re.Repo.Namespace,
-			repo:      re.Repo.Name,
-			branch:    re.PullRequest.Base.Ref,
-			number:    re.PullRequest.Number,
-			body:      re.PullRequest.Body,
-			author:    re.PullRequest.Author.Login,
-			assignees: re.PullRequest.Assignees,
-			htmlURL:   re.PullRequest.Link,
+			org:          re.Repo.Namespace,
+			repo:         re.Repo.Name,
+			branch:       re.PullRequest.Base.Ref,
+			number:       re.PullRequest.Number,
+			body:         re.PullRequest.Body,
+			author:       re.PullRequest.Author.Login,
+			assignees:    re.PullRequest.Assignees,
+			htmlURL:      re.PullRequest.Link,
+			autoApproved: autoApproved(config, re.Repo.Namespace, re.Repo.Name, re.PullRequest.Author.Login),
 		},
 	)
 
@@ -319,16 +333,17 @@ func handlePullRequest(log *logrus.Entry, spc scmProviderClient, oc ownersClient
 		spc,
 		repo,
 		serverURL,
-		optionsForRepo(config, pre.Repo.Namespace, pre.Repo.Name),
+		optionsForBranch(config, pre.Repo.Namespace, pre.Repo.Name, ref),
 		&state{
-			org:       pre.Repo.Namespace,
-			repo:      pre.Repo.Name,
-			branch:    ref,
-			number:    pre.PullRequest.Number,
-			body:      pre.PullRequest.Body,
-			author:    pre.PullRequest.Author.Login,
-			assignees: pre.PullRequest.Assignees,
-			htmlURL:   pre.PullRequest.Link,
+			org:          pre.Repo.Namespace,
+			repo:         pre.Repo.Name,
+			branch:       ref,
+			number:       pre.PullRequest.Number,
+			body:         pre.PullRequest.Body,
+			author:       pre.PullRequest.Author.Login,
+			assignees:    pre.PullRequest.Assignees,
+			htmlURL:      pre.PullRequest.Link,
+			autoApproved: autoApproved(config, pre.Repo.Namespace, pre.Repo.Name, pre.PullRequest.Author.Login),
 		},
 	)
 }
@@ -372,13 +387,19 @@ func handle(log *logrus.Entry, spc scmProviderClient, repo approvers.Repo, baseU
 	}
 
 	changes, err := spc.GetPullRequestChanges(pr.org, pr.repo, pr.number)
-	if err != nil {
+	tooManyChanges := scmprovider.IsTooManyChanges(err)
+	if err != nil && !tooManyChanges {
 		return fetchErr("PR file changes", err)
 	}
 	var filenames []string
 	for _, change := range changes {
 		filenames = append(filenames, change.Path)
 	}
+	if tooManyChanges {
+		// the files which are not listed may belong to any directory, so the approval of the root approvers is required
+		log.WithError(err).Info("Requiring the approval of the root approvers.")
+		filenames = append(filenames, rootOwnersFile)
+	}
 	issueLabels, err := spc.GetIssueLabels(pr.org, pr.repo, pr.number, true)
 	if err != nil {
 		return fetchErr("issue labels", err)
@@ -406,11 +427,17 @@ func handle(log *logrus.Entry, spc scmProviderClient, repo approvers.Repo, baseU
 	if err != nil {
 		return fetchErr("review comments", err)
 	}
-	reviews, err := spc.ListReviews(pr.org, pr.repo, pr.number)
-	if err != nil && err.Error() != scm.ErrNotSupported.Error() {
-		return fetchErr("reviews", err)
+	var reviews []*scm.Review
+	if scmprovider.CapabilitiesOf(spc.ProviderType()).Reviews {
+		reviews, err = spc.ListReviews(pr.org, pr.repo, pr.number)
+		if err != nil && err.Error() != scm.ErrNotSupported.Error() {
+			return fetchErr("reviews", err)
+		}
 	}
 
+	if len(opts.Approvers) > 0 {
+		repo = teamApprovers{Repo: repo, approvers: normLogins(opts.Approvers)}
+	}
 	approversHandler := approvers.NewApprovers(
 		approvers.NewOwners(
 			log,
@@ -425,6 +452,9 @@ func handle(log *logrus.Entry, spc scmProviderClient, repo approvers.Repo, baseU
 	}
 	approversHandler.RequireIssue = opts.IssueRequired
 	approversHandler.ManuallyApproved = humanAddedApproved(spc, log, pr.org, pr.repo, pr.number, botName, hasApprovedLabel)
+	if pr.autoApproved {
+		approversHandler.ManuallyApproved = func() bool { return true }
+	}
 
 	// Author implicitly approves their own PR if config allows it
 	if opts.HasSelfApproval() {
@@ -476,6 +506,12 @@ func handle(log *logrus.Entry, spc scmProviderClient, repo approvers.Repo, baseU
 	return nil
 }
 
+// autoApproved returns true if the pull requests of the author are approved by the dependency update profile of the repo
+func autoApproved(config *plugins.Configuration, org, repo, author string) bool {
+	update := config.DependencyUpdateFor(org, repo, author)
+	return update != nil && update.Approve
+}
+
 func humanAddedApproved(spc scmProviderClient, log *logrus.Entry, org, repo string, number int, botName string, hasLabel bool) func() bool {
 	findOut := func() bool {
 		if !hasLabel {
@@ -533,8 +569,12 @@ func isApprovalCommand(botName string, lgtmActsAsApprove bool, c *comment) bool
 
 func removeLighthouseCommandPrefix(cmd string) string {
 	cmd = strings.ToUpper(cmd)
-	if strings.HasPrefix(cmd, strings.ToUpper(util.LighthouseCommandPrefix)) {
-		cmd = strings.TrimPrefix(cmd, strings.ToUpper(util.LighthouseCommandPrefix))
+	if strings.HasPrefix(cmd, strings.ToUpper(util.CommandPrefix)) {
+		return strings.TrimPrefix(cmd, strings.ToUpper(util.CommandPrefix))
+	}
+	if util.CommandPrefixRequired {
+		// the command is meant for another bot
+		return ""
 	}
 	return cmd
 }
@@ -674,6 +714,43 @@ func optionsForRepo(config *plugins.Configuration, org, repo string) *plugins.Ap
 	return a
 }
 
+// optionsForBranch gets the plugins.Approve struct that is applicable to the pull requests of the indicated branch
+// of the repo.
+func optionsForBranch(config *plugins.Configuration, org, repo, branch string) *plugins.Approve {
+	return config.ApproveForBranch(optionsForRepo(config, org, repo), org, repo, branch)
+}
+
+// teamApprovers makes a set of logins, e.g. a release team, the approvers of every file instead of the approvers
+// of the OWNERS files.
+type teamApprovers struct {
+	approvers.Repo
+	approvers sets.String
+}
+
+func (t teamApprovers) Approvers(path string) sets.String {
+	return t.approvers
+}
+
+func (t teamApprovers) LeafApprovers(path string) sets.String {
+	return t.approvers
+}
+
+func (t teamApprovers) FindApproverOwnersForFile(file string) string {
+	return ""
+}
+
+func (t teamApprovers) IsNoParentOwners(path string) bool {
+	return false
+}
+
+func normLogins(logins []string) sets.String {
+	answer := sets.NewString()
+	for _, login := range logins {
+		answer.Insert(scmprovider.NormLogin(strings.TrimPrefix(login, "@")))
+	}
+	return answer
+}
+
 type comment struct {
 	Body        string
 	Author      string
@@ -1273,6 +1273,29 @@ Approvers can cancel approval by writing ` + "`/approve cancel`" + ` in a commen
 	}
 }
 
+func TestTeamApprovers(t *testing.T) {
+	repo := fakeRepo{
+		approvers:      map[string]sets.String{"a": sets.NewString("bob")},
+		leafApprovers:  map[string]sets.String{"a": sets.NewString("bob")},
+		approverOwners: map[string]string{"a/a.go": "a"},
+	}
+	team := teamApprovers{Repo: repo, approvers: normLogins([]string{"@Alice", "carol"})}
+	newApprovers := func() approvers.Approvers {
+		return approvers.NewApprovers(approvers.NewOwners(logrus.WithField("plugin", "approve"), []string{"a/a.go"}, team, prNumber))
+	}
+
+	ap := newApprovers()
+	ap.AddApprover("bob", "", false)
+	if ap.IsApproved() {
+		t.Error("expected the approval of an OWNERS approver not to be enough")
+	}
+	ap = newApprovers()
+	ap.AddApprover("alice", "", false)
+	if !ap.IsApproved() {
+		t.Error("expected the approval of a release team member to be enough")
+	}
+}
+
 // TODO: cache approvers 'GetFilesApprovers' and 'GetCCs' since these are called repeatedly and are
 // expensive.
 
@@ -641,8 +641,8 @@ func GetMessage(ap Approvers, linkURL *url.URL, org, repo, branch string, usePre
 		return nil
 	}
 	lhPrefix := ""
-	if usePrefix {
-		lhPrefix = util.LighthouseCommandPrefix
+	if usePrefix || util.CommandPrefixRequired {
+		lhPrefix = util.CommandPrefix
 	}
 	message, err := GenerateTemplate(`{{if (and (not .ap.RequirementsMet) (call .ap.ManuallyApproved )) }}
 Approval requirements bypassed by manually added approval.
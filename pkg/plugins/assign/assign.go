@@ -23,6 +23,7 @@ import (
 
 	"github.com/jenkins-x/go-scm/scm"
 	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	"github.com/jenkins-x/lighthouse/pkg/util"
 	"github.com/sirupsen/logrus"
 
 	"github.com/jenkins-x/lighthouse/pkg/pluginhelp"
@@ -32,9 +33,9 @@ import (
 const pluginName = "assign"
 
 var (
-	assignRe = regexp.MustCompile(`(?mi)^/(?:lh-)?(un)?assign(( @?(?:")?[-\w]+?)*(?:")?)\s*$`)
+	assignRe = regexp.MustCompile(`(?mi)^/` + util.CommandPrefixPattern + `(un)?assign(( @?(?:")?[-\w]+?)*(?:")?)\s*$`)
 	// CCRegexp parses and validates /cc commands, also used by blunderbuss
-	CCRegexp = regexp.MustCompile(`(?mi)^/(?:lh-)?(un)?cc(( +@?(?:")?[-/\w]+?)*(?:")?)\s*$`)
+	CCRegexp = regexp.MustCompile(`(?mi)^/` + util.CommandPrefixPattern + `(un)?cc(( +@?(?:")?[-/\w]+?)*(?:")?)\s*$`)
 )
 
 func init() {
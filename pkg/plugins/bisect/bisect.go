@@ -0,0 +1,305 @@
+// Package bisect implements a plugin finding the commit which broke a postsubmit, by running the postsubmit on the
+// commits between its last successful run and its first failed run, and commenting on the pull request of the commit.
+package bisect
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/jx/v2/pkg/tekton/metapipeline"
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/jobutil"
+	"github.com/jenkins-x/lighthouse/pkg/pluginhelp"
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// PluginName defines this plugin's registered name.
+	PluginName = "bisect"
+
+	// maxCommits is the maximum number of commits between the last successful run and the first failed run which
+	// are bisected
+	maxCommits = 100
+)
+
+// pullRequestRe matches the pull request number in the messages of merge and squashed commits on GitHub and GitLab
+var pullRequestRe = regexp.MustCompile(`(?:Merge pull request #(\d+)|\(#(\d+)\)\s*(?:\n|$)|See merge request [^\s!]*!(\d+))`)
+
+func init() {
+	plugins.RegisterScheduledHandler(PluginName, handleSchedule, helpProvider)
+}
+
+func helpProvider(config *plugins.Configuration, enabledRepos []string) (*pluginhelp.PluginHelp, error) {
+	// Only the Description field is specified because this plugin is not triggered with commands and is not configurable.
+	return &pluginhelp.PluginHelp{
+			Description: fmt.Sprintf("The bisect plugin finds the commit which broke a postsubmit annotated with '%s: \"true\"'. Once the postsubmit fails after a successful run, the plugin runs it on the commits in between, halving them each time, and comments on the pull request of the first failing commit. The postsubmits deploying to an environment are never bisected. The plugin runs every --schedule-interval of the hook.", util.BisectAnnotation),
+		},
+		nil
+}
+
+type scmProviderClient interface {
+	ListCommits(owner, repo string, opts scm.CommitListOptions) ([]*scm.Commit, error)
+	CreateComment(owner, repo string, number int, pr bool, comment string) error
+}
+
+type launcher interface {
+	Launch(*v1alpha1.LighthouseJob, metapipeline.Client, scm.Repository) (*v1alpha1.LighthouseJob, error)
+}
+
+type jobClient interface {
+	List(opts metav1.ListOptions) (*v1alpha1.LighthouseJobList, error)
+	Update(*v1alpha1.LighthouseJob) (*v1alpha1.LighthouseJob, error)
+}
+
+type client struct {
+	spc      scmProviderClient
+	launcher launcher
+	mpClient metapipeline.Client
+	lhClient jobClient
+	logger   *logrus.Entry
+	repo     scm.Repository
+}
+
+func handleSchedule(pc plugins.Agent, repo scm.Repository) error {
+	if pc.LighthouseClient == nil {
+		return nil
+	}
+	var postsubmits []config.Postsubmit
+	for _, ps := range pc.Config.GetPostsubmits(repo) {
+		if ps.Annotations[util.BisectAnnotation] != "true" {
+			continue
+		}
+		if ps.Annotations[util.EnvironmentAnnotation] != "" {
+			pc.Logger.WithField("job", ps.Name).Warn("Not bisecting a postsubmit deploying to an environment.")
+			continue
+		}
+		postsubmits = append(postsubmits, ps)
+	}
+	if len(postsubmits) == 0 {
+		return nil
+	}
+	c := &client{
+		spc:      pc.SCMProviderClient,
+		launcher: pc.LauncherClient,
+		mpClient: pc.MetapipelineClient,
+		lhClient: pc.LighthouseClient,
+		logger:   pc.Logger,
+		repo:     repo,
+	}
+	for i := range postsubmits {
+		if err := c.bisect(&postsubmits[i]); err != nil {
+			pc.Logger.WithError(err).WithField("job", postsubmits[i].Name).Warn("Failed to bisect the postsubmit.")
+		}
+	}
+	return nil
+}
+
+// bisect bisects the failing branches of the postsubmit
+func (c *client) bisect(ps *config.Postsubmit) error {
+	selector := labels.SelectorFromSet(labels.Set{
+		config.LighthouseJobTypeLabel: string(config.PostsubmitJob),
+		util.OrgLabel:                 strings.ToLower(c.repo.Namespace),
+		util.RepoLabel:                c.repo.Name,
+	})
+	list, err := c.lhClient.List(metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return err
+	}
+	branches := map[string][]*v1alpha1.LighthouseJob{}
+	for i := range list.Items {
+		job := &list.Items[i]
+		if job.Spec.Job == ps.Name && job.Spec.Refs != nil {
+			branches[job.Spec.Refs.BaseRef] = append(branches[job.Spec.Refs.BaseRef], job)
+		}
+	}
+	for branch, runs := range branches {
+		if err := c.bisectBranch(ps, branch, runs); err != nil {
+			c.logger.WithError(err).WithField("branch", branch).Warn("Failed to bisect the postsubmit on the branch.")
+		}
+	}
+	return nil
+}
+
+// bisectBranch runs the postsubmit on the next commit to bisect, or comments on the pull request of the commit which
+// broke it once it is found
+func (c *client) bisectBranch(ps *config.Postsubmit, branch string, runs []*v1alpha1.LighthouseJob) error {
+	var regular []*v1alpha1.LighthouseJob
+	for _, run := range runs {
+		if run.Annotations[util.BisectionAnnotation] == "" {
+			regular = append(regular, run)
+		}
+	}
+	sort.SliceStable(regular, func(i, j int) bool {
+		return regular[i].Status.StartTime.Before(&regular[j].Status.StartTime)
+	})
+	firstRed, lastGreen := brokenRange(regular)
+	if firstRed == nil || lastGreen == nil || firstRed.Annotations[util.BisectCulpritAnnotation] != "" {
+		return nil
+	}
+	log := c.logger.WithFields(logrus.Fields{"job": ps.Name, "branch": branch, "red": firstRed.Spec.Refs.BaseSHA, "green": lastGreen.Spec.Refs.BaseSHA})
+
+	commits, err := c.spc.ListCommits(c.repo.Namespace, c.repo.Name, scm.CommitListOptions{Ref: firstRed.Spec.Refs.BaseSHA, Size: maxCommits})
+	if err != nil {
+		return err
+	}
+	green := -1
+	for i, commit := range commits {
+		if commit.Sha == lastGreen.Spec.Refs.BaseSHA {
+			green = i
+			break
+		}
+	}
+	if green <= 0 {
+		log.Info("The last successful commit is not among the latest commits of the failed one, not bisecting.")
+		return c.markCulprit(firstRed, "unknown")
+	}
+
+	// the latest state of the postsubmit on each commit, the runs being sorted from the oldest to the latest
+	states := map[string]v1alpha1.PipelineState{}
+	sorted := append([]*v1alpha1.LighthouseJob{}, runs...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Status.StartTime.Before(&sorted[j].Status.StartTime)
+	})
+	for _, run := range sorted {
+		states[run.Spec.Refs.BaseSHA] = run.Status.State
+	}
+	next, culprit := nextCommit(commits[:green+1], states)
+	if culprit != nil {
+		log.WithField("culprit", culprit.Sha).Info("Found the commit which broke the postsubmit.")
+		if err := c.reportCulprit(ps, branch, firstRed, lastGreen, culprit); err != nil {
+			return err
+		}
+		return c.markCulprit(firstRed, culprit.Sha)
+	}
+	if next == nil {
+		// a run of the bisection is still pending
+		return nil
+	}
+	refs := *firstRed.Spec.Refs
+	refs.BaseSHA = next.Sha
+	refs.BaseLink = ""
+	refs.Pulls = nil
+	annotations := map[string]string{}
+	for k, v := range ps.Annotations {
+		annotations[k] = v
+	}
+	annotations[util.BisectionAnnotation] = firstRed.Spec.Refs.BaseSHA
+	pj := jobutil.NewLighthouseJob(jobutil.PostsubmitSpec(*ps, refs), ps.Labels, annotations)
+	log.WithField("sha", next.Sha).Info("Running the postsubmit to bisect its failure.")
+	_, err = c.launcher.Launch(&pj, c.mpClient, c.repo)
+	return err
+}
+
+// brokenRange returns the first failed run after the last successful one, if the latest run failed
+func brokenRange(runs []*v1alpha1.LighthouseJob) (firstRed, lastGreen *v1alpha1.LighthouseJob) {
+	for i := len(runs) - 1; i >= 0; i-- {
+		switch runs[i].Status.State {
+		case v1alpha1.FailureState:
+			firstRed = runs[i]
+		case v1alpha1.SuccessState:
+			return firstRed, runs[i]
+		case v1alpha1.ErrorState, v1alpha1.AbortedState:
+			// the job could not tell whether the commit is broken
+		default:
+			if firstRed == nil {
+				// the latest run is not finished yet
+				return nil, nil
+			}
+		}
+	}
+	return firstRed, nil
+}
+
+// nextCommit returns the commit to run the postsubmit on next, or the culprit once the failed commit following a
+// successful commit is found. The commits are ordered from the first failed one to the last successful one.
+func nextCommit(commits []*scm.Commit, states map[string]v1alpha1.PipelineState) (next, culprit *scm.Commit) {
+	good := len(commits) - 1
+	for i := 1; i < good; i++ {
+		if states[commits[i].Sha] == v1alpha1.SuccessState {
+			good = i
+			break
+		}
+	}
+	bad := 0
+	for i := good - 1; i > 0; i-- {
+		if states[commits[i].Sha] == v1alpha1.FailureState {
+			bad = i
+			break
+		}
+	}
+	if good-bad == 1 {
+		return nil, commits[bad]
+	}
+	for i := bad + 1; i < good; i++ {
+		switch states[commits[i].Sha] {
+		case "", v1alpha1.ErrorState, v1alpha1.AbortedState:
+		default:
+			// the commit is still being tested
+			return nil, nil
+		}
+	}
+	mid := (bad + good) / 2
+	if states[commits[mid].Sha] != "" {
+		// the run errored or was aborted, so the commit is skipped
+		for i := mid + 1; i < good; i++ {
+			if states[commits[i].Sha] == "" {
+				return commits[i], nil
+			}
+		}
+		for i := mid - 1; i > bad; i-- {
+			if states[commits[i].Sha] == "" {
+				return commits[i], nil
+			}
+		}
+		return nil, commits[bad]
+	}
+	return commits[mid], nil
+}
+
+// reportCulprit comments on the pull request of the commit which broke the postsubmit
+func (c *client) reportCulprit(ps *config.Postsubmit, branch string, firstRed, lastGreen *v1alpha1.LighthouseJob, culprit *scm.Commit) error {
+	number := pullRequestNumber(culprit.Message)
+	if number == 0 {
+		c.logger.WithField("culprit", culprit.Sha).Info("The commit which broke the postsubmit has no pull request to comment on.")
+		return nil
+	}
+	comment := fmt.Sprintf("The postsubmit `%s` fails on `%s` since commit %s of this pull request, found by bisecting the commits since its last successful run on %s.", ps.Name, branch, culprit.Sha, lastGreen.Spec.Refs.BaseSHA)
+	if firstRed.Status.ReportURL != "" {
+		comment += fmt.Sprintf(" See the [logs](%s) of its first failure.", firstRed.Status.ReportURL)
+	}
+	return c.spc.CreateComment(c.repo.Namespace, c.repo.Name, number, true, comment)
+}
+
+// markCulprit records the end of the bisection on the first failed run
+func (c *client) markCulprit(firstRed *v1alpha1.LighthouseJob, sha string) error {
+	job := firstRed.DeepCopy()
+	if job.Annotations == nil {
+		job.Annotations = map[string]string{}
+	}
+	job.Annotations[util.BisectCulpritAnnotation] = sha
+	_, err := c.lhClient.Update(job)
+	return err
+}
+
+// pullRequestNumber returns the number of the pull request of a merge or squashed commit, or 0 if there is none
+func pullRequestNumber(message string) int {
+	match := pullRequestRe.FindStringSubmatch(message)
+	if match == nil {
+		return 0
+	}
+	for _, group := range match[1:] {
+		if number, err := strconv.Atoi(group); err == nil {
+			return number
+		}
+	}
+	return 0
+}
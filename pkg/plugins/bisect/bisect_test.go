@@ -0,0 +1,138 @@
+package bisect
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/launcher/fake"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type fakeSCMClient struct {
+	commits  []*scm.Commit
+	comments map[int][]string
+}
+
+func (f *fakeSCMClient) ListCommits(owner, repo string, opts scm.CommitListOptions) ([]*scm.Commit, error) {
+	for i, commit := range f.commits {
+		if commit.Sha == opts.Ref {
+			return f.commits[i:], nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeSCMClient) CreateComment(owner, repo string, number int, pr bool, comment string) error {
+	f.comments[number] = append(f.comments[number], comment)
+	return nil
+}
+
+type fakeJobClient struct {
+	jobs []*v1alpha1.LighthouseJob
+}
+
+func (f *fakeJobClient) List(opts metav1.ListOptions) (*v1alpha1.LighthouseJobList, error) {
+	list := &v1alpha1.LighthouseJobList{}
+	for _, job := range f.jobs {
+		list.Items = append(list.Items, *job)
+	}
+	return list, nil
+}
+
+func (f *fakeJobClient) Update(job *v1alpha1.LighthouseJob) (*v1alpha1.LighthouseJob, error) {
+	for i := range f.jobs {
+		if f.jobs[i].Name == job.Name {
+			f.jobs[i] = job
+		}
+	}
+	return job, nil
+}
+
+func TestBisect(t *testing.T) {
+	ps := config.Postsubmit{
+		JobBase: config.JobBase{
+			Name:        "release",
+			Annotations: map[string]string{util.BisectAnnotation: "true"},
+		},
+	}
+	// the commits of the branch, the latest first, c5 breaking the postsubmit
+	spc := &fakeSCMClient{comments: map[int][]string{}}
+	for i := 9; i >= 0; i-- {
+		spc.commits = append(spc.commits, &scm.Commit{Sha: fmt.Sprintf("c%d", i), Message: fmt.Sprintf("Change %d (#%d)", i, 100+i)})
+	}
+	broken := func(sha string) bool { return sha >= "c5" }
+
+	start := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	run := func(sha string, state v1alpha1.PipelineState) *v1alpha1.LighthouseJob {
+		start = start.Add(time.Minute)
+		return &v1alpha1.LighthouseJob{
+			ObjectMeta: metav1.ObjectMeta{Name: "run-" + sha},
+			Spec: v1alpha1.LighthouseJobSpec{
+				Type: config.PostsubmitJob,
+				Job:  "release",
+				Refs: &v1alpha1.Refs{Org: "org", Repo: "repo", BaseRef: "master", BaseSHA: sha},
+			},
+			Status: v1alpha1.LighthouseJobStatus{State: state, StartTime: metav1.Time{Time: start}},
+		}
+	}
+	lhClient := &fakeJobClient{jobs: []*v1alpha1.LighthouseJob{
+		run("c0", v1alpha1.SuccessState),
+		run("c8", v1alpha1.FailureState),
+		run("c9", v1alpha1.FailureState),
+	}}
+	launcher := fake.NewLauncher()
+	c := &client{
+		spc:      spc,
+		launcher: launcher,
+		lhClient: lhClient,
+		logger:   logrus.WithField("plugin", PluginName),
+		repo:     scm.Repository{Namespace: "org", Name: "repo"},
+	}
+
+	for i := 0; i < 10 && len(spc.comments) == 0; i++ {
+		require.NoError(t, c.bisect(&ps))
+		if len(launcher.Pipelines) == 0 {
+			continue
+		}
+		// nothing more is run while the bisection run is pending
+		pending := launcher.Pipelines[0]
+		launcher.Pipelines = nil
+		assert.Equal(t, "c8", pending.Annotations[util.BisectionAnnotation])
+		pending.Status.StartTime = metav1.Time{Time: start.Add(time.Minute)}
+		pending.Status.State = v1alpha1.PendingState
+		lhClient.jobs = append(lhClient.jobs, pending)
+		require.NoError(t, c.bisect(&ps))
+		assert.Empty(t, launcher.Pipelines)
+
+		if broken(pending.Spec.Refs.BaseSHA) {
+			pending.Status.State = v1alpha1.FailureState
+		} else {
+			pending.Status.State = v1alpha1.SuccessState
+		}
+	}
+	require.Len(t, spc.comments[105], 1, "the pull request of the culprit should be commented")
+	assert.True(t, strings.Contains(spc.comments[105][0], "fails on `master` since commit c5 of this pull request"), spc.comments[105][0])
+	assert.Equal(t, "c5", lhClient.jobs[1].Annotations[util.BisectCulpritAnnotation])
+	assert.LessOrEqual(t, len(lhClient.jobs), 3+3, "the commits should be bisected")
+
+	// the bisection is over
+	require.NoError(t, c.bisect(&ps))
+	assert.Empty(t, launcher.Pipelines)
+	assert.Len(t, spc.comments[105], 1)
+}
+
+func TestPullRequestNumber(t *testing.T) {
+	assert.Equal(t, 12, pullRequestNumber("Merge pull request #12 from someone/branch\n\nFix things"))
+	assert.Equal(t, 34, pullRequestNumber("Fix things (#34)\n\n* details"))
+	assert.Equal(t, 56, pullRequestNumber("Merge branch 'fix' into 'master'\n\nFix things\n\nSee merge request group/project!56"))
+	assert.Equal(t, 0, pullRequestNumber("Fix #78 directly on master"))
+}
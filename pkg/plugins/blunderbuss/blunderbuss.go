@@ -0,0 +1,85 @@
+// Package blunderbuss implements a plugin requesting the reviews of the reviewers of the modules changed by pull
+// requests.
+package blunderbuss
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse/pkg/pluginhelp"
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	// PluginName defines this plugin's registered name.
+	PluginName = "blunderbuss"
+)
+
+func init() {
+	plugins.RegisterPullRequestHandler(PluginName, handlePullRequest, helpProvider)
+}
+
+func helpProvider(config *plugins.Configuration, enabledRepos []string) (*pluginhelp.PluginHelp, error) {
+	configInfo := map[string]string{}
+	if config.Blunderbuss.MaxReviewerCount > 0 {
+		for _, repo := range enabledRepos {
+			configInfo[repo] = fmt.Sprintf("At most %d reviewers are requested.", config.Blunderbuss.MaxReviewerCount)
+		}
+	}
+	return &pluginhelp.PluginHelp{
+			Description: "The blunderbuss plugin requests reviews from the reviewers of the modules a pull request changes, as configured in the 'modules' section of the plugin configuration, when the pull request is opened or ready for review. The author of the pull request is never requested, and 'max_request_count' limits the number of reviewers picked at random.",
+			Config:      configInfo,
+		},
+		nil
+}
+
+type scmProviderClient interface {
+	GetPullRequestChanges(org, repo string, number int) ([]*scm.Change, error)
+	RequestReview(org, repo string, number int, logins []string) error
+}
+
+func handlePullRequest(pc plugins.Agent, pe scm.PullRequestHook) error {
+	if pe.Action != scm.ActionOpen && pe.Action != scm.ActionReopen && pe.Action != scm.ActionReadyForReview {
+		return nil
+	}
+	if pe.PullRequest.Draft {
+		return nil
+	}
+	modules := pc.PluginConfig.ModulesFor(pe.Repo.Namespace, pe.Repo.Name)
+	if len(modules) == 0 {
+		return nil
+	}
+	return handle(pc.SCMProviderClient, pc.Logger, modules, pc.PluginConfig.Blunderbuss.MaxReviewerCount, &pe.PullRequest)
+}
+
+func handle(spc scmProviderClient, log *logrus.Entry, modules []plugins.Module, maxReviewers int, pr *scm.PullRequest) error {
+	org, repo := pr.Base.Repo.Namespace, pr.Base.Repo.Name
+	// the reviewers of the files which are listed are requested if the PR changes too many files
+	changes, err := spc.GetPullRequestChanges(org, repo, pr.Number)
+	if err != nil && !scmprovider.IsTooManyChanges(err) {
+		return fmt.Errorf("error getting PR changes: %v", err)
+	}
+	var paths []string
+	for _, change := range changes {
+		paths = append(paths, change.Path)
+	}
+	reviewers := sets.NewString(plugins.ModuleReviewers(plugins.ChangedModules(modules, paths))...)
+	reviewers.Delete(pr.Author.Login)
+	for _, r := range pr.Reviewers {
+		reviewers.Delete(r.Login)
+	}
+	if reviewers.Len() == 0 {
+		return nil
+	}
+	logins := reviewers.List()
+	if maxReviewers > 0 && len(logins) > maxReviewers {
+		rand.Shuffle(len(logins), func(i, j int) { logins[i], logins[j] = logins[j], logins[i] })
+		logins = logins[:maxReviewers]
+	}
+	log.Infof("Requesting reviews from the module reviewers %v.", logins)
+	return spc.RequestReview(org, repo, pr.Number, logins)
+}
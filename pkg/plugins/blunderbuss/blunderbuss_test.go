@@ -0,0 +1,76 @@
+package blunderbuss
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSCMClient struct {
+	changes   []*scm.Change
+	requested []string
+}
+
+func (f *fakeSCMClient) GetPullRequestChanges(org, repo string, number int) ([]*scm.Change, error) {
+	return f.changes, nil
+}
+
+func (f *fakeSCMClient) RequestReview(org, repo string, number int, logins []string) error {
+	f.requested = append(f.requested, logins...)
+	return nil
+}
+
+func TestHandle(t *testing.T) {
+	modules := []plugins.Module{
+		{Name: "api", Paths: []string{"services/api"}, Reviewers: []string{"alice", "bob"}},
+		{Name: "web", Paths: []string{"web"}, Reviewers: []string{"carol"}},
+		{Name: "docs", Paths: []string{"docs"}, Reviewers: []string{"dave"}},
+	}
+	pr := &scm.PullRequest{
+		Number:    1,
+		Author:    scm.User{Login: "bob"},
+		Reviewers: []scm.User{{Login: "carol"}},
+		Base:      scm.PullRequestBranch{Repo: scm.Repository{Namespace: "org", Name: "repo"}},
+	}
+	log := logrus.WithField("plugin", PluginName)
+
+	testCases := []struct {
+		name         string
+		changes      []string
+		maxReviewers int
+		expected     []string
+	}{
+		{
+			name:    "no module",
+			changes: []string{"README.md", "services/apis/main.go"},
+		},
+		{
+			name:     "author and requested reviewers are left out",
+			changes:  []string{"services/api/main.go", "web/index.html"},
+			expected: []string{"alice"},
+		},
+		{
+			name:     "several modules",
+			changes:  []string{"services/api/main.go", "docs/index.md"},
+			expected: []string{"alice", "dave"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			spc := &fakeSCMClient{}
+			for _, path := range tc.changes {
+				spc.changes = append(spc.changes, &scm.Change{Path: path})
+			}
+			require.NoError(t, handle(spc, log, modules, tc.maxReviewers, pr))
+			assert.Equal(t, tc.expected, spc.requested)
+		})
+	}
+
+	spc := &fakeSCMClient{changes: []*scm.Change{{Path: "services/api/main.go"}, {Path: "docs/index.md"}}}
+	require.NoError(t, handle(spc, log, modules, 1, pr))
+	assert.Len(t, spc.requested, 1, "max_request_count should limit the reviewers")
+}
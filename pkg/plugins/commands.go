@@ -0,0 +1,299 @@
+package plugins
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse/pkg/pluginhelp"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+)
+
+// CommandPermission is who can use a command
+type CommandPermission string
+
+const (
+	// CommandAnyone lets anyone use the command
+	CommandAnyone CommandPermission = ""
+	// CommandCollaborator lets the collaborators of the repository use the command
+	CommandCollaborator CommandPermission = "collaborator"
+	// CommandOrgMember lets the members of the org of the repository use the command
+	CommandOrgMember CommandPermission = "org-member"
+)
+
+// HelpCommandName is the command answered with the commands of the plugins enabled for the repository, which is
+// always used with the command prefix as /help is a command of the help plugin
+var HelpCommandName = util.CommandPrefix + "help"
+
+// HelpCommandRe matches the command answered with the commands of the plugins enabled for the repository
+var HelpCommandRe = regexp.MustCompile(`(?mi)^/` + regexp.QuoteMeta(HelpCommandName) + `\s*$`)
+
+// CommandArgs is the grammar of the arguments of a command
+type CommandArgs struct {
+	// Usage describes the arguments in the help, e.g. "[cancel]"
+	Usage string
+	// Pattern is the regular expression the arguments match, e.g. "cancel"
+	Pattern string
+	// Optional lets the command be used without arguments
+	Optional bool
+}
+
+// CommandMatch is a command found in a comment
+type CommandMatch struct {
+	// Name is the name the command was used with, which is one of its aliases
+	Name string
+	// Args are the arguments of the command, which are empty if it was used without
+	Args string
+}
+
+// CommandHandler handles a command found in a comment
+type CommandHandler func(match CommandMatch, agent Agent, e scmprovider.GenericCommentEvent) error
+
+// Command declares a command of a plugin, from which the plugin help and the handling of the comments using it are
+// generated
+type Command struct {
+	// Name is the name of the command, which is used as /name or /lh-name, or only with the command prefix if it
+	// is required
+	Name string
+	// Aliases are the other names of the command
+	Aliases []string
+	// Args is the grammar of the arguments of the command, which has none if nil
+	Args *CommandArgs
+	// Description is a short description of what the command does
+	Description string
+	// Featured highlights the command in the help
+	Featured bool
+	// Permission is who can use the command. The other users are told that they cannot.
+	Permission CommandPermission
+	// WhoCanUse describes who can use the command in the help, if it is not described by the permission alone
+	WhoCanUse string
+	// Examples are examples of using the command, which default to its name
+	Examples []string
+	// Handler handles each use of the command in the comments created on issues and pull requests
+	Handler CommandHandler
+
+	re *regexp.Regexp
+}
+
+// Plugin declares a plugin, from which its help and handlers are generated
+type Plugin struct {
+	// Description is a description of what the plugin does
+	Description string
+	// ConfigHelp returns the description of the configuration of the plugin for the repositories it is enabled for,
+	// by org/repo
+	ConfigHelp func(config *Configuration, enabledRepos []string) (map[string]string, error)
+	// Commands are the commands of the plugin
+	Commands []Command
+}
+
+// RegisterPlugin registers the help of a plugin and the handler of its commands, generating them from its
+// declaration
+func RegisterPlugin(name string, plugin Plugin) {
+	commands := make([]Command, 0, len(plugin.Commands))
+	for _, command := range plugin.Commands {
+		command.re = regexp.MustCompile(command.pattern())
+		commands = append(commands, command)
+	}
+	plugin.Commands = commands
+	help := plugin.help
+	if len(commands) == 0 {
+		pluginHelp[name] = help
+		return
+	}
+	RegisterGenericCommentHandler(name, plugin.handleGenericComment, help)
+}
+
+// Match returns the uses of the command in the comment
+func (c *Command) Match(body string) []CommandMatch {
+	re := c.re
+	if re == nil {
+		re = regexp.MustCompile(c.pattern())
+	}
+	var answer []CommandMatch
+	for _, match := range re.FindAllStringSubmatch(body, -1) {
+		m := CommandMatch{Name: strings.ToLower(match[1])}
+		if len(match) > 2 {
+			m.Args = strings.TrimSpace(match[2])
+		}
+		answer = append(answer, m)
+	}
+	return answer
+}
+
+func (c *Command) pattern() string {
+	names := []string{regexp.QuoteMeta(c.Name)}
+	for _, alias := range c.Aliases {
+		names = append(names, regexp.QuoteMeta(alias))
+	}
+	pattern := `(?mi)^/` + util.CommandPrefixPattern + `(` + strings.Join(names, "|") + `)`
+	if c.Args != nil {
+		args := `[ \t]+(` + c.Args.Pattern + `)`
+		if c.Args.Optional {
+			args = `(?:` + args + `)?`
+		}
+		pattern += args
+	}
+	return pattern + `\s*$`
+}
+
+func (c *Command) usage() string {
+	usage := "/" + c.Name
+	if c.Args != nil && c.Args.Usage != "" {
+		usage += " " + c.Args.Usage
+	}
+	return usage
+}
+
+func (c *Command) whoCanUse() string {
+	if c.WhoCanUse != "" {
+		return c.WhoCanUse
+	}
+	return c.permitted() + "."
+}
+
+// permitted describes the users who have the permission of the command
+func (c *Command) permitted() string {
+	switch c.Permission {
+	case CommandCollaborator:
+		return "Collaborators of the repository"
+	case CommandOrgMember:
+		return "Members of the org of the repository"
+	default:
+		return "Anyone"
+	}
+}
+
+// allowed returns true if the user has the permission of the command in the repository
+func (c *Command) allowed(spc *scmprovider.Client, org, repo, user string) (bool, error) {
+	switch c.Permission {
+	case CommandCollaborator:
+		return spc.IsCollaborator(org, repo, user)
+	case CommandOrgMember:
+		return spc.IsMember(org, user)
+	default:
+		return true, nil
+	}
+}
+
+func (p Plugin) help(config *Configuration, enabledRepos []string) (*pluginhelp.PluginHelp, error) {
+	answer := &pluginhelp.PluginHelp{Description: p.Description}
+	if p.ConfigHelp != nil {
+		configInfo, err := p.ConfigHelp(config, enabledRepos)
+		if err != nil {
+			return nil, err
+		}
+		answer.Config = configInfo
+	}
+	for i := range p.Commands {
+		c := &p.Commands[i]
+		examples := c.Examples
+		if len(examples) == 0 {
+			examples = []string{"/" + c.Name, "/lh-" + c.Name}
+		}
+		answer.AddCommand(pluginhelp.Command{
+			Usage:       c.usage(),
+			Description: c.Description,
+			Featured:    c.Featured,
+			WhoCanUse:   c.whoCanUse(),
+			Examples:    examples,
+		})
+	}
+	return answer, nil
+}
+
+func (p Plugin) handleGenericComment(agent Agent, e scmprovider.GenericCommentEvent) error {
+	if e.Action != scm.ActionCreate {
+		return nil
+	}
+	org, repo := e.Repo.Namespace, e.Repo.Name
+	var errs []string
+	for i := range p.Commands {
+		c := &p.Commands[i]
+		for _, match := range c.Match(e.Body) {
+			allowed, err := c.allowed(agent.SCMProviderClient, org, repo, e.Author.Login)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("checking if %s can use /%s: %v", e.Author.Login, match.Name, err))
+				continue
+			}
+			if !allowed {
+				agent.Logger.Infof("%s cannot use /%s.", e.Author.Login, match.Name)
+				React(agent.SCMProviderClient, agent.Logger, e, scmprovider.ReactionRejected)
+				reply := fmt.Sprintf("you cannot use `/%s`. %s can use it.", match.Name, c.permitted())
+				if err := agent.SCMProviderClient.CreateComment(org, repo, e.Number, e.IsPR, FormatResponseRaw(e.Body, e.Link, agent.SCMProviderClient.QuoteAuthorForComment(e.Author.Login), reply)); err != nil {
+					errs = append(errs, err.Error())
+				}
+				continue
+			}
+			if err := c.Handler(match, agent, e); err != nil {
+				errs = append(errs, fmt.Sprintf("/%s: %v", match.Name, err))
+				continue
+			}
+			React(agent.SCMProviderClient, agent.Logger, e, scmprovider.ReactionAccepted)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to handle the commands: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// RepoPluginHelp is the help of a plugin enabled for a repository
+type RepoPluginHelp struct {
+	Name string                `json:"name"`
+	Help pluginhelp.PluginHelp `json:"help"`
+}
+
+// RepoHelp is the help of the plugins enabled for a repository
+type RepoHelp struct {
+	Repo            string           `json:"repo"`
+	Plugins         []RepoPluginHelp `json:"plugins"`
+	ExternalPlugins []ExternalPlugin `json:"externalPlugins,omitempty"`
+}
+
+// HelpForRepo returns the help of the plugins enabled for the repository, sorted by name. The configuration of each
+// plugin only describes the configuration applying to the repository.
+func HelpForRepo(config *Configuration, org, repo string) *RepoHelp {
+	fullName := scm.Join(org, repo)
+	answer := &RepoHelp{Repo: fullName, Plugins: []RepoPluginHelp{}, ExternalPlugins: config.ExternalPluginsFor(org, repo)}
+	names := config.PluginsFor(org, repo)
+	sort.Strings(names)
+	for _, name := range names {
+		provider, ok := pluginHelp[name]
+		if !ok || provider == nil {
+			continue
+		}
+		help, err := provider(config, []string{fullName})
+		if err != nil || help == nil {
+			continue
+		}
+		repoConfig := map[string]string{}
+		for _, key := range []string{"", org, fullName} {
+			if text, ok := help.Config[key]; ok {
+				repoConfig[key] = text
+			}
+		}
+		help.Config = repoConfig
+		answer.Plugins = append(answer.Plugins, RepoPluginHelp{Name: name, Help: *help})
+	}
+	return answer
+}
+
+// HelpResponse returns the commands of the plugins enabled for the repository as a markdown table, generated from
+// the help of the plugins
+func HelpResponse(config *Configuration, org, repo string) string {
+	lines := []string{"| Command | Plugin | Description | Who can use it |", "| --- | --- | --- | --- |"}
+	for _, plugin := range HelpForRepo(config, org, repo).Plugins {
+		for _, c := range plugin.Help.Commands {
+			lines = append(lines, fmt.Sprintf("| `%s` | %s | %s | %s |", c.Usage, plugin.Name, tableCell(c.Description), tableCell(c.WhoCanUse)))
+		}
+	}
+	lines = append(lines, fmt.Sprintf("| `/%s` | | Lists the commands available in this repository. | Anyone. |", HelpCommandName))
+	return "The following commands are available in this repository:\n\n" + strings.Join(lines, "\n")
+}
+
+func tableCell(text string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(text)
+}
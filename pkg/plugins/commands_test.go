@@ -0,0 +1,101 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/go-scm/scm/driver/fake"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommandMatch(t *testing.T) {
+	c := Command{Name: "retitle", Aliases: []string{"rename"}, Args: &CommandArgs{Usage: "<title>", Pattern: ".+"}}
+	assert.Equal(t, []CommandMatch{{Name: "retitle", Args: "new title"}, {Name: "rename", Args: "other"}}, c.Match("/retitle new title\n/LH-RENAME other\n/retitle\n"))
+
+	c = Command{Name: "hold", Args: &CommandArgs{Pattern: "cancel", Optional: true}}
+	assert.Equal(t, []CommandMatch{{Name: "hold"}, {Name: "hold", Args: "cancel"}}, c.Match("/hold\n/hold cancel\n/hold other"))
+
+	c = Command{Name: "ping"}
+	assert.Empty(t, c.Match("/ping me\n /ping\n/pingpong"))
+}
+
+func TestRegisterPlugin(t *testing.T) {
+	var handled []CommandMatch
+	RegisterPlugin("command-test", Plugin{
+		Description: "Tests the commands.",
+		ConfigHelp: func(config *Configuration, enabledRepos []string) (map[string]string, error) {
+			return map[string]string{"": "Not configurable."}, nil
+		},
+		Commands: []Command{
+			{
+				Name:        "ping",
+				Description: "Replies | pong.",
+				Handler: func(match CommandMatch, agent Agent, e scmprovider.GenericCommentEvent) error {
+					handled = append(handled, match)
+					return nil
+				},
+			},
+			{
+				Name:        "deploy",
+				Args:        &CommandArgs{Usage: "<env>", Pattern: `\w+`},
+				Description: "Deploys.",
+				Permission:  CommandCollaborator,
+				Handler: func(match CommandMatch, agent Agent, e scmprovider.GenericCommentEvent) error {
+					handled = append(handled, match)
+					return nil
+				},
+			},
+		},
+	})
+	defer func() {
+		delete(pluginHelp, "command-test")
+		delete(genericCommentHandlers, "command-test")
+	}()
+
+	help, err := HelpProviders()["command-test"](&Configuration{}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Tests the commands.", help.Description)
+	assert.Equal(t, map[string]string{"": "Not configurable."}, help.Config)
+	require.Len(t, help.Commands, 2)
+	assert.Equal(t, "/ping", help.Commands[0].Usage)
+	assert.Equal(t, []string{"/ping", "/lh-ping"}, help.Commands[0].Examples)
+	assert.Equal(t, "Anyone.", help.Commands[0].WhoCanUse)
+	assert.Equal(t, "/deploy <env>", help.Commands[1].Usage)
+	assert.Equal(t, "Collaborators of the repository.", help.Commands[1].WhoCanUse)
+
+	client, data := fake.NewDefault()
+	data.Collaborators = []string{"alice"}
+	agent := Agent{SCMProviderClient: scmprovider.ToClient(client, "bot"), Logger: logrus.WithField("plugin", "command-test")}
+	e := scmprovider.GenericCommentEvent{
+		IsPR:   true,
+		Action: scm.ActionCreate,
+		Body:   "/ping\n/deploy staging",
+		Number: 1,
+		Repo:   scm.Repository{Namespace: "org", Name: "repo"},
+		Author: scm.User{Login: "bob"},
+	}
+	handler := genericCommentHandlers["command-test"]
+	require.NoError(t, handler(agent, e))
+	assert.Equal(t, []CommandMatch{{Name: "ping"}}, handled)
+	require.Len(t, data.PullRequestComments[1], 1)
+	assert.Contains(t, data.PullRequestComments[1][0].Body, "you cannot use `/deploy`. Collaborators of the repository can use it.")
+
+	handled = nil
+	e.Author.Login = "alice"
+	require.NoError(t, handler(agent, e))
+	assert.Equal(t, []CommandMatch{{Name: "ping"}, {Name: "deploy", Args: "staging"}}, handled)
+
+	handled = nil
+	e.Action = scm.ActionUpdate
+	require.NoError(t, handler(agent, e))
+	assert.Empty(t, handled, "the commands of edited comments should be ignored")
+
+	response := HelpResponse(&Configuration{Plugins: map[string][]string{"org": {"command-test"}}}, "org", "repo")
+	assert.Contains(t, response, "| `/ping` | command-test | Replies \\| pong. | Anyone. |")
+	assert.Contains(t, response, "| `/deploy <env>` | command-test | Deploys. | Collaborators of the repository. |")
+	assert.Contains(t, response, "`/lh-help`")
+	assert.NotContains(t, HelpResponse(&Configuration{}, "org", "repo"), "/ping")
+}
@@ -19,10 +19,13 @@ package plugins
 import (
 	"errors"
 	"fmt"
+	"net/url"
 	"path"
+	"reflect"
 	"regexp"
 	"strings"
 	"sync"
+	"text/template"
 	"time"
 
 	"github.com/jenkins-x/lighthouse/pkg/labels"
@@ -34,12 +37,30 @@ import (
 const (
 	defaultBlunderbussReviewerCount = 2
 	failOnMissingPlugin             = false
+	defaultCommentEditWindow        = 10 * time.Minute
+	defaultCommandLoopLimit         = 3
+	defaultCommandLoopWindow        = time.Hour
+	defaultDownstreamAttempts       = 3
+	defaultDownstreamBackoff        = time.Second
+	defaultReminderAfter            = 72 * time.Hour
+
+	// DefaultsKey is the key of the plugins enabled for every repository, and the repo of the trigger
+	// applying to every repository, which the orgs and the repos inherit
+	DefaultsKey = "*"
+	// disabledPluginPrefix prefixes the plugins enabled for every repository or for an org which a more
+	// specific org or repo disables
+	disabledPluginPrefix = "-"
 )
 
+// defaultTriagePrefixes are the prefixes of the labels the checked boxes of the templates apply by default
+var defaultTriagePrefixes = []string{"kind", "area"}
+
 // Configuration is the top-level serialization target for plugin Configuration.
 type Configuration struct {
 	// Plugins is a map of repositories (eg "k/k") to lists of
-	// plugin names.
+	// plugin names. The plugins of the "*" key are enabled for every
+	// repository, and a repository inherits the plugins of its org, unless
+	// they are listed with a "-" prefix, e.g. "-cat".
 	// TODO: Link to the list of supported plugins.
 	// https://github.com/kubernetes/test-infra/issues/3476
 	Plugins map[string][]string `json:"plugins,omitempty"`
@@ -48,9 +69,37 @@ type Configuration struct {
 	// external plugins.
 	ExternalPlugins map[string][]ExternalPlugin `json:"external_plugins,omitempty"`
 
+	// Providers holds the plugins of the SCM providers served in addition to
+	// the default one, keyed by the name of the provider.
+	Providers map[string]ProviderPlugins `json:"providers,omitempty"`
+
 	// Owners contains configuration related to handling OWNERS files.
 	Owners Owners `json:"owners,omitempty"`
 
+	// ChatOpsPolicy authorizes chat commands with an external policy engine.
+	ChatOpsPolicy ChatOpsPolicy `json:"chatops_policy,omitempty"`
+
+	// Comments configures how the bot comments on the pull requests of repos.
+	Comments []Comments `json:"comments,omitempty"`
+
+	// CommentEdits configures the repos whose commands added by editing a comment are handled.
+	CommentEdits []CommentEdits `json:"comment_edits,omitempty"`
+
+	// Downstreams are the endpoints the original payload of the webhooks is forwarded to once they are handled.
+	Downstreams []Downstream `json:"downstreams,omitempty"`
+
+	// CommandGuards configures the users and bots whose commands are ignored, to prevent automation feedback loops.
+	CommandGuards []CommandGuard `json:"command_guards,omitempty"`
+
+	// BranchOverrides override the trigger, lgtm and approve configuration of the pull requests of some branches.
+	BranchOverrides []BranchOverride `json:"branch_overrides,omitempty"`
+
+	// Modules maps the directories of monorepos to modules with their own presubmits, reviewers and labels.
+	Modules []Module `json:"modules,omitempty"`
+
+	// DependencyUpdates are the profiles of the pull requests of dependency update bots.
+	DependencyUpdates []DependencyUpdate `json:"dependency_updates,omitempty"`
+
 	// Built-in plugins specific configuration.
 	Approve                    []Approve              `json:"approve,omitempty"`
 	UseDeprecatedSelfApprove   bool                   `json:"use_deprecated_2018_implicit_self_approve_default_migrate_before_july_2019,omitempty"`
@@ -64,12 +113,16 @@ type Configuration struct {
 	Heart                      Heart                  `json:"heart,omitempty"`
 	Label                      Label                  `json:"label,omitempty"`
 	Lgtm                       []Lgtm                 `json:"lgtm,omitempty"`
+	Previews                   []Preview              `json:"previews,omitempty"`
+	Promotions                 []Promotion            `json:"promotions,omitempty"`
+	Reminders                  []Reminder             `json:"reminders,omitempty"`
 	RepoMilestone              map[string]Milestone   `json:"repo_milestone,omitempty"`
 	RequireMatchingLabel       []RequireMatchingLabel `json:"require_matching_label,omitempty"`
 	RequireSIG                 RequireSIG             `json:"requiresig,omitempty"`
 	Slack                      Slack                  `json:"slack,omitempty"`
 	SigMention                 SigMention             `json:"sigmention,omitempty"`
 	Size                       Size                   `json:"size,omitempty"`
+	Triage                     []Triage               `json:"triage,omitempty"`
 	Triggers                   []Trigger              `json:"triggers,omitempty"`
 	Welcome                    []Welcome              `json:"welcome,omitempty"`
 }
@@ -96,6 +149,212 @@ type ExternalPlugin struct {
 	Events []string `json:"events,omitempty"`
 }
 
+// Downstream is an endpoint the hook forwards the webhooks to once it handled them, e.g. a legacy Jenkins or an
+// analytics collector, instead of the SCM duplicating the webhooks. The original headers and payload are forwarded,
+// so the endpoint can validate their signature with the HMAC token of the hook.
+type Downstream struct {
+	// Name of the downstream endpoint, used in the logs.
+	Name string `json:"name"`
+	// Endpoint is the URL the webhooks are posted to.
+	Endpoint string `json:"endpoint"`
+	// Repos is either of the form org/repos or just org. The webhooks of
+	// every repository are forwarded if it is empty.
+	Repos []string `json:"repos,omitempty"`
+	// ExcludeRepos are the org/repo repositories whose webhooks are not
+	// forwarded.
+	ExcludeRepos []string `json:"exclude_repos,omitempty"`
+	// Events are the kinds of webhooks forwarded, e.g. push or
+	// pull_request. Every kind is forwarded if it is empty.
+	Events []string `json:"events,omitempty"`
+	// Attempts is how many times a webhook is posted before giving up.
+	// Defaults to 3.
+	Attempts int `json:"attempts,omitempty"`
+	// Backoff is how long to wait after the first failed attempt, doubled
+	// after each further one, e.g. 5s. Defaults to 1s.
+	Backoff         string        `json:"backoff,omitempty"`
+	BackoffDuration time.Duration `json:"-"`
+}
+
+// ChatOpsPolicy configures the policy every chat command is evaluated against before the plugins handle it.
+type ChatOpsPolicy struct {
+	// URL of the Open Policy Agent document deciding whether a command is
+	// allowed, e.g. http://localhost:8181/v1/data/lighthouse/chatops. The
+	// document is evaluated with the user, repo, command and labels as input
+	// and is either a boolean or an object with allow and reason fields. No
+	// policy is evaluated if it is empty.
+	URL string `json:"url,omitempty"`
+	// Timeout of a policy evaluation. Defaults to 10s.
+	Timeout         string        `json:"timeout,omitempty"`
+	TimeoutDuration time.Duration `json:"-"`
+	// IncludeTeams adds the teams of the org the user belongs to to the
+	// input. This lists the members of every team of the org for each
+	// comment with commands.
+	IncludeTeams bool `json:"include_teams,omitempty"`
+	// FailOpen allows commands when the policy cannot be evaluated. By
+	// default they are denied.
+	FailOpen bool `json:"fail_open,omitempty"`
+}
+
+// Comments configures how the bot comments on the pull requests of a set of repos.
+type Comments struct {
+	// Repos is either of the form org/repos or just org.
+	Repos []string `json:"repos,omitempty"`
+	// SingleReport maintains a single "Lighthouse report" comment per pull
+	// request which is edited in place with the current results of every
+	// job, the actions required to get them passing and the summaries of
+	// the failures. By default a comment listing the failed jobs is posted
+	// again each time a job fails.
+	SingleReport bool `json:"single_report,omitempty"`
+}
+
+// ProviderPlugins are the plugins enabled on the repositories of an SCM provider other than the default one,
+// which is useful when the same org and repository names exist on several providers. A provider without this
+// section uses the plugins of the default provider.
+type ProviderPlugins struct {
+	// Plugins replaces the Plugins of the configuration for the provider.
+	Plugins map[string][]string `json:"plugins,omitempty"`
+	// ExternalPlugins replaces the ExternalPlugins of the configuration for
+	// the provider.
+	ExternalPlugins map[string][]ExternalPlugin `json:"external_plugins,omitempty"`
+}
+
+// CommentEdits handles the commands added by editing a comment of a set of repos, so that a mistyped command
+// can be fixed in place rather than in a new comment. Only the commands which were not in the comment before
+// the edit are handled.
+type CommentEdits struct {
+	// Repos is either of the form org/repos or just org.
+	Repos []string `json:"repos,omitempty"`
+	// Window is how long after a comment was posted edits to it are
+	// handled, e.g. 10m. Defaults to 10m.
+	Window         string        `json:"window,omitempty"`
+	WindowDuration time.Duration `json:"-"`
+}
+
+// CommandGuard configures whose comments are treated as commands on a set of repos. The comments of the bot
+// itself are never treated as commands, whether or not a CommandGuard is configured.
+type CommandGuard struct {
+	// Repos is either of the form org/repos or just org.
+	Repos []string `json:"repos,omitempty"`
+	// IgnoredUsers are the logins whose comments are never treated as
+	// commands, e.g. the bots of other CI systems.
+	IgnoredUsers []string `json:"ignored_users,omitempty"`
+	// Bots are the logins of the automation accounts allowed to use
+	// commands, e.g. a release bot commenting /retest. Logins ending with
+	// [bot] are bots too. A command a bot repeats loop_limit times on the
+	// same pull request or issue within loop_window is ignored from then on.
+	Bots []string `json:"bots,omitempty"`
+	// LoopLimit is the number of times a bot may use the same command within
+	// loop_window. Defaults to 3.
+	LoopLimit int `json:"loop_limit,omitempty"`
+	// LoopWindow is how long the commands of bots are remembered, e.g. 30m.
+	// Defaults to 1h.
+	LoopWindow         string        `json:"loop_window,omitempty"`
+	LoopWindowDuration time.Duration `json:"-"`
+}
+
+// BranchOverride overrides the configuration of the trigger, lgtm and approve plugins for the pull requests of the
+// matching base branches of a set of repos, so that long-lived release branches can have stricter policies than the
+// default branch. Only the fields which are set override the configuration of the repos.
+type BranchOverride struct {
+	// Repos is either of the form org/repos or just org.
+	Repos []string `json:"repos,omitempty"`
+	// Branches are regular expressions matching the whole name of the
+	// base branches of the pull requests, e.g. release-.*
+	Branches []string         `json:"branches,omitempty"`
+	BranchRe []*regexp.Regexp `json:"-"`
+	// Trigger overrides the trigger configuration of the repos, e.g. to
+	// trust fewer users.
+	Trigger *Trigger `json:"trigger,omitempty"`
+	// Lgtm overrides the lgtm configuration of the repos.
+	Lgtm *Lgtm `json:"lgtm,omitempty"`
+	// Approve overrides the approve configuration of the repos, e.g. to
+	// require the approval of a release team.
+	Approve *Approve `json:"approve,omitempty"`
+}
+
+// Matches returns true if the override applies to the pull requests of the branch
+func (o *BranchOverride) Matches(branch string) bool {
+	for _, re := range o.BranchRe {
+		if re.MatchString(branch) {
+			return true
+		}
+	}
+	return false
+}
+
+// Preview is the configuration of the preview plugin for a set of repos.
+type Preview struct {
+	// Repos is either of the form org/repos or just org.
+	Repos []string `json:"repos,omitempty"`
+	// Job is the name of the presubmit deploying the preview environment of
+	// a pull request.
+	Job string `json:"job"`
+	// CleanupJob is the name of the presubmit tearing the preview
+	// environment down once the pull request is closed or merged.
+	CleanupJob string `json:"cleanup_job,omitempty"`
+	// Auto deploys the preview environment when the pull request is opened
+	// and on each new commit, rather than only on /preview.
+	Auto bool `json:"auto,omitempty"`
+	// URL is a template of the URL of the preview environment, which may use
+	// the Org, Repo, Number, Author, Branch and SHA of the pull request, e.g.
+	// https://pr-{{ .Number }}.{{ .Repo }}.preview.example.com
+	URL string `json:"url,omitempty"`
+}
+
+// Promotion is the configuration of the promote plugin for a set of repos.
+type Promotion struct {
+	// Repos is either of the form org/repos or just org.
+	Repos []string `json:"repos,omitempty"`
+	// Job is the name of the job promoting an application to an environment,
+	// which is the presubmit of that name when /promote is commented on a
+	// pull request and the postsubmit of that name, run against the head of
+	// the default branch, when it is commented on an issue. The job gets the
+	// application and environment as the PROMOTE_APP and PROMOTE_ENVIRONMENT
+	// environment variables and the App and Environment pipeline parameters.
+	Job string `json:"job"`
+	// Teams are the slugs of the teams of the org whose members may promote.
+	Teams []string `json:"teams"`
+	// Apps are the applications which can be promoted, any if empty.
+	Apps []string `json:"apps,omitempty"`
+	// Environments are the environments applications can be promoted to,
+	// any if empty.
+	Environments []string `json:"environments,omitempty"`
+}
+
+// Reminder is the configuration of the reminder plugin for a set of repos.
+type Reminder struct {
+	// Repos is either of the form org/repos or just org.
+	Repos []string `json:"repos,omitempty"`
+	// ExcludeRepos are the org/repo repositories of the orgs in Repos
+	// which opted out of the reminders.
+	ExcludeRepos []string `json:"exclude_repos,omitempty"`
+	// After is how long a pull request awaits review before its reviewers
+	// and assignees are reminded, e.g. 72h. Defaults to 72h.
+	After         string        `json:"after,omitempty"`
+	AfterDuration time.Duration `json:"-"`
+	// EscalateAfter is how long a pull request awaits review before the
+	// approvers in the OWNERS files of its changes are pinged too, e.g.
+	// 168h. Reminders are not escalated if it is empty.
+	EscalateAfter         string        `json:"escalate_after,omitempty"`
+	EscalateAfterDuration time.Duration `json:"-"`
+}
+
+// Triage is the configuration of the triage plugin for a set of repos.
+type Triage struct {
+	// Repos is either of the form org/repos or just org.
+	Repos []string `json:"repos,omitempty"`
+	// SkipAssignAuthor stops assigning new pull requests to their author.
+	SkipAssignAuthor bool `json:"skip_assign_author,omitempty"`
+	// Prefixes are the prefixes of the labels applied by the checked boxes of
+	// the templates starting with them, e.g. "- [x] kind/bug". Defaults to
+	// kind and area.
+	Prefixes []string `json:"prefixes,omitempty"`
+	// Checkboxes maps the text of the boxes of the templates to the labels
+	// they apply when checked, e.g. "Bug fix": "kind/bug". The text is
+	// matched case insensitively.
+	Checkboxes map[string]string `json:"checkboxes,omitempty"`
+}
+
 // Blunderbuss defines configuration for the blunderbuss plugin.
 type Blunderbuss struct {
 	// ReviewerCount is the minimum number of reviewers to request
@@ -147,6 +406,21 @@ type Owners struct {
 	// OWNERS file, preventing their automatic addition by the owners-label plugin.
 	// This check is performed by the verify-owners plugin.
 	LabelsBlackList []string `json:"labels_blacklist,omitempty"`
+
+	// OrgAliases are the OWNERS_ALIASES files of central repositories shared by the repos of orgs, whose own
+	// OWNERS_ALIASES files take precedence.
+	OrgAliases []OrgAliases `json:"org_aliases,omitempty"`
+}
+
+// OrgAliases is an OWNERS_ALIASES file at the root of a repository whose aliases can be used in the OWNERS files of
+// the repos of orgs.
+type OrgAliases struct {
+	// Orgs are the orgs whose OWNERS files use the aliases.
+	Orgs []string `json:"orgs"`
+	// Repo is the org/repo repository holding the OWNERS_ALIASES file.
+	Repo string `json:"repo"`
+	// Branch is the branch of the repository, defaulting to master.
+	Branch string `json:"branch,omitempty"`
 }
 
 // MDYAMLEnabled returns a boolean denoting if the passed repo supports YAML OWNERS config headers
@@ -162,6 +436,19 @@ func (c *Configuration) MDYAMLEnabled(org, repo string) bool {
 	return false
 }
 
+// OrgAliasesFor returns the org/repo repository and the branch of the OWNERS_ALIASES file shared by the repos of the
+// org, if any.
+func (c *Configuration) OrgAliasesFor(org string) (string, string) {
+	for _, aliases := range c.Owners.OrgAliases {
+		for _, elem := range aliases.Orgs {
+			if elem == org {
+				return aliases.Repo, aliases.Branch
+			}
+		}
+	}
+	return "", ""
+}
+
 // SkipCollaborators returns a boolean denoting if collaborator cross-checks are enabled for
 // the passed repo. If it's true, approve and lgtm plugins rely solely on OWNERS files.
 func (c *Configuration) SkipCollaborators(org, repo string) bool {
@@ -253,6 +540,10 @@ type Approve struct {
 	// * an APPROVE github review is equivalent to leaving an "/approve" message.
 	// * A REQUEST_CHANGES github review is equivalent to leaving an /approve cancel" message.
 	IgnoreReviewState *bool `json:"ignore_review_state,omitempty"`
+
+	// Approvers are the logins who approve every file of the pull requests
+	// instead of the approvers of the OWNERS files, e.g. a release team.
+	Approvers []string `json:"approvers,omitempty"`
 }
 
 var (
@@ -350,12 +641,85 @@ type Trigger struct {
 	// OnlyOrgMembers requires PRs and/or /ok-to-test comments to come from org members.
 	// By default, trigger also include repo collaborators.
 	OnlyOrgMembers bool `json:"only_org_members,omitempty"`
+	// TrustedUsers are the logins of users trusted in addition to org
+	// members, such as external contributors who are not members of the org.
+	TrustedUsers []string `json:"trusted_users,omitempty"`
+	// TrustedTeams are teams whose members are trusted. A team is either the
+	// slug of a team of the repository's org or org/slug for a team of
+	// another org.
+	TrustedTeams []string `json:"trusted_teams,omitempty"`
 	// IgnoreOkToTest makes trigger ignore /ok-to-test comments.
 	// This is a security mitigation to only allow testing from trusted users.
 	IgnoreOkToTest bool `json:"ignore_ok_to_test,omitempty"`
+	// TrustedTesters are the logins of the maintainers who can run the
+	// presubmits of untrusted PRs with /test-trusted when IgnoreOkToTest is
+	// set. The jobs run with the RestrictedServiceAccount, which should not
+	// have access to the secrets of the other jobs.
+	TrustedTesters []string `json:"trusted_testers,omitempty"`
+	// RestrictedServiceAccount is the service account of the jobs run with
+	// /test-trusted.
+	RestrictedServiceAccount string `json:"restricted_service_account,omitempty"`
+	// TrustedLabel is a label, e.g. ok-to-test, which has the same effect as
+	// an /ok-to-test comment when a trusted user adds it to a PR: the PR is
+	// trusted, needs-ok-to-test is removed and the presubmits are run. The
+	// label is removed if an untrusted user adds it.
+	TrustedLabel string `json:"trusted_label,omitempty"`
 	// ElideSkippedContexts makes trigger not post "Skipped" contexts for jobs
 	// that could run but do not run.
 	ElideSkippedContexts bool `json:"elide_skipped_contexts,omitempty"`
+	// SkipDraftPR makes trigger not run the presubmits of draft PRs when
+	// they are opened or updated. All the presubmits are run once the PR is
+	// marked as ready for review.
+	SkipDraftPR bool `json:"skip_draft_pr,omitempty"`
+	// Quotas limit how many presubmits trigger runs for the repos within a
+	// time window. Once a quota is exhausted the jobs are not run and get an
+	// error status telling when older runs leave the window.
+	Quotas []Quota `json:"quotas,omitempty"`
+	// CommandRateLimit limits how many /test and /retest commands each user
+	// can comment on a PR within a time window. Further commands are ignored
+	// once the user was told when they can retry.
+	CommandRateLimit *CommandRateLimit `json:"command_rate_limit,omitempty"`
+	// CommentOnDuplicateJobs makes trigger reply with links to the running
+	// builds when it does not start jobs, e.g. on /retest, as the same jobs
+	// are still pending for the head of the PR.
+	CommentOnDuplicateJobs bool `json:"comment_on_duplicate_jobs,omitempty"`
+	// SkipCI makes trigger skip the presubmits of PRs whose title or head
+	// commit message contains one of the SkipCIMarkers. The PR is labeled
+	// skip-ci so that keeper does not require the skipped contexts either.
+	SkipCI bool `json:"skip_ci,omitempty"`
+	// SkipCIMarkers are the markers, matched ignoring case, which skip the
+	// presubmits of a PR when SkipCI is set. Defaults to [skip ci] and
+	// [ci skip].
+	SkipCIMarkers []string `json:"skip_ci_markers,omitempty"`
+}
+
+// CommandRateLimit is the maximum number of commands a user can comment on a
+// PR within a time window, e.g. 10 per hour.
+type CommandRateLimit struct {
+	// Max is the number of commands which are handled within the window.
+	Max int `json:"max"`
+	// Window is the duration commands are counted over, e.g. 1h.
+	Window         string        `json:"window"`
+	WindowDuration time.Duration `json:"-"`
+}
+
+const (
+	// QuotaScopeOrg counts the presubmits of all the repositories of the org
+	QuotaScopeOrg = "org"
+	// QuotaScopeUser counts the presubmits of the pull requests of each author
+	QuotaScopeUser = "user"
+)
+
+// Quota is the maximum number of presubmits run within a time window, e.g.
+// 500 runs per org per 24h.
+type Quota struct {
+	// Scope is either org or user.
+	Scope string `json:"scope"`
+	// Max is the number of presubmits which can run within the window.
+	Max int `json:"max"`
+	// Window is the duration runs are counted over, e.g. 1h.
+	Window         string        `json:"window"`
+	WindowDuration time.Duration `json:"-"`
 }
 
 // Heart contains the configuration for the heart plugin.
@@ -571,18 +935,320 @@ func (r RequireMatchingLabel) Describe() string {
 	return str.String()
 }
 
-// TriggerFor finds the Trigger for a repo, if one exists
-// a trigger can be listed for the repo itself or for the
-// owning organization
+// TriggerFor returns the Trigger of a repo, resolved from the trigger listed
+// for every repository ("*"), then from the one of the owning organization and
+// then from the one of the repo itself: the fields set by a more specific
+// trigger override those it inherits
 func (c *Configuration) TriggerFor(org, repo string) *Trigger {
-	for _, tr := range c.Triggers {
-		for _, r := range tr.Repos {
-			if r == org || r == fmt.Sprintf("%s/%s", org, repo) {
-				return &tr
+	answer := &Trigger{}
+	for _, name := range []string{DefaultsKey, org, fmt.Sprintf("%s/%s", org, repo)} {
+		for i := range c.Triggers {
+			if sets.NewString(c.Triggers[i].Repos...).Has(name) {
+				overrideSetFields(answer, &c.Triggers[i])
+				break
+			}
+		}
+	}
+	return answer
+}
+
+// BranchOverridesFor returns the branch overrides of a repo, whichever branches they match
+func (c *Configuration) BranchOverridesFor(org, repo string) []BranchOverride {
+	fullName := fmt.Sprintf("%s/%s", org, repo)
+	var answer []BranchOverride
+	for _, o := range c.BranchOverrides {
+		if sets.NewString(o.Repos...).HasAny(org, fullName) {
+			answer = append(answer, o)
+		}
+	}
+	return answer
+}
+
+// TriggerForBranch returns a copy of the Trigger of a repo overridden by the
+// branch overrides matching the base branch of a pull request, or the Trigger
+// itself if none matches
+func (c *Configuration) TriggerForBranch(trigger *Trigger, org, repo, branch string) *Trigger {
+	answer := trigger
+	for _, o := range c.BranchOverridesFor(org, repo) {
+		if o.Trigger != nil && o.Matches(branch) {
+			if answer == trigger {
+				copied := *trigger
+				answer = &copied
 			}
+			overrideSetFields(answer, o.Trigger)
+		}
+	}
+	return answer
+}
+
+// LgtmForBranch returns a copy of the Lgtm of a repo overridden by the branch
+// overrides matching the base branch of a pull request, or the Lgtm itself if
+// none matches
+func (c *Configuration) LgtmForBranch(lgtm *Lgtm, org, repo, branch string) *Lgtm {
+	answer := lgtm
+	for _, o := range c.BranchOverridesFor(org, repo) {
+		if o.Lgtm != nil && o.Matches(branch) {
+			if answer == lgtm {
+				copied := *lgtm
+				answer = &copied
+			}
+			overrideSetFields(answer, o.Lgtm)
+		}
+	}
+	return answer
+}
+
+// ApproveForBranch returns a copy of the Approve of a repo overridden by the
+// branch overrides matching the base branch of a pull request, or the Approve
+// itself if none matches
+func (c *Configuration) ApproveForBranch(approve *Approve, org, repo, branch string) *Approve {
+	answer := approve
+	for _, o := range c.BranchOverridesFor(org, repo) {
+		if o.Approve != nil && o.Matches(branch) {
+			if answer == approve {
+				copied := *approve
+				answer = &copied
+			}
+			overrideSetFields(answer, o.Approve)
+		}
+	}
+	return answer
+}
+
+// overrideSetFields copies the fields of the struct pointed by from which are set onto the struct pointed by to
+func overrideSetFields(to, from interface{}) {
+	toValue := reflect.ValueOf(to).Elem()
+	fromValue := reflect.ValueOf(from).Elem()
+	for i := 0; i < fromValue.NumField(); i++ {
+		if f := fromValue.Field(i); !f.IsZero() {
+			toValue.Field(i).Set(f)
+		}
+	}
+}
+
+// CommentsFor finds the Comments configuration for a repo, if one exists
+// a configuration can be listed for the repo itself or for the owning
+// organization
+func (c *Configuration) CommentsFor(org, repo string) *Comments {
+	fullName := fmt.Sprintf("%s/%s", org, repo)
+	for i := range c.Comments {
+		for _, r := range c.Comments[i].Repos {
+			if r == org || r == fullName {
+				return &c.Comments[i]
+			}
+		}
+	}
+	return &Comments{}
+}
+
+// ForProvider returns the configuration of the plugins of the named SCM provider, which is the configuration
+// itself unless it has a section for the provider
+func (c *Configuration) ForProvider(name string) *Configuration {
+	p, ok := c.Providers[name]
+	if name == "" || !ok {
+		return c
+	}
+	answer := *c
+	answer.Plugins = p.Plugins
+	answer.ExternalPlugins = p.ExternalPlugins
+	return &answer
+}
+
+// CommentEditsFor finds the CommentEdits configuration for a repo, returning nil
+// if the commands added by editing comments are ignored
+func (c *Configuration) CommentEditsFor(org, repo string) *CommentEdits {
+	fullName := fmt.Sprintf("%s/%s", org, repo)
+	for i := range c.CommentEdits {
+		for _, r := range c.CommentEdits[i].Repos {
+			if r == fullName {
+				return &c.CommentEdits[i]
+			}
+		}
+	}
+	for i := range c.CommentEdits {
+		for _, r := range c.CommentEdits[i].Repos {
+			if r == org {
+				return &c.CommentEdits[i]
+			}
+		}
+	}
+	return nil
+}
+
+// DownstreamsFor returns the downstream endpoints the webhooks of a repo are forwarded to
+func (c *Configuration) DownstreamsFor(org, repo string) []Downstream {
+	fullName := fmt.Sprintf("%s/%s", org, repo)
+	var answer []Downstream
+	for _, d := range c.Downstreams {
+		if sets.NewString(d.ExcludeRepos...).Has(fullName) {
+			continue
+		}
+		if len(d.Repos) == 0 || sets.NewString(d.Repos...).HasAny(org, fullName) {
+			answer = append(answer, d)
+		}
+	}
+	return answer
+}
+
+// CommandGuardFor finds the CommandGuard configuration for a repo, returning nil
+// if only the comments of the bot itself are ignored
+func (c *Configuration) CommandGuardFor(org, repo string) *CommandGuard {
+	fullName := fmt.Sprintf("%s/%s", org, repo)
+	for i := range c.CommandGuards {
+		for _, r := range c.CommandGuards[i].Repos {
+			if r == fullName {
+				return &c.CommandGuards[i]
+			}
+		}
+	}
+	for i := range c.CommandGuards {
+		for _, r := range c.CommandGuards[i].Repos {
+			if r == org {
+				return &c.CommandGuards[i]
+			}
+		}
+	}
+	return nil
+}
+
+// PreviewFor finds the Preview configuration for a repo, if one exists
+// a configuration can be listed for the repo itself or for the owning
+// organization
+func (c *Configuration) PreviewFor(org, repo string) *Preview {
+	fullName := fmt.Sprintf("%s/%s", org, repo)
+	for i := range c.Previews {
+		for _, r := range c.Previews[i].Repos {
+			if r == fullName {
+				return &c.Previews[i]
+			}
+		}
+	}
+	for i := range c.Previews {
+		for _, r := range c.Previews[i].Repos {
+			if r == org {
+				return &c.Previews[i]
+			}
+		}
+	}
+	return nil
+}
+
+// PromotionFor finds the Promotion configuration for a repo, if one exists
+// a configuration can be listed for the repo itself or for the owning
+// organization
+func (c *Configuration) PromotionFor(org, repo string) *Promotion {
+	fullName := fmt.Sprintf("%s/%s", org, repo)
+	for i := range c.Promotions {
+		for _, r := range c.Promotions[i].Repos {
+			if r == fullName {
+				return &c.Promotions[i]
+			}
+		}
+	}
+	for i := range c.Promotions {
+		for _, r := range c.Promotions[i].Repos {
+			if r == org {
+				return &c.Promotions[i]
+			}
+		}
+	}
+	return nil
+}
+
+// ReminderFor finds the Reminder configuration for a repo, returning the
+// defaults if there is none and nil if the repo opted out of the reminders
+func (c *Configuration) ReminderFor(org, repo string) *Reminder {
+	fullName := fmt.Sprintf("%s/%s", org, repo)
+	var answer *Reminder
+	for i := range c.Reminders {
+		for _, r := range c.Reminders[i].Repos {
+			if r == fullName {
+				answer = &c.Reminders[i]
+			}
+		}
+	}
+	for i := range c.Reminders {
+		for _, r := range c.Reminders[i].Repos {
+			if r == org && answer == nil {
+				answer = &c.Reminders[i]
+			}
+		}
+	}
+	if answer == nil {
+		return &Reminder{AfterDuration: defaultReminderAfter}
+	}
+	if sets.NewString(answer.ExcludeRepos...).Has(fullName) {
+		return nil
+	}
+	return answer
+}
+
+// TriageFor finds the Triage configuration for a repo, returning the
+// defaults if there is none
+func (c *Configuration) TriageFor(org, repo string) *Triage {
+	fullName := fmt.Sprintf("%s/%s", org, repo)
+	for i := range c.Triage {
+		for _, r := range c.Triage[i].Repos {
+			if r == fullName {
+				return &c.Triage[i]
+			}
+		}
+	}
+	for i := range c.Triage {
+		for _, r := range c.Triage[i].Repos {
+			if r == org {
+				return &c.Triage[i]
+			}
+		}
+	}
+	return &Triage{Prefixes: defaultTriagePrefixes}
+}
+
+// PluginsFor returns the plugins enabled for the repository: those enabled for every repository, for its org or
+// for the repository itself, leaving out the plugins disabled by its org or by the repository.
+func (c *Configuration) PluginsFor(org, repo string) []string {
+	// on bitbucket server the owner can be the ProjectKey which is upper case - so lets also check for the case
+	// of a lower case project key matching projects
+	orgs := []string{org}
+	if lowerOrg := strings.ToLower(org); lowerOrg != org {
+		orgs = append(orgs, lowerOrg)
+	}
+	keys := []string{DefaultsKey}
+	keys = append(keys, orgs...)
+	for _, o := range orgs {
+		keys = append(keys, fmt.Sprintf("%s/%s", o, repo))
+	}
+
+	var answer []string
+	for _, key := range keys {
+		for _, plugin := range c.Plugins[key] {
+			if strings.HasPrefix(plugin, disabledPluginPrefix) {
+				answer = removePlugin(answer, strings.TrimPrefix(plugin, disabledPluginPrefix))
+			} else if !hasPlugin(answer, plugin) {
+				answer = append(answer, plugin)
+			}
+		}
+	}
+	return answer
+}
+
+func hasPlugin(plugins []string, plugin string) bool {
+	for _, p := range plugins {
+		if p == plugin {
+			return true
+		}
+	}
+	return false
+}
+
+func removePlugin(plugins []string, plugin string) []string {
+	var answer []string
+	for _, p := range plugins {
+		if p != plugin {
+			answer = append(answer, p)
 		}
 	}
-	return &Trigger{}
+	return answer
 }
 
 // EnabledReposForPlugin returns the orgs and repos that have enabled the passed plugin.
@@ -628,6 +1294,15 @@ func (c *Configuration) EnabledReposForExternalPlugin(plugin string) (orgs, repo
 	return
 }
 
+// ExternalPluginsFor returns the external plugins enabled for the repository, either for its org or for
+// the repository itself.
+func (c *Configuration) ExternalPluginsFor(org, repo string) []ExternalPlugin {
+	var answer []ExternalPlugin
+	answer = append(answer, c.ExternalPlugins[org]...)
+	answer = append(answer, c.ExternalPlugins[fmt.Sprintf("%s/%s", org, repo)]...)
+	return answer
+}
+
 // SetDefaults sets default options for config updating
 func (c *ConfigUpdater) SetDefaults() {
 	if len(c.Maps) == 0 {
@@ -659,21 +1334,33 @@ func (c *ConfigUpdater) SetDefaults() {
 	}
 }
 
-func (c *Configuration) setDefaults() {
-	c.ConfigUpdater.SetDefaults()
-
-	for repo, plugins := range c.ExternalPlugins {
+func setExternalPluginEndpoints(pluginMap map[string][]ExternalPlugin) {
+	for repo, plugins := range pluginMap {
 		for i, p := range plugins {
 			if p.Endpoint != "" {
 				continue
 			}
-			c.ExternalPlugins[repo][i].Endpoint = fmt.Sprintf("http://%s", p.Name)
+			pluginMap[repo][i].Endpoint = fmt.Sprintf("http://%s", p.Name)
 		}
 	}
+}
+
+func (c *Configuration) setDefaults() {
+	c.ConfigUpdater.SetDefaults()
+
+	setExternalPluginEndpoints(c.ExternalPlugins)
+	for _, p := range c.Providers {
+		setExternalPluginEndpoints(p.ExternalPlugins)
+	}
 	if c.Blunderbuss.ReviewerCount == nil && c.Blunderbuss.FileWeightCount == nil {
 		c.Blunderbuss.ReviewerCount = new(int)
 		*c.Blunderbuss.ReviewerCount = defaultBlunderbussReviewerCount
 	}
+	for i := range c.Triage {
+		if len(c.Triage[i].Prefixes) == 0 {
+			c.Triage[i].Prefixes = defaultTriagePrefixes
+		}
+	}
 	for i, trigger := range c.Triggers {
 		if trigger.TrustedOrg == "" || trigger.JoinOrgURL != "" {
 			continue
@@ -686,6 +1373,21 @@ func (c *Configuration) setDefaults() {
 	if c.Owners.LabelsBlackList == nil {
 		c.Owners.LabelsBlackList = []string{labels.Approved, labels.LGTM}
 	}
+	for i := range c.Downstreams {
+		if c.Downstreams[i].Attempts == 0 {
+			c.Downstreams[i].Attempts = defaultDownstreamAttempts
+		}
+	}
+	for i := range c.CommandGuards {
+		if c.CommandGuards[i].LoopLimit == 0 {
+			c.CommandGuards[i].LoopLimit = defaultCommandLoopLimit
+		}
+	}
+	for i := range c.Owners.OrgAliases {
+		if c.Owners.OrgAliases[i].Branch == "" {
+			c.Owners.OrgAliases[i].Branch = "master"
+		}
+	}
 	for _, milestone := range c.RepoMilestone {
 		if milestone.MaintainersFriendlyName == "" {
 			milestone.MaintainersFriendlyName = "SIG Chairs/TLs"
@@ -715,6 +1417,7 @@ func validatePlugins(plugins map[string][]string) error {
 	var errors []string
 	for _, configuration := range plugins {
 		for _, plugin := range configuration {
+			plugin = strings.TrimPrefix(plugin, disabledPluginPrefix)
 			if _, ok := pluginHelp[plugin]; !ok {
 				if failOnMissingPlugin {
 					errors = append(errors, fmt.Sprintf("unknown plugin: %s", plugin))
@@ -725,10 +1428,27 @@ func validatePlugins(plugins map[string][]string) error {
 		}
 	}
 	for repo, repoConfig := range plugins {
+		if repo == DefaultsKey {
+			continue
+		}
+		// the plugins of a repo are inherited from its org and from the defaults, those of an org from the defaults
+		parents := []string{DefaultsKey}
 		if strings.Contains(repo, "/") {
-			org := strings.Split(repo, "/")[0]
-			if dupes := findDuplicatedPluginConfig(repoConfig, plugins[org]); len(dupes) > 0 {
-				errors = append(errors, fmt.Sprintf("plugins %v are duplicated for %s and %s", dupes, repo, org))
+			parents = append(parents, strings.Split(repo, "/")[0])
+		}
+		var inherited []string
+		for _, parent := range parents {
+			if dupes := findDuplicatedPluginConfig(repoConfig, plugins[parent]); len(dupes) > 0 {
+				errors = append(errors, fmt.Sprintf("plugins %v are duplicated for %s and %s", dupes, repo, parent))
+			}
+			inherited = append(inherited, plugins[parent]...)
+		}
+		for _, plugin := range repoConfig {
+			if !strings.HasPrefix(plugin, disabledPluginPrefix) {
+				continue
+			}
+			if name := strings.TrimPrefix(plugin, disabledPluginPrefix); !hasPlugin(inherited, name) {
+				errors = append(errors, fmt.Sprintf("plugin %s is disabled for %s but is not enabled for any of %v", name, repo, parents))
 			}
 		}
 	}
@@ -838,6 +1558,158 @@ func validateRequireMatchingLabel(rs []RequireMatchingLabel) error {
 	return nil
 }
 
+func validateTriggers(triggers []Trigger) error {
+	for i, t := range triggers {
+		for j, q := range t.Quotas {
+			if q.Scope != QuotaScopeOrg && q.Scope != QuotaScopeUser {
+				return fmt.Errorf("quota #%d of trigger config #%d has an invalid scope %q, expected %s or %s", j, i, q.Scope, QuotaScopeOrg, QuotaScopeUser)
+			}
+			if q.Max <= 0 || q.WindowDuration <= 0 {
+				return fmt.Errorf("quota #%d of trigger config #%d needs a positive max and window", j, i)
+			}
+		}
+		if limit := t.CommandRateLimit; limit != nil && (limit.Max <= 0 || limit.WindowDuration <= 0) {
+			return fmt.Errorf("the command rate limit of trigger config #%d needs a positive max and window", i)
+		}
+		if len(t.TrustedTesters) > 0 && t.RestrictedServiceAccount == "" {
+			return fmt.Errorf("trigger config #%d has trusted testers but no restricted service account", i)
+		}
+	}
+	return nil
+}
+
+func validateReminders(reminders []Reminder) error {
+	for i, r := range reminders {
+		if r.AfterDuration <= 0 {
+			return fmt.Errorf("reminder config #%d has a non positive after duration", i)
+		}
+		if r.EscalateAfter != "" && r.EscalateAfterDuration <= r.AfterDuration {
+			return fmt.Errorf("reminder config #%d escalates after %s, which is not after the reminder", i, r.EscalateAfter)
+		}
+	}
+	return nil
+}
+
+func validateTriage(triage []Triage) error {
+	for i, t := range triage {
+		for _, prefix := range t.Prefixes {
+			if prefix == "" || strings.Contains(prefix, "/") {
+				return fmt.Errorf("triage config #%d has an invalid prefix %q", i, prefix)
+			}
+		}
+		for text, label := range t.Checkboxes {
+			if strings.TrimSpace(text) == "" || strings.TrimSpace(label) == "" {
+				return fmt.Errorf("triage config #%d maps the checkbox %q to the label %q, which cannot be empty", i, text, label)
+			}
+		}
+	}
+	return nil
+}
+
+func validateOrgAliases(orgAliases []OrgAliases) error {
+	orgs := map[string]bool{}
+	for i, aliases := range orgAliases {
+		if parts := strings.Split(aliases.Repo, "/"); len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return fmt.Errorf("owners org_aliases #%d has an invalid repo %q, it should be org/repo", i, aliases.Repo)
+		}
+		if len(aliases.Orgs) == 0 {
+			return fmt.Errorf("owners org_aliases #%d has no orgs", i)
+		}
+		for _, org := range aliases.Orgs {
+			if orgs[org] {
+				return fmt.Errorf("owners org_aliases #%d lists the org %q, which already has aliases", i, org)
+			}
+			orgs[org] = true
+		}
+	}
+	return nil
+}
+
+func validateCommandGuards(guards []CommandGuard) error {
+	for i, guard := range guards {
+		if len(guard.Repos) == 0 {
+			return fmt.Errorf("command_guards #%d has no repos", i)
+		}
+		if guard.LoopLimit < 1 {
+			return fmt.Errorf("command_guards #%d has an invalid loop_limit %d, it should be at least 1", i, guard.LoopLimit)
+		}
+		if guard.LoopWindowDuration <= 0 {
+			return fmt.Errorf("command_guards #%d has an invalid loop_window %q, it should be positive", i, guard.LoopWindow)
+		}
+	}
+	return nil
+}
+
+func validateBranchOverrides(overrides []BranchOverride) error {
+	for i, o := range overrides {
+		if len(o.Repos) == 0 {
+			return fmt.Errorf("branch_overrides #%d has no repos", i)
+		}
+		if len(o.Branches) == 0 {
+			return fmt.Errorf("branch_overrides #%d has no branches", i)
+		}
+		if o.Trigger == nil && o.Lgtm == nil && o.Approve == nil {
+			return fmt.Errorf("branch_overrides #%d overrides no plugin", i)
+		}
+		if o.Trigger != nil && len(o.Trigger.Repos) > 0 || o.Lgtm != nil && len(o.Lgtm.Repos) > 0 || o.Approve != nil && len(o.Approve.Repos) > 0 {
+			return fmt.Errorf("branch_overrides #%d sets the repos of a plugin, they are the repos of the override", i)
+		}
+		if o.Trigger != nil {
+			if err := validateTriggers([]Trigger{*o.Trigger}); err != nil {
+				return fmt.Errorf("branch_overrides #%d has an invalid trigger: %v", i, err)
+			}
+		}
+	}
+	return nil
+}
+
+func validateDownstreams(downstreams []Downstream) error {
+	names := map[string]bool{}
+	for i, d := range downstreams {
+		if d.Name == "" {
+			return fmt.Errorf("downstreams #%d has no name", i)
+		}
+		if names[d.Name] {
+			return fmt.Errorf("downstream %s is listed twice", d.Name)
+		}
+		names[d.Name] = true
+		if u, err := url.Parse(d.Endpoint); err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("downstream %s has an invalid endpoint %q", d.Name, d.Endpoint)
+		}
+		if d.Attempts < 1 {
+			return fmt.Errorf("downstream %s has an invalid attempts %d, it should be at least 1", d.Name, d.Attempts)
+		}
+		if d.BackoffDuration < 0 {
+			return fmt.Errorf("downstream %s has a negative backoff %q", d.Name, d.Backoff)
+		}
+	}
+	return nil
+}
+
+func validatePreviews(previews []Preview) error {
+	for i, p := range previews {
+		if p.Job == "" {
+			return fmt.Errorf("preview config #%d has no job", i)
+		}
+		if _, err := template.New("url").Parse(p.URL); err != nil {
+			return fmt.Errorf("preview config #%d has an invalid url template: %v", i, err)
+		}
+	}
+	return nil
+}
+
+func validatePromotions(promotions []Promotion) error {
+	for i, p := range promotions {
+		if p.Job == "" {
+			return fmt.Errorf("promotion config #%d has no job", i)
+		}
+		if len(p.Teams) == 0 {
+			return fmt.Errorf("promotion config #%d has no teams allowed to promote", i)
+		}
+	}
+	return nil
+}
+
 func compileRegexpsAndDurations(pc *Configuration) error {
 	cRe, err := regexp.Compile(pc.SigMention.Regexp)
 	if err != nil {
@@ -872,6 +1744,114 @@ func compileRegexpsAndDurations(pc *Configuration) error {
 		}
 		rs[i].GracePeriodDuration = dur
 	}
+
+	if pc.ChatOpsPolicy.Timeout != "" {
+		dur, err := time.ParseDuration(pc.ChatOpsPolicy.Timeout)
+		if err != nil {
+			return fmt.Errorf("failed to compile chatops policy timeout: %q, error: %v", pc.ChatOpsPolicy.Timeout, err)
+		}
+		pc.ChatOpsPolicy.TimeoutDuration = dur
+	}
+
+	for i := range pc.Triggers {
+		if err := compileTriggerDurations(&pc.Triggers[i]); err != nil {
+			return err
+		}
+	}
+
+	for i := range pc.BranchOverrides {
+		o := &pc.BranchOverrides[i]
+		o.BranchRe = nil
+		for _, branch := range o.Branches {
+			re, err := regexp.Compile("^(?:" + branch + ")$")
+			if err != nil {
+				return fmt.Errorf("failed to compile branch override regexp: %q, error: %v", branch, err)
+			}
+			o.BranchRe = append(o.BranchRe, re)
+		}
+		if o.Trigger != nil {
+			if err := compileTriggerDurations(o.Trigger); err != nil {
+				return err
+			}
+		}
+	}
+
+	for i := range pc.Reminders {
+		reminder := &pc.Reminders[i]
+		reminder.AfterDuration = defaultReminderAfter
+		if reminder.After != "" {
+			dur, err := time.ParseDuration(reminder.After)
+			if err != nil {
+				return fmt.Errorf("failed to compile reminder after duration: %q, error: %v", reminder.After, err)
+			}
+			reminder.AfterDuration = dur
+		}
+		if reminder.EscalateAfter != "" {
+			dur, err := time.ParseDuration(reminder.EscalateAfter)
+			if err != nil {
+				return fmt.Errorf("failed to compile reminder escalate_after duration: %q, error: %v", reminder.EscalateAfter, err)
+			}
+			reminder.EscalateAfterDuration = dur
+		}
+	}
+
+	for i := range pc.CommentEdits {
+		edits := &pc.CommentEdits[i]
+		if edits.Window == "" {
+			edits.WindowDuration = defaultCommentEditWindow
+			continue
+		}
+		dur, err := time.ParseDuration(edits.Window)
+		if err != nil {
+			return fmt.Errorf("failed to compile comment edit window: %q, error: %v", edits.Window, err)
+		}
+		edits.WindowDuration = dur
+	}
+
+	for i := range pc.Downstreams {
+		d := &pc.Downstreams[i]
+		if d.Backoff == "" {
+			d.BackoffDuration = defaultDownstreamBackoff
+			continue
+		}
+		dur, err := time.ParseDuration(d.Backoff)
+		if err != nil {
+			return fmt.Errorf("failed to compile downstream %s backoff: %q, error: %v", d.Name, d.Backoff, err)
+		}
+		d.BackoffDuration = dur
+	}
+
+	for i := range pc.CommandGuards {
+		guard := &pc.CommandGuards[i]
+		if guard.LoopWindow == "" {
+			guard.LoopWindowDuration = defaultCommandLoopWindow
+			continue
+		}
+		dur, err := time.ParseDuration(guard.LoopWindow)
+		if err != nil {
+			return fmt.Errorf("failed to compile command guard loop window: %q, error: %v", guard.LoopWindow, err)
+		}
+		guard.LoopWindowDuration = dur
+	}
+	return nil
+}
+
+func compileTriggerDurations(trigger *Trigger) error {
+	for j := range trigger.Quotas {
+		quota := &trigger.Quotas[j]
+		dur, err := time.ParseDuration(quota.Window)
+		if err != nil {
+			return fmt.Errorf("failed to compile quota window: %q, error: %v", quota.Window, err)
+		}
+		quota.WindowDuration = dur
+	}
+	if limit := trigger.CommandRateLimit; limit != nil {
+		dur, err := time.ParseDuration(limit.Window)
+		if err != nil {
+			return fmt.Errorf("failed to compile command rate limit window: %q, error: %v", limit.Window, err)
+		}
+		limit.WindowDuration = dur
+	}
 	return nil
 }
 
@@ -894,6 +1874,14 @@ func (c *Configuration) Validate() error {
 	if err := validateExternalPlugins(c.ExternalPlugins); err != nil {
 		return err
 	}
+	for name, p := range c.Providers {
+		if err := validatePlugins(p.Plugins); err != nil {
+			return fmt.Errorf("invalid plugins of provider %s: %v", name, err)
+		}
+		if err := validateExternalPlugins(p.ExternalPlugins); err != nil {
+			return fmt.Errorf("invalid external plugins of provider %s: %v", name, err)
+		}
+	}
 	if err := validateBlunderbuss(&c.Blunderbuss); err != nil {
 		return err
 	}
@@ -906,6 +1894,39 @@ func (c *Configuration) Validate() error {
 	if err := validateRequireMatchingLabel(c.RequireMatchingLabel); err != nil {
 		return err
 	}
+	if err := validateTriggers(c.Triggers); err != nil {
+		return err
+	}
+	if err := validatePreviews(c.Previews); err != nil {
+		return err
+	}
+	if err := validatePromotions(c.Promotions); err != nil {
+		return err
+	}
+	if err := validateReminders(c.Reminders); err != nil {
+		return err
+	}
+	if err := validateTriage(c.Triage); err != nil {
+		return err
+	}
+	if err := validateOrgAliases(c.Owners.OrgAliases); err != nil {
+		return err
+	}
+	if err := validateModules(c.Modules); err != nil {
+		return err
+	}
+	if err := validateDependencyUpdates(c.DependencyUpdates); err != nil {
+		return err
+	}
+	if err := validateCommandGuards(c.CommandGuards); err != nil {
+		return err
+	}
+	if err := validateDownstreams(c.Downstreams); err != nil {
+		return err
+	}
+	if err := validateBranchOverrides(c.BranchOverrides); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -20,6 +20,7 @@ import (
 	"errors"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestValidateExternalPlugins(t *testing.T) {
@@ -90,6 +91,353 @@ func TestValidateExternalPlugins(t *testing.T) {
 	}
 }
 
+func TestPreviews(t *testing.T) {
+	c := &Configuration{
+		Previews: []Preview{
+			{Repos: []string{"org"}, Job: "deploy-org"},
+			{Repos: []string{"org/repo"}, Job: "deploy-repo", URL: "https://pr-{{ .Number }}.example.com"},
+		},
+	}
+	if err := validatePreviews(c.Previews); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if p := c.PreviewFor("org", "repo"); p == nil || p.Job != "deploy-repo" {
+		t.Errorf("expected the repo preview config, got %v", p)
+	}
+	if p := c.PreviewFor("org", "other"); p == nil || p.Job != "deploy-org" {
+		t.Errorf("expected the org preview config, got %v", p)
+	}
+	if p := c.PreviewFor("other", "repo"); p != nil {
+		t.Errorf("expected no preview config, got %v", p)
+	}
+
+	if err := validatePreviews([]Preview{{Repos: []string{"org"}}}); err == nil {
+		t.Error("expected an error for a preview without a job")
+	}
+	if err := validatePreviews([]Preview{{Repos: []string{"org"}, Job: "deploy", URL: "{{ .Number"}}); err == nil {
+		t.Error("expected an error for an invalid url template")
+	}
+}
+
+func TestPromotions(t *testing.T) {
+	c := &Configuration{
+		Promotions: []Promotion{
+			{Repos: []string{"org"}, Job: "promote-org", Teams: []string{"release"}},
+			{Repos: []string{"org/repo"}, Job: "promote-repo", Teams: []string{"release"}},
+		},
+	}
+	if err := validatePromotions(c.Promotions); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if p := c.PromotionFor("org", "repo"); p == nil || p.Job != "promote-repo" {
+		t.Errorf("expected the repo promotion config, got %v", p)
+	}
+	if p := c.PromotionFor("org", "other"); p == nil || p.Job != "promote-org" {
+		t.Errorf("expected the org promotion config, got %v", p)
+	}
+	if p := c.PromotionFor("other", "repo"); p != nil {
+		t.Errorf("expected no promotion config, got %v", p)
+	}
+
+	if err := validatePromotions([]Promotion{{Repos: []string{"org"}, Teams: []string{"release"}}}); err == nil {
+		t.Error("expected an error for a promotion without a job")
+	}
+	if err := validatePromotions([]Promotion{{Repos: []string{"org"}, Job: "promote"}}); err == nil {
+		t.Error("expected an error for a promotion without teams")
+	}
+}
+
+func TestTriage(t *testing.T) {
+	c := &Configuration{
+		Triage: []Triage{
+			{Repos: []string{"org"}},
+			{Repos: []string{"org/repo"}, Prefixes: []string{"kind"}, Checkboxes: map[string]string{"Bug fix": "kind/bug"}},
+		},
+	}
+	c.setDefaults()
+	if err := validateTriage(c.Triage); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if tr := c.TriageFor("org", "repo"); len(tr.Prefixes) != 1 || tr.Checkboxes["Bug fix"] != "kind/bug" {
+		t.Errorf("expected the repo triage config, got %v", tr)
+	}
+	if tr := c.TriageFor("org", "other"); !reflect.DeepEqual(tr.Prefixes, defaultTriagePrefixes) {
+		t.Errorf("expected the org triage config with the default prefixes, got %v", tr)
+	}
+	if tr := c.TriageFor("other", "repo"); tr.SkipAssignAuthor || !reflect.DeepEqual(tr.Prefixes, defaultTriagePrefixes) {
+		t.Errorf("expected the default triage config, got %v", tr)
+	}
+
+	if err := validateTriage([]Triage{{Repos: []string{"org"}, Prefixes: []string{"kind/"}}}); err == nil {
+		t.Error("expected an error for a prefix with a slash")
+	}
+	if err := validateTriage([]Triage{{Repos: []string{"org"}, Checkboxes: map[string]string{"Bug fix": ""}}}); err == nil {
+		t.Error("expected an error for a checkbox without a label")
+	}
+}
+
+func TestOrgAliases(t *testing.T) {
+	c := &Configuration{
+		Owners: Owners{
+			OrgAliases: []OrgAliases{
+				{Orgs: []string{"org", "other"}, Repo: "org/community"},
+				{Orgs: []string{"third"}, Repo: "third/.github", Branch: "main"},
+			},
+		},
+	}
+	c.setDefaults()
+	if err := validateOrgAliases(c.Owners.OrgAliases); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if repo, branch := c.OrgAliasesFor("other"); repo != "org/community" || branch != "master" {
+		t.Errorf("expected the aliases of org/community:master, got %s:%s", repo, branch)
+	}
+	if repo, branch := c.OrgAliasesFor("third"); repo != "third/.github" || branch != "main" {
+		t.Errorf("expected the aliases of third/.github:main, got %s:%s", repo, branch)
+	}
+	if repo, _ := c.OrgAliasesFor("unknown"); repo != "" {
+		t.Errorf("expected no aliases, got %s", repo)
+	}
+
+	if err := validateOrgAliases([]OrgAliases{{Orgs: []string{"org"}, Repo: "community"}}); err == nil {
+		t.Error("expected an error for a repo without an org")
+	}
+	if err := validateOrgAliases([]OrgAliases{{Orgs: []string{"org"}, Repo: "org/a"}, {Orgs: []string{"org"}, Repo: "org/b"}}); err == nil {
+		t.Error("expected an error for an org with two aliases repos")
+	}
+}
+
+func TestCommentEdits(t *testing.T) {
+	c := &Configuration{
+		CommentEdits: []CommentEdits{
+			{Repos: []string{"org"}},
+			{Repos: []string{"org/repo"}, Window: "1h"},
+		},
+	}
+	if err := compileRegexpsAndDurations(c); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e := c.CommentEditsFor("org", "repo"); e == nil || e.WindowDuration != time.Hour {
+		t.Errorf("expected the repo comment edits config, got %v", e)
+	}
+	if e := c.CommentEditsFor("org", "other"); e == nil || e.WindowDuration != defaultCommentEditWindow {
+		t.Errorf("expected the org comment edits config with the default window, got %v", e)
+	}
+	if e := c.CommentEditsFor("other", "repo"); e != nil {
+		t.Errorf("expected no comment edits config, got %v", e)
+	}
+
+	c.CommentEdits = []CommentEdits{{Repos: []string{"org"}, Window: "soon"}}
+	if err := compileRegexpsAndDurations(c); err == nil {
+		t.Error("expected an error for an invalid window")
+	}
+}
+
+func TestCommandGuards(t *testing.T) {
+	c := &Configuration{
+		CommandGuards: []CommandGuard{
+			{Repos: []string{"org"}},
+			{Repos: []string{"org/repo"}, LoopLimit: 5, LoopWindow: "10m"},
+		},
+	}
+	if err := c.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if g := c.CommandGuardFor("org", "repo"); g == nil || g.LoopLimit != 5 || g.LoopWindowDuration != 10*time.Minute {
+		t.Errorf("expected the repo command guard, got %v", g)
+	}
+	if g := c.CommandGuardFor("org", "other"); g == nil || g.LoopLimit != defaultCommandLoopLimit || g.LoopWindowDuration != defaultCommandLoopWindow {
+		t.Errorf("expected the org command guard with the defaults, got %v", g)
+	}
+	if g := c.CommandGuardFor("other", "repo"); g != nil {
+		t.Errorf("expected no command guard, got %v", g)
+	}
+
+	c.CommandGuards = []CommandGuard{{Repos: []string{"org"}, LoopWindow: "soon"}}
+	if err := c.Validate(); err == nil {
+		t.Error("expected an error for an invalid loop window")
+	}
+	c.CommandGuards = []CommandGuard{{LoopWindow: "1h"}}
+	if err := c.Validate(); err == nil {
+		t.Error("expected an error for a command guard without repos")
+	}
+}
+
+func TestBranchOverrides(t *testing.T) {
+	yes := true
+	c := &Configuration{
+		Triggers: []Trigger{{Repos: []string{"org"}, TrustedOrg: "org", ElideSkippedContexts: true}},
+		Lgtm:     []Lgtm{{Repos: []string{"org/repo"}}},
+		BranchOverrides: []BranchOverride{
+			{
+				Repos:    []string{"org/repo"},
+				Branches: []string{"release-.*"},
+				Trigger:  &Trigger{OnlyOrgMembers: true, TrustedUsers: []string{"release-bot"}},
+				Lgtm:     &Lgtm{StoreTreeHash: true},
+				Approve:  &Approve{RequireSelfApproval: &yes, Approvers: []string{"alice"}},
+			},
+		},
+	}
+	if err := c.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	trigger := c.TriggerFor("org", "repo")
+	release := c.TriggerForBranch(trigger, "org", "repo", "release-1.0")
+	if !release.OnlyOrgMembers || !reflect.DeepEqual(release.TrustedUsers, []string{"release-bot"}) || release.TrustedOrg != "org" || !release.ElideSkippedContexts {
+		t.Errorf("expected the release trigger to override the set fields of the repo trigger, got %v", release)
+	}
+	if trigger.OnlyOrgMembers {
+		t.Error("expected the repo trigger not to be modified")
+	}
+	for _, branch := range []string{"master", "my-release-1.0", "release"} {
+		if got := c.TriggerForBranch(trigger, "org", "repo", branch); got != trigger {
+			t.Errorf("expected the repo trigger for branch %s, got %v", branch, got)
+		}
+	}
+	if got := c.TriggerForBranch(trigger, "org", "other", "release-1.0"); got != trigger {
+		t.Errorf("expected the repo trigger for another repo, got %v", got)
+	}
+
+	if lgtm := c.LgtmForBranch(&c.Lgtm[0], "org", "repo", "release-2"); !lgtm.StoreTreeHash || !reflect.DeepEqual(lgtm.Repos, []string{"org/repo"}) {
+		t.Errorf("expected the release lgtm to store the tree hash, got %v", lgtm)
+	}
+	if approve := c.ApproveForBranch(&Approve{}, "org", "repo", "release-2"); approve.HasSelfApproval() || !reflect.DeepEqual(approve.Approvers, []string{"alice"}) {
+		t.Errorf("expected the release approve to require the approval of the release team, got %v", approve)
+	}
+
+	for _, override := range []BranchOverride{
+		{Branches: []string{"release-.*"}, Lgtm: &Lgtm{StoreTreeHash: true}},
+		{Repos: []string{"org"}, Lgtm: &Lgtm{StoreTreeHash: true}},
+		{Repos: []string{"org"}, Branches: []string{"release-.*"}},
+		{Repos: []string{"org"}, Branches: []string{"release-("}, Lgtm: &Lgtm{StoreTreeHash: true}},
+		{Repos: []string{"org"}, Branches: []string{"release-.*"}, Lgtm: &Lgtm{Repos: []string{"org/repo"}}},
+		{Repos: []string{"org"}, Branches: []string{"release-.*"}, Trigger: &Trigger{Quotas: []Quota{{Scope: "team", Max: 1, Window: "1h"}}}},
+	} {
+		c.BranchOverrides = []BranchOverride{override}
+		if err := c.Validate(); err == nil {
+			t.Errorf("expected an error for the branch override %v", override)
+		}
+	}
+}
+
+func TestDownstreams(t *testing.T) {
+	c := &Configuration{
+		Downstreams: []Downstream{
+			{Name: "jenkins", Endpoint: "http://jenkins/github-webhook/", Repos: []string{"org"}, ExcludeRepos: []string{"org/new"}},
+			{Name: "analytics", Endpoint: "https://analytics.example.com/hook", Attempts: 5, Backoff: "5s"},
+		},
+	}
+	if err := c.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d := c.Downstreams[0]; d.Attempts != defaultDownstreamAttempts || d.BackoffDuration != defaultDownstreamBackoff {
+		t.Errorf("expected the default attempts and backoff, got %v", d)
+	}
+	if d := c.Downstreams[1]; d.Attempts != 5 || d.BackoffDuration != 5*time.Second {
+		t.Errorf("expected the configured attempts and backoff, got %v", d)
+	}
+	for _, test := range []struct {
+		org, repo string
+		expected  []string
+	}{
+		{org: "org", repo: "repo", expected: []string{"jenkins", "analytics"}},
+		{org: "org", repo: "new", expected: []string{"analytics"}},
+		{org: "other", repo: "repo", expected: []string{"analytics"}},
+	} {
+		var names []string
+		for _, d := range c.DownstreamsFor(test.org, test.repo) {
+			names = append(names, d.Name)
+		}
+		if !reflect.DeepEqual(names, test.expected) {
+			t.Errorf("expected the downstreams %v for %s/%s, got %v", test.expected, test.org, test.repo, names)
+		}
+	}
+
+	for _, downstreams := range [][]Downstream{
+		{{Endpoint: "http://jenkins"}},
+		{{Name: "jenkins", Endpoint: "jenkins"}},
+		{{Name: "jenkins", Endpoint: "http://jenkins"}, {Name: "jenkins", Endpoint: "http://other"}},
+		{{Name: "jenkins", Endpoint: "http://jenkins", Attempts: -1}},
+		{{Name: "jenkins", Endpoint: "http://jenkins", Backoff: "later"}},
+	} {
+		c.Downstreams = downstreams
+		if err := c.Validate(); err == nil {
+			t.Errorf("expected an error for the downstreams %v", downstreams)
+		}
+	}
+}
+
+func TestReminders(t *testing.T) {
+	c := &Configuration{
+		Reminders: []Reminder{
+			{Repos: []string{"org"}, ExcludeRepos: []string{"org/legacy"}, After: "24h", EscalateAfter: "96h"},
+			{Repos: []string{"org/repo"}, After: "48h"},
+		},
+	}
+	if err := compileRegexpsAndDurations(c); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := validateReminders(c.Reminders); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if r := c.ReminderFor("org", "repo"); r == nil || r.AfterDuration != 48*time.Hour || r.EscalateAfterDuration != 0 {
+		t.Errorf("expected the repo reminder config, got %v", r)
+	}
+	if r := c.ReminderFor("org", "other"); r == nil || r.AfterDuration != 24*time.Hour || r.EscalateAfterDuration != 96*time.Hour {
+		t.Errorf("expected the org reminder config, got %v", r)
+	}
+	if r := c.ReminderFor("org", "legacy"); r != nil {
+		t.Errorf("expected the repo to opt out, got %v", r)
+	}
+	if r := c.ReminderFor("other", "repo"); r == nil || r.AfterDuration != defaultReminderAfter {
+		t.Errorf("expected the default reminder config, got %v", r)
+	}
+
+	c.Reminders = []Reminder{{Repos: []string{"org"}, After: "48h", EscalateAfter: "24h"}}
+	if err := compileRegexpsAndDurations(c); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := validateReminders(c.Reminders); err == nil {
+		t.Error("expected an error for an escalation before the reminder")
+	}
+	c.Reminders = []Reminder{{Repos: []string{"org"}, After: "soon"}}
+	if err := compileRegexpsAndDurations(c); err == nil {
+		t.Error("expected an error for an invalid after duration")
+	}
+}
+
+func TestForProvider(t *testing.T) {
+	c := &Configuration{
+		Plugins: map[string][]string{"org": {"lgtm"}},
+		Providers: map[string]ProviderPlugins{
+			"gitlab": {
+				Plugins:         map[string][]string{"org": {"approve"}},
+				ExternalPlugins: map[string][]ExternalPlugin{"org": {{Name: "needs-rebase"}}},
+			},
+		},
+	}
+	if err := c.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p := c.ForProvider(""); p != c {
+		t.Error("expected the configuration of the default provider")
+	}
+	if p := c.ForProvider("bitbucket"); p != c {
+		t.Error("expected the configuration of the default provider for a provider without plugins")
+	}
+	p := c.ForProvider("gitlab")
+	if !reflect.DeepEqual(p.Plugins, map[string][]string{"org": {"approve"}}) {
+		t.Errorf("expected the plugins of the provider, got %v", p.Plugins)
+	}
+	if endpoint := p.ExternalPlugins["org"][0].Endpoint; endpoint != "http://needs-rebase" {
+		t.Errorf("expected the default endpoint of the external plugin, got %s", endpoint)
+	}
+	if !reflect.DeepEqual(c.Plugins, map[string][]string{"org": {"lgtm"}}) {
+		t.Errorf("expected the plugins of the default provider to be unchanged, got %v", c.Plugins)
+	}
+}
+
 func TestSetDefault_Maps(t *testing.T) {
 	cases := []struct {
 		name     string
@@ -317,3 +665,135 @@ The list of patch release managers for each release can be found [here](https://
 		}
 	}
 }
+
+func TestTriggerQuotas(t *testing.T) {
+	testCases := []struct {
+		name      string
+		quota     Quota
+		rateLimit *CommandRateLimit
+		expected  time.Duration
+		invalid   bool
+	}{
+		{
+			name:     "org quota",
+			quota:    Quota{Scope: QuotaScopeOrg, Max: 500, Window: "24h"},
+			expected: 24 * time.Hour,
+		},
+		{
+			name:    "unknown scope",
+			quota:   Quota{Scope: "repo", Max: 10, Window: "1h"},
+			invalid: true,
+		},
+		{
+			name:    "no max",
+			quota:   Quota{Scope: QuotaScopeUser, Window: "1h"},
+			invalid: true,
+		},
+		{
+			name:    "no window",
+			quota:   Quota{Scope: QuotaScopeUser, Max: 10},
+			invalid: true,
+		},
+		{
+			name:      "command rate limit",
+			quota:     Quota{Scope: QuotaScopeUser, Max: 10, Window: "1h"},
+			rateLimit: &CommandRateLimit{Max: 5, Window: "1h"},
+			expected:  time.Hour,
+		},
+		{
+			name:      "command rate limit without max",
+			quota:     Quota{Scope: QuotaScopeUser, Max: 10, Window: "1h"},
+			rateLimit: &CommandRateLimit{Window: "1h"},
+			invalid:   true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := &Configuration{
+				Triggers: []Trigger{{Repos: []string{"org"}, Quotas: []Quota{tc.quota}, CommandRateLimit: tc.rateLimit}},
+			}
+			err := c.Validate()
+			if tc.invalid {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if d := c.TriggerFor("org", "repo").Quotas[0].WindowDuration; d != tc.expected {
+				t.Errorf("expected a window of %s, got %s", tc.expected, d)
+			}
+			if limit := c.TriggerFor("org", "repo").CommandRateLimit; limit != nil && limit.WindowDuration != time.Hour {
+				t.Errorf("expected a command rate limit window of 1h, got %s", limit.WindowDuration)
+			}
+		})
+	}
+}
+
+func TestTriggerFor(t *testing.T) {
+	c := &Configuration{
+		Triggers: []Trigger{
+			{Repos: []string{"org/repo"}, TrustedUsers: []string{"contributor"}},
+			{Repos: []string{"org"}, OnlyOrgMembers: true, TrustedLabel: "ok-to-test"},
+			{Repos: []string{DefaultsKey}, TrustedLabel: "trusted", SkipDraftPR: true},
+		},
+	}
+	expected := &Trigger{
+		Repos:          []string{"org/repo"},
+		OnlyOrgMembers: true,
+		TrustedUsers:   []string{"contributor"},
+		TrustedLabel:   "ok-to-test",
+		SkipDraftPR:    true,
+	}
+	if tr := c.TriggerFor("org", "repo"); !reflect.DeepEqual(tr, expected) {
+		t.Errorf("expected the trigger of the repo inheriting those of its org and the defaults, got %+v", tr)
+	}
+	expected = &Trigger{Repos: []string{DefaultsKey}, TrustedLabel: "trusted", SkipDraftPR: true}
+	if tr := c.TriggerFor("other", "repo"); !reflect.DeepEqual(tr, expected) {
+		t.Errorf("expected the default trigger, got %+v", tr)
+	}
+	c.Triggers = nil
+	if tr := c.TriggerFor("org", "repo"); !reflect.DeepEqual(tr, &Trigger{}) {
+		t.Errorf("expected an empty trigger, got %+v", tr)
+	}
+}
+
+func TestValidatePluginInheritance(t *testing.T) {
+	testCases := []struct {
+		name    string
+		plugins map[string][]string
+		invalid bool
+	}{
+		{
+			name:    "inherited plugins",
+			plugins: map[string][]string{DefaultsKey: {"lgtm"}, "org": {"approve"}, "org/repo": {"size"}},
+		},
+		{
+			name:    "disabled plugins",
+			plugins: map[string][]string{DefaultsKey: {"lgtm"}, "org": {"approve", "-lgtm"}, "org/repo": {"-approve"}},
+		},
+		{
+			name:    "plugin of the defaults duplicated for a repo",
+			plugins: map[string][]string{DefaultsKey: {"lgtm"}, "org/repo": {"lgtm"}},
+			invalid: true,
+		},
+		{
+			name:    "plugin disabled without being inherited",
+			plugins: map[string][]string{"org": {"approve"}, "org/repo": {"-lgtm"}},
+			invalid: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validatePlugins(tc.plugins)
+			if tc.invalid && err == nil {
+				t.Error("expected an error")
+			}
+			if !tc.invalid && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
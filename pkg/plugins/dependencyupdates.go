@@ -0,0 +1,83 @@
+package plugins
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// DependencyUpdate is the profile of the pull requests opened by dependency update bots, e.g. dependabot or renovate,
+// on a set of repos. The trigger plugin labels them ok-to-test and dependency-update and runs their presubmits, the
+// approve and lgtm plugins approve them if configured, and keeper merges them once their presubmits pass if one of
+// its queries matches the dependency-update label.
+type DependencyUpdate struct {
+	// Repos is either of the form org/repos or just org.
+	Repos []string `json:"repos,omitempty"`
+	// Authors are the logins of the bots opening the pull requests, e.g.
+	// dependabot[bot] or renovate[bot].
+	Authors []string `json:"authors"`
+	// Presubmits are the names of the presubmits run for the pull requests.
+	// The other presubmits are reported as skipped and keeper does not
+	// require them. Every presubmit runs if it is empty.
+	Presubmits []string `json:"presubmits,omitempty"`
+	// Approve approves the pull requests, as if the approvers of every
+	// changed file had approved them.
+	Approve bool `json:"approve,omitempty"`
+	// LGTM labels the pull requests lgtm, again after each new commit.
+	LGTM bool `json:"lgtm,omitempty"`
+}
+
+// DependencyUpdateFor returns the dependency update profile of the pull requests of the author on the repo, or nil if
+// the author is not a dependency update bot of the repo. The profiles of the repo take precedence over those of its
+// org.
+func (c *Configuration) DependencyUpdateFor(org, repo, author string) *DependencyUpdate {
+	fullName := fmt.Sprintf("%s/%s", org, repo)
+	for _, name := range []string{fullName, org} {
+		for i := range c.DependencyUpdates {
+			d := &c.DependencyUpdates[i]
+			if d.hasAuthor(author) && sets.NewString(d.Repos...).Has(name) {
+				return d
+			}
+		}
+	}
+	return nil
+}
+
+func (d *DependencyUpdate) hasAuthor(author string) bool {
+	for _, a := range d.Authors {
+		if strings.EqualFold(a, author) {
+			return true
+		}
+	}
+	return false
+}
+
+// ReducePresubmits splits the presubmits into those run for the pull requests of the profile and those skipped.
+func (d *DependencyUpdate) ReducePresubmits(presubmits []config.Presubmit) (run, skipped []config.Presubmit) {
+	if len(d.Presubmits) == 0 {
+		return presubmits, nil
+	}
+	names := sets.NewString(d.Presubmits...)
+	for _, ps := range presubmits {
+		if names.Has(ps.Name) {
+			run = append(run, ps)
+		} else {
+			skipped = append(skipped, ps)
+		}
+	}
+	return run, skipped
+}
+
+func validateDependencyUpdates(updates []DependencyUpdate) error {
+	for i, d := range updates {
+		if len(d.Repos) == 0 {
+			return fmt.Errorf("dependency update config #%d has no repos", i)
+		}
+		if len(d.Authors) == 0 {
+			return fmt.Errorf("dependency update config #%d has no authors", i)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+)
+
+func TestDependencyUpdates(t *testing.T) {
+	c := &Configuration{
+		DependencyUpdates: []DependencyUpdate{
+			{Repos: []string{"org"}, Authors: []string{"dependabot[bot]", "renovate[bot]"}},
+			{Repos: []string{"org/repo"}, Authors: []string{"Dependabot[bot]"}, Presubmits: []string{"unit"}, Approve: true, LGTM: true},
+		},
+	}
+	if err := validateDependencyUpdates(c.DependencyUpdates); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if update := c.DependencyUpdateFor("org", "other", "renovate[bot]"); update == nil || update.Approve {
+		t.Errorf("expected the profile of the org, got %v", update)
+	}
+	if update := c.DependencyUpdateFor("org", "other", "alice"); update != nil {
+		t.Errorf("expected no profile for a user, got %v", update)
+	}
+	if update := c.DependencyUpdateFor("other", "repo", "dependabot[bot]"); update != nil {
+		t.Errorf("expected no profile for another org, got %v", update)
+	}
+	update := c.DependencyUpdateFor("org", "repo", "dependabot[bot]")
+	if update == nil || !update.Approve {
+		t.Fatalf("expected the profile of the repo, got %v", update)
+	}
+
+	presubmits := []config.Presubmit{
+		{JobBase: config.JobBase{Name: "unit"}},
+		{JobBase: config.JobBase{Name: "e2e"}},
+	}
+	run, skipped := update.ReducePresubmits(presubmits)
+	if len(run) != 1 || run[0].Name != "unit" || len(skipped) != 1 || skipped[0].Name != "e2e" {
+		t.Errorf("expected unit to run and e2e to be skipped, got %v and %v", run, skipped)
+	}
+	if run, skipped := c.DependencyUpdateFor("org", "other", "renovate[bot]").ReducePresubmits(presubmits); len(run) != 2 || len(skipped) != 0 {
+		t.Errorf("expected every presubmit to run, got %v and %v", run, skipped)
+	}
+
+	if err := validateDependencyUpdates([]DependencyUpdate{{Repos: []string{"org"}}}); err == nil {
+		t.Error("expected an error for a profile without authors")
+	}
+}
@@ -27,6 +27,7 @@ import (
 
 	"github.com/jenkins-x/go-scm/scm"
 	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	"github.com/jenkins-x/lighthouse/pkg/util"
 	"github.com/sirupsen/logrus"
 
 	"github.com/jenkins-x/lighthouse/pkg/pluginhelp"
@@ -34,10 +35,10 @@ import (
 )
 
 var (
-	match           = regexp.MustCompile(`(?mi)^/(?:lh-)?(woof|bark)\s*$`)
-	fineRegex       = regexp.MustCompile(`(?mi)^/(?:lh-)?this-is-fine\s*$`)
-	notFineRegex    = regexp.MustCompile(`(?mi)^/(?:lh-)?this-is-not-fine\s*$`)
-	unbearableRegex = regexp.MustCompile(`(?mi)^/(?:lh-)?this-is-unbearable\s*$`)
+	match           = regexp.MustCompile(`(?mi)^/` + util.CommandPrefixPattern + `(woof|bark)\s*$`)
+	fineRegex       = regexp.MustCompile(`(?mi)^/` + util.CommandPrefixPattern + `this-is-fine\s*$`)
+	notFineRegex    = regexp.MustCompile(`(?mi)^/` + util.CommandPrefixPattern + `this-is-not-fine\s*$`)
+	unbearableRegex = regexp.MustCompile(`(?mi)^/` + util.CommandPrefixPattern + `this-is-unbearable\s*$`)
 	filetypes       = regexp.MustCompile(`(?i)\.(jpg|gif|png)$`)
 )
 
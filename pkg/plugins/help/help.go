@@ -25,16 +25,17 @@ import (
 	"github.com/jenkins-x/lighthouse/pkg/pluginhelp"
 	"github.com/jenkins-x/lighthouse/pkg/plugins"
 	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	"github.com/jenkins-x/lighthouse/pkg/util"
 	"github.com/sirupsen/logrus"
 )
 
 const pluginName = "help"
 
 var (
-	helpRe                     = regexp.MustCompile(`(?mi)^/(?:lh-)?help\s*$`)
-	helpRemoveRe               = regexp.MustCompile(`(?mi)^/(?:lh-)?remove-help\s*$`)
-	helpGoodFirstIssueRe       = regexp.MustCompile(`(?mi)^/(?:lh-)?good-first-issue\s*$`)
-	helpGoodFirstIssueRemoveRe = regexp.MustCompile(`(?mi)^/(?:lh-)?remove-good-first-issue\s*$`)
+	helpRe                     = regexp.MustCompile(`(?mi)^/` + util.CommandPrefixPattern + `help\s*$`)
+	helpRemoveRe               = regexp.MustCompile(`(?mi)^/` + util.CommandPrefixPattern + `remove-help\s*$`)
+	helpGoodFirstIssueRe       = regexp.MustCompile(`(?mi)^/` + util.CommandPrefixPattern + `good-first-issue\s*$`)
+	helpGoodFirstIssueRemoveRe = regexp.MustCompile(`(?mi)^/` + util.CommandPrefixPattern + `remove-good-first-issue\s*$`)
 	helpGuidelinesURL          = "https://git.k8s.io/community/contributors/guide/help-wanted.md"
 	helpMsgPruneMatch          = "This request has been marked as needing help from a contributor."
 	helpMsg                    = `
@@ -43,7 +44,7 @@ var (
 Please ensure the request meets the requirements listed [here](` + helpGuidelinesURL + `).
 
 If this request no longer meets these requirements, the label can be removed
-by commenting with the ` + "`/remove-help`" + ` command.
+by commenting with the ` + "`" + util.FormatCommand("/remove-help") + "`" + ` command.
 `
 	goodFirstIssueMsgPruneMatch = "This request has been marked as suitable for new contributors."
 	goodFirstIssueMsg           = `
@@ -52,7 +53,7 @@ by commenting with the ` + "`/remove-help`" + ` command.
 Please ensure the request meets the requirements listed [here](` + helpGuidelinesURL + "#good-first-issue" + `).
 
 If this request no longer meets these requirements, the label can be removed
-by commenting with the ` + "`/remove-good-first-issue`" + ` command.
+by commenting with the ` + "`" + util.FormatCommand("/remove-good-first-issue") + "`" + ` command.
 `
 )
 
@@ -22,14 +22,12 @@ package hold
 
 import (
 	"fmt"
-	"regexp"
 
 	"github.com/jenkins-x/go-scm/scm"
 	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
 	"github.com/sirupsen/logrus"
 
 	"github.com/jenkins-x/lighthouse/pkg/labels"
-	"github.com/jenkins-x/lighthouse/pkg/pluginhelp"
 	"github.com/jenkins-x/lighthouse/pkg/plugins"
 )
 
@@ -38,30 +36,29 @@ const (
 	PluginName = "hold"
 )
 
-var (
-	labelRe       = regexp.MustCompile(`(?mi)^/(?:lh-)?hold\s*$`)
-	labelCancelRe = regexp.MustCompile(`(?mi)^/(?:lh-)?hold cancel\s*$`)
-)
+// holdCommand is the command adding or removing the hold label
+var holdCommand = plugins.Command{
+	Name: "hold",
+	Args: &plugins.CommandArgs{
+		Usage:    "[cancel]",
+		Pattern:  "cancel",
+		Optional: true,
+	},
+	Description: "Adds or removes the `" + labels.Hold + "` Label which is used to indicate that the PR should not be automatically merged.",
+	WhoCanUse:   "Anyone can use the /hold command to add or remove the '" + labels.Hold + "' Label.",
+	Examples:    []string{"/hold", "/hold cancel"},
+}
 
 type hasLabelFunc func(label string, issueLabels []*scm.Label) bool
 
 func init() {
-	plugins.RegisterGenericCommentHandler(PluginName, handleGenericComment, helpProvider)
-}
-
-func helpProvider(config *plugins.Configuration, enabledRepos []string) (*pluginhelp.PluginHelp, error) {
+	command := holdCommand
+	command.Handler = handleCommand
 	// The Config field is omitted because this plugin is not configurable.
-	pluginHelp := &pluginhelp.PluginHelp{
+	plugins.RegisterPlugin(PluginName, plugins.Plugin{
 		Description: "The hold plugin allows anyone to add or remove the '" + labels.Hold + "' Label from a pull request in order to temporarily prevent the PR from merging without withholding approval.",
-	}
-	pluginHelp.AddCommand(pluginhelp.Command{
-		Usage:       "/hold [cancel]",
-		Description: "Adds or removes the `" + labels.Hold + "` Label which is used to indicate that the PR should not be automatically merged.",
-		Featured:    false,
-		WhoCanUse:   "Anyone can use the /hold command to add or remove the '" + labels.Hold + "' Label.",
-		Examples:    []string{"/hold", "/hold cancel"},
+		Commands:    []plugins.Command{command},
 	})
-	return pluginHelp, nil
 }
 
 type scmProviderClient interface {
@@ -70,11 +67,11 @@ type scmProviderClient interface {
 	GetIssueLabels(org, repo string, number int, pr bool) ([]*scm.Label, error)
 }
 
-func handleGenericComment(pc plugins.Agent, e scmprovider.GenericCommentEvent) error {
+func handleCommand(match plugins.CommandMatch, pc plugins.Agent, e scmprovider.GenericCommentEvent) error {
 	hasLabel := func(label string, labels []*scm.Label) bool {
 		return scmprovider.HasLabel(label, labels)
 	}
-	return handle(pc.SCMProviderClient, pc.Logger, &e, hasLabel)
+	return apply(pc.SCMProviderClient, pc.Logger, &e, hasLabel, match.Args == "")
 }
 
 // handle drives the pull request to the desired state. If any user adds
@@ -84,15 +81,21 @@ func handle(spc scmProviderClient, log *logrus.Entry, e *scmprovider.GenericComm
 	if e.Action != scm.ActionCreate {
 		return nil
 	}
-	needsLabel := false
-	if labelRe.MatchString(e.Body) {
-		needsLabel = true
-	} else if labelCancelRe.MatchString(e.Body) {
-		needsLabel = false
-	} else {
+	matches := holdCommand.Match(e.Body)
+	if len(matches) == 0 {
 		return nil
 	}
+	needsLabel := false
+	for _, match := range matches {
+		if match.Args == "" {
+			needsLabel = true
+		}
+	}
+	return apply(spc, log, e, f, needsLabel)
+}
 
+// apply adds the hold label if it is needed, or removes it otherwise
+func apply(spc scmProviderClient, log *logrus.Entry, e *scmprovider.GenericCommentEvent, f hasLabelFunc, needsLabel bool) error {
 	org := e.Repo.Namespace
 	repo := e.Repo.Name
 	issueLabels, err := spc.GetIssueLabels(org, repo, e.Number, e.IsPR)
@@ -23,6 +23,7 @@ import (
 
 	"github.com/jenkins-x/go-scm/scm"
 	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	"github.com/jenkins-x/lighthouse/pkg/util"
 	"github.com/sirupsen/logrus"
 
 	"github.com/jenkins-x/lighthouse/pkg/pluginhelp"
@@ -33,10 +34,10 @@ const pluginName = "label"
 
 var (
 	defaultLabels           = []string{"kind", "priority", "area"}
-	labelRegex              = regexp.MustCompile(`(?m)^/(?:lh-)?(area|committee|kind|language|priority|sig|triage|wg)\s*(.*)$`)
-	removeLabelRegex        = regexp.MustCompile(`(?m)^/(?:lh-)?remove-(area|committee|kind|language|priority|sig|triage|wg)\s*(.*)$`)
-	customLabelRegex        = regexp.MustCompile(`(?m)^/(?:lh-)?label\s*(.*)$`)
-	customRemoveLabelRegex  = regexp.MustCompile(`(?m)^/(?:lh-)?remove-label\s*(.*)$`)
+	labelRegex              = regexp.MustCompile(`(?m)^/` + util.CommandPrefixPattern + `(area|committee|kind|language|priority|sig|triage|wg)\s*(.*)$`)
+	removeLabelRegex        = regexp.MustCompile(`(?m)^/` + util.CommandPrefixPattern + `remove-(area|committee|kind|language|priority|sig|triage|wg)\s*(.*)$`)
+	customLabelRegex        = regexp.MustCompile(`(?m)^/` + util.CommandPrefixPattern + `label\s*(.*)$`)
+	customRemoveLabelRegex  = regexp.MustCompile(`(?m)^/` + util.CommandPrefixPattern + `remove-label\s*(.*)$`)
 	nonExistentLabelOnIssue = "Those labels are not set on the issue: `%v`"
 )
 
@@ -105,7 +106,8 @@ func getLabelsFromGenericMatches(matches [][]string, additionalLabels []string)
 	var labels []string
 	for _, match := range matches {
 		parts := strings.Split(match[0], " ")
-		if ((parts[0] != "/label") && (parts[0] != "/remove-label") && (parts[0] != "/lh-label") && (parts[0] != "/lh-remove-label")) || len(parts) != 2 {
+		command := strings.TrimPrefix(strings.TrimPrefix(parts[0], "/"), util.CommandPrefix)
+		if ((command != "label") && (command != "remove-label")) || len(parts) != 2 {
 			continue
 		}
 		for _, l := range additionalLabels {
@@ -24,6 +24,7 @@ import (
 
 	"github.com/jenkins-x/go-scm/scm"
 	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	"github.com/jenkins-x/lighthouse/pkg/util"
 	"github.com/sirupsen/logrus"
 	"k8s.io/apimachinery/pkg/util/sets"
 
@@ -31,6 +32,7 @@ import (
 	"github.com/jenkins-x/lighthouse/pkg/pluginhelp"
 	"github.com/jenkins-x/lighthouse/pkg/plugins"
 	"github.com/jenkins-x/lighthouse/pkg/repoowners"
+	"github.com/jenkins-x/lighthouse/pkg/store"
 )
 
 const (
@@ -45,8 +47,8 @@ var (
 	configInfoStoreTreeHash    = `Squashing commits does not remove LGTM.`
 	// LGTMLabel is the name of the lgtm label applied by the lgtm plugin
 	LGTMLabel           = labels.LGTM
-	lgtmRe              = regexp.MustCompile(`(?mi)^/(?:lh-)?lgtm(?: no-issue)?\s*$`)
-	lgtmCancelRe        = regexp.MustCompile(`(?mi)^/(?:lh-)?lgtm cancel\s*$`)
+	lgtmRe              = regexp.MustCompile(`(?mi)^/` + util.CommandPrefixPattern + `lgtm(?: no-issue)?\s*$`)
+	lgtmCancelRe        = regexp.MustCompile(`(?mi)^/` + util.CommandPrefixPattern + `lgtm cancel\s*$`)
 	removeLGTMLabelNoti = "New changes are detected. LGTM label has been removed."
 )
 
@@ -132,6 +134,12 @@ func optionsForRepo(config *plugins.Configuration, org, repo string) *plugins.Lg
 	return &plugins.Lgtm{}
 }
 
+// optionsForBranch gets the plugins.Lgtm struct that is applicable to the pull requests of the indicated branch of
+// the repo.
+func optionsForBranch(config *plugins.Configuration, org, repo, branch string) *plugins.Lgtm {
+	return config.LgtmForBranch(optionsForRepo(config, org, repo), org, repo, branch)
+}
+
 type scmProviderClient interface {
 	IsCollaborator(owner, repo, login string) (bool, error)
 	AddLabel(owner, repo string, number int, label string, pr bool) error
@@ -155,8 +163,12 @@ type scmProviderClient interface {
 type reviewCtx struct {
 	author, issueAuthor, body, htmlURL string
 	repo                               scm.Repository
-	assignees                          []scm.User
-	number                             int
+	// branch is the base branch of the pull request, which is fetched when empty if the repo has branch overrides
+	branch    string
+	assignees []scm.User
+	number    int
+	// treeHashes shares the tree hashes of the LGTM'ed pull requests between the replicas, if not nil
+	treeHashes store.Store
 }
 
 func handleGenericCommentEvent(pc plugins.Agent, e scmprovider.GenericCommentEvent) error {
@@ -164,7 +176,7 @@ func handleGenericCommentEvent(pc plugins.Agent, e scmprovider.GenericCommentEve
 	if err != nil {
 		return err
 	}
-	return handleGenericComment(pc.SCMProviderClient, pc.PluginConfig, pc.OwnersClient, pc.Logger, cp, e)
+	return handleGenericComment(pc.SCMProviderClient, pc.PluginConfig, pc.OwnersClient, pc.Logger, cp, e, pc.Store)
 }
 
 func handlePullRequestEvent(pc plugins.Agent, pre scm.PullRequestHook) error {
@@ -173,12 +185,13 @@ func handlePullRequestEvent(pc plugins.Agent, pre scm.PullRequestHook) error {
 		pc.SCMProviderClient,
 		pc.PluginConfig,
 		&pre,
+		pc.Store,
 	)
 }
 
 func handlePullRequestReviewEvent(pc plugins.Agent, e scm.ReviewHook) error {
 	// If ReviewActsAsLgtm is disabled, ignore review event.
-	opts := optionsForRepo(pc.PluginConfig, e.Repo.Namespace, e.Repo.Name)
+	opts := optionsForBranch(pc.PluginConfig, e.Repo.Namespace, e.Repo.Name, e.PullRequest.Base.Ref)
 	if !opts.ReviewActsAsLgtm {
 		return nil
 	}
@@ -186,10 +199,10 @@ func handlePullRequestReviewEvent(pc plugins.Agent, e scm.ReviewHook) error {
 	if err != nil {
 		return err
 	}
-	return handlePullRequestReview(pc.SCMProviderClient, pc.PluginConfig, pc.OwnersClient, pc.Logger, cp, e)
+	return handlePullRequestReview(pc.SCMProviderClient, pc.PluginConfig, pc.OwnersClient, pc.Logger, cp, e, pc.Store)
 }
 
-func handleGenericComment(spc scmProviderClient, config *plugins.Configuration, ownersClient repoowners.Interface, log *logrus.Entry, cp commentPruner, e scmprovider.GenericCommentEvent) error {
+func handleGenericComment(spc scmProviderClient, config *plugins.Configuration, ownersClient repoowners.Interface, log *logrus.Entry, cp commentPruner, e scmprovider.GenericCommentEvent, treeHashes store.Store) error {
 	rc := reviewCtx{
 		author:      e.Author.Login,
 		issueAuthor: e.IssueAuthor.Login,
@@ -198,6 +211,7 @@ func handleGenericComment(spc scmProviderClient, config *plugins.Configuration,
 		repo:        e.Repo,
 		assignees:   e.Assignees,
 		number:      e.Number,
+		treeHashes:  treeHashes,
 	}
 
 	// Only consider open PRs and new comments.
@@ -220,15 +234,17 @@ func handleGenericComment(spc scmProviderClient, config *plugins.Configuration,
 	return handle(wantLGTM, config, ownersClient, rc, spc, log, cp)
 }
 
-func handlePullRequestReview(spc scmProviderClient, config *plugins.Configuration, ownersClient repoowners.Interface, log *logrus.Entry, cp commentPruner, e scm.ReviewHook) error {
+func handlePullRequestReview(spc scmProviderClient, config *plugins.Configuration, ownersClient repoowners.Interface, log *logrus.Entry, cp commentPruner, e scm.ReviewHook, treeHashes store.Store) error {
 	rc := reviewCtx{
 		author:      e.Review.Author.Login,
 		issueAuthor: e.PullRequest.Author.Login,
 		repo:        e.Repo,
+		branch:      e.PullRequest.Base.Ref,
 		assignees:   e.PullRequest.Assignees,
 		number:      e.PullRequest.Number,
 		body:        e.Review.Body,
 		htmlURL:     e.Review.Link,
+		treeHashes:  treeHashes,
 	}
 
 	// Only react to reviews that are being submitted (not editted or dismissed).
@@ -344,7 +360,18 @@ func handle(wantLGTM bool, config *plugins.Configuration, ownersClient repoowner
 	hasLGTM := scmprovider.HasLabel(LGTMLabel, labels)
 
 	// remove the label if necessary, we're done after this
-	opts := optionsForRepo(config, rc.repo.Namespace, rc.repo.Name)
+	opts := optionsForRepo(config, org, repoName)
+	if len(config.BranchOverridesFor(org, repoName)) > 0 {
+		branch := rc.branch
+		if branch == "" {
+			pr, err := spc.GetPullRequest(org, repoName, number)
+			if err != nil {
+				return err
+			}
+			branch = pr.Base.Ref
+		}
+		opts = config.LgtmForBranch(opts, org, repoName, branch)
+	}
 	if hasLGTM && !wantLGTM {
 		log.Info("Removing LGTM label.")
 		if err := spc.RemoveLabel(org, repoName, number, LGTMLabel, true); err != nil {
@@ -354,6 +381,11 @@ func handle(wantLGTM bool, config *plugins.Configuration, ownersClient repoowner
 			cp.PruneComments(true, func(comment *scm.Comment) bool {
 				return addLGTMLabelNotificationRe.MatchString(comment.Body)
 			})
+			if rc.treeHashes != nil {
+				if err := rc.treeHashes.Delete(treeHashKey(org, repoName, number)); err != nil {
+					log.WithError(err).Warn("Failed to forget the stored tree-hash.")
+				}
+			}
 		}
 	} else if !hasLGTM && wantLGTM {
 		log.Info("Adding LGTM label.")
@@ -378,6 +410,11 @@ func handle(wantLGTM bool, config *plugins.Configuration, ownersClient repoowner
 				if err := spc.CreateComment(org, repoName, number, true, fmt.Sprintf(addLGTMLabelNotification, treeHash)); err != nil {
 					log.WithError(err).Error("Failed to add comment.")
 				}
+				if rc.treeHashes != nil && treeHash != "" {
+					if err := rc.treeHashes.Set(treeHashKey(org, repoName, number), treeHash, 0); err != nil {
+						log.WithError(err).Warn("Failed to store the tree-hash.")
+					}
+				}
 			}
 			// Delete the LGTM removed noti after the LGTM label is added.
 			cp.PruneComments(true, func(comment *scm.Comment) bool {
@@ -414,20 +451,24 @@ func stickyLgtm(log *logrus.Entry, spc scmProviderClient, config *plugins.Config
 	return false
 }
 
-func handlePullRequest(log *logrus.Entry, spc scmProviderClient, config *plugins.Configuration, pe *scm.PullRequestHook) error {
+func handlePullRequest(log *logrus.Entry, spc scmProviderClient, config *plugins.Configuration, pe *scm.PullRequestHook, treeHashes store.Store) error {
 	if pe.PullRequest.Merged {
 		return nil
 	}
 
-	if pe.Action != scm.ActionSync {
-		return nil
-	}
-
 	org := pe.PullRequest.Base.Repo.Namespace
 	repo := pe.PullRequest.Base.Repo.Name
 	number := pe.PullRequest.Number
 
-	opts := optionsForRepo(config, org, repo)
+	if update := config.DependencyUpdateFor(org, repo, pe.PullRequest.Author.Login); update != nil && update.LGTM {
+		return handleDependencyUpdate(log, spc, pe)
+	}
+
+	if pe.Action != scm.ActionSync {
+		return nil
+	}
+
+	opts := optionsForBranch(config, org, repo, pe.PullRequest.Base.Ref)
 	if stickyLgtm(log, spc, config, opts, pe.PullRequest.Author.Login, org, repo) {
 		// If the author is trusted, skip tree hash verification and LGTM removal.
 		return nil
@@ -443,24 +484,29 @@ func handlePullRequest(log *logrus.Entry, spc scmProviderClient, config *plugins
 	}
 
 	if opts.StoreTreeHash {
-		// Check if we have a tree-hash comment
-		var lastLgtmTreeHash string
-		botname, err := spc.BotName()
-		if err != nil {
-			return err
-		}
-		comments, err := spc.ListPullRequestComments(org, repo, number)
+		// Check if we have a stored tree-hash, or else a tree-hash comment
+		lastLgtmTreeHash, err := storedTreeHash(treeHashes, org, repo, number)
 		if err != nil {
-			log.WithError(err).Error("Failed to get issue comments.")
+			log.WithError(err).Warn("Failed to get the stored tree-hash.")
 		}
-		// older comments are still present
-		// iterate backwards to find the last LGTM tree-hash
-		for i := len(comments) - 1; i >= 0; i-- {
-			comment := comments[i]
-			m := addLGTMLabelNotificationRe.FindStringSubmatch(comment.Body)
-			if comment.Author.Login == botname && m != nil && comment.Updated.Equal(comment.Created) {
-				lastLgtmTreeHash = m[1]
-				break
+		if lastLgtmTreeHash == "" {
+			botname, err := spc.BotName()
+			if err != nil {
+				return err
+			}
+			comments, err := spc.ListPullRequestComments(org, repo, number)
+			if err != nil {
+				log.WithError(err).Error("Failed to get issue comments.")
+			}
+			// older comments are still present
+			// iterate backwards to find the last LGTM tree-hash
+			for i := len(comments) - 1; i >= 0; i-- {
+				comment := comments[i]
+				m := addLGTMLabelNotificationRe.FindStringSubmatch(comment.Body)
+				if comment.Author.Login == botname && m != nil && comment.Updated.Equal(comment.Created) {
+					lastLgtmTreeHash = m[1]
+					break
+				}
 			}
 		}
 		if lastLgtmTreeHash != "" {
@@ -481,6 +527,11 @@ func handlePullRequest(log *logrus.Entry, spc scmProviderClient, config *plugins
 	if err := spc.RemoveLabel(org, repo, number, LGTMLabel, true); err != nil {
 		return fmt.Errorf("failed removing lgtm label: %v", err)
 	}
+	if treeHashes != nil {
+		if err := treeHashes.Delete(treeHashKey(org, repo, number)); err != nil {
+			log.WithError(err).Warn("Failed to forget the stored tree-hash.")
+		}
+	}
 
 	// Launch a comment to inform participants that LGTM label is removed due to new
 	// pull request changes.
@@ -488,6 +539,40 @@ func handlePullRequest(log *logrus.Entry, spc scmProviderClient, config *plugins
 	return spc.CreateComment(org, repo, number, true, removeLGTMLabelNoti)
 }
 
+// handleDependencyUpdate labels the pull request of a dependency update bot lgtm when it is opened and after each
+// new commit, which would otherwise remove the label
+func handleDependencyUpdate(log *logrus.Entry, spc scmProviderClient, pe *scm.PullRequestHook) error {
+	if pe.Action != scm.ActionOpen && pe.Action != scm.ActionReopen && pe.Action != scm.ActionSync {
+		return nil
+	}
+	org := pe.PullRequest.Base.Repo.Namespace
+	repo := pe.PullRequest.Base.Repo.Name
+	number := pe.PullRequest.Number
+	labels, err := spc.GetIssueLabels(org, repo, number, true)
+	if err != nil {
+		return err
+	}
+	if scmprovider.HasLabel(LGTMLabel, labels) {
+		return nil
+	}
+	log.Infof("Adding LGTM label to the dependency update PR of %s.", pe.PullRequest.Author.Login)
+	return spc.AddLabel(org, repo, number, LGTMLabel, true)
+}
+
+// treeHashKey is the key of the tree-hash of the LGTM'ed pull request in the state store
+func treeHashKey(org, repo string, number int) string {
+	return fmt.Sprintf("lgtm/tree-hash/%s/%s#%d", org, repo, number)
+}
+
+// storedTreeHash returns the tree-hash of the LGTM'ed pull request in the state store, if any
+func storedTreeHash(treeHashes store.Store, org, repo string, number int) (string, error) {
+	if treeHashes == nil {
+		return "", nil
+	}
+	treeHash, _, err := treeHashes.Get(treeHashKey(org, repo, number))
+	return treeHash, err
+}
+
 func skipCollaborators(config *plugins.Configuration, org, repo string) bool {
 	full := fmt.Sprintf("%s/%s", org, repo)
 	for _, elem := range config.Owners.SkipCollaborators {
@@ -25,6 +25,7 @@ import (
 	"github.com/jenkins-x/go-scm/scm"
 	"github.com/jenkins-x/go-scm/scm/driver/fake"
 	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	"github.com/jenkins-x/lighthouse/pkg/store"
 	"github.com/sirupsen/logrus"
 	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -348,7 +349,7 @@ func TestLGTMComment(t *testing.T) {
 				SCMProviderClient:   fc,
 				PullRequestComments: fc.PullRequestComments[5],
 			}
-			if err := handleGenericComment(fakeClient, pc, oc, logrus.WithField("plugin", PluginName), fp, *e); err != nil {
+			if err := handleGenericComment(fakeClient, pc, oc, logrus.WithField("plugin", PluginName), fp, *e, nil); err != nil {
 				t.Fatalf("didn't expect error from lgtmComment: %v", err)
 			}
 			if err := fakeClient.PopulateFakeLabelsFromComments("org", "repo", 5, fakeLabel, tc.hasLGTM && tc.shouldToggle); err != nil {
@@ -509,7 +510,7 @@ func TestLGTMCommentWithLGTMNoti(t *testing.T) {
 			SCMProviderClient:   fc,
 			PullRequestComments: fc.PullRequestComments[5],
 		}
-		if err := handleGenericComment(fakeClient, pc, oc, logrus.WithField("plugin", PluginName), fp, *e); err != nil {
+		if err := handleGenericComment(fakeClient, pc, oc, logrus.WithField("plugin", PluginName), fp, *e, nil); err != nil {
 			t.Errorf("For case %s, didn't expect error from lgtmComment: %v", tc.name, err)
 			continue
 		}
@@ -706,7 +707,7 @@ func TestLGTMFromApproveReview(t *testing.T) {
 			SCMProviderClient:   fc,
 			PullRequestComments: fc.PullRequestComments[5],
 		}
-		if err := handlePullRequestReview(fakeClient, pc, oc, logrus.WithField("plugin", PluginName), fp, *e); err != nil {
+		if err := handlePullRequestReview(fakeClient, pc, oc, logrus.WithField("plugin", PluginName), fp, *e, nil); err != nil {
 			t.Errorf("For case %s, didn't expect error from pull request review: %v", tc.name, err)
 			continue
 		}
@@ -1007,6 +1008,7 @@ func TestHandlePullRequest(t *testing.T) {
 				fakeClient,
 				pc,
 				&c.event,
+				nil,
 			)
 
 			if err != nil && c.err == nil {
@@ -1123,6 +1125,104 @@ func TestAddTreeHashComment(t *testing.T) {
 	}
 }
 
+func TestStoredTreeHash(t *testing.T) {
+	SHA := "0bd3ed50c88cd53a09316bf7a298f900e9371652"
+	treeSHA := "6dcb09b5b57875f334f61aebed695e2e4193db5e"
+	pc := &plugins.Configuration{}
+	pc.Lgtm = append(pc.Lgtm, plugins.Lgtm{
+		Repos:         []string{"kubernetes/kubernetes"},
+		StoreTreeHash: true,
+	})
+	treeHashes := store.NewMemoryStore()
+	rc := reviewCtx{
+		author:      "collab1",
+		issueAuthor: "bob",
+		repo:        scm.Repository{Namespace: "kubernetes", Name: "kubernetes"},
+		number:      101,
+		body:        "/lgtm",
+		treeHashes:  treeHashes,
+	}
+	fakeScmClient, fc := fake.NewDefault()
+	fakeClient := scmprovider.ToClient(fakeScmClient, "k8s-ci-robot")
+	fc.PullRequests[101] = &scm.PullRequest{
+		Number: 101,
+		Base:   scm.PullRequestBranch{Ref: "master"},
+		Head:   scm.PullRequestBranch{Sha: SHA},
+	}
+	fc.Collaborators = []string{"collab1"}
+	commit := &scm.Commit{}
+	commit.Tree.Sha = treeSHA
+	fc.Commits[SHA] = commit
+
+	if err := handle(true, pc, &fakeOwnersClient{}, rc, fakeClient, logrus.WithField("plugin", PluginName), &fakePruner{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stored, err := storedTreeHash(treeHashes, "kubernetes", "kubernetes", 101); err != nil || stored != treeSHA {
+		t.Fatalf("expected the tree-hash %s to be stored, got %q (%v)", treeSHA, stored, err)
+	}
+
+	// the label is kept without looking for the tree-hash comment
+	fc.PullRequestComments = map[int][]*scm.Comment{}
+	event := &scm.PullRequestHook{
+		Action:      scm.ActionSync,
+		PullRequest: *fc.PullRequests[101],
+	}
+	event.PullRequest.Base.Repo = rc.repo
+	if err := handlePullRequest(logrus.WithField("plugin", PluginName), fakeClient, pc, event, treeHashes); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(fc.PullRequestLabelsRemoved) != 0 {
+		t.Fatalf("expected the label to be kept, got %v removed", fc.PullRequestLabelsRemoved)
+	}
+
+	// the stored tree-hash is forgotten once the tree changes
+	commit.Tree.Sha = "changed"
+	if err := handlePullRequest(logrus.WithField("plugin", PluginName), fakeClient, pc, event, treeHashes); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(fc.PullRequestLabelsRemoved) != 1 {
+		t.Fatalf("expected the label to be removed, got %v removed", fc.PullRequestLabelsRemoved)
+	}
+	if stored, err := storedTreeHash(treeHashes, "kubernetes", "kubernetes", 101); err != nil || stored != "" {
+		t.Fatalf("expected the tree-hash to be forgotten, got %q (%v)", stored, err)
+	}
+}
+
+func TestDependencyUpdateLGTM(t *testing.T) {
+	pc := &plugins.Configuration{
+		DependencyUpdates: []plugins.DependencyUpdate{
+			{Repos: []string{"kubernetes"}, Authors: []string{"dependabot[bot]"}, LGTM: true},
+		},
+	}
+	fakeScmClient, fc := fake.NewDefault()
+	fakeClient := scmprovider.ToClient(fakeScmClient, "k8s-ci-robot")
+	event := &scm.PullRequestHook{
+		Action: scm.ActionOpen,
+		PullRequest: scm.PullRequest{
+			Number: 101,
+			Author: scm.User{Login: "dependabot[bot]"},
+			Base:   scm.PullRequestBranch{Ref: "master", Repo: scm.Repository{Namespace: "kubernetes", Name: "kubernetes"}},
+		},
+	}
+	if err := handlePullRequest(logrus.WithField("plugin", PluginName), fakeClient, pc, event, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{"kubernetes/kubernetes#101:" + LGTMLabel}
+	if !equality.Semantic.DeepEqual(fc.PullRequestLabelsAdded, expected) {
+		t.Fatalf("expected %v to be added, got %v", expected, fc.PullRequestLabelsAdded)
+	}
+
+	// the label is kept after a new commit
+	fc.PullRequestLabelsExisting = expected
+	event.Action = scm.ActionSync
+	if err := handlePullRequest(logrus.WithField("plugin", PluginName), fakeClient, pc, event, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(fc.PullRequestLabelsRemoved) != 0 || len(fc.PullRequestLabelsAdded) != 1 {
+		t.Fatalf("expected the label to be kept, got %v removed and %v added", fc.PullRequestLabelsRemoved, fc.PullRequestLabelsAdded)
+	}
+}
+
 func TestRemoveTreeHashComment(t *testing.T) {
 	treeSHA := "6dcb09b5b57875f334f61aebed695e2e4193db5e"
 	pc := &plugins.Configuration{}
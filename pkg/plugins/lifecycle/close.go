@@ -22,12 +22,13 @@ import (
 
 	"github.com/jenkins-x/go-scm/scm"
 	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	"github.com/jenkins-x/lighthouse/pkg/util"
 	"github.com/sirupsen/logrus"
 
 	"github.com/jenkins-x/lighthouse/pkg/plugins"
 )
 
-var closeRe = regexp.MustCompile(`(?mi)^/(?:lh-)?close\s*$`)
+var closeRe = regexp.MustCompile(`(?mi)^/` + util.CommandPrefixPattern + `close\s*$`)
 
 type closeClient interface {
 	IsCollaborator(owner, repo, login string) (bool, error)
@@ -22,12 +22,13 @@ import (
 
 	"github.com/jenkins-x/go-scm/scm"
 	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	"github.com/jenkins-x/lighthouse/pkg/util"
 	"github.com/sirupsen/logrus"
 
 	"github.com/jenkins-x/lighthouse/pkg/plugins"
 )
 
-var reopenRe = regexp.MustCompile(`(?mi)^/(?:lh-)?reopen\s*$`)
+var reopenRe = regexp.MustCompile(`(?mi)^/` + util.CommandPrefixPattern + `reopen\s*$`)
 
 type scmProviderClient interface {
 	IsCollaborator(owner, repo, login string) (bool, error)
@@ -0,0 +1,95 @@
+// Package mergeafter contains a plugin scheduling the merge of pull requests with a merge-after label, which keeps
+// them out of the keeper pool until the time of the label, so that merges can be coordinated with release trains.
+package mergeafter
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse/pkg/labels"
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// PluginName defines this plugin's registered name.
+	PluginName = "merge-after"
+)
+
+// mergeAfterCommand is the command adding or removing the merge-after label
+var mergeAfterCommand = plugins.Command{
+	Name: "merge-after",
+	Args: &plugins.CommandArgs{
+		Usage:   "<time>|cancel",
+		Pattern: `cancel|\d{4}-\d{2}-\d{2}\S*`,
+	},
+	Description: "Keeps the PR out of the merge pool until the time, given in UTC unless it has a zone, by adding a `" + labels.MergeAfterPrefix + "<time>` Label. The PR enters the pool once the time has passed.",
+	WhoCanUse:   "Anyone can use the /merge-after command to add or remove the '" + labels.MergeAfterPrefix + "<time>' Label.",
+	Examples:    []string{"/merge-after 2024-07-01T09:00Z", "/merge-after 2024-07-01", "/merge-after cancel"},
+}
+
+func init() {
+	command := mergeAfterCommand
+	command.Handler = handleCommand
+	// The Config field is omitted because this plugin is not configurable.
+	plugins.RegisterPlugin(PluginName, plugins.Plugin{
+		Description: "The merge-after plugin allows anyone to schedule the merge of a pull request with a '" + labels.MergeAfterPrefix + "<time>' Label, which keeps the PR out of the merge pool until the time, e.g. to merge it with a scheduled release train. The Label can also be added by hand.",
+		Commands:    []plugins.Command{command},
+	})
+}
+
+type scmProviderClient interface {
+	AddLabel(owner, repo string, number int, label string, pr bool) error
+	RemoveLabel(owner, repo string, number int, label string, pr bool) error
+	GetIssueLabels(org, repo string, number int, pr bool) ([]*scm.Label, error)
+	CreateComment(owner, repo string, number int, pr bool, comment string) error
+	QuoteAuthorForComment(string) string
+}
+
+func handleCommand(match plugins.CommandMatch, pc plugins.Agent, e scmprovider.GenericCommentEvent) error {
+	return handle(pc.SCMProviderClient, pc.Logger, &e, match.Args, time.Now())
+}
+
+// handle replaces the merge-after labels of the pull request with the label of the time, or removes them if the
+// command is cancelled. A time which is invalid or already passed is answered with a comment.
+func handle(spc scmProviderClient, log *logrus.Entry, e *scmprovider.GenericCommentEvent, args string, now time.Time) error {
+	org := e.Repo.Namespace
+	repo := e.Repo.Name
+	label := ""
+	if !strings.EqualFold(args, "cancel") {
+		t, err := labels.ParseMergeAfterTime(args)
+		if err == nil && !t.After(now) {
+			err = fmt.Errorf("%s has already passed", t.Format(time.RFC3339))
+		}
+		if err != nil {
+			msg := fmt.Sprintf("cannot schedule the merge: %v.", err)
+			return spc.CreateComment(org, repo, e.Number, e.IsPR, plugins.FormatResponseRaw(e.Body, e.Link, spc.QuoteAuthorForComment(e.Author.Login), msg))
+		}
+		label = labels.MergeAfter(t)
+	}
+
+	issueLabels, err := spc.GetIssueLabels(org, repo, e.Number, e.IsPR)
+	if err != nil {
+		return fmt.Errorf("failed to get the labels on %s/%s#%d: %v", org, repo, e.Number, err)
+	}
+	hasLabel := false
+	for _, l := range issueLabels {
+		switch {
+		case l.Name == label:
+			hasLabel = true
+		case labels.IsMergeAfter(l.Name):
+			log.Infof("Removing %q Label for %s/%s#%d", l.Name, org, repo, e.Number)
+			if err := spc.RemoveLabel(org, repo, e.Number, l.Name, e.IsPR); err != nil {
+				return err
+			}
+		}
+	}
+	if label != "" && !hasLabel {
+		log.Infof("Adding %q Label for %s/%s#%d", label, org, repo, e.Number)
+		return spc.AddLabel(org, repo, e.Number, label, e.IsPR)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package mergeafter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/go-scm/scm/driver/fake"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandle(t *testing.T) {
+	now := time.Date(2024, 6, 28, 12, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name     string
+		args     string
+		existing []string
+		added    []string
+		removed  []string
+		comment  string
+	}{
+		{
+			name:  "schedule",
+			args:  "2024-07-01T09:00Z",
+			added: []string{"org/repo#1:merge-after: 2024-07-01T09:00Z"},
+		},
+		{
+			name:  "schedule in another zone",
+			args:  "2024-07-01T11:30:00+02:00",
+			added: []string{"org/repo#1:merge-after: 2024-07-01T09:30Z"},
+		},
+		{
+			name:  "schedule a date",
+			args:  "2024-07-01",
+			added: []string{"org/repo#1:merge-after: 2024-07-01T00:00Z"},
+		},
+		{
+			name:     "reschedule",
+			args:     "2024-07-02t09:00z",
+			existing: []string{"org/repo#1:merge-after: 2024-07-01T09:00Z", "org/repo#1:lgtm"},
+			added:    []string{"org/repo#1:merge-after: 2024-07-02T09:00Z"},
+			removed:  []string{"org/repo#1:merge-after: 2024-07-01T09:00Z"},
+		},
+		{
+			name:     "already scheduled",
+			args:     "2024-07-01T09:00Z",
+			existing: []string{"org/repo#1:merge-after: 2024-07-01T09:00Z"},
+		},
+		{
+			name:     "cancel",
+			args:     "cancel",
+			existing: []string{"org/repo#1:merge-after: 2024-07-01T09:00Z", "org/repo#1:lgtm"},
+			removed:  []string{"org/repo#1:merge-after: 2024-07-01T09:00Z"},
+		},
+		{
+			name:    "passed",
+			args:    "2024-06-01T09:00Z",
+			comment: "2024-06-01T09:00:00Z has already passed",
+		},
+		{
+			name:    "invalid",
+			args:    "2024-13-01",
+			comment: "invalid time",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client, fc := fake.NewDefault()
+			fc.IssueLabelsExisting = tc.existing
+			e := &scmprovider.GenericCommentEvent{
+				Action: scm.ActionCreate,
+				Body:   "/merge-after " + tc.args,
+				Number: 1,
+				Repo:   scm.Repository{Namespace: "org", Name: "repo"},
+				Author: scm.User{Login: "bob"},
+			}
+
+			err := handle(scmprovider.ToTestClient(client), logrus.WithField("plugin", PluginName), e, tc.args, now)
+			require.NoError(t, err)
+
+			assert.ElementsMatch(t, tc.added, fc.IssueLabelsAdded)
+			assert.ElementsMatch(t, tc.removed, fc.IssueLabelsRemoved)
+			if tc.comment == "" {
+				assert.Empty(t, fc.IssueComments[1])
+			} else if assert.Len(t, fc.IssueComments[1], 1) {
+				assert.Contains(t, fc.IssueComments[1][0].Body, tc.comment)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package plugins
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// Module is a part of a monorepo made of directories, with its own presubmits, reviewers and labels. The trigger,
+// blunderbuss and owners-label plugins route the pull requests changing the module with it.
+type Module struct {
+	// Name of the module, unique among the modules of a repository.
+	Name string `json:"name"`
+	// Repos is either of the form org/repos or just org.
+	Repos []string `json:"repos,omitempty"`
+	// Paths are the directory prefixes of the module, e.g. services/api.
+	Paths []string `json:"paths"`
+	// Presubmits are the names of the presubmits run when the module changes, and only then.
+	Presubmits []string `json:"presubmits,omitempty"`
+	// Reviewers are the users whose reviews are requested when the module changes.
+	Reviewers []string `json:"reviewers,omitempty"`
+	// Labels are added to the pull requests changing the module.
+	Labels []string `json:"labels,omitempty"`
+}
+
+// Owns returns true if the path is in one of the directories of the module.
+func (m *Module) Owns(path string) bool {
+	for _, prefix := range m.Paths {
+		prefix = strings.Trim(prefix, "/")
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// ModulesFor returns the modules of the repository.
+func (c *Configuration) ModulesFor(org, repo string) []Module {
+	fullName := fmt.Sprintf("%s/%s", org, repo)
+	var answer []Module
+	for _, m := range c.Modules {
+		for _, r := range m.Repos {
+			if r == org || r == fullName {
+				answer = append(answer, m)
+				break
+			}
+		}
+	}
+	return answer
+}
+
+// ChangedModules returns the modules owning any of the changed paths.
+func ChangedModules(modules []Module, paths []string) []Module {
+	var answer []Module
+	for i := range modules {
+		for _, path := range paths {
+			if modules[i].Owns(path) {
+				answer = append(answer, modules[i])
+				break
+			}
+		}
+	}
+	return answer
+}
+
+// ModulePresubmits returns a copy of the presubmits in which those of modules run if and only if the files of their
+// modules change, in place of their always_run and run_if_changed.
+func ModulePresubmits(modules []Module, presubmits []config.Presubmit) ([]config.Presubmit, error) {
+	paths := map[string][]string{}
+	for _, m := range modules {
+		for _, name := range m.Presubmits {
+			paths[name] = append(paths[name], m.Paths...)
+		}
+	}
+	if len(paths) == 0 {
+		return presubmits, nil
+	}
+	answer := make([]config.Presubmit, len(presubmits))
+	copy(answer, presubmits)
+	var changed []config.Presubmit
+	var indexes []int
+	for i := range answer {
+		prefixes, ok := paths[answer[i].Name]
+		if !ok {
+			continue
+		}
+		ps := answer[i]
+		ps.AlwaysRun = false
+		ps.RunIfChanged = pathsRegexp(prefixes)
+		changed = append(changed, ps)
+		indexes = append(indexes, i)
+	}
+	if err := config.SetPresubmitRegexes(changed); err != nil {
+		return nil, err
+	}
+	for j, i := range indexes {
+		answer[i] = changed[j]
+	}
+	return answer, nil
+}
+
+// pathsRegexp returns the run_if_changed regexp matching the files of the directories
+func pathsRegexp(prefixes []string) string {
+	quoted := sets.NewString()
+	for _, prefix := range prefixes {
+		quoted.Insert(regexp.QuoteMeta(strings.Trim(prefix, "/")))
+	}
+	return fmt.Sprintf("^(?:%s)(?:/|$)", strings.Join(quoted.List(), "|"))
+}
+
+// ModuleReviewers returns the sorted reviewers of the modules.
+func ModuleReviewers(modules []Module) []string {
+	reviewers := sets.NewString()
+	for _, m := range modules {
+		reviewers.Insert(m.Reviewers...)
+	}
+	return reviewers.List()
+}
+
+// ModuleLabels returns the sorted labels of the modules.
+func ModuleLabels(modules []Module) []string {
+	labels := sets.NewString()
+	for _, m := range modules {
+		labels.Insert(m.Labels...)
+	}
+	return labels.List()
+}
+
+func validateModules(modules []Module) error {
+	names := map[string][]string{}
+	for i, m := range modules {
+		if m.Name == "" {
+			return fmt.Errorf("module #%d has no name", i)
+		}
+		if len(m.Repos) == 0 {
+			return fmt.Errorf("module %s has no repos", m.Name)
+		}
+		if len(m.Paths) == 0 {
+			return fmt.Errorf("module %s has no paths", m.Name)
+		}
+		for _, path := range m.Paths {
+			if strings.Trim(path, "/") == "" {
+				return fmt.Errorf("module %s has an empty path, which would own the whole repository", m.Name)
+			}
+		}
+		for _, r := range m.Repos {
+			names[r] = append(names[r], m.Name)
+		}
+	}
+	for r, n := range names {
+		sort.Strings(n)
+		for i := 1; i < len(n); i++ {
+			if n[i] == n[i-1] {
+				return fmt.Errorf("module %s is defined twice for %s", n[i], r)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package plugins
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+)
+
+func TestModules(t *testing.T) {
+	c := &Configuration{
+		Modules: []Module{
+			{Name: "api", Repos: []string{"org"}, Paths: []string{"services/api/"}, Presubmits: []string{"api-unit"}, Labels: []string{"area/api"}},
+			{Name: "web", Repos: []string{"org/repo"}, Paths: []string{"web", "/shared"}, Presubmits: []string{"web-unit", "lint"}},
+		},
+	}
+	if err := validateModules(c.Modules); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if modules := c.ModulesFor("org", "other"); len(modules) != 1 || modules[0].Name != "api" {
+		t.Errorf("expected the modules of the org, got %v", modules)
+	}
+	modules := c.ModulesFor("org", "repo")
+	if len(modules) != 2 {
+		t.Fatalf("expected the modules of the org and of the repo, got %v", modules)
+	}
+
+	changed := ChangedModules(modules, []string{"services/apis/main.go", "shared/util.go"})
+	if len(changed) != 1 || changed[0].Name != "web" {
+		t.Errorf("expected the web module to change, got %v", changed)
+	}
+
+	presubmits := []config.Presubmit{
+		{JobBase: config.JobBase{Name: "api-unit"}, AlwaysRun: true},
+		{JobBase: config.JobBase{Name: "lint"}, AlwaysRun: true},
+		{JobBase: config.JobBase{Name: "e2e"}, AlwaysRun: true},
+	}
+	if err := config.SetPresubmitRegexes(presubmits); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	routed, err := ModulePresubmits(modules, presubmits)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !presubmits[0].AlwaysRun {
+		t.Error("the presubmits should not be modified")
+	}
+	changes := func(paths ...string) config.ChangedFilesProvider {
+		return func() ([]string, error) { return paths, nil }
+	}
+	for _, tc := range []struct {
+		changes  config.ChangedFilesProvider
+		expected []string
+	}{
+		{changes: changes("README.md"), expected: []string{"e2e"}},
+		{changes: changes("services/api/main.go"), expected: []string{"api-unit", "e2e"}},
+		{changes: changes("shared/util.go", "webapp/index.html"), expected: []string{"lint", "e2e"}},
+	} {
+		var run []string
+		for _, ps := range routed {
+			if shouldRun, err := ps.ShouldRun("master", tc.changes, false, false); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			} else if shouldRun {
+				run = append(run, ps.Name)
+			}
+		}
+		if !reflect.DeepEqual(tc.expected, run) {
+			t.Errorf("expected %v to run, got %v", tc.expected, run)
+		}
+	}
+
+	for _, invalid := range [][]Module{
+		{{Repos: []string{"org"}, Paths: []string{"api"}}},
+		{{Name: "api", Repos: []string{"org"}}},
+		{{Name: "api", Repos: []string{"org"}, Paths: []string{"/"}}},
+		{{Name: "api", Repos: []string{"org"}, Paths: []string{"api"}}, {Name: "api", Repos: []string{"org"}, Paths: []string{"v2/api"}}},
+	} {
+		if err := validateModules(invalid); err == nil {
+			t.Errorf("expected an error for the modules %v", invalid)
+		}
+	}
+}
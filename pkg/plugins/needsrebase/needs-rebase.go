@@ -0,0 +1,161 @@
+// Package needsrebase implements a plugin labelling the pull requests which conflict with their base branch, so that
+// keeper and the reviewers can see at a glance which ones must be rebased.
+package needsrebase
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse/pkg/labels"
+	"github.com/jenkins-x/lighthouse/pkg/pluginhelp"
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// PluginName defines this plugin's registered name.
+	PluginName = "needs-rebase"
+
+	// needsRebaseMarker tags the comment of the plugin, so that each conflicting pull request is commented once and
+	// the comment is deleted once the conflicts are resolved
+	needsRebaseMarker = "<!-- lighthouse:needs-rebase -->"
+
+	needsRebaseMessage = "This pull request has merge conflicts with its base branch `%s` and needs to be rebased."
+)
+
+func init() {
+	plugins.RegisterPullRequestHandler(PluginName, handlePullRequest, helpProvider)
+	plugins.RegisterPushEventHandler(PluginName, handlePush, helpProvider)
+	plugins.RegisterScheduledHandler(PluginName, handleSchedule, helpProvider)
+}
+
+func helpProvider(config *plugins.Configuration, enabledRepos []string) (*pluginhelp.PluginHelp, error) {
+	// Only the Description field is specified because this plugin is not triggered with commands and is not configurable.
+	return &pluginhelp.PluginHelp{
+			Description: "The needs-rebase plugin applies the '" + labels.NeedsRebase + "' label to the pull requests which have merge conflicts with their base branch, and comments on them once explaining why. The label and the comment are removed once the conflicts are resolved. The pull requests are checked when they are opened or updated, when their base branch is pushed to, and every --schedule-interval of the hook.",
+		},
+		nil
+}
+
+type scmProviderClient interface {
+	GetPullRequest(owner, repo string, number int) (*scm.PullRequest, error)
+	ListAllPullRequestsForFullNameRepo(fullName string, opts scm.PullRequestListOptions) ([]*scm.PullRequest, error)
+	ListPullRequestComments(owner, repo string, number int) ([]*scm.Comment, error)
+	AddLabel(owner, repo string, number int, label string, pr bool) error
+	RemoveLabel(owner, repo string, number int, label string, pr bool) error
+	CreateComment(owner, repo string, number int, pr bool, comment string) error
+	DeleteStaleComments(org, repo string, number int, comments []*scm.Comment, pr bool, isStale func(*scm.Comment) bool) error
+	BotName() (string, error)
+}
+
+func handlePullRequest(pc plugins.Agent, pe scm.PullRequestHook) error {
+	// These are the only actions which may change the mergeability of the pull request.
+	if pe.Action != scm.ActionOpen &&
+		pe.Action != scm.ActionReopen &&
+		pe.Action != scm.ActionSync &&
+		pe.Action != scm.ActionEdited {
+		return nil
+	}
+	org, repo := pe.Repo.Namespace, pe.Repo.Name
+	// the mergeability is rarely known in the webhook, so the pull request is fetched again
+	pr, err := pc.SCMProviderClient.GetPullRequest(org, repo, pe.PullRequest.Number)
+	if err != nil {
+		return err
+	}
+	return check(pc.SCMProviderClient, pc.Logger, org, repo, pr)
+}
+
+func handlePush(pc plugins.Agent, pe scm.PushHook) error {
+	if pe.Deleted || !strings.HasPrefix(pe.Ref, "refs/heads/") {
+		return nil
+	}
+	branch := strings.TrimPrefix(pe.Ref, "refs/heads/")
+	return checkAll(pc.SCMProviderClient, pc.Logger, pe.Repo, func(pr *scm.PullRequest) bool {
+		return pr.Base.Ref == branch
+	})
+}
+
+func handleSchedule(pc plugins.Agent, repo scm.Repository) error {
+	return checkAll(pc.SCMProviderClient, pc.Logger, repo, func(*scm.PullRequest) bool { return true })
+}
+
+// checkAll checks the open pull requests of the repository accepted by the filter
+func checkAll(spc scmProviderClient, log *logrus.Entry, repo scm.Repository, filter func(*scm.PullRequest) bool) error {
+	fullName := repo.FullName
+	if fullName == "" {
+		fullName = scm.Join(repo.Namespace, repo.Name)
+	}
+	prs, err := spc.ListAllPullRequestsForFullNameRepo(fullName, scm.PullRequestListOptions{Open: true, Size: 100})
+	if err != nil {
+		return err
+	}
+	for _, pr := range prs {
+		if pr.Closed || pr.Merged || !filter(pr) {
+			continue
+		}
+		l := log.WithField("pr", pr.Number)
+		if pr.MergeableState == scm.MergeableStateUnknown {
+			// the providers do not return the mergeability when listing the pull requests
+			if pr, err = spc.GetPullRequest(repo.Namespace, repo.Name, pr.Number); err != nil {
+				l.WithError(err).Warn("failed to get the pull request")
+				continue
+			}
+		}
+		if err := check(spc, l, repo.Namespace, repo.Name, pr); err != nil {
+			l.WithError(err).Warn("failed to check if the pull request needs a rebase")
+		}
+	}
+	return nil
+}
+
+// check labels and comments on the pull request if it conflicts with its base branch, and removes the label and
+// the comment otherwise. Nothing is done while its mergeability is unknown, as the providers compute it lazily.
+func check(spc scmProviderClient, log *logrus.Entry, org, repo string, pr *scm.PullRequest) error {
+	if pr.Closed || pr.Merged || pr.MergeableState == scm.MergeableStateUnknown {
+		return nil
+	}
+	conflicting := pr.MergeableState == scm.MergeableStateConflicting
+	hasLabel := false
+	for _, label := range pr.Labels {
+		if label.Name == labels.NeedsRebase {
+			hasLabel = true
+			break
+		}
+	}
+	botName, err := spc.BotName()
+	if err != nil {
+		return err
+	}
+	comments, err := spc.ListPullRequestComments(org, repo, pr.Number)
+	if err != nil {
+		return err
+	}
+	isOwnComment := func(comment *scm.Comment) bool {
+		return comment.Author.Login == botName && strings.Contains(comment.Body, needsRebaseMarker)
+	}
+
+	if !conflicting {
+		if hasLabel {
+			log.Info("Removing the needs-rebase label.")
+			if err := spc.RemoveLabel(org, repo, pr.Number, labels.NeedsRebase, true); err != nil {
+				return err
+			}
+		}
+		return spc.DeleteStaleComments(org, repo, pr.Number, comments, true, isOwnComment)
+	}
+
+	if !hasLabel {
+		log.Info("Adding the needs-rebase label.")
+		if err := spc.AddLabel(org, repo, pr.Number, labels.NeedsRebase, true); err != nil {
+			return err
+		}
+	}
+	for _, comment := range comments {
+		if isOwnComment(comment) {
+			return nil
+		}
+	}
+	message := fmt.Sprintf(needsRebaseMessage, pr.Base.Ref)
+	return spc.CreateComment(org, repo, pr.Number, true, plugins.FormatSimpleResponse(pr.Author.Login, message)+"\n"+needsRebaseMarker)
+}
@@ -0,0 +1,117 @@
+package needsrebase
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse/pkg/labels"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSCMClient struct {
+	prs      map[int]*scm.PullRequest
+	comments map[int][]*scm.Comment
+	added    []string
+	removed  []string
+	nextID   int
+}
+
+func (f *fakeSCMClient) GetPullRequest(owner, repo string, number int) (*scm.PullRequest, error) {
+	return f.prs[number], nil
+}
+
+func (f *fakeSCMClient) ListAllPullRequestsForFullNameRepo(fullName string, opts scm.PullRequestListOptions) ([]*scm.PullRequest, error) {
+	var answer []*scm.PullRequest
+	for _, pr := range f.prs {
+		// the mergeability is not returned when listing the pull requests
+		listed := *pr
+		listed.MergeableState = scm.MergeableStateUnknown
+		answer = append(answer, &listed)
+	}
+	return answer, nil
+}
+
+func (f *fakeSCMClient) ListPullRequestComments(owner, repo string, number int) ([]*scm.Comment, error) {
+	return append([]*scm.Comment{}, f.comments[number]...), nil
+}
+
+func (f *fakeSCMClient) AddLabel(owner, repo string, number int, label string, pr bool) error {
+	f.added = append(f.added, label)
+	f.prs[number].Labels = append(f.prs[number].Labels, &scm.Label{Name: label})
+	return nil
+}
+
+func (f *fakeSCMClient) RemoveLabel(owner, repo string, number int, label string, pr bool) error {
+	f.removed = append(f.removed, label)
+	f.prs[number].Labels = nil
+	return nil
+}
+
+func (f *fakeSCMClient) CreateComment(owner, repo string, number int, pr bool, comment string) error {
+	f.nextID++
+	f.comments[number] = append(f.comments[number], &scm.Comment{ID: f.nextID, Body: comment, Author: scm.User{Login: "bot"}})
+	return nil
+}
+
+func (f *fakeSCMClient) DeleteStaleComments(org, repo string, number int, comments []*scm.Comment, pr bool, isStale func(*scm.Comment) bool) error {
+	var kept []*scm.Comment
+	for _, comment := range f.comments[number] {
+		if !isStale(comment) {
+			kept = append(kept, comment)
+		}
+	}
+	f.comments[number] = kept
+	return nil
+}
+
+func (f *fakeSCMClient) BotName() (string, error) {
+	return "bot", nil
+}
+
+func TestCheck(t *testing.T) {
+	pr := &scm.PullRequest{
+		Number:         1,
+		Base:           scm.PullRequestBranch{Ref: "master"},
+		Author:         scm.User{Login: "author"},
+		MergeableState: scm.MergeableStateConflicting,
+	}
+	other := &scm.PullRequest{
+		Number:         2,
+		Base:           scm.PullRequestBranch{Ref: "release"},
+		MergeableState: scm.MergeableStateConflicting,
+	}
+	spc := &fakeSCMClient{
+		prs:      map[int]*scm.PullRequest{1: pr, 2: other},
+		comments: map[int][]*scm.Comment{1: {{ID: 100, Body: "/lgtm", Author: scm.User{Login: "reviewer"}}}},
+		nextID:   100,
+	}
+	log := logrus.WithField("plugin", PluginName)
+	repo := scm.Repository{Namespace: "org", Name: "repo"}
+	onMaster := func(pr *scm.PullRequest) bool { return pr.Base.Ref == "master" }
+
+	require.NoError(t, checkAll(spc, log, repo, onMaster))
+	assert.Equal(t, []string{labels.NeedsRebase}, spc.added)
+	require.Len(t, spc.comments[1], 2)
+	assert.True(t, strings.Contains(spc.comments[1][1].Body, "@author"))
+	assert.True(t, strings.Contains(spc.comments[1][1].Body, "merge conflicts with its base branch `master`"))
+	assert.Empty(t, spc.comments[2], "the pull requests of other branches should not be checked")
+
+	// the pull request is labelled and commented once
+	require.NoError(t, checkAll(spc, log, repo, onMaster))
+	assert.Equal(t, []string{labels.NeedsRebase}, spc.added)
+	assert.Len(t, spc.comments[1], 2)
+
+	// nothing is done while the mergeability is computed
+	pr.MergeableState = scm.MergeableStateUnknown
+	require.NoError(t, check(spc, log, "org", "repo", pr))
+	assert.Empty(t, spc.removed)
+
+	pr.MergeableState = scm.MergeableStateMergeable
+	require.NoError(t, checkAll(spc, log, repo, onMaster))
+	assert.Equal(t, []string{labels.NeedsRebase}, spc.removed)
+	require.Len(t, spc.comments[1], 1)
+	assert.Equal(t, "/lgtm", spc.comments[1][0].Body)
+}
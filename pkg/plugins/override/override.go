@@ -42,7 +42,7 @@ import (
 const pluginName = "override"
 
 var (
-	overrideRe = regexp.MustCompile(`(?mi)^/(?:lh-)?override( (.+?)\s*)?$`)
+	overrideRe = regexp.MustCompile(`(?mi)^/` + util.CommandPrefixPattern + `override( (.+?)\s*)?$`)
 )
 
 type scmProviderClient interface {
@@ -284,6 +284,7 @@ Only the following contexts were expected:
 				StartTime:      now,
 				CompletionTime: &now,
 			}
+			pj.SetStateConditions()
 			log.WithFields(jobutil.LighthouseJobFields(&pj)).Info("Creating a new override LighthouseJob.")
 			if _, err := oc.createOverrideJob(&pj); err != nil {
 				resp := fmt.Sprintf("Failed to create override job for %s", status.Label)
@@ -24,6 +24,7 @@ import (
 
 	"github.com/jenkins-x/lighthouse/pkg/pluginhelp"
 	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
 	"k8s.io/apimachinery/pkg/util/sets"
 )
 
@@ -38,7 +39,7 @@ func init() {
 
 func helpProvider(config *plugins.Configuration, enabledRepos []string) (*pluginhelp.PluginHelp, error) {
 	return &pluginhelp.PluginHelp{
-			Description: "The owners-label plugin automatically adds labels to PRs based on the files they touch. Specifically, the 'labels' sections of OWNERS files are used to determine which labels apply to the changes.",
+			Description: "The owners-label plugin automatically adds labels to PRs based on the files they touch. Specifically, the 'labels' sections of OWNERS files are used to determine which labels apply to the changes, along with the labels of the modules they change.",
 		},
 		nil
 }
@@ -64,23 +65,27 @@ func handlePullRequest(pc plugins.Agent, pre scm.PullRequestHook) error {
 		return fmt.Errorf("error loading RepoOwners: %v", err)
 	}
 
-	return handle(pc.SCMProviderClient, oc, pc.Logger, &pre)
+	return handle(pc.SCMProviderClient, oc, pc.PluginConfig.ModulesFor(pre.Repo.Namespace, pre.Repo.Name), pc.Logger, &pre)
 }
 
-func handle(spc scmProviderClient, oc ownersClient, log *logrus.Entry, pre *scm.PullRequestHook) error {
+func handle(spc scmProviderClient, oc ownersClient, modules []plugins.Module, log *logrus.Entry, pre *scm.PullRequestHook) error {
 	org := pre.Repo.Namespace
 	repo := pre.Repo.Name
 	number := pre.PullRequest.Number
 
 	// First see if there are any labels requested based on the files changed.
+	// the labels of the files which are listed are added if the PR changes too many files
 	changes, err := spc.GetPullRequestChanges(org, repo, number)
-	if err != nil {
+	if err != nil && !scmprovider.IsTooManyChanges(err) {
 		return fmt.Errorf("error getting PR changes: %v", err)
 	}
 	neededLabels := sets.NewString()
+	var paths []string
 	for _, change := range changes {
 		neededLabels.Insert(oc.FindLabelsForFile(change.Path).List()...)
+		paths = append(paths, change.Path)
 	}
+	neededLabels.Insert(plugins.ModuleLabels(plugins.ChangedModules(modules, paths))...)
 	if neededLabels.Len() == 0 {
 		// No labels requested for the given files. Return now to save API tokens.
 		return nil
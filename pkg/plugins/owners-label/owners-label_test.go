@@ -28,6 +28,7 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/jenkins-x/lighthouse/pkg/labels"
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
 	"k8s.io/apimachinery/pkg/util/sets"
 )
 
@@ -62,6 +63,8 @@ func TestHandle(t *testing.T) {
 		},
 	}
 
+	modules := []plugins.Module{{Name: "docs", Paths: []string{"docs/"}, Labels: []string{"area/docs"}}}
+
 	type testCase struct {
 		name              string
 		filesChanged      []string
@@ -147,6 +150,20 @@ func TestHandle(t *testing.T) {
 			repoLabels:        []string{"dnm/bash", labels.Approved, labels.LGTM, "dnm/frozen-docs"},
 			prLabels:          []string{"dnm/bash", labels.Approved},
 		},
+		{
+			name:              "module label",
+			filesChanged:      []string{"docs/index.md", "b.go"},
+			expectedNewLabels: formatLabels(labels.LGTM, "area/docs"),
+			repoLabels:        []string{labels.LGTM, "area/docs"},
+			prLabels:          []string{},
+		},
+		{
+			name:              "file named like a module",
+			filesChanged:      []string{"docs.go"},
+			expectedNewLabels: []string{},
+			repoLabels:        []string{"area/docs"},
+			prLabels:          []string{},
+		},
 		{
 			name:              "2 files complete overlap, label already present",
 			filesChanged:      []string{"d.sh", "e.sh"},
@@ -193,7 +210,7 @@ func TestHandle(t *testing.T) {
 			Repo:        basicPR.Base.Repo,
 		}
 
-		err := handle(fakeClient, foc, logrus.WithField("plugin", PluginName), pre)
+		err := handle(fakeClient, foc, modules, logrus.WithField("plugin", PluginName), pre)
 		if err != nil {
 			t.Errorf("[%s] unexpected error from handle: %v", tc.name, err)
 			continue
@@ -18,7 +18,6 @@ package plugins
 
 import (
 	"errors"
-	"fmt"
 	"io/ioutil"
 	"net/url"
 	"os"
@@ -34,12 +33,13 @@ import (
 	"github.com/jenkins-x/lighthouse/pkg/commentpruner"
 	git2 "github.com/jenkins-x/lighthouse/pkg/git"
 	"github.com/jenkins-x/lighthouse/pkg/launcher"
+	"github.com/jenkins-x/lighthouse/pkg/payload"
 	"github.com/jenkins-x/lighthouse/pkg/pluginhelp"
 	"github.com/jenkins-x/lighthouse/pkg/repoowners"
 	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	"github.com/jenkins-x/lighthouse/pkg/store"
 	"github.com/sirupsen/logrus"
 	"k8s.io/client-go/kubernetes"
-	"sigs.k8s.io/yaml"
 )
 
 var (
@@ -52,6 +52,8 @@ var (
 	reviewEventHandlers        = map[string]ReviewEventHandler{}
 	reviewCommentEventHandlers = map[string]ReviewCommentEventHandler{}
 	statusEventHandlers        = map[string]StatusEventHandler{}
+	checkRerequestHandlers     = map[string]CheckRerequestHandler{}
+	scheduledHandlers          = map[string]ScheduledHandler{}
 )
 
 // HelpProvider defines the function type that construct a pluginhelp.PluginHelp for enabled
@@ -90,15 +92,34 @@ func RegisterPullRequestHandler(name string, fn PullRequestHandler, help HelpPro
 	pullRequestHandlers[name] = fn
 }
 
-// StatusEventHandler defines the function contract for a scm.Status handler.
-type StatusEventHandler func(Agent, scm.Status) error
+// StatusEventHandler defines the function contract for a payload.StatusHook handler.
+type StatusEventHandler func(Agent, payload.StatusHook) error
 
-// RegisterStatusEventHandler registers a plugin's scm.Status handler.
+// RegisterStatusEventHandler registers a plugin's payload.StatusHook handler.
 func RegisterStatusEventHandler(name string, fn StatusEventHandler, help HelpProvider) {
 	pluginHelp[name] = help
 	statusEventHandlers[name] = fn
 }
 
+// CheckRerequestHandler defines the function contract for a payload.CheckRerequestHook handler.
+type CheckRerequestHandler func(Agent, payload.CheckRerequestHook) error
+
+// RegisterCheckRerequestHandler registers a plugin's payload.CheckRerequestHook handler.
+func RegisterCheckRerequestHandler(name string, fn CheckRerequestHandler, help HelpProvider) {
+	pluginHelp[name] = help
+	checkRerequestHandlers[name] = fn
+}
+
+// ScheduledHandler defines the function contract for a handler run periodically on each repository the plugin
+// is enabled for, rather than in reaction to an event.
+type ScheduledHandler func(Agent, scm.Repository) error
+
+// RegisterScheduledHandler registers a plugin's ScheduledHandler.
+func RegisterScheduledHandler(name string, fn ScheduledHandler, help HelpProvider) {
+	pluginHelp[name] = help
+	scheduledHandlers[name] = fn
+}
+
 // PushEventHandler defines the function contract for a scm.PushHook handler.
 type PushEventHandler func(Agent, scm.PushHook) error
 
@@ -145,6 +166,8 @@ type Agent struct {
 	KubernetesClient   kubernetes.Interface
 	LighthouseClient   lighthouseclient.LighthouseJobInterface
 	ServerURL          *url.URL
+	// Store keeps the state shared by the replicas of the hook
+	Store store.Store
 	/*
 		SlackClient      *slack.Client
 	*/
@@ -168,6 +191,9 @@ func NewAgent(clientFactory jxfactory.Factory, configAgent *config.Agent, plugin
 	prowConfig := configAgent.Config()
 	pluginConfig := pluginConfigAgent.Config()
 	scmClient := scmprovider.ToClient(clientAgent.SCMProviderClient, clientAgent.BotName)
+	if clientAgent.ChangesCache != nil {
+		scmClient.WithChangesCache(clientAgent.ChangesCache)
+	}
 	return Agent{
 		ClientFactory:      clientFactory,
 		SCMProviderClient:  scmClient,
@@ -176,21 +202,31 @@ func NewAgent(clientFactory jxfactory.Factory, configAgent *config.Agent, plugin
 		MetapipelineClient: metapipelineClient,
 		LighthouseClient:   clientAgent.LighthouseClient,
 		ServerURL:          serverURL,
+		Store:              clientAgent.Store,
 
 		/*
 			SlackClient:   clientAgent.SlackClient,
 		*/
-		OwnersClient: repoowners.NewClient(
-			clientAgent.GitClient, scmClient,
-			prowConfig, pluginConfig.MDYAMLEnabled,
-			pluginConfig.SkipCollaborators,
-		),
+		OwnersClient: ownersClient(clientAgent, scmClient, prowConfig, pluginConfig),
 		Config:       prowConfig,
 		PluginConfig: pluginConfig,
 		Logger:       logger,
 	}
 }
 
+// ownersClient returns the client of the OWNERS files sharing the cache of the client agent, if any
+func ownersClient(clientAgent *ClientAgent, scmClient *scmprovider.Client, prowConfig *config.Config, pluginConfig *Configuration) *repoowners.Client {
+	client := repoowners.NewClient(
+		clientAgent.GitClient, scmClient,
+		prowConfig, pluginConfig.MDYAMLEnabled,
+		pluginConfig.SkipCollaborators,
+	).WithOrgAliases(pluginConfig.OrgAliasesFor)
+	if clientAgent.OwnersCache != nil {
+		client.WithCache(clientAgent.OwnersCache)
+	}
+	return client
+}
+
 // InitializeCommentPruner attaches a commentpruner.EventClient to the agent to handle
 // pruning comments.
 func (a *Agent) InitializeCommentPruner(org, repo string, pr int) {
@@ -213,12 +249,17 @@ func (a *Agent) CommentPruner() (*commentpruner.EventClient, error) {
 type ClientAgent struct {
 	BotName           string
 	SCMProviderClient *scm.Client
+	// ChangesCache shares the changed files of the pull requests between the plugins handling the event
+	ChangesCache *scmprovider.ChangesCache
+	// OwnersCache shares the OWNERS files loaded by the plugins across the events
+	OwnersCache *repoowners.Cache
 
 	KubernetesClient   kubernetes.Interface
 	GitClient          git2.Client
 	LauncherClient     launcher.PipelineLauncher
 	MetapipelineClient metapipeline.Client
 	LighthouseClient   lighthouseclient.LighthouseJobInterface
+	Store              store.Store
 
 	/*	SlackClient      *slack.Client
 	 */
@@ -238,7 +279,7 @@ func (pa *ConfigAgent) Load(path string) error {
 		return err
 	}
 	np := &Configuration{}
-	if err := yaml.Unmarshal(b, np); err != nil {
+	if err := unmarshalStrict(b, np); err != nil {
 		return err
 	}
 	if err := np.Validate(); err != nil {
@@ -252,7 +293,7 @@ func (pa *ConfigAgent) Load(path string) error {
 // LoadYAMLConfig loads the configuration from the given data
 func (pa *ConfigAgent) LoadYAMLConfig(data []byte) (*Configuration, error) {
 	c := &Configuration{}
-	if err := yaml.Unmarshal(data, c); err != nil {
+	if err := unmarshalStrict(data, c); err != nil {
 		return c, err
 	}
 	if err := c.Validate(); err != nil {
@@ -398,6 +439,21 @@ func (pa *ConfigAgent) StatusEventHandlers(owner, repo string) map[string]Status
 	return hs
 }
 
+// CheckRerequestHandlers returns a map of plugin names to handlers for the repo.
+func (pa *ConfigAgent) CheckRerequestHandlers(owner, repo string) map[string]CheckRerequestHandler {
+	pa.mut.Lock()
+	defer pa.mut.Unlock()
+
+	hs := map[string]CheckRerequestHandler{}
+	for _, p := range pa.getPlugins(owner, repo) {
+		if h, ok := checkRerequestHandlers[p]; ok {
+			hs[p] = h
+		}
+	}
+
+	return hs
+}
+
 // PushEventHandlers returns a map of plugin names to handlers for the repo.
 func (pa *ConfigAgent) PushEventHandlers(owner, repo string) map[string]PushEventHandler {
 	pa.mut.Lock()
@@ -413,22 +469,24 @@ func (pa *ConfigAgent) PushEventHandlers(owner, repo string) map[string]PushEven
 	return hs
 }
 
+// ScheduledHandlers returns a map of plugin names to scheduled handlers for the repo.
+func (pa *ConfigAgent) ScheduledHandlers(owner, repo string) map[string]ScheduledHandler {
+	pa.mut.Lock()
+	defer pa.mut.Unlock()
+
+	hs := map[string]ScheduledHandler{}
+	for _, p := range pa.getPlugins(owner, repo) {
+		if h, ok := scheduledHandlers[p]; ok {
+			hs[p] = h
+		}
+	}
+
+	return hs
+}
+
 // getPlugins returns a list of plugins that are enabled on a given (org, repository).
 func (pa *ConfigAgent) getPlugins(owner, repo string) []string {
-	var plugins []string
-
-	// on bitbucket server the owner can be the ProjectKey which is upper case - so lets also check for the case
-	// of a lower case project key matching projects
-	owners := []string{owner}
-	lowerOwner := strings.ToLower(owner)
-	if lowerOwner != owner {
-		owners = append(owners, lowerOwner)
-	}
-	for _, o := range owners {
-		fullName := fmt.Sprintf("%s/%s", o, repo)
-		plugins = append(plugins, pa.configuration.Plugins[o]...)
-		plugins = append(plugins, pa.configuration.Plugins[fullName]...)
-	}
+	plugins := pa.configuration.PluginsFor(owner, repo)
 
 	// until we have the configuration stuff setup nicely - lets add a simple way to enable plugins
 	pluginNames := os.Getenv("LIGHTHOUSE_PLUGINS")
@@ -478,6 +536,12 @@ func EventsForPlugin(name string) []string {
 	if _, ok := statusEventHandlers[name]; ok {
 		events = append(events, "status")
 	}
+	if _, ok := checkRerequestHandlers[name]; ok {
+		events = append(events, "check_run", "check_suite")
+	}
+	if _, ok := scheduledHandlers[name]; ok {
+		events = append(events, "schedule")
+	}
 	if _, ok := genericCommentHandlers[name]; ok {
 		events = append(events, "GenericCommentEvent (any event for user text)")
 	}
@@ -155,6 +155,27 @@ func TestGetPlugins(t *testing.T) {
 			repo:            "repo",
 			expectedPlugins: []string{"plugin3"},
 		},
+		{
+			name: "Plugins enabled for every repo are inherited unless disabled",
+			pluginMap: map[string][]string{
+				"*":         {"plugin1", "plugin2"},
+				"org1":      {"plugin3", "-plugin1"},
+				"org1/repo": {"-plugin3", "plugin4"},
+			},
+			owner:           "org1",
+			repo:            "repo",
+			expectedPlugins: []string{"plugin2", "plugin4"},
+		},
+		{
+			name: "Plugins enabled for every repo are returned for other orgs",
+			pluginMap: map[string][]string{
+				"*":    {"plugin1", "plugin2"},
+				"org1": {"-plugin1"},
+			},
+			owner:           "org2",
+			repo:            "repo",
+			expectedPlugins: []string{"plugin1", "plugin2"},
+		},
 	}
 	for _, tc := range testcases {
 		pa := ConfigAgent{configuration: &Configuration{Plugins: tc.pluginMap}}
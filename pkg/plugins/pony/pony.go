@@ -29,6 +29,7 @@ import (
 	"github.com/jenkins-x/lighthouse/pkg/pluginhelp"
 	"github.com/jenkins-x/lighthouse/pkg/plugins"
 	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	"github.com/jenkins-x/lighthouse/pkg/util"
 	"github.com/sirupsen/logrus"
 )
 
@@ -52,7 +53,7 @@ const (
 )
 
 var (
-	match = regexp.MustCompile(`(?mi)^/(?:lh-)?(?:pony)(?: +(.+?))?\s*$`)
+	match = regexp.MustCompile(`(?mi)^/` + util.CommandPrefixPattern + `(?:pony)(?: +(.+?))?\s*$`)
 )
 
 func init() {
@@ -0,0 +1,275 @@
+// Package preview deploys a preview environment of each pull request with a configured job, comments its URL
+// on the pull request and tears it down with a cleanup job once the pull request is closed or merged.
+package preview
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/jx/v2/pkg/tekton/metapipeline"
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/commentpruner"
+	"github.com/jenkins-x/lighthouse/pkg/jobutil"
+	"github.com/jenkins-x/lighthouse/pkg/pluginhelp"
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/jenkins-x/lighthouse/pkg/plugins/trigger"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	pluginName = "preview"
+
+	// ActionLabel is added to the jobs launched by the plugin with the action they perform
+	ActionLabel = "lighthouse.jenkins-x.io/preview"
+	// URLAnnotation holds the URL of the preview environment deployed by a job
+	URLAnnotation = "lighthouse.jenkins-x.io/previewURL"
+
+	// DeployAction is the action of the jobs deploying a preview environment
+	DeployAction = "deploy"
+	// CleanupAction is the action of the jobs tearing a preview environment down
+	CleanupAction = "cleanup"
+)
+
+var (
+	previewRe = regexp.MustCompile(`(?mi)^/` + util.CommandPrefixPattern + `preview\s*$`)
+
+	// CommentTag marks the comment holding the state of the preview environment of a pull request
+	CommentTag = commentpruner.Tag(pluginName)
+)
+
+func init() {
+	plugins.RegisterGenericCommentHandler(pluginName, handleGenericComment, helpProvider)
+	plugins.RegisterPullRequestHandler(pluginName, handlePullRequest, helpProvider)
+}
+
+func helpProvider(config *plugins.Configuration, enabledRepos []string) (*pluginhelp.PluginHelp, error) {
+	configInfo := map[string]string{}
+	for _, repo := range enabledRepos {
+		org, name := repo, ""
+		if parts := strings.SplitN(repo, "/", 2); len(parts) == 2 {
+			org, name = parts[0], parts[1]
+		}
+		p := config.PreviewFor(org, name)
+		if p == nil {
+			configInfo[repo] = "No preview job is configured."
+			continue
+		}
+		info := fmt.Sprintf("Previews are deployed by the %s job", p.Job)
+		if p.Auto {
+			info += " when a pull request is opened or updated"
+		}
+		if p.CleanupJob != "" {
+			info += fmt.Sprintf(" and deleted by the %s job once the pull request is closed", p.CleanupJob)
+		}
+		configInfo[repo] = info + "."
+	}
+	pluginHelp := &pluginhelp.PluginHelp{
+		Description: "The preview plugin deploys a preview environment of a pull request with a configured job, comments its URL on the pull request and deletes it with a cleanup job once the pull request is closed or merged.",
+		Config:      configInfo,
+	}
+	pluginHelp.AddCommand(pluginhelp.Command{
+		Usage:       "/preview",
+		Description: "Deploys the preview environment of the pull request.",
+		Featured:    true,
+		WhoCanUse:   "Anyone trusted by the trigger plugin.",
+		Examples:    []string{"/preview", "/lh-preview"},
+	})
+	return pluginHelp, nil
+}
+
+type scmProviderClient interface {
+	BotName() (string, error)
+	IsCollaborator(org, repo, user string) (bool, error)
+	IsMember(org, user string) (bool, error)
+	ListTeams(org string) ([]*scm.Team, error)
+	ListTeamMembers(id int, role string) ([]*scm.TeamMember, error)
+	GetPullRequest(org, repo string, number int) (*scm.PullRequest, error)
+	GetRef(org, repo, ref string) (string, error)
+	CreateComment(org, repo string, number int, pr bool, comment string) error
+	EditComment(org, repo string, number, id int, comment string, pr bool) error
+	DeleteComment(org, repo string, number, id int, pr bool) error
+	ListIssueComments(org, repo string, number int) ([]*scm.Comment, error)
+	ListPullRequestComments(org, repo string, number int) ([]*scm.Comment, error)
+	QuoteAuthorForComment(string) string
+	Capabilities() scmprovider.Capabilities
+}
+
+type launcher interface {
+	Launch(*v1alpha1.LighthouseJob, metapipeline.Client, scm.Repository) (*v1alpha1.LighthouseJob, error)
+}
+
+type client struct {
+	spc                scmProviderClient
+	launcher           launcher
+	metapipelineClient metapipeline.Client
+	presubmits         []config.Presubmit
+	preview            *plugins.Preview
+	trigger            *plugins.Trigger
+	logger             *logrus.Entry
+}
+
+func newClient(pc plugins.Agent, repo scm.Repository) *client {
+	return &client{
+		spc:                pc.SCMProviderClient,
+		launcher:           pc.LauncherClient,
+		metapipelineClient: pc.MetapipelineClient,
+		presubmits:         pc.Config.GetPresubmits(repo),
+		preview:            pc.PluginConfig.PreviewFor(repo.Namespace, repo.Name),
+		trigger:            pc.PluginConfig.TriggerFor(repo.Namespace, repo.Name),
+		logger:             pc.Logger,
+	}
+}
+
+func handleGenericComment(pc plugins.Agent, e scmprovider.GenericCommentEvent) error {
+	return handleComment(newClient(pc, e.Repo), &e)
+}
+
+func handlePullRequest(pc plugins.Agent, pe scm.PullRequestHook) error {
+	return handlePR(newClient(pc, pe.Repo), &pe)
+}
+
+func handleComment(c *client, e *scmprovider.GenericCommentEvent) error {
+	if !e.IsPR || e.IssueState != "open" || e.Action != scm.ActionCreate || !previewRe.MatchString(e.Body) {
+		return nil
+	}
+	org := e.Repo.Namespace
+	repo := e.Repo.Name
+	respond := func(message string) error {
+		return c.spc.CreateComment(org, repo, e.Number, true, plugins.FormatResponseRaw(e.Body, e.Link, c.spc.QuoteAuthorForComment(e.Author.Login), message))
+	}
+	if c.preview == nil {
+		return respond("No preview environment is configured for this repository.")
+	}
+	trusted, err := trigger.TrustedUser(c.spc, c.trigger, e.Author.Login, org, repo)
+	if err != nil {
+		return errors.Wrapf(err, "checking whether %s is trusted", e.Author.Login)
+	}
+	if !trusted {
+		return respond("Only trusted users can deploy preview environments.")
+	}
+	pr, err := c.spc.GetPullRequest(org, repo, e.Number)
+	if err != nil {
+		return errors.Wrapf(err, "getting pull request %s/%s#%d", org, repo, e.Number)
+	}
+	if err := c.deploy(pr, e.GUID); err != nil {
+		return respond(fmt.Sprintf("Failed to deploy the preview environment: %v", err))
+	}
+	return nil
+}
+
+func handlePR(c *client, pe *scm.PullRequestHook) error {
+	if c.preview == nil {
+		return nil
+	}
+	pr := &pe.PullRequest
+	switch pe.Action {
+	case scm.ActionOpen, scm.ActionReopen, scm.ActionSync:
+		if !c.preview.Auto {
+			return nil
+		}
+		trusted, err := trigger.TrustedUser(c.spc, c.trigger, pr.Author.Login, pr.Base.Repo.Namespace, pr.Base.Repo.Name)
+		if err != nil {
+			return errors.Wrapf(err, "checking whether %s is trusted", pr.Author.Login)
+		}
+		if !trusted {
+			c.logger.Debugf("Not deploying the preview of a pull request from untrusted user %s.", pr.Author.Login)
+			return nil
+		}
+		return c.deploy(pr, pe.GUID)
+	case scm.ActionClose:
+		return c.cleanup(pr, pe.GUID)
+	}
+	return nil
+}
+
+// deploy launches the job deploying the preview environment of the pull request and comments its URL
+func (c *client) deploy(pr *scm.PullRequest, eventGUID string) error {
+	url, err := c.url(pr)
+	if err != nil {
+		return err
+	}
+	if _, err := c.launch(pr, c.preview.Job, DeployAction, url, eventGUID); err != nil {
+		return err
+	}
+	message := fmt.Sprintf("Deploying the preview environment of %s with the `%s` job.", pr.Head.Sha, c.preview.Job)
+	if url != "" {
+		message += fmt.Sprintf(" It will be available at %s once the job succeeds.", url)
+	}
+	return c.comments(pr).UpsertComment(true, CommentTag, message)
+}
+
+// cleanup launches the job deleting the preview environment of the pull request, if one was deployed
+func (c *client) cleanup(pr *scm.PullRequest, eventGUID string) error {
+	if c.preview.CleanupJob == "" {
+		return nil
+	}
+	comments := c.comments(pr)
+	if comments.TaggedComment(true, CommentTag) == nil {
+		return nil
+	}
+	if _, err := c.launch(pr, c.preview.CleanupJob, CleanupAction, "", eventGUID); err != nil {
+		return err
+	}
+	return comments.UpsertComment(true, CommentTag, fmt.Sprintf("Deleting the preview environment with the `%s` job.", c.preview.CleanupJob))
+}
+
+func (c *client) comments(pr *scm.PullRequest) *commentpruner.EventClient {
+	return commentpruner.NewEventClient(c.spc, c.logger, pr.Base.Repo.Namespace, pr.Base.Repo.Name, pr.Number)
+}
+
+// launch creates a job of the pull request from the named presubmit
+func (c *client) launch(pr *scm.PullRequest, jobName, action, url, eventGUID string) (*v1alpha1.LighthouseJob, error) {
+	var presubmit *config.Presubmit
+	for i := range c.presubmits {
+		if c.presubmits[i].Name == jobName {
+			presubmit = &c.presubmits[i]
+			break
+		}
+	}
+	if presubmit == nil {
+		return nil, errors.Errorf("there is no presubmit named %s", jobName)
+	}
+	baseSHA, err := c.spc.GetRef(pr.Base.Repo.Namespace, pr.Base.Repo.Name, "heads/"+pr.Base.Ref)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting the head of %s", pr.Base.Ref)
+	}
+	pj := jobutil.NewPresubmit(pr, baseSHA, *presubmit, eventGUID)
+	pj.Labels[ActionLabel] = action
+	if url != "" {
+		pj.Annotations[URLAnnotation] = url
+	}
+	c.logger.WithFields(jobutil.LighthouseJobFields(&pj)).Infof("Creating a LighthouseJob to %s the preview environment.", action)
+	return c.launcher.Launch(&pj, c.metapipelineClient, pr.Repository())
+}
+
+// url renders the URL of the preview environment of the pull request
+func (c *client) url(pr *scm.PullRequest) (string, error) {
+	if c.preview.URL == "" {
+		return "", nil
+	}
+	t, err := template.New("url").Parse(c.preview.URL)
+	if err != nil {
+		return "", errors.Wrap(err, "parsing the preview URL template")
+	}
+	var buf bytes.Buffer
+	err = t.Execute(&buf, map[string]interface{}{
+		"Org":    pr.Base.Repo.Namespace,
+		"Repo":   pr.Base.Repo.Name,
+		"Number": pr.Number,
+		"Author": pr.Author.Login,
+		"Branch": pr.Head.Ref,
+		"SHA":    pr.Head.Sha,
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "rendering the preview URL template")
+	}
+	return buf.String(), nil
+}
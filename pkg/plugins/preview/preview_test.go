@@ -0,0 +1,185 @@
+package preview
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	launcherfake "github.com/jenkins-x/lighthouse/pkg/launcher/fake"
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider/fake"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testPullRequest() *scm.PullRequest {
+	return &scm.PullRequest{
+		Number: 5,
+		Author: scm.User{Login: "trusted"},
+		Head:   scm.PullRequestBranch{Ref: "feature", Sha: "abc123"},
+		Base: scm.PullRequestBranch{
+			Ref:  "master",
+			Repo: scm.Repository{Namespace: "org", Name: "repo"},
+		},
+	}
+}
+
+func testClient(preview *plugins.Preview) (*client, *fake.SCMClient, *launcherfake.Launcher) {
+	spc := &fake.SCMClient{
+		OrgMembers:          map[string][]string{"org": {"trusted"}},
+		PullRequests:        map[int]*scm.PullRequest{5: testPullRequest()},
+		PullRequestComments: map[int][]*scm.Comment{},
+		IssueComments:       map[int][]*scm.Comment{},
+	}
+	l := launcherfake.NewLauncher()
+	presubmits := []config.Presubmit{
+		{JobBase: config.JobBase{Name: "deploy-preview"}, Reporter: config.Reporter{Context: "preview"}},
+		{JobBase: config.JobBase{Name: "delete-preview"}, Reporter: config.Reporter{Context: "preview-cleanup"}},
+	}
+	return &client{
+		spc:        spc,
+		launcher:   l,
+		presubmits: presubmits,
+		preview:    preview,
+		trigger:    &plugins.Trigger{},
+		logger:     logrus.WithField("plugin", pluginName),
+	}, spc, l
+}
+
+func testPreview() *plugins.Preview {
+	return &plugins.Preview{
+		Repos:      []string{"org/repo"},
+		Job:        "deploy-preview",
+		CleanupJob: "delete-preview",
+		URL:        "https://pr-{{ .Number }}.{{ .Repo }}.example.com",
+	}
+}
+
+func commentEvent(author, body string) *scmprovider.GenericCommentEvent {
+	return &scmprovider.GenericCommentEvent{
+		IsPR:       true,
+		Action:     scm.ActionCreate,
+		IssueState: "open",
+		Body:       body,
+		Number:     5,
+		Author:     scm.User{Login: author},
+		Repo:       scm.Repository{Namespace: "org", Name: "repo"},
+	}
+}
+
+func TestPreviewCommand(t *testing.T) {
+	testCases := []struct {
+		name            string
+		author          string
+		body            string
+		preview         *plugins.Preview
+		expectedJobs    []string
+		expectedComment string
+	}{
+		{
+			name:            "trusted user deploys a preview",
+			author:          "trusted",
+			body:            "/preview",
+			preview:         testPreview(),
+			expectedJobs:    []string{"deploy-preview"},
+			expectedComment: "It will be available at https://pr-5.repo.example.com once the job succeeds.",
+		},
+		{
+			name:            "untrusted user",
+			author:          "someone",
+			body:            "/lh-preview",
+			preview:         testPreview(),
+			expectedComment: "Only trusted users can deploy preview environments.",
+		},
+		{
+			name:            "not configured",
+			author:          "trusted",
+			body:            "/preview",
+			expectedComment: "No preview environment is configured for this repository.",
+		},
+		{
+			name:    "other command",
+			author:  "trusted",
+			body:    "/previews",
+			preview: testPreview(),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, spc, l := testClient(tc.preview)
+			require.NoError(t, handleComment(c, commentEvent(tc.author, tc.body)))
+
+			var jobs []string
+			for _, job := range l.Pipelines {
+				jobs = append(jobs, job.Spec.Job)
+				assert.Equal(t, DeployAction, job.Labels[ActionLabel])
+				assert.Equal(t, "https://pr-5.repo.example.com", job.Annotations[URLAnnotation])
+			}
+			assert.Equal(t, tc.expectedJobs, jobs)
+			if tc.expectedComment == "" {
+				assert.Empty(t, spc.PullRequestComments[5])
+				return
+			}
+			require.Len(t, spc.PullRequestComments[5], 1)
+			assert.Contains(t, spc.PullRequestComments[5][0].Body, tc.expectedComment)
+		})
+	}
+}
+
+func TestPreviewLifecycle(t *testing.T) {
+	p := testPreview()
+	p.Auto = true
+	c, spc, l := testClient(p)
+
+	// closing a pull request without a preview does nothing
+	require.NoError(t, handlePR(c, &scm.PullRequestHook{Action: scm.ActionClose, PullRequest: *testPullRequest()}))
+	assert.Empty(t, l.Pipelines)
+
+	require.NoError(t, handlePR(c, &scm.PullRequestHook{Action: scm.ActionOpen, PullRequest: *testPullRequest()}))
+	require.Len(t, l.Pipelines, 1)
+	require.Len(t, spc.PullRequestComments[5], 1)
+	assert.Contains(t, spc.PullRequestComments[5][0].Body, CommentTag)
+
+	deployJob := l.Pipelines[0]
+	deployJob.Status.ReportURL = "https://dashboard/1"
+	require.NoError(t, Report(spc, deployJob, scm.StateSuccess, c.logger))
+	require.Len(t, spc.PullRequestComments[5], 1)
+	assert.True(t, strings.HasPrefix(spc.PullRequestComments[5][0].Body, "The preview environment of abc123 is available at https://pr-5.repo.example.com"))
+
+	require.NoError(t, handlePR(c, &scm.PullRequestHook{Action: scm.ActionClose, PullRequest: *testPullRequest()}))
+	require.Len(t, l.Pipelines, 2)
+	cleanupJob := l.Pipelines[1]
+	assert.Equal(t, "delete-preview", cleanupJob.Spec.Job)
+	assert.Equal(t, CleanupAction, cleanupJob.Labels[ActionLabel])
+	require.Len(t, spc.PullRequestComments[5], 1)
+	assert.Contains(t, spc.PullRequestComments[5][0].Body, "Deleting the preview environment with the `delete-preview` job.")
+
+	// the result of an outdated deployment is not reported
+	require.NoError(t, Report(spc, deployJob, scm.StateFailure, c.logger))
+	assert.Contains(t, spc.PullRequestComments[5][0].Body, "Deleting the preview environment")
+
+	require.NoError(t, Report(spc, cleanupJob, scm.StateSuccess, c.logger))
+	assert.Contains(t, spc.PullRequestComments[5][0].Body, "The preview environment was deleted.")
+}
+
+func TestReportFailedDeployment(t *testing.T) {
+	c, spc, l := testClient(testPreview())
+	require.NoError(t, handleComment(c, commentEvent("trusted", "/preview")))
+	require.Len(t, l.Pipelines, 1)
+
+	job := l.Pipelines[0]
+	job.Status.ReportURL = "https://dashboard/1"
+	require.NoError(t, Report(spc, job, scm.StatePending, c.logger))
+	assert.Contains(t, spc.PullRequestComments[5][0].Body, "Deploying the preview environment of abc123")
+
+	require.NoError(t, Report(spc, job, scm.StateFailure, c.logger))
+	assert.Contains(t, spc.PullRequestComments[5][0].Body, "Deploying the preview environment of abc123 failed, see the [details](https://dashboard/1). Comment `/preview` to try again.")
+
+	other := &v1alpha1.LighthouseJob{Spec: v1alpha1.LighthouseJobSpec{Refs: job.Spec.Refs}}
+	require.NoError(t, Report(spc, other, scm.StateSuccess, c.logger))
+	assert.Contains(t, spc.PullRequestComments[5][0].Body, "failed")
+}
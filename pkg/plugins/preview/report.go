@@ -0,0 +1,73 @@
+package preview
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/commentpruner"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"github.com/sirupsen/logrus"
+)
+
+// ReportClient is the subset of the SCM client used to report the result of preview jobs
+type ReportClient interface {
+	BotName() (string, error)
+	CreateComment(org, repo string, number int, pr bool, comment string) error
+	EditComment(org, repo string, number, id int, comment string, pr bool) error
+	DeleteComment(org, repo string, number, id int, pr bool) error
+	ListIssueComments(org, repo string, number int) ([]*scm.Comment, error)
+	ListPullRequestComments(org, repo string, number int) ([]*scm.Comment, error)
+}
+
+// Report updates the preview comment of the pull request once a job launched by the plugin completed, with the
+// URL of the deployed preview environment or a link to the failure
+func Report(spc ReportClient, job *v1alpha1.LighthouseJob, state scm.State, logger *logrus.Entry) error {
+	action := job.Labels[ActionLabel]
+	refs := job.Spec.Refs
+	if action == "" || refs == nil || len(refs.Pulls) != 1 {
+		return nil
+	}
+	failed := state == scm.StateFailure || state == scm.StateError
+	if state != scm.StateSuccess && !failed {
+		return nil
+	}
+	pull := refs.Pulls[0]
+	comments := commentpruner.NewEventClient(spc, logger, refs.Org, refs.Repo, pull.Number)
+	current := comments.TaggedComment(true, CommentTag)
+
+	var message string
+	switch action {
+	case DeployAction:
+		// a newer commit may have been deployed since
+		if current == nil || !strings.Contains(current.Body, pull.SHA) {
+			return nil
+		}
+		url := job.Annotations[URLAnnotation]
+		switch {
+		case failed:
+			message = fmt.Sprintf("Deploying the preview environment of %s failed%s. Comment `%s` to try again.", pull.SHA, detailsLink(job), util.FormatCommand("/preview"))
+		case url != "":
+			message = fmt.Sprintf("The preview environment of %s is available at %s", pull.SHA, url)
+		default:
+			message = fmt.Sprintf("The preview environment of %s was deployed.", pull.SHA)
+		}
+	case CleanupAction:
+		if failed {
+			message = fmt.Sprintf("Deleting the preview environment failed%s.", detailsLink(job))
+		} else {
+			message = "The preview environment was deleted."
+		}
+	default:
+		return nil
+	}
+	return comments.UpsertComment(true, CommentTag, message)
+}
+
+func detailsLink(job *v1alpha1.LighthouseJob) string {
+	if job.Status.ReportURL == "" {
+		return ""
+	}
+	return fmt.Sprintf(", see the [details](%s)", job.Status.ReportURL)
+}
@@ -0,0 +1,293 @@
+// Package promote promotes an application to an environment with a configured job on /promote <app> <env>, which
+// members of the configured teams can comment on a pull request or an issue. The status of the job is reported as a
+// commit status of the pull request or of the promoted commit, and the links to the resulting release and
+// promotion pull requests are commented once it completes.
+package promote
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/jx/v2/pkg/tekton/metapipeline"
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/commentpruner"
+	"github.com/jenkins-x/lighthouse/pkg/jobutil"
+	"github.com/jenkins-x/lighthouse/pkg/pluginhelp"
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	pluginName = "promote"
+
+	// IssueAnnotation is added to the jobs launched by the plugin with the number of the pull request or issue
+	// /promote was commented on
+	IssueAnnotation = "lighthouse.jenkins-x.io/promoteIssue"
+)
+
+var promoteRe = regexp.MustCompile(`(?mi)^/` + util.CommandPrefixPattern + `promote\s+(\S+)\s+(\S+)\s*$`)
+
+func init() {
+	plugins.RegisterGenericCommentHandler(pluginName, handleGenericComment, helpProvider)
+}
+
+func helpProvider(config *plugins.Configuration, enabledRepos []string) (*pluginhelp.PluginHelp, error) {
+	configInfo := map[string]string{}
+	for _, repo := range enabledRepos {
+		org, name := repo, ""
+		if parts := strings.SplitN(repo, "/", 2); len(parts) == 2 {
+			org, name = parts[0], parts[1]
+		}
+		p := config.PromotionFor(org, name)
+		if p == nil {
+			configInfo[repo] = "No promotion job is configured."
+			continue
+		}
+		info := fmt.Sprintf("Applications are promoted by the %s job, on behalf of the members of the %s teams", p.Job, strings.Join(p.Teams, ", "))
+		if len(p.Apps) > 0 {
+			info += fmt.Sprintf(", for the %s applications", strings.Join(p.Apps, ", "))
+		}
+		if len(p.Environments) > 0 {
+			info += fmt.Sprintf(", to the %s environments", strings.Join(p.Environments, ", "))
+		}
+		configInfo[repo] = info + "."
+	}
+	pluginHelp := &pluginhelp.PluginHelp{
+		Description: "The promote plugin promotes an application to an environment with a configured job, reports the status of the job on the pull request or commit and comments the links to the resulting release and promotion pull requests.",
+		Config:      configInfo,
+	}
+	pluginHelp.AddCommand(pluginhelp.Command{
+		Usage:       "/promote <app> <env>",
+		Description: "Promotes the application to the environment, from the head of the pull request or, on an issue, of the default branch.",
+		Featured:    true,
+		WhoCanUse:   "Members of the teams configured for the repository.",
+		Examples:    []string{"/promote my-app staging", "/lh-promote my-app production"},
+	})
+	return pluginHelp, nil
+}
+
+type scmProviderClient interface {
+	BotName() (string, error)
+	ListTeams(org string) ([]*scm.Team, error)
+	ListTeamMembers(id int, role string) ([]*scm.TeamMember, error)
+	GetPullRequest(org, repo string, number int) (*scm.PullRequest, error)
+	GetRef(org, repo, ref string) (string, error)
+	CreateComment(org, repo string, number int, pr bool, comment string) error
+	EditComment(org, repo string, number, id int, comment string, pr bool) error
+	DeleteComment(org, repo string, number, id int, pr bool) error
+	ListIssueComments(org, repo string, number int) ([]*scm.Comment, error)
+	ListPullRequestComments(org, repo string, number int) ([]*scm.Comment, error)
+	QuoteAuthorForComment(string) string
+}
+
+type launcher interface {
+	Launch(*v1alpha1.LighthouseJob, metapipeline.Client, scm.Repository) (*v1alpha1.LighthouseJob, error)
+}
+
+type client struct {
+	spc                scmProviderClient
+	launcher           launcher
+	metapipelineClient metapipeline.Client
+	presubmits         []config.Presubmit
+	postsubmits        []config.Postsubmit
+	promotion          *plugins.Promotion
+	logger             *logrus.Entry
+}
+
+func newClient(pc plugins.Agent, repo scm.Repository) *client {
+	return &client{
+		spc:                pc.SCMProviderClient,
+		launcher:           pc.LauncherClient,
+		metapipelineClient: pc.MetapipelineClient,
+		presubmits:         pc.Config.GetPresubmits(repo),
+		postsubmits:        pc.Config.GetPostsubmits(repo),
+		promotion:          pc.PluginConfig.PromotionFor(repo.Namespace, repo.Name),
+		logger:             pc.Logger,
+	}
+}
+
+func handleGenericComment(pc plugins.Agent, e scmprovider.GenericCommentEvent) error {
+	return handleComment(newClient(pc, e.Repo), &e)
+}
+
+func handleComment(c *client, e *scmprovider.GenericCommentEvent) error {
+	if e.Action != scm.ActionCreate {
+		return nil
+	}
+	m := promoteRe.FindStringSubmatch(e.Body)
+	if m == nil {
+		return nil
+	}
+	app, env := m[1], m[2]
+	org := e.Repo.Namespace
+	repo := e.Repo.Name
+	respond := func(message string) error {
+		return c.spc.CreateComment(org, repo, e.Number, e.IsPR, plugins.FormatResponseRaw(e.Body, e.Link, c.spc.QuoteAuthorForComment(e.Author.Login), message))
+	}
+	if c.promotion == nil {
+		return respond("No promotion is configured for this repository.")
+	}
+	if !allowed(c.promotion.Apps, app) {
+		return respond(fmt.Sprintf("`%s` cannot be promoted, the applications which can be promoted are %s.", app, strings.Join(c.promotion.Apps, ", ")))
+	}
+	if !allowed(c.promotion.Environments, env) {
+		return respond(fmt.Sprintf("Applications cannot be promoted to `%s`, the environments are %s.", env, strings.Join(c.promotion.Environments, ", ")))
+	}
+	member, err := c.teamMember(org, e.Author.Login)
+	if err != nil {
+		return errors.Wrapf(err, "checking whether %s is a member of the promotion teams", e.Author.Login)
+	}
+	if !member {
+		return respond(fmt.Sprintf("Only members of the %s teams can promote applications.", strings.Join(c.promotion.Teams, ", ")))
+	}
+
+	var pj *v1alpha1.LighthouseJob
+	if e.IsPR {
+		pj, err = c.presubmit(org, repo, e.Number, e.GUID)
+	} else {
+		pj, err = c.postsubmit(e.Repo, e.GUID)
+	}
+	if err != nil {
+		return respond(fmt.Sprintf("Failed to promote `%s` to `%s`: %v", app, env, err))
+	}
+	pj.Spec.App = app
+	pj.Spec.Environment = env
+	pj.Annotations[IssueAnnotation] = strconv.Itoa(e.Number)
+	c.logger.WithFields(jobutil.LighthouseJobFields(pj)).Infof("Creating a LighthouseJob to promote %s to %s.", app, env)
+	if _, err := c.launcher.Launch(pj, c.metapipelineClient, e.Repo); err != nil {
+		return respond(fmt.Sprintf("Failed to promote `%s` to `%s`: %v", app, env, err))
+	}
+	comments := commentpruner.NewEventClient(c.spc, c.logger, org, repo, e.Number)
+	return comments.UpsertComment(e.IsPR, CommentTag(app, env), fmt.Sprintf("Promoting `%s` to `%s` from %s with the `%s` job.", app, env, promotedSHA(pj), pj.Spec.Job))
+}
+
+// presubmit creates the promotion job of the pull request from the configured presubmit
+func (c *client) presubmit(org, repo string, number int, eventGUID string) (*v1alpha1.LighthouseJob, error) {
+	var presubmit *config.Presubmit
+	for i := range c.presubmits {
+		if c.presubmits[i].Name == c.promotion.Job {
+			presubmit = &c.presubmits[i]
+			break
+		}
+	}
+	if presubmit == nil {
+		return nil, errors.Errorf("there is no presubmit named %s", c.promotion.Job)
+	}
+	pr, err := c.spc.GetPullRequest(org, repo, number)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting pull request %s/%s#%d", org, repo, number)
+	}
+	baseSHA, err := c.spc.GetRef(org, repo, "heads/"+pr.Base.Ref)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting the head of %s", pr.Base.Ref)
+	}
+	pj := jobutil.NewPresubmit(pr, baseSHA, *presubmit, eventGUID)
+	return &pj, nil
+}
+
+// postsubmit creates the promotion job of the head of the default branch of the repository from the configured
+// postsubmit
+func (c *client) postsubmit(repo scm.Repository, eventGUID string) (*v1alpha1.LighthouseJob, error) {
+	var postsubmit *config.Postsubmit
+	for i := range c.postsubmits {
+		if c.postsubmits[i].Name == c.promotion.Job {
+			postsubmit = &c.postsubmits[i]
+			break
+		}
+	}
+	if postsubmit == nil {
+		return nil, errors.Errorf("there is no postsubmit named %s", c.promotion.Job)
+	}
+	branch := repo.Branch
+	if branch == "" {
+		branch = "master"
+	}
+	sha, err := c.spc.GetRef(repo.Namespace, repo.Name, "heads/"+branch)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting the head of %s", branch)
+	}
+	refs := v1alpha1.Refs{
+		Org:      repo.Namespace,
+		Repo:     repo.Name,
+		RepoLink: repo.Link,
+		BaseRef:  branch,
+		BaseSHA:  sha,
+		CloneURI: repo.Clone,
+	}
+	labels := map[string]string{}
+	for k, v := range postsubmit.Labels {
+		labels[k] = v
+	}
+	labels[scmprovider.EventGUID] = eventGUID
+	pj := jobutil.NewLighthouseJob(jobutil.PostsubmitSpec(*postsubmit, refs), labels, postsubmit.Annotations)
+	return &pj, nil
+}
+
+// teamMember returns true if the user is a member of one of the teams allowed to promote
+func (c *client) teamMember(org, login string) (bool, error) {
+	teams, err := c.spc.ListTeams(org)
+	if err != nil {
+		return false, err
+	}
+	for _, team := range teams {
+		if !allowedTeam(c.promotion.Teams, team) {
+			continue
+		}
+		members, err := c.spc.ListTeamMembers(team.ID, scmprovider.RoleAll)
+		if err != nil {
+			return false, err
+		}
+		for _, member := range members {
+			if scmprovider.NormLogin(member.Login) == scmprovider.NormLogin(login) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func allowedTeam(teams []string, team *scm.Team) bool {
+	for _, t := range teams {
+		if strings.EqualFold(t, team.Slug) || strings.EqualFold(t, team.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowed returns true if the value is one of the values, or if any value is allowed
+func allowed(values []string, value string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// CommentTag marks the comment tracking the promotion of the application to the environment
+func CommentTag(app, env string) string {
+	return commentpruner.Tag(fmt.Sprintf("%s:%s:%s", pluginName, app, env))
+}
+
+// promotedSHA returns the commit promoted by the job
+func promotedSHA(job *v1alpha1.LighthouseJob) string {
+	refs := job.Spec.Refs
+	if refs == nil {
+		return ""
+	}
+	if len(refs.Pulls) > 0 {
+		return refs.Pulls[0].SHA
+	}
+	return refs.BaseSHA
+}
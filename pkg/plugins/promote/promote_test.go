@@ -0,0 +1,193 @@
+package promote
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	jxv1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	launcherfake "github.com/jenkins-x/lighthouse/pkg/launcher/fake"
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider/fake"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testClient(promotion *plugins.Promotion) (*client, *fake.SCMClient, *launcherfake.Launcher) {
+	spc := &fake.SCMClient{
+		PullRequests: map[int]*scm.PullRequest{5: {
+			Number: 5,
+			Author: scm.User{Login: "author"},
+			Head:   scm.PullRequestBranch{Ref: "feature", Sha: "abc123"},
+			Base: scm.PullRequestBranch{
+				Ref:  "master",
+				Repo: scm.Repository{Namespace: "org", Name: "repo"},
+			},
+		}},
+		PullRequestComments: map[int][]*scm.Comment{},
+		IssueComments:       map[int][]*scm.Comment{},
+	}
+	l := launcherfake.NewLauncher()
+	return &client{
+		spc:         spc,
+		launcher:    l,
+		presubmits:  []config.Presubmit{{JobBase: config.JobBase{Name: "promote"}, Reporter: config.Reporter{Context: "promote"}}},
+		postsubmits: []config.Postsubmit{{JobBase: config.JobBase{Name: "promote"}, Reporter: config.Reporter{Context: "promote"}}},
+		promotion:   promotion,
+		logger:      logrus.WithField("plugin", pluginName),
+	}, spc, l
+}
+
+func testPromotion() *plugins.Promotion {
+	return &plugins.Promotion{
+		Repos:        []string{"org/repo"},
+		Job:          "promote",
+		Teams:        []string{"leads"},
+		Environments: []string{"staging", "production"},
+	}
+}
+
+func commentEvent(author, body string, pr bool) *scmprovider.GenericCommentEvent {
+	return &scmprovider.GenericCommentEvent{
+		IsPR:   pr,
+		Action: scm.ActionCreate,
+		Body:   body,
+		Number: 5,
+		Author: scm.User{Login: author},
+		Repo:   scm.Repository{Namespace: "org", Name: "repo", Branch: "main"},
+	}
+}
+
+func TestPromoteCommand(t *testing.T) {
+	testCases := []struct {
+		name            string
+		author          string
+		body            string
+		pr              bool
+		promotion       *plugins.Promotion
+		expectedType    config.PipelineKind
+		expectedComment string
+	}{
+		{
+			name:            "team member promotes a pull request",
+			author:          "sig-lead",
+			body:            "/promote my-app staging",
+			pr:              true,
+			promotion:       testPromotion(),
+			expectedType:    config.PresubmitJob,
+			expectedComment: "Promoting `my-app` to `staging` from abc123 with the `promote` job.",
+		},
+		{
+			name:            "team member promotes the default branch from an issue",
+			author:          "sig-lead",
+			body:            "/lh-promote my-app production",
+			promotion:       testPromotion(),
+			expectedType:    config.PostsubmitJob,
+			expectedComment: "Promoting `my-app` to `production` from " + fake.TestRef + " with the `promote` job.",
+		},
+		{
+			name:            "not a team member",
+			author:          "someone",
+			body:            "/promote my-app staging",
+			pr:              true,
+			promotion:       testPromotion(),
+			expectedComment: "Only members of the leads teams can promote applications.",
+		},
+		{
+			name:            "unknown environment",
+			author:          "sig-lead",
+			body:            "/promote my-app qa",
+			pr:              true,
+			promotion:       testPromotion(),
+			expectedComment: "Applications cannot be promoted to `qa`, the environments are staging, production.",
+		},
+		{
+			name:            "not configured",
+			author:          "sig-lead",
+			body:            "/promote my-app staging",
+			pr:              true,
+			expectedComment: "No promotion is configured for this repository.",
+		},
+		{
+			name:      "missing environment",
+			author:    "sig-lead",
+			body:      "/promote my-app",
+			pr:        true,
+			promotion: testPromotion(),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, spc, l := testClient(tc.promotion)
+			require.NoError(t, handleComment(c, commentEvent(tc.author, tc.body, tc.pr)))
+
+			comments := spc.IssueComments[5]
+			if tc.pr {
+				comments = spc.PullRequestComments[5]
+			}
+			if tc.expectedType == "" {
+				assert.Empty(t, l.Pipelines)
+			} else {
+				require.Len(t, l.Pipelines, 1)
+				job := l.Pipelines[0]
+				assert.Equal(t, tc.expectedType, job.Spec.Type)
+				assert.Equal(t, "my-app", job.Spec.App)
+				assert.Equal(t, "5", job.Annotations[IssueAnnotation])
+				if !tc.pr {
+					assert.Equal(t, "main", job.Spec.Refs.BaseRef)
+				}
+			}
+			if tc.expectedComment == "" {
+				assert.Empty(t, comments)
+				return
+			}
+			require.Len(t, comments, 1)
+			assert.Contains(t, comments[0].Body, tc.expectedComment)
+		})
+	}
+}
+
+func TestReport(t *testing.T) {
+	c, spc, l := testClient(testPromotion())
+	require.NoError(t, handleComment(c, commentEvent("sig-lead", "/promote my-app staging", true)))
+	require.Len(t, l.Pipelines, 1)
+	job := l.Pipelines[0]
+
+	activity := &jxv1.PipelineActivity{Spec: jxv1.PipelineActivitySpec{
+		Version:         "1.2.3",
+		ReleaseNotesURL: "https://github.com/org/repo/releases/tag/v1.2.3",
+		Steps: []jxv1.PipelineActivityStep{
+			{Promote: &jxv1.PromoteActivityStep{
+				Environment:    "staging",
+				PullRequest:    &jxv1.PromotePullRequestStep{PullRequestURL: "https://github.com/org/environment-staging/pull/7"},
+				ApplicationURL: "https://my-app.staging.example.com",
+			}},
+			{Promote: &jxv1.PromoteActivityStep{
+				Environment: "production",
+				PullRequest: &jxv1.PromotePullRequestStep{PullRequestURL: "https://github.com/org/environment-production/pull/3"},
+			}},
+		},
+	}}
+	require.NoError(t, Report(spc, job, activity, scm.StatePending, c.logger))
+	assert.Contains(t, spc.PullRequestComments[5][0].Body, "Promoting `my-app` to `staging`")
+
+	require.NoError(t, Report(spc, job, activity, scm.StateSuccess, c.logger))
+	require.Len(t, spc.PullRequestComments[5], 1)
+	body := spc.PullRequestComments[5][0].Body
+	assert.Contains(t, body, "Promoted `my-app` to `staging` from abc123.")
+	assert.Contains(t, body, "* Release: [1.2.3](https://github.com/org/repo/releases/tag/v1.2.3)")
+	assert.Contains(t, body, "* Promotion pull request: https://github.com/org/environment-staging/pull/7")
+	assert.Contains(t, body, "* Application: https://my-app.staging.example.com")
+	assert.NotContains(t, body, "environment-production")
+
+	job.Status.ReportURL = "https://dashboard/1"
+	require.NoError(t, Report(spc, job, nil, scm.StateFailure, c.logger))
+	assert.Contains(t, spc.PullRequestComments[5][0].Body, "Promoting `my-app` to `staging` from abc123 failed, see the [details](https://dashboard/1). Comment `/promote my-app staging` to try again.")
+
+	other := &v1alpha1.LighthouseJob{Spec: v1alpha1.LighthouseJobSpec{Refs: job.Spec.Refs}}
+	require.NoError(t, Report(spc, other, activity, scm.StateSuccess, c.logger))
+	assert.Contains(t, spc.PullRequestComments[5][0].Body, "failed")
+}
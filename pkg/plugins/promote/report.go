@@ -0,0 +1,94 @@
+package promote
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jenkins-x/go-scm/scm"
+	jxv1 "github.com/jenkins-x/jx/v2/pkg/apis/jenkins.io/v1"
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/commentpruner"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"github.com/sirupsen/logrus"
+)
+
+// ReportClient is the subset of the SCM client used to report the result of promotion jobs
+type ReportClient interface {
+	BotName() (string, error)
+	CreateComment(org, repo string, number int, pr bool, comment string) error
+	EditComment(org, repo string, number, id int, comment string, pr bool) error
+	DeleteComment(org, repo string, number, id int, pr bool) error
+	ListIssueComments(org, repo string, number int) ([]*scm.Comment, error)
+	ListPullRequestComments(org, repo string, number int) ([]*scm.Comment, error)
+}
+
+// Report updates the promotion comment of the pull request or issue once a job launched by the plugin completed,
+// with the links to the release and to the promotion pull requests recorded in the activity of the job, if any
+func Report(spc ReportClient, job *v1alpha1.LighthouseJob, activity *jxv1.PipelineActivity, state scm.State, logger *logrus.Entry) error {
+	app, env := job.Spec.App, job.Spec.Environment
+	number, err := strconv.Atoi(job.Annotations[IssueAnnotation])
+	if app == "" || err != nil || job.Spec.Refs == nil {
+		return nil
+	}
+	failed := state == scm.StateFailure || state == scm.StateError
+	if state != scm.StateSuccess && !failed {
+		return nil
+	}
+	refs := job.Spec.Refs
+	pr := job.Spec.Type == config.PresubmitJob
+	sha := promotedSHA(job)
+	comments := commentpruner.NewEventClient(spc, logger, refs.Org, refs.Repo, number)
+	// a newer commit may have been promoted since
+	if current := comments.TaggedComment(pr, CommentTag(app, env)); current == nil || !strings.Contains(current.Body, sha) {
+		return nil
+	}
+
+	var message string
+	if failed {
+		message = fmt.Sprintf("Promoting `%s` to `%s` from %s failed%s. Comment `%s` to try again.", app, env, sha, detailsLink(job), util.FormatCommand(fmt.Sprintf("/promote %s %s", app, env)))
+	} else {
+		message = fmt.Sprintf("Promoted `%s` to `%s` from %s.", app, env, sha)
+		for _, link := range links(activity, env) {
+			message += "\n* " + link
+		}
+	}
+	return comments.UpsertComment(pr, CommentTag(app, env), message)
+}
+
+// links returns the markdown links to the release and to the promotion pull requests and applications of the
+// environment recorded in the activity
+func links(activity *jxv1.PipelineActivity, env string) []string {
+	if activity == nil {
+		return nil
+	}
+	var answer []string
+	if url := activity.Spec.ReleaseNotesURL; url != "" {
+		version := activity.Spec.Version
+		if version == "" {
+			version = "release"
+		}
+		answer = append(answer, fmt.Sprintf("Release: [%s](%s)", version, url))
+	}
+	for _, step := range activity.Spec.Steps {
+		promote := step.Promote
+		if promote == nil || (promote.Environment != "" && !strings.EqualFold(promote.Environment, env)) {
+			continue
+		}
+		if promote.PullRequest != nil && promote.PullRequest.PullRequestURL != "" {
+			answer = append(answer, fmt.Sprintf("Promotion pull request: %s", promote.PullRequest.PullRequestURL))
+		}
+		if promote.ApplicationURL != "" {
+			answer = append(answer, fmt.Sprintf("Application: %s", promote.ApplicationURL))
+		}
+	}
+	return answer
+}
+
+func detailsLink(job *v1alpha1.LighthouseJob) string {
+	if job.Status.ReportURL == "" {
+		return ""
+	}
+	return fmt.Sprintf(", see the [details](%s)", job.Status.ReportURL)
+}
@@ -0,0 +1,218 @@
+// Package reminder implements a scheduled plugin reminding the reviewers of pull requests which await review,
+// escalating to the approvers of their OWNERS files if they still await review later on.
+package reminder
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse/pkg/labels"
+	"github.com/jenkins-x/lighthouse/pkg/pluginhelp"
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/jenkins-x/lighthouse/pkg/repoowners"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	pluginName = "reminder"
+
+	// reminderMarker and escalationMarker tag the comments of the plugin, so that each pull request is reminded
+	// and escalated once until someone comments on it again
+	reminderMarker   = "<!-- lighthouse:reminder -->"
+	escalationMarker = "<!-- lighthouse:reminder-escalation -->"
+)
+
+func init() {
+	plugins.RegisterScheduledHandler(pluginName, handleSchedule, helpProvider)
+}
+
+func helpProvider(config *plugins.Configuration, enabledRepos []string) (*pluginhelp.PluginHelp, error) {
+	configInfo := map[string]string{}
+	for _, orgRepo := range enabledRepos {
+		parts := strings.Split(orgRepo, "/")
+		var reminder *plugins.Reminder
+		switch len(parts) {
+		case 1:
+			reminder = config.ReminderFor(orgRepo, "")
+		case 2:
+			reminder = config.ReminderFor(parts[0], parts[1])
+		default:
+			return nil, fmt.Errorf("invalid repo in enabledRepos: %q", orgRepo)
+		}
+		if reminder == nil {
+			configInfo[orgRepo] = "The repository opted out of the reminders."
+			continue
+		}
+		configInfo[orgRepo] = fmt.Sprintf("The reviewers and assignees of pull requests awaiting review for %s are reminded.", reminder.AfterDuration)
+		if reminder.EscalateAfterDuration > 0 {
+			configInfo[orgRepo] += fmt.Sprintf(" The approvers of the changed files are pinged after %s.", reminder.EscalateAfterDuration)
+		}
+	}
+	// The {WhoCanUse, Usage, Examples} fields are omitted because this plugin is not triggered with commands.
+	return &pluginhelp.PluginHelp{
+			Description: "The reminder plugin periodically comments on the pull requests which have awaited review for too long, pinging their reviewers and assignees, and escalating to the approvers in the OWNERS files if configured. A pull request awaits review until it is approved or gets the lgtm label, and the wait starts over whenever someone else than its author comments on it. It runs every --schedule-interval of the hook.",
+			Config:      configInfo,
+		},
+		nil
+}
+
+type scmProviderClient interface {
+	ListAllPullRequestsForFullNameRepo(fullName string, opts scm.PullRequestListOptions) ([]*scm.PullRequest, error)
+	ListPullRequestComments(owner, repo string, number int) ([]*scm.Comment, error)
+	GetPullRequestChanges(org, repo string, number int) ([]*scm.Change, error)
+	CreateComment(owner, repo string, number int, pr bool, comment string) error
+	BotName() (string, error)
+	QuoteAuthorForComment(string) string
+}
+
+type ownersClient interface {
+	LoadRepoOwners(org, repo, base string) (repoowners.RepoOwner, error)
+}
+
+type client struct {
+	SCMProviderClient scmProviderClient
+	OwnersClient      ownersClient
+	Logger            *logrus.Entry
+}
+
+func handleSchedule(pc plugins.Agent, repo scm.Repository) error {
+	reminder := pc.PluginConfig.ReminderFor(repo.Namespace, repo.Name)
+	if reminder == nil {
+		return nil
+	}
+	c := client{
+		SCMProviderClient: pc.SCMProviderClient,
+		OwnersClient:      pc.OwnersClient,
+		Logger:            pc.Logger,
+	}
+	return handle(c, reminder, repo, time.Now())
+}
+
+func handle(c client, reminder *plugins.Reminder, repo scm.Repository, now time.Time) error {
+	fullName := repo.FullName
+	if fullName == "" {
+		fullName = scm.Join(repo.Namespace, repo.Name)
+	}
+	prs, err := c.SCMProviderClient.ListAllPullRequestsForFullNameRepo(fullName, scm.PullRequestListOptions{Open: true, Size: 100})
+	if err != nil {
+		return err
+	}
+	botName, err := c.SCMProviderClient.BotName()
+	if err != nil {
+		return err
+	}
+	for _, pr := range prs {
+		if !awaitsReview(pr) || now.Sub(pr.Created) < reminder.AfterDuration {
+			continue
+		}
+		l := c.Logger.WithField("pr", pr.Number)
+		if err := remind(c, reminder, repo, pr, botName, now, l); err != nil {
+			l.WithError(err).Warn("failed to remind the reviewers of the pull request")
+		}
+	}
+	return nil
+}
+
+// awaitsReview returns true if the pull request is ready for review and has been neither approved nor lgtm'd
+func awaitsReview(pr *scm.PullRequest) bool {
+	if pr.Draft || pr.Closed || pr.Merged {
+		return false
+	}
+	for _, label := range pr.Labels {
+		if label.Name == labels.LGTM || label.Name == labels.Approved {
+			return false
+		}
+	}
+	return true
+}
+
+func remind(c client, reminder *plugins.Reminder, repo scm.Repository, pr *scm.PullRequest, botName string, now time.Time, l *logrus.Entry) error {
+	comments, err := c.SCMProviderClient.ListPullRequestComments(repo.Namespace, repo.Name, pr.Number)
+	if err != nil {
+		return err
+	}
+	// the pull request awaits review since it was opened or since someone else than its author last commented on it
+	since := pr.Created
+	reminded, escalated := false, false
+	for _, comment := range comments {
+		switch {
+		case comment.Author.Login == botName:
+			if comment.Created.Before(since) {
+				continue
+			}
+			reminded = reminded || strings.Contains(comment.Body, reminderMarker)
+			escalated = escalated || strings.Contains(comment.Body, escalationMarker)
+		case comment.Author.Login != pr.Author.Login && comment.Created.After(since):
+			since = comment.Created
+			reminded, escalated = false, false
+		}
+	}
+	waited := now.Sub(since)
+
+	if reminder.EscalateAfterDuration > 0 && waited >= reminder.EscalateAfterDuration && !escalated {
+		approvers, err := approversOf(c, repo, pr)
+		if err != nil {
+			return err
+		}
+		if approvers.Len() > 0 {
+			l.Info("Escalating the pull request to the approvers.")
+			body := fmt.Sprintf("%s\nThis pull request has been awaiting review for %s. %s, could you take a look or find someone to review it?",
+				escalationMarker, humanDuration(waited), mentions(c, approvers.List()))
+			return c.SCMProviderClient.CreateComment(repo.Namespace, repo.Name, pr.Number, true, body)
+		}
+	}
+	if waited < reminder.AfterDuration || reminded || escalated {
+		return nil
+	}
+	reviewers := sets.NewString()
+	for _, user := range append(pr.Reviewers, pr.Assignees...) {
+		if user.Login != "" && user.Login != pr.Author.Login && user.Login != botName {
+			reviewers.Insert(user.Login)
+		}
+	}
+	if reviewers.Len() == 0 {
+		// there is nobody to remind until the pull request is escalated
+		return nil
+	}
+	l.Info("Reminding the reviewers of the pull request.")
+	body := fmt.Sprintf("%s\nThis pull request has been awaiting review for %s. %s, could you take a look?",
+		reminderMarker, humanDuration(waited), mentions(c, reviewers.List()))
+	return c.SCMProviderClient.CreateComment(repo.Namespace, repo.Name, pr.Number, true, body)
+}
+
+// approversOf returns the approvers in the OWNERS files of the changes of the pull request, leaving out its author
+func approversOf(c client, repo scm.Repository, pr *scm.PullRequest) (sets.String, error) {
+	changes, err := c.SCMProviderClient.GetPullRequestChanges(repo.Namespace, repo.Name, pr.Number)
+	if err != nil {
+		return nil, err
+	}
+	owners, err := c.OwnersClient.LoadRepoOwners(repo.Namespace, repo.Name, pr.Base.Ref)
+	if err != nil {
+		return nil, err
+	}
+	approvers := sets.NewString()
+	for _, change := range changes {
+		approvers = approvers.Union(owners.Approvers(change.Path))
+	}
+	approvers.Delete(strings.ToLower(pr.Author.Login))
+	return approvers, nil
+}
+
+func mentions(c client, logins []string) string {
+	var answer []string
+	for _, login := range logins {
+		answer = append(answer, "@"+c.SCMProviderClient.QuoteAuthorForComment(login))
+	}
+	return strings.Join(answer, ", ")
+}
+
+// humanDuration formats the duration in days, or in hours if it is shorter than 2 days
+func humanDuration(d time.Duration) string {
+	if d >= 48*time.Hour {
+		return fmt.Sprintf("%d days", int(d/(24*time.Hour)))
+	}
+	return fmt.Sprintf("%d hours", int(d/time.Hour))
+}
@@ -0,0 +1,186 @@
+package reminder
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse/pkg/labels"
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/jenkins-x/lighthouse/pkg/repoowners"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+type fakeSCMClient struct {
+	prs      []*scm.PullRequest
+	comments map[int][]*scm.Comment
+	changes  []*scm.Change
+	created  map[int][]string
+}
+
+func (f *fakeSCMClient) ListAllPullRequestsForFullNameRepo(fullName string, opts scm.PullRequestListOptions) ([]*scm.PullRequest, error) {
+	return f.prs, nil
+}
+
+func (f *fakeSCMClient) ListPullRequestComments(owner, repo string, number int) ([]*scm.Comment, error) {
+	return f.comments[number], nil
+}
+
+func (f *fakeSCMClient) GetPullRequestChanges(org, repo string, number int) ([]*scm.Change, error) {
+	return f.changes, nil
+}
+
+func (f *fakeSCMClient) CreateComment(owner, repo string, number int, pr bool, comment string) error {
+	f.created[number] = append(f.created[number], comment)
+	return nil
+}
+
+func (f *fakeSCMClient) BotName() (string, error) {
+	return "bot", nil
+}
+
+func (f *fakeSCMClient) QuoteAuthorForComment(author string) string {
+	return author
+}
+
+type fakeOwnersClient struct {
+	approvers map[string]sets.String
+}
+
+func (f *fakeOwnersClient) LoadRepoOwners(org, repo, base string) (repoowners.RepoOwner, error) {
+	return &fakeRepoOwners{approvers: f.approvers}, nil
+}
+
+type fakeRepoOwners struct {
+	repoowners.RepoOwner
+	approvers map[string]sets.String
+}
+
+func (f *fakeRepoOwners) Approvers(path string) sets.String {
+	return f.approvers[path]
+}
+
+func TestHandle(t *testing.T) {
+	now := time.Date(2020, 6, 15, 12, 0, 0, 0, time.UTC)
+	daysAgo := func(days int) time.Time {
+		return now.Add(-time.Duration(days) * 24 * time.Hour)
+	}
+	reviewers := []scm.User{{Login: "reviewer"}}
+	testCases := []struct {
+		name     string
+		pr       *scm.PullRequest
+		comments []*scm.Comment
+		expected string
+	}{
+		{
+			name: "recent pull request",
+			pr:   &scm.PullRequest{Created: daysAgo(1), Reviewers: reviewers},
+		},
+		{
+			name:     "awaiting review",
+			pr:       &scm.PullRequest{Created: daysAgo(4), Reviewers: reviewers},
+			expected: reminderMarker + "\nThis pull request has been awaiting review for 4 days. @reviewer, could you take a look?",
+		},
+		{
+			name: "draft",
+			pr:   &scm.PullRequest{Created: daysAgo(4), Reviewers: reviewers, Draft: true},
+		},
+		{
+			name: "lgtm",
+			pr:   &scm.PullRequest{Created: daysAgo(4), Reviewers: reviewers, Labels: []*scm.Label{{Name: labels.LGTM}}},
+		},
+		{
+			name: "nobody to remind",
+			pr:   &scm.PullRequest{Created: daysAgo(4)},
+		},
+		{
+			name:     "assignees are reminded",
+			pr:       &scm.PullRequest{Created: daysAgo(4), Assignees: []scm.User{{Login: "assignee"}, {Login: "author"}}},
+			expected: reminderMarker + "\nThis pull request has been awaiting review for 4 days. @assignee, could you take a look?",
+		},
+		{
+			name: "already reminded",
+			pr:   &scm.PullRequest{Created: daysAgo(5), Reviewers: reviewers},
+			comments: []*scm.Comment{
+				{Author: scm.User{Login: "bot"}, Body: reminderMarker + "\nreminder", Created: daysAgo(2)},
+			},
+		},
+		{
+			name: "commented by the author",
+			pr:   &scm.PullRequest{Created: daysAgo(5), Reviewers: reviewers},
+			comments: []*scm.Comment{
+				{Author: scm.User{Login: "author"}, Body: "ping", Created: daysAgo(1)},
+			},
+			expected: reminderMarker + "\nThis pull request has been awaiting review for 5 days. @reviewer, could you take a look?",
+		},
+		{
+			name: "reviewed recently",
+			pr:   &scm.PullRequest{Created: daysAgo(5), Reviewers: reviewers},
+			comments: []*scm.Comment{
+				{Author: scm.User{Login: "bot"}, Body: reminderMarker + "\nreminder", Created: daysAgo(2)},
+				{Author: scm.User{Login: "reviewer"}, Body: "looks good but", Created: daysAgo(1)},
+			},
+		},
+		{
+			name: "reminded again after a review",
+			pr:   &scm.PullRequest{Created: daysAgo(5), Reviewers: reviewers},
+			comments: []*scm.Comment{
+				{Author: scm.User{Login: "bot"}, Body: reminderMarker + "\nreminder", Created: daysAgo(5)},
+				{Author: scm.User{Login: "reviewer"}, Body: "looks good but", Created: daysAgo(4)},
+			},
+			expected: reminderMarker + "\nThis pull request has been awaiting review for 4 days. @reviewer, could you take a look?",
+		},
+		{
+			name: "escalated",
+			pr:   &scm.PullRequest{Created: daysAgo(8), Reviewers: reviewers},
+			comments: []*scm.Comment{
+				{Author: scm.User{Login: "bot"}, Body: reminderMarker + "\nreminder", Created: daysAgo(5)},
+			},
+			expected: escalationMarker + "\nThis pull request has been awaiting review for 8 days. @approver, could you take a look or find someone to review it?",
+		},
+		{
+			name: "already escalated",
+			pr:   &scm.PullRequest{Created: daysAgo(9), Reviewers: reviewers},
+			comments: []*scm.Comment{
+				{Author: scm.User{Login: "bot"}, Body: escalationMarker + "\nescalation", Created: daysAgo(1)},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.pr.Number = 1
+			tc.pr.Author = scm.User{Login: "author"}
+			fake := &fakeSCMClient{
+				prs:      []*scm.PullRequest{tc.pr},
+				comments: map[int][]*scm.Comment{1: tc.comments},
+				changes:  []*scm.Change{{Path: "main.go"}},
+				created:  map[int][]string{},
+			}
+			c := client{
+				SCMProviderClient: fake,
+				OwnersClient:      &fakeOwnersClient{approvers: map[string]sets.String{"main.go": sets.NewString("approver", "author")}},
+				Logger:            logrus.WithField("plugin", pluginName),
+			}
+			reminder := &plugins.Reminder{AfterDuration: 3 * 24 * time.Hour, EscalateAfterDuration: 7 * 24 * time.Hour}
+			require.NoError(t, handle(c, reminder, scm.Repository{Namespace: "org", Name: "repo"}, now))
+
+			comments := fake.created[1]
+			if tc.expected == "" {
+				assert.Empty(t, comments)
+				return
+			}
+			require.Len(t, comments, 1)
+			assert.Equal(t, tc.expected, comments[0])
+		})
+	}
+}
+
+func TestHumanDuration(t *testing.T) {
+	assert.Equal(t, "30 hours", humanDuration(30*time.Hour))
+	assert.Equal(t, "3 days", humanDuration(80*time.Hour))
+	assert.True(t, strings.HasSuffix(humanDuration(0), "hours"))
+}
@@ -21,16 +21,20 @@ import (
 	"strings"
 
 	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"github.com/sirupsen/logrus"
 )
 
-// AboutThisBotWithoutCommands contains the message that explains how to interact with the bot.
-const AboutThisBotWithoutCommands = "Instructions for interacting with me using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to my behavior, please file an issue against the [jenkins-x/lighthouse](https://github.com/jenkins-x/lighthouse/issues/new?title=Command%20issue:) repository."
+// AboutThisBotWithoutCommands contains the message that explains how to interact with the bot, which is named with
+// its display name.
+var AboutThisBotWithoutCommands = "Instructions for interacting with " + util.BotDisplayName + " using PR comments are available [here](https://git.k8s.io/community/contributors/guide/pull-requests.md).  If you have questions or suggestions related to its behavior, please file an issue against the [jenkins-x/lighthouse](https://github.com/jenkins-x/lighthouse/issues/new?title=Command%20issue:) repository."
 
 // AboutThisBotCommands contains the message that links to the commands the bot understand.
-const AboutThisBotCommands = "I understand the commands that are listed [here](https://go.k8s.io/bot-commands)."
+var AboutThisBotCommands = "I understand the commands that are listed [here](https://go.k8s.io/bot-commands), or by commenting `/" + HelpCommandName + "`."
 
 // AboutThisBot contains the text of both AboutThisBotWithoutCommands and AboutThisBotCommands.
-const AboutThisBot = AboutThisBotWithoutCommands + " " + AboutThisBotCommands
+var AboutThisBot = AboutThisBotWithoutCommands + " " + AboutThisBotCommands
 
 // FormatResponse nicely formats a response to a generic reason.
 func FormatResponse(to, message, reason string) string {
@@ -77,3 +81,25 @@ func FormatResponseRaw(body, bodyURL, login, reply string) string {
 	}
 	return FormatResponse(login, reply, fmt.Sprintf(format, bodyURL, strings.Join(quoted, "\n")))
 }
+
+// CommentReactor reacts to comments with emojis
+type CommentReactor interface {
+	CreateCommentReaction(owner, repo string, number int, pr bool, kind scmprovider.CommentKind, commentID int, reaction scmprovider.Reaction) error
+}
+
+// React reacts to the comment of the event to give feedback on its commands. The failures are only logged, and
+// nothing is done for the events which are not comments or the providers without reactions. The reaction is only
+// collected if the commands of the comment are handled as a batch, which reacts once to the whole comment.
+func React(spc CommentReactor, log *logrus.Entry, e scmprovider.GenericCommentEvent, reaction scmprovider.Reaction) {
+	if e.CommentID == 0 {
+		return
+	}
+	if e.Reactions != nil {
+		e.Reactions.Add(reaction)
+		return
+	}
+	err := spc.CreateCommentReaction(e.Repo.Namespace, e.Repo.Name, e.Number, e.IsPR, e.CommentKind, e.CommentID, reaction)
+	if err != nil && err != scm.ErrNotSupported {
+		log.WithError(err).Warnf("failed to react to the comment with %s", reaction)
+	}
+}
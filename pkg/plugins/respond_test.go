@@ -21,6 +21,8 @@ import (
 	"testing"
 
 	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	"github.com/sirupsen/logrus"
 )
 
 func TestFormatICResponse(t *testing.T) {
@@ -54,3 +56,32 @@ func TestFormatResponseRaw(t *testing.T) {
 		t.Errorf("Expected quotes, got:\n%s", out)
 	}
 }
+
+type fakeReactor struct {
+	reactions []scmprovider.Reaction
+}
+
+func (f *fakeReactor) CreateCommentReaction(owner, repo string, number int, pr bool, kind scmprovider.CommentKind, commentID int, reaction scmprovider.Reaction) error {
+	f.reactions = append(f.reactions, reaction)
+	return nil
+}
+
+func TestReactBatch(t *testing.T) {
+	spc := &fakeReactor{}
+	e := scmprovider.GenericCommentEvent{CommentID: 5001, Reactions: &scmprovider.ReactionBatch{}}
+	for _, reaction := range []scmprovider.Reaction{scmprovider.ReactionAccepted, scmprovider.ReactionRejected, scmprovider.ReactionStarted} {
+		React(spc, logrus.WithField("plugin", "test"), e, reaction)
+	}
+	if len(spc.reactions) != 0 {
+		t.Errorf("Expected the reactions of a batch to be collected, got %v", spc.reactions)
+	}
+	if reaction := e.Reactions.Reaction(); reaction != scmprovider.ReactionRejected {
+		t.Errorf("Expected the batch to be rejected, got %q", reaction)
+	}
+
+	e.Reactions = nil
+	React(spc, logrus.WithField("plugin", "test"), e, scmprovider.ReactionAccepted)
+	if len(spc.reactions) != 1 || spc.reactions[0] != scmprovider.ReactionAccepted {
+		t.Errorf("Expected the comment to be accepted, got %v", spc.reactions)
+	}
+}
@@ -0,0 +1,17 @@
+package plugins
+
+import (
+	"encoding/json"
+
+	"github.com/alecthomas/jsonschema"
+)
+
+// SchemaFile is the path of the published JSON schema of plugins.yaml, relative to the root of the repository
+const SchemaFile = "schema/plugins.schema.json"
+
+// Schema returns the JSON schema of the plugins configuration, which editors validate plugins.yaml with. Unknown
+// fields are rejected, as they are when the configuration is loaded.
+func Schema() ([]byte, error) {
+	r := &jsonschema.Reflector{RequiredFromJSONSchemaTags: true, ExpandedStruct: true}
+	return json.MarshalIndent(r.Reflect(Configuration{}), "", "  ")
+}
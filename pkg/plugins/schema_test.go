@@ -0,0 +1,30 @@
+package plugins
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSchema(t *testing.T) {
+	actual, err := Schema()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	actual = append(actual, '\n')
+	path := filepath.Join("..", "..", SchemaFile)
+	if os.Getenv("UPDATE_GOLDEN") == "true" {
+		if err := ioutil.WriteFile(path, actual, 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", path, err)
+		}
+		return
+	}
+	expected, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read %s, which is written by running the test with UPDATE_GOLDEN=true: %v", path, err)
+	}
+	if string(expected) != string(actual) {
+		t.Errorf("%s is out of date, run the test with UPDATE_GOLDEN=true to update it", path)
+	}
+}
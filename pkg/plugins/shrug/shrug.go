@@ -22,6 +22,7 @@ import (
 
 	"github.com/jenkins-x/go-scm/scm"
 	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	"github.com/jenkins-x/lighthouse/pkg/util"
 	"github.com/sirupsen/logrus"
 
 	"github.com/jenkins-x/lighthouse/pkg/labels"
@@ -32,8 +33,8 @@ import (
 const pluginName = "shrug"
 
 var (
-	shrugRe   = regexp.MustCompile(`(?mi)^/(?:lh-)?shrug\s*$`)
-	unshrugRe = regexp.MustCompile(`(?mi)^/(?:lh-)?unshrug\s*$`)
+	shrugRe   = regexp.MustCompile(`(?mi)^/` + util.CommandPrefixPattern + `shrug\s*$`)
+	unshrugRe = regexp.MustCompile(`(?mi)^/` + util.CommandPrefixPattern + `unshrug\s*$`)
 )
 
 type event struct {
@@ -30,6 +30,7 @@ import (
 	"github.com/jenkins-x/lighthouse/pkg/gitattributes"
 	"github.com/jenkins-x/lighthouse/pkg/pluginhelp"
 	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
 )
 
 // The sizes are configurable in the `plugins.yaml` config file; the line constants
@@ -104,7 +105,8 @@ func handlePR(spc scmProviderClient, sizes plugins.Size, le *logrus.Entry, pe sc
 	}
 
 	changes, err := spc.GetPullRequestChanges(owner, repo, num)
-	if err != nil {
+	tooManyChanges := scmprovider.IsTooManyChanges(err)
+	if err != nil && !tooManyChanges {
 		return fmt.Errorf("can not get PR changes for size plugin: %v", err)
 	}
 
@@ -124,6 +126,10 @@ func handlePR(spc scmProviderClient, sizes plugins.Size, le *logrus.Entry, pe sc
 	}
 
 	newLabel := bucket(count, sizes).label()
+	if tooManyChanges {
+		// the lines of the files which are not listed are not counted
+		newLabel = labelXXL
+	}
 	var hasLabel bool
 
 	for _, label := range labels {
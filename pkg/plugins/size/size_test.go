@@ -23,6 +23,7 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
 )
 
 type spc struct {
@@ -585,6 +586,40 @@ func TestHandlePR(t *testing.T) {
 				Xxl: 4,
 			},
 		},
+		{
+			name: "too many changes, size/XXL",
+			client: &spc{
+				labels:     map[scm.Label]bool{},
+				getFileErr: scm.ErrNotFound,
+				prChanges: []*scm.Change{
+					{
+						Sha:       "abcd",
+						Path:      "foobar",
+						Additions: 1,
+						Deletions: 1,
+						Changes:   2,
+					},
+				},
+				getPullRequestChangesErr: &scmprovider.TooManyChangesError{Limit: 1},
+			},
+			event: scm.PullRequestHook{
+				Action: scm.ActionOpen,
+				PullRequest: scm.PullRequest{
+					Number: 101,
+					Base: scm.PullRequestBranch{
+						Sha: "abcd",
+						Repo: scm.Repository{
+							Namespace: "kubernetes",
+							Name:      "kubernetes",
+						},
+					},
+				},
+			},
+			finalLabels: []*scm.Label{
+				{Name: "size/XXL"},
+			},
+			sizes: defaultSizes,
+		},
 	}
 
 	for _, c := range cases {
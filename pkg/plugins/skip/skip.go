@@ -27,14 +27,16 @@ import (
 	"github.com/jenkins-x/lighthouse/pkg/pluginhelp"
 	"github.com/jenkins-x/lighthouse/pkg/plugins"
 	"github.com/jenkins-x/lighthouse/pkg/plugins/trigger"
+	"github.com/jenkins-x/lighthouse/pkg/requiredjobs"
 	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	"github.com/jenkins-x/lighthouse/pkg/util"
 	"github.com/sirupsen/logrus"
 )
 
 const pluginName = "skip"
 
 var (
-	skipRe = regexp.MustCompile(`(?mi)^/(?:lh-)?skip\s*$`)
+	skipRe = regexp.MustCompile(`(?mi)^/` + util.CommandPrefixPattern + `skip\s*$`)
 )
 
 type scmProviderClient interface {
@@ -130,7 +132,7 @@ func handle(spc scmProviderClient, log *logrus.Entry, e *scmprovider.GenericComm
 			continue
 		}
 		// Only skip jobs that are not required
-		if job.ContextRequired() {
+		if requiredjobs.Required(job) {
 			continue
 		}
 		context := job.Context
@@ -0,0 +1,178 @@
+package plugins
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// yamlBools are the YAML 1.1 booleans, which the YAML decoder of the configuration accepts
+var yamlBools = map[string]bool{
+	"y": true, "yes": true, "on": true, "true": true,
+	"n": true, "no": true, "off": true, "false": true,
+}
+
+// unmarshalStrict decodes the YAML of the plugins configuration, rejecting the fields which are unknown or whose
+// value does not have the type of the field, which would otherwise be silently ignored. The error gives the line
+// and the path of the offending field.
+func unmarshalStrict(data []byte, c *Configuration) error {
+	var doc yamlv3.Node
+	if err := yamlv3.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	if len(doc.Content) > 0 {
+		if err := checkNode(doc.Content[0], reflect.TypeOf(c).Elem(), ""); err != nil {
+			return err
+		}
+	}
+	return yaml.UnmarshalStrict(data, c)
+}
+
+// checkNode returns an error for the first field of the YAML node which the type does not have, or whose value
+// does not have the kind of the type
+func checkNode(node *yamlv3.Node, t reflect.Type, path string) error {
+	if node.Kind == yamlv3.AliasNode {
+		node = node.Alias
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if node.Kind == yamlv3.ScalarNode && node.Tag == "!!null" {
+		return nil
+	}
+	if reflect.PtrTo(t).Implements(jsonUnmarshalerType) || reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		return nil
+	}
+	mismatch := func(expected string) error {
+		return fmt.Errorf("line %d: %s should be %s", node.Line, displayPath(path), expected)
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yamlv3.MappingNode {
+			return mismatch("an object")
+		}
+		fields := jsonFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			field, ok := lookupField(fields, key.Value)
+			if !ok {
+				return fmt.Errorf("line %d: unknown field %q in %s", key.Line, key.Value, displayPath(path))
+			}
+			if err := checkNode(value, field.Type, joinPath(path, key.Value)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if node.Kind != yamlv3.MappingNode {
+			return mismatch("an object")
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if err := checkNode(node.Content[i+1], t.Elem(), joinPath(path, node.Content[i].Value)); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			break
+		}
+		if node.Kind != yamlv3.SequenceNode {
+			return mismatch("a list")
+		}
+		for i, item := range node.Content {
+			if err := checkNode(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Bool:
+		if node.Kind != yamlv3.ScalarNode || !yamlBools[strings.ToLower(node.Value)] {
+			return mismatch("a boolean")
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if node.Kind != yamlv3.ScalarNode || node.Tag != "!!int" {
+			return mismatch("an integer")
+		}
+	case reflect.Float32, reflect.Float64:
+		if node.Kind != yamlv3.ScalarNode || (node.Tag != "!!int" && node.Tag != "!!float") {
+			return mismatch("a number")
+		}
+	case reflect.String:
+		if node.Kind != yamlv3.ScalarNode {
+			return mismatch("a string")
+		}
+	}
+	return nil
+}
+
+// jsonFields returns the fields of the struct by their JSON name, including the fields of its embedded structs
+func jsonFields(t reflect.Type) map[string]reflect.StructField {
+	answer := map[string]reflect.StructField{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				for n, f := range jsonFields(embedded) {
+					if _, ok := answer[n]; !ok {
+						answer[n] = f
+					}
+				}
+				continue
+			}
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		answer[name] = field
+	}
+	return answer
+}
+
+// lookupField finds the field of a key the way encoding/json does, preferring an exact match to a case-insensitive one
+func lookupField(fields map[string]reflect.StructField, key string) (reflect.StructField, bool) {
+	if field, ok := fields[key]; ok {
+		return field, true
+	}
+	for name, field := range fields {
+		if strings.EqualFold(name, key) {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func displayPath(path string) string {
+	if path == "" {
+		return "the configuration"
+	}
+	return path
+}
@@ -0,0 +1,94 @@
+package plugins
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnmarshalStrict(t *testing.T) {
+	tests := []struct {
+		name        string
+		yaml        string
+		expectedErr string
+	}{
+		{
+			name: "valid",
+			yaml: `plugins:
+  org/repo:
+  - trigger
+triggers:
+- repos:
+  - org
+  trusted_org: org
+  skip_draft_pr: yes
+  quotas:
+  - max: 10
+blunderbuss:
+  max_request_count: ~
+`,
+		},
+		{
+			name: "unknown field",
+			yaml: `triggers:
+- repos:
+  - org
+  trusted_orgg: org
+`,
+			expectedErr: `line 4: unknown field "trusted_orgg" in triggers[0]`,
+		},
+		{
+			name: "unknown top level field",
+			yaml: `plugins: {}
+trigger: []
+`,
+			expectedErr: `line 2: unknown field "trigger" in the configuration`,
+		},
+		{
+			name: "boolean mismatch",
+			yaml: `triggers:
+- repos:
+  - org
+  skip_draft_pr: sometimes
+`,
+			expectedErr: `line 4: triggers[0].skip_draft_pr should be a boolean`,
+		},
+		{
+			name: "list mismatch",
+			yaml: `triggers:
+- repos: org
+`,
+			expectedErr: `line 2: triggers[0].repos should be a list`,
+		},
+		{
+			name: "integer mismatch",
+			yaml: `triggers:
+- repos:
+  - org
+  quotas:
+  - max: lots
+`,
+			expectedErr: `line 5: triggers[0].quotas[0].max should be an integer`,
+		},
+		{
+			name: "map values",
+			yaml: `plugins:
+  org:
+    trigger: true
+`,
+			expectedErr: `line 3: plugins.org should be a list`,
+		},
+	}
+	for _, test := range tests {
+		c := &Configuration{}
+		err := unmarshalStrict([]byte(test.yaml), c)
+		if test.expectedErr == "" {
+			if err != nil {
+				t.Errorf("%s: unexpected error: %v", test.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), test.expectedErr) {
+			t.Errorf("%s: expected error %q, got %v", test.name, test.expectedErr, err)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+// Package triage implements a plugin assigning new pull requests to their author and labeling them from the boxes
+// checked in their templates
+package triage
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse/pkg/pluginhelp"
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/sirupsen/logrus"
+)
+
+const pluginName = "triage"
+
+var (
+	checkedBoxRe   = regexp.MustCompile(`(?m)^\s*[-*]\s*\[[xX]\]\s*(.+?)\s*$`)
+	htmlCommentsRe = regexp.MustCompile(`(?s)<!--.*?-->`)
+)
+
+func init() {
+	plugins.RegisterPullRequestHandler(pluginName, handlePullRequest, helpProvider)
+}
+
+func helpProvider(config *plugins.Configuration, enabledRepos []string) (*pluginhelp.PluginHelp, error) {
+	configInfo := map[string]string{}
+	for _, repo := range enabledRepos {
+		parts := strings.Split(repo, "/")
+		if len(parts) > 2 {
+			return nil, fmt.Errorf("invalid repo in enabledRepos: %q", repo)
+		}
+		org, name := parts[0], ""
+		if len(parts) == 2 {
+			name = parts[1]
+		}
+		t := config.TriageFor(org, name)
+		info := fmt.Sprintf("The checked boxes starting with a label with the %s prefixes apply it.", strings.Join(t.Prefixes, ", "))
+		if len(t.Checkboxes) > 0 {
+			info += fmt.Sprintf(" %d other boxes apply the labels they are mapped to.", len(t.Checkboxes))
+		}
+		if !t.SkipAssignAuthor {
+			info = "New pull requests are assigned to their author. " + info
+		}
+		configInfo[repo] = info
+	}
+	// The {WhoCanUse, Usage, Examples} fields are omitted because this plugin is not triggered with commands.
+	return &pluginhelp.PluginHelp{
+			Description: "The triage plugin assigns new pull requests to their author and applies the labels of the boxes checked in their description, such as '- [x] kind/bug' in a pull request template, to feed the plugins requiring matching labels.",
+			Config:      configInfo,
+		},
+		nil
+}
+
+type scmProviderClient interface {
+	AddLabel(owner, repo string, number int, label string, pr bool) error
+	AssignIssue(owner, repo string, number int, logins []string) error
+	BotName() (string, error)
+	GetIssueLabels(org, repo string, number int, pr bool) ([]*scm.Label, error)
+	GetRepoLabels(owner, repo string) ([]*scm.Label, error)
+}
+
+func handlePullRequest(pc plugins.Agent, pre scm.PullRequestHook) error {
+	return handlePR(pc.SCMProviderClient, pc.Logger, pc.PluginConfig.TriageFor(pre.Repo.Namespace, pre.Repo.Name), pre)
+}
+
+func handlePR(spc scmProviderClient, log *logrus.Entry, config *plugins.Triage, pre scm.PullRequestHook) error {
+	// Only consider newly opened PRs
+	if pre.Action != scm.ActionOpen {
+		return nil
+	}
+	pr := pre.PullRequest
+	org := pr.Base.Repo.Namespace
+	repo := pr.Base.Repo.Name
+
+	if !config.SkipAssignAuthor {
+		if err := assignAuthor(spc, log, org, repo, pr); err != nil {
+			return err
+		}
+	}
+
+	wanted := templateLabels(config, pr.Body)
+	if len(wanted) == 0 {
+		return nil
+	}
+	repoLabels, err := spc.GetRepoLabels(org, repo)
+	if err != nil {
+		return err
+	}
+	existing := map[string]string{}
+	for _, l := range repoLabels {
+		existing[strings.ToLower(l.Name)] = l.Name
+	}
+	current, err := spc.GetIssueLabels(org, repo, pr.Number, true)
+	if err != nil {
+		return err
+	}
+	applied := map[string]bool{}
+	for _, l := range current {
+		applied[strings.ToLower(l.Name)] = true
+	}
+	var missing []string
+	for _, label := range wanted {
+		name, ok := existing[strings.ToLower(label)]
+		if !ok {
+			missing = append(missing, label)
+			continue
+		}
+		if applied[strings.ToLower(name)] {
+			continue
+		}
+		if err := spc.AddLabel(org, repo, pr.Number, name, true); err != nil {
+			return err
+		}
+	}
+	if len(missing) > 0 {
+		log.Infof("Not applying the labels %s of the template of %s/%s#%d, which do not exist.", strings.Join(missing, ", "), org, repo, pr.Number)
+	}
+	return nil
+}
+
+// assignAuthor assigns the pull request to its author, unless it is already assigned or opened by the bot
+func assignAuthor(spc scmProviderClient, log *logrus.Entry, org, repo string, pr scm.PullRequest) error {
+	author := pr.Author.Login
+	if author == "" || len(pr.Assignees) > 0 {
+		return nil
+	}
+	botName, err := spc.BotName()
+	if err != nil {
+		return err
+	}
+	if author == botName {
+		return nil
+	}
+	if err := spc.AssignIssue(org, repo, pr.Number, []string{author}); err != nil {
+		// the authors who cannot be assigned, such as outside contributors, are not an error
+		log.WithError(err).Infof("Cannot assign %s/%s#%d to its author %s.", org, repo, pr.Number, author)
+	}
+	return nil
+}
+
+// templateLabels returns the sorted labels of the boxes checked in the body, which are the labels starting the text
+// of the boxes with one of the prefixes of the config and the labels the text of the boxes is mapped to. The boxes
+// in HTML comments are ignored, as templates explain themselves in comments.
+func templateLabels(config *plugins.Triage, body string) []string {
+	checkboxes := map[string]string{}
+	for text, label := range config.Checkboxes {
+		checkboxes[strings.ToLower(strings.TrimSpace(text))] = strings.TrimSpace(label)
+	}
+	found := map[string]bool{}
+	for _, match := range checkedBoxRe.FindAllStringSubmatch(htmlCommentsRe.ReplaceAllString(body, ""), -1) {
+		text := match[1]
+		if label, ok := checkboxes[strings.ToLower(text)]; ok {
+			found[label] = true
+			continue
+		}
+		first := strings.TrimRight(strings.Fields(text)[0], ":,.")
+		for _, prefix := range config.Prefixes {
+			if strings.HasPrefix(strings.ToLower(first), strings.ToLower(prefix)+"/") && len(first) > len(prefix)+1 {
+				found[strings.ToLower(first)] = true
+			}
+		}
+	}
+	var answer []string
+	for label := range found {
+		answer = append(answer, label)
+	}
+	sort.Strings(answer)
+	return answer
+}
@@ -0,0 +1,106 @@
+package triage
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider/fake"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const template = `<!-- Check the kind of change, e.g.
+- [x] kind/example
+-->
+**Kind**
+- [x] kind/bug: fixes a bug
+- [ ] kind/feature
+- [X] Documentation
+
+**Area**
+* [x] area/api
+* [x] Area/Docs.
+- [x] status/wip
+`
+
+func TestTemplateLabels(t *testing.T) {
+	config := &plugins.Triage{
+		Prefixes:   []string{"kind", "area"},
+		Checkboxes: map[string]string{"documentation": "kind/documentation"},
+	}
+	assert.Equal(t, []string{"area/api", "area/docs", "kind/bug", "kind/documentation"}, templateLabels(config, template))
+	assert.Empty(t, templateLabels(config, "- [ ] kind/bug\n- [x] kind/"))
+}
+
+func TestHandlePR(t *testing.T) {
+	testCases := []struct {
+		name      string
+		action    scm.Action
+		author    string
+		assignees []scm.User
+		config    *plugins.Triage
+		assigned  []string
+		labels    []string
+	}{
+		{
+			name:     "new pull request",
+			action:   scm.ActionOpen,
+			author:   "author",
+			config:   &plugins.Triage{Prefixes: []string{"kind", "area"}},
+			assigned: []string{"org/repo#5:author"},
+			labels:   []string{"org/repo#5:area/api", "org/repo#5:kind/bug"},
+		},
+		{
+			name:   "edited pull request",
+			action: scm.ActionEdited,
+			author: "author",
+			config: &plugins.Triage{Prefixes: []string{"kind", "area"}},
+		},
+		{
+			name:      "already assigned",
+			action:    scm.ActionOpen,
+			author:    "author",
+			assignees: []scm.User{{Login: "someone"}},
+			config:    &plugins.Triage{Prefixes: []string{"kind"}},
+			labels:    []string{"org/repo#5:kind/bug"},
+		},
+		{
+			name:   "opened by the bot",
+			action: scm.ActionOpen,
+			author: "k8s-ci-robot",
+			config: &plugins.Triage{Prefixes: []string{"area"}},
+			labels: []string{"org/repo#5:area/api"},
+		},
+		{
+			name:   "assigning disabled",
+			action: scm.ActionOpen,
+			author: "author",
+			config: &plugins.Triage{SkipAssignAuthor: true},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			spc := &fake.SCMClient{
+				RepoLabelsExisting:        []string{"kind/bug", "area/api", "area/ui"},
+				PullRequestLabelsExisting: []string{"org/repo#5:area/ui"},
+			}
+			repo := scm.Repository{Namespace: "org", Name: "repo"}
+			pre := scm.PullRequestHook{
+				Action: tc.action,
+				Repo:   repo,
+				PullRequest: scm.PullRequest{
+					Number:    5,
+					Author:    scm.User{Login: tc.author},
+					Assignees: tc.assignees,
+					Body:      "- [x] kind/bug\n- [x] area/api\n- [x] area/ui\n- [x] area/missing",
+					Base:      scm.PullRequestBranch{Repo: repo},
+				},
+			}
+			require.NoError(t, handlePR(spc, logrus.WithField("plugin", pluginName), tc.config, pre))
+			assert.Equal(t, tc.assigned, spc.AssigneesAdded)
+			assert.ElementsMatch(t, tc.labels, spc.PullRequestLabelsAdded)
+		})
+	}
+}
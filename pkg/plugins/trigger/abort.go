@@ -0,0 +1,142 @@
+package trigger
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/jobutil"
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// handleAbort aborts the jobs running for the head of the pull request, or only those named by the /abort commands
+// of the comment. The jobs are annotated for the watchdog to stop them and their contexts are failed straight away.
+func handleAbort(c Client, trigger *plugins.Trigger, gc scmprovider.GenericCommentEvent) error {
+	org, repo, number := gc.Repo.Namespace, gc.Repo.Name, gc.Number
+	botName, err := c.SCMProviderClient.BotName()
+	if err != nil {
+		return err
+	}
+	if gc.Author.Login == botName {
+		c.Logger.Debug("Comment is made by the bot, skipping.")
+		return nil
+	}
+	respond := func(resp string) error {
+		c.Logger.Infof("Commenting \"%s\".", resp)
+		return c.SCMProviderClient.CreateComment(org, repo, number, true, plugins.FormatResponseRaw(gc.Body, gc.Link, c.SCMProviderClient.QuoteAuthorForComment(gc.Author.Login), resp))
+	}
+	if c.LighthouseClient == nil {
+		return respond("Jobs cannot be aborted as the jobs of the pull request cannot be listed.")
+	}
+
+	pr, err := c.SCMProviderClient.GetPullRequest(org, repo, number)
+	if err != nil {
+		return err
+	}
+	if c.PluginConfig != nil {
+		trigger = c.PluginConfig.TriggerForBranch(trigger, org, repo, pr.Base.Ref)
+	}
+	if scmprovider.NormLogin(pr.Author.Login) != scmprovider.NormLogin(gc.Author.Login) {
+		trusted, err := TrustedUser(c.SCMProviderClient, trigger, gc.Author.Login, org, repo)
+		if err != nil {
+			return fmt.Errorf("error checking trust of %s: %v", gc.Author.Login, err)
+		}
+		if !trusted {
+			return respond("Only the author of the pull request and the trusted users of the repository can abort its jobs.")
+		}
+	}
+
+	pendings, err := pendingJobs(c, pr)
+	if err != nil {
+		return err
+	}
+	names := abortedJobNames(gc.Body)
+	var aborted, unknown []string
+	if names.Len() == 0 {
+		for name := range pendings {
+			names.Insert(name)
+		}
+	}
+	description := fmt.Sprintf("Aborted by @%s", gc.Author.Login)
+	for _, name := range names.List() {
+		job, ok := pendings[name]
+		if !ok || !abortable(job) {
+			unknown = append(unknown, name)
+			continue
+		}
+		if err := abortJob(c, pr, job, description); err != nil {
+			c.Logger.WithError(err).WithField("job", job.Name).Error("Failed to abort the job.")
+			unknown = append(unknown, name)
+			continue
+		}
+		aborted = append(aborted, name)
+	}
+
+	var lines []string
+	if len(aborted) > 0 {
+		lines = append(lines, fmt.Sprintf("Aborted the jobs %s for commit %s.", formatJobNames(aborted), pr.Head.Sha))
+	}
+	if len(unknown) > 0 {
+		lines = append(lines, fmt.Sprintf("The jobs %s are not running for commit %s.", formatJobNames(unknown), pr.Head.Sha))
+	}
+	if len(lines) == 0 {
+		lines = append(lines, fmt.Sprintf("No job is running for commit %s.", pr.Head.Sha))
+	}
+	return respond(strings.Join(lines, "\n"))
+}
+
+// abortedJobNames returns the names of the jobs the /abort commands of the comment name, which is empty if all the
+// jobs are aborted
+func abortedJobNames(body string) sets.String {
+	names := sets.NewString()
+	for _, match := range jobutil.AbortRe.FindAllStringSubmatch(body, -1) {
+		names.Insert(strings.Fields(match[1])...)
+	}
+	return names
+}
+
+// abortable returns true if the job is run by tekton or as a pod, whose runs the watchdog can stop
+func abortable(job *v1alpha1.LighthouseJob) bool {
+	switch job.Spec.Agent {
+	case "", v1alpha1.TektonAgent, v1alpha1.KubernetesAgent:
+		return true
+	}
+	return false
+}
+
+// abortJob annotates the job for the watchdog to abort it and fails its context with the description
+func abortJob(c Client, pr *scm.PullRequest, job *v1alpha1.LighthouseJob, description string) error {
+	job = job.DeepCopy()
+	if job.Annotations == nil {
+		job.Annotations = map[string]string{}
+	}
+	job.Annotations[util.AbortAnnotation] = description
+	if _, err := c.LighthouseClient.Update(job); err != nil {
+		return err
+	}
+	if job.Spec.Context == "" {
+		return nil
+	}
+	_, err := c.SCMProviderClient.CreateStatus(pr.Base.Repo.Namespace, pr.Base.Repo.Name, pr.Head.Sha, &scm.StatusInput{
+		State:  scm.StateFailure,
+		Label:  job.Spec.Context,
+		Desc:   description,
+		Target: job.Status.ReportURL,
+	})
+	return err
+}
+
+// formatJobNames formats the names of jobs for a comment
+func formatJobNames(names []string) string {
+	sort.Strings(names)
+	quoted := make([]string, 0, len(names))
+	for _, name := range names {
+		quoted = append(quoted, "`"+name+"`")
+	}
+	return strings.Join(quoted, ", ")
+}
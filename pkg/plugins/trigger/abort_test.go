@@ -0,0 +1,127 @@
+package trigger
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	lhfake "github.com/jenkins-x/lighthouse/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/lighthouse/pkg/jobutil"
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	fake2 "github.com/jenkins-x/lighthouse/pkg/scmprovider/fake"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestHandleAbort(t *testing.T) {
+	pr := &scm.PullRequest{
+		Number: 1,
+		Author: scm.User{Login: "bob"},
+		Head:   scm.PullRequestBranch{Ref: "feature", Sha: "head"},
+		Base: scm.PullRequestBranch{
+			Ref:  "master",
+			Repo: scm.Repository{Namespace: "org", Name: "repo"},
+		},
+	}
+	existing := func(name, sha string, state v1alpha1.PipelineState) runtime.Object {
+		refs := v1alpha1.Refs{Org: "org", Repo: "repo", BaseRef: "master", Pulls: []v1alpha1.Pull{{Number: 1, SHA: sha}}}
+		job := jobutil.NewLighthouseJob(jobutil.PresubmitSpec(config.Presubmit{JobBase: config.JobBase{Name: name}, Reporter: config.Reporter{Context: name}}, refs), nil, nil)
+		job.Name = name + "-" + sha
+		job.Namespace = "jx"
+		job.Status.State = state
+		return &job
+	}
+
+	testCases := []struct {
+		name            string
+		author          string
+		body            string
+		expectedAborted []string
+		expectedComment string
+	}{
+		{
+			name:            "the author aborts all the running jobs of the head",
+			author:          "bob",
+			body:            "/abort",
+			expectedAborted: []string{"lint", "unit"},
+			expectedComment: "Aborted the jobs `lint`, `unit` for commit head.",
+		},
+		{
+			name:            "a trusted user aborts a named job",
+			author:          "alice",
+			body:            "/lh-abort unit",
+			expectedAborted: []string{"unit"},
+			expectedComment: "Aborted the jobs `unit` for commit head.",
+		},
+		{
+			name:            "jobs which are not running are reported",
+			author:          "bob",
+			body:            "/abort unit e2e",
+			expectedAborted: []string{"unit"},
+			expectedComment: "The jobs `e2e` are not running for commit head.",
+		},
+		{
+			name:            "untrusted users cannot abort jobs",
+			author:          "mallory",
+			body:            "/abort",
+			expectedComment: "Only the author of the pull request and the trusted users of the repository can abort its jobs.",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := &fake2.SCMClient{
+				PullRequests:        map[int]*scm.PullRequest{1: pr},
+				PullRequestComments: map[int][]*scm.Comment{},
+				OrgMembers:          map[string][]string{"org": {"alice"}},
+			}
+			lhClient := lhfake.NewSimpleClientset(
+				existing("unit", "head", v1alpha1.RunningState),
+				existing("lint", "head", v1alpha1.PendingState),
+				existing("e2e", "head", v1alpha1.FailureState),
+				existing("e2e", "older", v1alpha1.RunningState),
+			).LighthouseV1alpha1().LighthouseJobs("jx")
+			c := Client{
+				SCMProviderClient: g,
+				Config:            &config.Config{},
+				Logger:            logrus.WithField("plugin", PluginName),
+				LighthouseClient:  lhClient,
+			}
+			gc := scmprovider.GenericCommentEvent{
+				Action:     scm.ActionCreate,
+				IsPR:       true,
+				IssueState: "open",
+				Author:     scm.User{Login: tc.author},
+				Body:       tc.body,
+				Number:     1,
+				Repo:       scm.Repository{Namespace: "org", Name: "repo"},
+			}
+			require.NoError(t, handleGenericComment(c, &plugins.Trigger{}, gc))
+
+			jobs, err := lhClient.List(metav1.ListOptions{})
+			require.NoError(t, err)
+			var aborted []string
+			for _, job := range jobs.Items {
+				if description := job.Annotations[util.AbortAnnotation]; description != "" {
+					assert.Equal(t, "Aborted by @"+tc.author, description)
+					aborted = append(aborted, job.Spec.Job)
+				}
+			}
+			assert.ElementsMatch(t, tc.expectedAborted, aborted)
+			var failed []string
+			for _, status := range g.CreatedStatuses["head"] {
+				assert.Equal(t, scm.StateFailure, status.State)
+				failed = append(failed, status.Label)
+			}
+			assert.ElementsMatch(t, tc.expectedAborted, failed)
+
+			require.Len(t, g.PullRequestCommentsAdded, 1)
+			assert.Contains(t, g.PullRequestCommentsAdded[0], tc.expectedComment)
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package trigger
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+)
+
+// tooManyChangesCommentTag marks the comment warning that the pull request changes too many files, so that it is
+// only posted once
+const tooManyChangesCommentTag = "<!-- lighthouse-trigger-too-many-changes -->"
+
+// warnTooManyChanges comments that every job applies to the pull request as it changes more files than can be listed,
+// unless it was already commented
+func warnTooManyChanges(c Client, pr *scm.PullRequest, tooMany *scmprovider.TooManyChangesError) error {
+	org, repo, number := pr.Base.Repo.Namespace, pr.Base.Repo.Name, pr.Number
+	botName, err := c.SCMProviderClient.BotName()
+	if err != nil {
+		return err
+	}
+	comments, err := c.SCMProviderClient.ListPullRequestComments(org, repo, number)
+	if err != nil {
+		return err
+	}
+	for _, comment := range comments {
+		if comment.Author.Login == botName && strings.Contains(comment.Body, tooManyChangesCommentTag) {
+			return nil
+		}
+	}
+	comment := fmt.Sprintf(`%s
+This PR changes more than %d files, which is more than can be listed. All the jobs of the branch run whatever their `+"`run_if_changed`"+`, without build cache hints, and the jobs using the changed files are not run. Consider splitting it into smaller PRs.`,
+		tooManyChangesCommentTag, tooMany.Limit)
+	return c.SCMProviderClient.CreateComment(org, repo, number, true, comment)
+}
@@ -0,0 +1,74 @@
+package trigger
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/launcher/fake"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	fake2 "github.com/jenkins-x/lighthouse/pkg/scmprovider/fake"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTooManyChanges(t *testing.T) {
+	defer func(max int) { scmprovider.MaxChangedFiles = max }(scmprovider.MaxChangedFiles)
+	scmprovider.MaxChangedFiles = 2
+
+	pr := scm.PullRequest{
+		Number: 1,
+		Author: scm.User{Login: "bob"},
+		Head:   scm.PullRequestBranch{Ref: "feature", Sha: "sha"},
+		Base: scm.PullRequestBranch{
+			Ref:  "master",
+			Repo: scm.Repository{Namespace: "org", Name: "repo"},
+		},
+	}
+	var changes []*scm.Change
+	for i := 0; i < 3; i++ {
+		changes = append(changes, &scm.Change{Path: fmt.Sprintf("src/%d.go", i)})
+	}
+	g := &fake2.SCMClient{
+		PullRequests:        map[int]*scm.PullRequest{1: &pr},
+		PullRequestChanges:  map[int][]*scm.Change{1: changes},
+		PullRequestComments: map[int][]*scm.Comment{},
+		CreatedStatuses:     map[string][]*scm.StatusInput{},
+	}
+	fakeLauncher := fake.NewLauncher()
+	c := Client{
+		SCMProviderClient: g,
+		LauncherClient:    fakeLauncher,
+		Config:            &config.Config{},
+		Logger:            logrus.WithField("plugin", PluginName),
+	}
+	presubmits := []config.Presubmit{
+		{
+			JobBase:             config.JobBase{Name: "docs"},
+			Reporter:            config.Reporter{Context: "docs"},
+			RegexpChangeMatcher: config.RegexpChangeMatcher{RunIfChanged: "^docs/"},
+		},
+		{
+			JobBase:   config.JobBase{Name: "unit"},
+			Reporter:  config.Reporter{Context: "unit"},
+			AlwaysRun: true,
+		},
+	}
+	require.NoError(t, config.SetPresubmitRegexes(presubmits))
+	c.Config.Presubmits = map[string][]config.Presubmit{"org/repo": presubmits}
+
+	toTest, _, err := FilterPresubmits(false, g, "/test all", &pr, presubmits, c.Logger)
+	require.NoError(t, err)
+	assert.Len(t, toTest, 2, "the jobs which run if docs changed should run as the changed files are truncated")
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, runRequested(c, &pr, toTest, "guid"))
+	}
+	assert.Len(t, fakeLauncher.Pipelines, 4)
+	require.Len(t, g.PullRequestCommentsAdded, 1, "the warning should only be commented once")
+	assert.True(t, strings.Contains(g.PullRequestCommentsAdded[0], tooManyChangesCommentTag))
+	assert.True(t, strings.Contains(g.PullRequestCommentsAdded[0], "more than 2 files"))
+}
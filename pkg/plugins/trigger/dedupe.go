@@ -0,0 +1,112 @@
+package trigger
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// duplicateCommentTag marks the comment linking to the jobs which were already pending, so that only the latest one
+// is kept
+const duplicateCommentTag = "<!-- lighthouse-trigger-duplicate -->"
+
+// pendingJobs returns the presubmits which are not complete yet for the head of the pull request by their name
+func pendingJobs(c Client, pr *scm.PullRequest) (map[string]*v1alpha1.LighthouseJob, error) {
+	if c.LighthouseClient == nil {
+		return nil, nil
+	}
+	selector := labels.SelectorFromSet(labels.Set{
+		config.LighthouseJobTypeLabel: string(config.PresubmitJob),
+		util.OrgLabel:                 strings.ToLower(pr.Base.Repo.Namespace),
+		util.RepoLabel:                pr.Base.Repo.Name,
+		util.PullLabel:                strconv.Itoa(pr.Number),
+	})
+	jobs, err := c.LighthouseClient.List(metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+	answer := map[string]*v1alpha1.LighthouseJob{}
+	for i := range jobs.Items {
+		job := &jobs.Items[i]
+		refs := job.Spec.Refs
+		if refs == nil || len(refs.Pulls) == 0 || refs.Pulls[0].SHA != pr.Head.Sha || refs.BaseRef != pr.Base.Ref {
+			continue
+		}
+		if job.Status.CompletionTime != nil || !pending(job.Status.State) {
+			continue
+		}
+		answer[job.Spec.Job] = job
+	}
+	return answer, nil
+}
+
+// pending returns true if the job with the state has not finished
+func pending(state v1alpha1.PipelineState) bool {
+	switch state {
+	case "", v1alpha1.TriggeredState, v1alpha1.PendingState, v1alpha1.RunningState:
+		return true
+	}
+	return false
+}
+
+// removeDuplicates returns the requested jobs which are not already pending for the head of the pull request, and
+// the pending jobs which were requested again
+func removeDuplicates(c Client, pr *scm.PullRequest, requestedJobs []config.Presubmit) ([]config.Presubmit, []*v1alpha1.LighthouseJob) {
+	pendings, err := pendingJobs(c, pr)
+	if err != nil {
+		c.Logger.WithError(err).Warn("Failed to list the pending jobs, running the requested jobs anyway.")
+		return requestedJobs, nil
+	}
+	if len(pendings) == 0 {
+		return requestedJobs, nil
+	}
+	var toRun []config.Presubmit
+	var duplicates []*v1alpha1.LighthouseJob
+	for _, job := range requestedJobs {
+		if pj, ok := pendings[job.Name]; ok {
+			c.Logger.WithField("job", job.Name).Infof("Not starting %s as the LighthouseJob %s is pending for the same commit.", job.Name, pj.Name)
+			duplicates = append(duplicates, pj)
+			continue
+		}
+		toRun = append(toRun, job)
+	}
+	return toRun, duplicates
+}
+
+// reportDuplicates replaces the comment linking to the pending jobs which were requested again, if trigger is
+// configured to comment on them
+func reportDuplicates(c Client, pr *scm.PullRequest, duplicates []*v1alpha1.LighthouseJob) error {
+	if len(duplicates) == 0 || c.PluginConfig == nil {
+		return nil
+	}
+	org, repo, number := pr.Base.Repo.Namespace, pr.Base.Repo.Name, pr.Number
+	if !triggerForPR(c.PluginConfig, pr).CommentOnDuplicateJobs {
+		return nil
+	}
+	botName, err := c.SCMProviderClient.BotName()
+	if err != nil {
+		return err
+	}
+	isStale := func(comment *scm.Comment) bool {
+		return comment.Author.Login == botName && strings.Contains(comment.Body, duplicateCommentTag)
+	}
+	if err := c.SCMProviderClient.DeleteStaleComments(org, repo, number, nil, true, isStale); err != nil {
+		return err
+	}
+	lines := []string{duplicateCommentTag, "The following jobs were not started again as they are still running for commit " + pr.Head.Sha + ":", ""}
+	for _, pj := range duplicates {
+		if pj.Status.ReportURL != "" {
+			lines = append(lines, fmt.Sprintf("* [%s](%s)", pj.Spec.Job, pj.Status.ReportURL))
+		} else {
+			lines = append(lines, "* "+pj.Spec.Job)
+		}
+	}
+	return c.SCMProviderClient.CreateComment(org, repo, number, true, strings.Join(lines, "\n"))
+}
@@ -0,0 +1,113 @@
+package trigger
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	lhfake "github.com/jenkins-x/lighthouse/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/lighthouse/pkg/jobutil"
+	"github.com/jenkins-x/lighthouse/pkg/launcher/fake"
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	fake2 "github.com/jenkins-x/lighthouse/pkg/scmprovider/fake"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestRunRequestedSkipsPendingJobs(t *testing.T) {
+	pr := &scm.PullRequest{
+		Number: 1,
+		Author: scm.User{Login: "bob"},
+		Head:   scm.PullRequestBranch{Ref: "feature", Sha: "head"},
+		Base: scm.PullRequestBranch{
+			Ref:  "master",
+			Repo: scm.Repository{Namespace: "org", Name: "repo"},
+		},
+	}
+	existing := func(name, sha string, state v1alpha1.PipelineState) runtime.Object {
+		refs := v1alpha1.Refs{Org: "org", Repo: "repo", BaseRef: "master", Pulls: []v1alpha1.Pull{{Number: 1, SHA: sha}}}
+		job := jobutil.NewLighthouseJob(jobutil.PresubmitSpec(config.Presubmit{JobBase: config.JobBase{Name: name}}, refs), nil, nil)
+		job.Namespace = "jx"
+		job.Status.State = state
+		job.Status.ReportURL = "https://dashboard/" + name
+		return &job
+	}
+
+	testCases := []struct {
+		name            string
+		jobs            []runtime.Object
+		comment         bool
+		expectedStarted []string
+		expectedLinks   []string
+	}{
+		{
+			name:            "no pending jobs",
+			expectedStarted: []string{"lint", "unit"},
+		},
+		{
+			name: "pending job of the head is not started again",
+			jobs: []runtime.Object{
+				existing("unit", "head", v1alpha1.RunningState),
+			},
+			expectedStarted: []string{"lint"},
+		},
+		{
+			name: "completed jobs and jobs of older commits are started again",
+			jobs: []runtime.Object{
+				existing("unit", "head", v1alpha1.FailureState),
+				existing("lint", "older", v1alpha1.PendingState),
+			},
+			expectedStarted: []string{"lint", "unit"},
+		},
+		{
+			name: "links to the pending jobs are commented",
+			jobs: []runtime.Object{
+				existing("unit", "head", v1alpha1.PendingState),
+				existing("lint", "head", v1alpha1.TriggeredState),
+			},
+			comment:       true,
+			expectedLinks: []string{"https://dashboard/unit", "https://dashboard/lint"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := &fake2.SCMClient{PullRequestComments: map[int][]*scm.Comment{}}
+			fakeLauncher := fake.NewLauncher()
+			c := Client{
+				SCMProviderClient: g,
+				LauncherClient:    fakeLauncher,
+				Config:            &config.Config{},
+				Logger:            logrus.WithField("plugin", PluginName),
+				PluginConfig: &plugins.Configuration{
+					Triggers: []plugins.Trigger{{Repos: []string{"org"}, CommentOnDuplicateJobs: tc.comment}},
+				},
+				LighthouseClient: lhfake.NewSimpleClientset(tc.jobs...).LighthouseV1alpha1().LighthouseJobs("jx"),
+			}
+			jobs := []config.Presubmit{
+				{JobBase: config.JobBase{Name: "lint"}, Reporter: config.Reporter{Context: "lint"}},
+				{JobBase: config.JobBase{Name: "unit"}, Reporter: config.Reporter{Context: "unit"}},
+			}
+			require.NoError(t, runRequested(c, pr, jobs, "guid"))
+
+			var started []string
+			for _, job := range fakeLauncher.Pipelines {
+				started = append(started, job.Spec.Job)
+			}
+			assert.ElementsMatch(t, tc.expectedStarted, started)
+			if len(tc.expectedLinks) == 0 {
+				assert.Empty(t, g.PullRequestCommentsAdded)
+				return
+			}
+			require.Len(t, g.PullRequestCommentsAdded, 1)
+			comment := g.PullRequestCommentsAdded[0]
+			assert.True(t, strings.Contains(comment, duplicateCommentTag), comment)
+			for _, link := range tc.expectedLinks {
+				assert.Contains(t, comment, link)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package trigger
+
+import (
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse/pkg/errorutil"
+	"github.com/jenkins-x/lighthouse/pkg/labels"
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+)
+
+// dependencyUpdateFor returns the dependency update profile of the author of the PR, or nil if the author is not a
+// dependency update bot of the repo
+func dependencyUpdateFor(c Client, pr *scm.PullRequest) *plugins.DependencyUpdate {
+	if c.PluginConfig == nil {
+		return nil
+	}
+	org, repo, author := orgRepoAuthor(*pr)
+	return c.PluginConfig.DependencyUpdateFor(org, repo, string(author))
+}
+
+// labelDependencyUpdate labels the PR of a dependency update bot ok-to-test, so that its new commits are tested, and
+// dependency-update, so that keeper only requires the presubmits of the profile and its queries can match the PR
+func labelDependencyUpdate(c Client, pr *scm.PullRequest) error {
+	org, repo, number := pr.Base.Repo.Namespace, pr.Base.Repo.Name, pr.Number
+	l, err := c.SCMProviderClient.GetIssueLabels(org, repo, number, true)
+	if err != nil {
+		return err
+	}
+	var errors []error
+	for _, label := range []string{labels.OkToTest, labels.DependencyUpdate} {
+		if !scmprovider.HasLabel(label, l) {
+			if err := c.SCMProviderClient.AddLabel(org, repo, number, label, true); err != nil {
+				errors = append(errors, err)
+			}
+		}
+	}
+	if scmprovider.HasLabel(labels.NeedsOkToTest, l) {
+		if err := c.SCMProviderClient.RemoveLabel(org, repo, number, labels.NeedsOkToTest, true); err != nil {
+			errors = append(errors, err)
+		}
+	}
+	return errorutil.NewAggregate(errors...)
+}
@@ -0,0 +1,103 @@
+package trigger
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/labels"
+	"github.com/jenkins-x/lighthouse/pkg/launcher/fake"
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	fake2 "github.com/jenkins-x/lighthouse/pkg/scmprovider/fake"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDependencyUpdates(t *testing.T) {
+	testCases := []struct {
+		name           string
+		action         scm.Action
+		author         string
+		existingLabels []string
+		expectedAdded  []string
+		expectedJobs   []string
+	}{
+		{
+			name:          "opened by a dependency update bot",
+			action:        scm.ActionOpen,
+			author:        "dependabot[bot]",
+			expectedAdded: issueLabels(labels.OkToTest, labels.DependencyUpdate),
+			expectedJobs:  []string{"unit"},
+		},
+		{
+			name:           "updated by a dependency update bot",
+			action:         scm.ActionSync,
+			author:         "dependabot[bot]",
+			existingLabels: issueLabels(labels.OkToTest, labels.DependencyUpdate),
+			expectedJobs:   []string{"unit"},
+		},
+		{
+			name:          "opened by an untrusted user",
+			action:        scm.ActionOpen,
+			author:        "u",
+			expectedAdded: issueLabels(labels.NeedsOkToTest),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := &fake2.SCMClient{
+				PullRequestComments:       map[int][]*scm.Comment{},
+				OrgMembers:                map[string][]string{"org": {"t"}},
+				PullRequestLabelsExisting: tc.existingLabels,
+				CreatedStatuses:           map[string][]*scm.StatusInput{},
+			}
+			fakeLauncher := fake.NewLauncher()
+			trigger := plugins.Trigger{Repos: []string{"org"}, ElideSkippedContexts: true}
+			c := Client{
+				SCMProviderClient: g,
+				LauncherClient:    fakeLauncher,
+				Config:            &config.Config{},
+				Logger:            logrus.WithField("plugin", PluginName),
+				PluginConfig: &plugins.Configuration{
+					Triggers: []plugins.Trigger{trigger},
+					DependencyUpdates: []plugins.DependencyUpdate{
+						{Repos: []string{"org/repo"}, Authors: []string{"dependabot[bot]"}, Presubmits: []string{"unit"}},
+					},
+				},
+			}
+			presubmits := map[string][]config.Presubmit{
+				"org/repo": {
+					{JobBase: config.JobBase{Name: "unit"}, Reporter: config.Reporter{Context: "unit"}, AlwaysRun: true},
+					{JobBase: config.JobBase{Name: "e2e"}, Reporter: config.Reporter{Context: "e2e"}, AlwaysRun: true},
+				},
+			}
+			require.NoError(t, c.Config.SetPresubmits(presubmits))
+			pr := scm.PullRequestHook{
+				Action: tc.action,
+				PullRequest: scm.PullRequest{
+					Author: scm.User{Login: tc.author},
+					Head:   scm.PullRequestBranch{Ref: "feature", Sha: "sha"},
+					Base: scm.PullRequestBranch{
+						Ref:  "master",
+						Repo: scm.Repository{Namespace: "org", Name: "repo", FullName: "org/repo"},
+					},
+				},
+			}
+			require.NoError(t, handlePR(c, &trigger, pr))
+
+			assert.Equal(t, tc.expectedAdded, g.PullRequestLabelsAdded)
+			var jobs []string
+			for _, job := range fakeLauncher.Pipelines {
+				jobs = append(jobs, job.Spec.Job)
+			}
+			assert.Equal(t, tc.expectedJobs, jobs)
+			if len(tc.expectedJobs) > 0 {
+				skipped := g.CreatedStatuses["feature"]
+				require.Len(t, skipped, 1, "the presubmits left out by the profile are reported as skipped even if elided")
+				assert.Equal(t, "e2e", skipped[0].Label)
+				assert.Equal(t, scm.StateSuccess, skipped[0].State)
+			}
+		})
+	}
+}
@@ -18,6 +18,7 @@ package trigger
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/jenkins-x/go-scm/scm"
 	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
@@ -27,6 +28,8 @@ import (
 	"github.com/jenkins-x/lighthouse/pkg/jobutil"
 	"github.com/jenkins-x/lighthouse/pkg/labels"
 	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/jenkins-x/lighthouse/pkg/requiredjobs"
+	"github.com/jenkins-x/lighthouse/pkg/util"
 	"k8s.io/apimachinery/pkg/util/sets"
 )
 
@@ -36,24 +39,36 @@ func handleGenericComment(c Client, trigger *plugins.Trigger, gc scmprovider.Gen
 	number := gc.Number
 	commentAuthor := gc.Author.Login
 	// Only take action when a comment is first created,
-	// when it belongs to a PR,
-	// and the PR is open.
-	if gc.Action != scm.ActionCreate || !gc.IsPR || gc.IssueState != "open" {
+	// and the PR or issue is open.
+	if gc.Action != scm.ActionCreate || gc.IssueState != "open" {
 		return nil
 	}
+	// Comments on issues only run the jobs triggered by issue commands.
+	if !gc.IsPR {
+		return handleIssueCommand(c, trigger, gc)
+	}
+	if jobutil.AbortRe.MatchString(gc.Body) {
+		if err := handleAbort(c, trigger, gc); err != nil {
+			return err
+		}
+	}
+	testTrusted := trigger.IgnoreOkToTest && jobutil.TestTrustedRe.MatchString(gc.Body)
+	unknown := unknownJobs(c.Config.GetPresubmits(gc.Repo), gc.Body)
 	// Skip comments not germane to this plugin
-	if !jobutil.RetestRe.MatchString(gc.Body) && !jobutil.OkToTestRe.MatchString(gc.Body) && !jobutil.TestAllRe.MatchString(gc.Body) {
+	onlyUnknown := false
+	if !testTrusted && !jobutil.RetestRe.MatchString(gc.Body) && !jobutil.OkToTestRe.MatchString(gc.Body) && !jobutil.TestAllRe.MatchString(gc.Body) {
 		matched := false
 		for _, presubmit := range c.Config.GetPresubmits(gc.Repo) {
-			matched = matched || presubmit.TriggerMatches(gc.Body)
+			matched = matched || presubmit.TriggerMatches(util.TrimCommandPrefix(gc.Body))
 			if matched {
 				break
 			}
 		}
-		if !matched {
+		if !matched && len(unknown) == 0 {
 			c.Logger.Debug("Comment doesn't match any triggering regex, skipping.")
 			return nil
 		}
+		onlyUnknown = !matched
 	}
 
 	// Skip bot comments.
@@ -66,10 +81,39 @@ func handleGenericComment(c Client, trigger *plugins.Trigger, gc scmprovider.Gen
 		return nil
 	}
 
+	if len(unknown) > 0 {
+		if err := reportUnknownJobs(c, gc, c.Config.GetPresubmits(gc.Repo), unknown); err != nil {
+			return err
+		}
+		if onlyUnknown {
+			return nil
+		}
+	}
+
+	// Skip the test commands of users who commented too many of them.
+	if limit := trigger.CommandRateLimit; limit != nil && isTestCommand(c, gc) {
+		allowed, retryAt, notify := allowCommand(c, limit, commandLimitKey(org, repo, number, commentAuthor))
+		if !allowed {
+			c.Logger.Infof("Ignoring the command of %s who reached the limit of %d commands per %s.", commentAuthor, limit.Max, limit.WindowDuration)
+			if !notify {
+				return nil
+			}
+			resp := fmt.Sprintf("You already commented %d test commands on this PR in the last %s, which is the most allowed. You can run `%s` or `%s` again after %s.", limit.Max, limit.WindowDuration, util.FormatCommand("/test"), util.FormatCommand("/retest"), retryAt.UTC().Format(time.RFC1123))
+			return c.SCMProviderClient.CreateComment(org, repo, number, true, plugins.FormatResponseRaw(gc.Body, gc.Link, c.SCMProviderClient.QuoteAuthorForComment(commentAuthor), resp))
+		}
+	}
+
 	pr, err := c.SCMProviderClient.GetPullRequest(org, repo, number)
 	if err != nil {
 		return err
 	}
+	if c.PluginConfig != nil {
+		trigger = c.PluginConfig.TriggerForBranch(trigger, org, repo, pr.Base.Ref)
+	}
+
+	if testTrusted {
+		return handleTestTrusted(c, trigger, gc, pr)
+	}
 
 	// Skip untrusted users comments.
 	trusted, err := TrustedUser(c.SCMProviderClient, trigger, commentAuthor, org, repo)
@@ -84,7 +128,7 @@ func handleGenericComment(c Client, trigger *plugins.Trigger, gc scmprovider.Gen
 			return err
 		}
 		if !trusted {
-			resp := fmt.Sprintf("Cannot trigger testing until a trusted user reviews the PR and leaves an `/ok-to-test` message.")
+			resp := fmt.Sprintf("Cannot trigger testing until a trusted user reviews the PR and leaves an `%s` message.", util.FormatCommand("/ok-to-test"))
 			c.Logger.Infof("Commenting \"%s\".", resp)
 			return c.SCMProviderClient.CreateComment(org, repo, number, true, plugins.FormatResponseRaw(gc.Body, gc.Link, c.SCMProviderClient.QuoteAuthorForComment(gc.Author.Login), resp))
 		}
@@ -111,11 +155,45 @@ func handleGenericComment(c Client, trigger *plugins.Trigger, gc scmprovider.Gen
 		}
 	}
 
-	toTest, toSkip, err := FilterPresubmits(HonorOkToTest(trigger), c.SCMProviderClient, gc.Body, pr, c.Config.GetPresubmits(gc.Repo), c.Logger)
+	presubmits := presubmitsFor(c, gc.Repo)
+	if excluded := excludedJobs(presubmits, gc.Body, pr.Base.Ref); len(excluded) > 0 {
+		if err := reportExcludedJobs(c, gc, pr.Base.Ref, excluded); err != nil {
+			return err
+		}
+	}
+	toTest, toSkip, err := FilterPresubmits(HonorOkToTest(trigger), c.SCMProviderClient, gc.Body, pr, presubmits, c.Logger)
 	if err != nil {
 		return err
 	}
-	return RunAndSkipJobs(c, pr, toTest, toSkip, gc.GUID, trigger.ElideSkippedContexts)
+	var denied []config.Presubmit
+	toTest, denied = filterRerunAuthorized(c, org, commentAuthor, toTest)
+	if len(denied) > 0 {
+		resp := rerunDeniedResponse(denied)
+		c.Logger.Infof("Commenting \"%s\".", resp)
+		if err := c.SCMProviderClient.CreateComment(org, repo, number, true, plugins.FormatResponseRaw(gc.Body, gc.Link, c.SCMProviderClient.QuoteAuthorForComment(commentAuthor), resp)); err != nil {
+			return err
+		}
+	}
+	if err := RunAndSkipJobs(c, pr, toTest, toSkip, gc.GUID, trigger.ElideSkippedContexts); err != nil {
+		return err
+	}
+	if len(toTest) > 0 {
+		plugins.React(c.SCMProviderClient, c.Logger, gc, scmprovider.ReactionStarted)
+	}
+	return nil
+}
+
+// isTestCommand returns true if the comment asks to run jobs with /test or /retest, rather than only with /ok-to-test
+func isTestCommand(c Client, gc scmprovider.GenericCommentEvent) bool {
+	if jobutil.RetestRe.MatchString(gc.Body) || jobutil.TestAllRe.MatchString(gc.Body) || jobutil.TestTrustedRe.MatchString(gc.Body) {
+		return true
+	}
+	for _, presubmit := range c.Config.GetPresubmits(gc.Repo) {
+		if presubmit.TriggerMatches(util.TrimCommandPrefix(gc.Body)) {
+			return true
+		}
+	}
+	return false
 }
 
 // HonorOkToTest checks if shoudn't ignore the ok test
@@ -162,7 +240,7 @@ func FilterPresubmits(honorOkToTest bool, scmClient SCMProviderClient, body stri
 	}
 
 	number, branch := pr.Number, pr.Base.Ref
-	changes := config.NewGitHubDeferredChangedFilesProvider(scmClient, org, repo, number)
+	changes := requiredjobs.ChangedFiles(scmClient, org, repo, number)
 	return jobutil.FilterPresubmits(filter, changes, branch, presubmits, logger)
 }
 
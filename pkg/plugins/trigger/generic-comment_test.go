@@ -30,6 +30,7 @@ import (
 	"github.com/jenkins-x/lighthouse/pkg/plugins"
 	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
 	fake2 "github.com/jenkins-x/lighthouse/pkg/scmprovider/fake"
+	"github.com/jenkins-x/lighthouse/pkg/util"
 	"github.com/sirupsen/logrus"
 	"k8s.io/apimachinery/pkg/util/sets"
 )
@@ -196,6 +197,84 @@ func TestHandleGenericComment(t *testing.T) {
 			ShouldBuild: true,
 			AddedLabels: issueLabels(labels.OkToTest),
 		},
+		{
+			name: "Trusted member's ok to test does not run the presubmits restricted to other users",
+
+			Author:        "trusted-member",
+			Body:          "/ok-to-test",
+			State:         "open",
+			IsPR:          true,
+			ShouldBuild:   true,
+			StartsExactly: "pull-job",
+			AddedLabels:   issueLabels(labels.OkToTest),
+			Presubmits: map[string][]config.Presubmit{
+				"org/repo": {
+					{
+						JobBase:   config.JobBase{Name: "job"},
+						AlwaysRun: true,
+						Reporter:  config.Reporter{Context: "pull-job"},
+					},
+					{
+						JobBase: config.JobBase{
+							Name:        "deploy",
+							Annotations: map[string]string{util.RerunAuthConfigAnnotation: "alice"},
+						},
+						AlwaysRun: true,
+						Reporter:  config.Reporter{Context: "pull-deploy"},
+					},
+				},
+			},
+		},
+		{
+			name: "Trusted member's test all does not run the presubmits restricted to other users",
+
+			Author:        "trusted-member",
+			Body:          "/test all",
+			State:         "open",
+			IsPR:          true,
+			ShouldBuild:   true,
+			StartsExactly: "pull-job",
+			Presubmits: map[string][]config.Presubmit{
+				"org/repo": {
+					{
+						JobBase:   config.JobBase{Name: "job"},
+						AlwaysRun: true,
+						Reporter:  config.Reporter{Context: "pull-job"},
+					},
+					{
+						JobBase: config.JobBase{
+							Name:        "deploy",
+							Annotations: map[string]string{util.RerunAuthConfigAnnotation: "alice"},
+						},
+						AlwaysRun: true,
+						Reporter:  config.Reporter{Context: "pull-deploy"},
+					},
+				},
+			},
+		},
+		{
+			name: "Trusted member's ok to test runs the presubmits restricted to the member",
+
+			Author:        "trusted-member",
+			Body:          "/ok-to-test",
+			State:         "open",
+			IsPR:          true,
+			ShouldBuild:   true,
+			StartsExactly: "pull-deploy",
+			AddedLabels:   issueLabels(labels.OkToTest),
+			Presubmits: map[string][]config.Presubmit{
+				"org/repo": {
+					{
+						JobBase: config.JobBase{
+							Name:        "deploy",
+							Annotations: map[string]string{util.RerunAuthConfigAnnotation: "alice, trusted-member"},
+						},
+						AlwaysRun: true,
+						Reporter:  config.Reporter{Context: "pull-deploy"},
+					},
+				},
+			},
+		},
 		{
 			name: "Trusted member's ok to test, trailing space.",
 
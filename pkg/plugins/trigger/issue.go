@@ -0,0 +1,122 @@
+package trigger
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/jobutil"
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"github.com/pkg/errors"
+)
+
+// issueJob is a postsubmit matching the command of an issue comment, with the branch to run it against
+type issueJob struct {
+	postsubmit config.Postsubmit
+	command    string
+	branch     string
+}
+
+// handleIssueCommand runs the postsubmits whose IssueTriggerAnnotation matches a command of a trusted user's comment
+// on an issue. The jobs run against the head of the branch named by the command, or of the default branch, and
+// report their result as a comment on the issue rather than as a commit status.
+func handleIssueCommand(c Client, trigger *plugins.Trigger, gc scmprovider.GenericCommentEvent) error {
+	org := gc.Repo.Namespace
+	repo := gc.Repo.Name
+	jobs := issueJobs(c.Config.GetPostsubmits(gc.Repo), util.TrimCommandPrefix(gc.Body), gc.Repo.Branch)
+	if len(jobs) == 0 {
+		return nil
+	}
+
+	// Skip bot comments.
+	botName, err := c.SCMProviderClient.BotName()
+	if err != nil {
+		return err
+	}
+	if gc.Author.Login == botName {
+		c.Logger.Debug("Comment is made by the bot, skipping.")
+		return nil
+	}
+
+	trusted, err := TrustedUser(c.SCMProviderClient, trigger, gc.Author.Login, org, repo)
+	if err != nil {
+		return fmt.Errorf("error checking trust of %s: %v", gc.Author.Login, err)
+	}
+	if !trusted {
+		resp := "Only the trusted users of the repository can run jobs from issues."
+		c.Logger.Infof("Commenting \"%s\".", resp)
+		return c.SCMProviderClient.CreateComment(org, repo, gc.Number, false, plugins.FormatResponseRaw(gc.Body, gc.Link, c.SCMProviderClient.QuoteAuthorForComment(gc.Author.Login), resp))
+	}
+
+	var errs []string
+	for _, j := range jobs {
+		if !jobutil.RefMatches(j.postsubmit.Brancher, "refs/heads/"+j.branch) {
+			errs = append(errs, fmt.Sprintf("The job `%s` does not run on the branch `%s`.", j.postsubmit.Name, j.branch))
+			continue
+		}
+		sha, err := c.SCMProviderClient.GetRef(org, repo, "heads/"+j.branch)
+		if err != nil {
+			c.Logger.WithError(err).Warnf("failed to get the head of %s", j.branch)
+			errs = append(errs, fmt.Sprintf("The job `%s` cannot run as the branch `%s` was not found.", j.postsubmit.Name, j.branch))
+			continue
+		}
+		refs := v1alpha1.Refs{
+			Org:      org,
+			Repo:     repo,
+			RepoLink: gc.Repo.Link,
+			BaseRef:  j.branch,
+			BaseSHA:  sha,
+			CloneURI: gc.Repo.Clone,
+		}
+		labels := make(map[string]string)
+		for k, v := range j.postsubmit.Labels {
+			labels[k] = v
+		}
+		labels[scmprovider.EventGUID] = gc.GUID
+		spec := jobutil.PostsubmitSpec(j.postsubmit, refs)
+		spec.IssueNumber = gc.Number
+		spec.IssueCommand = j.command
+		pj := jobutil.NewLighthouseJob(spec, labels, j.postsubmit.Annotations)
+		c.Logger.WithFields(jobutil.LighthouseJobFields(&pj)).Infof("Creating a new LighthouseJob for %q on issue %d.", j.command, gc.Number)
+		if _, err := c.LauncherClient.Launch(&pj, c.MetapipelineClient, gc.Repo); err != nil {
+			return errors.Wrapf(err, "launching the job %s", j.postsubmit.Name)
+		}
+	}
+	if len(errs) < len(jobs) {
+		plugins.React(c.SCMProviderClient, c.Logger, gc, scmprovider.ReactionStarted)
+	}
+	if len(errs) > 0 {
+		return c.SCMProviderClient.CreateComment(org, repo, gc.Number, false, plugins.FormatResponseRaw(gc.Body, gc.Link, c.SCMProviderClient.QuoteAuthorForComment(gc.Author.Login), strings.Join(errs, "\n")))
+	}
+	return nil
+}
+
+// issueJobs returns the postsubmits triggered by the commands of the comment, with the branch they run against which
+// is the named group "branch" of their expression or the default branch
+func issueJobs(postsubmits []config.Postsubmit, body, defaultBranch string) []issueJob {
+	if defaultBranch == "" {
+		defaultBranch = "master"
+	}
+	var jobs []issueJob
+	for _, j := range postsubmits {
+		re := jobutil.IssueTrigger(j.JobBase)
+		if re == nil {
+			continue
+		}
+		match := re.FindStringSubmatch(body)
+		if match == nil {
+			continue
+		}
+		job := issueJob{postsubmit: j, command: strings.TrimSpace(match[0]), branch: defaultBranch}
+		for i, name := range re.SubexpNames() {
+			if name == "branch" && match[i] != "" {
+				job.branch = match[i]
+			}
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs
+}
@@ -0,0 +1,167 @@
+package trigger
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/launcher/fake"
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	fake2 "github.com/jenkins-x/lighthouse/pkg/scmprovider/fake"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleIssueCommand(t *testing.T) {
+	testCases := []struct {
+		name     string
+		author   string
+		body     string
+		isPR     bool
+		expected []string
+		branch   string
+		comments int
+	}{
+		{
+			name:     "release from the default branch",
+			author:   "trusted-member",
+			body:     "/release patch",
+			expected: []string{"release"},
+			branch:   "main",
+		},
+		{
+			name:     "benchmark of a branch",
+			author:   "trusted-member",
+			body:     "Let's see how it performs\n/benchmark feature-1",
+			expected: []string{"benchmark"},
+			branch:   "feature-1",
+		},
+		{
+			name:     "command the job does not accept",
+			author:   "trusted-member",
+			body:     "/release huge",
+			expected: nil,
+		},
+		{
+			name:     "untrusted user",
+			author:   "random-user",
+			body:     "/release patch",
+			expected: nil,
+			comments: 1,
+		},
+		{
+			name:     "branch the job does not run on",
+			author:   "trusted-member",
+			body:     "/release-branch develop",
+			expected: nil,
+			comments: 1,
+		},
+		{
+			name:     "pull request",
+			author:   "trusted-member",
+			body:     "/release patch",
+			isPR:     true,
+			expected: nil,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := &fake2.SCMClient{
+				IssueComments:       map[int][]*scm.Comment{},
+				PullRequestComments: map[int][]*scm.Comment{},
+				OrgMembers:          map[string][]string{"org": {"trusted-member"}},
+				PullRequests:        map[int]*scm.PullRequest{5: {Number: 5, Base: scm.PullRequestBranch{Ref: "main"}}},
+			}
+			fakeLauncher := fake.NewLauncher()
+			c := Client{
+				SCMProviderClient: g,
+				LauncherClient:    fakeLauncher,
+				Config:            &config.Config{ProwConfig: config.ProwConfig{LighthouseJobNamespace: "lighthouseJobs"}},
+				Logger:            logrus.WithField("plugin", PluginName),
+			}
+			postsubmits := map[string][]config.Postsubmit{
+				"org/repo": {
+					{
+						JobBase: config.JobBase{Name: "build"},
+					},
+					{
+						JobBase: config.JobBase{
+							Name:        "release",
+							Annotations: map[string]string{util.IssueTriggerAnnotation: `(?m)^/release (patch|minor|major)\s*$`},
+						},
+					},
+					{
+						JobBase: config.JobBase{
+							Name:        "benchmark",
+							Annotations: map[string]string{util.IssueTriggerAnnotation: `(?m)^/benchmark (?P<branch>\S+)\s*$`},
+						},
+					},
+					{
+						JobBase: config.JobBase{
+							Name:        "release-branch",
+							Annotations: map[string]string{util.IssueTriggerAnnotation: `(?m)^/release-branch (?P<branch>\S+)\s*$`},
+						},
+						Brancher: config.Brancher{Branches: []string{"main", "release-.*"}},
+					},
+				},
+			}
+			require.NoError(t, c.Config.SetPostsubmits(postsubmits))
+			event := scmprovider.GenericCommentEvent{
+				Action:     scm.ActionCreate,
+				Repo:       scm.Repository{Namespace: "org", Name: "repo", FullName: "org/repo", Branch: "main"},
+				Body:       tc.body,
+				Author:     scm.User{Login: tc.author},
+				IssueState: "open",
+				IsPR:       tc.isPR,
+				Number:     5,
+			}
+			require.NoError(t, handleGenericComment(c, &plugins.Trigger{}, event))
+
+			var started []string
+			for _, job := range fakeLauncher.Pipelines {
+				started = append(started, job.Spec.Job)
+				assert.Equal(t, config.PostsubmitJob, job.Spec.Type)
+				assert.Equal(t, tc.branch, job.Spec.Refs.BaseRef)
+				assert.Equal(t, fake2.TestRef, job.Spec.Refs.BaseSHA)
+				assert.Equal(t, 5, job.Spec.IssueNumber)
+				assert.Contains(t, tc.body, job.Spec.IssueCommand)
+			}
+			assert.Equal(t, tc.expected, started)
+			assert.Len(t, g.IssueCommentsAdded, tc.comments)
+		})
+	}
+}
+
+func TestHandlePESkipsJobsTriggeredByIssues(t *testing.T) {
+	fakeLauncher := fake.NewLauncher()
+	c := Client{
+		SCMProviderClient: &fake2.SCMClient{},
+		LauncherClient:    fakeLauncher,
+		Config:            &config.Config{ProwConfig: config.ProwConfig{LighthouseJobNamespace: "lighthouseJobs"}},
+		Logger:            logrus.WithField("plugin", PluginName),
+	}
+	postsubmits := map[string][]config.Postsubmit{
+		"org/repo": {
+			{
+				JobBase: config.JobBase{Name: "build"},
+			},
+			{
+				JobBase: config.JobBase{
+					Name:        "release",
+					Annotations: map[string]string{util.IssueTriggerAnnotation: `^/release$`},
+				},
+			},
+		},
+	}
+	require.NoError(t, c.Config.SetPostsubmits(postsubmits))
+	pe := scm.PushHook{Ref: "refs/heads/master", After: "abc", Repo: scm.Repository{Namespace: "org", Name: "repo", FullName: "org/repo"}}
+	require.NoError(t, handlePE(c, pe))
+
+	require.Len(t, fakeLauncher.Pipelines, 1)
+	for _, job := range fakeLauncher.Pipelines {
+		assert.Equal(t, "build", job.Spec.Job)
+	}
+}
@@ -26,7 +26,9 @@ import (
 	"github.com/jenkins-x/lighthouse/pkg/jobutil"
 	"github.com/jenkins-x/lighthouse/pkg/labels"
 	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/jenkins-x/lighthouse/pkg/requiredjobs"
 	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	"github.com/jenkins-x/lighthouse/pkg/util"
 )
 
 func handlePR(c Client, trigger *plugins.Trigger, pr scm.PullRequestHook) error {
@@ -42,13 +44,26 @@ func handlePR(c Client, trigger *plugins.Trigger, pr scm.PullRequestHook) error
 		// When a PR is opened, if the author is in the org then build it.
 		// Otherwise, ask for "/ok-to-test". There's no need to look for previous
 		// "/ok-to-test" comments since the PR was just opened!
+		if update := dependencyUpdateFor(c, &pr.PullRequest); update != nil {
+			if err := labelDependencyUpdate(c, &pr.PullRequest); err != nil {
+				return fmt.Errorf("could not label the dependency update PR: %v", err)
+			}
+			if skipDraft(c, trigger, &pr.PullRequest) {
+				return nil
+			}
+			c.Logger.Infof("Author %q is a dependency update bot, Starting its jobs for new PR.", author)
+			return buildAll(c, &pr.PullRequest, pr.GUID, trigger.ElideSkippedContexts, "")
+		}
 		member, err := TrustedUser(c.SCMProviderClient, trigger, author, org, repo)
 		if err != nil {
 			return fmt.Errorf("could not check membership: %s", err)
 		}
 		if member {
+			if skipDraft(c, trigger, &pr.PullRequest) {
+				return nil
+			}
 			c.Logger.Infof("Author %q is a member, Starting all jobs for new PR.", author)
-			return buildAll(c, &pr.PullRequest, pr.GUID, trigger.ElideSkippedContexts)
+			return buildAll(c, &pr.PullRequest, pr.GUID, trigger.ElideSkippedContexts, "")
 		}
 		c.Logger.Infof("Author is not a member, Welcome message to PR author %q.", author)
 		if err := welcomeMsg(c.SCMProviderClient, trigger, pr.PullRequest); err != nil {
@@ -68,8 +83,11 @@ func handlePR(c Client, trigger *plugins.Trigger, pr scm.PullRequestHook) error
 					return err
 				}
 			}
+			if skipDraft(c, trigger, &pr.PullRequest) {
+				return nil
+			}
 			c.Logger.Info("Starting all jobs for updated PR.")
-			return buildAll(c, &pr.PullRequest, pr.GUID, trigger.ElideSkippedContexts)
+			return buildAll(c, &pr.PullRequest, pr.GUID, trigger.ElideSkippedContexts, "")
 		}
 	case scm.ActionEdited, scm.ActionUpdate:
 		// if someone changes the base of their PR, we will get this
@@ -82,7 +100,21 @@ func handlePR(c Client, trigger *plugins.Trigger, pr scm.PullRequestHook) error
 		}
 	case scm.ActionSync:
 		return buildAllIfTrusted(c, trigger, pr)
+	case scm.ActionReadyForReview:
+		// run the presubmits which were skipped while the PR was a draft
+		if trigger.SkipDraftPR {
+			return buildAllIfTrusted(c, trigger, pr)
+		}
 	case scm.ActionLabel:
+		if pr.Label.Name == labels.SkipCI {
+			return handleSkipCILabel(c, pr)
+		}
+		if trigger.TrustedLabel != "" && pr.Label.Name == trigger.TrustedLabel {
+			return handleTrustedLabel(c, trigger, pr)
+		}
+		if HonorOkToTest(trigger) && pr.Label.Name == labels.OkToTest {
+			return handleTrustedLabel(c, trigger, pr)
+		}
 		// When a PR is LGTMd, if it is untrusted then build it once.
 		if pr.Label.Name == labels.LGTM {
 			_, trusted, err := TrustedPullRequest(c.SCMProviderClient, trigger, author, org, repo, num, nil)
@@ -90,15 +122,84 @@ func handlePR(c Client, trigger *plugins.Trigger, pr scm.PullRequestHook) error
 				return fmt.Errorf("could not validate PR: %s", err)
 			} else if !trusted {
 				c.Logger.Info("Starting all jobs for untrusted PR with LGTM.")
-				return buildAll(c, &pr.PullRequest, pr.GUID, trigger.ElideSkippedContexts)
+				return buildAll(c, &pr.PullRequest, pr.GUID, trigger.ElideSkippedContexts, "")
 			}
 		}
+	case scm.ActionUnlabel:
+		if (trigger.TrustedLabel != "" && pr.Label.Name == trigger.TrustedLabel) || pr.Label.Name == labels.OkToTest {
+			return handleRevokedTrust(c, trigger, pr)
+		}
 	default:
 		c.Logger.Warnf("unknown PR Action %d of %s", int(pr.Action), pr.Action.String())
 	}
 	return nil
 }
 
+// handleTrustedLabel treats the trusted label or the ok-to-test label like an /ok-to-test comment if the user who
+// added it is trusted. Otherwise the label is removed, as its presence alone marks the PR as trusted.
+func handleTrustedLabel(c Client, trigger *plugins.Trigger, pr scm.PullRequestHook) error {
+	org, repo, _ := orgRepoAuthor(pr.PullRequest)
+	num := pr.PullRequest.Number
+	sender := pr.Sender.Login
+	label := pr.Label.Name
+	if botName, err := c.SCMProviderClient.BotName(); err == nil && sender == botName {
+		// the bot labels the PRs it was asked to test with /ok-to-test, whose jobs are already started
+		return nil
+	}
+	trusted, err := TrustedUser(c.SCMProviderClient, trigger, sender, org, repo)
+	if err != nil {
+		return fmt.Errorf("could not check membership: %s", err)
+	}
+	if !trusted {
+		c.Logger.Infof("Removing label %q added by untrusted user %q.", label, sender)
+		if err := c.SCMProviderClient.RemoveLabel(org, repo, num, label, true); err != nil {
+			return err
+		}
+		comment := fmt.Sprintf("%s: only trusted users can add the `%s` label.", c.SCMProviderClient.QuoteAuthorForComment(sender), label)
+		return c.SCMProviderClient.CreateComment(org, repo, num, true, comment)
+	}
+
+	l, err := c.SCMProviderClient.GetIssueLabels(org, repo, num, true)
+	if err != nil {
+		return err
+	}
+	if scmprovider.HasLabel(labels.NeedsOkToTest, l) {
+		if err := c.SCMProviderClient.RemoveLabel(org, repo, num, labels.NeedsOkToTest, true); err != nil {
+			return err
+		}
+	}
+	c.Logger.Infof("Starting all jobs for PR labeled %q by trusted user %q.", label, sender)
+	return buildAll(c, &pr.PullRequest, pr.GUID, trigger.ElideSkippedContexts, sender)
+}
+
+// handleRevokedTrust labels the PR needs-ok-to-test again once the label which made it trusted is removed, so that
+// its new commits are no longer tested until a trusted user comments /ok-to-test again
+func handleRevokedTrust(c Client, trigger *plugins.Trigger, pr scm.PullRequestHook) error {
+	if !HonorOkToTest(trigger) {
+		return nil
+	}
+	org, repo, a := orgRepoAuthor(pr.PullRequest)
+	num := pr.PullRequest.Number
+	l, trusted, err := TrustedPullRequest(c.SCMProviderClient, trigger, string(a), org, repo, num, nil)
+	if err != nil {
+		return fmt.Errorf("could not validate PR: %s", err)
+	}
+	if trusted || scmprovider.HasLabel(labels.NeedsOkToTest, l) {
+		return nil
+	}
+	c.Logger.Infof("Label %q was removed from the PR of untrusted user %q, which needs /ok-to-test again.", pr.Label.Name, a)
+	return c.SCMProviderClient.AddLabel(org, repo, num, labels.NeedsOkToTest, true)
+}
+
+// skipDraft returns whether the jobs of the PR are skipped until it is ready for review
+func skipDraft(c Client, trigger *plugins.Trigger, pr *scm.PullRequest) bool {
+	if !trigger.SkipDraftPR || !pr.Draft {
+		return false
+	}
+	c.Logger.Info("Skipping the jobs of the draft PR until it is ready for review.")
+	return true
+}
+
 type login string
 
 func orgRepoAuthor(pr scm.PullRequest) (string, string, login) {
@@ -118,7 +219,7 @@ func buildAllIfTrusted(c Client, trigger *plugins.Trigger, pr scm.PullRequestHoo
 	l, trusted, err := TrustedPullRequest(c.SCMProviderClient, trigger, author, org, repo, num, nil)
 	if err != nil {
 		return fmt.Errorf("could not validate PR: %s", err)
-	} else if trusted {
+	} else if trusted || dependencyUpdateFor(c, &pr.PullRequest) != nil {
 		// Eventually remove needs-ok-to-test
 		// Will not work for org members since labels are not fetched in this case
 		if scmprovider.HasLabel(labels.NeedsOkToTest, l) {
@@ -126,8 +227,11 @@ func buildAllIfTrusted(c Client, trigger *plugins.Trigger, pr scm.PullRequestHoo
 				return err
 			}
 		}
+		if skipDraft(c, trigger, &pr.PullRequest) {
+			return nil
+		}
 		c.Logger.Info("Starting all jobs for updated PR.")
-		return buildAll(c, &pr.PullRequest, pr.GUID, trigger.ElideSkippedContexts)
+		return buildAll(c, &pr.PullRequest, pr.GUID, trigger.ElideSkippedContexts, "")
 	}
 	return nil
 }
@@ -153,7 +257,7 @@ func welcomeMsg(spc scmProviderClient, trigger *plugins.Trigger, pr scm.PullRequ
 	if trigger.IgnoreOkToTest {
 		comment = fmt.Sprintf(`Hi @%s. Thanks for your PR.
 
-PRs from untrusted users cannot be marked as trusted with `+"`/ok-to-test`"+` in this repo meaning untrusted PR authors can never trigger tests themselves. Collaborators can still trigger tests on the PR using `+"`/test all`"+`.
+PRs from untrusted users cannot be marked as trusted with `+"`%s`"+` in this repo meaning untrusted PR authors can never trigger tests themselves. Collaborators can still trigger tests on the PR using `+"`%s`"+`.
 
 I understand the commands that are listed [here](https://go.k8s.io/bot-commands?repo=%s).
 
@@ -161,11 +265,11 @@ I understand the commands that are listed [here](https://go.k8s.io/bot-commands?
 
 %s
 </details>
-`, author, encodedRepoFullName, plugins.AboutThisBotWithoutCommands)
+`, author, util.FormatCommand("/ok-to-test"), util.FormatCommand("/test all"), encodedRepoFullName, plugins.AboutThisBotWithoutCommands)
 	} else {
 		comment = fmt.Sprintf(`Hi @%s. Thanks for your PR.
 
-I'm waiting for a [%s](https://github.com/orgs/%s/people) %smember to verify that this patch is reasonable to test. If it is, they should reply with `+"`/ok-to-test`"+` on its own line. Until that is done, I will not automatically test new commits in this PR, but the usual testing commands by org members will still work. Regular contributors should [join the org](%s) to skip this step.
+I'm waiting for a [%s](https://github.com/orgs/%s/people) %smember to verify that this patch is reasonable to test. If it is, they should reply with `+"`%s`"+` on its own line. Until that is done, I will not automatically test new commits in this PR, but the usual testing commands by org members will still work. Regular contributors should [join the org](%s) to skip this step.
 
 Once the patch is verified, the new status will be reflected by the `+"`%s`"+` label.
 
@@ -175,7 +279,7 @@ I understand the commands that are listed [here](https://go.k8s.io/bot-commands?
 
 %s
 </details>
-`, author, org, org, more, joinOrgURL, labels.OkToTest, encodedRepoFullName, plugins.AboutThisBotWithoutCommands)
+`, author, org, org, more, util.FormatCommand("/ok-to-test"), joinOrgURL, labels.OkToTest, encodedRepoFullName, plugins.AboutThisBotWithoutCommands)
 		if err := spc.AddLabel(org, repo, pr.Number, labels.NeedsOkToTest, true); err != nil {
 			errors = append(errors, err)
 		}
@@ -192,7 +296,7 @@ I understand the commands that are listed [here](https://go.k8s.io/bot-commands?
 }
 
 // TrustedPullRequest returns whether or not the given PR should be tested.
-// It first checks if the author is in the org, then looks for "ok-to-test" label.
+// It first checks if the author is in the org, then looks for "ok-to-test" label or the trusted label.
 func TrustedPullRequest(spc scmProviderClient, trigger *plugins.Trigger, author, org, repo string, num int, l []*scm.Label) ([]*scm.Label, bool, error) {
 	// First check if the author is a member of the org.
 	if orgMember, err := TrustedUser(spc, trigger, author, org, repo); err != nil {
@@ -200,7 +304,7 @@ func TrustedPullRequest(spc scmProviderClient, trigger *plugins.Trigger, author,
 	} else if orgMember {
 		return l, true, nil
 	}
-	// Then check if PR has ok-to-test label
+	// Then check if PR has ok-to-test label, or the trusted label of the repo
 	if l == nil {
 		var err error
 		l, err = spc.GetIssueLabels(org, repo, num, true)
@@ -208,16 +312,49 @@ func TrustedPullRequest(spc scmProviderClient, trigger *plugins.Trigger, author,
 			return l, false, err
 		}
 	}
+	if trigger.TrustedLabel != "" && scmprovider.HasLabel(trigger.TrustedLabel, l) {
+		return l, true, nil
+	}
 	return l, scmprovider.HasLabel(labels.OkToTest, l), nil
 }
 
-// buildAll ensures that all builds that should run and will be required are built
-func buildAll(c Client, pr *scm.PullRequest, eventGUID string, elideSkippedContexts bool) error {
+// buildAll ensures that all builds that should run and will be required are built. The presubmits are only run on
+// behalf of the requester, if any, when the requester can run them on demand.
+func buildAll(c Client, pr *scm.PullRequest, eventGUID string, elideSkippedContexts bool, requester string) error {
 	org, repo, number, branch := pr.Base.Repo.Namespace, pr.Base.Repo.Name, pr.Number, pr.Base.Ref
-	changes := config.NewGitHubDeferredChangedFilesProvider(c.SCMProviderClient, org, repo, number)
-	toTest, toSkip, err := jobutil.FilterPresubmits(jobutil.TestAllFilter(), changes, branch, c.Config.GetPresubmits(pr.Base.Repo), c.Logger)
+	changes := requiredjobs.ChangedFiles(c.SCMProviderClient, org, repo, number)
+	toTest, toSkip, err := jobutil.FilterPresubmits(jobutil.TestAllFilter(), changes, branch, presubmitsFor(c, pr.Base.Repo), c.Logger)
 	if err != nil {
 		return err
 	}
-	return RunAndSkipJobs(c, pr, toTest, toSkip, eventGUID, elideSkippedContexts)
+	if marker := skipCIMarker(c, pr); marker != "" {
+		c.Logger.Infof("Skipping all jobs for PR marked %q.", marker)
+		if err := syncSkipCILabel(c, pr, true); err != nil {
+			return err
+		}
+		if elideSkippedContexts {
+			return nil
+		}
+		return skipRequested(c, pr, append(toTest, toSkip...))
+	}
+	if c.PluginConfig != nil && triggerForPR(c.PluginConfig, pr).SkipCI {
+		if err := syncSkipCILabel(c, pr, false); err != nil {
+			c.Logger.WithError(err).Warnf("Failed to remove the %q label.", labels.SkipCI)
+		}
+	}
+	if requester != "" {
+		var denied []config.Presubmit
+		toTest, denied = filterRerunAuthorized(c, org, requester, toTest)
+		if err := reportRerunDenied(c, pr, requester, denied); err != nil {
+			return err
+		}
+	}
+	var reducedErr error
+	if update := dependencyUpdateFor(c, pr); update != nil {
+		var reduced []config.Presubmit
+		toTest, reduced = update.ReducePresubmits(toTest)
+		// keeper only leaves out the presubmits whose contexts are reported as skipped, even if they are elided
+		reducedErr = skipRequested(c, pr, reduced)
+	}
+	return errorutil.NewAggregate(RunAndSkipJobs(c, pr, toTest, toSkip, eventGUID, elideSkippedContexts), reducedErr)
 }
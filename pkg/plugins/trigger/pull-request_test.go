@@ -17,6 +17,7 @@ limitations under the License.
 package trigger
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/jenkins-x/go-scm/scm"
@@ -25,7 +26,10 @@ import (
 	"github.com/jenkins-x/lighthouse/pkg/launcher/fake"
 	"github.com/jenkins-x/lighthouse/pkg/plugins"
 	fake2 "github.com/jenkins-x/lighthouse/pkg/scmprovider/fake"
+	"github.com/jenkins-x/lighthouse/pkg/util"
 	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestTrusted(t *testing.T) {
@@ -117,6 +121,8 @@ func TestHandlePullRequest(t *testing.T) {
 		prLabel       string
 		prChanges     bool
 		prAction      scm.Action
+		draft         bool
+		skipDraftPR   bool
 	}{
 		{
 			name: "Trusted user open PR should build",
@@ -248,6 +254,65 @@ func TestHandlePullRequest(t *testing.T) {
 			prAction:    scm.ActionLabel,
 			prLabel:     "test",
 		},
+		{
+			name: "Trusted user open draft PR should build",
+
+			Author:      "t",
+			ShouldBuild: true,
+			prAction:    scm.ActionOpen,
+			draft:       true,
+		},
+		{
+			name: "Trusted user open draft PR should not build when skipping drafts",
+
+			Author:      "t",
+			ShouldBuild: false,
+			prAction:    scm.ActionOpen,
+			draft:       true,
+			skipDraftPR: true,
+		},
+		{
+			name: "Untrusted user open draft PR should comment when skipping drafts",
+
+			Author:        "u",
+			ShouldBuild:   false,
+			ShouldComment: true,
+			prAction:      scm.ActionOpen,
+			draft:         true,
+			skipDraftPR:   true,
+		},
+		{
+			name: "Trusted user sync draft PR should not build when skipping drafts",
+
+			Author:      "t",
+			ShouldBuild: false,
+			prAction:    scm.ActionSync,
+			draft:       true,
+			skipDraftPR: true,
+		},
+		{
+			name: "Trusted user PR ready for review should build when skipping drafts",
+
+			Author:      "t",
+			ShouldBuild: true,
+			prAction:    scm.ActionReadyForReview,
+			skipDraftPR: true,
+		},
+		{
+			name: "Untrusted user PR ready for review without ok-to-test should not build",
+
+			Author:      "u",
+			ShouldBuild: false,
+			prAction:    scm.ActionReadyForReview,
+			skipDraftPR: true,
+		},
+		{
+			name: "Trusted user PR ready for review should not build when drafts were built",
+
+			Author:      "t",
+			ShouldBuild: false,
+			prAction:    scm.ActionReadyForReview,
+		},
 		{
 			name: "Trusted user closed PR should not build",
 
@@ -307,6 +372,7 @@ func TestHandlePullRequest(t *testing.T) {
 			PullRequest: scm.PullRequest{
 				Number: 0,
 				Author: scm.User{Login: tc.Author},
+				Draft:  tc.draft,
 				Base: scm.PullRequestBranch{
 					Ref: "master",
 					Repo: scm.Repository{
@@ -332,6 +398,7 @@ func TestHandlePullRequest(t *testing.T) {
 		trigger := &plugins.Trigger{
 			TrustedOrg:     "org",
 			OnlyOrgMembers: true,
+			SkipDraftPR:    tc.skipDraftPR,
 		}
 		if err := handlePR(c, trigger, pr); err != nil {
 			t.Fatalf("Didn't expect error: %s", err)
@@ -353,3 +420,247 @@ func TestHandlePullRequest(t *testing.T) {
 		}
 	}
 }
+
+func TestHandleTrustedLabel(t *testing.T) {
+	var testcases = []struct {
+		name          string
+		label         string
+		sender        string
+		shouldBuild   bool
+		removedLabels []string
+	}{
+		{
+			name:          "trusted user adding the label builds the PR",
+			label:         "ok-to-test-label",
+			sender:        "t",
+			shouldBuild:   true,
+			removedLabels: issueLabels(labels.NeedsOkToTest),
+		},
+		{
+			name:          "untrusted user adding the label has it removed",
+			label:         "ok-to-test-label",
+			sender:        "u",
+			removedLabels: issueLabels("ok-to-test-label"),
+		},
+		{
+			name:          "trusted user adding the ok-to-test label builds the PR",
+			label:         labels.OkToTest,
+			sender:        "t",
+			shouldBuild:   true,
+			removedLabels: issueLabels(labels.NeedsOkToTest),
+		},
+		{
+			name:          "untrusted user adding the ok-to-test label has it removed",
+			label:         labels.OkToTest,
+			sender:        "u",
+			removedLabels: issueLabels(labels.OkToTest),
+		},
+		{
+			name:   "the bot adding the ok-to-test label for an /ok-to-test comment is ignored",
+			label:  labels.OkToTest,
+			sender: "k8s-ci-robot",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := &fake2.SCMClient{
+				PullRequestComments:       map[int][]*scm.Comment{},
+				OrgMembers:                map[string][]string{"org": {"t"}},
+				PullRequestLabelsExisting: issueLabels(labels.NeedsOkToTest, tc.label),
+			}
+			fakeLauncher := fake.NewLauncher()
+			c := Client{
+				SCMProviderClient: g,
+				LauncherClient:    fakeLauncher,
+				Config:            &config.Config{},
+				Logger:            logrus.WithField("plugin", PluginName),
+			}
+			presubmits := map[string][]config.Presubmit{
+				"org/repo": {{JobBase: config.JobBase{Name: "jib"}, AlwaysRun: true}},
+			}
+			if err := c.Config.SetPresubmits(presubmits); err != nil {
+				t.Fatalf("failed to set presubmits: %v", err)
+			}
+			pr := scm.PullRequestHook{
+				Action: scm.ActionLabel,
+				Label:  scm.Label{Name: tc.label},
+				Sender: scm.User{Login: tc.sender},
+				PullRequest: scm.PullRequest{
+					Author: scm.User{Login: "u"},
+					Base: scm.PullRequestBranch{
+						Ref:  "master",
+						Repo: scm.Repository{Namespace: "org", Name: "repo", FullName: "org/repo"},
+					},
+				},
+			}
+			trigger := &plugins.Trigger{OnlyOrgMembers: true, TrustedLabel: "ok-to-test-label"}
+			if err := handlePR(c, trigger, pr); err != nil {
+				t.Fatalf("Didn't expect error: %s", err)
+			}
+			if built := len(fakeLauncher.Pipelines) > 0; built != tc.shouldBuild {
+				t.Errorf("expected built %t but got %t", tc.shouldBuild, built)
+			}
+			if !reflect.DeepEqual(g.PullRequestLabelsRemoved, tc.removedLabels) {
+				t.Errorf("expected removed labels %v but got %v", tc.removedLabels, g.PullRequestLabelsRemoved)
+			}
+
+			_, trusted, err := TrustedPullRequest(g, trigger, "u", "org", "repo", 0, nil)
+			if err != nil {
+				t.Fatalf("Didn't expect error: %s", err)
+			}
+			if expected := tc.shouldBuild || tc.sender == "k8s-ci-robot"; trusted != expected {
+				t.Errorf("expected the PR to be trusted %t but got %t", expected, trusted)
+			}
+		})
+	}
+}
+
+func TestHandleTrustedLabelRerunAuthConfig(t *testing.T) {
+	var testcases = []struct {
+		name         string
+		sender       string
+		expectedJobs []string
+		denied       bool
+	}{
+		{
+			name:         "the presubmits restricted to other users are not run",
+			sender:       "t",
+			expectedJobs: []string{"jib"},
+			denied:       true,
+		},
+		{
+			name:         "the presubmits restricted to the user adding the label are run",
+			sender:       "alice",
+			expectedJobs: []string{"jib", "deploy"},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := &fake2.SCMClient{
+				PullRequestComments:       map[int][]*scm.Comment{},
+				OrgMembers:                map[string][]string{"org": {"t", "alice"}},
+				PullRequestLabelsExisting: issueLabels(labels.NeedsOkToTest, labels.OkToTest),
+			}
+			fakeLauncher := fake.NewLauncher()
+			c := Client{
+				SCMProviderClient: g,
+				LauncherClient:    fakeLauncher,
+				Config:            &config.Config{},
+				Logger:            logrus.WithField("plugin", PluginName),
+			}
+			presubmits := map[string][]config.Presubmit{
+				"org/repo": {
+					{JobBase: config.JobBase{Name: "jib"}, AlwaysRun: true},
+					{JobBase: config.JobBase{Name: "deploy", Annotations: map[string]string{util.RerunAuthConfigAnnotation: "alice"}}, AlwaysRun: true},
+				},
+			}
+			require.NoError(t, c.Config.SetPresubmits(presubmits))
+			pr := scm.PullRequestHook{
+				Action: scm.ActionLabel,
+				Label:  scm.Label{Name: labels.OkToTest},
+				Sender: scm.User{Login: tc.sender},
+				PullRequest: scm.PullRequest{
+					Author: scm.User{Login: "u"},
+					Base: scm.PullRequestBranch{
+						Ref:  "master",
+						Repo: scm.Repository{Namespace: "org", Name: "repo", FullName: "org/repo"},
+					},
+				},
+			}
+			trigger := &plugins.Trigger{OnlyOrgMembers: true}
+
+			require.NoError(t, handlePR(c, trigger, pr))
+
+			var jobs []string
+			for _, job := range fakeLauncher.Pipelines {
+				jobs = append(jobs, job.Spec.Job)
+			}
+			assert.ElementsMatch(t, tc.expectedJobs, jobs)
+			if tc.denied {
+				require.Len(t, g.PullRequestComments[0], 1)
+				assert.Contains(t, g.PullRequestComments[0][0].Body, "* `deploy`: alice")
+			} else {
+				assert.Empty(t, g.PullRequestComments[0])
+			}
+		})
+	}
+}
+
+func TestHandleRevokedTrust(t *testing.T) {
+	var testcases = []struct {
+		name           string
+		author         string
+		existing       []string
+		addedLabels    []string
+		ignoreOkToTest bool
+	}{
+		{
+			name:        "untrusted PR needs ok-to-test again",
+			author:      "u",
+			addedLabels: issueLabels(labels.NeedsOkToTest),
+		},
+		{
+			name:   "PR of a trusted author stays trusted",
+			author: "t",
+		},
+		{
+			name:     "PR still labeled with the trusted label stays trusted",
+			author:   "u",
+			existing: issueLabels("ok-to-test-label"),
+		},
+		{
+			name:     "PR already labeled needs-ok-to-test",
+			author:   "u",
+			existing: issueLabels(labels.NeedsOkToTest),
+		},
+		{
+			name:           "ok-to-test is ignored",
+			author:         "u",
+			ignoreOkToTest: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := &fake2.SCMClient{
+				PullRequestComments:       map[int][]*scm.Comment{},
+				OrgMembers:                map[string][]string{"org": {"t"}},
+				PullRequestLabelsExisting: tc.existing,
+			}
+			fakeLauncher := fake.NewLauncher()
+			c := Client{
+				SCMProviderClient: g,
+				LauncherClient:    fakeLauncher,
+				Config:            &config.Config{},
+				Logger:            logrus.WithField("plugin", PluginName),
+			}
+			presubmits := map[string][]config.Presubmit{
+				"org/repo": {{JobBase: config.JobBase{Name: "jib"}, AlwaysRun: true}},
+			}
+			if err := c.Config.SetPresubmits(presubmits); err != nil {
+				t.Fatalf("failed to set presubmits: %v", err)
+			}
+			pr := scm.PullRequestHook{
+				Action: scm.ActionUnlabel,
+				Label:  scm.Label{Name: labels.OkToTest},
+				Sender: scm.User{Login: "t"},
+				PullRequest: scm.PullRequest{
+					Author: scm.User{Login: tc.author},
+					Base: scm.PullRequestBranch{
+						Ref:  "master",
+						Repo: scm.Repository{Namespace: "org", Name: "repo", FullName: "org/repo"},
+					},
+				},
+			}
+			trigger := &plugins.Trigger{OnlyOrgMembers: true, TrustedLabel: "ok-to-test-label", IgnoreOkToTest: tc.ignoreOkToTest}
+			if err := handlePR(c, trigger, pr); err != nil {
+				t.Fatalf("Didn't expect error: %s", err)
+			}
+			if !reflect.DeepEqual(g.PullRequestLabelsAdded, tc.addedLabels) {
+				t.Errorf("expected added labels %v but got %v", tc.addedLabels, g.PullRequestLabelsAdded)
+			}
+			if len(fakeLauncher.Pipelines) > 0 {
+				t.Error("expected no jobs to be started")
+			}
+		})
+	}
+}
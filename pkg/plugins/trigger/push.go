@@ -63,8 +63,15 @@ func handlePE(c Client, pe scm.PushHook) error {
 		return nil
 	}
 	for _, j := range c.Config.GetPostsubmits(pe.Repo) {
-		branch := scmprovider.PushHookBranch(&pe)
-		if shouldRun, err := j.ShouldRun(branch, listPushEventChanges(pe)); err != nil {
+		if context, _ := jobutil.TriggerContext(j.JobBase); context != "" {
+			// the job runs once the context reaches its state
+			continue
+		}
+		if jobutil.IssueTrigger(j.JobBase) != nil {
+			// the job runs when its command is commented on an issue
+			continue
+		}
+		if shouldRun, err := jobutil.PostsubmitShouldRun(j, pe.Ref, listPushEventChanges(pe)); err != nil {
 			return err
 		} else if !shouldRun {
 			continue
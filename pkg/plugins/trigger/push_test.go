@@ -129,6 +129,31 @@ func TestHandlePE(t *testing.T) {
 			},
 			jobsToRun: 1,
 		},
+		{
+			name: "release tag",
+			pe: &scm.PushHook{
+				Ref: "refs/tags/v1.2.0",
+				Repo: scm.Repository{
+					FullName: "org4/repo4",
+				},
+			},
+			jobsToRun: 1,
+		},
+		{
+			name: "branch named like a tag",
+			pe: &scm.PushHook{
+				Ref: "refs/heads/v1.2.0",
+				Commits: []scm.PushCommit{
+					{
+						Added: []string{"example.txt"},
+					},
+				},
+				Repo: scm.Repository{
+					FullName: "org4/repo4",
+				},
+			},
+			jobsToRun: 0,
+		},
 	}
 	for _, tc := range testCases {
 		g := &fake2.SCMClient{}
@@ -167,6 +192,16 @@ func TestHandlePE(t *testing.T) {
 					},
 				},
 			},
+			"org4/repo4": {
+				{
+					JobBase: config.JobBase{
+						Name: "release",
+					},
+					Brancher: config.Brancher{
+						Branches: []string{`^refs/tags/v\d+\.\d+\.\d+$`},
+					},
+				},
+			},
 		}
 		if err := c.Config.SetPostsubmits(postsubmits); err != nil {
 			t.Fatalf("failed to set postsubmits: %v", err)
@@ -0,0 +1,133 @@
+package trigger
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/errorutil"
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// quotaCommentTag marks the comment telling that a quota is exhausted, so that only the latest one is kept
+const quotaCommentTag = "<!-- lighthouse-trigger-quota -->"
+
+// jobClient lists the jobs of the pull requests, gets the jobs of rerequested check runs and annotates the jobs to
+// abort
+type jobClient interface {
+	Get(name string, opts metav1.GetOptions) (*v1alpha1.LighthouseJob, error)
+	List(opts metav1.ListOptions) (*v1alpha1.LighthouseJobList, error)
+	Update(*v1alpha1.LighthouseJob) (*v1alpha1.LighthouseJob, error)
+}
+
+// exhaustedQuota is a quota which does not allow the requested jobs to run
+type exhaustedQuota struct {
+	quota plugins.Quota
+	// subject is the org or user the quota applies to
+	subject string
+	// retryAt is when enough runs have left the window for the requested jobs to run
+	retryAt time.Time
+}
+
+// checkQuotas returns the first quota of the repository which the requested number of jobs would exceed
+func checkQuotas(c Client, pr *scm.PullRequest, requested int) (*exhaustedQuota, error) {
+	if c.PluginConfig == nil || c.LighthouseClient == nil {
+		return nil, nil
+	}
+	org, _, author := orgRepoAuthor(*pr)
+	quotas := triggerForPR(c.PluginConfig, pr).Quotas
+	if len(quotas) == 0 {
+		return nil, nil
+	}
+	selector := labels.SelectorFromSet(labels.Set{
+		config.LighthouseJobTypeLabel: string(config.PresubmitJob),
+		util.OrgLabel:                 strings.ToLower(org),
+	})
+	jobs, err := c.LighthouseClient.List(metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for _, quota := range quotas {
+		subject := org
+		var runs []time.Time
+		for i := range jobs.Items {
+			job := &jobs.Items[i]
+			created := job.CreationTimestamp.Time
+			if now.Sub(created) >= quota.WindowDuration {
+				continue
+			}
+			if quota.Scope == plugins.QuotaScopeUser {
+				subject = string(author)
+				refs := job.Spec.Refs
+				if refs == nil || len(refs.Pulls) == 0 || refs.Pulls[0].Author != subject {
+					continue
+				}
+			}
+			runs = append(runs, created)
+		}
+		if len(runs)+requested <= quota.Max {
+			continue
+		}
+		// the requested jobs can run once the oldest runs leave the window
+		sort.Slice(runs, func(i, j int) bool { return runs[i].Before(runs[j]) })
+		retryAt := now
+		if leaving := len(runs) + requested - quota.Max; leaving <= len(runs) {
+			retryAt = runs[leaving-1].Add(quota.WindowDuration)
+		}
+		return &exhaustedQuota{quota: quota, subject: subject, retryAt: retryAt}, nil
+	}
+	return nil, nil
+}
+
+// reportExhaustedQuota gives the requested jobs an error status and replaces the comment explaining why they
+// did not run
+func reportExhaustedQuota(c Client, pr *scm.PullRequest, requestedJobs []config.Presubmit, exhausted *exhaustedQuota) error {
+	org, repo, number := pr.Base.Repo.Namespace, pr.Base.Repo.Name, pr.Number
+	quota := exhausted.quota
+	c.Logger.WithField("scope", quota.Scope).Infof("Not running %d jobs as the quota of %s is exhausted.", len(requestedJobs), exhausted.subject)
+
+	var errs []error
+	for _, job := range requestedJobs {
+		if job.SkipReport {
+			continue
+		}
+		status := &scm.StatusInput{
+			State: scm.StateError,
+			Label: job.Context,
+			Desc:  fmt.Sprintf("Not run: quota of %d runs per %s exhausted until %s", quota.Max, quota.WindowDuration, exhausted.retryAt.UTC().Format("15:04 MST")),
+		}
+		if _, err := c.SCMProviderClient.CreateStatus(org, repo, pr.Head.Ref, status); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	botName, err := c.SCMProviderClient.BotName()
+	if err != nil {
+		return err
+	}
+	isStale := func(comment *scm.Comment) bool {
+		return comment.Author.Login == botName && strings.Contains(comment.Body, quotaCommentTag)
+	}
+	if err := c.SCMProviderClient.DeleteStaleComments(org, repo, number, nil, true, isStale); err != nil {
+		errs = append(errs, err)
+	}
+	of := "the " + org + " org"
+	if quota.Scope == plugins.QuotaScopeUser {
+		of = c.SCMProviderClient.QuoteAuthorForComment(exhausted.subject)
+	}
+	comment := fmt.Sprintf(`%s
+Sorry, the jobs of this PR were not run as %s already ran %d jobs in the last %s, which is the most allowed to share the CI fairly. They can be triggered again after %s.`,
+		quotaCommentTag, of, quota.Max, quota.WindowDuration, exhausted.retryAt.UTC().Format(time.RFC1123))
+	if err := c.SCMProviderClient.CreateComment(org, repo, number, true, comment); err != nil {
+		errs = append(errs, err)
+	}
+	return errorutil.NewAggregate(errs...)
+}
@@ -0,0 +1,138 @@
+package trigger
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	lhfake "github.com/jenkins-x/lighthouse/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/lighthouse/pkg/launcher/fake"
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	fake2 "github.com/jenkins-x/lighthouse/pkg/scmprovider/fake"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func quotaJob(name, org, author string, age time.Duration) runtime.Object {
+	return &v1alpha1.LighthouseJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "jx",
+			CreationTimestamp: metav1.NewTime(time.Now().Add(-age)),
+			Labels: map[string]string{
+				config.LighthouseJobTypeLabel: string(config.PresubmitJob),
+				util.OrgLabel:                 org,
+			},
+		},
+		Spec: v1alpha1.LighthouseJobSpec{
+			Type: config.PresubmitJob,
+			Refs: &v1alpha1.Refs{Org: org, Pulls: []v1alpha1.Pull{{Author: author}}},
+		},
+	}
+}
+
+func TestQuotas(t *testing.T) {
+	testCases := []struct {
+		name            string
+		quotas          []plugins.Quota
+		jobs            []runtime.Object
+		expectedStarted bool
+		expectedRetryIn time.Duration
+	}{
+		{
+			name:            "no quota",
+			jobs:            []runtime.Object{quotaJob("a", "org", "bob", time.Minute)},
+			expectedStarted: true,
+		},
+		{
+			name:            "org quota not exhausted",
+			quotas:          []plugins.Quota{{Scope: plugins.QuotaScopeOrg, Max: 3, WindowDuration: time.Hour}},
+			jobs:            []runtime.Object{quotaJob("a", "org", "bob", time.Minute), quotaJob("b", "org", "alice", 2*time.Hour)},
+			expectedStarted: true,
+		},
+		{
+			name:   "org quota exhausted",
+			quotas: []plugins.Quota{{Scope: plugins.QuotaScopeOrg, Max: 2, WindowDuration: time.Hour}},
+			jobs: []runtime.Object{
+				quotaJob("a", "org", "bob", 10*time.Minute),
+				quotaJob("b", "org", "alice", 20*time.Minute),
+				quotaJob("c", "org", "alice", 2*time.Hour),
+				quotaJob("d", "other", "alice", time.Minute),
+			},
+			expectedRetryIn: 40 * time.Minute,
+		},
+		{
+			name:   "user quota counts the jobs of the author",
+			quotas: []plugins.Quota{{Scope: plugins.QuotaScopeUser, Max: 2, WindowDuration: time.Hour}},
+			jobs: []runtime.Object{
+				quotaJob("a", "org", "alice", 10*time.Minute),
+				quotaJob("b", "org", "alice", 20*time.Minute),
+			},
+			expectedStarted: true,
+		},
+		{
+			name:            "user quota exhausted",
+			quotas:          []plugins.Quota{{Scope: plugins.QuotaScopeUser, Max: 2, WindowDuration: time.Hour}},
+			jobs:            []runtime.Object{quotaJob("a", "org", "bob", 10*time.Minute), quotaJob("b", "org", "bob", 30*time.Minute)},
+			expectedRetryIn: 30 * time.Minute,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := &fake2.SCMClient{
+				PullRequestComments: map[int][]*scm.Comment{
+					1: {{ID: 1, Body: quotaCommentTag + "\nold", Author: scm.User{Login: fake2.Bot}}},
+				},
+				CreatedStatuses: map[string][]*scm.StatusInput{},
+			}
+			fakeLauncher := fake.NewLauncher()
+			c := Client{
+				SCMProviderClient: g,
+				LauncherClient:    fakeLauncher,
+				Config:            &config.Config{},
+				Logger:            logrus.WithField("plugin", PluginName),
+				PluginConfig: &plugins.Configuration{
+					Triggers: []plugins.Trigger{{Repos: []string{"org"}, Quotas: tc.quotas}},
+				},
+				LighthouseClient: lhfake.NewSimpleClientset(tc.jobs...).LighthouseV1alpha1().LighthouseJobs("jx"),
+			}
+			pr := &scm.PullRequest{
+				Number: 1,
+				Author: scm.User{Login: "bob"},
+				Head:   scm.PullRequestBranch{Ref: "feature", Sha: "sha"},
+				Base: scm.PullRequestBranch{
+					Ref:  "master",
+					Repo: scm.Repository{Namespace: "org", Name: "repo"},
+				},
+			}
+			jobs := []config.Presubmit{
+				{JobBase: config.JobBase{Name: "unit"}, Reporter: config.Reporter{Context: "unit"}},
+			}
+			require.NoError(t, runRequested(c, pr, jobs, "guid"))
+
+			if tc.expectedStarted {
+				assert.Len(t, fakeLauncher.Pipelines, 1)
+				assert.Empty(t, g.CreatedStatuses["feature"])
+				return
+			}
+			assert.Empty(t, fakeLauncher.Pipelines)
+			require.Len(t, g.CreatedStatuses["feature"], 1)
+			assert.Equal(t, scm.StateError, g.CreatedStatuses["feature"][0].State)
+			assert.Equal(t, []string{"org/repo#1"}, g.PullRequestCommentsDeleted)
+			require.Len(t, g.PullRequestCommentsAdded, 1)
+			comment := g.PullRequestCommentsAdded[0]
+			assert.True(t, strings.Contains(comment, quotaCommentTag), comment)
+			retryAt := time.Now().Add(tc.expectedRetryIn).UTC()
+			// the retry time is formatted to the second
+			assert.True(t, strings.Contains(comment, retryAt.Format(time.RFC1123)) || strings.Contains(comment, retryAt.Add(-time.Second).Format(time.RFC1123)), fmt.Sprintf("expected a retry at %s in %s", retryAt, comment))
+		})
+	}
+}
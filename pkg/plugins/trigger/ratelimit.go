@@ -0,0 +1,118 @@
+package trigger
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+)
+
+// userCommands are the times a user commented a command on a PR
+type userCommands struct {
+	times []time.Time
+	// window is how long the commands are counted, which depends on the configuration of the repository
+	window time.Duration
+	// notified is true once the user was told when they can retry, until they can comment commands again
+	notified bool
+}
+
+// storedCommands are the userCommands kept in the state store
+type storedCommands struct {
+	Times    []time.Time `json:"times"`
+	Notified bool        `json:"notified,omitempty"`
+}
+
+// commandLimiter counts the /test and /retest commands of each user on each PR, keyed by org/repo#number@user
+type commandLimiter struct {
+	lock     sync.Mutex
+	commands map[string]*userCommands
+	now      func() time.Time
+}
+
+var commandLimits = &commandLimiter{
+	commands: map[string]*userCommands{},
+	now:      time.Now,
+}
+
+func commandLimitKey(org, repo string, number int, user string) string {
+	return fmt.Sprintf("%s/%s#%d@%s", strings.ToLower(org), strings.ToLower(repo), number, scmprovider.NormLogin(user))
+}
+
+// allowCommand records a command of the user in the state store shared by the replicas, or in memory if there is
+// none. The command is allowed if the store fails, as ignoring it would be worse than running it.
+func allowCommand(c Client, limit *plugins.CommandRateLimit, key string) (allowed bool, retryAt time.Time, notify bool) {
+	if c.Store == nil {
+		return commandLimits.allow(limit, key)
+	}
+	now := commandLimits.now()
+	err := c.Store.Update("trigger/commands/"+key, limit.WindowDuration, func(value string, found bool) (string, error) {
+		stored := storedCommands{}
+		if found {
+			if err := json.Unmarshal([]byte(value), &stored); err != nil {
+				c.Logger.WithError(err).Warnf("Ignoring the invalid commands of %s.", key)
+			}
+		}
+		commands := &userCommands{times: stored.Times, notified: stored.Notified}
+		allowed, retryAt, notify = commands.record(limit, now)
+		data, err := json.Marshal(storedCommands{Times: commands.times, Notified: commands.notified})
+		return string(data), err
+	})
+	if err != nil {
+		c.Logger.WithError(err).Warn("Failed to count the commands of the user in the state store.")
+		return true, time.Time{}, false
+	}
+	return allowed, retryAt, notify
+}
+
+// allow records a command of the user unless the limit is reached, in which case it returns when the user can
+// comment again and whether they should be told so, which is only the first time the limit is hit
+func (l *commandLimiter) allow(limit *plugins.CommandRateLimit, key string) (allowed bool, retryAt time.Time, notify bool) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	now := l.now()
+	l.expire(now)
+	commands := l.commands[key]
+	if commands == nil {
+		commands = &userCommands{}
+		l.commands[key] = commands
+	}
+	return commands.record(limit, now)
+}
+
+// expire forgets the commands which left the window, and the users who have none left
+func (l *commandLimiter) expire(now time.Time) {
+	for key, commands := range l.commands {
+		commands.expire(now)
+		if len(commands.times) == 0 {
+			delete(l.commands, key)
+		}
+	}
+}
+
+// record records a command of the user unless the limit is reached, like allow
+func (u *userCommands) record(limit *plugins.CommandRateLimit, now time.Time) (allowed bool, retryAt time.Time, notify bool) {
+	u.window = limit.WindowDuration
+	u.expire(now)
+	if len(u.times) < limit.Max {
+		u.times = append(u.times, now)
+		u.notified = false
+		return true, time.Time{}, false
+	}
+	notify = !u.notified
+	u.notified = true
+	return false, u.times[len(u.times)-limit.Max].Add(limit.WindowDuration), notify
+}
+
+// expire forgets the commands which left the window
+func (u *userCommands) expire(now time.Time) {
+	i := 0
+	for i < len(u.times) && now.Sub(u.times[i]) >= u.window {
+		i++
+	}
+	u.times = u.times[i:]
+}
@@ -0,0 +1,146 @@
+package trigger
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/launcher/fake"
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	fake2 "github.com/jenkins-x/lighthouse/pkg/scmprovider/fake"
+	"github.com/jenkins-x/lighthouse/pkg/store"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommandLimiter(t *testing.T) {
+	start := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	now := start
+	l := &commandLimiter{commands: map[string]*userCommands{}, now: func() time.Time { return now }}
+	limit := &plugins.CommandRateLimit{Max: 2, WindowDuration: time.Hour}
+
+	allowed, _, _ := l.allow(limit, "bob")
+	assert.True(t, allowed)
+	now = start.Add(10 * time.Minute)
+	allowed, _, _ = l.allow(limit, "bob")
+	assert.True(t, allowed)
+	allowed, _, _ = l.allow(limit, "alice")
+	assert.True(t, allowed, "the commands of other users are counted apart")
+
+	now = start.Add(20 * time.Minute)
+	allowed, retryAt, notify := l.allow(limit, "bob")
+	assert.False(t, allowed)
+	assert.True(t, notify)
+	assert.Equal(t, start.Add(time.Hour), retryAt)
+	allowed, _, notify = l.allow(limit, "bob")
+	assert.False(t, allowed)
+	assert.False(t, notify, "the user is only told once")
+
+	now = start.Add(time.Hour)
+	allowed, _, _ = l.allow(limit, "bob")
+	assert.True(t, allowed, "the first command left the window")
+	allowed, retryAt, notify = l.allow(limit, "bob")
+	assert.False(t, allowed)
+	assert.True(t, notify, "the user is told again once they hit the limit again")
+	assert.Equal(t, start.Add(70*time.Minute), retryAt)
+
+	now = start.Add(3 * time.Hour)
+	l.allow(limit, "alice")
+	assert.NotContains(t, l.commands, "bob", "users without commands in the window are forgotten")
+}
+
+func TestAllowCommandSharedStore(t *testing.T) {
+	shared := store.NewMemoryStore()
+	replicas := []Client{
+		{Store: shared, Logger: logrus.WithField("replica", 0)},
+		{Store: shared, Logger: logrus.WithField("replica", 1)},
+	}
+	limit := &plugins.CommandRateLimit{Max: 2, WindowDuration: time.Hour}
+
+	allowed, _, _ := allowCommand(replicas[0], limit, "bob")
+	assert.True(t, allowed)
+	allowed, _, _ = allowCommand(replicas[1], limit, "bob")
+	assert.True(t, allowed)
+	allowed, _, notify := allowCommand(replicas[0], limit, "bob")
+	assert.False(t, allowed, "the commands on every replica should be counted")
+	assert.True(t, notify)
+	allowed, _, notify = allowCommand(replicas[1], limit, "bob")
+	assert.False(t, allowed)
+	assert.False(t, notify, "the user is only told once whichever replica handles the command")
+	allowed, _, _ = allowCommand(replicas[1], limit, "alice")
+	assert.True(t, allowed)
+}
+
+func TestGenericCommentRateLimit(t *testing.T) {
+	commandLimits = &commandLimiter{commands: map[string]*userCommands{}, now: time.Now}
+	defer func() {
+		commandLimits = &commandLimiter{commands: map[string]*userCommands{}, now: time.Now}
+	}()
+	g := &fake2.SCMClient{
+		CreatedStatuses:     map[string][]*scm.StatusInput{},
+		PullRequestComments: map[int][]*scm.Comment{},
+		OrgMembers:          map[string][]string{"org": {"trusted-member"}},
+		PullRequests: map[int]*scm.PullRequest{
+			1: {
+				Number: 1,
+				Author: scm.User{Login: "trusted-member"},
+				Head:   scm.PullRequestBranch{Ref: "feature", Sha: "cafe"},
+				Base: scm.PullRequestBranch{
+					Ref:  "master",
+					Repo: scm.Repository{Namespace: "org", Name: "repo"},
+				},
+			},
+		},
+		PullRequestChanges: map[int][]*scm.Change{1: {{Path: "CHANGED"}}},
+		CombinedStatuses:   map[string]*scm.CombinedStatus{"cafe": {}},
+	}
+	fakeLauncher := fake.NewLauncher()
+	c := Client{
+		SCMProviderClient: g,
+		LauncherClient:    fakeLauncher,
+		Config:            &config.Config{},
+		Logger:            logrus.WithField("plugin", PluginName),
+	}
+	require.NoError(t, c.Config.SetPresubmits(map[string][]config.Presubmit{
+		"org/repo": {{
+			JobBase:      config.JobBase{Name: "job"},
+			AlwaysRun:    true,
+			Reporter:     config.Reporter{Context: "pull-job"},
+			Trigger:      `(?m)^/test (?:.*? )?job(?: .*?)?$`,
+			RerunCommand: `/test job`,
+		}},
+	}))
+	trigger := &plugins.Trigger{CommandRateLimit: &plugins.CommandRateLimit{Max: 2, WindowDuration: time.Hour}}
+	comment := func(author, body string) {
+		event := scmprovider.GenericCommentEvent{
+			Action:      scm.ActionCreate,
+			Repo:        scm.Repository{Namespace: "org", Name: "repo", FullName: "org/repo"},
+			Body:        body,
+			Number:      1,
+			Author:      scm.User{Login: author},
+			IssueAuthor: scm.User{Login: "trusted-member"},
+			IssueState:  "open",
+			IsPR:        true,
+		}
+		require.NoError(t, handleGenericComment(c, trigger, event))
+	}
+
+	comment("trusted-member", "/test job")
+	comment("trusted-member", "/retest")
+	assert.Len(t, fakeLauncher.Pipelines, 2)
+	assert.Empty(t, g.PullRequestCommentsAdded)
+
+	comment("trusted-member", "/test all")
+	comment("trusted-member", "/test job")
+	assert.Len(t, fakeLauncher.Pipelines, 2)
+	require.Len(t, g.PullRequestCommentsAdded, 1, "the user is only told once")
+	assert.True(t, strings.Contains(g.PullRequestCommentsAdded[0], "You can run `/test` or `/retest` again after"), g.PullRequestCommentsAdded[0])
+
+	comment("trusted-member", "/ok-to-test")
+	comment("someone-else", "/test job")
+	assert.Len(t, g.PullRequestCommentsAdded, 1)
+}
@@ -0,0 +1,96 @@
+package trigger
+
+import (
+	"fmt"
+
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/jobutil"
+	"github.com/jenkins-x/lighthouse/pkg/payload"
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/jenkins-x/lighthouse/pkg/requiredjobs"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func handleCheckRerequest(pc plugins.Agent, ce payload.CheckRerequestHook) error {
+	return handleCR(getClient(pc), ce)
+}
+
+// handleCR runs the presubmits of a check run or a check suite rerequested from the GitHub UI again for the pull
+// requests whose head is the commit, as a /test comment of the user who clicked "Re-run" would: a check run runs its
+// presubmit again while a check suite runs all the presubmits which run by default
+func handleCR(c Client, ce payload.CheckRerequestHook) error {
+	org, repo := ce.Repo.Namespace, ce.Repo.Name
+	presubmits := c.Config.GetPresubmits(ce.Repo)
+	if len(presubmits) == 0 {
+		return nil
+	}
+	if len(ce.PullRequests) == 0 {
+		c.Logger.Info("The rerequested check is not run for a pull request, skipping.")
+		return nil
+	}
+
+	filter := jobutil.TestAllFilter()
+	if ce.Name != "" {
+		name := rerequestedJob(c, presubmits, ce)
+		if name == "" {
+			c.Logger.Infof("The check %s is not reported by a presubmit, skipping.", ce.Name)
+			return nil
+		}
+		filter = func(p config.Presubmit) (bool, bool, bool) {
+			matches := p.Name == name
+			return matches, matches, true
+		}
+	}
+
+	for _, number := range ce.PullRequests {
+		pr, err := c.SCMProviderClient.GetPullRequest(org, repo, number)
+		if err != nil {
+			return err
+		}
+		if pr.Closed || pr.Merged || pr.Head.Sha != ce.SHA {
+			c.Logger.Infof("The head of pull request #%d is no longer %s, skipping.", number, ce.SHA)
+			continue
+		}
+		trigger := triggerForPR(c.PluginConfig, pr)
+		trusted, err := TrustedUser(c.SCMProviderClient, trigger, ce.Sender.Login, org, repo)
+		if err != nil {
+			return fmt.Errorf("error checking trust of %s: %v", ce.Sender.Login, err)
+		}
+		if !trusted {
+			c.Logger.Infof("Not running the checks of pull request #%d rerequested by the untrusted user %s.", number, ce.Sender.Login)
+			continue
+		}
+		changes := requiredjobs.ChangedFiles(c.SCMProviderClient, org, repo, number)
+		toTest, toSkip, err := jobutil.FilterPresubmits(filter, changes, pr.Base.Ref, presubmits, c.Logger)
+		if err != nil {
+			return err
+		}
+		toTest, denied := filterRerunAuthorized(c, org, ce.Sender.Login, toTest)
+		if err := reportRerunDenied(c, pr, ce.Sender.Login, denied); err != nil {
+			return err
+		}
+		if err := RunAndSkipJobs(c, pr, toTest, toSkip, "", trigger.ElideSkippedContexts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rerequestedJob returns the name of the presubmit which reported the rerequested check run. A check run reported
+// for a LighthouseJob carries the name of the LighthouseJob as its external ID, otherwise the name of the check run
+// is matched against the contexts of the presubmits.
+func rerequestedJob(c Client, presubmits []config.Presubmit, ce payload.CheckRerequestHook) string {
+	if ce.ExternalID != "" && c.LighthouseClient != nil {
+		job, err := c.LighthouseClient.Get(ce.ExternalID, metav1.GetOptions{})
+		if err == nil {
+			return job.Spec.Job
+		}
+		c.Logger.WithError(err).Debugf("Failed to get the job %s of the check run.", ce.ExternalID)
+	}
+	for _, presubmit := range presubmits {
+		if presubmit.Context == ce.Name || presubmit.Name == ce.ExternalID {
+			return presubmit.Name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,138 @@
+package trigger
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	lhfake "github.com/jenkins-x/lighthouse/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/lighthouse/pkg/jobutil"
+	"github.com/jenkins-x/lighthouse/pkg/launcher/fake"
+	"github.com/jenkins-x/lighthouse/pkg/payload"
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	fake2 "github.com/jenkins-x/lighthouse/pkg/scmprovider/fake"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleCheckRerequest(t *testing.T) {
+	refs := v1alpha1.Refs{Org: "org", Repo: "repo", BaseRef: "master", Pulls: []v1alpha1.Pull{{Number: 1, SHA: "sha"}}}
+	reported := jobutil.NewLighthouseJob(jobutil.PresubmitSpec(config.Presubmit{JobBase: config.JobBase{Name: "lint"}, Reporter: config.Reporter{Context: "lint-check"}}, refs), nil, nil)
+	reported.Name = "org-repo-pr-1-lint-1"
+	reported.Namespace = "jx"
+	reported.Status.State = v1alpha1.FailureState
+
+	testCases := []struct {
+		name         string
+		sender       string
+		check        string
+		externalID   string
+		sha          string
+		expectedJobs []string
+	}{
+		{
+			name:         "a rerequested check suite runs the default presubmits",
+			sender:       "t",
+			expectedJobs: []string{"always", "lint"},
+		},
+		{
+			name:         "a rerequested check run runs its presubmit by context",
+			sender:       "t",
+			check:        "e2e",
+			expectedJobs: []string{"e2e"},
+		},
+		{
+			name:         "a rerequested check run runs the job of its external ID",
+			sender:       "t",
+			check:        "renamed",
+			externalID:   "org-repo-pr-1-lint-1",
+			expectedJobs: []string{"lint"},
+		},
+		{
+			name:   "unknown check runs are ignored",
+			sender: "t",
+			check:  "security-scan",
+		},
+		{
+			name:         "a restricted presubmit runs again for the users allowed to rerun it",
+			sender:       "sig-lead",
+			check:        "deploy",
+			expectedJobs: []string{"deploy"},
+		},
+		{
+			name:   "a restricted presubmit does not run again for the other trusted users",
+			sender: "t",
+			check:  "deploy",
+		},
+		{
+			name:   "untrusted users cannot run the checks again",
+			sender: "u",
+		},
+		{
+			name:   "the checks of an outdated head are not run again",
+			sender: "t",
+			sha:    "old",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pr := &scm.PullRequest{
+				Number: 1,
+				Author: scm.User{Login: "t"},
+				Head:   scm.PullRequestBranch{Ref: "feature", Sha: "sha"},
+				Base: scm.PullRequestBranch{
+					Ref:  "master",
+					Repo: scm.Repository{Namespace: "org", Name: "repo", FullName: "org/repo"},
+				},
+			}
+			g := &fake2.SCMClient{
+				OrgMembers:          map[string][]string{"org": {"t", "sig-lead"}},
+				PullRequests:        map[int]*scm.PullRequest{1: pr},
+				PullRequestComments: map[int][]*scm.Comment{},
+				PullRequestChanges:  map[int][]*scm.Change{1: {{Path: "README.md"}}},
+				CreatedStatuses:     map[string][]*scm.StatusInput{},
+			}
+			fakeLauncher := fake.NewLauncher()
+			c := Client{
+				SCMProviderClient: g,
+				LauncherClient:    fakeLauncher,
+				LighthouseClient:  lhfake.NewSimpleClientset(&reported).LighthouseV1alpha1().LighthouseJobs("jx"),
+				Config:            &config.Config{},
+				PluginConfig:      &plugins.Configuration{Triggers: []plugins.Trigger{{Repos: []string{"org"}, OnlyOrgMembers: true}}},
+				Logger:            logrus.WithField("plugin", PluginName),
+			}
+			presubmits := map[string][]config.Presubmit{
+				"org/repo": {
+					{JobBase: config.JobBase{Name: "always"}, AlwaysRun: true, Reporter: config.Reporter{Context: "always"}},
+					{JobBase: config.JobBase{Name: "lint"}, AlwaysRun: true, Reporter: config.Reporter{Context: "lint-check"}},
+					{JobBase: config.JobBase{Name: "e2e"}, Reporter: config.Reporter{Context: "e2e"}},
+					{JobBase: config.JobBase{Name: "deploy", Annotations: map[string]string{util.RerunAuthConfigAnnotation: "alice, org/leads"}}, Reporter: config.Reporter{Context: "deploy"}},
+				},
+			}
+			require.NoError(t, c.Config.SetPresubmits(presubmits))
+			sha := tc.sha
+			if sha == "" {
+				sha = "sha"
+			}
+			ce := payload.CheckRerequestHook{
+				Repo:         scm.Repository{Namespace: "org", Name: "repo", FullName: "org/repo"},
+				SHA:          sha,
+				Name:         tc.check,
+				ExternalID:   tc.externalID,
+				PullRequests: []int{1},
+				Sender:       scm.User{Login: tc.sender},
+			}
+
+			require.NoError(t, handleCR(c, ce))
+
+			var jobs []string
+			for _, job := range fakeLauncher.Pipelines {
+				jobs = append(jobs, job.Spec.Job)
+			}
+			assert.ElementsMatch(t, tc.expectedJobs, jobs)
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package trigger
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/jobutil"
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+)
+
+type rerunAuthClient interface {
+	teamClient
+	Capabilities() scmprovider.Capabilities
+}
+
+// CanRerun returns true if the user can run the job on demand according to its RerunAuthConfigAnnotation, which
+// restricts it to some users and teams. The teams are looked up in the org of the repository unless given as org/team.
+func CanRerun(spc rerunAuthClient, jb config.JobBase, org, user string) (bool, error) {
+	allowed := jobutil.RerunAuthConfig(jb)
+	if len(allowed) == 0 {
+		return true, nil
+	}
+	var teams []string
+	for _, principal := range allowed {
+		if strings.Contains(principal, "/") {
+			teams = append(teams, principal)
+		} else if scmprovider.NormLogin(principal) == scmprovider.NormLogin(user) {
+			return true, nil
+		}
+	}
+	if len(teams) > 0 && !spc.Capabilities().TeamMembership {
+		return false, fmt.Errorf("the provider does not list the members of teams, cannot check if %s can rerun %s", user, jb.Name)
+	}
+	var lastErr error
+	for _, team := range teams {
+		member, err := trustedTeams.isMember(spc, org, team, user)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if member {
+			return true, nil
+		}
+	}
+	return false, lastErr
+}
+
+// filterRerunAuthorized splits the presubmits the user asked to run into those the user can rerun and those
+// restricted to other users and teams. A presubmit whose restriction cannot be checked is not run.
+func filterRerunAuthorized(c Client, org, user string, presubmits []config.Presubmit) (allowed, denied []config.Presubmit) {
+	for _, presubmit := range presubmits {
+		ok, err := CanRerun(c.SCMProviderClient, presubmit.JobBase, org, user)
+		if err != nil {
+			c.Logger.WithError(err).Warnf("Failed to check if %s can rerun %s.", user, presubmit.Name)
+		}
+		if ok {
+			allowed = append(allowed, presubmit)
+		} else {
+			c.Logger.Infof("Not running %s for %s, who is not allowed to by its %s annotation.", presubmit.Name, user, util.RerunAuthConfigAnnotation)
+			denied = append(denied, presubmit)
+		}
+	}
+	return allowed, denied
+}
+
+// rerunDeniedResponse explains that the user cannot run the presubmits and who can
+func rerunDeniedResponse(denied []config.Presubmit) string {
+	lines := []string{"You are not allowed to run the following jobs, which can only be run on demand by:", ""}
+	for _, presubmit := range denied {
+		lines = append(lines, fmt.Sprintf("* `%s`: %s", presubmit.Name, strings.Join(jobutil.RerunAuthConfig(presubmit.JobBase), ", ")))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// reportRerunDenied comments on the pull request which presubmits the user rerequested from its checks cannot be run
+func reportRerunDenied(c Client, pr *scm.PullRequest, user string, denied []config.Presubmit) error {
+	if len(denied) == 0 {
+		return nil
+	}
+	resp := plugins.FormatSimpleResponse(c.SCMProviderClient.QuoteAuthorForComment(user), rerunDeniedResponse(denied))
+	return c.SCMProviderClient.CreateComment(pr.Base.Repo.Namespace, pr.Base.Repo.Name, pr.Number, true, resp)
+}
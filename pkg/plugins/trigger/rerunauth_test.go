@@ -0,0 +1,64 @@
+package trigger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider/fake"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanRerun(t *testing.T) {
+	trustedTeams = &teamCache{teams: map[string]cachedTeam{}, now: time.Now}
+	testCases := []struct {
+		name       string
+		annotation string
+		user       string
+		expected   bool
+		err        bool
+	}{
+		{
+			name:     "anyone can rerun a job without restriction",
+			user:     "bob",
+			expected: true,
+		},
+		{
+			name:       "an allowed user can rerun the job",
+			annotation: "Alice, org/leads",
+			user:       "alice",
+			expected:   true,
+		},
+		{
+			name:       "a member of an allowed team can rerun the job",
+			annotation: "alice, org/leads",
+			user:       "sig-lead",
+			expected:   true,
+		},
+		{
+			name:       "the other users cannot rerun the job",
+			annotation: "alice, org/leads",
+			user:       "bob",
+		},
+		{
+			name:       "a team which cannot be looked up allows nobody",
+			annotation: "org/missing",
+			user:       "sig-lead",
+			err:        true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			jb := config.JobBase{Name: "deploy", Annotations: map[string]string{util.RerunAuthConfigAnnotation: tc.annotation}}
+			allowed, err := CanRerun(&fake.SCMClient{}, jb, "org", tc.user)
+			if tc.err {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tc.expected, allowed)
+		})
+	}
+}
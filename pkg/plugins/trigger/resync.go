@@ -0,0 +1,71 @@
+package trigger
+
+import (
+	"fmt"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/jobutil"
+	"github.com/jenkins-x/lighthouse/pkg/labels"
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/jenkins-x/lighthouse/pkg/requiredjobs"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// Resync re-evaluates an open pull request as if its webhooks had been delivered: the presubmits of a trusted
+// pull request which should have run but have no status are run, while an untrusted pull request is asked for
+// /ok-to-test unless it already was
+func Resync(pc plugins.Agent, pr *scm.PullRequest) error {
+	return resync(getClient(pc), triggerForPR(pc.PluginConfig, pr), pr)
+}
+
+func resync(c Client, trigger *plugins.Trigger, pr *scm.PullRequest) error {
+	if pr.Closed || pr.Merged || len(c.Config.GetPresubmits(pr.Base.Repo)) == 0 || skipDraft(c, trigger, pr) {
+		return nil
+	}
+	org, repo, a := orgRepoAuthor(*pr)
+	l, trusted, err := TrustedPullRequest(c.SCMProviderClient, trigger, string(a), org, repo, pr.Number, nil)
+	if err != nil {
+		return fmt.Errorf("could not validate PR: %s", err)
+	}
+	if !trusted {
+		if !HonorOkToTest(trigger) || scmprovider.HasLabel(labels.NeedsOkToTest, l) {
+			return nil
+		}
+		c.Logger.Infof("Welcoming PR author %q whose PR was never asked for /ok-to-test.", a)
+		return welcomeMsg(c.SCMProviderClient, trigger, *pr)
+	}
+
+	status, err := c.SCMProviderClient.GetCombinedStatus(org, repo, pr.Head.Sha)
+	if err != nil {
+		return err
+	}
+	_, contexts := getContexts(status)
+	changes := requiredjobs.ChangedFiles(c.SCMProviderClient, org, repo, pr.Number)
+	toTest, toSkip, err := jobutil.FilterPresubmits(jobutil.TestAllFilter(), changes, pr.Base.Ref, presubmitsFor(c, pr.Base.Repo), c.Logger)
+	if err != nil {
+		return err
+	}
+	toTest, toSkip = withoutStatus(toTest, contexts), withoutStatus(toSkip, contexts)
+	if trigger.ElideSkippedContexts {
+		toSkip = nil
+	}
+	if len(toTest) == 0 && len(toSkip) == 0 {
+		return nil
+	}
+	c.Logger.Infof("Starting %d and skipping %d jobs of the PR which have no status.", len(toTest), len(toSkip))
+	return RunAndSkipJobs(c, pr, toTest, toSkip, "", trigger.ElideSkippedContexts)
+}
+
+// withoutStatus returns the presubmits which have not reported their context. Presubmits which never report a
+// status cannot be told apart from those which did not run, so they are left out.
+func withoutStatus(presubmits []config.Presubmit, contexts sets.String) []config.Presubmit {
+	var answer []config.Presubmit
+	for _, job := range presubmits {
+		if !job.SkipReport && !contexts.Has(job.Context) {
+			answer = append(answer, job)
+		}
+	}
+	return answer
+}
@@ -0,0 +1,163 @@
+package trigger
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/labels"
+	"github.com/jenkins-x/lighthouse/pkg/launcher/fake"
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	fake2 "github.com/jenkins-x/lighthouse/pkg/scmprovider/fake"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResync(t *testing.T) {
+	testCases := []struct {
+		name             string
+		author           string
+		closed           bool
+		statuses         []string
+		labels           []string
+		expectedJobs     []string
+		expectedSkipped  []string
+		expectedComment  bool
+		expectedNeedsOk  bool
+		ignoreOkToTest   bool
+		skipDraftPR      bool
+		draft            bool
+		elideSkippedJobs bool
+	}{
+		{
+			name:            "trusted PR without any status runs its jobs",
+			author:          "t",
+			expectedJobs:    []string{"always", "other"},
+			expectedSkipped: []string{"go"},
+		},
+		{
+			name:         "only the jobs without a status run",
+			author:       "t",
+			statuses:     []string{"always", "go"},
+			expectedJobs: []string{"other"},
+		},
+		{
+			name:     "nothing runs once all jobs reported",
+			author:   "t",
+			statuses: []string{"always", "other", "go"},
+		},
+		{
+			name:             "elided skipped contexts are not reported",
+			author:           "t",
+			statuses:         []string{"always", "other"},
+			elideSkippedJobs: true,
+		},
+		{
+			name:   "closed PR",
+			author: "t",
+			closed: true,
+		},
+		{
+			name:        "draft PR skipped until ready",
+			author:      "t",
+			draft:       true,
+			skipDraftPR: true,
+		},
+		{
+			name:            "untrusted PR is asked for ok-to-test",
+			author:          "u",
+			expectedComment: true,
+			expectedNeedsOk: true,
+		},
+		{
+			name:   "untrusted PR already asked for ok-to-test",
+			author: "u",
+			labels: []string{labels.NeedsOkToTest},
+		},
+		{
+			name:           "untrusted PR when ok-to-test is ignored",
+			author:         "u",
+			ignoreOkToTest: true,
+		},
+		{
+			name:         "untrusted PR with ok-to-test",
+			author:       "u",
+			labels:       []string{labels.OkToTest},
+			statuses:     []string{"go"},
+			expectedJobs: []string{"always", "other"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pr := &scm.PullRequest{
+				Number: 1,
+				Author: scm.User{Login: tc.author},
+				Closed: tc.closed,
+				Draft:  tc.draft,
+				Head:   scm.PullRequestBranch{Ref: "feature", Sha: "sha"},
+				Base: scm.PullRequestBranch{
+					Ref:  "master",
+					Repo: scm.Repository{Namespace: "org", Name: "repo", FullName: "org/repo"},
+				},
+			}
+			status := &scm.CombinedStatus{}
+			for _, context := range tc.statuses {
+				status.Statuses = append(status.Statuses, &scm.Status{Label: context, State: scm.StateSuccess})
+			}
+			g := &fake2.SCMClient{
+				OrgMembers:          map[string][]string{"org": {"t"}},
+				PullRequests:        map[int]*scm.PullRequest{1: pr},
+				PullRequestComments: map[int][]*scm.Comment{},
+				PullRequestChanges:  map[int][]*scm.Change{1: {{Path: "README.md"}}},
+				CombinedStatuses:    map[string]*scm.CombinedStatus{"sha": status},
+				CreatedStatuses:     map[string][]*scm.StatusInput{},
+			}
+			for _, label := range tc.labels {
+				g.PullRequestLabelsExisting = append(g.PullRequestLabelsExisting, "org/repo#1:"+label)
+			}
+			fakeLauncher := fake.NewLauncher()
+			c := Client{
+				SCMProviderClient: g,
+				LauncherClient:    fakeLauncher,
+				Config:            &config.Config{},
+				Logger:            logrus.WithField("plugin", PluginName),
+			}
+			presubmits := map[string][]config.Presubmit{
+				"org/repo": {
+					{JobBase: config.JobBase{Name: "always"}, AlwaysRun: true, Reporter: config.Reporter{Context: "always"}},
+					{JobBase: config.JobBase{Name: "other"}, AlwaysRun: true, Reporter: config.Reporter{Context: "other"}},
+					{
+						JobBase:             config.JobBase{Name: "go"},
+						Reporter:            config.Reporter{Context: "go"},
+						RegexpChangeMatcher: config.RegexpChangeMatcher{RunIfChanged: `\.go$`},
+					},
+					{JobBase: config.JobBase{Name: "silent"}, AlwaysRun: true, Reporter: config.Reporter{Context: "silent", SkipReport: true}},
+				},
+			}
+			require.NoError(t, c.Config.SetPresubmits(presubmits))
+			trigger := &plugins.Trigger{
+				TrustedOrg:           "org",
+				OnlyOrgMembers:       true,
+				IgnoreOkToTest:       tc.ignoreOkToTest,
+				SkipDraftPR:          tc.skipDraftPR,
+				ElideSkippedContexts: tc.elideSkippedJobs,
+			}
+
+			require.NoError(t, resync(c, trigger, pr))
+
+			var jobs []string
+			for _, job := range fakeLauncher.Pipelines {
+				jobs = append(jobs, job.Spec.Job)
+			}
+			assert.ElementsMatch(t, tc.expectedJobs, jobs)
+			var skipped []string
+			for _, status := range g.CreatedStatuses["feature"] {
+				skipped = append(skipped, status.Label)
+			}
+			assert.ElementsMatch(t, tc.expectedSkipped, skipped)
+			assert.Equal(t, tc.expectedComment, len(g.PullRequestCommentsAdded) > 0)
+			assert.Equal(t, tc.expectedNeedsOk, len(g.PullRequestLabelsAdded) > 0)
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package trigger
+
+import (
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse/pkg/labels"
+	"github.com/jenkins-x/lighthouse/pkg/requiredjobs"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+)
+
+// skipCIMarker returns the marker in the title or the head commit message of the PR which skips its presubmits, or
+// an empty string if they run
+func skipCIMarker(c Client, pr *scm.PullRequest) string {
+	if c.PluginConfig == nil {
+		return ""
+	}
+	org, repo := pr.Base.Repo.Namespace, pr.Base.Repo.Name
+	trigger := triggerForPR(c.PluginConfig, pr)
+	if !trigger.SkipCI {
+		return ""
+	}
+	texts := []string{pr.Title}
+	commit, err := c.SCMProviderClient.GetSingleCommit(org, repo, pr.Head.Sha)
+	if err != nil {
+		c.Logger.WithError(err).Warn("Failed to get the head commit of the PR, only its title is checked for skip CI markers.")
+	} else if commit != nil {
+		texts = append(texts, commit.Message)
+	}
+	return requiredjobs.SkipCIMarker(trigger.SkipCIMarkers, texts...)
+}
+
+// syncSkipCILabel labels the PR skip-ci if its presubmits are skipped, and removes the label otherwise, so that
+// keeper knows whether the contexts of the presubmits are required
+func syncSkipCILabel(c Client, pr *scm.PullRequest, skipped bool) error {
+	org, repo, number := pr.Base.Repo.Namespace, pr.Base.Repo.Name, pr.Number
+	l, err := c.SCMProviderClient.GetIssueLabels(org, repo, number, true)
+	if err != nil {
+		return err
+	}
+	hasLabel := scmprovider.HasLabel(labels.SkipCI, l)
+	switch {
+	case skipped && !hasLabel:
+		return c.SCMProviderClient.AddLabel(org, repo, number, labels.SkipCI, true)
+	case !skipped && hasLabel:
+		return c.SCMProviderClient.RemoveLabel(org, repo, number, labels.SkipCI, true)
+	}
+	return nil
+}
+
+// handleSkipCILabel removes the skip-ci label when someone other than the bot adds it to a PR without a skip CI
+// marker, as keeper would no longer require its presubmits
+func handleSkipCILabel(c Client, pr scm.PullRequestHook) error {
+	if botName, err := c.SCMProviderClient.BotName(); err == nil && pr.Sender.Login == botName {
+		return nil
+	}
+	if skipCIMarker(c, &pr.PullRequest) != "" {
+		return nil
+	}
+	org, repo, _ := orgRepoAuthor(pr.PullRequest)
+	c.Logger.Infof("Removing label %q added by %q to a PR without skip CI marker.", labels.SkipCI, pr.Sender.Login)
+	return c.SCMProviderClient.RemoveLabel(org, repo, pr.PullRequest.Number, labels.SkipCI, true)
+}
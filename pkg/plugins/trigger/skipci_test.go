@@ -0,0 +1,171 @@
+package trigger
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/labels"
+	"github.com/jenkins-x/lighthouse/pkg/launcher/fake"
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	fake2 "github.com/jenkins-x/lighthouse/pkg/scmprovider/fake"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSkipCI(t *testing.T) {
+	testCases := []struct {
+		name            string
+		title           string
+		message         string
+		existingLabels  []string
+		elide           bool
+		expectedBuilt   bool
+		expectedAdded   []string
+		expectedRemoved []string
+		expectedSkipped int
+	}{
+		{
+			name:          "no marker",
+			title:         "Fix the build",
+			message:       "Fix the build",
+			expectedBuilt: true,
+		},
+		{
+			name:            "marker in the title",
+			title:           "Update the docs [skip ci]",
+			message:         "Update the docs",
+			expectedAdded:   issueLabels(labels.SkipCI),
+			expectedSkipped: 1,
+		},
+		{
+			name:            "marker in the head commit message",
+			title:           "Bump the version",
+			message:         "Bump the version\n\n[CI SKIP]",
+			expectedAdded:   issueLabels(labels.SkipCI),
+			expectedSkipped: 1,
+		},
+		{
+			name:          "elided contexts",
+			title:         "[skip ci] Update the docs",
+			elide:         true,
+			expectedAdded: issueLabels(labels.SkipCI),
+		},
+		{
+			name:            "marker removed by a new commit",
+			title:           "Fix the build",
+			message:         "Fix the build",
+			existingLabels:  issueLabels(labels.SkipCI),
+			expectedBuilt:   true,
+			expectedRemoved: issueLabels(labels.SkipCI),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := &fake2.SCMClient{
+				PullRequestComments:       map[int][]*scm.Comment{},
+				OrgMembers:                map[string][]string{"org": {"t"}},
+				PullRequestLabelsExisting: tc.existingLabels,
+				Commits:                   map[string]*scm.Commit{"sha": {Sha: "sha", Message: tc.message}},
+				CreatedStatuses:           map[string][]*scm.StatusInput{},
+			}
+			fakeLauncher := fake.NewLauncher()
+			trigger := plugins.Trigger{Repos: []string{"org"}, SkipCI: true, ElideSkippedContexts: tc.elide}
+			c := Client{
+				SCMProviderClient: g,
+				LauncherClient:    fakeLauncher,
+				Config:            &config.Config{},
+				Logger:            logrus.WithField("plugin", PluginName),
+				PluginConfig:      &plugins.Configuration{Triggers: []plugins.Trigger{trigger}},
+			}
+			presubmits := map[string][]config.Presubmit{
+				"org/repo": {{JobBase: config.JobBase{Name: "unit"}, Reporter: config.Reporter{Context: "unit"}, AlwaysRun: true}},
+			}
+			require.NoError(t, c.Config.SetPresubmits(presubmits))
+			pr := scm.PullRequestHook{
+				Action: scm.ActionSync,
+				PullRequest: scm.PullRequest{
+					Title:  tc.title,
+					Author: scm.User{Login: "t"},
+					Head:   scm.PullRequestBranch{Ref: "feature", Sha: "sha"},
+					Base: scm.PullRequestBranch{
+						Ref:  "master",
+						Repo: scm.Repository{Namespace: "org", Name: "repo", FullName: "org/repo"},
+					},
+				},
+			}
+			require.NoError(t, handlePR(c, &trigger, pr))
+
+			assert.Equal(t, tc.expectedBuilt, len(fakeLauncher.Pipelines) > 0)
+			assert.Equal(t, tc.expectedAdded, g.PullRequestLabelsAdded)
+			assert.Equal(t, tc.expectedRemoved, g.PullRequestLabelsRemoved)
+			skipped := g.CreatedStatuses["feature"]
+			require.Len(t, skipped, tc.expectedSkipped)
+			for _, status := range skipped {
+				assert.Equal(t, scm.StateSuccess, status.State)
+			}
+		})
+	}
+}
+
+func TestSkipCILabel(t *testing.T) {
+	testCases := []struct {
+		name            string
+		sender          string
+		title           string
+		expectedRemoved []string
+	}{
+		{
+			name:            "added to a PR without marker",
+			sender:          "t",
+			title:           "Fix the build",
+			expectedRemoved: issueLabels(labels.SkipCI),
+		},
+		{
+			name:   "added to a PR with a marker",
+			sender: "t",
+			title:  "Update the docs [skip ci]",
+		},
+		{
+			name:   "added by the bot",
+			sender: fake2.Bot,
+			title:  "Fix the build",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := &fake2.SCMClient{
+				PullRequestLabelsExisting: issueLabels(labels.SkipCI),
+				Commits:                   map[string]*scm.Commit{},
+			}
+			trigger := plugins.Trigger{Repos: []string{"org"}, SkipCI: true}
+			c := Client{
+				SCMProviderClient: g,
+				LauncherClient:    fake.NewLauncher(),
+				Config:            &config.Config{},
+				Logger:            logrus.WithField("plugin", PluginName),
+				PluginConfig:      &plugins.Configuration{Triggers: []plugins.Trigger{trigger}},
+			}
+			presubmits := map[string][]config.Presubmit{
+				"org/repo": {{JobBase: config.JobBase{Name: "unit"}, Reporter: config.Reporter{Context: "unit"}, AlwaysRun: true}},
+			}
+			require.NoError(t, c.Config.SetPresubmits(presubmits))
+			pr := scm.PullRequestHook{
+				Action: scm.ActionLabel,
+				Label:  scm.Label{Name: labels.SkipCI},
+				Sender: scm.User{Login: tc.sender},
+				PullRequest: scm.PullRequest{
+					Title: tc.title,
+					Head:  scm.PullRequestBranch{Sha: "sha"},
+					Base: scm.PullRequestBranch{
+						Ref:  "master",
+						Repo: scm.Repository{Namespace: "org", Name: "repo", FullName: "org/repo"},
+					},
+				},
+			}
+			require.NoError(t, handlePR(c, &trigger, pr))
+			assert.Equal(t, tc.expectedRemoved, g.PullRequestLabelsRemoved)
+		})
+	}
+}
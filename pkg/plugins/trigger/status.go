@@ -0,0 +1,43 @@
+package trigger
+
+import (
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/jobutil"
+	"github.com/jenkins-x/lighthouse/pkg/payload"
+)
+
+// handleSE runs the postsubmits triggered by the context of the status reaching its state on each branch whose head
+// is the commit of the status. These postsubmits do not run when the commit is pushed, and as the status tells
+// nothing of the changed files they run whatever their run_if_changed.
+func handleSE(c Client, se payload.StatusHook) error {
+	if se.SHA == "" || se.Context == "" {
+		return nil
+	}
+	for _, j := range c.Config.GetPostsubmits(se.Repo) {
+		context, state := jobutil.TriggerContext(j.JobBase)
+		if context != se.Context || state != se.State {
+			continue
+		}
+		for _, branch := range se.Branches {
+			if !jobutil.RefMatches(j.Brancher, "refs/heads/"+branch) {
+				continue
+			}
+			refs := v1alpha1.Refs{
+				Org:     se.Repo.Namespace,
+				Repo:    se.Repo.Name,
+				BaseRef: branch,
+				BaseSHA: se.SHA,
+			}
+			labels := make(map[string]string)
+			for k, v := range j.Labels {
+				labels[k] = v
+			}
+			pj := jobutil.NewLighthouseJob(jobutil.PostsubmitSpec(j, refs), labels, j.Annotations)
+			c.Logger.WithFields(jobutil.LighthouseJobFields(&pj)).Infof("Creating a new LighthouseJob as %s reached %s.", se.Context, se.State)
+			if _, err := c.LauncherClient.Launch(&pj, c.MetapipelineClient, se.Repository()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package trigger
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/launcher/fake"
+	"github.com/jenkins-x/lighthouse/pkg/payload"
+	fake2 "github.com/jenkins-x/lighthouse/pkg/scmprovider/fake"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleSE(t *testing.T) {
+	repo := scm.Repository{Namespace: "org", Name: "repo", FullName: "org/repo"}
+	testCases := []struct {
+		name     string
+		se       payload.StatusHook
+		expected []string
+	}{
+		{
+			name:     "context succeeded",
+			se:       payload.StatusHook{Repo: repo, SHA: "abc", Context: "security-scan", State: "success", Branches: []string{"master"}},
+			expected: []string{"publish-image"},
+		},
+		{
+			name:     "context failed",
+			se:       payload.StatusHook{Repo: repo, SHA: "abc", Context: "security-scan", State: "failure", Branches: []string{"master"}},
+			expected: []string{"report-vulnerabilities"},
+		},
+		{
+			name: "context pending",
+			se:   payload.StatusHook{Repo: repo, SHA: "abc", Context: "security-scan", State: "pending", Branches: []string{"master"}},
+		},
+		{
+			name: "other context",
+			se:   payload.StatusHook{Repo: repo, SHA: "abc", Context: "lint", State: "success", Branches: []string{"master"}},
+		},
+		{
+			name: "branch not matching",
+			se:   payload.StatusHook{Repo: repo, SHA: "abc", Context: "security-scan", State: "success", Branches: []string{"feature"}},
+		},
+		{
+			name: "commit not the head of a branch",
+			se:   payload.StatusHook{Repo: repo, SHA: "abc", Context: "security-scan", State: "success"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fakeLauncher := fake.NewLauncher()
+			c := Client{
+				SCMProviderClient: &fake2.SCMClient{},
+				LauncherClient:    fakeLauncher,
+				Config:            &config.Config{ProwConfig: config.ProwConfig{LighthouseJobNamespace: "lighthouseJobs"}},
+				Logger:            logrus.WithField("plugin", PluginName),
+			}
+			postsubmits := map[string][]config.Postsubmit{
+				"org/repo": {
+					{
+						JobBase: config.JobBase{Name: "build"},
+					},
+					{
+						JobBase: config.JobBase{
+							Name:        "publish-image",
+							Annotations: map[string]string{util.TriggerOnContextAnnotation: "security-scan"},
+						},
+						Brancher: config.Brancher{Branches: []string{"master"}},
+					},
+					{
+						JobBase: config.JobBase{
+							Name:        "report-vulnerabilities",
+							Annotations: map[string]string{util.TriggerOnContextAnnotation: "security-scan=failure"},
+						},
+					},
+				},
+			}
+			require.NoError(t, c.Config.SetPostsubmits(postsubmits))
+			require.NoError(t, handleSE(c, tc.se))
+
+			var started []string
+			for _, job := range fakeLauncher.Pipelines {
+				started = append(started, job.Spec.Job)
+				assert.Equal(t, "abc", job.Spec.Refs.BaseSHA)
+				assert.Equal(t, "master", job.Spec.Refs.BaseRef)
+			}
+			assert.Equal(t, tc.expected, started)
+		})
+	}
+}
+
+func TestHandlePESkipsJobsTriggeredByContext(t *testing.T) {
+	fakeLauncher := fake.NewLauncher()
+	c := Client{
+		SCMProviderClient: &fake2.SCMClient{},
+		LauncherClient:    fakeLauncher,
+		Config:            &config.Config{ProwConfig: config.ProwConfig{LighthouseJobNamespace: "lighthouseJobs"}},
+		Logger:            logrus.WithField("plugin", PluginName),
+	}
+	postsubmits := map[string][]config.Postsubmit{
+		"org/repo": {
+			{
+				JobBase: config.JobBase{Name: "build"},
+			},
+			{
+				JobBase: config.JobBase{
+					Name:        "publish-image",
+					Annotations: map[string]string{util.TriggerOnContextAnnotation: "security-scan"},
+				},
+			},
+		},
+	}
+	require.NoError(t, c.Config.SetPostsubmits(postsubmits))
+	pe := scm.PushHook{Ref: "refs/heads/master", After: "abc", Repo: scm.Repository{Namespace: "org", Name: "repo", FullName: "org/repo"}}
+	require.NoError(t, handlePE(c, pe))
+
+	require.Len(t, fakeLauncher.Pipelines, 1)
+	for _, job := range fakeLauncher.Pipelines {
+		assert.Equal(t, "build", job.Spec.Job)
+	}
+}
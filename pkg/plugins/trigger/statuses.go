@@ -0,0 +1,136 @@
+package trigger
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// statusAttempts is how many times a status is written before it is left to the reconciliation
+	statusAttempts = 3
+	// maxUnreportedStatuses bounds the statuses waiting to be reconciled, the oldest being dropped first
+	maxUnreportedStatuses = 1000
+	// unreportedStatusTTL is how long a status is reconciled before it is given up on
+	unreportedStatusTTL = 24 * time.Hour
+)
+
+// statusBackoff is the delay before the second attempt to write a status, doubled for each further attempt
+var statusBackoff = time.Second
+
+type statusClient interface {
+	CreateStatus(org, repo, ref string, s *scm.StatusInput) (*scm.Status, error)
+	GetCombinedStatus(org, repo, ref string) (*scm.CombinedStatus, error)
+}
+
+// createStatus writes the status, retrying with backoff as the writes fail transiently when the provider rate
+// limits or is unavailable
+func createStatus(spc statusClient, org, repo, ref string, status *scm.StatusInput) error {
+	var err error
+	backoff := statusBackoff
+	for attempt := 1; attempt <= statusAttempts; attempt++ {
+		if _, err = spc.CreateStatus(org, repo, ref, status); err == nil || err == scm.ErrNotFound {
+			return err
+		}
+		if attempt < statusAttempts {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}
+	return err
+}
+
+// unreportedStatus is a status which could not be written, along with the client to write it with
+type unreportedStatus struct {
+	client    statusClient
+	org       string
+	repo      string
+	ref       string
+	status    *scm.StatusInput
+	firstSeen time.Time
+}
+
+func (s *unreportedStatus) key() string {
+	return fmt.Sprintf("%s/%s@%s:%s", s.org, s.repo, s.ref, s.status.Label)
+}
+
+// statusQueue holds the statuses to write again once the provider is available
+type statusQueue struct {
+	lock     sync.Mutex
+	statuses map[string]*unreportedStatus
+	order    []string
+}
+
+var unreportedStatuses = &statusQueue{statuses: map[string]*unreportedStatus{}}
+
+func (q *statusQueue) add(s *unreportedStatus) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	key := s.key()
+	if _, ok := q.statuses[key]; !ok {
+		q.order = append(q.order, key)
+	}
+	q.statuses[key] = s
+	for len(q.order) > maxUnreportedStatuses {
+		delete(q.statuses, q.order[0])
+		q.order = q.order[1:]
+	}
+}
+
+func (q *statusQueue) list() []*unreportedStatus {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	answer := make([]*unreportedStatus, 0, len(q.order))
+	for _, key := range q.order {
+		answer = append(answer, q.statuses[key])
+	}
+	return answer
+}
+
+func (q *statusQueue) remove(s *unreportedStatus) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	key := s.key()
+	if q.statuses[key] != s {
+		// the status was replaced by a newer one in the meantime
+		return
+	}
+	delete(q.statuses, key)
+	for i, k := range q.order {
+		if k == key {
+			q.order = append(q.order[:i], q.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// ReconcileStatuses writes the skipped statuses which failed to be written by the trigger plugin, unless their
+// contexts were reported since. The statuses still failing are tried again on the next call until they expire.
+func ReconcileStatuses(logger *logrus.Entry) {
+	for _, s := range unreportedStatuses.list() {
+		l := logger.WithFields(logrus.Fields{"org": s.org, "repo": s.repo, "ref": s.ref, "context": s.status.Label})
+		if time.Since(s.firstSeen) > unreportedStatusTTL {
+			l.Error("Giving up on reporting the status.")
+			unreportedStatuses.remove(s)
+			continue
+		}
+		combined, err := s.client.GetCombinedStatus(s.org, s.repo, s.ref)
+		if err != nil {
+			l.WithError(err).Warn("Failed to get the statuses, the status is reported later.")
+			continue
+		}
+		if _, contexts := getContexts(combined); contexts.Has(s.status.Label) {
+			unreportedStatuses.remove(s)
+			continue
+		}
+		if _, err := s.client.CreateStatus(s.org, s.repo, s.ref, s.status); err != nil {
+			l.WithError(err).Warn("Failed to report the status, it is reported later.")
+			continue
+		}
+		l.Info("Reported the status which previously failed.")
+		unreportedStatuses.remove(s)
+	}
+}
@@ -0,0 +1,81 @@
+package trigger
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	fake2 "github.com/jenkins-x/lighthouse/pkg/scmprovider/fake"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyClient fails to create statuses the given number of times
+type flakyClient struct {
+	*fake2.SCMClient
+	failures int
+	attempts int
+}
+
+func (f *flakyClient) CreateStatus(owner, repo, ref string, s *scm.StatusInput) (*scm.Status, error) {
+	f.attempts++
+	if f.failures > 0 {
+		f.failures--
+		return nil, errors.New("502 Bad Gateway")
+	}
+	return f.SCMClient.CreateStatus(owner, repo, ref, s)
+}
+
+func TestSkippedStatusesAreRetried(t *testing.T) {
+	backoff := statusBackoff
+	statusBackoff = 0
+	defer func() { statusBackoff = backoff }()
+
+	pr := &scm.PullRequest{
+		Number: 1,
+		Head:   scm.PullRequestBranch{Ref: "feature", Sha: "head"},
+		Base: scm.PullRequestBranch{
+			Ref:  "master",
+			Repo: scm.Repository{Namespace: "org", Name: "repo"},
+		},
+	}
+	jobs := []config.Presubmit{
+		{JobBase: config.JobBase{Name: "unit"}, Reporter: config.Reporter{Context: "unit"}},
+	}
+
+	spc := &flakyClient{SCMClient: &fake2.SCMClient{}, failures: statusAttempts - 1}
+	c := Client{SCMProviderClient: spc, Logger: logrus.WithField("plugin", PluginName)}
+	require.NoError(t, skipRequested(c, pr, jobs))
+	assert.Equal(t, statusAttempts, spc.attempts)
+	require.Len(t, spc.CreatedStatuses["feature"], 1)
+	assert.Empty(t, unreportedStatuses.list())
+
+	spc = &flakyClient{SCMClient: &fake2.SCMClient{}, failures: statusAttempts + 1}
+	c.SCMProviderClient = spc
+	require.Error(t, skipRequested(c, pr, jobs))
+	assert.Empty(t, spc.CreatedStatuses["feature"])
+	require.Len(t, unreportedStatuses.list(), 1)
+
+	// the provider is still failing
+	ReconcileStatuses(c.Logger)
+	require.Len(t, unreportedStatuses.list(), 1)
+
+	ReconcileStatuses(c.Logger)
+	assert.Empty(t, unreportedStatuses.list())
+	require.Len(t, spc.CreatedStatuses["feature"], 1)
+	assert.Equal(t, "Skipped.", spc.CreatedStatuses["feature"][0].Desc)
+
+	// the statuses reported since are not overwritten
+	spc = &flakyClient{SCMClient: &fake2.SCMClient{
+		CombinedStatuses: map[string]*scm.CombinedStatus{
+			"feature": {Statuses: []*scm.Status{{Label: "unit", State: scm.StatePending}}},
+		},
+	}, failures: statusAttempts}
+	c.SCMProviderClient = spc
+	require.Error(t, skipRequested(c, pr, jobs))
+	ReconcileStatuses(c.Logger)
+	assert.Empty(t, unreportedStatuses.list())
+	assert.Empty(t, spc.CreatedStatuses["feature"])
+}
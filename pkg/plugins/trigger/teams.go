@@ -0,0 +1,80 @@
+package trigger
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// teamMembershipTTL is how long the members of a trusted team are cached, so that each event does not list
+// the teams of the org again
+const teamMembershipTTL = 5 * time.Minute
+
+type teamClient interface {
+	ListTeams(org string) ([]*scm.Team, error)
+	ListTeamMembers(id int, role string) ([]*scm.TeamMember, error)
+}
+
+type cachedTeam struct {
+	members sets.String
+	fetched time.Time
+}
+
+// teamCache caches the normalized logins of the members of teams, keyed by org/slug
+type teamCache struct {
+	lock  sync.Mutex
+	teams map[string]cachedTeam
+	now   func() time.Time
+}
+
+var trustedTeams = &teamCache{
+	teams: map[string]cachedTeam{},
+	now:   time.Now,
+}
+
+// isMember returns true if the user belongs to the team, given as a slug of the org or as org/slug
+func (c *teamCache) isMember(spc teamClient, org, team, user string) (bool, error) {
+	if parts := strings.SplitN(team, "/", 2); len(parts) == 2 {
+		org, team = parts[0], parts[1]
+	}
+	members, err := c.members(spc, org, team)
+	if err != nil {
+		return false, err
+	}
+	return members.Has(scmprovider.NormLogin(user)), nil
+}
+
+func (c *teamCache) members(spc teamClient, org, slug string) (sets.String, error) {
+	key := org + "/" + slug
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if cached, ok := c.teams[key]; ok && c.now().Sub(cached.fetched) < teamMembershipTTL {
+		return cached.members, nil
+	}
+	teams, err := spc.ListTeams(org)
+	if err != nil {
+		return nil, fmt.Errorf("error in ListTeams(%s): %v", org, err)
+	}
+	for _, team := range teams {
+		if !strings.EqualFold(team.Slug, slug) && !strings.EqualFold(team.Name, slug) {
+			continue
+		}
+		members, err := spc.ListTeamMembers(team.ID, scmprovider.RoleAll)
+		if err != nil {
+			return nil, fmt.Errorf("error in ListTeamMembers(%s): %v", key, err)
+		}
+		logins := sets.NewString()
+		for _, member := range members {
+			logins.Insert(scmprovider.NormLogin(member.Login))
+		}
+		c.teams[key] = cachedTeam{members: logins, fetched: c.now()}
+		return logins, nil
+	}
+	return nil, fmt.Errorf("no team %s in org %s", slug, org)
+}
@@ -0,0 +1,81 @@
+package trigger
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/censor"
+	"github.com/jenkins-x/lighthouse/pkg/errorutil"
+	"github.com/jenkins-x/lighthouse/pkg/jobutil"
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/jenkins-x/lighthouse/pkg/requiredjobs"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+)
+
+// handleTestTrusted runs the presubmits of the PR as /test all would when one of the trusted testers comments
+// /test-trusted in a repository ignoring /ok-to-test. The jobs run with the restricted service account and without
+// secrets, and the PR is not labeled ok-to-test, so that it stays untrusted for its next commits.
+func handleTestTrusted(c Client, trigger *plugins.Trigger, gc scmprovider.GenericCommentEvent, pr *scm.PullRequest) error {
+	org, repo, number := gc.Repo.Namespace, gc.Repo.Name, gc.Number
+	if !isTrustedTester(trigger, gc.Author.Login) || trigger.RestrictedServiceAccount == "" {
+		resp := "Only the trusted testers of this repository can run the tests of the PR with `" + util.FormatCommand("/test-trusted") + "`."
+		c.Logger.Infof("Commenting \"%s\".", resp)
+		return c.SCMProviderClient.CreateComment(org, repo, number, true, plugins.FormatResponseRaw(gc.Body, gc.Link, c.SCMProviderClient.QuoteAuthorForComment(gc.Author.Login), resp))
+	}
+
+	changes := requiredjobs.ChangedFiles(c.SCMProviderClient, org, repo, number)
+	toTest, toSkip, err := jobutil.FilterPresubmits(jobutil.TestAllFilter(), changes, pr.Base.Ref, presubmitsFor(c, gc.Repo), c.Logger)
+	if err != nil {
+		return err
+	}
+	toTest, denied := filterRerunAuthorized(c, org, gc.Author.Login, toTest)
+	if len(denied) > 0 {
+		resp := rerunDeniedResponse(denied)
+		c.Logger.Infof("Commenting \"%s\".", resp)
+		if err := c.SCMProviderClient.CreateComment(org, repo, number, true, plugins.FormatResponseRaw(gc.Body, gc.Link, c.SCMProviderClient.QuoteAuthorForComment(gc.Author.Login), resp)); err != nil {
+			return err
+		}
+	}
+	if err := validateContextOverlap(toTest, toSkip); err != nil {
+		c.Logger.WithError(err).Warn("Could not run or skip requested jobs, overlapping contexts.")
+		return err
+	}
+	c.Logger.Infof("Running the presubmits as %s, requested by %s.", trigger.RestrictedServiceAccount, gc.Author.Login)
+	runErr := runRequestedAs(c, pr, toTest, gc.GUID, trigger.RestrictedServiceAccount)
+	var skipErr error
+	if !trigger.ElideSkippedContexts {
+		skipErr = skipRequested(c, pr, toSkip)
+	}
+	return errorutil.NewAggregate(runErr, skipErr)
+}
+
+// isTrustedTester returns true if the user is one of the trusted testers of the trigger configuration
+func isTrustedTester(trigger *plugins.Trigger, user string) bool {
+	for _, login := range trigger.TrustedTesters {
+		if scmprovider.NormLogin(login) == scmprovider.NormLogin(user) {
+			return true
+		}
+	}
+	return false
+}
+
+// restrictJob makes the job of an untrusted PR run with the restricted service account and without the secrets of
+// its environment. A job on an agent ignoring the service account, or whose pod spec reads secrets, which cannot be
+// removed without breaking it, is refused.
+func restrictJob(pj *v1alpha1.LighthouseJob, serviceAccount string) error {
+	spec := &pj.Spec
+	switch spec.Agent {
+	case "", v1alpha1.TektonAgent, v1alpha1.KubernetesAgent:
+	default:
+		return fmt.Errorf("the %s agent does not run jobs with the service account", spec.Agent)
+	}
+	if secrets := censor.SecretNames(spec.PodSpec); len(secrets) > 0 {
+		return fmt.Errorf("the job reads the secrets %s", strings.Join(secrets, ", "))
+	}
+	spec.ServiceAccountName = serviceAccount
+	spec.EnvFromSecrets = nil
+	return nil
+}
@@ -0,0 +1,160 @@
+package trigger
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/launcher/fake"
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	fake2 "github.com/jenkins-x/lighthouse/pkg/scmprovider/fake"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestHandleTestTrusted(t *testing.T) {
+	testCases := []struct {
+		name           string
+		author         string
+		ignoreOkToTest bool
+		agent          string
+		expectedJobs   []string
+		expectComment  bool
+		expectStatus   bool
+	}{
+		{
+			name:           "trusted tester",
+			author:         "Maintainer",
+			ignoreOkToTest: true,
+			expectedJobs:   []string{"job"},
+		},
+		{
+			name:           "trusted member who is not a trusted tester",
+			author:         "trusted-member",
+			ignoreOkToTest: true,
+			expectComment:  true,
+		},
+		{
+			name:           "agent ignoring the restricted service account",
+			author:         "maintainer",
+			ignoreOkToTest: true,
+			agent:          "jenkins",
+			expectStatus:   true,
+		},
+		{
+			name:   "ok-to-test is honored",
+			author: "maintainer",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := &fake2.SCMClient{
+				IssueComments:       map[int][]*scm.Comment{},
+				PullRequestComments: map[int][]*scm.Comment{},
+				OrgMembers:          map[string][]string{"org": {"trusted-member"}},
+				PullRequests: map[int]*scm.PullRequest{
+					1: {
+						Author: scm.User{Login: "contributor"},
+						Number: 1,
+						Head:   scm.PullRequestBranch{Sha: "cafe"},
+						Base: scm.PullRequestBranch{
+							Ref:  "master",
+							Repo: scm.Repository{Namespace: "org", Name: "repo"},
+						},
+					},
+				},
+			}
+			fakeLauncher := fake.NewLauncher()
+			c := Client{
+				SCMProviderClient: g,
+				LauncherClient:    fakeLauncher,
+				Config:            &config.Config{ProwConfig: config.ProwConfig{LighthouseJobNamespace: "lighthouseJobs"}},
+				Logger:            logrus.WithField("plugin", PluginName),
+			}
+			presubmits := map[string][]config.Presubmit{
+				"org/repo": {
+					{
+						JobBase:   config.JobBase{Name: "job", Agent: tc.agent},
+						AlwaysRun: true,
+						Reporter:  config.Reporter{Context: "pull-job"},
+					},
+					{
+						JobBase:      config.JobBase{Name: "manual"},
+						Reporter:     config.Reporter{Context: "pull-manual"},
+						Trigger:      `(?m)^/test manual$`,
+						RerunCommand: "/test manual",
+					},
+				},
+			}
+			require.NoError(t, c.Config.SetPresubmits(presubmits))
+			trigger := &plugins.Trigger{
+				IgnoreOkToTest:           tc.ignoreOkToTest,
+				TrustedTesters:           []string{"maintainer"},
+				RestrictedServiceAccount: "untrusted-tester",
+			}
+			event := scmprovider.GenericCommentEvent{
+				Action:      scm.ActionCreate,
+				Repo:        scm.Repository{Namespace: "org", Name: "repo", FullName: "org/repo"},
+				Body:        "/test-trusted",
+				Author:      scm.User{Login: tc.author},
+				IssueAuthor: scm.User{Login: "contributor"},
+				IssueState:  "open",
+				IsPR:        true,
+				Number:      1,
+			}
+			require.NoError(t, handleGenericComment(c, trigger, event))
+
+			var started []string
+			for _, job := range fakeLauncher.Pipelines {
+				started = append(started, job.Spec.Job)
+				assert.Equal(t, "untrusted-tester", job.Spec.ServiceAccountName)
+			}
+			assert.Equal(t, tc.expectedJobs, started)
+			assert.Empty(t, g.PullRequestLabelsAdded)
+			if tc.expectStatus {
+				require.Len(t, g.CreatedStatuses["cafe"], 1)
+				assert.Equal(t, scm.StateError, g.CreatedStatuses["cafe"][0].State)
+			} else {
+				assert.Empty(t, g.CreatedStatuses["cafe"])
+			}
+			if tc.expectComment {
+				assert.Len(t, g.PullRequestComments[1], 1)
+			} else {
+				assert.Empty(t, g.PullRequestComments[1])
+			}
+		})
+	}
+}
+
+func TestRestrictJob(t *testing.T) {
+	pj := &v1alpha1.LighthouseJob{Spec: v1alpha1.LighthouseJobSpec{
+		Agent:          v1alpha1.KubernetesAgent,
+		PodSpec:        &corev1.PodSpec{Containers: []corev1.Container{{Name: "build"}}},
+		EnvFromSecrets: []string{"npm-token"},
+	}}
+	require.NoError(t, restrictJob(pj, "untrusted-tester"))
+	assert.Equal(t, "untrusted-tester", pj.Spec.ServiceAccountName)
+	assert.Empty(t, pj.Spec.EnvFromSecrets, "the secrets of the environment are removed")
+
+	pj = &v1alpha1.LighthouseJob{Spec: v1alpha1.LighthouseJobSpec{Agent: v1alpha1.TektonAgent}}
+	assert.NoError(t, restrictJob(pj, "untrusted-tester"))
+
+	pj = &v1alpha1.LighthouseJob{Spec: v1alpha1.LighthouseJobSpec{Agent: v1alpha1.JenkinsAgent}}
+	assert.Error(t, restrictJob(pj, "untrusted-tester"), "jenkins ignores the service account")
+
+	pj = &v1alpha1.LighthouseJob{Spec: v1alpha1.LighthouseJobSpec{
+		Agent: v1alpha1.KubernetesAgent,
+		PodSpec: &corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "build"}},
+			Volumes:    []corev1.Volume{{Name: "token", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "deploy-key"}}}},
+		},
+	}}
+	err := restrictJob(pj, "untrusted-tester")
+	require.Error(t, err, "the secrets of the pod spec cannot be removed")
+	assert.Contains(t, err.Error(), "deploy-key")
+	assert.Empty(t, pj.Spec.ServiceAccountName)
+}
@@ -19,6 +19,7 @@ package trigger
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/jenkins-x/go-scm/scm"
 	"github.com/jenkins-x/jx/v2/pkg/tekton/metapipeline"
@@ -26,9 +27,12 @@ import (
 	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
 	"github.com/jenkins-x/lighthouse/pkg/errorutil"
 	"github.com/jenkins-x/lighthouse/pkg/jobutil"
+	"github.com/jenkins-x/lighthouse/pkg/payload"
 	"github.com/jenkins-x/lighthouse/pkg/pluginhelp"
 	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/jenkins-x/lighthouse/pkg/requiredjobs"
 	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	"github.com/jenkins-x/lighthouse/pkg/store"
 	"github.com/sirupsen/logrus"
 	"k8s.io/apimachinery/pkg/util/sets"
 )
@@ -42,6 +46,8 @@ func init() {
 	plugins.RegisterGenericCommentHandler(PluginName, handleGenericCommentEvent, helpProvider)
 	plugins.RegisterPullRequestHandler(PluginName, handlePullRequest, helpProvider)
 	plugins.RegisterPushEventHandler(PluginName, handlePush, helpProvider)
+	plugins.RegisterStatusEventHandler(PluginName, handleStatus, helpProvider)
+	plugins.RegisterCheckRerequestHandler(PluginName, handleCheckRerequest, helpProvider)
 }
 
 func helpProvider(config *plugins.Configuration, enabledRepos []string) (*pluginhelp.PluginHelp, error) {
@@ -62,11 +68,46 @@ func helpProvider(config *plugins.Configuration, enabledRepos []string) (*plugin
 			org = trigger.TrustedOrg
 		}
 		configInfo[orgRepo] = fmt.Sprintf("The trusted GitHub organization for this repository is %q.", org)
+		if len(trigger.TrustedTeams) > 0 {
+			configInfo[orgRepo] += fmt.Sprintf(" Members of the teams %s are trusted too.", strings.Join(trigger.TrustedTeams, ", "))
+		}
+		if len(trigger.TrustedUsers) > 0 {
+			configInfo[orgRepo] += fmt.Sprintf(" The users %s are trusted too.", strings.Join(trigger.TrustedUsers, ", "))
+		}
+		if trigger.TrustedLabel != "" {
+			configInfo[orgRepo] += fmt.Sprintf(" Trusted users can add the %q label to a PR instead of commenting '/ok-to-test'.", trigger.TrustedLabel)
+		}
+		if trigger.SkipDraftPR {
+			configInfo[orgRepo] += " The jobs of draft PRs are run once they are ready for review."
+		}
+		if trigger.SkipCI {
+			markers := trigger.SkipCIMarkers
+			if len(markers) == 0 {
+				markers = requiredjobs.DefaultSkipCIMarkers
+			}
+			configInfo[orgRepo] += fmt.Sprintf(" The jobs of PRs whose title or head commit message contains %s are skipped unless requested.", strings.Join(markers, " or "))
+		}
+		for _, quota := range trigger.Quotas {
+			configInfo[orgRepo] += fmt.Sprintf(" At most %d jobs are run per %s every %s.", quota.Max, quota.Scope, quota.WindowDuration)
+		}
+		if limit := trigger.CommandRateLimit; limit != nil {
+			configInfo[orgRepo] += fmt.Sprintf(" Each user can comment at most %d test commands per PR every %s.", limit.Max, limit.WindowDuration)
+		}
+		if trigger.CommentOnDuplicateJobs {
+			configInfo[orgRepo] += " The jobs which are not started again as they are still running for the head of the PR are linked in a comment."
+		}
+		if trigger.IgnoreOkToTest && len(trigger.TrustedTesters) > 0 {
+			configInfo[orgRepo] += fmt.Sprintf(" '/ok-to-test' is ignored, but %s can run the presubmits of untrusted PRs with '/test-trusted' using the %q service account.", strings.Join(trigger.TrustedTesters, ", "), trigger.RestrictedServiceAccount)
+		}
 	}
 	pluginHelp := &pluginhelp.PluginHelp{
 		Description: `The trigger plugin starts tests in reaction to commands and pull request events. It is responsible for ensuring that test jobs are only run on trusted PRs. A PR is considered trusted if the author is a member of the 'trusted organization' for the repository or if such a member has left an '/ok-to-test' command on the PR.
 <br>Trigger starts jobs automatically when a new trusted PR is created or when an untrusted PR becomes trusted, but it can also be used to start jobs manually via the '/test' command.
-<br>The '/retest' command can be used to rerun jobs that have reported failure.`,
+<br>The '/retest' command can be used to rerun jobs that have reported failure. The jobs still running for the head of the PR are not started again.
+<br>The PRs of untrusted users are labeled 'needs-ok-to-test' until a trusted user comments '/ok-to-test' or adds the 'ok-to-test' label, which is removed if anyone else adds it. The PR stays trusted for its new commits until the 'ok-to-test' label is removed again.
+<br>Postsubmits with the 'lighthouse.jenkins-x.io/triggerOnContext' annotation, such as 'security-scan=success', are started when the status or check of that context reaches the state on the head of a branch rather than when the branch is pushed.
+<br>Postsubmits with the 'lighthouse.jenkins-x.io/issueTrigger' annotation are started when a trusted user comments a command matching its regular expression on an issue, such as '/release patch', and their result is commented on the issue.
+<br>Presubmits with the 'lighthouse.jenkins-x.io/rerunAuthConfig' annotation, such as 'alice,myorg/release-managers', can only be run on demand with '/test', '/retest', '/test-trusted' or the "Re-run" button of their check by the users and teams it lists.`,
 		Config: configInfo,
 	}
 	pluginHelp.AddCommand(pluginhelp.Command{
@@ -90,6 +131,20 @@ func helpProvider(config *plugins.Configuration, enabledRepos []string) (*plugin
 		WhoCanUse:   "Anyone can trigger this command on a trusted PR.",
 		Examples:    []string{"/retest", "/lh-retest"},
 	})
+	pluginHelp.AddCommand(pluginhelp.Command{
+		Usage:       "/abort [<job name>...]",
+		Description: "Aborts the jobs running for the head of a PR, or only the named ones, and fails their contexts.",
+		Featured:    false,
+		WhoCanUse:   "The author of the PR and the trusted users of the repo.",
+		Examples:    []string{"/abort", "/abort pull-bazel-test", "/lh-abort"},
+	})
+	pluginHelp.AddCommand(pluginhelp.Command{
+		Usage:       "/test-trusted",
+		Description: "Starts all the test jobs of an untrusted PR with a restricted service account and without secrets, in repositories ignoring '/ok-to-test'. The PR stays untrusted.",
+		Featured:    false,
+		WhoCanUse:   "The trusted testers of the repo.",
+		Examples:    []string{"/test-trusted", "/lh-test-trusted"},
+	})
 	return pluginHelp, nil
 }
 
@@ -98,10 +153,14 @@ type scmProviderClient interface {
 	BotName() (string, error)
 	IsCollaborator(org, repo, user string) (bool, error)
 	IsMember(org, user string) (bool, error)
+	ListTeams(org string) ([]*scm.Team, error)
+	ListTeamMembers(id int, role string) ([]*scm.TeamMember, error)
 	GetPullRequest(org, repo string, number int) (*scm.PullRequest, error)
 	GetRef(org, repo, ref string) (string, error)
+	GetSingleCommit(org, repo, SHA string) (*scm.Commit, error)
 	CreateComment(owner, repo string, number int, pr bool, comment string) error
 	ListIssueComments(owner, repo string, issue int) ([]*scm.Comment, error)
+	ListPullRequestComments(owner, repo string, number int) ([]*scm.Comment, error)
 	CreateStatus(org, repo, ref string, s *scm.StatusInput) (*scm.Status, error)
 	GetCombinedStatus(org, repo, ref string) (*scm.CombinedStatus, error)
 	GetPullRequestChanges(org, repo string, number int) ([]*scm.Change, error)
@@ -109,6 +168,8 @@ type scmProviderClient interface {
 	DeleteStaleComments(org, repo string, number int, comments []*scm.Comment, pr bool, isStale func(*scm.Comment) bool) error
 	GetIssueLabels(org, repo string, number int, pr bool) ([]*scm.Label, error)
 	QuoteAuthorForComment(string) string
+	Capabilities() scmprovider.Capabilities
+	CreateCommentReaction(owner, repo string, number int, pr bool, kind scmprovider.CommentKind, commentID int, reaction scmprovider.Reaction) error
 }
 
 type launcher interface {
@@ -124,12 +185,21 @@ type Client struct {
 	Config             *config.Config
 	Logger             *logrus.Entry
 	MetapipelineClient metapipeline.Client
+	// PluginConfig and LighthouseClient are used to enforce the quotas of trigger, which are not enforced
+	// if either is nil. Jobs cannot be aborted without LighthouseClient.
+	PluginConfig     *plugins.Configuration
+	LighthouseClient jobClient
+	// Store shares the command rate limits between the replicas, which count them in memory if it is nil
+	Store store.Store
 }
 
 type trustedUserClient interface {
 	IsCollaborator(org, repo, user string) (bool, error)
 	IsMember(org, user string) (bool, error)
 	BotName() (string, error)
+	ListTeams(org string) ([]*scm.Team, error)
+	ListTeamMembers(id int, role string) ([]*scm.TeamMember, error)
+	Capabilities() scmprovider.Capabilities
 }
 
 func getClient(pc plugins.Agent) Client {
@@ -139,12 +209,21 @@ func getClient(pc plugins.Agent) Client {
 		LauncherClient:     pc.LauncherClient,
 		Logger:             pc.Logger,
 		MetapipelineClient: pc.MetapipelineClient,
+		PluginConfig:       pc.PluginConfig,
+		LighthouseClient:   pc.LighthouseClient,
+		Store:              pc.Store,
 	}
 }
 
 func handlePullRequest(pc plugins.Agent, pr scm.PullRequestHook) error {
-	org, repo, _ := orgRepoAuthor(pr.PullRequest)
-	return handlePR(getClient(pc), pc.PluginConfig.TriggerFor(org, repo), pr)
+	return handlePR(getClient(pc), triggerForPR(pc.PluginConfig, &pr.PullRequest), pr)
+}
+
+// triggerForPR returns the Trigger of the repo of the pull request overridden by the branch overrides of its base
+// branch
+func triggerForPR(config *plugins.Configuration, pr *scm.PullRequest) *plugins.Trigger {
+	org, repo, _ := orgRepoAuthor(*pr)
+	return config.TriggerForBranch(config.TriggerFor(org, repo), org, repo, pr.Base.Ref)
 }
 
 func handleGenericCommentEvent(pc plugins.Agent, gc scmprovider.GenericCommentEvent) error {
@@ -155,16 +234,27 @@ func handlePush(pc plugins.Agent, pe scm.PushHook) error {
 	return handlePE(getClient(pc), pe)
 }
 
+func handleStatus(pc plugins.Agent, se payload.StatusHook) error {
+	return handleSE(getClient(pc), se)
+}
+
 // TrustedUser returns true if user is trusted in repo.
 //
-// Trusted users are either repo collaborators, org members or trusted org members.
-// Whether repo collaborators and/or a second org is trusted is configured by trigger.
+// Trusted users are either repo collaborators, org members, trusted org members, members of trusted teams
+// or explicitly trusted users. Whether repo collaborators, a second org, teams and users are trusted is
+// configured by trigger.
 func TrustedUser(spc trustedUserClient, trigger *plugins.Trigger, user, org, repo string) (bool, error) {
 	botUser, err := spc.BotName()
 	if err == nil && user == botUser {
 		logrus.Infof("User %q is the bot user", user)
 		return true, nil
 	}
+	for _, trusted := range trigger.TrustedUsers {
+		if scmprovider.NormLogin(trusted) == scmprovider.NormLogin(user) {
+			logrus.Infof("User %q is a trusted user", user)
+			return true, nil
+		}
+	}
 	// First check if user is a collaborator, assuming this is allowed
 	if !trigger.OnlyOrgMembers {
 		if ok, err := spc.IsCollaborator(org, repo, user); err != nil {
@@ -185,25 +275,43 @@ func TrustedUser(spc trustedUserClient, trigger *plugins.Trigger, user, org, rep
 		return true, nil
 	}
 
-	// Determine if there is a second org to check
-	if trigger.TrustedOrg == "" || trigger.TrustedOrg == org {
-		return false, nil // No trusted org and/or it is the same
+	// Check the second trusted org, if it differs
+	if trigger.TrustedOrg != "" && trigger.TrustedOrg != org {
+		member, err := spc.IsMember(trigger.TrustedOrg, user)
+		if err != nil {
+			return false, fmt.Errorf("error in IsMember(%s): %v", trigger.TrustedOrg, err)
+		}
+		logrus.Infof("User %q is a member of the trusted org %q - %t", user, trigger.TrustedOrg, member)
+		if member {
+			return true, nil
+		}
 	}
 
-	// Check the second trusted org.
-	member, err := spc.IsMember(trigger.TrustedOrg, user)
-	if err != nil {
-		return false, fmt.Errorf("error in IsMember(%s): %v", trigger.TrustedOrg, err)
+	// Finally check the trusted teams. A team which cannot be looked up does not stop the others from
+	// being checked.
+	if len(trigger.TrustedTeams) > 0 && !spc.Capabilities().TeamMembership {
+		logrus.Warnf("The provider does not list the members of teams, ignoring the trusted teams of %s/%s", org, repo)
+		return false, nil
 	}
-	logrus.Infof("User %q is a member of the trusted org %q - %t", user, trigger.TrustedOrg, member)
-	return member, nil
+	for _, team := range trigger.TrustedTeams {
+		member, err := trustedTeams.isMember(spc, org, team, user)
+		if err != nil {
+			logrus.WithError(err).Warnf("failed to check if user %q is a member of the trusted team %q", user, team)
+			continue
+		}
+		if member {
+			logrus.Infof("User %q is a member of the trusted team %q", user, team)
+			return true, nil
+		}
+	}
+	return false, nil
 }
 
 func skippedStatusFor(context string) *scm.StatusInput {
 	return &scm.StatusInput{
 		State: scm.StateSuccess,
 		Label: context,
-		Desc:  "Skipped.",
+		Desc:  requiredjobs.SkippedDescription,
 	}
 }
 
@@ -248,17 +356,81 @@ func validateContextOverlap(toRun, toSkip []config.Presubmit) error {
 	return nil
 }
 
+// presubmitsFor returns the presubmits of the repository, those of its modules running when the files of the modules
+// change
+func presubmitsFor(c Client, repo scm.Repository) []config.Presubmit {
+	presubmits := c.Config.GetPresubmits(repo)
+	if c.PluginConfig == nil {
+		return presubmits
+	}
+	answer, err := plugins.ModulePresubmits(c.PluginConfig.ModulesFor(repo.Namespace, repo.Name), presubmits)
+	if err != nil {
+		c.Logger.WithError(err).Warn("Failed to apply the modules of the repository to its presubmits.")
+		return presubmits
+	}
+	return answer
+}
+
 // runRequested executes the config.Presubmits that are requested
 func runRequested(c Client, pr *scm.PullRequest, requestedJobs []config.Presubmit, eventGUID string) error {
+	return runRequestedAs(c, pr, requestedJobs, eventGUID, "")
+}
+
+// runRequestedAs executes the config.Presubmits that are requested with the given restricted service account and
+// without secrets, or as configured if it is empty
+func runRequestedAs(c Client, pr *scm.PullRequest, requestedJobs []config.Presubmit, eventGUID string, serviceAccount string) error {
+	var errors []error
+	requestedJobs, duplicates := removeDuplicates(c, pr, requestedJobs)
+	if err := reportDuplicates(c, pr, duplicates); err != nil {
+		c.Logger.WithError(err).Warn("Failed to comment on the jobs which are already pending.")
+		errors = append(errors, err)
+	}
+	if len(requestedJobs) == 0 {
+		return errorutil.NewAggregate(errors...)
+	}
+	if exhausted, err := checkQuotas(c, pr, len(requestedJobs)); err != nil {
+		c.Logger.WithError(err).Warn("Failed to check the quotas, running the jobs anyway.")
+	} else if exhausted != nil {
+		return reportExhaustedQuota(c, pr, requestedJobs, exhausted)
+	}
 	baseSHA, err := c.SCMProviderClient.GetRef(pr.Base.Repo.Namespace, pr.Base.Repo.Name, "heads/"+pr.Base.Ref)
 	if err != nil {
 		return err
 	}
 
-	var errors []error
+	changes, changesErr := c.SCMProviderClient.GetPullRequestChanges(pr.Base.Repo.Namespace, pr.Base.Repo.Name, pr.Number)
+	if tooMany, ok := changesErr.(*scmprovider.TooManyChangesError); ok {
+		c.Logger.WithError(changesErr).Warn("The pull request changes too many files, the jobs run without build cache hints.")
+		if err := warnTooManyChanges(c, pr, tooMany); err != nil {
+			c.Logger.WithError(err).Warn("Failed to comment that the pull request changes too many files.")
+		}
+	} else if changesErr != nil {
+		c.Logger.WithError(changesErr).Warn("Failed to get the changed files of the pull request, the jobs run without build cache hints.")
+	}
+
 	for _, job := range requestedJobs {
 		c.Logger.Infof("Starting %s build.", job.Name)
 		pj := jobutil.NewPresubmit(pr, baseSHA, job, eventGUID)
+		if serviceAccount != "" {
+			if err := restrictJob(&pj, serviceAccount); err != nil {
+				c.Logger.WithError(err).Warnf("Not running %s with the restricted service account.", job.Name)
+				if _, statusErr := c.SCMProviderClient.CreateStatus(pr.Base.Repo.Namespace, pr.Base.Repo.Name, pr.Head.Sha, &scm.StatusInput{
+					State: scm.StateError,
+					Label: job.Context,
+					Desc:  fmt.Sprintf("Cannot run with a restricted service account: %s", err),
+				}); statusErr != nil {
+					errors = append(errors, statusErr)
+				}
+				continue
+			}
+		}
+		if changesErr == nil {
+			jobutil.AddBuildCacheHints(&pj, changes, job.RunIfChanged)
+		} else if jobutil.NeedsChangedFiles(&pj.Spec) {
+			c.Logger.WithError(changesErr).Error("Failed to get the changed files of the pull request.")
+			errors = append(errors, changesErr)
+			continue
+		}
 		c.Logger.WithFields(jobutil.LighthouseJobFields(&pj)).Info("Creating a new LighthouseJob.")
 		if _, err := c.LauncherClient.Launch(&pj, c.MetapipelineClient, pr.Repository()); err != nil {
 			c.Logger.WithError(err).Error("Failed to create LighthouseJob.")
@@ -271,7 +443,8 @@ func runRequested(c Client, pr *scm.PullRequest, requestedJobs []config.Presubmi
 	return errorutil.NewAggregate(errors...)
 }
 
-// skipRequested posts skipped statuses for the config.Presubmits that are requested
+// skipRequested posts skipped statuses for the config.Presubmits that are requested, the statuses which cannot be
+// posted being reconciled later
 func skipRequested(c Client, pr *scm.PullRequest, skippedJobs []config.Presubmit) error {
 	var errors []error
 	for _, job := range skippedJobs {
@@ -279,7 +452,10 @@ func skipRequested(c Client, pr *scm.PullRequest, skippedJobs []config.Presubmit
 			continue
 		}
 		c.Logger.Infof("Skipping %s build.", job.Name)
-		if _, err := c.SCMProviderClient.CreateStatus(pr.Base.Repo.Namespace, pr.Base.Repo.Name, pr.Head.Ref, skippedStatusFor(job.Context)); err != nil {
+		org, repo, status := pr.Base.Repo.Namespace, pr.Base.Repo.Name, skippedStatusFor(job.Context)
+		if err := createStatus(c.SCMProviderClient, org, repo, pr.Head.Ref, status); err != nil {
+			c.Logger.WithError(err).Warnf("Failed to report %s as skipped, it is reported later.", job.Context)
+			unreportedStatuses.add(&unreportedStatus{client: c.SCMProviderClient, org: org, repo: repo, ref: pr.Head.Ref, status: status, firstSeen: time.Now()})
 			errors = append(errors, err)
 		}
 	}
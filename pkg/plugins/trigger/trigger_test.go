@@ -19,12 +19,14 @@ package trigger
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/jenkins-x/go-scm/scm"
 	"github.com/jenkins-x/lighthouse-config/pkg/config"
 	"github.com/jenkins-x/lighthouse/pkg/launcher/fake"
 	"github.com/jenkins-x/lighthouse/pkg/plugins"
 	fake2 "github.com/jenkins-x/lighthouse/pkg/scmprovider/fake"
+	"github.com/jenkins-x/lighthouse/pkg/util"
 	"github.com/sirupsen/logrus"
 	"k8s.io/apimachinery/pkg/util/diff"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -462,6 +464,61 @@ func TestRunRequested(t *testing.T) {
 	}
 }
 
+func TestRunRequestedChangedFiles(t *testing.T) {
+	pr := &scm.PullRequest{
+		Number: 3,
+		Base: scm.PullRequestBranch{
+			Repo: scm.Repository{
+				Namespace: "org",
+				Name:      "repo",
+			},
+			Ref: "branch",
+		},
+		Head: scm.PullRequestBranch{
+			Sha: "foobar1",
+		},
+	}
+	requestedJobs := []config.Presubmit{{
+		JobBase: config.JobBase{
+			Name: "templated",
+			Annotations: map[string]string{
+				util.PipelineRefAnnotation:    "build",
+				util.PipelineParamsAnnotation: "files: '{{ join \" \" .ChangedFiles }}'",
+			},
+		},
+		Reporter: config.Reporter{Context: "templated"},
+	}, {
+		JobBase:  config.JobBase{Name: "plain"},
+		Reporter: config.Reporter{Context: "plain"},
+	}}
+
+	fakeSCMClient := fake2.SCMClient{
+		PullRequestChanges: map[int][]*scm.Change{3: {{Path: "a.go"}, {Path: "b.go"}}},
+	}
+	fakeLauncher := fake.NewLauncher()
+	client := Client{
+		SCMProviderClient: &fakeSCMClient,
+		LauncherClient:    fakeLauncher,
+		Logger:            logrus.WithField("plugin", PluginName),
+	}
+
+	if err := runRequested(client, pr, requestedJobs, "event-guid"); err != nil {
+		t.Fatalf("expected no error but got one: %v", err)
+	}
+	if len(fakeLauncher.Pipelines) != 2 {
+		t.Fatalf("expected 2 jobs, got %d", len(fakeLauncher.Pipelines))
+	}
+	for _, job := range fakeLauncher.Pipelines {
+		expected := []string{"a.go", "b.go"}
+		if actual := job.Spec.Refs.Pulls[0].ChangedFiles; !reflect.DeepEqual(expected, actual) {
+			t.Errorf("%s: expected changed files %v, got %v", job.Spec.Job, expected, actual)
+		}
+		if hash := job.Annotations[util.ChangesHashAnnotation]; hash == "" || hash != job.Spec.Refs.Pulls[0].ChangesHash {
+			t.Errorf("%s: expected the changes hash to be annotated, got %q", job.Spec.Job, hash)
+		}
+	}
+}
+
 func TestValidateContextOverlap(t *testing.T) {
 	var testCases = []struct {
 		name          string
@@ -515,3 +572,91 @@ func TestValidateContextOverlap(t *testing.T) {
 		})
 	}
 }
+
+type countingTeamClient struct {
+	*fake2.SCMClient
+	listTeams int
+}
+
+func (c *countingTeamClient) ListTeams(org string) ([]*scm.Team, error) {
+	c.listTeams++
+	return c.SCMClient.ListTeams(org)
+}
+
+func TestTrustedUser(t *testing.T) {
+	testCases := []struct {
+		name    string
+		trigger plugins.Trigger
+		user    string
+		trusted bool
+	}{
+		{
+			name: "org member",
+			user: "member",
+		},
+		{
+			name:    "collaborator when only org members are trusted",
+			trigger: plugins.Trigger{OnlyOrgMembers: true},
+			user:    "collab",
+		},
+		{
+			name:    "trusted user",
+			trigger: plugins.Trigger{OnlyOrgMembers: true, TrustedUsers: []string{"External"}},
+			user:    "external",
+			trusted: true,
+		},
+		{
+			name:    "trusted team member",
+			trigger: plugins.Trigger{TrustedTeams: []string{"missing", "other/Leads"}},
+			user:    "sig-lead",
+			trusted: true,
+		},
+		{
+			name:    "not a trusted team member",
+			trigger: plugins.Trigger{TrustedTeams: []string{"Leads"}},
+			user:    "external",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			trustedTeams = &teamCache{teams: map[string]cachedTeam{}, now: time.Now}
+			client := &fake2.SCMClient{
+				OrgMembers:    map[string][]string{"org": {"member"}},
+				Collaborators: []string{"collab"},
+			}
+			trusted, err := TrustedUser(client, &tc.trigger, tc.user, "org", "repo")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			expected := tc.trusted || tc.user == "member" || (tc.user == "collab" && !tc.trigger.OnlyOrgMembers)
+			if trusted != expected {
+				t.Errorf("expected trusted %t but got %t", expected, trusted)
+			}
+		})
+	}
+}
+
+func TestTrustedTeamsAreCached(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	trustedTeams = &teamCache{teams: map[string]cachedTeam{}, now: func() time.Time { return now }}
+	defer func() { trustedTeams = &teamCache{teams: map[string]cachedTeam{}, now: time.Now} }()
+	client := &countingTeamClient{SCMClient: &fake2.SCMClient{}}
+	trigger := &plugins.Trigger{TrustedTeams: []string{"Leads"}}
+
+	for i := 0; i < 2; i++ {
+		if trusted, err := TrustedUser(client, trigger, "sig-lead", "org", "repo"); err != nil || !trusted {
+			t.Fatalf("expected sig-lead to be trusted, got %t, %v", trusted, err)
+		}
+	}
+	if client.listTeams != 1 {
+		t.Errorf("expected the teams to be listed once but they were listed %d times", client.listTeams)
+	}
+
+	now = now.Add(teamMembershipTTL)
+	if _, err := TrustedUser(client, trigger, "sig-lead", "org", "repo"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if client.listTeams != 2 {
+		t.Errorf("expected the teams to be listed again once the cache expired but they were listed %d times", client.listTeams)
+	}
+}
@@ -0,0 +1,87 @@
+package trigger
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// testCommandRe matches the /test commands along with the names of the jobs they run
+var testCommandRe = regexp.MustCompile(`(?m)^/` + util.CommandPrefixPattern + `test((?:[ \t]+[^\s,]+,?)+)[ \t]*$`)
+
+// unknownJobs returns the names of the /test commands of the comment which match none of the presubmits
+func unknownJobs(presubmits []config.Presubmit, body string) []string {
+	unknown := sets.NewString()
+	for _, match := range testCommandRe.FindAllStringSubmatch(body, -1) {
+		for _, name := range strings.FieldsFunc(match[1], func(r rune) bool { return r == ' ' || r == '\t' || r == ',' }) {
+			if name == "all" || knownJob(presubmits, name) {
+				continue
+			}
+			unknown.Insert(name)
+		}
+	}
+	return unknown.List()
+}
+
+func knownJob(presubmits []config.Presubmit, name string) bool {
+	for _, ps := range presubmits {
+		if ps.Name == name || ps.Context == name || ps.TriggerMatches("/test "+name) {
+			return true
+		}
+	}
+	return false
+}
+
+// reportUnknownJobs reacts to the comment using unknown jobs and replies with the commands running the presubmits
+// of the repository
+func reportUnknownJobs(c Client, gc scmprovider.GenericCommentEvent, presubmits []config.Presubmit, unknown []string) error {
+	plugins.React(c.SCMProviderClient, c.Logger, gc, scmprovider.ReactionRejected)
+	commands := sets.NewString()
+	for _, ps := range presubmits {
+		if ps.RerunCommand != "" {
+			commands.Insert(util.FormatCommand(ps.RerunCommand))
+		}
+	}
+	var names []string
+	for _, name := range unknown {
+		names = append(names, "`"+name+"`")
+	}
+	resp := fmt.Sprintf("There is no job named %s in this repository.", strings.Join(names, ", "))
+	if commands.Len() > 0 {
+		resp += fmt.Sprintf(" The jobs can be run with `%s` or:\n\n* `%s`", util.FormatCommand("/test all"), strings.Join(commands.List(), "`\n* `"))
+	}
+	c.Logger.Infof("Commenting \"%s\".", resp)
+	return c.SCMProviderClient.CreateComment(gc.Repo.Namespace, gc.Repo.Name, gc.Number, true, plugins.FormatResponseRaw(gc.Body, gc.Link, c.SCMProviderClient.QuoteAuthorForComment(gc.Author.Login), resp))
+}
+
+// excludedJobs returns the names of the presubmits which the comment runs but which do not run against pull requests
+// to the branch, according to their branches and skip_branches
+func excludedJobs(presubmits []config.Presubmit, body, branch string) []string {
+	excluded := sets.NewString()
+	for _, ps := range presubmits {
+		if ps.TriggerMatches(util.TrimCommandPrefix(body)) && !ps.CouldRun(branch) {
+			excluded.Insert(ps.Name)
+		}
+	}
+	return excluded.List()
+}
+
+// reportExcludedJobs replies to the comment running presubmits which do not run against pull requests to the branch
+func reportExcludedJobs(c Client, gc scmprovider.GenericCommentEvent, branch string, excluded []string) error {
+	var names []string
+	for _, name := range excluded {
+		names = append(names, "`"+name+"`")
+	}
+	resp := fmt.Sprintf("The jobs %s do not run against pull requests to the `%s` branch, as configured by their `branches` and `skip_branches`.", strings.Join(names, ", "), branch)
+	if len(names) == 1 {
+		resp = fmt.Sprintf("The job %s does not run against pull requests to the `%s` branch, as configured by its `branches` and `skip_branches`.", names[0], branch)
+	}
+	c.Logger.Infof("Commenting \"%s\".", resp)
+	return c.SCMProviderClient.CreateComment(gc.Repo.Namespace, gc.Repo.Name, gc.Number, true, plugins.FormatResponseRaw(gc.Body, gc.Link, c.SCMProviderClient.QuoteAuthorForComment(gc.Author.Login), resp))
+}
@@ -0,0 +1,110 @@
+package trigger
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/launcher/fake"
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	fake2 "github.com/jenkins-x/lighthouse/pkg/scmprovider/fake"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnknownJobs(t *testing.T) {
+	presubmits := []config.Presubmit{{
+		JobBase:      config.JobBase{Name: "unit"},
+		Reporter:     config.Reporter{Context: "pr-unit"},
+		Trigger:      `(?m)^/test (?:.*? )?unit(?: .*?)?$`,
+		RerunCommand: `/test unit`,
+	}}
+	assert.Empty(t, unknownJobs(presubmits, "/test unit\n/test all\n/test-trusted\n/retest"))
+	assert.Empty(t, unknownJobs(presubmits, "/test pr-unit"))
+	assert.Equal(t, []string{"e2e", "lint"}, unknownJobs(presubmits, "/test unit, lint\n/lh-test e2e\nsome /test text"))
+}
+
+func TestExcludedJobs(t *testing.T) {
+	presubmits := []config.Presubmit{
+		{
+			JobBase:  config.JobBase{Name: "unit"},
+			Trigger:  `(?m)^/test (?:.*? )?unit(?: .*?)?$`,
+			Brancher: config.Brancher{SkipBranches: []string{`^release-`}},
+		},
+		{
+			JobBase:  config.JobBase{Name: "e2e"},
+			Trigger:  `(?m)^/test (?:.*? )?e2e(?: .*?)?$`,
+			Brancher: config.Brancher{Branches: []string{`^release-`}},
+		},
+	}
+	assert.Equal(t, []string{"unit"}, excludedJobs(presubmits, "/test unit e2e", "release-1.0"))
+	assert.Equal(t, []string{"e2e"}, excludedJobs(presubmits, "/test unit e2e", "main"))
+	assert.Empty(t, excludedJobs(presubmits, "/test unit", "main"))
+}
+
+func TestCommandReactions(t *testing.T) {
+	g := &fake2.SCMClient{
+		CreatedStatuses:     map[string][]*scm.StatusInput{},
+		PullRequestComments: map[int][]*scm.Comment{},
+		OrgMembers:          map[string][]string{"org": {"trusted-member"}},
+		PullRequests: map[int]*scm.PullRequest{
+			1: {
+				Number: 1,
+				Author: scm.User{Login: "trusted-member"},
+				Head:   scm.PullRequestBranch{Ref: "feature", Sha: "cafe"},
+				Base: scm.PullRequestBranch{
+					Ref:  "master",
+					Repo: scm.Repository{Namespace: "org", Name: "repo"},
+				},
+			},
+		},
+		PullRequestChanges: map[int][]*scm.Change{1: {{Path: "CHANGED"}}},
+		CombinedStatuses:   map[string]*scm.CombinedStatus{"cafe": {}},
+	}
+	fakeLauncher := fake.NewLauncher()
+	c := Client{
+		SCMProviderClient: g,
+		LauncherClient:    fakeLauncher,
+		Config:            &config.Config{},
+		Logger:            logrus.WithField("plugin", PluginName),
+	}
+	require.NoError(t, c.Config.SetPresubmits(map[string][]config.Presubmit{
+		"org/repo": {{
+			JobBase:      config.JobBase{Name: "job"},
+			AlwaysRun:    true,
+			Reporter:     config.Reporter{Context: "pull-job"},
+			Trigger:      `(?m)^/test (?:.*? )?job(?: .*?)?$`,
+			RerunCommand: `/test job`,
+		}},
+	}))
+	comment := func(id int, body string) {
+		event := scmprovider.GenericCommentEvent{
+			Action:      scm.ActionCreate,
+			Repo:        scm.Repository{Namespace: "org", Name: "repo", FullName: "org/repo"},
+			Body:        body,
+			Number:      1,
+			Author:      scm.User{Login: "trusted-member"},
+			IssueAuthor: scm.User{Login: "trusted-member"},
+			IssueState:  "open",
+			IsPR:        true,
+			CommentID:   id,
+			CommentKind: scmprovider.IssueComment,
+		}
+		require.NoError(t, handleGenericComment(c, &plugins.Trigger{}, event))
+	}
+
+	comment(10, "/test job")
+	assert.Len(t, fakeLauncher.Pipelines, 1)
+	assert.Equal(t, []string{"org/repo#10:started"}, g.CommentReactionsAdded)
+	assert.Empty(t, g.PullRequestCommentsAdded)
+
+	comment(11, "/test jbo")
+	assert.Len(t, fakeLauncher.Pipelines, 1)
+	assert.Equal(t, []string{"org/repo#10:started", "org/repo#11:rejected"}, g.CommentReactionsAdded)
+	require.Len(t, g.PullRequestCommentsAdded, 1)
+	assert.True(t, strings.Contains(g.PullRequestCommentsAdded[0], "There is no job named `jbo` in this repository."), g.PullRequestCommentsAdded[0])
+	assert.True(t, strings.Contains(g.PullRequestCommentsAdded[0], "* `/test job`"), g.PullRequestCommentsAdded[0])
+}
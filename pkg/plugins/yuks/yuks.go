@@ -25,6 +25,7 @@ import (
 
 	"github.com/jenkins-x/go-scm/scm"
 	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	"github.com/jenkins-x/lighthouse/pkg/util"
 	"github.com/sirupsen/logrus"
 
 	"github.com/jenkins-x/lighthouse/pkg/pluginhelp"
@@ -32,7 +33,7 @@ import (
 )
 
 var (
-	match  = regexp.MustCompile(`(?mi)^/(?:lh-)?joke\s*$`)
+	match  = regexp.MustCompile(`(?mi)^/` + util.CommandPrefixPattern + `joke\s*$`)
 	simple = regexp.MustCompile(`^[\w?'!., ]+$`)
 )
 
@@ -0,0 +1,265 @@
+package podagent
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/gc"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// CloneContainerName is the name of the init container which clones the repository
+	CloneContainerName = "clone"
+	// LogsContainerName is the name of the sidecar which captures the build log
+	LogsContainerName = "logs"
+
+	// WorkspaceMountPath is where the workspace volume is mounted in every container
+	WorkspaceMountPath = "/workspace"
+	// LogsMountPath is where the logs volume is mounted in the job and sidecar containers
+	LogsMountPath = "/logs"
+	// ArchiveMountPath is where the log archive volume is mounted in the sidecar
+	ArchiveMountPath = "/archive"
+	// GitCredentialsMountPath is where the git credentials secret is mounted in the clone container
+	GitCredentialsMountPath = "/secrets/git"
+	// GitCredentialsKey is the key of the git credentials secret holding a git credential store file
+	GitCredentialsKey = ".git-credentials"
+	// BuildLogFile is the file in the logs volume the job's output is written to
+	BuildLogFile = gc.BuildLogFileName
+	// ExitCodeFile is the file in the logs volume the job's exit code is written to when it finishes
+	ExitCodeFile = "exit-code"
+
+	// DefaultCloneImage is the image used to clone the repository
+	DefaultCloneImage = "alpine/git:latest"
+	// DefaultLogsImage is the image used by the log capture sidecar
+	DefaultLogsImage = "busybox:latest"
+
+	workspaceVolumeName      = "workspace"
+	logsVolumeName           = "logs"
+	archiveVolumeName        = "archive"
+	gitCredentialsVolumeName = "git-credentials"
+)
+
+// cloneScript clones the base ref into the source directory and merges each pull request on top of it,
+// reading its inputs from the environment so that no ref needs quoting. A pull request is fetched from its own
+// ref when it has one, such as the refs/changes/ ref of a Gerrit patch set.
+const cloneScript = `set -e
+if [ -n "$GIT_CREDENTIALS" ]; then
+  git config --global credential.helper "store --file=$GIT_CREDENTIALS"
+fi
+git init -q "$SOURCE_DIR"
+cd "$SOURCE_DIR"
+git config user.name lighthouse
+git config user.email lighthouse@jenkins-x.io
+git remote add origin "$CLONE_URL"
+git fetch -q origin "$BASE_REF"
+git checkout -q "${BASE_SHA:-FETCH_HEAD}"
+for pull in $PULL_REFS; do
+  number="${pull%%:*}"
+  sha="${pull#*:}"
+  ref=""
+  case "$sha" in
+    *:*) ref="${sha#*:}"; sha="${sha%%:*}" ;;
+  esac
+  if [ -n "$ref" ]; then
+    git fetch -q origin "$ref"
+  else
+    git fetch -q origin "pull/$number/head" || git fetch -q origin "merge-requests/$number/head"
+  fi
+  git merge -q --no-ff -m "Merge pull request #$number" "${sha:-FETCH_HEAD}"
+done
+if [ -z "$SKIP_SUBMODULES" ] && [ -f .gitmodules ]; then
+  git submodule update -q --init --recursive
+fi
+`
+
+// entrypointScript runs the job's command, teeing its output into the build log and recording its exit code
+// so the sidecar knows when the job has finished.
+var entrypointScript = fmt.Sprintf(`( "$@"; echo $? > %[1]s ) 2>&1 | tee %[2]s
+exit "$(cat %[1]s)"`, path.Join(LogsMountPath, ExitCodeFile), path.Join(LogsMountPath, BuildLogFile))
+
+// sidecarScript streams the build log until the job records its exit code, copying it into $ARCHIVE_DIR
+// when the log archive is mounted.
+var sidecarScript = fmt.Sprintf(`touch %[2]s
+if [ -n "$ARCHIVE_DIR" ]; then
+  mkdir -p "$ARCHIVE_DIR"
+  tail -n +1 -f %[2]s | tee "$ARCHIVE_DIR/%[3]s" &
+else
+  tail -n +1 -f %[2]s &
+fi
+until [ -f %[1]s ]; do sleep 1; done
+sleep 1
+kill $!
+if [ -n "$ARCHIVE_DIR" ]; then
+  cp %[2]s "$ARCHIVE_DIR/%[3]s"
+  cp %[1]s "$ARCHIVE_DIR/%[4]s"
+fi`, path.Join(LogsMountPath, ExitCodeFile), path.Join(LogsMountPath, BuildLogFile), gc.BuildLogFileName, ExitCodeFile)
+
+// Decoration configures the utilities added to the pods of jobs: cloning the repository, injecting the
+// standard environment variables and capturing the build log.
+type Decoration struct {
+	// CloneImage is the image of the init container cloning the repository, which needs git and a shell
+	CloneImage string
+	// LogsImage is the image of the log capture sidecar, which needs a shell with tail and tee
+	LogsImage string
+	// LogArchiveClaim is the PersistentVolumeClaim, usually backed by a storage bucket, the build log is
+	// streamed to using the same layout as archived jobs. Logs are only captured by the sidecar if unset.
+	LogArchiveClaim string
+	// GitCredentialsSecret is the secret containing the git credentials used to clone private repositories
+	GitCredentialsSecret string
+	// LogsURL is the base URL of the webhooks service, which serves the build logs the commit statuses link to
+	LogsURL string
+}
+
+// LogsURL returns the URL the webhooks service below baseURL serves the build log of the job at, following it
+// while the job runs
+func LogsURL(baseURL string, job *v1alpha1.LighthouseJob) string {
+	org, repo, jobName, build := gc.BuildCoordinates(job)
+	parts := []string{strings.TrimRight(baseURL, "/"), "logs"}
+	for _, part := range []string{org, repo, jobName, build} {
+		parts = append(parts, url.PathEscape(part))
+	}
+	return strings.Join(parts, "/") + "?follow=true"
+}
+
+// SourceDir returns the directory the repository of the job is cloned into
+func SourceDir(refs *v1alpha1.Refs) string {
+	if refs.PathAlias != "" {
+		return path.Join(WorkspaceMountPath, "src", refs.PathAlias)
+	}
+	return path.Join(WorkspaceMountPath, "src", refs.Org, refs.Repo)
+}
+
+// Decorate adds the clone init container, log capture sidecar and standard environment variables for the
+// job to the pod spec, wrapping the command of its single container so its output can be captured.
+func Decorate(spec *corev1.PodSpec, job *v1alpha1.LighthouseJob, d Decoration) error {
+	if len(spec.Containers) != 1 {
+		return errors.Errorf("job %s must have exactly one container, found %d", job.Spec.Job, len(spec.Containers))
+	}
+	container := &spec.Containers[0]
+	if len(container.Command) == 0 {
+		return errors.Errorf("job %s must specify the command of its container", job.Spec.Job)
+	}
+	if d.CloneImage == "" {
+		d.CloneImage = DefaultCloneImage
+	}
+	if d.LogsImage == "" {
+		d.LogsImage = DefaultLogsImage
+	}
+
+	workspaceMount := corev1.VolumeMount{Name: workspaceVolumeName, MountPath: WorkspaceMountPath}
+	logsMount := corev1.VolumeMount{Name: logsVolumeName, MountPath: LogsMountPath}
+	spec.Volumes = append(spec.Volumes,
+		corev1.Volume{Name: workspaceVolumeName, VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+		corev1.Volume{Name: logsVolumeName, VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+	)
+
+	if refs := job.Spec.Refs; refs != nil {
+		clone, err := cloneContainer(refs, d, workspaceMount)
+		if err != nil {
+			return errors.Wrapf(err, "job %s", job.Spec.Job)
+		}
+		if d.GitCredentialsSecret != "" {
+			spec.Volumes = append(spec.Volumes, corev1.Volume{
+				Name:         gitCredentialsVolumeName,
+				VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: d.GitCredentialsSecret}},
+			})
+		}
+		spec.InitContainers = append([]corev1.Container{*clone}, spec.InitContainers...)
+		if container.WorkingDir == "" {
+			container.WorkingDir = SourceDir(refs)
+		}
+	}
+
+	args := append([]string{"/bin/sh", "-c", entrypointScript, "lighthouse-entrypoint"}, container.Command...)
+	container.Command = append(args, container.Args...)
+	container.Args = nil
+	container.VolumeMounts = append(container.VolumeMounts, workspaceMount, logsMount)
+	container.Env = append(container.Env, EnvVars(job)...)
+
+	sidecar := corev1.Container{
+		Name:         LogsContainerName,
+		Image:        d.LogsImage,
+		Command:      []string{"/bin/sh", "-c", sidecarScript},
+		VolumeMounts: []corev1.VolumeMount{logsMount},
+	}
+	if d.LogArchiveClaim != "" {
+		spec.Volumes = append(spec.Volumes, corev1.Volume{
+			Name: archiveVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: d.LogArchiveClaim},
+			},
+		})
+		sidecar.VolumeMounts = append(sidecar.VolumeMounts, corev1.VolumeMount{Name: archiveVolumeName, MountPath: ArchiveMountPath})
+		sidecar.Env = []corev1.EnvVar{{Name: "ARCHIVE_DIR", Value: path.Join(ArchiveMountPath, gc.BuildDir(job))}}
+	}
+	spec.Containers = append(spec.Containers, sidecar)
+	return nil
+}
+
+func cloneContainer(refs *v1alpha1.Refs, d Decoration, workspaceMount corev1.VolumeMount) (*corev1.Container, error) {
+	if refs.CloneURI == "" {
+		return nil, errors.New("no clone URI for the repository")
+	}
+	var pulls []string
+	for _, pull := range refs.Pulls {
+		ref := strconv.Itoa(pull.Number) + ":" + pull.SHA
+		if pull.Ref != "" {
+			ref += ":" + pull.Ref
+		}
+		pulls = append(pulls, ref)
+	}
+	container := &corev1.Container{
+		Name:    CloneContainerName,
+		Image:   d.CloneImage,
+		Command: []string{"/bin/sh", "-c", cloneScript},
+		Env: []corev1.EnvVar{
+			{Name: "SOURCE_DIR", Value: SourceDir(refs)},
+			{Name: "CLONE_URL", Value: refs.CloneURI},
+			{Name: "BASE_REF", Value: refs.BaseRef},
+			{Name: "BASE_SHA", Value: refs.BaseSHA},
+			{Name: "PULL_REFS", Value: strings.Join(pulls, " ")},
+		},
+		VolumeMounts: []corev1.VolumeMount{workspaceMount},
+	}
+	if refs.SkipSubmodules {
+		container.Env = append(container.Env, corev1.EnvVar{Name: "SKIP_SUBMODULES", Value: "true"})
+	}
+	if d.GitCredentialsSecret != "" {
+		container.Env = append(container.Env, corev1.EnvVar{Name: "GIT_CREDENTIALS", Value: path.Join(GitCredentialsMountPath, GitCredentialsKey)})
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+			Name:      gitCredentialsVolumeName,
+			MountPath: GitCredentialsMountPath,
+			ReadOnly:  true,
+		})
+	}
+	return container, nil
+}
+
+// EnvVars returns the standard environment variables of a decorated job, sorted by name
+func EnvVars(job *v1alpha1.LighthouseJob) []corev1.EnvVar {
+	env := job.Spec.GetEnvVars()
+	buildID := job.Labels[util.BuildNumLabel]
+	if buildID == "" {
+		buildID = job.Name
+	}
+	env[v1alpha1.BuildIDEnv] = buildID
+
+	var names []string
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var answer []corev1.EnvVar
+	for _, name := range names {
+		answer = append(answer, corev1.EnvVar{Name: name, Value: env[name]})
+	}
+	return answer
+}
@@ -0,0 +1,57 @@
+package podagent
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestDecorate(t *testing.T) {
+	job := makeJob("job")
+	job.Labels[util.BuildNumLabel] = "7"
+	job.Spec.Refs.SkipSubmodules = true
+	spec := job.Spec.PodSpec.DeepCopy()
+
+	d := Decoration{LogArchiveClaim: "logs-bucket", GitCredentialsSecret: "git-creds"}
+	require.NoError(t, Decorate(spec, job, d))
+
+	volumes := map[string]corev1.Volume{}
+	for _, v := range spec.Volumes {
+		volumes[v.Name] = v
+	}
+	require.Contains(t, volumes, archiveVolumeName)
+	assert.Equal(t, "logs-bucket", volumes[archiveVolumeName].PersistentVolumeClaim.ClaimName)
+	require.Contains(t, volumes, gitCredentialsVolumeName)
+	assert.Equal(t, "git-creds", volumes[gitCredentialsVolumeName].Secret.SecretName)
+
+	clone := spec.InitContainers[0]
+	assert.Contains(t, clone.Env, corev1.EnvVar{Name: "SKIP_SUBMODULES", Value: "true"})
+	assert.Contains(t, clone.Env, corev1.EnvVar{Name: "GIT_CREDENTIALS", Value: "/secrets/git/.git-credentials"})
+
+	assert.Contains(t, spec.Containers[0].Env, corev1.EnvVar{Name: v1alpha1.BuildIDEnv, Value: "7"})
+
+	sidecar := spec.Containers[1]
+	assert.Contains(t, sidecar.Env, corev1.EnvVar{Name: "ARCHIVE_DIR", Value: "/archive/org/repo/unit/7"})
+	assert.Len(t, sidecar.VolumeMounts, 2)
+}
+
+func TestDecorateWithoutArchive(t *testing.T) {
+	job := makeJob("job")
+	spec := job.Spec.PodSpec.DeepCopy()
+	require.NoError(t, Decorate(spec, job, Decoration{}))
+
+	assert.Len(t, spec.Volumes, 2)
+	assert.Empty(t, spec.Containers[1].Env)
+	assert.Len(t, spec.InitContainers[0].VolumeMounts, 1)
+	assert.Contains(t, spec.Containers[0].Env, corev1.EnvVar{Name: v1alpha1.BuildIDEnv, Value: "job"})
+}
+
+func TestLogsURL(t *testing.T) {
+	job := makeJob("job")
+	job.Labels[util.BuildNumLabel] = "7"
+	assert.Equal(t, "https://hook.example.com/logs/org/repo/unit/7?follow=true", LogsURL("https://hook.example.com/", job))
+}
@@ -0,0 +1,33 @@
+package podagent
+
+import (
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PodForJob creates the decorated pod which runs a job using the kubernetes agent
+func PodForJob(job *v1alpha1.LighthouseJob, d Decoration) (*corev1.Pod, error) {
+	if job.Spec.PodSpec == nil {
+		return nil, errors.Errorf("job %s has no pod spec", job.Spec.Job)
+	}
+	spec := job.Spec.PodSpec.DeepCopy()
+	spec.RestartPolicy = corev1.RestartPolicyNever
+	if err := Decorate(spec, job, d); err != nil {
+		return nil, err
+	}
+
+	labels := map[string]string{}
+	for k, v := range job.Labels {
+		labels[k] = v
+	}
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        job.Name,
+			Labels:      labels,
+			Annotations: job.Annotations,
+		},
+		Spec: *spec,
+	}, nil
+}
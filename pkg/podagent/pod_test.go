@@ -0,0 +1,105 @@
+package podagent
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func makeJob(name string) *v1alpha1.LighthouseJob {
+	return &v1alpha1.LighthouseJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "jx",
+			Labels:    map[string]string{"created-by-lighthouse": "true"},
+		},
+		Spec: v1alpha1.LighthouseJobSpec{
+			Type:    "presubmit",
+			Job:     "unit",
+			Agent:   v1alpha1.KubernetesAgent,
+			Context: "unit",
+			Refs: &v1alpha1.Refs{
+				Org:      "org",
+				Repo:     "repo",
+				BaseRef:  "master",
+				BaseSHA:  "base",
+				CloneURI: "https://github.com/org/repo.git",
+				Pulls:    []v1alpha1.Pull{{Number: 1, SHA: "head"}, {Number: 2, SHA: "other"}},
+			},
+			PodSpec: &corev1.PodSpec{
+				Containers: []corev1.Container{{
+					Image:   "golang:1.13",
+					Command: []string{"make"},
+					Args:    []string{"test"},
+				}},
+			},
+		},
+	}
+}
+
+func TestPodForJob(t *testing.T) {
+	job := makeJob("job")
+	pod, err := PodForJob(job, Decoration{CloneImage: "git-image"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "job", pod.Name)
+	assert.Equal(t, "true", pod.Labels["created-by-lighthouse"])
+	assert.Equal(t, corev1.RestartPolicyNever, pod.Spec.RestartPolicy)
+	assert.Len(t, pod.Spec.Volumes, 2)
+
+	require.Len(t, pod.Spec.InitContainers, 1)
+	clone := pod.Spec.InitContainers[0]
+	assert.Equal(t, CloneContainerName, clone.Name)
+	assert.Equal(t, "git-image", clone.Image)
+	assert.Contains(t, clone.Env, corev1.EnvVar{Name: "PULL_REFS", Value: "1:head 2:other"})
+	assert.Contains(t, clone.Env, corev1.EnvVar{Name: "SOURCE_DIR", Value: "/workspace/src/org/repo"})
+
+	require.Len(t, pod.Spec.Containers, 2)
+	test := pod.Spec.Containers[0]
+	assert.Equal(t, []string{"/bin/sh", "-c", entrypointScript, "lighthouse-entrypoint", "make", "test"}, test.Command)
+	assert.Empty(t, test.Args)
+	assert.Equal(t, "/workspace/src/org/repo", test.WorkingDir)
+	assert.Contains(t, test.Env, corev1.EnvVar{Name: v1alpha1.PullNumberEnv, Value: "1"})
+	assert.Len(t, test.VolumeMounts, 2)
+
+	logs := pod.Spec.Containers[1]
+	assert.Equal(t, LogsContainerName, logs.Name)
+	assert.Equal(t, DefaultLogsImage, logs.Image)
+
+	// the job's own pod spec must not be modified
+	assert.Equal(t, []string{"make"}, job.Spec.PodSpec.Containers[0].Command)
+	assert.Empty(t, job.Spec.PodSpec.Volumes)
+}
+
+func TestPodForJobWithPullRef(t *testing.T) {
+	job := makeJob("job")
+	job.Spec.Refs.Pulls = []v1alpha1.Pull{{Number: 42, SHA: "head", Ref: "refs/changes/42/42/3"}}
+	pod, err := PodForJob(job, Decoration{CloneImage: "git-image"})
+	require.NoError(t, err)
+
+	require.Len(t, pod.Spec.InitContainers, 1)
+	assert.Contains(t, pod.Spec.InitContainers[0].Env, corev1.EnvVar{Name: "PULL_REFS", Value: "42:head:refs/changes/42/42/3"})
+}
+
+func TestPodForJobInvalid(t *testing.T) {
+	noSpec := makeJob("no-spec")
+	noSpec.Spec.PodSpec = nil
+
+	noCommand := makeJob("no-command")
+	noCommand.Spec.PodSpec.Containers[0].Command = nil
+
+	twoContainers := makeJob("two-containers")
+	twoContainers.Spec.PodSpec.Containers = append(twoContainers.Spec.PodSpec.Containers, corev1.Container{Command: []string{"sh"}})
+
+	noCloneURI := makeJob("no-clone-uri")
+	noCloneURI.Spec.Refs.CloneURI = ""
+
+	for _, job := range []*v1alpha1.LighthouseJob{noSpec, noCommand, twoContainers, noCloneURI} {
+		_, err := PodForJob(job, Decoration{})
+		assert.Error(t, err, job.Name)
+	}
+}
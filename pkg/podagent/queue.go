@@ -0,0 +1,184 @@
+package podagent
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// groupPrefix prefixes the keys of the queues of the concurrency groups, so that they do not clash with job names
+const groupPrefix = "group:"
+
+// queues holds the jobs waiting for a max_concurrency slot, by job name, or for their concurrency group to be free,
+// by group, along with the start time of the running jobs and how long the completed ones ran, which estimate when
+// the waiting jobs start
+type queues struct {
+	running   map[string][]time.Time
+	waiting   map[string][]*v1alpha1.LighthouseJob
+	durations map[string][]time.Duration
+}
+
+// newQueues sorts the jobs limited by max_concurrency or a concurrency group into the running and the waiting ones.
+// The jobs whose pod was not created yet wait in the order they were created.
+func newQueues(jobs []v1alpha1.LighthouseJob) *queues {
+	q := &queues{
+		running:   map[string][]time.Time{},
+		waiting:   map[string][]*v1alpha1.LighthouseJob{},
+		durations: map[string][]time.Duration{},
+	}
+	for i := range jobs {
+		job := &jobs[i]
+		if job.Spec.Agent != v1alpha1.KubernetesAgent || limit(job) <= 0 {
+			continue
+		}
+		key := queueKey(job)
+		switch {
+		case job.Status.CompletionTime != nil:
+			if started := runStart(job); !started.IsZero() && job.Status.CompletionTime.After(started) {
+				q.durations[key] = append(q.durations[key], job.Status.CompletionTime.Sub(started))
+			}
+		case job.Status.State == v1alpha1.PendingState || job.Status.State == v1alpha1.RunningState:
+			q.running[key] = append(q.running[key], runStart(job))
+		default:
+			q.waiting[key] = append(q.waiting[key], job)
+		}
+	}
+	for _, waiting := range q.waiting {
+		sort.SliceStable(waiting, func(i, j int) bool {
+			a, b := waiting[i].CreationTimestamp, waiting[j].CreationTimestamp
+			if !a.Equal(&b) {
+				return a.Before(&b)
+			}
+			return waiting[i].Name < waiting[j].Name
+		})
+	}
+	return q
+}
+
+// queueKey returns the key of the queue of the job: its concurrency group if it has one, or else its name
+func queueKey(job *v1alpha1.LighthouseJob) string {
+	if job.Spec.ConcurrencyGroup != "" {
+		return groupPrefix + job.Spec.ConcurrencyGroup
+	}
+	return job.Spec.Job
+}
+
+// limit returns how many jobs of the queue of the job may run at once, which is one for a concurrency group, or 0 if
+// the job is not limited
+func limit(job *v1alpha1.LighthouseJob) int {
+	if job.Spec.ConcurrencyGroup != "" {
+		return 1
+	}
+	return job.Spec.MaxConcurrency
+}
+
+// runStart returns when the pod of the job was created, or when the job was created if it was not recorded
+func runStart(job *v1alpha1.LighthouseJob) time.Time {
+	if c := job.Status.GetCondition(v1alpha1.JobScheduled); c != nil && c.Status == corev1.ConditionTrue && !c.LastTransitionTime.IsZero() {
+		return c.LastTransitionTime.Time
+	}
+	return job.Status.StartTime.Time
+}
+
+// position returns the 1-based position of the job in its queue, or 0 if it can run now. A job can run once it is
+// ahead of the queue and there is a free slot.
+func (q *queues) position(job *v1alpha1.LighthouseJob) int {
+	if limit(job) <= 0 {
+		return 0
+	}
+	free := q.free(job)
+	for i, waiting := range q.waiting[queueKey(job)] {
+		if waiting.Name != job.Name || waiting.Namespace != job.Namespace {
+			continue
+		}
+		if i < free {
+			return 0
+		}
+		return i - free + 1
+	}
+	return 0
+}
+
+// free returns the number of slots left in the queue of the job, which is negative if more jobs are running than
+// allowed, e.g. after max_concurrency was lowered
+func (q *queues) free(job *v1alpha1.LighthouseJob) int {
+	return limit(job) - len(q.running[queueKey(job)])
+}
+
+// started moves the job from the waiting to the running ones of its queue once its pod was created
+func (q *queues) started(job *v1alpha1.LighthouseJob, now time.Time) {
+	if limit(job) <= 0 {
+		return
+	}
+	q.dequeue(job)
+	key := queueKey(job)
+	q.running[key] = append(q.running[key], now)
+}
+
+// dequeue removes the job from the waiting ones of its queue
+func (q *queues) dequeue(job *v1alpha1.LighthouseJob) {
+	key := queueKey(job)
+	waiting := q.waiting[key]
+	for i := range waiting {
+		if waiting[i].Name == job.Name && waiting[i].Namespace == job.Namespace {
+			q.waiting[key] = append(waiting[:i:i], waiting[i+1:]...)
+			return
+		}
+	}
+}
+
+// estimatedWait estimates how long the job at the given position waits for a slot from the average duration of the
+// completed jobs of its queue. The slots are freed as the running jobs complete, then every average duration.
+// It returns false if no job of the queue completed yet.
+func (q *queues) estimatedWait(job *v1alpha1.LighthouseJob, position int, now time.Time) (time.Duration, bool) {
+	key := queueKey(job)
+	durations := q.durations[key]
+	if len(durations) == 0 {
+		return 0, false
+	}
+	var total time.Duration
+	for _, d := range durations {
+		total += d
+	}
+	average := total / time.Duration(len(durations))
+
+	var remaining []time.Duration
+	for _, started := range q.running[key] {
+		left := average - now.Sub(started)
+		if left < 0 {
+			left = 0
+		}
+		remaining = append(remaining, left)
+	}
+	sort.Slice(remaining, func(i, j int) bool { return remaining[i] < remaining[j] })
+	for len(remaining) < limit(job) {
+		remaining = append([]time.Duration{0}, remaining...)
+	}
+	slots := len(remaining)
+	slot, rounds := (position-1)%slots, (position-1)/slots
+	return remaining[slot] + time.Duration(rounds)*average, true
+}
+
+// queuedDescription describes the position of the job in its queue and when it is expected to start, for the
+// pending status of the job
+func (q *queues) queuedDescription(job *v1alpha1.LighthouseJob, position int, now time.Time) string {
+	description := fmt.Sprintf("Queued: position %d of %d", position, len(q.waiting[queueKey(job)])-q.free(job))
+	if job.Spec.ConcurrencyGroup != "" {
+		description += fmt.Sprintf(" in group %s", job.Spec.ConcurrencyGroup)
+	}
+	wait, ok := q.estimatedWait(job, position, now)
+	if !ok {
+		return fmt.Sprintf("%s, max %d running", description, limit(job))
+	}
+	if wait < time.Minute {
+		return description + ", starting in less than a minute"
+	}
+	wait = wait.Round(time.Minute)
+	if wait < time.Hour {
+		return fmt.Sprintf("%s, starting in about %dm", description, int(wait.Minutes()))
+	}
+	return fmt.Sprintf("%s, starting in about %dh%02dm", description, int(wait.Hours()), int(wait.Minutes())%60)
+}
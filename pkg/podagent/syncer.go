@@ -0,0 +1,295 @@
+package podagent
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	clientset "github.com/jenkins-x/lighthouse/pkg/client/clientset/versioned"
+	"github.com/jenkins-x/lighthouse/pkg/slo"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// StatusClient is the subset of the SCM client used to report job statuses
+type StatusClient interface {
+	CreateStatus(org, repo, ref string, s *scm.StatusInput) (*scm.Status, error)
+}
+
+// Syncer runs the LighthouseJobs using the kubernetes agent as pods, updating the jobs' status from
+// their pods and reporting it to the SCM provider. The pods which are evicted or lost with their node
+// are recreated up to maxRetries times rather than failing the job. The jobs whose max_concurrency is reached
+// wait for a running job of the same name to complete, and the jobs of a concurrency group for the running job of
+// the group, their pending status telling their position in the queue.
+type Syncer struct {
+	kubeClient kubernetes.Interface
+	lhClient   clientset.Interface
+	scmClients func(job *v1alpha1.LighthouseJob) (StatusClient, error)
+	namespace  string
+	decoration Decoration
+	maxRetries int
+	logger     *logrus.Entry
+
+	now func() time.Time
+}
+
+// NewSyncer creates a new syncer for the kubernetes LighthouseJobs in the given namespace
+func NewSyncer(kubeClient kubernetes.Interface, lhClient clientset.Interface, scmClients func(job *v1alpha1.LighthouseJob) (StatusClient, error), namespace string, decoration Decoration, maxRetries int, logger *logrus.Entry) *Syncer {
+	if logger == nil {
+		logger = logrus.NewEntry(logrus.StandardLogger())
+	}
+	return &Syncer{
+		kubeClient: kubeClient,
+		lhClient:   lhClient,
+		scmClients: scmClients,
+		namespace:  namespace,
+		decoration: decoration,
+		maxRetries: maxRetries,
+		logger:     logger.WithField("controller", "pod-syncer"),
+		now:        time.Now,
+	}
+}
+
+// Sync creates the pods of new kubernetes LighthouseJobs and updates the jobs which have not completed yet
+func (s *Syncer) Sync() error {
+	jobList, err := s.lhClient.LighthouseV1alpha1().LighthouseJobs(s.namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "listing LighthouseJobs in namespace %s", s.namespace)
+	}
+	queues := newQueues(jobList.Items)
+	for i := range jobList.Items {
+		job := &jobList.Items[i]
+		if job.Spec.Agent != v1alpha1.KubernetesAgent {
+			continue
+		}
+		if job.Status.CompletionTime != nil {
+			if job.Status.ReportFailed() {
+				if err := s.retryReport(job); err != nil {
+					s.logger.WithError(err).Warnf("failed to report LighthouseJob %s", job.Name)
+				}
+			}
+			continue
+		}
+		if err := s.syncJob(job, queues); err != nil {
+			s.logger.WithError(err).Warnf("failed to sync LighthouseJob %s", job.Name)
+		}
+	}
+	return nil
+}
+
+// podNamespace returns the namespace the pod of the job runs in
+func (s *Syncer) podNamespace(job *v1alpha1.LighthouseJob) string {
+	if job.Spec.Namespace != "" {
+		return job.Spec.Namespace
+	}
+	if s.namespace == "" {
+		// the syncer handles the jobs of every namespace
+		return job.Namespace
+	}
+	return s.namespace
+}
+
+func (s *Syncer) syncJob(job *v1alpha1.LighthouseJob, queues *queues) error {
+	jobCopy := job.DeepCopy()
+	ns := s.podNamespace(job)
+
+	pod, err := s.kubeClient.CoreV1().Pods(ns).Get(job.Name, metav1.GetOptions{})
+	switch {
+	case kubeerrors.IsNotFound(err):
+		if job.Status.State == v1alpha1.PendingState || job.Status.State == v1alpha1.RunningState {
+			requeued, err := s.requeue(jobCopy, ns, "Pod was deleted")
+			if err != nil {
+				return err
+			}
+			if !requeued {
+				s.updateState(jobCopy, v1alpha1.ErrorState, "Pod was deleted")
+			}
+			break
+		}
+		if position := queues.position(job); position > 0 {
+			// the job waits for a slot of its max_concurrency or group, the description of its pending status telling
+			// when it is expected to start is refreshed on every sync
+			s.updateState(jobCopy, v1alpha1.TriggeredState, queues.queuedDescription(job, position, s.now()))
+			break
+		}
+		pod, err = PodForJob(job, s.decoration)
+		if err != nil {
+			queues.dequeue(job)
+			s.updateState(jobCopy, v1alpha1.ErrorState, err.Error())
+			break
+		}
+		if _, err := s.kubeClient.CoreV1().Pods(ns).Create(pod); err != nil {
+			return errors.Wrapf(err, "creating pod for LighthouseJob %s", job.Name)
+		}
+		queues.started(job, s.now())
+		if jobCopy.Status.ReportURL == "" && s.decoration.LogsURL != "" {
+			jobCopy.Status.ReportURL = LogsURL(s.decoration.LogsURL, job)
+		}
+		s.updateState(jobCopy, v1alpha1.PendingState, "Pod created")
+	case err != nil:
+		return errors.Wrapf(err, "getting pod of LighthouseJob %s", job.Name)
+	case pod.DeletionTimestamp != nil && job.Status.State == v1alpha1.TriggeredState:
+		// the lost pod is still being deleted, it is recreated once it is gone
+		return nil
+	default:
+		if reason := lostReason(pod); reason != "" {
+			requeued, err := s.requeue(jobCopy, ns, reason)
+			if err != nil {
+				return err
+			}
+			if requeued {
+				break
+			}
+		}
+		state, description := ToPipelineState(pod)
+		s.updateState(jobCopy, state, description)
+	}
+
+	if !reflect.DeepEqual(jobCopy.Status, job.Status) {
+		if _, err := s.lhClient.LighthouseV1alpha1().LighthouseJobs(jobCopy.Namespace).UpdateStatus(jobCopy); err != nil {
+			return errors.Wrapf(err, "updating status of LighthouseJob %s", job.Name)
+		}
+	}
+	return nil
+}
+
+// lostReason returns why the pod did not complete if it was evicted or lost with its node, or an empty string
+// if it was not lost
+func lostReason(pod *corev1.Pod) string {
+	switch {
+	case pod.Status.Reason == "NodeLost" || pod.Status.Phase == corev1.PodUnknown:
+		return "Node of the pod was lost"
+	case pod.Status.Phase != corev1.PodFailed:
+		return ""
+	case pod.Status.Reason == "Evicted":
+		return "Pod was evicted"
+	case pod.Status.Reason == "Shutdown":
+		return "Node of the pod was shut down"
+	default:
+		return ""
+	}
+}
+
+// requeue deletes the lost pod of the job so that the next sync recreates it, returning false without doing
+// anything if the job errors on eviction or was already retried too many times
+func (s *Syncer) requeue(job *v1alpha1.LighthouseJob, ns, reason string) (bool, error) {
+	if job.Spec.ErrorOnEviction || job.Status.Retries >= s.maxRetries {
+		return false, nil
+	}
+	var gracePeriod int64
+	err := s.kubeClient.CoreV1().Pods(ns).Delete(job.Name, &metav1.DeleteOptions{GracePeriodSeconds: &gracePeriod})
+	if err != nil && !kubeerrors.IsNotFound(err) {
+		return false, errors.Wrapf(err, "deleting lost pod of LighthouseJob %s", job.Name)
+	}
+	job.Status.Retries++
+	s.logger.WithField("job", job.Name).Infof("%s, recreating it", reason)
+	s.updateState(job, v1alpha1.TriggeredState, fmt.Sprintf("%s, retrying (%d/%d)", reason, job.Status.Retries, s.maxRetries))
+	return true, nil
+}
+
+// ToPipelineState converts the phase of a job's pod into a LighthouseJob state and description
+func ToPipelineState(pod *corev1.Pod) (v1alpha1.PipelineState, string) {
+	switch pod.Status.Phase {
+	case corev1.PodRunning:
+		return v1alpha1.RunningState, "Job running"
+	case corev1.PodSucceeded:
+		return v1alpha1.SuccessState, "Job succeeded"
+	case corev1.PodFailed:
+		if pod.Status.Reason == "Evicted" {
+			return v1alpha1.ErrorState, "Pod was evicted"
+		}
+		for _, status := range pod.Status.InitContainerStatuses {
+			if status.Name == CloneContainerName && status.State.Terminated != nil && status.State.Terminated.ExitCode != 0 {
+				return v1alpha1.ErrorState, "Failed to clone the repository"
+			}
+		}
+		return v1alpha1.FailureState, "Job failed"
+	default:
+		return v1alpha1.PendingState, "Pod pending"
+	}
+}
+
+// updateState updates the job's state, completing it and reporting to the SCM provider if the state changed
+func (s *Syncer) updateState(job *v1alpha1.LighthouseJob, state v1alpha1.PipelineState, description string) {
+	if job.Status.State == state && job.Status.Description == description && !job.Status.ReportFailed() {
+		return
+	}
+	job.Status.State = state
+	job.Status.Description = description
+	switch state {
+	case v1alpha1.SuccessState, v1alpha1.FailureState, v1alpha1.AbortedState, v1alpha1.ErrorState:
+		if job.Status.CompletionTime == nil {
+			now := metav1.NewTime(s.now())
+			job.Status.CompletionTime = &now
+		}
+	}
+	job.SetStateConditions()
+	if scmState := toSCMState(state); s.report(job, scmState, description) {
+		job.Status.LastReportState = scmState.String()
+	}
+}
+
+func toSCMState(state v1alpha1.PipelineState) scm.State {
+	switch state {
+	case v1alpha1.SuccessState:
+		return scm.StateSuccess
+	case v1alpha1.FailureState:
+		return scm.StateFailure
+	case v1alpha1.ErrorState:
+		return scm.StateError
+	case v1alpha1.AbortedState:
+		return scm.StateCanceled
+	case v1alpha1.RunningState:
+		return scm.StateRunning
+	default:
+		return scm.StatePending
+	}
+}
+
+// report sets the status of the job's commit, returning whether the status was created
+func (s *Syncer) report(job *v1alpha1.LighthouseJob, state scm.State, description string) bool {
+	sha := job.Spec.GetSHA()
+	if s.scmClients == nil || job.Spec.Refs == nil || sha == "" || job.Spec.Context == "" {
+		return false
+	}
+	l := s.logger.WithField("job", job.Name)
+	scmClient, err := s.scmClients(job)
+	if err != nil {
+		l.WithError(err).Warn("failed to create SCM client")
+		job.SetReported(state.String(), err)
+		return false
+	}
+	status := &scm.StatusInput{
+		State:  state,
+		Label:  job.Spec.Context,
+		Desc:   description,
+		Target: job.Status.ReportURL,
+	}
+	if _, err := scmClient.CreateStatus(job.Spec.Refs.Org, job.Spec.Refs.Repo, sha, status); err != nil {
+		l.WithError(err).Warn("failed to report pod status")
+		job.SetReported(state.String(), err)
+		return false
+	}
+	slo.JobReported(l, job, state.String(), s.now())
+	job.SetReported(state.String(), nil)
+	return true
+}
+
+// retryReport reports the final state of the completed job again, as its last report failed
+func (s *Syncer) retryReport(job *v1alpha1.LighthouseJob) error {
+	jobCopy := job.DeepCopy()
+	s.updateState(jobCopy, job.Status.State, job.Status.Description)
+	if reflect.DeepEqual(jobCopy.Status, job.Status) {
+		return nil
+	}
+	if _, err := s.lhClient.LighthouseV1alpha1().LighthouseJobs(jobCopy.Namespace).UpdateStatus(jobCopy); err != nil {
+		return errors.Wrapf(err, "updating status of LighthouseJob %s", job.Name)
+	}
+	return nil
+}
@@ -0,0 +1,382 @@
+package podagent
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	lhfake "github.com/jenkins-x/lighthouse/pkg/client/clientset/versioned/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+type fakeStatusClient struct {
+	statuses []*scm.StatusInput
+	err      error
+}
+
+func (f *fakeStatusClient) CreateStatus(org, repo, ref string, s *scm.StatusInput) (*scm.Status, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.statuses = append(f.statuses, s)
+	return &scm.Status{}, nil
+}
+
+func withState(job *v1alpha1.LighthouseJob, state v1alpha1.PipelineState) *v1alpha1.LighthouseJob {
+	job.Status.State = state
+	return job
+}
+
+func makePod(name string, phase corev1.PodPhase) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "jx"},
+		Status:     corev1.PodStatus{Phase: phase},
+	}
+}
+
+func TestSync(t *testing.T) {
+	invalid := withState(makeJob("invalid"), v1alpha1.TriggeredState)
+	invalid.Spec.PodSpec = nil
+	tekton := withState(makeJob("tekton"), v1alpha1.TriggeredState)
+	tekton.Spec.Agent = v1alpha1.TektonAgent
+
+	lhClient := lhfake.NewSimpleClientset(
+		withState(makeJob("new"), v1alpha1.TriggeredState),
+		invalid,
+		withState(makeJob("running"), v1alpha1.PendingState),
+		withState(makeJob("succeeded"), v1alpha1.RunningState),
+		withState(makeJob("deleted"), v1alpha1.RunningState),
+		tekton,
+	)
+	kubeClient := kubefake.NewSimpleClientset(
+		makePod("running", corev1.PodRunning),
+		makePod("succeeded", corev1.PodSucceeded),
+	)
+	statusClient := &fakeStatusClient{}
+	scmClients := func(job *v1alpha1.LighthouseJob) (StatusClient, error) {
+		return statusClient, nil
+	}
+
+	s := NewSyncer(kubeClient, lhClient, scmClients, "jx", Decoration{}, 0, nil)
+	now := time.Now()
+	s.now = func() time.Time { return now }
+	require.NoError(t, s.Sync())
+
+	expected := map[string]struct {
+		state     v1alpha1.PipelineState
+		completed bool
+	}{
+		"new":       {state: v1alpha1.PendingState},
+		"invalid":   {state: v1alpha1.ErrorState, completed: true},
+		"running":   {state: v1alpha1.RunningState},
+		"succeeded": {state: v1alpha1.SuccessState, completed: true},
+		"deleted":   {state: v1alpha1.ErrorState, completed: true},
+		"tekton":    {state: v1alpha1.TriggeredState},
+	}
+	for name, e := range expected {
+		job, err := lhClient.LighthouseV1alpha1().LighthouseJobs("jx").Get(name, metav1.GetOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, e.state, job.Status.State, name)
+		assert.Equal(t, e.completed, job.Status.CompletionTime != nil, name)
+	}
+
+	_, err := kubeClient.CoreV1().Pods("jx").Get("new", metav1.GetOptions{})
+	assert.NoError(t, err, "pod of new job should have been created")
+	_, err = kubeClient.CoreV1().Pods("jx").Get("tekton", metav1.GetOptions{})
+	assert.Error(t, err, "pod of tekton job should not have been created")
+
+	assert.Len(t, statusClient.statuses, 5)
+}
+
+func TestToPipelineState(t *testing.T) {
+	evicted := makePod("evicted", corev1.PodFailed)
+	evicted.Status.Reason = "Evicted"
+	cloneFailed := makePod("clone-failed", corev1.PodFailed)
+	cloneFailed.Status.InitContainerStatuses = []corev1.ContainerStatus{{
+		Name:  CloneContainerName,
+		State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 128}},
+	}}
+
+	tests := []struct {
+		pod   *corev1.Pod
+		state v1alpha1.PipelineState
+	}{
+		{pod: makePod("pending", corev1.PodPending), state: v1alpha1.PendingState},
+		{pod: makePod("running", corev1.PodRunning), state: v1alpha1.RunningState},
+		{pod: makePod("succeeded", corev1.PodSucceeded), state: v1alpha1.SuccessState},
+		{pod: makePod("failed", corev1.PodFailed), state: v1alpha1.FailureState},
+		{pod: evicted, state: v1alpha1.ErrorState},
+		{pod: cloneFailed, state: v1alpha1.ErrorState},
+	}
+	for _, tc := range tests {
+		state, _ := ToPipelineState(tc.pod)
+		assert.Equal(t, tc.state, state, tc.pod.Name)
+	}
+}
+
+func TestSyncRequeuesLostPods(t *testing.T) {
+	evicted := makePod("evicted", corev1.PodFailed)
+	evicted.Status.Reason = "Evicted"
+	nodeLost := makePod("node-lost", corev1.PodUnknown)
+	exhausted := withState(makeJob("exhausted"), v1alpha1.RunningState)
+	exhausted.Status.Retries = 2
+	exhaustedPod := makePod("exhausted", corev1.PodFailed)
+	exhaustedPod.Status.Reason = "Evicted"
+	errorOnEviction := withState(makeJob("error-on-eviction"), v1alpha1.RunningState)
+	errorOnEviction.Spec.ErrorOnEviction = true
+	errorOnEvictionPod := makePod("error-on-eviction", corev1.PodFailed)
+	errorOnEvictionPod.Status.Reason = "Evicted"
+
+	lhClient := lhfake.NewSimpleClientset(
+		withState(makeJob("evicted"), v1alpha1.RunningState),
+		withState(makeJob("node-lost"), v1alpha1.RunningState),
+		withState(makeJob("deleted"), v1alpha1.PendingState),
+		withState(makeJob("failed"), v1alpha1.RunningState),
+		exhausted,
+		errorOnEviction,
+	)
+	kubeClient := kubefake.NewSimpleClientset(evicted, nodeLost, makePod("failed", corev1.PodFailed), exhaustedPod, errorOnEvictionPod)
+	statusClient := &fakeStatusClient{}
+	scmClients := func(job *v1alpha1.LighthouseJob) (StatusClient, error) {
+		return statusClient, nil
+	}
+
+	s := NewSyncer(kubeClient, lhClient, scmClients, "jx", Decoration{}, 2, nil)
+	require.NoError(t, s.Sync())
+
+	expected := map[string]struct {
+		state       v1alpha1.PipelineState
+		description string
+		retries     int
+	}{
+		"evicted":           {state: v1alpha1.TriggeredState, description: "Pod was evicted, retrying (1/2)", retries: 1},
+		"node-lost":         {state: v1alpha1.TriggeredState, description: "Node of the pod was lost, retrying (1/2)", retries: 1},
+		"deleted":           {state: v1alpha1.TriggeredState, description: "Pod was deleted, retrying (1/2)", retries: 1},
+		"failed":            {state: v1alpha1.FailureState, description: "Job failed"},
+		"exhausted":         {state: v1alpha1.ErrorState, description: "Pod was evicted", retries: 2},
+		"error-on-eviction": {state: v1alpha1.ErrorState, description: "Pod was evicted"},
+	}
+	for name, e := range expected {
+		job, err := lhClient.LighthouseV1alpha1().LighthouseJobs("jx").Get(name, metav1.GetOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, e.state, job.Status.State, name)
+		assert.Equal(t, e.description, job.Status.Description, name)
+		assert.Equal(t, e.retries, job.Status.Retries, name)
+		if e.state == v1alpha1.TriggeredState {
+			assert.Nil(t, job.Status.CompletionTime, name)
+			_, err = kubeClient.CoreV1().Pods("jx").Get(name, metav1.GetOptions{})
+			assert.True(t, kubeerrors.IsNotFound(err), "lost pod %s should have been deleted", name)
+		}
+	}
+	// the requeued jobs are reported as pending rather than failed
+	var failed int
+	for _, status := range statusClient.statuses {
+		if status.State != scm.StatePending {
+			failed++
+		}
+	}
+	assert.Equal(t, 3, failed)
+
+	// the next sync recreates the pods of the requeued jobs
+	require.NoError(t, s.Sync())
+	for _, name := range []string{"evicted", "node-lost", "deleted"} {
+		job, err := lhClient.LighthouseV1alpha1().LighthouseJobs("jx").Get(name, metav1.GetOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, v1alpha1.PendingState, job.Status.State, name)
+		assert.Equal(t, 1, job.Status.Retries, name)
+		_, err = kubeClient.CoreV1().Pods("jx").Get(name, metav1.GetOptions{})
+		assert.NoError(t, err, "pod of %s should have been recreated", name)
+	}
+}
+
+func TestSyncRetriesFailedReport(t *testing.T) {
+	lhClient := lhfake.NewSimpleClientset(withState(makeJob("succeeded"), v1alpha1.RunningState))
+	kubeClient := kubefake.NewSimpleClientset(makePod("succeeded", corev1.PodSucceeded))
+	statusClient := &fakeStatusClient{err: errors.New("rate limited")}
+	scmClients := func(job *v1alpha1.LighthouseJob) (StatusClient, error) {
+		return statusClient, nil
+	}
+	s := NewSyncer(kubeClient, lhClient, scmClients, "jx", Decoration{}, 0, nil)
+
+	require.NoError(t, s.Sync())
+	job, err := lhClient.LighthouseV1alpha1().LighthouseJobs("jx").Get("succeeded", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, v1alpha1.SuccessState, job.Status.State)
+	assert.Equal(t, corev1.ConditionTrue, job.Status.ConditionStatus(v1alpha1.JobCompleted))
+	assert.True(t, job.Status.ReportFailed())
+	assert.Empty(t, job.Status.LastReportState)
+
+	// the completed job is reported once the SCM provider is back
+	statusClient.err = nil
+	require.NoError(t, s.Sync())
+	job, err = lhClient.LighthouseV1alpha1().LighthouseJobs("jx").Get("succeeded", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, corev1.ConditionTrue, job.Status.ConditionStatus(v1alpha1.JobReported))
+	assert.Equal(t, "success", job.Status.LastReportState)
+	require.Len(t, statusClient.statuses, 1)
+	assert.Equal(t, scm.StateSuccess, statusClient.statuses[0].State)
+}
+
+func TestSyncQueuesMaxConcurrency(t *testing.T) {
+	now := time.Now()
+	limited := func(name string, state v1alpha1.PipelineState, created time.Duration) *v1alpha1.LighthouseJob {
+		job := withState(makeJob(name), state)
+		job.Spec.MaxConcurrency = 1
+		job.CreationTimestamp = metav1.NewTime(now.Add(-created))
+		job.Status.StartTime = job.CreationTimestamp
+		return job
+	}
+	done := limited("done", v1alpha1.SuccessState, 20*time.Minute)
+	completed := metav1.NewTime(now.Add(-10 * time.Minute))
+	done.Status.CompletionTime = &completed
+
+	lhClient := lhfake.NewSimpleClientset(
+		done,
+		limited("running", v1alpha1.PendingState, 4*time.Minute),
+		limited("second", v1alpha1.TriggeredState, time.Minute),
+		limited("first", v1alpha1.TriggeredState, 2*time.Minute),
+	)
+	kubeClient := kubefake.NewSimpleClientset(makePod("running", corev1.PodRunning))
+	statusClient := &fakeStatusClient{}
+	scmClients := func(job *v1alpha1.LighthouseJob) (StatusClient, error) {
+		return statusClient, nil
+	}
+	s := NewSyncer(kubeClient, lhClient, scmClients, "jx", Decoration{}, 0, nil)
+	s.now = func() time.Time { return now }
+	require.NoError(t, s.Sync())
+
+	expected := map[string]string{
+		"first":  "Queued: position 1 of 2, starting in about 6m",
+		"second": "Queued: position 2 of 2, starting in about 16m",
+	}
+	for name, description := range expected {
+		job, err := lhClient.LighthouseV1alpha1().LighthouseJobs("jx").Get(name, metav1.GetOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, v1alpha1.TriggeredState, job.Status.State, name)
+		assert.Equal(t, description, job.Status.Description, name)
+		_, err = kubeClient.CoreV1().Pods("jx").Get(name, metav1.GetOptions{})
+		assert.True(t, kubeerrors.IsNotFound(err), "pod of queued job %s should not have been created", name)
+	}
+	var queued []string
+	for _, status := range statusClient.statuses {
+		if status.State == scm.StatePending {
+			queued = append(queued, status.Desc)
+		}
+	}
+	assert.ElementsMatch(t, []string{expected["first"], expected["second"]}, queued)
+
+	// the first job runs once the running one has completed
+	pod := makePod("running", corev1.PodSucceeded)
+	_, err := kubeClient.CoreV1().Pods("jx").UpdateStatus(pod)
+	require.NoError(t, err)
+	require.NoError(t, s.Sync())
+	require.NoError(t, s.Sync())
+
+	first, err := lhClient.LighthouseV1alpha1().LighthouseJobs("jx").Get("first", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, v1alpha1.PendingState, first.Status.State)
+	second, err := lhClient.LighthouseV1alpha1().LighthouseJobs("jx").Get("second", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, v1alpha1.TriggeredState, second.Status.State)
+	assert.Contains(t, second.Status.Description, "Queued: position 1 of 1")
+}
+
+func TestSyncQueuesConcurrencyGroup(t *testing.T) {
+	now := time.Now()
+	grouped := func(name, repo string, state v1alpha1.PipelineState, created time.Duration) *v1alpha1.LighthouseJob {
+		job := withState(makeJob(name), state)
+		job.Spec.Job = "deploy-" + repo
+		job.Spec.Refs.Repo = repo
+		job.Spec.ConcurrencyGroup = "staging"
+		job.CreationTimestamp = metav1.NewTime(now.Add(-created))
+		job.Status.StartTime = job.CreationTimestamp
+		return job
+	}
+	ungrouped := withState(makeJob("ungrouped"), v1alpha1.TriggeredState)
+	ungrouped.Spec.Job = "deploy-api"
+
+	lhClient := lhfake.NewSimpleClientset(
+		grouped("running", "api", v1alpha1.PendingState, 4*time.Minute),
+		grouped("second", "api", v1alpha1.TriggeredState, time.Minute),
+		grouped("first", "ui", v1alpha1.TriggeredState, 2*time.Minute),
+		ungrouped,
+	)
+	kubeClient := kubefake.NewSimpleClientset(makePod("running", corev1.PodRunning))
+	scmClients := func(job *v1alpha1.LighthouseJob) (StatusClient, error) {
+		return &fakeStatusClient{}, nil
+	}
+	s := NewSyncer(kubeClient, lhClient, scmClients, "jx", Decoration{}, 0, nil)
+	s.now = func() time.Time { return now }
+	require.NoError(t, s.Sync())
+
+	expected := map[string]string{
+		"first":  "Queued: position 1 of 2 in group staging, max 1 running",
+		"second": "Queued: position 2 of 2 in group staging, max 1 running",
+	}
+	for name, description := range expected {
+		job, err := lhClient.LighthouseV1alpha1().LighthouseJobs("jx").Get(name, metav1.GetOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, v1alpha1.TriggeredState, job.Status.State, name)
+		assert.Equal(t, description, job.Status.Description, name)
+	}
+	job, err := lhClient.LighthouseV1alpha1().LighthouseJobs("jx").Get("ungrouped", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, v1alpha1.PendingState, job.Status.State, "the jobs out of the group are not queued")
+
+	// the job of the other repository which was triggered first runs once the running one has completed
+	_, err = kubeClient.CoreV1().Pods("jx").UpdateStatus(makePod("running", corev1.PodSucceeded))
+	require.NoError(t, err)
+	require.NoError(t, s.Sync())
+	require.NoError(t, s.Sync())
+
+	first, err := lhClient.LighthouseV1alpha1().LighthouseJobs("jx").Get("first", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, v1alpha1.PendingState, first.Status.State)
+	second, err := lhClient.LighthouseV1alpha1().LighthouseJobs("jx").Get("second", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, v1alpha1.TriggeredState, second.Status.State)
+	assert.Contains(t, second.Status.Description, "Queued: position 1 of 1 in group staging")
+}
+
+func TestSyncQueuesConcurrencyGroupAcrossNamespaces(t *testing.T) {
+	now := time.Now()
+	grouped := func(name, namespace string, created time.Duration) *v1alpha1.LighthouseJob {
+		job := withState(makeJob(name), v1alpha1.TriggeredState)
+		job.Namespace = namespace
+		job.Spec.ConcurrencyGroup = "staging"
+		job.CreationTimestamp = metav1.NewTime(now.Add(-created))
+		return job
+	}
+	lhClient := lhfake.NewSimpleClientset(
+		grouped("team-a", "team-a", 2*time.Minute),
+		grouped("team-b", "team-b", time.Minute),
+	)
+	kubeClient := kubefake.NewSimpleClientset()
+	scmClients := func(job *v1alpha1.LighthouseJob) (StatusClient, error) {
+		return &fakeStatusClient{}, nil
+	}
+	// the syncer of foghorn running with --all-namespaces
+	s := NewSyncer(kubeClient, lhClient, scmClients, "", Decoration{}, 0, nil)
+	s.now = func() time.Time { return now }
+	require.NoError(t, s.Sync())
+
+	first, err := lhClient.LighthouseV1alpha1().LighthouseJobs("team-a").Get("team-a", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, v1alpha1.PendingState, first.Status.State)
+	_, err = kubeClient.CoreV1().Pods("team-a").Get("team-a", metav1.GetOptions{})
+	assert.NoError(t, err)
+
+	second, err := lhClient.LighthouseV1alpha1().LighthouseJobs("team-b").Get("team-b", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, v1alpha1.TriggeredState, second.Status.State, "the group spans the namespaces")
+	assert.Contains(t, second.Status.Description, "Queued: position 1 of 1 in group staging")
+	_, err = kubeClient.CoreV1().Pods("team-b").Get("team-b", metav1.GetOptions{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,214 @@
+package policy
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"github.com/sirupsen/logrus"
+)
+
+// commandRegex matches the lines of a comment which are chat commands, e.g. /test all
+var commandRegex = regexp.MustCompile(`(?m)^/([-\w]+)(?:[ \t]+([^\r\n]*?))?[ \t]*\r?$`)
+
+// SCMClient is the subset of the SCM client used to gather the context of commands and report denials
+type SCMClient interface {
+	GetIssueLabels(org, repo string, number int, pr bool) ([]*scm.Label, error)
+	ListTeams(org string) ([]*scm.Team, error)
+	ListTeamMembers(id int, role string) ([]*scm.TeamMember, error)
+	CreateComment(org, repo string, number int, pr bool, comment string) error
+	QuoteAuthorForComment(string) string
+}
+
+// Command is a chat command found in a comment
+type Command struct {
+	Name string
+	Args string
+}
+
+// ParseCommands returns the chat commands in the comment
+func ParseCommands(body string) []Command {
+	var answer []Command
+	for _, match := range commandRegex.FindAllStringSubmatch(body, -1) {
+		if name, ok := commandName(match[1]); ok {
+			answer = append(answer, Command{Name: name, Args: match[2]})
+		}
+	}
+	return answer
+}
+
+// FilterCommands returns the comment without the lines of the commands which are not kept. The lines of commands
+// meant for other bots are always kept.
+func FilterCommands(body string, keep func(Command) bool) string {
+	return commandRegex.ReplaceAllStringFunc(body, func(line string) string {
+		match := commandRegex.FindStringSubmatch(line)
+		name, ok := commandName(match[1])
+		if !ok || keep(Command{Name: name, Args: match[2]}) {
+			return line
+		}
+		return ""
+	})
+}
+
+// SplitCommands splits a comment holding several chat commands into one comment per command, in the order they are
+// used, so that they can be handled one after the other. The consecutive uses of the same command stay together, such
+// as several /test commands, and the text which is not a command is kept with the first command. It returns nil if
+// the comment holds less than two commands.
+func SplitCommands(body string) []string {
+	var segments [][]string
+	var text []string
+	last := ""
+	for _, line := range strings.Split(body, "\n") {
+		match := commandRegex.FindStringSubmatch(line)
+		if match == nil {
+			text = append(text, line)
+			continue
+		}
+		name, ok := commandName(match[1])
+		switch {
+		case !ok:
+			text = append(text, line)
+			continue
+		case len(segments) > 0 && name == last:
+			segments[len(segments)-1] = append(segments[len(segments)-1], line)
+		default:
+			segments = append(segments, []string{line})
+		}
+		last = name
+	}
+	if len(segments) < 2 {
+		return nil
+	}
+	answer := make([]string, 0, len(segments))
+	for _, segment := range segments {
+		answer = append(answer, strings.Join(segment, "\n"))
+	}
+	if remaining := strings.TrimSpace(strings.Join(text, "\n")); remaining != "" {
+		answer[0] = remaining + "\n" + answer[0]
+	}
+	return answer
+}
+
+// commandName returns the name of the command without the command prefix, or false if the command is meant for
+// another bot as it is used without the required command prefix
+func commandName(command string) (string, bool) {
+	name := strings.ToLower(command)
+	prefix := strings.ToLower(util.CommandPrefix)
+	if strings.HasPrefix(name, prefix) {
+		return strings.TrimPrefix(name, prefix), true
+	}
+	return name, !util.CommandPrefixRequired
+}
+
+// Authorizer evaluates the chat commands of comments before the plugins handle them
+type Authorizer struct {
+	Evaluator Evaluator
+	// IncludeTeams looks up the teams of the org the commenter belongs to, which lists every team of the org
+	IncludeTeams bool
+	// FailOpen allows commands when the policy cannot be evaluated, rather than denying them
+	FailOpen bool
+}
+
+// Authorize evaluates each command of the comment, returning the comment without the denied commands so
+// that no plugin acts on them. The user is told in a single comment why commands were denied.
+func (a *Authorizer) Authorize(client SCMClient, ce *scmprovider.GenericCommentEvent, logger *logrus.Entry) string {
+	if len(ParseCommands(ce.Body)) == 0 {
+		return ce.Body
+	}
+	input := a.input(client, ce, logger)
+	var denials []string
+	body := commandRegex.ReplaceAllStringFunc(ce.Body, func(line string) string {
+		match := commandRegex.FindStringSubmatch(line)
+		name, ok := commandName(match[1])
+		if !ok {
+			return line
+		}
+		commandInput := *input
+		commandInput.Command = name
+		commandInput.Args = match[2]
+
+		l := logger.WithField("command", commandInput.Command)
+		decision, err := a.Evaluator.Evaluate(&commandInput)
+		if err != nil {
+			l.WithError(err).Error("failed to evaluate the chat command policy")
+			if a.FailOpen {
+				return line
+			}
+			decision = &Decision{Reason: "the policy could not be evaluated"}
+		}
+		if decision.Allow {
+			return line
+		}
+		l.WithField("reason", decision.Reason).Info("chat command denied by policy")
+		denial := fmt.Sprintf("- `%s`", util.FormatCommand("/"+commandInput.Command))
+		if decision.Reason != "" {
+			denial += ": " + decision.Reason
+		}
+		denials = append(denials, denial)
+		return ""
+	})
+	if len(denials) > 0 {
+		comment := fmt.Sprintf("%s: the following commands are not allowed:\n\n%s", client.QuoteAuthorForComment(ce.Author.Login), strings.Join(denials, "\n"))
+		if err := client.CreateComment(ce.Repo.Namespace, ce.Repo.Name, ce.Number, ce.IsPR, comment); err != nil {
+			logger.WithError(err).Error("failed to comment on the denied chat commands")
+		}
+	}
+	return body
+}
+
+// input gathers the context shared by all the commands of the comment. Lookup failures are logged, leaving
+// the policy to decide without the missing information.
+func (a *Authorizer) input(client SCMClient, ce *scmprovider.GenericCommentEvent, logger *logrus.Entry) *Input {
+	input := &Input{
+		User:        ce.Author.Login,
+		Org:         ce.Repo.Namespace,
+		Repo:        ce.Repo.Name,
+		Number:      ce.Number,
+		IsPR:        ce.IsPR,
+		IssueAuthor: ce.IssueAuthor.Login,
+		Labels:      []string{},
+	}
+	labels, err := client.GetIssueLabels(ce.Repo.Namespace, ce.Repo.Name, ce.Number, ce.IsPR)
+	if err != nil {
+		logger.WithError(err).Warn("failed to get the labels for the chat command policy")
+	}
+	for _, label := range labels {
+		input.Labels = append(input.Labels, label.Name)
+	}
+	if a.IncludeTeams {
+		input.Teams, err = teamsOf(client, ce.Repo.Namespace, ce.Author.Login)
+		if err != nil {
+			logger.WithError(err).Warn("failed to get the teams for the chat command policy")
+		}
+	}
+	return input
+}
+
+func teamsOf(client SCMClient, org, login string) ([]string, error) {
+	teams, err := client.ListTeams(org)
+	if err != nil {
+		return nil, err
+	}
+	var answer []string
+	for _, team := range teams {
+		members, err := client.ListTeamMembers(team.ID, scmprovider.RoleAll)
+		if err != nil {
+			return answer, err
+		}
+		for _, member := range members {
+			if scmprovider.NormLogin(member.Login) != scmprovider.NormLogin(login) {
+				continue
+			}
+			name := team.Slug
+			if name == "" {
+				name = team.Name
+			}
+			answer = append(answer, name)
+			break
+		}
+	}
+	return answer, nil
+}
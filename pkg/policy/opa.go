@@ -0,0 +1,106 @@
+// Package policy authorizes chat commands against operator supplied policies, such as Rego policies served
+// by Open Policy Agent, so that who can run which command can be decided centrally.
+package policy
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultTimeout is how long a policy evaluation may take by default
+const DefaultTimeout = 10 * time.Second
+
+// Input is the context a command is evaluated with, available to Rego policies as input
+type Input struct {
+	// User is the login of the user running the command
+	User string `json:"user"`
+	// Teams are the slugs of the teams of the org the user belongs to, if team lookups are enabled
+	Teams []string `json:"teams,omitempty"`
+	Org   string   `json:"org"`
+	Repo  string   `json:"repo"`
+	// Number of the issue or pull request the command was run on
+	Number      int    `json:"number"`
+	IsPR        bool   `json:"is_pr"`
+	IssueAuthor string `json:"issue_author,omitempty"`
+	// Command is the name of the command without the leading slash, e.g. test
+	Command string `json:"command"`
+	// Args is the rest of the command line, e.g. all
+	Args string `json:"args,omitempty"`
+	// Labels are the labels of the issue or pull request
+	Labels []string `json:"labels"`
+}
+
+// Decision is the result of evaluating a policy
+type Decision struct {
+	Allow bool `json:"allow"`
+	// Reason explains a denial to the user
+	Reason string `json:"reason,omitempty"`
+}
+
+// Evaluator decides whether a command is allowed
+type Evaluator interface {
+	Evaluate(input *Input) (*Decision, error)
+}
+
+// OPAEvaluator evaluates a policy with the data API of an Open Policy Agent server. The document at URL, e.g.
+// http://localhost:8181/v1/data/lighthouse/chatops, may either be a boolean or an object with the fields of
+// Decision; an undefined document denies the command.
+type OPAEvaluator struct {
+	URL        string
+	HTTPClient *http.Client
+}
+
+// NewOPAEvaluator creates an evaluator querying the document at the URL
+func NewOPAEvaluator(url string, timeout time.Duration) *OPAEvaluator {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &OPAEvaluator{
+		URL:        url,
+		HTTPClient: &http.Client{Timeout: timeout},
+	}
+}
+
+type opaRequest struct {
+	Input *Input `json:"input"`
+}
+
+type opaResponse struct {
+	Result json.RawMessage `json:"result"`
+}
+
+// Evaluate queries the policy document with the input
+func (e *OPAEvaluator) Evaluate(input *Input) (*Decision, error) {
+	body, err := json.Marshal(&opaRequest{Input: input})
+	if err != nil {
+		return nil, errors.Wrap(err, "marshalling policy input")
+	}
+	resp, err := e.HTTPClient.Post(e.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.Wrapf(err, "querying policy %s", e.URL)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("querying policy %s: unexpected status %q", e.URL, resp.Status)
+	}
+	result := opaResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, errors.Wrapf(err, "parsing the result of policy %s", e.URL)
+	}
+	if len(result.Result) == 0 {
+		return &Decision{Reason: "no policy is defined for the command"}, nil
+	}
+	allow := false
+	if err := json.Unmarshal(result.Result, &allow); err == nil {
+		return &Decision{Allow: allow}, nil
+	}
+	decision := &Decision{}
+	if err := json.Unmarshal(result.Result, decision); err != nil {
+		return nil, errors.Wrapf(err, "policy %s returned neither a boolean nor a decision", e.URL)
+	}
+	return decision, nil
+}
@@ -0,0 +1,115 @@
+package policy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider/fake"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCommands(t *testing.T) {
+	commands := ParseCommands("Looks good\n/lgtm\n/test  all \r\nnot /a command\n/Hold")
+	assert.Equal(t, []Command{
+		{Name: "lgtm"},
+		{Name: "test", Args: "all"},
+		{Name: "hold"},
+	}, commands)
+}
+
+func TestSplitCommands(t *testing.T) {
+	assert.Equal(t, []string{
+		"Looks good\nnot /a command\n/lgtm",
+		"/test unit\n/test e2e",
+		"/label tide/merge-method-squash",
+	}, SplitCommands("Looks good\n/lgtm\n/test unit\n/test e2e\nnot /a command\n/label tide/merge-method-squash"))
+	assert.Nil(t, SplitCommands("/test unit\n/test e2e"))
+	assert.Nil(t, SplitCommands("no command"))
+}
+
+func TestOPAEvaluator(t *testing.T) {
+	var input *Input
+	result := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := opaRequest{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		input = request.Input
+		_, _ = w.Write([]byte(result))
+	}))
+	defer server.Close()
+	evaluator := NewOPAEvaluator(server.URL, 0)
+
+	testCases := []struct {
+		result   string
+		expected *Decision
+	}{
+		{result: `{"result": true}`, expected: &Decision{Allow: true}},
+		{result: `{"result": {"allow": false, "reason": "only maintainers can override"}}`, expected: &Decision{Reason: "only maintainers can override"}},
+		{result: `{}`, expected: &Decision{Reason: "no policy is defined for the command"}},
+	}
+	for _, tc := range testCases {
+		result = tc.result
+		decision, err := evaluator.Evaluate(&Input{User: "bob", Command: "override"})
+		require.NoError(t, err, tc.result)
+		assert.Equal(t, tc.expected, decision, tc.result)
+		assert.Equal(t, "bob", input.User)
+	}
+
+	result = `{"result": "yes"}`
+	_, err := evaluator.Evaluate(&Input{})
+	assert.Error(t, err)
+}
+
+type fakeEvaluator struct {
+	inputs []Input
+	denied map[string]string
+}
+
+func (e *fakeEvaluator) Evaluate(input *Input) (*Decision, error) {
+	e.inputs = append(e.inputs, *input)
+	if reason, ok := e.denied[input.Command]; ok {
+		return &Decision{Reason: reason}, nil
+	}
+	return &Decision{Allow: true}, nil
+}
+
+func TestAuthorize(t *testing.T) {
+	client := &fake.SCMClient{
+		IssueComments:             map[int][]*scm.Comment{},
+		PullRequestComments:       map[int][]*scm.Comment{},
+		PullRequestLabelsExisting: []string{"org/repo#5:needs-ok-to-test"},
+	}
+	evaluator := &fakeEvaluator{denied: map[string]string{"override": "only release managers can override contexts"}}
+	authorizer := &Authorizer{Evaluator: evaluator, IncludeTeams: true}
+	ce := &scmprovider.GenericCommentEvent{
+		IsPR:   true,
+		Body:   "/test all\n/override ci/build\nthanks",
+		Number: 5,
+		Repo:   scm.Repository{Namespace: "org", Name: "repo"},
+		Author: scm.User{Login: "sig-lead"},
+	}
+
+	body := authorizer.Authorize(client, ce, logrus.WithField("test", t.Name()))
+
+	assert.Equal(t, "/test all\n\nthanks", body)
+	require.Len(t, evaluator.inputs, 2)
+	assert.Equal(t, Input{
+		User:    "sig-lead",
+		Teams:   []string{"Leads"},
+		Org:     "org",
+		Repo:    "repo",
+		Number:  5,
+		IsPR:    true,
+		Command: "test",
+		Args:    "all",
+		Labels:  []string{"needs-ok-to-test"},
+	}, evaluator.inputs[0])
+	require.Len(t, client.PullRequestCommentsAdded, 1)
+	assert.Contains(t, client.PullRequestCommentsAdded[0], "`/override`: only release managers can override contexts")
+}
@@ -0,0 +1,82 @@
+package provenance
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/gc"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// FileName is the name of the file the signed provenance of a job is stored to inside its build directory
+	FileName = "provenance.intoto.json"
+	// CertificateFileName is the name of the file the PEM certificate chain of the key which signed the provenance
+	// is stored to inside the build directory, when signing keylessly
+	CertificateFileName = "provenance.pem"
+)
+
+// Attestor stores the signed provenance of the jobs as they complete
+type Attestor struct {
+	dir       string
+	builderID string
+	signer    Signer
+	logger    *logrus.Entry
+}
+
+// NewAttestor creates an Attestor signing the provenance of the jobs built by the builder with the signer, which
+// stores it in the build directories of the jobs below dir, the directory the build logs are archived to
+func NewAttestor(dir, builderID string, signer Signer, logger *logrus.Entry) *Attestor {
+	if logger == nil {
+		logger = logrus.NewEntry(logrus.StandardLogger())
+	}
+	return &Attestor{dir: dir, builderID: builderID, signer: signer, logger: logger.WithField("component", "provenance")}
+}
+
+// JobChanged attests the job when it completes
+func (a *Attestor) JobChanged(old, job *v1alpha1.LighthouseJob) {
+	if a == nil || old == nil || job == nil || old.Status.CompletionTime != nil || job.Status.CompletionTime == nil {
+		return
+	}
+	if job.Spec.Refs == nil {
+		// periodics without refs have no source to attest
+		return
+	}
+	if err := a.Attest(job); err != nil {
+		a.logger.WithError(err).WithField("job", job.Name).Warn("failed to attest the provenance of the job")
+	}
+}
+
+// Attest signs the provenance of the job and stores it in its build directory
+func (a *Attestor) Attest(job *v1alpha1.LighthouseJob) error {
+	statement, err := NewStatement(job, a.builderID)
+	if err != nil {
+		return err
+	}
+	envelope, chain, err := Sign(a.signer, statement)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(envelope, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "marshalling the envelope")
+	}
+	dir := filepath.Join(a.dir, filepath.FromSlash(gc.BuildDir(job)))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return errors.Wrapf(err, "creating the build directory %s", dir)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, FileName), data, 0644); err != nil {
+		return errors.Wrapf(err, "writing the provenance of %s", job.Name)
+	}
+	if len(chain) > 0 {
+		if err := ioutil.WriteFile(filepath.Join(dir, CertificateFileName), chain, 0644); err != nil {
+			return errors.Wrapf(err, "writing the signing certificate of %s", job.Name)
+		}
+	}
+	a.logger.WithField("job", job.Name).Debugf("Attested the provenance of the job to %s.", dir)
+	return nil
+}
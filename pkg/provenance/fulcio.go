@@ -0,0 +1,134 @@
+package provenance
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultFulcioURL is the URL of the public Fulcio instance of sigstore
+const DefaultFulcioURL = "https://fulcio.sigstore.dev"
+
+// fulcioSigner signs keylessly: each signature is made with an ephemeral key, certified by Fulcio for the identity
+// of an OIDC token, such as a projected service account token with the sigstore audience
+type fulcioSigner struct {
+	url       string
+	tokenFile string
+	client    *http.Client
+}
+
+// NewFulcioSigner returns a signer certifying ephemeral keys with the Fulcio instance at the URL for the identity
+// of the OIDC token read from the file on each signature, as projected tokens are rotated
+func NewFulcioSigner(url, tokenFile string) Signer {
+	return &fulcioSigner{
+		url:       strings.TrimRight(url, "/"),
+		tokenFile: tokenFile,
+		client:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+type fulcioRequest struct {
+	PublicKey          fulcioPublicKey `json:"publicKey"`
+	SignedEmailAddress string          `json:"signedEmailAddress"`
+}
+
+type fulcioPublicKey struct {
+	Content   string `json:"content"`
+	Algorithm string `json:"algorithm"`
+}
+
+// Sign signs the data with an ephemeral key and returns the certificate chain Fulcio issued for it
+func (s *fulcioSigner) Sign(data []byte) (Signature, []byte, error) {
+	token, err := ioutil.ReadFile(s.tokenFile)
+	if err != nil {
+		return Signature{}, nil, errors.Wrapf(err, "reading the identity token %s", s.tokenFile)
+	}
+	idToken := strings.TrimSpace(string(token))
+	subject, err := tokenSubject(idToken)
+	if err != nil {
+		return Signature{}, nil, err
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return Signature{}, nil, errors.Wrap(err, "generating the ephemeral key")
+	}
+	signer, err := newKeySigner(key)
+	if err != nil {
+		return Signature{}, nil, err
+	}
+	// Fulcio checks the possession of the key with a signature of the subject of the token
+	proof, err := signData(key, []byte(subject))
+	if err != nil {
+		return Signature{}, nil, errors.Wrap(err, "signing the proof of possession")
+	}
+	der, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		return Signature{}, nil, errors.Wrap(err, "marshalling the ephemeral public key")
+	}
+	body, err := json.Marshal(&fulcioRequest{
+		PublicKey:          fulcioPublicKey{Content: base64.StdEncoding.EncodeToString(der), Algorithm: "ecdsa"},
+		SignedEmailAddress: base64.StdEncoding.EncodeToString(proof),
+	})
+	if err != nil {
+		return Signature{}, nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, s.url+"/api/v1/signingCert", bytes.NewReader(body))
+	if err != nil {
+		return Signature{}, nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+idToken)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/pem-certificate-chain")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return Signature{}, nil, errors.Wrap(err, "requesting a signing certificate from Fulcio")
+	}
+	defer resp.Body.Close()
+	chain, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return Signature{}, nil, errors.Wrap(err, "reading the signing certificate")
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return Signature{}, nil, errors.Errorf("Fulcio refused the signing certificate with status %d: %s", resp.StatusCode, strings.TrimSpace(string(chain)))
+	}
+	signature, _, err := signer.Sign(data)
+	return signature, chain, err
+}
+
+// tokenSubject returns the identity Fulcio certifies for the OIDC token, which is its email or else its subject.
+// The token is not verified, which Fulcio does.
+func tokenSubject(token string) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", errors.New("the identity token is not a JWT")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return "", errors.Wrap(err, "decoding the claims of the identity token")
+	}
+	claims := struct {
+		Subject string `json:"sub"`
+		Email   string `json:"email"`
+	}{}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return "", errors.Wrap(err, "parsing the claims of the identity token")
+	}
+	if claims.Email != "" {
+		return claims.Email, nil
+	}
+	if claims.Subject == "" {
+		return "", fmt.Errorf("the identity token has no subject")
+	}
+	return claims.Subject, nil
+}
@@ -0,0 +1,176 @@
+// Package provenance attests how the jobs were built with SLSA provenance: an in-toto statement of the source
+// repository and commit, the digest of the job's configuration and the identity of the builder, signed as a DSSE
+// envelope with a configured key or keylessly with a certificate from Fulcio, and stored next to the build log in
+// the archive of the job's build.
+package provenance
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/pkg/errors"
+)
+
+const (
+	// StatementType is the type of the in-toto statements
+	StatementType = "https://in-toto.io/Statement/v0.1"
+	// PredicateType is the type of the SLSA provenance predicates
+	PredicateType = "https://slsa.dev/provenance/v0.2"
+	// BuildType identifies the builds of LighthouseJobs, whose configuration is the spec of the job
+	BuildType = "https://github.com/jenkins-x/lighthouse/LighthouseJob@v1"
+)
+
+// DigestSet maps digest algorithms, such as sha1 or sha256, to hex encoded digests
+type DigestSet map[string]string
+
+// Subject is the artifact the statement is about
+type Subject struct {
+	Name   string    `json:"name"`
+	Digest DigestSet `json:"digest"`
+}
+
+// Statement is an in-toto statement of the provenance of the build of a job
+type Statement struct {
+	Type          string    `json:"_type"`
+	PredicateType string    `json:"predicateType"`
+	Subject       []Subject `json:"subject"`
+	Predicate     Predicate `json:"predicate"`
+}
+
+// Predicate is the SLSA provenance of the build of a job
+type Predicate struct {
+	Builder     Builder     `json:"builder"`
+	BuildType   string      `json:"buildType"`
+	Invocation  Invocation  `json:"invocation"`
+	BuildConfig BuildConfig `json:"buildConfig"`
+	Metadata    Metadata    `json:"metadata"`
+	Materials   []Material  `json:"materials"`
+}
+
+// Builder identifies the builder which ran the job
+type Builder struct {
+	ID string `json:"id"`
+}
+
+// Invocation is how the job was started
+type Invocation struct {
+	ConfigSource ConfigSource     `json:"configSource"`
+	Parameters   InvocationParams `json:"parameters"`
+}
+
+// ConfigSource is the repository and commit the job's pipeline comes from
+type ConfigSource struct {
+	URI        string    `json:"uri"`
+	Digest     DigestSet `json:"digest"`
+	EntryPoint string    `json:"entryPoint"`
+}
+
+// InvocationParams are the refs the job was started for
+type InvocationParams struct {
+	Type string `json:"type"`
+	Refs string `json:"refs,omitempty"`
+}
+
+// BuildConfig identifies the configuration of the job
+type BuildConfig struct {
+	Job    string    `json:"job"`
+	Agent  string    `json:"agent,omitempty"`
+	Digest DigestSet `json:"digest"`
+}
+
+// Metadata is when and as which LighthouseJob the job ran, and how it completed
+type Metadata struct {
+	BuildInvocationID string       `json:"buildInvocationId"`
+	BuildStartedOn    *time.Time   `json:"buildStartedOn,omitempty"`
+	BuildFinishedOn   *time.Time   `json:"buildFinishedOn,omitempty"`
+	Completeness      Completeness `json:"completeness"`
+	Reproducible      bool         `json:"reproducible"`
+	State             string       `json:"state"`
+}
+
+// Completeness tells which parts of the provenance are complete
+type Completeness struct {
+	Parameters  bool `json:"parameters"`
+	Environment bool `json:"environment"`
+	Materials   bool `json:"materials"`
+}
+
+// Material is a source the job was built from
+type Material struct {
+	URI    string    `json:"uri"`
+	Digest DigestSet `json:"digest"`
+}
+
+// NewStatement returns the provenance of the completed job, built by the builder
+func NewStatement(job *v1alpha1.LighthouseJob, builderID string) (*Statement, error) {
+	refs := job.Spec.Refs
+	if refs == nil {
+		return nil, errors.Errorf("LighthouseJob %s has no refs to attest", job.Name)
+	}
+	specDigest, err := SpecDigest(&job.Spec)
+	if err != nil {
+		return nil, err
+	}
+	uri := sourceURI(refs)
+	sha := job.Spec.GetSHA()
+	materials := []Material{{URI: uri, Digest: DigestSet{"sha1": refs.BaseSHA}}}
+	for _, pull := range refs.Pulls {
+		materials = append(materials, Material{URI: uri, Digest: DigestSet{"sha1": pull.SHA}})
+	}
+	statement := &Statement{
+		Type:          StatementType,
+		PredicateType: PredicateType,
+		Subject:       []Subject{{Name: fmt.Sprintf("%s@%s", uri, sha), Digest: DigestSet{"sha1": sha}}},
+		Predicate: Predicate{
+			Builder:   Builder{ID: builderID},
+			BuildType: BuildType,
+			Invocation: Invocation{
+				ConfigSource: ConfigSource{URI: uri, Digest: DigestSet{"sha1": sha}, EntryPoint: job.Spec.Job},
+				Parameters:   InvocationParams{Type: string(job.Spec.Type), Refs: refs.String()},
+			},
+			BuildConfig: BuildConfig{Job: job.Spec.Job, Agent: job.Spec.Agent, Digest: DigestSet{"sha256": specDigest}},
+			Metadata: Metadata{
+				BuildInvocationID: job.Name,
+				Completeness:      Completeness{Parameters: true},
+				State:             string(job.Status.State),
+			},
+			Materials: materials,
+		},
+	}
+	if !job.Status.StartTime.IsZero() {
+		started := job.Status.StartTime.UTC()
+		statement.Predicate.Metadata.BuildStartedOn = &started
+	}
+	if job.Status.CompletionTime != nil {
+		finished := job.Status.CompletionTime.UTC()
+		statement.Predicate.Metadata.BuildFinishedOn = &finished
+	}
+	return statement, nil
+}
+
+// SpecDigest returns the hex encoded sha256 digest of the JSON of the job's spec, which identifies the
+// configuration the job ran with
+func SpecDigest(spec *v1alpha1.LighthouseJobSpec) (string, error) {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return "", errors.Wrap(err, "marshalling the spec of the job")
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// sourceURI returns the URI of the repository of the refs in the git+ form of SLSA
+func sourceURI(refs *v1alpha1.Refs) string {
+	uri := refs.CloneURI
+	if uri == "" {
+		uri = refs.RepoLink
+	}
+	if uri == "" {
+		return fmt.Sprintf("git+%s/%s", refs.Org, refs.Repo)
+	}
+	return "git+" + uri
+}
@@ -0,0 +1,190 @@
+package provenance
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var start = time.Date(2020, 6, 15, 12, 0, 0, 0, time.UTC)
+
+func testJob() *v1alpha1.LighthouseJob {
+	return &v1alpha1.LighthouseJob{
+		ObjectMeta: metav1.ObjectMeta{Name: "abc-123", Labels: map[string]string{util.BuildNumLabel: "7"}},
+		Spec: v1alpha1.LighthouseJobSpec{
+			Type:  config.PresubmitJob,
+			Job:   "unit",
+			Agent: v1alpha1.TektonAgent,
+			Refs: &v1alpha1.Refs{
+				Org:      "org",
+				Repo:     "repo",
+				CloneURI: "https://github.com/org/repo.git",
+				BaseRef:  "master",
+				BaseSHA:  "base",
+				Pulls:    []v1alpha1.Pull{{Number: 5, SHA: "head"}},
+			},
+		},
+		Status: v1alpha1.LighthouseJobStatus{
+			State:          v1alpha1.SuccessState,
+			StartTime:      metav1.NewTime(start),
+			CompletionTime: &metav1.Time{Time: start.Add(time.Minute)},
+		},
+	}
+}
+
+func pemKey(t *testing.T, key interface{}) []byte {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+}
+
+func TestNewStatement(t *testing.T) {
+	job := testJob()
+	statement, err := NewStatement(job, "https://lighthouse.example.com")
+	require.NoError(t, err)
+
+	assert.Equal(t, []Subject{{Name: "git+https://github.com/org/repo.git@head", Digest: DigestSet{"sha1": "head"}}}, statement.Subject)
+	p := statement.Predicate
+	assert.Equal(t, "https://lighthouse.example.com", p.Builder.ID)
+	assert.Equal(t, "unit", p.Invocation.ConfigSource.EntryPoint)
+	assert.Equal(t, "master:base,5:head", p.Invocation.Parameters.Refs)
+	assert.Len(t, p.Materials, 2)
+	assert.Equal(t, "abc-123", p.Metadata.BuildInvocationID)
+	assert.Equal(t, start.Add(time.Minute), *p.Metadata.BuildFinishedOn)
+
+	digest := p.BuildConfig.Digest["sha256"]
+	assert.Len(t, digest, 64)
+	job.Spec.PipelineRef = "other"
+	changed, err := SpecDigest(&job.Spec)
+	require.NoError(t, err)
+	assert.NotEqual(t, digest, changed, "the digest identifies the configuration of the job")
+
+	_, err = NewStatement(&v1alpha1.LighthouseJob{}, "builder")
+	assert.Error(t, err)
+}
+
+func TestSignAndVerify(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	edPublic, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	ecDER, err := x509.MarshalECPrivateKey(ecKey)
+	require.NoError(t, err)
+
+	keys := map[string]struct {
+		pem    []byte
+		public interface{}
+	}{
+		"ecdsa pkcs8": {pemKey(t, ecKey), &ecKey.PublicKey},
+		"ecdsa sec1":  {pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: ecDER}), &ecKey.PublicKey},
+		"ed25519":     {pemKey(t, edKey), edPublic},
+	}
+	statement, err := NewStatement(testJob(), "builder")
+	require.NoError(t, err)
+	for name, key := range keys {
+		t.Run(name, func(t *testing.T) {
+			signer, err := NewKeySigner(key.pem)
+			require.NoError(t, err)
+			envelope, chain, err := Sign(signer, statement)
+			require.NoError(t, err)
+			assert.Empty(t, chain)
+			assert.Equal(t, PayloadType, envelope.PayloadType)
+
+			verified, err := Verify(envelope, key.public)
+			require.NoError(t, err)
+			assert.Equal(t, statement.Subject, verified.Subject)
+
+			envelope.Payload = base64.StdEncoding.EncodeToString([]byte(`{"subject":[]}`))
+			_, err = Verify(envelope, key.public)
+			assert.Error(t, err, "a tampered payload does not verify")
+		})
+	}
+
+	_, err = NewKeySigner([]byte("not a key"))
+	assert.Error(t, err)
+}
+
+func TestAttestor(t *testing.T) {
+	dir, err := ioutil.TempDir("", "provenance")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	signer, err := NewKeySigner(pemKey(t, key))
+	require.NoError(t, err)
+	a := NewAttestor(dir, "builder", signer, nil)
+
+	job := testJob()
+	running := job.DeepCopy()
+	running.Status.CompletionTime = nil
+	a.JobChanged(running, running)
+	fileName := filepath.Join(dir, "org", "repo", "unit", "7", FileName)
+	assert.NoFileExists(t, fileName, "running jobs are not attested")
+
+	a.JobChanged(running, job)
+	data, err := ioutil.ReadFile(fileName)
+	require.NoError(t, err)
+	envelope := &Envelope{}
+	require.NoError(t, json.Unmarshal(data, envelope))
+	statement, err := Verify(envelope, &key.PublicKey)
+	require.NoError(t, err)
+	assert.Equal(t, "builder", statement.Predicate.Builder.ID)
+	assert.NoFileExists(t, filepath.Join(dir, "org", "repo", "unit", "7", CertificateFileName))
+}
+
+func TestFulcioSigner(t *testing.T) {
+	claims := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"system:serviceaccount:jx:lighthouse-foghorn"}`))
+	token := "header." + claims + ".signature"
+	tokenFile, err := ioutil.TempFile("", "token")
+	require.NoError(t, err)
+	defer os.Remove(tokenFile.Name())
+	_, err = tokenFile.WriteString(token + "\n")
+	require.NoError(t, err)
+	require.NoError(t, tokenFile.Close())
+
+	var request fulcioRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/signingCert", r.URL.Path)
+		assert.Equal(t, "Bearer "+token, r.Header.Get("Authorization"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("-----BEGIN CERTIFICATE-----\n...\n-----END CERTIFICATE-----\n"))
+	}))
+	defer server.Close()
+
+	statement, err := NewStatement(testJob(), "builder")
+	require.NoError(t, err)
+	envelope, chain, err := Sign(NewFulcioSigner(server.URL+"/", tokenFile.Name()), statement)
+	require.NoError(t, err)
+	assert.Contains(t, string(chain), "BEGIN CERTIFICATE")
+
+	// the envelope verifies with the ephemeral key certified by Fulcio
+	der, err := base64.StdEncoding.DecodeString(request.PublicKey.Content)
+	require.NoError(t, err)
+	public, err := x509.ParsePKIXPublicKey(der)
+	require.NoError(t, err)
+	_, err = Verify(envelope, public)
+	require.NoError(t, err)
+
+	_, _, err = NewFulcioSigner(server.URL, "/does/not/exist").Sign([]byte("data"))
+	assert.Error(t, err)
+}
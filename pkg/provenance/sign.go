@@ -0,0 +1,172 @@
+package provenance
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+
+	"github.com/pkg/errors"
+)
+
+// PayloadType is the DSSE payload type of in-toto statements
+const PayloadType = "application/vnd.in-toto+json"
+
+// Envelope is a DSSE envelope holding a signed statement
+type Envelope struct {
+	PayloadType string      `json:"payloadType"`
+	Payload     string      `json:"payload"`
+	Signatures  []Signature `json:"signatures"`
+}
+
+// Signature is a DSSE signature of an envelope
+type Signature struct {
+	KeyID string `json:"keyid"`
+	Sig   string `json:"sig"`
+}
+
+// Signer signs the pre-authentication encoding of envelopes
+type Signer interface {
+	// Sign signs the data, returning the signature and the PEM encoded certificate chain of the signing key when
+	// it is certified, as with keyless signing
+	Sign(data []byte) (Signature, []byte, error)
+}
+
+// Sign signs the statement as a DSSE envelope, returning the envelope and the certificate chain of the signer, if any
+func Sign(signer Signer, statement *Statement) (*Envelope, []byte, error) {
+	payload, err := json.Marshal(statement)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "marshalling the statement")
+	}
+	signature, chain, err := signer.Sign(PAE(PayloadType, payload))
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "signing the statement")
+	}
+	return &Envelope{
+		PayloadType: PayloadType,
+		Payload:     base64.StdEncoding.EncodeToString(payload),
+		Signatures:  []Signature{signature},
+	}, chain, nil
+}
+
+// PAE returns the DSSE pre-authentication encoding of the payload, which is what is signed
+func PAE(payloadType string, payload []byte) []byte {
+	return []byte(fmt.Sprintf("DSSEv1 %d %s %d %s", len(payloadType), payloadType, len(payload), payload))
+}
+
+// keySigner signs with an ECDSA or ed25519 private key
+type keySigner struct {
+	key   crypto.Signer
+	keyID string
+}
+
+// LoadKeySigner returns a signer using the PEM encoded ECDSA or ed25519 private key of the file, in PKCS #8 or
+// SEC 1 form
+func LoadKeySigner(fileName string) (Signer, error) {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading the signing key %s", fileName)
+	}
+	return NewKeySigner(data)
+}
+
+// NewKeySigner returns a signer using the PEM encoded ECDSA or ed25519 private key, in PKCS #8 or SEC 1 form
+func NewKeySigner(pemData []byte) (Signer, error) {
+	block, _ := pem.Decode(pemData)
+	if block == nil {
+		return nil, errors.New("the signing key is not PEM encoded")
+	}
+	var key interface{}
+	var err error
+	switch block.Type {
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing the signing key")
+	}
+	switch k := key.(type) {
+	case *ecdsa.PrivateKey:
+		return newKeySigner(k)
+	case ed25519.PrivateKey:
+		return newKeySigner(k)
+	}
+	return nil, errors.Errorf("unsupported signing key %T, expected an ECDSA or ed25519 key", key)
+}
+
+func newKeySigner(key crypto.Signer) (*keySigner, error) {
+	der, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		return nil, errors.Wrap(err, "marshalling the public key")
+	}
+	sum := sha256.Sum256(der)
+	return &keySigner{key: key, keyID: hex.EncodeToString(sum[:])}, nil
+}
+
+// Sign signs the sha256 digest of the data with an ECDSA key, or the data itself with an ed25519 key
+func (s *keySigner) Sign(data []byte) (Signature, []byte, error) {
+	sig, err := signData(s.key, data)
+	if err != nil {
+		return Signature{}, nil, err
+	}
+	return Signature{KeyID: s.keyID, Sig: base64.StdEncoding.EncodeToString(sig)}, nil, nil
+}
+
+func signData(key crypto.Signer, data []byte) ([]byte, error) {
+	if _, ok := key.(ed25519.PrivateKey); ok {
+		return key.Sign(rand.Reader, data, crypto.Hash(0))
+	}
+	sum := sha256.Sum256(data)
+	return key.Sign(rand.Reader, sum[:], crypto.SHA256)
+}
+
+// Verify checks the signatures of the envelope were made by the public key, returning the statement
+func Verify(envelope *Envelope, publicKey crypto.PublicKey) (*Statement, error) {
+	payload, err := base64.StdEncoding.DecodeString(envelope.Payload)
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding the payload")
+	}
+	if len(envelope.Signatures) == 0 {
+		return nil, errors.New("the envelope is not signed")
+	}
+	data := PAE(envelope.PayloadType, payload)
+	for _, s := range envelope.Signatures {
+		sig, err := base64.StdEncoding.DecodeString(s.Sig)
+		if err != nil {
+			return nil, errors.Wrap(err, "decoding the signature")
+		}
+		verified := false
+		switch k := publicKey.(type) {
+		case *ecdsa.PublicKey:
+			sum := sha256.Sum256(data)
+			var rs struct{ R, S *big.Int }
+			if _, err := asn1.Unmarshal(sig, &rs); err == nil {
+				verified = ecdsa.Verify(k, sum[:], rs.R, rs.S)
+			}
+		case ed25519.PublicKey:
+			verified = ed25519.Verify(k, data, sig)
+		default:
+			return nil, errors.Errorf("unsupported public key %T", publicKey)
+		}
+		if !verified {
+			return nil, errors.Errorf("the signature of key %s does not verify", s.KeyID)
+		}
+	}
+	statement := &Statement{}
+	if err := json.Unmarshal(payload, statement); err != nil {
+		return nil, errors.Wrap(err, "parsing the statement")
+	}
+	return statement, nil
+}
@@ -0,0 +1,80 @@
+package repoowners
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/jenkins-x/go-scm/scm"
+)
+
+// maxPushCommits is the most commits a push event lists, GitHub omitting the following ones
+const maxPushCommits = 20
+
+// Cache keeps the OWNERS files and aliases loaded for the branches of the repos, so that they are shared by the
+// clients of the events until a push changes them
+type Cache struct {
+	lock    sync.Mutex
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	// sha is the head of the branch the entry is up to date with
+	sha string
+	// loadedSHA is the head of the branch the aliases were loaded at, which the pushes not changing them keep
+	loadedSHA string
+	aliases   RepoAliases
+	owners    *RepoOwners
+	// orgAliasesSHA is the loadedSHA of the aliases of the org the owners were loaded with
+	orgAliasesSHA string
+}
+
+// NewCache creates an empty cache of OWNERS files
+func NewCache() *Cache {
+	return &Cache{entries: map[string]cacheEntry{}}
+}
+
+// Push updates the cache for a push to a branch: the entry of the branch is moved to the pushed head if the commits
+// of the push change neither OWNERS files, nor the aliases, nor the markdown files with OWNERS headers if they are
+// enabled, so that it is not loaded again. Otherwise the entry is dropped, as well as when the commits of the push
+// are not all listed.
+func (c *Cache) Push(pe scm.PushHook) {
+	if !strings.HasPrefix(pe.Ref, "refs/heads/") {
+		return
+	}
+	repo := pe.Repository()
+	fullName := fmt.Sprintf("%s/%s:%s", repo.Namespace, repo.Name, strings.TrimPrefix(pe.Ref, "refs/heads/"))
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	entry, ok := c.entries[fullName]
+	if !ok {
+		return
+	}
+	mdYaml := entry.owners != nil && entry.owners.enableMDYAML
+	if pe.Deleted || pe.Forced || entry.sha != pe.Before || changesOwners(pe.Commits, mdYaml) {
+		delete(c.entries, fullName)
+		return
+	}
+	entry.sha = pe.After
+	c.entries[fullName] = entry
+}
+
+// changesOwners returns true if the commits of a push may change the OWNERS files or the aliases of the branch
+func changesOwners(commits []scm.PushCommit, mdYaml bool) bool {
+	if len(commits) == 0 || len(commits) >= maxPushCommits {
+		return true
+	}
+	for _, commit := range commits {
+		for _, files := range [][]string{commit.Added, commit.Removed, commit.Modified} {
+			for _, file := range files {
+				name := path.Base(file)
+				if name == ownersFileName || name == aliasesFileName || (mdYaml && strings.HasSuffix(name, ".md")) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,165 @@
+package repoowners
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse/pkg/git/localgit"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider/fake"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	prowConf "github.com/jenkins-x/lighthouse-config/pkg/config"
+)
+
+func TestCachePush(t *testing.T) {
+	tests := []struct {
+		name     string
+		ref      string
+		before   string
+		forced   bool
+		commits  []scm.PushCommit
+		mdYaml   bool
+		expected string
+	}{
+		{
+			name:     "unrelated changes",
+			ref:      "refs/heads/master",
+			before:   "old",
+			commits:  []scm.PushCommit{{Modified: []string{"main.go"}}, {Added: []string{"docs/README.md"}}},
+			expected: "new",
+		},
+		{
+			name:     "other branch",
+			ref:      "refs/heads/release",
+			before:   "old",
+			commits:  []scm.PushCommit{{Modified: []string{"OWNERS"}}},
+			expected: "old",
+		},
+		{
+			name:    "OWNERS changed",
+			ref:     "refs/heads/master",
+			before:  "old",
+			commits: []scm.PushCommit{{Modified: []string{"main.go"}}, {Removed: []string{"pkg/OWNERS"}}},
+		},
+		{
+			name:    "aliases changed",
+			ref:     "refs/heads/master",
+			before:  "old",
+			commits: []scm.PushCommit{{Added: []string{"OWNERS_ALIASES"}}},
+		},
+		{
+			name:    "markdown header changed",
+			ref:     "refs/heads/master",
+			before:  "old",
+			commits: []scm.PushCommit{{Modified: []string{"docs/README.md"}}},
+			mdYaml:  true,
+		},
+		{
+			name:    "force push",
+			ref:     "refs/heads/master",
+			before:  "old",
+			forced:  true,
+			commits: []scm.PushCommit{{Modified: []string{"main.go"}}},
+		},
+		{
+			name:    "missed push",
+			ref:     "refs/heads/master",
+			before:  "older",
+			commits: []scm.PushCommit{{Modified: []string{"main.go"}}},
+		},
+		{
+			name:   "commits not listed",
+			ref:    "refs/heads/master",
+			before: "old",
+		},
+	}
+	for _, test := range tests {
+		cache := NewCache()
+		cache.entries["org/repo:master"] = cacheEntry{sha: "old", owners: &RepoOwners{enableMDYAML: test.mdYaml}}
+		cache.Push(scm.PushHook{
+			Ref:     test.ref,
+			Repo:    scm.Repository{Namespace: "org", Name: "repo"},
+			Before:  test.before,
+			After:   "new",
+			Forced:  test.forced,
+			Commits: test.commits,
+		})
+		entry, ok := cache.entries["org/repo:master"]
+		if test.expected == "" && ok {
+			t.Errorf("[%s] Expected the entry to be dropped, but it is at %s.", test.name, entry.sha)
+		} else if test.expected != "" && entry.sha != test.expected {
+			t.Errorf("[%s] Expected the entry at %s, but got %q.", test.name, test.expected, entry.sha)
+		}
+	}
+}
+
+func TestOrgAliases(t *testing.T) {
+	localGit, git, err := localgit.New()
+	if err != nil {
+		t.Fatalf("Error creating localgit: %v.", err)
+	}
+	defer localGit.Clean()
+	defer git.Clean()
+	if err := localGit.MakeFakeRepo("org", "repo"); err != nil {
+		t.Fatalf("Cannot make fake repo: %v.", err)
+	}
+	if err := localGit.AddCommit("org", "repo", map[string][]byte{
+		"OWNERS":         []byte("approvers:\n- org-approvers\nreviewers:\n- reviewers"),
+		"OWNERS_ALIASES": []byte("aliases:\n  reviewers:\n  - carl"),
+	}); err != nil {
+		t.Fatalf("Cannot add commit: %v.", err)
+	}
+	if err := localGit.MakeFakeRepo("org", "community"); err != nil {
+		t.Fatalf("Cannot make fake repo: %v.", err)
+	}
+	if err := localGit.AddCommit("org", "community", map[string][]byte{
+		"OWNERS_ALIASES": []byte("aliases:\n  org-approvers:\n  - Alice\n  - bob\n  reviewers:\n  - maggie"),
+	}); err != nil {
+		t.Fatalf("Cannot add commit: %v.", err)
+	}
+
+	client := NewClient(git, &fake.SCMClient{}, &prowConf.Config{},
+		func(org, repo string) bool { return false },
+		func(org, repo string) bool { return true },
+	).WithOrgAliases(func(org string) (string, string) {
+		return "org/community", "master"
+	})
+
+	owners, err := client.LoadRepoOwners("org", "repo", "master")
+	if err != nil {
+		t.Fatalf("Unexpected error loading RepoOwners: %v.", err)
+	}
+	if expected, got := sets.NewString("alice", "bob"), owners.Approvers("main.go"); !expected.Equal(got) {
+		t.Errorf("Expected the approvers %v, but got %v.", expected.List(), got.List())
+	}
+	if expected, got := sets.NewString("carl"), owners.Reviewers("main.go"); !expected.Equal(got) {
+		t.Errorf("Expected the aliases of the repo to take precedence, but got the reviewers %v.", got.List())
+	}
+	aliases, err := client.LoadRepoAliases("org", "repo", "master")
+	if err != nil {
+		t.Fatalf("Unexpected error loading RepoAliases: %v.", err)
+	}
+	expected := RepoAliases{
+		"org-approvers": sets.NewString("alice", "bob"),
+		"reviewers":     sets.NewString("carl"),
+	}
+	if len(aliases) != len(expected) || !aliases["org-approvers"].Equal(expected["org-approvers"]) || !aliases["reviewers"].Equal(expected["reviewers"]) {
+		t.Errorf("Expected RepoAliases: %v, but got: %v.", expected, aliases)
+	}
+
+	// a push changing the aliases of the org reloads the OWNERS files of its repos
+	entry := client.cache.entries["org/repo:master"]
+	if entry.orgAliasesSHA != fake.TestRef {
+		t.Errorf("Expected the owners to be loaded with the aliases at %s, but got %q.", fake.TestRef, entry.orgAliasesSHA)
+	}
+	client.cache.Push(scm.PushHook{
+		Ref:     "refs/heads/master",
+		Repo:    scm.Repository{Namespace: "org", Name: "community"},
+		Before:  fake.TestRef,
+		After:   "new",
+		Commits: []scm.PushCommit{{Modified: []string{"OWNERS_ALIASES"}}},
+	})
+	if _, ok := client.cache.entries["org/community:master"]; ok {
+		t.Errorf("Expected the aliases of the org to be dropped from the cache.")
+	}
+}
@@ -0,0 +1,42 @@
+package repoowners
+
+// QueryPath is the URL path of the endpoint of the admin port querying the owners of the files of a branch, as
+// GET /owners?repo={org}/{repo}&base={branch}&path={file}
+const QueryPath = "/owners"
+
+// Ownership holds the owners of a file according to the OWNERS files of its branch
+type Ownership struct {
+	Path string `json:"path"`
+	// ApproversOwners is the directory of the closest OWNERS file with approvers
+	ApproversOwners string `json:"approvers_owners"`
+	// ReviewersOwners is the directory of the closest OWNERS file with reviewers
+	ReviewersOwners   string   `json:"reviewers_owners"`
+	Approvers         []string `json:"approvers,omitempty"`
+	LeafApprovers     []string `json:"leaf_approvers,omitempty"`
+	Reviewers         []string `json:"reviewers,omitempty"`
+	LeafReviewers     []string `json:"leaf_reviewers,omitempty"`
+	RequiredReviewers []string `json:"required_reviewers,omitempty"`
+	Labels            []string `json:"labels,omitempty"`
+	NoParentOwners    bool     `json:"no_parent_owners,omitempty"`
+}
+
+// Query returns the ownership of the paths, with the aliases expanded
+func Query(owners RepoOwner, paths []string) []Ownership {
+	var answer []Ownership
+	for _, path := range paths {
+		approversOwners := owners.FindApproverOwnersForFile(path)
+		answer = append(answer, Ownership{
+			Path:              path,
+			ApproversOwners:   approversOwners,
+			ReviewersOwners:   owners.FindReviewersOwnersForFile(path),
+			Approvers:         owners.Approvers(path).List(),
+			LeafApprovers:     owners.LeafApprovers(path).List(),
+			Reviewers:         owners.Reviewers(path).List(),
+			LeafReviewers:     owners.LeafReviewers(path).List(),
+			RequiredReviewers: owners.RequiredReviewers(path).List(),
+			Labels:            owners.FindLabelsForFile(path).List(),
+			NoParentOwners:    owners.IsNoParentOwners(approversOwners),
+		})
+	}
+	return answer
+}
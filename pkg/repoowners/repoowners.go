@@ -23,7 +23,6 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
-	"sync"
 
 	"github.com/jenkins-x/go-scm/scm"
 	git2 "github.com/jenkins-x/lighthouse/pkg/git"
@@ -79,11 +78,6 @@ type scmProviderClient interface {
 	GetRef(org, repo, ref string) (string, error)
 }
 
-type cacheEntry struct {
-	sha     string
-	aliases RepoAliases
-	owners  *RepoOwners
-}
 
 // Interface is an interface to work with OWNERS files.
 type Interface interface {
@@ -104,9 +98,9 @@ type Client struct {
 
 	mdYAMLEnabled     func(org, repo string) bool
 	skipCollaborators func(org, repo string) bool
+	orgAliases        func(org string) (repo, branch string)
 
-	lock  sync.Mutex
-	cache map[string]cacheEntry
+	cache *Cache
 }
 
 // NewClient is the constructor for Client
@@ -121,7 +115,7 @@ func NewClient(
 		git:    gc,
 		spc:    spc,
 		logger: logrus.WithField("client", "repoowners"),
-		cache:  make(map[string]cacheEntry),
+		cache:  NewCache(),
 
 		mdYAMLEnabled:     mdYAMLEnabled,
 		skipCollaborators: skipCollaborators,
@@ -130,6 +124,20 @@ func NewClient(
 	}
 }
 
+// WithCache makes the client share the OWNERS files and aliases loaded in the cache, e.g. with the clients of the
+// other events
+func (c *Client) WithCache(cache *Cache) *Client {
+	c.cache = cache
+	return c
+}
+
+// WithOrgAliases makes the client merge the aliases of the org/repo repository and branch returned for an org, if any,
+// into the aliases of the repos of the org, which take precedence
+func (c *Client) WithOrgAliases(orgAliases func(org string) (repo, branch string)) *Client {
+	c.orgAliases = orgAliases
+	return c
+}
+
 // RepoAliases defines groups of people to be used in OWNERS files
 type RepoAliases map[string]sets.String
 
@@ -165,39 +173,77 @@ type RepoOwners struct {
 	log *logrus.Entry
 }
 
-// LoadRepoAliases returns an up-to-date RepoAliases struct for the specified repo.
+// LoadRepoAliases returns an up-to-date RepoAliases struct for the specified repo, including the aliases of its org.
 // If the repo does not have an aliases file then an empty alias map is returned with no error.
 // Note: The returned RepoAliases should be treated as read only.
 func (c *Client) LoadRepoAliases(org, repo, base string) (RepoAliases, error) {
 	log := c.logger.WithFields(logrus.Fields{"org": org, "repo": repo, "base": base})
-	cloneRef := fmt.Sprintf("%s/%s", org, repo)
-	fullName := fmt.Sprintf("%s:%s", cloneRef, base)
+	fullName := fmt.Sprintf("%s/%s:%s", org, repo, base)
 
 	sha, err := c.spc.GetRef(org, repo, fmt.Sprintf("heads/%s", base))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get current SHA for %s: %v", fullName, err)
 	}
 
-	c.lock.Lock()
-	defer c.lock.Unlock()
-	entry, ok := c.cache[fullName]
+	c.cache.lock.Lock()
+	defer c.cache.lock.Unlock()
+	orgAliases, _, err := c.loadOrgAliases(org, log)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := c.loadAliases(org, repo, base, sha, log)
+	if err != nil {
+		return nil, err
+	}
+	return mergeAliases(orgAliases, entry.aliases), nil
+}
+
+// loadAliases returns the cache entry of the branch with up-to-date aliases, which the cache lock must be held for
+func (c *Client) loadAliases(org, repo, base, sha string, log *logrus.Entry) (cacheEntry, error) {
+	cloneRef := fmt.Sprintf("%s/%s", org, repo)
+	fullName := fmt.Sprintf("%s:%s", cloneRef, base)
+	entry, ok := c.cache.entries[fullName]
 	if !ok || entry.sha != sha {
 		// entry is non-existent or stale.
-		gitRepo, err := c.git.Clone(cloneRef)
+		gitRepo, err := c.git.CloneRef(cloneRef, base)
 		if err != nil {
-			return nil, fmt.Errorf("failed to clone %s: %v", cloneRef, err)
+			return entry, fmt.Errorf("failed to clone %s: %v", cloneRef, err)
 		}
 		defer gitRepo.Clean()
 		if err := gitRepo.Checkout(base); err != nil {
-			return nil, err
+			return entry, err
 		}
 
-		entry.aliases = loadAliasesFrom(gitRepo.Dir, log)
-		entry.sha = sha
-		c.cache[fullName] = entry
+		entry = cacheEntry{
+			sha:       sha,
+			loadedSHA: sha,
+			aliases:   loadAliasesFrom(gitRepo.Dir, log),
+		}
+		c.cache.entries[fullName] = entry
 	}
+	return entry, nil
+}
 
-	return entry.aliases, nil
+// loadOrgAliases returns the aliases shared by the repos of the org, if any, with the SHA they were loaded at. The
+// cache lock must be held.
+func (c *Client) loadOrgAliases(org string, log *logrus.Entry) (RepoAliases, string, error) {
+	if c.orgAliases == nil {
+		return nil, "", nil
+	}
+	fullName, branch := c.orgAliases(org)
+	parts := strings.Split(fullName, "/")
+	if len(parts) != 2 {
+		return nil, "", nil
+	}
+	sha, err := c.spc.GetRef(parts[0], parts[1], fmt.Sprintf("heads/%s", branch))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get current SHA for the aliases of %s in %s:%s: %v", org, fullName, branch, err)
+	}
+	entry, err := c.loadAliases(parts[0], parts[1], branch, sha, log.WithField("aliases", fullName))
+	if err != nil {
+		return nil, "", err
+	}
+	return entry.aliases, entry.loadedSHA, nil
 }
 
 // LoadRepoOwners returns an up-to-date RepoOwners struct for the specified repo.
@@ -213,40 +259,23 @@ func (c *Client) LoadRepoOwners(org, repo, base string) (RepoOwner, error) {
 		return nil, fmt.Errorf("failed to get current SHA for %s: %v", fullName, err)
 	}
 
-	c.lock.Lock()
-	defer c.lock.Unlock()
-	entry, ok := c.cache[fullName]
-	if !ok || entry.sha != sha || entry.owners == nil || entry.owners.enableMDYAML != mdYaml {
-		gitRepo, err := c.git.Clone(cloneRef)
+	c.cache.lock.Lock()
+	orgAliases, orgAliasesSHA, err := c.loadOrgAliases(org, log)
+	if err != nil {
+		c.cache.lock.Unlock()
+		return nil, err
+	}
+	entry, ok := c.cache.entries[fullName]
+	if !ok || entry.sha != sha || entry.owners == nil || entry.owners.enableMDYAML != mdYaml || entry.orgAliasesSHA != orgAliasesSHA {
+		entry, err = c.loadOwners(org, repo, base, sha, entry, mdYaml, orgAliases, log)
 		if err != nil {
-			return nil, fmt.Errorf("failed to clone %s: %v", cloneRef, err)
-		}
-		defer gitRepo.Clean()
-		if err := gitRepo.Checkout(base); err != nil {
+			c.cache.lock.Unlock()
 			return nil, err
 		}
-
-		if entry.aliases == nil || entry.sha != sha {
-			// aliases must be loaded
-			entry.aliases = loadAliasesFrom(gitRepo.Dir, log)
-		}
-
-		blacklistConfig := c.config.OwnersDirBlacklist
-
-		dirBlacklist := defaultDirBlacklist.Union(sets.NewString(blacklistConfig.Default...))
-		if bl, ok := blacklistConfig.Repos[org]; ok {
-			dirBlacklist.Insert(bl...)
-		}
-		if bl, ok := blacklistConfig.Repos[org+"/"+repo]; ok {
-			dirBlacklist.Insert(bl...)
-		}
-		entry.owners, err = loadOwnersFrom(gitRepo.Dir, mdYaml, entry.aliases, dirBlacklist, log)
-		if err != nil {
-			return nil, fmt.Errorf("failed to load RepoOwners for %s: %v", fullName, err)
-		}
-		entry.sha = sha
-		c.cache[fullName] = entry
+		entry.orgAliasesSHA = orgAliasesSHA
+		c.cache.entries[fullName] = entry
 	}
+	c.cache.lock.Unlock()
 
 	if c.skipCollaborators(org, repo) {
 		log.Debugf("Skipping collaborator checks for %s/%s", org, repo)
@@ -266,6 +295,56 @@ func (c *Client) LoadRepoOwners(org, repo, base string) (RepoOwner, error) {
 	return owners, nil
 }
 
+// loadOwners loads the OWNERS files of the branch at the SHA into the cache entry
+func (c *Client) loadOwners(org, repo, base, sha string, entry cacheEntry, mdYaml bool, orgAliases RepoAliases, log *logrus.Entry) (cacheEntry, error) {
+	cloneRef := fmt.Sprintf("%s/%s", org, repo)
+	gitRepo, err := c.git.CloneRef(cloneRef, base)
+	if err != nil {
+		return entry, fmt.Errorf("failed to clone %s: %v", cloneRef, err)
+	}
+	defer gitRepo.Clean()
+	if err := gitRepo.Checkout(base); err != nil {
+		return entry, err
+	}
+
+	if entry.aliases == nil || entry.sha != sha {
+		// aliases must be loaded
+		entry.aliases = loadAliasesFrom(gitRepo.Dir, log)
+		entry.loadedSHA = sha
+	}
+
+	blacklistConfig := c.config.OwnersDirBlacklist
+
+	dirBlacklist := defaultDirBlacklist.Union(sets.NewString(blacklistConfig.Default...))
+	if bl, ok := blacklistConfig.Repos[org]; ok {
+		dirBlacklist.Insert(bl...)
+	}
+	if bl, ok := blacklistConfig.Repos[org+"/"+repo]; ok {
+		dirBlacklist.Insert(bl...)
+	}
+	entry.owners, err = loadOwnersFrom(gitRepo.Dir, mdYaml, mergeAliases(orgAliases, entry.aliases), dirBlacklist, log)
+	if err != nil {
+		return entry, fmt.Errorf("failed to load RepoOwners for %s:%s: %v", cloneRef, base, err)
+	}
+	entry.sha = sha
+	return entry, nil
+}
+
+// mergeAliases returns the aliases of the org overridden by the aliases of the repo
+func mergeAliases(orgAliases, repoAliases RepoAliases) RepoAliases {
+	if len(orgAliases) == 0 {
+		return repoAliases
+	}
+	merged := make(RepoAliases, len(orgAliases)+len(repoAliases))
+	for alias, logins := range orgAliases {
+		merged[alias] = logins
+	}
+	for alias, logins := range repoAliases {
+		merged[alias] = logins
+	}
+	return merged
+}
+
 // ExpandAlias returns members of an alias
 func (a RepoAliases) ExpandAlias(alias string) sets.String {
 	if a == nil {
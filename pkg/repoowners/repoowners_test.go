@@ -148,7 +148,7 @@ func getTestClient(
 			git:    git,
 			spc:    &fake.SCMClient{Collaborators: []string{"cjwagner", "k8s-ci-robot", "alice", "bob", "carl", "mml", "maggie"}},
 			logger: logrus.WithField("client", "repoowners"),
-			cache:  make(map[string]cacheEntry),
+			cache:  NewCache(),
 
 			mdYAMLEnabled: func(org, repo string) bool {
 				return enableMdYaml
@@ -0,0 +1,190 @@
+// Package requiredjobs decides which presubmits apply to a pull request and which of their contexts are required for
+// it to be merged, so that the trigger plugin, keeper and the notifier agree on them.
+package requiredjobs
+
+import (
+	"strings"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// SkippedDescription is the description of the successful statuses the trigger plugin reports for the contexts of the
+// presubmits it skips
+const SkippedDescription = "Skipped."
+
+// DefaultSkipCIMarkers are the markers of the pull requests whose presubmits are skipped, when no others are configured
+var DefaultSkipCIMarkers = []string{"[skip ci]", "[ci skip]"}
+
+// Presubmits returns the presubmits of the repository, including the ones configured with the lowercase name of its
+// owner
+func Presubmits(cfg *config.Config, org, repo string) []config.Presubmit {
+	if cfg == nil {
+		return nil
+	}
+	return cfg.GetPresubmits(scm.Repository{Namespace: org, Name: repo, FullName: scm.Join(org, repo)})
+}
+
+// Presubmit returns the presubmit of the repository with the name, or nil if there is none
+func Presubmit(cfg *config.Config, org, repo, name string) *config.Presubmit {
+	for _, ps := range Presubmits(cfg, org, repo) {
+		if ps.Name == name {
+			answer := ps
+			return &answer
+		}
+	}
+	return nil
+}
+
+// Required returns true if the context of the presubmit must be successful for a pull request to be merged: the
+// optional presubmits, the ones which do not report and the merge gates, checked by keeper on its own, are not required
+func Required(ps config.Presubmit) bool {
+	return ps.ContextRequired() && !IsMergeGate(ps)
+}
+
+// IsMergeGate returns true if keeper runs the presubmit against the merge result of a pull request right before
+// merging it
+func IsMergeGate(ps config.Presubmit) bool {
+	return ps.Annotations[util.MergeGateAnnotation] == "true"
+}
+
+// MergeGates returns the merge gates of the presubmits which run against the branch
+func MergeGates(presubmits []config.Presubmit, branch string) []config.Presubmit {
+	var answer []config.Presubmit
+	for _, ps := range presubmits {
+		if IsMergeGate(ps) && ps.CouldRun(branch) {
+			answer = append(answer, ps)
+		}
+	}
+	return answer
+}
+
+// ShouldRun returns true if the presubmit runs against a pull request to the branch changing the files. A forced
+// presubmit runs whatever the files, and defaults makes the presubmits without any run_if_changed run. As the files
+// of a pull request changing too many files are truncated, every presubmit which could run against the branch runs.
+func ShouldRun(ps config.Presubmit, branch string, changes config.ChangedFilesProvider, forced, defaults bool) (bool, error) {
+	shouldRun, err := ps.ShouldRun(branch, changes, forced, defaults)
+	if err != nil && scmprovider.IsTooManyChanges(err) {
+		return ps.CouldRun(branch), nil
+	}
+	return shouldRun, err
+}
+
+// ChangesClient lists the files changed by a pull request
+type ChangesClient interface {
+	GetPullRequestChanges(org, repo string, number int) ([]*scm.Change, error)
+}
+
+// ChangedFiles returns the provider of the files changed by the pull request, fetched at most once. Unlike
+// config.NewGitHubDeferredChangedFilesProvider, it keeps the cause of the errors so that ShouldRun falls back to
+// running every presubmit if the pull request changes too many files.
+func ChangedFiles(client ChangesClient, org, repo string, number int) config.ChangedFilesProvider {
+	var changedFiles []string
+	var tooMany error
+	fetched := false
+	return func() ([]string, error) {
+		if !fetched {
+			changes, err := client.GetPullRequestChanges(org, repo, number)
+			if err != nil && !scmprovider.IsTooManyChanges(err) {
+				return nil, errors.Wrap(err, "getting the pull request changes")
+			}
+			for _, change := range changes {
+				changedFiles = append(changedFiles, change.Path)
+			}
+			tooMany = err
+			fetched = true
+		}
+		return changedFiles, tooMany
+	}
+}
+
+// Applies returns true if the presubmit runs against a pull request to the branch changing the files without being
+// requested by a comment
+func Applies(ps config.Presubmit, branch string, changes config.ChangedFilesProvider) (bool, error) {
+	return ShouldRun(ps, branch, changes, false, false)
+}
+
+// For returns the required presubmits which apply to a pull request to the branch changing the files
+func For(presubmits []config.Presubmit, branch string, changes config.ChangedFilesProvider) ([]config.Presubmit, error) {
+	var answer []config.Presubmit
+	for _, ps := range presubmits {
+		if !Required(ps) {
+			continue
+		}
+		applies, err := Applies(ps, branch, changes)
+		if err != nil {
+			return nil, err
+		}
+		if applies {
+			answer = append(answer, ps)
+		}
+	}
+	return answer, nil
+}
+
+// Contexts returns the contexts of the required presubmits which apply to a pull request to the branch changing the
+// files
+func Contexts(presubmits []config.Presubmit, branch string, changes config.ChangedFilesProvider) (sets.String, error) {
+	required, err := For(presubmits, branch, changes)
+	if err != nil {
+		return nil, err
+	}
+	answer := sets.NewString()
+	for _, ps := range required {
+		answer.Insert(ps.Context)
+	}
+	return answer, nil
+}
+
+// ConditionalContexts returns the contexts of the required presubmits against the branch which only run when the
+// files they match are changed, so may or may not be required for a given pull request
+func ConditionalContexts(presubmits []config.Presubmit, branch string) sets.String {
+	answer := sets.NewString()
+	for _, ps := range presubmits {
+		if Required(ps) && ps.RegexpChangeMatcher.CouldRun() && ps.CouldRun(branch) {
+			answer.Insert(ps.Context)
+		}
+	}
+	return answer
+}
+
+// IsRequired returns true if the presubmit of the repository with the name is required, looking it up without
+// knowing the files changed by the pull request
+func IsRequired(cfg *config.Config, org, repo, name string) bool {
+	ps := Presubmit(cfg, org, repo, name)
+	return ps != nil && Required(*ps)
+}
+
+// SkipCIMarker returns the first of the markers, or of the DefaultSkipCIMarkers if there is none, which one of the
+// texts, such as the title of a pull request and the message of its head commit, contains ignoring case, or an empty
+// string if none does
+func SkipCIMarker(markers []string, texts ...string) string {
+	if len(markers) == 0 {
+		markers = DefaultSkipCIMarkers
+	}
+	for _, text := range texts {
+		text = strings.ToLower(text)
+		for _, marker := range markers {
+			if marker != "" && strings.Contains(text, strings.ToLower(marker)) {
+				return marker
+			}
+		}
+	}
+	return ""
+}
+
+// SkippedContexts returns the contexts of the presubmits against the branch which are not required for a pull request
+// labeled skip-ci, as its presubmits only run when requested
+func SkippedContexts(presubmits []config.Presubmit, branch string) sets.String {
+	answer := sets.NewString()
+	for _, ps := range presubmits {
+		if Required(ps) && ps.CouldRun(branch) {
+			answer.Insert(ps.Context)
+		}
+	}
+	return answer
+}
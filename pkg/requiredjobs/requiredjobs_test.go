@@ -0,0 +1,135 @@
+package requiredjobs
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const jobs = `
+presubmits:
+  org/repo:
+  - name: unit
+    context: unit
+    agent: tekton
+    always_run: true
+  - name: docs
+    context: docs
+    agent: tekton
+    run_if_changed: '^docs/'
+  - name: lint
+    context: lint
+    agent: tekton
+    always_run: true
+    optional: true
+  - name: release
+    context: release
+    agent: tekton
+    always_run: true
+    branches:
+    - release
+  Org/repo:
+  - name: e2e
+    context: e2e
+    agent: tekton
+    always_run: true
+`
+
+func changes(files ...string) config.ChangedFilesProvider {
+	return func() ([]string, error) {
+		return files, nil
+	}
+}
+
+func TestContexts(t *testing.T) {
+	cfg, err := config.LoadYAMLConfig([]byte(jobs))
+	require.NoError(t, err)
+
+	presubmits := Presubmits(cfg, "Org", "repo")
+	assert.Len(t, presubmits, 5, "the presubmits of the lowercase owner should be included")
+
+	contexts, err := Contexts(presubmits, "master", changes("main.go"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"e2e", "unit"}, contexts.List())
+
+	contexts, err = Contexts(presubmits, "master", changes("docs/index.md"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"docs", "e2e", "unit"}, contexts.List())
+
+	contexts, err = Contexts(presubmits, "release", changes("main.go"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"e2e", "release", "unit"}, contexts.List())
+
+	assert.Equal(t, []string{"docs"}, ConditionalContexts(presubmits, "master").List())
+
+	assert.True(t, IsRequired(cfg, "Org", "repo", "unit"))
+	assert.True(t, IsRequired(cfg, "Org", "repo", "e2e"))
+	assert.False(t, IsRequired(cfg, "Org", "repo", "lint"))
+	assert.False(t, IsRequired(cfg, "org", "other", "unit"))
+}
+
+type fakeChangesClient struct {
+	changes []*scm.Change
+	err     error
+	calls   int
+}
+
+func (f *fakeChangesClient) GetPullRequestChanges(string, string, int) ([]*scm.Change, error) {
+	f.calls++
+	return f.changes, f.err
+}
+
+func TestTooManyChanges(t *testing.T) {
+	cfg, err := config.LoadYAMLConfig([]byte(jobs))
+	require.NoError(t, err)
+	presubmits := Presubmits(cfg, "org", "repo")
+
+	client := &fakeChangesClient{
+		changes: []*scm.Change{{Path: "main.go"}},
+		err:     &scmprovider.TooManyChangesError{Limit: 1},
+	}
+	changedFiles := ChangedFiles(client, "org", "repo", 1)
+	contexts, err := Contexts(presubmits, "master", changedFiles)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"docs", "unit"}, contexts.List(), "the conditional presubmits should apply as the changes are truncated")
+
+	files, err := changedFiles()
+	assert.True(t, scmprovider.IsTooManyChanges(err))
+	assert.Equal(t, []string{"main.go"}, files)
+	assert.Equal(t, 1, client.calls, "the changes should be fetched once")
+
+	client = &fakeChangesClient{err: scm.ErrNotFound}
+	_, err = Contexts(presubmits, "master", ChangedFiles(client, "org", "repo", 1))
+	assert.Error(t, err)
+}
+
+func TestMergeGates(t *testing.T) {
+	gate := config.Presubmit{
+		JobBase:  config.JobBase{Name: "gate", Annotations: map[string]string{util.MergeGateAnnotation: "true"}},
+		Reporter: config.Reporter{Context: "gate"},
+		Brancher: config.Brancher{Branches: []string{"master"}},
+	}
+	unit := config.Presubmit{JobBase: config.JobBase{Name: "unit"}, Reporter: config.Reporter{Context: "unit"}, AlwaysRun: true}
+	presubmits := []config.Presubmit{gate, unit}
+	require.NoError(t, config.SetPresubmitRegexes(presubmits))
+
+	assert.False(t, Required(presubmits[0]), "the merge gates are checked by keeper on their own")
+	assert.True(t, Required(presubmits[1]))
+	gates := MergeGates(presubmits, "master")
+	require.Len(t, gates, 1)
+	assert.Equal(t, "gate", gates[0].Name)
+	assert.Empty(t, MergeGates(presubmits, "release"))
+}
+
+func TestSkipCIMarker(t *testing.T) {
+	assert.Equal(t, "[skip ci]", SkipCIMarker(nil, "Update the docs [Skip CI]"))
+	assert.Equal(t, "[ci skip]", SkipCIMarker(nil, "Bump the version", "Bump the version\n\n[ci skip]"))
+	assert.Empty(t, SkipCIMarker(nil, "Fix the build", "skip ci"))
+	assert.Equal(t, "[docs]", SkipCIMarker([]string{"[docs]"}, "[docs] Fix a typo"))
+	assert.Empty(t, SkipCIMarker([]string{"[docs]"}, "Fix a typo [skip ci]"))
+}
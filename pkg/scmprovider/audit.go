@@ -0,0 +1,24 @@
+package scmprovider
+
+import "github.com/jenkins-x/lighthouse/pkg/audit"
+
+// audit records a change made by the bot to the audit stream
+func (c *Client) audit(action, owner, repo string, number int, ref, target string, err error) {
+	if !audit.Default().Enabled() {
+		return
+	}
+	actor, _ := c.BotName()
+	outcome, message := audit.Outcome(err)
+	audit.Record(audit.Event{
+		Type:    audit.ActionEvent,
+		Actor:   actor,
+		Org:     owner,
+		Repo:    repo,
+		Number:  number,
+		Ref:     ref,
+		Action:  action,
+		Target:  target,
+		Outcome: outcome,
+		Error:   message,
+	})
+}
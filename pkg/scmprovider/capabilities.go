@@ -0,0 +1,79 @@
+package scmprovider
+
+// Capabilities describes which features of GitHub an SCM provider supports, so that plugins and keeper can leave out
+// or replace what the provider lacks instead of failing when they call it
+type Capabilities struct {
+	// Checks is true if the provider reports commit results as check runs which can be rerequested
+	Checks bool
+	// DraftPRs is true if the provider marks pull requests as drafts
+	DraftPRs bool
+	// TeamMembership is true if the teams of an organization and their members can be listed
+	TeamMembership bool
+	// Reviews is true if the reviews of a pull request can be listed
+	Reviews bool
+	// PRLabels is true if pull requests can be labeled, otherwise labels are kept in comments
+	PRLabels bool
+	// Reactions is true if reactions can be added to comments
+	Reactions bool
+	// MaxStatusesPerSHA is the number of statuses the provider accepts for a commit, 0 if it is not limited
+	MaxStatusesPerSHA int
+	// StatusTargetURLRequired is true if a status is rejected without a target URL
+	StatusTargetURLRequired bool
+	// QuotedMentions is true if logins have to be quoted to mention their users in a comment
+	QuotedMentions bool
+}
+
+// githubStatusesPerSHA is the number of statuses GitHub accepts for a commit and context
+const githubStatusesPerSHA = 1000
+
+var providerCapabilities = map[string]Capabilities{
+	"github": {
+		Checks:            true,
+		DraftPRs:          true,
+		TeamMembership:    true,
+		Reviews:           true,
+		PRLabels:          true,
+		Reactions:         true,
+		MaxStatusesPerSHA: githubStatusesPerSHA,
+	},
+	"gitlab": {
+		DraftPRs:  true,
+		PRLabels:  true,
+		Reactions: true,
+	},
+	"stash": {
+		StatusTargetURLRequired: true,
+		QuotedMentions:          true,
+	},
+	// "coding" is a placeholder provider name from go-scm that we use for testing labels kept in comments
+	"coding":    {},
+	"gitea":     {PRLabels: true},
+	"gogs":      {PRLabels: true},
+	"bitbucket": {PRLabels: true},
+	// the hashtags of Gerrit changes are their labels
+	"gerrit": {PRLabels: true},
+	// the fake provider of the tests behaves like GitHub
+	"fake": {
+		Checks:            true,
+		DraftPRs:          true,
+		TeamMembership:    true,
+		Reviews:           true,
+		PRLabels:          true,
+		Reactions:         true,
+		MaxStatusesPerSHA: githubStatusesPerSHA,
+	},
+}
+
+// CapabilitiesOf returns the capabilities of the given provider type. An unknown provider is assumed to support
+// labels only.
+func CapabilitiesOf(providerType string) Capabilities {
+	if capabilities, ok := providerCapabilities[providerType]; ok {
+		return capabilities
+	}
+	return Capabilities{PRLabels: true}
+}
+
+// Capabilities returns the capabilities of the underlying SCM provider
+func (c *Client) Capabilities() Capabilities {
+	return CapabilitiesOf(c.ProviderType())
+}
@@ -0,0 +1,136 @@
+package scmprovider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// MaxChangedFilesEnvVar is the environment variable setting the most changed files fetched for a pull request
+	MaxChangedFilesEnvVar = "LIGHTHOUSE_MAX_CHANGED_FILES"
+
+	// DefaultMaxChangedFiles is the most changed files fetched for a pull request by default, which is also the most
+	// GitHub lists
+	DefaultMaxChangedFiles = 3000
+
+	// changesPageSize is the number of changed files fetched per request, the most the providers accept
+	changesPageSize = 100
+)
+
+// MaxChangedFiles is the most changed files fetched for a pull request, beyond which GetPullRequestChanges returns a
+// TooManyChangesError
+var MaxChangedFiles = DefaultMaxChangedFiles
+
+func init() {
+	if text := os.Getenv(MaxChangedFilesEnvVar); text != "" {
+		max, err := strconv.Atoi(text)
+		if err != nil || max <= 0 {
+			logrus.Warnf("invalid $%s %q, using %d", MaxChangedFilesEnvVar, text, DefaultMaxChangedFiles)
+		} else {
+			MaxChangedFiles = max
+		}
+	}
+}
+
+// TooManyChangesError is returned with the first changed files of a pull request changing more files than the limit,
+// for which the logic depending on the changed files falls back to assuming every file may have changed
+type TooManyChangesError struct {
+	Limit int
+}
+
+func (e *TooManyChangesError) Error() string {
+	return fmt.Sprintf("the pull request changes more than %d files", e.Limit)
+}
+
+// IsTooManyChanges returns true if the cause of the error is a TooManyChangesError
+func IsTooManyChanges(err error) bool {
+	_, ok := errors.Cause(err).(*TooManyChangesError)
+	return ok
+}
+
+// ChangesCache caches the changed files of the pull requests so that the plugins handling the same event share them
+// instead of paging through them again
+type ChangesCache struct {
+	lock    sync.Mutex
+	entries map[string]cachedChanges
+}
+
+type cachedChanges struct {
+	changes []*scm.Change
+	err     error
+}
+
+// NewChangesCache creates an empty cache of changed files
+func NewChangesCache() *ChangesCache {
+	return &ChangesCache{entries: map[string]cachedChanges{}}
+}
+
+func (c *ChangesCache) get(key string) (cachedChanges, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	entry, ok := c.entries[key]
+	return entry, ok
+}
+
+func (c *ChangesCache) put(key string, entry cachedChanges) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.entries[key] = entry
+}
+
+// WithChangesCache makes the client look up and store the changed files of the pull requests in the cache
+func (c *Client) WithChangesCache(cache *ChangesCache) *Client {
+	c.changes = cache
+	return c
+}
+
+// GetPullRequestChanges returns the changes in a pull request. If it changes more than MaxChangedFiles files, the
+// first MaxChangedFiles changes are returned with a TooManyChangesError.
+func (c *Client) GetPullRequestChanges(org, repo string, number int) ([]*scm.Change, error) {
+	key := fmt.Sprintf("%s/%s#%d", org, repo, number)
+	if c.changes != nil {
+		if entry, ok := c.changes.get(key); ok {
+			return entry.changes, entry.err
+		}
+	}
+	changes, err := c.listPullRequestChanges(org, repo, number)
+	if c.changes != nil && (err == nil || IsTooManyChanges(err)) {
+		c.changes.put(key, cachedChanges{changes: changes, err: err})
+	}
+	return changes, err
+}
+
+func (c *Client) listPullRequestChanges(org, repo string, number int) ([]*scm.Change, error) {
+	ctx := context.Background()
+	fullName := c.repositoryName(org, repo)
+	var allChanges []*scm.Change
+	var resp *scm.Response
+	var changes []*scm.Change
+	var err error
+	firstRun := false
+	opts := scm.ListOptions{
+		Page: 1,
+		Size: changesPageSize,
+	}
+	for !firstRun || (resp != nil && opts.Page <= resp.Page.Last) {
+		changes, resp, err = c.client.PullRequests.ListChanges(ctx, fullName, number, opts)
+		if err != nil {
+			return nil, err
+		}
+		firstRun = true
+		allChanges = append(allChanges, changes...)
+		morePages := resp != nil && opts.Page < resp.Page.Last
+		if len(allChanges) > MaxChangedFiles || (len(allChanges) == MaxChangedFiles && morePages) {
+			return allChanges[:MaxChangedFiles], &TooManyChangesError{Limit: MaxChangedFiles}
+		}
+		opts.Page++
+	}
+	return allChanges, nil
+}
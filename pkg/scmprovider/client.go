@@ -7,6 +7,7 @@ import (
 	"os"
 
 	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse/pkg/gerrit"
 	"k8s.io/apimachinery/pkg/util/sets"
 )
 
@@ -26,6 +27,9 @@ type SCMClient interface {
 	ServerURL() *url.URL
 	QuoteAuthorForComment(string) string
 
+	// Functions implemented in capabilities.go
+	Capabilities() Capabilities
+
 	// Functions implemented in content.go
 	GetFile(string, string, string, string) ([]byte, error)
 
@@ -33,6 +37,7 @@ type SCMClient interface {
 	GetRef(string, string, string) (string, error)
 	DeleteRef(string, string, string) error
 	GetSingleCommit(string, string, string) (*scm.Commit, error)
+	ListCommits(string, string, scm.CommitListOptions) ([]*scm.Commit, error)
 
 	// Functions implemented in issues.go
 	Query(context.Context, interface{}, map[string]interface{}) error
@@ -67,8 +72,14 @@ type SCMClient interface {
 	ClosePR(string, string, int) error
 	ListAllPullRequestsForFullNameRepo(string, scm.PullRequestListOptions) ([]*scm.PullRequest, error)
 
+	// Functions implemented in reactions.go
+	CreateCommentReaction(string, string, int, bool, CommentKind, int, Reaction) error
+
 	// Functions implemented in repositories.go
 	GetRepoLabels(string, string) ([]*scm.Label, error)
+	CreateRepoLabel(string, string, scm.Label) error
+	UpdateRepoLabel(string, string, string, scm.Label) error
+	DeleteRepoLabel(string, string, string) error
 	IsCollaborator(string, string, string) (bool, error)
 	ListCollaborators(string, string) ([]scm.User, error)
 	CreateStatus(string, string, string, *scm.StatusInput) (*scm.Status, error)
@@ -95,6 +106,7 @@ type SCMClient interface {
 type Client struct {
 	client  *scm.Client
 	botName string
+	changes *ChangesCache
 }
 
 // ClearMilestone clears milestone
@@ -132,12 +144,12 @@ func (c *Client) SetBotName(botName string) {
 
 // SupportsPRLabels returns true if the underlying provider supports PR labels
 func (c *Client) SupportsPRLabels() bool {
-	return !NoLabelProviders().Has(c.ProviderType())
+	return c.Capabilities().PRLabels
 }
 
 // QuoteAuthorForComment will quote the author login for use in "@author" if appropriate for the provider.
 func (c *Client) QuoteAuthorForComment(author string) string {
-	if c.ProviderType() == "stash" {
+	if c.Capabilities().QuotedMentions {
 		return `"` + author + `"`
 	}
 	return author
@@ -155,6 +167,9 @@ func (c *Client) SupportsGraphQL() bool {
 
 // ProviderType returns the type of the underlying SCM provider
 func (c *Client) ProviderType() string {
+	if c.client.Driver == gerrit.Driver {
+		return gerrit.Kind
+	}
 	return c.client.Driver.String()
 }
 
@@ -52,6 +52,8 @@ type SCMClient struct {
 	CreatedStatuses     map[string][]*scm.StatusInput
 	IssueEvents         map[int][]*scm.ListedIssueEvent
 	Commits             map[string]*scm.Commit
+	// CommitHistories are the commits returned by ListCommits for a ref, the latest first
+	CommitHistories map[string][]*scm.Commit
 
 	//All Labels That Exist In The Repo
 	RepoLabelsExisting []string
@@ -98,6 +100,11 @@ func (f *SCMClient) ProviderType() string {
 	return providerType
 }
 
+// Capabilities returns the capabilities of the fake provider, which are the ones of GitHub
+func (f *SCMClient) Capabilities() scmprovider.Capabilities {
+	return scmprovider.CapabilitiesOf(providerType)
+}
+
 // SupportsGraphQL returns whether the provider supports GraphQL
 func (f *SCMClient) SupportsGraphQL() bool {
 	return false
@@ -176,7 +183,7 @@ func (f *SCMClient) CreateReview(org, repo string, number int, r scmprovider.Dra
 }
 
 // CreateCommentReaction adds emoji to a comment.
-func (f *SCMClient) CreateCommentReaction(org, repo string, ID int, reaction string) error {
+func (f *SCMClient) CreateCommentReaction(org, repo string, number int, pr bool, kind scmprovider.CommentKind, ID int, reaction scmprovider.Reaction) error {
 	f.CommentReactionsAdded = append(f.CommentReactionsAdded, fmt.Sprintf("%s/%s#%d:%s", org, repo, ID, reaction))
 	return nil
 }
@@ -214,6 +221,21 @@ func (f *SCMClient) DeleteComment(owner, repo string, number, ID int, pr bool) e
 	return fmt.Errorf("could not find issue comment %d", ID)
 }
 
+// EditComment edits a comment.
+func (f *SCMClient) EditComment(owner, repo string, number, ID int, comment string, pr bool) error {
+	comments := f.IssueComments
+	if pr {
+		comments = f.PullRequestComments
+	}
+	for _, c := range comments[number] {
+		if c.ID == ID {
+			c.Body = comment
+			return nil
+		}
+	}
+	return fmt.Errorf("could not find comment %d", ID)
+}
+
 // DeleteStaleComments deletes comments flagged by isStale.
 func (f *SCMClient) DeleteStaleComments(org, repo string, number int, comments []*scm.Comment, pr bool, isStale func(*scm.Comment) bool) error {
 	if comments == nil {
@@ -242,9 +264,13 @@ func (f *SCMClient) GetPullRequest(owner, repo string, number int) (*scm.PullReq
 	return val, nil
 }
 
-// GetPullRequestChanges returns the file modifications in a PR.
+// GetPullRequestChanges returns the file modifications in a PR, up to scmprovider.MaxChangedFiles.
 func (f *SCMClient) GetPullRequestChanges(org, repo string, number int) ([]*scm.Change, error) {
-	return f.PullRequestChanges[number], nil
+	changes := f.PullRequestChanges[number]
+	if len(changes) > scmprovider.MaxChangedFiles {
+		return changes[:scmprovider.MaxChangedFiles], &scmprovider.TooManyChangesError{Limit: scmprovider.MaxChangedFiles}
+	}
+	return changes, nil
 }
 
 // GetRef returns the hash of a ref.
@@ -263,6 +289,11 @@ func (f *SCMClient) GetSingleCommit(org, repo, SHA string) (*scm.Commit, error)
 	return f.Commits[SHA], nil
 }
 
+// ListCommits lists the commits of the history of the ref.
+func (f *SCMClient) ListCommits(owner, repo string, opts scm.CommitListOptions) ([]*scm.Commit, error) {
+	return f.CommitHistories[opts.Ref], nil
+}
+
 // CreateStatus adds a status context to a commit.
 func (f *SCMClient) CreateStatus(owner, repo, SHA string, s *scm.StatusInput) (*scm.Status, error) {
 	if f.CreatedStatuses == nil {
@@ -0,0 +1,243 @@
+// Package harness runs the registered handlers of plugins against the go-scm fake driver and a fake launcher, so that
+// plugins maintained outside of lighthouse can be tested as they are run by its webhooks.
+package harness
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/jenkins-x/go-scm/scm"
+	fakescm "github.com/jenkins-x/go-scm/scm/driver/fake"
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	lhfake "github.com/jenkins-x/lighthouse/pkg/client/clientset/versioned/fake"
+	lighthouseclient "github.com/jenkins-x/lighthouse/pkg/client/clientset/versioned/typed/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/errorutil"
+	launcherfake "github.com/jenkins-x/lighthouse/pkg/launcher/fake"
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider/fake"
+	"github.com/sirupsen/logrus"
+)
+
+// Namespace is the namespace of the LighthouseJobs of the harness
+const Namespace = "jx"
+
+// Harness runs the handlers of the plugins enabled by its PluginConfig, recording what they do
+type Harness struct {
+	// Data is what the go-scm fake driver serves to the plugins and records of their actions
+	Data *fakescm.Data
+	// Launcher records the jobs the plugins start
+	Launcher *launcherfake.Launcher
+	// Jobs are the LighthouseJobs the plugins can list
+	Jobs lighthouseclient.LighthouseJobInterface
+	// Config is the configuration of the jobs
+	Config *config.Config
+	// PluginConfig is the configuration of the plugins, enabling them for repositories
+	PluginConfig *plugins.Configuration
+	Logger       *logrus.Entry
+
+	client *scm.Client
+}
+
+// New creates a harness with empty configurations, the plugins to test being enabled with PluginConfig
+func New() *Harness {
+	client, data := fakescm.NewDefault()
+	return &Harness{
+		Data:         data,
+		Launcher:     launcherfake.NewLauncher(),
+		Jobs:         lhfake.NewSimpleClientset().LighthouseV1alpha1().LighthouseJobs(Namespace),
+		Config:       &config.Config{},
+		PluginConfig: &plugins.Configuration{},
+		Logger:       logrus.WithField("harness", "fake"),
+		client:       client,
+	}
+}
+
+// Client returns the fake SCM client whose calls are recorded in Data
+func (h *Harness) Client() *scm.Client {
+	return h.client
+}
+
+// Agent returns the agent the handlers are called with. The plugins using git or OWNERS files need a GitClient and
+// an OwnersClient to be set on it.
+func (h *Harness) Agent() plugins.Agent {
+	return plugins.Agent{
+		SCMProviderClient: scmprovider.ToClient(h.client, fake.Bot),
+		LauncherClient:    h.Launcher,
+		LighthouseClient:  h.Jobs,
+		Config:            h.Config,
+		PluginConfig:      h.PluginConfig,
+		Logger:            h.Logger,
+	}
+}
+
+func (h *Harness) configAgent() *plugins.ConfigAgent {
+	pa := &plugins.ConfigAgent{}
+	pa.Set(h.PluginConfig)
+	return pa
+}
+
+// HandleGenericComment calls the comment handlers of the plugins enabled for the repository of the event
+func (h *Harness) HandleGenericComment(e scmprovider.GenericCommentEvent) error {
+	handlers := h.configAgent().GenericCommentHandlers(e.Repo.Namespace, e.Repo.Name)
+	var errs []error
+	for _, name := range sortedNames(handlers) {
+		if err := handlers[name](h.Agent(), e); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", name, err))
+		}
+	}
+	return errorutil.NewAggregate(errs...)
+}
+
+// HandlePullRequest calls the pull request handlers of the plugins enabled for the repository of the event
+func (h *Harness) HandlePullRequest(e scm.PullRequestHook) error {
+	handlers := h.configAgent().PullRequestHandlers(e.Repo.Namespace, e.Repo.Name)
+	var errs []error
+	for _, name := range sortedNames(handlers) {
+		if err := handlers[name](h.Agent(), e); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", name, err))
+		}
+	}
+	return errorutil.NewAggregate(errs...)
+}
+
+// HandlePush calls the push handlers of the plugins enabled for the repository of the event
+func (h *Harness) HandlePush(e scm.PushHook) error {
+	handlers := h.configAgent().PushEventHandlers(e.Repo.Namespace, e.Repo.Name)
+	var errs []error
+	for _, name := range sortedNames(handlers) {
+		if err := handlers[name](h.Agent(), e); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %v", name, err))
+		}
+	}
+	return errorutil.NewAggregate(errs...)
+}
+
+// PullRequest returns an open pull request of the repository by the author against master, which the fake driver
+// serves too
+func (h *Harness) PullRequest(org, repo string, number int, author string) *scm.PullRequest {
+	repository := scm.Repository{
+		Namespace: org,
+		Name:      repo,
+		FullName:  scm.Join(org, repo),
+		Branch:    "master",
+		Link:      fmt.Sprintf("https://fake.com/%s/%s", org, repo),
+	}
+	pr := &scm.PullRequest{
+		Number: number,
+		Title:  fmt.Sprintf("Pull request %d", number),
+		Author: scm.User{Login: author},
+		Link:   fmt.Sprintf("%s/pull/%d", repository.Link, number),
+		Base: scm.PullRequestBranch{
+			Ref:  "master",
+			Sha:  fake.TestRef,
+			Repo: repository,
+		},
+		Head: scm.PullRequestBranch{
+			Ref:  fmt.Sprintf("pr-%d", number),
+			Sha:  fmt.Sprintf("head-%d", number),
+			Repo: repository,
+		},
+	}
+	h.Data.PullRequests[number] = pr
+	return pr
+}
+
+// CommentEvent returns the event of the author commenting the body on the pull request
+func CommentEvent(pr *scm.PullRequest, author, body string) scmprovider.GenericCommentEvent {
+	return scmprovider.GenericCommentEvent{
+		IsPR:        true,
+		Action:      scm.ActionCreate,
+		Body:        body,
+		Link:        pr.Link,
+		Number:      pr.Number,
+		Repo:        pr.Base.Repo,
+		Author:      scm.User{Login: author},
+		IssueAuthor: pr.Author,
+		IssueState:  "open",
+		IssueBody:   pr.Body,
+		IssueLink:   pr.Link,
+		GUID:        "guid",
+	}
+}
+
+// PullRequestEvent returns the event of the action on the pull request by its author
+func PullRequestEvent(pr *scm.PullRequest, action scm.Action) scm.PullRequestHook {
+	return scm.PullRequestHook{
+		Action:      action,
+		Repo:        pr.Base.Repo,
+		PullRequest: *pr,
+		Sender:      pr.Author,
+		GUID:        "guid",
+	}
+}
+
+// Actions are what the plugins did, in a form which tests can compare with what they expect
+type Actions struct {
+	// Comments are the comments added to issues and pull requests as org/repo#number:body
+	Comments []string `json:"comments,omitempty"`
+	// LabelsAdded are the labels added to issues and pull requests as org/repo#number:label
+	LabelsAdded []string `json:"labelsAdded,omitempty"`
+	// LabelsRemoved are the labels removed from issues and pull requests as org/repo#number:label
+	LabelsRemoved []string `json:"labelsRemoved,omitempty"`
+	// Assignees are the users assigned to issues and pull requests as org/repo#number:assignee
+	Assignees []string `json:"assignees,omitempty"`
+	// Reactions are the reactions added to issues and comments as org/repo#id:reaction
+	Reactions []string `json:"reactions,omitempty"`
+	// Statuses are the statuses created as ref:context=state
+	Statuses []string `json:"statuses,omitempty"`
+	// Jobs are the names of the jobs started
+	Jobs []string `json:"jobs,omitempty"`
+}
+
+// Actions returns what the plugins did so far, sorted
+func (h *Harness) Actions() Actions {
+	answer := Actions{}
+	answer.Comments = sorted(h.Data.IssueCommentsAdded, h.Data.PullRequestCommentsAdded)
+	answer.LabelsAdded = sorted(h.Data.IssueLabelsAdded, h.Data.PullRequestLabelsAdded)
+	answer.LabelsRemoved = sorted(h.Data.IssueLabelsRemoved, h.Data.PullRequestLabelsRemoved)
+	answer.Assignees = sorted(h.Data.AssigneesAdded)
+	answer.Reactions = sorted(h.Data.IssueReactionsAdded, h.Data.CommentReactionsAdded)
+	var statuses []string
+	for ref, list := range h.Data.Statuses {
+		for _, s := range list {
+			statuses = append(statuses, fmt.Sprintf("%s:%s=%s", ref, s.Label, s.State))
+		}
+	}
+	answer.Statuses = sorted(statuses)
+	var jobs []string
+	for _, job := range h.Launcher.Pipelines {
+		jobs = append(jobs, job.Spec.Job)
+	}
+	answer.Jobs = sorted(jobs)
+	return answer
+}
+
+func sorted(lists ...[]string) []string {
+	var answer []string
+	for _, list := range lists {
+		answer = append(answer, list...)
+	}
+	sort.Strings(answer)
+	return answer
+}
+
+func sortedNames(handlers interface{}) []string {
+	var names []string
+	switch hs := handlers.(type) {
+	case map[string]plugins.GenericCommentHandler:
+		for name := range hs {
+			names = append(names, name)
+		}
+	case map[string]plugins.PullRequestHandler:
+		for name := range hs {
+			names = append(names, name)
+		}
+	case map[string]plugins.PushEventHandler:
+		for name := range hs {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,61 @@
+package harness
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/jobutil"
+	"github.com/jenkins-x/lighthouse/pkg/pluginhelp"
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPlugin = "harness-test"
+
+func init() {
+	plugins.RegisterGenericCommentHandler(testPlugin, func(agent plugins.Agent, e scmprovider.GenericCommentEvent) error {
+		if e.Body != "/build" {
+			return nil
+		}
+		pr, err := agent.SCMProviderClient.GetPullRequest(e.Repo.Namespace, e.Repo.Name, e.Number)
+		if err != nil {
+			return err
+		}
+		job := jobutil.NewPresubmit(pr, pr.Base.Sha, config.Presubmit{JobBase: config.JobBase{Name: "build"}}, e.GUID)
+		if _, err := agent.LauncherClient.Launch(&job, nil, pr.Repository()); err != nil {
+			return err
+		}
+		if _, err := agent.SCMProviderClient.CreateStatus(e.Repo.Namespace, e.Repo.Name, pr.Head.Sha, &scm.StatusInput{Label: "build", State: scm.StatePending}); err != nil {
+			return err
+		}
+		if err := agent.SCMProviderClient.AddLabel(e.Repo.Namespace, e.Repo.Name, e.Number, "building", true); err != nil {
+			return err
+		}
+		return agent.SCMProviderClient.CreateComment(e.Repo.Namespace, e.Repo.Name, e.Number, true, "started")
+	}, func(*plugins.Configuration, []string) (*pluginhelp.PluginHelp, error) {
+		return &pluginhelp.PluginHelp{}, nil
+	})
+}
+
+func TestHarness(t *testing.T) {
+	h := New()
+	h.PluginConfig.Plugins = map[string][]string{"org/repo": {testPlugin}}
+	pr := h.PullRequest("org", "repo", 1, "alice")
+
+	require.NoError(t, h.HandleGenericComment(CommentEvent(pr, "bob", "/build")))
+	assert.Equal(t, Actions{
+		Comments:    []string{"org/repo#1:started"},
+		LabelsAdded: []string{"org/repo#1:building"},
+		Statuses:    []string{"head-1:build=pending"},
+		Jobs:        []string{"build"},
+	}, h.Actions())
+
+	other := New()
+	pr = other.PullRequest("org", "other", 1, "alice")
+	other.PluginConfig.Plugins = map[string][]string{"org/repo": {testPlugin}}
+	require.NoError(t, other.HandleGenericComment(CommentEvent(pr, "bob", "/build")))
+	assert.Equal(t, Actions{}, other.Actions(), "the plugin should not handle the events of the repositories it is not enabled for")
+}
@@ -15,10 +15,11 @@ func (c *Client) GetRef(owner, repo, ref string) (string, error) {
 }
 
 // DeleteRef deletes the ref from repository
-func (c *Client) DeleteRef(owner, repo, ref string) error {
+func (c *Client) DeleteRef(owner, repo, ref string) (err error) {
+	defer func() { c.audit("delete_ref", owner, repo, 0, ref, "", err) }()
 	ctx := context.Background()
 	fullName := c.repositoryName(owner, repo)
-	_, err := c.client.Git.DeleteRef(ctx, fullName, ref)
+	_, err = c.client.Git.DeleteRef(ctx, fullName, ref)
 	return err
 }
 
@@ -29,3 +30,11 @@ func (c *Client) GetSingleCommit(owner, repo, SHA string) (*scm.Commit, error) {
 	commit, _, err := c.client.Git.FindCommit(ctx, fullName, SHA)
 	return commit, err
 }
+
+// ListCommits lists the commits of the repository reachable from the ref of the options, the latest first
+func (c *Client) ListCommits(owner, repo string, opts scm.CommitListOptions) ([]*scm.Commit, error) {
+	ctx := context.Background()
+	fullName := c.repositoryName(owner, repo)
+	commits, _, err := c.client.Git.ListCommits(ctx, fullName, opts)
+	return commits, err
+}
@@ -0,0 +1,109 @@
+package scmprovider
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/jenkins-x/go-scm/scm"
+)
+
+// MergeRequestApprovals is the approval state of a GitLab merge request
+type MergeRequestApprovals struct {
+	// Approved is true once the approval rules of the merge request are satisfied
+	Approved bool `json:"approved"`
+	// ApprovalsRequired is the number of approvals required by the approval rules
+	ApprovalsRequired int `json:"approvals_required"`
+	// ApprovalsLeft is the number of approvals still required
+	ApprovalsLeft int `json:"approvals_left"`
+}
+
+// Pipeline is a GitLab pipeline of a merge request
+type Pipeline struct {
+	ID     int    `json:"id"`
+	SHA    string `json:"sha"`
+	Ref    string `json:"ref"`
+	Status string `json:"status"`
+	// Source is what created the pipeline, which is external for the pipelines of the commit statuses
+	Source string `json:"source"`
+	WebURL string `json:"web_url"`
+}
+
+// The statuses of GitLab pipelines
+const (
+	PipelineSuccess  = "success"
+	PipelineFailed   = "failed"
+	PipelineCanceled = "canceled"
+	PipelineSkipped  = "skipped"
+	PipelineManual   = "manual"
+)
+
+// GetMergeRequestApprovals returns the approval state of a GitLab merge request
+func (c *Client) GetMergeRequestApprovals(owner, repo string, number int) (*MergeRequestApprovals, error) {
+	path, err := c.mergeRequestPath(owner, repo, number)
+	if err != nil {
+		return nil, err
+	}
+	answer := &MergeRequestApprovals{}
+	if _, err := c.doRequest(http.MethodGet, path+"/approvals", nil, answer, fmt.Sprintf("get the approvals of merge request %d", number)); err != nil {
+		return nil, err
+	}
+	return answer, nil
+}
+
+// ListMergeRequestPipelines returns the pipelines of a GitLab merge request, the most recent first
+func (c *Client) ListMergeRequestPipelines(owner, repo string, number int) ([]*Pipeline, error) {
+	path, err := c.mergeRequestPath(owner, repo, number)
+	if err != nil {
+		return nil, err
+	}
+	var answer []*Pipeline
+	if _, err := c.doRequest(http.MethodGet, path+"/pipelines", nil, &answer, fmt.Sprintf("list the pipelines of merge request %d", number)); err != nil {
+		return nil, err
+	}
+	return answer, nil
+}
+
+// mergeGitLab merges a GitLab merge request with the options go-scm does not pass: the head SHA, squashing, the
+// commit message and merging when the pipeline succeeds. The rebase method merges with the merge method of the
+// project, as GitLab only rebases merge requests on request.
+func (c *Client) mergeGitLab(owner, repo string, number int, details MergeDetails) error {
+	path, err := c.mergeRequestPath(owner, repo, number)
+	if err != nil {
+		return err
+	}
+	body := map[string]interface{}{}
+	if details.SHA != "" {
+		body["sha"] = details.SHA
+	}
+	message := strings.TrimSpace(strings.Join([]string{details.CommitTitle, details.CommitMessage}, "\n\n"))
+	if details.MergeMethod == "squash" {
+		body["squash"] = true
+		if message != "" {
+			body["squash_commit_message"] = message
+		}
+	} else if message != "" {
+		body["merge_commit_message"] = message
+	}
+	if details.MergeWhenPipelineSucceeds {
+		body["merge_when_pipeline_succeeds"] = true
+	}
+	status, err := c.doRequest(http.MethodPut, path+"/merge", body, nil, fmt.Sprintf("merge merge request %d", number))
+	switch status {
+	case http.StatusConflict:
+		return ModifiedHeadError(err.Error())
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return UnauthorizedToPushError(err.Error())
+	case http.StatusMethodNotAllowed, http.StatusNotAcceptable, http.StatusUnprocessableEntity:
+		return UnmergablePRError(err.Error())
+	}
+	return err
+}
+
+func (c *Client) mergeRequestPath(owner, repo string, number int) (string, error) {
+	if c.client.Driver != scm.DriverGitlab {
+		return "", scm.ErrNotSupported
+	}
+	return fmt.Sprintf("api/v4/projects/%s/merge_requests/%d", url.PathEscape(c.repositoryName(owner, repo)), number), nil
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"strconv"
+	"strings"
 
 	"github.com/jenkins-x/go-scm/scm"
 	"github.com/jenkins-x/jx/v2/pkg/log"
@@ -86,23 +87,26 @@ func (c *Client) ListIssueEvents(org, repo string, number int) ([]*scm.ListedIss
 }
 
 // AssignIssue assigns issue
-func (c *Client) AssignIssue(owner, repo string, number int, logins []string) error {
+func (c *Client) AssignIssue(owner, repo string, number int, logins []string) (err error) {
+	defer func() { c.audit("assign", owner, repo, number, "", strings.Join(logins, ","), err) }()
 	ctx := context.Background()
 	fullName := c.repositoryName(owner, repo)
-	_, err := c.client.Issues.AssignIssue(ctx, fullName, number, logins)
+	_, err = c.client.Issues.AssignIssue(ctx, fullName, number, logins)
 	return err
 }
 
 // UnassignIssue unassigns issue
-func (c *Client) UnassignIssue(owner, repo string, number int, logins []string) error {
+func (c *Client) UnassignIssue(owner, repo string, number int, logins []string) (err error) {
+	defer func() { c.audit("unassign", owner, repo, number, "", strings.Join(logins, ","), err) }()
 	ctx := context.Background()
 	fullName := c.repositoryName(owner, repo)
-	_, err := c.client.Issues.UnassignIssue(ctx, fullName, number, logins)
+	_, err = c.client.Issues.UnassignIssue(ctx, fullName, number, logins)
 	return err
 }
 
 // AddLabel adds a label
-func (c *Client) AddLabel(owner, repo string, number int, label string, pr bool) error {
+func (c *Client) AddLabel(owner, repo string, number int, label string, pr bool) (err error) {
+	defer func() { c.audit("add_label", owner, repo, number, "", label, err) }()
 	ctx := context.Background()
 	fullName := c.repositoryName(owner, repo)
 	if pr {
@@ -112,12 +116,13 @@ func (c *Client) AddLabel(owner, repo string, number int, label string, pr bool)
 		_, err := c.client.PullRequests.AddLabel(ctx, fullName, number, label)
 		return err
 	}
-	_, err := c.client.Issues.AddLabel(ctx, fullName, number, label)
+	_, err = c.client.Issues.AddLabel(ctx, fullName, number, label)
 	return err
 }
 
 // RemoveLabel removes labesl
-func (c *Client) RemoveLabel(owner, repo string, number int, label string, pr bool) error {
+func (c *Client) RemoveLabel(owner, repo string, number int, label string, pr bool) (err error) {
+	defer func() { c.audit("remove_label", owner, repo, number, "", label, err) }()
 	ctx := context.Background()
 	fullName := c.repositoryName(owner, repo)
 	if pr {
@@ -127,19 +132,20 @@ func (c *Client) RemoveLabel(owner, repo string, number int, label string, pr bo
 		_, err := c.client.PullRequests.DeleteLabel(ctx, fullName, number, label)
 		return err
 	}
-	_, err := c.client.Issues.DeleteLabel(ctx, fullName, number, label)
+	_, err = c.client.Issues.DeleteLabel(ctx, fullName, number, label)
 	return err
 }
 
 // DeleteComment delete comments
-func (c *Client) DeleteComment(org, repo string, number, ID int, pr bool) error {
+func (c *Client) DeleteComment(org, repo string, number, ID int, pr bool) (err error) {
+	defer func() { c.audit("delete_comment", org, repo, number, "", strconv.Itoa(ID), err) }()
 	ctx := context.Background()
 	fullName := c.repositoryName(org, repo)
 	if pr {
 		_, err := c.client.PullRequests.DeleteComment(ctx, fullName, number, ID)
 		return err
 	}
-	_, err := c.client.Issues.DeleteComment(ctx, fullName, number, ID)
+	_, err = c.client.Issues.DeleteComment(ctx, fullName, number, ID)
 	return err
 }
 
@@ -230,7 +236,8 @@ func (c *Client) GetIssueLabels(org, repo string, number int, pr bool) ([]*scm.L
 }
 
 // CreateComment create a comment
-func (c *Client) CreateComment(owner, repo string, number int, pr bool, comment string) error {
+func (c *Client) CreateComment(owner, repo string, number int, pr bool, comment string) (err error) {
+	defer func() { c.audit("create_comment", owner, repo, number, "", "", err) }()
 	fullName := c.repositoryName(owner, repo)
 	commentInput := scm.CommentInput{
 		Body: comment,
@@ -262,7 +269,8 @@ func (c *Client) CreateComment(owner, repo string, number int, pr bool, comment
 }
 
 // EditComment edit a comment
-func (c *Client) EditComment(owner, repo string, number int, id int, comment string, pr bool) error {
+func (c *Client) EditComment(owner, repo string, number int, id int, comment string, pr bool) (err error) {
+	defer func() { c.audit("edit_comment", owner, repo, number, "", strconv.Itoa(id), err) }()
 	fullName := c.repositoryName(owner, repo)
 	commentInput := scm.CommentInput{
 		Body: comment,
@@ -294,10 +302,11 @@ func (c *Client) EditComment(owner, repo string, number int, id int, comment str
 }
 
 // ReopenIssue reopen an issue
-func (c *Client) ReopenIssue(owner, repo string, number int) error {
+func (c *Client) ReopenIssue(owner, repo string, number int) (err error) {
+	defer func() { c.audit("reopen_issue", owner, repo, number, "", "", err) }()
 	ctx := context.Background()
 	fullName := c.repositoryName(owner, repo)
-	_, err := c.client.Issues.Reopen(ctx, fullName, number)
+	_, err = c.client.Issues.Reopen(ctx, fullName, number)
 	return err
 }
 
@@ -307,9 +316,10 @@ func (c *Client) FindIssues(query, sort string, asc bool) ([]scm.Issue, error) {
 }
 
 // CloseIssue close issue
-func (c *Client) CloseIssue(owner, repo string, number int) error {
+func (c *Client) CloseIssue(owner, repo string, number int) (err error) {
+	defer func() { c.audit("close_issue", owner, repo, number, "", "", err) }()
 	ctx := context.Background()
 	fullName := c.repositoryName(owner, repo)
-	_, err := c.client.Issues.Close(ctx, fullName, number)
+	_, err = c.client.Issues.Close(ctx, fullName, number)
 	return err
 }
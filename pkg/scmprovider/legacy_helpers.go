@@ -149,6 +149,13 @@ type GenericCommentEvent struct {
 	IssueBody   string
 	IssueLink   string
 	GUID        string
+	// CommentID is the ID of the comment, which is 0 for the bodies of pull requests and reviews
+	CommentID int
+	// CommentKind is the kind of the comment, which tells how to react to it
+	CommentKind CommentKind
+	// Reactions collects the reactions of the plugins when the commands of the comment are handled one after the
+	// other, the comment then being given a single reaction once all of them were handled
+	Reactions *ReactionBatch
 }
 
 // ReviewAction is the action that a review can be made with.
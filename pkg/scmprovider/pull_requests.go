@@ -14,6 +14,8 @@ type MergeDetails struct {
 	MergeMethod   string
 	CommitTitle   string
 	CommitMessage string
+	// MergeWhenPipelineSucceeds lets GitLab merge once the pipeline of the merge request succeeds
+	MergeWhenPipelineSucceeds bool
 }
 
 // GetPullRequest returns the pull request
@@ -112,32 +114,12 @@ func (c *Client) ListPullRequestComments(owner, repo string, number int) ([]*scm
 	return allComments, nil
 }
 
-// GetPullRequestChanges returns the changes in a pull request
-func (c *Client) GetPullRequestChanges(org, repo string, number int) ([]*scm.Change, error) {
-	ctx := context.Background()
-	fullName := c.repositoryName(org, repo)
-	var allChanges []*scm.Change
-	var resp *scm.Response
-	var changes []*scm.Change
-	var err error
-	firstRun := false
-	opts := scm.ListOptions{
-		Page: 1,
-	}
-	for !firstRun || (resp != nil && opts.Page <= resp.Page.Last) {
-		changes, resp, err = c.client.PullRequests.ListChanges(ctx, fullName, number, opts)
-		if err != nil {
-			return nil, err
-		}
-		firstRun = true
-		allChanges = append(allChanges, changes...)
-		opts.Page++
-	}
-	return allChanges, nil
-}
-
 // Merge reopens a pull request
-func (c *Client) Merge(owner, repo string, number int, details MergeDetails) error {
+func (c *Client) Merge(owner, repo string, number int, details MergeDetails) (err error) {
+	defer func() { c.audit("merge", owner, repo, number, details.SHA, details.MergeMethod, err) }()
+	if c.client.Driver == scm.DriverGitlab {
+		return c.mergeGitLab(owner, repo, number, details)
+	}
 	ctx := context.Background()
 	fullName := c.repositoryName(owner, repo)
 	mergeOptions := &scm.PullRequestMergeOptions{
@@ -145,7 +127,7 @@ func (c *Client) Merge(owner, repo string, number int, details MergeDetails) err
 		SHA:         details.SHA,
 		MergeMethod: details.MergeMethod,
 	}
-	_, err := c.client.PullRequests.Merge(ctx, fullName, number, mergeOptions)
+	_, err = c.client.PullRequests.Merge(ctx, fullName, number, mergeOptions)
 	return err
 }
 
@@ -175,17 +157,19 @@ type MergeCommitsForbiddenError string
 func (e MergeCommitsForbiddenError) Error() string { return string(e) }
 
 // ReopenPR reopens a pull request
-func (c *Client) ReopenPR(owner, repo string, number int) error {
+func (c *Client) ReopenPR(owner, repo string, number int) (err error) {
+	defer func() { c.audit("reopen_pr", owner, repo, number, "", "", err) }()
 	ctx := context.Background()
 	fullName := c.repositoryName(owner, repo)
-	_, err := c.client.PullRequests.Reopen(ctx, fullName, number)
+	_, err = c.client.PullRequests.Reopen(ctx, fullName, number)
 	return err
 }
 
 // ClosePR closes a pull request
-func (c *Client) ClosePR(owner, repo string, number int) error {
+func (c *Client) ClosePR(owner, repo string, number int) (err error) {
+	defer func() { c.audit("close_pr", owner, repo, number, "", "", err) }()
 	ctx := context.Background()
 	fullName := c.repositoryName(owner, repo)
-	_, err := c.client.PullRequests.Close(ctx, fullName, number)
+	_, err = c.client.PullRequests.Close(ctx, fullName, number)
 	return err
 }
@@ -0,0 +1,162 @@
+package scmprovider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/jenkins-x/go-scm/scm"
+)
+
+// CommentKind is the kind of a comment, which tells the API to react to it with
+type CommentKind string
+
+const (
+	// IssueComment is a comment on the conversation of an issue or pull request
+	IssueComment CommentKind = "issue"
+	// ReviewComment is a comment on the diff of a pull request, or any comment on a GitLab merge request
+	ReviewComment CommentKind = "review"
+	// CommitComment is a comment on a commit
+	CommitComment CommentKind = "commit"
+)
+
+// Reaction is the feedback given on a comment with an emoji
+type Reaction string
+
+const (
+	// ReactionAccepted tells that the commands of the comment were accepted
+	ReactionAccepted Reaction = "accepted"
+	// ReactionStarted tells that the commands of the comment started jobs
+	ReactionStarted Reaction = "started"
+	// ReactionRejected tells that the commands of the comment were rejected
+	ReactionRejected Reaction = "rejected"
+)
+
+// reactionPriorities rank the reactions, the reaction of highest priority summarizing those of several commands
+var reactionPriorities = map[Reaction]int{
+	ReactionAccepted: 1,
+	ReactionStarted:  2,
+	ReactionRejected: 3,
+}
+
+// ReactionBatch collects the reactions to the commands of a comment which are handled one after the other, so that
+// the comment is given a single reaction once all of them were handled
+type ReactionBatch struct {
+	lock     sync.Mutex
+	reaction Reaction
+}
+
+// Add records the reaction to a command of the comment
+func (b *ReactionBatch) Add(reaction Reaction) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if reactionPriorities[reaction] > reactionPriorities[b.reaction] {
+		b.reaction = reaction
+	}
+}
+
+// Reaction returns the reaction summarizing those of the commands, a rejected command winning over a started job
+// and over an accepted command, or an empty reaction if none was added
+func (b *ReactionBatch) Reaction() Reaction {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.reaction
+}
+
+// githubReactions are the reactions of GitHub, which has no cross mark
+var githubReactions = map[Reaction]string{
+	ReactionAccepted: "+1",
+	ReactionStarted:  "rocket",
+	ReactionRejected: "-1",
+}
+
+// gitlabReactions are the award emojis of GitLab
+var gitlabReactions = map[Reaction]string{
+	ReactionAccepted: "thumbsup",
+	ReactionStarted:  "rocket",
+	ReactionRejected: "x",
+}
+
+// CreateCommentReaction reacts to the comment of the issue or pull request with an emoji. It returns
+// scm.ErrNotSupported for the providers without reactions.
+func (c *Client) CreateCommentReaction(owner, repo string, number int, pr bool, kind CommentKind, commentID int, reaction Reaction) error {
+	if commentID == 0 {
+		return fmt.Errorf("no comment to react to on %s/%s#%d", owner, repo, number)
+	}
+	fullName := c.repositoryName(owner, repo)
+	var path string
+	var body interface{}
+	switch c.client.Driver {
+	case scm.DriverGithub:
+		switch kind {
+		case ReviewComment:
+			path = fmt.Sprintf("repos/%s/pulls/comments/%d/reactions", fullName, commentID)
+		case CommitComment:
+			path = fmt.Sprintf("repos/%s/comments/%d/reactions", fullName, commentID)
+		default:
+			path = fmt.Sprintf("repos/%s/issues/comments/%d/reactions", fullName, commentID)
+		}
+		body = map[string]string{"content": githubReactions[reaction]}
+	case scm.DriverGitlab:
+		if kind == CommitComment {
+			return scm.ErrNotSupported
+		}
+		resource := "issues"
+		if pr {
+			resource = "merge_requests"
+		}
+		path = fmt.Sprintf("api/v4/projects/%s/%s/%d/notes/%d/award_emoji?name=%s", strings.Replace(fullName, "/", "%2F", -1), resource, number, commentID, url.QueryEscape(gitlabReactions[reaction]))
+	default:
+		return scm.ErrNotSupported
+	}
+	return c.react(owner, repo, number, commentID, reaction, path, body)
+}
+
+func (c *Client) react(owner, repo string, number, commentID int, reaction Reaction, path string, body interface{}) (err error) {
+	defer func() {
+		c.audit("create_comment_reaction", owner, repo, number, "", fmt.Sprintf("%d:%s", commentID, reaction), err)
+	}()
+	return c.doJSON(http.MethodPost, path, body, fmt.Sprintf("react to comment %d", commentID))
+}
+
+// doJSON sends a request of the API which go-scm does not wrap, with the body encoded as JSON if not nil,
+// failing if the provider does not reply with a success status
+func (c *Client) doJSON(method, path string, body interface{}, action string) error {
+	_, err := c.doRequest(method, path, body, nil, action)
+	return err
+}
+
+// doRequest sends a request of the API which go-scm does not wrap like doJSON, decoding the JSON response into out
+// if not nil. It returns the status of the response, which is set along with the error of an unsuccessful reply.
+func (c *Client) doRequest(method, path string, body, out interface{}, action string) (int, error) {
+	req := &scm.Request{Method: method, Path: path, Header: http.Header{}}
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return 0, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Body = bytes.NewReader(data)
+	}
+	res, err := c.client.Do(context.Background(), req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	if res.Status >= http.StatusMultipleChoices {
+		data, _ := ioutil.ReadAll(res.Body)
+		return res.Status, fmt.Errorf("failed to %s with status %d: %s", action, res.Status, string(data))
+	}
+	if out != nil {
+		if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+			return res.Status, fmt.Errorf("failed to decode the response to %s: %v", action, err)
+		}
+	}
+	return res.Status, nil
+}
@@ -27,6 +27,7 @@ import (
 	"github.com/jenkins-x/lighthouse-config/pkg/config"
 	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
 	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/jenkins-x/lighthouse/pkg/util"
 )
 
 const (
@@ -192,10 +193,15 @@ func parsePRComments(lhj *v1alpha1.LighthouseJob, botName string, ics []*scm.Com
 }
 
 func createEntry(lhj *v1alpha1.LighthouseJob) string {
+	details := fmt.Sprintf("[link](%s)", lhj.Status.ReportURL)
+	if lhj.Status.FailureClass != "" {
+		// the description of a classified failure tells what to do about it
+		details += " " + sanitizeCell(lhj.Status.Description)
+	}
 	return strings.Join([]string{
 		lhj.Spec.Context,
 		lhj.Spec.Refs.Pulls[0].SHA,
-		fmt.Sprintf("[link](%s)", lhj.Status.ReportURL),
+		details,
 		fmt.Sprintf("`%s`", lhj.Spec.RerunCommand),
 	}, " | ")
 }
@@ -215,7 +221,7 @@ func createComment(reportTemplate *template.Template, lhj *v1alpha1.LighthouseJo
 		}
 	}
 	lines := []string{
-		fmt.Sprintf("@%s: The following test%s **failed**, say `/retest` to rerun them all:", author, plural),
+		fmt.Sprintf("@%s: The following test%s **failed**, say `%s` to rerun them all:", author, plural, util.FormatCommand("/retest")),
 		"",
 		"Test name | Commit | Details | Rerun command",
 		"--- | --- | --- | ---",
@@ -223,6 +223,29 @@ func TestParsePRComment(t *testing.T) {
 	}
 }
 
+func TestCreateEntry(t *testing.T) {
+	lhj := &v1alpha1.LighthouseJob{
+		Spec: v1alpha1.LighthouseJobSpec{
+			Context:      "unit",
+			RerunCommand: "/test unit",
+			Refs:         &v1alpha1.Refs{Pulls: []v1alpha1.Pull{{SHA: "abc"}}},
+		},
+		Status: v1alpha1.LighthouseJobStatus{
+			State:       v1alpha1.FailureState,
+			Description: "Pipeline failed",
+			ReportURL:   "https://dashboard/unit/1",
+		},
+	}
+	if got, expected := createEntry(lhj), "unit | abc | [link](https://dashboard/unit/1) | `/test unit`"; got != expected {
+		t.Errorf("expected entry %q, got %q", expected, got)
+	}
+	lhj.Status.FailureClass = "infra-flake"
+	lhj.Status.Description = "Infra flake — say /retest"
+	if got, expected := createEntry(lhj), "unit | abc | [link](https://dashboard/unit/1) Infra flake — say /retest | `/test unit`"; got != expected {
+		t.Errorf("expected entry %q, got %q", expected, got)
+	}
+}
+
 func TestShouldReport(t *testing.T) {
 	var testcases = []struct {
 		name       string
@@ -270,3 +293,118 @@ func TestShouldReport(t *testing.T) {
 		}
 	}
 }
+
+type fakeReportClient struct {
+	comments []*scm.Comment
+	created  []string
+	edited   map[int]string
+	deleted  []int
+}
+
+func (f *fakeReportClient) BotName() (string, error) {
+	return "k8s-ci-robot", nil
+}
+
+func (f *fakeReportClient) ListPullRequestComments(string, string, int) ([]*scm.Comment, error) {
+	return f.comments, nil
+}
+
+func (f *fakeReportClient) CreateComment(_ string, _ string, _ int, _ bool, comment string) error {
+	f.created = append(f.created, comment)
+	f.comments = append(f.comments, &scm.Comment{ID: 100 + len(f.created), Body: comment, Author: scm.User{Login: "k8s-ci-robot"}})
+	return nil
+}
+
+func (f *fakeReportClient) DeleteComment(_ string, _ string, _ int, id int, _ bool) error {
+	f.deleted = append(f.deleted, id)
+	return nil
+}
+
+func (f *fakeReportClient) EditComment(_ string, _ string, _ int, id int, comment string, _ bool) error {
+	if f.edited == nil {
+		f.edited = map[int]string{}
+	}
+	f.edited[id] = comment
+	for _, c := range f.comments {
+		if c.ID == id {
+			c.Body = comment
+		}
+	}
+	return nil
+}
+
+func (f *fakeReportClient) QuoteAuthorForComment(author string) string {
+	return author
+}
+
+func summaryJob(context string, state v1alpha1.PipelineState, sha, description string) *v1alpha1.LighthouseJob {
+	return &v1alpha1.LighthouseJob{
+		Spec: v1alpha1.LighthouseJobSpec{
+			Type:         config.PresubmitJob,
+			Context:      context,
+			RerunCommand: "/test " + context,
+			Refs: &v1alpha1.Refs{
+				Org:   "org",
+				Repo:  "repo",
+				Pulls: []v1alpha1.Pull{{Number: 5, Author: "alice", SHA: sha}},
+			},
+		},
+		Status: v1alpha1.LighthouseJobStatus{
+			State:       state,
+			Description: description,
+			ReportURL:   "https://example.com/" + context,
+		},
+	}
+}
+
+func TestReportSummary(t *testing.T) {
+	client := &fakeReportClient{}
+	presubmits := []config.PipelineKind{config.PresubmitJob}
+
+	if err := ReportSummary(client, summaryJob("unit", v1alpha1.RunningState, "abc", "Running"), presubmits); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(client.created) != 1 {
+		t.Fatalf("expected a report comment to be created, got %d", len(client.created))
+	}
+	if !strings.Contains(client.created[0], "1 job(s) still running") {
+		t.Errorf("expected the running job to be mentioned, got:\n%s", client.created[0])
+	}
+
+	if err := ReportSummary(client, summaryJob("lint", v1alpha1.FailureState, "abc", "Failed | lint errors"), presubmits); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := ReportSummary(client, summaryJob("unit", v1alpha1.SuccessState, "abc", "Succeeded"), presubmits); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(client.created) != 1 {
+		t.Errorf("expected the report comment to be edited in place, got %d comments", len(client.created))
+	}
+	body := client.edited[101]
+	entries := parseSummaryEntries(body)
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 jobs in the report, got %d:\n%s", len(entries), body)
+	}
+	if entries[0].context != "unit" || entries[0].state != v1alpha1.SuccessState {
+		t.Errorf("expected unit to have succeeded, got %+v", entries[0])
+	}
+	if entries[1].context != "lint" || entries[1].state != v1alpha1.FailureState || entries[1].summary != "Failed / lint errors" {
+		t.Errorf("expected lint to have failed, got %+v", entries[1])
+	}
+	for _, expected := range []string{"say `/test lint` to rerun it", "- **lint**: Failed / lint errors", summaryTag} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("expected the report to contain %q, got:\n%s", expected, body)
+		}
+	}
+
+	if err := ReportSummary(client, summaryJob("unit", v1alpha1.SuccessState, "def", "Succeeded"), presubmits); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	entries = parseSummaryEntries(client.edited[101])
+	if len(entries) != 1 || entries[0].sha != "def" {
+		t.Errorf("expected the results of the previous commit to be dropped, got %+v", entries)
+	}
+	if !strings.Contains(client.edited[101], "None, all jobs passed.") {
+		t.Errorf("expected no required action, got:\n%s", client.edited[101])
+	}
+}
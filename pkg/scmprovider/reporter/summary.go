@@ -0,0 +1,237 @@
+package reporter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+)
+
+const (
+	// summaryTag marks the single report comment of a pull request
+	summaryTag = "<!-- lighthouse report -->"
+
+	summaryHeader = "Job | Status | Commit | Details | Summary | Rerun command"
+)
+
+// summaryEntry is a row of the report table, holding the last result of a job
+type summaryEntry struct {
+	context      string
+	state        v1alpha1.PipelineState
+	sha          string
+	link         string
+	summary      string
+	rerunCommand string
+}
+
+// ReportSummary maintains a single report comment per pull request, edited in place with the current state of
+// every job which ran against the head of the pull request. Unlike Report, jobs are reported while they run
+// too, so that the comment always reflects the latest results.
+func ReportSummary(spc SCMProviderClient, lhj *v1alpha1.LighthouseJob, validTypes []config.PipelineKind) error {
+	if spc == nil {
+		return fmt.Errorf("trying to report lhj %s, but found empty SCM provider client", lhj.ObjectMeta.Name)
+	}
+	if !ShouldReport(lhj, validTypes) {
+		return nil
+	}
+	refs := lhj.Spec.Refs
+	if refs == nil || len(refs.Pulls) != 1 {
+		return nil
+	}
+	number := refs.Pulls[0].Number
+
+	prcs, err := spc.ListPullRequestComments(refs.Org, refs.Repo, number)
+	if err != nil {
+		return fmt.Errorf("error listing comments: %v", err)
+	}
+	botName, err := spc.BotName()
+	if err != nil {
+		return fmt.Errorf("error getting bot name: %v", err)
+	}
+	var reports []*scm.Comment
+	for _, c := range prcs {
+		if c.Author.Login == botName && strings.Contains(c.Body, summaryTag) {
+			reports = append(reports, c)
+		}
+	}
+	var previous string
+	if len(reports) > 0 {
+		previous = reports[len(reports)-1].Body
+	}
+	entries := mergeSummaryEntries(parseSummaryEntries(previous), lhj)
+	comment := createSummaryComment(spc.QuoteAuthorForComment(refs.Pulls[0].Author), refs.Pulls[0].SHA, entries)
+
+	if len(reports) == 0 {
+		if err := spc.CreateComment(refs.Org, refs.Repo, number, true, comment); err != nil {
+			return fmt.Errorf("error creating comment: %v", err)
+		}
+		return nil
+	}
+	for _, c := range reports[:len(reports)-1] {
+		if err := spc.DeleteComment(refs.Org, refs.Repo, number, c.ID, true); err != nil {
+			return fmt.Errorf("error deleting comment: %v", err)
+		}
+	}
+	if comment == previous {
+		return nil
+	}
+	if err := spc.EditComment(refs.Org, refs.Repo, number, reports[len(reports)-1].ID, comment, true); err != nil {
+		return fmt.Errorf("error updating comment: %v", err)
+	}
+	return nil
+}
+
+// parseSummaryEntries reads the rows of the table of a report comment
+func parseSummaryEntries(body string) []summaryEntry {
+	var entries []summaryEntry
+	tracking := false
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "---"):
+			tracking = true
+		case line == "":
+			tracking = false
+		case tracking:
+			fields := strings.Split(line, " | ")
+			if len(fields) != 6 {
+				continue
+			}
+			state := strings.Fields(fields[1])
+			if len(state) == 0 {
+				continue
+			}
+			entries = append(entries, summaryEntry{
+				context:      fields[0],
+				state:        v1alpha1.PipelineState(state[len(state)-1]),
+				sha:          strings.Trim(fields[2], "`"),
+				link:         fields[3],
+				summary:      fields[4],
+				rerunCommand: strings.Trim(fields[5], "`"),
+			})
+		}
+	}
+	return entries
+}
+
+// mergeSummaryEntries replaces the row of the job's context with its current result, dropping the rows of
+// older commits of the pull request
+func mergeSummaryEntries(entries []summaryEntry, lhj *v1alpha1.LighthouseJob) []summaryEntry {
+	sha := lhj.Spec.Refs.Pulls[0].SHA
+	link := ""
+	if lhj.Status.ReportURL != "" {
+		link = fmt.Sprintf("[link](%s)", lhj.Status.ReportURL)
+	}
+	current := summaryEntry{
+		context:      lhj.Spec.Context,
+		state:        lhj.Status.State,
+		sha:          sha,
+		link:         link,
+		summary:      sanitizeCell(lhj.Status.Description),
+		rerunCommand: lhj.Spec.RerunCommand,
+	}
+	if current.state == "" {
+		current.state = v1alpha1.TriggeredState
+	}
+	var answer []summaryEntry
+	replaced := false
+	for _, e := range entries {
+		if e.sha != sha {
+			continue
+		}
+		if e.context == current.context {
+			e = current
+			replaced = true
+		}
+		answer = append(answer, e)
+	}
+	if !replaced {
+		answer = append(answer, current)
+	}
+	return answer
+}
+
+// sanitizeCell keeps text from breaking the table it is written to
+func sanitizeCell(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	return strings.ReplaceAll(text, "|", "/")
+}
+
+func stateIcon(state v1alpha1.PipelineState) string {
+	switch state {
+	case v1alpha1.SuccessState:
+		return ":heavy_check_mark:"
+	case v1alpha1.FailureState, v1alpha1.ErrorState:
+		return ":x:"
+	case v1alpha1.AbortedState:
+		return ":no_entry_sign:"
+	default:
+		return ":hourglass:"
+	}
+}
+
+// createSummaryComment renders the report comment for the entries
+func createSummaryComment(author, sha string, entries []summaryEntry) string {
+	lines := []string{
+		fmt.Sprintf("### %s report for %s", util.BotDisplayName, sha),
+		"",
+		summaryHeader,
+		"--- | --- | --- | --- | --- | ---",
+	}
+	var failed, running []summaryEntry
+	for _, e := range entries {
+		lines = append(lines, strings.Join([]string{
+			e.context,
+			fmt.Sprintf("%s %s", stateIcon(e.state), e.state),
+			fmt.Sprintf("`%s`", e.sha),
+			e.link,
+			e.summary,
+			fmt.Sprintf("`%s`", e.rerunCommand),
+		}, " | "))
+		switch e.state {
+		case v1alpha1.FailureState, v1alpha1.ErrorState, v1alpha1.AbortedState:
+			failed = append(failed, e)
+		case v1alpha1.SuccessState:
+		default:
+			running = append(running, e)
+		}
+	}
+
+	lines = append(lines, "", "#### Required actions", "")
+	switch {
+	case len(failed) > 0:
+		for _, e := range failed {
+			lines = append(lines, fmt.Sprintf("- `%s` did not pass: say `%s` to rerun it", e.context, e.rerunCommand))
+		}
+		lines = append(lines, fmt.Sprintf("- @%s: say `%s` to rerun all the jobs which did not pass", author, util.FormatCommand("/retest")))
+	case len(running) > 0:
+		lines = append(lines, fmt.Sprintf("None yet, %d job(s) still running.", len(running)))
+	default:
+		lines = append(lines, "None, all jobs passed.")
+	}
+
+	if len(failed) > 0 {
+		lines = append(lines, "", "#### Failures", "")
+		for _, e := range failed {
+			summary := e.summary
+			if summary == "" {
+				summary = string(e.state)
+			}
+			lines = append(lines, fmt.Sprintf("- **%s**: %s", e.context, summary))
+		}
+	}
+
+	lines = append(lines,
+		"",
+		"<details>",
+		"",
+		plugins.AboutThisBot,
+		"</details>",
+		summaryTag,
+	)
+	return strings.Join(lines, "\n")
+}
@@ -2,6 +2,10 @@ package scmprovider
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
 
 	"github.com/jenkins-x/go-scm/scm"
 )
@@ -37,6 +41,83 @@ func (c *Client) GetRepoLabels(owner, repo string) ([]*scm.Label, error) {
 	return allLabels, nil
 }
 
+// CreateRepoLabel creates the label of the repository. It returns scm.ErrNotSupported for the providers other
+// than GitHub and GitLab.
+func (c *Client) CreateRepoLabel(owner, repo string, label scm.Label) (err error) {
+	defer func() { c.audit("create_repo_label", owner, repo, 0, "", label.Name, err) }()
+	path, err := c.repoLabelsPath(owner, repo, "")
+	if err != nil {
+		return err
+	}
+	body := map[string]string{
+		"name":        label.Name,
+		"color":       c.labelColor(label.Color),
+		"description": label.Description,
+	}
+	return c.doJSON(http.MethodPost, path, body, fmt.Sprintf("create label %s", label.Name))
+}
+
+// UpdateRepoLabel updates the color and description of the label of the repository with the given name, renaming
+// it if the name of the label differs, which keeps it on the issues and pull requests it is on. It returns
+// scm.ErrNotSupported for the providers other than GitHub and GitLab.
+func (c *Client) UpdateRepoLabel(owner, repo, name string, label scm.Label) (err error) {
+	defer func() { c.audit("update_repo_label", owner, repo, 0, "", name+":"+label.Name, err) }()
+	path, err := c.repoLabelsPath(owner, repo, name)
+	if err != nil {
+		return err
+	}
+	body := map[string]string{
+		"color":       c.labelColor(label.Color),
+		"description": label.Description,
+	}
+	if label.Name != name {
+		body["new_name"] = label.Name
+	}
+	method := http.MethodPatch
+	if c.client.Driver == scm.DriverGitlab {
+		method = http.MethodPut
+	}
+	return c.doJSON(method, path, body, fmt.Sprintf("update label %s", name))
+}
+
+// DeleteRepoLabel deletes the label of the repository. It returns scm.ErrNotSupported for the providers other
+// than GitHub and GitLab.
+func (c *Client) DeleteRepoLabel(owner, repo, name string) (err error) {
+	defer func() { c.audit("delete_repo_label", owner, repo, 0, "", name, err) }()
+	path, err := c.repoLabelsPath(owner, repo, name)
+	if err != nil {
+		return err
+	}
+	return c.doJSON(http.MethodDelete, path, nil, fmt.Sprintf("delete label %s", name))
+}
+
+// repoLabelsPath returns the API path of the labels of the repository, or of the named label if not empty
+func (c *Client) repoLabelsPath(owner, repo, name string) (string, error) {
+	fullName := c.repositoryName(owner, repo)
+	var path string
+	switch c.client.Driver {
+	case scm.DriverGithub:
+		path = fmt.Sprintf("repos/%s/labels", fullName)
+	case scm.DriverGitlab:
+		path = fmt.Sprintf("api/v4/projects/%s/labels", url.PathEscape(fullName))
+	default:
+		return "", scm.ErrNotSupported
+	}
+	if name != "" {
+		path += "/" + url.PathEscape(name)
+	}
+	return path, nil
+}
+
+// labelColor returns the hexadecimal color in the format of the provider, which is prefixed with # on GitLab
+func (c *Client) labelColor(color string) string {
+	color = strings.TrimPrefix(color, "#")
+	if c.client.Driver == scm.DriverGitlab {
+		return "#" + color
+	}
+	return color
+}
+
 // IsCollaborator check if a user is collaborator to a repository
 func (c *Client) IsCollaborator(owner, repo, login string) (bool, error) {
 	ctx := context.Background()
@@ -70,10 +151,11 @@ func (c *Client) ListCollaborators(owner, repo string) ([]scm.User, error) {
 }
 
 // CreateStatus create a status into a repository
-func (c *Client) CreateStatus(owner, repo, ref string, s *scm.StatusInput) (*scm.Status, error) {
+func (c *Client) CreateStatus(owner, repo, ref string, s *scm.StatusInput) (status *scm.Status, err error) {
+	defer func() { c.audit("create_status", owner, repo, 0, ref, s.Label+"="+s.State.String(), err) }()
 	ctx := context.Background()
 	fullName := c.repositoryName(owner, repo)
-	status, _, err := c.client.Repositories.CreateStatus(ctx, fullName, ref, s)
+	status, _, err = c.client.Repositories.CreateStatus(ctx, fullName, ref, s)
 	return status, err
 }
 
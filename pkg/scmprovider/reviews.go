@@ -2,6 +2,7 @@ package scmprovider
 
 import (
 	"context"
+	"strings"
 
 	"github.com/jenkins-x/go-scm/scm"
 	"github.com/pkg/errors"
@@ -32,17 +33,19 @@ func (c *Client) ListReviews(owner, repo string, number int) ([]*scm.Review, err
 }
 
 // RequestReview requests a review
-func (c *Client) RequestReview(org, repo string, number int, logins []string) error {
+func (c *Client) RequestReview(org, repo string, number int, logins []string) (err error) {
+	defer func() { c.audit("request_review", org, repo, number, "", strings.Join(logins, ","), err) }()
 	ctx := context.Background()
 	fullName := c.repositoryName(org, repo)
-	_, err := c.client.PullRequests.RequestReview(ctx, fullName, number, logins)
+	_, err = c.client.PullRequests.RequestReview(ctx, fullName, number, logins)
 	return errors.Wrapf(err, "requesting review from %s", logins)
 }
 
 // UnrequestReview unrequest a review
-func (c *Client) UnrequestReview(org, repo string, number int, logins []string) error {
+func (c *Client) UnrequestReview(org, repo string, number int, logins []string) (err error) {
+	defer func() { c.audit("unrequest_review", org, repo, number, "", strings.Join(logins, ","), err) }()
 	ctx := context.Background()
 	fullName := c.repositoryName(org, repo)
-	_, err := c.client.PullRequests.UnrequestReview(ctx, fullName, number, logins)
+	_, err = c.client.PullRequests.UnrequestReview(ctx, fullName, number, logins)
 	return errors.Wrapf(err, "unrequesting review from %s", logins)
 }
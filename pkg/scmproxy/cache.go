@@ -0,0 +1,117 @@
+package scmproxy
+
+import (
+	"container/list"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// entry is a cached response
+type entry struct {
+	key    string
+	header http.Header
+	body   []byte
+	// vary are the values of the request headers named by the Vary header of the response
+	vary map[string]string
+}
+
+func (e *entry) size() int64 {
+	size := int64(len(e.key) + len(e.body))
+	for k, values := range e.header {
+		for _, v := range values {
+			size += int64(len(k) + len(v))
+		}
+	}
+	return size
+}
+
+// matches returns true if the request has the same values as the cached request for the headers the response
+// varies on
+func (e *entry) matches(r *http.Request) bool {
+	for name, value := range e.vary {
+		if r.Header.Get(name) != value {
+			return false
+		}
+	}
+	return true
+}
+
+// cache keeps the responses in memory, evicting the least recently used ones beyond the maximum size in bytes
+type cache struct {
+	maxSize int64
+
+	lock    sync.Mutex
+	size    int64
+	lru     *list.List
+	entries map[string]*list.Element
+}
+
+func newCache(maxSize int64) *cache {
+	return &cache{
+		maxSize: maxSize,
+		lru:     list.New(),
+		entries: map[string]*list.Element{},
+	}
+}
+
+func (c *cache) get(key string) *entry {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	c.lru.MoveToFront(element)
+	return element.Value.(*entry)
+}
+
+func (c *cache) put(e *entry) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if e.size() > c.maxSize {
+		c.remove(e.key)
+		return
+	}
+	c.remove(e.key)
+	c.entries[e.key] = c.lru.PushFront(e)
+	c.size += e.size()
+	for c.size > c.maxSize {
+		c.remove(c.lru.Back().Value.(*entry).key)
+	}
+	cacheSize.Set(float64(c.size))
+}
+
+func (c *cache) delete(key string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.remove(key)
+	cacheSize.Set(float64(c.size))
+}
+
+// deletePrefix removes the entries whose key starts with the prefix
+func (c *cache) deletePrefix(prefix string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for key := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			c.remove(key)
+		}
+	}
+	cacheSize.Set(float64(c.size))
+}
+
+// remove removes the entry of the key, the lock being held
+func (c *cache) remove(key string) {
+	element, ok := c.entries[key]
+	if !ok {
+		return
+	}
+	c.lru.Remove(element)
+	delete(c.entries, key)
+	c.size -= element.Value.(*entry).size()
+}
@@ -0,0 +1,293 @@
+// Package scmproxy provides a caching reverse proxy of the API of an SCM provider, which the SCM clients of the
+// lighthouse components can be pointed at to save the rate limit of their tokens. GET responses are cached per
+// URL and token, and revalidated with the provider on every request using their ETag or Last-Modified date, so
+// that a response which did not change is served from the cache without counting against the rate limit while
+// the clients never see stale data.
+package scmproxy
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// CacheHeader is the response header telling how the proxy served the request, which is one of the modes
+	CacheHeader = "X-Lighthouse-Cache"
+
+	// ModeRevalidated is a request served from the cache once the provider replied that it did not change
+	ModeRevalidated = "revalidated"
+	// ModeChanged is a cached request whose response changed
+	ModeChanged = "changed"
+	// ModeMiss is a request which was not cached
+	ModeMiss = "miss"
+	// ModeNoStore is a request whose response cannot be cached
+	ModeNoStore = "no-store"
+	// ModeBypass is a request which is not cacheable, such as a POST or a conditional request of the client
+	ModeBypass = "bypass"
+	// ModeError is a request which the provider could not be reached for
+	ModeError = "error"
+)
+
+var (
+	requests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "lighthouse_scm_proxy_requests",
+		Help: "A counter of the requests served by the SCM proxy, by cache mode.",
+	}, []string{"mode"})
+	cacheSize = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "lighthouse_scm_proxy_cache_bytes",
+		Help: "The size of the responses cached by the SCM proxy.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(requests, cacheSize)
+}
+
+// hopHeaders are the headers of a connection which are not forwarded, see RFC 7230 section 6.1
+var hopHeaders = []string{
+	"Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// Proxy is a caching reverse proxy of the API of an SCM provider
+type Proxy struct {
+	upstream  *url.URL
+	transport http.RoundTripper
+	cache     *cache
+	logger    *logrus.Entry
+}
+
+// NewProxy creates a proxy of the API at the upstream URL, e.g. https://api.github.com, caching at most the given
+// number of bytes of responses
+func NewProxy(upstream string, maxCacheSize int64, logger *logrus.Entry) (*Proxy, error) {
+	u, err := url.Parse(upstream)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing upstream URL %s", upstream)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, errors.Errorf("invalid upstream URL %s, expected an absolute URL", upstream)
+	}
+	if logger == nil {
+		logger = logrus.NewEntry(logrus.StandardLogger())
+	}
+	return &Proxy{
+		upstream:  u,
+		transport: http.DefaultTransport,
+		cache:     newCache(maxCacheSize),
+		logger:    logger.WithField("upstream", upstream),
+	}, nil
+}
+
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	out := p.outgoing(r)
+	if r.Method != http.MethodGet || !cacheableRequest(r) {
+		res, err := p.roundTrip(w, out)
+		if err != nil {
+			return
+		}
+		if unsafeMethod(r.Method) && res.StatusCode < http.StatusBadRequest {
+			// the cached responses of the resource are invalid, see RFC 7234 section 4.4
+			p.cache.deletePrefix(out.URL.String() + "\x00")
+		}
+		p.write(w, res.StatusCode, res.Header, res.Body, ModeBypass)
+		return
+	}
+
+	key := cacheKey(out)
+	cached := p.cache.get(key)
+	if cached != nil && !cached.matches(r) {
+		cached = nil
+	}
+	if cached != nil {
+		if etag := cached.header.Get("ETag"); etag != "" {
+			out.Header.Set("If-None-Match", etag)
+		}
+		if modified := cached.header.Get("Last-Modified"); modified != "" {
+			out.Header.Set("If-Modified-Since", modified)
+		}
+	}
+	res, err := p.roundTrip(w, out)
+	if err != nil {
+		return
+	}
+
+	if cached != nil && res.StatusCode == http.StatusNotModified {
+		res.Body.Close() // #nosec
+		updated := &entry{key: key, header: updatedHeader(cached.header, res.Header), body: cached.body, vary: cached.vary}
+		p.cache.put(updated)
+		p.write(w, http.StatusOK, updated.header, ioutil.NopCloser(bytes.NewReader(updated.body)), ModeRevalidated)
+		return
+	}
+
+	mode := ModeMiss
+	if cached != nil {
+		mode = ModeChanged
+	}
+	if !storable(res) {
+		p.cache.delete(key)
+		if res.StatusCode == http.StatusOK {
+			mode = ModeNoStore
+		}
+		p.write(w, res.StatusCode, res.Header, res.Body, mode)
+		return
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(res.Body, p.cache.maxSize+1))
+	if err != nil {
+		res.Body.Close() // #nosec
+		p.fail(w, err)
+		return
+	}
+	if int64(len(body)) <= p.cache.maxSize {
+		p.cache.put(&entry{key: key, header: res.Header.Clone(), body: body, vary: varyValues(r, res)})
+	}
+	p.write(w, res.StatusCode, res.Header, readCloser{Reader: io.MultiReader(bytes.NewReader(body), res.Body), Closer: res.Body}, mode)
+}
+
+// outgoing returns the request to the upstream server
+func (p *Proxy) outgoing(r *http.Request) *http.Request {
+	out := r.Clone(r.Context())
+	out.RequestURI = ""
+	out.URL.Scheme = p.upstream.Scheme
+	out.URL.Host = p.upstream.Host
+	out.URL.Path = strings.TrimSuffix(p.upstream.Path, "/") + r.URL.Path
+	out.URL.RawPath = ""
+	out.Host = p.upstream.Host
+	removeHopHeaders(out.Header)
+	return out
+}
+
+// roundTrip sends the request upstream, replying with a bad gateway error if it fails
+func (p *Proxy) roundTrip(w http.ResponseWriter, out *http.Request) (*http.Response, error) {
+	res, err := p.transport.RoundTrip(out)
+	if err != nil {
+		p.fail(w, err)
+		return nil, err
+	}
+	return res, nil
+}
+
+func (p *Proxy) fail(w http.ResponseWriter, err error) {
+	p.logger.WithError(err).Warn("failed to proxy the request")
+	requests.WithLabelValues(ModeError).Inc()
+	http.Error(w, "failed to reach the SCM provider", http.StatusBadGateway)
+}
+
+func (p *Proxy) write(w http.ResponseWriter, status int, header http.Header, body io.ReadCloser, mode string) {
+	defer body.Close() // #nosec
+	requests.WithLabelValues(mode).Inc()
+	for k, values := range header {
+		w.Header()[k] = values
+	}
+	removeHopHeaders(w.Header())
+	w.Header().Set(CacheHeader, mode)
+	w.WriteHeader(status)
+	if _, err := io.Copy(w, body); err != nil {
+		p.logger.WithError(err).Debug("failed to copy the response")
+	}
+}
+
+// cacheableRequest returns false for the requests which ask not to be cached, or which are conditional requests
+// the client should get the reply of the provider to
+func cacheableRequest(r *http.Request) bool {
+	if hasDirective(r.Header, "no-store") {
+		return false
+	}
+	for _, name := range []string{"If-None-Match", "If-Modified-Since", "If-Match", "If-Unmodified-Since", "Range"} {
+		if r.Header.Get(name) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// storable returns true if the response can be cached and revalidated. Responses marked private are stored too,
+// as the cache is partitioned by the credentials of the requests.
+func storable(res *http.Response) bool {
+	if res.StatusCode != http.StatusOK || hasDirective(res.Header, "no-store") || res.Header.Get("Vary") == "*" {
+		return false
+	}
+	return res.Header.Get("ETag") != "" || res.Header.Get("Last-Modified") != ""
+}
+
+func unsafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return false
+	}
+	return true
+}
+
+func hasDirective(header http.Header, directive string) bool {
+	for _, value := range header["Cache-Control"] {
+		for _, d := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(d), directive) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// cacheKey returns the key of the response of the request, which is specific to its credentials
+func cacheKey(r *http.Request) string {
+	auth := sha256.Sum256([]byte(r.Header.Get("Authorization") + "\x00" + r.Header.Get("Private-Token")))
+	return r.URL.String() + "\x00" + hex.EncodeToString(auth[:])
+}
+
+// varyValues returns the values of the request headers the response varies on
+func varyValues(r *http.Request, res *http.Response) map[string]string {
+	answer := map[string]string{}
+	for _, value := range res.Header["Vary"] {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				answer[name] = r.Header.Get(name)
+			}
+		}
+	}
+	return answer
+}
+
+// updatedHeader returns the header of a cached response updated with the header of the not modified response
+// revalidating it, see RFC 7234 section 4.3.4
+func updatedHeader(cached, notModified http.Header) http.Header {
+	answer := cached.Clone()
+	for k, values := range notModified {
+		if k == "Content-Length" {
+			continue
+		}
+		answer[k] = values
+	}
+	return answer
+}
+
+func removeHopHeaders(header http.Header) {
+	for _, value := range header["Connection"] {
+		for _, name := range strings.Split(value, ",") {
+			header.Del(strings.TrimSpace(name))
+		}
+	}
+	for _, name := range hopHeaders {
+		header.Del(name)
+	}
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
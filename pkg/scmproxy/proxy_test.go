@@ -0,0 +1,110 @@
+package scmproxy
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAPI serves versioned resources which can be revalidated with their ETag
+type fakeAPI struct {
+	lock     sync.Mutex
+	version  int
+	requests []string
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.requests = append(f.requests, fmt.Sprintf("%s %s %s", r.Method, r.URL.Path, r.Header.Get("If-None-Match")))
+	etag := fmt.Sprintf(`"v%d"`, f.version)
+	w.Header().Set("X-RateLimit-Remaining", fmt.Sprintf("%d", 5000-len(f.requests)))
+	switch {
+	case r.Method == http.MethodPost:
+		f.version++
+		w.WriteHeader(http.StatusCreated)
+	case r.URL.Path == "/api/v3/nostore":
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("ETag", etag)
+		fmt.Fprint(w, "secret")
+	case r.Header.Get("If-None-Match") == etag:
+		w.WriteHeader(http.StatusNotModified)
+	default:
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Vary", "Accept")
+		fmt.Fprintf(w, "%s version %d for %s", r.URL.Path, f.version, r.Header.Get("Authorization"))
+	}
+}
+
+func TestProxy(t *testing.T) {
+	api := &fakeAPI{}
+	upstream := httptest.NewServer(api)
+	defer upstream.Close()
+	p, err := NewProxy(upstream.URL+"/api/v3", 1<<20, nil)
+	require.NoError(t, err)
+	proxy := httptest.NewServer(p)
+	defer proxy.Close()
+
+	get := func(method, path, token, accept, expectedMode string) string {
+		req, err := http.NewRequest(method, proxy.URL+path, nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", token)
+		req.Header.Set("Accept", accept)
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		body, err := ioutil.ReadAll(res.Body)
+		require.NoError(t, err)
+		assert.Equal(t, expectedMode, res.Header.Get(CacheHeader), "%s %s", method, path)
+		assert.True(t, res.StatusCode < http.StatusBadRequest)
+		if res.StatusCode == http.StatusOK {
+			assert.Equal(t, fmt.Sprintf("%d", 5000-len(api.requests)), res.Header.Get("X-RateLimit-Remaining"), "the headers of the revalidation should be used")
+		}
+		return string(body)
+	}
+
+	assert.Equal(t, "/api/v3/repos/org/repo version 0 for token a", get(http.MethodGet, "/repos/org/repo", "token a", "json", ModeMiss))
+	assert.Equal(t, "/api/v3/repos/org/repo version 0 for token a", get(http.MethodGet, "/repos/org/repo", "token a", "json", ModeRevalidated))
+	assert.Equal(t, "/api/v3/repos/org/repo version 0 for token b", get(http.MethodGet, "/repos/org/repo", "token b", "json", ModeMiss), "the cache should be partitioned by token")
+	get(http.MethodGet, "/repos/org/repo", "token a", "text", ModeMiss)
+
+	get(http.MethodPost, "/repos/org/repo", "token a", "json", ModeBypass)
+	assert.Equal(t, "/api/v3/repos/org/repo version 1 for token a", get(http.MethodGet, "/repos/org/repo", "token a", "json", ModeMiss), "a POST should invalidate the cached responses")
+	assert.Equal(t, "/api/v3/repos/org/repo version 1 for token b", get(http.MethodGet, "/repos/org/repo", "token b", "json", ModeMiss))
+	get(http.MethodPost, "/other", "token a", "json", ModeBypass)
+	assert.Equal(t, "/api/v3/repos/org/repo version 2 for token b", get(http.MethodGet, "/repos/org/repo", "token b", "json", ModeChanged))
+
+	get(http.MethodGet, "/nostore", "token a", "json", ModeNoStore)
+	get(http.MethodGet, "/nostore", "token a", "json", ModeNoStore)
+
+	var revalidations int
+	for _, r := range api.requests {
+		if strings.HasSuffix(r, `"v0"`) || strings.HasSuffix(r, `"v1"`) {
+			revalidations++
+		}
+	}
+	assert.Equal(t, 2, revalidations, "the cached responses should be revalidated with their ETag")
+}
+
+func TestCacheEviction(t *testing.T) {
+	c := newCache(100)
+	for i := 0; i < 3; i++ {
+		c.put(&entry{key: fmt.Sprintf("key%d", i), body: make([]byte, 40)})
+	}
+	assert.Nil(t, c.get("key0"), "the least recently used entry should be evicted")
+	assert.NotNil(t, c.get("key1"))
+	c.put(&entry{key: "key3", body: make([]byte, 40)})
+	assert.NotNil(t, c.get("key1"))
+	assert.Nil(t, c.get("key2"))
+	assert.LessOrEqual(t, c.size, int64(100))
+
+	c.put(&entry{key: "large", body: make([]byte, 200)})
+	assert.Nil(t, c.get("large"), "an entry larger than the cache should not be stored")
+}
@@ -0,0 +1,208 @@
+package secrets
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// RefreshIntervalEnv is the environment variable overriding how often secrets are reloaded from files and Vault
+	RefreshIntervalEnv = "LIGHTHOUSE_SECRETS_REFRESH_INTERVAL"
+	// DefaultRefreshInterval is how often secrets are reloaded from files and Vault by default
+	DefaultRefreshInterval = time.Minute
+
+	// FileSuffix is appended to the name of a secret to get the environment variable pointing at the file
+	// containing it, such as a file mounted by the Kubernetes secrets store CSI driver
+	FileSuffix = "_FILE"
+	// VaultPathSuffix is appended to the name of a secret to get the environment variable containing its
+	// Vault location, formatted as path#key, e.g. secret/data/lighthouse#hmac
+	VaultPathSuffix = "_VAULT_PATH"
+)
+
+// Source fetches the current value of a secret
+type Source interface {
+	Fetch() (string, error)
+}
+
+// EnvSource reads a secret from an environment variable
+type EnvSource string
+
+// Fetch returns the value of the environment variable
+func (s EnvSource) Fetch() (string, error) {
+	return os.Getenv(string(s)), nil
+}
+
+// FileSource reads a secret from a file, which is trimmed of surrounding whitespace
+type FileSource string
+
+// Fetch reads the file
+func (s FileSource) Fetch() (string, error) {
+	/* #nosec */
+	data, err := ioutil.ReadFile(string(s))
+	if err != nil {
+		return "", errors.Wrapf(err, "reading secret file %s", string(s))
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// VaultSource reads a key of a Vault secret
+type VaultSource struct {
+	Client *VaultClient
+	Path   string
+	Key    string
+}
+
+// Fetch reads the secret from Vault
+func (s *VaultSource) Fetch() (string, error) {
+	data, err := s.Client.Read(s.Path)
+	if err != nil {
+		return "", err
+	}
+	value, ok := data[s.Key]
+	if !ok {
+		return "", errors.Errorf("no key %s in Vault secret %s", s.Key, s.Path)
+	}
+	text, ok := value.(string)
+	if !ok {
+		return "", errors.Errorf("key %s in Vault secret %s is not a string", s.Key, s.Path)
+	}
+	return text, nil
+}
+
+// Secret caches the value of a Source, fetching it again once the refresh interval has passed so that
+// rotated secrets are picked up without restarting. The previous value is kept if it cannot be fetched.
+type Secret struct {
+	name    string
+	source  Source
+	refresh time.Duration
+
+	lock    sync.Mutex
+	value   string
+	fetched time.Time
+	now     func() time.Time
+}
+
+// NewSecret creates a secret fetching its value from the source at most once per refresh interval
+func NewSecret(name string, source Source, refresh time.Duration) *Secret {
+	return &Secret{
+		name:    name,
+		source:  source,
+		refresh: refresh,
+		now:     time.Now,
+	}
+}
+
+// Get returns the current value of the secret
+func (s *Secret) Get() (string, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := s.now()
+	if !s.fetched.IsZero() && now.Sub(s.fetched) < s.refresh {
+		return s.value, nil
+	}
+	value, err := s.source.Fetch()
+	if err != nil {
+		if s.fetched.IsZero() {
+			return "", errors.Wrapf(err, "loading secret %s", s.name)
+		}
+		logrus.WithError(err).Warnf("failed to refresh secret %s, using the previous value", s.name)
+		return s.value, nil
+	}
+	if !s.fetched.IsZero() && value != s.value {
+		logrus.Infof("secret %s has been rotated", s.name)
+	}
+	s.value = value
+	s.fetched = now
+	return s.value, nil
+}
+
+var (
+	envSecretsLock sync.Mutex
+	envSecrets     = map[string]*Secret{}
+)
+
+// FromEnv returns the secret with the given name, which is loaded from Vault if $<name>_VAULT_PATH is set,
+// from a file if $<name>_FILE is set or else from the $<name> environment variable.
+func FromEnv(name string) *Secret {
+	envSecretsLock.Lock()
+	defer envSecretsLock.Unlock()
+
+	if secret, ok := envSecrets[name]; ok {
+		return secret
+	}
+	secret := newSecretFromEnv(name)
+	envSecrets[name] = secret
+	return secret
+}
+
+func newSecretFromEnv(name string) *Secret {
+	refresh := DefaultRefreshInterval
+	if text := os.Getenv(RefreshIntervalEnv); text != "" {
+		d, err := time.ParseDuration(text)
+		if err != nil {
+			logrus.WithError(err).Warnf("invalid $%s, using %s", RefreshIntervalEnv, DefaultRefreshInterval)
+		} else {
+			refresh = d
+		}
+	}
+
+	if location := os.Getenv(name + VaultPathSuffix); location != "" {
+		source, err := vaultSourceFromEnv(location)
+		if err == nil {
+			return NewSecret(name, source, refresh)
+		}
+		logrus.WithError(err).Errorf("cannot load secret %s from Vault", name)
+		return NewSecret(name, errorSource{err}, refresh)
+	}
+	if fileName := os.Getenv(name + FileSuffix); fileName != "" {
+		return NewSecret(name, FileSource(fileName), refresh)
+	}
+	// environment variables cannot change so there is nothing to refresh, but reading them each time
+	// keeps tests which set them working
+	return NewSecret(name, EnvSource(name), 0)
+}
+
+func vaultSourceFromEnv(location string) (*VaultSource, error) {
+	parts := strings.SplitN(location, "#", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, errors.Errorf("invalid Vault location %q, expected path#key", location)
+	}
+	client, err := SharedVaultClient()
+	if err != nil {
+		return nil, err
+	}
+	return &VaultSource{Client: client, Path: parts[0], Key: parts[1]}, nil
+}
+
+// errorSource always fails, so that a misconfigured secret is reported whenever it is used
+type errorSource struct {
+	err error
+}
+
+func (s errorSource) Fetch() (string, error) {
+	return "", s.err
+}
+
+// Tokens returns the tokens in the secret with the given name, see FromEnv, where several tokens are separated
+// by commas or new lines so that a new token can be rolled out before the old one is retired. The newest
+// token should be given first.
+func Tokens(name string) ([]string, error) {
+	value, err := FromEnv(name).Get()
+	if err != nil {
+		return nil, err
+	}
+	var tokens []string
+	for _, token := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == '\n' }) {
+		if token = strings.TrimSpace(token); token != "" {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens, nil
+}
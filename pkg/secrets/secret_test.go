@@ -0,0 +1,92 @@
+package secrets
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSource struct {
+	value string
+	err   error
+	calls int
+}
+
+func (s *fakeSource) Fetch() (string, error) {
+	s.calls++
+	return s.value, s.err
+}
+
+func TestSecretRefresh(t *testing.T) {
+	source := &fakeSource{value: "first"}
+	secret := NewSecret("HMAC_TOKEN", source, time.Minute)
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	secret.now = func() time.Time { return now }
+
+	value, err := secret.Get()
+	require.NoError(t, err)
+	assert.Equal(t, "first", value)
+
+	source.value = "second"
+	value, err = secret.Get()
+	require.NoError(t, err)
+	assert.Equal(t, "first", value, "should use the cached value within the refresh interval")
+	assert.Equal(t, 1, source.calls)
+
+	now = now.Add(time.Minute)
+	value, err = secret.Get()
+	require.NoError(t, err)
+	assert.Equal(t, "second", value)
+
+	source.err = errors.New("vault is down")
+	now = now.Add(time.Minute)
+	value, err = secret.Get()
+	require.NoError(t, err)
+	assert.Equal(t, "second", value, "should keep the previous value when refreshing fails")
+}
+
+func TestSecretInitialError(t *testing.T) {
+	secret := NewSecret("HMAC_TOKEN", &fakeSource{err: errors.New("boom")}, time.Minute)
+	_, err := secret.Get()
+	assert.Error(t, err)
+}
+
+func TestNewSecretFromEnv(t *testing.T) {
+	dir, err := ioutil.TempDir("", "secrets")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	fileName := filepath.Join(dir, "hmac")
+	require.NoError(t, ioutil.WriteFile(fileName, []byte("from-file\n"), 0600))
+
+	os.Setenv("TEST_SECRET", "from-env")
+	defer os.Unsetenv("TEST_SECRET")
+	value, err := newSecretFromEnv("TEST_SECRET").Get()
+	require.NoError(t, err)
+	assert.Equal(t, "from-env", value)
+
+	os.Setenv("TEST_SECRET"+FileSuffix, fileName)
+	defer os.Unsetenv("TEST_SECRET" + FileSuffix)
+	value, err = newSecretFromEnv("TEST_SECRET").Get()
+	require.NoError(t, err)
+	assert.Equal(t, "from-file", value)
+
+	os.Setenv("TEST_SECRET"+VaultPathSuffix, "missing-key")
+	defer os.Unsetenv("TEST_SECRET" + VaultPathSuffix)
+	_, err = newSecretFromEnv("TEST_SECRET").Get()
+	assert.Error(t, err)
+}
+
+func TestTokens(t *testing.T) {
+	os.Setenv("TEST_TOKENS", "first\nsecond,, third\n")
+	defer os.Unsetenv("TEST_TOKENS")
+
+	tokens, err := Tokens("TEST_TOKENS")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"first", "second", "third"}, tokens)
+}
@@ -0,0 +1,192 @@
+package secrets
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// ServiceAccountTokenFile is the token of the pod's service account used to log into Vault with a role
+	ServiceAccountTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token" // #nosec
+
+	defaultVaultAuthPath = "kubernetes"
+	vaultTimeout         = 30 * time.Second
+)
+
+// VaultClient reads secrets from HashiCorp Vault using either a static token or the Kubernetes auth
+// method, in which case it logs in again whenever its token expires.
+type VaultClient struct {
+	Address   string
+	Namespace string
+	// Token is used as is when Role is not set
+	Token string
+	// Role is the Vault role to log in as with the pod's service account token
+	Role string
+	// AuthPath is the mount path of the Kubernetes auth method, "kubernetes" by default
+	AuthPath string
+	// JWTFile is the service account token used to log in, ServiceAccountTokenFile by default
+	JWTFile string
+
+	httpClient *http.Client
+	lock       sync.Mutex
+	expiry     time.Time
+	now        func() time.Time
+}
+
+var (
+	sharedVaultLock   sync.Mutex
+	sharedVaultClient *VaultClient
+)
+
+// SharedVaultClient returns the Vault client configured by $VAULT_ADDR and either $VAULT_TOKEN or $VAULT_ROLE,
+// with $VAULT_AUTH_PATH and $VAULT_NAMESPACE being optional.
+func SharedVaultClient() (*VaultClient, error) {
+	sharedVaultLock.Lock()
+	defer sharedVaultLock.Unlock()
+
+	if sharedVaultClient != nil {
+		return sharedVaultClient, nil
+	}
+	client := &VaultClient{
+		Address:   os.Getenv("VAULT_ADDR"),
+		Namespace: os.Getenv("VAULT_NAMESPACE"),
+		Token:     os.Getenv("VAULT_TOKEN"),
+		Role:      os.Getenv("VAULT_ROLE"),
+		AuthPath:  os.Getenv("VAULT_AUTH_PATH"),
+	}
+	if client.Address == "" {
+		return nil, errors.New("no Vault address at environment variable $VAULT_ADDR")
+	}
+	if client.Token == "" && client.Role == "" {
+		return nil, errors.New("either $VAULT_TOKEN or $VAULT_ROLE must be set to authenticate with Vault")
+	}
+	sharedVaultClient = client
+	return client, nil
+}
+
+type vaultResponse struct {
+	Data   map[string]interface{} `json:"data"`
+	Auth   *vaultAuth             `json:"auth"`
+	Errors []string               `json:"errors"`
+}
+
+type vaultAuth struct {
+	ClientToken   string `json:"client_token"`
+	LeaseDuration int    `json:"lease_duration"`
+}
+
+// Read returns the data of the secret at the given path. The data of version 2 key/value secrets, whose
+// paths contain /data/, is unwrapped so that both versions return the secret's keys.
+func (c *VaultClient) Read(path string) (map[string]interface{}, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if err := c.login(false); err != nil {
+		return nil, err
+	}
+	status, resp, err := c.do(http.MethodGet, path, nil)
+	if err == nil && status == http.StatusForbidden && c.Role != "" {
+		// the token may have been revoked before its lease ended
+		if err := c.login(true); err != nil {
+			return nil, err
+		}
+		status, resp, err = c.do(http.MethodGet, path, nil)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading Vault secret %s", path)
+	}
+	if status != http.StatusOK {
+		return nil, errors.Errorf("reading Vault secret %s: status %d %s", path, status, strings.Join(resp.Errors, ", "))
+	}
+	data := resp.Data
+	if inner, ok := data["data"].(map[string]interface{}); ok {
+		if _, ok := data["metadata"]; ok {
+			data = inner
+		}
+	}
+	return data, nil
+}
+
+// login authenticates with the Kubernetes auth method if a role is configured and the token has expired
+func (c *VaultClient) login(force bool) error {
+	if c.Role == "" {
+		return nil
+	}
+	if c.now == nil {
+		c.now = time.Now
+	}
+	if !force && c.Token != "" && c.now().Before(c.expiry) {
+		return nil
+	}
+	jwtFile := c.JWTFile
+	if jwtFile == "" {
+		jwtFile = ServiceAccountTokenFile
+	}
+	jwt, err := FileSource(jwtFile).Fetch()
+	if err != nil {
+		return errors.Wrap(err, "reading the service account token to log into Vault")
+	}
+	authPath := c.AuthPath
+	if authPath == "" {
+		authPath = defaultVaultAuthPath
+	}
+	body, err := json.Marshal(map[string]string{"role": c.Role, "jwt": jwt})
+	if err != nil {
+		return err
+	}
+	c.Token = ""
+	status, resp, err := c.do(http.MethodPost, fmt.Sprintf("auth/%s/login", authPath), body)
+	if err != nil {
+		return errors.Wrapf(err, "logging into Vault as role %s", c.Role)
+	}
+	if status != http.StatusOK || resp.Auth == nil || resp.Auth.ClientToken == "" {
+		return errors.Errorf("logging into Vault as role %s: status %d %s", c.Role, status, strings.Join(resp.Errors, ", "))
+	}
+	c.Token = resp.Auth.ClientToken
+	// renew a little before the lease ends
+	lease := time.Duration(resp.Auth.LeaseDuration) * time.Second
+	c.expiry = c.now().Add(lease - lease/10)
+	return nil
+}
+
+func (c *VaultClient) do(method, path string, body []byte) (int, *vaultResponse, error) {
+	if c.httpClient == nil {
+		c.httpClient = &http.Client{Timeout: vaultTimeout}
+	}
+	u := strings.TrimSuffix(c.Address, "/") + "/v1/" + strings.TrimPrefix(path, "/")
+	req, err := http.NewRequest(method, u, bytes.NewReader(body))
+	if err != nil {
+		return 0, nil, err
+	}
+	if c.Token != "" {
+		req.Header.Set("X-Vault-Token", c.Token)
+	}
+	if c.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", c.Namespace)
+	}
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer res.Body.Close()
+	data, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return 0, nil, err
+	}
+	resp := &vaultResponse{}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, resp); err != nil {
+			return res.StatusCode, resp, errors.Wrapf(err, "parsing Vault response with status %d", res.StatusCode)
+		}
+	}
+	return res.StatusCode, resp, nil
+}
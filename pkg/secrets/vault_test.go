@@ -0,0 +1,93 @@
+package secrets
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVaultSourceWithToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "root", r.Header.Get("X-Vault-Token"))
+		switch r.URL.Path {
+		case "/v1/secret/data/lighthouse":
+			_, _ = w.Write([]byte(`{"data": {"data": {"hmac": "v2-secret"}, "metadata": {"version": 3}}}`))
+		case "/v1/kv/lighthouse":
+			_, _ = w.Write([]byte(`{"data": {"hmac": "v1-secret"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errors": []}`))
+		}
+	}))
+	defer server.Close()
+	client := &VaultClient{Address: server.URL, Token: "root"}
+
+	value, err := (&VaultSource{Client: client, Path: "secret/data/lighthouse", Key: "hmac"}).Fetch()
+	require.NoError(t, err)
+	assert.Equal(t, "v2-secret", value)
+
+	value, err = (&VaultSource{Client: client, Path: "kv/lighthouse", Key: "hmac"}).Fetch()
+	require.NoError(t, err)
+	assert.Equal(t, "v1-secret", value)
+
+	_, err = (&VaultSource{Client: client, Path: "kv/lighthouse", Key: "token"}).Fetch()
+	assert.Error(t, err)
+	_, err = (&VaultSource{Client: client, Path: "kv/missing", Key: "hmac"}).Fetch()
+	assert.Error(t, err)
+}
+
+func TestVaultKubernetesLogin(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vault")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	jwtFile := filepath.Join(dir, "token")
+	require.NoError(t, ioutil.WriteFile(jwtFile, []byte("service-account-jwt"), 0600))
+
+	logins := 0
+	validToken := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/auth/k8s/login":
+			body := map[string]string{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.Equal(t, "lighthouse", body["role"])
+			assert.Equal(t, "service-account-jwt", body["jwt"])
+			logins++
+			validToken = fmt.Sprintf("token-%d", logins)
+			_, _ = w.Write([]byte(`{"auth": {"client_token": "` + validToken + `", "lease_duration": 3600}}`))
+		case "/v1/secret/data/lighthouse":
+			if r.Header.Get("X-Vault-Token") != validToken {
+				w.WriteHeader(http.StatusForbidden)
+				_, _ = w.Write([]byte(`{"errors": ["permission denied"]}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"data": {"data": {"hmac": "secret"}, "metadata": {}}}`))
+		}
+	}))
+	defer server.Close()
+
+	client := &VaultClient{Address: server.URL, Role: "lighthouse", AuthPath: "k8s", JWTFile: jwtFile}
+	data, err := client.Read("secret/data/lighthouse")
+	require.NoError(t, err)
+	assert.Equal(t, "secret", data["hmac"])
+	assert.Equal(t, 1, logins)
+
+	_, err = client.Read("secret/data/lighthouse")
+	require.NoError(t, err)
+	assert.Equal(t, 1, logins, "should reuse the token until it expires")
+
+	// revoke the token
+	validToken = "revoked"
+	data, err = client.Read("secret/data/lighthouse")
+	require.NoError(t, err)
+	assert.Equal(t, "secret", data["hmac"])
+	assert.Equal(t, 2, logins)
+}
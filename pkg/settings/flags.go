@@ -0,0 +1,183 @@
+package settings
+
+import (
+	"flag"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// flagSet is the part of the flags of a component the settings are applied to, either the flag.FlagSet of keeper,
+// foghorn and gc or the flags of the cobra command of the webhooks
+type flagSet interface {
+	has(name string) bool
+	changed(name string) bool
+	set(name string, values []string) error
+}
+
+type goFlags struct {
+	fs      *flag.FlagSet
+	visited map[string]bool
+}
+
+func (f *goFlags) has(name string) bool {
+	return f.fs.Lookup(name) != nil
+}
+
+func (f *goFlags) changed(name string) bool {
+	if f.visited == nil {
+		f.visited = map[string]bool{}
+		f.fs.Visit(func(fl *flag.Flag) {
+			f.visited[fl.Name] = true
+		})
+	}
+	return f.visited[name]
+}
+
+// set sets the flag to each of the values in turn, which appends them to the flags which can be repeated
+func (f *goFlags) set(name string, values []string) error {
+	for _, value := range values {
+		if err := f.fs.Set(name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type cobraFlags struct {
+	cmd *cobra.Command
+}
+
+func (f *cobraFlags) has(name string) bool {
+	return f.cmd.Flags().Lookup(name) != nil
+}
+
+func (f *cobraFlags) changed(name string) bool {
+	return f.cmd.Flags().Changed(name)
+}
+
+// set replaces the values of the slice flags rather than appending to the values given on the command line
+func (f *cobraFlags) set(name string, values []string) error {
+	if slice, ok := f.cmd.Flags().Lookup(name).Value.(interface{ Replace([]string) error }); ok {
+		return slice.Replace(values)
+	}
+	for _, value := range values {
+		if err := f.cmd.Flags().Set(name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Configure applies the section of the settings file at the path, which may be empty, to the flags of keeper,
+// foghorn or gc once they are parsed. The settings win over the flags, which are deprecated when they have a setting.
+func Configure(fs *flag.FlagSet, path, section string) (*Settings, error) {
+	return configure(&goFlags{fs: fs}, path, section)
+}
+
+// ConfigureCommand applies the section of the settings file at the path, which may be empty, to the flags of the
+// command once they are parsed. The settings win over the flags, which are deprecated when they have a setting.
+func ConfigureCommand(cmd *cobra.Command, path, section string) (*Settings, error) {
+	return configure(&cobraFlags{cmd: cmd}, path, section)
+}
+
+func configure(fs flagSet, path, section string) (*Settings, error) {
+	s := &Settings{}
+	if path != "" {
+		var err error
+		if s, err = Load(path); err != nil {
+			return nil, err
+		}
+	}
+	v, err := sectionOf(s, section)
+	if err != nil {
+		return nil, err
+	}
+	if err := apply(fs, section, v); err != nil {
+		return nil, errors.Wrapf(err, "applying the %s settings of %s", section, path)
+	}
+	ApplyLogLevel(v.Addr().Interface())
+	return s, nil
+}
+
+// sectionOf returns the section of the settings named after its JSON field, e.g. webhooks
+func sectionOf(s *Settings, section string) (reflect.Value, error) {
+	v := reflect.ValueOf(s).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		if jsonName(v.Type().Field(i)) == section && v.Field(i).Kind() == reflect.Struct {
+			return v.Field(i), nil
+		}
+	}
+	return reflect.Value{}, errors.Errorf("unknown settings section %s", section)
+}
+
+func jsonName(field reflect.StructField) string {
+	return strings.Split(field.Tag.Get("json"), ",")[0]
+}
+
+// apply sets the flags of the settings of the section, warning about the flags given on the command line
+func apply(fs flagSet, section string, v reflect.Value) error {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		name := field.Tag.Get("flag")
+		if name == "" {
+			continue
+		}
+		if !fs.has(name) {
+			return errors.Errorf("setting %s.%s has no --%s flag", section, jsonName(field), name)
+		}
+		values, ok := flagValues(v.Field(i))
+		if fs.changed(name) {
+			l := logrus.WithField("flag", name)
+			if ok {
+				l.Warnf("--%s is overridden by %s.%s of the %s", name, section, jsonName(field), Kind)
+			} else {
+				l.Warnf("--%s is deprecated, set %s.%s in the %s instead", name, section, jsonName(field), Kind)
+			}
+		}
+		if !ok {
+			continue
+		}
+		if err := fs.set(name, values); err != nil {
+			return errors.Wrapf(err, "invalid %s.%s", section, jsonName(field))
+		}
+	}
+	return nil
+}
+
+// flagValues returns the values of the flag of a setting, or false if the setting is not set
+func flagValues(v reflect.Value) ([]string, bool) {
+	switch v.Kind() {
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil, false
+		}
+		values := make([]string, v.Len())
+		for i := range values {
+			values[i] = v.Index(i).String()
+		}
+		return values, true
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil, false
+		}
+		switch value := v.Interface().(type) {
+		case *metav1.Duration:
+			return []string{value.Duration.String()}, true
+		case *string:
+			return []string{*value}, true
+		case *bool:
+			return []string{strconv.FormatBool(*value)}, true
+		case *int:
+			return []string{strconv.Itoa(*value)}, true
+		case *int64:
+			return []string{strconv.FormatInt(*value, 10)}, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,199 @@
+// Package settings configures the lighthouse components from a single LighthouseSettings file, usually rendered by
+// the chart into the lighthouse-settings ConfigMap, rather than from the command line flags of each binary. Each
+// section holds the settings of a component, named after the flag it replaces:
+//
+//	apiVersion: lighthouse.jenkins.io/v1alpha1
+//	kind: LighthouseSettings
+//	webhooks:
+//	  log_level: debug
+//	  plugin_timeout: 2m
+//	  allowed_repos:
+//	  - myorg
+//	keeper:
+//	  sync_hourly_tokens: 1000
+//	foghorn:
+//	  pod_max_retries: 5
+//	gc:
+//	  max_age: 72h
+//
+// The settings tagged reload:"true" are reloaded when the file changes, the others require a restart.
+package settings
+
+import (
+	"io/ioutil"
+	"reflect"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// APIVersion is the apiVersion of the LighthouseSettings
+	APIVersion = "lighthouse.jenkins.io/v1alpha1"
+	// Kind is the kind of the LighthouseSettings
+	Kind = "LighthouseSettings"
+	// FileFlag is the flag of the components giving the path of the LighthouseSettings file
+	FileFlag = "settings-file"
+)
+
+// Settings holds the settings of every lighthouse component
+type Settings struct {
+	APIVersion string   `json:"apiVersion,omitempty"`
+	Kind       string   `json:"kind,omitempty"`
+	Webhooks   Webhooks `json:"webhooks,omitempty"`
+	Keeper     Keeper   `json:"keeper,omitempty"`
+	Foghorn    Foghorn  `json:"foghorn,omitempty"`
+	GC         GC       `json:"gc,omitempty"`
+}
+
+// Webhooks holds the settings of the webhooks
+type Webhooks struct {
+	LogLevel                *string          `json:"log_level,omitempty" flag:"log-level" reload:"true"`
+	LogFormat               *string          `json:"log_format,omitempty" flag:"log-format"`
+	AdminPort               *int             `json:"admin_port,omitempty" flag:"admin-port"`
+	MaxPayloadSize          *int64           `json:"max_payload_size,omitempty" flag:"max-payload-size"`
+	AllowedSourceRanges     []string         `json:"allowed_source_ranges,omitempty" flag:"allowed-source-ranges"`
+	ProviderIPRangesURL     *string          `json:"provider_ip_ranges_url,omitempty" flag:"provider-ip-ranges-url"`
+	ProviderIPRangesRefresh *metav1.Duration `json:"provider_ip_ranges_refresh,omitempty" flag:"provider-ip-ranges-refresh"`
+	TrustForwardedFor       *bool            `json:"trust_forwarded_for,omitempty" flag:"trust-forwarded-for"`
+	AllowedRepos            []string         `json:"allowed_repos,omitempty" flag:"allowed-repos" reload:"true"`
+	DeniedRepos             []string         `json:"denied_repos,omitempty" flag:"denied-repos" reload:"true"`
+	DeliveryDedup           *string          `json:"delivery_dedup,omitempty" flag:"delivery-dedup"`
+	DeliveryDedupTTL        *metav1.Duration `json:"delivery_dedup_ttl,omitempty" flag:"delivery-dedup-ttl"`
+	StateStore              *string          `json:"state_store,omitempty" flag:"state-store"`
+	StateConfigMap          *string          `json:"state_configmap,omitempty" flag:"state-configmap"`
+	RedisAddress            *string          `json:"redis_address,omitempty" flag:"redis-address"`
+	RedisDatabase           *int             `json:"redis_database,omitempty" flag:"redis-database"`
+	RedisPrefix             *string          `json:"redis_prefix,omitempty" flag:"redis-prefix"`
+	LogArchiveDir           *string          `json:"log_archive_dir,omitempty" flag:"log-archive-dir"`
+	PollInterval            *metav1.Duration `json:"poll_interval,omitempty" flag:"poll-interval"`
+	ResyncInterval          *metav1.Duration `json:"resync_interval,omitempty" flag:"resync-interval"`
+	ScheduleInterval        *metav1.Duration `json:"schedule_interval,omitempty" flag:"schedule-interval"`
+	LabelConfig             *string          `json:"label_config,omitempty" flag:"label-config"`
+	LabelSyncInterval       *metav1.Duration `json:"label_sync_interval,omitempty" flag:"label-sync-interval"`
+	HookURL                 *string          `json:"hook_url,omitempty" flag:"hook-url"`
+	PluginTimeout           *metav1.Duration `json:"plugin_timeout,omitempty" flag:"plugin-timeout"`
+	PluginWorkers           *int             `json:"plugin_workers,omitempty" flag:"plugin-workers"`
+	PluginQueueSize         *int             `json:"plugin_queue_size,omitempty" flag:"plugin-queue-size"`
+	AdmissionPort           *int             `json:"admission_port,omitempty" flag:"admission-port"`
+	AdmissionCertFile       *string          `json:"admission_cert_file,omitempty" flag:"admission-cert-file"`
+	AdmissionKeyFile        *string          `json:"admission_key_file,omitempty" flag:"admission-key-file"`
+	WatchLighthouseConfigs  *bool            `json:"watch_lighthouse_configs,omitempty" flag:"watch-lighthouse-configs"`
+	RecordDir               *string          `json:"record_dir,omitempty" flag:"record-dir"`
+	RecordKeyFile           *string          `json:"record_key_file,omitempty" flag:"record-key-file"`
+	RecordRetention         *metav1.Duration `json:"record_retention,omitempty" flag:"record-retention"`
+	GitCacheDir             *string          `json:"git_cache_dir,omitempty" flag:"git-cache-dir"`
+	GitCacheMaxSize         *string          `json:"git_cache_max_size,omitempty" flag:"git-cache-max-size"`
+}
+
+// Keeper holds the settings of keeper
+type Keeper struct {
+	LogLevel               *string `json:"log_level,omitempty" reload:"true"`
+	Port                   *int    `json:"port,omitempty" flag:"port"`
+	AdminPort              *int    `json:"admin_port,omitempty" flag:"admin-port"`
+	Provider               *string `json:"provider,omitempty" flag:"provider"`
+	DryRun                 *bool   `json:"dry_run,omitempty" flag:"dry-run"`
+	WatchLighthouseConfigs *bool   `json:"watch_lighthouse_configs,omitempty" flag:"watch-lighthouse-configs"`
+	SyncHourlyTokens       *int    `json:"sync_hourly_tokens,omitempty" flag:"sync-hourly-tokens"`
+	StatusHourlyTokens     *int    `json:"status_hourly_tokens,omitempty" flag:"status-hourly-tokens"`
+	MaxRecordsPerPool      *int    `json:"max_records_per_pool,omitempty" flag:"max-records-per-pool"`
+	HistoryURI             *string `json:"history_uri,omitempty" flag:"history-uri"`
+	StatusPath             *string `json:"status_path,omitempty" flag:"status-path"`
+}
+
+// Foghorn holds the settings of foghorn
+type Foghorn struct {
+	LogLevel                  *string          `json:"log_level,omitempty" reload:"true"`
+	Port                      *int             `json:"port,omitempty" flag:"port"`
+	AdminPort                 *int             `json:"admin_port,omitempty" flag:"admin-port"`
+	DryRun                    *bool            `json:"dry_run,omitempty" flag:"dry-run"`
+	AllNamespaces             *bool            `json:"all_namespaces,omitempty" flag:"all-namespaces"`
+	WatchdogInterval          *metav1.Duration `json:"watchdog_interval,omitempty" flag:"watchdog-interval"`
+	PendingTimeout            *metav1.Duration `json:"pending_timeout,omitempty" flag:"pending-timeout"`
+	UnscheduledTimeout        *metav1.Duration `json:"unscheduled_timeout,omitempty" flag:"unscheduled-timeout"`
+	MissingPipelineRunTimeout *metav1.Duration `json:"missing_pipelinerun_timeout,omitempty" flag:"missing-pipelinerun-timeout"`
+	JenkinsSyncInterval       *metav1.Duration `json:"jenkins_sync_interval,omitempty" flag:"jenkins-sync-interval"`
+	PodSyncInterval           *metav1.Duration `json:"pod_sync_interval,omitempty" flag:"pod-sync-interval"`
+	PodMaxRetries             *int             `json:"pod_max_retries,omitempty" flag:"pod-max-retries"`
+	CloneImage                *string          `json:"clone_image,omitempty" flag:"clone-image"`
+	LogsImage                 *string          `json:"logs_image,omitempty" flag:"logs-image"`
+	LogArchiveClaim           *string          `json:"log_archive_claim,omitempty" flag:"log-archive-claim"`
+	LogsURL                   *string          `json:"logs_url,omitempty" flag:"logs-url"`
+	GitCredentialsSecret      *string          `json:"git_credentials_secret,omitempty" flag:"git-credentials-secret"`
+	HookURL                   *string          `json:"hook_url,omitempty" flag:"hook-url"`
+	HookSyncInterval          *metav1.Duration `json:"hook_sync_interval,omitempty" flag:"hook-sync-interval"`
+	HookPrune                 *bool            `json:"hook_prune,omitempty" flag:"hook-prune"`
+	HookDryRun                *bool            `json:"hook_dry_run,omitempty" flag:"hook-dry-run"`
+	EmailDigestHour           *int             `json:"email_digest_hour,omitempty" flag:"email-digest-hour"`
+	UsageStore                *string          `json:"usage_store,omitempty" flag:"usage-store"`
+	UsageConfigMap            *string          `json:"usage_configmap,omitempty" flag:"usage-configmap"`
+	UsageRedisAddress         *string          `json:"usage_redis_address,omitempty" flag:"usage-redis-address"`
+	UsageRedisPrefix          *string          `json:"usage_redis_prefix,omitempty" flag:"usage-redis-prefix"`
+	ProvenanceDir             *string          `json:"provenance_dir,omitempty" flag:"provenance-dir"`
+	ProvenanceKey             *string          `json:"provenance_key,omitempty" flag:"provenance-key"`
+	ProvenanceFulcioURL       *string          `json:"provenance_fulcio_url,omitempty" flag:"provenance-fulcio-url"`
+	ProvenanceIdentityToken   *string          `json:"provenance_identity_token,omitempty" flag:"provenance-identity-token"`
+	ProvenanceBuilderID       *string          `json:"provenance_builder_id,omitempty" flag:"provenance-builder-id"`
+}
+
+// GC holds the settings of the garbage collection of the LighthouseJobs
+type GC struct {
+	LogLevel        *string          `json:"log_level,omitempty"`
+	AllNamespaces   *bool            `json:"all_namespaces,omitempty" flag:"all-namespaces"`
+	MaxAge          *metav1.Duration `json:"max_age,omitempty" flag:"max-age"`
+	SucceededMaxAge *metav1.Duration `json:"succeeded_max_age,omitempty" flag:"succeeded-max-age"`
+	FailedMaxAge    *metav1.Duration `json:"failed_max_age,omitempty" flag:"failed-max-age"`
+	StateMaxAge     []string         `json:"state_max_age,omitempty" flag:"state-max-age"`
+	MaxPerRepo      *int             `json:"max_per_repo,omitempty" flag:"max-per-repo"`
+	DryRun          *bool            `json:"dry_run,omitempty" flag:"dry-run"`
+	ArchiveDir      *string          `json:"archive_dir,omitempty" flag:"archive-dir"`
+	PushGateway     *string          `json:"push_gateway,omitempty" flag:"push-gateway"`
+}
+
+// Load reads the LighthouseSettings file, rejecting unknown fields so that a misspelled setting is not ignored
+func Load(path string) (*Settings, error) {
+	data, err := ioutil.ReadFile(path) // #nosec
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading the settings %s", path)
+	}
+	answer, err := Parse(data)
+	return answer, errors.Wrapf(err, "loading the settings %s", path)
+}
+
+// Parse parses the text of a LighthouseSettings file
+func Parse(data []byte) (*Settings, error) {
+	answer := &Settings{}
+	if err := yaml.UnmarshalStrict(data, answer); err != nil {
+		return nil, errors.Wrap(err, "parsing the settings")
+	}
+	if answer.Kind != "" && answer.Kind != Kind {
+		return nil, errors.Errorf("unexpected kind %s instead of %s", answer.Kind, Kind)
+	}
+	for _, level := range []*string{answer.Webhooks.LogLevel, answer.Keeper.LogLevel, answer.Foghorn.LogLevel, answer.GC.LogLevel} {
+		if level == nil {
+			continue
+		}
+		if _, err := logrus.ParseLevel(*level); err != nil {
+			return nil, errors.Wrap(err, "invalid log_level")
+		}
+	}
+	return answer, nil
+}
+
+// ApplyLogLevel sets the level of the standard logger to the log_level of the section, if any
+func ApplyLogLevel(section interface{}) {
+	v := reflect.Indirect(reflect.ValueOf(section)).FieldByName("LogLevel")
+	if !v.IsValid() || v.IsNil() {
+		return
+	}
+	level, err := logrus.ParseLevel(v.Elem().String())
+	if err != nil {
+		return
+	}
+	if level != logrus.GetLevel() {
+		logrus.Infof("changing the log level from %s to %s", logrus.GetLevel(), level)
+		logrus.SetLevel(level)
+	}
+}
@@ -0,0 +1,146 @@
+package settings
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const settingsYAML = `
+apiVersion: lighthouse.jenkins.io/v1alpha1
+kind: LighthouseSettings
+webhooks:
+  log_level: debug
+  plugin_timeout: 2m
+  allowed_repos:
+  - myorg
+  - other/repo
+keeper:
+  sync_hourly_tokens: 1000
+  dry_run: false
+gc:
+  max_age: 72h
+  state_max_age:
+  - failure=24h
+`
+
+func writeSettings(t *testing.T, text string) string {
+	dir, err := ioutil.TempDir("", "settings")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, "settings.yaml")
+	require.NoError(t, ioutil.WriteFile(path, []byte(text), 0600))
+	return path
+}
+
+func TestParse(t *testing.T) {
+	s, err := Parse([]byte(settingsYAML))
+	require.NoError(t, err)
+	assert.Equal(t, 2*time.Minute, s.Webhooks.PluginTimeout.Duration)
+	assert.Equal(t, 1000, *s.Keeper.SyncHourlyTokens)
+	assert.Nil(t, s.Keeper.StatusHourlyTokens)
+
+	_, err = Parse([]byte("keeper:\n  sync_hourly_tokns: 1000\n"))
+	assert.Error(t, err, "a misspelled setting should be rejected")
+	_, err = Parse([]byte("gc:\n  max_age: forever\n"))
+	assert.Error(t, err, "a setting of the wrong type should be rejected")
+	_, err = Parse([]byte("webhooks:\n  log_level: loud\n"))
+	assert.Error(t, err)
+	_, err = Parse([]byte("kind: ConfigMap\n"))
+	assert.Error(t, err)
+}
+
+func TestConfigure(t *testing.T) {
+	defer logrus.SetLevel(logrus.GetLevel())
+	path := writeSettings(t, settingsYAML)
+
+	fs := flag.NewFlagSet("keeper", flag.ContinueOnError)
+	syncTokens := fs.Int("sync-hourly-tokens", 800, "")
+	statusTokens := fs.Int("status-hourly-tokens", 400, "")
+	dryRun := fs.Bool("dry-run", true, "")
+	for _, name := range []string{"port", "admin-port", "provider", "watch-lighthouse-configs", "max-records-per-pool", "history-uri", "status-path"} {
+		fs.String(name, "", "")
+	}
+	require.NoError(t, fs.Parse([]string{"--sync-hourly-tokens=500", "--status-hourly-tokens=300"}))
+	_, err := Configure(fs, path, "keeper")
+	require.NoError(t, err)
+	assert.Equal(t, 1000, *syncTokens, "the settings should win over the flags")
+	assert.Equal(t, 300, *statusTokens, "the flags without setting should be kept")
+	assert.False(t, *dryRun)
+
+	fs = flag.NewFlagSet("keeper", flag.ContinueOnError)
+	fs.Int("sync-hourly-tokens", 800, "")
+	_, err = Configure(fs, path, "keeper")
+	assert.Error(t, err, "a setting without flag should be reported")
+
+	_, err = Configure(flag.NewFlagSet("gc", flag.ContinueOnError), "", "keeper")
+	assert.Error(t, err, "every flag of the settings should exist even without settings file")
+
+	_, err = Configure(fs, path, "unknown")
+	assert.Error(t, err)
+}
+
+func TestConfigureCommand(t *testing.T) {
+	defer logrus.SetLevel(logrus.GetLevel())
+	path := writeSettings(t, "webhooks:\n  log_level: debug\n  plugin_timeout: 2m\n  allowed_repos:\n  - myorg\n")
+
+	var timeout time.Duration
+	var allowed []string
+	cmd := &cobra.Command{}
+	cmd.Flags().DurationVar(&timeout, "plugin-timeout", time.Minute, "")
+	cmd.Flags().StringSliceVar(&allowed, "allowed-repos", nil, "")
+	for _, name := range []string{"log-level", "log-format", "provider-ip-ranges-url", "delivery-dedup", "state-store", "state-configmap", "redis-address", "redis-prefix", "log-archive-dir", "label-config", "hook-url", "admission-cert-file", "admission-key-file", "record-dir", "record-key-file", "git-cache-dir", "git-cache-max-size"} {
+		cmd.Flags().String(name, "", "")
+	}
+	for _, name := range []string{"admin-port", "redis-database", "plugin-workers", "plugin-queue-size", "admission-port"} {
+		cmd.Flags().Int(name, 0, "")
+	}
+	for _, name := range []string{"provider-ip-ranges-refresh", "delivery-dedup-ttl", "poll-interval", "resync-interval", "schedule-interval", "label-sync-interval", "record-retention"} {
+		cmd.Flags().Duration(name, 0, "")
+	}
+	cmd.Flags().Int64("max-payload-size", 0, "")
+	cmd.Flags().StringSlice("allowed-source-ranges", nil, "")
+	cmd.Flags().StringSlice("denied-repos", nil, "")
+	cmd.Flags().Bool("trust-forwarded-for", false, "")
+	cmd.Flags().Bool("watch-lighthouse-configs", false, "")
+	require.NoError(t, cmd.Flags().Parse([]string{"--allowed-repos=legacy"}))
+
+	_, err := ConfigureCommand(cmd, path, "webhooks")
+	require.NoError(t, err)
+	assert.Equal(t, 2*time.Minute, timeout)
+	assert.Equal(t, []string{"myorg"}, allowed, "the list of the settings should replace the one of the flags")
+	assert.Equal(t, logrus.DebugLevel, logrus.GetLevel())
+}
+
+func TestChanges(t *testing.T) {
+	old, err := Parse([]byte(settingsYAML))
+	require.NoError(t, err)
+	updated, err := Parse([]byte(`
+webhooks:
+  log_level: info
+  plugin_timeout: 5m
+  allowed_repos:
+  - myorg
+keeper:
+  sync_hourly_tokens: 1000
+  dry_run: false
+`))
+	require.NoError(t, err)
+	reloaded, restart, err := changes(old, updated, "webhooks")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"log_level", "allowed_repos"}, reloaded)
+	assert.Equal(t, []string{"plugin_timeout"}, restart)
+
+	reloaded, restart, err = changes(old, updated, "keeper")
+	require.NoError(t, err)
+	assert.Empty(t, reloaded)
+	assert.Empty(t, restart)
+}
@@ -0,0 +1,80 @@
+package settings
+
+import (
+	"io/ioutil"
+	"reflect"
+	"time"
+
+	"github.com/jenkins-x/lighthouse/pkg/interrupts"
+	"github.com/sirupsen/logrus"
+)
+
+// watchInterval is how often the settings file is checked for changes, which the kubelet propagates to the mounted
+// ConfigMap within about a minute
+const watchInterval = time.Minute
+
+// Watch reloads the section of the settings file at the path whenever the file changes, applying the log level and
+// passing the new settings to onReload if any of the settings tagged reload changed, which may be nil. The changes
+// of the other settings are only logged as they require a restart.
+func Watch(path, section string, current *Settings, onReload func(*Settings)) {
+	if path == "" {
+		return
+	}
+	last, _ := ioutil.ReadFile(path) // #nosec
+	interrupts.TickLiteral(func() {
+		data, err := ioutil.ReadFile(path) // #nosec
+		if err != nil {
+			logrus.WithError(err).WithField("path", path).Error("Error reading the settings.")
+			return
+		}
+		if string(data) == string(last) {
+			return
+		}
+		last = data
+		s, err := Parse(data)
+		if err != nil {
+			logrus.WithError(err).WithField("path", path).Error("Error loading the settings.")
+			return
+		}
+		reloaded, restart, err := changes(current, s, section)
+		if err != nil {
+			logrus.WithError(err).Error("Error comparing the settings.")
+			return
+		}
+		current = s
+		for _, name := range restart {
+			logrus.WithField("setting", section+"."+name).Warn("Setting changed, restart to apply it.")
+		}
+		if len(reloaded) == 0 {
+			return
+		}
+		logrus.WithField("settings", reloaded).Infof("Reloading the %s settings.", section)
+		v, _ := sectionOf(s, section)
+		ApplyLogLevel(v.Addr().Interface())
+		if onReload != nil {
+			onReload(s)
+		}
+	}, watchInterval)
+}
+
+// changes returns the names of the settings of the section which changed and are tagged reload, and of those which
+// changed and require a restart
+func changes(old, updated *Settings, section string) (reloaded, restart []string, err error) {
+	oldSection, err := sectionOf(old, section)
+	if err != nil {
+		return nil, nil, err
+	}
+	newSection, _ := sectionOf(updated, section)
+	for i := 0; i < oldSection.NumField(); i++ {
+		if reflect.DeepEqual(oldSection.Field(i).Interface(), newSection.Field(i).Interface()) {
+			continue
+		}
+		field := oldSection.Type().Field(i)
+		if field.Tag.Get("reload") == "true" {
+			reloaded = append(reloaded, jsonName(field))
+		} else {
+			restart = append(restart, jsonName(field))
+		}
+	}
+	return reloaded, restart, nil
+}
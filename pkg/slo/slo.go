@@ -0,0 +1,97 @@
+package slo
+
+import (
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/logrusutil"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	webhookToStatus = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "lighthouse_slo_webhook_to_status_seconds",
+		Help:    "The time from the receipt of a webhook to the first commit status reported for the jobs it launched.",
+		Buckets: prometheus.ExponentialBuckets(0.5, 2, 12),
+	}, []string{"agent"})
+	completionToReport = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "lighthouse_slo_completion_to_report_seconds",
+		Help:    "The time from the completion of a job to the report of its final commit status.",
+		Buckets: prometheus.ExponentialBuckets(0.5, 2, 12),
+	}, []string{"agent"})
+)
+
+func init() {
+	prometheus.MustRegister(webhookToStatus, completionToReport)
+}
+
+// JobReported observes the SLOs of a job whose commit status was just reported in the state, which must be called
+// before the state is recorded as the LastReportState of the job: the first status of a job launched for a webhook is
+// observed from the receipt of the webhook, and its final status from its completion. Client library exemplars are
+// not available, so the observations are logged at debug level with the correlation ID of the webhook instead.
+func JobReported(logger *logrus.Entry, job *v1alpha1.LighthouseJob, state string, now time.Time) {
+	agent := job.Spec.Agent
+	if agent == "" {
+		agent = v1alpha1.TektonAgent
+	}
+	l := logger.WithFields(logrus.Fields{
+		"job":                         job.Name,
+		"agent":                       agent,
+		logrusutil.CorrelationIDField: job.Annotations[util.CorrelationIDAnnotation],
+	})
+	if d, ok := webhookToFirstStatus(job, now); ok {
+		webhookToStatus.WithLabelValues(agent).Observe(d.Seconds())
+		l.WithField("seconds", d.Seconds()).Debug("observed the time from the webhook to the first status")
+	}
+	if d, ok := completionToFinalStatus(job, state, now); ok {
+		completionToReport.WithLabelValues(agent).Observe(d.Seconds())
+		l.WithField("seconds", d.Seconds()).Debug("observed the time from the completion to the final status")
+	}
+}
+
+// webhookToFirstStatus returns the time from the receipt of the webhook which launched the job to now, if the job
+// was launched for a webhook and has not reported any status yet
+func webhookToFirstStatus(job *v1alpha1.LighthouseJob, now time.Time) (time.Duration, bool) {
+	if job.Status.LastReportState != "" {
+		return 0, false
+	}
+	received, ok := ReceivedAt(job)
+	if !ok {
+		return 0, false
+	}
+	return now.Sub(received), true
+}
+
+// completionToFinalStatus returns the time from the completion of the job to now, if the state is the first final
+// state reported
+func completionToFinalStatus(job *v1alpha1.LighthouseJob, state string, now time.Time) (time.Duration, bool) {
+	if job.Status.CompletionTime == nil || !final(state) || final(job.Status.LastReportState) {
+		return 0, false
+	}
+	return now.Sub(job.Status.CompletionTime.Time), true
+}
+
+// ReceivedAt returns when the webhook which launched the job was received, if known
+func ReceivedAt(job *v1alpha1.LighthouseJob) (time.Time, bool) {
+	value := job.Annotations[util.ReceivedAtAnnotation]
+	if value == "" {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// final returns true for the commit status states of a completed job
+func final(state string) bool {
+	switch scm.ToState(state) {
+	case scm.StateSuccess, scm.StateFailure, scm.StateError, scm.StateCanceled:
+		return true
+	}
+	return false
+}
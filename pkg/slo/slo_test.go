@@ -0,0 +1,58 @@
+package slo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestWebhookToFirstStatus(t *testing.T) {
+	now := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	job := &v1alpha1.LighthouseJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{util.ReceivedAtAnnotation: now.Add(-3 * time.Second).Format(time.RFC3339Nano)},
+		},
+	}
+	d, ok := webhookToFirstStatus(job, now)
+	assert.True(t, ok)
+	assert.Equal(t, 3*time.Second, d)
+
+	job.Status.LastReportState = "pending"
+	_, ok = webhookToFirstStatus(job, now)
+	assert.False(t, ok, "only the first status is observed")
+
+	job.Status.LastReportState = ""
+	job.Annotations[util.ReceivedAtAnnotation] = "yesterday"
+	_, ok = webhookToFirstStatus(job, now)
+	assert.False(t, ok, "an invalid receipt time is ignored")
+
+	_, ok = webhookToFirstStatus(&v1alpha1.LighthouseJob{}, now)
+	assert.False(t, ok, "a job which was not launched for a webhook is ignored")
+}
+
+func TestCompletionToFinalStatus(t *testing.T) {
+	now := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	completed := metav1.NewTime(now.Add(-10 * time.Second))
+	job := &v1alpha1.LighthouseJob{
+		Status: v1alpha1.LighthouseJobStatus{CompletionTime: &completed, LastReportState: "running"},
+	}
+	d, ok := completionToFinalStatus(job, "failure", now)
+	assert.True(t, ok)
+	assert.Equal(t, 10*time.Second, d)
+
+	_, ok = completionToFinalStatus(job, "running", now)
+	assert.False(t, ok, "a state which is not final is ignored")
+
+	job.Status.LastReportState = "failure"
+	_, ok = completionToFinalStatus(job, "failure", now)
+	assert.False(t, ok, "a final state reported again is ignored")
+
+	job.Status.LastReportState = "running"
+	job.Status.CompletionTime = nil
+	_, ok = completionToFinalStatus(job, "success", now)
+	assert.False(t, ok, "a job which did not complete is ignored")
+}
@@ -0,0 +1,165 @@
+package store
+
+import (
+	"encoding/json"
+	"regexp"
+	"time"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+var invalidKeyChars = regexp.MustCompile(`[^-._a-zA-Z0-9]`)
+
+// configMapEntry is the value of a key in the ConfigMap
+type configMapEntry struct {
+	Value   string     `json:"value"`
+	Expires *time.Time `json:"expires,omitempty"`
+}
+
+// configMapStore keeps the values in a ConfigMap. Updates use the ConfigMap's resource version so that concurrent
+// updates of the replicas are retried rather than lost.
+type configMapStore struct {
+	kubeClient kubernetes.Interface
+	namespace  string
+	name       string
+	now        func() time.Time
+}
+
+// NewConfigMapStore creates a store keeping the values in the ConfigMap with the given name, the characters of the
+// keys which are invalid in a ConfigMap being replaced with underscores
+func NewConfigMapStore(kubeClient kubernetes.Interface, namespace, name string) Store {
+	return newConfigMapStore(kubeClient, namespace, name)
+}
+
+func newConfigMapStore(kubeClient kubernetes.Interface, namespace, name string) *configMapStore {
+	return &configMapStore{
+		kubeClient: kubeClient,
+		namespace:  namespace,
+		name:       name,
+		now:        time.Now,
+	}
+}
+
+func (s *configMapStore) Get(key string) (string, bool, error) {
+	cm, err := s.kubeClient.CoreV1().ConfigMaps(s.namespace).Get(s.name, metav1.GetOptions{})
+	if kubeerrors.IsNotFound(err) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, errors.Wrapf(err, "getting ConfigMap %s", s.name)
+	}
+	entry, found := s.entry(cm.Data, configMapKey(key))
+	return entry.Value, found, nil
+}
+
+func (s *configMapStore) Set(key, value string, ttl time.Duration) error {
+	return s.Update(key, ttl, func(string, bool) (string, error) {
+		return value, nil
+	})
+}
+
+func (s *configMapStore) SetIfAbsent(key, value string, ttl time.Duration) (bool, error) {
+	set := false
+	err := s.modify(func(data map[string]string) (bool, error) {
+		if _, found := s.entry(data, configMapKey(key)); found {
+			return false, nil
+		}
+		set = true
+		return true, s.setEntry(data, configMapKey(key), value, ttl)
+	})
+	return set, err
+}
+
+func (s *configMapStore) Update(key string, ttl time.Duration, update func(string, bool) (string, error)) error {
+	return s.modify(func(data map[string]string) (bool, error) {
+		entry, found := s.entry(data, configMapKey(key))
+		value, err := update(entry.Value, found)
+		if err != nil {
+			return false, err
+		}
+		return true, s.setEntry(data, configMapKey(key), value, ttl)
+	})
+}
+
+func (s *configMapStore) Delete(key string) error {
+	return s.modify(func(data map[string]string) (bool, error) {
+		_, found := data[configMapKey(key)]
+		delete(data, configMapKey(key))
+		return found, nil
+	})
+}
+
+// modify applies the modification to the data of the ConfigMap, pruning the expired entries, and saves it if it
+// changed, retrying on conflicts
+func (s *configMapStore) modify(modification func(data map[string]string) (bool, error)) error {
+	configMaps := s.kubeClient.CoreV1().ConfigMaps(s.namespace)
+	for i := 0; i < maxConflictRetries; i++ {
+		cm, err := configMaps.Get(s.name, metav1.GetOptions{})
+		create := kubeerrors.IsNotFound(err)
+		if create {
+			cm = &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: s.name}}
+		} else if err != nil {
+			return errors.Wrapf(err, "getting ConfigMap %s", s.name)
+		}
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+		for k := range cm.Data {
+			if _, found := s.entry(cm.Data, k); !found {
+				delete(cm.Data, k)
+			}
+		}
+		changed, err := modification(cm.Data)
+		if err != nil || !changed {
+			return err
+		}
+		if create {
+			_, err = configMaps.Create(cm)
+			if kubeerrors.IsAlreadyExists(err) {
+				continue
+			}
+			return errors.Wrapf(err, "creating ConfigMap %s", s.name)
+		}
+		_, err = configMaps.Update(cm)
+		if kubeerrors.IsConflict(err) {
+			continue
+		}
+		return errors.Wrapf(err, "updating ConfigMap %s", s.name)
+	}
+	return errors.Errorf("too many conflicts updating ConfigMap %s", s.name)
+}
+
+// entry returns the entry of the key unless it is invalid or expired
+func (s *configMapStore) entry(data map[string]string, key string) (configMapEntry, bool) {
+	var entry configMapEntry
+	value, found := data[key]
+	if !found || json.Unmarshal([]byte(value), &entry) != nil {
+		return configMapEntry{}, false
+	}
+	if entry.Expires != nil && !s.now().Before(*entry.Expires) {
+		return configMapEntry{}, false
+	}
+	return entry, true
+}
+
+func (s *configMapStore) setEntry(data map[string]string, key, value string, ttl time.Duration) error {
+	entry := configMapEntry{Value: value}
+	if ttl > 0 {
+		expires := s.now().Add(ttl).UTC()
+		entry.Expires = &expires
+	}
+	encoded, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	data[key] = string(encoded)
+	return nil
+}
+
+func configMapKey(key string) string {
+	return invalidKeyChars.ReplaceAllString(key, "_")
+}
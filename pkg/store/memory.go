@@ -0,0 +1,106 @@
+package store
+
+import (
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	value   string
+	expires time.Time
+}
+
+// memoryStore keeps the values in memory, so they are only shared by the callers in the same process
+type memoryStore struct {
+	now func() time.Time
+
+	lock    sync.Mutex
+	entries map[string]memoryEntry
+}
+
+// NewMemoryStore creates a store keeping the values in memory
+func NewMemoryStore() Store {
+	return newMemoryStore()
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		now:     time.Now,
+		entries: map[string]memoryEntry{},
+	}
+}
+
+func (s *memoryStore) Get(key string) (string, bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	value, found := s.get(key)
+	return value, found, nil
+}
+
+func (s *memoryStore) Set(key, value string, ttl time.Duration) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.set(key, value, ttl)
+	return nil
+}
+
+func (s *memoryStore) SetIfAbsent(key, value string, ttl time.Duration) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, found := s.get(key); found {
+		return false, nil
+	}
+	s.set(key, value, ttl)
+	return true, nil
+}
+
+func (s *memoryStore) Update(key string, ttl time.Duration, update func(string, bool) (string, error)) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	value, err := update(s.get(key))
+	if err != nil {
+		return err
+	}
+	s.set(key, value, ttl)
+	return nil
+}
+
+func (s *memoryStore) Delete(key string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.entries, key)
+	return nil
+}
+
+// get returns the value of the key unless it expired, the lock being held
+func (s *memoryStore) get(key string) (string, bool) {
+	entry, found := s.entries[key]
+	if !found {
+		return "", false
+	}
+	if !entry.expires.IsZero() && !s.now().Before(entry.expires) {
+		delete(s.entries, key)
+		return "", false
+	}
+	return entry.value, true
+}
+
+// set sets the value of the key and forgets the expired values, the lock being held
+func (s *memoryStore) set(key, value string, ttl time.Duration) {
+	now := s.now()
+	for k, entry := range s.entries {
+		if !entry.expires.IsZero() && !now.Before(entry.expires) {
+			delete(s.entries, k)
+		}
+	}
+	entry := memoryEntry{value: value}
+	if ttl > 0 {
+		entry.expires = now.Add(ttl)
+	}
+	s.entries[key] = entry
+}
@@ -0,0 +1,131 @@
+package store
+
+import (
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/pkg/errors"
+)
+
+const (
+	redisTimeout = 10 * time.Second
+	// redisMaxIdle is the number of idle connections the pool of a store keeps to the Redis server
+	redisMaxIdle = 4
+	// redisIdleTimeout is the duration after which an idle connection is closed
+	redisIdleTimeout = 5 * time.Minute
+)
+
+// redisStore keeps the values in a Redis server, through a pool of connections which drops the connections failing
+// with an error. Updates watch the key so that concurrent updates of the replicas are retried rather than lost.
+type redisStore struct {
+	pool   *redis.Pool
+	prefix string
+}
+
+// NewRedisStore creates a store keeping the values in the Redis server at the address, with the given password if
+// not empty, prefixing the keys
+func NewRedisStore(address, password string, database int, prefix string) Store {
+	return newRedisStore(prefix, func() (redis.Conn, error) {
+		conn, err := redis.Dial("tcp", address,
+			redis.DialPassword(password),
+			redis.DialDatabase(database),
+			redis.DialConnectTimeout(redisTimeout),
+			redis.DialReadTimeout(redisTimeout),
+			redis.DialWriteTimeout(redisTimeout),
+		)
+		return conn, errors.Wrapf(err, "connecting to Redis server %s", address)
+	})
+}
+
+func newRedisStore(prefix string, dial func() (redis.Conn, error)) *redisStore {
+	return &redisStore{
+		pool: &redis.Pool{
+			Dial:        dial,
+			MaxIdle:     redisMaxIdle,
+			IdleTimeout: redisIdleTimeout,
+		},
+		prefix: prefix,
+	}
+}
+
+func (s *redisStore) Get(key string) (string, bool, error) {
+	conn := s.pool.Get()
+	defer conn.Close()
+
+	value, err := redis.String(conn.Do("GET", s.prefix+key))
+	if err == redis.ErrNil {
+		return "", false, nil
+	}
+	return value, err == nil, err
+}
+
+func (s *redisStore) Set(key, value string, ttl time.Duration) error {
+	conn := s.pool.Get()
+	defer conn.Close()
+
+	_, err := conn.Do("SET", setArgs(s.prefix+key, value, ttl)...)
+	return err
+}
+
+func (s *redisStore) SetIfAbsent(key, value string, ttl time.Duration) (bool, error) {
+	conn := s.pool.Get()
+	defer conn.Close()
+
+	reply, err := conn.Do("SET", append(setArgs(s.prefix+key, value, ttl), "NX")...)
+	return reply != nil, err
+}
+
+func (s *redisStore) Update(key string, ttl time.Duration, update func(string, bool) (string, error)) error {
+	conn := s.pool.Get()
+	defer conn.Close()
+
+	key = s.prefix + key
+	for i := 0; i < maxConflictRetries; i++ {
+		if _, err := conn.Do("WATCH", key); err != nil {
+			return err
+		}
+		current, err := redis.String(conn.Do("GET", key))
+		found := err == nil
+		if err != nil && err != redis.ErrNil {
+			return err
+		}
+		value, err := update(current, found)
+		if err != nil {
+			if _, unwatchErr := conn.Do("UNWATCH"); unwatchErr != nil {
+				return errors.Wrapf(unwatchErr, "unwatching Redis key %s after %v", key, err)
+			}
+			return err
+		}
+		if err := conn.Send("MULTI"); err != nil {
+			return err
+		}
+		if err := conn.Send("SET", setArgs(key, value, ttl)...); err != nil {
+			return err
+		}
+		reply, err := conn.Do("EXEC")
+		if err != nil {
+			return err
+		}
+		if reply != nil {
+			return nil
+		}
+		// the key was modified since it was watched
+	}
+	return errors.Errorf("too many conflicts updating Redis key %s", key)
+}
+
+func (s *redisStore) Delete(key string) error {
+	conn := s.pool.Get()
+	defer conn.Close()
+
+	_, err := conn.Do("DEL", s.prefix+key)
+	return err
+}
+
+func setArgs(key, value string, ttl time.Duration) []interface{} {
+	args := []interface{}{key, value}
+	if ttl > 0 {
+		args = append(args, "PX", ttl.Milliseconds())
+	}
+	return args
+}
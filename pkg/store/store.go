@@ -0,0 +1,85 @@
+// Package store provides a key-value store shared by the replicas of a component, so that the state of rate
+// limiters, deduplication caches and the like is consistent whichever replica handles a request.
+package store
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// Memory keeps the state in the memory of each replica
+	Memory = "memory"
+	// ConfigMap keeps the state in a ConfigMap shared by the replicas, which suits small amounts of state
+	ConfigMap = "configmap"
+	// Redis keeps the state in a Redis server shared by the replicas
+	Redis = "redis"
+
+	// DefaultConfigMap is the name of the ConfigMap of the ConfigMap store
+	DefaultConfigMap = "lighthouse-state"
+
+	// RedisPasswordEnv is the environment variable containing the password of the Redis server, if any
+	RedisPasswordEnv = "REDIS_PASSWORD"
+
+	// maxConflictRetries is the number of times an update is retried when the value is modified concurrently
+	maxConflictRetries = 10
+)
+
+// Store is a key-value store whose values can expire
+type Store interface {
+	// Get returns the value of the key, and false if the key is not set or expired
+	Get(key string) (string, bool, error)
+	// Set sets the value of the key, which expires after the TTL unless it is 0
+	Set(key, value string, ttl time.Duration) error
+	// SetIfAbsent sets the value of the key unless it is set already, returning false in that case
+	SetIfAbsent(key, value string, ttl time.Duration) (bool, error)
+	// Update atomically replaces the value of the key with the one returned by the function, given the current
+	// value and whether the key is set, the new value expiring after the TTL unless it is 0. The function may be
+	// called several times if the value is modified concurrently.
+	Update(key string, ttl time.Duration, update func(value string, found bool) (string, error)) error
+	// Delete removes the key
+	Delete(key string) error
+}
+
+// Options configure the store of a component
+type Options struct {
+	Kind          string
+	ConfigMap     string
+	RedisAddress  string
+	RedisDatabase int
+	RedisPrefix   string
+}
+
+// AddFlags adds the flags configuring the store to the command
+func (o *Options) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&o.Kind, "state-store", Memory, "Where the state shared by the replicas, such as rate limits and deduplicated deliveries, is kept: memory for a single replica, configmap for small amounts of state or redis.")
+	cmd.Flags().StringVar(&o.ConfigMap, "state-configmap", DefaultConfigMap, "The ConfigMap the state is kept in with --state-store=configmap.")
+	cmd.Flags().StringVar(&o.RedisAddress, "redis-address", "", "The host:port of the Redis server the state is kept in with --state-store=redis. Its password is read from $"+RedisPasswordEnv+".")
+	cmd.Flags().IntVar(&o.RedisDatabase, "redis-database", 0, "The Redis database the state is kept in.")
+	cmd.Flags().StringVar(&o.RedisPrefix, "redis-prefix", "lighthouse:", "The prefix of the Redis keys, so that several installations can share a Redis server.")
+}
+
+// New creates the store configured by the options
+func (o *Options) New(kubeClient kubernetes.Interface, namespace string) (Store, error) {
+	switch o.Kind {
+	case "", Memory:
+		return NewMemoryStore(), nil
+	case ConfigMap:
+		name := o.ConfigMap
+		if name == "" {
+			name = DefaultConfigMap
+		}
+		return NewConfigMapStore(kubeClient, namespace, name), nil
+	case Redis:
+		if o.RedisAddress == "" {
+			return nil, fmt.Errorf("no Redis server address given")
+		}
+		return NewRedisStore(o.RedisAddress, os.Getenv(RedisPasswordEnv), o.RedisDatabase, o.RedisPrefix), nil
+	default:
+		return nil, fmt.Errorf("unknown state store %q, expected one of %s, %s or %s", o.Kind, Memory, ConfigMap, Redis)
+	}
+}
@@ -0,0 +1,280 @@
+package store
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+// testStore checks the behaviour shared by the stores
+func testStore(t *testing.T, s Store) {
+	_, found, err := s.Get("missing")
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	require.NoError(t, s.Set("key", "value", 0))
+	value, found, err := s.Get("key")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "value", value)
+
+	set, err := s.SetIfAbsent("key", "other", time.Hour)
+	require.NoError(t, err)
+	assert.False(t, set)
+	set, err = s.SetIfAbsent("org/repo#1", "other", time.Hour)
+	require.NoError(t, err)
+	assert.True(t, set)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, s.Update("counter", time.Hour, func(value string, found bool) (string, error) {
+			assert.Equal(t, i > 0, found)
+			return value + "x", nil
+		}))
+	}
+	value, _, err = s.Get("counter")
+	require.NoError(t, err)
+	assert.Equal(t, "xxx", value)
+	assert.Error(t, s.Update("counter", time.Hour, func(string, bool) (string, error) {
+		return "", fmt.Errorf("failed")
+	}))
+	value, _, err = s.Get("counter")
+	require.NoError(t, err)
+	assert.Equal(t, "xxx", value, "a failed update should leave the value")
+
+	require.NoError(t, s.Delete("key"))
+	_, found, err = s.Get("key")
+	require.NoError(t, err)
+	assert.False(t, found)
+}
+
+func TestMemoryStore(t *testing.T) {
+	s := newMemoryStore()
+	testStore(t, s)
+
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+	require.NoError(t, s.Set("expiring", "value", time.Hour))
+	now = now.Add(time.Hour)
+	_, found, _ := s.Get("expiring")
+	assert.False(t, found, "the value should expire")
+}
+
+func TestConfigMapStore(t *testing.T) {
+	kubeClient := kubefake.NewSimpleClientset()
+	s := newConfigMapStore(kubeClient, "jx", DefaultConfigMap)
+	testStore(t, s)
+
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+	require.NoError(t, s.Set("expiring", "value", time.Hour))
+	cm, err := kubeClient.CoreV1().ConfigMaps("jx").Get(DefaultConfigMap, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, `{"value":"value","expires":"2020-01-01T01:00:00Z"}`, cm.Data["expiring"])
+	assert.Contains(t, cm.Data, "org_repo_1", "the invalid characters of the keys should be replaced")
+
+	now = now.Add(time.Hour)
+	_, found, _ := s.Get("expiring")
+	assert.False(t, found, "the value should expire")
+	require.NoError(t, s.Set("other", "value", 0))
+	cm, err = kubeClient.CoreV1().ConfigMaps("jx").Get(DefaultConfigMap, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.NotContains(t, cm.Data, "expiring", "the expired values should be pruned")
+}
+
+// fakeRedis serves the commands used by the store over in-memory connections
+type fakeRedis struct {
+	lock    sync.Mutex
+	values  map[string]string
+	ttls    map[string]string
+	version map[string]int
+	// beforeExec is called before executing a transaction, to simulate concurrent modifications
+	beforeExec func(*fakeRedis)
+	// drop closes the connection of the next command
+	drop bool
+}
+
+func (f *fakeRedis) dial(network, address string) (net.Conn, error) {
+	client, server := net.Pipe()
+	go f.serve(server)
+	return client, nil
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	watched := map[string]int{}
+	var queued [][]string
+	inMulti := false
+	for {
+		args, err := readRequest(r)
+		if err != nil {
+			return
+		}
+		f.lock.Lock()
+		drop := f.drop
+		f.drop = false
+		f.lock.Unlock()
+		if drop {
+			return
+		}
+		command := strings.ToUpper(args[0])
+		var reply string
+		switch {
+		case inMulti && command != "EXEC" && command != "DISCARD":
+			queued = append(queued, args)
+			reply = "+QUEUED\r\n"
+		case command == "AUTH" || command == "UNWATCH":
+			watched = map[string]int{}
+			reply = "+OK\r\n"
+		case command == "WATCH":
+			f.lock.Lock()
+			watched[args[1]] = f.version[args[1]]
+			f.lock.Unlock()
+			reply = "+OK\r\n"
+		case command == "MULTI":
+			inMulti = true
+			reply = "+OK\r\n"
+		case command == "DISCARD":
+			inMulti, queued, watched = false, nil, map[string]int{}
+			reply = "+OK\r\n"
+		case command == "EXEC":
+			if f.beforeExec != nil {
+				f.beforeExec(f)
+			}
+			f.lock.Lock()
+			conflict := false
+			for k, v := range watched {
+				conflict = conflict || f.version[k] != v
+			}
+			if conflict {
+				reply = "*-1\r\n"
+			} else {
+				reply = fmt.Sprintf("*%d\r\n", len(queued))
+				for _, q := range queued {
+					reply += f.execute(q)
+				}
+			}
+			f.lock.Unlock()
+			inMulti, queued, watched = false, nil, map[string]int{}
+		default:
+			f.lock.Lock()
+			reply = f.execute(args)
+			f.lock.Unlock()
+		}
+		if _, err := conn.Write([]byte(reply)); err != nil {
+			return
+		}
+	}
+}
+
+// readRequest reads a command sent with the Redis protocol, as an array of bulk strings
+func readRequest(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil {
+		return nil, err
+	}
+	var args []string
+	for i := 0; i < count; i++ {
+		if _, err := r.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		arg, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, strings.TrimSuffix(arg, "\r\n"))
+	}
+	return args, nil
+}
+
+func (f *fakeRedis) execute(args []string) string {
+	switch strings.ToUpper(args[0]) {
+	case "GET":
+		if value, ok := f.values[args[1]]; ok {
+			return fmt.Sprintf("$%d\r\n%s\r\n", len(value), value)
+		}
+		return "$-1\r\n"
+	case "SET":
+		options := strings.Join(args[3:], " ")
+		if _, ok := f.values[args[1]]; ok && strings.Contains(options, "NX") {
+			return "$-1\r\n"
+		}
+		f.values[args[1]] = args[2]
+		f.ttls[args[1]] = options
+		f.version[args[1]]++
+		return "+OK\r\n"
+	case "DEL":
+		delete(f.values, args[1])
+		f.version[args[1]]++
+		return ":1\r\n"
+	}
+	return "-ERR unknown command\r\n"
+}
+
+func TestRedisStore(t *testing.T) {
+	f := &fakeRedis{values: map[string]string{}, ttls: map[string]string{}, version: map[string]int{}}
+	s := newRedisStore("lighthouse:", func() (redis.Conn, error) {
+		return redis.Dial("tcp", "redis:6379", redis.DialNetDial(f.dial), redis.DialPassword("secret"))
+	})
+	testStore(t, s)
+	assert.Equal(t, "xxx", f.values["lighthouse:counter"], "the keys should be prefixed")
+	assert.Equal(t, "PX 3600000", f.ttls["lighthouse:counter"])
+
+	// a concurrent modification makes the update retry
+	modified := false
+	f.beforeExec = func(f *fakeRedis) {
+		if !modified {
+			modified = true
+			f.lock.Lock()
+			f.values["lighthouse:counter"] = "concurrent"
+			f.version["lighthouse:counter"]++
+			f.lock.Unlock()
+		}
+	}
+	require.NoError(t, s.Update("counter", 0, func(value string, found bool) (string, error) {
+		return value + "!", nil
+	}))
+	assert.Equal(t, "concurrent!", f.values["lighthouse:counter"])
+
+	// the connection is dialed again after an error
+	f.lock.Lock()
+	f.drop = true
+	f.lock.Unlock()
+	value, found, err := s.Get("counter")
+	assert.Error(t, err)
+	assert.False(t, found)
+	value, found, err = s.Get("counter")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "concurrent!", value)
+}
+
+func TestNewStore(t *testing.T) {
+	o := &Options{}
+	s, err := o.New(nil, "jx")
+	require.NoError(t, err)
+	assert.IsType(t, &memoryStore{}, s)
+
+	o.Kind = Redis
+	_, err = o.New(nil, "jx")
+	assert.Error(t, err, "the address of the Redis server is required")
+
+	o.Kind = "etcd"
+	_, err = o.New(nil, "jx")
+	assert.Error(t, err)
+}
@@ -0,0 +1,264 @@
+// Package tenants lets a lighthouse installation serve several teams in isolation. Each tenant owns some orgs, or
+// repositories, whose jobs run in the namespace of the tenant, whose webhooks must be delivered by the SCM
+// provider of the tenant with its credentials, whose notifications can only be sent to the sinks of the tenant
+// and whose LighthouseConfigs are only accepted from the namespace of the tenant. The tenants are read from the
+// tenants section of config.yaml:
+//
+//	tenants:
+//	- name: team-a
+//	  namespace: team-a
+//	  orgs:
+//	  - team-a
+//	  - shared/frontend
+//	  provider: ghe
+//	  notification_sinks:
+//	  - team-a-teams
+package tenants
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/yaml"
+)
+
+// Tenant is a team served by lighthouse in isolation from the other teams
+type Tenant struct {
+	// Name is the name of the tenant, which labels its jobs
+	Name string `json:"name"`
+	// Namespace is the namespace the jobs of the tenant run in, and the only one its LighthouseConfigs are accepted
+	// from, so that the teams only need access to their own namespace
+	Namespace string `json:"namespace"`
+	// Orgs are the orgs, or org/repo repositories, of the tenant
+	Orgs []string `json:"orgs"`
+	// Provider is the name of the SCM provider listed in $LIGHTHOUSE_PROVIDERS whose webhooks and credentials the
+	// repositories of the tenant use, the default provider if empty
+	Provider string `json:"provider,omitempty"`
+	// NotificationSinks are the names of the notification webhooks reserved to the events of the tenant
+	NotificationSinks []string `json:"notification_sinks,omitempty"`
+}
+
+// Config holds the tenants
+type Config struct {
+	Tenants []Tenant `json:"tenants,omitempty"`
+}
+
+// LoadConfig reads the Config from the tenants section of the text of config.yaml
+func LoadConfig(data []byte) (*Config, error) {
+	answer := &Config{}
+	if err := yaml.Unmarshal(data, answer); err != nil {
+		return nil, errors.Wrap(err, "parsing the tenants")
+	}
+	if err := answer.validate(); err != nil {
+		return nil, err
+	}
+	return answer, nil
+}
+
+func (c *Config) validate() error {
+	names := map[string]bool{}
+	owners := map[string]string{}
+	sinks := map[string]string{}
+	for i := range c.Tenants {
+		t := &c.Tenants[i]
+		if t.Name == "" {
+			return errors.Errorf("tenant %d has no name", i)
+		}
+		if names[t.Name] {
+			return errors.Errorf("tenant %s is defined twice", t.Name)
+		}
+		names[t.Name] = true
+		if errs := validation.IsDNS1123Label(t.Namespace); len(errs) > 0 {
+			return errors.Errorf("tenant %s has an invalid namespace %q: %s", t.Name, t.Namespace, strings.Join(errs, ", "))
+		}
+		if len(t.Orgs) == 0 {
+			return errors.Errorf("tenant %s has no orgs", t.Name)
+		}
+		for _, org := range t.Orgs {
+			org = strings.ToLower(org)
+			for other, owner := range owners {
+				if overlaps(org, other) {
+					return errors.Errorf("%s of tenant %s is already owned by tenant %s", org, t.Name, owner)
+				}
+			}
+		}
+		for _, org := range t.Orgs {
+			owners[strings.ToLower(org)] = t.Name
+		}
+		for _, sink := range t.NotificationSinks {
+			if owner, ok := sinks[sink]; ok {
+				return errors.Errorf("notification sink %s of tenant %s is already reserved to tenant %s", sink, t.Name, owner)
+			}
+			sinks[sink] = t.Name
+		}
+	}
+	return nil
+}
+
+// overlaps returns true if the orgs or repositories are the same or one is a repository of the other
+func overlaps(a, b string) bool {
+	return a == b || strings.HasPrefix(a, b+"/") || strings.HasPrefix(b, a+"/")
+}
+
+// Overlaps returns true if the org or repository, or some of its repositories, belong to the tenant
+func (t *Tenant) Overlaps(repo string) bool {
+	repo = strings.ToLower(repo)
+	for _, org := range t.Orgs {
+		if overlaps(repo, strings.ToLower(org)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Owns returns true if the repository, given as org/repo, or the org belongs to the tenant
+func (t *Tenant) Owns(repo string) bool {
+	repo = strings.ToLower(repo)
+	for _, org := range t.Orgs {
+		org = strings.ToLower(org)
+		if repo == org || strings.HasPrefix(repo, org+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// ForRepo returns the tenant owning the repository of the org, or nil
+func (c *Config) ForRepo(org, repo string) *Tenant {
+	if c == nil {
+		return nil
+	}
+	for i := range c.Tenants {
+		if c.Tenants[i].Owns(org + "/" + repo) {
+			return &c.Tenants[i]
+		}
+	}
+	return nil
+}
+
+// ForOrg returns the tenant owning some repositories of the org, or nil
+func (c *Config) ForOrg(org string) *Tenant {
+	if c == nil {
+		return nil
+	}
+	for i := range c.Tenants {
+		for _, owned := range c.Tenants[i].Orgs {
+			if strings.EqualFold(strings.SplitN(owned, "/", 2)[0], org) {
+				return &c.Tenants[i]
+			}
+		}
+	}
+	return nil
+}
+
+// ForNamespace returns the tenant of the namespace, or nil
+func (c *Config) ForNamespace(namespace string) *Tenant {
+	if c == nil {
+		return nil
+	}
+	for i := range c.Tenants {
+		if c.Tenants[i].Namespace == namespace {
+			return &c.Tenants[i]
+		}
+	}
+	return nil
+}
+
+// ForSink returns the tenant the notification sink is reserved to, or nil
+func (c *Config) ForSink(sink string) *Tenant {
+	if c == nil {
+		return nil
+	}
+	for i := range c.Tenants {
+		for _, name := range c.Tenants[i].NotificationSinks {
+			if name == sink {
+				return &c.Tenants[i]
+			}
+		}
+	}
+	return nil
+}
+
+// Assign labels the job of a repository of a tenant with the tenant and moves it, and its pod, to the namespace of
+// the tenant, returning the tenant or nil if the repository has none
+func (c *Config) Assign(job *v1alpha1.LighthouseJob) *Tenant {
+	if c == nil || len(c.Tenants) == 0 || job.Spec.Refs == nil {
+		return nil
+	}
+	t := c.ForRepo(job.Spec.Refs.Org, job.Spec.Refs.Repo)
+	if t == nil {
+		return nil
+	}
+	if job.Labels == nil {
+		job.Labels = map[string]string{}
+	}
+	job.Labels[util.TenantLabel] = t.Name
+	job.Namespace = t.Namespace
+	job.Spec.Namespace = t.Namespace
+	return t
+}
+
+// Agent holds the current Config
+type Agent struct {
+	lock   sync.RWMutex
+	config *Config
+}
+
+// Set replaces the current Config
+func (a *Agent) Set(config *Config) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.config = config
+}
+
+// Config returns the current Config, which is empty until one is set
+func (a *Agent) Config() *Config {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+	if a.config == nil {
+		return &Config{}
+	}
+	return a.config
+}
+
+// FileConfig returns the Config of the config file, reloaded whenever the file changes. The previous
+// Config is kept if the file cannot be loaded.
+func FileConfig(fileName string) func() *Config {
+	var lock sync.Mutex
+	var modTime time.Time
+	config := &Config{}
+	return func() *Config {
+		lock.Lock()
+		defer lock.Unlock()
+
+		info, err := os.Stat(fileName)
+		if err != nil {
+			logrus.WithError(err).Warnf("failed to find config file %s", fileName)
+			return config
+		}
+		if info.ModTime().Equal(modTime) {
+			return config
+		}
+		data, err := ioutil.ReadFile(fileName) // #nosec
+		if err == nil {
+			var loaded *Config
+			loaded, err = LoadConfig(data)
+			if err == nil {
+				config = loaded
+				modTime = info.ModTime()
+			}
+		}
+		if err != nil {
+			logrus.WithError(err).Warnf("failed to load the tenants of config file %s", fileName)
+		}
+		return config
+	}
+}
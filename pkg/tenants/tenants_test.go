@@ -0,0 +1,125 @@
+package tenants
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const configYAML = `
+keeper:
+  sync_period: 1m
+tenants:
+- name: team-a
+  namespace: team-a
+  orgs:
+  - team-a
+  - shared/frontend
+  provider: ghe
+  notification_sinks:
+  - team-a-teams
+- name: team-b
+  namespace: team-b
+  orgs:
+  - Team-B
+  - shared/backend
+`
+
+func TestLoadConfig(t *testing.T) {
+	cfg, err := LoadConfig([]byte(configYAML))
+	require.NoError(t, err)
+	require.Len(t, cfg.Tenants, 2)
+	assert.Equal(t, "ghe", cfg.Tenants[0].Provider)
+	assert.Equal(t, []string{"team-a-teams"}, cfg.Tenants[0].NotificationSinks)
+
+	cfg, err = LoadConfig([]byte("keeper: {}"))
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Tenants)
+
+	invalid := map[string]string{
+		"no name":           "- namespace: a\n  orgs: [a]\n",
+		"duplicate names":   "- name: a\n  namespace: a\n  orgs: [a]\n- name: a\n  namespace: b\n  orgs: [b]\n",
+		"no namespace":      "- name: a\n  orgs: [a]\n",
+		"invalid namespace": "- name: a\n  namespace: Team_A\n  orgs: [a]\n",
+		"no orgs":           "- name: a\n  namespace: a\n",
+		"same org":          "- name: a\n  namespace: a\n  orgs: [org]\n- name: b\n  namespace: b\n  orgs: [ORG]\n",
+		"repo of an org":    "- name: a\n  namespace: a\n  orgs: [org/repo]\n- name: b\n  namespace: b\n  orgs: [org]\n",
+		"shared sink":       "- name: a\n  namespace: a\n  orgs: [a]\n  notification_sinks: [s]\n- name: b\n  namespace: b\n  orgs: [b]\n  notification_sinks: [s]\n",
+	}
+	for name, text := range invalid {
+		_, err = LoadConfig([]byte("tenants:\n" + text))
+		assert.Error(t, err, name)
+	}
+}
+
+func TestLookups(t *testing.T) {
+	cfg, err := LoadConfig([]byte(configYAML))
+	require.NoError(t, err)
+
+	assert.Equal(t, "team-a", cfg.ForRepo("team-a", "app").Name)
+	assert.Equal(t, "team-a", cfg.ForRepo("shared", "Frontend").Name)
+	assert.Equal(t, "team-b", cfg.ForRepo("team-b", "app").Name)
+	assert.Equal(t, "team-b", cfg.ForRepo("shared", "backend").Name)
+	assert.Nil(t, cfg.ForRepo("shared", "docs"))
+	assert.Nil(t, cfg.ForRepo("team-ab", "app"))
+
+	assert.Equal(t, "team-a", cfg.ForOrg("shared").Name)
+	assert.Nil(t, cfg.ForOrg("other"))
+	assert.Equal(t, "team-b", cfg.ForNamespace("team-b").Name)
+	assert.Nil(t, cfg.ForNamespace("jx"))
+	assert.Equal(t, "team-a", cfg.ForSink("team-a-teams").Name)
+	assert.Nil(t, cfg.ForSink("slack"))
+
+	var none *Config
+	assert.Nil(t, none.ForRepo("team-a", "app"))
+}
+
+func TestAssign(t *testing.T) {
+	cfg, err := LoadConfig([]byte(configYAML))
+	require.NoError(t, err)
+
+	job := &v1alpha1.LighthouseJob{}
+	job.Namespace = "jx"
+	job.Spec.Refs = &v1alpha1.Refs{Org: "shared", Repo: "backend"}
+	tenant := cfg.Assign(job)
+	require.NotNil(t, tenant)
+	assert.Equal(t, "team-b", job.Namespace)
+	assert.Equal(t, "team-b", job.Spec.Namespace)
+	assert.Equal(t, "team-b", job.Labels[util.TenantLabel])
+
+	job = &v1alpha1.LighthouseJob{}
+	job.Namespace = "jx"
+	job.Spec.Refs = &v1alpha1.Refs{Org: "other", Repo: "app"}
+	assert.Nil(t, cfg.Assign(job))
+	assert.Equal(t, "jx", job.Namespace)
+	assert.Empty(t, job.Labels)
+}
+
+func TestFileConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tenants")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	fileName := filepath.Join(dir, "config.yaml")
+	require.NoError(t, ioutil.WriteFile(fileName, []byte(configYAML), 0600))
+
+	config := FileConfig(fileName)
+	require.Len(t, config().Tenants, 2)
+
+	// an invalid config keeps the previous one
+	require.NoError(t, ioutil.WriteFile(fileName, []byte("tenants:\n- name: a\n"), 0600))
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(fileName, later, later))
+	assert.Len(t, config().Tenants, 2)
+
+	require.NoError(t, ioutil.WriteFile(fileName, []byte("tenants: []\n"), 0600))
+	later = later.Add(time.Minute)
+	require.NoError(t, os.Chtimes(fileName, later, later))
+	assert.Empty(t, config().Tenants)
+}
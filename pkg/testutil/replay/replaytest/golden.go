@@ -0,0 +1,30 @@
+package replaytest
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider/fake/harness"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+)
+
+// UpdateGoldenEnvVar is the environment variable which, set to true, makes the tests write the golden files
+// instead of asserting them
+const UpdateGoldenEnvVar = "UPDATE_GOLDEN"
+
+// AssertGolden asserts that what the plugins did matches the YAML golden file, or writes it when $UPDATE_GOLDEN is
+// true
+func AssertGolden(t testing.TB, path string, actions harness.Actions) {
+	actual, err := yaml.Marshal(actions)
+	require.NoError(t, err, "marshalling the actions")
+	if os.Getenv(UpdateGoldenEnvVar) == "true" {
+		require.NoError(t, ioutil.WriteFile(path, actual, 0644), "writing golden file %s", path)
+		return
+	}
+	expected, err := ioutil.ReadFile(path)
+	require.NoError(t, err, "reading golden file %s, which is written by running the test with %s=true", path, UpdateGoldenEnvVar)
+	assert.Equal(t, string(expected), string(actual), "the actions do not match golden file %s", path)
+}
@@ -0,0 +1,58 @@
+// Package replaytest replays the webhook deliveries recorded by the record package against the plugins, with the
+// fake SCM provider and launcher of the plugin harness, and asserts what the plugins did against golden files.
+package replaytest
+
+import (
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider/fake"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider/fake/harness"
+	"github.com/jenkins-x/lighthouse/pkg/webhook/record"
+	"github.com/sirupsen/logrus"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+// Router routes the webhooks to the plugins handling them, such as the options of the webhook server
+type Router interface {
+	// ProcessWebHook dispatches the webhook to the plugins
+	ProcessWebHook(l *logrus.Entry, webhook scm.Webhook) (*logrus.Entry, string, error)
+	// Wait waits for the plugins to handle the webhooks dispatched so far
+	Wait()
+}
+
+// Harness replays deliveries against a router whose plugins use the fake SCM provider and launcher of the plugin
+// harness, whose Actions are what the plugins did. Its Data can be seeded with the collaborators, pull requests
+// and changes the plugins look up before replaying deliveries.
+type Harness struct {
+	*harness.Harness
+}
+
+// NewHarness creates a harness with an empty fake SCM provider
+func NewHarness() *Harness {
+	return &Harness{Harness: harness.New()}
+}
+
+// ClientAgent returns the clients of the plugins, which the router has to use. It has no git client, so the
+// plugins reading the OWNERS files of the repositories fail, which is isolated from the other plugins.
+func (h *Harness) ClientAgent() *plugins.ClientAgent {
+	return &plugins.ClientAgent{
+		BotName:           fake.Bot,
+		SCMProviderClient: h.Client(),
+		ChangesCache:      scmprovider.NewChangesCache(),
+		KubernetesClient:  kubefake.NewSimpleClientset(),
+		LauncherClient:    h.Launcher,
+		LighthouseClient:  h.Jobs,
+	}
+}
+
+// Replay parses the delivery and dispatches it to the router, returning once its plugins handled it
+func (h *Harness) Replay(router Router, d *record.Delivery) error {
+	webhook, err := d.Parse()
+	if err != nil {
+		return err
+	}
+	_, _, err = router.ProcessWebHook(h.Logger.WithField("replay", webhook.Kind()), webhook)
+	router.Wait()
+	return err
+}
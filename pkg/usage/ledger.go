@@ -0,0 +1,157 @@
+package usage
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jenkins-x/lighthouse/pkg/store"
+	"github.com/pkg/errors"
+)
+
+const (
+	// MonthFormat is the layout of the months of the reports, e.g. 2020-06
+	MonthFormat = "2006-01"
+
+	// retention is how long the monthly reports are kept, so that the reports of the last year can be exported
+	retention = 400 * 24 * time.Hour
+)
+
+// Entry is the usage of the runs of a job of a repository during a month
+type Entry struct {
+	Org  string `json:"org"`
+	Repo string `json:"repo"`
+	Job  string `json:"job"`
+	Runs int    `json:"runs"`
+	Usage
+}
+
+// Report is the usage of the jobs during a month
+type Report struct {
+	Month   string   `json:"month"`
+	Entries []*Entry `json:"entries"`
+}
+
+// Ledger keeps the monthly usage reports in a store
+type Ledger struct {
+	store store.Store
+}
+
+// NewLedger creates a Ledger keeping the reports in the store
+func NewLedger(s store.Store) *Ledger {
+	return &Ledger{store: s}
+}
+
+// key returns the key of the report of the month in the store
+func key(month string) string {
+	return "usage-" + month
+}
+
+// Record adds the usage of a run of the job of the repository to the report of the month of the time
+func (l *Ledger) Record(org, repo, job string, u Usage, at time.Time) error {
+	month := at.UTC().Format(MonthFormat)
+	return l.store.Update(key(month), retention, func(value string, found bool) (string, error) {
+		report := &Report{Month: month}
+		if found {
+			if err := json.Unmarshal([]byte(value), report); err != nil {
+				return "", errors.Wrapf(err, "parsing the usage report of %s", month)
+			}
+		}
+		report.add(org, repo, job, u)
+		data, err := json.Marshal(report)
+		return string(data), err
+	})
+}
+
+// Report returns the usage report of the month, formatted as MonthFormat, which is empty if nothing was recorded
+func (l *Ledger) Report(month string) (*Report, error) {
+	if _, err := time.Parse(MonthFormat, month); err != nil {
+		return nil, errors.Errorf("invalid month %q, expected a month such as 2020-06", month)
+	}
+	report := &Report{Month: month}
+	value, found, err := l.store.Get(key(month))
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading the usage report of %s", month)
+	}
+	if found {
+		if err := json.Unmarshal([]byte(value), report); err != nil {
+			return nil, errors.Wrapf(err, "parsing the usage report of %s", month)
+		}
+	}
+	return report, nil
+}
+
+func (r *Report) add(org, repo, job string, u Usage) {
+	for _, e := range r.Entries {
+		if e.Org == org && e.Repo == repo && e.Job == job {
+			e.Runs++
+			e.Add(u)
+			return
+		}
+	}
+	r.Entries = append(r.Entries, &Entry{Org: org, Repo: repo, Job: job, Runs: 1, Usage: u})
+	sort.Slice(r.Entries, func(i, j int) bool {
+		a, b := r.Entries[i], r.Entries[j]
+		if a.Org != b.Org {
+			return a.Org < b.Org
+		}
+		if a.Repo != b.Repo {
+			return a.Repo < b.Repo
+		}
+		return a.Job < b.Job
+	})
+}
+
+// WriteCSV writes the report as CSV with a header row
+func (r *Report) WriteCSV(w io.Writer) error {
+	out := csv.NewWriter(w)
+	rows := [][]string{{"month", "org", "repo", "job", "runs", "cpu_core_seconds", "memory_byte_seconds"}}
+	for _, e := range r.Entries {
+		rows = append(rows, []string{
+			r.Month,
+			e.Org,
+			e.Repo,
+			e.Job,
+			strconv.Itoa(e.Runs),
+			strconv.FormatFloat(e.CPUCoreSeconds, 'f', 3, 64),
+			strconv.FormatFloat(e.MemoryByteSeconds, 'f', 0, 64),
+		})
+	}
+	return out.WriteAll(rows)
+}
+
+// Handler serves the usage report of the month of the month parameter, or of the current month, as JSON or as CSV
+// when the format parameter is csv
+func (l *Ledger) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		month := r.URL.Query().Get("month")
+		if month == "" {
+			month = time.Now().UTC().Format(MonthFormat)
+		}
+		report, err := l.Report(month)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		switch format := r.URL.Query().Get("format"); format {
+		case "", "json":
+			w.Header().Set("Content-Type", "application/json")
+			err = json.NewEncoder(w).Encode(report)
+		case "csv":
+			w.Header().Set("Content-Type", "text/csv")
+			w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=usage-%s.csv", month))
+			err = report.WriteCSV(w)
+		default:
+			http.Error(w, fmt.Sprintf("unknown format %q, expected json or csv", format), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
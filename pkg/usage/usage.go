@@ -0,0 +1,179 @@
+// Package usage accounts for the resources used by the jobs, as the CPU and memory requested by their pods multiplied
+// by how long the pods ran, so that platform teams can charge the cost of CI back to the orgs and repositories.
+package usage
+
+import (
+	"time"
+
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/gc"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+var (
+	cpuCoreSeconds = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "lighthouse_job_cpu_core_seconds_total",
+		Help: "The CPU cores requested by the pods of the completed jobs multiplied by the seconds they ran, by org, repository and job.",
+	}, []string{"org", "repo", "job"})
+	memoryByteSeconds = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "lighthouse_job_memory_byte_seconds_total",
+		Help: "The memory bytes requested by the pods of the completed jobs multiplied by the seconds they ran, by org, repository and job.",
+	}, []string{"org", "repo", "job"})
+)
+
+func init() {
+	prometheus.MustRegister(cpuCoreSeconds, memoryByteSeconds)
+}
+
+// Usage is the amount of resources used by pods
+type Usage struct {
+	// CPUCoreSeconds are the requested CPU cores multiplied by the seconds the pods ran
+	CPUCoreSeconds float64 `json:"cpuCoreSeconds"`
+	// MemoryByteSeconds are the requested memory bytes multiplied by the seconds the pods ran
+	MemoryByteSeconds float64 `json:"memoryByteSeconds"`
+}
+
+// Add adds the other usage to the usage
+func (u *Usage) Add(other Usage) {
+	u.CPUCoreSeconds += other.CPUCoreSeconds
+	u.MemoryByteSeconds += other.MemoryByteSeconds
+}
+
+// PodUsage returns the resources used by the pod, which is considered running until the end when its containers
+// have not all terminated. The requests of a pod are those of its containers, or of its largest init container
+// if larger, as the scheduler reserves them.
+func PodUsage(pod *corev1.Pod, end time.Time) Usage {
+	if pod.Status.StartTime == nil {
+		return Usage{}
+	}
+	finished := time.Time{}
+	for _, s := range append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...) {
+		if s.State.Terminated == nil {
+			finished = time.Time{}
+			break
+		}
+		if t := s.State.Terminated.FinishedAt.Time; t.After(finished) {
+			finished = t
+		}
+	}
+	if !finished.IsZero() {
+		end = finished
+	}
+	seconds := end.Sub(pod.Status.StartTime.Time).Seconds()
+	if seconds <= 0 {
+		return Usage{}
+	}
+	cpu, memory := podRequests(&pod.Spec)
+	return Usage{CPUCoreSeconds: cpu * seconds, MemoryByteSeconds: memory * seconds}
+}
+
+// podRequests returns the CPU cores and memory bytes requested by the pod
+func podRequests(spec *corev1.PodSpec) (float64, float64) {
+	var cpu, memory float64
+	for i := range spec.Containers {
+		requests := spec.Containers[i].Resources.Requests
+		cpu += float64(requests.Cpu().MilliValue()) / 1000
+		memory += float64(requests.Memory().Value())
+	}
+	for i := range spec.InitContainers {
+		requests := spec.InitContainers[i].Resources.Requests
+		if c := float64(requests.Cpu().MilliValue()) / 1000; c > cpu {
+			cpu = c
+		}
+		if m := float64(requests.Memory().Value()); m > memory {
+			memory = m
+		}
+	}
+	return cpu, memory
+}
+
+// Accountant records the usage of the jobs as they complete
+type Accountant struct {
+	kubeClient kubernetes.Interface
+	ledger     *Ledger
+	logger     *logrus.Entry
+}
+
+// NewAccountant creates an Accountant finding the pods of the jobs with the client, which adds the usage of the
+// jobs to the monthly reports of the ledger unless it is nil
+func NewAccountant(kubeClient kubernetes.Interface, ledger *Ledger, logger *logrus.Entry) *Accountant {
+	if logger == nil {
+		logger = logrus.NewEntry(logrus.StandardLogger())
+	}
+	return &Accountant{kubeClient: kubeClient, ledger: ledger, logger: logger.WithField("component", "usage")}
+}
+
+// JobChanged records the usage of the job when it completes
+func (a *Accountant) JobChanged(old, job *v1alpha1.LighthouseJob) {
+	if a == nil || old == nil || job == nil || old.Status.CompletionTime != nil || job.Status.CompletionTime == nil {
+		return
+	}
+	if err := a.Record(job); err != nil {
+		a.logger.WithError(err).WithField("job", job.Name).Warn("failed to record the usage of the job")
+	}
+}
+
+// Record records the usage of the pods of the completed job
+func (a *Accountant) Record(job *v1alpha1.LighthouseJob) error {
+	pods, err := a.pods(job)
+	if err != nil {
+		return err
+	}
+	if len(pods) == 0 {
+		return nil
+	}
+	end := time.Now()
+	if job.Status.CompletionTime != nil {
+		end = job.Status.CompletionTime.Time
+	}
+	total := Usage{}
+	for i := range pods {
+		total.Add(PodUsage(&pods[i], end))
+	}
+	org, repo, name, _ := gc.BuildCoordinates(job)
+	cpuCoreSeconds.WithLabelValues(org, repo, name).Add(total.CPUCoreSeconds)
+	memoryByteSeconds.WithLabelValues(org, repo, name).Add(total.MemoryByteSeconds)
+	if a.ledger == nil {
+		return nil
+	}
+	return a.ledger.Record(org, repo, name, total, end)
+}
+
+// pods returns the pods of the job, which is the pod of a job using the kubernetes agent or the pods of the
+// PipelineRun of a job run by tekton
+func (a *Accountant) pods(job *v1alpha1.LighthouseJob) ([]corev1.Pod, error) {
+	switch job.Spec.Agent {
+	case v1alpha1.KubernetesAgent:
+		namespace := job.Spec.Namespace
+		if namespace == "" {
+			namespace = job.Namespace
+		}
+		pod, err := a.kubeClient.CoreV1().Pods(namespace).Get(job.Name, metav1.GetOptions{})
+		if kubeerrors.IsNotFound(err) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "getting the pod of LighthouseJob %s", job.Name)
+		}
+		return []corev1.Pod{*pod}, nil
+	case "", v1alpha1.TektonAgent:
+		selector := gc.PipelineRunSelector(job)
+		if selector == "" {
+			return nil, nil
+		}
+		// tekton labels the pods of a PipelineRun with the labels of the PipelineRun
+		pods, err := a.kubeClient.CoreV1().Pods(job.Namespace).List(metav1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			return nil, errors.Wrapf(err, "listing the pods of LighthouseJob %s", job.Name)
+		}
+		return pods.Items, nil
+	}
+	// the jobs of the other agents do not run in pods of the cluster
+	return nil, nil
+}
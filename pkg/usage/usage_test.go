@@ -0,0 +1,141 @@
+package usage
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/store"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+var start = time.Date(2020, 6, 15, 12, 0, 0, 0, time.UTC)
+
+func container(cpu, memory string) corev1.Container {
+	return corev1.Container{Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse(cpu),
+		corev1.ResourceMemory: resource.MustParse(memory),
+	}}}
+}
+
+func terminated(at time.Time) corev1.ContainerStatus {
+	return corev1.ContainerStatus{State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{FinishedAt: metav1.NewTime(at)}}}
+}
+
+func makePod(namespace, name string, labels map[string]string, runFor time.Duration) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: labels},
+		Spec: corev1.PodSpec{
+			InitContainers: []corev1.Container{container("100m", "64Mi")},
+			Containers:     []corev1.Container{container("500m", "1Gi"), container("500m", "1Gi")},
+		},
+		Status: corev1.PodStatus{
+			StartTime:         &metav1.Time{Time: start},
+			ContainerStatuses: []corev1.ContainerStatus{terminated(start.Add(runFor / 2)), terminated(start.Add(runFor))},
+		},
+	}
+}
+
+func TestPodUsage(t *testing.T) {
+	pod := makePod("jx", "pod", nil, 100*time.Second)
+	u := PodUsage(pod, start.Add(time.Hour))
+	assert.InDelta(t, 100, u.CPUCoreSeconds, 0.001, "the pod ran until its last container terminated")
+	assert.InDelta(t, 200*1024*1024*1024, u.MemoryByteSeconds, 1)
+
+	pod.Status.ContainerStatuses[1] = corev1.ContainerStatus{}
+	assert.InDelta(t, 3600, PodUsage(pod, start.Add(time.Hour)).CPUCoreSeconds, 0.001, "a pod still running is accounted until the end")
+
+	pod.Spec.InitContainers = []corev1.Container{container("4", "64Mi")}
+	assert.InDelta(t, 4*3600, PodUsage(pod, start.Add(time.Hour)).CPUCoreSeconds, 0.001, "a larger init container is reserved")
+
+	pod.Status.StartTime = nil
+	assert.Equal(t, Usage{}, PodUsage(pod, start.Add(time.Hour)), "a pod which never started used nothing")
+}
+
+func TestAccountant(t *testing.T) {
+	tekton := &v1alpha1.LighthouseJob{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "jx", Name: "tekton", Labels: map[string]string{util.BuildNumLabel: "3"}},
+		Spec: v1alpha1.LighthouseJobSpec{
+			Job:  "unit",
+			Refs: &v1alpha1.Refs{Org: "org", Repo: "repo", BaseRef: "master"},
+		},
+		Status: v1alpha1.LighthouseJobStatus{CompletionTime: &metav1.Time{Time: start.Add(time.Hour)}},
+	}
+	pod := &v1alpha1.LighthouseJob{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "jx", Name: "pod"},
+		Spec: v1alpha1.LighthouseJobSpec{
+			Job:       "lint",
+			Agent:     v1alpha1.KubernetesAgent,
+			Namespace: "pods",
+			Refs:      &v1alpha1.Refs{Org: "org", Repo: "repo", BaseRef: "master"},
+		},
+		Status: v1alpha1.LighthouseJobStatus{CompletionTime: &metav1.Time{Time: start.Add(time.Hour)}},
+	}
+	runLabels := map[string]string{
+		util.ActivityOwnerLabel:      "org",
+		util.ActivityRepositoryLabel: "repo",
+		util.ActivityBranchLabel:     "master",
+		util.ActivityBuildLabel:      "3",
+	}
+	kubeClient := kubefake.NewSimpleClientset(
+		makePod("jx", "task-1", runLabels, 10*time.Second),
+		makePod("jx", "task-2", runLabels, 20*time.Second),
+		makePod("jx", "other", map[string]string{util.ActivityBuildLabel: "4"}, time.Hour),
+		makePod("pods", "pod", nil, 60*time.Second),
+	)
+	ledger := NewLedger(store.NewMemoryStore())
+	a := NewAccountant(kubeClient, ledger, nil)
+	require.NoError(t, a.Record(tekton))
+	require.NoError(t, a.Record(tekton))
+	require.NoError(t, a.Record(pod))
+	require.NoError(t, a.Record(&v1alpha1.LighthouseJob{Spec: v1alpha1.LighthouseJobSpec{Job: "jenkins", Agent: v1alpha1.JenkinsAgent}}))
+
+	report, err := ledger.Report("2020-06")
+	require.NoError(t, err)
+	require.Len(t, report.Entries, 2)
+	assert.Equal(t, "lint", report.Entries[0].Job)
+	assert.Equal(t, 1, report.Entries[0].Runs)
+	assert.InDelta(t, 60, report.Entries[0].CPUCoreSeconds, 0.001)
+	assert.Equal(t, "unit", report.Entries[1].Job)
+	assert.Equal(t, 2, report.Entries[1].Runs)
+	assert.InDelta(t, 60, report.Entries[1].CPUCoreSeconds, 0.001)
+
+	report, err = ledger.Report("2020-07")
+	require.NoError(t, err)
+	assert.Empty(t, report.Entries)
+	_, err = ledger.Report("June")
+	assert.Error(t, err)
+}
+
+func TestHandler(t *testing.T) {
+	ledger := NewLedger(store.NewMemoryStore())
+	require.NoError(t, ledger.Record("org", "repo", "unit", Usage{CPUCoreSeconds: 1.5, MemoryByteSeconds: 1024}, start))
+	handler := ledger.Handler()
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/usage?month=2020-06&format=csv", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "month,org,repo,job,runs,cpu_core_seconds,memory_byte_seconds\n2020-06,org,repo,unit,1,1.500,1024\n", w.Body.String())
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/usage?month=2020-06", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	report := &Report{}
+	require.NoError(t, json.NewDecoder(bytes.NewReader(w.Body.Bytes())).Decode(report))
+	require.Len(t, report.Entries, 1)
+	assert.Equal(t, 1.5, report.Entries[0].CPUCoreSeconds)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/usage?format=xml", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,96 @@
+package util
+
+import (
+	"os"
+	"regexp"
+	"strings"
+)
+
+const (
+	// CommandPrefixEnvVar is the environment variable setting the prefix the commands must be used with, e.g. lh- to
+	// handle /lh-test while leaving /test to another bot such as Prow running on the same repositories
+	CommandPrefixEnvVar = "LIGHTHOUSE_COMMAND_PREFIX"
+	// BotDisplayNameEnvVar is the environment variable setting the name the bot refers to itself with in comments
+	BotDisplayNameEnvVar = "LIGHTHOUSE_BOT_DISPLAY_NAME"
+
+	// DefaultBotDisplayName is the name the bot refers to itself with by default
+	DefaultBotDisplayName = "Lighthouse"
+)
+
+var (
+	// CommandPrefix is the prefix of the commands, which they can be used with or without unless CommandPrefixRequired
+	CommandPrefix string
+	// CommandPrefixRequired is true if the commands are only handled when used with the CommandPrefix
+	CommandPrefixRequired bool
+	// CommandPrefixPattern matches the prefix of a command in the regular expressions of the commands, following
+	// the / of the command, e.g. `^/` + CommandPrefixPattern + `retest\s*$`
+	CommandPrefixPattern string
+	// BotDisplayName is the name the bot refers to itself with in comments
+	BotDisplayName string
+)
+
+func init() {
+	SetCommandPrefix(os.Getenv(CommandPrefixEnvVar))
+	BotDisplayName = os.Getenv(BotDisplayNameEnvVar)
+	if BotDisplayName == "" {
+		BotDisplayName = DefaultBotDisplayName
+	}
+}
+
+// SetCommandPrefix sets the prefix the commands must be used with. If empty, the commands can be used with or
+// without the LighthouseCommandPrefix. As the regular expressions of the commands are compiled when their packages
+// are initialized, it is set from $LIGHTHOUSE_COMMAND_PREFIX rather than at runtime.
+func SetCommandPrefix(prefix string) {
+	CommandPrefixRequired = prefix != ""
+	CommandPrefix = prefix
+	if !CommandPrefixRequired {
+		CommandPrefix = LighthouseCommandPrefix
+	}
+	CommandPrefixPattern = `(?:` + regexp.QuoteMeta(CommandPrefix) + `)`
+	if !CommandPrefixRequired {
+		CommandPrefixPattern += `?`
+	}
+}
+
+// FormatCommand returns the command, e.g. "/test all", as it should be written in comments, which is with the
+// CommandPrefix if it is required
+func FormatCommand(command string) string {
+	if !CommandPrefixRequired || !strings.HasPrefix(command, "/") {
+		return command
+	}
+	name := strings.TrimPrefix(command, "/")
+	for _, prefix := range []string{"(" + LighthouseCommandPrefix + ")?", LighthouseCommandPrefix, CommandPrefix} {
+		name = strings.TrimPrefix(name, prefix)
+	}
+	return "/" + CommandPrefix + name
+}
+
+// FormatCommands returns the commands as they should be written in comments, without duplicates
+func FormatCommands(commands []string) []string {
+	answer := make([]string, 0, len(commands))
+	seen := map[string]bool{}
+	for _, command := range commands {
+		command = FormatCommand(command)
+		if !seen[command] {
+			seen[command] = true
+			answer = append(answer, command)
+		}
+	}
+	return answer
+}
+
+// TrimCommandPrefix returns the text with the command prefix removed from its commands, so that it can be matched
+// against the triggers of the jobs, which are written without the prefix, e.g. /test my-job. If the prefix is
+// required, the commands used without it are removed as they are meant for another bot.
+func TrimCommandPrefix(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "/"+CommandPrefix):
+			lines[i] = "/" + strings.TrimPrefix(line, "/"+CommandPrefix)
+		case CommandPrefixRequired && strings.HasPrefix(line, "/"):
+			lines[i] = ""
+		}
+	}
+	return strings.Join(lines, "\n")
+}
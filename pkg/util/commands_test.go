@@ -0,0 +1,32 @@
+package util_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommandPrefix(t *testing.T) {
+	defer util.SetCommandPrefix("")
+
+	re := func() *regexp.Regexp {
+		return regexp.MustCompile(`(?m)^/` + util.CommandPrefixPattern + `retest\s*$`)
+	}
+	assert.True(t, re().MatchString("/retest"))
+	assert.True(t, re().MatchString("/lh-retest"))
+	assert.Equal(t, "/test all", util.FormatCommand("/test all"))
+	assert.Equal(t, "/test my-job\n/test other", util.TrimCommandPrefix("/lh-test my-job\n/test other"))
+
+	util.SetCommandPrefix("ci.")
+	assert.False(t, re().MatchString("/retest"), "the commands of other bots should be ignored")
+	assert.False(t, re().MatchString("/ci-retest"))
+	assert.True(t, re().MatchString("/ci.retest"))
+	assert.Equal(t, "/ci.test all", util.FormatCommand("/test all"))
+	assert.Equal(t, "/ci.approve", util.FormatCommand("/lh-approve"))
+	assert.Equal(t, "/ci.woof", util.FormatCommand("/(lh-)?woof"))
+	assert.Equal(t, "text", util.FormatCommand("text"))
+	assert.Equal(t, []string{"/ci.skip"}, util.FormatCommands([]string{"/skip", "/lh-skip"}))
+	assert.Equal(t, "/test my-job\n\nthanks", util.TrimCommandPrefix("/ci.test my-job\n/test other\nthanks"))
+}
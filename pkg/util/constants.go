@@ -50,6 +50,126 @@ const (
 	// BuildNumLabel is added in resources created by Lighthouse and contains the build number for the job.
 	BuildNumLabel = "lighthouse.jenkins-x.io/buildNum"
 
+	// ProviderLabel is added to the jobs of the SCM providers other than the default one and contains the name
+	// of the provider, see the gitprovider package.
+	ProviderLabel = "lighthouse.jenkins-x.io/provider"
+
+	// TenantLabel is added to the jobs of the repositories of a tenant and contains the name of the tenant
+	TenantLabel = "lighthouse.jenkins-x.io/tenant"
+
+	// AgentLabel can be added to a job's labels to choose the agent which runs it, overriding the agent in the job config.
+	AgentLabel = "lighthouse.jenkins-x.io/agent"
+
+	// JenkinsJobAnnotation can be added to a job's annotations to give the path of the Jenkins job to trigger,
+	// which defaults to the job name.
+	JenkinsJobAnnotation = "lighthouse.jenkins-x.io/jenkinsJob"
+
+	// JenkinsQueueURLAnnotation is added to LighthouseJobs run by Jenkins and contains the URL of the queue item
+	// created when the build was triggered.
+	JenkinsQueueURLAnnotation = "lighthouse.jenkins-x.io/jenkinsQueueURL"
+
+	// PipelineRefAnnotation can be added to a job's annotations to run the named Tekton Pipeline rather than the
+	// pipeline generated from the repository.
+	PipelineRefAnnotation = "lighthouse.jenkins-x.io/pipelineRef"
+
+	// PipelineParamsAnnotation can be added to a job's annotations alongside PipelineRefAnnotation and contains a
+	// YAML map of parameter names to templates for their values.
+	PipelineParamsAnnotation = "lighthouse.jenkins-x.io/pipelineParams"
+
+	// TimeoutAnnotation can be added to a job's annotations to give the duration after which the job is aborted,
+	// such as 1h30m.
+	TimeoutAnnotation = "lighthouse.jenkins-x.io/timeout"
+
+	// AbortAnnotation is added to a running job by the /abort command, with the description of its context, so that
+	// the watchdog aborts it.
+	AbortAnnotation = "lighthouse.jenkins-x.io/abort"
+
+	// GracePeriodAnnotation can be added to a job's annotations alongside TimeoutAnnotation to give how long the
+	// pods of a timed out job are given to terminate before they are killed.
+	GracePeriodAnnotation = "lighthouse.jenkins-x.io/gracePeriod"
+
+	// EnvironmentAnnotation can be added to a postsubmit's annotations to give the environment it deploys to, such as
+	// staging or production, which is tracked with a deployment on providers supporting them.
+	EnvironmentAnnotation = "lighthouse.jenkins-x.io/environment"
+
+	// DeploymentAnnotation is added to the LighthouseJobs of postsubmits deploying to an environment and contains the
+	// ID of the deployment created on the provider.
+	DeploymentAnnotation = "lighthouse.jenkins-x.io/deployment"
+
+	// TriggerOnContextAnnotation can be added to a postsubmit's annotations to run it when a context of the commit,
+	// such as an external check, reaches a state rather than when the commit is pushed. Its value is the context
+	// and optionally the state, such as "security-scan=success", the state defaulting to success.
+	TriggerOnContextAnnotation = "lighthouse.jenkins-x.io/triggerOnContext"
+
+	// IssueTriggerAnnotation can be added to a postsubmit's annotations to run it when a trusted user comments a
+	// command matching its regular expression on an issue, such as "^/release (patch|minor|major)$", rather than on
+	// pushes. The named group "branch" of the expression gives the branch it runs against, the default one otherwise.
+	IssueTriggerAnnotation = "lighthouse.jenkins-x.io/issueTrigger"
+
+	// EnvFromSecretsAnnotation can be added to a job's annotations to set the environment variables of its pod from
+	// the comma separated Kubernetes secrets, such as "npm-token,sonar-token". The secrets must be allowed for the
+	// repository in the job defaults of the launcher.
+	EnvFromSecretsAnnotation = "lighthouse.jenkins-x.io/envFromSecrets"
+
+	// JobEnvVersionAnnotation can be added to a job's annotations to pin the version of the contract of the
+	// environment variables its pipeline runs with, such as "2", which stays the same across upgrades. The jobs
+	// without it run with version 1, the variables inherited from Prow.
+	JobEnvVersionAnnotation = "lighthouse.jenkins-x.io/jobEnvVersion"
+
+	// CorrelationIDAnnotation is added to the LighthouseJobs launched for a webhook and contains the correlation ID
+	// logged while handling the webhook.
+	CorrelationIDAnnotation = "lighthouse.jenkins-x.io/correlationID"
+
+	// ReceivedAtAnnotation is added to the LighthouseJobs launched for a webhook and contains the RFC 3339 time the
+	// webhook was received, from which the time to the first commit status of the job is measured.
+	ReceivedAtAnnotation = "lighthouse.jenkins-x.io/receivedAt"
+
+	// FileIssueOnFailureAnnotation can be added to a periodic's annotations with the value "true" to open an issue
+	// when it fails, which is updated while it keeps failing and closed once it succeeds again.
+	FileIssueOnFailureAnnotation = "lighthouse.jenkins-x.io/fileIssueOnFailure"
+
+	// IssueRepoAnnotation can be added alongside FileIssueOnFailureAnnotation to give the org/repo the issues are
+	// filed in, defaulting to the repository of the periodic.
+	IssueRepoAnnotation = "lighthouse.jenkins-x.io/issueRepo"
+
+	// BisectAnnotation can be added to a postsubmit's annotations with the value "true" to bisect the commits between
+	// its last successful run and its first failed run once it starts failing, to find the commit which broke it.
+	BisectAnnotation = "lighthouse.jenkins-x.io/bisect"
+
+	// BisectionAnnotation is added to the LighthouseJobs run to bisect a failing postsubmit and contains the SHA of the
+	// first failed run of the postsubmit.
+	BisectionAnnotation = "lighthouse.jenkins-x.io/bisection"
+
+	// BisectCulpritAnnotation is added to the first failed run of a bisected postsubmit once the bisection is over
+	// and contains the SHA of the commit which broke the postsubmit.
+	BisectCulpritAnnotation = "lighthouse.jenkins-x.io/bisectCulprit"
+
+	// ChangedFilesAnnotation is added to the LighthouseJobs of pull requests and contains the comma separated list of
+	// the files they change, left out if it is too long.
+	ChangedFilesAnnotation = "lighthouse.jenkins-x.io/changedFiles"
+
+	// BaseSHAAnnotation is added to the LighthouseJobs of pull requests and contains the SHA of their base branch.
+	BaseSHAAnnotation = "lighthouse.jenkins-x.io/baseSHA"
+
+	// ChangesHashAnnotation is added to the LighthouseJobs of pull requests and contains a hash of the changed files
+	// relevant to the job, so that pipelines can skip the modules which did not change.
+	ChangesHashAnnotation = "lighthouse.jenkins-x.io/changesHash"
+
+	// MergeGateAnnotation can be added to a presubmit's annotations with the value "true" to make keeper run it against
+	// the exact merge result of a pull request immediately before merging it, the merge waiting for it to succeed.
+	MergeGateAnnotation = "lighthouse.jenkins-x.io/mergeGate"
+
+	// RerunAuthConfigAnnotation can be added to a presubmit's annotations to restrict who can run it on demand, with
+	// /test, /retest, /test-trusted or the "Re-run" button of its check, to the comma separated users and org/team
+	// teams, such as "alice,myorg/release-managers", even if other users are trusted. Its automatic runs are unchanged.
+	RerunAuthConfigAnnotation = "lighthouse.jenkins-x.io/rerunAuthConfig"
+
+	// ConcurrencyGroupAnnotation can be added to a job's annotations to name the group of jobs of which only one may
+	// run at a time, whatever their repository or job name, such as "deploy-staging" for the jobs deploying to a shared
+	// environment. The jobs of the group wait in the order they were triggered. It is only supported by the kubernetes
+	// agent, the jobs of the other agents failing to launch with a concurrency group.
+	ConcurrencyGroupAnnotation = "lighthouse.jenkins-x.io/concurrencyGroup"
+
 	// ActivityOwnerLabel is the label for the org/owner on the PipelineActivity
 	ActivityOwnerLabel = "owner"
 	// ActivityRepositoryLabel is the label for the repo name on the PipelineActivity
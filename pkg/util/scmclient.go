@@ -6,6 +6,7 @@ import (
 
 	"github.com/jenkins-x/go-scm/scm"
 	"github.com/jenkins-x/go-scm/scm/transport"
+	"github.com/jenkins-x/lighthouse/pkg/gerrit"
 	"golang.org/x/oauth2"
 )
 
@@ -24,6 +25,10 @@ func AddAuthToSCMClient(client *scm.Client, token string, isGitHubApp bool) {
 		client.Client.Transport = tr
 		return
 	}
+	if _, ok := gerrit.Username(client); ok {
+		gerrit.SetCredentials(client, token)
+		return
+	}
 	if client.Driver.String() == "gitlab" || client.Driver.String() == "bitbucketcloud" {
 		client.Client = &http.Client{
 			Transport: &transport.PrivateToken{
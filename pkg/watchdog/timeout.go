@@ -0,0 +1,92 @@
+package watchdog
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/pkg/errors"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline"
+	pipelinev1alpha1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
+	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// timedOut returns the timeout of the job if it has run for longer, or zero otherwise. Only the jobs run by
+// tekton or as pods can be aborted.
+func (w *Watchdog) timedOut(job *v1alpha1.LighthouseJob) time.Duration {
+	timeout := job.Spec.Timeout
+	if timeout == nil || timeout.Duration <= 0 || job.Status.StartTime.IsZero() || !abortable(job) {
+		return 0
+	}
+	if w.now().Sub(job.Status.StartTime.Time) <= timeout.Duration {
+		return 0
+	}
+	return timeout.Duration
+}
+
+// abortable returns true if the job is run by tekton or as a pod, which can be aborted
+func abortable(job *v1alpha1.LighthouseJob) bool {
+	switch job.Spec.Agent {
+	case "", v1alpha1.TektonAgent, v1alpha1.KubernetesAgent:
+		return true
+	}
+	return false
+}
+
+// abort stops the PipelineRuns or the pod running the job, giving their pods the grace period of the job to
+// terminate
+func (w *Watchdog) abort(job *v1alpha1.LighthouseJob) error {
+	deleteOptions := &metav1.DeleteOptions{}
+	if job.Spec.GracePeriod != nil {
+		seconds := int64(job.Spec.GracePeriod.Duration / time.Second)
+		deleteOptions.GracePeriodSeconds = &seconds
+	}
+
+	if job.Spec.Agent == v1alpha1.KubernetesAgent {
+		if w.kubeClient == nil {
+			return nil
+		}
+		ns := job.Spec.Namespace
+		if ns == "" {
+			ns = w.namespace
+		}
+		err := w.kubeClient.CoreV1().Pods(ns).Delete(job.Name, deleteOptions)
+		if err != nil && !kubeerrors.IsNotFound(err) {
+			return errors.Wrapf(err, "deleting pod of LighthouseJob %s", job.Name)
+		}
+		return nil
+	}
+
+	runs, err := w.pipelineRuns(job)
+	if err != nil {
+		return err
+	}
+	for i := range runs {
+		run := &runs[i]
+		if run.IsDone() || run.IsCancelled() {
+			continue
+		}
+		run.Spec.Status = pipelinev1alpha1.PipelineRunSpecStatusCancelled
+		if _, err := w.tektonClient.TektonV1alpha1().PipelineRuns(w.namespace).Update(run); err != nil {
+			return errors.Wrapf(err, "cancelling PipelineRun %s", run.Name)
+		}
+		if deleteOptions.GracePeriodSeconds == nil || w.kubeClient == nil {
+			// tekton deletes the pods of cancelled runs itself
+			continue
+		}
+		pods, err := w.kubeClient.CoreV1().Pods(w.namespace).List(metav1.ListOptions{
+			LabelSelector: fmt.Sprintf("%s%s=%s", pipeline.GroupName, pipeline.PipelineRunLabelKey, run.Name),
+		})
+		if err != nil {
+			return errors.Wrapf(err, "listing pods for PipelineRun %s", run.Name)
+		}
+		for _, pod := range pods.Items {
+			err := w.kubeClient.CoreV1().Pods(w.namespace).Delete(pod.Name, deleteOptions)
+			if err != nil && !kubeerrors.IsNotFound(err) {
+				return errors.Wrapf(err, "deleting pod %s of PipelineRun %s", pod.Name, run.Name)
+			}
+		}
+	}
+	return nil
+}
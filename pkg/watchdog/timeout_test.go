@@ -0,0 +1,127 @@
+package watchdog
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	lhfake "github.com/jenkins-x/lighthouse/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	pipelinev1alpha1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
+	tektonfake "github.com/tektoncd/pipeline/pkg/client/clientset/versioned/fake"
+	corev1 "k8s.io/api/core/v1"
+	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestCheckTimeouts(t *testing.T) {
+	withTimeout := func(job *v1alpha1.LighthouseJob, agent string, timeout time.Duration) *v1alpha1.LighthouseJob {
+		job.Spec.Agent = agent
+		job.Spec.Timeout = &v1alpha1.Duration{Duration: timeout}
+		job.Spec.GracePeriod = &v1alpha1.Duration{Duration: 10 * time.Second}
+		return job
+	}
+	lhClient := lhfake.NewSimpleClientset(
+		withTimeout(makeJob("timed-out", "1", v1alpha1.RunningState, 2*time.Hour), v1alpha1.TektonAgent, time.Hour),
+		withTimeout(makeJob("in-time", "2", v1alpha1.RunningState, 30*time.Minute), v1alpha1.TektonAgent, time.Hour),
+		withTimeout(makeJob("timed-out-pod", "3", v1alpha1.RunningState, 2*time.Hour), v1alpha1.KubernetesAgent, time.Hour),
+		withTimeout(makeJob("jenkins", "4", v1alpha1.RunningState, 2*time.Hour), v1alpha1.JenkinsAgent, time.Hour),
+	)
+	tektonClient := tektonfake.NewSimpleClientset(
+		makeRun("timed-out-run", "1"),
+		makeRun("in-time-run", "2"),
+	)
+	kubeClient := kubefake.NewSimpleClientset(
+		makePod("timed-out-run-pod", "timed-out-run", corev1.ConditionTrue, 2*time.Hour),
+		makePod("in-time-run-pod", "in-time-run", corev1.ConditionTrue, 30*time.Minute),
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "timed-out-pod", Namespace: ns}},
+	)
+	statusClient := &fakeStatusClient{statuses: map[string]*scm.StatusInput{}}
+	scmClients := func(job *v1alpha1.LighthouseJob) (StatusClient, error) {
+		return statusClient, nil
+	}
+	w := NewWatchdog(lhClient, tektonClient, kubeClient, scmClients, ns, Timeouts{}, nil)
+	w.now = func() time.Time { return now }
+
+	ended, err := w.Check()
+	require.NoError(t, err)
+	sort.Strings(ended)
+	assert.Equal(t, []string{"timed-out", "timed-out-pod"}, ended)
+
+	for _, name := range ended {
+		job, err := lhClient.LighthouseV1alpha1().LighthouseJobs(ns).Get(name, metav1.GetOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, v1alpha1.AbortedState, job.Status.State, name)
+		assert.Equal(t, "Pipeline timed out after 1h0m0s", job.Status.Description, name)
+		require.NotNil(t, job.Status.CompletionTime, name)
+
+		status := statusClient.statuses["org/repo@head:"+name]
+		require.NotNil(t, status, name)
+		assert.Equal(t, scm.StateCanceled, status.State, name)
+		assert.Equal(t, "Pipeline timed out after 1h0m0s", status.Desc, name)
+	}
+	for _, name := range []string{"in-time", "jenkins"} {
+		job, err := lhClient.LighthouseV1alpha1().LighthouseJobs(ns).Get(name, metav1.GetOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, v1alpha1.RunningState, job.Status.State, name)
+	}
+
+	run, err := tektonClient.TektonV1alpha1().PipelineRuns(ns).Get("timed-out-run", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, pipelinev1alpha1.PipelineRunSpecStatusCancelled, string(run.Spec.Status))
+	run, err = tektonClient.TektonV1alpha1().PipelineRuns(ns).Get("in-time-run", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, run.Spec.Status)
+
+	for name, deleted := range map[string]bool{"timed-out-run-pod": true, "timed-out-pod": true, "in-time-run-pod": false} {
+		_, err := kubeClient.CoreV1().Pods(ns).Get(name, metav1.GetOptions{})
+		assert.Equal(t, deleted, kubeerrors.IsNotFound(err), name)
+	}
+}
+
+func TestCheckAborted(t *testing.T) {
+	withAbort := func(job *v1alpha1.LighthouseJob, agent string) *v1alpha1.LighthouseJob {
+		job.Spec.Agent = agent
+		job.Annotations = map[string]string{util.AbortAnnotation: "Aborted by @alice"}
+		return job
+	}
+	lhClient := lhfake.NewSimpleClientset(
+		withAbort(makeJob("aborted", "1", v1alpha1.RunningState, time.Minute), v1alpha1.TektonAgent),
+		makeJob("running", "2", v1alpha1.RunningState, time.Minute),
+		withAbort(makeJob("jenkins", "3", v1alpha1.RunningState, time.Minute), v1alpha1.JenkinsAgent),
+	)
+	tektonClient := tektonfake.NewSimpleClientset(
+		makeRun("aborted-run", "1"),
+		makeRun("running-run", "2"),
+	)
+	statusClient := &fakeStatusClient{statuses: map[string]*scm.StatusInput{}}
+	scmClients := func(job *v1alpha1.LighthouseJob) (StatusClient, error) {
+		return statusClient, nil
+	}
+	w := NewWatchdog(lhClient, tektonClient, nil, scmClients, ns, Timeouts{}, nil)
+	w.now = func() time.Time { return now }
+
+	ended, err := w.Check()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"aborted"}, ended)
+
+	job, err := lhClient.LighthouseV1alpha1().LighthouseJobs(ns).Get("aborted", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, v1alpha1.AbortedState, job.Status.State)
+	assert.Equal(t, "Aborted by @alice", job.Status.Description)
+	status := statusClient.statuses["org/repo@head:aborted"]
+	require.NotNil(t, status)
+	assert.Equal(t, scm.StateCanceled, status.State)
+
+	run, err := tektonClient.TektonV1alpha1().PipelineRuns(ns).Get("aborted-run", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, pipelinev1alpha1.PipelineRunSpecStatusCancelled, string(run.Spec.Status))
+	run, err = tektonClient.TektonV1alpha1().PipelineRuns(ns).Get("running-run", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, run.Spec.Status)
+}
@@ -0,0 +1,316 @@
+// Package watchdog detects LighthouseJobs which will never complete, marks them as errored and
+// reports the failure to the SCM provider so that pull requests are not left waiting forever. It also
+// aborts the jobs which run for longer than their timeout or which the /abort command annotated.
+package watchdog
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	clientset "github.com/jenkins-x/lighthouse/pkg/client/clientset/versioned"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/tektoncd/pipeline/pkg/apis/pipeline"
+	pipelinev1alpha1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
+	tektonclient "github.com/tektoncd/pipeline/pkg/client/clientset/versioned"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// StatusClient is the subset of the SCM client used to report stuck jobs
+type StatusClient interface {
+	CreateStatus(org, repo, ref string, s *scm.StatusInput) (*scm.Status, error)
+}
+
+// SCMClientFactory returns the SCM client to report the statuses of the job with
+type SCMClientFactory func(job *v1alpha1.LighthouseJob) (StatusClient, error)
+
+// Timeouts configures how long a job may stay in each phase before it is considered stuck
+type Timeouts struct {
+	// Pending is how long a job may stay triggered or pending before it is considered stuck
+	Pending time.Duration
+	// Unscheduled is how long a pod of a running job may stay unscheduled
+	Unscheduled time.Duration
+	// MissingPipelineRun is how long after its start a launched job must have a PipelineRun
+	MissingPipelineRun time.Duration
+}
+
+// Watchdog finds and errors LighthouseJobs which are stuck
+type Watchdog struct {
+	lhClient     clientset.Interface
+	tektonClient tektonclient.Interface
+	kubeClient   kubernetes.Interface
+	scmClients   SCMClientFactory
+	namespace    string
+	timeouts     Timeouts
+	logger       *logrus.Entry
+	dryRun       bool
+
+	now func() time.Time
+}
+
+// NewWatchdog creates a new watchdog for the jobs in the given namespace
+func NewWatchdog(lhClient clientset.Interface, tektonClient tektonclient.Interface, kubeClient kubernetes.Interface, scmClients SCMClientFactory, namespace string, timeouts Timeouts, logger *logrus.Entry) *Watchdog {
+	if logger == nil {
+		logger = logrus.NewEntry(logrus.StandardLogger())
+	}
+	return &Watchdog{
+		lhClient:     lhClient,
+		tektonClient: tektonClient,
+		kubeClient:   kubeClient,
+		scmClients:   scmClients,
+		namespace:    namespace,
+		timeouts:     timeouts,
+		logger:       logger.WithField("controller", "watchdog"),
+		now:          time.Now,
+	}
+}
+
+// DryRun makes the watchdog only log the jobs it would abort or error, without aborting them, updating their status
+// or reporting them to the SCM provider
+func (w *Watchdog) DryRun(enabled bool) {
+	w.dryRun = enabled
+}
+
+// Check looks at every job which has not completed, aborting the ones which timed out or which were annotated
+// to be aborted and erroring the ones which are stuck, returning the names of the jobs which were ended. Nothing is
+// ended in dry run mode.
+func (w *Watchdog) Check() ([]string, error) {
+	jobList, err := w.lhClient.LighthouseV1alpha1().LighthouseJobs(w.namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing LighthouseJobs in namespace %s", w.namespace)
+	}
+
+	var ended []string
+	for i := range jobList.Items {
+		job := &jobList.Items[i]
+		if job.Status.CompletionTime != nil || !isActive(job.Status.State) {
+			continue
+		}
+		if reason := job.Annotations[util.AbortAnnotation]; reason != "" && abortable(job) {
+			if w.dryRun {
+				w.logDryRun(job, v1alpha1.AbortedState, reason)
+				continue
+			}
+			if err := w.abort(job); err != nil {
+				w.logger.WithError(err).Warnf("failed to abort LighthouseJob %s", job.Name)
+				continue
+			}
+			if err := w.complete(job, v1alpha1.AbortedState, reason); err != nil {
+				return ended, err
+			}
+			ended = append(ended, job.Name)
+			continue
+		}
+		if timeout := w.timedOut(job); timeout > 0 {
+			if w.dryRun {
+				w.logDryRun(job, v1alpha1.AbortedState, fmt.Sprintf("Pipeline timed out after %s", timeout))
+				continue
+			}
+			if err := w.abort(job); err != nil {
+				w.logger.WithError(err).Warnf("failed to abort timed out LighthouseJob %s", job.Name)
+				continue
+			}
+			if err := w.complete(job, v1alpha1.AbortedState, fmt.Sprintf("Pipeline timed out after %s", timeout)); err != nil {
+				return ended, err
+			}
+			ended = append(ended, job.Name)
+			continue
+		}
+		reason, err := w.stuckReason(job)
+		if err != nil {
+			w.logger.WithError(err).Warnf("failed to check LighthouseJob %s", job.Name)
+			continue
+		}
+		if reason == "" {
+			continue
+		}
+		if w.dryRun {
+			w.logDryRun(job, v1alpha1.ErrorState, reason)
+			continue
+		}
+		if err := w.complete(job, v1alpha1.ErrorState, reason); err != nil {
+			return ended, err
+		}
+		ended = append(ended, job.Name)
+	}
+	return ended, nil
+}
+
+// logDryRun logs the state the job would be ended in outside of dry run mode
+func (w *Watchdog) logDryRun(job *v1alpha1.LighthouseJob, state v1alpha1.PipelineState, reason string) {
+	w.logger.WithFields(logrus.Fields{
+		"job":    job.Name,
+		"reason": reason,
+	}).Infof("Dry run: would mark LighthouseJob as %s", state)
+}
+
+func isActive(state v1alpha1.PipelineState) bool {
+	return state == v1alpha1.TriggeredState || state == v1alpha1.PendingState || state == v1alpha1.RunningState || state == ""
+}
+
+// stuckReason returns a description of why the job is stuck, or an empty string if it is not
+func (w *Watchdog) stuckReason(job *v1alpha1.LighthouseJob) (string, error) {
+	age := w.now().Sub(job.Status.StartTime.Time)
+
+	runs, err := w.pipelineRuns(job)
+	if err != nil {
+		return "", err
+	}
+	if runs == nil {
+		// without the PipelineRuns we can only tell how long the job has been waiting
+		return w.pendingReason(job, age), nil
+	}
+	if len(runs) == 0 {
+		if job.Status.ActivityName != "" && w.timeouts.MissingPipelineRun > 0 && age > w.timeouts.MissingPipelineRun {
+			return "Pipeline disappeared before completing", nil
+		}
+		if w.timeouts.Pending > 0 && age > w.timeouts.Pending {
+			return fmt.Sprintf("Pipeline did not start within %s", w.timeouts.Pending), nil
+		}
+		return "", nil
+	}
+
+	if w.timeouts.Unscheduled > 0 {
+		for _, run := range runs {
+			pod, err := w.unscheduledPod(run.Namespace, run.Name)
+			if err != nil {
+				return "", err
+			}
+			if pod != "" {
+				return fmt.Sprintf("Pod %s could not be scheduled within %s", pod, w.timeouts.Unscheduled), nil
+			}
+		}
+	}
+
+	return w.pendingReason(job, age), nil
+}
+
+// pendingReason returns why the job is stuck if it has not started running within the pending timeout
+func (w *Watchdog) pendingReason(job *v1alpha1.LighthouseJob, age time.Duration) string {
+	if job.Status.State == v1alpha1.RunningState || w.timeouts.Pending <= 0 || age <= w.timeouts.Pending {
+		return ""
+	}
+	state := job.Status.State
+	if state == "" {
+		state = v1alpha1.TriggeredState
+	}
+	return fmt.Sprintf("Pipeline stuck in %s for more than %s", state, w.timeouts.Pending)
+}
+
+// pipelineRuns returns the PipelineRuns of the job, or nil if they cannot be looked up
+func (w *Watchdog) pipelineRuns(job *v1alpha1.LighthouseJob) ([]pipelinev1alpha1.PipelineRun, error) {
+	buildNum := job.Labels[util.BuildNumLabel]
+	if buildNum == "" || job.Spec.Refs == nil || w.tektonClient == nil {
+		return nil, nil
+	}
+	if job.Spec.Agent != "" && job.Spec.Agent != v1alpha1.TektonAgent {
+		// only jobs run by tekton have PipelineRuns
+		return nil, nil
+	}
+	selector := strings.Join([]string{
+		fmt.Sprintf("%s=%s", util.ActivityOwnerLabel, job.Spec.Refs.Org),
+		fmt.Sprintf("%s=%s", util.ActivityRepositoryLabel, job.Spec.Refs.Repo),
+		fmt.Sprintf("%s=%s", util.ActivityBranchLabel, job.Spec.GetBranch()),
+		fmt.Sprintf("%s=%s", util.ActivityBuildLabel, buildNum),
+	}, ",")
+	runs, err := w.tektonClient.TektonV1alpha1().PipelineRuns(job.Namespace).List(metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing PipelineRuns for LighthouseJob %s", job.Name)
+	}
+	if runs.Items == nil {
+		return []pipelinev1alpha1.PipelineRun{}, nil
+	}
+	return runs.Items, nil
+}
+
+// unscheduledPod returns the name of a pod of the PipelineRun which has not been scheduled within the timeout
+func (w *Watchdog) unscheduledPod(namespace, runName string) (string, error) {
+	if w.kubeClient == nil {
+		return "", nil
+	}
+	pods, err := w.kubeClient.CoreV1().Pods(namespace).List(metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s%s=%s", pipeline.GroupName, pipeline.PipelineRunLabelKey, runName),
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "listing pods for PipelineRun %s", runName)
+	}
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != corev1.PodPending {
+			continue
+		}
+		for _, cond := range pod.Status.Conditions {
+			if cond.Type == corev1.PodScheduled && cond.Status == corev1.ConditionFalse &&
+				w.now().Sub(pod.CreationTimestamp.Time) > w.timeouts.Unscheduled {
+				return pod.Name, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// complete ends the job in the given state, reporting the reason to the SCM provider
+func (w *Watchdog) complete(job *v1alpha1.LighthouseJob, state v1alpha1.PipelineState, reason string) error {
+	l := w.logger.WithFields(logrus.Fields{
+		"job":    job.Name,
+		"reason": reason,
+	})
+	l.Warnf("Marking LighthouseJob as %s", state)
+
+	now := metav1.NewTime(w.now())
+	jobCopy := job.DeepCopy()
+	jobCopy.Status.State = state
+	jobCopy.Status.Description = reason
+	jobCopy.Status.CompletionTime = &now
+	jobCopy.SetStateConditions()
+
+	scmState := scm.StateError
+	if state == v1alpha1.AbortedState {
+		scmState = scm.StateCanceled
+	}
+	if w.report(jobCopy, scmState, reason) {
+		jobCopy.Status.LastReportState = scmState.String()
+	}
+
+	_, err := w.lhClient.LighthouseV1alpha1().LighthouseJobs(jobCopy.Namespace).UpdateStatus(jobCopy)
+	if err != nil {
+		return errors.Wrapf(err, "updating status of LighthouseJob %s", job.Name)
+	}
+	return nil
+}
+
+// report sets the status of the job's commit, returning whether the status was created
+func (w *Watchdog) report(job *v1alpha1.LighthouseJob, state scm.State, reason string) bool {
+	sha := job.Status.LastCommitSHA
+	if sha == "" {
+		sha = job.Spec.GetSHA()
+	}
+	if w.scmClients == nil || job.Spec.Refs == nil || sha == "" || job.Spec.Context == "" {
+		return false
+	}
+	l := w.logger.WithField("job", job.Name)
+	scmClient, err := w.scmClients(job)
+	if err != nil {
+		l.WithError(err).Warn("failed to create SCM client")
+		job.SetReported(state.String(), err)
+		return false
+	}
+	status := &scm.StatusInput{
+		State:  state,
+		Label:  job.Spec.Context,
+		Desc:   reason,
+		Target: job.Status.ReportURL,
+	}
+	if _, err := scmClient.CreateStatus(job.Spec.Refs.Org, job.Spec.Refs.Repo, sha, status); err != nil {
+		l.WithError(err).Warn("failed to report the job status")
+		job.SetReported(state.String(), err)
+		return false
+	}
+	job.SetReported(state.String(), nil)
+	return true
+}
@@ -0,0 +1,182 @@
+package watchdog
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	lhfake "github.com/jenkins-x/lighthouse/pkg/client/clientset/versioned/fake"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	pipelinev1alpha1 "github.com/tektoncd/pipeline/pkg/apis/pipeline/v1alpha1"
+	tektonfake "github.com/tektoncd/pipeline/pkg/client/clientset/versioned/fake"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+const ns = "jx"
+
+var now = time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+
+type fakeStatusClient struct {
+	statuses map[string]*scm.StatusInput
+}
+
+func (f *fakeStatusClient) CreateStatus(org, repo, ref string, s *scm.StatusInput) (*scm.Status, error) {
+	f.statuses[org+"/"+repo+"@"+ref+":"+s.Label] = s
+	return &scm.Status{State: s.State, Label: s.Label, Desc: s.Desc}, nil
+}
+
+func makeJob(name, build string, state v1alpha1.PipelineState, age time.Duration) *v1alpha1.LighthouseJob {
+	return &v1alpha1.LighthouseJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ns,
+			Labels:    map[string]string{util.BuildNumLabel: build},
+		},
+		Spec: v1alpha1.LighthouseJobSpec{
+			Type:    "presubmit",
+			Context: name,
+			Refs: &v1alpha1.Refs{
+				Org:     "org",
+				Repo:    "repo",
+				BaseRef: "master",
+				BaseSHA: "base",
+				Pulls:   []v1alpha1.Pull{{Number: 1, SHA: "head"}},
+			},
+		},
+		Status: v1alpha1.LighthouseJobStatus{
+			State:        state,
+			ActivityName: name + "-activity",
+			StartTime:    metav1.NewTime(now.Add(-age)),
+		},
+	}
+}
+
+func makeRun(name, build string) *pipelinev1alpha1.PipelineRun {
+	return &pipelinev1alpha1.PipelineRun{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ns,
+			Labels: map[string]string{
+				util.ActivityOwnerLabel:      "org",
+				util.ActivityRepositoryLabel: "repo",
+				util.ActivityBranchLabel:     "PR-1",
+				util.ActivityBuildLabel:      build,
+			},
+		},
+	}
+}
+
+func makePod(name, run string, scheduled corev1.ConditionStatus, age time.Duration) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         ns,
+			CreationTimestamp: metav1.NewTime(now.Add(-age)),
+			Labels:            map[string]string{"tekton.dev/pipelineRun": run},
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodPending,
+			Conditions: []corev1.PodCondition{{
+				Type:   corev1.PodScheduled,
+				Status: scheduled,
+			}},
+		},
+	}
+}
+
+func TestCheck(t *testing.T) {
+	completed := makeJob("completed", "9", v1alpha1.SuccessState, 10*time.Hour)
+	completedAt := metav1.NewTime(now.Add(-9 * time.Hour))
+	completed.Status.CompletionTime = &completedAt
+
+	lhClient := lhfake.NewSimpleClientset(
+		makeJob("healthy", "1", v1alpha1.RunningState, 2*time.Hour),
+		makeJob("unscheduled", "2", v1alpha1.RunningState, time.Hour),
+		makeJob("stuck-pending", "3", v1alpha1.PendingState, 3*time.Hour),
+		makeJob("disappeared", "4", v1alpha1.RunningState, 10*time.Minute),
+		makeJob("just-started", "5", v1alpha1.PendingState, time.Minute),
+		completed,
+	)
+	tektonClient := tektonfake.NewSimpleClientset(
+		makeRun("healthy-run", "1"),
+		makeRun("unscheduled-run", "2"),
+		makeRun("stuck-pending-run", "3"),
+	)
+	kubeClient := kubefake.NewSimpleClientset([]runtime.Object{
+		makePod("healthy-pod", "healthy-run", corev1.ConditionTrue, 2*time.Hour),
+		makePod("unscheduled-pod", "unscheduled-run", corev1.ConditionFalse, 45*time.Minute),
+	}...)
+	statusClient := &fakeStatusClient{statuses: map[string]*scm.StatusInput{}}
+	scmClients := func(job *v1alpha1.LighthouseJob) (StatusClient, error) {
+		return statusClient, nil
+	}
+
+	w := NewWatchdog(lhClient, tektonClient, kubeClient, scmClients, ns, Timeouts{
+		Pending:            time.Hour,
+		Unscheduled:        30 * time.Minute,
+		MissingPipelineRun: 5 * time.Minute,
+	}, nil)
+	w.now = func() time.Time { return now }
+
+	errored, err := w.Check()
+	require.NoError(t, err)
+	sort.Strings(errored)
+	assert.Equal(t, []string{"disappeared", "stuck-pending", "unscheduled"}, errored)
+
+	expectedDescriptions := map[string]string{
+		"disappeared":   "Pipeline disappeared before completing",
+		"stuck-pending": "Pipeline stuck in pending for more than 1h0m0s",
+		"unscheduled":   "Pod unscheduled-pod could not be scheduled within 30m0s",
+	}
+	for name, desc := range expectedDescriptions {
+		job, err := lhClient.LighthouseV1alpha1().LighthouseJobs(ns).Get(name, metav1.GetOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, v1alpha1.ErrorState, job.Status.State, name)
+		assert.Equal(t, desc, job.Status.Description, name)
+		assert.Equal(t, "error", job.Status.LastReportState, name)
+		require.NotNil(t, job.Status.CompletionTime, name)
+
+		status := statusClient.statuses["org/repo@head:"+name]
+		require.NotNil(t, status, name)
+		assert.Equal(t, scm.StateError, status.State, name)
+		assert.Equal(t, desc, status.Desc, name)
+	}
+
+	for _, name := range []string{"healthy", "just-started", "completed"} {
+		job, err := lhClient.LighthouseV1alpha1().LighthouseJobs(ns).Get(name, metav1.GetOptions{})
+		require.NoError(t, err)
+		assert.NotEqual(t, v1alpha1.ErrorState, job.Status.State, name)
+	}
+	assert.Len(t, statusClient.statuses, 3)
+}
+
+func TestCheckDryRun(t *testing.T) {
+	lhClient := lhfake.NewSimpleClientset(
+		makeJob("stuck-pending", "3", v1alpha1.PendingState, 3*time.Hour),
+	)
+	statusClient := &fakeStatusClient{statuses: map[string]*scm.StatusInput{}}
+	scmClients := func(job *v1alpha1.LighthouseJob) (StatusClient, error) {
+		return statusClient, nil
+	}
+
+	w := NewWatchdog(lhClient, tektonfake.NewSimpleClientset(), nil, scmClients, ns, Timeouts{Pending: time.Hour}, nil)
+	w.now = func() time.Time { return now }
+	w.DryRun(true)
+
+	ended, err := w.Check()
+	require.NoError(t, err)
+	assert.Empty(t, ended)
+
+	job, err := lhClient.LighthouseV1alpha1().LighthouseJobs(ns).Get("stuck-pending", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, v1alpha1.PendingState, job.Status.State)
+	assert.Nil(t, job.Status.CompletionTime)
+	assert.Empty(t, statusClient.statuses)
+}
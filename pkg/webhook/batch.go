@@ -0,0 +1,51 @@
+package webhook
+
+import (
+	"sort"
+
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	"github.com/sirupsen/logrus"
+)
+
+// batchPlugin names the handling of the comments holding several commands in the logs and metrics of the dropped
+// events
+const batchPlugin = "command-batch"
+
+// handleCommandBatch handles a comment holding several commands one command after the other, in the order they are
+// used in the comment, each command being handled by the plugins in the order of their names. The reactions of the
+// plugins are collected and the comment is given a single reaction summarizing them once all the commands were
+// handled.
+func (s *Server) handleCommandBatch(l *logrus.Entry, clientAgent *plugins.ClientAgent, ce *scmprovider.GenericCommentEvent, handlers map[string]plugins.GenericCommentHandler, segments []string) {
+	names := make([]string, 0, len(handlers))
+	for name := range handlers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	l = l.WithField("commands", len(segments))
+	l.Info("Handling the commands of the comment one after the other.")
+	s.runQueued(commentQueue("GenericCommentEvent", ce.Body), l, batchPlugin, "GenericCommentEvent", func() {
+		reactions := &scmprovider.ReactionBatch{}
+		for _, segment := range segments {
+			e := *ce
+			e.Body = segment
+			e.Reactions = reactions
+			for _, name := range names {
+				h := handlers[name]
+				s.callPlugin(l, name, "GenericCommentEvent", func(l *logrus.Entry) error {
+					agent := plugins.NewAgent(s.ClientFactory, s.configAgentFor(e.Repo), s.Plugins, clientAgent, s.MetapipelineClient, s.ServerURL, l)
+					agent.InitializeCommentPruner(
+						e.Repo.Namespace,
+						e.Repo.Name,
+						e.Number,
+					)
+					return h(agent, e)
+				})
+			}
+		}
+		if reaction := reactions.Reaction(); reaction != "" && clientAgent != nil {
+			spc := scmprovider.ToClient(clientAgent.SCMProviderClient, clientAgent.BotName)
+			plugins.React(spc, l, *ce, reaction)
+		}
+	})
+}
@@ -0,0 +1,43 @@
+package webhook
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/canary"
+	"github.com/sirupsen/logrus"
+)
+
+// configAgentFor returns the agent of the config.yaml the events of the repository use, which is the canary one if
+// the repository is part of the canary
+func (s *Server) configAgentFor(repo scm.Repository) *config.Agent {
+	return s.Canary.ConfigAgentFor(s.ConfigAgent, repo.Namespace, repo.Name)
+}
+
+// handleCanary responds to a GET /config/canary request, served on the admin port, with the JSON report of the
+// repositories the canary config.yaml triggers different jobs or allows different merges for than the stable one.
+// The branch parameter is the default branch whose required contexts are compared, master if not set.
+func (o *Options) handleCanary(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "only GET is supported", http.StatusMethodNotAllowed)
+		return
+	}
+	server := o.providers[0].server
+	settings, canaryConfig := server.Canary.Snapshot()
+	stable := server.ConfigAgent.Config()
+	if canaryConfig == nil || stable == nil {
+		http.Error(w, "no canary config.yaml in the "+canary.ConfigMapName+" ConfigMap", http.StatusNotFound)
+		return
+	}
+	branch := r.URL.Query().Get("branch")
+	if branch == "" {
+		branch = "master"
+	}
+	report := canary.Compare(stable, canaryConfig, settings, branch)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		logrus.WithError(err).Debug("failed to write the canary report")
+	}
+}
@@ -0,0 +1,30 @@
+package webhook
+
+import (
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/jx/v2/pkg/tekton/metapipeline"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/launcher"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+)
+
+// correlatedLauncher annotates the jobs launched for a webhook with its correlation ID, so that the logs of the
+// webhook can be found from a job, and with the time the webhook was received
+type correlatedLauncher struct {
+	launcher.PipelineLauncher
+	correlationID string
+}
+
+// Launch annotates the job with the correlation ID before launching it
+func (l *correlatedLauncher) Launch(job *v1alpha1.LighthouseJob, metapipelineClient metapipeline.Client, repo scm.Repository) (*v1alpha1.LighthouseJob, error) {
+	if job.Annotations == nil {
+		job.Annotations = map[string]string{}
+	}
+	job.Annotations[util.CorrelationIDAnnotation] = l.correlationID
+	if received, ok := pendingEvents.received(l.correlationID); ok {
+		job.Annotations[util.ReceivedAtAnnotation] = received.UTC().Format(time.RFC3339Nano)
+	}
+	return l.PipelineLauncher.Launch(job, metapipelineClient, repo)
+}
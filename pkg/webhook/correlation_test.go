@@ -0,0 +1,22 @@
+package webhook
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/launcher/fake"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCorrelatedLauncher(t *testing.T) {
+	jobs := fake.NewLauncher()
+	l := &correlatedLauncher{PipelineLauncher: &providerLauncher{PipelineLauncher: jobs, provider: "gitlab"}, correlationID: "delivery"}
+	_, err := l.Launch(&v1alpha1.LighthouseJob{}, nil, scm.Repository{})
+	require.NoError(t, err)
+	require.Len(t, jobs.Pipelines, 1)
+	assert.Equal(t, "delivery", jobs.Pipelines[0].Annotations[util.CorrelationIDAnnotation])
+	assert.Equal(t, "gitlab", jobs.Pipelines[0].Labels[util.ProviderLabel])
+}
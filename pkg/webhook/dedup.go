@@ -0,0 +1,227 @@
+package webhook
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/jenkins-x/lighthouse/pkg/store"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// DeliveriesConfigMap is the ConfigMap recording the webhook deliveries processed by the hook replicas
+	DeliveriesConfigMap = "lighthouse-webhook-deliveries"
+
+	// NoDedup processes every delivery, even redelivered ones
+	NoDedup = "none"
+	// MemoryDedup skips deliveries already processed by the same replica
+	MemoryDedup = "memory"
+	// ConfigMapDedup skips deliveries already processed by any replica, recording them in a shared ConfigMap
+	ConfigMapDedup = "configmap"
+	// StoreDedup skips deliveries already processed by any replica, recording them in the state store of the hook
+	StoreDedup = "store"
+
+	maxConflictRetries = 10
+)
+
+var invalidKeyChars = regexp.MustCompile(`[^-._a-zA-Z0-9]`)
+
+// DeliveryStore records which webhook deliveries have been processed so that retried deliveries are only
+// handled once
+type DeliveryStore interface {
+	// Claim records the delivery, returning false if it has been claimed already
+	Claim(id string) (bool, error)
+	// Release forgets a claimed delivery which failed to be processed, so that its redelivery is processed
+	Release(id string) error
+}
+
+// NewDeliveryStore creates the store of the given kind, returning nil for NoDedup
+func NewDeliveryStore(kind string, kubeClient kubernetes.Interface, namespace string, ttl time.Duration, shared store.Store) (DeliveryStore, error) {
+	switch kind {
+	case "", NoDedup:
+		return nil, nil
+	case MemoryDedup:
+		return newMemoryDeliveryStore(ttl), nil
+	case ConfigMapDedup:
+		return newConfigMapDeliveryStore(kubeClient, namespace, ttl), nil
+	case StoreDedup:
+		if shared == nil {
+			return nil, fmt.Errorf("no state store to record the deliveries in")
+		}
+		return &sharedDeliveryStore{store: shared, ttl: ttl}, nil
+	default:
+		return nil, fmt.Errorf("unknown delivery deduplication %q, expected one of %s, %s, %s or %s", kind, NoDedup, MemoryDedup, ConfigMapDedup, StoreDedup)
+	}
+}
+
+// sharedDeliveryStore records the deliveries in the state store of the hook
+type sharedDeliveryStore struct {
+	store store.Store
+	ttl   time.Duration
+}
+
+func (s *sharedDeliveryStore) Claim(id string) (bool, error) {
+	return s.store.SetIfAbsent("deliveries/"+id, time.Now().UTC().Format(time.RFC3339), s.ttl)
+}
+
+func (s *sharedDeliveryStore) Release(id string) error {
+	return s.store.Delete("deliveries/" + id)
+}
+
+// memoryDeliveryStore remembers the deliveries processed by this replica until their TTL expires
+type memoryDeliveryStore struct {
+	ttl time.Duration
+	now func() time.Time
+
+	lock    sync.Mutex
+	claimed map[string]time.Time
+}
+
+func newMemoryDeliveryStore(ttl time.Duration) *memoryDeliveryStore {
+	return &memoryDeliveryStore{
+		ttl:     ttl,
+		now:     time.Now,
+		claimed: map[string]time.Time{},
+	}
+}
+
+func (s *memoryDeliveryStore) Claim(id string) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := s.now()
+	for k, t := range s.claimed {
+		if now.Sub(t) > s.ttl {
+			delete(s.claimed, k)
+		}
+	}
+	if _, ok := s.claimed[id]; ok {
+		return false, nil
+	}
+	s.claimed[id] = now
+	return true, nil
+}
+
+func (s *memoryDeliveryStore) Release(id string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.claimed, id)
+	return nil
+}
+
+// configMapDeliveryStore records the deliveries in a ConfigMap shared by all the replicas. Updates use the
+// ConfigMap's resource version so that only one replica can claim a delivery.
+type configMapDeliveryStore struct {
+	kubeClient kubernetes.Interface
+	namespace  string
+	ttl        time.Duration
+	now        func() time.Time
+	// local avoids reading the ConfigMap for deliveries this replica has claimed itself
+	local *memoryDeliveryStore
+}
+
+func newConfigMapDeliveryStore(kubeClient kubernetes.Interface, namespace string, ttl time.Duration) *configMapDeliveryStore {
+	return &configMapDeliveryStore{
+		kubeClient: kubeClient,
+		namespace:  namespace,
+		ttl:        ttl,
+		now:        time.Now,
+		local:      newMemoryDeliveryStore(ttl),
+	}
+}
+
+func (s *configMapDeliveryStore) Claim(id string) (bool, error) {
+	if claimed, _ := s.local.Claim(id); !claimed {
+		return false, nil
+	}
+	claimed, err := s.claim(id)
+	if err != nil {
+		// the delivery was not recorded, so that its redelivery must not be skipped by this replica either
+		_ = s.local.Release(id)
+	}
+	return claimed, err
+}
+
+// claim records the delivery in the ConfigMap, returning false if another replica recorded it already
+func (s *configMapDeliveryStore) claim(id string) (bool, error) {
+	key := invalidKeyChars.ReplaceAllString(id, "_")
+	configMaps := s.kubeClient.CoreV1().ConfigMaps(s.namespace)
+	for i := 0; i < maxConflictRetries; i++ {
+		now := s.now()
+		cm, err := configMaps.Get(DeliveriesConfigMap, metav1.GetOptions{})
+		if kubeerrors.IsNotFound(err) {
+			cm = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: DeliveriesConfigMap},
+				Data:       map[string]string{key: now.UTC().Format(time.RFC3339)},
+			}
+			_, err = configMaps.Create(cm)
+			if kubeerrors.IsAlreadyExists(err) {
+				continue
+			}
+			if err != nil {
+				return false, errors.Wrapf(err, "creating ConfigMap %s", DeliveriesConfigMap)
+			}
+			return true, nil
+		}
+		if err != nil {
+			return false, errors.Wrapf(err, "getting ConfigMap %s", DeliveriesConfigMap)
+		}
+
+		if _, ok := cm.Data[key]; ok {
+			return false, nil
+		}
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+		for k, v := range cm.Data {
+			if t, err := time.Parse(time.RFC3339, v); err != nil || now.Sub(t) > s.ttl {
+				delete(cm.Data, k)
+			}
+		}
+		cm.Data[key] = now.UTC().Format(time.RFC3339)
+		_, err = configMaps.Update(cm)
+		if kubeerrors.IsConflict(err) {
+			continue
+		}
+		if err != nil {
+			return false, errors.Wrapf(err, "updating ConfigMap %s", DeliveriesConfigMap)
+		}
+		return true, nil
+	}
+	return false, errors.Errorf("too many conflicts updating ConfigMap %s", DeliveriesConfigMap)
+}
+
+func (s *configMapDeliveryStore) Release(id string) error {
+	_ = s.local.Release(id)
+	key := invalidKeyChars.ReplaceAllString(id, "_")
+	configMaps := s.kubeClient.CoreV1().ConfigMaps(s.namespace)
+	for i := 0; i < maxConflictRetries; i++ {
+		cm, err := configMaps.Get(DeliveriesConfigMap, metav1.GetOptions{})
+		if kubeerrors.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrapf(err, "getting ConfigMap %s", DeliveriesConfigMap)
+		}
+		if _, ok := cm.Data[key]; !ok {
+			return nil
+		}
+		delete(cm.Data, key)
+		_, err = configMaps.Update(cm)
+		if kubeerrors.IsConflict(err) {
+			continue
+		}
+		if err != nil {
+			return errors.Wrapf(err, "updating ConfigMap %s", DeliveriesConfigMap)
+		}
+		return nil
+	}
+	return errors.Errorf("too many conflicts updating ConfigMap %s", DeliveriesConfigMap)
+}
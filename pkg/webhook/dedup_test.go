@@ -0,0 +1,114 @@
+package webhook
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/lighthouse/pkg/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestMemoryDeliveryStore(t *testing.T) {
+	store := newMemoryDeliveryStore(time.Hour)
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+
+	claimed, err := store.Claim("a")
+	require.NoError(t, err)
+	assert.True(t, claimed)
+	claimed, _ = store.Claim("a")
+	assert.False(t, claimed)
+
+	now = now.Add(2 * time.Hour)
+	claimed, _ = store.Claim("a")
+	assert.True(t, claimed, "expired deliveries can be claimed again")
+}
+
+func TestConfigMapDeliveryStore(t *testing.T) {
+	kubeClient := kubefake.NewSimpleClientset()
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	replicas := []*configMapDeliveryStore{
+		newConfigMapDeliveryStore(kubeClient, "jx", time.Hour),
+		newConfigMapDeliveryStore(kubeClient, "jx", time.Hour),
+	}
+	for _, r := range replicas {
+		r.now = func() time.Time { return now }
+	}
+
+	claimed, err := replicas[0].Claim("72d3162e-cc78-11e3-81ab-4c9367dc0958")
+	require.NoError(t, err)
+	assert.True(t, claimed)
+
+	claimed, err = replicas[1].Claim("72d3162e-cc78-11e3-81ab-4c9367dc0958")
+	require.NoError(t, err)
+	assert.False(t, claimed, "another replica claimed the delivery")
+
+	now = now.Add(2 * time.Hour)
+	claimed, err = replicas[1].Claim("other/delivery")
+	require.NoError(t, err)
+	assert.True(t, claimed)
+
+	cm, err := kubeClient.CoreV1().ConfigMaps("jx").Get(DeliveriesConfigMap, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"other_delivery": "2020-01-01T02:00:00Z"}, cm.Data, "expired deliveries should be pruned")
+
+	require.NoError(t, replicas[1].Release("other/delivery"))
+	claimed, err = replicas[0].Claim("other/delivery")
+	require.NoError(t, err)
+	assert.True(t, claimed, "a released delivery can be claimed again")
+}
+
+func TestConfigMapDeliveryStoreFailedUpdate(t *testing.T) {
+	kubeClient := kubefake.NewSimpleClientset()
+	s := newConfigMapDeliveryStore(kubeClient, "jx", time.Hour)
+	fail := true
+	kubeClient.PrependReactor("create", "configmaps", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		if fail {
+			return true, nil, errors.New("etcd is unavailable")
+		}
+		return false, nil, nil
+	})
+
+	_, err := s.Claim("guid")
+	require.Error(t, err)
+
+	fail = false
+	claimed, err := s.Claim("guid")
+	require.NoError(t, err)
+	assert.True(t, claimed, "a delivery which failed to be recorded is not claimed by the replica")
+}
+
+func TestClaimDelivery(t *testing.T) {
+	o := &Options{}
+	req, err := http.NewRequest(http.MethodPost, "/hook", nil)
+	require.NoError(t, err)
+	req.Header.Set("X-GitHub-Delivery", "guid")
+	assert.True(t, o.claimDelivery(req))
+	assert.True(t, o.claimDelivery(req), "deliveries are not deduplicated by default")
+
+	o.deliveries, err = NewDeliveryStore(MemoryDedup, nil, "", time.Hour, nil)
+	require.NoError(t, err)
+	assert.True(t, o.claimDelivery(req))
+	assert.False(t, o.claimDelivery(req))
+	o.releaseDelivery(req)
+	assert.True(t, o.claimDelivery(req), "a released delivery is processed when redelivered")
+
+	o.deliveries, err = NewDeliveryStore(StoreDedup, nil, "", time.Hour, store.NewMemoryStore())
+	require.NoError(t, err)
+	assert.True(t, o.claimDelivery(req))
+	assert.False(t, o.claimDelivery(req))
+	o.releaseDelivery(req)
+	assert.True(t, o.claimDelivery(req), "a released delivery is processed when redelivered")
+
+	_, err = NewDeliveryStore(StoreDedup, nil, "", time.Hour, nil)
+	assert.Error(t, err, "the state store is required")
+	_, err = NewDeliveryStore("redis", nil, "", time.Hour, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,47 @@
+package webhook
+
+import (
+	"context"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/jx/v2/pkg/tekton/metapipeline"
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/launcher"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"github.com/sirupsen/logrus"
+)
+
+// deploymentLauncher creates a deployment on the provider for the postsubmits deploying to an environment, and
+// annotates their jobs with it so that foghorn reports the progress of the jobs as deployment statuses
+type deploymentLauncher struct {
+	launcher.PipelineLauncher
+	deployments scm.DeploymentService
+	logger      *logrus.Entry
+}
+
+// Launch creates the deployment of the job, if any, before launching it. The job is launched even if the
+// deployment cannot be created.
+func (l *deploymentLauncher) Launch(job *v1alpha1.LighthouseJob, metapipelineClient metapipeline.Client, repo scm.Repository) (*v1alpha1.LighthouseJob, error) {
+	if job.Spec.Type == config.PostsubmitJob && job.Spec.Environment != "" && job.Spec.Refs != nil {
+		fullName := scm.Join(job.Spec.Refs.Org, job.Spec.Refs.Repo)
+		deployment, _, err := l.deployments.Create(context.Background(), fullName, &scm.DeploymentInput{
+			Ref:         job.Spec.Refs.BaseSHA,
+			Task:        "deploy",
+			Environment: job.Spec.Environment,
+			Description: "Deploying with " + job.Spec.Job,
+			// the job itself tells whether the commit can be deployed
+			RequiredContexts:      []string{},
+			ProductionEnvironment: job.Spec.Environment == "production",
+		})
+		if err != nil {
+			l.logger.WithError(err).WithField("environment", job.Spec.Environment).Warnf("failed to create the deployment of %s", fullName)
+		} else {
+			if job.Annotations == nil {
+				job.Annotations = map[string]string{}
+			}
+			job.Annotations[util.DeploymentAnnotation] = deployment.ID
+		}
+	}
+	return l.PipelineLauncher.Launch(job, metapipelineClient, repo)
+}
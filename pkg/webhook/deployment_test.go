@@ -0,0 +1,82 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/launcher/fake"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeDeployments struct {
+	scm.DeploymentService
+	created []*scm.DeploymentInput
+	err     error
+}
+
+func (d *fakeDeployments) Create(ctx context.Context, repoFullName string, input *scm.DeploymentInput) (*scm.Deployment, *scm.Response, error) {
+	if d.err != nil {
+		return nil, nil, d.err
+	}
+	d.created = append(d.created, input)
+	return &scm.Deployment{ID: "42", FullName: repoFullName, Environment: input.Environment}, nil, nil
+}
+
+func deployingJob(kind config.PipelineKind, environment string) *v1alpha1.LighthouseJob {
+	return &v1alpha1.LighthouseJob{
+		Spec: v1alpha1.LighthouseJobSpec{
+			Type:        kind,
+			Job:         "deploy",
+			Environment: environment,
+			Refs: &v1alpha1.Refs{
+				Org:     "org",
+				Repo:    "repo",
+				BaseRef: "master",
+				BaseSHA: "abc",
+			},
+		},
+	}
+}
+
+func TestDeploymentLauncher(t *testing.T) {
+	jobs := fake.NewLauncher()
+	deployments := &fakeDeployments{}
+	l := &deploymentLauncher{PipelineLauncher: jobs, deployments: deployments, logger: logrus.NewEntry(logrus.StandardLogger())}
+
+	_, err := l.Launch(deployingJob(config.PostsubmitJob, "production"), nil, scm.Repository{})
+	require.NoError(t, err)
+	require.Len(t, deployments.created, 1)
+	assert.Equal(t, &scm.DeploymentInput{
+		Ref:                   "abc",
+		Task:                  "deploy",
+		Environment:           "production",
+		Description:           "Deploying with deploy",
+		RequiredContexts:      []string{},
+		ProductionEnvironment: true,
+	}, deployments.created[0])
+	assert.Equal(t, "42", jobs.Pipelines[0].Annotations[util.DeploymentAnnotation])
+
+	// jobs which do not deploy have no deployment
+	_, err = l.Launch(deployingJob(config.PostsubmitJob, ""), nil, scm.Repository{})
+	require.NoError(t, err)
+	_, err = l.Launch(deployingJob(config.PresubmitJob, "staging"), nil, scm.Repository{})
+	require.NoError(t, err)
+	assert.Len(t, deployments.created, 1)
+	require.Len(t, jobs.Pipelines, 3)
+	assert.Empty(t, jobs.Pipelines[1].Annotations[util.DeploymentAnnotation])
+	assert.Empty(t, jobs.Pipelines[2].Annotations[util.DeploymentAnnotation])
+
+	// the job is launched even if its deployment cannot be created
+	deployments.err = errors.New("forbidden")
+	_, err = l.Launch(deployingJob(config.PostsubmitJob, "staging"), nil, scm.Repository{})
+	require.NoError(t, err)
+	require.Len(t, jobs.Pipelines, 4)
+	assert.Empty(t, jobs.Pipelines[3].Annotations[util.DeploymentAnnotation])
+}
@@ -0,0 +1,48 @@
+package webhook
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/sirupsen/logrus"
+)
+
+// HandleDownstreams forwards the webhook, with its original headers and payload, to the downstream endpoints of its
+// repository which subscribe to its kind of event. Each endpoint is retried on its own, so that a failing endpoint
+// delays neither the others nor the plugins.
+func (s *Server) HandleDownstreams(l *logrus.Entry, webhook scm.Webhook, header http.Header, body []byte) {
+	if s.Plugins == nil || s.Plugins.Config() == nil {
+		return
+	}
+	repo := webhook.Repository()
+	kind := string(webhook.Kind())
+	for _, d := range s.Plugins.Config().DownstreamsFor(repo.Namespace, repo.Name) {
+		if !subscribesTo(plugins.ExternalPlugin{Events: d.Events}, kind) {
+			continue
+		}
+		s.wg.Add(1)
+		go func(d plugins.Downstream) {
+			defer s.wg.Done()
+			dl := l.WithFields(logrus.Fields{"downstream": d.Name, "endpoint": d.Endpoint})
+			backoff := d.BackoffDuration
+			for attempt := 1; ; attempt++ {
+				err := s.forwardToExternalPlugin(d.Endpoint, header, body)
+				if err == nil {
+					downstreamCounter.WithLabelValues(d.Name, "forwarded").Inc()
+					dl.Debug("Forwarded the webhook downstream.")
+					return
+				}
+				if attempt >= d.Attempts {
+					downstreamCounter.WithLabelValues(d.Name, "failed").Inc()
+					dl.WithError(err).Errorf("Error forwarding the webhook downstream after %d attempts.", attempt)
+					return
+				}
+				dl.WithError(err).Warnf("Error forwarding the webhook downstream, retrying in %s.", backoff)
+				time.Sleep(backoff)
+				backoff *= 2
+			}
+		}(d)
+	}
+}
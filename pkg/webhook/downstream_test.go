@@ -0,0 +1,59 @@
+package webhook
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleDownstreams(t *testing.T) {
+	var lock sync.Mutex
+	attempts := map[string]int{}
+	received := map[string]string{}
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		lock.Lock()
+		defer lock.Unlock()
+		attempts[r.URL.Path]++
+		if r.URL.Path == "/flaky" && attempts[r.URL.Path] == 1 || r.URL.Path == "/down" {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		received[r.URL.Path] = string(body)
+	}))
+	defer downstream.Close()
+
+	pluginAgent := &plugins.ConfigAgent{}
+	pluginAgent.Set(&plugins.Configuration{
+		Downstreams: []plugins.Downstream{
+			{Name: "jenkins", Endpoint: downstream.URL + "/jenkins", Attempts: 1},
+			{Name: "flaky", Endpoint: downstream.URL + "/flaky", Attempts: 2},
+			{Name: "down", Endpoint: downstream.URL + "/down", Attempts: 3},
+			{Name: "pushes", Endpoint: downstream.URL + "/pushes", Events: []string{"push"}, Attempts: 1},
+			{Name: "other", Endpoint: downstream.URL + "/other", Repos: []string{"org/other"}, Attempts: 1},
+			{Name: "excluded", Endpoint: downstream.URL + "/excluded", Repos: []string{"org"}, ExcludeRepos: []string{"org/repo"}, Attempts: 1},
+		},
+	})
+	server := &Server{Plugins: pluginAgent}
+
+	header := http.Header{}
+	header.Set("X-GitHub-Event", "issue_comment")
+	webhook := &scm.IssueCommentHook{Repo: scm.Repository{Namespace: "org", Name: "repo"}}
+	server.HandleDownstreams(logrus.WithField("test", t.Name()), webhook, header, []byte(`{"action":"created"}`))
+	server.wg.Wait()
+
+	assert.Equal(t, map[string]string{
+		"/jenkins": `{"action":"created"}`,
+		"/flaky":   `{"action":"created"}`,
+	}, received)
+	assert.Equal(t, map[string]int{"/jenkins": 1, "/flaky": 2, "/down": 3}, attempts)
+}
@@ -0,0 +1,93 @@
+package webhook
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/jenkins-x/lighthouse/pkg/policy"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// commentEdits remembers the commands of recent comments, so that the commands added by editing a comment are
+// handled once each, as if they had been posted in a new comment
+type commentEdits struct {
+	lock     sync.Mutex
+	comments map[string]*editedComment
+	now      func() time.Time
+}
+
+type editedComment struct {
+	expires  time.Time
+	commands sets.String
+}
+
+// process records the commands of created comments and rewrites edits of a recent comment into a created comment
+// holding only the commands which were added by the edit. Other edits are left untouched, so plugins ignore them.
+// Comments created before lighthouse started, or received by another replica, are never handled when edited.
+func (e *commentEdits) process(cfg *plugins.CommentEdits, ce *scmprovider.GenericCommentEvent) *scmprovider.GenericCommentEvent {
+	if cfg == nil || ce.GUID == "" || (ce.Action != scm.ActionCreate && ce.Action != scm.ActionEdited) {
+		return ce
+	}
+	key := fmt.Sprintf("%s/%s#%d/%s", ce.Repo.Namespace, ce.Repo.Name, ce.Number, ce.GUID)
+	commands := commandLines(ce.Body)
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	now := time.Now()
+	if e.now != nil {
+		now = e.now()
+	}
+	if e.comments == nil {
+		e.comments = map[string]*editedComment{}
+	}
+	for k, c := range e.comments {
+		if now.After(c.expires) {
+			delete(e.comments, k)
+		}
+	}
+
+	if ce.Action == scm.ActionCreate {
+		e.comments[key] = &editedComment{
+			expires:  now.Add(cfg.WindowDuration),
+			commands: sets.NewString(commands...),
+		}
+		return ce
+	}
+	seen, ok := e.comments[key]
+	if !ok {
+		return ce
+	}
+	var added []string
+	for _, command := range commands {
+		if !seen.commands.Has(command) {
+			seen.commands.Insert(command)
+			added = append(added, command)
+		}
+	}
+	if len(added) == 0 {
+		return ce
+	}
+	edited := *ce
+	edited.Action = scm.ActionCreate
+	edited.Body = strings.Join(added, "\n")
+	return &edited
+}
+
+// commandLines returns the commands of the comment in a normalised form
+func commandLines(body string) []string {
+	var answer []string
+	for _, command := range policy.ParseCommands(body) {
+		line := util.FormatCommand("/" + command.Name)
+		if command.Args != "" {
+			line += " " + command.Args
+		}
+		answer = append(answer, line)
+	}
+	return answer
+}
@@ -0,0 +1,47 @@
+package webhook
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommentEdits(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	edits := &commentEdits{now: func() time.Time { return now }}
+	cfg := &plugins.CommentEdits{WindowDuration: 10 * time.Minute}
+	comment := func(guid string, action scm.Action, body string) *scmprovider.GenericCommentEvent {
+		return &scmprovider.GenericCommentEvent{
+			GUID:   guid,
+			Action: action,
+			Body:   body,
+			Number: 1,
+			Repo:   scm.Repository{Namespace: "org", Name: "repo"},
+		}
+	}
+
+	created := comment("1", scm.ActionCreate, "/tset foo\n/lgtm")
+	assert.Equal(t, created, edits.process(cfg, created))
+
+	e := edits.process(cfg, comment("1", scm.ActionEdited, "/test foo\n/lgtm"))
+	assert.Equal(t, scm.ActionCreate, e.Action)
+	assert.Equal(t, "/test foo", e.Body, "only the commands added by the edit are handled")
+
+	e = edits.process(cfg, comment("1", scm.ActionEdited, "/test foo\n/lgtm\nthanks"))
+	assert.Equal(t, scm.ActionEdited, e.Action, "commands are not triggered twice")
+
+	e = edits.process(nil, comment("1", scm.ActionEdited, "/test bar"))
+	assert.Equal(t, scm.ActionEdited, e.Action, "edits are ignored unless configured")
+
+	e = edits.process(cfg, comment("2", scm.ActionEdited, "/test bar"))
+	assert.Equal(t, scm.ActionEdited, e.Action, "edits of unknown comments are ignored")
+
+	now = now.Add(11 * time.Minute)
+	e = edits.process(cfg, comment("1", scm.ActionEdited, "/test bar"))
+	assert.Equal(t, scm.ActionEdited, e.Action, "edits after the window are ignored")
+	assert.Empty(t, edits.comments)
+}
@@ -17,15 +17,21 @@ limitations under the License.
 package webhook
 
 import (
+	"net/http"
 	"net/url"
 	"strconv"
 	"sync"
+	"time"
 
 	"github.com/jenkins-x/go-scm/scm"
 	"github.com/jenkins-x/jx/v2/pkg/jxfactory"
 	"github.com/jenkins-x/jx/v2/pkg/tekton/metapipeline"
 	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/audit"
+	"github.com/jenkins-x/lighthouse/pkg/canary"
+	"github.com/jenkins-x/lighthouse/pkg/payload"
 	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/jenkins-x/lighthouse/pkg/policy"
 	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
 	"github.com/sirupsen/logrus"
 )
@@ -34,21 +40,35 @@ import (
 type Server struct {
 	ClientFactory      jxfactory.Factory
 	MetapipelineClient metapipeline.Client
-	ClientAgent        *plugins.ClientAgent
-	Plugins            *plugins.ConfigAgent
-	ConfigAgent        *config.Agent
-	ServerURL          *url.URL
-	TokenGenerator     func() []byte
-	Metrics            *Metrics
+	// ClientAgent holds the clients of the plugins handling the events of ProcessWebHook, the events delivered to the
+	// hook or polled being handled with the clients created for the owner of each of them
+	ClientAgent    *plugins.ClientAgent
+	Plugins        *plugins.ConfigAgent
+	ConfigAgent    *config.Agent
+	ServerURL      *url.URL
+	TokenGenerator func() []byte
+	Metrics        *Metrics
+	// Canary holds the canary config.yaml used by the events of some repositories instead of ConfigAgent
+	Canary *canary.Agent
+	// ExternalPluginClient sends the webhooks to external plugins, defaulting to a client with a timeout
+	ExternalPluginClient *http.Client
+	// PluginTimeout is the duration after which a plugin still handling an event is reported, defaulting to DefaultPluginTimeout
+	PluginTimeout time.Duration
 
 	// Tracks running handlers for graceful shutdown
 	wg sync.WaitGroup
+	// queues are the event queues of the workers running the plugins, which run in their own goroutine if nil
+	queues *eventQueues
+	// edits tracks the commands of recent comments to handle the commands added by editing them
+	edits commentEdits
+	// guard tracks the commands of bots to ignore those they keep repeating
+	guard commandGuard
 }
 
 const failedCommentCoerceFmt = "Could not coerce %s event to a GenericCommentEvent. Unknown 'action': %q."
 
 // HandleIssueCommentEvent handle comment events
-func (s *Server) HandleIssueCommentEvent(l *logrus.Entry, ic scm.IssueCommentHook) {
+func (s *Server) HandleIssueCommentEvent(l *logrus.Entry, clientAgent *plugins.ClientAgent, ic scm.IssueCommentHook) {
 	l = l.WithFields(logrus.Fields{
 		scmprovider.OrgLogField:  ic.Repo.Namespace,
 		scmprovider.RepoLogField: ic.Repo.Name,
@@ -58,25 +78,25 @@ func (s *Server) HandleIssueCommentEvent(l *logrus.Entry, ic scm.IssueCommentHoo
 	})
 	l.Infof("Issue comment %s.", ic.Action)
 	for p, h := range s.Plugins.IssueCommentHandlers(ic.Repo.Namespace, ic.Repo.Name) {
-		s.wg.Add(1)
-		go func(p string, h plugins.IssueCommentHandler) {
-			defer s.wg.Done()
-			agent := plugins.NewAgent(s.ClientFactory, s.ConfigAgent, s.Plugins, s.ClientAgent, s.MetapipelineClient, s.ServerURL, l.WithField("plugin", p))
+		h := h
+		s.runQueuedPlugin(commentQueue("IssueCommentEvent", ic.Comment.Body), l, p, "IssueCommentEvent", func(l *logrus.Entry) error {
+			agent := plugins.NewAgent(s.ClientFactory, s.configAgentFor(ic.Repo), s.Plugins, clientAgent, s.MetapipelineClient, s.ServerURL, l)
 			agent.InitializeCommentPruner(
 				ic.Repo.Namespace,
 				ic.Repo.Name,
 				ic.Issue.Number,
 			)
-			if err := h(agent, ic); err != nil {
-				agent.Logger.WithError(err).Error("Error handling IssueCommentEvent.")
-			}
-		}(p, h)
+			return h(agent, ic)
+		})
 	}
 
 	s.handleGenericComment(
 		l,
+		clientAgent,
 		&scmprovider.GenericCommentEvent{
 			GUID:        strconv.Itoa(ic.Comment.ID),
+			CommentID:   ic.Comment.ID,
+			CommentKind: scmprovider.IssueComment,
 			IsPR:        ic.Issue.PullRequest,
 			Action:      ic.Action,
 			Body:        ic.Comment.Body,
@@ -94,7 +114,7 @@ func (s *Server) HandleIssueCommentEvent(l *logrus.Entry, ic scm.IssueCommentHoo
 }
 
 // HandlePullRequestCommentEvent handles pull request comments events
-func (s *Server) HandlePullRequestCommentEvent(l *logrus.Entry, pc scm.PullRequestCommentHook) {
+func (s *Server) HandlePullRequestCommentEvent(l *logrus.Entry, clientAgent *plugins.ClientAgent, pc scm.PullRequestCommentHook) {
 	l = l.WithFields(logrus.Fields{
 		scmprovider.OrgLogField:  pc.Repo.Namespace,
 		scmprovider.RepoLogField: pc.Repo.Name,
@@ -106,8 +126,11 @@ func (s *Server) HandlePullRequestCommentEvent(l *logrus.Entry, pc scm.PullReque
 
 	s.handleGenericComment(
 		l,
+		clientAgent,
 		&scmprovider.GenericCommentEvent{
 			GUID:        strconv.Itoa(pc.Comment.ID),
+			CommentID:   pc.Comment.ID,
+			CommentKind: scmprovider.ReviewComment,
 			IsPR:        true,
 			Action:      pc.Action,
 			Body:        pc.Comment.Body,
@@ -124,26 +147,188 @@ func (s *Server) HandlePullRequestCommentEvent(l *logrus.Entry, pc scm.PullReque
 	)
 }
 
-func (s *Server) handleGenericComment(l *logrus.Entry, ce *scmprovider.GenericCommentEvent) {
-	for p, h := range s.Plugins.GenericCommentHandlers(ce.Repo.Namespace, ce.Repo.Name) {
+// HandleCommitCommentEvent handles comments on commits, which reach the plugins as comments on each open pull
+// request whose head is the commit
+func (s *Server) HandleCommitCommentEvent(l *logrus.Entry, clientAgent *plugins.ClientAgent, cc payload.CommitCommentHook) {
+	l = l.WithFields(logrus.Fields{
+		scmprovider.OrgLogField:  cc.Repo.Namespace,
+		scmprovider.RepoLogField: cc.Repo.Name,
+		"sha":                    cc.SHA,
+		"author":                 cc.Comment.Author.Login,
+		"url":                    cc.Comment.Link,
+	})
+	l.Infof("Commit comment %s.", cc.Action)
+	spc := scmprovider.ToClient(clientAgent.SCMProviderClient, clientAgent.BotName)
+	prs, err := pullRequestsForCommit(spc, cc.Repo, cc.SHA)
+	if err != nil {
+		l.WithError(err).Error("Failed to find the pull requests of the commit.")
+		return
+	}
+	for _, pr := range prs {
+		s.handleGenericComment(
+			l.WithField(scmprovider.PrLogField, pr.Number),
+			clientAgent,
+			&scmprovider.GenericCommentEvent{
+				GUID:        strconv.Itoa(cc.Comment.ID),
+				CommentID:   cc.Comment.ID,
+				CommentKind: scmprovider.CommitComment,
+				IsPR:        true,
+				Action:      cc.Action,
+				Body:        cc.Comment.Body,
+				Link:        cc.Comment.Link,
+				Number:      pr.Number,
+				Repo:        cc.Repo,
+				Author:      cc.Comment.Author,
+				IssueAuthor: pr.Author,
+				Assignees:   pr.Assignees,
+				IssueState:  "open",
+				IssueBody:   pr.Body,
+				IssueLink:   pr.Link,
+			},
+		)
+	}
+}
+
+type pullRequestLister interface {
+	ListAllPullRequestsForFullNameRepo(fullName string, opts scm.PullRequestListOptions) ([]*scm.PullRequest, error)
+}
+
+// pullRequestsForCommit returns the open pull requests of the repository whose head is the commit
+func pullRequestsForCommit(spc pullRequestLister, repo scm.Repository, sha string) ([]*scm.PullRequest, error) {
+	fullName := repo.FullName
+	if fullName == "" {
+		fullName = scm.Join(repo.Namespace, repo.Name)
+	}
+	prs, err := spc.ListAllPullRequestsForFullNameRepo(fullName, scm.PullRequestListOptions{Open: true, Size: 100})
+	if err != nil {
+		return nil, err
+	}
+	var answer []*scm.PullRequest
+	for _, pr := range prs {
+		if pr.Head.Sha == sha || pr.Sha == sha {
+			answer = append(answer, pr)
+		}
+	}
+	return answer, nil
+}
+
+func (s *Server) handleGenericComment(l *logrus.Entry, clientAgent *plugins.ClientAgent, ce *scmprovider.GenericCommentEvent) {
+	if s.Plugins != nil && s.Plugins.Config() != nil {
+		edited := s.edits.process(s.Plugins.Config().CommentEditsFor(ce.Repo.Namespace, ce.Repo.Name), ce)
+		if edited != ce {
+			l.Infof("Handling the commands added by editing the comment: %q.", edited.Body)
+		}
+		ce = edited
+	}
+	if ce.Action != scm.ActionDelete {
+		body := ce.Body
+		if s.Plugins != nil && s.Plugins.Config() != nil && clientAgent != nil {
+			ce.Body = s.guard.process(s.Plugins.Config().CommandGuardFor(ce.Repo.Namespace, ce.Repo.Name), clientAgent.BotName, ce, l)
+		}
+		if authorizer := s.chatOpsAuthorizer(); authorizer != nil {
+			switch {
+			case clientAgent != nil:
+				ce.Body = authorizer.Authorize(scmprovider.ToClient(clientAgent.SCMProviderClient, clientAgent.BotName), ce, l)
+			case authorizer.FailOpen:
+				l.Error("not authorizing the commands of the comment with the chat ops policy as there is no SCM client")
+			default:
+				l.Error("denying the commands of the comment as the chat ops policy cannot be evaluated without an SCM client")
+				ce.Body = policy.FilterCommands(ce.Body, func(policy.Command) bool { return false })
+			}
+		}
+		recordCommands(ce, body)
+	}
+	handlers := s.Plugins.GenericCommentHandlers(ce.Repo.Namespace, ce.Repo.Name)
+	var segments []string
+	if ce.Action == scm.ActionCreate {
+		segments = policy.SplitCommands(ce.Body)
+	}
+	if len(segments) > 0 {
+		s.handleCommandBatch(l, clientAgent, ce, handlers, segments)
+	} else {
+		for p, h := range handlers {
+			h := h
+			s.runQueuedPlugin(commentQueue("GenericCommentEvent", ce.Body), l, p, "GenericCommentEvent", func(l *logrus.Entry) error {
+				agent := plugins.NewAgent(s.ClientFactory, s.configAgentFor(ce.Repo), s.Plugins, clientAgent, s.MetapipelineClient, s.ServerURL, l)
+				agent.InitializeCommentPruner(
+					ce.Repo.Namespace,
+					ce.Repo.Name,
+					ce.Number,
+				)
+				return h(agent, *ce)
+			})
+		}
+	}
+	if ce.Action == scm.ActionCreate && plugins.HelpCommandRe.MatchString(ce.Body) {
 		s.wg.Add(1)
-		go func(p string, h plugins.GenericCommentHandler) {
+		go func() {
 			defer s.wg.Done()
-			agent := plugins.NewAgent(s.ClientFactory, s.ConfigAgent, s.Plugins, s.ClientAgent, s.MetapipelineClient, s.ServerURL, l.WithField("plugin", p))
-			agent.InitializeCommentPruner(
-				ce.Repo.Namespace,
-				ce.Repo.Name,
-				ce.Number,
-			)
-			if err := h(agent, *ce); err != nil {
-				agent.Logger.WithError(err).Error("Error handling GenericCommentEvent.")
+			if err := s.respondHelp(clientAgent, ce); err != nil {
+				l.WithError(err).Error("Error responding with the available commands.")
 			}
-		}(p, h)
+		}()
+	}
+}
+
+// respondHelp replies to /lh-help with the commands of the plugins enabled for the repository
+func (s *Server) respondHelp(clientAgent *plugins.ClientAgent, ce *scmprovider.GenericCommentEvent) error {
+	cfg := s.Plugins.Config()
+	if cfg == nil {
+		return nil
+	}
+	org, repo := ce.Repo.Namespace, ce.Repo.Name
+	spc := scmprovider.ToClient(clientAgent.SCMProviderClient, clientAgent.BotName)
+	reply := plugins.HelpResponse(cfg, org, repo)
+	return spc.CreateComment(org, repo, ce.Number, ce.IsPR, plugins.FormatResponseRaw(ce.Body, ce.Link, spc.QuoteAuthorForComment(ce.Author.Login), reply))
+}
+
+// recordCommands records the chat commands of the original comment body to the audit stream, as denied if
+// the chat command policy removed them from the event
+func recordCommands(ce *scmprovider.GenericCommentEvent, body string) {
+	if !audit.Default().Enabled() {
+		return
+	}
+	allowed := map[policy.Command]int{}
+	for _, command := range policy.ParseCommands(ce.Body) {
+		allowed[command]++
+	}
+	for _, command := range policy.ParseCommands(body) {
+		outcome := audit.OutcomeDenied
+		if allowed[command] > 0 {
+			allowed[command]--
+			outcome = audit.OutcomeAccepted
+		}
+		audit.Record(audit.Event{
+			Type:    audit.CommandEvent,
+			Actor:   ce.Author.Login,
+			Org:     ce.Repo.Namespace,
+			Repo:    ce.Repo.Name,
+			Number:  ce.Number,
+			Command: command.Name,
+			Args:    command.Args,
+			Outcome: outcome,
+		})
+	}
+}
+
+// chatOpsAuthorizer returns the authorizer of chat commands, or nil if no policy is configured
+func (s *Server) chatOpsAuthorizer() *policy.Authorizer {
+	if s.Plugins == nil || s.Plugins.Config() == nil {
+		return nil
+	}
+	cfg := s.Plugins.Config().ChatOpsPolicy
+	if cfg.URL == "" {
+		return nil
+	}
+	return &policy.Authorizer{
+		Evaluator:    policy.NewOPAEvaluator(cfg.URL, cfg.TimeoutDuration),
+		IncludeTeams: cfg.IncludeTeams,
+		FailOpen:     cfg.FailOpen,
 	}
 }
 
 // HandlePushEvent handles a push event
-func (s *Server) HandlePushEvent(l *logrus.Entry, pe *scm.PushHook) {
+func (s *Server) HandlePushEvent(l *logrus.Entry, clientAgent *plugins.ClientAgent, pe *scm.PushHook) {
 	repo := pe.Repository()
 	l = l.WithFields(logrus.Fields{
 		scmprovider.OrgLogField:  repo.Namespace,
@@ -152,23 +337,63 @@ func (s *Server) HandlePushEvent(l *logrus.Entry, pe *scm.PushHook) {
 		"head":                   pe.After,
 	})
 	l.Info("Push event.")
+	if clientAgent != nil && clientAgent.OwnersCache != nil {
+		clientAgent.OwnersCache.Push(*pe)
+	}
 	c := 0
 	for p, h := range s.Plugins.PushEventHandlers(repo.Namespace, repo.Name) {
-		s.wg.Add(1)
 		c++
-		go func(p string, h plugins.PushEventHandler) {
-			defer s.wg.Done()
-			agent := plugins.NewAgent(s.ClientFactory, s.ConfigAgent, s.Plugins, s.ClientAgent, s.MetapipelineClient, s.ServerURL, l.WithField("plugin", p))
-			if err := h(agent, *pe); err != nil {
-				agent.Logger.WithError(err).Error("Error handling PushEvent.")
-			}
-		}(p, h)
+		h := h
+		s.runPlugin(l, p, "PushEvent", func(l *logrus.Entry) error {
+			agent := plugins.NewAgent(s.ClientFactory, s.configAgentFor(repo), s.Plugins, clientAgent, s.MetapipelineClient, s.ServerURL, l)
+			return h(agent, *pe)
+		})
 	}
 	l.WithField("count", strconv.Itoa(c)).Info("number of push handlers")
 }
 
+// HandleStatusEvent handles a change of the state of a context of a commit, either a commit status or a check run
+func (s *Server) HandleStatusEvent(l *logrus.Entry, clientAgent *plugins.ClientAgent, se *payload.StatusHook) {
+	repo := se.Repository()
+	l = l.WithFields(logrus.Fields{
+		scmprovider.OrgLogField:  repo.Namespace,
+		scmprovider.RepoLogField: repo.Name,
+		"sha":                    se.SHA,
+		"context":                se.Context,
+		"state":                  se.State,
+	})
+	l.Debug("Status event.")
+	for p, h := range s.Plugins.StatusEventHandlers(repo.Namespace, repo.Name) {
+		h := h
+		s.runPlugin(l, p, "StatusEvent", func(l *logrus.Entry) error {
+			agent := plugins.NewAgent(s.ClientFactory, s.configAgentFor(repo), s.Plugins, clientAgent, s.MetapipelineClient, s.ServerURL, l)
+			return h(agent, *se)
+		})
+	}
+}
+
+// HandleCheckRerequestEvent handles a request to run a check run or a check suite of a commit again
+func (s *Server) HandleCheckRerequestEvent(l *logrus.Entry, clientAgent *plugins.ClientAgent, ce *payload.CheckRerequestHook) {
+	repo := ce.Repository()
+	l = l.WithFields(logrus.Fields{
+		scmprovider.OrgLogField:  repo.Namespace,
+		scmprovider.RepoLogField: repo.Name,
+		"sha":                    ce.SHA,
+		"check":                  ce.Name,
+		"author":                 ce.Sender.Login,
+	})
+	l.Info("Check rerequested.")
+	for p, h := range s.Plugins.CheckRerequestHandlers(repo.Namespace, repo.Name) {
+		h := h
+		s.runPlugin(l, p, "CheckRerequestEvent", func(l *logrus.Entry) error {
+			agent := plugins.NewAgent(s.ClientFactory, s.configAgentFor(repo), s.Plugins, clientAgent, s.MetapipelineClient, s.ServerURL, l)
+			return h(agent, *ce)
+		})
+	}
+}
+
 // HandlePullRequestEvent handles a pull request event
-func (s *Server) HandlePullRequestEvent(l *logrus.Entry, pr *scm.PullRequestHook) {
+func (s *Server) HandlePullRequestEvent(l *logrus.Entry, clientAgent *plugins.ClientAgent, pr *scm.PullRequestHook) {
 	l = l.WithFields(logrus.Fields{
 		scmprovider.OrgLogField:  pr.Repo.Namespace,
 		scmprovider.RepoLogField: pr.Repo.Name,
@@ -184,20 +409,17 @@ func (s *Server) HandlePullRequestEvent(l *logrus.Entry, pr *scm.PullRequestHook
 		repo = pr.Repo
 	}
 	for p, h := range s.Plugins.PullRequestHandlers(repo.Namespace, repo.Name) {
-		s.wg.Add(1)
 		c++
-		go func(p string, h plugins.PullRequestHandler) {
-			defer s.wg.Done()
-			agent := plugins.NewAgent(s.ClientFactory, s.ConfigAgent, s.Plugins, s.ClientAgent, s.MetapipelineClient, s.ServerURL, l.WithField("plugin", p))
+		h := h
+		s.runQueuedPlugin(pullRequestQueue(action), l, p, "PullRequestEvent", func(l *logrus.Entry) error {
+			agent := plugins.NewAgent(s.ClientFactory, s.configAgentFor(repo), s.Plugins, clientAgent, s.MetapipelineClient, s.ServerURL, l)
 			agent.InitializeCommentPruner(
 				pr.Repo.Namespace,
 				pr.Repo.Name,
 				pr.PullRequest.Number,
 			)
-			if err := h(agent, *pr); err != nil {
-				agent.Logger.WithError(err).Error("Error handling PullRequestEvent.")
-			}
-		}(p, h)
+			return h(agent, *pr)
+		})
 	}
 	l.WithField("count", strconv.Itoa(c)).Info("number of PR handlers")
 
@@ -206,6 +428,7 @@ func (s *Server) HandlePullRequestEvent(l *logrus.Entry, pr *scm.PullRequestHook
 	}
 	s.handleGenericComment(
 		l,
+		clientAgent,
 		&scmprovider.GenericCommentEvent{
 			GUID:        pr.GUID,
 			IsPR:        true,
@@ -230,7 +453,7 @@ func (s *Server) HandleBranchEvent(entry *logrus.Entry, hook *scm.BranchHook) {
 }
 
 // HandleReviewEvent handles a PR review event
-func (s *Server) HandleReviewEvent(l *logrus.Entry, re scm.ReviewHook) {
+func (s *Server) HandleReviewEvent(l *logrus.Entry, clientAgent *plugins.ClientAgent, re scm.ReviewHook) {
 	l = l.WithFields(logrus.Fields{
 		scmprovider.OrgLogField:  re.Repo.Namespace,
 		scmprovider.RepoLogField: re.Repo.Name,
@@ -241,19 +464,16 @@ func (s *Server) HandleReviewEvent(l *logrus.Entry, re scm.ReviewHook) {
 	})
 	l.Infof("Review %s.", re.Action)
 	for p, h := range s.Plugins.ReviewEventHandlers(re.PullRequest.Base.Repo.Namespace, re.PullRequest.Base.Repo.Name) {
-		s.wg.Add(1)
-		go func(p string, h plugins.ReviewEventHandler) {
-			defer s.wg.Done()
-			agent := plugins.NewAgent(s.ClientFactory, s.ConfigAgent, s.Plugins, s.ClientAgent, s.MetapipelineClient, s.ServerURL, l.WithField("plugin", p))
+		h := h
+		s.runPlugin(l, p, "ReviewEvent", func(l *logrus.Entry) error {
+			agent := plugins.NewAgent(s.ClientFactory, s.configAgentFor(re.PullRequest.Base.Repo), s.Plugins, clientAgent, s.MetapipelineClient, s.ServerURL, l)
 			agent.InitializeCommentPruner(
 				re.Repo.Namespace,
 				re.Repo.Name,
 				re.PullRequest.Number,
 			)
-			if err := h(agent, re); err != nil {
-				agent.Logger.WithError(err).Error("Error handling ReviewEvent.")
-			}
-		}(p, h)
+			return h(agent, re)
+		})
 	}
 	action := re.Action
 	if !actionRelatesToPullRequestComment(action, l) {
@@ -261,6 +481,7 @@ func (s *Server) HandleReviewEvent(l *logrus.Entry, re scm.ReviewHook) {
 	}
 	s.handleGenericComment(
 		l,
+		clientAgent,
 		&scmprovider.GenericCommentEvent{
 			GUID:        re.GUID,
 			IsPR:        true,
@@ -0,0 +1,102 @@
+package webhook
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/go-scm/scm/driver/fake"
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakePullRequestLister struct {
+	fullName string
+	prs      []*scm.PullRequest
+}
+
+func (f *fakePullRequestLister) ListAllPullRequestsForFullNameRepo(fullName string, opts scm.PullRequestListOptions) ([]*scm.PullRequest, error) {
+	f.fullName = fullName
+	return f.prs, nil
+}
+
+func TestPullRequestsForCommit(t *testing.T) {
+	lister := &fakePullRequestLister{
+		prs: []*scm.PullRequest{
+			{Number: 1, Head: scm.PullRequestBranch{Sha: "abc"}},
+			{Number: 2, Head: scm.PullRequestBranch{Sha: "def"}},
+			{Number: 3, Sha: "abc"},
+		},
+	}
+	prs, err := pullRequestsForCommit(lister, scm.Repository{Namespace: "org", Name: "repo"}, "abc")
+	require.NoError(t, err)
+	assert.Equal(t, "org/repo", lister.fullName)
+
+	var numbers []int
+	for _, pr := range prs {
+		numbers = append(numbers, pr.Number)
+	}
+	assert.Equal(t, []int{1, 3}, numbers)
+}
+
+func TestHandleGenericCommentWithoutClientAgent(t *testing.T) {
+	for _, failOpen := range []bool{false, true} {
+		pluginAgent := &plugins.ConfigAgent{}
+		pluginAgent.Set(&plugins.Configuration{
+			ChatOpsPolicy: plugins.ChatOpsPolicy{URL: "http://opa.example.com/v1/data/lighthouse/allow", FailOpen: failOpen},
+		})
+		s := &Server{Plugins: pluginAgent}
+		ce := &scmprovider.GenericCommentEvent{
+			Action: scm.ActionCreate,
+			Body:   "/lgtm",
+			Repo:   scm.Repository{Namespace: "org", Name: "repo"},
+		}
+		assert.NotPanics(t, func() {
+			s.handleGenericComment(logrus.WithField("test", t.Name()), nil, ce)
+			s.wg.Wait()
+		})
+		if failOpen {
+			assert.Equal(t, "/lgtm", ce.Body, "the commands are kept when the policy fails open")
+		} else {
+			assert.Empty(t, ce.Body, "the commands cannot be authorized without an SCM client")
+		}
+	}
+}
+
+func TestHandleIssueCommentEventClientAgent(t *testing.T) {
+	var bots []string
+	plugins.RegisterGenericCommentHandler("test-client-agent", func(agent plugins.Agent, e scmprovider.GenericCommentEvent) error {
+		bot, err := agent.SCMProviderClient.BotName()
+		bots = append(bots, bot)
+		return err
+	}, nil)
+	configAgent := &config.Agent{}
+	configAgent.Set(&config.Config{})
+	pluginAgent := &plugins.ConfigAgent{}
+	pluginAgent.Set(&plugins.Configuration{
+		Plugins: map[string][]string{"org/repo": {"test-client-agent"}},
+	})
+	s := &Server{ConfigAgent: configAgent, Plugins: pluginAgent, queues: newEventQueues(0, 10)}
+	clientAgent := func(bot string) *plugins.ClientAgent {
+		client, _ := fake.NewDefault()
+		return &plugins.ClientAgent{BotName: bot, SCMProviderClient: client}
+	}
+	ic := scm.IssueCommentHook{
+		Action:  scm.ActionCreate,
+		Repo:    scm.Repository{Namespace: "org", Name: "repo"},
+		Issue:   scm.Issue{Number: 1},
+		Comment: scm.Comment{Body: "looks good"},
+	}
+	l := logrus.WithField("test", t.Name())
+
+	// the queued handlers use the clients of their own event, even when the next event was delivered by another owner
+	s.HandleIssueCommentEvent(l, clientAgent("bot-a"), ic)
+	s.HandleIssueCommentEvent(l, clientAgent("bot-b"), ic)
+	s.queues.next()()
+	s.queues.next()()
+	s.Wait()
+	assert.Equal(t, []string{"bot-a", "bot-b"}, bots)
+}
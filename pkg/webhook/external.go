@@ -0,0 +1,97 @@
+package webhook
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// ExternalPluginTimeout is how long an external plugin has to accept a webhook
+const ExternalPluginTimeout = 30 * time.Second
+
+// hopHeaders are not forwarded to external plugins as they only apply to the connection with the hook
+var hopHeaders = []string{
+	"Connection",
+	"Content-Length",
+	"Keep-Alive",
+	"Proxy-Authorization",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// HandleExternalPlugins forwards the webhook, with its original headers and payload, to the external plugins
+// enabled for its repository which subscribe to its kind of event. As the payload is unchanged, plugins can
+// validate its signature with the same HMAC token as the hook.
+func (s *Server) HandleExternalPlugins(l *logrus.Entry, webhook scm.Webhook, header http.Header, body []byte) {
+	if s.Plugins == nil || s.Plugins.Config() == nil {
+		return
+	}
+	repo := webhook.Repository()
+	kind := string(webhook.Kind())
+	for _, p := range s.Plugins.Config().ExternalPluginsFor(repo.Namespace, repo.Name) {
+		if !subscribesTo(p, kind) {
+			continue
+		}
+		s.wg.Add(1)
+		go func(p plugins.ExternalPlugin) {
+			defer s.wg.Done()
+			pl := l.WithFields(logrus.Fields{"external-plugin": p.Name, "endpoint": p.Endpoint})
+			if err := s.forwardToExternalPlugin(p.Endpoint, header, body); err != nil {
+				pl.WithError(err).Error("Error forwarding the webhook to the external plugin.")
+				return
+			}
+			pl.Debug("Forwarded the webhook to the external plugin.")
+		}(p)
+	}
+}
+
+func (s *Server) forwardToExternalPlugin(endpoint string, header http.Header, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrapf(err, "creating request for %s", endpoint)
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	for _, name := range hopHeaders {
+		req.Header.Del(name)
+	}
+	client := s.ExternalPluginClient
+	if client == nil {
+		client = &http.Client{Timeout: ExternalPluginTimeout}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// drain the response so that the connection can be reused
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.Errorf("response has status %q", resp.Status)
+	}
+	return nil
+}
+
+// subscribesTo returns true if the external plugin handles the kind of webhook, which all plugins without
+// events do
+func subscribesTo(p plugins.ExternalPlugin, kind string) bool {
+	if len(p.Events) == 0 {
+		return true
+	}
+	for _, event := range p.Events {
+		if event == kind {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,60 @@
+package webhook
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleExternalPlugins(t *testing.T) {
+	var lock sync.Mutex
+	received := map[string]string{}
+	signatures := map[string]string{}
+	plugin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		lock.Lock()
+		defer lock.Unlock()
+		received[r.URL.Path] = string(body)
+		signatures[r.URL.Path] = r.Header.Get("X-Hub-Signature")
+	}))
+	defer plugin.Close()
+
+	pluginAgent := &plugins.ConfigAgent{}
+	pluginAgent.Set(&plugins.Configuration{
+		ExternalPlugins: map[string][]plugins.ExternalPlugin{
+			"org": {
+				{Name: "all", Endpoint: plugin.URL + "/all"},
+				{Name: "pushes", Endpoint: plugin.URL + "/pushes", Events: []string{"push"}},
+			},
+			"org/repo": {
+				{Name: "comments", Endpoint: plugin.URL + "/comments", Events: []string{"issue_comment"}},
+			},
+			"org/other": {
+				{Name: "other", Endpoint: plugin.URL + "/other"},
+			},
+		},
+	})
+	server := &Server{Plugins: pluginAgent}
+
+	header := http.Header{}
+	header.Set("X-GitHub-Event", "issue_comment")
+	header.Set("X-Hub-Signature", "sha1=abc")
+	webhook := &scm.IssueCommentHook{Repo: scm.Repository{Namespace: "org", Name: "repo"}}
+	server.HandleExternalPlugins(logrus.WithField("test", t.Name()), webhook, header, []byte(`{"action":"created"}`))
+	server.wg.Wait()
+
+	assert.Equal(t, map[string]string{
+		"/all":      `{"action":"created"}`,
+		"/comments": `{"action":"created"}`,
+	}, received)
+	assert.Equal(t, "sha1=abc", signatures["/comments"])
+}
@@ -0,0 +1,90 @@
+package webhook
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/jenkins-x/lighthouse/pkg/policy"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	"github.com/sirupsen/logrus"
+)
+
+// commandGuard removes the commands of the comments which must not be handled: those of the bot itself, of the
+// ignored users and the commands bots keep repeating, which would otherwise start feedback loops between automations
+type commandGuard struct {
+	lock sync.Mutex
+	// uses are the times each bot used each command on each pull request or issue
+	uses map[string][]time.Time
+	now  func() time.Time
+}
+
+// process returns the body of the comment without the commands which must not be handled
+func (g *commandGuard) process(cfg *plugins.CommandGuard, botName string, ce *scmprovider.GenericCommentEvent, l *logrus.Entry) string {
+	if len(policy.ParseCommands(ce.Body)) == 0 {
+		return ce.Body
+	}
+	author := strings.ToLower(ce.Author.Login)
+	ignore := func(policy.Command) bool { return false }
+	if author == strings.ToLower(botName) {
+		l.Debug("Ignoring the commands of the bot.")
+		return policy.FilterCommands(ce.Body, ignore)
+	}
+	if cfg == nil {
+		return ce.Body
+	}
+	for _, user := range cfg.IgnoredUsers {
+		if author == strings.ToLower(user) {
+			l.Info("Ignoring the commands of an ignored user.")
+			return policy.FilterCommands(ce.Body, ignore)
+		}
+	}
+	if !isBot(cfg, author) {
+		return ce.Body
+	}
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	now := time.Now()
+	if g.now != nil {
+		now = g.now()
+	}
+	if g.uses == nil {
+		g.uses = map[string][]time.Time{}
+	}
+	for k, uses := range g.uses {
+		if !now.Before(uses[len(uses)-1].Add(cfg.LoopWindowDuration)) {
+			delete(g.uses, k)
+		}
+	}
+	return policy.FilterCommands(ce.Body, func(command policy.Command) bool {
+		key := fmt.Sprintf("%s/%s#%d/%s/%s %s", ce.Repo.Namespace, ce.Repo.Name, ce.Number, author, command.Name, command.Args)
+		var recent []time.Time
+		for _, t := range g.uses[key] {
+			if now.Before(t.Add(cfg.LoopWindowDuration)) {
+				recent = append(recent, t)
+			}
+		}
+		if len(recent) >= cfg.LoopLimit {
+			l.WithField("command", command.Name).Warnf("Ignoring a command the bot used %d times within %s.", len(recent), cfg.LoopWindowDuration)
+			return false
+		}
+		g.uses[key] = append(recent, now)
+		return true
+	})
+}
+
+// isBot returns true if the login is one of an automation account, whose repeated commands are ignored
+func isBot(cfg *plugins.CommandGuard, login string) bool {
+	if strings.HasSuffix(login, "[bot]") {
+		return true
+	}
+	for _, bot := range cfg.Bots {
+		if login == strings.ToLower(bot) {
+			return true
+		}
+	}
+	return false
+}
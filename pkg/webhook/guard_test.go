@@ -0,0 +1,49 @@
+package webhook
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommandGuard(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	guard := &commandGuard{now: func() time.Time { return now }}
+	cfg := &plugins.CommandGuard{
+		IgnoredUsers:       []string{"Other-CI"},
+		Bots:               []string{"release-bot"},
+		LoopLimit:          2,
+		LoopWindowDuration: time.Hour,
+	}
+	l := logrus.WithField("test", t.Name())
+	comment := func(author, body string) *scmprovider.GenericCommentEvent {
+		return &scmprovider.GenericCommentEvent{
+			Action: scm.ActionCreate,
+			Author: scm.User{Login: author},
+			Body:   body,
+			Number: 1,
+			Repo:   scm.Repository{Namespace: "org", Name: "repo"},
+		}
+	}
+
+	assert.Equal(t, "retrying\n", guard.process(nil, "k8s-ci-robot", comment("k8s-ci-robot", "retrying\n/retest"), l), "the bot's own commands are always ignored")
+	assert.Equal(t, "/retest", guard.process(nil, "k8s-ci-robot", comment("alice", "/retest"), l))
+	assert.Equal(t, "", guard.process(cfg, "k8s-ci-robot", comment("other-ci", "/retest"), l), "the commands of ignored users are ignored")
+
+	for i := 0; i < 2; i++ {
+		assert.Equal(t, "/retest", guard.process(cfg, "k8s-ci-robot", comment("release-bot", "/retest"), l))
+		assert.Equal(t, "/retest", guard.process(cfg, "k8s-ci-robot", comment("renovate[bot]", "/retest"), l))
+		assert.Equal(t, "/retest", guard.process(cfg, "k8s-ci-robot", comment("alice", "/retest"), l))
+	}
+	assert.Equal(t, "\n/lgtm", guard.process(cfg, "k8s-ci-robot", comment("release-bot", "/retest\n/lgtm"), l), "a bot repeating a command is ignored")
+	assert.Equal(t, "", guard.process(cfg, "k8s-ci-robot", comment("renovate[bot]", "/retest"), l))
+	assert.Equal(t, "/retest", guard.process(cfg, "k8s-ci-robot", comment("alice", "/retest"), l), "users may repeat commands")
+
+	now = now.Add(time.Hour)
+	assert.Equal(t, "/retest", guard.process(cfg, "k8s-ci-robot", comment("release-bot", "/retest"), l), "commands are handled again after the window")
+}
@@ -0,0 +1,109 @@
+package webhook
+
+import (
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultPluginTimeout is the duration after which a plugin handling an event is reported as timed out
+	DefaultPluginTimeout = 5 * time.Minute
+
+	outcomeSuccess = "success"
+	outcomeError   = "error"
+	outcomePanic   = "panic"
+	outcomeTimeout = "timeout"
+)
+
+var (
+	pluginOutcomeCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "lighthouse_plugin_handler_outcomes",
+		Help: "A counter of the events handled by the plugins, by plugin, event type and outcome: success, error, panic or timeout.",
+	}, []string{"plugin", "event_type", "outcome"})
+	pluginDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "lighthouse_plugin_handler_duration_seconds",
+		Help:    "The duration of the handling of the events by the plugins.",
+		Buckets: prometheus.ExponentialBuckets(0.05, 2, 14),
+	}, []string{"plugin", "event_type"})
+)
+
+func init() {
+	prometheus.MustRegister(pluginOutcomeCounter, pluginDuration)
+}
+
+// runPlugin handles the event with the plugin, queued by event type, tracked for the graceful shutdown
+func (s *Server) runPlugin(l *logrus.Entry, plugin, eventType string, handle func(l *logrus.Entry) error) {
+	s.runQueuedPlugin(eventType, l, plugin, eventType, handle)
+}
+
+// runQueuedPlugin handles the event with the plugin on a worker of the event queues, taking it from the given
+// queue, or in its own goroutine if the server has no queues. The handler is dropped if the queue is full and of
+// low priority.
+func (s *Server) runQueuedPlugin(queue string, l *logrus.Entry, plugin, eventType string, handle func(l *logrus.Entry) error) {
+	s.runQueued(queue, l, plugin, eventType, func() {
+		s.callPlugin(l, plugin, eventType, handle)
+	})
+}
+
+// runQueued runs the handling of the event by one or more plugins on a worker of the event queues, taking it from
+// the given queue, or in its own goroutine if the server has no queues. The plugin names the handling in the logs
+// and metrics of the dropped events.
+func (s *Server) runQueued(queue string, l *logrus.Entry, plugin, eventType string, handle func()) {
+	id := correlationID(l)
+	pendingEvents.begin(id)
+	s.wg.Add(1)
+	run := func() {
+		defer s.wg.Done()
+		defer pendingEvents.done(id)
+		handle()
+	}
+	if s.queues == nil {
+		go run()
+		return
+	}
+	if !s.queues.add(queue, run) {
+		s.wg.Done()
+		pendingEvents.done(id)
+		droppedEventsCounter.WithLabelValues(queue, plugin).Inc()
+		l.WithFields(logrus.Fields{"plugin": plugin, "queue": queue}).Warnf("Dropped %s as its queue is full.", eventType)
+	}
+}
+
+// Wait waits for the plugins to handle the events dispatched so far
+func (s *Server) Wait() {
+	s.wg.Wait()
+}
+
+// callPlugin handles the event with the plugin, isolating the other plugins from its failures: a panic is recovered
+// and logged with the fields of the event, and a handler still running after the plugin timeout is reported, as it
+// cannot be interrupted, so that a slow plugin can be told apart from a stuck event
+func (s *Server) callPlugin(l *logrus.Entry, plugin, eventType string, handle func(l *logrus.Entry) error) {
+	l = l.WithFields(logrus.Fields{"plugin": plugin, "event-type": eventType})
+	start := time.Now()
+	timeout := s.PluginTimeout
+	if timeout <= 0 {
+		timeout = DefaultPluginTimeout
+	}
+	timer := time.AfterFunc(timeout, func() {
+		pluginOutcomeCounter.WithLabelValues(plugin, eventType, outcomeTimeout).Inc()
+		l.WithField("timeout", timeout.String()).Warnf("Plugin still handling %s after the timeout.", eventType)
+	})
+	outcome := outcomeSuccess
+	defer func() {
+		timer.Stop()
+		if r := recover(); r != nil {
+			outcome = outcomePanic
+			l.WithField("stack", string(debug.Stack())).WithError(fmt.Errorf("%v", r)).Errorf("Panic handling %s.", eventType)
+		}
+		pluginOutcomeCounter.WithLabelValues(plugin, eventType, outcome).Inc()
+		pluginDuration.WithLabelValues(plugin, eventType).Observe(time.Since(start).Seconds())
+	}()
+	if err := handle(l); err != nil {
+		outcome = outcomeError
+		l.WithError(err).Errorf("Error handling %s.", eventType)
+	}
+}
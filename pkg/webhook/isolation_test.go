@@ -0,0 +1,47 @@
+package webhook
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunPluginIsolation(t *testing.T) {
+	server := &Server{PluginTimeout: 10 * time.Millisecond}
+	l := logrus.WithField("test", t.Name())
+	outcome := func(plugin, outcome string) float64 {
+		return testutil.ToFloat64(pluginOutcomeCounter.WithLabelValues(plugin, "TestEvent", outcome))
+	}
+
+	var handled int32
+	server.runPlugin(l, "panics", "TestEvent", func(l *logrus.Entry) error {
+		panic("nil map")
+	})
+	server.runPlugin(l, "fails", "TestEvent", func(l *logrus.Entry) error {
+		return fmt.Errorf("failed")
+	})
+	server.runPlugin(l, "slow", "TestEvent", func(l *logrus.Entry) error {
+		time.Sleep(50 * time.Millisecond)
+		atomic.AddInt32(&handled, 1)
+		return nil
+	})
+	server.runPlugin(l, "works", "TestEvent", func(l *logrus.Entry) error {
+		assert.Equal(t, "works", l.Data["plugin"])
+		atomic.AddInt32(&handled, 1)
+		return nil
+	})
+	server.wg.Wait()
+
+	assert.Equal(t, int32(2), atomic.LoadInt32(&handled), "the other plugins should handle the event")
+	assert.Equal(t, float64(1), outcome("panics", outcomePanic))
+	assert.Equal(t, float64(1), outcome("fails", outcomeError))
+	assert.Equal(t, float64(1), outcome("slow", outcomeTimeout))
+	assert.Equal(t, float64(1), outcome("slow", outcomeSuccess), "a slow plugin should complete")
+	assert.Equal(t, float64(1), outcome("works", outcomeSuccess))
+	assert.Equal(t, float64(0), outcome("works", outcomeTimeout))
+}
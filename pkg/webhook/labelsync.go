@@ -0,0 +1,35 @@
+package webhook
+
+import (
+	"strings"
+
+	"github.com/jenkins-x/lighthouse/pkg/labelsync"
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	"github.com/sirupsen/logrus"
+)
+
+// labelSyncProvider reconciles the labels of the configured repositories hosted by the provider with the label
+// configuration, which is loaded again each time so that its changes are picked up
+func (o *Options) labelSyncProvider(p *hookProvider) {
+	if p.server.Plugins.Config() == nil {
+		// the configuration has not been loaded yet
+		return
+	}
+	config, err := labelsync.Load(o.LabelConfig)
+	if err != nil {
+		logrus.WithError(err).Error("failed to load the label configuration")
+		return
+	}
+	o.forEachHostedRepository(p, func(l *logrus.Entry, client *scmPollClient, agent *plugins.ClientAgent, fullName string) {
+		i := strings.LastIndex(fullName, "/")
+		org, repo := fullName[:i], fullName[i+1:]
+		labels := config.LabelsFor(org, repo)
+		if len(labels) == 0 {
+			return
+		}
+		if err := labelsync.Sync(scmprovider.ToClient(agent.SCMProviderClient, agent.BotName), org, repo, labels, l); err != nil {
+			l.WithError(err).Error("failed to synchronize the labels")
+		}
+	})
+}
@@ -30,17 +30,28 @@ var (
 		Name: "prow_webhook_response_codes",
 		Help: "A counter of the different responses hook has responded to webhooks with.",
 	}, []string{"response_code"})
+	rejectedCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "lighthouse_webhook_rejected_deliveries",
+		Help: "A counter of the webhook deliveries rejected before any plugin handled them.",
+	}, []string{"reason"})
+	downstreamCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "lighthouse_webhook_downstream_deliveries",
+		Help: "A counter of the webhooks forwarded to the downstream endpoints, by endpoint and outcome.",
+	}, []string{"downstream", "outcome"})
 )
 
 func init() {
 	prometheus.MustRegister(webhookCounter)
 	prometheus.MustRegister(responseCounter)
+	prometheus.MustRegister(rejectedCounter)
+	prometheus.MustRegister(downstreamCounter)
 }
 
 // Metrics is a set of metrics gathered by hook.
 type Metrics struct {
 	WebhookCounter  *prometheus.CounterVec
 	ResponseCounter *prometheus.CounterVec
+	RejectedCounter *prometheus.CounterVec
 }
 
 // NewMetrics creates a new set of metrics for the hook server.
@@ -48,5 +59,6 @@ func NewMetrics() *Metrics {
 	return &Metrics{
 		WebhookCounter:  webhookCounter,
 		ResponseCounter: responseCounter,
+		RejectedCounter: rejectedCounter,
 	}
 }
@@ -0,0 +1,91 @@
+package webhook
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jenkins-x/lighthouse/pkg/hooks"
+	"github.com/jenkins-x/lighthouse/pkg/labelsync"
+	"github.com/jenkins-x/lighthouse/pkg/onboard"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	"github.com/sirupsen/logrus"
+)
+
+// handleOnboard onboards the repository of a POST /onboard?repo={org}/{repo} request, served on the admin port as
+// it changes the repository, and responds with the JSON report of the onboarding steps. The dry_run=true parameter
+// only checks the configuration and reports what would change.
+func (o *Options) handleOnboard(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "only POST is supported", http.StatusMethodNotAllowed)
+		return
+	}
+	fullName := r.URL.Query().Get("repo")
+	i := strings.LastIndex(fullName, "/")
+	if i <= 0 {
+		http.Error(w, "the repo parameter is required as org/repo", http.StatusBadRequest)
+		return
+	}
+	owner := fullName[:i]
+	l := logrus.WithFields(logrus.Fields{"repo": fullName, "handler": "onboard"})
+
+	var p *hookProvider
+	for _, candidate := range o.providers {
+		if hosted, err := candidate.Hosts(owner); err == nil && hosted {
+			p = candidate
+			break
+		}
+	}
+	if p == nil {
+		http.Error(w, "no provider hosts "+owner, http.StatusBadRequest)
+		return
+	}
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+	options := onboard.Options{
+		Hook:    hooks.ProviderOptions(p.Provider, o.HookURL),
+		Secret:  hooks.ProviderSecret(p.Provider),
+		HelpURL: o.pluginHelpURL(),
+		DryRun:  dryRun,
+	}
+	if o.LabelConfig != "" {
+		labels, err := labelsync.Load(o.LabelConfig)
+		if err != nil {
+			l.WithError(err).Error("failed to load the label configuration")
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		options.Labels = labels
+	}
+	scmClient, serverURL, err := o.createSCMClient(p.Provider)
+	if err != nil {
+		l.WithError(err).Error("failed to create the SCM client")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	agent, err := o.clientAgent(p, scmClient, serverURL, owner, l)
+	if err != nil {
+		l.WithError(err).Error("failed to create the clients of the plugins")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	clients := onboard.Clients{
+		Hooks: scmClient.Repositories,
+		SCM:   scmprovider.ToClient(agent.SCMProviderClient, agent.BotName),
+	}
+
+	report, err := onboard.Onboard(p.server.ConfigAgent.Config(), p.server.Plugins.Config(), clients, options, fullName, l)
+	status := http.StatusOK
+	if err != nil {
+		l.WithError(err).Error("failed to onboard the repository")
+		status = http.StatusUnprocessableEntity
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(report)
+}
+
+// pluginHelpURL returns the public URL of the plugin help page, which is served next to the hook endpoint
+func (o *Options) pluginHelpURL() string {
+	return strings.TrimSuffix(strings.TrimSuffix(o.HookURL, "/"), o.Path) + PluginHelpPath
+}
@@ -0,0 +1,91 @@
+package webhook
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/jenkins-x/lighthouse/pkg/repoowners"
+	"github.com/sirupsen/logrus"
+)
+
+// ownersQuery is the response of the owners endpoint
+type ownersQuery struct {
+	Repo    string                 `json:"repo"`
+	Base    string                 `json:"base"`
+	Aliases map[string][]string    `json:"aliases,omitempty"`
+	Files   []repoowners.Ownership `json:"files,omitempty"`
+}
+
+// handleOwners responds to a GET /owners?repo={org}/{repo}&base={branch}&path={file} request, served on the admin
+// port, with the JSON ownership of the path parameters according to the OWNERS files and aliases of the branch, as
+// the plugins see them. It shares the cache of the plugins.
+func (o *Options) handleOwners(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "only GET is supported", http.StatusMethodNotAllowed)
+		return
+	}
+	query := r.URL.Query()
+	fullName := query.Get("repo")
+	parts := strings.Split(fullName, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		http.Error(w, "the repo parameter is required as org/repo", http.StatusBadRequest)
+		return
+	}
+	org, repo := parts[0], parts[1]
+	base := query.Get("base")
+	if base == "" {
+		base = "master"
+	}
+	l := logrus.WithFields(logrus.Fields{"repo": fullName, "base": base, "handler": "owners"})
+
+	var p *hookProvider
+	for _, candidate := range o.providers {
+		if hosted, err := candidate.Hosts(org); err == nil && hosted {
+			p = candidate
+			break
+		}
+	}
+	if p == nil {
+		http.Error(w, "no provider hosts "+org, http.StatusBadRequest)
+		return
+	}
+	scmClient, serverURL, err := o.createSCMClient(p.Provider)
+	if err != nil {
+		l.WithError(err).Error("failed to create the SCM client")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	clientAgent, err := o.clientAgent(p, scmClient, serverURL, org, l)
+	if err != nil {
+		l.WithError(err).Error("failed to create the clients of the plugins")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	agent := plugins.NewAgent(p.server.ClientFactory, p.server.ConfigAgent, p.server.Plugins, clientAgent, p.server.MetapipelineClient, p.server.ServerURL, l)
+
+	owners, err := agent.OwnersClient.LoadRepoOwners(org, repo, base)
+	if err != nil {
+		l.WithError(err).Error("failed to load the OWNERS files")
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	aliases, err := agent.OwnersClient.LoadRepoAliases(org, repo, base)
+	if err != nil {
+		l.WithError(err).Error("failed to load the aliases")
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	response := ownersQuery{
+		Repo:    fullName,
+		Base:    base,
+		Aliases: map[string][]string{},
+		Files:   repoowners.Query(owners, query["path"]),
+	}
+	for alias, logins := range aliases {
+		response.Aliases[alias] = logins.List()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,101 @@
+package webhook
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/sirupsen/logrus"
+)
+
+// PluginHelpPath is the URL path of the page describing the plugins and commands enabled for a repository, as
+// /plugin-help?repo={org}/{repo}
+const PluginHelpPath = "/plugin-help"
+
+// pluginHelpTemplate renders the help of the plugins of a repository. The descriptions and configuration of the
+// plugins may include HTML, as in the plugin help of Prow.
+var pluginHelpTemplate = template.Must(template.New("plugin-help").Funcs(template.FuncMap{
+	"html": func(text string) template.HTML { return template.HTML(text) }, // #nosec G203
+}).Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Plugins of {{.Repo}}</title></head>
+<body>
+<h1>Plugins of {{.Repo}}</h1>
+{{- if not .Plugins}}
+<p>No plugins are enabled for this repository.</p>
+{{- end}}
+{{- range .Plugins}}
+<h2 id="{{.Name}}">{{.Name}}</h2>
+<p>{{html .Help.Description}}</p>
+{{- range $key, $config := .Help.Config}}
+<p>{{html $config}}</p>
+{{- end}}
+{{- if .Help.Commands}}
+<table>
+<tr><th>Command</th><th>Description</th><th>Who can use it</th><th>Examples</th></tr>
+{{- range .Help.Commands}}
+<tr><td><code>{{.Usage}}</code></td><td>{{.Description}}</td><td>{{html .WhoCanUse}}</td><td>{{range .Examples}}<code>{{.}}</code> {{end}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- end}}
+{{- if .ExternalPlugins}}
+<h2>External plugins</h2>
+<ul>
+{{- range .ExternalPlugins}}
+<li>{{.Name}}{{if .Events}} ({{range $i, $e := .Events}}{{if $i}}, {{end}}{{$e}}{{end}}){{end}}</li>
+{{- end}}
+</ul>
+{{- end}}
+</body>
+</html>
+`))
+
+// PluginHelpHandler serves the plugins and commands enabled for a repository as HTML, or as JSON if ?format=json
+// is set or JSON is accepted
+type PluginHelpHandler struct {
+	Plugins *plugins.ConfigAgent
+	Logger  *logrus.Entry
+}
+
+// ServeHTTP serves the help of the plugins of the repository given by ?repo={org}/{repo}
+func (h *PluginHelpHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "only GET is supported", http.StatusMethodNotAllowed)
+		return
+	}
+	parts := strings.Split(strings.Trim(r.URL.Query().Get("repo"), "/"), "/")
+	if len(parts) < 2 || parts[0] == "" || parts[len(parts)-1] == "" {
+		http.Error(w, "expected a repository of the form ?repo={org}/{repo}", http.StatusBadRequest)
+		return
+	}
+	org, repo := strings.Join(parts[:len(parts)-1], "/"), parts[len(parts)-1]
+	config := h.Plugins.Config()
+	if config == nil {
+		http.Error(w, "the plugins configuration is not loaded yet", http.StatusServiceUnavailable)
+		return
+	}
+	help := plugins.HelpForRepo(config, org, repo)
+	l := h.logger().WithField("repo", help.Repo)
+
+	if r.URL.Query().Get("format") == "json" || strings.Contains(r.Header.Get("Accept"), "application/json") {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(help); err != nil {
+			l.WithError(err).Warn("failed to write the plugin help")
+		}
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := pluginHelpTemplate.Execute(w, help); err != nil {
+		l.WithError(err).Warn("failed to render the plugin help")
+	}
+}
+
+func (h *PluginHelpHandler) logger() *logrus.Entry {
+	if h.Logger != nil {
+		return h.Logger
+	}
+	return logrus.WithField("component", "plugin-help")
+}
@@ -0,0 +1,55 @@
+package webhook
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPluginHelpHandler(t *testing.T) {
+	agent := &plugins.ConfigAgent{}
+	handler := &PluginHelpHandler{Plugins: agent}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, PluginHelpPath+"?repo=org/repo", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	agent.Set(&plugins.Configuration{
+		Plugins: map[string][]string{"org": {"hold"}, "org/other": {"size"}},
+		ExternalPlugins: map[string][]plugins.ExternalPlugin{
+			"org/repo": {{Name: "cherrypicker", Events: []string{"issue_comment"}}},
+		},
+	})
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, PluginHelpPath, nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, PluginHelpPath+"?repo=org/repo&format=json", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var help plugins.RepoHelp
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &help))
+	assert.Equal(t, "org/repo", help.Repo)
+	require.Len(t, help.Plugins, 1)
+	assert.Equal(t, "hold", help.Plugins[0].Name)
+	require.NotEmpty(t, help.Plugins[0].Help.Commands)
+	assert.Equal(t, "/hold [cancel]", help.Plugins[0].Help.Commands[0].Usage)
+	require.Len(t, help.ExternalPlugins, 1)
+	assert.Equal(t, "cherrypicker", help.ExternalPlugins[0].Name)
+
+	w = httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, PluginHelpPath+"?repo=org/repo", nil)
+	handler.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/html")
+	assert.Contains(t, w.Body.String(), "<h2 id=\"hold\">hold</h2>")
+	assert.Contains(t, w.Body.String(), "<code>/hold [cancel]</code>")
+	assert.Contains(t, w.Body.String(), "cherrypicker (issue_comment)")
+	assert.NotContains(t, w.Body.String(), "size")
+}
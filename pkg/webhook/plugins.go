@@ -21,7 +21,9 @@ package webhook
 import (
 	_ "github.com/jenkins-x/lighthouse/pkg/plugins/approve" // Import all enabled plugins.
 	_ "github.com/jenkins-x/lighthouse/pkg/plugins/assign"
+	_ "github.com/jenkins-x/lighthouse/pkg/plugins/bisect"
 	_ "github.com/jenkins-x/lighthouse/pkg/plugins/blockade"
+	_ "github.com/jenkins-x/lighthouse/pkg/plugins/blunderbuss"
 	_ "github.com/jenkins-x/lighthouse/pkg/plugins/cat"
 	_ "github.com/jenkins-x/lighthouse/pkg/plugins/cherrypickunapproved"
 	_ "github.com/jenkins-x/lighthouse/pkg/plugins/dog"
@@ -30,15 +32,21 @@ import (
 	_ "github.com/jenkins-x/lighthouse/pkg/plugins/label"
 	_ "github.com/jenkins-x/lighthouse/pkg/plugins/lgtm"
 	_ "github.com/jenkins-x/lighthouse/pkg/plugins/lifecycle"
+	_ "github.com/jenkins-x/lighthouse/pkg/plugins/mergeafter"
 	_ "github.com/jenkins-x/lighthouse/pkg/plugins/milestone"
 	_ "github.com/jenkins-x/lighthouse/pkg/plugins/milestonestatus"
+	_ "github.com/jenkins-x/lighthouse/pkg/plugins/needsrebase"
 	_ "github.com/jenkins-x/lighthouse/pkg/plugins/override"
 	_ "github.com/jenkins-x/lighthouse/pkg/plugins/owners-label"
 	_ "github.com/jenkins-x/lighthouse/pkg/plugins/pony"
+	_ "github.com/jenkins-x/lighthouse/pkg/plugins/preview"
+	_ "github.com/jenkins-x/lighthouse/pkg/plugins/promote"
+	_ "github.com/jenkins-x/lighthouse/pkg/plugins/reminder"
 	_ "github.com/jenkins-x/lighthouse/pkg/plugins/shrug"
 	_ "github.com/jenkins-x/lighthouse/pkg/plugins/sigmention"
 	_ "github.com/jenkins-x/lighthouse/pkg/plugins/size"
 	_ "github.com/jenkins-x/lighthouse/pkg/plugins/stage"
+	_ "github.com/jenkins-x/lighthouse/pkg/plugins/triage"
 	_ "github.com/jenkins-x/lighthouse/pkg/plugins/trigger"
 	_ "github.com/jenkins-x/lighthouse/pkg/plugins/welcome"
 	_ "github.com/jenkins-x/lighthouse/pkg/plugins/wip"
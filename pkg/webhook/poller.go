@@ -0,0 +1,314 @@
+package webhook
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse/pkg/hooks"
+	"github.com/jenkins-x/lighthouse/pkg/logrusutil"
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/sirupsen/logrus"
+)
+
+// pollClient is the subset of the SCM API used to poll repositories
+type pollClient interface {
+	GetRepository(fullName string) (*scm.Repository, error)
+	ListOrgRepositories(org string) ([]*scm.Repository, error)
+	ListOpenPullRequests(fullName string) ([]*scm.PullRequest, error)
+	ListPullRequestComments(fullName string, number int) ([]*scm.Comment, error)
+	ListBranches(fullName string) ([]*scm.Reference, error)
+	ListCommitChanges(fullName, sha string) ([]*scm.Change, error)
+}
+
+// poller synthesizes the webhooks of the changes of repositories from their pull requests, comments and branches
+// listed by the SCM API, for installations the provider cannot deliver webhooks to. Only what a listing shows is
+// noticed: pull requests which were opened and closed between two polls or pushes which were overwritten are
+// missed.
+type poller struct {
+	lock  sync.Mutex
+	repos map[string]*polledRepo
+}
+
+type polledRepo struct {
+	repo     scm.Repository
+	pulls    map[int]scm.PullRequest
+	branches map[string]string
+}
+
+// pollRepo lists the repository and returns the webhooks of its changes since the previous poll. The first poll
+// of a repository only records its state.
+func (p *poller) pollRepo(client pollClient, fullName string) ([]scm.Webhook, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.repos == nil {
+		p.repos = map[string]*polledRepo{}
+	}
+	previous := p.repos[fullName]
+	current := &polledRepo{
+		pulls:    map[int]scm.PullRequest{},
+		branches: map[string]string{},
+	}
+	if previous != nil {
+		current.repo = previous.repo
+	} else {
+		repo, err := client.GetRepository(fullName)
+		if err != nil {
+			return nil, err
+		}
+		current.repo = *repo
+	}
+
+	prs, err := client.ListOpenPullRequests(fullName)
+	if err != nil {
+		return nil, err
+	}
+	branches, err := client.ListBranches(fullName)
+	if err != nil {
+		return nil, err
+	}
+	for _, pr := range prs {
+		current.pulls[pr.Number] = *pr
+	}
+	for _, branch := range branches {
+		current.branches[branch.Name] = branch.Sha
+	}
+	if previous == nil {
+		p.repos[fullName] = current
+		return nil, nil
+	}
+
+	var answer []scm.Webhook
+	for _, pr := range prs {
+		before, ok := previous.pulls[pr.Number]
+		switch {
+		case !ok:
+			answer = append(answer, current.pullRequestHook(scm.ActionOpen, *pr))
+		case before.Sha != pr.Sha:
+			answer = append(answer, current.pullRequestHook(scm.ActionSync, *pr))
+		}
+		if !ok || pr.Updated.IsZero() || !pr.Updated.After(before.Updated) {
+			continue
+		}
+		// comments posted since the previous poll updated the pull request after it was last listed
+		comments, err := client.ListPullRequestComments(fullName, pr.Number)
+		if err != nil {
+			return nil, err
+		}
+		for _, comment := range comments {
+			if comment.Created.After(before.Updated) {
+				answer = append(answer, &scm.PullRequestCommentHook{
+					Action:      scm.ActionCreate,
+					Repo:        current.repo,
+					PullRequest: *pr,
+					Comment:     *comment,
+					Sender:      comment.Author,
+				})
+			}
+		}
+	}
+	for number, pr := range previous.pulls {
+		if _, ok := current.pulls[number]; !ok {
+			pr.Closed = true
+			pr.State = "closed"
+			answer = append(answer, current.pullRequestHook(scm.ActionClose, pr))
+		}
+	}
+	for name, sha := range current.branches {
+		before, ok := previous.branches[name]
+		if ok && before == sha {
+			continue
+		}
+		hook := &scm.PushHook{
+			Ref:     "refs/heads/" + name,
+			Repo:    current.repo,
+			Before:  before,
+			After:   sha,
+			Created: !ok,
+			Commit:  scm.Commit{Sha: sha},
+		}
+		// only the changes of the head commit are known, which is enough for the run_if_changed of most pushes
+		changes, err := client.ListCommitChanges(fullName, sha)
+		if err != nil {
+			return nil, err
+		}
+		commit := scm.PushCommit{ID: sha}
+		for _, change := range changes {
+			switch {
+			case change.Added:
+				commit.Added = append(commit.Added, change.Path)
+			case change.Deleted:
+				commit.Removed = append(commit.Removed, change.Path)
+			default:
+				commit.Modified = append(commit.Modified, change.Path)
+			}
+		}
+		hook.Commits = []scm.PushCommit{commit}
+		answer = append(answer, hook)
+	}
+	// the changes are polled again if listing their details failed
+	p.repos[fullName] = current
+	return answer, nil
+}
+
+func (r *polledRepo) pullRequestHook(action scm.Action, pr scm.PullRequest) *scm.PullRequestHook {
+	return &scm.PullRequestHook{
+		Action:      action,
+		Repo:        r.repo,
+		PullRequest: pr,
+		Sender:      pr.Author,
+	}
+}
+
+// pollProvider polls the configured repositories hosted by the provider and handles the webhooks of their changes
+func (o *Options) pollProvider(p *hookProvider) {
+	if p.server.Plugins.Config() == nil {
+		// the configuration has not been loaded yet
+		return
+	}
+	o.forEachHostedRepository(p, func(l *logrus.Entry, client *scmPollClient, agent *plugins.ClientAgent, fullName string) {
+		webhooks, err := o.poller.pollRepo(client, fullName)
+		if err != nil {
+			l.WithError(err).Error("failed to poll the repository")
+		}
+		for _, webhook := range webhooks {
+			wl := l.WithFields(logrus.Fields{"Webhook": webhook.Kind(), logrusutil.CorrelationIDField: logrusutil.NewCorrelationID()})
+			if _, _, err := o.processWebHook(p.server, agent, wl, webhook); err != nil {
+				wl.WithError(err).Error("failed to process a polled change")
+			}
+		}
+	})
+}
+
+// forEachHostedRepository calls handle with each configured repository hosted by the provider, which includes all
+// the repositories of an org configured as a whole, and the clients of its owner
+func (o *Options) forEachHostedRepository(p *hookProvider, handle func(l *logrus.Entry, client *scmPollClient, agent *plugins.ClientAgent, fullName string)) {
+	logger := logrus.WithField("provider", p.String())
+	orgs, repos := hooks.ConfiguredRepositories(p.server.ConfigAgent.Config(), p.server.Plugins.Config())
+	// the repositories by owner, which are all those of the org when nil
+	owners := map[string][]string{}
+	for _, fullName := range repos {
+		if i := strings.LastIndex(fullName, "/"); i > 0 {
+			owners[fullName[:i]] = append(owners[fullName[:i]], fullName)
+		}
+	}
+	for _, org := range orgs {
+		owners[org] = nil
+	}
+
+	for owner, names := range owners {
+		l := logger.WithField("owner", owner)
+		if hosted, err := p.Hosts(owner); err != nil || !hosted {
+			continue
+		}
+		scmClient, serverURL, err := o.createSCMClient(p.Provider)
+		if err != nil {
+			l.WithError(err).Error("failed to create the SCM client")
+			continue
+		}
+		agent, err := o.clientAgent(p, scmClient, serverURL, owner, l)
+		if err != nil {
+			l.WithError(err).Error("failed to create the clients of the plugins")
+			continue
+		}
+		client := &scmPollClient{client: scmClient}
+		if names == nil {
+			orgRepos, err := client.ListOrgRepositories(owner)
+			if err != nil {
+				l.WithError(err).Error("failed to list the repositories of the org")
+				continue
+			}
+			for _, repo := range orgRepos {
+				names = append(names, repo.FullName)
+			}
+		}
+		for _, fullName := range names {
+			parts := strings.Split(fullName, "/")
+			if o.repoAllowed(scm.Repository{Namespace: strings.Join(parts[:len(parts)-1], "/"), Name: parts[len(parts)-1]}) {
+				handle(l.WithField("repo", fullName), client, agent, fullName)
+			}
+		}
+	}
+}
+
+// scmPollClient lists the state of repositories with go-scm
+type scmPollClient struct {
+	client *scm.Client
+}
+
+// GetRepository returns the repository
+func (c *scmPollClient) GetRepository(fullName string) (*scm.Repository, error) {
+	repo, _, err := c.client.Repositories.Find(context.Background(), fullName)
+	return repo, err
+}
+
+// ListOrgRepositories returns all the repositories of the org
+func (c *scmPollClient) ListOrgRepositories(org string) ([]*scm.Repository, error) {
+	var answer []*scm.Repository
+	err := allPages(func(opts scm.ListOptions) (*scm.Response, error) {
+		repos, resp, err := c.client.Repositories.ListOrganisation(context.Background(), org, opts)
+		answer = append(answer, repos...)
+		return resp, err
+	})
+	return answer, err
+}
+
+// ListOpenPullRequests returns all the open pull requests of the repository
+func (c *scmPollClient) ListOpenPullRequests(fullName string) ([]*scm.PullRequest, error) {
+	var answer []*scm.PullRequest
+	err := allPages(func(opts scm.ListOptions) (*scm.Response, error) {
+		prs, resp, err := c.client.PullRequests.List(context.Background(), fullName, scm.PullRequestListOptions{Page: opts.Page, Size: opts.Size, Open: true})
+		answer = append(answer, prs...)
+		return resp, err
+	})
+	return answer, err
+}
+
+// ListPullRequestComments returns all the comments of the pull request
+func (c *scmPollClient) ListPullRequestComments(fullName string, number int) ([]*scm.Comment, error) {
+	var answer []*scm.Comment
+	err := allPages(func(opts scm.ListOptions) (*scm.Response, error) {
+		comments, resp, err := c.client.PullRequests.ListComments(context.Background(), fullName, number, opts)
+		answer = append(answer, comments...)
+		return resp, err
+	})
+	return answer, err
+}
+
+// ListBranches returns all the branches of the repository
+func (c *scmPollClient) ListBranches(fullName string) ([]*scm.Reference, error) {
+	var answer []*scm.Reference
+	err := allPages(func(opts scm.ListOptions) (*scm.Response, error) {
+		branches, resp, err := c.client.Git.ListBranches(context.Background(), fullName, opts)
+		answer = append(answer, branches...)
+		return resp, err
+	})
+	return answer, err
+}
+
+// ListCommitChanges returns the files changed by the commit
+func (c *scmPollClient) ListCommitChanges(fullName, sha string) ([]*scm.Change, error) {
+	var answer []*scm.Change
+	err := allPages(func(opts scm.ListOptions) (*scm.Response, error) {
+		changes, resp, err := c.client.Git.ListChanges(context.Background(), fullName, sha, opts)
+		answer = append(answer, changes...)
+		return resp, err
+	})
+	return answer, err
+}
+
+// allPages calls list with each page until the last one
+func allPages(list func(opts scm.ListOptions) (*scm.Response, error)) error {
+	opts := scm.ListOptions{Page: 1, Size: 100}
+	for {
+		resp, err := list(opts)
+		if err != nil {
+			return err
+		}
+		if resp == nil || opts.Page >= resp.Page.Last {
+			return nil
+		}
+		opts.Page++
+	}
+}
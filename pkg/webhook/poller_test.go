@@ -0,0 +1,120 @@
+package webhook
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakePollClient struct {
+	prs      []*scm.PullRequest
+	comments map[int][]*scm.Comment
+	branches []*scm.Reference
+	changes  map[string][]*scm.Change
+}
+
+func (c *fakePollClient) GetRepository(fullName string) (*scm.Repository, error) {
+	return &scm.Repository{Namespace: "org", Name: "repo", FullName: fullName}, nil
+}
+
+func (c *fakePollClient) ListOrgRepositories(org string) ([]*scm.Repository, error) {
+	return nil, nil
+}
+
+func (c *fakePollClient) ListOpenPullRequests(fullName string) ([]*scm.PullRequest, error) {
+	return c.prs, nil
+}
+
+func (c *fakePollClient) ListPullRequestComments(fullName string, number int) ([]*scm.Comment, error) {
+	return c.comments[number], nil
+}
+
+func (c *fakePollClient) ListBranches(fullName string) ([]*scm.Reference, error) {
+	return c.branches, nil
+}
+
+func (c *fakePollClient) ListCommitChanges(fullName, sha string) ([]*scm.Change, error) {
+	return c.changes[sha], nil
+}
+
+func TestPollRepo(t *testing.T) {
+	t0 := time.Date(2020, 5, 1, 10, 0, 0, 0, time.UTC)
+	client := &fakePollClient{
+		prs: []*scm.PullRequest{
+			{Number: 1, Sha: "a1", Updated: t0},
+			{Number: 2, Sha: "b1", Updated: t0},
+		},
+		comments: map[int][]*scm.Comment{
+			1: {{ID: 10, Body: "old", Created: t0.Add(-time.Hour)}},
+		},
+		branches: []*scm.Reference{{Name: "master", Sha: "m1"}},
+		changes: map[string][]*scm.Change{
+			"m2": {{Path: "new.go", Added: true}, {Path: "main.go"}, {Path: "old.go", Deleted: true}},
+		},
+	}
+	p := &poller{}
+
+	webhooks, err := p.pollRepo(client, "org/repo")
+	require.NoError(t, err)
+	assert.Empty(t, webhooks, "the first poll only records the state")
+
+	webhooks, err = p.pollRepo(client, "org/repo")
+	require.NoError(t, err)
+	assert.Empty(t, webhooks)
+
+	client.prs = []*scm.PullRequest{
+		{Number: 1, Sha: "a2", Updated: t0.Add(2 * time.Minute)},
+		{Number: 3, Sha: "c1", Updated: t0.Add(time.Minute)},
+	}
+	client.comments[1] = append(client.comments[1], &scm.Comment{ID: 11, Body: "/test all", Created: t0.Add(time.Minute), Author: scm.User{Login: "bob"}})
+	client.branches = []*scm.Reference{{Name: "master", Sha: "m2"}, {Name: "feature", Sha: "f1"}}
+
+	webhooks, err = p.pollRepo(client, "org/repo")
+	require.NoError(t, err)
+	require.Len(t, webhooks, 6)
+
+	sync := webhooks[0].(*scm.PullRequestHook)
+	assert.Equal(t, scm.ActionSync, sync.Action)
+	assert.Equal(t, "a2", sync.PullRequest.Sha)
+	assert.Equal(t, "org/repo", sync.Repo.FullName)
+
+	comment := webhooks[1].(*scm.PullRequestCommentHook)
+	assert.Equal(t, scm.ActionCreate, comment.Action)
+	assert.Equal(t, "/test all", comment.Comment.Body)
+	assert.Equal(t, "bob", comment.Sender.Login)
+	assert.Equal(t, 1, comment.PullRequest.Number)
+
+	opened := webhooks[2].(*scm.PullRequestHook)
+	assert.Equal(t, scm.ActionOpen, opened.Action)
+	assert.Equal(t, 3, opened.PullRequest.Number)
+
+	closed := webhooks[3].(*scm.PullRequestHook)
+	assert.Equal(t, scm.ActionClose, closed.Action)
+	assert.Equal(t, 2, closed.PullRequest.Number)
+	assert.True(t, closed.PullRequest.Closed)
+
+	pushes := map[string]*scm.PushHook{}
+	for _, webhook := range webhooks[4:] {
+		push := webhook.(*scm.PushHook)
+		pushes[push.Ref] = push
+	}
+	master := pushes["refs/heads/master"]
+	require.NotNil(t, master)
+	assert.Equal(t, "m1", master.Before)
+	assert.Equal(t, "m2", master.After)
+	assert.False(t, master.Created)
+	require.Len(t, master.Commits, 1)
+	assert.Equal(t, []string{"new.go"}, master.Commits[0].Added)
+	assert.Equal(t, []string{"main.go"}, master.Commits[0].Modified)
+	assert.Equal(t, []string{"old.go"}, master.Commits[0].Removed)
+	feature := pushes["refs/heads/feature"]
+	require.NotNil(t, feature)
+	assert.True(t, feature.Created)
+
+	webhooks, err = p.pollRepo(client, "org/repo")
+	require.NoError(t, err)
+	assert.Empty(t, webhooks, "changes are only handled once")
+}
@@ -0,0 +1,38 @@
+package webhook
+
+import (
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/jx/v2/pkg/tekton/metapipeline"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/git"
+	"github.com/jenkins-x/lighthouse/pkg/gitprovider"
+	"github.com/jenkins-x/lighthouse/pkg/launcher"
+	"github.com/jenkins-x/lighthouse/pkg/repoowners"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+)
+
+// hookProvider is an SCM provider whose webhooks are served at the hook path, or below it at the name of the
+// provider for the providers other than the default one
+type hookProvider struct {
+	*gitprovider.Provider
+	server    *Server
+	gitClient git.Client
+	// owners caches the OWNERS files of the repos of the provider across the events
+	owners *repoowners.Cache
+}
+
+// providerLauncher labels the jobs launched for the webhooks of a provider other than the default one, so that
+// their statuses are reported to that provider
+type providerLauncher struct {
+	launcher.PipelineLauncher
+	provider string
+}
+
+// Launch labels the job with the provider before launching it
+func (l *providerLauncher) Launch(job *v1alpha1.LighthouseJob, metapipelineClient metapipeline.Client, repo scm.Repository) (*v1alpha1.LighthouseJob, error) {
+	if job.Labels == nil {
+		job.Labels = map[string]string{}
+	}
+	job.Labels[util.ProviderLabel] = l.provider
+	return l.PipelineLauncher.Launch(job, metapipelineClient, repo)
+}
@@ -0,0 +1,34 @@
+package webhook
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/gitprovider"
+	"github.com/jenkins-x/lighthouse/pkg/launcher/fake"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProviderForPath(t *testing.T) {
+	defaultProvider := &hookProvider{Provider: gitprovider.Default()}
+	gitlab := &hookProvider{Provider: &gitprovider.Provider{Name: "gitlab"}}
+	o := &Options{Path: "/hook", providers: []*hookProvider{defaultProvider, gitlab}}
+
+	assert.Equal(t, defaultProvider, o.providerForPath("/hook"))
+	assert.Equal(t, defaultProvider, o.providerForPath("/hook/"))
+	assert.Equal(t, gitlab, o.providerForPath("/hook/gitlab"))
+	assert.Equal(t, gitlab, o.providerForPath("/hook/gitlab/"))
+	assert.Equal(t, defaultProvider, o.providerForPath("/hook/other"), "other paths below the hook path are served by the default provider")
+}
+
+func TestProviderLauncher(t *testing.T) {
+	jobs := fake.NewLauncher()
+	l := &providerLauncher{PipelineLauncher: jobs, provider: "gitlab"}
+	_, err := l.Launch(&v1alpha1.LighthouseJob{}, nil, scm.Repository{})
+	require.NoError(t, err)
+	require.Len(t, jobs.Pipelines, 1)
+	assert.Equal(t, "gitlab", jobs.Pipelines[0].Labels[util.ProviderLabel])
+}
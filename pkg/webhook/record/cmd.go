@@ -0,0 +1,62 @@
+package record
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/jenkins-x/lighthouse/pkg/cmd/helper"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// DecryptOptions are the options of the decrypt command
+type DecryptOptions struct {
+	KeyFile   string
+	OutputDir string
+}
+
+// NewCmdDecrypt creates the command decrypting the webhooks recorded with --record-key-file
+func NewCmdDecrypt() *cobra.Command {
+	options := DecryptOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "decrypt-recordings file...",
+		Short: "Decrypts recorded webhooks so that they can be added to the replay test data",
+		Long:  "Decrypts the webhooks recorded encrypted by --record-dir and --record-key-file, writing each of them as a plain .json file next to it or to the output directory.",
+		Args:  cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := options.Run(args)
+			helper.CheckErr(err)
+		},
+	}
+
+	cmd.Flags().StringVar(&options.KeyFile, "record-key-file", "", "The keyring file the webhooks were recorded with.")
+	cmd.Flags().StringVar(&options.OutputDir, "output-dir", "", "The directory the decrypted webhooks are written to. Defaults to the directory of each recording.")
+
+	return cmd
+}
+
+// Run decrypts the recorded webhooks
+func (o *DecryptOptions) Run(paths []string) error {
+	if o.KeyFile == "" {
+		return errors.New("--record-key-file is required")
+	}
+	keyring, err := LoadKeyring(o.KeyFile)
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		d, err := keyring.Load(path)
+		if err != nil {
+			return err
+		}
+		out := strings.TrimSuffix(path, SealedExtension)
+		if o.OutputDir != "" {
+			out = filepath.Join(o.OutputDir, filepath.Base(out))
+		}
+		if err := d.Save(out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
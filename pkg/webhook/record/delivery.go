@@ -0,0 +1,84 @@
+// Package record records the webhook payloads delivered to the hook, sanitized, so that the replaytest package can
+// replay them against the plugins in regression tests of the whole hook pipeline.
+package record
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/go-scm/scm/factory"
+	"github.com/jenkins-x/lighthouse/pkg/payload"
+	"github.com/pkg/errors"
+)
+
+// Delivery is a webhook delivered by an SCM provider, as recorded in a file
+type Delivery struct {
+	// Provider is the kind of the SCM provider which sent the webhook, such as github or gitlab
+	Provider string `json:"provider"`
+	// ServerURL is the URL of the SCM provider, which is only required to parse the webhooks of some providers
+	ServerURL string `json:"serverURL,omitempty"`
+	// Headers are the headers of the webhook request
+	Headers map[string]string `json:"headers"`
+	// Body is the payload of the webhook
+	Body json.RawMessage `json:"body"`
+}
+
+// Load reads the delivery recorded in the file
+func Load(path string) (*Delivery, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading delivery %s", path)
+	}
+	d := &Delivery{}
+	if err := json.Unmarshal(data, d); err != nil {
+		return nil, errors.Wrapf(err, "parsing delivery %s", path)
+	}
+	return d, nil
+}
+
+// Save writes the delivery to the file
+func (d *Delivery) Save(path string) error {
+	data, err := d.marshal()
+	if err != nil {
+		return err
+	}
+	return errors.Wrapf(ioutil.WriteFile(path, data, 0644), "writing delivery %s", path)
+}
+
+func (d *Delivery) marshal() ([]byte, error) {
+	data, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return nil, errors.Wrap(err, "marshalling delivery")
+	}
+	return append(data, '\n'), nil
+}
+
+// Request returns the webhook request of the delivery
+func (d *Delivery) Request() *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/hook", bytes.NewReader(d.Body))
+	for name, value := range d.Headers {
+		r.Header.Set(name, value)
+	}
+	return r
+}
+
+// Parse parses the delivery into the go-scm event of its provider, without verifying its signature as recorded
+// deliveries are sanitized
+func (d *Delivery) Parse() (scm.Webhook, error) {
+	client, err := factory.NewClient(d.Provider, d.ServerURL, "")
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating %s client", d.Provider)
+	}
+	webhook, _, err := payload.Parse(client, d.Request(), nil, 0)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing webhook")
+	}
+	if webhook == nil {
+		return nil, errors.New("no webhook was parsed")
+	}
+	return webhook, nil
+}
@@ -0,0 +1,182 @@
+package record
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// SealedExtension is the extension of the files of the deliveries recorded encrypted
+const SealedExtension = ".enc"
+
+const (
+	// keySize is the size of the AES-256 keys encrypting the deliveries and their data keys
+	keySize = 32
+	// envelopeVersion is the version of the format of the encrypted deliveries
+	envelopeVersion = 1
+)
+
+// envelope is an encrypted delivery. The delivery is encrypted with a data key generated for it, which is itself
+// encrypted with a key of the keyring, so that the deliveries can be read again once the keys are rotated.
+type envelope struct {
+	Version int `json:"version"`
+	// KeyID identifies the key of the keyring the data key was encrypted with
+	KeyID string `json:"keyID"`
+	// WrappedKey is the data key encrypted with the key of the keyring, prefixed with its nonce
+	WrappedKey []byte `json:"wrappedKey"`
+	// Ciphertext is the delivery encrypted with the data key, prefixed with its nonce
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// Keyring holds the keys encrypting the recorded deliveries. The first key encrypts the new deliveries while all of
+// them decrypt the deliveries, so that a new key can be added first to rotate the keys.
+type Keyring struct {
+	ids  []string
+	keys map[string]cipher.AEAD
+}
+
+// NewKeyring creates a keyring of AES-256 keys, the first one encrypting the new deliveries
+func NewKeyring(keys ...[]byte) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("no key")
+	}
+	k := &Keyring{keys: map[string]cipher.AEAD{}}
+	for i, key := range keys {
+		if len(key) != keySize {
+			return nil, errors.Errorf("key #%d has %d bytes instead of %d", i+1, len(key), keySize)
+		}
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, errors.Wrapf(err, "key #%d", i+1)
+		}
+		sum := sha256.Sum256(key)
+		id := hex.EncodeToString(sum[:4])
+		k.ids = append(k.ids, id)
+		k.keys[id] = aead
+	}
+	return k, nil
+}
+
+// LoadKeyring reads a keyring from a file, usually mounted from a secret, listing base64 encoded 32 byte keys one per
+// line, the first one encrypting the new deliveries. Empty lines and lines starting with # are ignored.
+func LoadKeyring(path string) (*Keyring, error) {
+	data, err := ioutil.ReadFile(path) // #nosec
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading keyring %s", path)
+	}
+	var keys [][]byte
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, err := base64.StdEncoding.DecodeString(line)
+		if err != nil {
+			return nil, errors.Wrapf(err, "decoding key #%d of keyring %s", len(keys)+1, path)
+		}
+		keys = append(keys, key)
+	}
+	k, err := NewKeyring(keys...)
+	return k, errors.Wrapf(err, "loading keyring %s", path)
+}
+
+// Seal encrypts the data with a new data key, itself encrypted with the first key of the keyring
+func (k *Keyring) Seal(data []byte) ([]byte, error) {
+	dataKey := make([]byte, keySize)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return nil, errors.Wrap(err, "generating data key")
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	id := k.ids[0]
+	wrappedKey, err := seal(k.keys[id], dataKey, []byte(id))
+	if err != nil {
+		return nil, errors.Wrap(err, "encrypting data key")
+	}
+	ciphertext, err := seal(aead, data, []byte(id))
+	if err != nil {
+		return nil, errors.Wrap(err, "encrypting delivery")
+	}
+	return json.Marshal(&envelope{Version: envelopeVersion, KeyID: id, WrappedKey: wrappedKey, Ciphertext: ciphertext})
+}
+
+// Open decrypts the data encrypted by Seal with one of the keys of the keyring
+func (k *Keyring) Open(data []byte) ([]byte, error) {
+	e := &envelope{}
+	if err := json.Unmarshal(data, e); err != nil {
+		return nil, errors.Wrap(err, "parsing envelope")
+	}
+	if e.Version != envelopeVersion {
+		return nil, errors.Errorf("unsupported envelope version %d", e.Version)
+	}
+	key, ok := k.keys[e.KeyID]
+	if !ok {
+		return nil, errors.Errorf("the key %s is not in the keyring", e.KeyID)
+	}
+	dataKey, err := open(key, e.WrappedKey, []byte(e.KeyID))
+	if err != nil {
+		return nil, errors.Wrap(err, "decrypting data key")
+	}
+	aead, err := newAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := open(aead, e.Ciphertext, []byte(e.KeyID))
+	return plaintext, errors.Wrap(err, "decrypting delivery")
+}
+
+// Load reads the delivery recorded encrypted in the file
+func (k *Keyring) Load(path string) (*Delivery, error) {
+	data, err := ioutil.ReadFile(path) // #nosec
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading delivery %s", path)
+	}
+	plaintext, err := k.Open(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "decrypting delivery %s", path)
+	}
+	d := &Delivery{}
+	if err := json.Unmarshal(plaintext, d); err != nil {
+		return nil, errors.Wrapf(err, "parsing delivery %s", path)
+	}
+	return d, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating cipher")
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts the plaintext, prefixing the ciphertext with the random nonce it was encrypted with
+func seal(aead cipher.AEAD, plaintext, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func open(aead cipher.AEAD, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, additionalData)
+}
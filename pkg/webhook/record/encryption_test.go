@@ -0,0 +1,95 @@
+package record
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordEncrypted(t *testing.T) {
+	dir, err := ioutil.TempDir("", "replay")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	oldKey, newKey := bytes.Repeat([]byte{1}, 32), bytes.Repeat([]byte{2}, 32)
+	keyFile := filepath.Join(dir, "keys")
+	require.NoError(t, ioutil.WriteFile(keyFile, []byte("# rotated on 2020-06-01\n"+base64.StdEncoding.EncodeToString(oldKey)+"\n"), 0600))
+	keyring, err := LoadKeyring(keyFile)
+	require.NoError(t, err)
+
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	header.Set("X-GitHub-Event", "ping")
+	header.Set("X-GitHub-Delivery", "72d3162e-cc78-11e3-81ab-4c9367dc0958")
+
+	recorder := &Recorder{Dir: dir, Keyring: keyring}
+	path, err := recorder.Record("github", "", header, []byte(pingBody))
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "ping-72d3162e-cc78-11e3-81ab-4c9367dc0958.json.enc"), path)
+
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "jenkins-x/dummy", "the payload should not be stored in plain text")
+
+	// the keys are rotated by adding the new key first
+	rotated, err := NewKeyring(newKey, oldKey)
+	require.NoError(t, err)
+	d, err := rotated.Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, "ping", d.Headers["X-Github-Event"])
+	assert.Contains(t, string(d.Body), "jenkins-x/dummy")
+
+	other, err := NewKeyring(newKey)
+	require.NoError(t, err)
+	_, err = other.Load(path)
+	assert.Error(t, err, "the recording should not be decrypted without its key")
+
+	tampered := bytes.Replace(data, []byte(`"ciphertext":"`), []byte(`"ciphertext":"AAAA`), 1)
+	_, err = keyring.Open(tampered)
+	assert.Error(t, err, "a tampered recording should not be decrypted")
+
+	_, err = NewKeyring([]byte("too short"))
+	assert.Error(t, err)
+}
+
+func TestPurge(t *testing.T) {
+	dir, err := ioutil.TempDir("", "replay")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	now := time.Now()
+	files := map[string]time.Duration{
+		"ping-old.json":     48 * time.Hour,
+		"ping-old.json.enc": 48 * time.Hour,
+		"ping-new.json.enc": time.Hour,
+		"notes.txt":         48 * time.Hour,
+	}
+	for name, age := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, ioutil.WriteFile(path, []byte("{}"), 0600))
+		require.NoError(t, os.Chtimes(path, now.Add(-age), now.Add(-age)))
+	}
+
+	removed, err := (&Recorder{Dir: dir}).Purge(now)
+	require.NoError(t, err)
+	assert.Equal(t, 0, removed, "the recordings are kept forever without retention")
+
+	removed, err = (&Recorder{Dir: dir, Retention: 24 * time.Hour}).Purge(now)
+	require.NoError(t, err)
+	assert.Equal(t, 2, removed)
+	left, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	var names []string
+	for _, f := range left {
+		names = append(names, f.Name())
+	}
+	assert.ElementsMatch(t, []string{"notes.txt", "ping-new.json.enc"}, names)
+}
@@ -0,0 +1,198 @@
+package record
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/lighthouse/pkg/payload"
+	"github.com/pkg/errors"
+)
+
+// Redacted replaces the sensitive values of the recorded payloads
+const Redacted = "REDACTED"
+
+// eventHeaders are the headers the SCM providers send the kind of the event in
+var eventHeaders = []string{
+	"X-GitHub-Event",
+	"X-Gitea-Event",
+	"X-Gogs-Event",
+	"X-Gitlab-Event",
+	"X-Event-Key",
+}
+
+// sensitiveHeaders carry the signatures, tokens and credentials of the requests, which are never recorded
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Cookie":              true,
+	"X-Hub-Signature":     true,
+	"X-Hub-Signature-256": true,
+	"X-Gitea-Signature":   true,
+	"X-Gogs-Signature":    true,
+	"X-Gitlab-Token":      true,
+	"X-Forwarded-For":     true,
+}
+
+// sensitiveKeys are the keys of the payloads whose values are redacted, as they hold the email addresses of the
+// users or credentials
+var sensitiveKeys = map[string]bool{
+	"email":        true,
+	"password":     true,
+	"secret":       true,
+	"token":        true,
+	"access_token": true,
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^a-z0-9_.-]+`)
+
+// Recorder records the webhooks delivered to the hook, sanitized, as files of a directory
+type Recorder struct {
+	// Dir is the directory the deliveries are written to
+	Dir string
+	// Keyring encrypts the deliveries, which are written in plain text if it is nil
+	Keyring *Keyring
+	// Retention is how long Purge keeps the deliveries, forever if 0
+	Retention time.Duration
+}
+
+// Record writes the sanitized webhook of the provider to a file named after its event and delivery ID, returning
+// the path of the file
+func (r *Recorder) Record(provider, serverURL string, header http.Header, body []byte) (string, error) {
+	d, err := Sanitize(&Delivery{
+		Provider:  provider,
+		ServerURL: serverURL,
+		Headers:   flatten(header),
+		Body:      body,
+	})
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(r.Dir, 0755); err != nil {
+		return "", errors.Wrapf(err, "creating directory %s", r.Dir)
+	}
+	path := filepath.Join(r.Dir, fileName(header))
+	if r.Keyring == nil {
+		return path, d.Save(path)
+	}
+	data, err := d.marshal()
+	if err != nil {
+		return "", err
+	}
+	sealed, err := r.Keyring.Seal(data)
+	if err != nil {
+		return "", err
+	}
+	path += SealedExtension
+	return path, errors.Wrapf(ioutil.WriteFile(path, sealed, 0600), "writing delivery %s", path)
+}
+
+// Purge removes the deliveries recorded before the retention, returning how many were removed
+func (r *Recorder) Purge(now time.Time) (int, error) {
+	if r.Retention <= 0 {
+		return 0, nil
+	}
+	files, err := ioutil.ReadDir(r.Dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, errors.Wrapf(err, "listing directory %s", r.Dir)
+	}
+	removed := 0
+	cutoff := now.Add(-r.Retention)
+	for _, f := range files {
+		name := f.Name()
+		if !f.Mode().IsRegular() || !(strings.HasSuffix(name, ".json") || strings.HasSuffix(name, ".json"+SealedExtension)) || !f.ModTime().Before(cutoff) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(r.Dir, name)); err != nil && !os.IsNotExist(err) {
+			return removed, errors.Wrapf(err, "removing delivery %s", name)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// Sanitize returns a copy of the delivery without its sensitive headers and with the sensitive values of its
+// payload redacted
+func Sanitize(d *Delivery) (*Delivery, error) {
+	answer := &Delivery{
+		Provider:  d.Provider,
+		ServerURL: d.ServerURL,
+		Headers:   map[string]string{},
+	}
+	for name, value := range d.Headers {
+		if !sensitiveHeaders[http.CanonicalHeaderKey(name)] {
+			answer.Headers[name] = value
+		}
+	}
+	decoder := json.NewDecoder(bytes.NewReader(d.Body))
+	// keep the large IDs of the payloads intact
+	decoder.UseNumber()
+	var body interface{}
+	if err := decoder.Decode(&body); err != nil {
+		return nil, errors.Wrap(err, "decoding payload")
+	}
+	data, err := json.MarshalIndent(redact(body), "", "  ")
+	if err != nil {
+		return nil, errors.Wrap(err, "encoding payload")
+	}
+	answer.Body = data
+	return answer, nil
+}
+
+// redact replaces the non empty values of the sensitive keys of the decoded JSON value
+func redact(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if s, ok := child.(string); ok && s != "" && sensitiveKeys[strings.ToLower(key)] {
+				v[key] = Redacted
+				continue
+			}
+			v[key] = redact(child)
+		}
+	case []interface{}:
+		for i, child := range v {
+			v[i] = redact(child)
+		}
+	}
+	return value
+}
+
+// flatten returns the headers of the request which describe the webhook, keeping the first value of each
+func flatten(header http.Header) map[string]string {
+	answer := map[string]string{}
+	for name, values := range header {
+		name = http.CanonicalHeaderKey(name)
+		if len(values) == 0 || (name != "Content-Type" && !strings.HasPrefix(name, "X-")) {
+			continue
+		}
+		answer[name] = values[0]
+	}
+	return answer
+}
+
+// fileName returns the name of the file recording the webhook, made of its event and delivery ID
+func fileName(header http.Header) string {
+	event := "event"
+	for _, name := range eventHeaders {
+		if value := header.Get(name); value != "" {
+			event = value
+			break
+		}
+	}
+	id := payload.DeliveryID(header)
+	if id == "" {
+		id = fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	name := unsafeFileChars.ReplaceAllString(strings.ToLower(event+"-"+id), "-")
+	return name + ".json"
+}
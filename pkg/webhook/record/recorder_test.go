@@ -0,0 +1,58 @@
+package record
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const pingBody = `{"zen":"Keep it logically awesome.","hook_id":123456789012,"hook":{"type":"Repository","config":{"secret":"hunter2","url":"https://hook.example.com"}},"repository":{"id":42,"name":"dummy","full_name":"jenkins-x/dummy","owner":{"login":"jenkins-x","email":"admin@example.com"}},"sender":{"login":"alice","email":""}}`
+
+func TestRecordAndParse(t *testing.T) {
+	dir, err := ioutil.TempDir("", "replay")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	header.Set("X-GitHub-Event", "ping")
+	header.Set("X-GitHub-Delivery", "72d3162e-cc78-11e3-81ab-4c9367dc0958")
+	header.Set("X-Hub-Signature-256", "sha256=d57c68ca6f92289e6987922ff26938930f6e66a2d161ef06abdf1859230aa23c")
+	header.Set("User-Agent", "GitHub-Hookshot/044aadd")
+
+	recorder := &Recorder{Dir: dir}
+	path, err := recorder.Record("github", "", header, []byte(pingBody))
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "ping-72d3162e-cc78-11e3-81ab-4c9367dc0958.json"), path)
+
+	d, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"Content-Type":      "application/json",
+		"X-Github-Event":    "ping",
+		"X-Github-Delivery": "72d3162e-cc78-11e3-81ab-4c9367dc0958",
+	}, d.Headers, "only the headers describing the webhook should be recorded")
+	body := string(d.Body)
+	assert.NotContains(t, body, "hunter2")
+	assert.NotContains(t, body, "admin@example.com")
+	assert.Contains(t, body, `"email": ""`, "empty values are left as is")
+	assert.Contains(t, body, "123456789012", "large IDs should be kept intact")
+
+	webhook, err := d.Parse()
+	require.NoError(t, err)
+	require.IsType(t, &scm.PingHook{}, webhook)
+	assert.Equal(t, "jenkins-x", webhook.Repository().Namespace)
+}
+
+func TestFileName(t *testing.T) {
+	header := http.Header{}
+	header.Set("X-Gitlab-Event", "Merge Request Hook")
+	header.Set("X-Gitlab-Event-UUID", "B0B5")
+	assert.Equal(t, "merge-request-hook-b0b5.json", fileName(header))
+}
@@ -0,0 +1,66 @@
+package webhook
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/jenkins-x/lighthouse/pkg/testutil/replay/replaytest"
+	"github.com/jenkins-x/lighthouse/pkg/webhook/record"
+	"github.com/stretchr/testify/require"
+)
+
+// TestReplayDeliveries replays the deliveries of test_data/replay against the plugins, asserting the API calls
+// they make against the golden file of each delivery, which are written by running the test with UPDATE_GOLDEN=true
+func TestReplayDeliveries(t *testing.T) {
+	configBytes, err := ioutil.ReadFile("test_data/test_config.yaml")
+	require.NoError(t, err)
+	cfg, err := config.LoadYAMLConfig(configBytes)
+	require.NoError(t, err)
+	configAgent := &config.Agent{}
+	configAgent.Set(cfg)
+
+	pluginBytes, err := ioutil.ReadFile("test_data/test_plugins.yaml")
+	require.NoError(t, err)
+	pluginAgent := &plugins.ConfigAgent{}
+	pluginCfg, err := pluginAgent.LoadYAMLConfig(pluginBytes)
+	require.NoError(t, err)
+	pluginAgent.Set(pluginCfg)
+
+	deliveries, err := filepath.Glob("test_data/replay/*.json")
+	require.NoError(t, err)
+	require.NotEmpty(t, deliveries)
+	for _, path := range deliveries {
+		path := path
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			h := replaytest.NewHarness()
+			h.Data.Collaborators = []string{"alice", "bob"}
+			h.Data.PullRequests[7] = &scm.PullRequest{
+				Number: 7,
+				Title:  "Add the install docs",
+				State:  "open",
+				Author: scm.User{Login: "alice"},
+				Base:   scm.PullRequestBranch{Ref: "master", Sha: "9f8e7d6c5b4a39281706f5e4d3c2b1a098765432"},
+				Head:   scm.PullRequestBranch{Ref: "install-docs", Sha: "1b4e3c2a5d6f7e8091a2b3c4d5e6f708192a3b4c"},
+				Sha:    "1b4e3c2a5d6f7e8091a2b3c4d5e6f708192a3b4c",
+			}
+			h.Data.PullRequestChanges[7] = []*scm.Change{{Path: "docs/install.md", Added: true}}
+			o := &Options{
+				server: &Server{
+					ConfigAgent: configAgent,
+					Plugins:     pluginAgent,
+					ClientAgent: h.ClientAgent(),
+				},
+			}
+
+			d, err := record.Load(path)
+			require.NoError(t, err)
+			require.NoError(t, h.Replay(o, d))
+			replaytest.AssertGolden(t, strings.TrimSuffix(path, ".json")+".golden.yaml", h.Actions())
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package webhook
+
+import (
+	"strings"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/jenkins-x/lighthouse/pkg/plugins/trigger"
+	"github.com/sirupsen/logrus"
+)
+
+// resyncGracePeriod is how long a pull request is left alone after it was updated, so that the jobs triggered
+// by its webhooks have reported their statuses before it is resynced
+const resyncGracePeriod = 10 * time.Minute
+
+// resyncProvider re-evaluates the open pull requests of the configured repositories hosted by the provider which
+// have the trigger plugin enabled, so that the pull requests whose webhooks were not delivered still get tested
+func (o *Options) resyncProvider(p *hookProvider) {
+	if p.server.Plugins.Config() == nil {
+		// the configuration has not been loaded yet
+		return
+	}
+	o.forEachHostedRepository(p, func(l *logrus.Entry, client *scmPollClient, agent *plugins.ClientAgent, fullName string) {
+		i := strings.LastIndex(fullName, "/")
+		org, repo := fullName[:i], fullName[i+1:]
+		if _, ok := p.server.Plugins.PullRequestHandlers(org, repo)[trigger.PluginName]; !ok {
+			return
+		}
+		prs, err := client.ListOpenPullRequests(fullName)
+		if err != nil {
+			l.WithError(err).Error("failed to list the open pull requests")
+			return
+		}
+		for _, pr := range prs {
+			if time.Since(pr.Updated) < resyncGracePeriod {
+				continue
+			}
+			if pr.Base.Repo.Namespace == "" {
+				pr.Base.Repo = scm.Repository{Namespace: org, Name: repo, FullName: fullName}
+			}
+			logger := l.WithField("pr", pr.Number)
+			pluginAgent := plugins.NewAgent(p.server.ClientFactory, p.server.ConfigAgent, p.server.Plugins, agent, p.server.MetapipelineClient, p.server.ServerURL, logger)
+			if err := trigger.Resync(pluginAgent, pr); err != nil {
+				logger.WithError(err).Error("failed to resync the pull request")
+			}
+		}
+	})
+}
@@ -0,0 +1,27 @@
+package webhook
+
+import (
+	"strings"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/sirupsen/logrus"
+)
+
+// scheduleProvider runs the scheduled plugins enabled on each of the configured repositories hosted by the provider
+func (o *Options) scheduleProvider(p *hookProvider) {
+	if p.server.Plugins.Config() == nil {
+		// the configuration has not been loaded yet
+		return
+	}
+	o.forEachHostedRepository(p, func(l *logrus.Entry, client *scmPollClient, agent *plugins.ClientAgent, fullName string) {
+		i := strings.LastIndex(fullName, "/")
+		repo := scm.Repository{Namespace: fullName[:i], Name: fullName[i+1:], FullName: fullName}
+		for name, h := range p.server.Plugins.ScheduledHandlers(repo.Namespace, repo.Name) {
+			p.server.callPlugin(l, name, "ScheduleEvent", func(logger *logrus.Entry) error {
+				pluginAgent := plugins.NewAgent(p.server.ClientFactory, p.server.ConfigAgent, p.server.Plugins, agent, p.server.MetapipelineClient, p.server.ServerURL, logger)
+				return h(pluginAgent, repo)
+			})
+		}
+	})
+}
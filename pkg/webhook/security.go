@@ -0,0 +1,247 @@
+package webhook
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// rejectedSource is the reason of deliveries rejected as they come from a source address which is not allowed
+	rejectedSource = "source"
+	// rejectedRepository is the reason of deliveries rejected as they come from an org or repository which is
+	// not allowed
+	rejectedRepository = "repository"
+	// rejectedProvider is the reason of deliveries rejected as they come from an org hosted by another provider
+	rejectedProvider = "provider"
+)
+
+// ipAllowlist restricts the source addresses webhooks are accepted from to static CIDR ranges and the ranges
+// published by the SCM provider, which are fetched again periodically
+type ipAllowlist struct {
+	static            []*net.IPNet
+	rangesURL         string
+	refresh           time.Duration
+	trustForwardedFor bool
+	httpClient        *http.Client
+
+	lock    sync.Mutex
+	ranges  []*net.IPNet
+	fetched time.Time
+	now     func() time.Time
+}
+
+// newIPAllowlist creates an allowlist of the CIDR ranges plus those published at rangesURL, returning nil if
+// neither is given so that all addresses are allowed
+func newIPAllowlist(cidrs []string, rangesURL string, refresh time.Duration, trustForwardedFor bool) (*ipAllowlist, error) {
+	if len(cidrs) == 0 && rangesURL == "" {
+		return nil, nil
+	}
+	static, err := parseCIDRs(cidrs)
+	if err != nil {
+		return nil, err
+	}
+	return &ipAllowlist{
+		static:            static,
+		rangesURL:         rangesURL,
+		refresh:           refresh,
+		trustForwardedFor: trustForwardedFor,
+		httpClient:        &http.Client{Timeout: 30 * time.Second},
+		now:               time.Now,
+	}, nil
+}
+
+// allowed returns true if the request comes from an allowed address
+func (a *ipAllowlist) allowed(r *http.Request) bool {
+	if a == nil {
+		return true
+	}
+	ip := a.sourceIP(r)
+	if ip == nil {
+		return false
+	}
+	for _, ipNet := range a.static {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	for _, ipNet := range a.providerRanges() {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// sourceIP returns the address of the client, which is the last X-Forwarded-For entry when the hook is behind a
+// trusted proxy: the proxy appends the address it received the request from, the previous entries being written by
+// the client, which could then claim any address
+func (a *ipAllowlist) sourceIP(r *http.Request) net.IP {
+	if a.trustForwardedFor {
+		if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+			entries := strings.Split(forwarded[len(forwarded)-1], ",")
+			return net.ParseIP(strings.TrimSpace(entries[len(entries)-1]))
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return net.ParseIP(host)
+}
+
+// providerRanges returns the ranges published by the provider, keeping the previous ones if they cannot be fetched
+func (a *ipAllowlist) providerRanges() []*net.IPNet {
+	if a.rangesURL == "" {
+		return nil
+	}
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if !a.fetched.IsZero() && a.now().Sub(a.fetched) < a.refresh {
+		return a.ranges
+	}
+	ranges, err := a.fetchRanges()
+	if err != nil {
+		logrus.WithError(err).Warnf("failed to fetch the webhook IP ranges from %s", a.rangesURL)
+		return a.ranges
+	}
+	a.ranges = ranges
+	a.fetched = a.now()
+	return a.ranges
+}
+
+// providerIPRanges is the format of both GitHub's meta API, whose hooks are the webhook sources, and of
+// Atlassian's published IP ranges
+type providerIPRanges struct {
+	Hooks []string `json:"hooks"`
+	Items []struct {
+		CIDR string `json:"cidr"`
+	} `json:"items"`
+}
+
+func (a *ipAllowlist) fetchRanges() ([]*net.IPNet, error) {
+	resp, err := a.httpClient.Get(a.rangesURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	published := providerIPRanges{}
+	if err := json.NewDecoder(resp.Body).Decode(&published); err != nil {
+		return nil, errors.Wrap(err, "parsing IP ranges")
+	}
+	cidrs := published.Hooks
+	for _, item := range published.Items {
+		cidrs = append(cidrs, item.CIDR)
+	}
+	if len(cidrs) == 0 {
+		return nil, errors.New("no IP ranges published")
+	}
+	return parseCIDRs(cidrs)
+}
+
+func parseCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	var answer []*net.IPNet
+	for _, cidr := range cidrs {
+		cidr = strings.TrimSpace(cidr)
+		if cidr == "" {
+			continue
+		}
+		if !strings.Contains(cidr, "/") {
+			if strings.Contains(cidr, ":") {
+				cidr += "/128"
+			} else {
+				cidr += "/32"
+			}
+		}
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid CIDR %s", cidr)
+		}
+		answer = append(answer, ipNet)
+	}
+	return answer, nil
+}
+
+// repoFilter restricts the orgs and repositories webhooks are handled for. Entries are either an org, which may
+// be a nested group on GitLab, or the full name of a repository.
+type repoFilter struct {
+	allow []string
+	deny  []string
+}
+
+// newRepoFilter creates the filter of the allowed and denied orgs and repositories, returning nil if neither is
+// given so that all repositories are allowed
+func newRepoFilter(allow, deny []string) (*repoFilter, error) {
+	f := &repoFilter{}
+	var err error
+	if f.allow, err = parseRepoEntries(allow); err != nil {
+		return nil, err
+	}
+	if f.deny, err = parseRepoEntries(deny); err != nil {
+		return nil, err
+	}
+	if len(f.allow) == 0 && len(f.deny) == 0 {
+		return nil, nil
+	}
+	return f, nil
+}
+
+// repoAllowed returns true if the webhooks of the repository are handled by the repository filter, which is replaced
+// when the allowed or denied repositories are reloaded from the settings
+func (o *Options) repoAllowed(repo scm.Repository) bool {
+	o.repoFilterLock.RLock()
+	defer o.repoFilterLock.RUnlock()
+	return o.repoFilter.allowed(repo)
+}
+
+// allowed returns true if webhooks of the repository are handled. Denied entries take precedence over allowed
+// ones and, when any entry is allowed, all other repositories are rejected.
+func (f *repoFilter) allowed(repo scm.Repository) bool {
+	if f == nil {
+		return true
+	}
+	fullName := repo.FullName
+	if fullName == "" {
+		fullName = scm.Join(repo.Namespace, repo.Name)
+	}
+	fullName = strings.ToLower(fullName)
+	if matchRepoEntries(f.deny, fullName) {
+		return false
+	}
+	return len(f.allow) == 0 || matchRepoEntries(f.allow, fullName)
+}
+
+func parseRepoEntries(entries []string) ([]string, error) {
+	var answer []string
+	for _, entry := range entries {
+		entry = strings.ToLower(strings.Trim(strings.TrimSpace(entry), "/"))
+		if entry == "" {
+			continue
+		}
+		if strings.Contains(entry, "//") {
+			return nil, errors.Errorf("invalid org or repository %s", entry)
+		}
+		answer = append(answer, entry)
+	}
+	return answer, nil
+}
+
+func matchRepoEntries(entries []string, fullName string) bool {
+	for _, entry := range entries {
+		if fullName == entry || strings.HasPrefix(fullName, entry+"/") {
+			return true
+		}
+	}
+	return false
+}
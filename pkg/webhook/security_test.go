@@ -0,0 +1,161 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1" // #nosec
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/go-scm/scm/factory"
+	"github.com/jenkins-x/lighthouse/pkg/gitprovider"
+	"github.com/jenkins-x/lighthouse/pkg/payload"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const pingBody = `{"zen": "Keep it logically awesome.", "hook_id": 1}`
+
+func sign(h func() hash.Hash, token, body string) string {
+	mac := hmac.New(h, []byte(token))
+	_, _ = mac.Write([]byte(body))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func signedPing(t *testing.T, token string) *http.Request {
+	req, err := http.NewRequest(http.MethodPost, "/hook", bytes.NewReader([]byte(pingBody)))
+	require.NoError(t, err)
+	req.Header.Set("X-GitHub-Event", "ping")
+	req.Header.Set("X-GitHub-Delivery", "72d3162e-cc78-11e3-81ab-4c9367dc0958")
+	req.Header.Set("X-Hub-Signature", "sha1="+sign(sha1.New, token, pingBody))
+	return req
+}
+
+func TestParseWebhookWithRotatedTokens(t *testing.T) {
+	os.Setenv("HMAC_TOKEN", "new-token, old-token")
+	defer os.Unsetenv("HMAC_TOKEN")
+
+	scmClient, err := factory.NewClient("github", "", "")
+	require.NoError(t, err)
+	o := &Options{}
+
+	for _, token := range []string{"new-token", "old-token"} {
+		webhook, _, err := o.parseWebhook(gitprovider.Default(), scmClient, signedPing(t, token))
+		require.NoError(t, err, "token %s", token)
+		assert.Equal(t, scm.WebhookKindPing, webhook.Kind())
+	}
+
+	_, _, err = o.parseWebhook(gitprovider.Default(), scmClient, signedPing(t, "retired-token"))
+	assert.Equal(t, scm.ErrSignatureInvalid, err)
+}
+
+func TestParseWebhookSHA256(t *testing.T) {
+	os.Setenv("HMAC_TOKEN", "token")
+	defer os.Unsetenv("HMAC_TOKEN")
+
+	scmClient, err := factory.NewClient("github", "", "")
+	require.NoError(t, err)
+	o := &Options{}
+
+	req := signedPing(t, "token")
+	req.Header.Set("X-Hub-Signature-256", "sha256="+sign(sha256.New, "token", pingBody))
+	_, _, err = o.parseWebhook(gitprovider.Default(), scmClient, req)
+	require.NoError(t, err)
+
+	// the sha256 signature takes precedence
+	req = signedPing(t, "token")
+	req.Header.Set("X-Hub-Signature-256", "sha256="+sign(sha256.New, "other", pingBody))
+	_, _, err = o.parseWebhook(gitprovider.Default(), scmClient, req)
+	assert.Equal(t, scm.ErrSignatureInvalid, err)
+}
+
+func TestParseWebhookTooLarge(t *testing.T) {
+	scmClient, err := factory.NewClient("github", "", "")
+	require.NoError(t, err)
+	o := &Options{MaxPayloadSize: 10}
+
+	_, _, err = o.parseWebhook(gitprovider.Default(), scmClient, signedPing(t, ""))
+	assert.Equal(t, payload.ErrTooLarge, err)
+}
+
+func TestIPAllowlist(t *testing.T) {
+	fetches := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		_, _ = w.Write([]byte(`{"hooks": ["192.30.252.0/22", "2620:112:3000::/44"]}`))
+	}))
+	defer server.Close()
+
+	allowlist, err := newIPAllowlist([]string{"10.0.0.0/8", "172.16.0.1"}, server.URL, time.Hour, false)
+	require.NoError(t, err)
+
+	request := func(remoteAddr, forwarded string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/hook", strings.NewReader(""))
+		req.RemoteAddr = remoteAddr
+		if forwarded != "" {
+			req.Header.Set("X-Forwarded-For", forwarded)
+		}
+		return req
+	}
+
+	assert.True(t, allowlist.allowed(request("10.1.2.3:1234", "")))
+	assert.True(t, allowlist.allowed(request("172.16.0.1:1234", "")))
+	assert.True(t, allowlist.allowed(request("192.30.252.10:1234", "")))
+	assert.True(t, allowlist.allowed(request("[2620:112:3000::1]:1234", "")))
+	assert.False(t, allowlist.allowed(request("8.8.8.8:1234", "")))
+	assert.False(t, allowlist.allowed(request("8.8.8.8:1234", "10.1.2.3")), "X-Forwarded-For should not be trusted")
+	assert.Equal(t, 1, fetches, "the provider ranges should be cached")
+
+	allowlist.trustForwardedFor = true
+	assert.True(t, allowlist.allowed(request("10.99.0.1:1234", "192.30.252.10")))
+	assert.True(t, allowlist.allowed(request("10.99.0.1:1234", "8.8.8.8, 192.30.252.10")))
+	assert.False(t, allowlist.allowed(request("10.99.0.1:1234", "192.30.252.10, 8.8.8.8")), "the spoofed X-Forwarded-For entries of the client should not be trusted")
+	spoofed := request("10.99.0.1:1234", "10.1.2.3")
+	spoofed.Header.Add("X-Forwarded-For", "8.8.8.8")
+	assert.False(t, allowlist.allowed(spoofed), "the X-Forwarded-For header added by the proxy should be the last one")
+
+	var none *ipAllowlist
+	assert.True(t, none.allowed(request("8.8.8.8:1234", "")))
+
+	_, err = newIPAllowlist([]string{"not-an-ip"}, "", time.Hour, false)
+	assert.Error(t, err)
+}
+
+func TestRepoFilter(t *testing.T) {
+	repo := func(fullName string) scm.Repository {
+		i := strings.LastIndex(fullName, "/")
+		return scm.Repository{Namespace: fullName[:i], Name: fullName[i+1:], FullName: fullName}
+	}
+
+	filter, err := newRepoFilter([]string{"org", "other/repo", "group/sub"}, []string{"org/secret"})
+	require.NoError(t, err)
+	assert.True(t, filter.allowed(repo("org/repo")))
+	assert.True(t, filter.allowed(repo("Org/Repo")), "orgs and repositories are case insensitive")
+	assert.True(t, filter.allowed(repo("other/repo")))
+	assert.True(t, filter.allowed(repo("group/sub/repo")), "nested groups are matched")
+	assert.True(t, filter.allowed(scm.Repository{Namespace: "org", Name: "repo"}))
+	assert.False(t, filter.allowed(repo("org/secret")), "denied repositories take precedence")
+	assert.False(t, filter.allowed(repo("other/another")))
+	assert.False(t, filter.allowed(repo("organisation/repo")))
+
+	filter, err = newRepoFilter(nil, []string{"spam"})
+	require.NoError(t, err)
+	assert.True(t, filter.allowed(repo("org/repo")))
+	assert.False(t, filter.allowed(repo("spam/repo")))
+
+	filter, err = newRepoFilter(nil, []string{" "})
+	require.NoError(t, err)
+	assert.Nil(t, filter)
+	assert.True(t, filter.allowed(repo("org/repo")))
+
+	_, err = newRepoFilter([]string{"org//repo"}, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,27 @@
+package webhook
+
+import (
+	"github.com/jenkins-x/lighthouse/pkg/settings"
+	"github.com/sirupsen/logrus"
+)
+
+// reloadSettings applies the allowed and denied repositories of the reloaded settings, keeping the current
+// repository filter if they are invalid. A list removed from the settings falls back to the one the webhooks were
+// started with, so that removing it never allows more repositories until a restart.
+func (o *Options) reloadSettings(s *settings.Settings) {
+	allowed, denied := s.Webhooks.AllowedRepos, s.Webhooks.DeniedRepos
+	if allowed == nil {
+		allowed = o.AllowedRepos
+	}
+	if denied == nil {
+		denied = o.DeniedRepos
+	}
+	filter, err := newRepoFilter(allowed, denied)
+	if err != nil {
+		logrus.WithError(err).Error("Invalid allowed_repos or denied_repos, keeping the current ones.")
+		return
+	}
+	o.repoFilterLock.Lock()
+	defer o.repoFilterLock.Unlock()
+	o.repoFilter = filter
+}
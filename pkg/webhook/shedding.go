@@ -0,0 +1,169 @@
+package webhook
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// DefaultPluginWorkers is the default number of workers handling the events with the plugins
+	DefaultPluginWorkers = 64
+	// DefaultPluginQueueSize is the default number of plugin handlers each event queue holds
+	DefaultPluginQueueSize = 1000
+
+	// labelQueue holds the pull request events of labels being added or removed
+	labelQueue = "PullRequestLabelEvent"
+	// emojiQueue holds the comment events whose body is only made of emoji
+	emojiQueue = "EmojiCommentEvent"
+)
+
+// the priorities of the event queues, the workers always taking the oldest handler of the highest priority queues
+const (
+	priorityLow = iota
+	priorityNormal
+	priorityHigh
+)
+
+// queuePriorities are the priorities of the event queues, which are normal if not listed. The handlers of the low
+// priority queues are dropped when their queue is full while the others wait for room.
+var queuePriorities = map[string]int{
+	"PullRequestEvent":    priorityHigh,
+	"IssueCommentEvent":   priorityHigh,
+	"GenericCommentEvent": priorityHigh,
+	"ReviewEvent":         priorityHigh,
+	"StatusEvent":         priorityLow,
+	emojiQueue:            priorityLow,
+	// the trusted labels grant and revoke the trust of pull requests, so their events are never dropped
+	labelQueue: priorityNormal,
+}
+
+// emojiOnly matches the bodies made of emoji, as :shortcodes: or symbols, without any command or text
+var emojiOnly = regexp.MustCompile(`^(?:\s|:[a-z0-9_+-]+:|[^\p{L}\p{N}/])*$`)
+
+var (
+	queuedEventsGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "lighthouse_webhook_queued_plugin_events",
+		Help: "The number of plugin handlers of the events waiting for a worker, by queue.",
+	}, []string{"queue"})
+	droppedEventsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "lighthouse_webhook_dropped_plugin_events",
+		Help: "A counter of the plugin handlers of low priority events dropped as their queue was full, by queue and plugin.",
+	}, []string{"queue", "plugin"})
+)
+
+func init() {
+	prometheus.MustRegister(queuedEventsGauge, droppedEventsCounter)
+}
+
+// pullRequestQueue returns the queue of the handlers of a pull request event
+func pullRequestQueue(action scm.Action) string {
+	if action == scm.ActionLabel || action == scm.ActionUnlabel {
+		return labelQueue
+	}
+	return "PullRequestEvent"
+}
+
+// commentQueue returns the queue of the handlers of a comment event of the type
+func commentQueue(eventType, body string) string {
+	if strings.TrimSpace(body) != "" && emojiOnly.MatchString(body) {
+		return emojiQueue
+	}
+	return eventType
+}
+
+type queuedHandler struct {
+	run      func()
+	queued   time.Time
+	priority int
+}
+
+// eventQueues is a bounded pool of workers running the plugin handlers of the events from a queue per event type,
+// so that a storm of webhooks cannot exhaust the memory of the hook
+type eventQueues struct {
+	lock sync.Mutex
+	// changed is signalled whenever a handler is queued or taken by a worker
+	changed *sync.Cond
+	size    int
+	queues  map[string][]queuedHandler
+	now     func() time.Time
+}
+
+// newEventQueues creates queues of the given size, handled by the given number of workers
+func newEventQueues(workers, size int) *eventQueues {
+	q := &eventQueues{size: size, queues: map[string][]queuedHandler{}, now: time.Now}
+	q.changed = sync.NewCond(&q.lock)
+	for i := 0; i < workers; i++ {
+		go q.work()
+	}
+	return q
+}
+
+// add queues the handler, returning false if it is dropped as its low priority queue is full. The handlers of the
+// other queues wait for room, which slows down the deliveries of the webhooks.
+func (q *eventQueues) add(queue string, run func()) bool {
+	priority, ok := queuePriorities[queue]
+	if !ok {
+		priority = priorityNormal
+	}
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	for len(q.queues[queue]) >= q.size {
+		if priority == priorityLow {
+			return false
+		}
+		q.changed.Wait()
+	}
+	q.queues[queue] = append(q.queues[queue], queuedHandler{run: run, queued: q.now(), priority: priority})
+	queuedEventsGauge.WithLabelValues(queue).Set(float64(len(q.queues[queue])))
+	q.changed.Broadcast()
+	return true
+}
+
+// work runs the queued handlers forever
+func (q *eventQueues) work() {
+	for {
+		q.next()()
+	}
+}
+
+// next waits for a handler to run
+func (q *eventQueues) next() func() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	for {
+		if run, ok := q.pop(); ok {
+			q.changed.Broadcast()
+			return run
+		}
+		q.changed.Wait()
+	}
+}
+
+// pop removes the oldest handler of the highest priority queues, which must be called with the lock held
+func (q *eventQueues) pop() (func(), bool) {
+	var best string
+	var head *queuedHandler
+	for queue, handlers := range q.queues {
+		if len(handlers) == 0 {
+			continue
+		}
+		h := &handlers[0]
+		if head == nil || h.priority > head.priority || (h.priority == head.priority && h.queued.Before(head.queued)) {
+			best, head = queue, h
+		}
+	}
+	if head == nil {
+		return nil, false
+	}
+	run := head.run
+	// release the handler, which the backing array of the queue would retain
+	*head = queuedHandler{}
+	q.queues[best] = q.queues[best][1:]
+	queuedEventsGauge.WithLabelValues(best).Set(float64(len(q.queues[best])))
+	return run, true
+}
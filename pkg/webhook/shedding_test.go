@@ -0,0 +1,96 @@
+package webhook
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventQueuesOrder(t *testing.T) {
+	q := newEventQueues(0, 2)
+	clock := time.Unix(0, 0)
+	q.now = func() time.Time {
+		clock = clock.Add(time.Second)
+		return clock
+	}
+	var ran []string
+	add := func(queue, name string) bool {
+		return q.add(queue, func() { ran = append(ran, name) })
+	}
+	assert.True(t, add("StatusEvent", "status"))
+	assert.True(t, add("PushEvent", "push"))
+	assert.True(t, add(labelQueue, "label"))
+	assert.True(t, add("PullRequestEvent", "opened"))
+	assert.True(t, add("GenericCommentEvent", "/retest"))
+	assert.True(t, add("PullRequestEvent", "synchronize"))
+	assert.True(t, add("StatusEvent", "other status"))
+	assert.False(t, add("StatusEvent", "dropped status"), "low priority handlers are dropped when their queue is full")
+
+	for {
+		run, ok := q.pop()
+		if !ok {
+			break
+		}
+		run()
+	}
+	assert.Equal(t, []string{"opened", "/retest", "synchronize", "push", "label", "status", "other status"}, ran)
+}
+
+func TestEventQueuesBackpressure(t *testing.T) {
+	// the label events grant and revoke the trust of pull requests so they must not be dropped either
+	for _, queue := range []string{"PullRequestEvent", labelQueue} {
+		t.Run(queue, func(t *testing.T) {
+			q := newEventQueues(0, 1)
+			require.True(t, q.add(queue, func() {}))
+
+			added := make(chan bool)
+			go func() {
+				added <- q.add(queue, func() {})
+			}()
+			select {
+			case <-added:
+				t.Fatal("the handler should wait for room in its queue")
+			case <-time.After(50 * time.Millisecond):
+			}
+			q.next()
+			assert.True(t, <-added, "the handler should be queued once there is room")
+		})
+	}
+}
+
+func TestRunQueuedPluginDropsLowPriorityEvents(t *testing.T) {
+	server := &Server{queues: newEventQueues(0, 1)}
+	l := logrus.WithField("test", t.Name())
+	dropped := testutil.ToFloat64(droppedEventsCounter.WithLabelValues("StatusEvent", "status-reconciler"))
+
+	handled := 0
+	handle := func(l *logrus.Entry) error {
+		handled++
+		return nil
+	}
+	server.runQueuedPlugin("StatusEvent", l, "status-reconciler", "StatusEvent", handle)
+	server.runQueuedPlugin("StatusEvent", l, "status-reconciler", "StatusEvent", handle)
+	assert.Equal(t, dropped+1, testutil.ToFloat64(droppedEventsCounter.WithLabelValues("StatusEvent", "status-reconciler")))
+
+	server.queues.next()()
+	server.Wait()
+	assert.Equal(t, 1, handled)
+}
+
+func TestEventQueueOf(t *testing.T) {
+	assert.Equal(t, labelQueue, pullRequestQueue(scm.ActionLabel))
+	assert.Equal(t, labelQueue, pullRequestQueue(scm.ActionUnlabel))
+	assert.Equal(t, "PullRequestEvent", pullRequestQueue(scm.ActionSync))
+
+	for _, body := range []string{":+1:", "👍 🎉", " :tada: :rocket:\n", "❤️!"} {
+		assert.Equal(t, emojiQueue, commentQueue("GenericCommentEvent", body), "body %q", body)
+	}
+	for _, body := range []string{"", "/lgtm", ":+1: /retest", "LGTM 👍", "thanks"} {
+		assert.Equal(t, "GenericCommentEvent", commentQueue("GenericCommentEvent", body), "body %q", body)
+	}
+}
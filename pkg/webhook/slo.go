@@ -0,0 +1,99 @@
+package webhook
+
+import (
+	"sync"
+	"time"
+
+	"github.com/jenkins-x/lighthouse/pkg/logrusutil"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+// pendingEvents tracks the events received by the webhooks until every plugin handled them
+var pendingEvents = newEventTracker(time.Now)
+
+var oldestPendingEvent = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+	Name: "lighthouse_slo_oldest_pending_event_age_seconds",
+	Help: "The age of the oldest event received by the webhooks which the plugins have not all handled yet, 0 if there is none.",
+}, func() float64 {
+	return pendingEvents.oldest().Seconds()
+})
+
+func init() {
+	prometheus.MustRegister(oldestPendingEvent)
+}
+
+// eventTracker counts the handlers of the events still running, keyed by correlation ID
+type eventTracker struct {
+	lock   sync.Mutex
+	events map[string]*pendingEvent
+	now    func() time.Time
+}
+
+type pendingEvent struct {
+	received time.Time
+	handlers int
+}
+
+func newEventTracker(now func() time.Time) *eventTracker {
+	return &eventTracker{events: map[string]*pendingEvent{}, now: now}
+}
+
+// begin records a handler of the event, which is received now if it is not tracked yet
+func (t *eventTracker) begin(id string) {
+	if id == "" {
+		return
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	e := t.events[id]
+	if e == nil {
+		e = &pendingEvent{received: t.now()}
+		t.events[id] = e
+	}
+	e.handlers++
+}
+
+// done records that a handler of the event completed, forgetting the event once all did
+func (t *eventTracker) done(id string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	e := t.events[id]
+	if e == nil {
+		return
+	}
+	e.handlers--
+	if e.handlers <= 0 {
+		delete(t.events, id)
+	}
+}
+
+// received returns when the event was received, if it is still pending
+func (t *eventTracker) received(id string) (time.Time, bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if e := t.events[id]; e != nil {
+		return e.received, true
+	}
+	return time.Time{}, false
+}
+
+// oldest returns the age of the oldest pending event
+func (t *eventTracker) oldest() time.Duration {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	var answer time.Duration
+	now := t.now()
+	for _, e := range t.events {
+		if age := now.Sub(e.received); age > answer {
+			answer = age
+		}
+	}
+	return answer
+}
+
+// correlationID returns the correlation ID of the event logged by the logger
+func correlationID(l *logrus.Entry) string {
+	id, _ := l.Data[logrusutil.CorrelationIDField].(string)
+	return id
+}
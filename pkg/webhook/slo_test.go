@@ -0,0 +1,51 @@
+package webhook
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/launcher/fake"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventTracker(t *testing.T) {
+	now := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	tracker := newEventTracker(func() time.Time { return now })
+	assert.Equal(t, time.Duration(0), tracker.oldest())
+
+	tracker.begin("first")
+	now = now.Add(time.Second)
+	tracker.begin("second")
+	tracker.begin("first")
+	now = now.Add(time.Second)
+	assert.Equal(t, 2*time.Second, tracker.oldest())
+
+	tracker.done("first")
+	assert.Equal(t, 2*time.Second, tracker.oldest(), "a handler of the first event is still running")
+	tracker.done("first")
+	assert.Equal(t, time.Second, tracker.oldest())
+	_, ok := tracker.received("first")
+	assert.False(t, ok)
+
+	tracker.done("second")
+	tracker.done("unknown")
+	assert.Equal(t, time.Duration(0), tracker.oldest())
+}
+
+func TestCorrelatedLauncherReceivedAt(t *testing.T) {
+	pendingEvents.begin("pending-delivery")
+	defer pendingEvents.done("pending-delivery")
+	received, ok := pendingEvents.received("pending-delivery")
+	require.True(t, ok)
+
+	jobs := fake.NewLauncher()
+	l := &correlatedLauncher{PipelineLauncher: jobs, correlationID: "pending-delivery"}
+	_, err := l.Launch(&v1alpha1.LighthouseJob{}, nil, scm.Repository{})
+	require.NoError(t, err)
+	require.Len(t, jobs.Pipelines, 1)
+	assert.Equal(t, received.UTC().Format(time.RFC3339Nano), jobs.Pipelines[0].Annotations[util.ReceivedAtAnnotation])
+}
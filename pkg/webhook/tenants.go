@@ -0,0 +1,63 @@
+package webhook
+
+import (
+	"strings"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/jx/v2/pkg/tekton/metapipeline"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/launcher"
+	"github.com/jenkins-x/lighthouse/pkg/tenants"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// rejectedTenant is the reason of deliveries rejected as they come from a repository of a tenant delivered by
+// another provider than the one of the tenant
+const rejectedTenant = "tenant"
+
+// tenantLauncher moves the jobs of the repositories of a tenant to the namespace of the tenant
+type tenantLauncher struct {
+	launcher.PipelineLauncher
+	tenants func() *tenants.Config
+}
+
+// Launch assigns the job to its tenant, if any, before launching it
+func (l *tenantLauncher) Launch(job *v1alpha1.LighthouseJob, metapipelineClient metapipeline.Client, repo scm.Repository) (*v1alpha1.LighthouseJob, error) {
+	l.tenants().Assign(job)
+	return l.PipelineLauncher.Launch(job, metapipelineClient, repo)
+}
+
+// tenantConfig returns the current tenants
+func (o *Options) tenantConfig() *tenants.Config {
+	if o.tenants == nil {
+		return &tenants.Config{}
+	}
+	return o.tenants.Config()
+}
+
+// jobsNamespace returns the namespace the plugins list the jobs of the owner in, which is the namespace of its
+// tenant if the whole org belongs to one, all the namespaces if some of its repositories belong to a tenant, or
+// the namespace of lighthouse
+func (o *Options) jobsNamespace(owner string) string {
+	cfg := o.tenantConfig()
+	t := cfg.ForOrg(owner)
+	if t == nil {
+		return o.namespace
+	}
+	for _, org := range t.Orgs {
+		if strings.EqualFold(org, owner) {
+			return t.Namespace
+		}
+	}
+	return metav1.NamespaceAll
+}
+
+// tenantAllowed returns false if the repository belongs to a tenant whose webhooks are delivered by another
+// provider, as the credentials of the tenant would not be used
+func (o *Options) tenantAllowed(p *hookProvider, repo scm.Repository) (*tenants.Tenant, bool) {
+	t := o.tenantConfig().ForRepo(repo.Namespace, repo.Name)
+	if t == nil {
+		return nil, true
+	}
+	return t, t.Provider == p.Name
+}
@@ -0,0 +1,75 @@
+package webhook
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/lighthouse/pkg/apis/lighthouse/v1alpha1"
+	"github.com/jenkins-x/lighthouse/pkg/gitprovider"
+	"github.com/jenkins-x/lighthouse/pkg/launcher/fake"
+	"github.com/jenkins-x/lighthouse/pkg/tenants"
+	"github.com/jenkins-x/lighthouse/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const tenantsYAML = `
+tenants:
+- name: team-a
+  namespace: team-a
+  orgs:
+  - team-a
+  - shared/frontend
+  provider: ghe
+`
+
+func tenantOptions(t *testing.T) *Options {
+	cfg, err := tenants.LoadConfig([]byte(tenantsYAML))
+	require.NoError(t, err)
+	o := &Options{namespace: "jx", tenants: &tenants.Agent{}}
+	o.tenants.Set(cfg)
+	return o
+}
+
+func TestTenantLauncher(t *testing.T) {
+	o := tenantOptions(t)
+	jobs := fake.NewLauncher()
+	l := &tenantLauncher{PipelineLauncher: jobs, tenants: o.tenantConfig}
+
+	job := &v1alpha1.LighthouseJob{}
+	job.Spec.Refs = &v1alpha1.Refs{Org: "shared", Repo: "frontend"}
+	_, err := l.Launch(job, nil, scm.Repository{})
+	require.NoError(t, err)
+	job = &v1alpha1.LighthouseJob{}
+	job.Spec.Refs = &v1alpha1.Refs{Org: "shared", Repo: "docs"}
+	_, err = l.Launch(job, nil, scm.Repository{})
+	require.NoError(t, err)
+
+	require.Len(t, jobs.Pipelines, 2)
+	assert.Equal(t, "team-a", jobs.Pipelines[0].Namespace)
+	assert.Equal(t, "team-a", jobs.Pipelines[0].Labels[util.TenantLabel])
+	assert.Empty(t, jobs.Pipelines[1].Namespace)
+}
+
+func TestJobsNamespace(t *testing.T) {
+	o := tenantOptions(t)
+	assert.Equal(t, "team-a", o.jobsNamespace("Team-A"))
+	assert.Equal(t, metav1.NamespaceAll, o.jobsNamespace("shared"), "the jobs of an org shared by tenants are listed in all the namespaces")
+	assert.Equal(t, "jx", o.jobsNamespace("other"))
+	assert.Equal(t, "jx", (&Options{namespace: "jx"}).jobsNamespace("team-a"))
+}
+
+func TestTenantAllowed(t *testing.T) {
+	o := tenantOptions(t)
+	defaultProvider := &hookProvider{Provider: gitprovider.Default()}
+	ghe := &hookProvider{Provider: &gitprovider.Provider{Name: "ghe"}}
+
+	_, ok := o.tenantAllowed(ghe, scm.Repository{Namespace: "shared", Name: "frontend"})
+	assert.True(t, ok)
+	tenant, ok := o.tenantAllowed(defaultProvider, scm.Repository{Namespace: "shared", Name: "frontend"})
+	assert.False(t, ok, "the repositories of a tenant are only served by its provider")
+	assert.Equal(t, "team-a", tenant.Name)
+	_, ok = o.tenantAllowed(defaultProvider, scm.Repository{Namespace: "shared", Name: "docs"})
+	assert.True(t, ok)
+}
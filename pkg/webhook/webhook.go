@@ -6,28 +6,52 @@ import (
 	"net/url"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"syscall"
+	"time"
 
 	"github.com/jenkins-x/go-scm/scm"
-	"github.com/jenkins-x/go-scm/scm/factory"
 	"github.com/jenkins-x/jx/v2/pkg/jxfactory"
 	"github.com/jenkins-x/lighthouse-config/pkg/config"
+	"github.com/jenkins-x/lighthouse/pkg/admin"
+	"github.com/jenkins-x/lighthouse/pkg/admission"
+	"github.com/jenkins-x/lighthouse/pkg/canary"
 	"github.com/jenkins-x/lighthouse/pkg/clients"
 	"github.com/jenkins-x/lighthouse/pkg/cmd/helper"
+	"github.com/jenkins-x/lighthouse/pkg/configinclude"
+	"github.com/jenkins-x/lighthouse/pkg/configshards"
 	"github.com/jenkins-x/lighthouse/pkg/git"
+	"github.com/jenkins-x/lighthouse/pkg/gitprovider"
+	"github.com/jenkins-x/lighthouse/pkg/health"
+	"github.com/jenkins-x/lighthouse/pkg/interrupts"
 	"github.com/jenkins-x/lighthouse/pkg/launcher"
 	"github.com/jenkins-x/lighthouse/pkg/logrusutil"
+	"github.com/jenkins-x/lighthouse/pkg/logs"
 	"github.com/jenkins-x/lighthouse/pkg/metrics"
+	"github.com/jenkins-x/lighthouse/pkg/migrate"
+	"github.com/jenkins-x/lighthouse/pkg/onboard"
+	"github.com/jenkins-x/lighthouse/pkg/payload"
 	"github.com/jenkins-x/lighthouse/pkg/plugins"
+	"github.com/jenkins-x/lighthouse/pkg/plugins/trigger"
+	"github.com/jenkins-x/lighthouse/pkg/repoowners"
+	"github.com/jenkins-x/lighthouse/pkg/scmprovider"
+	"github.com/jenkins-x/lighthouse/pkg/settings"
+	"github.com/jenkins-x/lighthouse/pkg/store"
+	"github.com/jenkins-x/lighthouse/pkg/tenants"
 	"github.com/jenkins-x/lighthouse/pkg/util"
 	"github.com/jenkins-x/lighthouse/pkg/version"
 	"github.com/jenkins-x/lighthouse/pkg/watcher"
+	"github.com/jenkins-x/lighthouse/pkg/webhook/record"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 
 	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
 )
 
 const (
@@ -35,6 +59,11 @@ const (
 	HealthPath = "/health"
 	// ReadyPath URL path for the HTTP endpoint that returns ready status.
 	ReadyPath = "/ready"
+
+	// statusReconcileInterval is how often the statuses the plugins failed to report are reported again
+	statusReconcileInterval = time.Minute
+	// recordPurgeInterval is how often the recorded webhooks older than --record-retention are purged
+	recordPurgeInterval = time.Hour
 )
 
 // Options holds the command line arguments
@@ -43,6 +72,41 @@ type Options struct {
 	Path        string
 	Port        int
 	JSONLog     bool
+	LogFormat   string
+	LogLevel    string
+	AdminPort   int
+
+	MaxPayloadSize         int64
+	AllowedSourceRanges    []string
+	ProviderIPRangesURL    string
+	ProviderIPRangesPeriod time.Duration
+	TrustForwardedFor      bool
+	AllowedRepos           []string
+	DeniedRepos            []string
+	DeliveryDedup          string
+	DeliveryDedupTTL       time.Duration
+	LogArchiveDir          string
+	CensorSecrets          []string
+	PollInterval           time.Duration
+	ResyncInterval         time.Duration
+	ScheduleInterval       time.Duration
+	LabelConfig            string
+	LabelSyncInterval      time.Duration
+	HookURL                string
+	PluginTimeout          time.Duration
+	PluginWorkers          int
+	PluginQueueSize        int
+	AdmissionPort          int
+	AdmissionCertFile      string
+	AdmissionKeyFile       string
+	WatchLighthouseConfigs bool
+	RecordDir              string
+	RecordKeyFile          string
+	RecordRetention        time.Duration
+	GitCacheDir            string
+	GitCacheMaxSize        string
+	StateStore             store.Options
+	SettingsFile           string
 
 	factory          jxfactory.Factory
 	namespace        string
@@ -50,10 +114,19 @@ type Options struct {
 	configFilename   string
 	server           *Server
 	botName          string
-	gitServerURL     string
+	providers        []*hookProvider
 	configMapWatcher *watcher.ConfigMapWatcher
-	gitClient        git.Client
 	launcher         launcher.PipelineLauncher
+	ipAllowlist      *ipAllowlist
+	repoFilter       *repoFilter
+	repoFilterLock   sync.RWMutex
+	tenants          *tenants.Agent
+	settings         *settings.Settings
+	deliveries       DeliveryStore
+	recorder         *record.Recorder
+	store            store.Store
+	poller           poller
+	health           *health.Checker
 }
 
 // NewCmdWebhook creates the command
@@ -64,12 +137,18 @@ func NewCmdWebhook() *cobra.Command {
 		Use:   "lighthouse",
 		Short: "Runs the lighthouse webhook handler",
 		Run: func(cmd *cobra.Command, args []string) {
-			err := options.Run()
+			var err error
+			options.settings, err = settings.ConfigureCommand(cmd, options.SettingsFile, "webhooks")
+			helper.CheckErr(err)
+			err = options.Run()
 			helper.CheckErr(err)
 		},
 	}
 
 	cmd.Flags().BoolVarP(&options.JSONLog, "json", "", true, "Enable JSON logging")
+	cmd.Flags().StringVar(&options.LogFormat, "log-format", "", "The format of the logs: json, text or stackdriver. Defaults to $LOGRUS_FORMAT, or JSON when --json is set.")
+	cmd.Flags().StringVar(&options.LogLevel, "log-level", logrus.InfoLevel.String(), "The level of the logs, which can be changed at runtime on the admin port.")
+	cmd.Flags().IntVar(&options.AdminPort, "admin-port", 0, "The TCP port serving the admin endpoints: pprof profiles, expvar variables at /debug/vars and "+logrusutil.LevelPath+" to read or PUT the log level. It should not be exposed publicly. Disabled by default.")
 	cmd.Flags().IntVarP(&options.Port, "port", "", 8080, "The TCP port to listen on.")
 	cmd.Flags().StringVarP(&options.BindAddress, "bind", "", "",
 		"The interface address to bind to (by default, will listen on all interfaces/addresses).")
@@ -77,8 +156,42 @@ func NewCmdWebhook() *cobra.Command {
 		"The path to listen on for requests to trigger a pipeline run.")
 	cmd.Flags().StringVar(&options.pluginFilename, "plugin-file", "", "Path to the plugins.yaml file. If not specified it is loaded from the 'plugins' ConfigMap")
 	cmd.Flags().StringVar(&options.configFilename, "config-file", "", "Path to the config.yaml file. If not specified it is loaded from the 'config' ConfigMap")
+	cmd.Flags().Int64Var(&options.MaxPayloadSize, "max-payload-size", payload.DefaultMaxSize, "The largest webhook payload in bytes which is accepted.")
+	cmd.Flags().StringSliceVar(&options.AllowedSourceRanges, "allowed-source-ranges", nil, "The CIDR ranges webhooks are accepted from. All addresses are allowed if neither this nor --provider-ip-ranges-url is given.")
+	cmd.Flags().StringVar(&options.ProviderIPRangesURL, "provider-ip-ranges-url", "", "The URL the SCM provider publishes its webhook source ranges at, e.g. https://api.github.com/meta, which are allowed too.")
+	cmd.Flags().DurationVar(&options.ProviderIPRangesPeriod, "provider-ip-ranges-refresh", time.Hour, "How often the ranges at --provider-ip-ranges-url are fetched again.")
+	cmd.Flags().BoolVar(&options.TrustForwardedFor, "trust-forwarded-for", false, "Use the last X-Forwarded-For entry, appended by a trusted proxy, as the source address of webhooks.")
+	cmd.Flags().StringSliceVar(&options.AllowedRepos, "allowed-repos", nil, "The orgs or org/repo repositories webhooks are handled for. All repositories are allowed if not given.")
+	cmd.Flags().StringSliceVar(&options.DeniedRepos, "denied-repos", nil, "The orgs or org/repo repositories whose webhooks are rejected, even if allowed by --allowed-repos.")
+	cmd.Flags().StringVar(&options.DeliveryDedup, "delivery-dedup", NoDedup, "How to skip retried webhook deliveries: none, memory for a single replica, configmap to share them across replicas or store to keep them in the --state-store.")
+	cmd.Flags().DurationVar(&options.DeliveryDedupTTL, "delivery-dedup-ttl", time.Hour, "How long processed webhook deliveries are remembered.")
+	cmd.Flags().StringVar(&options.LogArchiveDir, "log-archive-dir", "", "The directory, usually a mounted storage bucket, build logs are archived to and served from below "+logs.Path+" once their pods are gone.")
+	cmd.Flags().StringSliceVar(&options.CensorSecrets, "censor-secrets", nil, "The secrets whose values are masked in every build log served below "+logs.Path+", in addition to the secrets mounted into the pods of the job.")
+	cmd.Flags().DurationVar(&options.PollInterval, "poll-interval", 0, "How often the configured repositories are polled for changes, which are handled as if their webhooks had been delivered. Polling is disabled by default, it is meant for SCM providers which cannot send webhooks to lighthouse.")
+	cmd.Flags().DurationVar(&options.ResyncInterval, "resync-interval", 0, "How often the open pull requests of the configured repositories are checked for jobs which never ran, e.g. as their webhooks were not delivered. Disabled by default.")
+	cmd.Flags().DurationVar(&options.ScheduleInterval, "schedule-interval", 0, "How often the scheduled plugins, such as reminder, run on the configured repositories they are enabled for. Disabled by default.")
+	cmd.Flags().StringVar(&options.LabelConfig, "label-config", "", "Path to the labels.yaml file declaring the labels, with their colors, descriptions and aliases, which the configured repositories should have.")
+	cmd.Flags().DurationVar(&options.LabelSyncInterval, "label-sync-interval", time.Hour, "How often the labels of the configured repositories are synchronized with --label-config.")
+	cmd.Flags().StringVar(&options.HookURL, "hook-url", "", "The public URL of the hook endpoint, which the webhooks registered by the "+onboard.Path+" endpoint of the admin port point at. The endpoint is disabled if not set.")
+	cmd.Flags().DurationVar(&options.PluginTimeout, "plugin-timeout", DefaultPluginTimeout, "How long a plugin can handle an event before it is reported as timed out in the logs and the lighthouse_plugin_handler_outcomes metric.")
+	cmd.Flags().IntVar(&options.PluginWorkers, "plugin-workers", DefaultPluginWorkers, "How many plugin handlers of the events run at once, taken from a queue per event type which favors pull request and comment events. Every handler runs in its own goroutine if 0.")
+	cmd.Flags().IntVar(&options.PluginQueueSize, "plugin-queue-size", DefaultPluginQueueSize, "How many plugin handlers each event queue holds. Low priority events, such as statuses, label changes and emoji comments, are dropped when their queue is full while the deliveries of the other events wait for room.")
+	cmd.Flags().IntVar(&options.AdmissionPort, "admission-port", 0, "The TCP port serving the validating admission webhook of LighthouseJobs at "+admission.Path+" and their conversion webhook at "+admission.ConversionPath+" over TLS. Disabled by default.")
+	cmd.Flags().StringVar(&options.AdmissionCertFile, "admission-cert-file", "", "The TLS certificate of the admission webhook.")
+	cmd.Flags().StringVar(&options.AdmissionKeyFile, "admission-key-file", "", "The TLS private key of the admission webhook.")
+	cmd.Flags().BoolVar(&options.WatchLighthouseConfigs, "watch-lighthouse-configs", false, "Merges the jobs of the LighthouseConfig resources of every namespace into the config.yaml of the ConfigMap.")
+	cmd.Flags().StringVar(&options.RecordDir, "record-dir", "", "The directory the accepted webhooks are recorded to, with their signatures and email addresses removed, so that they can be replayed by regression tests. Disabled by default.")
+	cmd.Flags().StringVar(&options.RecordKeyFile, "record-key-file", "", "The keyring file, usually mounted from a secret, of the base64 encoded 32 byte AES keys the recorded webhooks are encrypted with, one per line. The first key encrypts the new recordings while all of them decrypt, so keys are rotated by adding a new first line. The recordings are written in plain text if not set.")
+	cmd.Flags().DurationVar(&options.RecordRetention, "record-retention", 0, "How long the recorded webhooks are kept before they are purged. Kept forever if 0.")
 	cmd.Flags().StringVar(&options.botName, "bot-name", "", "The name of the bot user to run as. Defaults to $GIT_USER if not specified.")
-
+	cmd.Flags().StringVar(&options.GitCacheDir, "git-cache-dir", "", "The directory, usually a persistent volume, of the bare repos the git clones resolving OWNERS files are made from, which are kept across events and restarts. A temporary directory is used if not set.")
+	cmd.Flags().StringVar(&options.GitCacheMaxSize, "git-cache-max-size", "", "The size of the git cache, e.g. 20Gi, above which the least recently used repos are evicted. Unlimited if not set.")
+	options.StateStore.AddFlags(cmd)
+	cmd.Flags().StringVar(&options.SettingsFile, settings.FileFlag, "", "The LighthouseSettings file, usually mounted from the lighthouse-settings ConfigMap, whose webhooks section overrides the other flags. Its log_level, allowed_repos and denied_repos are reloaded when it changes.")
+
+	cmd.AddCommand(migrate.NewCmdMigrate())
+	cmd.AddCommand(onboard.NewCmdOnboard())
+	cmd.AddCommand(record.NewCmdDecrypt())
 	return cmd
 }
 
@@ -92,64 +205,221 @@ func NewWebhook(factory jxfactory.Factory, server *Server) *Options {
 
 // Run will implement this command
 func (o *Options) Run() error {
-	if o.JSONLog {
+	switch {
+	case o.LogFormat != "":
+		logrus.SetFormatter(logrusutil.CreateFormatter(o.LogFormat))
+	case o.JSONLog:
 		logrus.SetFormatter(logrusutil.CreateDefaultFormatter())
 	}
+	level, err := logrus.ParseLevel(o.LogLevel)
+	if err != nil {
+		return errors.Wrap(err, "invalid --log-level")
+	}
+	logrus.SetLevel(level)
 
 	_, ns, err := o.GetFactory().CreateJXClient()
 	if err != nil {
 		return errors.Wrapf(err, "failed to create JX Client")
 	}
 	o.namespace = ns
-	o.server, err = o.createHookServer()
+	o.ipAllowlist, err = newIPAllowlist(o.AllowedSourceRanges, o.ProviderIPRangesURL, o.ProviderIPRangesPeriod, o.TrustForwardedFor)
 	if err != nil {
-		return errors.Wrapf(err, "failed to create Hook Server")
+		return errors.Wrap(err, "invalid --allowed-source-ranges")
 	}
-	defer o.configMapWatcher.Stop()
-
-	_, o.gitServerURL, err = o.createSCMClient()
+	o.repoFilter, err = newRepoFilter(o.AllowedRepos, o.DeniedRepos)
 	if err != nil {
-		return errors.Wrapf(err, "failed to create ScmClient")
+		return errors.Wrap(err, "invalid --allowed-repos or --denied-repos")
 	}
-
-	gitClient, err := git.NewClient(o.gitServerURL, o.gitKind())
+	settings.Watch(o.SettingsFile, "webhooks", o.settings, o.reloadSettings)
+	providers, err := gitprovider.All()
+	if err != nil {
+		return err
+	}
+	err = o.createHookServers(providers)
 	if err != nil {
-		logrus.WithError(err).Fatal("Error getting git client.")
+		return errors.Wrapf(err, "failed to create Hook Server")
 	}
-	defer func() {
-		err := gitClient.Clean()
+	defer o.configMapWatcher.Stop()
+
+	cacheOptions := git.CacheOptions{}
+	if o.GitCacheMaxSize != "" {
+		maxSize, err := resource.ParseQuantity(o.GitCacheMaxSize)
 		if err != nil {
-			logrus.WithError(err).Fatal("Error cleaning the git client.")
+			return errors.Wrap(err, "invalid --git-cache-max-size")
 		}
-	}()
-
-	o.gitClient = gitClient
+		cacheOptions.MaxSize = maxSize.Value()
+	}
+	for _, p := range o.providers {
+		options := cacheOptions
+		if o.GitCacheDir != "" {
+			options.Dir = filepath.Join(o.GitCacheDir, p.String())
+		}
+		p.gitClient, err = git.NewClientWithCache(p.ServerURL(), p.Kind(), options)
+		p.owners = repoowners.NewCache()
+		if err != nil {
+			logrus.WithError(err).Fatalf("Error getting git client of provider %s.", p)
+		}
+		defer func(gitClient git.Client) {
+			err := gitClient.Clean()
+			if err != nil {
+				logrus.WithError(err).Fatal("Error cleaning the git client.")
+			}
+		}(p.gitClient)
+	}
 
-	_, jxClient, _, lhClient, _, err := clients.GetClientsAndNamespace(nil)
+	tektonClient, jxClient, kubeClient, lhClient, _, err := clients.GetClientsAndNamespace(nil)
 	if err != nil {
 		err = errors.Wrapf(err, "failed to create JX client")
 		logrus.Errorf("%s", err.Error())
 		return err
 	}
-	o.launcher, err = launcher.NewLauncher(jxClient, lhClient, o.namespace)
+	o.store, err = o.StateStore.New(kubeClient, o.namespace)
+	if err != nil {
+		return errors.Wrap(err, "invalid --state-store")
+	}
+	o.deliveries, err = NewDeliveryStore(o.DeliveryDedup, kubeClient, o.namespace, o.DeliveryDedupTTL, o.store)
+	if err != nil {
+		return errors.Wrap(err, "invalid --delivery-dedup")
+	}
+	o.launcher, err = launcher.NewLauncher(jxClient, tektonClient, lhClient, kubeClient, o.namespace)
 	if err != nil {
 		err = errors.Wrapf(err, "failed to create PipelineLauncher client")
 		logrus.Errorf("%s", err.Error())
 		return err
 	}
+	if o.RecordDir != "" {
+		o.recorder = &record.Recorder{Dir: o.RecordDir, Retention: o.RecordRetention}
+		if o.RecordKeyFile != "" {
+			if o.recorder.Keyring, err = record.LoadKeyring(o.RecordKeyFile); err != nil {
+				return errors.Wrap(err, "invalid --record-key-file")
+			}
+		}
+		if o.RecordRetention > 0 {
+			interrupts.TickLiteral(func() {
+				if removed, err := o.recorder.Purge(time.Now()); err != nil {
+					logrus.WithError(err).Warn("failed to purge the recorded webhooks")
+				} else if removed > 0 {
+					logrus.Infof("Purged %d recorded webhooks older than %s", removed, o.RecordRetention)
+				}
+			}, recordPurgeInterval)
+		}
+	}
+
+	if o.PollInterval > 0 {
+		for _, p := range o.providers {
+			p := p
+			logrus.Infof("Polling the repositories of provider %s every %s", p, o.PollInterval)
+			interrupts.TickLiteral(func() {
+				o.pollProvider(p)
+			}, o.PollInterval)
+		}
+	}
+
+	if o.ResyncInterval > 0 {
+		for _, p := range o.providers {
+			p := p
+			interrupts.TickLiteral(func() {
+				o.resyncProvider(p)
+			}, o.ResyncInterval)
+		}
+	}
+
+	interrupts.TickLiteral(func() {
+		trigger.ReconcileStatuses(logrus.WithField("component", "status-reconciler"))
+	}, statusReconcileInterval)
+
+	if o.ScheduleInterval > 0 {
+		for _, p := range o.providers {
+			p := p
+			interrupts.TickLiteral(func() {
+				o.scheduleProvider(p)
+			}, o.ScheduleInterval)
+		}
+	}
+
+	if o.LabelConfig != "" && o.LabelSyncInterval > 0 {
+		for _, p := range o.providers {
+			p := p
+			interrupts.TickLiteral(func() {
+				o.labelSyncProvider(p)
+			}, o.LabelSyncInterval)
+		}
+	}
+
+	o.health = o.healthChecker(kubeClient)
+
 	mux := http.NewServeMux()
-	mux.Handle(HealthPath, http.HandlerFunc(o.health))
+	o.health.Register(mux)
+	mux.Handle(HealthPath, http.HandlerFunc(o.healthCheck))
 	mux.Handle(ReadyPath, http.HandlerFunc(o.ready))
+	mux.Handle(logs.Path, &logs.Handler{
+		KubeClient:    kubeClient,
+		JobClient:     lhClient.LighthouseV1alpha1().LighthouseJobs(o.namespace),
+		Namespace:     o.namespace,
+		ArchiveDir:    o.LogArchiveDir,
+		CensorSecrets: o.CensorSecrets,
+	})
+	mux.Handle(PluginHelpPath, &PluginHelpHandler{Plugins: o.providers[0].server.Plugins})
+
+	if o.AdmissionPort > 0 {
+		if o.AdmissionCertFile == "" || o.AdmissionKeyFile == "" {
+			return errors.New("--admission-cert-file and --admission-key-file are required to serve the admission webhook")
+		}
+		admissionMux := http.NewServeMux()
+		admissionMux.Handle(admission.Path, &admission.Validator{
+			JobClient: lhClient.LighthouseV1alpha1().LighthouseJobs(o.namespace),
+			Namespace: o.namespace,
+		})
+		admissionMux.Handle(admission.ConversionPath, &admission.Converter{})
+		logrus.Infof("Serving the admission webhook on port %d", o.AdmissionPort)
+		server := &http.Server{Addr: ":" + strconv.Itoa(o.AdmissionPort), Handler: admissionMux}
+		interrupts.ListenAndServeTLS(server, o.AdmissionCertFile, o.AdmissionKeyFile, 5*time.Second)
+	}
+
+	if o.AdminPort > 0 {
+		server := o.providers[0].server
+		admin.PublishConfigHash("config_hash", func() interface{} { return server.ConfigAgent.Config() })
+		admin.PublishConfigHash("plugins_hash", func() interface{} { return server.Plugins.Config() })
+		if o.HookURL != "" {
+			admin.Handle(onboard.Path, http.HandlerFunc(o.handleOnboard))
+		}
+		admin.Handle(repoowners.QueryPath, http.HandlerFunc(o.handleOwners))
+		admin.Handle(canary.Path, http.HandlerFunc(o.handleCanary))
+		admin.Serve(o.AdminPort)
+	}
 
 	mux.Handle("/", http.HandlerFunc(o.defaultHandler))
 	mux.Handle(o.Path, http.HandlerFunc(o.handleWebHookRequests))
 
+	for _, p := range o.providers[1:] {
+		logrus.Infof("Serving the WebHooks of provider %s on path %s/%s", p, o.Path, p.Name)
+	}
 	logrus.Infof("Lighthouse is now listening on path %s and port %d for WebHooks", o.Path, o.Port)
 	return http.ListenAndServe(":"+strconv.Itoa(o.Port), mux)
 }
 
-// health returns either HTTP 204 if the service is healthy, otherwise nothing ('cos it's dead).
-func (o *Options) health(w http.ResponseWriter, r *http.Request) {
+// healthChecker checks the Kubernetes API, the configuration and the API of each SCM provider
+func (o *Options) healthChecker(kubeClient kubernetes.Interface) *health.Checker {
+	checker := &health.Checker{}
+	checker.AddLiveness("kubernetes", health.Kubernetes(kubeClient))
+	for _, p := range o.providers {
+		server := p.server
+		checker.AddReadiness("config-"+p.String(), health.Loaded("configuration", func() bool {
+			return server.ConfigAgent.Config() != nil && server.Plugins.Config() != nil
+		}))
+		scmClient, _, err := o.createSCMClient(p.Provider)
+		if err != nil {
+			logrus.WithError(err).Warnf("not checking the API of provider %s", p)
+			continue
+		}
+		// the API is rate limited so it is not checked on every probe
+		checker.AddReadiness("scm-"+p.String(), health.Cached(health.Reachable(scmClient.BaseURL.String()), time.Minute))
+	}
+	return checker
+}
+
+// healthCheck returns either HTTP 204 if the service is healthy, otherwise nothing ('cos it's dead).
+func (o *Options) healthCheck(w http.ResponseWriter, r *http.Request) {
 	logrus.Debug("Health check")
 	w.WriteHeader(http.StatusNoContent)
 }
@@ -178,92 +448,194 @@ func (o *Options) defaultHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func (o *Options) isReady() bool {
-	// TODO a better readiness check
-	return true
+	return o.health == nil || o.health.Readiness().OK
 }
 
 // handle request for pipeline runs
 func (o *Options) handleWebHookRequests(w http.ResponseWriter, r *http.Request) {
+	l := logrus.WithField(logrusutil.CorrelationIDField, logrusutil.CorrelationID(r))
 	if r.Method != http.MethodPost {
 		// liveness probe etc
-		logrus.WithField("method", r.Method).Debug("invalid http method so returning 200")
+		l.WithField("method", r.Method).Debug("invalid http method so returning 200")
+		return
+	}
+	if !o.ipAllowlist.allowed(r) {
+		rejectedCounter.WithLabelValues(rejectedSource).Inc()
+		responseHTTPError(w, http.StatusForbidden, fmt.Sprintf("403 Forbidden: source address %s is not allowed", r.RemoteAddr))
+		return
+	}
+	p := o.providerForPath(r.URL.Path)
+	if p == nil {
+		responseHTTPError(w, http.StatusNotFound, fmt.Sprintf("404 Not Found: no provider is served at %s", r.URL.Path))
 		return
 	}
-	logrus.Debug("about to parse webhook")
+	l.Debug("about to parse webhook")
 
-	scmClient, serverURL, err := o.createSCMClient()
+	scmClient, serverURL, err := o.createSCMClient(p.Provider)
 	if err != nil {
-		logrus.Errorf("failed to create SCM scmClient: %s", err.Error())
+		l.Errorf("failed to create SCM scmClient: %s", err.Error())
 		responseHTTPError(w, http.StatusInternalServerError, fmt.Sprintf("500 Internal Server Error: Failed to parse webhook: %s", err.Error()))
 		return
 	}
 
-	webhook, err := scmClient.Webhooks.Parse(r, o.secretFn)
-	if err != nil {
-		logrus.Warnf("failed to parse webhook: %s", err.Error())
+	webhook, body, err := o.parseWebhook(p.Provider, scmClient, r)
+	switch {
+	case err == payload.ErrTooLarge:
+		responseHTTPError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("413 Request Entity Too Large: payload exceeds %d bytes", o.MaxPayloadSize))
+		return
+	case err == scm.ErrSignatureInvalid:
+		l.Warn("webhook has an invalid signature")
+		responseHTTPError(w, http.StatusUnauthorized, "401 Unauthorized: invalid webhook signature")
+		return
+	case err != nil:
+		l.Warnf("failed to parse webhook: %s", err.Error())
 
 		responseHTTPError(w, http.StatusInternalServerError, fmt.Sprintf("500 Internal Server Error: Failed to parse webhook: %s", err.Error()))
 		return
 	}
 	if webhook == nil {
-		logrus.Error("no webhook was parsed")
+		l.Error("no webhook was parsed")
 
 		responseHTTPError(w, http.StatusInternalServerError, "500 Internal Server Error: No webhook could be parsed")
 		return
 	}
+	if _, ok := webhook.(*scm.PingHook); !ok && !o.repoAllowed(webhook.Repository()) {
+		repo := webhook.Repository()
+		l.WithField("Webhook", webhook.Kind()).Infof("rejecting webhook of repository %s/%s which is not allowed", repo.Namespace, repo.Name)
+		rejectedCounter.WithLabelValues(rejectedRepository).Inc()
+		responseHTTPError(w, http.StatusForbidden, fmt.Sprintf("403 Forbidden: repository %s/%s is not allowed", repo.Namespace, repo.Name))
+		return
+	}
+	if _, ok := webhook.(*scm.PingHook); !ok {
+		// the webhooks of an org hosted by another provider would be handled with the wrong endpoint and credentials
+		org := webhook.Repository().Namespace
+		if hosted, err := p.Hosts(org); err == nil && !hosted {
+			l.WithField("Webhook", webhook.Kind()).Infof("rejecting webhook of org %s which is not hosted by provider %s", org, p.String())
+			rejectedCounter.WithLabelValues(rejectedProvider).Inc()
+			responseHTTPError(w, http.StatusForbidden, fmt.Sprintf("403 Forbidden: org %s is not hosted by this provider", org))
+			return
+		}
+		if t, ok := o.tenantAllowed(p, webhook.Repository()); !ok {
+			repo := webhook.Repository()
+			l.WithField("Webhook", webhook.Kind()).Infof("rejecting webhook of repository %s/%s of tenant %s which is not delivered by provider %s", repo.Namespace, repo.Name, t.Name, p.String())
+			rejectedCounter.WithLabelValues(rejectedTenant).Inc()
+			responseHTTPError(w, http.StatusForbidden, fmt.Sprintf("403 Forbidden: repository %s/%s is not served by this provider", repo.Namespace, repo.Name))
+			return
+		}
+	}
+	if !o.claimDelivery(r) {
+		_, err = w.Write([]byte("skipped duplicate delivery"))
+		if err != nil {
+			l.Debugf("failed to write the response: %v", err)
+		}
+		return
+	}
+	if o.RecordDir != "" {
+		recorder := o.recorder
+		if recorder == nil {
+			recorder = &record.Recorder{Dir: o.RecordDir}
+		}
+		if path, err := recorder.Record(p.Kind(), p.ServerURL(), r.Header, body); err != nil {
+			l.WithError(err).Warn("failed to record the webhook")
+		} else {
+			l.WithField("path", path).Debug("recorded the webhook")
+		}
+	}
+	// the event is pending until the plugins handled it
+	pendingEvents.begin(correlationID(l))
+	defer pendingEvents.done(correlationID(l))
+	// let the external plugins log the same correlation ID
+	r.Header.Set(logrusutil.CorrelationIDHeader, l.Data[logrusutil.CorrelationIDField].(string))
+	p.server.HandleExternalPlugins(l.WithField("Webhook", webhook.Kind()), webhook, r.Header, body)
+
+	agent, err := o.clientAgent(p, scmClient, serverURL, webhook.Repository().Namespace, l)
+	if err != nil {
+		l.Errorf("failed to create the clients of the plugins: %s", err.Error())
+		o.releaseDelivery(r)
+		responseHTTPError(w, http.StatusInternalServerError, fmt.Sprintf("500 Internal Server Error: %s", err.Error()))
+		return
+	}
+	l, output, err := o.processWebHook(p.server, agent, l.WithField("Webhook", webhook.Kind()), webhook)
+	if err != nil {
+		o.releaseDelivery(r)
+		responseHTTPError(w, http.StatusInternalServerError, fmt.Sprintf("500 Internal Server Error: %s", err.Error()))
+	}
+	p.server.HandleDownstreams(l, webhook, r.Header, body)
+	_, err = w.Write([]byte(output))
+	if err != nil {
+		l.Debugf("failed to process the webhook: %v", err)
+	}
+}
 
+// clientAgent returns the clients of the plugins handling an event of the owner on the provider, authenticating
+// the SCM client with the token of the bot or of the GitHub App installation of the owner. The requests of the SCM
+// client and the jobs launched carry the correlation ID of the logger, if any.
+func (o *Options) clientAgent(p *hookProvider, scmClient *scm.Client, serverURL, owner string, l *logrus.Entry) (*plugins.ClientAgent, error) {
 	ghaSecretDir := util.GetGitHubAppSecretDir()
 
 	var gitCloneUser string
 	var token string
+	var err error
 	if ghaSecretDir != "" {
 		gitCloneUser = util.GitHubAppGitRemoteUsername
 		tokenFinder := util.NewOwnerTokensDir(serverURL, ghaSecretDir)
-		token, err = tokenFinder.FindToken(webhook.Repository().Namespace)
+		token, err = tokenFinder.FindToken(owner)
 		if err != nil {
-			logrus.Errorf("failed to read owner token: %s", err.Error())
-			responseHTTPError(w, http.StatusInternalServerError, fmt.Sprintf("500 Internal Server Error: failed to read owner token: %s", err.Error()))
-			return
+			return nil, errors.Wrap(err, "failed to read owner token")
 		}
 	} else {
-		gitCloneUser = o.GetBotName()
-		token, err = o.createSCMToken(o.gitKind())
+		gitCloneUser = o.providerBotName(p.Provider)
+		token, err = p.Token()
 		if err != nil {
-			logrus.Errorf("no scm token specified: %s", err.Error())
-			responseHTTPError(w, http.StatusInternalServerError, fmt.Sprintf("500 Internal Server Error: no scm token specified: %s", err.Error()))
-			return
+			return nil, errors.Wrap(err, "no scm token specified")
 		}
 	}
 	_, _, kubeClient, lhClient, _, err := clients.GetClientsAndNamespace(nil)
 	if err != nil {
-		responseHTTPError(w, http.StatusInternalServerError, fmt.Sprintf("500 Internal Server Error: %s", err.Error()))
+		return nil, err
 	}
 
-	o.gitClient.SetCredentials(gitCloneUser, func() []byte {
+	p.gitClient.SetCredentials(gitCloneUser, func() []byte {
 		return []byte(token)
 	})
 	util.AddAuthToSCMClient(scmClient, token, ghaSecretDir != "")
+	scmClient.Client.Transport = &logrusutil.CorrelatedTransport{Base: scmClient.Client.Transport, Logger: l}
 
-	o.server.ClientAgent = &plugins.ClientAgent{
-		BotName:           o.GetBotName(),
-		SCMProviderClient: scmClient,
-		KubernetesClient:  kubeClient,
-		GitClient:         o.gitClient,
-		LighthouseClient:  lhClient.LighthouseV1alpha1().LighthouseJobs(o.namespace),
-		LauncherClient:    o.launcher,
+	var jobLauncher launcher.PipelineLauncher = o.launcher
+	if p.Name != "" {
+		jobLauncher = &providerLauncher{PipelineLauncher: o.launcher, provider: p.Name}
 	}
-	l, output, err := o.ProcessWebHook(logrus.WithField("Webhook", webhook.Kind()), webhook)
-	if err != nil {
-		responseHTTPError(w, http.StatusInternalServerError, fmt.Sprintf("500 Internal Server Error: %s", err.Error()))
+	jobLauncher = &tenantLauncher{PipelineLauncher: jobLauncher, tenants: o.tenantConfig}
+	if id, ok := l.Data[logrusutil.CorrelationIDField].(string); ok {
+		jobLauncher = &correlatedLauncher{PipelineLauncher: jobLauncher, correlationID: id}
 	}
-	_, err = w.Write([]byte(output))
-	if err != nil {
-		l.Debugf("failed to process the webhook: %v", err)
+	if scmClient.Deployments != nil {
+		jobLauncher = &deploymentLauncher{PipelineLauncher: jobLauncher, deployments: scmClient.Deployments, logger: l}
 	}
+	return &plugins.ClientAgent{
+		BotName:           o.providerBotName(p.Provider),
+		SCMProviderClient: scmClient,
+		ChangesCache:      scmprovider.NewChangesCache(),
+		OwnersCache:       p.owners,
+		KubernetesClient:  kubeClient,
+		GitClient:         p.gitClient,
+		LighthouseClient:  lhClient.LighthouseV1alpha1().LighthouseJobs(o.jobsNamespace(owner)),
+		LauncherClient:    jobLauncher,
+		Store:             o.store,
+	}, nil
 }
 
-// ProcessWebHook process a webhook
+// ProcessWebHook process a webhook of the default provider
 func (o *Options) ProcessWebHook(l *logrus.Entry, webhook scm.Webhook) (*logrus.Entry, string, error) {
+	return o.processWebHook(o.server, o.server.ClientAgent, l, webhook)
+}
+
+// Wait waits for the plugins to handle the webhooks processed so far
+func (o *Options) Wait() {
+	o.server.Wait()
+}
+
+func (o *Options) processWebHook(server *Server, clientAgent *plugins.ClientAgent, l *logrus.Entry, webhook scm.Webhook) (*logrus.Entry, string, error) {
 	repository := webhook.Repository()
 	fields := map[string]interface{}{
 		"Namespace": repository.Namespace,
@@ -282,8 +654,8 @@ func (o *Options) ProcessWebHook(l *logrus.Entry, webhook scm.Webhook) (*logrus.
 	}
 	// If we are in GitHub App mode and have a populated config, check if the repository for this webhook is one we actually
 	// know about and error out if not.
-	if util.GetGitHubAppSecretDir() != "" && o.server.ConfigAgent != nil {
-		cfg := o.server.ConfigAgent.Config()
+	if util.GetGitHubAppSecretDir() != "" && server.ConfigAgent != nil {
+		cfg := server.ConfigAgent.Config()
 		if cfg != nil {
 			if len(cfg.GetPostsubmits(repository)) == 0 && len(cfg.GetPresubmits(repository)) == 0 {
 				l.Infof("webhook from unconfigured repository %s, returning error", repository.Link)
@@ -303,7 +675,7 @@ func (o *Options) ProcessWebHook(l *logrus.Entry, webhook scm.Webhook) (*logrus.
 
 		l.Info("invoking Push handler")
 
-		o.server.HandlePushEvent(l, pushHook)
+		server.HandlePushEvent(l, clientAgent, pushHook)
 		return l, "processed push hook", nil
 	}
 	prHook, ok := webhook.(*scm.PullRequestHook)
@@ -319,7 +691,7 @@ func (o *Options) ProcessWebHook(l *logrus.Entry, webhook scm.Webhook) (*logrus.
 
 		l.Info("invoking PR handler")
 
-		o.server.HandlePullRequestEvent(l, prHook)
+		server.HandlePullRequestEvent(l, clientAgent, prHook)
 		return l, "processed PR hook", nil
 	}
 	branchHook, ok := webhook.(*scm.BranchHook)
@@ -333,7 +705,7 @@ func (o *Options) ProcessWebHook(l *logrus.Entry, webhook scm.Webhook) (*logrus.
 
 		l.Info("invoking branch handler")
 
-		o.server.HandleBranchEvent(l, branchHook)
+		server.HandleBranchEvent(l, branchHook)
 		return l, "processed branch hook", nil
 	}
 	issueCommentHook, ok := webhook.(*scm.IssueCommentHook)
@@ -353,7 +725,7 @@ func (o *Options) ProcessWebHook(l *logrus.Entry, webhook scm.Webhook) (*logrus.
 
 		l.Info("invoking Issue Comment handler")
 
-		o.server.HandleIssueCommentEvent(l, *issueCommentHook)
+		server.HandleIssueCommentEvent(l, clientAgent, *issueCommentHook)
 		return l, "processed issue comment hook", nil
 	}
 	prCommentHook, ok := webhook.(*scm.PullRequestCommentHook)
@@ -377,7 +749,7 @@ func (o *Options) ProcessWebHook(l *logrus.Entry, webhook scm.Webhook) (*logrus.
 
 		l.Info("invoking Issue Comment handler")
 
-		o.server.HandlePullRequestCommentEvent(l, *prCommentHook)
+		server.HandlePullRequestCommentEvent(l, clientAgent, *prCommentHook)
 		return l, "processed PR comment hook", nil
 	}
 	prReviewHook, ok := webhook.(*scm.ReviewHook)
@@ -397,9 +769,43 @@ func (o *Options) ProcessWebHook(l *logrus.Entry, webhook scm.Webhook) (*logrus.
 
 		l.Info("invoking PR Review handler")
 
-		o.server.HandleReviewEvent(l, *prReviewHook)
+		server.HandleReviewEvent(l, clientAgent, *prReviewHook)
 		return l, "processed PR review hook", nil
 	}
+	commitCommentHook, ok := webhook.(*payload.CommitCommentHook)
+	if ok {
+		fields["Action"] = commitCommentHook.Action.String()
+		fields["Commit.Sha"] = commitCommentHook.SHA
+		fields["Comment.Body"] = commitCommentHook.Comment.Body
+		fields["Author.Login"] = commitCommentHook.Comment.Author.Login
+
+		l.Info("invoking Commit Comment handler")
+
+		server.HandleCommitCommentEvent(l, clientAgent, *commitCommentHook)
+		return l, "processed commit comment hook", nil
+	}
+	checkRerequestHook, ok := webhook.(*payload.CheckRerequestHook)
+	if ok {
+		fields["Commit.Sha"] = checkRerequestHook.SHA
+		fields["Check.Name"] = checkRerequestHook.Name
+		fields["Sender.Login"] = checkRerequestHook.Sender.Login
+
+		l.Info("invoking Check Rerequest handler")
+
+		server.HandleCheckRerequestEvent(l, clientAgent, checkRerequestHook)
+		return l, "processed check rerequest hook", nil
+	}
+	statusHook, ok := webhook.(*payload.StatusHook)
+	if ok {
+		fields["Commit.Sha"] = statusHook.SHA
+		fields["Status.Context"] = statusHook.Context
+		fields["Status.State"] = statusHook.State
+
+		l.Info("invoking Status handler")
+
+		server.HandleStatusEvent(l, clientAgent, statusHook)
+		return l, "processed status hook", nil
+	}
 	l.Debugf("unknown kind %s webhook %#v", webhook.Kind(), webhook)
 	return l, fmt.Sprintf("unknown hook %s", webhook.Kind()), nil
 }
@@ -412,28 +818,58 @@ func (o *Options) GetFactory() jxfactory.Factory {
 	return o.factory
 }
 
-func (o *Options) secretFn(webhook scm.Webhook) (string, error) {
-	return os.Getenv("HMAC_TOKEN"), nil
+// parseWebhook parses the webhook request, accepting it if it is signed by any of the HMAC tokens of the provider, and returns
+// it along with its raw payload
+func (o *Options) parseWebhook(p *gitprovider.Provider, scmClient *scm.Client, r *http.Request) (scm.Webhook, []byte, error) {
+	tokens, err := p.HMACTokens()
+	if err != nil {
+		return nil, nil, err
+	}
+	return payload.Parse(scmClient, r, tokens, o.MaxPayloadSize)
 }
 
-func (o *Options) createSCMClient() (*scm.Client, string, error) {
-	kind := o.gitKind()
-	serverURL := os.Getenv("GIT_SERVER")
-
-	client, err := factory.NewClient(kind, serverURL, "")
-	return client, serverURL, err
+// claimDelivery returns false if the delivery of the webhook has already been processed. Deliveries are
+// processed if they cannot be recorded, as missing an event is worse than handling it twice.
+func (o *Options) claimDelivery(r *http.Request) bool {
+	id := payload.DeliveryID(r.Header)
+	if o.deliveries == nil || id == "" {
+		return true
+	}
+	l := logrus.WithField("delivery", id)
+	claimed, err := o.deliveries.Claim(id)
+	if err != nil {
+		l.WithError(err).Warn("failed to record the webhook delivery, processing it anyway")
+		return true
+	}
+	if !claimed {
+		l.Info("skipping duplicate webhook delivery")
+	}
+	return claimed
 }
 
-func (o *Options) gitKind() string {
-	kind := os.Getenv("GIT_KIND")
-	if kind == "" {
-		kind = "github"
+// releaseDelivery forgets the delivery of a webhook which failed to be processed, so that its redelivery by the
+// provider is processed instead of being skipped as a duplicate
+func (o *Options) releaseDelivery(r *http.Request) {
+	id := payload.DeliveryID(r.Header)
+	if o.deliveries == nil || id == "" {
+		return
+	}
+	if err := o.deliveries.Release(id); err != nil {
+		logrus.WithField("delivery", id).WithError(err).Warn("failed to release the webhook delivery, its redelivery will be skipped")
 	}
-	return kind
 }
 
-// GetBotName returns the bot name
+func (o *Options) createSCMClient(p *gitprovider.Provider) (*scm.Client, string, error) {
+	client, err := p.NewClient("")
+	return client, p.ServerURL(), err
+}
+
+// GetBotName returns the bot name of the default provider
 func (o *Options) GetBotName() string {
+	return o.providerBotName(gitprovider.Default())
+}
+
+func (o *Options) providerBotName(p *gitprovider.Provider) string {
 	if util.GetGitHubAppSecretDir() != "" {
 		ghaBotName, err := util.GetGitHubAppAPIUser()
 		// TODO: Probably should handle error cases here better, but for now, just fall through.
@@ -441,29 +877,58 @@ func (o *Options) GetBotName() string {
 			return ghaBotName
 		}
 	}
-	o.botName = os.Getenv("GIT_USER")
-	if o.botName == "" {
-		o.botName = "jenkins-x-bot"
+	if p.Name == "" && o.botName != "" {
+		return o.botName
 	}
-	return o.botName
+	return p.BotName()
 }
 
-func (o *Options) createSCMToken(gitKind string) (string, error) {
-	envName := "GIT_TOKEN"
-	value := os.Getenv(envName)
-	if value == "" {
-		return value, fmt.Errorf("No token available for git kind %s at environment variable $%s", gitKind, envName)
+// providerForPath returns the provider whose webhooks are served at the path, which is the default provider
+// unless the path below the hook path is the name of another provider
+func (o *Options) providerForPath(path string) *hookProvider {
+	name := strings.Trim(strings.TrimPrefix(path, o.Path), "/")
+	var defaultProvider *hookProvider
+	for _, p := range o.providers {
+		if p.Name == name {
+			return p
+		}
+		if p.Name == "" {
+			defaultProvider = p
+		}
 	}
-	return value, nil
+	return defaultProvider
 }
 
-func (o *Options) createHookServer() (*Server, error) {
+// createHookServers creates the server handling the webhooks of each provider. The servers share the job
+// configuration while each gets the plugins configured for its provider.
+func (o *Options) createHookServers(providers []*gitprovider.Provider) error {
 	configAgent := &config.Agent{}
-	pluginAgent := &plugins.ConfigAgent{}
+	canaryAgent := &canary.Agent{}
+	pluginAgents := map[string]*plugins.ConfigAgent{}
+	for _, p := range providers {
+		pluginAgents[p.Name] = &plugins.ConfigAgent{}
+	}
+	pluginAgent := pluginAgents[""]
+	o.tenants = &tenants.Agent{}
+	// the tenants are only read from config.yaml so that the LighthouseConfigs cannot change them
+	setTenants := func(data []byte) {
+		cfg, err := tenants.LoadConfig(data)
+		if err != nil {
+			logrus.WithError(err).Error("Error processing the tenants of the prow Config YAML")
+			return
+		}
+		o.tenants.Set(cfg)
+	}
 
 	onConfigYamlChange := func(text string) {
 		if text != "" {
-			config, err := config.LoadYAMLConfig([]byte(text))
+			data, err := configinclude.Resolve([]byte(text), "")
+			if err != nil {
+				logrus.WithError(err).Error("Error resolving the includes of the prow Config YAML")
+				return
+			}
+			setTenants(data)
+			config, err := config.LoadYAMLConfig(data)
 			if err != nil {
 				logrus.WithError(err).Error("Error processing the prow Config YAML")
 			} else {
@@ -480,7 +945,9 @@ func (o *Options) createHookServer() (*Server, error) {
 				logrus.WithError(err).Error("Error processing the prow Plugins YAML")
 			} else {
 				logrus.Info("updating the prow plugins configuration")
-				pluginAgent.Set(config)
+				for name, agent := range pluginAgents {
+					agent.Set(config.ForProvider(name))
+				}
 			}
 		}
 	}
@@ -488,7 +955,43 @@ func (o *Options) createHookServer() (*Server, error) {
 	clientFactory := o.GetFactory()
 	kubeClient, _, err := clientFactory.CreateKubeClient()
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to create Kube client")
+		return errors.Wrapf(err, "failed to create Kube client")
+	}
+
+	if o.WatchLighthouseConfigs {
+		_, _, _, lhClient, _, err := clients.GetClientsAndNamespace(nil)
+		if err != nil {
+			return errors.Wrap(err, "failed to create Lighthouse client")
+		}
+		shards, err := configshards.NewWatcher(lhClient, metav1.NamespaceAll, configAgent.Set, stopper())
+		if err != nil {
+			return errors.Wrap(err, "failed to create LighthouseConfig watcher")
+		}
+		onConfigYamlChange = func(text string) {
+			if text != "" {
+				data, err := configinclude.Resolve([]byte(text), "")
+				if err != nil {
+					logrus.WithError(err).Error("Error resolving the includes of the prow Config YAML")
+					return
+				}
+				setTenants(data)
+				shards.SetBase(string(data))
+			}
+		}
+	}
+
+	onCanaryYamlChange := func(text string) {
+		data, err := configinclude.Resolve([]byte(text), "")
+		if err != nil {
+			logrus.WithError(err).Error("Error resolving the includes of the canary Config YAML")
+			return
+		}
+		if err := canaryAgent.Load(data); err != nil {
+			logrus.WithError(err).Error("Error processing the canary Config YAML")
+			return
+		}
+		settings, _ := canaryAgent.Snapshot()
+		logrus.WithField("repos", settings.Repos).Info("updating the canary configuration")
 	}
 
 	callbacks := []watcher.ConfigMapCallback{
@@ -497,6 +1000,11 @@ func (o *Options) createHookServer() (*Server, error) {
 			Key:      util.ProwConfigFilename,
 			Callback: onConfigYamlChange,
 		},
+		&watcher.ConfigMapEntryCallback{
+			Name:     canary.ConfigMapName,
+			Key:      util.ProwConfigFilename,
+			Callback: onCanaryYamlChange,
+		},
 		&watcher.ConfigMapEntryCallback{
 			Name:     util.ProwPluginsConfigMapName,
 			Key:      util.ProwPluginsFilename,
@@ -505,7 +1013,7 @@ func (o *Options) createHookServer() (*Server, error) {
 	}
 	o.configMapWatcher, err = watcher.NewConfigMapWatcher(kubeClient, o.namespace, callbacks, stopper())
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to create ConfigMap watcher")
+		return errors.Wrapf(err, "failed to create ConfigMap watcher")
 	}
 
 	promMetrics := NewMetrics()
@@ -526,23 +1034,42 @@ func (o *Options) createHookServer() (*Server, error) {
 
 	metapipelineClient, err := launcher.NewMetaPipelineClient(clientFactory)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to create metapipeline client")
+		return errors.Wrap(err, "failed to create metapipeline client")
 	}
 
-	serverURL, err := url.Parse(o.gitServerURL)
-	if err != nil {
-		return nil, errors.Wrapf(err, "failed to parse server URL %s", o.gitServerURL)
-	}
-	server := &Server{
-		ClientFactory:      clientFactory,
-		ConfigAgent:        configAgent,
-		Plugins:            pluginAgent,
-		Metrics:            promMetrics,
-		MetapipelineClient: metapipelineClient,
-		ServerURL:          serverURL,
-		//TokenGenerator: secretAgent.GetTokenGenerator(o.webhookSecretFile),
-	}
-	return server, nil
+	// the providers share the workers so that they bound the plugin handlers of the hook
+	var queues *eventQueues
+	if o.PluginWorkers > 0 {
+		size := o.PluginQueueSize
+		if size <= 0 {
+			size = DefaultPluginQueueSize
+		}
+		queues = newEventQueues(o.PluginWorkers, size)
+	}
+	o.providers = nil
+	for _, p := range providers {
+		serverURL, err := url.Parse(p.ServerURL())
+		if err != nil {
+			return errors.Wrapf(err, "failed to parse server URL %s of provider %s", p.ServerURL(), p)
+		}
+		server := &Server{
+			ClientFactory:      clientFactory,
+			ConfigAgent:        configAgent,
+			Canary:             canaryAgent,
+			Plugins:            pluginAgents[p.Name],
+			Metrics:            promMetrics,
+			MetapipelineClient: metapipelineClient,
+			ServerURL:          serverURL,
+			PluginTimeout:      o.PluginTimeout,
+			queues:             queues,
+			//TokenGenerator: secretAgent.GetTokenGenerator(o.webhookSecretFile),
+		}
+		if p.Name == "" {
+			o.server = server
+		}
+		o.providers = append(o.providers, &hookProvider{Provider: p, server: server})
+	}
+	return nil
 }
 
 func responseHTTPError(w http.ResponseWriter, statusCode int, response string) {
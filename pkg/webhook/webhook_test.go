@@ -10,6 +10,7 @@ import (
 	"github.com/jenkins-x/lighthouse-config/pkg/config"
 	"github.com/jenkins-x/lighthouse/pkg/client/clientset/versioned/fake"
 	"github.com/jenkins-x/lighthouse/pkg/git"
+	"github.com/jenkins-x/lighthouse/pkg/gitprovider"
 	"github.com/jenkins-x/lighthouse/pkg/plugins"
 	"github.com/jenkins-x/lighthouse/pkg/util"
 	"github.com/sirupsen/logrus"
@@ -141,12 +142,13 @@ func (suite *WebhookTestSuite) SetupSuite() {
 	var objs []runtime.Object
 	kubeClient := kubefake.NewSimpleClientset(objs...)
 	lhClient := fake.NewSimpleClientset()
-	scmClient, serverURL, err := options.createSCMClient()
+	provider := gitprovider.Default()
+	scmClient, serverURL, err := options.createSCMClient(provider)
 	assert.NoError(t, err)
-	gitClient, err := git.NewClient(serverURL, options.gitKind())
+	gitClient, err := git.NewClient(serverURL, provider.Kind())
 	assert.NoError(t, err)
 	user := options.GetBotName()
-	token, err := options.createSCMToken(options.gitKind())
+	token, err := provider.Token()
 	gitClient.SetCredentials(user, func() []byte {
 		return []byte(token)
 	})